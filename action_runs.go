@@ -0,0 +1,105 @@
+// action_runs.go
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"ropcode/internal/actionrun"
+	"ropcode/internal/database"
+)
+
+// newActionRunner creates the action runner, recording runs in the
+// database when there is one
+func (a *App) newActionRunner() *actionrun.Runner {
+	if a.dbManager == nil {
+		return actionrun.New(a.processManager, nil, a.eventHub)
+	}
+	if err := a.dbManager.InterruptActionRuns(); err != nil {
+		slog.Error("failed to mark unfinished runs", "component", "Actions", "error", err)
+	}
+	return actionrun.New(a.processManager, a.dbManager, a.eventHub)
+}
+
+// actionsFile returns the file holding the actions of a scope; project
+// and workspace actions live in cwd
+func actionsFile(scope, cwd string) (string, error) {
+	switch scope {
+	case "global":
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(homeDir, ".claude", "actions.json"), nil
+	case "project", "workspace":
+		if cwd == "" {
+			return "", fmt.Errorf("%s actions need a directory", scope)
+		}
+		return filepath.Join(cwd, ".claude", "actions.json"), nil
+	default:
+		return "", fmt.Errorf("unknown action scope: %q", scope)
+	}
+}
+
+// RunAction runs the script action actionID of scope ("global", "project"
+// or "workspace") in cwd. Its output follows as action-output events.
+func (a *App) RunAction(actionID, scope, cwd string) (*database.ActionRun, error) {
+	if a.actionRunner == nil {
+		return nil, a.unavailable(subsystemProcesses)
+	}
+	path, err := actionsFile(scope, cwd)
+	if err != nil {
+		return nil, err
+	}
+	actions, err := loadActionsFromFile(path, scope)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to load %s actions: %w", scope, err)
+	}
+	for _, action := range actions {
+		if action.ID != actionID {
+			continue
+		}
+		if action.ActionType == "web" {
+			return nil, fmt.Errorf("action %s opens a web page and can't be run", action.Name)
+		}
+		// The action's own variables override the project's
+		env := a.projectEnv(cwd)
+		if env == nil {
+			env = make(map[string]string, len(action.Env))
+		}
+		for name, value := range action.Env {
+			env[name] = value
+		}
+		return a.actionRunner.Start(actionrun.Spec{
+			ActionID:   action.ID,
+			ActionName: action.Name,
+			Scope:      scope,
+			Cwd:        cwd,
+			Command:    action.Command,
+			Env:        action.Env,
+		})
+	}
+	return nil, fmt.Errorf("no %s action %q", scope, actionID)
+}
+
+// StopAction stops an action run
+func (a *App) StopAction(runID string) error {
+	if a.actionRunner == nil {
+		return a.unavailable(subsystemProcesses)
+	}
+	return a.actionRunner.Stop(runID)
+}
+
+// ListActionRuns returns action runs matching filter, newest first;
+// without a database only the runs in progress are known
+func (a *App) ListActionRuns(filter database.ActionRunFilter) ([]*database.ActionRun, error) {
+	if a.dbManager == nil {
+		if a.actionRunner == nil {
+			return []*database.ActionRun{}, nil
+		}
+		return a.actionRunner.Running(), nil
+	}
+	return a.dbManager.ListActionRuns(filter)
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"ropcode/internal/database"
+	"ropcode/internal/eventhub"
+	"ropcode/internal/process"
+)
+
+func TestRunActionRecordsRuns(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test actions use sh")
+	}
+	app := &App{
+		dbManager:      openAppConfigTestDB(t),
+		processManager: process.NewManager(context.Background()),
+		eventHub:       eventhub.New(nil),
+	}
+	app.actionRunner = app.newActionRunner()
+
+	cwd := t.TempDir()
+	if err := saveActionsToFile(filepath.Join(cwd, ".claude", "actions.json"), []Action{
+		{ID: "hello", Name: "Hello", Command: "echo hello from {{projectPath}}"},
+		{ID: "docs", Name: "Docs", Command: "https://example.com", ActionType: "web"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	run, err := app.RunAction("hello", "project", cwd)
+	if err != nil {
+		t.Fatalf("RunAction() error = %v", err)
+	}
+	if run.Command != "echo hello from "+cwd || run.Scope != "project" {
+		t.Errorf("started run = %+v", run)
+	}
+
+	var runs []*database.ActionRun
+	for deadline := time.Now().Add(10 * time.Second); time.Now().Before(deadline); time.Sleep(20 * time.Millisecond) {
+		if runs, err = app.ListActionRuns(database.ActionRunFilter{Cwd: cwd}); err == nil && len(runs) == 1 && runs[0].Status != database.ActionRunRunning {
+			break
+		}
+	}
+	if len(runs) != 1 || runs[0].Status != database.ActionRunSucceeded || runs[0].Output != "hello from "+cwd+"\n" {
+		t.Fatalf("recorded runs = %+v, %v", runs, err)
+	}
+
+	if _, err := app.RunAction("docs", "project", cwd); err == nil {
+		t.Error("expected web actions to be refused")
+	}
+	if _, err := app.RunAction("missing", "workspace", cwd); err == nil {
+		t.Error("expected an error for an unknown action")
+	}
+	if _, err := app.RunAction("hello", "team", cwd); err == nil {
+		t.Error("expected an error for an unknown scope")
+	}
+}
+
+func TestLoadActionsMigratesOldFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "actions.json")
+	old := `[{"id":"b","name":"Build","command":"make","scope":"project"},{"id":"d","name":"Docs","command":"https://x","actionType":"web"}]`
+	if err := os.WriteFile(path, []byte(old), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	actions, err := loadActionsFromFile(path, "project")
+	if err != nil {
+		t.Fatalf("loadActionsFromFile() error = %v", err)
+	}
+	if len(actions) != 2 || actions[0].Order != 0 || actions[1].Order != 1 ||
+		actions[0].RunIn != ActionRunInTerminal || actions[1].RunIn != "" || actions[0].Scope != "project" {
+		t.Fatalf("migrated actions = %+v", actions)
+	}
+	data, _ := os.ReadFile(path)
+	if strings.Contains(string(data), `"scope"`) || !strings.Contains(string(data), `"runIn": "terminal"`) {
+		t.Errorf("migrated file = %s", data)
+	}
+
+	// Reordered actions load in their new order
+	actions[0].Order, actions[1].Order = 1, 0
+	if err := saveActionsToFile(path, actions); err != nil {
+		t.Fatal(err)
+	}
+	if actions, _ = loadActionsFromFile(path, "project"); actions[0].ID != "d" {
+		t.Errorf("actions after reordering = %+v", actions)
+	}
+}
+
+func TestValidateActions(t *testing.T) {
+	tests := []struct {
+		name    string
+		actions []Action
+		wantErr bool
+	}{
+		{name: "valid", actions: []Action{{Name: "a", Keybinding: "mod+shift+B"}, {Name: "b", Keybinding: "alt+f5", RunIn: ActionRunInBackground}}},
+		{name: "duplicate", actions: []Action{{Name: "a", Keybinding: "shift+mod+b"}, {Name: "b", Keybinding: "Mod+Shift+b"}}, wantErr: true},
+		{name: "no modifier", actions: []Action{{Name: "a", Keybinding: "b"}}, wantErr: true},
+		{name: "bad modifier", actions: []Action{{Name: "a", Keybinding: "hyper+b"}}, wantErr: true},
+		{name: "bad key", actions: []Action{{Name: "a", Keybinding: "ctrl+f13"}}, wantErr: true},
+		{name: "bad run mode", actions: []Action{{Name: "a", RunIn: "tmux"}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		if err := validateActions(tt.actions); (err != nil) != tt.wantErr {
+			t.Errorf("%s: validateActions() error = %v", tt.name, err)
+		}
+	}
+}
@@ -0,0 +1,258 @@
+// agent_batches.go
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"ropcode/internal/database"
+)
+
+// agentBatchConcurrency caps how many projects of one batch run at once. The
+// session concurrency limits still apply on top of it.
+const agentBatchConcurrency = 3
+
+// AgentBatchProgress counts batch items by status
+type AgentBatchProgress struct {
+	Total     int `json:"total"`
+	Pending   int `json:"pending"`
+	Running   int `json:"running"`
+	Completed int `json:"completed"`
+	Failed    int `json:"failed"`
+	Cancelled int `json:"cancelled"`
+}
+
+// agentBatchEvent is the "agent-batch:changed" payload
+type agentBatchEvent struct {
+	*database.AgentBatch
+	Progress AgentBatchProgress `json:"progress"`
+}
+
+func agentBatchProgress(batch *database.AgentBatch) AgentBatchProgress {
+	progress := AgentBatchProgress{Total: len(batch.Items)}
+	for _, item := range batch.Items {
+		switch item.Status {
+		case "pending":
+			progress.Pending++
+		case "running":
+			progress.Running++
+		case "completed":
+			progress.Completed++
+		case "failed":
+			progress.Failed++
+		case "cancelled":
+			progress.Cancelled++
+		}
+	}
+	return progress
+}
+
+// normalizeBatchProjectPaths trims and de-duplicates project paths, keeping order.
+func normalizeBatchProjectPaths(projectPaths []string) []string {
+	seen := make(map[string]bool, len(projectPaths))
+	result := make([]string, 0, len(projectPaths))
+	for _, path := range projectPaths {
+		path = strings.TrimSpace(path)
+		if path == "" || seen[path] {
+			continue
+		}
+		seen[path] = true
+		result = append(result, path)
+	}
+	return result
+}
+
+// agentBatchRunner drives one batch. mu guards batch, which is shared by the
+// worker goroutines.
+type agentBatchRunner struct {
+	app   *App
+	mu    sync.Mutex
+	batch *database.AgentBatch
+	model string
+}
+
+// update applies fn to the batch, then persists and broadcasts the result.
+func (r *agentBatchRunner) update(fn func(batch *database.AgentBatch)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	fn(r.batch)
+	if err := r.app.dbManager.UpdateAgentBatch(r.batch); err != nil {
+		slog.Error("failed to save batch", "component", "agent-batch", "batch_id", r.batch.ID, "error", err)
+	}
+	if r.app.eventHub != nil {
+		snapshot := *r.batch
+		snapshot.Items = append([]database.AgentBatchItem(nil), r.batch.Items...)
+		r.app.eventHub.Emit("agent-batch:changed", agentBatchEvent{
+			AgentBatch: &snapshot,
+			Progress:   agentBatchProgress(&snapshot),
+		})
+	}
+}
+
+func (r *agentBatchRunner) run(ctx context.Context) {
+	r.update(func(batch *database.AgentBatch) { batch.Status = "running" })
+
+	r.mu.Lock()
+	concurrency := r.batch.Concurrency
+	projectPaths := make([]string, 0, len(r.batch.Items))
+	for _, item := range r.batch.Items {
+		projectPaths = append(projectPaths, item.ProjectPath)
+	}
+	r.mu.Unlock()
+
+	slots := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, projectPath := range projectPaths {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		go func(index int, projectPath string) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			r.runItem(ctx, index, projectPath)
+		}(i, projectPath)
+	}
+	wg.Wait()
+
+	r.update(func(batch *database.AgentBatch) {
+		progress := agentBatchProgress(batch)
+		switch {
+		case ctx.Err() != nil:
+			for i := range batch.Items {
+				if batch.Items[i].Status == "pending" {
+					batch.Items[i].Status = "cancelled"
+				}
+			}
+			batch.Status = "cancelled"
+		case progress.Failed > 0 || progress.Cancelled > 0:
+			batch.Status = "failed"
+		default:
+			batch.Status = "completed"
+		}
+		completedAt := time.Now()
+		batch.CompletedAt = &completedAt
+	})
+}
+
+func (r *agentBatchRunner) runItem(ctx context.Context, index int, projectPath string) {
+	r.update(func(batch *database.AgentBatch) { batch.Items[index].Status = "running" })
+
+	_, status, err := r.app.runAgentToCompletion(ctx, r.batch.AgentID, projectPath, r.batch.Task, r.model, func(agentRun *database.AgentRun) {
+		r.update(func(batch *database.AgentBatch) { batch.Items[index].AgentRunID = agentRun.ID })
+	})
+	r.update(func(batch *database.AgentBatch) {
+		batch.Items[index].Status = status
+		if err != nil {
+			batch.Items[index].Error = err.Error()
+		}
+	})
+}
+
+// ExecuteAgentOnProjects runs an agent with the same task on every project in
+// projectPaths and returns the batch record immediately. Progress is reported
+// through "agent-batch:changed" events.
+func (a *App) ExecuteAgentOnProjects(agentID int64, projectPaths []string, task string) (*database.AgentBatch, error) {
+	if a.dbManager == nil {
+		return nil, a.unavailable(subsystemDatabase)
+	}
+
+	agent, err := a.dbManager.GetAgent(agentID)
+	if err != nil {
+		return nil, err
+	}
+	provider, err := validateAgentProvider(agent.Provider)
+	if err != nil {
+		return nil, err
+	}
+	if a.sessionManagerFor(provider) == nil {
+		return nil, fmt.Errorf("%s manager not initialized", provider)
+	}
+	projectPaths = normalizeBatchProjectPaths(projectPaths)
+	if len(projectPaths) == 0 {
+		return nil, fmt.Errorf("no projects selected")
+	}
+
+	batch := &database.AgentBatch{
+		AgentID:     agent.ID,
+		AgentName:   agent.Name,
+		Task:        task,
+		Status:      "pending",
+		Concurrency: min(agentBatchConcurrency, len(projectPaths)),
+		Items:       make([]database.AgentBatchItem, 0, len(projectPaths)),
+	}
+	for _, path := range projectPaths {
+		batch.Items = append(batch.Items, database.AgentBatchItem{ProjectPath: path, Status: "pending"})
+	}
+	if _, err := a.dbManager.CreateAgentBatch(batch); err != nil {
+		return nil, err
+	}
+
+	parent := a.ctx
+	if parent == nil {
+		parent = context.Background()
+	}
+	ctx, cancel := context.WithCancel(parent)
+	a.agentBatches.add(batch.ID, cancel)
+
+	snapshot := *batch
+	snapshot.Items = append([]database.AgentBatchItem(nil), batch.Items...)
+	runner := &agentBatchRunner{app: a, batch: batch, model: agent.Model}
+	go func() {
+		defer cancel()
+		defer a.agentBatches.remove(batch.ID)
+		runner.run(ctx)
+	}()
+	return &snapshot, nil
+}
+
+// GetAgentBatch returns an agent batch by ID
+func (a *App) GetAgentBatch(batchID int64) (*database.AgentBatch, error) {
+	if a.dbManager == nil {
+		return nil, a.unavailable(subsystemDatabase)
+	}
+	return a.dbManager.GetAgentBatch(batchID)
+}
+
+// ListAgentBatches returns agent batches, optionally filtered by agent ID
+func (a *App) ListAgentBatches(agentID int64, limit int) ([]*database.AgentBatch, error) {
+	if a.dbManager == nil {
+		return nil, a.unavailable(subsystemDatabase)
+	}
+	var agentIDPtr *int64
+	if agentID > 0 {
+		agentIDPtr = &agentID
+	}
+	if limit <= 0 {
+		limit = 50 // Default limit
+	}
+	return a.dbManager.ListAgentBatches(agentIDPtr, limit)
+}
+
+// CancelAgentBatch stops a running batch: running agent runs are cancelled and
+// projects that have not started yet are skipped.
+func (a *App) CancelAgentBatch(batchID int64) error {
+	if !a.agentBatches.cancel(batchID) {
+		return fmt.Errorf("agent batch is not active: %d", batchID)
+	}
+	return nil
+}
+
+// DeleteAgentBatch deletes a finished batch record; its agent runs are kept
+func (a *App) DeleteAgentBatch(batchID int64) error {
+	if a.dbManager == nil {
+		return a.unavailable(subsystemDatabase)
+	}
+	if a.agentBatches.active(batchID) {
+		return fmt.Errorf("agent batch is still running: %d", batchID)
+	}
+	return a.dbManager.DeleteAgentBatch(batchID)
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"ropcode/internal/database"
+)
+
+func TestNormalizeBatchProjectPathsTrimsAndDeduplicates(t *testing.T) {
+	got := normalizeBatchProjectPaths([]string{" /repos/a ", "", "/repos/b", "/repos/a", "  "})
+	want := []string{"/repos/a", "/repos/b"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("normalizeBatchProjectPaths = %#v, want %#v", got, want)
+	}
+}
+
+func TestAgentBatchProgressCountsStatuses(t *testing.T) {
+	batch := &database.AgentBatch{Items: []database.AgentBatchItem{
+		{Status: "pending"}, {Status: "running"}, {Status: "completed"},
+		{Status: "completed"}, {Status: "failed"}, {Status: "cancelled"},
+	}}
+	want := AgentBatchProgress{Total: 6, Pending: 1, Running: 1, Completed: 2, Failed: 1, Cancelled: 1}
+	if got := agentBatchProgress(batch); got != want {
+		t.Fatalf("agentBatchProgress = %#v, want %#v", got, want)
+	}
+}
@@ -0,0 +1,261 @@
+// agent_marketplace.go
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"ropcode/internal/database"
+	"ropcode/internal/github"
+)
+
+// Agent sources default to the opcode repository until the user configures
+// others; the index is kept so the marketplace can be browsed offline
+const (
+	agentSourcesSettingKey          = "agent_sources"
+	agentMarketplaceIndexSettingKey = "agent_marketplace_index"
+)
+
+// MarketplaceAgent is an agent published by an agent source
+type MarketplaceAgent struct {
+	SourceID         string `json:"source_id"`
+	SourceName       string `json:"source_name"`
+	FileName         string `json:"file_name"`
+	Path             string `json:"path"`
+	DownloadURL      string `json:"download_url"`
+	SHA              string `json:"sha"`
+	Name             string `json:"name"`
+	Icon             string `json:"icon"`
+	Model            string `json:"model"`
+	SystemPrompt     string `json:"system_prompt"`
+	DefaultTask      string `json:"default_task,omitempty"`
+	Error            string `json:"error,omitempty"` // set when the agent file could not be loaded
+	Installed        bool   `json:"installed"`
+	InstalledAgentID int64  `json:"installed_agent_id,omitempty"`
+	UpdateAvailable  bool   `json:"update_available"`
+}
+
+// AgentMarketplaceIndex is the locally cached list of available agents
+type AgentMarketplaceIndex struct {
+	Agents       []MarketplaceAgent `json:"agents"`
+	SourceErrors map[string]string  `json:"source_errors,omitempty"` // source ID -> error
+	SyncedAt     int64              `json:"synced_at"`
+}
+
+// AgentSourceUpdate describes the difference between an installed agent and
+// the current version published by its source
+type AgentSourceUpdate struct {
+	AgentID    int64               `json:"agent_id"`
+	SourceURL  string              `json:"source_url"`
+	Changed    bool                `json:"changed"`
+	Applied    bool                `json:"applied"`
+	PromptDiff []github.DiffLine   `json:"prompt_diff"`
+	Remote     github.AgentContent `json:"remote"`
+	Agent      *database.Agent     `json:"agent"`
+}
+
+// loadAgentSources returns the configured agent sources, or the default source
+// when none are configured.
+func (a *App) loadAgentSources() []github.AgentSource {
+	if a.dbManager != nil {
+		raw, err := a.dbManager.GetSetting(agentSourcesSettingKey)
+		if err == nil && raw != "" {
+			var sources []github.AgentSource
+			if err := json.Unmarshal([]byte(raw), &sources); err == nil {
+				if normalized, err := github.NormalizeAgentSources(sources); err == nil {
+					return normalized
+				}
+			}
+			log.Printf("[agents] ignoring invalid %s setting", agentSourcesSettingKey)
+		}
+	}
+	return []github.AgentSource{github.DefaultAgentSource()}
+}
+
+// GetAgentSources returns the configured agent marketplace sources
+func (a *App) GetAgentSources() []github.AgentSource {
+	return a.loadAgentSources()
+}
+
+// SetAgentSources replaces and persists the agent marketplace sources
+func (a *App) SetAgentSources(sources []github.AgentSource) ([]github.AgentSource, error) {
+	if a.dbManager == nil {
+		return nil, a.unavailable(subsystemDatabase)
+	}
+	normalized, err := github.NormalizeAgentSources(sources)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(normalized)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode agent sources: %w", err)
+	}
+	if err := a.dbManager.SaveSetting(agentSourcesSettingKey, string(data)); err != nil {
+		return nil, fmt.Errorf("failed to save agent sources: %w", err)
+	}
+	return normalized, nil
+}
+
+// syncAgentMarketplace fetches every enabled source and rebuilds the index.
+// A failing source is recorded in SourceErrors and does not abort the sync.
+func (a *App) syncAgentMarketplace() *AgentMarketplaceIndex {
+	index := &AgentMarketplaceIndex{
+		Agents:   make([]MarketplaceAgent, 0),
+		SyncedAt: time.Now().UnixMilli(),
+	}
+	for _, source := range a.loadAgentSources() {
+		if !source.Enabled {
+			continue
+		}
+		files, err := a.agentSourceClient.FetchSourceAgents(source)
+		if err != nil {
+			if index.SourceErrors == nil {
+				index.SourceErrors = make(map[string]string)
+			}
+			index.SourceErrors[source.ID] = err.Error()
+			continue
+		}
+		for _, file := range files {
+			entry := MarketplaceAgent{
+				SourceID:    source.ID,
+				SourceName:  source.Name,
+				FileName:    file.Name,
+				Path:        file.Path,
+				DownloadURL: file.DownloadURL,
+				SHA:         file.SHA,
+				Name:        strings.TrimSuffix(file.Name, ".opcode.json"),
+			}
+			exportFile, err := a.agentSourceClient.FetchAgentExportFile(file.DownloadURL)
+			if err != nil {
+				entry.Error = err.Error()
+			} else {
+				entry.Name = exportFile.Agent.Name
+				entry.Icon = exportFile.Agent.Icon
+				entry.Model = exportFile.Agent.Model
+				entry.SystemPrompt = exportFile.Agent.SystemPrompt
+				entry.DefaultTask = exportFile.Agent.DefaultTask
+			}
+			index.Agents = append(index.Agents, entry)
+		}
+	}
+	return index
+}
+
+// markInstalledAgents flags index entries that are installed locally and
+// whether the published version differs from the local one.
+func markInstalledAgents(index *AgentMarketplaceIndex, agents []*database.Agent) {
+	bySource := make(map[string]*database.Agent)
+	byName := make(map[string]*database.Agent)
+	for _, agent := range agents {
+		if agent.SourceURL != "" {
+			bySource[agent.SourceURL] = agent
+		} else {
+			byName[agent.Name] = agent
+		}
+	}
+
+	for i := range index.Agents {
+		entry := &index.Agents[i]
+		installed := bySource[entry.DownloadURL]
+		if installed == nil {
+			installed = byName[entry.Name]
+		}
+		entry.Installed = installed != nil
+		entry.InstalledAgentID = 0
+		entry.UpdateAvailable = false
+		if installed == nil {
+			continue
+		}
+		entry.InstalledAgentID = installed.ID
+		entry.UpdateAvailable = entry.Error == "" && (installed.SystemPrompt != entry.SystemPrompt ||
+			installed.Icon != entry.Icon ||
+			installed.Model != entry.Model ||
+			installed.DefaultTask != entry.DefaultTask)
+	}
+}
+
+// ListMarketplaceAgents returns the local index of available agents with their
+// installed state. With refresh (or when no index exists yet) all enabled
+// sources are synced first.
+func (a *App) ListMarketplaceAgents(refresh bool) (*AgentMarketplaceIndex, error) {
+	if a.dbManager == nil {
+		return nil, a.unavailable(subsystemDatabase)
+	}
+
+	var index *AgentMarketplaceIndex
+	if !refresh {
+		if raw, err := a.dbManager.GetSetting(agentMarketplaceIndexSettingKey); err == nil && raw != "" {
+			var cached AgentMarketplaceIndex
+			if err := json.Unmarshal([]byte(raw), &cached); err == nil {
+				index = &cached
+			}
+		}
+	}
+	if index == nil {
+		index = a.syncAgentMarketplace()
+		data, err := json.Marshal(index)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode agent marketplace index: %w", err)
+		}
+		if err := a.dbManager.SaveSetting(agentMarketplaceIndexSettingKey, string(data)); err != nil {
+			return nil, fmt.Errorf("failed to save agent marketplace index: %w", err)
+		}
+	}
+
+	agents, err := a.dbManager.ListAgents()
+	if err != nil {
+		return nil, err
+	}
+	markInstalledAgents(index, agents)
+	return index, nil
+}
+
+// UpdateAgentFromSource compares an installed agent with the version published
+// by its source. The system prompt diff is always returned; the local agent
+// is only overwritten when apply is true.
+func (a *App) UpdateAgentFromSource(agentID int64, apply bool) (*AgentSourceUpdate, error) {
+	if a.dbManager == nil {
+		return nil, a.unavailable(subsystemDatabase)
+	}
+	agent, err := a.dbManager.GetAgent(agentID)
+	if err != nil {
+		return nil, err
+	}
+	if agent.SourceURL == "" {
+		return nil, fmt.Errorf("agent %q was not installed from an agent source", agent.Name)
+	}
+
+	exportFile, err := a.agentSourceClient.FetchAgentExportFile(agent.SourceURL)
+	if err != nil {
+		return nil, err
+	}
+	remote := exportFile.Agent
+
+	update := &AgentSourceUpdate{
+		AgentID:    agent.ID,
+		SourceURL:  agent.SourceURL,
+		PromptDiff: github.DiffLines(agent.SystemPrompt, remote.SystemPrompt),
+		Remote:     remote,
+		Agent:      agent,
+	}
+	update.Changed = github.HasChanges(update.PromptDiff) ||
+		agent.Icon != remote.Icon ||
+		agent.Model != remote.Model ||
+		agent.DefaultTask != remote.DefaultTask
+	if !apply || !update.Changed {
+		return update, nil
+	}
+
+	agent.Icon = remote.Icon
+	agent.Model = remote.Model
+	agent.SystemPrompt = remote.SystemPrompt
+	agent.DefaultTask = remote.DefaultTask
+	if err := a.dbManager.UpdateAgent(agent); err != nil {
+		return nil, fmt.Errorf("failed to update agent: %w", err)
+	}
+	update.Applied = true
+	return update, nil
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"testing"
+
+	"ropcode/internal/database"
+)
+
+func TestMarkInstalledAgentsMatchesSourceURLThenName(t *testing.T) {
+	index := &AgentMarketplaceIndex{Agents: []MarketplaceAgent{
+		{Name: "Reviewer", DownloadURL: "https://raw/acme/reviewer.opcode.json", SystemPrompt: "v2", Icon: "🔍", Model: "sonnet"},
+		{Name: "Legacy", DownloadURL: "https://raw/acme/legacy.opcode.json", SystemPrompt: "same", Icon: "🤖", Model: "sonnet"},
+		{Name: "New", DownloadURL: "https://raw/acme/new.opcode.json"},
+	}}
+	agents := []*database.Agent{
+		// Renamed locally, still linked by source URL.
+		{ID: 1, Name: "My Reviewer", SourceURL: "https://raw/acme/reviewer.opcode.json", SystemPrompt: "v1", Icon: "🔍", Model: "sonnet"},
+		// Imported before sources were tracked.
+		{ID: 2, Name: "Legacy", SystemPrompt: "same", Icon: "🤖", Model: "sonnet"},
+	}
+
+	markInstalledAgents(index, agents)
+
+	reviewer, legacy, fresh := index.Agents[0], index.Agents[1], index.Agents[2]
+	if !reviewer.Installed || reviewer.InstalledAgentID != 1 || !reviewer.UpdateAvailable {
+		t.Fatalf("unexpected reviewer entry: %#v", reviewer)
+	}
+	if !legacy.Installed || legacy.InstalledAgentID != 2 || legacy.UpdateAvailable {
+		t.Fatalf("unexpected legacy entry: %#v", legacy)
+	}
+	if fresh.Installed || fresh.UpdateAvailable {
+		t.Fatalf("unexpected new entry: %#v", fresh)
+	}
+}
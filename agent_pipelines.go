@@ -0,0 +1,290 @@
+// agent_pipelines.go
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"ropcode/internal/database"
+)
+
+// pipelinePreviousPlaceholder is replaced with the previous step's output.
+const pipelinePreviousPlaceholder = "{{previous}}"
+
+// validatePipelineSteps checks that a pipeline has steps and each references
+// an existing agent.
+func (a *App) validatePipelineSteps(steps []database.PipelineStep) error {
+	if len(steps) == 0 {
+		return fmt.Errorf("pipeline must have at least one step")
+	}
+	for i, step := range steps {
+		switch step.InputMode {
+		case "", "result", "output":
+		default:
+			return fmt.Errorf("step %d: unsupported input mode: %s", i+1, step.InputMode)
+		}
+		if _, err := a.dbManager.GetAgent(step.AgentID); err != nil {
+			return fmt.Errorf("step %d: agent %d not found", i+1, step.AgentID)
+		}
+	}
+	return nil
+}
+
+// buildPipelineStepTask renders a step's task template with the previous output.
+func buildPipelineStepTask(template, previous string) string {
+	template = strings.TrimSpace(template)
+	switch {
+	case template == "":
+		return previous
+	case strings.Contains(template, pipelinePreviousPlaceholder):
+		return strings.ReplaceAll(template, pipelinePreviousPlaceholder, previous)
+	case previous == "":
+		return template
+	default:
+		return template + "\n\n---\n\nInput:\n" + previous
+	}
+}
+
+// emitPipelineChanged pushes a snapshot of run to the front-end.
+func (a *App) emitPipelineChanged(run *database.PipelineRun) {
+	if a == nil || a.eventHub == nil {
+		return
+	}
+	snapshot := *run
+	snapshot.Steps = append([]database.PipelineStepRun(nil), run.Steps...)
+	a.eventHub.Emit("pipeline:changed", &snapshot)
+}
+
+// savePipelineRun persists and broadcasts the current state of run.
+func (a *App) savePipelineRun(run *database.PipelineRun) {
+	if err := a.dbManager.UpdatePipelineRun(run); err != nil {
+		slog.Error("failed to save run", "component", "pipelines", "run_id", run.ID, "error", err)
+	}
+	a.emitPipelineChanged(run)
+}
+
+// runPipeline executes the steps of run in order, feeding each step's output
+// into the next one.
+func (a *App) runPipeline(ctx context.Context, pipeline *database.Pipeline, run *database.PipelineRun) {
+	defer a.pipelineRuns.remove(run.ID)
+
+	run.Status = "running"
+	a.savePipelineRun(run)
+
+	input := run.Task
+	for i, step := range pipeline.Steps {
+		run.CurrentStep = i
+		status, output, err := a.runPipelineStep(ctx, run, i, step, input)
+		run.Steps[i].Status = status
+		if err != nil {
+			run.Steps[i].Error = err.Error()
+		}
+		if status != "completed" {
+			run.Status = status
+			run.Error = fmt.Sprintf("step %d (%s) %s", i+1, run.Steps[i].AgentName, status)
+			if err != nil {
+				run.Error += ": " + err.Error()
+			}
+			for j := i + 1; j < len(run.Steps); j++ {
+				run.Steps[j].Status = "skipped"
+			}
+			break
+		}
+		a.savePipelineRun(run)
+
+		if step.InputMode == "output" {
+			input = strings.TrimSpace(output)
+		} else {
+			input = extractRunResult(output)
+		}
+	}
+
+	if run.Status == "running" {
+		run.Status = "completed"
+	}
+	completedAt := time.Now()
+	run.CompletedAt = &completedAt
+	a.savePipelineRun(run)
+}
+
+// runPipelineStep starts the agent for one step and waits for it to finish.
+// It returns the step status ("completed", "failed" or "cancelled") and the
+// raw session output.
+func (a *App) runPipelineStep(ctx context.Context, run *database.PipelineRun, index int, step database.PipelineStep, input string) (string, string, error) {
+	if ctx.Err() != nil {
+		return "cancelled", "", nil
+	}
+	run.Steps[index].Status = "running"
+	a.savePipelineRun(run)
+
+	task := buildPipelineStepTask(step.Task, input)
+	agentRun, status, err := a.runAgentToCompletion(ctx, step.AgentID, run.ProjectPath, task, step.Model, func(agentRun *database.AgentRun) {
+		run.Steps[index].AgentRunID = agentRun.ID
+		a.savePipelineRun(run)
+	})
+	if err != nil || status != "completed" {
+		return status, "", err
+	}
+
+	output, err := a.sessionManagerFor(agentRun.Provider).GetSessionOutput(agentRun.SessionID)
+	if err != nil {
+		return "failed", "", fmt.Errorf("failed to read output: %w", err)
+	}
+	return status, output, nil
+}
+
+// ListPipelines returns all agent pipelines
+func (a *App) ListPipelines() ([]*database.Pipeline, error) {
+	if a.dbManager == nil {
+		return nil, a.unavailable(subsystemDatabase)
+	}
+	return a.dbManager.ListPipelines()
+}
+
+// GetPipeline returns a single pipeline by ID
+func (a *App) GetPipeline(id int64) (*database.Pipeline, error) {
+	if a.dbManager == nil {
+		return nil, a.unavailable(subsystemDatabase)
+	}
+	return a.dbManager.GetPipeline(id)
+}
+
+// CreatePipeline creates a new agent pipeline
+func (a *App) CreatePipeline(name, description string, steps []database.PipelineStep) (*database.Pipeline, error) {
+	if a.dbManager == nil {
+		return nil, a.unavailable(subsystemDatabase)
+	}
+	if strings.TrimSpace(name) == "" {
+		return nil, fmt.Errorf("pipeline name is required")
+	}
+	if err := a.validatePipelineSteps(steps); err != nil {
+		return nil, err
+	}
+
+	pipeline := &database.Pipeline{
+		Name:        name,
+		Description: description,
+		Steps:       steps,
+	}
+	if _, err := a.dbManager.CreatePipeline(pipeline); err != nil {
+		return nil, err
+	}
+	return pipeline, nil
+}
+
+// UpdatePipeline updates an existing agent pipeline
+func (a *App) UpdatePipeline(id int64, name, description string, steps []database.PipelineStep) (*database.Pipeline, error) {
+	if a.dbManager == nil {
+		return nil, a.unavailable(subsystemDatabase)
+	}
+	if strings.TrimSpace(name) == "" {
+		return nil, fmt.Errorf("pipeline name is required")
+	}
+	if err := a.validatePipelineSteps(steps); err != nil {
+		return nil, err
+	}
+
+	pipeline, err := a.dbManager.GetPipeline(id)
+	if err != nil {
+		return nil, err
+	}
+	pipeline.Name = name
+	pipeline.Description = description
+	pipeline.Steps = steps
+	if err := a.dbManager.UpdatePipeline(pipeline); err != nil {
+		return nil, err
+	}
+	return pipeline, nil
+}
+
+// DeletePipeline deletes a pipeline and its run history
+func (a *App) DeletePipeline(id int64) error {
+	if a.dbManager == nil {
+		return a.unavailable(subsystemDatabase)
+	}
+	return a.dbManager.DeletePipeline(id)
+}
+
+// ExecutePipeline starts a pipeline on a project and returns immediately.
+// Progress is reported through "pipeline:changed" events.
+func (a *App) ExecutePipeline(pipelineID int64, projectPath, task string) (*database.PipelineRun, error) {
+	if a.dbManager == nil {
+		return nil, a.unavailable(subsystemDatabase)
+	}
+
+	pipeline, err := a.dbManager.GetPipeline(pipelineID)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.validatePipelineSteps(pipeline.Steps); err != nil {
+		return nil, err
+	}
+
+	run := &database.PipelineRun{
+		PipelineID:   pipeline.ID,
+		PipelineName: pipeline.Name,
+		ProjectPath:  projectPath,
+		Task:         task,
+		Status:       "pending",
+		Steps:        make([]database.PipelineStepRun, 0, len(pipeline.Steps)),
+	}
+	for _, step := range pipeline.Steps {
+		stepRun := database.PipelineStepRun{AgentID: step.AgentID, Status: "pending"}
+		if agent, err := a.dbManager.GetAgent(step.AgentID); err == nil {
+			stepRun.AgentName = agent.Name
+		}
+		run.Steps = append(run.Steps, stepRun)
+	}
+	if _, err := a.dbManager.CreatePipelineRun(run); err != nil {
+		return nil, err
+	}
+
+	parent := a.ctx
+	if parent == nil {
+		parent = context.Background()
+	}
+	ctx, cancel := context.WithCancel(parent)
+	a.pipelineRuns.add(run.ID, cancel)
+
+	snapshot := *run
+	snapshot.Steps = append([]database.PipelineStepRun(nil), run.Steps...)
+	go func() {
+		defer cancel()
+		a.runPipeline(ctx, pipeline, run)
+	}()
+	return &snapshot, nil
+}
+
+// GetPipelineRun returns a pipeline run by ID
+func (a *App) GetPipelineRun(runID int64) (*database.PipelineRun, error) {
+	if a.dbManager == nil {
+		return nil, a.unavailable(subsystemDatabase)
+	}
+	return a.dbManager.GetPipelineRun(runID)
+}
+
+// ListPipelineRuns returns pipeline runs, optionally filtered by pipeline ID
+func (a *App) ListPipelineRuns(pipelineID int64, limit int) ([]*database.PipelineRun, error) {
+	if a.dbManager == nil {
+		return nil, a.unavailable(subsystemDatabase)
+	}
+	var pipelineIDPtr *int64
+	if pipelineID > 0 {
+		pipelineIDPtr = &pipelineID
+	}
+	if limit <= 0 {
+		limit = 50 // Default limit
+	}
+	return a.dbManager.ListPipelineRuns(pipelineIDPtr, limit)
+}
+
+// CancelPipelineRun stops a running pipeline and cancels its current agent run
+func (a *App) CancelPipelineRun(runID int64) error {
+	if !a.pipelineRuns.cancel(runID) {
+		return fmt.Errorf("pipeline run is not active: %d", runID)
+	}
+	return nil
+}
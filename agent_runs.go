@@ -0,0 +1,311 @@
+// agent_runs.go
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"ropcode/internal/database"
+)
+
+// maxRunResultFallback caps the raw output tail used when no final assistant
+// message can be found.
+const maxRunResultFallback = 8000
+
+// runCancelStore tracks cancel funcs for background runs (pipelines, agent
+// batches) that are still in progress.
+type runCancelStore struct {
+	mu      sync.Mutex
+	cancels map[int64]context.CancelFunc
+}
+
+func newRunCancelStore() *runCancelStore {
+	return &runCancelStore{cancels: make(map[int64]context.CancelFunc)}
+}
+
+func (s *runCancelStore) add(runID int64, cancel context.CancelFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cancels[runID] = cancel
+}
+
+func (s *runCancelStore) remove(runID int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.cancels, runID)
+}
+
+func (s *runCancelStore) active(runID int64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.cancels[runID]
+	return ok
+}
+
+func (s *runCancelStore) cancel(runID int64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cancel, ok := s.cancels[runID]
+	if ok {
+		cancel()
+	}
+	return ok
+}
+
+// waitForAgentRun blocks until the run's provider session exits or ctx is
+// done, then records and returns the final status.
+func (a *App) waitForAgentRun(ctx context.Context, run *database.AgentRun) (string, error) {
+	manager := a.sessionManagerFor(run.Provider)
+	if manager == nil {
+		return "", nil
+	}
+	status, err := manager.WaitForSession(ctx, run.SessionID)
+	if err != nil {
+		return "", err
+	}
+
+	if a.dbManager != nil {
+		completedAt := time.Now()
+		if err := a.dbManager.UpdateAgentRunStatus(run.ID, status, run.PID, run.ProcessStartedAt, &completedAt); err != nil {
+			slog.Error("failed to record status", "component", "agents", "run_id", run.ID, "error", err)
+		}
+
+		output, _ := manager.GetSessionOutput(run.SessionID)
+		metrics := parseRunMetrics(output)
+		metrics.DurationMS = completedAt.Sub(run.CreatedAt).Milliseconds()
+		if err := a.dbManager.UpdateAgentRunMetrics(run.ID, metrics); err != nil {
+			slog.Error("failed to record metrics", "component", "agents", "run_id", run.ID, "error", err)
+		}
+	}
+	if status == "failed" {
+		a.notifyAgentRunFailed(run)
+	}
+	return status, nil
+}
+
+// trackAgentRun records the final status of a run in the background.
+func (a *App) trackAgentRun(run *database.AgentRun) {
+	ctx := a.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if _, err := a.waitForAgentRun(ctx, run); err != nil && ctx.Err() == nil {
+		log.Printf("[agents] stopped tracking run %d: %v", run.ID, err)
+	}
+}
+
+// runAgentToCompletion executes an agent and waits for its session to exit.
+// started is called with the new run before waiting. When ctx is cancelled the
+// agent run is cancelled and the status "cancelled" is returned. An empty
+// model falls back to the agent's default model.
+func (a *App) runAgentToCompletion(ctx context.Context, agentID int64, projectPath, task, model string, started func(*database.AgentRun)) (*database.AgentRun, string, error) {
+	if ctx.Err() != nil {
+		return nil, "cancelled", nil
+	}
+	if model == "" && a.dbManager != nil {
+		if agent, err := a.dbManager.GetAgent(agentID); err == nil {
+			model = agent.Model
+		}
+	}
+
+	agentRun, err := a.ExecuteAgent(agentID, projectPath, task, model)
+	if err != nil {
+		return nil, "failed", err
+	}
+	if agentRun == nil {
+		return nil, "failed", fmt.Errorf("database not initialized")
+	}
+	if started != nil {
+		started(agentRun)
+	}
+
+	manager := a.sessionManagerFor(agentRun.Provider)
+	if manager == nil {
+		return agentRun, "failed", fmt.Errorf("%s manager not initialized", agentRun.Provider)
+	}
+	status, err := manager.WaitForSession(ctx, agentRun.SessionID)
+	if err != nil {
+		if ctx.Err() != nil {
+			if cancelErr := a.CancelAgentRun(agentRun.ID); cancelErr != nil {
+				slog.Error("failed to cancel agent run", "component", "agents", "run_id", agentRun.ID, "error", cancelErr)
+			}
+			return agentRun, "cancelled", nil
+		}
+		return agentRun, "failed", err
+	}
+	return agentRun, status, nil
+}
+
+// parseRunMetrics sums token usage and cost reported in a provider session's
+// raw output. Claude and Gemini report cumulative totals in their result
+// event; Codex reports usage per turn.
+func parseRunMetrics(output string) database.AgentRunMetrics {
+	var metrics database.AgentRunMetrics
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "{") {
+			continue
+		}
+		var event map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			continue
+		}
+
+		eventType, _ := event["type"].(string)
+		switch eventType {
+		case "result":
+			if usage, ok := event["usage"].(map[string]interface{}); ok {
+				// Claude: cache tokens are reported separately from input_tokens.
+				metrics.InputTokens = usageTokens(usage, "input_tokens") +
+					usageTokens(usage, "cache_creation_input_tokens") +
+					usageTokens(usage, "cache_read_input_tokens")
+				metrics.OutputTokens = usageTokens(usage, "output_tokens")
+			} else if stats, ok := event["stats"].(map[string]interface{}); ok {
+				metrics.InputTokens = usageTokens(stats, "input_tokens")
+				metrics.OutputTokens = usageTokens(stats, "output_tokens")
+			}
+			if cost, ok := event["total_cost_usd"].(float64); ok {
+				metrics.CostUSD = cost
+			}
+		case "turn.completed":
+			if usage, ok := event["usage"].(map[string]interface{}); ok {
+				metrics.InputTokens += usageTokens(usage, "input_tokens")
+				metrics.OutputTokens += usageTokens(usage, "output_tokens")
+			}
+		}
+	}
+	metrics.TotalTokens = metrics.InputTokens + metrics.OutputTokens
+	return metrics
+}
+
+func usageTokens(usage map[string]interface{}, key string) int64 {
+	value, _ := usage[key].(float64)
+	return int64(value)
+}
+
+// extractRunResult returns the final answer from a provider session's raw
+// stream-json output: Claude's result event if present, otherwise the last
+// assistant message from Claude, Gemini or Codex. It falls back to the tail of
+// the output when nothing recognisable is found.
+func extractRunResult(output string) string {
+	var result, lastText string
+	geminiStreaming := false
+
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "{") {
+			continue
+		}
+		var event map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			continue
+		}
+
+		eventType, _ := event["type"].(string)
+		switch eventType {
+		case "result":
+			if text, _ := event["result"].(string); text != "" {
+				result = text
+			}
+		case "assistant":
+			if message, ok := event["message"].(map[string]interface{}); ok {
+				if text := contentText(message["content"]); text != "" {
+					lastText = text
+				}
+			}
+		case "message":
+			// Gemini streams assistant text as consecutive delta messages.
+			if role, _ := event["role"].(string); role == "assistant" {
+				text := contentText(event["content"])
+				if delta, _ := event["delta"].(bool); delta && geminiStreaming {
+					lastText += text
+				} else if text != "" {
+					lastText = text
+				}
+				geminiStreaming = true
+				continue
+			}
+		case "item.completed":
+			if item, ok := event["item"].(map[string]interface{}); ok {
+				itemType, _ := item["type"].(string)
+				if itemType == "" {
+					itemType, _ = item["item_type"].(string)
+				}
+				if itemType == "agent_message" || itemType == "assistant_message" {
+					if text, _ := item["text"].(string); text != "" {
+						lastText = text
+					}
+				}
+			}
+		}
+		geminiStreaming = false
+	}
+
+	if result != "" {
+		return strings.TrimSpace(result)
+	}
+	if lastText != "" {
+		return strings.TrimSpace(lastText)
+	}
+	tail := strings.TrimSpace(output)
+	if len(tail) > maxRunResultFallback {
+		tail = tail[len(tail)-maxRunResultFallback:]
+	}
+	return tail
+}
+
+// contentText joins the text parts of a message content field, which is either
+// a plain string or an array of typed parts.
+func contentText(content interface{}) string {
+	switch c := content.(type) {
+	case string:
+		return c
+	case []interface{}:
+		var sb strings.Builder
+		for _, part := range c {
+			partMap, ok := part.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if partType, _ := partMap["type"].(string); partType != "" && partType != "text" {
+				continue
+			}
+			if text, _ := partMap["text"].(string); text != "" {
+				sb.WriteString(text)
+			}
+		}
+		return sb.String()
+	}
+	return ""
+}
+
+// GetAgentRunMetrics returns duration, token usage and cost of a finished run
+func (a *App) GetAgentRunMetrics(runID int64) (*database.AgentRunMetrics, error) {
+	if a.dbManager == nil {
+		return nil, a.unavailable(subsystemDatabase)
+	}
+	run, err := a.dbManager.GetAgentRun(runID)
+	if err != nil {
+		return nil, err
+	}
+	return run.Metrics, nil
+}
+
+// GetAgentMetricsSummary aggregates run metrics per agent so agents can be
+// compared. An agentID of 0 returns a summary for every agent.
+func (a *App) GetAgentMetricsSummary(agentID int64) ([]*database.AgentMetricsSummary, error) {
+	if a.dbManager == nil {
+		return nil, a.unavailable(subsystemDatabase)
+	}
+	var agentIDPtr *int64
+	if agentID > 0 {
+		agentIDPtr = &agentID
+	}
+	return a.dbManager.GetAgentMetricsSummaries(agentIDPtr)
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"testing"
+
+	"ropcode/internal/database"
+)
+
+func TestExtractRunResultPrefersClaudeResultEvent(t *testing.T) {
+	output := `{"type":"system","subtype":"init"}
+{"type":"assistant","message":{"role":"assistant","content":[{"type":"text","text":"Looking at the code"}]}}
+{"type":"result","subtype":"success","result":"Found 2 bugs in auth.go"}
+`
+	if got := extractRunResult(output); got != "Found 2 bugs in auth.go" {
+		t.Fatalf("extractRunResult = %q", got)
+	}
+}
+
+func TestExtractRunResultUsesLastAssistantMessage(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   string
+	}{
+		{
+			name: "claude",
+			output: `{"type":"assistant","message":{"content":[{"type":"text","text":"first"}]}}
+{"type":"assistant","message":{"content":[{"type":"tool_use","name":"Read"},{"type":"text","text":"final answer"}]}}`,
+			want: "final answer",
+		},
+		{
+			name: "gemini deltas",
+			output: `{"type":"message","role":"assistant","content":"early","delta":true}
+{"type":"tool_use","tool_name":"read_file"}
+{"type":"message","role":"assistant","content":"Hello ","delta":true}
+{"type":"message","role":"assistant","content":"world","delta":true}
+{"type":"result","status":"success"}`,
+			want: "Hello world",
+		},
+		{
+			name: "codex",
+			output: `{"type":"item.completed","item":{"type":"reasoning","text":"thinking"}}
+{"type":"item.completed","item":{"type":"agent_message","text":"done: tests pass"}}
+{"type":"turn.completed"}`,
+			want: "done: tests pass",
+		},
+		{
+			name:   "plain text fallback",
+			output: "  not json output\n",
+			want:   "not json output",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := extractRunResult(tt.output); got != tt.want {
+				t.Fatalf("extractRunResult = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildPipelineStepTask(t *testing.T) {
+	tests := []struct {
+		template string
+		previous string
+		want     string
+	}{
+		{"", "review notes", "review notes"},
+		{"Fix: {{previous}}", "nil deref", "Fix: nil deref"},
+		{"Write tests", "", "Write tests"},
+		{"Write tests", "for auth.go", "Write tests\n\n---\n\nInput:\nfor auth.go"},
+	}
+	for _, tt := range tests {
+		if got := buildPipelineStepTask(tt.template, tt.previous); got != tt.want {
+			t.Errorf("buildPipelineStepTask(%q, %q) = %q, want %q", tt.template, tt.previous, got, tt.want)
+		}
+	}
+}
+
+func TestParseRunMetrics(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   database.AgentRunMetrics
+	}{
+		{
+			name:   "claude result",
+			output: `{"type":"result","total_cost_usd":0.42,"usage":{"input_tokens":10,"cache_creation_input_tokens":100,"cache_read_input_tokens":1000,"output_tokens":50}}`,
+			want:   database.AgentRunMetrics{InputTokens: 1110, OutputTokens: 50, TotalTokens: 1160, CostUSD: 0.42},
+		},
+		{
+			name:   "gemini stats",
+			output: `{"type":"result","status":"success","stats":{"input_tokens":300,"output_tokens":40,"total_tokens":340}}`,
+			want:   database.AgentRunMetrics{InputTokens: 300, OutputTokens: 40, TotalTokens: 340},
+		},
+		{
+			name: "codex turns",
+			output: `{"type":"turn.completed","usage":{"input_tokens":200,"cached_input_tokens":150,"output_tokens":20}}
+{"type":"turn.completed","usage":{"input_tokens":300,"output_tokens":30}}`,
+			want: database.AgentRunMetrics{InputTokens: 500, OutputTokens: 50, TotalTokens: 550},
+		},
+		{
+			name:   "no usage",
+			output: "plain text",
+			want:   database.AgentRunMetrics{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseRunMetrics(tt.output); got != tt.want {
+				t.Fatalf("parseRunMetrics = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
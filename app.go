@@ -4,24 +4,36 @@ package main
 import (
 	"context"
 	"log"
+	"log/slog"
 	"sync"
+	"time"
 
+	"ropcode/internal/actionrun"
 	"ropcode/internal/claude"
 	"ropcode/internal/claudeactivity"
 	"ropcode/internal/codex"
 	"ropcode/internal/config"
 	"ropcode/internal/database"
 	"ropcode/internal/eventhub"
+	"ropcode/internal/filechanges"
 	"ropcode/internal/gemini"
 	"ropcode/internal/git"
+	"ropcode/internal/github"
+	"ropcode/internal/jobs"
+	"ropcode/internal/loginenv"
 	"ropcode/internal/mcp"
 	"ropcode/internal/models"
+	"ropcode/internal/outputimages"
 	"ropcode/internal/plugin"
 	"ropcode/internal/process"
 	"ropcode/internal/pty"
+	"ropcode/internal/resource"
 	appRuntime "ropcode/internal/runtime"
+	"ropcode/internal/scheduler"
 	"ropcode/internal/session"
 	"ropcode/internal/ssh"
+	"ropcode/internal/updater"
+	"ropcode/internal/watcher"
 )
 
 // App struct contains the core application state and managers
@@ -33,27 +45,61 @@ type App struct {
 	// Core managers
 	ptyManager          *pty.Manager
 	processManager      *process.Manager
+	actionRunner        *actionrun.Runner
+	jobRunner           *jobs.Runner
 	dbManager           *database.Database
 	claudeManager       *claude.SessionManager
 	claudeActivity      *claudeactivity.Service
 	geminiManager       *gemini.SessionManager
 	codexManager        *codex.SessionManager
 	mcpManager          *mcp.Manager
+	mcpSupervisor       *mcp.Supervisor
 	sshManager          *ssh.Manager
 	pluginManager       *plugin.Manager
 	sessionManager      *session.HistoryManager
 	eventHub            *eventhub.EventHub
 	aiOutputCoalescer   *eventhub.ClaudeOutputCoalescer
+	fileChanges         *filechanges.Tracker
 	gitWatcher          *git.GitWatcher
+	gitStatusWatcher    *git.StatusWatcher
+	gitStatusCache      map[string]*GitStatusEvent // watched project → last status, guarded by mu
+	fsWatcher           *watcher.ProjectWatchers
 	modelRegistry       *models.Registry
 	capabilityDiscovery claudeCapabilityDiscovery
+	claudeSettingsWatch *watcher.Watcher
+	claudeSettingsEnv   map[string]string // env section of ~/.claude/settings.json last read, guarded by mu
 	sessionTitles       *sessionTitleStore
+	resourceMonitor     *resource.Monitor
+	sessionScheduler    *scheduler.Scheduler
+	pipelineRuns        *runCancelStore
+	agentBatches        *runCancelStore
+	providerComparisons *runCancelStore
+	contentSearches     *runCancelStore
+	sessionFollows      *runCancelStore
+	agentSourceClient   *github.Client
+	remoteServers       *remoteServerPool
+	settingsSyncMu      sync.Mutex        // serializes settings sync runs on the local clone
+	settingsMu          sync.Mutex        // serializes typed settings updates
+	usageQuotaMu        sync.Mutex        // serializes usage quota checks
+	updateMu            sync.Mutex        // serializes update checks, downloads and installs
+	updateStatus        *UpdateStatus     // guarded by mu
+	updateRelease       *updater.Release  // newest release found, guarded by mu
+	attentionCount      int               // sessions awaiting review, guarded by mu
+	initErrors          map[string]error  // subsystem → startup failure, guarded by mu
+	sessionProfiles     map[string]string // project → permission profile picked for its sessions, guarded by mu
 }
 
 // NewApp creates a new App application struct
 func NewApp() *App {
 	return &App{
-		sessionTitles: newSessionTitleStore(),
+		sessionTitles:       newSessionTitleStore(),
+		pipelineRuns:        newRunCancelStore(),
+		agentBatches:        newRunCancelStore(),
+		providerComparisons: newRunCancelStore(),
+		contentSearches:     newRunCancelStore(),
+		sessionFollows:      newRunCancelStore(),
+		agentSourceClient:   github.NewClient(),
+		remoteServers:       newRemoteServerPool(),
 	}
 }
 
@@ -64,7 +110,8 @@ func (a *App) startup(ctx context.Context) {
 	// Load config
 	cfg, err := config.Load()
 	if err != nil {
-		log.Printf("Failed to load config: %v", err)
+		slog.Error("Failed to load config", "component", "App", "error", err)
+		a.setInitError(subsystemConfig, err)
 		return
 	}
 	a.config = cfg
@@ -72,18 +119,27 @@ func (a *App) startup(ctx context.Context) {
 	// Initialize database
 	db, err := database.Open(cfg.DatabasePath)
 	if err != nil {
-		log.Printf("Failed to open database: %v", err)
+		slog.Error("Failed to open database", "component", "App", "error", err)
+		a.setInitError(subsystemDatabase, err)
 	} else {
 		a.dbManager = db
 
 		// Initialize model registry and sync builtin models
 		a.modelRegistry = models.NewRegistry(db)
 		if err := a.modelRegistry.Initialize(); err != nil {
-			log.Printf("Failed to initialize model registry: %v", err)
+			slog.Error("Failed to initialize model registry", "component", "App", "error", err)
 		}
 
+		a.applyLogLevel()
 		a.loadGeneratedSessionTitles()
+		a.seedModelPricing()
+		a.fileChanges = filechanges.NewTracker(db)
 	}
+	a.applyProxySettings()
+
+	// Capture the login shell's environment in the background; sessions,
+	// terminals and commands wait for it only if they start before it's done
+	loginenv.Capture(a.shellPreference().Path)
 
 	// Initialize EventHub (before managers that need it)
 	a.eventHub = eventhub.New(nil)
@@ -96,20 +152,30 @@ func (a *App) startup(ctx context.Context) {
 	// isn't saturated during long streaming runs. Other event types pass
 	// through unchanged after flushing any pending batch.
 	a.aiOutputCoalescer = eventhub.NewClaudeOutputCoalescer(a.eventHub.Emit)
-	aiSessionEmitter := &coalescedEmitter{coalescer: a.aiOutputCoalescer}
+	aiSessionEmitter := &coalescedEmitter{
+		coalescer: a.aiOutputCoalescer,
+		images:    a.outputImages(),
+		onLine:    a.observeOutputLine,
+	}
 
 	// Initialize PTY manager with event emitter
 	a.ptyManager = pty.NewManager(ctx, eventEmitter)
 
 	// Initialize process manager
 	a.processManager = process.NewManager(ctx)
-	a.processManager.SetEventHub(a.eventHub)
+	a.processManager.SetEventHub(newCommandNotifier(a, a.eventHub))
+	a.actionRunner = a.newActionRunner()
+	a.jobRunner = jobs.New(a.processManager, a.eventHub)
 
 	// Initialize Claude session manager
 	a.claudeActivity = claudeactivity.NewService()
 	a.claudeManager = claude.NewSessionManager(ctx, aiSessionEmitter)
 	a.claudeManager.SetProcessEmitter(&claudeProcessEmitter{eventHub: a.eventHub})
-	a.claudeManager.SetActivityObserver(a.claudeActivity)
+	if a.dbManager != nil {
+		a.claudeManager.SetActivityObserver(claudeObservers{a.claudeActivity, a.newHookRecorder()})
+	} else {
+		a.claudeManager.SetActivityObserver(a.claudeActivity)
+	}
 
 	// Initialize Gemini session manager
 	a.geminiManager = gemini.NewSessionManager(ctx, aiSessionEmitter)
@@ -123,23 +189,63 @@ func (a *App) startup(ctx context.Context) {
 	// Note: MCP manager now uses dynamic claude binary detection on each command execution
 	// This ensures it works in .app packages where PATH is limited
 	a.mcpManager = mcp.NewManager(cfg.ClaudeDir)
+	a.mcpSupervisor = mcp.NewSupervisor(a.processManager, a.emitMcpServerChanged)
+
+	// Use the CLI installations chosen in settings over the discovered ones
+	a.applyProviderBinaryPaths()
+	a.probeProviderCLIs()
+	a.applyWSLDistro()
 
 	// Initialize SSH manager
 	a.sshManager = ssh.NewManager()
+	a.sshManager.SetEmitter(&sshSyncEmitter{eventHub: a.eventHub})
 
 	// Initialize plugin manager
 	a.pluginManager = plugin.NewManager(cfg.ClaudeDir)
 
 	// Initialize session history manager
-	a.sessionManager = session.NewHistoryManager(cfg.ClaudeDir)
+	a.sessionManager = session.NewHistoryManagerWithIndexDir(cfg.ClaudeDir, cfg.SessionIndexDir())
 
 	// Initialize GitWatcher (EventHub already initialized above)
 	a.gitWatcher = git.NewGitWatcher(a.eventHub)
 
+	a.gitStatusWatcher = git.NewStatusWatcher(gitStatusDebounce, a.refreshGitStatus)
+
+	// Initialize project file watcher, batching changes for 250ms
+	a.fsWatcher = watcher.NewProjectWatchers(250*time.Millisecond, &fsChangeEmitter{app: a})
+
+	// Reload settings-dependent state when ~/.claude/settings.json is edited
+	a.startClaudeSettingsWatcher()
+
+	// Initialize session scheduler (concurrency limits for provider sessions)
+	a.sessionScheduler = scheduler.New(a.runningSessionCounts)
+	a.sessionScheduler.SetEmitter(&sessionQueueEmitter{eventHub: a.eventHub})
+	a.loadSessionConcurrencyLimits()
+
+	// Initialize resource monitor for provider sessions and managed processes
+	a.resourceMonitor = resource.NewMonitor(a.resourceTargets, resource.DefaultInterval)
+	a.resourceMonitor.SetEmitter(&resourceUsageEmitter{eventHub: a.eventHub})
+	a.resourceMonitor.Start(ctx)
+
+	// Check spend against the usage quota in the background
+	if a.dbManager != nil {
+		go a.runUsageQuotaChecker(ctx)
+		go a.runTelemetrySessionTracker(ctx)
+	}
+
+	// Look for new releases while update_auto_check is on
+	go a.runUpdateChecker(ctx)
+
+	// Remove pasted images the retention settings no longer keep
+	go a.runTempImagesCleanup(ctx)
+
+	// Archive the session transcripts the retention settings no longer keep
+	go a.runSessionArchival(ctx)
+
 	go func() {
 		service, err := a.getClaudeCapabilityDiscovery()
 		if err != nil {
-			log.Printf("[capability-discovery] startup prewarm init failed: %v", err)
+			slog.Warn("startup prewarm init failed", "component", "capability-discovery", "error", err)
 			return
 		}
 		ok := service.PrewarmSystem()
@@ -149,7 +255,7 @@ func (a *App) startup(ctx context.Context) {
 	go func() {
 		service, err := a.getClaudeCapabilityDiscovery()
 		if err != nil {
-			log.Printf("[capability-discovery] startup user prewarm init failed: %v", err)
+			slog.Warn("startup user prewarm init failed", "component", "capability-discovery", "error", err)
 			return
 		}
 		ok := service.PrewarmUser()
@@ -162,16 +268,40 @@ func (a *App) startup(ctx context.Context) {
 
 // shutdown is called when the app is shutting down
 func (a *App) shutdown(ctx context.Context) {
+	// Stop resource sampling before tearing down the managers it reads
+	if a.resourceMonitor != nil {
+		a.resourceMonitor.Stop()
+	}
+
 	// Close GitWatcher
 	if a.gitWatcher != nil {
 		a.gitWatcher.Close()
 	}
+	if a.gitStatusWatcher != nil {
+		a.gitStatusWatcher.Close()
+	}
+
+	// Close project file watchers
+	if a.fsWatcher != nil {
+		a.fsWatcher.Close()
+	}
+	if a.claudeSettingsWatch != nil {
+		a.claudeSettingsWatch.Close()
+	}
 
 	// Close PTY sessions
 	if a.ptyManager != nil {
 		a.ptyManager.CloseAll()
 	}
 
+	// Disconnect from remote ropcode servers; their sessions keep running
+	a.remoteServers.closeAll()
+
+	// Stop supervised MCP servers so they are not restarted
+	if a.mcpSupervisor != nil {
+		a.mcpSupervisor.StopAll()
+	}
+
 	// Kill all processes
 	if a.processManager != nil {
 		a.processManager.KillAll()
@@ -221,12 +351,24 @@ func (e *eventEmitter) Emit(eventName string, data interface{}) {
 // after flushing pending batches so order is preserved.
 type coalescedEmitter struct {
 	coalescer *eventhub.ClaudeOutputCoalescer
+	// images moves the images in claude-output frames to files
+	images *outputimages.Store
+	// onLine sees every claude-output line before it is sent
+	onLine func(line string)
 }
 
 func (e *coalescedEmitter) Emit(eventName string, data interface{}) {
 	if e.coalescer == nil {
 		return
 	}
+	if line, ok := data.(string); ok && eventName == "claude-output" {
+		if e.onLine != nil {
+			e.onLine(line)
+		}
+		if e.images != nil {
+			data = e.images.RewriteLine(line)
+		}
+	}
 	e.coalescer.Emit(eventName, data)
 }
 
@@ -325,7 +467,7 @@ func (a *App) Greet(name string) string {
 // WatchGitWorkspace 开始监听指定工作区的 Git 变化
 func (a *App) WatchGitWorkspace(workspacePath string) error {
 	if a.gitWatcher == nil {
-		return nil
+		return a.unavailable(subsystemGitWatcher)
 	}
 	return a.gitWatcher.Watch(workspacePath)
 }
@@ -337,3 +479,20 @@ func (a *App) UnwatchGitWorkspace(workspacePath string) {
 	}
 	a.gitWatcher.Unwatch(workspacePath)
 }
+
+// WatchProjectFiles 开始监听项目文件变化，变化以 fs:changed 事件推送。
+// 每次调用都需要一次对应的 UnwatchProjectFiles
+func (a *App) WatchProjectFiles(projectPath string) error {
+	if a.fsWatcher == nil {
+		return a.unavailable(subsystemFsWatcher)
+	}
+	return a.fsWatcher.Watch(projectPath)
+}
+
+// UnwatchProjectFiles 释放一次 WatchProjectFiles 的监听
+func (a *App) UnwatchProjectFiles(projectPath string) {
+	if a.fsWatcher == nil {
+		return
+	}
+	a.fsWatcher.Unwatch(projectPath)
+}
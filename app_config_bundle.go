@@ -0,0 +1,371 @@
+// app_config_bundle.go
+package main
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"ropcode/internal/database"
+)
+
+// appConfigFormatVersion is the archive format written by ExportAppConfig.
+const appConfigFormatVersion = 1
+
+const (
+	appConfigManifestFile       = "manifest.json"
+	appConfigProviderAPIsFile   = "provider_api_configs.json"
+	appConfigAgentsFile         = "agents.json"
+	appConfigModelConfigsFile   = "model_configs.json"
+	appConfigGlobalActionsFile  = "actions.json"
+	appConfigSettingsFile       = "settings.json"
+	appConfigProjectIndexesFile = "projects.json"
+)
+
+// appConfigExcludedSettings are settings that only make sense on the machine
+// that produced them and are never exported.
+var appConfigExcludedSettings = map[string]bool{
+	agentMarketplaceIndexSettingKey:  true,
+	generatedSessionTitlesSettingKey: true,
+	settingsSyncSettingKey:           true,
+}
+
+// AppConfigSummary describes the content of an app config archive
+type AppConfigSummary struct {
+	Version        int   `json:"version"`
+	ExportedAt     int64 `json:"exported_at"`
+	IncludesTokens bool  `json:"includes_tokens"`
+	ProviderAPIs   int   `json:"provider_apis"`
+	Agents         int   `json:"agents"`
+	ModelConfigs   int   `json:"model_configs"`
+	GlobalActions  int   `json:"global_actions"`
+	Settings       int   `json:"settings"`
+	ProjectIndexes int   `json:"project_indexes"`
+}
+
+// withoutRemoteServerKeys drops auth keys left in the remote_servers setting
+// by versions that did not keep them in the keychain
+func withoutRemoteServerKeys(raw string) (string, error) {
+	var servers map[string]RemoteServer
+	if err := json.Unmarshal([]byte(raw), &servers); err != nil {
+		return "", fmt.Errorf("invalid %s setting: %w", remoteServersSettingKey, err)
+	}
+	for project, server := range servers {
+		server.AuthKey = ""
+		servers[project] = server
+	}
+	data, err := json.Marshal(servers)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode remote servers: %w", err)
+	}
+	return string(data), nil
+}
+
+// appConfigBundle is the in-memory form of an app config archive
+type appConfigBundle struct {
+	Manifest       AppConfigSummary
+	ProviderAPIs   []*database.ProviderApiConfig
+	Agents         []*database.Agent
+	ModelConfigs   []*database.ModelConfig
+	GlobalActions  []Action
+	Settings       map[string]string
+	ProjectIndexes []*database.ProjectIndex
+}
+
+// collectAppConfig gathers the current configuration into a bundle.
+func (a *App) collectAppConfig(includeTokens bool) (*appConfigBundle, error) {
+	bundle := &appConfigBundle{Settings: make(map[string]string)}
+
+	providerAPIs, err := a.dbManager.GetAllProviderApiConfigs()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read provider API configs: %w", err)
+	}
+	for _, config := range providerAPIs {
+		if !includeTokens {
+			config.AuthToken = ""
+		}
+	}
+	bundle.ProviderAPIs = providerAPIs
+
+	if bundle.Agents, err = a.dbManager.ListAgents(); err != nil {
+		return nil, fmt.Errorf("failed to read agents: %w", err)
+	}
+	if bundle.ModelConfigs, err = a.dbManager.GetAllModelConfigs(); err != nil {
+		return nil, fmt.Errorf("failed to read model configs: %w", err)
+	}
+	if bundle.GlobalActions, err = a.GetGlobalActions(); err != nil {
+		return nil, fmt.Errorf("failed to read global actions: %w", err)
+	}
+
+	settings, err := a.dbManager.GetAllSettings()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read settings: %w", err)
+	}
+	for key, value := range settings {
+		if appConfigExcludedSettings[key] {
+			continue
+		}
+		if key == remoteServersSettingKey && !includeTokens {
+			if value, err = withoutRemoteServerKeys(value); err != nil {
+				return nil, err
+			}
+		}
+		bundle.Settings[key] = value
+	}
+
+	if bundle.ProjectIndexes, err = a.dbManager.GetAllProjectIndexes(); err != nil {
+		return nil, fmt.Errorf("failed to read project index: %w", err)
+	}
+
+	bundle.Manifest = AppConfigSummary{
+		Version:        appConfigFormatVersion,
+		ExportedAt:     time.Now().Unix(),
+		IncludesTokens: includeTokens,
+		ProviderAPIs:   len(bundle.ProviderAPIs),
+		Agents:         len(bundle.Agents),
+		ModelConfigs:   len(bundle.ModelConfigs),
+		GlobalActions:  len(bundle.GlobalActions),
+		Settings:       len(bundle.Settings),
+		ProjectIndexes: len(bundle.ProjectIndexes),
+	}
+	return bundle, nil
+}
+
+// writeAppConfigBundle writes bundle as a zip archive.
+func writeAppConfigBundle(w io.Writer, bundle *appConfigBundle) error {
+	zw := zip.NewWriter(w)
+	sections := []struct {
+		name  string
+		value interface{}
+	}{
+		{appConfigManifestFile, bundle.Manifest},
+		{appConfigProviderAPIsFile, bundle.ProviderAPIs},
+		{appConfigAgentsFile, bundle.Agents},
+		{appConfigModelConfigsFile, bundle.ModelConfigs},
+		{appConfigGlobalActionsFile, bundle.GlobalActions},
+		{appConfigSettingsFile, bundle.Settings},
+		{appConfigProjectIndexesFile, bundle.ProjectIndexes},
+	}
+	for _, section := range sections {
+		data, err := json.MarshalIndent(section.value, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode %s: %w", section.name, err)
+		}
+		f, err := zw.Create(section.name)
+		if err != nil {
+			return err
+		}
+		if _, err := f.Write(data); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+// readAppConfigBundle reads a zip archive written by writeAppConfigBundle.
+// Missing sections are treated as empty.
+func readAppConfigBundle(path string) (*appConfigBundle, error) {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open config archive: %w", err)
+	}
+	defer zr.Close()
+
+	bundle := &appConfigBundle{}
+	targets := map[string]interface{}{
+		appConfigManifestFile:       &bundle.Manifest,
+		appConfigProviderAPIsFile:   &bundle.ProviderAPIs,
+		appConfigAgentsFile:         &bundle.Agents,
+		appConfigModelConfigsFile:   &bundle.ModelConfigs,
+		appConfigGlobalActionsFile:  &bundle.GlobalActions,
+		appConfigSettingsFile:       &bundle.Settings,
+		appConfigProjectIndexesFile: &bundle.ProjectIndexes,
+	}
+	found := false
+	for _, file := range zr.File {
+		target, ok := targets[file.Name]
+		if !ok {
+			continue
+		}
+		rc, err := file.Open()
+		if err != nil {
+			return nil, err
+		}
+		err = json.NewDecoder(rc).Decode(target)
+		rc.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", file.Name, err)
+		}
+		if file.Name == appConfigManifestFile {
+			found = true
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("not a ropcode config archive: %s is missing", appConfigManifestFile)
+	}
+	if bundle.Manifest.Version > appConfigFormatVersion {
+		return nil, fmt.Errorf("unsupported config archive version: %d", bundle.Manifest.Version)
+	}
+	return bundle, nil
+}
+
+// applyAppConfig merges bundle into the current configuration and returns
+// what was imported.
+func (a *App) applyAppConfig(bundle *appConfigBundle) (*AppConfigSummary, error) {
+	summary := &AppConfigSummary{
+		Version:        bundle.Manifest.Version,
+		ExportedAt:     bundle.Manifest.ExportedAt,
+		IncludesTokens: bundle.Manifest.IncludesTokens,
+	}
+
+	for _, config := range bundle.ProviderAPIs {
+		if config == nil || config.ID == "" {
+			continue
+		}
+		if config.AuthToken == "" {
+			if existing, err := a.dbManager.GetProviderApiConfig(config.ID); err == nil {
+				config.AuthToken = existing.AuthToken
+			}
+		}
+		if config.IsDefault {
+			if err := a.dbManager.ClearDefaultProviderApiConfig(config.ProviderID); err != nil {
+				return nil, fmt.Errorf("failed to import provider API config %s: %w", config.Name, err)
+			}
+		}
+		if err := a.dbManager.SaveProviderApiConfig(config); err != nil {
+			return nil, fmt.Errorf("failed to import provider API config %s: %w", config.Name, err)
+		}
+		summary.ProviderAPIs++
+	}
+
+	for _, config := range bundle.ModelConfigs {
+		if config == nil || config.ID == "" {
+			continue
+		}
+		if config.IsDefault {
+			if err := a.dbManager.ClearDefaultModelConfig(config.ProviderID); err != nil {
+				return nil, fmt.Errorf("failed to import model config %s: %w", config.ModelID, err)
+			}
+		}
+		if err := a.dbManager.SaveModelConfig(config); err != nil {
+			return nil, fmt.Errorf("failed to import model config %s: %w", config.ModelID, err)
+		}
+		summary.ModelConfigs++
+	}
+
+	existingAgents, err := a.dbManager.ListAgents()
+	if err != nil {
+		return nil, err
+	}
+	agentsByName := make(map[string]*database.Agent, len(existingAgents))
+	for _, agent := range existingAgents {
+		agentsByName[agent.Name] = agent
+	}
+	for _, agent := range bundle.Agents {
+		if agent == nil || agent.Name == "" {
+			continue
+		}
+		if existing := agentsByName[agent.Name]; existing != nil {
+			agent.ID = existing.ID
+			err = a.dbManager.UpdateAgent(agent)
+		} else {
+			_, err = a.dbManager.CreateAgent(agent)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to import agent %s: %w", agent.Name, err)
+		}
+		summary.Agents++
+	}
+
+	if bundle.GlobalActions != nil {
+		if err := a.UpdateGlobalActions(bundle.GlobalActions); err != nil {
+			return nil, fmt.Errorf("failed to import global actions: %w", err)
+		}
+		summary.GlobalActions = len(bundle.GlobalActions)
+	}
+
+	keys := make([]string, 0, len(bundle.Settings))
+	for key := range bundle.Settings {
+		if !appConfigExcludedSettings[key] {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if err := a.dbManager.SaveSetting(key, bundle.Settings[key]); err != nil {
+			return nil, fmt.Errorf("failed to import setting %s: %w", key, err)
+		}
+		summary.Settings++
+	}
+
+	for _, project := range bundle.ProjectIndexes {
+		if project == nil || project.Name == "" {
+			continue
+		}
+		if err := a.dbManager.SaveProjectIndex(project); err != nil {
+			return nil, fmt.Errorf("failed to import project %s: %w", project.Name, err)
+		}
+		summary.ProjectIndexes++
+	}
+
+	a.loadSessionConcurrencyLimits()
+	return summary, nil
+}
+
+// ExportAppConfig writes provider API configs, agents, model configs, global
+// actions, settings and the project index to a single archive at path. Auth
+// tokens are only written when includeTokens is true.
+func (a *App) ExportAppConfig(path string, includeTokens bool) (*AppConfigSummary, error) {
+	if a.dbManager == nil {
+		return nil, a.unavailable(subsystemDatabase)
+	}
+	if path == "" {
+		return nil, fmt.Errorf("export path is required")
+	}
+
+	bundle, err := a.collectAppConfig(includeTokens)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	// Archives with tokens hold credentials, keep them private.
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create config archive: %w", err)
+	}
+	if err := writeAppConfigBundle(f, bundle); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to write config archive: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write config archive: %w", err)
+	}
+	return &bundle.Manifest, nil
+}
+
+// ImportAppConfig merges an archive written by ExportAppConfig into the
+// current configuration and returns what was imported. Provider API and model
+// configs are matched by ID, agents and projects by name; a provider API config
+// exported without its token keeps the token stored on this machine.
+func (a *App) ImportAppConfig(path string) (*AppConfigSummary, error) {
+	if a.dbManager == nil {
+		return nil, a.unavailable(subsystemDatabase)
+	}
+	if path == "" {
+		return nil, fmt.Errorf("import path is required")
+	}
+
+	bundle, err := readAppConfigBundle(path)
+	if err != nil {
+		return nil, err
+	}
+	return a.applyAppConfig(bundle)
+}
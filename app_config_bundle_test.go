@@ -0,0 +1,146 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"ropcode/internal/database"
+)
+
+func openAppConfigTestDB(t *testing.T) *database.Database {
+	t.Helper()
+	db, err := database.Open(filepath.Join(t.TempDir(), "ropcode.db"))
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestAppConfigExportImportRoundTrip(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	source := openAppConfigTestDB(t)
+	if err := source.SaveProviderApiConfig(&database.ProviderApiConfig{
+		ID: "team-api", Name: "Team API", ProviderID: "claude", BaseURL: "https://api.example.com", AuthToken: "secret",
+	}); err != nil {
+		t.Fatalf("SaveProviderApiConfig() error = %v", err)
+	}
+	if _, err := source.CreateAgent(&database.Agent{
+		Name: "Reviewer", Icon: "bot", SystemPrompt: "Review the diff", Model: "sonnet", ProviderApiID: "team-api",
+	}); err != nil {
+		t.Fatalf("CreateAgent() error = %v", err)
+	}
+	if err := source.SaveSetting("theme", "dark"); err != nil {
+		t.Fatalf("SaveSetting() error = %v", err)
+	}
+	if err := source.SaveSetting(generatedSessionTitlesSettingKey, `{"claude:s1":"title"}`); err != nil {
+		t.Fatalf("SaveSetting() error = %v", err)
+	}
+	if err := source.SaveProjectIndex(&database.ProjectIndex{Name: "ropcode", Available: true}); err != nil {
+		t.Fatalf("SaveProjectIndex() error = %v", err)
+	}
+
+	app := &App{dbManager: source}
+	if err := app.UpdateGlobalActions([]Action{{ID: "test", Name: "Test", Command: "go test ./..."}}); err != nil {
+		t.Fatalf("UpdateGlobalActions() error = %v", err)
+	}
+
+	archive := filepath.Join(t.TempDir(), "ropcode-config.zip")
+	exported, err := app.ExportAppConfig(archive, false)
+	if err != nil {
+		t.Fatalf("ExportAppConfig() error = %v", err)
+	}
+	if exported.IncludesTokens || exported.ProviderAPIs != 1 || exported.Agents != 1 || exported.GlobalActions != 1 {
+		t.Fatalf("export summary = %#v", exported)
+	}
+
+	target := openAppConfigTestDB(t)
+	if err := target.SaveProviderApiConfig(&database.ProviderApiConfig{
+		ID: "team-api", Name: "Old name", ProviderID: "claude", AuthToken: "local-token",
+	}); err != nil {
+		t.Fatalf("SaveProviderApiConfig() error = %v", err)
+	}
+	if _, err := target.CreateAgent(&database.Agent{Name: "Reviewer", SystemPrompt: "old prompt"}); err != nil {
+		t.Fatalf("CreateAgent() error = %v", err)
+	}
+
+	imported, err := (&App{dbManager: target}).ImportAppConfig(archive)
+	if err != nil {
+		t.Fatalf("ImportAppConfig() error = %v", err)
+	}
+	if imported.Agents != 1 || imported.ProjectIndexes != 1 || imported.Settings != 1 {
+		t.Fatalf("import summary = %#v", imported)
+	}
+
+	config, err := target.GetProviderApiConfig("team-api")
+	if err != nil {
+		t.Fatalf("GetProviderApiConfig() error = %v", err)
+	}
+	if config.Name != "Team API" || config.AuthToken != "local-token" {
+		t.Fatalf("provider config = %#v, want imported name and local token kept", config)
+	}
+
+	agents, err := target.ListAgents()
+	if err != nil {
+		t.Fatalf("ListAgents() error = %v", err)
+	}
+	if len(agents) != 1 || agents[0].SystemPrompt != "Review the diff" || agents[0].ProviderApiID != "team-api" {
+		t.Fatalf("agents = %#v, want existing agent updated", agents)
+	}
+
+	if value, _ := target.GetSetting("theme"); value != "dark" {
+		t.Fatalf("theme setting = %q, want dark", value)
+	}
+	if value, _ := target.GetSetting(generatedSessionTitlesSettingKey); value != "" {
+		t.Fatalf("generated session titles should not be exported, got %q", value)
+	}
+	if _, err := target.GetProjectIndex("ropcode"); err != nil {
+		t.Fatalf("GetProjectIndex() error = %v", err)
+	}
+}
+
+func TestAppConfigExportCanIncludeTokens(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	db := openAppConfigTestDB(t)
+	if err := db.SaveProviderApiConfig(&database.ProviderApiConfig{ID: "api", Name: "API", ProviderID: "claude", AuthToken: "secret"}); err != nil {
+		t.Fatalf("SaveProviderApiConfig() error = %v", err)
+	}
+
+	archive := filepath.Join(t.TempDir(), "config.zip")
+	if _, err := (&App{dbManager: db}).ExportAppConfig(archive, true); err != nil {
+		t.Fatalf("ExportAppConfig() error = %v", err)
+	}
+	bundle, err := readAppConfigBundle(archive)
+	if err != nil {
+		t.Fatalf("readAppConfigBundle() error = %v", err)
+	}
+	if !bundle.Manifest.IncludesTokens || len(bundle.ProviderAPIs) != 1 || bundle.ProviderAPIs[0].AuthToken != "secret" {
+		t.Fatalf("bundle = %#v, want token included", bundle)
+	}
+}
+
+func TestAppConfigExportLeavesOutRemoteServerKeys(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	db := openAppConfigTestDB(t)
+	legacy := `{"/work/app":{"url":"ws://box:8080/ws","auth_key":"s3cr3tkey"}}`
+	if err := db.SaveSetting(remoteServersSettingKey, legacy); err != nil {
+		t.Fatalf("SaveSetting() error = %v", err)
+	}
+
+	archive := filepath.Join(t.TempDir(), "config.zip")
+	if _, err := (&App{dbManager: db}).ExportAppConfig(archive, false); err != nil {
+		t.Fatalf("ExportAppConfig() error = %v", err)
+	}
+	bundle, err := readAppConfigBundle(archive)
+	if err != nil {
+		t.Fatalf("readAppConfigBundle() error = %v", err)
+	}
+	value := bundle.Settings[remoteServersSettingKey]
+	if !strings.Contains(value, "ws://box:8080/ws") || strings.Contains(value, "s3cr3tkey") {
+		t.Fatalf("remote servers setting = %q, want the server without its auth key", value)
+	}
+}
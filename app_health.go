@@ -0,0 +1,132 @@
+// app_health.go
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Subsystems that bindings depend on
+const (
+	subsystemConfig          = "config"
+	subsystemDatabase        = "database"
+	subsystemModels          = "models"
+	subsystemClaude          = "claude"
+	subsystemCodex           = "codex"
+	subsystemGemini          = "gemini"
+	subsystemMCP             = "mcp"
+	subsystemSSH             = "ssh"
+	subsystemPlugins         = "plugins"
+	subsystemSessionHistory  = "session_history"
+	subsystemProcesses       = "processes"
+	subsystemTerminals       = "terminals"
+	subsystemScheduler       = "scheduler"
+	subsystemGitWatcher      = "git_watcher"
+	subsystemFsWatcher       = "fs_watcher"
+	subsystemResourceMonitor = "resource_monitor"
+)
+
+// ErrSubsystemUnavailable matches every SubsystemUnavailableError
+var ErrSubsystemUnavailable = errors.New("subsystem unavailable")
+
+// SubsystemUnavailableError is returned by bindings whose subsystem failed
+// to initialize. Startup carries on without it, so the rest of the app stays
+// usable.
+type SubsystemUnavailableError struct {
+	Subsystem string
+	// Cause is why initialization failed, nil when unknown
+	Cause error
+}
+
+func (e *SubsystemUnavailableError) Error() string {
+	if e.Cause == nil {
+		return fmt.Sprintf("%s unavailable: not initialized", e.Subsystem)
+	}
+	return fmt.Sprintf("%s unavailable: %v", e.Subsystem, e.Cause)
+}
+
+func (e *SubsystemUnavailableError) Is(target error) bool {
+	return target == ErrSubsystemUnavailable
+}
+
+func (e *SubsystemUnavailableError) Unwrap() error {
+	return e.Cause
+}
+
+// SubsystemHealth is the state of one subsystem
+type SubsystemHealth struct {
+	Name      string `json:"name"`
+	Available bool   `json:"available"`
+	Error     string `json:"error,omitempty"`
+}
+
+// AppHealth is the result of GetAppHealth
+type AppHealth struct {
+	// OK is true when every subsystem is available
+	OK         bool               `json:"ok"`
+	Subsystems []*SubsystemHealth `json:"subsystems"`
+}
+
+// GetAppHealth reports which subsystems initialized
+func (a *App) GetAppHealth() *AppHealth {
+	health := &AppHealth{OK: true}
+	for _, s := range []struct {
+		name      string
+		available bool
+	}{
+		{subsystemConfig, a.config != nil},
+		{subsystemDatabase, a.dbManager != nil},
+		{subsystemModels, a.modelRegistry != nil},
+		{subsystemClaude, a.claudeManager != nil},
+		{subsystemCodex, a.codexManager != nil},
+		{subsystemGemini, a.geminiManager != nil},
+		{subsystemMCP, a.mcpManager != nil},
+		{subsystemSSH, a.sshManager != nil},
+		{subsystemPlugins, a.pluginManager != nil},
+		{subsystemSessionHistory, a.sessionManager != nil},
+		{subsystemProcesses, a.processManager != nil},
+		{subsystemTerminals, a.ptyManager != nil},
+		{subsystemScheduler, a.sessionScheduler != nil},
+		{subsystemGitWatcher, a.gitWatcher != nil && a.gitStatusWatcher != nil},
+		{subsystemFsWatcher, a.fsWatcher != nil},
+		{subsystemResourceMonitor, a.resourceMonitor != nil},
+	} {
+		entry := &SubsystemHealth{Name: s.name, Available: s.available}
+		if !s.available {
+			health.OK = false
+			entry.Error = a.unavailable(s.name).Error()
+		}
+		health.Subsystems = append(health.Subsystems, entry)
+	}
+	return health
+}
+
+// setInitError records why a subsystem failed to initialize
+func (a *App) setInitError(subsystem string, err error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.initErrors == nil {
+		a.initErrors = make(map[string]error)
+	}
+	a.initErrors[subsystem] = err
+}
+
+// unavailable returns the error for a subsystem that is not initialized.
+// Startup stops when the config fails to load, so that failure is the cause
+// of any subsystem without its own; the model registry needs the database.
+func (a *App) unavailable(subsystem string) error {
+	a.mu.RLock()
+	cause, ok := a.initErrors[subsystem]
+	if !ok && subsystem == subsystemModels {
+		if dbErr := a.initErrors[subsystemDatabase]; dbErr != nil {
+			cause, ok = fmt.Errorf("database failed to open: %w", dbErr), true
+		}
+	}
+	if !ok && subsystem != subsystemConfig {
+		if configErr := a.initErrors[subsystemConfig]; configErr != nil {
+			cause = fmt.Errorf("config failed to load: %w", configErr)
+		}
+	}
+	a.mu.RUnlock()
+	return &SubsystemUnavailableError{Subsystem: subsystem, Cause: cause}
+}
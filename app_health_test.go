@@ -0,0 +1,54 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"ropcode/internal/database"
+)
+
+func TestBindingsReportUnavailableSubsystems(t *testing.T) {
+	app := &App{}
+	app.setInitError(subsystemDatabase, errors.New("disk I/O error"))
+
+	err := app.SaveProviderApiConfig(&database.ProviderApiConfig{ID: "team"})
+	if !errors.Is(err, ErrSubsystemUnavailable) {
+		t.Fatalf("SaveProviderApiConfig() = %v, want ErrSubsystemUnavailable", err)
+	}
+	if !strings.Contains(err.Error(), "database unavailable: disk I/O error") {
+		t.Errorf("Expected the startup failure in the error, got %q", err)
+	}
+	if _, err := app.GetSetting("theme_preference"); !errors.Is(err, ErrSubsystemUnavailable) {
+		t.Errorf("GetSetting() = %v, want ErrSubsystemUnavailable", err)
+	}
+	if _, err := app.GetAllModelConfigs(); err == nil || !strings.Contains(err.Error(), "models unavailable: database failed to open") {
+		t.Errorf("Expected the model registry to blame the database, got %v", err)
+	}
+	var unavailable *SubsystemUnavailableError
+	if err := app.CancelClaudeExecution("s1"); !errors.As(err, &unavailable) || unavailable.Subsystem != subsystemClaude {
+		t.Errorf("CancelClaudeExecution() = %v, want the claude subsystem unavailable", err)
+	}
+}
+
+func TestGetAppHealth(t *testing.T) {
+	app := &App{dbManager: openAppConfigTestDB(t)}
+	app.setInitError(subsystemConfig, errors.New("no home directory"))
+
+	health := app.GetAppHealth()
+	if health.OK {
+		t.Error("Expected a partly initialized app not to be healthy")
+	}
+	for _, subsystem := range health.Subsystems {
+		switch subsystem.Name {
+		case subsystemDatabase:
+			if !subsystem.Available || subsystem.Error != "" {
+				t.Errorf("database = %+v, want available", subsystem)
+			}
+		case subsystemSSH:
+			if subsystem.Available || !strings.Contains(subsystem.Error, "config failed to load: no home directory") {
+				t.Errorf("ssh = %+v, want unavailable because of the config", subsystem)
+			}
+		}
+	}
+}
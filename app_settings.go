@@ -0,0 +1,65 @@
+// app_settings.go
+package main
+
+import (
+	"log"
+
+	"ropcode/internal/logging"
+	"ropcode/internal/settings"
+)
+
+// GetSettings returns every known setting, defaults filled in
+func (a *App) GetSettings() (map[string]interface{}, error) {
+	if a.dbManager == nil {
+		return nil, a.unavailable(subsystemDatabase)
+	}
+	return settings.NewService(a.dbManager).GetAll()
+}
+
+// UpdateSettings validates and saves the given settings and returns the
+// ones that changed; a null value resets a setting to its default
+func (a *App) UpdateSettings(values map[string]interface{}) (map[string]interface{}, error) {
+	if a.dbManager == nil {
+		return nil, a.unavailable(subsystemDatabase)
+	}
+	a.settingsMu.Lock()
+	defer a.settingsMu.Unlock()
+	changed, err := settings.NewService(a.dbManager).Update(values)
+	if err != nil {
+		return nil, err
+	}
+	a.settingsChanged(changed)
+	return changed, nil
+}
+
+// GetSettingsSchema describes the known settings
+func (a *App) GetSettingsSchema() []*settings.Field {
+	return settings.Schema()
+}
+
+// settingsChanged applies the settings that take effect at once and
+// broadcasts the change as "settings:changed", so panels showing the same
+// setting stay in sync
+func (a *App) settingsChanged(changed map[string]interface{}) {
+	if level, ok := changed[logLevelSettingKey].(string); ok {
+		if err := logging.SetLevel(level); err != nil {
+			log.Printf("[settings] %v", err)
+		}
+	}
+	if enabled, ok := changed[telemetryEnabledSettingKey].(bool); ok {
+		a.telemetryToggled(enabled)
+	}
+	if value, ok := changed[proxySettingsKey]; ok {
+		a.proxySettingsChanged(value)
+	}
+	if _, ok := changed[shellPreferenceSettingKey]; ok {
+		go a.ReloadEnvironment()
+	}
+	a.providerBinaryPathsChanged(changed)
+	if _, ok := changed[wslDistroSettingKey]; ok {
+		a.applyWSLDistro()
+	}
+	if a.eventHub != nil && len(changed) > 0 {
+		a.eventHub.Emit("settings:changed", changed)
+	}
+}
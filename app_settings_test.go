@@ -0,0 +1,50 @@
+package main
+
+import (
+	"testing"
+
+	"ropcode/internal/eventhub"
+)
+
+type settingsChangedRecorder struct {
+	events []map[string]interface{}
+}
+
+func (r *settingsChangedRecorder) BroadcastEvent(eventType string, payload interface{}) {
+	if eventType == "settings:changed" {
+		r.events = append(r.events, payload.(map[string]interface{}))
+	}
+}
+
+func TestUpdateSettingsEmitsChanges(t *testing.T) {
+	hub := eventhub.New(nil)
+	recorder := &settingsChangedRecorder{}
+	hub.SetBroadcaster(recorder)
+	app := &App{dbManager: openAppConfigTestDB(t), eventHub: hub}
+
+	changed, err := app.UpdateSettings(map[string]interface{}{"theme_preference": "dark"})
+	if err != nil || changed["theme_preference"] != "dark" {
+		t.Fatalf("UpdateSettings() = %v, %v", changed, err)
+	}
+	if raw, _ := app.GetSetting("theme_preference"); raw != "dark" {
+		t.Errorf("Expected the raw setting to be %q, got %q", "dark", raw)
+	}
+	if _, err := app.UpdateSettings(map[string]interface{}{"theme_preference": "dark"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := app.SaveSetting("startup_intro_enabled", "false"); err != nil {
+		t.Fatal(err)
+	}
+	if err := app.SaveSetting("unrelated_key", "x"); err != nil {
+		t.Fatal(err)
+	}
+	if events := recorder.events; len(events) != 2 || events[1]["startup_intro_enabled"] != false {
+		t.Errorf("Expected one event per change to a known setting, got %v", events)
+	}
+
+	values, err := app.GetSettings()
+	if err != nil || values["theme_preference"] != "dark" || values["startup_intro_enabled"] != false {
+		t.Errorf("GetSettings() = %v, %v", values, err)
+	}
+}
@@ -0,0 +1,56 @@
+// attachments.go
+package main
+
+import (
+	"os"
+	"path/filepath"
+
+	"ropcode/internal/attachments"
+)
+
+// attachmentsDir is where attachments are stored, ~/.ropcode/attachments
+func (a *App) attachmentsDir() (string, error) {
+	if a.config != nil {
+		return filepath.Join(a.config.RopcodeDir, "attachments"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".ropcode", "attachments"), nil
+}
+
+// AttachmentResult is a stored attachment and the text that refers to it
+// in a prompt
+type AttachmentResult struct {
+	*attachments.Attachment
+	Reference string `json:"reference"`
+}
+
+// AddAttachment copies the local file at sourcePath into the attachments of
+// sessionID (empty before the session has one) and returns the reference
+// to put in a prompt for provider
+func (a *App) AddAttachment(sessionID, provider, sourcePath string) (*AttachmentResult, error) {
+	root, err := a.attachmentsDir()
+	if err != nil {
+		return nil, err
+	}
+	attachment, err := attachments.Copy(attachments.SessionDir(root, sessionID), sourcePath)
+	if err != nil {
+		return nil, err
+	}
+	return &AttachmentResult{
+		Attachment: attachment,
+		Reference:  attachments.Reference(provider, attachment.Path, attachment.Kind),
+	}, nil
+}
+
+// GetAttachmentReference returns the text that refers to the file at path
+// in a prompt for provider, for files stored by an upload
+func (a *App) GetAttachmentReference(provider, path string) (string, error) {
+	kind, _, ok := attachments.Classify(path)
+	if !ok {
+		return "", attachments.ErrUnsupported
+	}
+	return attachments.Reference(provider, path, kind), nil
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"ropcode/internal/config"
+)
+
+func TestAddAttachment(t *testing.T) {
+	home := t.TempDir()
+	app := &App{config: &config.Config{RopcodeDir: filepath.Join(home, ".ropcode")}}
+
+	src := filepath.Join(home, "my notes.md")
+	if err := os.WriteFile(src, []byte("# notes"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	result, err := app.AddAttachment("session-1", "claude", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Dir(result.Path) != filepath.Join(home, ".ropcode", "attachments", "session-1") {
+		t.Errorf("stored at %s", result.Path)
+	}
+	if result.Kind != "text" || result.Reference != "@"+result.Path {
+		t.Errorf("AddAttachment() = %+v", result)
+	}
+	if data, _ := os.ReadFile(result.Path); string(data) != "# notes" {
+		t.Errorf("copy holds %q", data)
+	}
+
+	if _, err := app.AddAttachment("", "claude", filepath.Join(home, "missing.pdf")); err == nil {
+		t.Error("AddAttachment() accepted a missing file")
+	}
+
+	if ref, err := app.GetAttachmentReference("codex", "/tmp/a.pdf"); err != nil || ref != "[attached pdf: /tmp/a.pdf]" {
+		t.Errorf("GetAttachmentReference() = %q, %v", ref, err)
+	}
+}
@@ -0,0 +1,166 @@
+// attention.go
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+
+	"ropcode/internal/notify"
+)
+
+const attentionSettingsKey = "attention_settings"
+
+// Attention events that can play a sound
+const (
+	attentionSessionComplete = "session_complete"
+	attentionAgentRunFailed  = "agent_run_failed"
+	attentionCommandFinished = "command_finished"
+	attentionUsageQuota      = "usage_quota"
+)
+
+var attentionEvents = []string{attentionSessionComplete, attentionAgentRunFailed, attentionCommandFinished, attentionUsageQuota}
+
+// playAttentionSound plays a sound; tests replace it
+var playAttentionSound = notify.PlaySound
+
+// AttentionSettings configures the attention indicators
+type AttentionSettings struct {
+	// Badge shows the count of sessions awaiting review on the app icon
+	Badge bool `json:"badge"`
+	// Sounds maps an event to "default", a system sound name or the path of
+	// a sound file; a missing or empty entry is silent
+	Sounds map[string]string `json:"sounds"`
+}
+
+// AttentionChangedEvent is the payload of "attention:changed"
+type AttentionChangedEvent struct {
+	Count int  `json:"count"`
+	Badge bool `json:"badge"`
+}
+
+func defaultAttentionSettings() *AttentionSettings {
+	return &AttentionSettings{
+		Badge: true,
+		Sounds: map[string]string{
+			attentionSessionComplete: notify.DefaultSound,
+			attentionAgentRunFailed:  notify.DefaultSound,
+		},
+	}
+}
+
+// GetAttentionSettings returns the attention settings
+func (a *App) GetAttentionSettings() (*AttentionSettings, error) {
+	settings := defaultAttentionSettings()
+	if a.dbManager == nil {
+		return settings, nil
+	}
+	raw, err := a.dbManager.GetSetting(attentionSettingsKey)
+	if err != nil {
+		return nil, err
+	}
+	if raw != "" {
+		settings.Sounds = nil
+		if err := json.Unmarshal([]byte(raw), settings); err != nil {
+			return nil, fmt.Errorf("invalid %s setting: %w", attentionSettingsKey, err)
+		}
+	}
+	if settings.Sounds == nil {
+		settings.Sounds = map[string]string{}
+	}
+	return settings, nil
+}
+
+// SetAttentionSettings saves the attention settings and redraws the badge
+func (a *App) SetAttentionSettings(settings *AttentionSettings) error {
+	if a.dbManager == nil {
+		return a.unavailable(subsystemDatabase)
+	}
+	if settings == nil {
+		return fmt.Errorf("settings are required")
+	}
+	for event := range settings.Sounds {
+		if !isAttentionEvent(event) {
+			return fmt.Errorf("unknown attention event %q", event)
+		}
+	}
+	data, err := json.Marshal(settings)
+	if err != nil {
+		return fmt.Errorf("failed to encode attention settings: %w", err)
+	}
+	if err := a.dbManager.SaveSetting(attentionSettingsKey, string(data)); err != nil {
+		return fmt.Errorf("failed to save attention settings: %w", err)
+	}
+	a.emitAttentionChanged(a.GetAttentionCount(), settings.Badge)
+	return nil
+}
+
+// SetAttentionCount sets the number of sessions awaiting review, counted by
+// the frontend, which knows the focused tab. The count is broadcast so every
+// window of an instance draws the same badge.
+func (a *App) SetAttentionCount(count int) error {
+	if count < 0 {
+		return fmt.Errorf("count cannot be negative")
+	}
+	a.mu.Lock()
+	changed := a.attentionCount != count
+	a.attentionCount = count
+	a.mu.Unlock()
+	if changed {
+		a.emitAttentionChanged(count, a.attentionSettings().Badge)
+	}
+	return nil
+}
+
+// GetAttentionCount returns the number of sessions awaiting review
+func (a *App) GetAttentionCount() int {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.attentionCount
+}
+
+// PreviewAttentionSound plays a sound as configured for an event would
+func (a *App) PreviewAttentionSound(sound string) error {
+	if sound == "" {
+		return fmt.Errorf("sound is required")
+	}
+	return playAttentionSound(sound)
+}
+
+func (a *App) emitAttentionChanged(count int, badge bool) {
+	if a.eventHub != nil {
+		a.eventHub.Emit("attention:changed", AttentionChangedEvent{Count: count, Badge: badge})
+	}
+}
+
+// attentionSettings returns the settings in effect, the defaults when they
+// cannot be read
+func (a *App) attentionSettings() *AttentionSettings {
+	settings, err := a.GetAttentionSettings()
+	if err != nil {
+		log.Printf("[attention] %v", err)
+		return defaultAttentionSettings()
+	}
+	return settings
+}
+
+// playEventSound plays the sound configured for an attention event
+func (a *App) playEventSound(event string) {
+	sound := a.attentionSettings().Sounds[event]
+	if sound == "" {
+		return
+	}
+	if err := playAttentionSound(sound); err != nil && !errors.Is(err, notify.ErrUnavailable) {
+		log.Printf("[attention] %v", err)
+	}
+}
+
+func isAttentionEvent(event string) bool {
+	for _, known := range attentionEvents {
+		if event == known {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestAttentionSettingsAndSounds(t *testing.T) {
+	var played []string
+	previous := playAttentionSound
+	playAttentionSound = func(sound string) error {
+		played = append(played, sound)
+		return nil
+	}
+	t.Cleanup(func() { playAttentionSound = previous })
+	app := &App{dbManager: openAppConfigTestDB(t)}
+
+	settings, err := app.GetAttentionSettings()
+	if err != nil || !settings.Badge || settings.Sounds[attentionSessionComplete] != "default" {
+		t.Fatalf("GetAttentionSettings() = %+v, %v, want defaults", settings, err)
+	}
+
+	app.playEventSound(attentionSessionComplete)
+	app.playEventSound(attentionCommandFinished)
+	if len(played) != 1 || played[0] != "default" {
+		t.Fatalf("Expected only the session sound by default, played %q", played)
+	}
+
+	if err := app.SetAttentionSettings(&AttentionSettings{Sounds: map[string]string{attentionCommandFinished: "/sounds/done.wav"}}); err != nil {
+		t.Fatal(err)
+	}
+	app.playEventSound(attentionSessionComplete)
+	app.playEventSound(attentionCommandFinished)
+	if len(played) != 2 || played[1] != "/sounds/done.wav" {
+		t.Errorf("Expected the saved sounds to replace the defaults, played %q", played)
+	}
+
+	if err := app.SetAttentionSettings(&AttentionSettings{Sounds: map[string]string{"build": "default"}}); err == nil {
+		t.Error("Expected an unknown event to be rejected")
+	}
+}
+
+func TestSetAttentionCount(t *testing.T) {
+	app := &App{}
+	if err := app.SetAttentionCount(3); err != nil || app.GetAttentionCount() != 3 {
+		t.Errorf("SetAttentionCount(3) = %v, count %d", err, app.GetAttentionCount())
+	}
+	if err := app.SetAttentionCount(-1); err == nil || app.GetAttentionCount() != 3 {
+		t.Error("Expected a negative count to be rejected")
+	}
+}
@@ -2,6 +2,7 @@
 package main
 
 import (
+	"context"
 	"crypto/sha256"
 	"database/sql"
 	"encoding/base64"
@@ -11,11 +12,14 @@ import (
 	"io"
 	"io/fs"
 	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"reflect"
+	"regexp"
+	"slices"
 	"sort"
 	"strings"
 	"sync"
@@ -27,15 +31,22 @@ import (
 	"ropcode/internal/codex"
 	"ropcode/internal/command"
 	"ropcode/internal/database"
+	"ropcode/internal/fileops"
+	"ropcode/internal/filetree"
 	"ropcode/internal/gemini"
 	"ropcode/internal/git"
 	"ropcode/internal/gitcontent"
 	"ropcode/internal/github"
+	"ropcode/internal/imageconv"
+	"ropcode/internal/jobs"
 	"ropcode/internal/mcp"
 	"ropcode/internal/openin"
 	"ropcode/internal/pathutil"
 	"ropcode/internal/plugin"
+	"ropcode/internal/settings"
+	"ropcode/internal/slashexpand"
 	"ropcode/internal/ssh"
+	"ropcode/internal/textfile"
 	"ropcode/internal/usage"
 )
 
@@ -60,6 +71,7 @@ type claudeCapabilityDiscovery interface {
 	PrewarmSystem() bool
 	PrewarmUser() bool
 	PrewarmProject(projectPath string) bool
+	InvalidateUser()
 }
 
 var defaultClaudeCapabilityDiscovery = struct {
@@ -162,6 +174,57 @@ func (a *App) providerSessionConfig(provider, projectPath, sessionID string) liv
 	}
 }
 
+// providerSessionManager is the subset of the claude/gemini/codex session
+// managers needed to address a session once it has been started.
+type providerSessionManager interface {
+	IsRunning(sessionID string) bool
+	TerminateSession(sessionID string) error
+	GetSessionOutput(sessionID string) (string, error)
+	WaitForSession(ctx context.Context, sessionID string) (string, error)
+}
+
+// sessionManagerFor returns the session manager that runs provider, or nil
+// when that manager is not initialized.
+func (a *App) sessionManagerFor(provider string) providerSessionManager {
+	switch normalizeSessionProvider(provider) {
+	case "gemini":
+		if a.geminiManager != nil {
+			return a.geminiManager
+		}
+	case "codex":
+		if a.codexManager != nil {
+			return a.codexManager
+		}
+	default:
+		if a.claudeManager != nil {
+			return a.claudeManager
+		}
+	}
+	return nil
+}
+
+// providerSessionPID returns the PID of a running provider session, or 0.
+func (a *App) providerSessionPID(sessionID string) int {
+	for _, session := range a.ListRunningProviderSessions() {
+		if session.SessionID == sessionID {
+			return session.PID
+		}
+	}
+	return 0
+}
+
+// validateAgentProvider normalizes an agent provider, defaulting to Claude.
+func validateAgentProvider(provider string) (string, error) {
+	switch provider {
+	case "":
+		return database.DefaultAgentProvider, nil
+	case "claude", "gemini", "codex":
+		return provider, nil
+	default:
+		return "", fmt.Errorf("unsupported agent provider: %s", provider)
+	}
+}
+
 // ===== PTY Bindings =====
 
 // PtySessionInfo contains information about a PTY session
@@ -173,9 +236,13 @@ type PtySessionInfo struct {
 	Cols      int    `json:"cols"`
 }
 
-// CreatePtySession creates a new PTY terminal session
+// CreatePtySession creates a new PTY terminal session running shell, or
+// the preferred shell when shell is empty
 func (a *App) CreatePtySession(sessionID string, cwd string, rows, cols int, shell string) (*PtySessionInfo, error) {
-	session, err := a.ptyManager.CreateSession(sessionID, cwd, rows, cols, shell)
+	if info, ok, err := a.proxyCreatePtySession(sessionID, cwd, rows, cols, shell); ok {
+		return info, err
+	}
+	session, err := a.createPreferredPtySession(sessionID, cwd, rows, cols, shell)
 	if err != nil {
 		return nil, err
 	}
@@ -191,16 +258,25 @@ func (a *App) CreatePtySession(sessionID string, cwd string, rows, cols int, she
 
 // WriteToPty writes data to a PTY session
 func (a *App) WriteToPty(sessionID, data string) error {
+	if ok, err := a.proxyPtyCall("WriteToPty", sessionID, data); ok {
+		return err
+	}
 	return a.ptyManager.Write(sessionID, data)
 }
 
 // ResizePty resizes a PTY session terminal
 func (a *App) ResizePty(sessionID string, rows, cols int) error {
+	if ok, err := a.proxyPtyCall("ResizePty", sessionID, rows, cols); ok {
+		return err
+	}
 	return a.ptyManager.Resize(sessionID, rows, cols)
 }
 
 // ClosePtySession closes a PTY session
 func (a *App) ClosePtySession(sessionID string) error {
+	if ok, err := a.proxyPtyCall("ClosePtySession", sessionID); ok {
+		return err
+	}
 	return a.ptyManager.CloseSession(sessionID)
 }
 
@@ -252,7 +328,7 @@ func (a *App) ListProcesses() []string {
 // SaveProviderApiConfig saves a provider API configuration
 func (a *App) SaveProviderApiConfig(config *database.ProviderApiConfig) error {
 	if a.dbManager == nil {
-		return fmt.Errorf("database manager not initialized")
+		return a.unavailable(subsystemDatabase)
 	}
 	if err := a.dbManager.SaveProviderApiConfig(config); err != nil {
 		return fmt.Errorf("failed to save provider API config: %w", err)
@@ -263,7 +339,7 @@ func (a *App) SaveProviderApiConfig(config *database.ProviderApiConfig) error {
 // GetProviderApiConfig retrieves a provider API configuration
 func (a *App) GetProviderApiConfig(id string) (*database.ProviderApiConfig, error) {
 	if a.dbManager == nil {
-		return nil, nil
+		return nil, a.unavailable(subsystemDatabase)
 	}
 	return a.dbManager.GetProviderApiConfig(id)
 }
@@ -271,7 +347,7 @@ func (a *App) GetProviderApiConfig(id string) (*database.ProviderApiConfig, erro
 // GetAllProviderApiConfigs retrieves all provider API configurations
 func (a *App) GetAllProviderApiConfigs() ([]*database.ProviderApiConfig, error) {
 	if a.dbManager == nil {
-		return []*database.ProviderApiConfig{}, nil // Return empty array instead of nil
+		return []*database.ProviderApiConfig{}, a.unavailable(subsystemDatabase)
 	}
 	configs, err := a.dbManager.GetAllProviderApiConfigs()
 	if err != nil {
@@ -286,23 +362,36 @@ func (a *App) GetAllProviderApiConfigs() ([]*database.ProviderApiConfig, error)
 // DeleteProviderApiConfig deletes a provider API configuration
 func (a *App) DeleteProviderApiConfig(id string) error {
 	if a.dbManager == nil {
-		return nil
+		return a.unavailable(subsystemDatabase)
 	}
 	return a.dbManager.DeleteProviderApiConfig(id)
 }
 
-// SaveSetting saves a setting
+// SaveSetting saves a setting; known settings broadcast the change
 func (a *App) SaveSetting(key, value string) error {
 	if a.dbManager == nil {
-		return nil
+		return a.unavailable(subsystemDatabase)
+	}
+	field := settings.Lookup(key)
+	if field == nil {
+		return a.dbManager.SaveSetting(key, value)
 	}
-	return a.dbManager.SaveSetting(key, value)
+	a.settingsMu.Lock()
+	defer a.settingsMu.Unlock()
+	previous, _ := a.dbManager.GetSetting(key)
+	if err := a.dbManager.SaveSetting(key, value); err != nil {
+		return err
+	}
+	if previous != value {
+		a.settingsChanged(map[string]interface{}{key: field.Decode(value)})
+	}
+	return nil
 }
 
 // GetSetting retrieves a setting
 func (a *App) GetSetting(key string) (string, error) {
 	if a.dbManager == nil {
-		return "", nil
+		return "", a.unavailable(subsystemDatabase)
 	}
 	return a.dbManager.GetSetting(key)
 }
@@ -310,7 +399,7 @@ func (a *App) GetSetting(key string) (string, error) {
 // CreateProviderApiConfig creates a new provider API configuration
 func (a *App) CreateProviderApiConfig(config *database.ProviderApiConfig) error {
 	if a.dbManager == nil {
-		return fmt.Errorf("database manager not initialized")
+		return a.unavailable(subsystemDatabase)
 	}
 
 	// Generate new UUID for the config
@@ -333,7 +422,7 @@ func (a *App) CreateProviderApiConfig(config *database.ProviderApiConfig) error
 // GetProjectProviderApiConfig retrieves provider API config for a project
 func (a *App) GetProjectProviderApiConfig(projectPath, providerName string) (*database.ProviderApiConfig, error) {
 	if a.dbManager == nil {
-		return nil, nil
+		return nil, a.unavailable(subsystemDatabase)
 	}
 
 	// Get project index
@@ -362,146 +451,105 @@ func (a *App) GetProjectProviderApiConfig(projectPath, providerName string) (*da
 // SetProjectProviderApiConfig sets the provider API config ID for a project or workspace
 func (a *App) SetProjectProviderApiConfig(projectPath, providerName, configId string) error {
 	if a.dbManager == nil {
-		return nil
+		return a.unavailable(subsystemDatabase)
 	}
 
-	// Get project index - try direct lookup first
+	// Try the project first, then the workspace
 	name := filepath.Base(projectPath)
-	project, err := a.dbManager.GetProjectIndex(name)
-	if err != nil {
-		// If not found, try to find the project that contains this workspace
-		projects, err2 := a.dbManager.GetAllProjectIndexes()
-		if err2 != nil {
-			return err2
-		}
-
-		workspaceName := name
-		for _, p := range projects {
-			for i, workspace := range p.Workspaces {
-				if workspace.Name == workspaceName {
-					// Found workspace, update its provider info
-					providerFound := false
-					for j, provider := range p.Workspaces[i].Providers {
-						if provider.ProviderID == providerName {
-							p.Workspaces[i].Providers[j].ProviderApiID = configId
-							providerFound = true
-							break
-						}
-					}
-					if !providerFound {
-						// Add new provider info to workspace
-						p.Workspaces[i].Providers = append(p.Workspaces[i].Providers, database.ProviderInfo{
-							ID:            workspaceName,
-							ProviderID:    providerName,
-							Path:          projectPath,
-							ProviderApiID: configId,
-						})
-					}
-					return a.dbManager.SaveProjectIndex(p)
-				}
-			}
-		}
+	_, err := a.dbManager.PatchProjectIndex(name, func(project *database.ProjectIndex) error {
+		setProviderApiConfig(&project.Providers, name, providerName, projectPath, configId)
+		return nil
+	})
+	if err == nil {
+		return nil
+	}
+	_, wsErr := a.dbManager.PatchWorkspaceIndex(name, func(_ *database.ProjectIndex, workspace *database.WorkspaceIndex) error {
+		setProviderApiConfig(&workspace.Providers, name, providerName, projectPath, configId)
+		return nil
+	})
+	if errors.Is(wsErr, database.ErrWorkspaceNotFound) {
 		// Neither project nor workspace found
 		return err
 	}
+	return wsErr
+}
 
-	// Find or create provider info for this provider (project level)
-	found := false
-	for i, provider := range project.Providers {
-		if provider.ProviderID == providerName {
-			project.Providers[i].ProviderApiID = configId
-			found = true
-			break
+// setProviderApiConfig points the provider info of providerName at
+// configId, adding the provider info when there is none
+func setProviderApiConfig(providers *[]database.ProviderInfo, id, providerName, path, configId string) {
+	for i := range *providers {
+		if (*providers)[i].ProviderID == providerName {
+			(*providers)[i].ProviderApiID = configId
+			return
 		}
 	}
-
-	if !found {
-		// Add new provider info
-		project.Providers = append(project.Providers, database.ProviderInfo{
-			ID:            name,
-			ProviderID:    providerName,
-			Path:          projectPath,
-			ProviderApiID: configId,
-		})
-	}
-
-	return a.dbManager.SaveProjectIndex(project)
+	*providers = append(*providers, database.ProviderInfo{
+		ID:            id,
+		ProviderID:    providerName,
+		Path:          path,
+		ProviderApiID: configId,
+	})
 }
 
 // AddProviderToProject adds a provider to a project
 func (a *App) AddProviderToProject(path, provider string) error {
 	if a.dbManager == nil {
-		return nil
+		return a.unavailable(subsystemDatabase)
 	}
 
 	name := filepath.Base(path)
-	project, err := a.dbManager.GetProjectIndex(name)
-	if err != nil {
-		return err
-	}
-
-	// Check if provider already exists
-	for _, p := range project.Providers {
-		if p.ProviderID == provider {
-			return nil // Already exists
+	_, err := a.dbManager.PatchProjectIndex(name, func(project *database.ProjectIndex) error {
+		// Check if provider already exists
+		for _, p := range project.Providers {
+			if p.ProviderID == provider {
+				return nil
+			}
 		}
-	}
 
-	// Add new provider
-	project.Providers = append(project.Providers, database.ProviderInfo{
-		ID:         name,
-		ProviderID: provider,
-		Path:       path,
+		// Add new provider
+		project.Providers = append(project.Providers, database.ProviderInfo{
+			ID:         name,
+			ProviderID: provider,
+			Path:       path,
+		})
+		return nil
 	})
-
-	return a.dbManager.SaveProjectIndex(project)
+	return err
 }
 
 // UpdateProjectLastProvider updates the last used provider for a project
 func (a *App) UpdateProjectLastProvider(path, provider string) error {
 	if a.dbManager == nil {
-		return nil
-	}
-
-	name := filepath.Base(path)
-	project, err := a.dbManager.GetProjectIndex(name)
-	if err != nil {
-		return err
+		return a.unavailable(subsystemDatabase)
 	}
 
-	project.LastProvider = provider
-	return a.dbManager.SaveProjectIndex(project)
+	_, err := a.dbManager.PatchProjectIndex(filepath.Base(path), func(project *database.ProjectIndex) error {
+		project.LastProvider = provider
+		return nil
+	})
+	return err
 }
 
 // UpdateWorkspaceLastProvider updates the last used provider for a workspace
 func (a *App) UpdateWorkspaceLastProvider(path, provider string) error {
 	if a.dbManager == nil {
-		return nil
-	}
-
-	// Find the project that contains this workspace
-	projects, err := a.dbManager.GetAllProjectIndexes()
-	if err != nil {
-		return err
+		return a.unavailable(subsystemDatabase)
 	}
 
-	workspaceName := filepath.Base(path)
-	for _, project := range projects {
-		for i, workspace := range project.Workspaces {
-			if workspace.Name == workspaceName {
-				project.Workspaces[i].LastProvider = provider
-				return a.dbManager.SaveProjectIndex(project)
-			}
-		}
+	_, err := a.dbManager.PatchWorkspaceIndex(filepath.Base(path), func(_ *database.ProjectIndex, workspace *database.WorkspaceIndex) error {
+		workspace.LastProvider = provider
+		return nil
+	})
+	if errors.Is(err, database.ErrWorkspaceNotFound) {
+		return nil
 	}
-
-	return nil
+	return err
 }
 
 // UpdateProviderSession updates the session ID for a provider in a project
 func (a *App) UpdateProviderSession(path, provider, session string) error {
 	if a.dbManager == nil {
-		return nil
+		return a.unavailable(subsystemDatabase)
 	}
 
 	name := filepath.Base(path)
@@ -522,7 +570,7 @@ func (a *App) UpdateProviderSession(path, provider, session string) error {
 // GetSessionMessageIndex returns the message index for a session
 func (a *App) GetSessionMessageIndex(projectID, sessionID string) ([]int, error) {
 	if a.sessionManager == nil {
-		return []int{}, fmt.Errorf("session manager not initialized")
+		return []int{}, a.unavailable(subsystemSessionHistory)
 	}
 	return a.sessionManager.GetMessageIndex(projectID, sessionID)
 }
@@ -530,7 +578,7 @@ func (a *App) GetSessionMessageIndex(projectID, sessionID string) ([]int, error)
 // GetSessionMessagesRange returns a range of messages from a session
 func (a *App) GetSessionMessagesRange(projectID, sessionID string, start, end int) ([]claude.Message, error) {
 	if a.sessionManager == nil {
-		return []claude.Message{}, fmt.Errorf("session manager not initialized")
+		return []claude.Message{}, a.unavailable(subsystemSessionHistory)
 	}
 	return a.sessionManager.GetMessagesRange(projectID, sessionID, start, end)
 }
@@ -538,7 +586,7 @@ func (a *App) GetSessionMessagesRange(projectID, sessionID string, start, end in
 // StreamSessionOutput streams the output of a session
 func (a *App) StreamSessionOutput(projectID, sessionID string) error {
 	if a.sessionManager == nil {
-		return fmt.Errorf("session manager not initialized")
+		return a.unavailable(subsystemSessionHistory)
 	}
 
 	// Create channels for streaming
@@ -581,47 +629,68 @@ func (a *App) StreamSessionOutput(projectID, sessionID string) error {
 // LoadSessionHistory loads the history for a session
 func (a *App) LoadSessionHistory(sessionID, projectID string) ([]claude.Message, error) {
 	if a.sessionManager == nil {
-		return []claude.Message{}, fmt.Errorf("session manager not initialized")
+		return []claude.Message{}, a.unavailable(subsystemSessionHistory)
+	}
+	messages, err := a.sessionManager.LoadSessionHistory(projectID, sessionID)
+	if err == nil {
+		a.rewriteHistoryImages(sessionID, messages)
 	}
-	return a.sessionManager.LoadSessionHistory(projectID, sessionID)
+	return messages, err
 }
 
 // LoadProviderSessionHistory loads the history for a session based on provider type
 func (a *App) LoadProviderSessionHistory(sessionID, projectID, provider string) ([]claude.Message, error) {
 	log.Printf("[LoadProviderSessionHistory] Loading history for provider=%s, session=%s, project=%s", provider, sessionID, projectID)
 
+	messages, err := a.loadProviderHistory(sessionID, projectID, provider)
+	if err == nil {
+		a.rewriteHistoryImages(sessionID, messages)
+	}
+	return messages, err
+}
+
+// loadProviderHistory loads a session's history as stored by its provider,
+// images included
+func (a *App) loadProviderHistory(sessionID, projectID, provider string) ([]claude.Message, error) {
+	var messages []claude.Message
+	var err error
 	switch provider {
 	case "codex":
 		// Load from Codex sessions directory
-		codexDir, err := codex.CodexDir()
-		if err != nil {
-			return []claude.Message{}, fmt.Errorf("failed to get codex directory: %w", err)
+		codexDir, dirErr := codex.CodexDir()
+		if dirErr != nil {
+			return []claude.Message{}, fmt.Errorf("failed to get codex directory: %w", dirErr)
+		}
+		if catalog := a.codexCatalog(codexDir); catalog != nil {
+			messages, err = catalog.LoadSessionHistory(projectID, sessionID)
+		} else {
+			messages, err = codex.LoadSessionHistory(codexDir, projectID, sessionID)
 		}
-		return codex.LoadSessionHistory(codexDir, projectID, sessionID)
 
 	case "gemini":
 		// Load from Gemini sessions directory
-		geminiDir, err := gemini.GeminiDir()
-		if err != nil {
-			return []claude.Message{}, fmt.Errorf("failed to get gemini directory: %w", err)
+		geminiDir, dirErr := gemini.GeminiDir()
+		if dirErr != nil {
+			return []claude.Message{}, fmt.Errorf("failed to get gemini directory: %w", dirErr)
 		}
-		return gemini.LoadSessionHistory(geminiDir, projectID, sessionID)
+		messages, err = gemini.LoadSessionHistory(geminiDir, projectID, sessionID)
 
 	case "claude":
 		fallthrough
 	default:
 		// Load from Claude sessions directory
 		if a.sessionManager == nil {
-			return []claude.Message{}, fmt.Errorf("session manager not initialized")
+			return []claude.Message{}, a.unavailable(subsystemSessionHistory)
 		}
-		return a.sessionManager.LoadSessionHistory(projectID, sessionID)
+		messages, err = a.sessionManager.LoadSessionHistory(projectID, sessionID)
 	}
+	return messages, err
 }
 
 // LoadAgentSessionHistory loads the history for an agent session
 func (a *App) LoadAgentSessionHistory(sessionID string) ([]claude.Message, error) {
 	if a.sessionManager == nil {
-		return []claude.Message{}, fmt.Errorf("session manager not initialized")
+		return []claude.Message{}, a.unavailable(subsystemSessionHistory)
 	}
 	return a.sessionManager.LoadAgentSessionHistory(sessionID)
 }
@@ -629,7 +698,7 @@ func (a *App) LoadAgentSessionHistory(sessionID string) ([]claude.Message, error
 // LoadSubagentTranscripts loads sidechain subagent transcripts for a parent Claude session.
 func (a *App) LoadSubagentTranscripts(sessionID, projectID string) (map[string][]claude.Message, error) {
 	if a.sessionManager == nil {
-		return map[string][]claude.Message{}, fmt.Errorf("session manager not initialized")
+		return map[string][]claude.Message{}, a.unavailable(subsystemSessionHistory)
 	}
 	return a.sessionManager.LoadSubagentTranscripts(projectID, sessionID)
 }
@@ -665,6 +734,24 @@ func (s claudeActivityControlSender) SendStopTask(requestID, taskID string) erro
 	return s.manager.SendStopTaskRequest(s.sessionID, requestID, taskID)
 }
 
+// codexCatalog returns the catalog of the Codex sessions in codexDir kept
+// in the database, or nil without one
+func (a *App) codexCatalog(codexDir string) *codex.Catalog {
+	if a.dbManager == nil {
+		return nil
+	}
+	return codex.NewCatalog(codexDir, a.dbManager)
+}
+
+// listCodexSessions lists the Codex sessions of projectPath through the
+// catalog, scanning the sessions directory when there is no database
+func (a *App) listCodexSessions(codexDir, projectPath string, limit int) (codex.ProjectSessionsResult, error) {
+	if catalog := a.codexCatalog(codexDir); catalog != nil {
+		return catalog.ListProjectSessionsLimit(projectPath, limit)
+	}
+	return codex.ListProjectSessionsLimit(codexDir, projectPath, limit)
+}
+
 // ListProviderSessions lists sessions for a project based on provider type
 func (a *App) ListProviderSessions(projectPath, provider string) ([]ProviderSession, error) {
 	log.Printf("[ListProviderSessions] Listing sessions for provider=%s, project=%s", provider, projectPath)
@@ -674,14 +761,15 @@ func (a *App) ListProviderSessions(projectPath, provider string) ([]ProviderSess
 		// List from Codex sessions directory
 		codexDir, err := codex.CodexDir()
 		if err != nil {
-			log.Printf("[ListProviderSessions] Failed to get codex directory: %v", err)
+			slog.Warn("Failed to get codex directory", "component", "ListProviderSessions", "error", err)
 			return []ProviderSession{}, nil
 		}
-		codexSessions, err := codex.ListProjectSessions(codexDir, projectPath)
+		codexResult, err := a.listCodexSessions(codexDir, projectPath, 0)
 		if err != nil {
-			log.Printf("[ListProviderSessions] Failed to list codex sessions: %v", err)
+			slog.Warn("Failed to list codex sessions", "component", "ListProviderSessions", "error", err)
 			return []ProviderSession{}, nil
 		}
+		codexSessions := codexResult.Sessions
 		// Convert to ProviderSession
 		sessions := make([]ProviderSession, len(codexSessions))
 		for i, s := range codexSessions {
@@ -707,7 +795,7 @@ func (a *App) ListProviderSessions(projectPath, provider string) ([]ProviderSess
 		claudeDir := a.config.ClaudeDir
 		claudeSessions, err := claude.ListProjectSessions(claudeDir, projectPath)
 		if err != nil {
-			log.Printf("[ListProviderSessions] Failed to list Claude sessions: %v", err)
+			slog.Warn("Failed to list Claude sessions", "component", "ListProviderSessions", "error", err)
 			return []ProviderSession{}, nil
 		}
 		sessions := make([]ProviderSession, len(claudeSessions))
@@ -755,7 +843,7 @@ func (a *App) ListSpaceSessions(projectPath string, limit int) (SpaceSessionsRes
 				if err != nil {
 					return spaceSessionScanResult{}, err
 				}
-				codexResult, err := codex.ListProjectSessionsLimit(codexDir, projectPath, limit)
+				codexResult, err := a.listCodexSessions(codexDir, projectPath, limit)
 				if err != nil {
 					return spaceSessionScanResult{}, err
 				}
@@ -832,7 +920,7 @@ func (a *App) OpenFileDialog(title, defaultPath string, filters []map[string]int
 // ListProjects returns all project indexes
 func (a *App) ListProjects() ([]*database.ProjectIndex, error) {
 	if a.dbManager == nil {
-		return nil, nil
+		return nil, a.unavailable(subsystemDatabase)
 	}
 	projects, err := a.dbManager.GetAllProjectIndexes()
 	if err != nil {
@@ -865,8 +953,13 @@ func (a *App) ListProjects() ([]*database.ProjectIndex, error) {
 				_, gitErr := git.Open(path)
 				hasGit := gitErr == nil
 				project.HasGitSupport = &hasGit
-				// Save the updated project
-				a.dbManager.SaveProjectIndex(project)
+				// Save only the detected field, keeping concurrent updates
+				a.dbManager.PatchProjectIndex(project.Name, func(stored *database.ProjectIndex) error {
+					if stored.HasGitSupport == nil {
+						stored.HasGitSupport = &hasGit
+					}
+					return nil
+				})
 			}
 		}
 	}
@@ -877,7 +970,7 @@ func (a *App) ListProjects() ([]*database.ProjectIndex, error) {
 // GetProjectIndex retrieves a project index by name
 func (a *App) GetProjectIndex(name string) (*database.ProjectIndex, error) {
 	if a.dbManager == nil {
-		return nil, nil
+		return nil, a.unavailable(subsystemDatabase)
 	}
 	return a.dbManager.GetProjectIndex(name)
 }
@@ -885,7 +978,7 @@ func (a *App) GetProjectIndex(name string) (*database.ProjectIndex, error) {
 // SaveProjectIndex saves or updates a project index
 func (a *App) SaveProjectIndex(project *database.ProjectIndex) error {
 	if a.dbManager == nil {
-		return nil
+		return a.unavailable(subsystemDatabase)
 	}
 	return a.dbManager.SaveProjectIndex(project)
 }
@@ -893,7 +986,7 @@ func (a *App) SaveProjectIndex(project *database.ProjectIndex) error {
 // DeleteProjectIndex deletes a project index by name
 func (a *App) DeleteProjectIndex(name string) error {
 	if a.dbManager == nil {
-		return nil
+		return a.unavailable(subsystemDatabase)
 	}
 	return a.dbManager.DeleteProjectIndex(name)
 }
@@ -911,6 +1004,21 @@ type GitRepoStatus struct {
 
 // GetGitStatus returns the git status for a repository path
 func (a *App) GetGitStatus(path string) (*GitRepoStatus, error) {
+	var remote GitRepoStatus
+	if ok, err := a.proxyGitCall("GetGitStatus", path, &remote); ok {
+		if err != nil {
+			return nil, err
+		}
+		return &remote, nil
+	}
+	if snapshot := a.cachedGitStatus(path); snapshot != nil && snapshot.Status != nil {
+		return snapshot.Status, nil
+	}
+	return gitRepoStatus(path)
+}
+
+// gitRepoStatus reads the git status of a local repository
+func gitRepoStatus(path string) (*GitRepoStatus, error) {
 	repo, err := git.Open(path)
 	if err != nil {
 		return nil, err
@@ -932,6 +1040,10 @@ func (a *App) GetGitStatus(path string) (*GitRepoStatus, error) {
 
 // GetCurrentBranch returns the current git branch for a repository path
 func (a *App) GetCurrentBranch(path string) (string, error) {
+	var branch string
+	if ok, err := a.proxyGitCall("GetCurrentBranch", path, &branch); ok {
+		return branch, err
+	}
 	repo, err := git.Open(path)
 	if err != nil {
 		return "", err
@@ -941,6 +1053,10 @@ func (a *App) GetCurrentBranch(path string) (string, error) {
 
 // GetGitDiff returns the diff for a repository
 func (a *App) GetGitDiff(path string, cached bool) (string, error) {
+	var diff string
+	if ok, err := a.proxyGitCall("GetGitDiff", path, &diff, cached); ok {
+		return diff, err
+	}
 	repo, err := git.Open(path)
 	if err != nil {
 		return "", err
@@ -950,6 +1066,10 @@ func (a *App) GetGitDiff(path string, cached bool) (string, error) {
 
 // IsGitRepository checks if a path is a git repository
 func (a *App) IsGitRepository(path string) bool {
+	var isRepo bool
+	if ok, err := a.proxyGitCall("IsGitRepository", path, &isRepo); ok {
+		return err == nil && isRepo
+	}
 	_, err := git.Open(path)
 	return err == nil
 }
@@ -1029,7 +1149,7 @@ func (a *App) PushToMainWorktree(path string) (string, error) {
 	}
 
 	// 2. Get current branch name
-	cmd := exec.Command("git", "rev-parse", "--abbrev-ref", "HEAD")
+	cmd := git.Command("rev-parse", "--abbrev-ref", "HEAD")
 	cmd.Dir = path
 	output, err := cmd.Output()
 	if err != nil {
@@ -1038,7 +1158,7 @@ func (a *App) PushToMainWorktree(path string) (string, error) {
 	currentBranch := strings.TrimSpace(string(output))
 
 	// 3. Check if main worktree has uncommitted changes
-	cmd = exec.Command("git", "status", "--porcelain")
+	cmd = git.Command("status", "--porcelain")
 	cmd.Dir = worktreeInfo.RootPath
 	output, err = cmd.Output()
 	if err != nil {
@@ -1049,7 +1169,7 @@ func (a *App) PushToMainWorktree(path string) (string, error) {
 	}
 
 	// 4. Get the SHA of current branch (to avoid "branch is checked out" error)
-	cmd = exec.Command("git", "rev-parse", currentBranch)
+	cmd = git.Command("rev-parse", currentBranch)
 	cmd.Dir = path
 	output, err = cmd.Output()
 	if err != nil {
@@ -1059,7 +1179,7 @@ func (a *App) PushToMainWorktree(path string) (string, error) {
 
 	// 5. Perform merge in main worktree using SHA instead of branch name
 	// This avoids the "cannot merge branch that is checked out in a worktree" error
-	cmd = exec.Command("git", "merge", "--no-edit", branchSHA, "-m", "Merge from worktree: "+currentBranch)
+	cmd = git.Command("merge", "--no-edit", branchSHA, "-m", "Merge from worktree: "+currentBranch)
 	cmd.Dir = worktreeInfo.RootPath
 	output, err = cmd.CombinedOutput()
 	outputStr := string(output)
@@ -1068,7 +1188,7 @@ func (a *App) PushToMainWorktree(path string) (string, error) {
 		// Check if it's a conflict
 		if strings.Contains(outputStr, "CONFLICT") || strings.Contains(outputStr, "conflict") {
 			// Abort the merge
-			abortCmd := exec.Command("git", "merge", "--abort")
+			abortCmd := git.Command("merge", "--abort")
 			abortCmd.Dir = worktreeInfo.RootPath
 			abortCmd.Run()
 
@@ -1097,6 +1217,15 @@ func (a *App) PushToMainWorktree(path string) (string, error) {
 // GetUnpushedCommitsCount returns the count of commits not pushed to main worktree
 // This compares the current branch against the main worktree's branch (not remote)
 func (a *App) GetUnpushedCommitsCount(path string) (int, error) {
+	if snapshot := a.cachedGitStatus(path); snapshot != nil {
+		return snapshot.UnpushedCount, nil
+	}
+	return a.unpushedCommitsCount(path)
+}
+
+// unpushedCommitsCount counts the commits of a worktree child not in the
+// main worktree's branch
+func (a *App) unpushedCommitsCount(path string) (int, error) {
 	// 1. Detect worktree info
 	worktreeInfo, err := a.DetectWorktree(path)
 	if err != nil {
@@ -1109,7 +1238,7 @@ func (a *App) GetUnpushedCommitsCount(path string) (int, error) {
 	}
 
 	// 2. Get current branch
-	cmd := exec.Command("git", "rev-parse", "--abbrev-ref", "HEAD")
+	cmd := git.Command("rev-parse", "--abbrev-ref", "HEAD")
 	cmd.Dir = path
 	output, err := cmd.Output()
 	if err != nil {
@@ -1122,7 +1251,7 @@ func (a *App) GetUnpushedCommitsCount(path string) (int, error) {
 
 	// 3. Count commits between main branch and current branch
 	// This counts commits in current branch that are not in main branch
-	cmd = exec.Command("git", "rev-list", "--count", fmt.Sprintf("%s..%s", worktreeInfo.MainBranch, currentBranch))
+	cmd = git.Command("rev-list", "--count", fmt.Sprintf("%s..%s", worktreeInfo.MainBranch, currentBranch))
 	cmd.Dir = path
 	output, err = cmd.Output()
 	if err != nil {
@@ -1142,7 +1271,7 @@ func (a *App) GetUnpushedCommitsCount(path string) (int, error) {
 // Handles upstream setup for new branches and provides helpful error messages
 func (a *App) PushToRemote(path string) (string, error) {
 	// 1. Get current branch
-	cmd := exec.Command("git", "rev-parse", "--abbrev-ref", "HEAD")
+	cmd := git.Command("rev-parse", "--abbrev-ref", "HEAD")
 	cmd.Dir = path
 	output, err := cmd.Output()
 	if err != nil {
@@ -1154,20 +1283,20 @@ func (a *App) PushToRemote(path string) (string, error) {
 	}
 
 	// 2. Check if remote 'origin' exists
-	cmd = exec.Command("git", "remote", "get-url", "origin")
+	cmd = git.Command("remote", "get-url", "origin")
 	cmd.Dir = path
 	if err := cmd.Run(); err != nil {
 		return "", fmt.Errorf("no remote 'origin' configured")
 	}
 
 	// 3. Fetch latest from remote
-	cmd = exec.Command("git", "fetch", "origin")
+	cmd = git.Command("fetch", "origin")
 	cmd.Dir = path
 	cmd.Run() // Ignore fetch errors, push might still work
 
 	// 4. Check if remote branch exists
 	remoteBranch := fmt.Sprintf("refs/remotes/origin/%s", currentBranch)
-	cmd = exec.Command("git", "rev-parse", "--verify", "--quiet", remoteBranch)
+	cmd = git.Command("rev-parse", "--verify", "--quiet", remoteBranch)
 	cmd.Dir = path
 	remoteBranchExists := cmd.Run() == nil
 
@@ -1180,7 +1309,7 @@ func (a *App) PushToRemote(path string) (string, error) {
 		pushArgs = []string{"push", "-u", "origin", currentBranch}
 	}
 
-	cmd = exec.Command("git", pushArgs...)
+	cmd = git.Command(pushArgs...)
 	cmd.Dir = path
 	output, err = cmd.CombinedOutput()
 	if err != nil {
@@ -1200,8 +1329,16 @@ func (a *App) PushToRemote(path string) (string, error) {
 // GetUnpushedToRemoteCount returns the count of commits not pushed to remote
 // If the remote branch doesn't exist, returns the total number of commits on the branch
 func (a *App) GetUnpushedToRemoteCount(path string) (int, error) {
+	if snapshot := a.cachedGitStatus(path); snapshot != nil {
+		return snapshot.UnpushedToRemoteCount, nil
+	}
+	return unpushedToRemoteCount(path)
+}
+
+// unpushedToRemoteCount counts the commits of the current branch not on origin
+func unpushedToRemoteCount(path string) (int, error) {
 	// 1. Get current branch
-	cmd := exec.Command("git", "rev-parse", "--abbrev-ref", "HEAD")
+	cmd := git.Command("rev-parse", "--abbrev-ref", "HEAD")
 	cmd.Dir = path
 	output, err := cmd.Output()
 	if err != nil {
@@ -1214,13 +1351,13 @@ func (a *App) GetUnpushedToRemoteCount(path string) (int, error) {
 
 	// 2. Check if remote branch exists
 	remoteBranch := fmt.Sprintf("refs/remotes/origin/%s", currentBranch)
-	cmd = exec.Command("git", "rev-parse", "--verify", "--quiet", remoteBranch)
+	cmd = git.Command("rev-parse", "--verify", "--quiet", remoteBranch)
 	cmd.Dir = path
 	err = cmd.Run()
 
 	if err != nil {
 		// Remote branch doesn't exist, count total commits on this branch
-		cmd = exec.Command("git", "rev-list", "--count", currentBranch)
+		cmd = git.Command("rev-list", "--count", currentBranch)
 		cmd.Dir = path
 		output, err = cmd.Output()
 		if err != nil {
@@ -1236,7 +1373,7 @@ func (a *App) GetUnpushedToRemoteCount(path string) (int, error) {
 	}
 
 	// 3. Remote branch exists, count commits ahead of remote
-	cmd = exec.Command("git", "rev-list", "--count", fmt.Sprintf("%s..HEAD", remoteBranch))
+	cmd = git.Command("rev-list", "--count", fmt.Sprintf("%s..HEAD", remoteBranch))
 	cmd.Dir = path
 	output, err = cmd.Output()
 	if err != nil {
@@ -1256,7 +1393,7 @@ func (a *App) GetUnpushedToRemoteCount(path string) (int, error) {
 // Uses git command instead of go-git because go-git doesn't handle worktrees correctly
 func (a *App) CheckWorkspaceClean(path string) error {
 	// 1. Check for uncommitted changes using git status --porcelain
-	cmd := exec.Command("git", "status", "--porcelain")
+	cmd := git.Command("status", "--porcelain")
 	cmd.Dir = path
 
 	output, err := cmd.Output()
@@ -1286,7 +1423,13 @@ func (a *App) CheckWorkspaceClean(path string) error {
 // 1. Resetting all uncommitted changes (staged and unstaged)
 // 2. Removing all untracked files and directories
 // 3. Resetting to remote branch (if exists) or main branch (if worktree)
-func (a *App) CleanupWorkspace(path string) (string, error) {
+//
+// With workspace protection on it needs override set. With
+// trash_discarded_files on, the discarded files go to the trash first.
+func (a *App) CleanupWorkspace(path string, override bool) (string, error) {
+	if err := a.checkWorkspaceProtection("discard the changes and unpushed commits of "+path, override); err != nil {
+		return "", err
+	}
 	repo, err := git.Open(path)
 	if err != nil {
 		return "", err
@@ -1300,6 +1443,26 @@ func (a *App) CleanupWorkspace(path string) (string, error) {
 		return "", fmt.Errorf("not on a valid branch")
 	}
 
+	// Keep what the reset and clean below throw away
+	if a.trashEnabled() {
+		overwritten, untracked, err := git.DiscardedPaths(path)
+		if err != nil {
+			return "", fmt.Errorf("failed to list discarded files: %w", err)
+		}
+		kept, err := a.trashPaths(overwritten, true, "Cleanup of "+path)
+		if err == nil {
+			var moved int
+			moved, err = a.trashPaths(untracked, false, "Cleanup of "+path)
+			kept += moved
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to move discarded files to the trash: %w", err)
+		}
+		if kept > 0 {
+			cleanupOperations = append(cleanupOperations, fmt.Sprintf("Moved %d changed or untracked files to the trash", kept))
+		}
+	}
+
 	// 2. Reset all uncommitted changes (git reset --hard HEAD)
 	_, err = repo.RunGitCommand("reset", "--hard", "HEAD")
 	if err != nil {
@@ -1319,7 +1482,7 @@ func (a *App) CleanupWorkspace(path string) (string, error) {
 	remoteBranchFull := fmt.Sprintf("refs/remotes/%s", remoteBranch)
 
 	// Check if remote branch exists using git rev-parse
-	cmd := exec.Command("git", "rev-parse", "--verify", remoteBranchFull)
+	cmd := git.Command("rev-parse", "--verify", remoteBranchFull)
 	cmd.Dir = path
 	remoteBranchExists := cmd.Run() == nil
 
@@ -1387,7 +1550,7 @@ func (a *App) InitLocalGit(path string, commitAll bool) error {
 
 	// Create bare repository if it doesn't exist
 	if _, err := os.Stat(bareDir); os.IsNotExist(err) {
-		output, err := exec.Command("git", "init", "--bare", bareDir).CombinedOutput()
+		output, err := git.Command("init", "--bare", bareDir).CombinedOutput()
 		if err != nil {
 			return fmt.Errorf("failed to create bare repository: %s, %w", string(output), err)
 		}
@@ -1397,7 +1560,7 @@ func (a *App) InitLocalGit(path string, commitAll bool) error {
 	repo, err := git.Open(path)
 	if err != nil {
 		// If repo doesn't exist, initialize it
-		output, err := exec.Command("git", "init", path).CombinedOutput()
+		output, err := git.Command("init", path).CombinedOutput()
 		if err != nil {
 			return fmt.Errorf("failed to init repository: %s, %w", string(output), err)
 		}
@@ -1538,6 +1701,15 @@ func (a *App) ListDirectoryContents(path string) ([]FileEntry, error) {
 	return result, nil
 }
 
+// GetDirectoryTree returns the tree under basePath with depth levels of
+// children loaded. Directories deeper than that come back with a child count
+// but no children, and are expanded by calling it again on their path.
+// respectGitignore hides what git ignores.
+func (a *App) GetDirectoryTree(basePath string, depth int, respectGitignore bool) (*filetree.Node, error) {
+	basePath = pathutil.NormalizeClientPath(basePath)
+	return filetree.Read(basePath, depth, respectGitignore)
+}
+
 // ReadFile reads the content of a file
 func (a *App) ReadFile(path string) (string, error) {
 	path = pathutil.NormalizeClientPath(path)
@@ -1548,10 +1720,62 @@ func (a *App) ReadFile(path string) (string, error) {
 	return string(data), nil
 }
 
-// WriteFile writes content to a file
+// ReadFileSmart reads a file for display, reading at most maxBytes (0 for the
+// default limit). Binary files come back without content and text in UTF-16
+// or Latin-1 is decoded, unlike ReadFile which returns the raw bytes.
+func (a *App) ReadFileSmart(path string, maxBytes int64) (*textfile.Content, error) {
+	path = pathutil.NormalizeClientPath(path)
+	return textfile.Read(path, maxBytes)
+}
+
+// fileWriteBackupSettingKey keeps a .bak copy of files WriteFile replaces
+const fileWriteBackupSettingKey = "file_write_backup"
+
+// WriteFile writes content to a file. The file is replaced atomically and
+// keeps its permissions.
 func (a *App) WriteFile(path, content string) error {
 	path = pathutil.NormalizeClientPath(path)
-	return os.WriteFile(path, []byte(content), 0644)
+	return textfile.Write(path, []byte(content), a.fileWriteBackupEnabled())
+}
+
+// DeleteFile moves a file to the system trash, or deletes it for good when
+// permanent is set. Without a usable trash it fails so the caller can offer
+// a permanent delete.
+func (a *App) DeleteFile(path string, permanent bool) error {
+	path = pathutil.NormalizeClientPath(path)
+	if info, err := os.Lstat(path); err == nil && info.IsDir() {
+		return fmt.Errorf("%s is a directory", path)
+	}
+	return fileops.Delete(path, permanent)
+}
+
+// DeleteDirectory moves a directory and everything in it to the system
+// trash, or deletes it for good when permanent is set
+func (a *App) DeleteDirectory(path string, permanent bool) error {
+	path = pathutil.NormalizeClientPath(path)
+	if info, err := os.Lstat(path); err == nil && !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", path)
+	}
+	return fileops.Delete(path, permanent)
+}
+
+// RenamePath renames or moves a file or directory; an existing target is
+// never replaced
+func (a *App) RenamePath(oldPath, newPath string) error {
+	return fileops.Rename(pathutil.NormalizeClientPath(oldPath), pathutil.NormalizeClientPath(newPath))
+}
+
+// CopyPath copies a file or directory to a path that doesn't exist yet
+func (a *App) CopyPath(srcPath, dstPath string) error {
+	return fileops.Copy(pathutil.NormalizeClientPath(srcPath), pathutil.NormalizeClientPath(dstPath))
+}
+
+func (a *App) fileWriteBackupEnabled() bool {
+	if a.dbManager == nil {
+		return false
+	}
+	value, err := a.dbManager.GetSetting(fileWriteBackupSettingKey)
+	return err == nil && value == "true"
 }
 
 // GetFileMetadata returns cross-platform file information for the frontend editor.
@@ -1658,7 +1882,7 @@ func (a *App) SearchFiles(basePath, query string) ([]FileEntry, error) {
 // ExecuteClaudeCode starts a new Claude Code session
 func (a *App) ExecuteClaudeCode(projectPath, prompt, model string, sessionID, providerApiID string) (string, error) {
 	if a.claudeManager == nil {
-		return "", nil
+		return "", a.unavailable(subsystemClaude)
 	}
 
 	config := claude.SessionConfig{
@@ -1678,18 +1902,61 @@ func (a *App) ExecuteClaudeCode(projectPath, prompt, model string, sessionID, pr
 		}
 	}
 
+	config.Env = a.sessionEnv(projectPath)
+	config.AppendSystemPrompt = a.sessionSystemPrompt(projectPath)
+	a.applyProjectMcpToClaude(&config)
+	a.applyPermissionProfileToClaude(&config)
+	a.applyClaudeFlagProfile(&config)
+	if err := a.applyRemoteProjectToClaude(&config); err != nil {
+		return "", err
+	}
 	return a.claudeManager.StartSession(config)
 }
 
-// StartProviderSession starts a new provider session based on the provider type
+// StartProviderSession starts a new provider session based on the provider type.
+// When the session concurrency limit is reached the call waits in the session
+// queue until a slot frees up. A session on a config of a failover chain moves
+// to the next config on authentication and rate-limit errors. An empty model
+// falls back to the project's default model and thinking level.
 func (a *App) StartProviderSession(provider, projectPath, prompt, model, providerApiID, reasoningEffort string) (string, error) {
+	// A remote server queues the session itself
+	if sessionID, ok, err := a.proxyStartProviderSession(provider, projectPath, prompt, model, providerApiID, reasoningEffort); ok {
+		return sessionID, err
+	}
+	model, reasoningEffort = a.applyProjectModelDefaults(provider, projectPath, model, reasoningEffort)
+	release, err := a.acquireSessionSlot(provider, projectPath)
+	if err != nil {
+		return "", err
+	}
+	defer release()
+	checkpoint := a.createSessionCheckpoint(provider, projectPath, "")
+	sessionID, servedBy, reason, err := a.startProviderSessionWithFailover(provider, projectPath, prompt, model, providerApiID, reasoningEffort)
+	if err == nil {
+		a.recordTelemetry(telemetryKindFeature, "session.start."+provider, 0)
+		a.trackProviderSession(database.SessionProviderApi{
+			SessionID:     sessionID,
+			Provider:      provider,
+			ProjectPath:   projectPath,
+			ProviderApiID: servedBy,
+			Reason:        reason,
+		}, prompt, model, reasoningEffort)
+	} else {
+		a.dropSessionCheckpoint(checkpoint)
+	}
+	return sessionID, err
+}
+
+func (a *App) startProviderSession(provider, projectPath, prompt, model, providerApiID, reasoningEffort string) (string, error) {
 	switch provider {
 	case "claude":
 		return a.ExecuteClaudeCode(projectPath, prompt, model, "", providerApiID)
 
 	case "gemini":
 		if a.geminiManager == nil {
-			return "", fmt.Errorf("gemini manager not initialized")
+			return "", a.unavailable(subsystemGemini)
+		}
+		if err := a.requireLocalProject(projectPath, "gemini"); err != nil {
+			return "", err
 		}
 		config := gemini.SessionConfig{
 			ProjectPath:   projectPath,
@@ -1705,6 +1972,8 @@ func (a *App) StartProviderSession(provider, projectPath, prompt, model, provide
 				config.BaseURL = apiConfig.BaseURL
 			}
 		}
+		config.Env = a.sessionEnv(projectPath)
+		config.Remote = a.wslRemote(projectPath)
 		sessionID, err := a.geminiManager.StartSession(config)
 		if err != nil {
 			return "", err
@@ -1713,7 +1982,7 @@ func (a *App) StartProviderSession(provider, projectPath, prompt, model, provide
 
 	case "codex":
 		if a.codexManager == nil {
-			return "", fmt.Errorf("codex manager not initialized")
+			return "", a.unavailable(subsystemCodex)
 		}
 		config := codex.SessionConfig{
 			ProjectPath:     projectPath,
@@ -1729,6 +1998,14 @@ func (a *App) StartProviderSession(provider, projectPath, prompt, model, provide
 				config.BaseURL = apiConfig.BaseURL
 			}
 		}
+		config.Env = a.sessionEnv(projectPath)
+		config.DeveloperInstructions = a.sessionSystemPrompt(projectPath)
+		a.applyProjectMcpToCodex(&config)
+		a.applyCodexSandboxSettings(&config)
+		a.applyPermissionProfileToCodex(&config)
+		if err := a.applyRemoteProjectToCodex(&config); err != nil {
+			return "", err
+		}
 		sessionID, err := a.codexManager.StartSession(config)
 		if err != nil {
 			return "", err
@@ -1741,15 +2018,38 @@ func (a *App) StartProviderSession(provider, projectPath, prompt, model, provide
 	}
 }
 
-// ResumeProviderSession resumes an existing provider session based on the provider type
+// ResumeProviderSession resumes an existing provider session based on the provider type.
+// Like StartProviderSession it waits in the session queue when at capacity.
 func (a *App) ResumeProviderSession(provider, projectPath, prompt, model, sessionID, providerApiID, reasoningEffort string) (string, error) {
+	if resumedID, ok, err := a.proxyResumeProviderSession(provider, projectPath, prompt, model, sessionID, providerApiID, reasoningEffort); ok {
+		return resumedID, err
+	}
+	release, err := a.acquireSessionSlot(provider, projectPath)
+	if err != nil {
+		return "", err
+	}
+	defer release()
+	checkpoint := a.createSessionCheckpoint(provider, projectPath, sessionID)
+	resumedID, err := a.resumeProviderSession(provider, projectPath, prompt, model, sessionID, providerApiID, reasoningEffort)
+	if err == nil {
+		a.recordTelemetry(telemetryKindFeature, "session.resume."+provider, 0)
+	} else {
+		a.dropSessionCheckpoint(checkpoint)
+	}
+	return resumedID, err
+}
+
+func (a *App) resumeProviderSession(provider, projectPath, prompt, model, sessionID, providerApiID, reasoningEffort string) (string, error) {
 	switch provider {
 	case "claude":
 		return a.ResumeClaudeCode(projectPath, prompt, model, sessionID, providerApiID)
 
 	case "gemini":
 		if a.geminiManager == nil {
-			return "", fmt.Errorf("gemini manager not initialized")
+			return "", a.unavailable(subsystemGemini)
+		}
+		if err := a.requireLocalProject(projectPath, "gemini"); err != nil {
+			return "", err
 		}
 		config := gemini.SessionConfig{
 			ProjectPath:   projectPath,
@@ -1767,11 +2067,13 @@ func (a *App) ResumeProviderSession(provider, projectPath, prompt, model, sessio
 				config.BaseURL = apiConfig.BaseURL
 			}
 		}
+		config.Env = a.sessionEnv(projectPath)
+		config.Remote = a.wslRemote(projectPath)
 		return a.geminiManager.StartSession(config)
 
 	case "codex":
 		if a.codexManager == nil {
-			return "", fmt.Errorf("codex manager not initialized")
+			return "", a.unavailable(subsystemCodex)
 		}
 		config := codex.SessionConfig{
 			ProjectPath:     projectPath,
@@ -1789,6 +2091,14 @@ func (a *App) ResumeProviderSession(provider, projectPath, prompt, model, sessio
 				config.BaseURL = apiConfig.BaseURL
 			}
 		}
+		config.Env = a.sessionEnv(projectPath)
+		config.DeveloperInstructions = a.sessionSystemPrompt(projectPath)
+		a.applyProjectMcpToCodex(&config)
+		a.applyCodexSandboxSettings(&config)
+		a.applyPermissionProfileToCodex(&config)
+		if err := a.applyRemoteProjectToCodex(&config); err != nil {
+			return "", err
+		}
 		return a.codexManager.StartSession(config)
 
 	default:
@@ -1800,7 +2110,7 @@ func (a *App) ResumeProviderSession(provider, projectPath, prompt, model, sessio
 // ResumeClaudeCode resumes an existing Claude session
 func (a *App) ResumeClaudeCode(projectPath, prompt, model, sessionID, providerApiID string) (string, error) {
 	if a.claudeManager == nil {
-		return "", nil
+		return "", a.unavailable(subsystemClaude)
 	}
 
 	config := claude.SessionConfig{
@@ -1821,13 +2131,21 @@ func (a *App) ResumeClaudeCode(projectPath, prompt, model, sessionID, providerAp
 		}
 	}
 
+	config.Env = a.sessionEnv(projectPath)
+	config.AppendSystemPrompt = a.sessionSystemPrompt(projectPath)
+	a.applyProjectMcpToClaude(&config)
+	a.applyPermissionProfileToClaude(&config)
+	a.applyClaudeFlagProfile(&config)
+	if err := a.applyRemoteProjectToClaude(&config); err != nil {
+		return "", err
+	}
 	return a.claudeManager.StartSession(config)
 }
 
 // ContinueClaudeCode continues an existing session
 func (a *App) ContinueClaudeCode(projectPath, prompt, model, sessionID, providerApiID string) (string, error) {
 	if a.claudeManager == nil {
-		return "", nil
+		return "", a.unavailable(subsystemClaude)
 	}
 
 	config := claude.SessionConfig{
@@ -1848,15 +2166,26 @@ func (a *App) ContinueClaudeCode(projectPath, prompt, model, sessionID, provider
 		}
 	}
 
+	config.Env = a.sessionEnv(projectPath)
+	config.AppendSystemPrompt = a.sessionSystemPrompt(projectPath)
+	a.applyProjectMcpToClaude(&config)
+	a.applyPermissionProfileToClaude(&config)
+	a.applyClaudeFlagProfile(&config)
+	if err := a.applyRemoteProjectToClaude(&config); err != nil {
+		return "", err
+	}
 	return a.claudeManager.StartSession(config)
 }
 
 // SendProviderSessionMessage sends a prompt to an existing provider session.
 func (a *App) SendProviderSessionMessage(provider, projectPath, sessionID, prompt string) (string, error) {
+	if resultID, ok, err := a.proxySendProviderSessionMessage(provider, projectPath, sessionID, prompt); ok {
+		return resultID, err
+	}
 	switch provider {
 	case "gemini":
 		if a.geminiManager == nil {
-			return "", fmt.Errorf("gemini manager not initialized")
+			return "", a.unavailable(subsystemGemini)
 		}
 		cfg := a.providerSessionConfig(provider, projectPath, sessionID)
 		if err := a.geminiManager.TerminateSession(sessionID); err != nil && !strings.Contains(err.Error(), "session is not running") && !strings.Contains(err.Error(), "session not found") {
@@ -1865,7 +2194,7 @@ func (a *App) SendProviderSessionMessage(provider, projectPath, sessionID, promp
 		return a.StartProviderSession(provider, projectPath, prompt, cfg.model, cfg.providerApiID, cfg.reasoningEffort)
 	case "codex":
 		if a.codexManager == nil {
-			return "", fmt.Errorf("codex manager not initialized")
+			return "", a.unavailable(subsystemCodex)
 		}
 		cfg := a.providerSessionConfig(provider, projectPath, sessionID)
 		if err := a.codexManager.TerminateSession(sessionID); err != nil && !strings.Contains(err.Error(), "session is not running") && !strings.Contains(err.Error(), "session not found") {
@@ -1922,6 +2251,7 @@ func (a *App) ListRunningProviderSessions() []LiveProviderSession {
 			})
 		}
 	}
+	result = append(result, a.remoteRunningProviderSessions()...)
 	sort.Slice(result, func(i, j int) bool {
 		return result[i].StartedAt.After(result[j].StartedAt)
 	})
@@ -1930,6 +2260,9 @@ func (a *App) ListRunningProviderSessions() []LiveProviderSession {
 
 // GetProviderSessionOutput returns buffered output for a live provider session.
 func (a *App) GetProviderSessionOutput(sessionID string) (string, error) {
+	if output, ok, err := a.proxyGetProviderSessionOutput(sessionID); ok {
+		return output, err
+	}
 	if a.claudeManager != nil {
 		if output, err := a.claudeManager.GetSessionOutput(sessionID); err == nil {
 			return output, nil
@@ -1950,6 +2283,9 @@ func (a *App) GetProviderSessionOutput(sessionID string) (string, error) {
 
 // StopProviderSession stops a live provider session by id.
 func (a *App) StopProviderSession(sessionID string) error {
+	if ok, err := a.proxyStopProviderSession(sessionID); ok {
+		return err
+	}
 	if a.claudeManager != nil && a.claudeManager.IsRunning(sessionID) {
 		return a.claudeManager.TerminateSession(sessionID)
 	}
@@ -1965,7 +2301,7 @@ func (a *App) StopProviderSession(sessionID string) error {
 // CancelClaudeExecution cancels a running session
 func (a *App) CancelClaudeExecution(sessionID string) error {
 	if a.claudeManager == nil {
-		return nil
+		return a.unavailable(subsystemClaude)
 	}
 	return a.claudeManager.TerminateSession(sessionID)
 }
@@ -2004,7 +2340,7 @@ func (a *App) CancelClaudeExecutionByProject(projectPath string) error {
 					log.Printf("[CancelClaudeExecutionByProject] %s session already stopped for project: %s", p.name, projectPath)
 					return nil
 				}
-				log.Printf("[CancelClaudeExecutionByProject] Failed to terminate %s session: %v", p.name, err)
+				slog.Error("Failed to terminate session", "component", "CancelClaudeExecutionByProject", "provider", p.name, "error", err)
 				return err
 			}
 			log.Printf("[CancelClaudeExecutionByProject] Successfully cancelled %s execution for project: %s", p.name, projectPath)
@@ -2039,7 +2375,7 @@ func resolveInteractiveClaudeSessionStart(resumeSessionID string, hasExistingSes
 // resumeSessionID is the Claude-side session ID to resume (pass "" to start fresh).
 func (a *App) StartInteractiveClaudeSession(projectPath, model, providerApiID, resumeSessionID string) (string, error) {
 	if a.claudeManager == nil {
-		return "", fmt.Errorf("claude manager not initialized")
+		return "", a.unavailable(subsystemClaude)
 	}
 
 	existingSession := a.claudeManager.GetInteractiveSessionForProject(projectPath)
@@ -2073,6 +2409,14 @@ func (a *App) StartInteractiveClaudeSession(projectPath, model, providerApiID, r
 		}
 	}
 
+	config.Env = a.sessionEnv(projectPath)
+	config.AppendSystemPrompt = a.sessionSystemPrompt(projectPath)
+	a.applyProjectMcpToClaude(&config)
+	a.applyPermissionProfileToClaude(&config)
+	a.applyClaudeFlagProfile(&config)
+	if err := a.applyRemoteProjectToClaude(&config); err != nil {
+		return "", err
+	}
 	sessionID, err := a.claudeManager.StartSession(config)
 	if err != nil {
 		return "", err
@@ -2094,7 +2438,7 @@ func (a *App) StartInteractiveClaudeSession(projectPath, model, providerApiID, r
 		// may no longer exist (e.g., after app reinstall or manual cleanup). Retry without
 		// the resume ID to start a fresh session instead of failing.
 		if resumeSessionID != "" && strings.HasPrefix(err.Error(), "session exited before initialization") {
-			log.Printf("[StartInteractiveClaudeSession] Resume session %s failed (%v), retrying without resume", resumeSessionID, err)
+			slog.Warn("Resume failed, retrying without resume", "component", "StartInteractiveClaudeSession", "session_id", resumeSessionID, "error", err)
 			config.ResumeClaudeSessionID = ""
 			retryID, retryErr := a.claudeManager.StartSession(config)
 			if retryErr != nil {
@@ -2116,7 +2460,7 @@ func (a *App) StartInteractiveClaudeSession(projectPath, model, providerApiID, r
 // SendClaudeMessage sends a message to a running interactive Claude session
 func (a *App) SendClaudeMessage(projectPath, sessionID, prompt string) error {
 	if a.claudeManager == nil {
-		return fmt.Errorf("claude manager not initialized")
+		return a.unavailable(subsystemClaude)
 	}
 
 	return a.claudeManager.SendMessage(sessionID, prompt)
@@ -2127,7 +2471,7 @@ func (a *App) SendClaudeMessage(projectPath, sessionID, prompt string) error {
 // reset to the CLI's default model.
 func (a *App) SetClaudeSessionModel(sessionID, model string) error {
 	if a.claudeManager == nil {
-		return fmt.Errorf("claude manager not initialized")
+		return a.unavailable(subsystemClaude)
 	}
 	return a.claudeManager.SetSessionModel(sessionID, model)
 }
@@ -2137,7 +2481,7 @@ func (a *App) SetClaudeSessionModel(sessionID, model string) error {
 // bypassPermissions, plan, dontAsk.
 func (a *App) SetClaudeSessionPermissionMode(sessionID, mode string) error {
 	if a.claudeManager == nil {
-		return fmt.Errorf("claude manager not initialized")
+		return a.unavailable(subsystemClaude)
 	}
 	return a.claudeManager.SetSessionPermissionMode(sessionID, mode)
 }
@@ -2146,7 +2490,7 @@ func (a *App) SetClaudeSessionPermissionMode(sessionID, mode string) error {
 // without terminating the process. The session remains usable afterward.
 func (a *App) InterruptClaudeSession(sessionID string) error {
 	if a.claudeManager == nil {
-		return fmt.Errorf("claude manager not initialized")
+		return a.unavailable(subsystemClaude)
 	}
 	return a.claudeManager.InterruptSession(sessionID)
 }
@@ -2165,7 +2509,7 @@ func (a *App) InterruptClaudeSession(sessionID string) error {
 //     first if you want the new env to apply immediately.
 func (a *App) UpdateClaudeSessionEnvironment(sessionID string, variables map[string]string) error {
 	if a.claudeManager == nil {
-		return fmt.Errorf("claude manager not initialized")
+		return a.unavailable(subsystemClaude)
 	}
 	return a.claudeManager.UpdateSessionEnvironment(sessionID, variables)
 }
@@ -2186,7 +2530,7 @@ func (a *App) UpdateClaudeSessionEnvironment(sessionID string, variables map[str
 // API endpoint).
 func (a *App) SwitchClaudeSessionProviderApi(sessionID, providerApiID string) error {
 	if a.claudeManager == nil {
-		return fmt.Errorf("claude manager not initialized")
+		return a.unavailable(subsystemClaude)
 	}
 
 	variables := map[string]string{
@@ -2196,7 +2540,7 @@ func (a *App) SwitchClaudeSessionProviderApi(sessionID, providerApiID string) er
 
 	if providerApiID != "" {
 		if a.dbManager == nil {
-			return fmt.Errorf("database manager not initialized")
+			return a.unavailable(subsystemDatabase)
 		}
 		apiConfig, err := a.dbManager.GetProviderApiConfig(providerApiID)
 		if err != nil {
@@ -2251,11 +2595,11 @@ func (a *App) ListRunningClaudeSessions() []*claude.SessionStatus {
 
 func (a *App) GetClaudeSessionActivities(sessionID string) (claudeactivity.Snapshot, error) {
 	if a.claudeActivity == nil {
-		return claudeactivity.Snapshot{}, fmt.Errorf("claude activity service not initialized")
+		return claudeactivity.Snapshot{}, a.unavailable(subsystemClaude)
 	}
 	snapshot, err := a.claudeActivity.GetSnapshot(sessionID)
 	if err != nil {
-		log.Printf("[GetClaudeSessionActivities] session=%s error=%v", sessionID, err)
+		slog.Error("failed to read session activities", "component", "GetClaudeSessionActivities", "session_id", sessionID, "error", err)
 		return claudeactivity.Snapshot{}, err
 	}
 	log.Printf(
@@ -2274,11 +2618,11 @@ func (a *App) GetClaudeSessionActivities(sessionID string) (claudeactivity.Snaps
 
 func (a *App) GetClaudeActivityLogTail(sessionID, activityID string, maxLines int) (claudeactivity.LogTail, error) {
 	if a.claudeActivity == nil {
-		return claudeactivity.LogTail{}, fmt.Errorf("claude activity service not initialized")
+		return claudeactivity.LogTail{}, a.unavailable(subsystemClaude)
 	}
 	tail, err := a.claudeActivity.GetLogTail(sessionID, activityID, maxLines)
 	if err != nil {
-		log.Printf("[GetClaudeActivityLogTail] session=%s activity=%s max_lines=%d error=%v", sessionID, activityID, maxLines, err)
+		slog.Error("failed to read activity log", "component", "GetClaudeActivityLogTail", "session_id", sessionID, "activity", activityID, "max_lines", maxLines, "error", err)
 		return claudeactivity.LogTail{}, err
 	}
 	log.Printf(
@@ -2299,7 +2643,7 @@ func (a *App) GetClaudeActivityLogTail(sessionID, activityID string, maxLines in
 
 func (a *App) StopClaudeActivity(sessionID, activityID string) error {
 	if a.claudeActivity == nil {
-		return fmt.Errorf("claude activity service not initialized")
+		return a.unavailable(subsystemClaude)
 	}
 	log.Printf("[StopClaudeActivity] session=%s activity=%s", sessionID, activityID)
 	return a.claudeActivity.StopActivity(sessionID, activityID)
@@ -2307,11 +2651,11 @@ func (a *App) StopClaudeActivity(sessionID, activityID string) error {
 
 func (a *App) ReadClaudeSubagentLog(sessionID, activityID string, since int) (claudeactivity.SubagentLogChunk, error) {
 	if a.claudeActivity == nil {
-		return claudeactivity.SubagentLogChunk{}, fmt.Errorf("claude activity service not initialized")
+		return claudeactivity.SubagentLogChunk{}, a.unavailable(subsystemClaude)
 	}
 	chunk, err := a.claudeActivity.ReadSubagentLog(sessionID, activityID, since)
 	if err != nil {
-		log.Printf("[ReadClaudeSubagentLog] session=%s activity=%s since=%d error=%v", sessionID, activityID, since, err)
+		slog.Error("failed to read subagent log", "component", "ReadClaudeSubagentLog", "session_id", sessionID, "activity", activityID, "since", since, "error", err)
 		return claudeactivity.SubagentLogChunk{}, err
 	}
 	log.Printf(
@@ -2333,7 +2677,7 @@ func (a *App) ReadClaudeSubagentLog(sessionID, activityID string, since int) (cl
 // GetClaudeSessionOutput returns the output of a session
 func (a *App) GetClaudeSessionOutput(sessionID string) (string, error) {
 	if a.claudeManager == nil {
-		return "", nil
+		return "", a.unavailable(subsystemClaude)
 	}
 	return a.claudeManager.GetSessionOutput(sessionID)
 }
@@ -2346,7 +2690,8 @@ func (a *App) GetClaudeBinaryPath() string {
 	return a.claudeManager.GetBinaryPath()
 }
 
-// SetClaudeBinaryPath sets the binary path for both claude manager and mcp manager
+// SetClaudeBinaryPath sets the binary path for both claude manager and mcp
+// manager until the app quits; SwitchClaudeInstallation keeps the choice
 func (a *App) SetClaudeBinaryPath(path string) {
 	if a.claudeManager != nil {
 		a.claudeManager.SetBinaryPath(path)
@@ -2361,7 +2706,7 @@ func (a *App) SetClaudeBinaryPath(path string) {
 // GetClaudeSettings returns the Claude settings from ~/.claude/settings.json
 func (a *App) GetClaudeSettings() (map[string]interface{}, error) {
 	if a.config == nil {
-		return nil, nil
+		return nil, a.unavailable(subsystemConfig)
 	}
 	settingsPath := filepath.Join(a.config.ClaudeDir, "settings.json")
 	return claude.LoadSettings(settingsPath)
@@ -2378,7 +2723,7 @@ func (a *App) SaveClaudeSettings(settings map[string]interface{}) error {
 	log.Printf("SaveClaudeSettings: saving to %s", settingsPath)
 	err := claude.SaveSettings(settingsPath, settings)
 	if err != nil {
-		log.Printf("SaveClaudeSettings: error saving: %v", err)
+		slog.Error("error saving", "component", "SaveClaudeSettings", "error", err)
 	} else {
 		log.Printf("SaveClaudeSettings: saved successfully")
 	}
@@ -2388,7 +2733,7 @@ func (a *App) SaveClaudeSettings(settings map[string]interface{}) error {
 // GetSystemPrompt returns the global system prompt from ~/.claude/CLAUDE.md
 func (a *App) GetSystemPrompt() (string, error) {
 	if a.config == nil {
-		return "", nil
+		return "", a.unavailable(subsystemConfig)
 	}
 	return claude.GetSystemPrompt(a.config.ClaudeDir)
 }
@@ -2396,7 +2741,7 @@ func (a *App) GetSystemPrompt() (string, error) {
 // SaveSystemPrompt saves the global system prompt
 func (a *App) SaveSystemPrompt(content string) error {
 	if a.config == nil {
-		return nil
+		return a.unavailable(subsystemConfig)
 	}
 	return claude.SaveSystemPrompt(a.config.ClaudeDir, content)
 }
@@ -2406,6 +2751,15 @@ func (a *App) FindClaudeMdFiles(projectPath string) ([]claude.ClaudeMdFile, erro
 	return claude.FindClaudeMdFiles(projectPath)
 }
 
+// GetEffectiveMemory returns the CLAUDE.md files Claude reads in a project,
+// in its resolution order, and their merged content
+func (a *App) GetEffectiveMemory(projectPath string) (*claude.EffectiveMemory, error) {
+	if a.config == nil {
+		return nil, a.unavailable(subsystemConfig)
+	}
+	return claude.ResolveMemory(a.config.ClaudeDir, projectPath)
+}
+
 // ReadClaudeMdFile reads a CLAUDE.md file content
 func (a *App) ReadClaudeMdFile(path string) (string, error) {
 	data, err := os.ReadFile(path)
@@ -2423,7 +2777,7 @@ func (a *App) SaveClaudeMdFile(path, content string) error {
 // GetProviderSystemPrompt returns the provider system prompt from ~/.claude/providers/{provider}.md
 func (a *App) GetProviderSystemPrompt(provider string) (string, error) {
 	if a.config == nil {
-		return "", nil
+		return "", a.unavailable(subsystemConfig)
 	}
 	return claude.GetProviderSystemPrompt(a.config.ClaudeDir, provider)
 }
@@ -2431,7 +2785,7 @@ func (a *App) GetProviderSystemPrompt(provider string) (string, error) {
 // SaveProviderSystemPrompt saves the provider system prompt to ~/.claude/providers/{provider}.md
 func (a *App) SaveProviderSystemPrompt(provider, content string) (string, error) {
 	if a.config == nil {
-		return "", nil
+		return "", a.unavailable(subsystemConfig)
 	}
 	return claude.SaveProviderSystemPrompt(a.config.ClaudeDir, provider, content)
 }
@@ -2441,7 +2795,7 @@ func (a *App) SaveProviderSystemPrompt(provider, content string) (string, error)
 // ListAgents returns all agents
 func (a *App) ListAgents() ([]*database.Agent, error) {
 	if a.dbManager == nil {
-		return nil, nil
+		return nil, a.unavailable(subsystemDatabase)
 	}
 	return a.dbManager.ListAgents()
 }
@@ -2449,15 +2803,19 @@ func (a *App) ListAgents() ([]*database.Agent, error) {
 // GetAgent returns a single agent
 func (a *App) GetAgent(id int64) (*database.Agent, error) {
 	if a.dbManager == nil {
-		return nil, nil
+		return nil, a.unavailable(subsystemDatabase)
 	}
 	return a.dbManager.GetAgent(id)
 }
 
-// CreateAgent creates a new agent
-func (a *App) CreateAgent(name, icon, systemPrompt, defaultTask, model, providerApiID, hooks string) (int64, error) {
+// CreateAgent creates a new agent. An empty provider defaults to Claude.
+func (a *App) CreateAgent(name, icon, systemPrompt, defaultTask, model, providerApiID, hooks, provider string) (int64, error) {
 	if a.dbManager == nil {
-		return 0, nil
+		return 0, a.unavailable(subsystemDatabase)
+	}
+	provider, err := validateAgentProvider(provider)
+	if err != nil {
+		return 0, err
 	}
 	agent := &database.Agent{
 		Name:          name,
@@ -2465,16 +2823,21 @@ func (a *App) CreateAgent(name, icon, systemPrompt, defaultTask, model, provider
 		SystemPrompt:  systemPrompt,
 		DefaultTask:   defaultTask,
 		Model:         model,
+		Provider:      provider,
 		ProviderApiID: providerApiID,
 		Hooks:         hooks,
 	}
 	return a.dbManager.CreateAgent(agent)
 }
 
-// UpdateAgent updates an existing agent
-func (a *App) UpdateAgent(id int64, name, icon, systemPrompt, defaultTask, model, providerApiID, hooks string) error {
+// UpdateAgent updates an existing agent. An empty provider defaults to Claude.
+func (a *App) UpdateAgent(id int64, name, icon, systemPrompt, defaultTask, model, providerApiID, hooks, provider string) error {
 	if a.dbManager == nil {
-		return nil
+		return a.unavailable(subsystemDatabase)
+	}
+	provider, err := validateAgentProvider(provider)
+	if err != nil {
+		return err
 	}
 	agent := &database.Agent{
 		ID:            id,
@@ -2483,6 +2846,7 @@ func (a *App) UpdateAgent(id int64, name, icon, systemPrompt, defaultTask, model
 		SystemPrompt:  systemPrompt,
 		DefaultTask:   defaultTask,
 		Model:         model,
+		Provider:      provider,
 		ProviderApiID: providerApiID,
 		Hooks:         hooks,
 	}
@@ -2492,7 +2856,7 @@ func (a *App) UpdateAgent(id int64, name, icon, systemPrompt, defaultTask, model
 // DeleteAgent deletes an agent
 func (a *App) DeleteAgent(id int64) error {
 	if a.dbManager == nil {
-		return nil
+		return a.unavailable(subsystemDatabase)
 	}
 	return a.dbManager.DeleteAgent(id)
 }
@@ -2500,7 +2864,7 @@ func (a *App) DeleteAgent(id int64) error {
 // ExportAgent exports an agent as JSON string
 func (a *App) ExportAgent(id int64) (string, error) {
 	if a.dbManager == nil {
-		return "", nil
+		return "", a.unavailable(subsystemDatabase)
 	}
 	return a.dbManager.ExportAgent(id)
 }
@@ -2508,7 +2872,7 @@ func (a *App) ExportAgent(id int64) (string, error) {
 // ExportAgentToFile exports an agent to a file
 func (a *App) ExportAgentToFile(id int64, path string) error {
 	if a.dbManager == nil {
-		return nil
+		return a.unavailable(subsystemDatabase)
 	}
 	return a.dbManager.ExportAgentToFile(id, path)
 }
@@ -2516,7 +2880,7 @@ func (a *App) ExportAgentToFile(id int64, path string) error {
 // ImportAgent imports an agent from JSON string
 func (a *App) ImportAgent(data string) (*database.Agent, error) {
 	if a.dbManager == nil {
-		return nil, nil
+		return nil, a.unavailable(subsystemDatabase)
 	}
 	return a.dbManager.ImportAgent(data)
 }
@@ -2524,7 +2888,7 @@ func (a *App) ImportAgent(data string) (*database.Agent, error) {
 // ImportAgentFromFile imports an agent from a file
 func (a *App) ImportAgentFromFile(path string) (*database.Agent, error) {
 	if a.dbManager == nil {
-		return nil, nil
+		return nil, a.unavailable(subsystemDatabase)
 	}
 	return a.dbManager.ImportAgentFromFile(path)
 }
@@ -2549,7 +2913,7 @@ func (a *App) GetCachedClaudeCapabilityLayers(projectPath string) (*claudeCapabi
 func (a *App) PrewarmClaudeCapabilityLayers(projectPath string) {
 	service, err := a.getClaudeCapabilityDiscovery()
 	if err != nil {
-		log.Printf("[capability-discovery] prewarm init failed: %v", err)
+		slog.Warn("prewarm init failed", "component", "capability-discovery", "error", err)
 		return
 	}
 
@@ -2617,14 +2981,46 @@ func (a *App) GetSlashCommand(name, projectPath string) (*claude.SlashCommand, e
 	return claude.GetSlashCommand(name, projectPath)
 }
 
-// SaveSlashCommand saves a slash command to the appropriate location
-func (a *App) SaveSlashCommand(name, content, scope, projectPath string) error {
-	return claude.SaveSlashCommand(name, content, scope, projectPath)
+// SaveSlashCommand saves a slash command to the appropriate location;
+// commandType is "claude" (the default when empty) or "codex"
+func (a *App) SaveSlashCommand(name, content, scope, projectPath, commandType string) error {
+	return claude.SaveSlashCommand(name, content, scope, projectPath, claude.CommandType(commandType))
+}
+
+// ExpandSlashCommand previews the prompt the command name sends with args:
+// arguments filled in, !`bash` blocks run and @file references inlined.
+// Without a sandbox the blocks only run when allowUnsandboxed is set.
+func (a *App) ExpandSlashCommand(name, args, projectPath string, allowUnsandboxed bool) (*slashexpand.Expansion, error) {
+	cmd, err := claude.ResolveSlashCommand(name, projectPath)
+	if err != nil {
+		return nil, err
+	}
+	if cmd.Scope == "default" {
+		return nil, fmt.Errorf("%s is built into the CLI and has no prompt to preview", cmd.FullCommand)
+	}
+	ctx := a.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return slashexpand.Expand(ctx, cmd.Content, string(cmd.CommandType), args, projectPath, allowUnsandboxed), nil
+}
+
+// SaveSlashCommand2 creates or updates a slash command from its fields,
+// moving it when an edit changes its name, namespace, scope or type
+func (a *App) SaveSlashCommand2(input claude.SlashCommandInput) (*claude.SlashCommand, error) {
+	return claude.SaveSlashCommand2(input)
+}
+
+// DeleteSlashCommand deletes a slash command; commandType is "claude"
+// (the default when empty) or "codex"
+func (a *App) DeleteSlashCommand(name, scope, projectPath, commandType string) error {
+	return claude.DeleteSlashCommand(name, scope, projectPath, claude.CommandType(commandType))
 }
 
-// DeleteSlashCommand deletes a slash command
-func (a *App) DeleteSlashCommand(name, scope, projectPath string) error {
-	return claude.DeleteSlashCommand(name, scope, projectPath)
+// DeleteSlashCommandFile deletes the user or project command stored at
+// filePath
+func (a *App) DeleteSlashCommandFile(filePath, projectPath string) error {
+	return claude.DeleteSlashCommandFile(filePath, projectPath)
 }
 
 // ===== Claude Config Agents Bindings =====
@@ -2654,7 +3050,7 @@ func (a *App) DeleteClaudeConfigAgent(scope, name, projectPath string) error {
 // GetAllModelConfigs retrieves all model configurations
 func (a *App) GetAllModelConfigs() ([]*database.ModelConfig, error) {
 	if a.modelRegistry == nil {
-		return []*database.ModelConfig{}, nil
+		return []*database.ModelConfig{}, a.unavailable(subsystemModels)
 	}
 	configs, err := a.modelRegistry.GetAllModels()
 	if err != nil {
@@ -2669,7 +3065,7 @@ func (a *App) GetAllModelConfigs() ([]*database.ModelConfig, error) {
 // GetEnabledModelConfigs retrieves all enabled model configurations
 func (a *App) GetEnabledModelConfigs() ([]*database.ModelConfig, error) {
 	if a.modelRegistry == nil {
-		return []*database.ModelConfig{}, nil
+		return []*database.ModelConfig{}, a.unavailable(subsystemModels)
 	}
 	configs, err := a.modelRegistry.GetEnabledModels()
 	if err != nil {
@@ -2696,8 +3092,11 @@ const anthropicVersion = "2023-06-01"
 // (OpenAI- or Anthropic-shaped) and stores missing supported models as
 // user-defined configs.
 func (a *App) SyncProviderModelsFromAPI(providerID, providerApiID string) ([]*database.ModelConfig, error) {
-	if a.modelRegistry == nil || a.dbManager == nil {
-		return []*database.ModelConfig{}, nil
+	if a.dbManager == nil {
+		return nil, a.unavailable(subsystemDatabase)
+	}
+	if a.modelRegistry == nil {
+		return nil, a.unavailable(subsystemModels)
 	}
 	providerID = strings.TrimSpace(providerID)
 	if providerID == "" {
@@ -2779,7 +3178,7 @@ func (a *App) resolveProviderAPIConfig(providerID, providerApiID string) (*datab
 func codexConfigToProviderAPI() *database.ProviderApiConfig {
 	provider, err := codex.LoadActiveProvider()
 	if err != nil {
-		log.Printf("[ModelsSync] codex config load failed: %v", err)
+		slog.Warn("codex config load failed", "component", "ModelsSync", "error", err)
 		return nil
 	}
 	if provider == nil || strings.TrimSpace(provider.BaseURL) == "" {
@@ -2877,7 +3276,7 @@ func doModelsListRequest(req *http.Request, label string) ([]string, error) {
 	client := &http.Client{Timeout: 30 * time.Second}
 	resp, err := client.Do(req)
 	if err != nil {
-		log.Printf("[ModelsSync] %s request error: %v", label, err)
+		slog.Warn("request error", "component", "ModelsSync", "request", label, "error", err)
 		return nil, err
 	}
 	defer resp.Body.Close()
@@ -2934,7 +3333,7 @@ func looksLikeHTML(contentType string, body []byte) bool {
 // GetModelConfigsByProvider retrieves all model configurations for a specific provider
 func (a *App) GetModelConfigsByProvider(providerID string) ([]*database.ModelConfig, error) {
 	if a.modelRegistry == nil {
-		return []*database.ModelConfig{}, nil
+		return []*database.ModelConfig{}, a.unavailable(subsystemModels)
 	}
 	configs, err := a.modelRegistry.GetModelsByProvider(providerID)
 	if err != nil {
@@ -2949,7 +3348,7 @@ func (a *App) GetModelConfigsByProvider(providerID string) ([]*database.ModelCon
 // GetModelConfig retrieves a model configuration by ID
 func (a *App) GetModelConfig(id string) (*database.ModelConfig, error) {
 	if a.modelRegistry == nil {
-		return nil, nil
+		return nil, a.unavailable(subsystemModels)
 	}
 	return a.modelRegistry.GetModel(id)
 }
@@ -2957,7 +3356,7 @@ func (a *App) GetModelConfig(id string) (*database.ModelConfig, error) {
 // GetModelConfigByModelID retrieves a model configuration by model_id
 func (a *App) GetModelConfigByModelID(modelID string) (*database.ModelConfig, error) {
 	if a.modelRegistry == nil {
-		return nil, nil
+		return nil, a.unavailable(subsystemModels)
 	}
 	return a.modelRegistry.GetModelByModelID(modelID)
 }
@@ -2965,7 +3364,7 @@ func (a *App) GetModelConfigByModelID(modelID string) (*database.ModelConfig, er
 // GetDefaultModelConfig retrieves the default model configuration for a provider
 func (a *App) GetDefaultModelConfig(providerID string) (*database.ModelConfig, error) {
 	if a.modelRegistry == nil {
-		return nil, nil
+		return nil, a.unavailable(subsystemModels)
 	}
 	return a.modelRegistry.GetDefaultModel(providerID)
 }
@@ -2973,7 +3372,7 @@ func (a *App) GetDefaultModelConfig(providerID string) (*database.ModelConfig, e
 // CreateModelConfig creates a new user-defined model configuration
 func (a *App) CreateModelConfig(config *database.ModelConfig) error {
 	if a.modelRegistry == nil {
-		return nil
+		return a.unavailable(subsystemModels)
 	}
 	return a.modelRegistry.CreateModel(config)
 }
@@ -2981,7 +3380,7 @@ func (a *App) CreateModelConfig(config *database.ModelConfig) error {
 // UpdateModelConfig updates a user-defined model configuration
 func (a *App) UpdateModelConfig(id string, config *database.ModelConfig) error {
 	if a.modelRegistry == nil {
-		return nil
+		return a.unavailable(subsystemModels)
 	}
 	return a.modelRegistry.UpdateModel(id, config)
 }
@@ -2989,7 +3388,7 @@ func (a *App) UpdateModelConfig(id string, config *database.ModelConfig) error {
 // DeleteModelConfig deletes a user-defined model configuration
 func (a *App) DeleteModelConfig(id string) error {
 	if a.modelRegistry == nil {
-		return nil
+		return a.unavailable(subsystemModels)
 	}
 	return a.modelRegistry.DeleteModel(id)
 }
@@ -2997,7 +3396,7 @@ func (a *App) DeleteModelConfig(id string) error {
 // SetModelConfigEnabled enables or disables a model configuration
 func (a *App) SetModelConfigEnabled(id string, enabled bool) error {
 	if a.modelRegistry == nil {
-		return nil
+		return a.unavailable(subsystemModels)
 	}
 	return a.modelRegistry.SetModelEnabled(id, enabled)
 }
@@ -3005,7 +3404,7 @@ func (a *App) SetModelConfigEnabled(id string, enabled bool) error {
 // SetModelConfigDefault sets a model as the default for its provider
 func (a *App) SetModelConfigDefault(id string) error {
 	if a.modelRegistry == nil {
-		return nil
+		return a.unavailable(subsystemModels)
 	}
 	return a.modelRegistry.SetDefaultModel(id)
 }
@@ -3013,7 +3412,7 @@ func (a *App) SetModelConfigDefault(id string) error {
 // GetModelThinkingLevels retrieves the thinking levels for a model
 func (a *App) GetModelThinkingLevels(modelID string) ([]database.ThinkingLevel, error) {
 	if a.modelRegistry == nil {
-		return []database.ThinkingLevel{}, nil
+		return []database.ThinkingLevel{}, a.unavailable(subsystemModels)
 	}
 	levels, err := a.modelRegistry.GetThinkingLevels(modelID)
 	if err != nil {
@@ -3028,17 +3427,18 @@ func (a *App) GetModelThinkingLevels(modelID string) ([]database.ThinkingLevel,
 // GetDefaultThinkingLevel retrieves the default thinking level for a model
 func (a *App) GetDefaultThinkingLevel(modelID string) (*database.ThinkingLevel, error) {
 	if a.modelRegistry == nil {
-		return nil, nil
+		return nil, a.unavailable(subsystemModels)
 	}
 	return a.modelRegistry.GetDefaultThinkingLevel(modelID)
 }
 
 // ===== Agent Run Bindings =====
 
-// ExecuteAgent starts an agent run with the specified parameters
+// ExecuteAgent starts an agent run with the specified parameters on the
+// agent's provider (claude, codex or gemini).
 func (a *App) ExecuteAgent(agentID int64, projectPath, task, model string) (*database.AgentRun, error) {
-	if a.dbManager == nil || a.claudeManager == nil {
-		return nil, nil
+	if a.dbManager == nil {
+		return nil, a.unavailable(subsystemDatabase)
 	}
 
 	// Get the agent
@@ -3046,6 +3446,13 @@ func (a *App) ExecuteAgent(agentID int64, projectPath, task, model string) (*dat
 	if err != nil {
 		return nil, err
 	}
+	provider, err := validateAgentProvider(agent.Provider)
+	if err != nil {
+		return nil, err
+	}
+	if a.sessionManagerFor(provider) == nil {
+		return nil, fmt.Errorf("%s manager not initialized", provider)
+	}
 
 	// Create the agent run record
 	run := &database.AgentRun{
@@ -3054,6 +3461,7 @@ func (a *App) ExecuteAgent(agentID int64, projectPath, task, model string) (*dat
 		AgentIcon:   agent.Icon,
 		Task:        task,
 		Model:       model,
+		Provider:    provider,
 		ProjectPath: projectPath,
 		Status:      "pending",
 	}
@@ -3070,18 +3478,12 @@ func (a *App) ExecuteAgent(agentID int64, projectPath, task, model string) (*dat
 		prompt = prompt + "\n\n---\n\nTask: " + task
 	}
 
-	// Start the Claude session
-	config := claude.SessionConfig{
-		ProjectPath:   projectPath,
-		Prompt:        prompt,
-		Model:         model,
-		ProviderApiID: agent.ProviderApiID,
-	}
-
-	sessionID, err := a.claudeManager.StartSession(config)
+	// Start the provider session
+	sessionID, err := a.StartProviderSession(provider, projectPath, prompt, model, agent.ProviderApiID, "")
 	if err != nil {
 		// Update run status to failed
 		a.dbManager.UpdateAgentRunStatus(runID, "failed", 0, nil, nil)
+		a.notifyAgentRunFailed(run)
 		return nil, err
 	}
 
@@ -3090,24 +3492,27 @@ func (a *App) ExecuteAgent(agentID int64, projectPath, task, model string) (*dat
 	run.Status = "running"
 	now := run.CreatedAt
 	run.ProcessStartedAt = &now
+	run.PID = a.providerSessionPID(sessionID)
 
-	// Get PID from session status if available
-	if status := a.claudeManager.GetSession(sessionID); status != nil {
-		run.PID = status.PID
+	if err := a.dbManager.UpdateAgentRunSession(runID, sessionID); err != nil {
+		return nil, err
 	}
-
 	err = a.dbManager.UpdateAgentRunStatus(runID, "running", run.PID, run.ProcessStartedAt, nil)
 	if err != nil {
 		return nil, err
 	}
 
+	tracked := *run
+	go a.trackAgentRun(&tracked)
+	a.recordTelemetry(telemetryKindFeature, "agent.run."+provider, 0)
+
 	return run, nil
 }
 
 // ListAgentRuns returns agent runs, optionally filtered by agent ID
 func (a *App) ListAgentRuns(agentID int64, limit int) ([]*database.AgentRun, error) {
 	if a.dbManager == nil {
-		return nil, nil
+		return nil, a.unavailable(subsystemDatabase)
 	}
 	var agentIDPtr *int64
 	if agentID > 0 {
@@ -3122,7 +3527,7 @@ func (a *App) ListAgentRuns(agentID int64, limit int) ([]*database.AgentRun, err
 // GetAgentRun retrieves an agent run by ID
 func (a *App) GetAgentRun(id int64) (*database.AgentRun, error) {
 	if a.dbManager == nil {
-		return nil, nil
+		return nil, a.unavailable(subsystemDatabase)
 	}
 	return a.dbManager.GetAgentRun(id)
 }
@@ -3130,7 +3535,7 @@ func (a *App) GetAgentRun(id int64) (*database.AgentRun, error) {
 // GetAgentRunBySessionID retrieves an agent run by session ID
 func (a *App) GetAgentRunBySessionID(sessionID string) (*database.AgentRun, error) {
 	if a.dbManager == nil {
-		return nil, nil
+		return nil, a.unavailable(subsystemDatabase)
 	}
 	return a.dbManager.GetAgentRunBySessionID(sessionID)
 }
@@ -3138,15 +3543,15 @@ func (a *App) GetAgentRunBySessionID(sessionID string) (*database.AgentRun, erro
 // ListRunningAgentRuns returns all currently running agent runs
 func (a *App) ListRunningAgentRuns() ([]*database.AgentRun, error) {
 	if a.dbManager == nil {
-		return nil, nil
+		return nil, a.unavailable(subsystemDatabase)
 	}
 	return a.dbManager.ListRunningAgentRuns()
 }
 
 // CancelAgentRun cancels a running agent
 func (a *App) CancelAgentRun(runID int64) error {
-	if a.dbManager == nil || a.claudeManager == nil {
-		return nil
+	if a.dbManager == nil {
+		return a.unavailable(subsystemDatabase)
 	}
 
 	run, err := a.dbManager.GetAgentRun(runID)
@@ -3154,9 +3559,9 @@ func (a *App) CancelAgentRun(runID int64) error {
 		return err
 	}
 
-	// Cancel the Claude session if it exists
-	if run.SessionID != "" {
-		a.claudeManager.TerminateSession(run.SessionID)
+	// Cancel the provider session if it exists
+	if manager := a.sessionManagerFor(run.Provider); manager != nil && run.SessionID != "" {
+		manager.TerminateSession(run.SessionID)
 	}
 
 	// Update run status
@@ -3167,15 +3572,15 @@ func (a *App) CancelAgentRun(runID int64) error {
 // DeleteAgentRun deletes an agent run
 func (a *App) DeleteAgentRun(id int64) error {
 	if a.dbManager == nil {
-		return nil
+		return a.unavailable(subsystemDatabase)
 	}
 	return a.dbManager.DeleteAgentRun(id)
 }
 
 // GetAgentRunOutput returns the output of an agent run's session
 func (a *App) GetAgentRunOutput(runID int64) (string, error) {
-	if a.dbManager == nil || a.claudeManager == nil {
-		return "", nil
+	if a.dbManager == nil {
+		return "", a.unavailable(subsystemDatabase)
 	}
 
 	run, err := a.dbManager.GetAgentRun(runID)
@@ -3183,11 +3588,12 @@ func (a *App) GetAgentRunOutput(runID int64) (string, error) {
 		return "", err
 	}
 
-	if run.SessionID == "" {
+	manager := a.sessionManagerFor(run.Provider)
+	if manager == nil || run.SessionID == "" {
 		return "", nil
 	}
 
-	return a.claudeManager.GetSessionOutput(run.SessionID)
+	return manager.GetSessionOutput(run.SessionID)
 }
 
 // ===== Hooks Bindings =====
@@ -3195,7 +3601,7 @@ func (a *App) GetAgentRunOutput(runID int64) (string, error) {
 // GetHooks returns all hooks configuration from ~/.claude/settings.json
 func (a *App) GetHooks() (*claude.HooksConfig, error) {
 	if a.config == nil {
-		return nil, nil
+		return nil, a.unavailable(subsystemConfig)
 	}
 	return claude.GetHooks(a.config.ClaudeDir)
 }
@@ -3203,19 +3609,97 @@ func (a *App) GetHooks() (*claude.HooksConfig, error) {
 // SaveHooks saves the hooks configuration to ~/.claude/settings.json
 func (a *App) SaveHooks(hooks *claude.HooksConfig) error {
 	if a.config == nil {
-		return nil
+		return a.unavailable(subsystemConfig)
 	}
 	return claude.SaveHooks(a.config.ClaudeDir, hooks)
 }
 
+// GetProjectHooks returns the hooks of a project; scope is "project"
+// (.claude/settings.json, the default when empty) or "local"
+// (.claude/settings.local.json)
+func (a *App) GetProjectHooks(projectPath, scope string) (*claude.HooksConfig, error) {
+	if a.config == nil {
+		return nil, a.unavailable(subsystemConfig)
+	}
+	scope, err := projectHookScope(scope)
+	if err != nil {
+		return nil, err
+	}
+	return claude.GetScopedHooks(a.config.ClaudeDir, scope, projectPath)
+}
+
+// SaveProjectHooks saves the hooks of a project; scope is as for
+// GetProjectHooks
+func (a *App) SaveProjectHooks(projectPath string, hooks *claude.HooksConfig, scope string) error {
+	if a.config == nil {
+		return a.unavailable(subsystemConfig)
+	}
+	scope, err := projectHookScope(scope)
+	if err != nil {
+		return err
+	}
+	return claude.SaveScopedHooks(a.config.ClaudeDir, scope, projectPath, hooks)
+}
+
+// DeleteHookMatcher removes a hook matcher from one scope ("user",
+// "project" or "local"); projectPath is ignored for user hooks
+func (a *App) DeleteHookMatcher(scope, projectPath, hookType, matcher string) error {
+	if a.config == nil {
+		return a.unavailable(subsystemConfig)
+	}
+	return claude.DeleteHookMatcher(a.config.ClaudeDir, scope, projectPath, hookType, matcher)
+}
+
+// projectHookScope checks a project hook scope, defaulting to project
+func projectHookScope(scope string) (string, error) {
+	switch scope {
+	case "":
+		return claude.HookScopeProject, nil
+	case claude.HookScopeProject, claude.HookScopeLocal:
+		return scope, nil
+	default:
+		return "", fmt.Errorf("project hooks are project or local, not %q", scope)
+	}
+}
+
 // GetHooksByType returns hooks for a specific type (PreToolUse, PostToolUse, Notification, Stop)
 func (a *App) GetHooksByType(hookType string) ([]claude.HookMatcher, error) {
 	if a.config == nil {
-		return nil, nil
+		return nil, a.unavailable(subsystemConfig)
 	}
 	return claude.GetHooksByType(a.config.ClaudeDir, hookType)
 }
 
+// GetHookTemplates returns the built-in hook templates
+func (a *App) GetHookTemplates() []claude.HookTemplate {
+	return claude.HookTemplates()
+}
+
+// InsertHookTemplate adds a hook template to one scope ("user",
+// "project" or "local") and returns the scope's hooks
+func (a *App) InsertHookTemplate(scope, projectPath, templateID string) (*claude.HooksConfig, error) {
+	if a.config == nil {
+		return nil, a.unavailable(subsystemConfig)
+	}
+	return claude.InsertHookTemplate(a.config.ClaudeDir, scope, projectPath, templateID)
+}
+
+// SampleHookPayload returns a synthetic payload of a hook event for
+// RunHookTest
+func (a *App) SampleHookPayload(event, toolName, cwd string) string {
+	return claude.SampleHookPayload(event, toolName, cwd)
+}
+
+// RunHookTest runs a hook with samplePayload on stdin and returns its
+// output and exit code
+func (a *App) RunHookTest(hook claude.Hook, samplePayload string) (*claude.HookTestResult, error) {
+	ctx := a.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return claude.RunHookTest(ctx, hook, samplePayload)
+}
+
 // ===== Command Execution Bindings =====
 
 // CommandResult represents the result of a command execution
@@ -3225,9 +3709,12 @@ type CommandResult struct {
 	Error   string `json:"error"`
 }
 
-// ExecuteCommand executes a shell command string synchronously and returns the output.
+// ExecuteCommand executes a command string in the preferred shell synchronously and returns the output.
+//
+// Deprecated: it blocks the caller until the command exits, with no timeout;
+// use RunJob.
 func (a *App) ExecuteCommand(cmd string, cwd string) CommandResult {
-	r := command.Execute(cmd, cwd)
+	r := command.ExecuteIn(a.shellPreference(), cmd, cwd, a.projectEnvList(cwd))
 	return CommandResult{Success: r.Success, Output: r.Output, Error: r.Error}
 }
 
@@ -3238,10 +3725,11 @@ func (a *App) ReadGitFileAtHead(workspacePath, gitPath string) (string, error) {
 
 // ExecuteCommandWithArgs executes a command with arguments synchronously
 func (a *App) ExecuteCommandWithArgs(command string, args []string, cwd string) (string, error) {
-	// Use processManager to spawn and wait for the command
-	key := "sync-" + command + "-" + strings.Join(args, "-")
+	// Use processManager to spawn and wait for the command; the key is unique
+	// so concurrent runs of the same command don't replace each other
+	key := "sync-" + filepath.Base(command) + "-" + uuid.New().String()
 
-	proc, err := a.processManager.Spawn(key, command, args, cwd, nil)
+	proc, err := a.processManager.Spawn(key, command, args, cwd, a.projectCommandEnv(cwd))
 	if err != nil {
 		return "", err
 	}
@@ -3254,17 +3742,29 @@ func (a *App) ExecuteCommandWithArgs(command string, args []string, cwd string)
 	return "", nil
 }
 
-// ExecuteCommandAsync executes a command asynchronously and returns a process key
+// ExecuteCommandAsync executes a command asynchronously and returns its
+// process key, unique to this run. It runs as a job, so ListJobs reports it
+// and its output follows as job-output events; a preferred login shell
+// starts it, so it sees the login environment.
 func (a *App) ExecuteCommandAsync(command string, args []string, cwd string) (string, error) {
-	// Generate unique key for this async command
-	key := "async-" + command + "-" + strings.Join(args, "-")
-
-	_, err := a.processManager.Spawn(key, command, args, cwd, nil)
+	if a.jobRunner == nil {
+		return "", a.unavailable(subsystemProcesses)
+	}
+	if args == nil {
+		args = []string{}
+	}
+	job, err := a.jobRunner.Start(jobs.Spec{
+		Command: command,
+		Args:    args,
+		Cwd:     cwd,
+		Env:     a.projectEnv(cwd),
+		Shell:   a.shellPreference(),
+		Prefix:  "async-" + filepath.Base(command),
+	})
 	if err != nil {
 		return "", err
 	}
-
-	return key, nil
+	return job.Key, nil
 }
 
 // OpenInTerminal opens a directory in the platform's default terminal.
@@ -3300,7 +3800,7 @@ func (a *App) OpenUrl(url string) error {
 // ListMcpServers returns all configured MCP servers
 func (a *App) ListMcpServers() ([]*mcp.MCPServer, error) {
 	if a.mcpManager == nil {
-		return []*mcp.MCPServer{}, nil
+		return []*mcp.MCPServer{}, a.unavailable(subsystemMCP)
 	}
 	return a.mcpManager.ListMcpServers()
 }
@@ -3308,7 +3808,7 @@ func (a *App) ListMcpServers() ([]*mcp.MCPServer, error) {
 // GetMcpServer returns a specific MCP server configuration
 func (a *App) GetMcpServer(name string) (*mcp.MCPServer, error) {
 	if a.mcpManager == nil {
-		return nil, nil
+		return nil, a.unavailable(subsystemMCP)
 	}
 	return a.mcpManager.GetMcpServer(name)
 }
@@ -3316,7 +3816,7 @@ func (a *App) GetMcpServer(name string) (*mcp.MCPServer, error) {
 // SaveMcpServer saves or updates an MCP server configuration
 func (a *App) SaveMcpServer(name string, config *mcp.MCPServerConfig) error {
 	if a.mcpManager == nil {
-		return nil
+		return a.unavailable(subsystemMCP)
 	}
 	return a.mcpManager.SaveMcpServer(name, config)
 }
@@ -3324,7 +3824,7 @@ func (a *App) SaveMcpServer(name string, config *mcp.MCPServerConfig) error {
 // DeleteMcpServer removes an MCP server configuration
 func (a *App) DeleteMcpServer(name string) error {
 	if a.mcpManager == nil {
-		return nil
+		return a.unavailable(subsystemMCP)
 	}
 	return a.mcpManager.DeleteMcpServer(name)
 }
@@ -3332,7 +3832,16 @@ func (a *App) DeleteMcpServer(name string) error {
 // GetMcpServerStatus returns the runtime status of an MCP server
 func (a *App) GetMcpServerStatus(name string) (*mcp.MCPServerStatus, error) {
 	if a.mcpManager == nil {
-		return nil, nil
+		return nil, a.unavailable(subsystemMCP)
+	}
+	if a.mcpSupervisor != nil {
+		if status, ok := a.mcpSupervisor.Status(name); ok {
+			return &mcp.MCPServerStatus{
+				Running:     status.State == mcp.ServerRunning,
+				Error:       status.LastError,
+				LastChecked: status.LastHealthCheck,
+			}, nil
+		}
 	}
 	return a.mcpManager.GetMcpServerStatus(name)
 }
@@ -3372,7 +3881,7 @@ func (a *App) scanRopcodeWorktrees(projectPath string) ([]database.WorkspaceInde
 
 		// Get branch name using git command
 		branch := entry.Name()
-		cmd := exec.Command("git", "rev-parse", "--abbrev-ref", "HEAD")
+		cmd := git.Command("rev-parse", "--abbrev-ref", "HEAD")
 		cmd.Dir = worktreePath
 		if output, err := cmd.Output(); err == nil {
 			branch = strings.TrimSpace(string(output))
@@ -3401,7 +3910,7 @@ func (a *App) scanRopcodeWorktrees(projectPath string) ([]database.WorkspaceInde
 // AddProjectToIndex adds a project to the index
 func (a *App) AddProjectToIndex(path string) error {
 	if a.dbManager == nil {
-		return fmt.Errorf("database manager not initialized")
+		return a.unavailable(subsystemDatabase)
 	}
 
 	// Extract project name from path
@@ -3412,17 +3921,37 @@ func (a *App) AddProjectToIndex(path string) error {
 	_, gitErr := git.Open(path)
 	hasGitSupport := gitErr == nil
 
-	// Check if project already exists in database
-	existingProject, err := a.dbManager.GetProjectIndex(name)
-	if err == nil && existingProject != nil {
-		// Project exists - scan and merge new worktrees
+	// Scan .ropcode directory for existing worktrees
+	workspaces, scanErr := a.scanRopcodeWorktrees(path)
+	if scanErr != nil {
+		workspaces = []database.WorkspaceIndex{}
+	}
+
+	newProject := func() *database.ProjectIndex {
+		return &database.ProjectIndex{
+			Name:         name,
+			AddedAt:      now,
+			LastAccessed: now,
+			Available:    true,
+			Providers: []database.ProviderInfo{
+				{
+					ID:         name,
+					ProviderID: "claude",
+					Path:       path,
+				},
+			},
+			Workspaces:    workspaces,
+			LastProvider:  "claude",
+			HasGitSupport: &hasGitSupport,
+		}
+	}
+	// An indexed project only gains the worktrees it doesn't list yet
+	mergeProject := func(existingProject *database.ProjectIndex) error {
 		existingNames := make(map[string]bool)
 		for _, ws := range existingProject.Workspaces {
 			existingNames[ws.Name] = true
 		}
-
-		scannedWorkspaces, _ := a.scanRopcodeWorktrees(path)
-		for _, ws := range scannedWorkspaces {
+		for _, ws := range workspaces {
 			if !existingNames[ws.Name] {
 				existingProject.Workspaces = append(existingProject.Workspaces, ws)
 			}
@@ -3431,41 +3960,20 @@ func (a *App) AddProjectToIndex(path string) error {
 		existingProject.LastAccessed = now
 		// Update git support status
 		existingProject.HasGitSupport = &hasGitSupport
-		go a.PrewarmClaudeCapabilityLayers(path)
-		return a.dbManager.SaveProjectIndex(existingProject)
-	}
-
-	// Scan .ropcode directory for existing worktrees
-	workspaces, scanErr := a.scanRopcodeWorktrees(path)
-	if scanErr != nil {
-		workspaces = []database.WorkspaceIndex{}
+		return nil
 	}
-
-	project := &database.ProjectIndex{
-		Name:         name,
-		AddedAt:      now,
-		LastAccessed: now,
-		Available:    true,
-		Providers: []database.ProviderInfo{
-			{
-				ID:         name,
-				ProviderID: "claude",
-				Path:       path,
-			},
-		},
-		Workspaces:    workspaces,
-		LastProvider:  "claude",
-		HasGitSupport: &hasGitSupport,
+	if _, err := a.dbManager.CreateOrPatchProjectIndex(name, newProject, mergeProject); err != nil {
+		return err
 	}
 
 	go a.PrewarmClaudeCapabilityLayers(path)
-	return a.dbManager.SaveProjectIndex(project)
+	return nil
 }
 
 // RemoveProjectFromIndex removes a project from the index by ID (name)
 func (a *App) RemoveProjectFromIndex(id string) error {
 	if a.dbManager == nil {
-		return fmt.Errorf("database manager not initialized")
+		return a.unavailable(subsystemDatabase)
 	}
 	return a.dbManager.DeleteProjectIndex(id)
 }
@@ -3473,16 +3981,14 @@ func (a *App) RemoveProjectFromIndex(id string) error {
 // UpdateProjectAccessTime updates the last accessed time for a project
 func (a *App) UpdateProjectAccessTime(id string) error {
 	if a.dbManager == nil {
-		return fmt.Errorf("database manager not initialized")
-	}
-
-	project, err := a.dbManager.GetProjectIndex(id)
-	if err != nil {
-		return err
+		return a.unavailable(subsystemDatabase)
 	}
 
-	project.LastAccessed = time.Now().Unix()
-	return a.dbManager.SaveProjectIndex(project)
+	_, err := a.dbManager.PatchProjectIndex(id, func(project *database.ProjectIndex) error {
+		project.LastAccessed = time.Now().Unix()
+		return nil
+	})
+	return err
 }
 
 // CreateProject creates a new project directory structure
@@ -3496,15 +4002,17 @@ func (a *App) CreateProject(path string) error {
 	return a.AddProjectToIndex(path)
 }
 
-// GetProjectSessions returns session IDs for a project
-func (a *App) GetProjectSessions(id string) ([]string, error) {
+// GetProjectSessions returns the Claude sessions of project id, most
+// recent first, with a preview of their first prompt, their message count
+// and timestamps
+func (a *App) GetProjectSessions(id string) ([]claude.SessionSummary, error) {
 	if a.dbManager == nil {
-		return nil, fmt.Errorf("database manager not initialized")
+		return []claude.SessionSummary{}, a.unavailable(subsystemDatabase)
 	}
 
 	project, err := a.dbManager.GetProjectIndex(id)
 	if err != nil {
-		return nil, err
+		return []claude.SessionSummary{}, err
 	}
 
 	// Get the project path from providers
@@ -3516,20 +4024,25 @@ func (a *App) GetProjectSessions(id string) ([]string, error) {
 		}
 	}
 
-	if projectPath == "" {
-		return []string{}, nil
+	if projectPath == "" || a.config == nil {
+		return []claude.SessionSummary{}, nil
 	}
 
-	// Look for JSONL files in ~/.claude/projects/<encoded-path>/
-	// For simplicity, return empty array for now
-	// Full implementation would scan the Claude projects directory
-	return []string{}, nil
+	// Sessions are the JSONL files in ~/.claude/projects/<encoded-path>/
+	sessions, err := claude.ListProjectSessionSummaries(a.config.ClaudeDir, projectPath)
+	if err != nil {
+		return []claude.SessionSummary{}, err
+	}
+	if sessions == nil {
+		sessions = []claude.SessionSummary{}
+	}
+	return sessions, nil
 }
 
 // CreateWorkspace creates a new workspace (git worktree)
 func (a *App) CreateWorkspace(parent string, branch string, name string) error {
 	if a.dbManager == nil {
-		return fmt.Errorf("database manager not initialized")
+		return a.unavailable(subsystemDatabase)
 	}
 
 	// 1. Validate parent project path
@@ -3539,8 +4052,7 @@ func (a *App) CreateWorkspace(parent string, branch string, name string) error {
 
 	// Get parent project
 	parentName := filepath.Base(parent)
-	project, err := a.dbManager.GetProjectIndex(parentName)
-	if err != nil {
+	if _, err := a.dbManager.GetProjectIndex(parentName); err != nil {
 		return err
 	}
 
@@ -3561,7 +4073,7 @@ func (a *App) CreateWorkspace(parent string, branch string, name string) error {
 	// 4. Execute git worktree add
 	// Use -B to allow branch reset if it exists
 	// Syntax: git worktree add -B <branch> <path>
-	cmd := exec.Command("git", "worktree", "add", "-B", branch, workspacePath)
+	cmd := git.Command("worktree", "add", "-B", branch, workspacePath)
 	cmd.Dir = parent
 	output, err := cmd.CombinedOutput()
 	if err != nil {
@@ -3584,95 +4096,78 @@ func (a *App) CreateWorkspace(parent string, branch string, name string) error {
 	}
 
 	// Add workspace to project
-	project.Workspaces = append(project.Workspaces, workspace)
-
-	return a.dbManager.SaveProjectIndex(project)
+	_, err = a.dbManager.PatchProjectIndex(parentName, func(project *database.ProjectIndex) error {
+		project.Workspaces = append(project.Workspaces, workspace)
+		return nil
+	})
+	return err
 }
 
-// RemoveWorkspace removes a workspace from the index
-func (a *App) RemoveWorkspace(id string) error {
+// RemoveWorkspace removes a workspace from the index. With workspace
+// protection on it needs override set.
+func (a *App) RemoveWorkspace(id string, override bool) error {
 	if a.dbManager == nil {
-		return fmt.Errorf("database manager not initialized")
+		return a.unavailable(subsystemDatabase)
 	}
-
-	// Find parent project containing this workspace
-	projects, err := a.dbManager.GetAllProjectIndexes()
-	if err != nil {
+	if err := a.checkWorkspaceProtection("remove workspace "+id, override); err != nil {
 		return err
 	}
 
-	for _, project := range projects {
-		for i, workspace := range project.Workspaces {
-			if workspace.Name == id {
-				// Remove workspace from slice
-				project.Workspaces = append(project.Workspaces[:i], project.Workspaces[i+1:]...)
-				return a.dbManager.SaveProjectIndex(project)
-			}
-		}
+	// Remove the workspace from the project containing it
+	_, err := a.dbManager.PatchWorkspaceIndex(id, func(project *database.ProjectIndex, _ *database.WorkspaceIndex) error {
+		project.Workspaces = slices.DeleteFunc(project.Workspaces, func(ws database.WorkspaceIndex) bool {
+			return ws.Name == id
+		})
+		return nil
+	})
+	if errors.Is(err, database.ErrWorkspaceNotFound) {
+		return nil
 	}
-
-	return nil
+	return err
 }
 
 // UpdateProjectFields updates fields in a project
 func (a *App) UpdateProjectFields(path string, updates map[string]interface{}) error {
 	if a.dbManager == nil {
-		return fmt.Errorf("database manager not initialized")
-	}
-
-	name := filepath.Base(path)
-	project, err := a.dbManager.GetProjectIndex(name)
-	if err != nil {
-		return err
-	}
-
-	// Apply updates
-	if desc, ok := updates["description"].(string); ok {
-		project.Description = desc
-	}
-	if available, ok := updates["available"].(bool); ok {
-		project.Available = available
-	}
-	if lastProvider, ok := updates["last_provider"].(string); ok {
-		project.LastProvider = lastProvider
-	}
-	if projectType, ok := updates["project_type"].(string); ok {
-		project.ProjectType = projectType
+		return a.unavailable(subsystemDatabase)
 	}
 
-	return a.dbManager.SaveProjectIndex(project)
+	_, err := a.dbManager.PatchProjectIndex(filepath.Base(path), func(project *database.ProjectIndex) error {
+		// Apply updates
+		if desc, ok := updates["description"].(string); ok {
+			project.Description = desc
+		}
+		if available, ok := updates["available"].(bool); ok {
+			project.Available = available
+		}
+		if lastProvider, ok := updates["last_provider"].(string); ok {
+			project.LastProvider = lastProvider
+		}
+		if projectType, ok := updates["project_type"].(string); ok {
+			project.ProjectType = projectType
+		}
+		return nil
+	})
+	return err
 }
 
 // UpdateWorkspaceFields updates fields in a workspace
 func (a *App) UpdateWorkspaceFields(path string, updates map[string]interface{}) error {
 	if a.dbManager == nil {
-		return fmt.Errorf("database manager not initialized")
-	}
-
-	// Find parent project and workspace
-	projects, err := a.dbManager.GetAllProjectIndexes()
-	if err != nil {
-		return err
+		return a.unavailable(subsystemDatabase)
 	}
 
-	workspaceName := filepath.Base(path)
-	for _, project := range projects {
-		for i, workspace := range project.Workspaces {
-			if workspace.Name == workspaceName {
-				// Apply updates
-				if branch, ok := updates["branch"].(string); ok {
-					project.Workspaces[i].Branch = branch
-				}
-				if lastProvider, ok := updates["last_provider"].(string); ok {
-					project.Workspaces[i].LastProvider = lastProvider
-				}
-
-				return a.dbManager.SaveProjectIndex(project)
-			}
+	_, err := a.dbManager.PatchWorkspaceIndex(filepath.Base(path), func(_ *database.ProjectIndex, workspace *database.WorkspaceIndex) error {
+		// Apply updates
+		if branch, ok := updates["branch"].(string); ok {
+			workspace.Branch = branch
 		}
-	}
-
-	return fmt.Errorf("workspace not found: %s", workspaceName)
+		if lastProvider, ok := updates["last_provider"].(string); ok {
+			workspace.LastProvider = lastProvider
+		}
+		return nil
+	})
+	return err
 }
 
 // ===== Storage/Database Operations Bindings =====
@@ -3680,7 +4175,7 @@ func (a *App) UpdateWorkspaceFields(path string, updates map[string]interface{})
 // StorageListTables lists all tables in the database
 func (a *App) StorageListTables() ([]string, error) {
 	if a.dbManager == nil {
-		return nil, fmt.Errorf("database manager not initialized")
+		return nil, a.unavailable(subsystemDatabase)
 	}
 	return a.dbManager.ListTables()
 }
@@ -3688,7 +4183,7 @@ func (a *App) StorageListTables() ([]string, error) {
 // StorageReadTable reads table data with pagination
 func (a *App) StorageReadTable(table string, page, pageSize int) (*database.TableData, error) {
 	if a.dbManager == nil {
-		return nil, fmt.Errorf("database manager not initialized")
+		return nil, a.unavailable(subsystemDatabase)
 	}
 	return a.dbManager.ReadTable(table, page, pageSize)
 }
@@ -3696,7 +4191,7 @@ func (a *App) StorageReadTable(table string, page, pageSize int) (*database.Tabl
 // StorageInsertRow inserts a new row into the specified table
 func (a *App) StorageInsertRow(table string, data map[string]interface{}) (int64, error) {
 	if a.dbManager == nil {
-		return 0, fmt.Errorf("database manager not initialized")
+		return 0, a.unavailable(subsystemDatabase)
 	}
 	return a.dbManager.InsertRow(table, data)
 }
@@ -3704,7 +4199,7 @@ func (a *App) StorageInsertRow(table string, data map[string]interface{}) (int64
 // StorageUpdateRow updates a row in the specified table by ID
 func (a *App) StorageUpdateRow(table string, id int64, data map[string]interface{}) error {
 	if a.dbManager == nil {
-		return fmt.Errorf("database manager not initialized")
+		return a.unavailable(subsystemDatabase)
 	}
 	return a.dbManager.UpdateRow(table, id, data)
 }
@@ -3712,7 +4207,7 @@ func (a *App) StorageUpdateRow(table string, id int64, data map[string]interface
 // StorageDeleteRow deletes a row from the specified table by ID
 func (a *App) StorageDeleteRow(table string, id int64) error {
 	if a.dbManager == nil {
-		return fmt.Errorf("database manager not initialized")
+		return a.unavailable(subsystemDatabase)
 	}
 	return a.dbManager.DeleteRow(table, id)
 }
@@ -3720,7 +4215,7 @@ func (a *App) StorageDeleteRow(table string, id int64) error {
 // StorageExecuteSql executes a read-only SQL query (SELECT only)
 func (a *App) StorageExecuteSql(sql string) (*database.TableData, error) {
 	if a.dbManager == nil {
-		return nil, fmt.Errorf("database manager not initialized")
+		return nil, a.unavailable(subsystemDatabase)
 	}
 	return a.dbManager.ExecuteSQL(sql)
 }
@@ -3728,7 +4223,7 @@ func (a *App) StorageExecuteSql(sql string) (*database.TableData, error) {
 // StorageResetDatabase resets the database by dropping all tables and reinitializing
 func (a *App) StorageResetDatabase() error {
 	if a.dbManager == nil {
-		return fmt.Errorf("database manager not initialized")
+		return a.unavailable(subsystemDatabase)
 	}
 	return a.dbManager.ResetDatabase()
 }
@@ -3738,7 +4233,7 @@ func (a *App) StorageResetDatabase() error {
 // ListGlobalSshConnections returns all saved SSH connections
 func (a *App) ListGlobalSshConnections() ([]ssh.SshConnection, error) {
 	if a.sshManager == nil {
-		return []ssh.SshConnection{}, nil
+		return []ssh.SshConnection{}, a.unavailable(subsystemSSH)
 	}
 	return a.sshManager.ListGlobalConnections()
 }
@@ -3746,23 +4241,45 @@ func (a *App) ListGlobalSshConnections() ([]ssh.SshConnection, error) {
 // AddGlobalSshConnection adds a new global SSH connection
 func (a *App) AddGlobalSshConnection(conn ssh.SshConnection) error {
 	if a.sshManager == nil {
-		return fmt.Errorf("SSH manager not initialized")
+		return a.unavailable(subsystemSSH)
 	}
 	return a.sshManager.AddGlobalConnection(conn)
 }
 
+// UpdateGlobalSshConnection replaces a saved SSH connection, which may rename it
+func (a *App) UpdateGlobalSshConnection(name string, conn ssh.SshConnection) error {
+	if a.sshManager == nil {
+		return a.unavailable(subsystemSSH)
+	}
+	return a.sshManager.UpdateGlobalConnection(name, conn)
+}
+
 // DeleteGlobalSshConnection deletes a saved SSH connection by name
 func (a *App) DeleteGlobalSshConnection(name string) error {
 	if a.sshManager == nil {
-		return fmt.Errorf("SSH manager not initialized")
+		return a.unavailable(subsystemSSH)
 	}
 	return a.sshManager.DeleteGlobalConnection(name)
 }
 
+// SetSshConnectionPassword stores the password of a saved SSH connection in
+// the keychain; an empty password removes it
+func (a *App) SetSshConnectionPassword(name, password string) error {
+	if a.sshManager == nil {
+		return a.unavailable(subsystemSSH)
+	}
+	return a.sshManager.SetConnectionPassword(name, password)
+}
+
+// GetSshAgentStatus reports whether an ssh-agent is available and which keys it holds
+func (a *App) GetSshAgentStatus() *ssh.AgentStatus {
+	return ssh.GetAgentStatus()
+}
+
 // SyncFromSSH downloads files from remote SSH server to local
 func (a *App) SyncFromSSH(localPath, remotePath, connectionName string) error {
 	if a.sshManager == nil {
-		return fmt.Errorf("SSH manager not initialized")
+		return a.unavailable(subsystemSSH)
 	}
 	return a.sshManager.SyncFromSSH(localPath, remotePath, connectionName)
 }
@@ -3770,7 +4287,7 @@ func (a *App) SyncFromSSH(localPath, remotePath, connectionName string) error {
 // SyncToSSH uploads files from local to remote SSH server
 func (a *App) SyncToSSH(localPath, remotePath, connectionName string) error {
 	if a.sshManager == nil {
-		return fmt.Errorf("SSH manager not initialized")
+		return a.unavailable(subsystemSSH)
 	}
 	return a.sshManager.SyncToSSH(localPath, remotePath, connectionName)
 }
@@ -3778,7 +4295,7 @@ func (a *App) SyncToSSH(localPath, remotePath, connectionName string) error {
 // StartAutoSync starts automatic file sync for a path
 func (a *App) StartAutoSync(localPath, remotePath, connectionName string) error {
 	if a.sshManager == nil {
-		return fmt.Errorf("SSH manager not initialized")
+		return a.unavailable(subsystemSSH)
 	}
 	return a.sshManager.StartAutoSync(localPath, remotePath, connectionName)
 }
@@ -3786,7 +4303,7 @@ func (a *App) StartAutoSync(localPath, remotePath, connectionName string) error
 // StopAutoSync stops automatic file sync for a path
 func (a *App) StopAutoSync(localPath string) error {
 	if a.sshManager == nil {
-		return fmt.Errorf("SSH manager not initialized")
+		return a.unavailable(subsystemSSH)
 	}
 	return a.sshManager.StopAutoSync(localPath)
 }
@@ -3794,7 +4311,7 @@ func (a *App) StopAutoSync(localPath string) error {
 // PauseSshSync pauses an ongoing SSH sync operation
 func (a *App) PauseSshSync(localPath string) error {
 	if a.sshManager == nil {
-		return fmt.Errorf("SSH manager not initialized")
+		return a.unavailable(subsystemSSH)
 	}
 	return a.sshManager.PauseSshSync(localPath)
 }
@@ -3802,7 +4319,7 @@ func (a *App) PauseSshSync(localPath string) error {
 // ResumeSshSync resumes a paused SSH sync operation
 func (a *App) ResumeSshSync(localPath string) error {
 	if a.sshManager == nil {
-		return fmt.Errorf("SSH manager not initialized")
+		return a.unavailable(subsystemSSH)
 	}
 	return a.sshManager.ResumeSshSync(localPath)
 }
@@ -3810,7 +4327,7 @@ func (a *App) ResumeSshSync(localPath string) error {
 // CancelSshSync cancels an ongoing SSH sync operation
 func (a *App) CancelSshSync(localPath string) error {
 	if a.sshManager == nil {
-		return fmt.Errorf("SSH manager not initialized")
+		return a.unavailable(subsystemSSH)
 	}
 	return a.sshManager.CancelSshSync(localPath)
 }
@@ -3818,7 +4335,7 @@ func (a *App) CancelSshSync(localPath string) error {
 // GetAutoSyncStatus returns the auto-sync status for a path
 func (a *App) GetAutoSyncStatus(localPath string) (*ssh.AutoSyncStatus, error) {
 	if a.sshManager == nil {
-		return nil, fmt.Errorf("SSH manager not initialized")
+		return nil, a.unavailable(subsystemSSH)
 	}
 	return a.sshManager.GetAutoSyncStatus(localPath)
 }
@@ -3828,7 +4345,7 @@ func (a *App) GetAutoSyncStatus(localPath string) (*ssh.AutoSyncStatus, error) {
 // ListInstalledPlugins returns all installed plugins
 func (a *App) ListInstalledPlugins() ([]plugin.Plugin, error) {
 	if a.pluginManager == nil {
-		return []plugin.Plugin{}, nil
+		return []plugin.Plugin{}, a.unavailable(subsystemPlugins)
 	}
 	return a.pluginManager.ListInstalled()
 }
@@ -3836,7 +4353,7 @@ func (a *App) ListInstalledPlugins() ([]plugin.Plugin, error) {
 // GetPluginDetails returns details for a specific plugin
 func (a *App) GetPluginDetails(id string) (*plugin.Plugin, error) {
 	if a.pluginManager == nil {
-		return nil, fmt.Errorf("plugin manager not initialized")
+		return nil, a.unavailable(subsystemPlugins)
 	}
 	return a.pluginManager.GetDetails(id)
 }
@@ -3844,7 +4361,7 @@ func (a *App) GetPluginDetails(id string) (*plugin.Plugin, error) {
 // GetPluginContents returns all contents of a plugin (agents, commands, skills, hooks)
 func (a *App) GetPluginContents(id string) (*plugin.PluginContents, error) {
 	if a.pluginManager == nil {
-		return nil, fmt.Errorf("plugin manager not initialized")
+		return nil, a.unavailable(subsystemPlugins)
 	}
 	return a.pluginManager.GetContents(id)
 }
@@ -3852,7 +4369,7 @@ func (a *App) GetPluginContents(id string) (*plugin.PluginContents, error) {
 // ListPluginAgents returns all agents from a specific plugin
 func (a *App) ListPluginAgents(pluginID string) ([]plugin.PluginAgent, error) {
 	if a.pluginManager == nil {
-		return []plugin.PluginAgent{}, nil
+		return []plugin.PluginAgent{}, a.unavailable(subsystemPlugins)
 	}
 	return a.pluginManager.ListAgents(pluginID)
 }
@@ -3860,7 +4377,7 @@ func (a *App) ListPluginAgents(pluginID string) ([]plugin.PluginAgent, error) {
 // ListPluginCommands returns all commands from a specific plugin
 func (a *App) ListPluginCommands(pluginID string) ([]plugin.PluginCommand, error) {
 	if a.pluginManager == nil {
-		return []plugin.PluginCommand{}, nil
+		return []plugin.PluginCommand{}, a.unavailable(subsystemPlugins)
 	}
 	return a.pluginManager.ListCommands(pluginID)
 }
@@ -3868,7 +4385,7 @@ func (a *App) ListPluginCommands(pluginID string) ([]plugin.PluginCommand, error
 // ListPluginSkills returns all skills from a specific plugin
 func (a *App) ListPluginSkills(pluginID string) ([]plugin.PluginSkill, error) {
 	if a.pluginManager == nil {
-		return []plugin.PluginSkill{}, nil
+		return []plugin.PluginSkill{}, a.unavailable(subsystemPlugins)
 	}
 	return a.pluginManager.ListSkills(pluginID)
 }
@@ -3876,7 +4393,7 @@ func (a *App) ListPluginSkills(pluginID string) ([]plugin.PluginSkill, error) {
 // ListPluginHooks returns all hooks from a specific plugin
 func (a *App) ListPluginHooks(pluginID string) ([]plugin.PluginHook, error) {
 	if a.pluginManager == nil {
-		return []plugin.PluginHook{}, nil
+		return []plugin.PluginHook{}, a.unavailable(subsystemPlugins)
 	}
 	return a.pluginManager.ListHooks(pluginID)
 }
@@ -3884,7 +4401,7 @@ func (a *App) ListPluginHooks(pluginID string) ([]plugin.PluginHook, error) {
 // GetPluginAgent returns a specific agent from a plugin
 func (a *App) GetPluginAgent(pluginID, agentName string) (*plugin.PluginAgent, error) {
 	if a.pluginManager == nil {
-		return nil, fmt.Errorf("plugin manager not initialized")
+		return nil, a.unavailable(subsystemPlugins)
 	}
 	return a.pluginManager.GetAgent(pluginID, agentName)
 }
@@ -3892,7 +4409,7 @@ func (a *App) GetPluginAgent(pluginID, agentName string) (*plugin.PluginAgent, e
 // GetPluginCommand returns a specific command from a plugin
 func (a *App) GetPluginCommand(pluginID, commandName string) (*plugin.PluginCommand, error) {
 	if a.pluginManager == nil {
-		return nil, fmt.Errorf("plugin manager not initialized")
+		return nil, a.unavailable(subsystemPlugins)
 	}
 	return a.pluginManager.GetCommand(pluginID, commandName)
 }
@@ -3900,11 +4417,51 @@ func (a *App) GetPluginCommand(pluginID, commandName string) (*plugin.PluginComm
 // GetPluginSkill returns a specific skill from a plugin
 func (a *App) GetPluginSkill(pluginID, skillName string) (*plugin.PluginSkill, error) {
 	if a.pluginManager == nil {
-		return nil, fmt.Errorf("plugin manager not initialized")
+		return nil, a.unavailable(subsystemPlugins)
 	}
 	return a.pluginManager.GetSkill(pluginID, skillName)
 }
 
+// InstallPlugin installs and enables a plugin from "name@marketplace",
+// pinned to a tag, branch or commit with "#ref". The marketplace may be a
+// known one, a GitHub owner/repo, a git URL or a local directory; progress
+// arrives as plugin:progress.
+func (a *App) InstallPlugin(source string) (*plugin.Plugin, error) {
+	if a.pluginManager == nil {
+		return nil, a.unavailable(subsystemPlugins)
+	}
+	return a.pluginManager.Install(a.pluginContext(), source, a.emitPluginProgress)
+}
+
+// UpdatePlugin reinstalls a plugin from its marketplace, keeping its pin
+func (a *App) UpdatePlugin(id string) (*plugin.Plugin, error) {
+	if a.pluginManager == nil {
+		return nil, a.unavailable(subsystemPlugins)
+	}
+	return a.pluginManager.Update(a.pluginContext(), id, a.emitPluginProgress)
+}
+
+// UninstallPlugin removes a plugin and disables it
+func (a *App) UninstallPlugin(id string) error {
+	if a.pluginManager == nil {
+		return a.unavailable(subsystemPlugins)
+	}
+	return a.pluginManager.Uninstall(id, a.emitPluginProgress)
+}
+
+func (a *App) pluginContext() context.Context {
+	if a.ctx != nil {
+		return a.ctx
+	}
+	return context.Background()
+}
+
+func (a *App) emitPluginProgress(progress plugin.Progress) {
+	if a.eventHub != nil {
+		a.eventHub.Emit("plugin:progress", progress)
+	}
+}
+
 // ===== Usage Stats Bindings =====
 
 // ModelStat represents usage statistics for a model
@@ -3956,36 +4513,15 @@ type UsageStats struct {
 	ByProject                []ProjectStat `json:"by_project"`
 }
 
-// calculateTokenCost calculates the cost based on model and token counts
-// Prices are per million tokens (MTok)
-func calculateTokenCost(model string, inputTokens, outputTokens, cacheCreation, cacheRead int64) float64 {
-	// Default to Sonnet pricing
-	inputPrice := 3.0       // $ per MTok
-	outputPrice := 15.0     // $ per MTok
-	cacheWritePrice := 3.75 // $ per MTok
-	cacheReadPrice := 0.30  // $ per MTok
-
-	// Adjust pricing based on model
-	modelLower := strings.ToLower(model)
-	if strings.Contains(modelLower, "opus") {
-		inputPrice = 15.0
-		outputPrice = 75.0
-		cacheWritePrice = 18.75
-		cacheReadPrice = 1.50
-	} else if strings.Contains(modelLower, "haiku") {
-		inputPrice = 0.25
-		outputPrice = 1.25
-		cacheWritePrice = 0.30
-		cacheReadPrice = 0.03
+// usageCollector returns a collector using the configured model pricing
+// that only rereads log files changed since the last scan
+func (a *App) usageCollector(claudeDir string) *usage.Collector {
+	if a.dbManager == nil {
+		return usage.NewCollector(claudeDir).WithWorkspaceResolver(a.resolveUsageWorkspace)
 	}
-
-	// Calculate cost (divide by 1M to get per-token cost)
-	cost := float64(inputTokens) * inputPrice / 1_000_000
-	cost += float64(outputTokens) * outputPrice / 1_000_000
-	cost += float64(cacheCreation) * cacheWritePrice / 1_000_000
-	cost += float64(cacheRead) * cacheReadPrice / 1_000_000
-
-	return cost
+	return usage.NewCachedCollector(claudeDir, a.dbManager).
+		WithPricing(a.modelPricing()).
+		WithWorkspaceResolver(a.resolveUsageWorkspace)
 }
 
 // GetUsageStats returns overall usage statistics
@@ -3998,7 +4534,7 @@ func (a *App) GetUsageStats() (*UsageStats, error) {
 	claudeDir := filepath.Join(homeDir, ".claude")
 
 	// Create collector and collect stats
-	collector := usage.NewCollector(claudeDir)
+	collector := a.usageCollector(claudeDir)
 	overallStats, err := collector.CollectStats()
 	if err != nil {
 		return nil, fmt.Errorf("failed to collect usage stats: %w", err)
@@ -4011,7 +4547,7 @@ func (a *App) GetUsageStats() (*UsageStats, error) {
 	// Convert internal types to binding types
 	byModel := make([]ModelStat, 0, len(overallStats.ByModel))
 	for _, ms := range overallStats.ByModel {
-		modelCost := calculateTokenCost(ms.Model, ms.TotalInputTokens, ms.TotalOutputTokens, ms.TotalCacheCreation, ms.TotalCacheRead)
+		modelCost := ms.TotalCost
 		totalCost += modelCost
 		totalCacheCreation += ms.TotalCacheCreation
 		totalCacheRead += ms.TotalCacheRead
@@ -4103,7 +4639,7 @@ func (a *App) GetUsageByDateRange(start, end string) (*UsageStats, error) {
 	endDate = endDate.Add(24*time.Hour - time.Second)
 
 	// Create collector and collect stats
-	collector := usage.NewCollector(claudeDir)
+	collector := a.usageCollector(claudeDir)
 	overallStats, err := collector.CollectStatsByDateRange(startDate, endDate)
 	if err != nil {
 		return nil, fmt.Errorf("failed to collect usage stats: %w", err)
@@ -4116,7 +4652,7 @@ func (a *App) GetUsageByDateRange(start, end string) (*UsageStats, error) {
 	// Convert internal types to binding types
 	byModel := make([]ModelStat, 0, len(overallStats.ByModel))
 	for _, ms := range overallStats.ByModel {
-		modelCost := calculateTokenCost(ms.Model, ms.TotalInputTokens, ms.TotalOutputTokens, ms.TotalCacheCreation, ms.TotalCacheRead)
+		modelCost := ms.TotalCost
 		totalCost += modelCost
 		totalCacheCreation += ms.TotalCacheCreation
 		totalCacheRead += ms.TotalCacheRead
@@ -4196,7 +4732,7 @@ func (a *App) GetSessionStats(sessionId string, projectId string) ([]interface{}
 	claudeDir := filepath.Join(homeDir, ".claude")
 
 	// Create collector and collect session stats
-	collector := usage.NewCollector(claudeDir)
+	collector := a.usageCollector(claudeDir)
 	sessions, err := collector.CollectSessionStats()
 	if err != nil {
 		return nil, fmt.Errorf("failed to collect session stats: %w", err)
@@ -4221,7 +4757,7 @@ func (a *App) GetUsageDetails(limit int) ([]interface{}, error) {
 	claudeDir := filepath.Join(homeDir, ".claude")
 
 	// Create collector and collect usage details
-	collector := usage.NewCollector(claudeDir)
+	collector := a.usageCollector(claudeDir)
 	entries, err := collector.CollectUsageDetails(limit)
 	if err != nil {
 		return nil, fmt.Errorf("failed to collect usage details: %w", err)
@@ -4366,11 +4902,23 @@ func (a *App) SearchClaudeAgents(query string) ([]ClaudeAgentEntry, error) {
 
 // ===== GitHub Agents Bindings =====
 
-// FetchGitHubAgents fetches available agents from GitHub
+// FetchGitHubAgents fetches available agents from all enabled agent sources
 func (a *App) FetchGitHubAgents() ([]interface{}, error) {
-	agents, err := github.FetchAgents(github.DefaultAgentsURL)
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch GitHub agents: %w", err)
+	var agents []github.AgentMetadata
+	var lastErr error
+	for _, source := range a.loadAgentSources() {
+		if !source.Enabled {
+			continue
+		}
+		sourceAgents, err := a.agentSourceClient.FetchSourceAgents(source)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		agents = append(agents, sourceAgents...)
+	}
+	if len(agents) == 0 && lastErr != nil {
+		return nil, fmt.Errorf("failed to fetch GitHub agents: %w", lastErr)
 	}
 
 	// Convert to []interface{} for JSON serialization
@@ -4383,6 +4931,7 @@ func (a *App) FetchGitHubAgents() ([]interface{}, error) {
 			"download_url": agent.DownloadURL,
 			"size":         agent.Size,
 			"sha":          agent.SHA,
+			"source_id":    agent.SourceID,
 		}
 	}
 
@@ -4391,9 +4940,9 @@ func (a *App) FetchGitHubAgents() ([]interface{}, error) {
 
 // FetchGitHubAgentContent fetches the content of a GitHub agent
 func (a *App) FetchGitHubAgentContent(url string) (interface{}, error) {
-	exportFile, err := github.FetchAgentExportFile(url)
+	exportFile, err := a.agentSourceClient.FetchAgentExportFile(url)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch agent content: %w", err)
+		return nil, err
 	}
 
 	// Return as map matching frontend AgentExport interface
@@ -4413,18 +4962,21 @@ func (a *App) FetchGitHubAgentContent(url string) (interface{}, error) {
 // ImportAgentFromGitHub imports an agent from GitHub
 func (a *App) ImportAgentFromGitHub(url string) (*database.Agent, error) {
 	// Fetch agent content from GitHub
-	agentContent, err := github.FetchAgentContent(url)
+	exportFile, err := a.agentSourceClient.FetchAgentExportFile(url)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch agent from GitHub: %w", err)
 	}
+	agentContent := exportFile.Agent
 
-	// Create database agent from GitHub content
+	// Create database agent from GitHub content, remembering where it came
+	// from so UpdateAgentFromSource can pick up new versions
 	agent := &database.Agent{
 		Name:         agentContent.Name,
 		Icon:         agentContent.Icon,
 		SystemPrompt: agentContent.SystemPrompt,
 		DefaultTask:  agentContent.DefaultTask,
 		Model:        agentContent.Model,
+		SourceURL:    url,
 	}
 
 	// Save to database
@@ -4638,7 +5190,7 @@ func (a *App) ListClaudeInstallations() ([]ClaudeInstallation, error) {
 // CleanupFinishedProcesses cleans up finished processes and returns their keys
 func (a *App) CleanupFinishedProcesses() ([]string, error) {
 	if a.processManager == nil {
-		return []string{}, nil
+		return nil, a.unavailable(subsystemProcesses)
 	}
 
 	// Get all processes
@@ -4655,20 +5207,25 @@ func (a *App) CleanupFinishedProcesses() ([]string, error) {
 	return cleaned, nil
 }
 
-// SavePastedImage saves a pasted image from base64 data
-func (a *App) SavePastedImage(base64Data, filename string) (string, error) {
-	// Get the home directory
-	homeDir, err := os.UserHomeDir()
+// PastedImage is a saved pasted image
+type PastedImage struct {
+	Path   string `json:"path"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+	Size   int    `json:"size"`
+}
+
+// SavePastedImage saves a pasted image from base64 data, scaled down and
+// converted as the paste_image_* settings say
+func (a *App) SavePastedImage(base64Data, filename string) (*PastedImage, error) {
+	tempImagesDir, err := a.tempImagesDir()
 	if err != nil {
-		return "", fmt.Errorf("failed to get home directory: %w", err)
+		return nil, fmt.Errorf("failed to get home directory: %w", err)
 	}
 
-	// Create the temp-images directory path
-	tempImagesDir := filepath.Join(homeDir, ".ropcode", "temp-images")
-
 	// Ensure the directory exists with proper permissions
 	if err := os.MkdirAll(tempImagesDir, 0755); err != nil {
-		return "", fmt.Errorf("failed to create temp-images directory: %w", err)
+		return nil, fmt.Errorf("failed to create temp-images directory: %w", err)
 	}
 
 	// If filename is empty, generate a unique filename
@@ -4678,6 +5235,8 @@ func (a *App) SavePastedImage(base64Data, filename string) (string, error) {
 		uniqueID := uuid.New().String()[:8]
 		filename = fmt.Sprintf("pasted-%s-%s.png", timestamp, uniqueID)
 	}
+	// Keep the image inside temp-images whatever name the caller sent
+	filename = filepath.Base(filename)
 
 	// Remove data URL prefix if present (e.g., "data:image/png;base64,")
 	if idx := strings.Index(base64Data, ","); idx != -1 {
@@ -4687,18 +5246,32 @@ func (a *App) SavePastedImage(base64Data, filename string) (string, error) {
 	// Decode base64 data
 	imageData, err := base64.StdEncoding.DecodeString(base64Data)
 	if err != nil {
-		return "", fmt.Errorf("failed to decode base64 data: %w", err)
+		return nil, fmt.Errorf("failed to decode base64 data: %w", err)
+	}
+
+	converted, err := imageconv.Convert(imageData, a.pastedImageOptions())
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert image: %w", err)
+	}
+	// Name the file after the format it's now in
+	if converted.Ext != "" && !strings.EqualFold(filepath.Ext(filename), converted.Ext) {
+		filename = strings.TrimSuffix(filename, filepath.Ext(filename)) + converted.Ext
 	}
 
 	// Construct full file path
 	filePath := filepath.Join(tempImagesDir, filename)
 
 	// Write the image data to file with proper permissions
-	if err := os.WriteFile(filePath, imageData, 0644); err != nil {
-		return "", fmt.Errorf("failed to write image file: %w", err)
+	if err := os.WriteFile(filePath, converted.Data, 0644); err != nil {
+		return nil, fmt.Errorf("failed to write image file: %w", err)
 	}
 
-	return filePath, nil
+	return &PastedImage{
+		Path:   filePath,
+		Width:  converted.Width,
+		Height: converted.Height,
+		Size:   len(converted.Data),
+	}, nil
 }
 
 // OpenInExternalApp opens a file or path in an external application identified
@@ -4709,6 +5282,9 @@ func (a *App) OpenInExternalApp(appType, path string) error {
 
 // IsPtySessionAlive checks if a PTY session is alive
 func (a *App) IsPtySessionAlive(id string) (bool, error) {
+	if alive, ok, err := a.proxyIsPtySessionAlive(id); ok {
+		return alive, err
+	}
 	if a.ptyManager == nil {
 		return false, nil
 	}
@@ -4727,7 +5303,7 @@ func (a *App) IsPtySessionAlive(id string) (bool, error) {
 // KillCommand kills a command by ID
 func (a *App) KillCommand(id string) error {
 	if a.processManager == nil {
-		return fmt.Errorf("process manager not initialized")
+		return a.unavailable(subsystemProcesses)
 	}
 
 	return a.processManager.Kill(id)
@@ -4745,7 +5321,7 @@ type MCPAddResult struct {
 // McpAdd adds a new MCP server configuration
 func (a *App) McpAdd(name, command string, args []string, env map[string]string, scope string) (*MCPAddResult, error) {
 	if a.mcpManager == nil {
-		return &MCPAddResult{Name: name, Success: false, Message: "MCP manager not initialized"}, nil
+		return nil, a.unavailable(subsystemMCP)
 	}
 
 	config := &mcp.MCPServerConfig{
@@ -4762,10 +5338,30 @@ func (a *App) McpAdd(name, command string, args []string, env map[string]string,
 	return &MCPAddResult{Name: name, Success: true, Message: "MCP server added successfully"}, nil
 }
 
+// McpAddRemote adds a remote MCP server reached over SSE or streamable HTTP
+func (a *App) McpAddRemote(name, transport, url string, headers map[string]string, scope string) (*MCPAddResult, error) {
+	if a.mcpManager == nil {
+		return nil, a.unavailable(subsystemMCP)
+	}
+
+	config := &mcp.MCPServerConfig{
+		Type:    transport,
+		URL:     url,
+		Headers: headers,
+	}
+
+	err := a.mcpManager.SaveMcpServer(name, config)
+	if err != nil {
+		return &MCPAddResult{Name: name, Success: false, Message: err.Error()}, nil
+	}
+
+	return &MCPAddResult{Name: name, Success: true, Message: "MCP server added successfully"}, nil
+}
+
 // McpAddJson adds a new MCP server from JSON configuration
 func (a *App) McpAddJson(name string, configJson string) (*MCPAddResult, error) {
 	if a.mcpManager == nil {
-		return &MCPAddResult{Name: name, Success: false, Message: "MCP manager not initialized"}, nil
+		return nil, a.unavailable(subsystemMCP)
 	}
 
 	// Parse the JSON config
@@ -4823,37 +5419,7 @@ func (a *App) McpAddFromClaudeDesktop(scope string) (*MCPImportResult, error) {
 		return result, nil
 	}
 
-	var desktopConfig struct {
-		McpServers map[string]struct {
-			Command string            `json:"command"`
-			Args    []string          `json:"args"`
-			Env     map[string]string `json:"env"`
-		} `json:"mcpServers"`
-	}
-
-	if err := json.Unmarshal(data, &desktopConfig); err != nil {
-		result.Success = false
-		result.Messages = append(result.Messages, "Failed to parse config: "+err.Error())
-		return result, nil
-	}
-
-	// Import each server
-	for name, serverConfig := range desktopConfig.McpServers {
-		config := &mcp.MCPServerConfig{
-			Command: serverConfig.Command,
-			Args:    serverConfig.Args,
-			Env:     serverConfig.Env,
-		}
-
-		if err := a.mcpManager.SaveMcpServer(name, config); err != nil {
-			result.FailedCount++
-			result.Messages = append(result.Messages, fmt.Sprintf("Failed to import '%s': %s", name, err.Error()))
-		} else {
-			result.ImportedCount++
-			result.Messages = append(result.Messages, fmt.Sprintf("Imported '%s'", name))
-		}
-	}
-
+	a.importMcpServers(data, result)
 	return result, nil
 }
 
@@ -4866,7 +5432,7 @@ func (a *App) McpServe() (string, error) {
 // McpTestConnection tests connection to an MCP server
 func (a *App) McpTestConnection(name string) (string, error) {
 	if a.mcpManager == nil {
-		return "", fmt.Errorf("MCP manager not initialized")
+		return "", a.unavailable(subsystemMCP)
 	}
 
 	server, err := a.mcpManager.GetMcpServer(name)
@@ -4874,6 +5440,18 @@ func (a *App) McpTestConnection(name string) (string, error) {
 		return "", err
 	}
 
+	// Remote servers are tested by performing the initialize handshake
+	if server.Command == "" && server.URL != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		client, result, err := mcp.Connect(ctx, server)
+		if err != nil {
+			return fmt.Sprintf("Failed to connect to %s: %s", server.URL, err.Error()), nil
+		}
+		client.Close()
+		return fmt.Sprintf("Connected to %s %s over %s", result.ServerInfo.Name, result.ServerInfo.Version, server.Transport), nil
+	}
+
 	// Try to execute the command to test if it's valid
 	if server.Command == "" {
 		return "Server has no command configured", nil
@@ -4895,9 +5473,12 @@ func (a *App) McpResetProjectChoices() (string, error) {
 	return "Project choices reset", nil
 }
 
-// MCPProjectConfig represents project-level MCP configuration
+// MCPProjectConfig represents project-level MCP configuration. Enabled holds
+// per-project toggles for global and project servers; servers without an
+// entry are enabled.
 type MCPProjectConfig struct {
 	Servers map[string]mcp.MCPServerConfig `json:"servers"`
+	Enabled map[string]bool                `json:"enabled,omitempty"`
 }
 
 // McpReadProjectConfig reads project-level MCP configuration
@@ -4958,7 +5539,20 @@ type Action struct {
 	Type        string `json:"type,omitempty"`       // "global", "project", "workspace"
 	ActionType  string `json:"actionType,omitempty"` // "script", "web"
 	Shared      bool   `json:"shared,omitempty"`     // for project actions shared across workspaces
-}
+	Order       int    `json:"order"`                // position in the actions list
+	Confirm     bool   `json:"confirm,omitempty"`    // ask before running, for destructive actions
+	Keybinding  string `json:"keybinding,omitempty"` // e.g. "mod+shift+b"
+	RunIn       string `json:"runIn,omitempty"`      // script actions: "terminal" or "background"
+	// Env is added to the environment of script actions; values may use
+	// {{projectPath}} and {{branch}} like the command
+	Env map[string]string `json:"env,omitempty"`
+}
+
+// Where script actions run
+const (
+	ActionRunInTerminal   = "terminal"   // typed into the first terminal
+	ActionRunInBackground = "background" // run by RunAction
+)
 
 // ActionsResult represents the combined actions from all scopes
 type ActionsResult struct {
@@ -5006,7 +5600,8 @@ func (a *App) GetActions(projectPath, workspacePath string) (*ActionsResult, err
 	return result, nil
 }
 
-// loadActionsFromFile loads actions from a JSON file
+// loadActionsFromFile loads actions from a JSON file, in order. Files
+// written before actions had an order and a run mode are migrated.
 func loadActionsFromFile(path, scope string) ([]Action, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
@@ -5018,6 +5613,13 @@ func loadActionsFromFile(path, scope string) ([]Action, error) {
 		return nil, err
 	}
 
+	if migrateActions(data, actions) {
+		if err := saveActionsToFile(path, actions); err != nil {
+			slog.Error("failed to migrate", "component", "Actions", "path", path, "error", err)
+		}
+	}
+	sort.SliceStable(actions, func(i, j int) bool { return actions[i].Order < actions[j].Order })
+
 	// Set scope for each action
 	for i := range actions {
 		actions[i].Scope = scope
@@ -5026,6 +5628,32 @@ func loadActionsFromFile(path, scope string) ([]Action, error) {
 	return actions, nil
 }
 
+// migrateActions brings actions read from data up to date and reports
+// whether they changed: actions get their file position as order, script
+// actions keep running in the terminal as they did before run modes, and
+// the scope, which is implied by the file, is no longer stored
+func migrateActions(data []byte, actions []Action) bool {
+	var raw []map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil || len(raw) != len(actions) {
+		return false
+	}
+	changed := false
+	for i := range actions {
+		if _, ok := raw[i]["order"]; !ok {
+			actions[i].Order = i
+			changed = true
+		}
+		if actions[i].RunIn == "" && actions[i].ActionType != "web" {
+			actions[i].RunIn = ActionRunInTerminal
+			changed = true
+		}
+		if _, ok := raw[i]["scope"]; ok {
+			changed = true
+		}
+	}
+	return changed
+}
+
 // saveActionsToFile saves actions to a JSON file
 func saveActionsToFile(path string, actions []Action) error {
 	dir := filepath.Dir(path)
@@ -5033,7 +5661,12 @@ func saveActionsToFile(path string, actions []Action) error {
 		return err
 	}
 
-	data, err := json.MarshalIndent(actions, "", "  ")
+	stored := make([]Action, len(actions))
+	copy(stored, actions)
+	for i := range stored {
+		stored[i].Scope = ""
+	}
+	data, err := json.MarshalIndent(stored, "", "  ")
 	if err != nil {
 		return err
 	}
@@ -5041,12 +5674,74 @@ func saveActionsToFile(path string, actions []Action) error {
 	return os.WriteFile(path, data, 0644)
 }
 
+// actionKeys are the key names keybindings may end with besides letters,
+// digits and F1 to F12
+var actionKeys = map[string]bool{
+	"enter": true, "space": true, "tab": true, "escape": true, "backspace": true, "delete": true,
+	"up": true, "down": true, "left": true, "right": true, "home": true, "end": true,
+	"pageup": true, "pagedown": true,
+}
+
+var actionKeyPattern = regexp.MustCompile(`^([a-z0-9]|f([1-9]|1[0-2]))$`)
+
+// validateActions checks run modes and keybindings before actions are
+// saved; keybindings are "+"-joined modifiers (mod, ctrl, cmd, alt,
+// shift) followed by a key, and unique within a file
+func validateActions(actions []Action) error {
+	seen := map[string]string{}
+	for _, action := range actions {
+		switch action.RunIn {
+		case "", ActionRunInTerminal, ActionRunInBackground:
+		default:
+			return fmt.Errorf("action %s: unknown run mode %q", action.Name, action.RunIn)
+		}
+		if action.Keybinding == "" {
+			continue
+		}
+		binding, err := normalizeKeybinding(action.Keybinding)
+		if err != nil {
+			return fmt.Errorf("action %s: %w", action.Name, err)
+		}
+		if other, ok := seen[binding]; ok {
+			return fmt.Errorf("actions %s and %s both use %s", other, action.Name, action.Keybinding)
+		}
+		seen[binding] = action.Name
+	}
+	return nil
+}
+
+// normalizeKeybinding lowercases a keybinding and sorts its modifiers
+func normalizeKeybinding(binding string) (string, error) {
+	parts := strings.Split(strings.ToLower(strings.ReplaceAll(binding, " ", "")), "+")
+	key := parts[len(parts)-1]
+	if !actionKeyPattern.MatchString(key) && !actionKeys[key] {
+		return "", fmt.Errorf("invalid key in keybinding %q", binding)
+	}
+	modifiers := parts[:len(parts)-1]
+	if len(modifiers) == 0 {
+		return "", fmt.Errorf("keybinding %q needs a modifier", binding)
+	}
+	for _, m := range modifiers {
+		switch m {
+		case "mod", "ctrl", "cmd", "alt", "shift":
+		default:
+			return "", fmt.Errorf("invalid modifier %q in keybinding %q", m, binding)
+		}
+	}
+	sort.Strings(modifiers)
+	return strings.Join(append(modifiers, key), "+"), nil
+}
+
 // UpdateProjectActions updates project-level actions
 func (a *App) UpdateProjectActions(projectPath string, actions []Action) error {
 	if projectPath == "" {
 		return fmt.Errorf("project path is required")
 	}
 
+	if err := validateActions(actions); err != nil {
+		return err
+	}
+
 	actionsPath := filepath.Join(projectPath, ".claude", "actions.json")
 	return saveActionsToFile(actionsPath, actions)
 }
@@ -5057,6 +5752,10 @@ func (a *App) UpdateWorkspaceActions(workspacePath string, actions []Action) err
 		return fmt.Errorf("workspace path is required")
 	}
 
+	if err := validateActions(actions); err != nil {
+		return err
+	}
+
 	actionsPath := filepath.Join(workspacePath, ".claude", "actions.json")
 	return saveActionsToFile(actionsPath, actions)
 }
@@ -5084,6 +5783,10 @@ func (a *App) UpdateGlobalActions(actions []Action) error {
 		return err
 	}
 
+	if err := validateActions(actions); err != nil {
+		return err
+	}
+
 	globalPath := filepath.Join(homeDir, ".claude", "actions.json")
 	return saveActionsToFile(globalPath, actions)
 }
@@ -5556,7 +6259,7 @@ func (a *App) ValidateHookCommand(cmd string) (*HookValidationResult, error) {
 // GetMergedHooksConfig returns merged hooks config from global and project levels
 func (a *App) GetMergedHooksConfig(projectPath string) (*claude.HooksConfig, error) {
 	if a.config == nil {
-		return &claude.HooksConfig{}, nil
+		return nil, a.unavailable(subsystemConfig)
 	}
 
 	// Get global hooks
@@ -5571,36 +6274,18 @@ func (a *App) GetMergedHooksConfig(projectPath string) (*claude.HooksConfig, err
 	}
 
 	// Get project-level hooks
-	projectClaudeDir := filepath.Join(projectPath, ".claude")
-	projectSettingsPath := filepath.Join(projectClaudeDir, "settings.json")
-
-	projectSettings, err := claude.LoadSettings(projectSettingsPath)
-	if err != nil || projectSettings == nil {
-		return globalHooks, nil
-	}
-
-	// Extract project hooks
-	hooksData, ok := projectSettings["hooks"]
-	if !ok {
-		return globalHooks, nil
-	}
-
-	hooksJSON, err := json.Marshal(hooksData)
+	projectHooks, err := claude.GetScopedHooks(a.config.ClaudeDir, claude.HookScopeProject, projectPath)
 	if err != nil {
 		return globalHooks, nil
 	}
 
-	var projectHooks claude.HooksConfig
-	if err := json.Unmarshal(hooksJSON, &projectHooks); err != nil {
-		return globalHooks, nil
-	}
-
 	// Merge hooks (project hooks take precedence)
 	mergedHooks := &claude.HooksConfig{
 		PreToolUse:   mergeHookMatchers(globalHooks.PreToolUse, projectHooks.PreToolUse),
 		PostToolUse:  mergeHookMatchers(globalHooks.PostToolUse, projectHooks.PostToolUse),
 		Notification: mergeHookMatchers(globalHooks.Notification, projectHooks.Notification),
 		Stop:         mergeHookMatchers(globalHooks.Stop, projectHooks.Stop),
+		SubagentStop: mergeHookMatchers(globalHooks.SubagentStop, projectHooks.SubagentStop),
 	}
 
 	return mergedHooks, nil
@@ -5640,7 +6325,7 @@ func mergeHookMatchers(global, project []claude.HookMatcher) []claude.HookMatche
 // UpdateProviderApiConfig updates an existing provider API configuration
 func (a *App) UpdateProviderApiConfig(id string, updates map[string]interface{}) (*database.ProviderApiConfig, error) {
 	if a.dbManager == nil {
-		return nil, fmt.Errorf("database manager not initialized")
+		return nil, a.unavailable(subsystemDatabase)
 	}
 
 	// Get existing config
@@ -5682,24 +6367,28 @@ func (a *App) UpdateProviderApiConfig(id string, updates map[string]interface{})
 // TestSshConnection tests an SSH connection
 func (a *App) TestSshConnection(conn ssh.SshConnection) error {
 	if a.sshManager == nil {
-		return fmt.Errorf("SSH manager not initialized")
+		return a.unavailable(subsystemSSH)
 	}
 
-	// Build SSH command to test connection
-	sshArgs := []string{
-		"-o", "BatchMode=yes",
-		"-o", "ConnectTimeout=10",
-		"-o", "StrictHostKeyChecking=no",
-		"-p", fmt.Sprintf("%d", conn.Port),
+	if conn.Port == 0 {
+		conn.Port = 22
+	}
+	if err := a.sshManager.VerifyHostKey(&conn); err != nil {
+		return err
 	}
 
-	if conn.KeyPath != "" {
-		sshArgs = append(sshArgs, "-i", conn.KeyPath)
+	env, err := a.sshManager.CommandEnv(&conn)
+	if err != nil {
+		return err
 	}
 
+	// Build SSH command to test connection
+	sshArgs := []string{"-o", "ConnectTimeout=10"}
+	sshArgs = append(sshArgs, a.sshManager.SSHOptions(&conn)...)
 	sshArgs = append(sshArgs, fmt.Sprintf("%s@%s", conn.User, conn.Host), "echo", "Connection successful")
 
 	cmd := exec.Command("ssh", sshArgs...)
+	cmd.Env = env
 	output, err := cmd.CombinedOutput()
 	if err != nil {
 		return fmt.Errorf("SSH connection failed: %s - %s", err.Error(), string(output))
@@ -5708,6 +6397,47 @@ func (a *App) TestSshConnection(conn ssh.SshConnection) error {
 	return nil
 }
 
+// GetHostKeyFingerprint fetches the host key an SSH server presents and
+// reports whether it is trusted, so the user can confirm it
+func (a *App) GetHostKeyFingerprint(host string, port int) (*ssh.HostKeyInfo, error) {
+	if a.sshManager == nil {
+		return nil, a.unavailable(subsystemSSH)
+	}
+	return a.sshManager.GetHostKeyFingerprint(host, port)
+}
+
+// AcceptHostKey trusts the host key with the fingerprint the user confirmed
+func (a *App) AcceptHostKey(host string, port int, fingerprint string) error {
+	if a.sshManager == nil {
+		return a.unavailable(subsystemSSH)
+	}
+	return a.sshManager.AcceptHostKey(host, port, fingerprint)
+}
+
+// RejectHostKey refuses the host key with the fingerprint the user rejected
+func (a *App) RejectHostKey(host string, port int, fingerprint string) error {
+	if a.sshManager == nil {
+		return a.unavailable(subsystemSSH)
+	}
+	return a.sshManager.RejectHostKey(host, port, fingerprint)
+}
+
+// ForgetHostKey removes the accepted and rejected keys of a host
+func (a *App) ForgetHostKey(host string, port int) error {
+	if a.sshManager == nil {
+		return a.unavailable(subsystemSSH)
+	}
+	return a.sshManager.ForgetHostKey(host, port)
+}
+
+// ListKnownHosts returns the host keys accepted or rejected in ropcode
+func (a *App) ListKnownHosts() ([]ssh.KnownHost, error) {
+	if a.sshManager == nil {
+		return []ssh.KnownHost{}, a.unavailable(subsystemSSH)
+	}
+	return a.sshManager.ListKnownHosts()
+}
+
 // ===== Git Clone Repository Binding =====
 
 // CloneRepositoryResult represents the result of cloning a repository
@@ -5733,7 +6463,7 @@ func (a *App) CloneRepository(repoUrl, destPath, branch string) (*CloneRepositor
 		args = append(args, destPath)
 	}
 
-	cmd := exec.Command("git", args...)
+	cmd := git.Command(args...)
 	output, err := cmd.CombinedOutput()
 	if err != nil {
 		return nil, fmt.Errorf("git clone failed: %s - %s", err.Error(), string(output))
@@ -0,0 +1,171 @@
+// checkpoints.go
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"ropcode/internal/database"
+	"ropcode/internal/git"
+	"ropcode/internal/settings"
+)
+
+const sessionCheckpointsSettingKey = "session_checkpoints_enabled"
+
+// checkpointRetention is how long checkpoints are kept
+const checkpointRetention = 30 * 24 * time.Hour
+
+// checkpointLinkWindow bounds the time between taking a new session's
+// checkpoint and the session reporting its ID
+const checkpointLinkWindow = 10 * time.Minute
+
+// createSessionCheckpoint snapshots projectPath before a session runs and
+// returns it, nil when none was taken. sessionID is empty for a new
+// session. Failing to take one doesn't stop the session.
+func (a *App) createSessionCheckpoint(provider, projectPath, sessionID string) *database.SessionCheckpoint {
+	if a.dbManager == nil {
+		return nil
+	}
+	raw, _ := a.dbManager.GetSetting(sessionCheckpointsSettingKey)
+	if enabled, _ := settings.Lookup(sessionCheckpointsSettingKey).Decode(raw).(bool); !enabled {
+		return nil
+	}
+	if remote, err := a.GetRemoteProject(projectPath); err != nil || remote != nil {
+		return nil
+	}
+	projectPath = filepath.Clean(projectPath)
+	a.pruneSessionCheckpoints(projectPath)
+
+	label := sessionID
+	if label == "" {
+		label = "new " + provider + " session"
+	}
+	snapshot, err := git.CreateCheckpoint(projectPath, uuid.New().String(), "Before "+label)
+	if err != nil {
+		if !errors.Is(err, git.ErrNotRepository) {
+			slog.Error("failed to snapshot", "component", "Checkpoints", "project", projectPath, "error", err)
+		}
+		return nil
+	}
+	checkpoint := &database.SessionCheckpoint{
+		SessionID:   sessionID,
+		Provider:    provider,
+		ProjectPath: projectPath,
+		Commit:      snapshot.Commit,
+		Ref:         snapshot.Ref,
+	}
+	if _, err := a.dbManager.CreateSessionCheckpoint(checkpoint); err != nil {
+		slog.Error("failed to record checkpoint", "component", "Checkpoints", "error", err)
+		git.DeleteCheckpoint(projectPath, snapshot.Ref)
+		return nil
+	}
+	return checkpoint
+}
+
+// dropSessionCheckpoint deletes the checkpoint of a session that failed to
+// start
+func (a *App) dropSessionCheckpoint(checkpoint *database.SessionCheckpoint) {
+	if checkpoint != nil && a.dbManager != nil {
+		a.deleteSessionCheckpoint(checkpoint)
+	}
+}
+
+// pruneSessionCheckpoints deletes the checkpoints of projectPath older
+// than checkpointRetention
+func (a *App) pruneSessionCheckpoints(projectPath string) {
+	old, err := a.dbManager.ListProjectCheckpointsBefore(projectPath, time.Now().Add(-checkpointRetention))
+	if err != nil {
+		return
+	}
+	for _, checkpoint := range old {
+		a.deleteSessionCheckpoint(checkpoint)
+	}
+}
+
+func (a *App) deleteSessionCheckpoint(checkpoint *database.SessionCheckpoint) {
+	if err := git.DeleteCheckpoint(checkpoint.ProjectPath, checkpoint.Ref); err != nil {
+		slog.Warn("failed to delete", "component", "Checkpoints", "ref", checkpoint.Ref, "error", err)
+	}
+	a.dbManager.DeleteSessionCheckpoint(checkpoint.ID)
+}
+
+// linkCheckpointSession gives sessionID the checkpoint taken for it when it
+// was started in cwd. A session that already has a recent checkpoint was
+// resumed and had its own taken, so it doesn't claim another session's.
+func (a *App) linkCheckpointSession(sessionID, cwd string) {
+	if a.dbManager == nil || sessionID == "" || cwd == "" {
+		return
+	}
+	since := time.Now().Add(-checkpointLinkWindow)
+	checkpoints, err := a.dbManager.ListSessionCheckpoints(sessionID)
+	if err != nil {
+		return
+	}
+	for _, checkpoint := range checkpoints {
+		if !checkpoint.CreatedAt.Before(since) {
+			return
+		}
+	}
+	if _, err := a.dbManager.LinkSessionCheckpoint(filepath.Clean(cwd), sessionID, since); err != nil {
+		slog.Error("failed to link session", "component", "Checkpoints", "session_id", sessionID, "error", err)
+	}
+}
+
+// watchInitLine links checkpoints when a claude-output line is a session's
+// init line
+func (a *App) watchInitLine(line string) {
+	if !strings.Contains(line, `"init"`) {
+		return
+	}
+	var event struct {
+		Type      string `json:"type"`
+		Subtype   string `json:"subtype"`
+		SessionID string `json:"session_id"`
+		Cwd       string `json:"cwd"`
+	}
+	if json.Unmarshal([]byte(line), &event) != nil || event.Type != "system" || event.Subtype != "init" {
+		return
+	}
+	a.linkCheckpointSession(event.SessionID, event.Cwd)
+}
+
+// ListCheckpoints returns the checkpoints taken before the runs of a
+// session, oldest first
+func (a *App) ListCheckpoints(sessionID string) ([]*database.SessionCheckpoint, error) {
+	if a.dbManager == nil {
+		return nil, a.unavailable(subsystemDatabase)
+	}
+	return a.dbManager.ListSessionCheckpoints(sessionID)
+}
+
+// RestoreCheckpoint rolls the session's project back to its files before
+// the session first ran. The files as they were before the restore are
+// kept on a ref, so it can be undone with git.
+func (a *App) RestoreCheckpoint(sessionID string) (*git.RestoreResult, error) {
+	if a.dbManager == nil {
+		return nil, a.unavailable(subsystemDatabase)
+	}
+	checkpoints, err := a.dbManager.ListSessionCheckpoints(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if len(checkpoints) == 0 {
+		return nil, fmt.Errorf("no checkpoint for session %s", sessionID)
+	}
+	first := checkpoints[0]
+	result, err := git.RestoreCheckpoint(first.ProjectPath, first.Commit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to restore checkpoint: %w", err)
+	}
+	if err := a.dbManager.MarkSessionCheckpointRestored(first.ID); err != nil {
+		slog.Error("failed to mark checkpoint restored", "component", "Checkpoints", "checkpoint_id", first.ID, "error", err)
+	}
+	return result, nil
+}
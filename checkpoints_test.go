@@ -0,0 +1,67 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"ropcode/internal/config"
+)
+
+func TestSessionCheckpointRollsBackANewSession(t *testing.T) {
+	project := t.TempDir()
+	runGit(t, project, "init")
+	runGit(t, project, "-c", "user.name=t", "-c", "user.email=t@example.com", "commit", "--allow-empty", "-m", "initial")
+	if err := os.WriteFile(filepath.Join(project, "main.go"), []byte("mine"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	db := openAppConfigTestDB(t)
+	app := &App{dbManager: db, config: &config.Config{RopcodeDir: filepath.Join(t.TempDir(), ".ropcode")}}
+	if checkpoint := app.createSessionCheckpoint("codex", project, ""); checkpoint != nil {
+		t.Fatal("checkpoint taken with checkpoints off")
+	}
+	if err := db.SaveSetting(sessionCheckpointsSettingKey, "true"); err != nil {
+		t.Fatal(err)
+	}
+	if checkpoint := app.createSessionCheckpoint("codex", t.TempDir(), ""); checkpoint != nil {
+		t.Fatal("checkpoint taken outside a git repository")
+	}
+	if checkpoint := app.createSessionCheckpoint("codex", project, ""); checkpoint == nil {
+		t.Fatal("no checkpoint taken")
+	}
+
+	// The session reports its ID, then its agent changes the project
+	app.watchInitLine(`{"type":"system","subtype":"init","cwd":"` + project + `/","session_id":"thread-1"}`)
+	if err := os.WriteFile(filepath.Join(project, "main.go"), []byte("agent"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(project, "new.go"), []byte("agent"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	checkpoints, err := app.ListCheckpoints("thread-1")
+	if err != nil || len(checkpoints) != 1 {
+		t.Fatalf("ListCheckpoints = %+v, %v", checkpoints, err)
+	}
+	result, err := app.RestoreCheckpoint("thread-1")
+	if err != nil {
+		t.Fatalf("RestoreCheckpoint: %v", err)
+	}
+	if len(result.Restored) != 1 || len(result.Removed) != 1 {
+		t.Fatalf("result = %+v", result)
+	}
+	if data, _ := os.ReadFile(filepath.Join(project, "main.go")); string(data) != "mine" {
+		t.Fatalf("main.go = %q", data)
+	}
+	if _, err := os.Stat(filepath.Join(project, "new.go")); !os.IsNotExist(err) {
+		t.Fatalf("new.go not removed: %v", err)
+	}
+	if checkpoints, _ := app.ListCheckpoints("thread-1"); checkpoints[0].RestoredAt == nil {
+		t.Fatal("checkpoint not marked restored")
+	}
+
+	if _, err := app.RestoreCheckpoint("thread-2"); err == nil {
+		t.Fatal("restored a session without checkpoints")
+	}
+}
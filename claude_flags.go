@@ -0,0 +1,48 @@
+// claude_flags.go
+package main
+
+import (
+	"log"
+
+	"ropcode/internal/claude"
+	"ropcode/internal/settings"
+)
+
+const (
+	claudeFlagProfilesSettingKey = "claude_flag_profiles"
+	claudeFlagProfileSettingKey  = "claude_flag_profile"
+)
+
+// claudeFlagProfileArgs returns the arguments of the selected flag profile,
+// so new CLI options can be used before ropcode has a setting for them
+func (a *App) claudeFlagProfileArgs() []string {
+	if a.dbManager == nil {
+		return nil
+	}
+	name, err := a.dbManager.GetSetting(claudeFlagProfileSettingKey)
+	if err != nil || name == "" {
+		return nil
+	}
+	raw, err := a.dbManager.GetSetting(claudeFlagProfilesSettingKey)
+	if err != nil {
+		return nil
+	}
+	profiles, _ := settings.Lookup(claudeFlagProfilesSettingKey).Decode(raw).(map[string]interface{})
+	list, ok := profiles[name].([]interface{})
+	if !ok {
+		log.Printf("[Claude] flag profile %q not found", name)
+		return nil
+	}
+	args := make([]string, 0, len(list))
+	for _, arg := range list {
+		if s, ok := arg.(string); ok {
+			args = append(args, s)
+		}
+	}
+	return args
+}
+
+// applyClaudeFlagProfile adds the selected flag profile to a Claude session
+func (a *App) applyClaudeFlagProfile(config *claude.SessionConfig) {
+	config.ExtraArgs = append(config.ExtraArgs, a.claudeFlagProfileArgs()...)
+}
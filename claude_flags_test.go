@@ -0,0 +1,39 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"ropcode/internal/claude"
+)
+
+func TestClaudeFlagProfileAddsExtraArgs(t *testing.T) {
+	app := &App{dbManager: openAppConfigTestDB(t)}
+
+	config := claude.SessionConfig{}
+	app.applyClaudeFlagProfile(&config)
+	if len(config.ExtraArgs) != 0 {
+		t.Fatalf("extra args without a profile = %q", config.ExtraArgs)
+	}
+
+	if _, err := app.UpdateSettings(map[string]interface{}{
+		claudeFlagProfilesSettingKey: map[string]interface{}{
+			"Shared": []interface{}{"--add-dir", "/srv/shared"},
+			"Other":  []interface{}{"--fallback-model", "sonnet"},
+		},
+		claudeFlagProfileSettingKey: "Shared",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	app.applyClaudeFlagProfile(&config)
+	if want := []string{"--add-dir", "/srv/shared"}; !reflect.DeepEqual(config.ExtraArgs, want) {
+		t.Fatalf("extra args = %q, want %q", config.ExtraArgs, want)
+	}
+
+	if _, err := app.UpdateSettings(map[string]interface{}{claudeFlagProfileSettingKey: "Missing"}); err != nil {
+		t.Fatal(err)
+	}
+	if args := app.claudeFlagProfileArgs(); args != nil {
+		t.Fatalf("args of a missing profile = %q", args)
+	}
+}
@@ -0,0 +1,133 @@
+// claude_settings_watcher.go
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"ropcode/internal/watcher"
+)
+
+// claudeSettingsDebounce batches the writes of one save
+const claudeSettingsDebounce = 300 * time.Millisecond
+
+// ClaudeSettingsChange describes an edit of ~/.claude/settings.json
+type ClaudeSettingsChange struct {
+	Path string `json:"path"`
+	// Valid is false when the file is missing or not valid JSON
+	Valid bool   `json:"valid"`
+	Error string `json:"error,omitempty"`
+	// EnvKeys names the variables of the env section
+	EnvKeys []string `json:"env_keys"`
+	// ChangedEnvKeys names the variables added, removed or changed by the edit
+	ChangedEnvKeys []string `json:"changed_env_keys"`
+}
+
+// startClaudeSettingsWatcher starts watching the Claude directory for edits
+// of settings.json. The directory is watched because editors often replace
+// the file rather than write it in place.
+func (a *App) startClaudeSettingsWatcher() {
+	if a.config == nil {
+		return
+	}
+	path := filepath.Join(a.config.ClaudeDir, "settings.json")
+	env, _ := readClaudeSettingsEnvFile(path)
+	a.mu.Lock()
+	a.claudeSettingsEnv = env
+	a.mu.Unlock()
+
+	w, err := watcher.New(a.config.ClaudeDir, claudeSettingsDebounce, func(e watcher.Event) {
+		if filepath.Base(e.Path) == "settings.json" {
+			a.onClaudeSettingsChanged(path)
+		}
+	})
+	if err != nil {
+		log.Printf("[claude-settings] not watching %s: %v", a.config.ClaudeDir, err)
+		return
+	}
+	if err := w.Start(); err != nil {
+		w.Close()
+		slog.Error("failed to start watcher", "component", "claude-settings", "error", err)
+		return
+	}
+	a.claudeSettingsWatch = w
+}
+
+// onClaudeSettingsChanged reloads what depends on the settings at path
+func (a *App) onClaudeSettingsChanged(path string) {
+	env, err := readClaudeSettingsEnvFile(path)
+	change := &ClaudeSettingsChange{Path: path, Valid: err == nil, EnvKeys: []string{}}
+	if err != nil {
+		change.Error = err.Error()
+	}
+	for key := range env {
+		change.EnvKeys = append(change.EnvKeys, key)
+	}
+	sort.Strings(change.EnvKeys)
+
+	a.mu.Lock()
+	// A file being rewritten can be caught half written; keep the last
+	// env read until it parses again
+	previous := a.claudeSettingsEnv
+	if err == nil {
+		a.claudeSettingsEnv = env
+	}
+	discovery := a.capabilityDiscovery
+	a.mu.Unlock()
+
+	if err == nil {
+		change.ChangedEnvKeys = changedEnvKeys(previous, env)
+	} else {
+		change.ChangedEnvKeys = []string{}
+	}
+	if discovery != nil {
+		discovery.InvalidateUser()
+	}
+	log.Printf("[claude-settings] %s changed (valid=%t, env keys changed: %v)", path, change.Valid, change.ChangedEnvKeys)
+	if a.eventHub != nil {
+		a.eventHub.Emit("claude-settings:changed", change)
+	}
+}
+
+// readClaudeSettingsEnvFile reads the env section of a Claude settings file
+func readClaudeSettingsEnvFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var settings struct {
+		Env map[string]interface{} `json:"env"`
+	}
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return nil, fmt.Errorf("invalid settings.json: %w", err)
+	}
+	env := make(map[string]string, len(settings.Env))
+	for key, value := range settings.Env {
+		env[key] = fmt.Sprint(value)
+	}
+	return env, nil
+}
+
+// changedEnvKeys returns the sorted names whose values differ between two
+// env sections
+func changedEnvKeys(before, after map[string]string) []string {
+	changed := []string{}
+	for key, value := range after {
+		if old, ok := before[key]; !ok || old != value {
+			changed = append(changed, key)
+		}
+	}
+	for key := range before {
+		if _, ok := after[key]; !ok {
+			changed = append(changed, key)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"ropcode/internal/claude"
+	"ropcode/internal/eventhub"
+)
+
+type claudeSettingsRecorder struct {
+	changes []*ClaudeSettingsChange
+}
+
+func (r *claudeSettingsRecorder) BroadcastEvent(eventType string, payload interface{}) {
+	if eventType == "claude-settings:changed" {
+		r.changes = append(r.changes, payload.(*ClaudeSettingsChange))
+	}
+}
+
+type invalidationCounter struct {
+	claudeCapabilityDiscovery
+	invalidated int
+}
+
+func (d *invalidationCounter) InvalidateUser() { d.invalidated++ }
+
+var _ claudeCapabilityDiscovery = (*claude.CapabilityDiscoveryService)(nil)
+
+func TestClaudeSettingsChangeReloadsEnvAndInvalidatesDiscovery(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "settings.json")
+	hub := eventhub.New(nil)
+	recorder := &claudeSettingsRecorder{}
+	hub.SetBroadcaster(recorder)
+	discovery := &invalidationCounter{}
+	app := &App{
+		eventHub:            hub,
+		capabilityDiscovery: discovery,
+		claudeSettingsEnv:   map[string]string{"ANTHROPIC_BASE_URL": "https://a.example", "OLD": "1"},
+	}
+
+	write := func(content string) {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		app.onClaudeSettingsChanged(path)
+	}
+
+	write(`{"env": {"ANTHROPIC_BASE_URL": "https://b.example", "ANTHROPIC_AUTH_TOKEN": "secret", "RETRIES": 3}}`)
+	change := recorder.changes[0]
+	if !change.Valid || strings.Join(change.EnvKeys, ",") != "ANTHROPIC_AUTH_TOKEN,ANTHROPIC_BASE_URL,RETRIES" {
+		t.Fatalf("change = %+v", change)
+	}
+	if strings.Join(change.ChangedEnvKeys, ",") != "ANTHROPIC_AUTH_TOKEN,ANTHROPIC_BASE_URL,OLD,RETRIES" {
+		t.Fatalf("changed env keys = %v", change.ChangedEnvKeys)
+	}
+	if app.claudeSettingsEnv["RETRIES"] != "3" || discovery.invalidated != 1 {
+		t.Fatalf("env = %v, invalidated = %d", app.claudeSettingsEnv, discovery.invalidated)
+	}
+
+	// A half-written file keeps the env last read
+	write(`{"env": {`)
+	change = recorder.changes[1]
+	if change.Valid || change.Error == "" || len(change.ChangedEnvKeys) != 0 {
+		t.Fatalf("change = %+v", change)
+	}
+	if app.claudeSettingsEnv["ANTHROPIC_AUTH_TOKEN"] != "secret" {
+		t.Fatalf("env = %v", app.claudeSettingsEnv)
+	}
+
+	write(`{"env": {"ANTHROPIC_BASE_URL": "https://b.example", "ANTHROPIC_AUTH_TOKEN": "secret", "RETRIES": 3}}`)
+	if changed := recorder.changes[2].ChangedEnvKeys; len(changed) != 0 {
+		t.Fatalf("changed env keys = %v", changed)
+	}
+}
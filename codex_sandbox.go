@@ -0,0 +1,38 @@
+// codex_sandbox.go
+package main
+
+import (
+	"log"
+
+	"ropcode/internal/codex"
+	"ropcode/internal/settings"
+)
+
+const (
+	codexSandboxModeSettingKey     = "codex_sandbox_mode"
+	codexNetworkAccessSettingKey   = "codex_network_access"
+	codexConfigOverridesSettingKey = "codex_config_overrides"
+)
+
+// applyCodexSandboxSettings gives a Codex session the sandbox defaults when
+// its permission profile sets none, and the -c overrides in every case
+func (a *App) applyCodexSandboxSettings(config *codex.SessionConfig) {
+	setting := func(key string) interface{} {
+		raw := ""
+		if a.dbManager != nil {
+			raw, _ = a.dbManager.GetSetting(key)
+		}
+		return settings.Lookup(key).Decode(raw)
+	}
+	config.SandboxMode, _ = setting(codexSandboxModeSettingKey).(string)
+	network, _ := setting(codexNetworkAccessSettingKey).(bool)
+	config.DisableNetwork = !network
+
+	text, _ := setting(codexConfigOverridesSettingKey).(string)
+	overrides, err := settings.ParseConfigOverrides(text)
+	if err != nil {
+		log.Printf("[Codex] ignoring config overrides: %v", err)
+		return
+	}
+	config.ConfigOverrides = append(config.ConfigOverrides, overrides...)
+}
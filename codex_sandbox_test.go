@@ -0,0 +1,58 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"ropcode/internal/codex"
+	"ropcode/internal/database"
+)
+
+func TestCodexSandboxSettings(t *testing.T) {
+	app := &App{dbManager: openAppConfigTestDB(t)}
+
+	// The defaults keep the full access sessions had before
+	config := codex.SessionConfig{ProjectPath: "/p"}
+	app.applyCodexSandboxSettings(&config)
+	if config.SandboxMode != codex.SandboxDangerFullAccess || config.DisableNetwork || len(config.ConfigOverrides) != 0 {
+		t.Fatalf("default config = %+v", config)
+	}
+
+	if _, err := app.UpdateSettings(map[string]interface{}{
+		codexSandboxModeSettingKey:     codex.SandboxWorkspaceWrite,
+		codexNetworkAccessSettingKey:   false,
+		codexConfigOverridesSettingKey: "model_verbosity=\"low\"\n",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	config = codex.SessionConfig{ProjectPath: "/p"}
+	app.applyCodexSandboxSettings(&config)
+	app.applyPermissionProfileToCodex(&config)
+	if config.SandboxMode != codex.SandboxWorkspaceWrite || !config.DisableNetwork ||
+		!reflect.DeepEqual(config.ConfigOverrides, []string{`model_verbosity="low"`}) {
+		t.Fatalf("configured config = %+v", config)
+	}
+
+	// A profile's sandbox wins over the settings; one without a sandbox
+	// leaves them alone
+	if err := app.SetProjectPermissionProfile("/p", permissionProfileReadOnly); err != nil {
+		t.Fatal(err)
+	}
+	app.applyPermissionProfileToCodex(&config)
+	if config.SandboxMode != codex.SandboxReadOnly {
+		t.Fatalf("profile sandbox = %q, want read-only", config.SandboxMode)
+	}
+	custom, err := app.SavePermissionProfile(database.PermissionProfile{Name: "Claude only", ClaudePermissionMode: "plan"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := app.SetProjectPermissionProfile("/p", custom.ID); err != nil {
+		t.Fatal(err)
+	}
+	config = codex.SessionConfig{ProjectPath: "/p"}
+	app.applyCodexSandboxSettings(&config)
+	app.applyPermissionProfileToCodex(&config)
+	if config.SandboxMode != codex.SandboxWorkspaceWrite || !config.DisableNetwork {
+		t.Fatalf("config with a profile without a sandbox = %+v", config)
+	}
+}
@@ -0,0 +1,66 @@
+// command_jobs.go
+package main
+
+import (
+	"time"
+
+	"ropcode/internal/jobs"
+)
+
+// RunJob runs command in the preferred shell in cwd and returns the job's
+// key at once; the output follows as job-output events. env is added to the
+// project's environment. A positive timeoutSeconds cancels the job when it
+// runs longer.
+func (a *App) RunJob(command, cwd string, timeoutSeconds int, env map[string]string) (string, error) {
+	if a.jobRunner == nil {
+		return "", a.unavailable(subsystemProcesses)
+	}
+	// The job's own variables override the project's
+	merged := a.projectEnv(cwd)
+	if merged == nil {
+		merged = make(map[string]string, len(env))
+	}
+	for name, value := range env {
+		merged[name] = value
+	}
+	job, err := a.jobRunner.Start(jobs.Spec{
+		Command: command,
+		Cwd:     cwd,
+		Env:     merged,
+		Shell:   a.shellPreference(),
+		Timeout: time.Duration(timeoutSeconds) * time.Second,
+	})
+	if err != nil {
+		return "", err
+	}
+	return job.Key, nil
+}
+
+// CancelJob stops a running job; its exit event has status cancelled
+func (a *App) CancelJob(key string) error {
+	if a.jobRunner == nil {
+		return a.unavailable(subsystemProcesses)
+	}
+	return a.jobRunner.Cancel(key)
+}
+
+// ListJobs returns the running and recently finished jobs, those of
+// ExecuteCommandAsync included, newest first
+func (a *App) ListJobs() ([]*jobs.Job, error) {
+	if a.jobRunner == nil {
+		return []*jobs.Job{}, nil
+	}
+	return a.jobRunner.List(), nil
+}
+
+// GetJob returns a running or recently finished job
+func (a *App) GetJob(key string) (*jobs.Job, error) {
+	if a.jobRunner == nil {
+		return nil, a.unavailable(subsystemProcesses)
+	}
+	job, ok := a.jobRunner.Get(key)
+	if !ok {
+		return nil, jobs.ErrNotFound
+	}
+	return job, nil
+}
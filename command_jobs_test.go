@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"ropcode/internal/eventhub"
+	"ropcode/internal/jobs"
+	"ropcode/internal/process"
+)
+
+type jobOutputRecorder struct {
+	mu     sync.Mutex
+	stdout string
+	exit   *jobs.Output
+}
+
+func (r *jobOutputRecorder) BroadcastEvent(eventType string, payload interface{}) {
+	if eventType != jobs.OutputEvent {
+		return
+	}
+	output := payload.(jobs.Output)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	switch output.OutputType {
+	case "stdout":
+		r.stdout += output.Content
+	case "exit":
+		r.exit = &output
+	}
+}
+
+func TestRunJobStreamsOutputWithProjectEnv(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test commands use sh")
+	}
+	hub := eventhub.New(nil)
+	recorder := &jobOutputRecorder{}
+	hub.SetBroadcaster(recorder)
+	procs := process.NewManager(context.Background())
+	app := &App{eventHub: hub, jobRunner: jobs.New(procs, hub)}
+
+	key, err := app.RunJob(`echo "$JOB_GREETING"`, t.TempDir(), 10, map[string]string{"JOB_GREETING": "hello"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		recorder.mu.Lock()
+		exit, stdout := recorder.exit, recorder.stdout
+		recorder.mu.Unlock()
+		if exit != nil {
+			if exit.JobKey != key || exit.Status != jobs.StatusSucceeded || stdout != "hello\n" {
+				t.Fatalf("exit = %+v, stdout = %q", exit, stdout)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("job never ended")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	job, err := app.GetJob(key)
+	if err != nil || job.Status != jobs.StatusSucceeded || *job.ExitCode != 0 {
+		t.Fatalf("GetJob() = %+v, %v", job, err)
+	}
+	if _, err := app.GetJob("job-missing"); err != jobs.ErrNotFound {
+		t.Fatalf("GetJob() of an unknown job error = %v", err)
+	}
+	if err := app.CancelJob(key); err != jobs.ErrNotRunning {
+		t.Fatalf("CancelJob() of a finished job error = %v", err)
+	}
+}
+
+func TestExecuteCommandAsyncKeysAreUnique(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test commands use sh")
+	}
+	procs := process.NewManager(context.Background())
+	app := &App{jobRunner: jobs.New(procs, nil)}
+	defer procs.KillAll()
+
+	cwd := t.TempDir()
+	first, err := app.ExecuteCommandAsync("sleep", []string{"5"}, cwd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := app.ExecuteCommandAsync("sleep", []string{"5"}, cwd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first == second || !procs.IsAlive(first) || !procs.IsAlive(second) {
+		t.Fatalf("keys %q and %q should name two running processes", first, second)
+	}
+
+	list, err := app.ListJobs()
+	if err != nil || len(list) != 2 {
+		t.Fatalf("ListJobs() = %+v, %v", list, err)
+	}
+	for _, job := range list {
+		if job.Command != "sleep" || job.Cwd != cwd || job.Status != jobs.StatusRunning {
+			t.Errorf("listed job = %+v", job)
+		}
+	}
+}
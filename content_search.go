@@ -0,0 +1,97 @@
+// content_search.go
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+
+	"ropcode/internal/pathutil"
+	"ropcode/internal/search"
+)
+
+// contentSearchContextLines is how many lines around a match are returned
+const contentSearchContextLines = 2
+
+// contentSearchSeq numbers content searches
+var contentSearchSeq atomic.Int64
+
+// ContentSearchMatchesEvent is the payload of "content-search:matches"
+type ContentSearchMatchesEvent struct {
+	SearchID int64          `json:"search_id"`
+	Matches  []search.Match `json:"matches"`
+}
+
+// ContentSearchDoneEvent is the payload of "content-search:done"
+type ContentSearchDoneEvent struct {
+	SearchID int64 `json:"search_id"`
+	search.Result
+	Cancelled bool   `json:"cancelled"`
+	Error     string `json:"error,omitempty"`
+}
+
+// SearchFileContents searches the files under basePath for query, a regular
+// expression when regex is set. includeGlobs limits the files searched and
+// maxResults the matches returned (0 for the default). It returns the ID
+// carried by the search's events. A new search doesn't stop older ones; the
+// frontend cancels those it no longer needs.
+func (a *App) SearchFileContents(basePath, query string, regex bool, includeGlobs []string, maxResults int) (int64, error) {
+	basePath = pathutil.NormalizeClientPath(basePath)
+	opts := search.Options{
+		Query:        query,
+		Regex:        regex,
+		Include:      includeGlobs,
+		MaxResults:   maxResults,
+		ContextLines: contentSearchContextLines,
+	}
+	// Report a bad query to the caller rather than as an event
+	if _, err := search.Compile(opts); err != nil {
+		return 0, err
+	}
+
+	parent := a.ctx
+	if parent == nil {
+		parent = context.Background()
+	}
+	ctx, cancel := context.WithCancel(parent)
+	searchID := contentSearchSeq.Add(1)
+	a.contentSearches.add(searchID, cancel)
+
+	go func() {
+		defer cancel()
+		defer a.contentSearches.remove(searchID)
+
+		result, err := search.Search(ctx, basePath, opts, func(matches []search.Match) {
+			a.emitContentSearch("content-search:matches", &ContentSearchMatchesEvent{
+				SearchID: searchID,
+				Matches:  matches,
+			})
+		})
+		done := &ContentSearchDoneEvent{SearchID: searchID}
+		if result != nil {
+			done.Result = *result
+		}
+		if errors.Is(err, context.Canceled) {
+			done.Cancelled = true
+		} else if err != nil {
+			done.Error = err.Error()
+		}
+		a.emitContentSearch("content-search:done", done)
+	}()
+	return searchID, nil
+}
+
+// CancelFileContentSearch stops a running content search
+func (a *App) CancelFileContentSearch(searchID int64) error {
+	if !a.contentSearches.cancel(searchID) {
+		return fmt.Errorf("content search %d is not running", searchID)
+	}
+	return nil
+}
+
+func (a *App) emitContentSearch(name string, payload interface{}) {
+	if a.eventHub != nil {
+		a.eventHub.Emit(name, payload)
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"ropcode/internal/eventhub"
+)
+
+type contentSearchRecorder struct {
+	mu      sync.Mutex
+	matches map[int64]int
+	done    map[int64]*ContentSearchDoneEvent
+}
+
+func (r *contentSearchRecorder) BroadcastEvent(eventType string, payload interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	switch eventType {
+	case "content-search:matches":
+		event := payload.(*ContentSearchMatchesEvent)
+		r.matches[event.SearchID] += len(event.Matches)
+	case "content-search:done":
+		event := payload.(*ContentSearchDoneEvent)
+		r.done[event.SearchID] = event
+	}
+}
+
+func (r *contentSearchRecorder) waitDone(t *testing.T, searchID int64) (*ContentSearchDoneEvent, int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		r.mu.Lock()
+		done, matches := r.done[searchID], r.matches[searchID]
+		r.mu.Unlock()
+		if done != nil {
+			return done, matches
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("search %d never finished", searchID)
+	return nil, 0
+}
+
+func TestSearchFileContentsStreamsMatches(t *testing.T) {
+	root := t.TempDir()
+	os.WriteFile(filepath.Join(root, "a.go"), []byte("// needle one\n// needle two\n"), 0644)
+	os.WriteFile(filepath.Join(root, "b.md"), []byte("needle\n"), 0644)
+
+	hub := eventhub.New(nil)
+	recorder := &contentSearchRecorder{matches: map[int64]int{}, done: map[int64]*ContentSearchDoneEvent{}}
+	hub.SetBroadcaster(recorder)
+	app := &App{eventHub: hub, contentSearches: newRunCancelStore()}
+
+	searchID, err := app.SearchFileContents(root, "needle", false, []string{"*.go"}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	done, matches := recorder.waitDone(t, searchID)
+	if matches != 2 || done.Matches != 2 || done.FilesMatched != 1 || done.Cancelled || done.Error != "" {
+		t.Fatalf("matches = %d, done = %+v", matches, done)
+	}
+
+	if _, err := app.SearchFileContents(root, "[", true, nil, 0); err == nil {
+		t.Error("SearchFileContents() accepted an invalid regular expression")
+	}
+	if err := app.CancelFileContentSearch(searchID); err == nil {
+		t.Error("CancelFileContentSearch() succeeded for a finished search")
+	}
+}
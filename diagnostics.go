@@ -0,0 +1,339 @@
+// diagnostics.go
+package main
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"ropcode/internal/database"
+)
+
+const (
+	// diagnosticsMaxLogFiles is how many of the newest log files are included
+	diagnosticsMaxLogFiles = 5
+	// diagnosticsMaxLogBytes caps the log bytes taken from each file, keeping
+	// the end of the file
+	diagnosticsMaxLogBytes = 4 << 20
+)
+
+// diagnosticsExcludedSettings hold user content and are never exported
+var diagnosticsExcludedSettings = map[string]bool{
+	generatedSessionTitlesSettingKey: true,
+	agentMarketplaceIndexSettingKey:  true,
+	telemetryInstallIDSettingKey:     true,
+}
+
+// DiagnosticsSystem is the system.json section of a diagnostics bundle
+type DiagnosticsSystem struct {
+	App            *AppVersionInfo `json:"app"`
+	OSVersion      string          `json:"os_version,omitempty"`
+	CPUs           int             `json:"cpus"`
+	SchemaVersion  int             `json:"schema_version"`            // of this build
+	DatabaseSchema int             `json:"database_schema,omitempty"` // recorded in the database
+	GeneratedAt    string          `json:"generated_at"`              // RFC3339
+}
+
+// DiagnosticsSessions is the sessions.json section of a diagnostics bundle
+type DiagnosticsSessions struct {
+	ProviderSessions []DiagnosticsSession  `json:"provider_sessions"`
+	AgentRuns        []DiagnosticsAgentRun `json:"agent_runs"`
+	Commands         int                   `json:"commands"`
+	Terminals        int                   `json:"terminals"`
+}
+
+// DiagnosticsSession summarizes a running provider session
+type DiagnosticsSession struct {
+	Provider  string `json:"provider"`
+	SessionID string `json:"session_id"`
+	Project   string `json:"project"` // base name only
+	Model     string `json:"model,omitempty"`
+	Status    string `json:"status"`
+	StartedAt string `json:"started_at"`
+}
+
+// DiagnosticsAgentRun summarizes a running agent run
+type DiagnosticsAgentRun struct {
+	ID        int64  `json:"id"`
+	Provider  string `json:"provider"`
+	Model     string `json:"model,omitempty"`
+	Status    string `json:"status"`
+	CreatedAt string `json:"created_at"`
+}
+
+// DiagnosticsSummary describes a written diagnostics bundle
+type DiagnosticsSummary struct {
+	Path     string   `json:"path"`
+	Sections []string `json:"sections"`
+	LogFiles int      `json:"log_files"`
+}
+
+// ExportDiagnostics writes a diagnostics bundle to path. The bundle carries
+// no prompts, output or credentials: secrets are masked and the home
+// directory becomes "~".
+func (a *App) ExportDiagnostics(path string) (*DiagnosticsSummary, error) {
+	if path == "" {
+		return nil, fmt.Errorf("path is required")
+	}
+	home, _ := os.UserHomeDir()
+	anonymize := newDiagnosticsAnonymizer(home)
+
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create diagnostics archive: %w", err)
+	}
+	summary := &DiagnosticsSummary{Path: path}
+	zw := zip.NewWriter(file)
+	err = a.writeDiagnostics(zw, summary, anonymize)
+	if closeErr := zw.Close(); err == nil {
+		err = closeErr
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path)
+		return nil, fmt.Errorf("failed to write diagnostics archive: %w", err)
+	}
+	return summary, nil
+}
+
+func (a *App) writeDiagnostics(zw *zip.Writer, summary *DiagnosticsSummary, anonymize func(string) string) error {
+	doctor, err := a.Doctor()
+	if err != nil {
+		return err
+	}
+	sections := []struct {
+		name  string
+		value interface{}
+	}{
+		{"system.json", a.diagnosticsSystem()},
+		{"health.json", a.GetAppHealth()},
+		{"doctor.json", doctor},
+		{"settings.json", a.diagnosticsSettings()},
+		{"sessions.json", a.diagnosticsSessions()},
+	}
+	for _, section := range sections {
+		data, err := json.MarshalIndent(section.value, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode %s: %w", section.name, err)
+		}
+		if err := writeZipFile(zw, section.name, []byte(anonymize(string(data)))); err != nil {
+			return err
+		}
+		summary.Sections = append(summary.Sections, section.name)
+	}
+
+	for _, path := range diagnosticsLogFiles(a.diagnosticsLogDir()) {
+		data, err := readTail(path, diagnosticsMaxLogBytes)
+		if err != nil {
+			continue
+		}
+		if err := writeZipFile(zw, "logs/"+filepath.Base(path), []byte(anonymize(string(data)))); err != nil {
+			return err
+		}
+		summary.LogFiles++
+	}
+	return nil
+}
+
+func (a *App) diagnosticsSystem() *DiagnosticsSystem {
+	system := &DiagnosticsSystem{
+		App:           currentVersion(),
+		OSVersion:     osVersion(),
+		CPUs:          runtime.NumCPU(),
+		SchemaVersion: database.SchemaVersion(),
+		GeneratedAt:   time.Now().Format(time.RFC3339),
+	}
+	if a.dbManager != nil {
+		system.DatabaseSchema, _ = a.dbManager.UserVersion()
+	}
+	return system
+}
+
+// diagnosticsSettings returns the settings worth sharing; values are
+// anonymized with the rest of the section
+func (a *App) diagnosticsSettings() map[string]string {
+	settings := map[string]string{}
+	if a.dbManager == nil {
+		return settings
+	}
+	all, err := a.dbManager.GetAllSettings()
+	if err != nil {
+		return settings
+	}
+	for key, value := range all {
+		if !diagnosticsExcludedSettings[key] {
+			settings[key] = value
+		}
+	}
+	return settings
+}
+
+func (a *App) diagnosticsSessions() *DiagnosticsSessions {
+	sessions := &DiagnosticsSessions{
+		ProviderSessions: []DiagnosticsSession{},
+		AgentRuns:        []DiagnosticsAgentRun{},
+	}
+	for _, s := range a.ListRunningProviderSessions() {
+		sessions.ProviderSessions = append(sessions.ProviderSessions, DiagnosticsSession{
+			Provider:  s.Provider,
+			SessionID: s.SessionID,
+			Project:   filepath.Base(s.ProjectPath),
+			Model:     s.Model,
+			Status:    s.Status,
+			StartedAt: s.StartedAt.Format(time.RFC3339),
+		})
+	}
+	if a.dbManager != nil {
+		if runs, err := a.dbManager.ListRunningAgentRuns(); err == nil {
+			for _, run := range runs {
+				sessions.AgentRuns = append(sessions.AgentRuns, DiagnosticsAgentRun{
+					ID:        run.ID,
+					Provider:  run.Provider,
+					Model:     run.Model,
+					Status:    run.Status,
+					CreatedAt: run.CreatedAt.Format(time.RFC3339),
+				})
+			}
+		}
+	}
+	if a.processManager != nil {
+		sessions.Commands = len(a.processManager.List())
+	}
+	if a.ptyManager != nil {
+		sessions.Terminals = len(a.ptyManager.ListSessions())
+	}
+	return sessions
+}
+
+func (a *App) diagnosticsLogDir() string {
+	if a.config != nil {
+		return a.config.LogDir
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".ropcode", "logs")
+}
+
+// diagnosticsLogFiles returns the newest log files of dir, newest first
+func diagnosticsLogFiles(dir string) []string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	type logFile struct {
+		path    string
+		modTime time.Time
+	}
+	var files []logFile
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".log" {
+			continue
+		}
+		if info, err := entry.Info(); err == nil {
+			files = append(files, logFile{filepath.Join(dir, entry.Name()), info.ModTime()})
+		}
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].modTime.After(files[j].modTime) })
+	var paths []string
+	for i := 0; i < len(files) && i < diagnosticsMaxLogFiles; i++ {
+		paths = append(paths, files[i].path)
+	}
+	return paths
+}
+
+// readTail reads the last max bytes of a file
+func readTail(path string, max int64) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() > max {
+		if _, err := f.Seek(-max, io.SeekEnd); err != nil {
+			return nil, err
+		}
+	}
+	return io.ReadAll(f)
+}
+
+func writeZipFile(zw *zip.Writer, name string, data []byte) error {
+	w, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+var diagnosticsSecretPatterns = []struct {
+	pattern *regexp.Regexp
+	replace string
+}{
+	// Provider keys: sk-ant-..., sk-proj-..., AIza...
+	{regexp.MustCompile(`\b(sk-[A-Za-z0-9_-]{8,}|AIza[A-Za-z0-9_-]{20,})`), "[REDACTED]"},
+	{regexp.MustCompile(`(?i)(bearer\s+)[A-Za-z0-9._~+/=-]+`), "${1}[REDACTED]"},
+	// key=value and "key": "value" pairs whose key names a secret
+	{regexp.MustCompile(`(?i)((?:api[_-]?key|auth[_-]?key|auth[_-]?token|access[_-]?token|token|password|secret)\\?"?\s*[:=]\s*\\?"?)[^\s"\\,}&]+`), "${1}[REDACTED]"},
+	// Credentials in URLs, such as proxy settings
+	{regexp.MustCompile(`(://)[^/\s:@"]+:[^/\s@"]+@`), "${1}[REDACTED]@"},
+	// Older servers logged both keys on a WebSocket auth mismatch
+	{regexp.MustCompile(`(WS auth mismatch: ).*?( path=)`), "${1}[REDACTED]${2}"},
+}
+
+// newDiagnosticsAnonymizer masks secrets and replaces the home directory
+func newDiagnosticsAnonymizer(home string) func(string) string {
+	return func(text string) string {
+		for _, secret := range diagnosticsSecretPatterns {
+			text = secret.pattern.ReplaceAllString(text, secret.replace)
+		}
+		if home != "" && home != "/" {
+			text = strings.ReplaceAll(text, home, "~")
+			if escaped, err := json.Marshal(home); err == nil {
+				text = strings.ReplaceAll(text, strings.Trim(string(escaped), `"`), "~")
+			}
+		}
+		return text
+	}
+}
+
+// osVersion describes the operating system release, empty when unknown
+func osVersion() string {
+	switch runtime.GOOS {
+	case "darwin":
+		if out, err := exec.Command("sw_vers", "-productVersion").Output(); err == nil {
+			return "macOS " + strings.TrimSpace(string(out))
+		}
+	case "linux":
+		data, err := os.ReadFile("/etc/os-release")
+		if err != nil {
+			return ""
+		}
+		for _, line := range strings.Split(string(data), "\n") {
+			if value, ok := strings.CutPrefix(line, "PRETTY_NAME="); ok {
+				return strings.Trim(value, `"`)
+			}
+		}
+	case "windows":
+		if out, err := exec.Command("cmd", "/c", "ver").Output(); err == nil {
+			return strings.TrimSpace(string(out))
+		}
+	}
+	return ""
+}
@@ -0,0 +1,185 @@
+// dictation.go
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"ropcode/internal/settings"
+	"ropcode/internal/transcribe"
+)
+
+const (
+	dictationEngineSettingKey        = "dictation_engine"
+	dictationWhisperBinarySettingKey = "dictation_whisper_binary"
+	dictationWhisperModelSettingKey  = "dictation_whisper_model"
+	dictationProviderAPISettingKey   = "dictation_provider_api_id"
+	dictationAPIModelSettingKey      = "dictation_api_model"
+	dictationLanguageSettingKey      = "dictation_language"
+)
+
+// Dictation engines
+const (
+	dictationEngineWhisperCpp = "whisper_cpp"
+	dictationEngineAPI        = "api"
+)
+
+// maxRecordingSize is the upload limit of the OpenAI transcription API,
+// about 13 minutes of the 16 kHz WAV the prompt input records
+const maxRecordingSize = 25 << 20
+
+// recordingExts are the audio formats accepted from the recorder
+var recordingExts = []string{".wav", ".webm", ".ogg", ".mp3", ".m4a"}
+
+// transcribeTimeout bounds a transcription; whisper.cpp on a CPU takes a
+// while for long recordings
+const transcribeTimeout = 3 * time.Minute
+
+// recordingsDir is where recordings wait to be transcribed,
+// ~/.ropcode/recordings
+func (a *App) recordingsDir() (string, error) {
+	if a.config != nil {
+		return filepath.Join(a.config.RopcodeDir, "recordings"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".ropcode", "recordings"), nil
+}
+
+// RecordedAudio is a saved recording
+type RecordedAudio struct {
+	Path string `json:"path"`
+	Size int    `json:"size"`
+}
+
+// SaveRecordedAudio saves a recording sent as base64 data, optionally as a
+// data URL, under filename (generated when empty)
+func (a *App) SaveRecordedAudio(base64Data, filename string) (*RecordedAudio, error) {
+	dir, err := a.recordingsDir()
+	if err != nil {
+		return nil, err
+	}
+	if filename == "" {
+		timestamp := time.Now().Format("20060102-150405")
+		filename = fmt.Sprintf("recording-%s-%s.wav", timestamp, uuid.New().String()[:8])
+	}
+	// Keep the recording inside the recordings directory whatever name the
+	// caller sent
+	filename = filepath.Base(filename)
+	if !isRecordingFile(filename) {
+		return nil, fmt.Errorf("unsupported audio format: %s", filepath.Ext(filename))
+	}
+
+	if idx := strings.Index(base64Data, ","); idx != -1 {
+		base64Data = base64Data[idx+1:]
+	}
+	data, err := base64.StdEncoding.DecodeString(base64Data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode base64 data: %w", err)
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("recording is empty")
+	}
+	if len(data) > maxRecordingSize {
+		return nil, fmt.Errorf("recordings can't be larger than %d MB", maxRecordingSize>>20)
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create recordings directory: %w", err)
+	}
+	path := filepath.Join(dir, filename)
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return nil, fmt.Errorf("failed to write recording: %w", err)
+	}
+	return &RecordedAudio{Path: path, Size: len(data)}, nil
+}
+
+// TranscribeAudio transcribes a recording saved by SaveRecordedAudio with
+// the configured engine and deletes it once it has been turned into text
+func (a *App) TranscribeAudio(path string) (*transcribe.Result, error) {
+	dir, err := a.recordingsDir()
+	if err != nil {
+		return nil, err
+	}
+	path = filepath.Clean(path)
+	if filepath.Dir(path) != filepath.Clean(dir) || !isRecordingFile(path) {
+		return nil, fmt.Errorf("not a recording: %s", path)
+	}
+	if _, err := os.Stat(path); err != nil {
+		return nil, err
+	}
+
+	transcriber, err := a.dictationTranscriber()
+	if err != nil {
+		return nil, err
+	}
+	language, _ := a.dictationSetting(dictationLanguageSettingKey).(string)
+
+	ctx, cancel := context.WithTimeout(context.Background(), transcribeTimeout)
+	defer cancel()
+	result, err := transcriber.Transcribe(ctx, path, language)
+	if err != nil {
+		return nil, err
+	}
+	os.Remove(path)
+	return result, nil
+}
+
+// dictationTranscriber builds the transcriber dictation_engine selects
+func (a *App) dictationTranscriber() (transcribe.Transcriber, error) {
+	engine, _ := a.dictationSetting(dictationEngineSettingKey).(string)
+	switch engine {
+	case dictationEngineWhisperCpp:
+		binary, _ := a.dictationSetting(dictationWhisperBinarySettingKey).(string)
+		model, _ := a.dictationSetting(dictationWhisperModelSettingKey).(string)
+		return &transcribe.Whisper{
+			Binary: strings.TrimSpace(binary),
+			Model:  strings.TrimSpace(model),
+		}, nil
+	case dictationEngineAPI:
+		id, _ := a.dictationSetting(dictationProviderAPISettingKey).(string)
+		id = strings.TrimSpace(id)
+		if id == "" {
+			return nil, fmt.Errorf("transcription API provider not configured")
+		}
+		provider := a.resolveTitleProviderConfig(id)
+		if provider == nil {
+			return nil, fmt.Errorf("provider config %q not found", id)
+		}
+		model, _ := a.dictationSetting(dictationAPIModelSettingKey).(string)
+		return &transcribe.API{
+			BaseURL: provider.BaseURL,
+			APIKey:  provider.AuthToken,
+			Model:   strings.TrimSpace(model),
+		}, nil
+	default:
+		return nil, fmt.Errorf("dictation is turned off")
+	}
+}
+
+func (a *App) dictationSetting(key string) interface{} {
+	raw := ""
+	if a.dbManager != nil {
+		raw, _ = a.dbManager.GetSetting(key)
+	}
+	return settings.Lookup(key).Decode(raw)
+}
+
+func isRecordingFile(name string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+	for _, allowed := range recordingExts {
+		if ext == allowed {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"ropcode/internal/config"
+	"ropcode/internal/database"
+)
+
+func TestDictationTranscribesRecordingsWithTheAPI(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("model") != "whisper-large" || r.FormValue("language") != "fr" {
+			http.Error(w, "unexpected form", http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{"text":"ajoute un test","language":"french"}`))
+	}))
+	defer server.Close()
+
+	db := openAppConfigTestDB(t)
+	app := &App{dbManager: db, config: &config.Config{RopcodeDir: filepath.Join(t.TempDir(), ".ropcode")}}
+	if err := db.SaveProviderApiConfig(&database.ProviderApiConfig{
+		ID: "stt", Name: "Speech", ProviderID: "codex", BaseURL: server.URL, AuthToken: "sk-test",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	audio := "data:audio/wav;base64," + base64.StdEncoding.EncodeToString([]byte("RIFF"))
+	recording, err := app.SaveRecordedAudio(audio, "")
+	if err != nil {
+		t.Fatalf("SaveRecordedAudio: %v", err)
+	}
+	if recording.Size != 4 || filepath.Ext(recording.Path) != ".wav" {
+		t.Fatalf("recording = %+v", recording)
+	}
+
+	if _, err := app.TranscribeAudio(recording.Path); err == nil {
+		t.Fatal("expected an error while dictation is off")
+	}
+
+	if _, err := app.UpdateSettings(map[string]interface{}{
+		dictationEngineSettingKey:      dictationEngineAPI,
+		dictationProviderAPISettingKey: "stt",
+		dictationAPIModelSettingKey:    "whisper-large",
+		dictationLanguageSettingKey:    "fr",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	result, err := app.TranscribeAudio(recording.Path)
+	if err != nil {
+		t.Fatalf("TranscribeAudio: %v", err)
+	}
+	if result.Text != "ajoute un test" || result.Language != "french" {
+		t.Fatalf("result = %+v", result)
+	}
+	if _, err := os.Stat(recording.Path); !os.IsNotExist(err) {
+		t.Fatalf("recording kept after transcription: %v", err)
+	}
+}
+
+func TestDictationOnlyReadsRecordings(t *testing.T) {
+	app := &App{config: &config.Config{RopcodeDir: filepath.Join(t.TempDir(), ".ropcode")}}
+
+	if _, err := app.SaveRecordedAudio(base64.StdEncoding.EncodeToString([]byte("x")), "notes.txt"); err == nil {
+		t.Fatal("expected a non-audio file name to be rejected")
+	}
+	recording, err := app.SaveRecordedAudio(base64.StdEncoding.EncodeToString([]byte("x")), "../../escape.webm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir, _ := app.recordingsDir()
+	if filepath.Dir(recording.Path) != dir {
+		t.Fatalf("recording saved outside the recordings directory: %s", recording.Path)
+	}
+
+	outside := filepath.Join(t.TempDir(), "secret.wav")
+	os.WriteFile(outside, []byte("RIFF"), 0644)
+	if _, err := app.TranscribeAudio(outside); err == nil {
+		t.Fatal("expected a file outside the recordings directory to be rejected")
+	}
+}
@@ -0,0 +1,280 @@
+// doctor.go
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"ropcode/internal/loginenv"
+)
+
+// Doctor check statuses
+const (
+	doctorOK      = "ok"
+	doctorWarning = "warning"
+	doctorError   = "error"
+)
+
+// doctorCommandTimeout bounds each "--version" probe
+const doctorCommandTimeout = 10 * time.Second
+
+// doctorMinGitVersion is the oldest git with everything worktrees use
+var doctorMinGitVersion = [2]int{2, 20}
+
+// DoctorCheck is the outcome of one environment check
+type DoctorCheck struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Status string `json:"status"` // ok, warning or error
+	Detail string `json:"detail"`
+	// Hint says how to fix a warning or an error
+	Hint string `json:"hint,omitempty"`
+}
+
+// DoctorReport is the result of Doctor
+type DoctorReport struct {
+	// OK is false when any check failed; warnings leave it true
+	OK        bool           `json:"ok"`
+	Checks    []*DoctorCheck `json:"checks"`
+	CheckedAt string         `json:"checked_at"` // RFC3339
+}
+
+// Doctor checks the environment ropcode runs in
+func (a *App) Doctor() (*DoctorReport, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve home directory: %w", err)
+	}
+
+	checks := []*DoctorCheck{
+		doctorProviderBinary("claude", "Claude Code", a.GetClaudeBinaryPath(), doctorError, "npm install -g @anthropic-ai/claude-code"),
+		doctorProviderBinary("codex", "Codex", a.binaryPathOf("codex"), doctorWarning, "npm install -g @openai/codex"),
+		doctorProviderBinary("gemini", "Gemini CLI", a.binaryPathOf("gemini"), doctorWarning, "npm install -g @google/gemini-cli"),
+		doctorGit(),
+		doctorNode(),
+		doctorClaudeSettings(filepath.Join(home, ".claude", "settings.json")),
+		a.doctorAPIKeys(home),
+		doctorRopcodeDir(filepath.Join(home, ".ropcode")),
+		a.doctorDatabase(),
+	}
+
+	report := &DoctorReport{OK: true, Checks: checks, CheckedAt: time.Now().Format(time.RFC3339)}
+	for _, check := range checks {
+		if check.Status == doctorError {
+			report.OK = false
+		}
+	}
+	return report, nil
+}
+
+// binaryPathOf returns the binary a provider's session manager found
+func (a *App) binaryPathOf(provider string) string {
+	switch provider {
+	case "codex":
+		if a.codexManager != nil {
+			return a.codexManager.GetBinaryPath()
+		}
+	case "gemini":
+		if a.geminiManager != nil {
+			return a.geminiManager.GetBinaryPath()
+		}
+	}
+	return ""
+}
+
+// doctorProviderBinary checks a provider CLI; missing reports missingStatus
+func doctorProviderBinary(id, name, path, missingStatus, install string) *DoctorCheck {
+	check := &DoctorCheck{ID: id, Name: name}
+	if path == "" {
+		if found, err := exec.LookPath(id); err == nil {
+			path = found
+		}
+	}
+	if path == "" {
+		check.Status = missingStatus
+		check.Detail = fmt.Sprintf("%s binary not found in PATH or common locations", id)
+		check.Hint = fmt.Sprintf("Install it with `%s`, or set its path in Settings", install)
+		return check
+	}
+	version, err := doctorVersion(path, "--version")
+	if err != nil {
+		check.Status = doctorError
+		check.Detail = fmt.Sprintf("%s does not run: %v", path, err)
+		check.Hint = fmt.Sprintf("Reinstall it with `%s`", install)
+		return check
+	}
+	check.Status = doctorOK
+	check.Detail = fmt.Sprintf("%s (%s)", version, path)
+	return check
+}
+
+func doctorGit() *DoctorCheck {
+	check := &DoctorCheck{ID: "git", Name: "Git"}
+	version, err := doctorVersion("git", "--version")
+	if err != nil {
+		check.Status = doctorError
+		check.Detail = "git not found"
+		check.Hint = "Install git from https://git-scm.com/downloads"
+		return check
+	}
+	check.Detail = version
+	major, minor, ok := parseDoctorVersion(version)
+	if ok && (major < doctorMinGitVersion[0] || major == doctorMinGitVersion[0] && minor < doctorMinGitVersion[1]) {
+		check.Status = doctorWarning
+		check.Hint = fmt.Sprintf("Worktree features need git %d.%d or newer", doctorMinGitVersion[0], doctorMinGitVersion[1])
+		return check
+	}
+	check.Status = doctorOK
+	return check
+}
+
+func doctorNode() *DoctorCheck {
+	check := &DoctorCheck{ID: "node", Name: "Node.js"}
+	version, err := doctorVersion("node", "--version")
+	if err != nil {
+		check.Status = doctorWarning
+		check.Detail = "node not found"
+		check.Hint = "npm-installed CLIs and most MCP servers need Node.js; install it from https://nodejs.org"
+		return check
+	}
+	check.Status = doctorOK
+	check.Detail = version
+	return check
+}
+
+// doctorClaudeSettings checks that settings.json, when present, parses
+func doctorClaudeSettings(path string) *DoctorCheck {
+	check := &DoctorCheck{ID: "claude_settings", Name: "Claude settings"}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		check.Status = doctorOK
+		check.Detail = fmt.Sprintf("%s not present; defaults apply", path)
+		return check
+	}
+	if err != nil {
+		check.Status = doctorError
+		check.Detail = fmt.Sprintf("cannot read %s: %v", path, err)
+		check.Hint = "Fix the file's permissions"
+		return check
+	}
+	var settings map[string]interface{}
+	if err := json.Unmarshal(data, &settings); err != nil {
+		check.Status = doctorError
+		check.Detail = fmt.Sprintf("%s is not valid JSON: %v", path, err)
+		check.Hint = "Correct the file or move it aside; Claude Code ignores settings it cannot parse"
+		return check
+	}
+	check.Status = doctorOK
+	check.Detail = path
+	return check
+}
+
+// doctorAPIKeys looks for a credential any provider can use
+func (a *App) doctorAPIKeys(home string) *DoctorCheck {
+	check := &DoctorCheck{ID: "api_keys", Name: "API credentials"}
+	var sources []string
+	for _, env := range []string{"ANTHROPIC_API_KEY", "ANTHROPIC_AUTH_TOKEN", "OPENAI_API_KEY", "GEMINI_API_KEY"} {
+		if os.Getenv(env) != "" {
+			sources = append(sources, env)
+		}
+	}
+	if _, token := readClaudeSettingsEnv(); token != "" {
+		sources = append(sources, "~/.claude/settings.json")
+	}
+	for _, login := range []string{".claude/.credentials.json", ".codex/auth.json"} {
+		if _, err := os.Stat(filepath.Join(home, login)); err == nil {
+			sources = append(sources, "~/"+login)
+		}
+	}
+	if a.dbManager != nil {
+		if configs, err := a.dbManager.GetAllProviderApiConfigs(); err == nil {
+			for _, cfg := range configs {
+				if cfg != nil && cfg.AuthToken != "" {
+					sources = append(sources, "provider API configurations")
+					break
+				}
+			}
+		}
+	}
+	if len(sources) == 0 {
+		// A macOS keychain login is not visible from here
+		check.Status = doctorWarning
+		check.Detail = "no API key, token or CLI login found"
+		check.Hint = "Run `claude` once to log in, set ANTHROPIC_API_KEY, or add a provider API configuration in Settings"
+		return check
+	}
+	check.Status = doctorOK
+	check.Detail = "found in " + strings.Join(sources, ", ")
+	return check
+}
+
+// doctorRopcodeDir checks that ropcode can write its data directory
+func doctorRopcodeDir(dir string) *DoctorCheck {
+	check := &DoctorCheck{ID: "ropcode_dir", Name: "Data directory"}
+	err := os.MkdirAll(dir, 0755)
+	if err == nil {
+		var probe *os.File
+		if probe, err = os.CreateTemp(dir, ".doctor-*"); err == nil {
+			probe.Close()
+			os.Remove(probe.Name())
+		}
+	}
+	if err != nil {
+		check.Status = doctorError
+		check.Detail = fmt.Sprintf("%s is not writable: %v", dir, err)
+		check.Hint = fmt.Sprintf("Make %s writable by your user; settings, logs and the database live there", dir)
+		return check
+	}
+	check.Status = doctorOK
+	check.Detail = dir
+	return check
+}
+
+func (a *App) doctorDatabase() *DoctorCheck {
+	check := &DoctorCheck{ID: "database", Name: "Database"}
+	if a.dbManager == nil {
+		check.Status = doctorError
+		check.Detail = a.unavailable(subsystemDatabase).Error() + "; settings, agents and usage features are disabled"
+		check.Hint = "Check the startup log in ~/.ropcode/logs and that ~/.ropcode is writable, then restart"
+		return check
+	}
+	check.Status = doctorOK
+	check.Detail = "open"
+	return check
+}
+
+// doctorVersion runs a version command and returns its first output line
+func doctorVersion(name string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), doctorCommandTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, name, args...)
+	// CLIs run by node need the login PATH to find it
+	cmd.Env = loginenv.Environ()
+	output, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+	line, _, _ := strings.Cut(strings.TrimSpace(string(output)), "\n")
+	return strings.TrimSpace(line), nil
+}
+
+var doctorVersionPattern = regexp.MustCompile(`(\d+)\.(\d+)`)
+
+// parseDoctorVersion finds the major and minor version in a version line
+func parseDoctorVersion(line string) (major, minor int, ok bool) {
+	match := doctorVersionPattern.FindStringSubmatch(line)
+	if match == nil {
+		return 0, 0, false
+	}
+	major, _ = strconv.Atoi(match[1])
+	minor, _ = strconv.Atoi(match[2])
+	return major, minor, true
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"ropcode/internal/database"
+)
+
+func TestDoctorReportsMissingDatabase(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	report, err := (&App{}).Doctor()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.OK {
+		t.Error("Expected the report to fail without a database")
+	}
+	var dbCheck *DoctorCheck
+	for _, check := range report.Checks {
+		if check.ID == "database" {
+			dbCheck = check
+		}
+		if check.Status != doctorOK && check.Hint == "" {
+			t.Errorf("Expected a hint for %s (%s)", check.ID, check.Status)
+		}
+	}
+	if dbCheck == nil || dbCheck.Status != doctorError {
+		t.Errorf("database check = %+v, want an error", dbCheck)
+	}
+}
+
+func TestDoctorClaudeSettings(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "settings.json")
+	if check := doctorClaudeSettings(path); check.Status != doctorOK {
+		t.Errorf("Expected a missing file to pass, got %+v", check)
+	}
+	os.WriteFile(path, []byte(`{"env": {`), 0644)
+	if check := doctorClaudeSettings(path); check.Status != doctorError || check.Hint == "" {
+		t.Errorf("Expected invalid JSON to fail with a hint, got %+v", check)
+	}
+	os.WriteFile(path, []byte(`{"env": {}}`), 0644)
+	if check := doctorClaudeSettings(path); check.Status != doctorOK {
+		t.Errorf("Expected valid JSON to pass, got %+v", check)
+	}
+}
+
+func TestDoctorAPIKeys(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	for _, env := range []string{"ANTHROPIC_API_KEY", "ANTHROPIC_AUTH_TOKEN", "OPENAI_API_KEY", "GEMINI_API_KEY"} {
+		t.Setenv(env, "")
+	}
+	app := &App{dbManager: openAppConfigTestDB(t)}
+	if check := app.doctorAPIKeys(home); check.Status != doctorWarning {
+		t.Errorf("Expected a warning without credentials, got %+v", check)
+	}
+
+	if err := app.dbManager.SaveProviderApiConfig(&database.ProviderApiConfig{ID: "team", Name: "Team", ProviderID: "claude", AuthToken: "secret"}); err != nil {
+		t.Fatal(err)
+	}
+	if check := app.doctorAPIKeys(home); check.Status != doctorOK {
+		t.Errorf("Expected a saved provider token to count, got %+v", check)
+	}
+}
+
+func TestDoctorRopcodeDir(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), ".ropcode")
+	if check := doctorRopcodeDir(dir); check.Status != doctorOK {
+		t.Errorf("Expected a creatable directory to pass, got %+v", check)
+	}
+	file := filepath.Join(t.TempDir(), "file")
+	os.WriteFile(file, nil, 0644)
+	if check := doctorRopcodeDir(filepath.Join(file, ".ropcode")); check.Status != doctorError {
+		t.Errorf("Expected a path under a file to fail, got %+v", check)
+	}
+}
+
+func TestParseDoctorVersion(t *testing.T) {
+	for line, want := range map[string][2]int{
+		"git version 2.39.3 (Apple Git-145)": {2, 39},
+		"git version 2.17.1":                 {2, 17},
+	} {
+		major, minor, ok := parseDoctorVersion(line)
+		if !ok || major != want[0] || minor != want[1] {
+			t.Errorf("parseDoctorVersion(%q) = %d.%d, %v", line, major, minor, ok)
+		}
+	}
+	if _, _, ok := parseDoctorVersion("unknown"); ok {
+		t.Error("Expected no version in a line without one")
+	}
+}
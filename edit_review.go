@@ -0,0 +1,125 @@
+// edit_review.go
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"ropcode/internal/database"
+	"ropcode/internal/filechanges"
+	"ropcode/internal/git"
+)
+
+// GetSessionEditHunks returns the hunks changing file since the session
+// first ran, taken against the session's first checkpoint, so they include
+// the user's own edits since then
+func (a *App) GetSessionEditHunks(sessionID, file string) ([]git.CheckpointHunk, error) {
+	checkpoint, path, err := a.sessionEdit(sessionID, file)
+	if err != nil {
+		return nil, err
+	}
+	return git.CheckpointFileHunks(checkpoint.ProjectPath, checkpoint.Commit, path)
+}
+
+// AcceptSessionEdit keeps the session's changes to file and marks them
+// reviewed
+func (a *App) AcceptSessionEdit(sessionID, file string) error {
+	if a.dbManager == nil {
+		return a.unavailable(subsystemDatabase)
+	}
+	path, err := a.sessionChangedPath(sessionID, file)
+	if err != nil {
+		return err
+	}
+	return a.dbManager.SaveSessionEditReview(&database.SessionEditReview{
+		SessionID: sessionID,
+		Path:      path,
+		Status:    filechanges.ReviewAccepted,
+	})
+}
+
+// RejectSessionEdit reverts the hunk at index of the changes to file, or
+// with a negative index puts the whole file back as it was before the
+// session, keeping a copy in the trash when trash_discarded_files is on.
+// The file is marked rejected once none of its changes are left.
+func (a *App) RejectSessionEdit(sessionID, file string, hunk int) error {
+	checkpoint, path, err := a.sessionEdit(sessionID, file)
+	if err != nil {
+		return err
+	}
+	if hunk < 0 {
+		if err := a.trashRevertedFile(checkpoint.ProjectPath, path); err != nil {
+			return fmt.Errorf("failed to keep %s in the trash: %w", path, err)
+		}
+		err = git.RevertCheckpointFile(checkpoint.ProjectPath, checkpoint.Commit, path)
+	} else {
+		err = git.RevertCheckpointHunk(checkpoint.ProjectPath, checkpoint.Commit, path, hunk)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to revert %s: %w", path, err)
+	}
+	if hunk >= 0 {
+		left, err := git.CheckpointFileHunks(checkpoint.ProjectPath, checkpoint.Commit, path)
+		if err != nil || len(left) > 0 {
+			return err
+		}
+	}
+	return a.dbManager.SaveSessionEditReview(&database.SessionEditReview{
+		SessionID: sessionID,
+		Path:      path,
+		Status:    filechanges.ReviewRejected,
+	})
+}
+
+// trashRevertedFile keeps a copy of a file about to be reverted as a whole
+// when discarded files go to the trash
+func (a *App) trashRevertedFile(projectPath, file string) error {
+	if !a.trashEnabled() {
+		return nil
+	}
+	if !filepath.IsAbs(file) {
+		file = filepath.Join(projectPath, file)
+	}
+	if _, err := os.Lstat(file); err != nil {
+		// Nothing to keep
+		return nil
+	}
+	_, err := a.trashPaths([]string{file}, true, "Rejected session edit")
+	return err
+}
+
+// sessionEdit returns the checkpoint a session's changes to file are
+// reviewed against and the file's path
+func (a *App) sessionEdit(sessionID, file string) (*database.SessionCheckpoint, string, error) {
+	if a.dbManager == nil {
+		return nil, "", a.unavailable(subsystemDatabase)
+	}
+	path, err := a.sessionChangedPath(sessionID, file)
+	if err != nil {
+		return nil, "", err
+	}
+	checkpoints, err := a.dbManager.ListSessionCheckpoints(sessionID)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(checkpoints) == 0 {
+		return nil, "", fmt.Errorf("session %s has no checkpoint to review its edits against; turn on session checkpoints before starting it", sessionID)
+	}
+	return checkpoints[0], path, nil
+}
+
+// sessionChangedPath checks that the session changed file
+func (a *App) sessionChangedPath(sessionID, file string) (string, error) {
+	path := filepath.Clean(file)
+	changes, err := a.dbManager.ListSessionFileChanges(sessionID)
+	if err != nil {
+		return "", err
+	}
+	for _, change := range changes {
+		if change.Path == path {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("session %s did not change %s", sessionID, file)
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"ropcode/internal/config"
+	"ropcode/internal/filechanges"
+)
+
+func TestSessionEditsCanBeAcceptedOrRejected(t *testing.T) {
+	project := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(project, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("a.txt", "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n")
+	write("b.txt", "b\n")
+	runGit(t, project, "init")
+	runGit(t, project, "add", "-A")
+	runGit(t, project, "-c", "user.name=t", "-c", "user.email=t@example.com", "commit", "-m", "initial")
+
+	db := openAppConfigTestDB(t)
+	app := &App{
+		dbManager:   db,
+		config:      &config.Config{RopcodeDir: filepath.Join(t.TempDir(), ".ropcode")},
+		fileChanges: filechanges.NewTracker(db),
+	}
+	if err := db.SaveSetting(sessionCheckpointsSettingKey, "true"); err != nil {
+		t.Fatal(err)
+	}
+	if app.createSessionCheckpoint("claude", project, "s1") == nil {
+		t.Fatal("no checkpoint taken")
+	}
+
+	// The agent edits both ends of a.txt and rewrites b.txt
+	app.observeOutputLine(`{"type":"system","subtype":"init","cwd":"` + project + `","session_id":"s1"}`)
+	for i, name := range []string{"a.txt", "b.txt"} {
+		id := []string{"t1", "t2"}[i]
+		app.observeOutputLine(`{"type":"assistant","session_id":"s1","cwd":"` + project + `","message":{"content":[{"type":"tool_use","id":"` + id + `","name":"Write","input":{"file_path":"` + name + `","content":"x"}}]}}`)
+		if name == "a.txt" {
+			write(name, "one\n2\n3\n4\n5\n6\n7\n8\n9\nten\n")
+		} else {
+			write(name, "agent\n")
+		}
+		app.observeOutputLine(`{"type":"user","session_id":"s1","cwd":"` + project + `","message":{"content":[{"type":"tool_result","tool_use_id":"` + id + `","content":"ok"}]}}`)
+	}
+	a, b := filepath.Join(project, "a.txt"), filepath.Join(project, "b.txt")
+
+	hunks, err := app.GetSessionEditHunks("s1", a)
+	if err != nil || len(hunks) != 2 {
+		t.Fatalf("GetSessionEditHunks = %+v, %v", hunks, err)
+	}
+	if err := app.RejectSessionEdit("s1", a, 0); err != nil {
+		t.Fatalf("RejectSessionEdit hunk: %v", err)
+	}
+	if data, _ := os.ReadFile(a); string(data) != "1\n2\n3\n4\n5\n6\n7\n8\n9\nten\n" {
+		t.Fatalf("a.txt = %q", data)
+	}
+	if err := app.AcceptSessionEdit("s1", a); err != nil {
+		t.Fatalf("AcceptSessionEdit: %v", err)
+	}
+	if err := app.RejectSessionEdit("s1", b, -1); err != nil {
+		t.Fatalf("RejectSessionEdit file: %v", err)
+	}
+	if data, _ := os.ReadFile(b); string(data) != "b\n" {
+		t.Fatalf("b.txt = %q", data)
+	}
+
+	files, err := app.GetSessionChangedFiles("s1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	reviews := map[string]string{}
+	for _, file := range files {
+		reviews[filepath.Base(file.Path)] = file.Review
+	}
+	if reviews["a.txt"] != filechanges.ReviewAccepted || reviews["b.txt"] != filechanges.ReviewRejected {
+		t.Fatalf("reviews = %v", reviews)
+	}
+
+	if err := app.AcceptSessionEdit("s1", filepath.Join(project, "other.txt")); err == nil {
+		t.Fatal("accepted a file the session didn't change")
+	}
+}
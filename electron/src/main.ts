@@ -1,5 +1,5 @@
 // electron/src/main.ts
-import { app, BrowserWindow, ipcMain, dialog, webContents, protocol, net, Menu, MenuItem, clipboard, shell } from 'electron';
+import { app, BrowserWindow, ipcMain, dialog, webContents, protocol, net, Menu, MenuItem, clipboard, shell, nativeImage } from 'electron';
 import path from 'path';
 import { pathToFileURL } from 'url';
 import { startGoServer, stopGoServer, GoServerInfo } from './go-server';
@@ -9,6 +9,9 @@ import { resolveDevCliBinaryPath } from './main-paths';
 import { createFileLogger, patchConsoleToFile } from './file-logger';
 
 let mainWindow: BrowserWindow | null = null;
+
+// 16x16 red dot drawn over the Windows taskbar button
+const BADGE_OVERLAY_ICON = 'data:image/png;base64,iVBORw0KGgoAAAANSUhEUgAAABAAAAAQCAYAAAAf8/9hAAAANElEQVR42mO4o6bGQAnGJvifAMZrwH8iMVYD/pOIUQz4TyYeNWB4GUBxQqJKUqZKZiIZAwDHTdNkF9WqwQAAAABJRU5ErkJggg==';
 let goServerInfo: GoServerInfo | null = null;
 const electronLogger = createFileLogger('ropcode-electron');
 const rendererLogger = createFileLogger('ropcode-renderer');
@@ -210,6 +213,19 @@ function registerIpcHandlers() {
 
   ipcMain.handle('app:quit', () => app.quit());
 
+  // Sessions awaiting review: dock badge on macOS (and Unity on Linux),
+  // a red dot over the taskbar button on Windows
+  ipcMain.handle('app:setBadgeCount', (_, count: number) => {
+    if (process.platform === 'win32') {
+      mainWindow?.setOverlayIcon(
+        count > 0 ? nativeImage.createFromDataURL(BADGE_OVERLAY_ICON) : null,
+        count > 0 ? `${count} sessions awaiting review` : '',
+      );
+      return;
+    }
+    app.setBadgeCount(count);
+  });
+
   ipcMain.handle('dialog:openDirectory', async () => {
     if (!mainWindow) return { canceled: true };
     const result = await dialog.showOpenDialog(mainWindow, {
@@ -49,6 +49,7 @@ contextBridge.exposeInMainWorld('electronAPI', {
 
   // 应用控制
   quit: () => ipcRenderer.invoke('app:quit'),
+  setBadgeCount: (count: number) => ipcRenderer.invoke('app:setBadgeCount', count),
 
   // 文件对话框
   openDirectory: () => ipcRenderer.invoke('dialog:openDirectory'),
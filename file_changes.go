@@ -0,0 +1,42 @@
+// file_changes.go
+package main
+
+import (
+	"ropcode/internal/database"
+	"ropcode/internal/filechanges"
+)
+
+// observeOutputLine passes a claude-output line to what watches the stream
+func (a *App) observeOutputLine(line string) {
+	a.watchInitLine(line)
+	if a.fileChanges != nil {
+		a.fileChanges.Observe(line)
+	}
+}
+
+// GetSessionChangedFiles returns the files a session changed, most recently
+// changed first
+func (a *App) GetSessionChangedFiles(sessionID string) ([]*filechanges.File, error) {
+	if a.dbManager == nil {
+		return []*filechanges.File{}, a.unavailable(subsystemDatabase)
+	}
+	changes, err := a.dbManager.ListSessionFileChanges(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	files := filechanges.Summarize(changes)
+	reviews, err := a.dbManager.ListSessionEditReviews(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	byPath := make(map[string]*database.SessionEditReview, len(reviews))
+	for _, review := range reviews {
+		byPath[review.Path] = review
+	}
+	for _, file := range files {
+		if review := byPath[file.Path]; review != nil && !review.ReviewedAt.Before(file.ChangedAt) {
+			file.Review = review.Status
+		}
+	}
+	return files, nil
+}
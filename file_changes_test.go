@@ -0,0 +1,41 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	"ropcode/internal/config"
+	"ropcode/internal/filechanges"
+)
+
+func TestGetSessionChangedFilesFromOutput(t *testing.T) {
+	db := openAppConfigTestDB(t)
+	app := &App{
+		dbManager:   db,
+		config:      &config.Config{RopcodeDir: filepath.Join(t.TempDir(), ".ropcode")},
+		fileChanges: filechanges.NewTracker(db),
+	}
+	project := t.TempDir()
+
+	for _, line := range []string{
+		`{"type":"system","subtype":"init","cwd":"` + project + `","session_id":"s1"}`,
+		`{"type":"assistant","cwd":"` + project + `","message":{"content":[{"type":"tool_use","id":"t1","name":"Write","input":{"file_path":"notes.md","content":"hi"}}]}}`,
+		`{"type":"user","cwd":"` + project + `","message":{"content":[{"type":"tool_result","tool_use_id":"t1","content":"ok"}]}}`,
+	} {
+		app.observeOutputLine(line)
+	}
+
+	files, err := app.GetSessionChangedFiles("s1")
+	if err != nil {
+		t.Fatalf("GetSessionChangedFiles: %v", err)
+	}
+	if len(files) != 1 || files[0].Path != filepath.Join(project, "notes.md") || files[0].Kind != filechanges.KindCreated {
+		t.Fatalf("files = %+v", files)
+	}
+	if len(files[0].Changes) != 1 || files[0].Changes[0].ToolUseID != "t1" {
+		t.Fatalf("changes = %+v", files[0].Changes)
+	}
+	if files, _ := app.GetSessionChangedFiles("s2"); len(files) != 0 {
+		t.Fatalf("files of another session = %+v", files)
+	}
+}
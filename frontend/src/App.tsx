@@ -12,7 +12,7 @@ import { ClaudeBinaryDialog } from "@/components/ClaudeBinaryDialog";
 import { Toast, ToastContainer } from "@/components/ui/toast";
 import { MainLayout } from "@/components/MainLayout";
 import { useTabState } from "@/hooks/useTabState";
-import { useAppLifecycle } from "@/hooks";
+import { useAppLifecycle, useAttention } from "@/hooks";
 import { StartupIntro } from "@/components/StartupIntro";
 import { wsClient } from "@/lib/ws-rpc-client";
 import { mergeInstancesFromUrl } from '@/lib/instanceStore';
@@ -63,6 +63,7 @@ export const waitForWebSocket = async (timeout: number = 10000): Promise<void> =
  */
 function AppContent() {
   const { createClaudeMdTab, createSettingsTab, createUsageTab, createMCPTab, createAgentsTab } = useTabState();
+  useAttention();
   const [showNFO, setShowNFO] = useState(false);
   const [showClaudeBinaryDialog, setShowClaudeBinaryDialog] = useState(false);
   const [toast, setToast] = useState<{ message: string; type: "success" | "error" | "info" } | null>(null);
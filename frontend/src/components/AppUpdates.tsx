@@ -0,0 +1,124 @@
+import React, { useState, useEffect, useCallback } from "react";
+import { RefreshCw, Download, ExternalLink } from "lucide-react";
+import { Button } from "@/components/ui/button";
+import { Label } from "@/components/ui/label";
+import { Switch } from "@/components/ui/switch";
+import { cn } from "@/lib/utils";
+import { EventsOn } from "@/lib/rpc-events";
+import {
+  GetUpdateStatus,
+  CheckForUpdates,
+  DownloadUpdate,
+  InstallUpdate,
+  GetSettings,
+  UpdateSettings,
+  type main,
+} from "@/lib/rpc-client";
+
+function formatBytes(n: number) {
+  return n >= 1 << 20 ? `${(n / (1 << 20)).toFixed(1)} MB` : `${Math.round(n / 1024)} KB`;
+}
+
+/** Current version, available release and the automatic check setting */
+export const AppUpdates: React.FC = () => {
+  const [status, setStatus] = useState<main.UpdateStatus | null>(null);
+  const [progress, setProgress] = useState<main.UpdateProgress | null>(null);
+  const [autoCheck, setAutoCheck] = useState(true);
+  const [busy, setBusy] = useState(false);
+  const [error, setError] = useState<string | null>(null);
+
+  useEffect(() => {
+    GetUpdateStatus().then(setStatus).catch(() => {});
+    GetSettings().then((values) => setAutoCheck(values.update_auto_check !== false)).catch(() => {});
+    const unlisten = [
+      EventsOn("update:available", (s: main.UpdateStatus) => setStatus(s)),
+      EventsOn("update:staged", (s: main.UpdateStatus) => { setStatus(s); setProgress(null); }),
+      EventsOn("update:progress", (p: main.UpdateProgress) => setProgress(p)),
+      EventsOn("update:error", (e: { error: string }) => { setError(e.error); setProgress(null); }),
+    ];
+    return () => unlisten.forEach((fn) => fn());
+  }, []);
+
+  const run = useCallback(async (action: () => Promise<main.UpdateStatus>) => {
+    setBusy(true);
+    setError(null);
+    try {
+      setStatus(await action());
+    } catch (err) {
+      setError(String(err));
+    } finally {
+      setBusy(false);
+      setProgress(null);
+    }
+  }, []);
+
+  const handleAutoCheck = async (checked: boolean) => {
+    setAutoCheck(checked);
+    try {
+      await UpdateSettings({ update_auto_check: checked });
+    } catch (err) {
+      setAutoCheck(!checked);
+      setError(String(err));
+    }
+  };
+
+  const state = status?.state ?? "idle";
+
+  return (
+    <div className="space-y-4">
+      <div>
+        <h3 className="text-heading-4 mb-2">Updates</h3>
+        <p className="text-body-small text-muted-foreground">
+          Ropcode {status?.current_version ?? ""}
+          {state === "up_to_date" && " is up to date"}
+          {(state === "available" || state === "staged") && ` — version ${status?.latest_version} is available`}
+          {state === "installing" && (status?.restart_required
+            ? ` — version ${status?.latest_version} is installed; restart to use it`
+            : ` — finish installing version ${status?.latest_version} in the installer`)}
+        </p>
+      </div>
+
+      <div className="flex items-center gap-2 flex-wrap">
+        <Button variant="outline" size="sm" onClick={() => run(CheckForUpdates)} disabled={busy} className="gap-1.5">
+          <RefreshCw className={cn("h-3 w-3", busy && state !== "downloading" && "animate-spin")} />
+          Check for updates
+        </Button>
+        {state === "available" && (
+          <Button size="sm" onClick={() => run(DownloadUpdate)} disabled={busy || !status?.asset} className="gap-1.5">
+            <Download className="h-3 w-3" />
+            {progress
+              ? `Downloading ${formatBytes(progress.downloaded)}${progress.total ? ` of ${formatBytes(progress.total)}` : ""}`
+              : "Download"}
+          </Button>
+        )}
+        {state === "staged" && (
+          <Button size="sm" onClick={() => run(InstallUpdate)} disabled={busy}>
+            Install {status?.latest_version}
+          </Button>
+        )}
+        {status?.release_url && (state === "available" || state === "staged") && (
+          <a
+            href={status.release_url}
+            target="_blank"
+            rel="noreferrer"
+            className="inline-flex items-center gap-1 text-xs text-muted-foreground hover:text-foreground"
+          >
+            Release notes <ExternalLink className="h-3 w-3" />
+          </a>
+        )}
+      </div>
+
+      {(error || status?.error) && <p className="text-xs text-destructive">{error ?? status?.error}</p>}
+
+      <div className="flex items-center justify-between">
+        <div>
+          <Label htmlFor="update-auto-check">Check automatically</Label>
+          <p className="text-caption text-muted-foreground mt-1">
+            Look for new releases in the background
+          </p>
+        </div>
+        <Switch id="update-auto-check" checked={autoCheck} onCheckedChange={handleAutoCheck} />
+      </div>
+    </div>
+  );
+};
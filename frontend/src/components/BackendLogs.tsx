@@ -0,0 +1,157 @@
+import React, { useState, useEffect, useCallback } from "react";
+import { RefreshCw, Copy, Check } from "lucide-react";
+import { Button } from "@/components/ui/button";
+import { cn } from "@/lib/utils";
+import { GetRecentLogs, GetLogLevel, SetLogLevel, type logging } from "@/lib/rpc-client";
+
+type Level = logging.Entry['level'];
+
+const LEVELS: Level[] = ['debug', 'info', 'warn', 'error'];
+
+const LEVEL_STYLES: Record<Level, string> = {
+  error: "text-red-400",
+  warn: "text-yellow-400",
+  info: "text-blue-400",
+  debug: "text-muted-foreground",
+};
+
+const LEVEL_BG: Record<Level, string> = {
+  error: "bg-red-500/10",
+  warn: "bg-yellow-500/10",
+  info: "",
+  debug: "",
+};
+
+const LIMIT = 500;
+
+function formatTime(time: string) {
+  const d = new Date(time);
+  return d.toLocaleTimeString("en-US", { hour12: false, hour: "2-digit", minute: "2-digit", second: "2-digit" })
+    + "." + String(d.getMilliseconds()).padStart(3, "0");
+}
+
+function formatEntry(e: logging.Entry) {
+  const attrs = Object.entries(e.attrs ?? {}).map(([k, v]) => `${k}=${v}`).join(" ");
+  return [e.component ? `[${e.component}]` : "", e.message, attrs].filter(Boolean).join(" ");
+}
+
+/** Recent records of the backend log, with the level it writes */
+export const BackendLogs: React.FC = () => {
+  const [entries, setEntries] = useState<logging.Entry[]>([]);
+  const [filter, setFilter] = useState<Level>('info');
+  const [level, setLevel] = useState<Level>('info');
+  const [loading, setLoading] = useState(false);
+  const [copied, setCopied] = useState(false);
+  const [error, setError] = useState<string | null>(null);
+
+  const refresh = useCallback(async () => {
+    setLoading(true);
+    try {
+      setEntries(await GetRecentLogs(filter, LIMIT));
+      setError(null);
+    } catch (err) {
+      setError(String(err));
+    } finally {
+      setLoading(false);
+    }
+  }, [filter]);
+
+  useEffect(() => {
+    refresh();
+  }, [refresh]);
+
+  useEffect(() => {
+    GetLogLevel().then((l) => setLevel(l as Level)).catch(() => {});
+  }, []);
+
+  const handleLevel = async (next: Level) => {
+    try {
+      await SetLogLevel(next);
+      setLevel(next);
+    } catch (err) {
+      setError(String(err));
+    }
+  };
+
+  const handleCopy = useCallback(() => {
+    const text = entries.map(e =>
+      `${e.time} [${e.level.toUpperCase()}] ${formatEntry(e)}`
+    ).join("\n");
+    navigator.clipboard.writeText(text).then(() => {
+      setCopied(true);
+      setTimeout(() => setCopied(false), 1500);
+    });
+  }, [entries]);
+
+  return (
+    <div className="space-y-4">
+      <div>
+        <h3 className="text-heading-4 mb-2">Backend Logs</h3>
+        <p className="text-body-small text-muted-foreground">
+          Recent backend log records. Full logs are kept in ~/.ropcode/logs.
+        </p>
+      </div>
+
+      <div className="flex items-center gap-2 flex-wrap">
+        <span className="text-xs text-muted-foreground">Show</span>
+        {LEVELS.map(l => (
+          <button
+            key={l}
+            onClick={() => setFilter(l)}
+            className={cn(
+              "px-2.5 py-1 text-xs rounded-md transition-colors",
+              filter === l
+                ? "bg-primary text-primary-foreground"
+                : "bg-muted/50 hover:bg-muted text-muted-foreground"
+            )}
+          >
+            {l.charAt(0).toUpperCase() + l.slice(1)}+
+          </button>
+        ))}
+
+        <div className="flex-1" />
+
+        <label className="flex items-center gap-1.5 text-xs text-muted-foreground">
+          Log level
+          <select
+            value={level}
+            onChange={(e) => handleLevel(e.target.value as Level)}
+            className="h-7 rounded-md border bg-background px-2 text-xs"
+          >
+            {LEVELS.map(l => <option key={l} value={l}>{l}</option>)}
+          </select>
+        </label>
+        <Button variant="ghost" size="sm" onClick={handleCopy} className="gap-1.5 h-7">
+          {copied ? <Check className="h-3 w-3" /> : <Copy className="h-3 w-3" />}
+          {copied ? "Copied" : "Copy"}
+        </Button>
+        <Button variant="ghost" size="sm" onClick={refresh} disabled={loading} className="gap-1.5 h-7">
+          <RefreshCw className={cn("h-3 w-3", loading && "animate-spin")} />
+          Refresh
+        </Button>
+      </div>
+
+      {error && <p className="text-xs text-destructive">{error}</p>}
+
+      <div className="h-[60vh] overflow-y-auto rounded-lg border bg-black/30 font-mono text-xs p-2 space-y-px">
+        {entries.length === 0 ? (
+          <p className="text-muted-foreground text-center py-8">No logs yet</p>
+        ) : (
+          entries.map((entry, i) => (
+            <div key={i} className={cn("flex gap-2 px-1.5 py-0.5 rounded", LEVEL_BG[entry.level])}>
+              <span className="text-muted-foreground shrink-0 select-none">
+                {formatTime(entry.time)}
+              </span>
+              <span className={cn("shrink-0 w-12 uppercase select-none", LEVEL_STYLES[entry.level])}>
+                {entry.level}
+              </span>
+              <span className="break-all whitespace-pre-wrap">
+                {formatEntry(entry)}
+              </span>
+            </div>
+          ))
+        )}
+      </div>
+    </div>
+  );
+};
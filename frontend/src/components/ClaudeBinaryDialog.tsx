@@ -45,7 +45,7 @@ export function ClaudeBinaryDialog({ open, onOpenChange, onSuccess, onError }: C
 
     setIsValidating(true);
     try {
-      await api.setClaudeBinaryPath(selectedInstallation.path);
+      await api.switchClaudeInstallation(selectedInstallation.path);
       onSuccess();
       onOpenChange(false);
     } catch (error) {
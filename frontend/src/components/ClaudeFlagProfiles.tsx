@@ -0,0 +1,153 @@
+import React, { useState, useEffect } from "react";
+import { Plus, Save, Trash2 } from "lucide-react";
+import { Button } from "@/components/ui/button";
+import { Input } from "@/components/ui/input";
+import { Label } from "@/components/ui/label";
+import { Textarea } from "@/components/ui/textarea";
+import {
+  Select,
+  SelectContent,
+  SelectItem,
+  SelectTrigger,
+  SelectValue,
+} from "@/components/ui/select";
+import { GetSettings, UpdateSettings } from "@/lib/rpc-client";
+
+// Select 不支持空字符串作为值
+const NO_PROFILE = "__none__";
+
+interface FlagProfileRow {
+  key: string;
+  name: string;
+  /** 每行一个参数 */
+  args: string;
+}
+
+/** Named lists of extra Claude CLI arguments; the selected one is added to every Claude session */
+export const ClaudeFlagProfiles: React.FC<{ onSaved?: (message: string) => void }> = ({ onSaved }) => {
+  const [rows, setRows] = useState<FlagProfileRow[]>([]);
+  const [active, setActive] = useState("");
+  const [dirty, setDirty] = useState(false);
+  const [error, setError] = useState<string | null>(null);
+
+  useEffect(() => {
+    GetSettings().then((values) => {
+      const profiles = (values.claude_flag_profiles || {}) as Record<string, string[]>;
+      setRows(Object.entries(profiles).map(([name, args]) => ({ key: name, name, args: (args || []).join("\n") })));
+      setActive(typeof values.claude_flag_profile === "string" ? values.claude_flag_profile : "");
+    }).catch(() => {});
+  }, []);
+
+  const update = (key: string, changes: Partial<FlagProfileRow>) => {
+    setRows(prev => prev.map(r => (r.key === key ? { ...r, ...changes } : r)));
+    setDirty(true);
+  };
+
+  const handleAdd = () => {
+    setRows(prev => [...prev, { key: `new-${Date.now()}`, name: "", args: "" }]);
+    setDirty(true);
+  };
+
+  const handleRemove = (row: FlagProfileRow) => {
+    setRows(prev => prev.filter(r => r.key !== row.key));
+    if (row.name === active) setActive("");
+    setDirty(true);
+  };
+
+  const handleSave = async () => {
+    const profiles: Record<string, string[]> = {};
+    for (const row of rows) {
+      const name = row.name.trim();
+      if (!name) {
+        setError("Every flag profile needs a name");
+        return;
+      }
+      profiles[name] = row.args.split("\n").map(a => a.trim()).filter(Boolean);
+    }
+    const activeName = active && profiles[active] ? active : "";
+    try {
+      setError(null);
+      await UpdateSettings({ claude_flag_profiles: profiles, claude_flag_profile: activeName });
+      setActive(activeName);
+      setDirty(false);
+      onSaved?.("Claude flag profiles saved");
+    } catch (err) {
+      setError(String(err));
+    }
+  };
+
+  const names = rows.map(r => r.name.trim()).filter(Boolean);
+
+  return (
+    <div className="space-y-4">
+      <div>
+        <h3 className="text-heading-4 mb-2">Claude Flag Profiles</h3>
+        <p className="text-body-small text-muted-foreground">
+          Extra arguments added to every Claude session, one per line, for CLI options ropcode has no setting for,
+          such as <code>--add-dir</code> or <code>--fallback-model</code>. Flags ropcode sets itself, like
+          <code className="ml-1">--output-format</code> or <code>--resume</code>, are not allowed.
+        </p>
+      </div>
+
+      <div className="flex items-center justify-between">
+        <Label>Active profile</Label>
+        <Select
+          value={active || NO_PROFILE}
+          onValueChange={(value) => {
+            setActive(value === NO_PROFILE ? "" : value);
+            setDirty(true);
+          }}
+        >
+          <SelectTrigger className="w-56">
+            <SelectValue />
+          </SelectTrigger>
+          <SelectContent>
+            <SelectItem value={NO_PROFILE}>None</SelectItem>
+            {names.map(name => (
+              <SelectItem key={name} value={name}>{name}</SelectItem>
+            ))}
+          </SelectContent>
+        </Select>
+      </div>
+
+      {rows.map(row => (
+        <div key={row.key} className="space-y-2 rounded-md border p-3">
+          <div className="flex items-center gap-2">
+            <Input
+              value={row.name}
+              onChange={e => {
+                if (row.name === active) setActive(e.target.value);
+                update(row.key, { name: e.target.value });
+              }}
+              placeholder="Profile name"
+              className="h-8 text-sm"
+            />
+            <Button variant="ghost" size="icon" className="h-8 w-8 text-destructive" onClick={() => handleRemove(row)}>
+              <Trash2 className="h-3.5 w-3.5" />
+            </Button>
+          </div>
+          <Textarea
+            value={row.args}
+            onChange={e => update(row.key, { args: e.target.value })}
+            placeholder={"--add-dir\n/srv/shared"}
+            rows={3}
+            className="font-mono text-xs"
+          />
+        </div>
+      ))}
+
+      {error && <p className="text-xs text-destructive">{error}</p>}
+
+      <div className="flex items-center gap-2">
+        <Button variant="outline" size="sm" onClick={handleAdd}>
+          <Plus className="h-3 w-3 mr-1.5" />
+          Add Profile
+        </Button>
+        <Button size="sm" onClick={handleSave} disabled={!dirty}>
+          <Save className="h-3 w-3 mr-1.5" />
+          Save
+        </Button>
+      </div>
+    </div>
+  );
+};
@@ -1,11 +1,14 @@
 import React, { useState, useEffect } from "react";
 import { motion, AnimatePresence } from "framer-motion";
-import { ChevronDown, Edit2, FileText, Loader2 } from "lucide-react";
+import { ChevronDown, Edit2, Eye, FileText, Loader2, Sparkles } from "lucide-react";
 import { Button } from "@/components/ui/button";
 import { Card } from "@/components/ui/card";
+import { Textarea } from "@/components/ui/textarea";
+import { Dialog, DialogContent, DialogDescription, DialogHeader, DialogTitle } from "@/components/ui/dialog";
 import { cn } from "@/lib/utils";
 import { api, type ClaudeMdFile } from "@/lib/api";
 import { formatUnixTimestamp } from "@/lib/date-utils";
+import { GenerateProjectMemory, GetEffectiveMemory, type claude, type main } from "@/lib/rpc-client";
 
 interface ClaudeMemoriesDropdownProps {
   /**
@@ -40,6 +43,14 @@ export const ClaudeMemoriesDropdown: React.FC<ClaudeMemoriesDropdownProps> = ({
   const [files, setFiles] = useState<ClaudeMdFile[]>([]);
   const [loading, setLoading] = useState(false);
   const [error, setError] = useState<string | null>(null);
+  const [draft, setDraft] = useState<main.ProjectMemoryDraft | null>(null);
+  const [draftContent, setDraftContent] = useState("");
+  const [generating, setGenerating] = useState(false);
+  const [savingDraft, setSavingDraft] = useState(false);
+  const [draftError, setDraftError] = useState<string | null>(null);
+  const [effectiveMemory, setEffectiveMemory] = useState<claude.EffectiveMemory | null>(null);
+  const [loadingEffective, setLoadingEffective] = useState(false);
+  const [effectiveError, setEffectiveError] = useState<string | null>(null);
   
   // Load CLAUDE.md files when dropdown opens
   useEffect(() => {
@@ -62,6 +73,63 @@ export const ClaudeMemoriesDropdown: React.FC<ClaudeMemoriesDropdownProps> = ({
     }
   };
   
+  // Draft a CLAUDE.md from a survey of the project; nothing is written until saved
+  const generateDraft = async () => {
+    try {
+      setGenerating(true);
+      setDraftError(null);
+      const generated = await GenerateProjectMemory(projectPath, "claude");
+      setDraft(generated);
+      setDraftContent(generated.content);
+    } catch (err) {
+      console.error("Failed to generate CLAUDE.md:", err);
+      setDraftError(err instanceof Error ? err.message : String(err));
+    } finally {
+      setGenerating(false);
+    }
+  };
+
+  const saveDraft = async () => {
+    if (!draft) return;
+    if (draft.exists && !confirm(`Replace the existing ${draft.path}?`)) return;
+    try {
+      setSavingDraft(true);
+      setDraftError(null);
+      await api.saveClaudeMdFile(draft.path, draftContent);
+      setDraft(null);
+      await loadClaudeMdFiles();
+    } catch (err) {
+      console.error("Failed to save CLAUDE.md:", err);
+      setDraftError("Failed to save CLAUDE.md");
+    } finally {
+      setSavingDraft(false);
+    }
+  };
+
+  // Resolve every memory file Claude would read here, in the order it reads them
+  const previewEffectiveMemory = async () => {
+    try {
+      setLoadingEffective(true);
+      setEffectiveError(null);
+      setEffectiveMemory(await GetEffectiveMemory(projectPath));
+    } catch (err) {
+      console.error("Failed to resolve effective memory:", err);
+      setEffectiveError(err instanceof Error ? err.message : String(err));
+    } finally {
+      setLoadingEffective(false);
+    }
+  };
+
+  const scopeLabels: Record<string, string> = {
+    managed: "Managed policy",
+    user: "User",
+    project: "Project",
+    local: "Local",
+    nested: "Subdirectory",
+  };
+
+  const hasRootFile = files.some(file => file.relative_path === "CLAUDE.md");
+
   const formatFileSize = (bytes: number): string => {
     if (bytes < 1024) return `${bytes} B`;
     if (bytes < 1024 * 1024) return `${(bytes / 1024).toFixed(1)} KB`;
@@ -148,11 +216,99 @@ export const ClaudeMemoriesDropdown: React.FC<ClaudeMemoriesDropdownProps> = ({
                     ))}
                   </div>
                 )}
+                {!loading && !error && (!hasRootFile || draft) && (
+                  <div className="border-t border-border p-3 space-y-2">
+                    {draft ? (
+                      <>
+                        <p className="text-xs text-muted-foreground">
+                          Review the draft before saving it to {draft.path}
+                        </p>
+                        <Textarea
+                          value={draftContent}
+                          onChange={(e) => setDraftContent(e.target.value)}
+                          className="h-64 font-mono text-xs"
+                        />
+                        <div className="flex justify-end gap-2">
+                          <Button variant="ghost" size="sm" onClick={() => setDraft(null)} disabled={savingDraft}>
+                            Discard
+                          </Button>
+                          <Button size="sm" onClick={saveDraft} disabled={savingDraft || !draftContent.trim()}>
+                            {savingDraft && <Loader2 className="mr-2 h-3 w-3 animate-spin" />}
+                            Save CLAUDE.md
+                          </Button>
+                        </div>
+                      </>
+                    ) : (
+                      <Button
+                        variant="outline"
+                        size="sm"
+                        onClick={generateDraft}
+                        disabled={generating}
+                        className="w-full text-xs"
+                      >
+                        {generating ? <Loader2 className="mr-2 h-3 w-3 animate-spin" /> : <Sparkles className="mr-2 h-3 w-3" />}
+                        {generating ? "Drafting CLAUDE.md…" : "Generate CLAUDE.md"}
+                      </Button>
+                    )}
+                    {draftError && <p className="text-xs text-destructive">{draftError}</p>}
+                  </div>
+                )}
+                {!loading && !error && (
+                  <div className="border-t border-border p-3 space-y-2">
+                    <Button
+                      variant="ghost"
+                      size="sm"
+                      onClick={previewEffectiveMemory}
+                      disabled={loadingEffective}
+                      className="w-full text-xs"
+                    >
+                      {loadingEffective ? <Loader2 className="mr-2 h-3 w-3 animate-spin" /> : <Eye className="mr-2 h-3 w-3" />}
+                      Preview effective memory
+                    </Button>
+                    {effectiveError && <p className="text-xs text-destructive">{effectiveError}</p>}
+                  </div>
+                )}
               </div>
             </motion.div>
           )}
         </AnimatePresence>
       </Card>
+
+      <Dialog open={effectiveMemory !== null} onOpenChange={(open) => !open && setEffectiveMemory(null)}>
+        <DialogContent className="max-w-3xl max-h-[85vh] flex flex-col">
+          <DialogHeader>
+            <DialogTitle>Effective memory</DialogTitle>
+            <DialogDescription>
+              The memory files Claude reads in this project, in the order it reads them. Later sections take precedence.
+            </DialogDescription>
+          </DialogHeader>
+          <div className="flex-1 overflow-y-auto space-y-3">
+            {effectiveMemory?.sections.length === 0 ? (
+              <p className="text-xs text-muted-foreground text-center py-4">
+                Claude reads no memory files in this project
+              </p>
+            ) : (
+              effectiveMemory?.sections.map((section) => (
+                <div key={section.path} className="rounded-md border border-border">
+                  <div className="flex items-center gap-2 border-b border-border px-3 py-2">
+                    <span className="rounded bg-muted px-1.5 py-0.5 text-[10px] font-medium uppercase">
+                      {scopeLabels[section.scope] ?? section.scope}
+                    </span>
+                    <span className="text-xs font-mono truncate" title={section.path}>{section.path}</span>
+                  </div>
+                  {(section.imported_from || section.on_demand) && (
+                    <div className="px-3 pt-2 text-xs text-muted-foreground space-y-0.5">
+                      {section.imported_from && <p>Imported by <span className="font-mono">{section.imported_from}</span></p>}
+                      {section.on_demand && <p>Read only when Claude works in this directory</p>}
+                    </div>
+                  )}
+                  <pre className="max-h-64 overflow-auto whitespace-pre-wrap p-3 text-xs font-mono">{section.content}</pre>
+                </div>
+              ))
+            )}
+          </div>
+        </DialogContent>
+      </Dialog>
     </div>
   );
 }; 
\ No newline at end of file
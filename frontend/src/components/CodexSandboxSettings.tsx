@@ -0,0 +1,123 @@
+import React, { useState, useEffect } from "react";
+import { Save } from "lucide-react";
+import { Button } from "@/components/ui/button";
+import { Label } from "@/components/ui/label";
+import { Switch } from "@/components/ui/switch";
+import { Textarea } from "@/components/ui/textarea";
+import {
+  Select,
+  SelectContent,
+  SelectItem,
+  SelectTrigger,
+  SelectValue,
+} from "@/components/ui/select";
+import { GetSettings, UpdateSettings } from "@/lib/rpc-client";
+import { CODEX_SANDBOXES } from "./PermissionProfilesEditor";
+
+/** Sandbox Codex sessions run in unless their permission profile sets one */
+export const CodexSandboxSettings: React.FC = () => {
+  const [mode, setMode] = useState("danger-full-access");
+  const [network, setNetwork] = useState(true);
+  const [overrides, setOverrides] = useState("");
+  const [savedOverrides, setSavedOverrides] = useState("");
+  const [message, setMessage] = useState<string | null>(null);
+
+  useEffect(() => {
+    GetSettings().then((values) => {
+      if (typeof values.codex_sandbox_mode === "string") setMode(values.codex_sandbox_mode);
+      setNetwork(values.codex_network_access !== false);
+      const text = typeof values.codex_config_overrides === "string" ? values.codex_config_overrides : "";
+      setOverrides(text);
+      setSavedOverrides(text);
+    }).catch(() => {});
+  }, []);
+
+  const update = async (values: Record<string, unknown>, revert: () => void) => {
+    setMessage(null);
+    try {
+      await UpdateSettings(values);
+      return true;
+    } catch (err) {
+      revert();
+      setMessage(String(err));
+      return false;
+    }
+  };
+
+  const handleMode = (value: string) => {
+    const previous = mode;
+    setMode(value);
+    update({ codex_sandbox_mode: value }, () => setMode(previous));
+  };
+
+  const handleNetwork = (checked: boolean) => {
+    setNetwork(checked);
+    update({ codex_network_access: checked }, () => setNetwork(!checked));
+  };
+
+  const handleSaveOverrides = async () => {
+    if (await update({ codex_config_overrides: overrides }, () => {})) {
+      setSavedOverrides(overrides);
+      setMessage("Overrides saved");
+    }
+  };
+
+  return (
+    <div className="space-y-4">
+      <div>
+        <h3 className="text-heading-4 mb-2">Codex Sandbox</h3>
+        <p className="text-body-small text-muted-foreground">
+          Where Codex sessions may write and whether their commands reach the network. A project's permission
+          profile with a sandbox of its own takes precedence.
+        </p>
+      </div>
+
+      <div className="flex items-center justify-between">
+        <Label>Sandbox mode</Label>
+        <Select value={mode} onValueChange={handleMode}>
+          <SelectTrigger className="w-56">
+            <SelectValue />
+          </SelectTrigger>
+          <SelectContent>
+            {CODEX_SANDBOXES.map(s => (
+              <SelectItem key={s.value} value={s.value}>{s.label}</SelectItem>
+            ))}
+          </SelectContent>
+        </Select>
+      </div>
+
+      <div className="flex items-center justify-between">
+        <div>
+          <Label htmlFor="codex-network-access">Network access</Label>
+          <p className="text-caption text-muted-foreground mt-1">
+            Lets commands such as npm install or curl reach the network in the workspace-write sandbox
+          </p>
+        </div>
+        <Switch id="codex-network-access" checked={network} onCheckedChange={handleNetwork} />
+      </div>
+
+      <div className="space-y-2">
+        <Label htmlFor="codex-config-overrides">Extra config overrides</Label>
+        <Textarea
+          id="codex-config-overrides"
+          value={overrides}
+          onChange={e => setOverrides(e.target.value)}
+          placeholder={'model_verbosity="low"\nhide_agent_reasoning=true'}
+          rows={4}
+          className="font-mono text-xs"
+        />
+        <div className="flex items-center justify-between">
+          <p className="text-caption text-muted-foreground">
+            Passed to every Codex session as <code>-c key=value</code>, one per line; values are TOML.
+          </p>
+          <Button size="sm" variant="outline" onClick={handleSaveOverrides} disabled={overrides === savedOverrides}>
+            <Save className="h-3 w-3 mr-1.5" />
+            Save
+          </Button>
+        </div>
+      </div>
+
+      {message && <p className="text-xs text-muted-foreground">{message}</p>}
+    </div>
+  );
+};
@@ -56,6 +56,7 @@ export const CreateAgent: React.FC<CreateAgentProps> = ({
   const [systemPrompt, setSystemPrompt] = useState(agent?.system_prompt || "");
   const [defaultTask, setDefaultTask] = useState(agent?.default_task || "");
   const [model, setModel] = useState(agent?.model || "sonnet");
+  const provider = agent?.provider || "claude";
   const [providerApiId, setProviderApiId] = useState<string | null>(agent?.provider_api_id || null);
   const [providerConfigs, setProviderConfigs] = useState<ProviderApiConfig[]>([]);
   const [loadingConfigs, setLoadingConfigs] = useState(true);
@@ -75,8 +76,8 @@ export const CreateAgent: React.FC<CreateAgentProps> = ({
     try {
       setLoadingConfigs(true);
       const allConfigs = await api.listProviderApiConfigs();
-      // Filter for Claude provider
-      const claudeConfigs = allConfigs.filter(c => c.provider_id === 'claude');
+      // Filter for the agent's provider
+      const claudeConfigs = allConfigs.filter(c => c.provider_id === provider);
       setProviderConfigs(claudeConfigs);
     } catch (error) {
       console.error("Failed to load provider API configs:", error);
@@ -108,8 +109,9 @@ export const CreateAgent: React.FC<CreateAgentProps> = ({
           systemPrompt,
           defaultTask || undefined,
           model,
+          providerApiId || undefined,
           undefined, // hooks
-          providerApiId || undefined
+          provider
         );
       } else {
         await api.createAgent(
@@ -118,8 +120,9 @@ export const CreateAgent: React.FC<CreateAgentProps> = ({
           systemPrompt,
           defaultTask || undefined,
           model,
+          providerApiId || undefined,
           undefined, // hooks
-          providerApiId || undefined
+          provider
         );
       }
       
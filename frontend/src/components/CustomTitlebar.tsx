@@ -1,8 +1,8 @@
-import React, { useState, useEffect } from 'react';
+import React, { useState, useEffect, useCallback } from 'react';
 import { Minus, Square, X, ChevronRight, GitBranch, Upload, Folder, Trash2 } from 'lucide-react';
 import { WindowMinimise, WindowToggleMaximise, Quit } from '@/lib/rpc-window';
 import { motion } from 'framer-motion';
-import { useFullscreen, usePageVisibilityPolling } from '@/hooks';
+import { useFullscreen, usePageVisibilityPolling, useGitStatus, type GitStatusEvent } from '@/hooks';
 import { useIsMobile } from '@/hooks/useIsMobile';
 import { api } from '@/lib/api';
 import { Button } from '@/components/ui/button';
@@ -272,7 +272,20 @@ export const CustomTitlebar: React.FC<CustomTitlebarProps> = ({
     checkUnpushedToRemote();
   }, [currentProjectPath, hasGitSupport]);
 
-  // 页面可见性轮询 - 定期检查未推送的提交数量
+  // 后端监听 .git 和项目文件，提交、push 后立即推送未推送提交数
+  const handleGitStatus = useCallback((event: GitStatusEvent) => {
+    setUnpushedToRemoteCount(event.unpushed_to_remote_count);
+    if (isWorktreeChild) {
+      setUnpushedCount(event.unpushed_count);
+    }
+  }, [isWorktreeChild]);
+
+  const gitStatusLive = useGitStatus(
+    currentProjectPath && hasGitSupport ? currentProjectPath : undefined,
+    handleGitStatus
+  );
+
+  // 页面可见性轮询 - 无法实时监听时（例如远程项目）定期检查未推送的提交数量
   // 只在页面激活时轮询，用于捕获外部 git 操作导致的变化
   usePageVisibilityPolling(
     async () => {
@@ -295,7 +308,7 @@ export const CustomTitlebar: React.FC<CustomTitlebarProps> = ({
     },
     {
       interval: 3000, // 每 3 秒轮询一次
-      enabled: !!currentProjectPath && hasGitSupport,
+      enabled: !!currentProjectPath && hasGitSupport && !gitStatusLive,
       immediate: true,
     }
   );
@@ -403,7 +416,17 @@ export const CustomTitlebar: React.FC<CustomTitlebarProps> = ({
     setIsCleaning(true);
     setShowCleanupDialog(false);
     try {
-      const result = await api.cleanupWorkspace(currentProjectPath);
+      let result: string;
+      try {
+        result = await api.cleanupWorkspace(currentProjectPath, false);
+      } catch (error) {
+        // 工作空间保护开启时需要再次确认
+        if (!String(error).includes('protection_enabled')) throw error;
+        if (!window.confirm('Workspace protection is on. Discard all uncommitted changes and unpushed commits in this workspace anyway?')) {
+          return;
+        }
+        result = await api.cleanupWorkspace(currentProjectPath, true);
+      }
       console.log('Workspace cleanup successful:', result);
 
       // 清理成功后重新检查状态
@@ -0,0 +1,169 @@
+import React, { useState, useEffect, useRef } from "react";
+import { Loader2, Mic, Square } from "lucide-react";
+import { Button } from "@/components/ui/button";
+import { TooltipSimple } from "@/components/ui/tooltip-modern";
+import { GetSettings, SaveRecordedAudio, TranscribeAudio } from "@/lib/rpc-client";
+import { cn } from "@/lib/utils";
+
+interface DictationButtonProps {
+  /** 收到转写文本时调用 */
+  onTranscript: (text: string) => void;
+  disabled?: boolean;
+}
+
+type DictationState = "idle" | "recording" | "transcribing";
+
+// whisper.cpp 只读取 16 kHz 的 WAV，API 也接受这种格式
+const SAMPLE_RATE = 16000;
+
+/** 将录音转换为 16 kHz 单声道 16 位 WAV */
+async function toWav(recording: Blob): Promise<Blob> {
+  const context = new AudioContext();
+  try {
+    const decoded = await context.decodeAudioData(await recording.arrayBuffer());
+    // 单声道的离线上下文会自动混音并重采样
+    const offline = new OfflineAudioContext(1, Math.ceil(decoded.duration * SAMPLE_RATE), SAMPLE_RATE);
+    const source = offline.createBufferSource();
+    source.buffer = decoded;
+    source.connect(offline.destination);
+    source.start();
+    const samples = (await offline.startRendering()).getChannelData(0);
+
+    const view = new DataView(new ArrayBuffer(44 + samples.length * 2));
+    const writeString = (offset: number, text: string) => {
+      for (let i = 0; i < text.length; i++) view.setUint8(offset + i, text.charCodeAt(i));
+    };
+    writeString(0, "RIFF");
+    view.setUint32(4, 36 + samples.length * 2, true);
+    writeString(8, "WAVE");
+    writeString(12, "fmt ");
+    view.setUint32(16, 16, true);
+    view.setUint16(20, 1, true); // PCM
+    view.setUint16(22, 1, true); // 单声道
+    view.setUint32(24, SAMPLE_RATE, true);
+    view.setUint32(28, SAMPLE_RATE * 2, true);
+    view.setUint16(32, 2, true);
+    view.setUint16(34, 16, true);
+    writeString(36, "data");
+    view.setUint32(40, samples.length * 2, true);
+    samples.forEach((sample, i) => {
+      const clamped = Math.max(-1, Math.min(1, sample));
+      view.setInt16(44 + i * 2, clamped < 0 ? clamped * 0x8000 : clamped * 0x7fff, true);
+    });
+    return new Blob([view.buffer], { type: "audio/wav" });
+  } finally {
+    context.close();
+  }
+}
+
+const toDataURL = (blob: Blob) =>
+  new Promise<string>((resolve, reject) => {
+    const reader = new FileReader();
+    reader.onload = () => resolve(reader.result as string);
+    reader.onerror = () => reject(reader.error);
+    reader.readAsDataURL(blob);
+  });
+
+/**
+ * 语音输入按钮：录音并转写为提示词，未在设置中启用语音输入时不显示
+ */
+export const DictationButton: React.FC<DictationButtonProps> = ({ onTranscript, disabled = false }) => {
+  const [enabled, setEnabled] = useState(false);
+  const [state, setState] = useState<DictationState>("idle");
+  const [error, setError] = useState<string | null>(null);
+  const recorderRef = useRef<MediaRecorder | null>(null);
+  // 转写完成时输入框内容可能已变化，始终调用最新的回调
+  const onTranscriptRef = useRef(onTranscript);
+  onTranscriptRef.current = onTranscript;
+
+  useEffect(() => {
+    GetSettings()
+      .then((values) => setEnabled(!!values.dictation_engine && values.dictation_engine !== "off"))
+      .catch(() => {});
+    return () => {
+      const recorder = recorderRef.current;
+      if (recorder && recorder.state !== "inactive") {
+        recorder.onstop = null;
+        recorder.stop();
+        recorder.stream.getTracks().forEach(track => track.stop());
+      }
+    };
+  }, []);
+
+  const transcribe = async (recording: Blob) => {
+    setState("transcribing");
+    try {
+      const wav = await toWav(recording);
+      const saved = await SaveRecordedAudio(await toDataURL(wav), "");
+      const result = await TranscribeAudio(saved.path);
+      onTranscriptRef.current(result.text);
+    } catch (err) {
+      console.error("Failed to transcribe recording:", err);
+      setError(String(err));
+    } finally {
+      setState("idle");
+    }
+  };
+
+  const startRecording = async () => {
+    setError(null);
+    try {
+      const stream = await navigator.mediaDevices.getUserMedia({ audio: true });
+      const recorder = new MediaRecorder(stream);
+      const chunks: Blob[] = [];
+      recorder.ondataavailable = (e) => {
+        if (e.data.size > 0) chunks.push(e.data);
+      };
+      recorder.onstop = () => {
+        stream.getTracks().forEach(track => track.stop());
+        recorderRef.current = null;
+        transcribe(new Blob(chunks, { type: recorder.mimeType }));
+      };
+      recorder.start();
+      recorderRef.current = recorder;
+      setState("recording");
+    } catch (err) {
+      console.error("Failed to start recording:", err);
+      setError(`Microphone unavailable: ${err}`);
+    }
+  };
+
+  const handleClick = () => {
+    if (state === "recording") {
+      recorderRef.current?.stop();
+    } else if (state === "idle") {
+      startRecording();
+    }
+  };
+
+  if (!enabled) return null;
+
+  const tooltip =
+    state === "recording" ? "Stop and transcribe" :
+    state === "transcribing" ? "Transcribing..." :
+    error || "Dictate prompt";
+
+  return (
+    <TooltipSimple content={tooltip} side="top">
+      <Button
+        variant="ghost"
+        size="icon"
+        onClick={handleClick}
+        disabled={disabled || state === "transcribing"}
+        className={cn(
+          "h-8 w-8 hover:bg-accent/50 transition-colors active:scale-[0.97]",
+          state === "recording" && "text-destructive",
+          error && state === "idle" && "text-destructive/70"
+        )}
+      >
+        {state === "recording" ? (
+          <Square className="h-3.5 w-3.5 fill-current" />
+        ) : state === "transcribing" ? (
+          <Loader2 className="h-3.5 w-3.5 animate-spin" />
+        ) : (
+          <Mic className="h-3.5 w-3.5" />
+        )}
+      </Button>
+    </TooltipSimple>
+  );
+};
@@ -0,0 +1,160 @@
+import React, { useState, useEffect } from "react";
+import { Input } from "@/components/ui/input";
+import { Label } from "@/components/ui/label";
+import { Select, SelectContent, SelectItem, SelectTrigger, SelectValue } from "@/components/ui/select";
+import {
+  GetSettings,
+  UpdateSettings,
+  GetSessionTitleProviderOptions,
+  type TitleProviderOption,
+} from "@/lib/rpc-client";
+
+// Select 不支持空字符串作为值
+const NO_PROVIDER = "__none__";
+
+/** Voice prompts: which engine transcribes recordings, and in which language */
+export const DictationSettings: React.FC = () => {
+  const [engine, setEngine] = useState("off");
+  const [binary, setBinary] = useState("");
+  const [model, setModel] = useState("");
+  const [providerId, setProviderId] = useState("");
+  const [apiModel, setApiModel] = useState("");
+  const [language, setLanguage] = useState("");
+  const [providers, setProviders] = useState<TitleProviderOption[]>([]);
+  const [message, setMessage] = useState<string | null>(null);
+
+  useEffect(() => {
+    GetSettings().then((values) => {
+      setEngine(String(values.dictation_engine ?? "off"));
+      setBinary(String(values.dictation_whisper_binary ?? ""));
+      setModel(String(values.dictation_whisper_model ?? ""));
+      setProviderId(String(values.dictation_provider_api_id ?? ""));
+      setApiModel(String(values.dictation_api_model ?? ""));
+      setLanguage(String(values.dictation_language ?? ""));
+    }).catch(() => {});
+    // Anthropic 接口没有语音转写，只列出 OpenAI 兼容的配置
+    GetSessionTitleProviderOptions()
+      .then((options) => setProviders(options.filter(o => o.provider_id !== "claude")))
+      .catch(() => {});
+  }, []);
+
+  const update = async (key: string, value: string) => {
+    setMessage(null);
+    try {
+      await UpdateSettings({ [key]: value.trim() });
+    } catch (err) {
+      setMessage(String(err));
+    }
+  };
+
+  return (
+    <div className="space-y-4">
+      <div>
+        <h3 className="text-heading-4 mb-2">Dictation</h3>
+        <p className="text-body-small text-muted-foreground">
+          Adds a microphone button to the prompt input. Recordings are turned into prompt text by a local
+          whisper.cpp binary, which keeps the audio on this machine, or by an OpenAI-compatible transcription API.
+          Reopen the session after turning dictation on to see the button.
+        </p>
+      </div>
+
+      <div className="grid grid-cols-2 gap-4">
+        <div className="space-y-1">
+          <Label>Transcribe with</Label>
+          <Select
+            value={engine}
+            onValueChange={(value) => {
+              setEngine(value);
+              update("dictation_engine", value);
+            }}
+          >
+            <SelectTrigger>
+              <SelectValue />
+            </SelectTrigger>
+            <SelectContent>
+              <SelectItem value="off">Off</SelectItem>
+              <SelectItem value="whisper_cpp">whisper.cpp (local)</SelectItem>
+              <SelectItem value="api">Transcription API</SelectItem>
+            </SelectContent>
+          </Select>
+        </div>
+        <div className="space-y-1">
+          <Label htmlFor="dictation-language">Language (code or auto)</Label>
+          <Input
+            id="dictation-language"
+            value={language}
+            placeholder="auto"
+            disabled={engine === "off"}
+            onChange={(e) => setLanguage(e.target.value)}
+            onBlur={() => update("dictation_language", language)}
+          />
+        </div>
+
+        {engine === "whisper_cpp" && (
+          <>
+            <div className="space-y-1">
+              <Label htmlFor="dictation-whisper-binary">whisper.cpp binary</Label>
+              <Input
+                id="dictation-whisper-binary"
+                value={binary}
+                placeholder="whisper-cli"
+                className="font-mono text-sm"
+                onChange={(e) => setBinary(e.target.value)}
+                onBlur={() => update("dictation_whisper_binary", binary)}
+              />
+            </div>
+            <div className="space-y-1">
+              <Label htmlFor="dictation-whisper-model">Model file</Label>
+              <Input
+                id="dictation-whisper-model"
+                value={model}
+                placeholder="/path/to/ggml-base.bin"
+                className="font-mono text-sm"
+                onChange={(e) => setModel(e.target.value)}
+                onBlur={() => update("dictation_whisper_model", model)}
+              />
+            </div>
+          </>
+        )}
+
+        {engine === "api" && (
+          <>
+            <div className="space-y-1">
+              <Label>Provider</Label>
+              <Select
+                value={providerId || NO_PROVIDER}
+                onValueChange={(value) => {
+                  const id = value === NO_PROVIDER ? "" : value;
+                  setProviderId(id);
+                  update("dictation_provider_api_id", id);
+                }}
+              >
+                <SelectTrigger>
+                  <SelectValue />
+                </SelectTrigger>
+                <SelectContent>
+                  <SelectItem value={NO_PROVIDER}>None</SelectItem>
+                  {providers.map(p => (
+                    <SelectItem key={p.id} value={p.id}>{p.name}</SelectItem>
+                  ))}
+                </SelectContent>
+              </Select>
+            </div>
+            <div className="space-y-1">
+              <Label htmlFor="dictation-api-model">Model</Label>
+              <Input
+                id="dictation-api-model"
+                value={apiModel}
+                placeholder="whisper-1"
+                onChange={(e) => setApiModel(e.target.value)}
+                onBlur={() => update("dictation_api_model", apiModel)}
+              />
+            </div>
+          </>
+        )}
+      </div>
+
+      {message && <p className="text-xs text-muted-foreground">{message}</p>}
+    </div>
+  );
+};
@@ -4,7 +4,8 @@ import * as monaco from 'monaco-editor';
 import { cn } from '@/lib/utils';
 import { api } from '@/lib/api';
 import { FileText, Save, Eye, Pencil } from 'lucide-react';
-import { basename } from '@/lib/pathUtils';
+import { basename, normalizePath } from '@/lib/pathUtils';
+import { useFsChanged, type FsChangedEvent } from '@/hooks';
 
 // 使用本地 monaco-editor 而非 CDN，避免 404 错误
 loader.config({ monaco });
@@ -142,7 +143,6 @@ export const FileViewer: React.FC<FileViewerProps> = ({
       try {
         const metadata = await api.getFileMetadata(filePath);
         setFileSize(metadata.size);
-        setIsWritable(metadata.is_writable);
 
         if (metadata.size > MAX_FILE_SIZE) {
           setIsLargeFile(true);
@@ -150,14 +150,22 @@ export const FileViewer: React.FC<FileViewerProps> = ({
           return;
         }
 
-        if (metadata.is_binary) {
+        const result = await api.readFileSmart(filePath, MAX_FILE_SIZE);
+        setFileSize(result.size);
+        if (result.is_binary) {
           setIsBinary(true);
           setLoading(false);
           return;
         }
+        if (result.truncated) {
+          setIsLargeFile(true);
+          setLoading(false);
+          return;
+        }
 
-        const result = await api.readFile(filePath);
-        setContent(result || '');
+        // 保存时总是写回 UTF-8，其他编码的文件只读，避免改变文件编码
+        setIsWritable(metadata.is_writable && result.encoding === 'utf-8');
+        setContent(result.content);
         setLoading(false);
       } catch (err) {
         console.error('Failed to fetch file content:', err);
@@ -169,6 +177,26 @@ export const FileViewer: React.FC<FileViewerProps> = ({
     fetchContent();
   }, [filePath, workspacePath]);
 
+  // 文件在磁盘上变化时（例如 agent 编辑后）重新读取，不覆盖未保存的编辑
+  const handleFsChanged = useCallback(async (event: FsChangedEvent) => {
+    const target = normalizePath(filePath);
+    if (!event.truncated && !event.changes.some(change => normalizePath(change.path) === target)) return;
+    if (hasUnsavedChanges || isBinary || isLargeFile || isSaving) return;
+
+    try {
+      const result = await api.readFileSmart(filePath, MAX_FILE_SIZE);
+      if (result.is_binary || result.truncated) return;
+      setContent(result.content);
+      if (isEditMode) {
+        setEditedContent(result.content);
+      }
+    } catch (err) {
+      console.warn('[FileViewer] Failed to reload changed file:', filePath, err);
+    }
+  }, [filePath, hasUnsavedChanges, isBinary, isLargeFile, isSaving, isEditMode]);
+
+  useFsChanged(workspacePath, handleFsChanged);
+
   // 获取文件名和语言
   const fileName = useMemo(() => {
     return basename(filePath, filePath);
@@ -23,7 +23,7 @@ test('FileViewer uses cross-platform file RPCs instead of Unix shell commands',
   const source = await readSource(fileViewerPath);
 
   assert.match(source, /api\.getFileMetadata\(/);
-  assert.match(source, /api\.readFile\(/);
+  assert.match(source, /api\.readFileSmart\(/);
   assert.doesNotMatch(source, /wc -c/);
   assert.doesNotMatch(source, /test -w/);
   assert.doesNotMatch(source, /file --mime-type/);
@@ -36,7 +36,7 @@ test('DiffViewer uses cross-platform file RPCs for working tree content', async
 
   assert.match(source, /from '@\/lib\/diffPath'/);
   assert.match(source, /api\.getFileMetadata\(/);
-  assert.match(source, /api\.readFile\(/);
+  assert.match(source, /api\.readFileSmart\(/);
   assert.match(source, /api\.readGitFileAtHead\(/);
   assert.doesNotMatch(source, /wc -c/);
   assert.doesNotMatch(source, /file --mime/);
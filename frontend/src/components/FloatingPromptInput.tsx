@@ -27,8 +27,11 @@ import { SkillPicker } from "./SkillPicker";
 import { ClaudeCapabilityPicker } from "./ClaudeCapabilityPicker";
 import { ImagePreview } from "./ImagePreview";
 import { ProviderApiQuickSelector } from "./ProviderApiQuickSelector";
+import { SnippetPicker } from "./SnippetPicker";
+import { DictationButton } from "./DictationButton";
+import { PermissionProfileQuickSelector } from "./PermissionProfileQuickSelector";
 import { api, type main, type claude, type database } from "@/lib/api";
-import type { ClaudeCapability } from "@/lib/rpc-client";
+import { GetProjectModelDefaults, SetProjectModelDefaults, type ClaudeCapability } from "@/lib/rpc-client";
 import { useProviderApiStore } from "@/stores/providerApiStore";
 import { ClaudeIcon } from "./icons/ClaudeIcon";
 import { OpenAIIcon } from "./icons/OpenAIIcon";
@@ -507,6 +510,8 @@ const FloatingPromptInputInner = (
     defaultProvider === 'codex' ? 'medium' : 'auto' // Gemini defaults to 'auto' like Claude
   );
   const [selectedProviderApiId, setSelectedProviderApiId] = useState<string | null>(null);
+  // Model and thinking level this project pre-selects for the provider
+  const [projectModelDefaults, setProjectModelDefaults] = useState<main.ProjectModelDefaults | null>(null);
   const [isExpanded, setIsExpanded] = useState(false);
   const [mobileSelectorsOpen, setMobileSelectorsOpen] = useState(false);
 
@@ -549,6 +554,9 @@ const FloatingPromptInputInner = (
   const [textareaHeight, setTextareaHeight] = useState<number>(getDefaultHeight);
   const isIMEComposingRef = useRef(false);
   const effectiveProvider = projectPath?.trim() ? selectedProvider : defaultProvider;
+  // The drop listener is set up once, so it reads the session it attaches to from here
+  const attachmentTargetRef = useRef({ provider: effectiveProvider, sessionId: interactiveSessionId || '' });
+  attachmentTargetRef.current = { provider: effectiveProvider, sessionId: interactiveSessionId || '' };
   const usesClaudeCapabilityPicker = defaultProvider === 'claude';
 
   useEffect(() => {
@@ -749,8 +757,12 @@ const FloatingPromptInputInner = (
     if (modelConfigsLoaded && modelConfigs.length > 0) {
       const modelConfig = modelConfigs.find((c: ModelConfig) => c.model_id === modelId && c.provider_id === providerId);
       if (modelConfig && modelConfig.thinking_levels && modelConfig.thinking_levels.length > 0) {
+        // Models without thinking support keep only their default level
+        const levels = modelConfig.capabilities?.supports_thinking === false
+          ? [modelConfig.thinking_levels.find((t: ThinkingLevel) => t.is_default) ?? modelConfig.thinking_levels[0]]
+          : modelConfig.thinking_levels;
         // Convert ThinkingLevel to ThinkingModeConfig format
-        return modelConfig.thinking_levels.map((t: ThinkingLevel, index) => ({
+        return levels.map((t: ThinkingLevel, index) => ({
           id: t.id as ThinkingMode,
           name: t.name,
           description: t.name,
@@ -769,6 +781,18 @@ const FloatingPromptInputInner = (
   // Get current thinking modes using the helper
   const currentThinkingModes = getModelThinkingModes(selectedModel, selectedProvider);
 
+  // Models the capability catalog doesn't know are assumed to accept images.
+  // The drop listener is set up once, so it reads this from a ref.
+  const supportsVision = modelConfigs.find(c => c.model_id === selectedModel && c.provider_id === selectedProvider)
+    ?.capabilities?.supports_vision !== false;
+  const supportsVisionRef = useRef(supportsVision);
+  supportsVisionRef.current = supportsVision;
+
+  const rejectImages = () => {
+    setUploadError('当前模型不支持图片输入');
+    setTimeout(() => setUploadError(null), 5000);
+  };
+
   useEffect(() => {
     onConfigChange?.({
       provider: selectedProvider,
@@ -797,6 +821,51 @@ const FloatingPromptInputInner = (
     }
   }, [selectedProvider, modelConfigs, modelConfigsLoaded, getProviderModels]);
 
+  // Pre-select the project's default model and thinking level
+  useEffect(() => {
+    if (!projectPath || !modelConfigsLoaded) return;
+    let cancelled = false;
+    GetProjectModelDefaults(projectPath, selectedProvider)
+      .then((defaults) => {
+        if (cancelled) return;
+        setProjectModelDefaults(defaults);
+        if (!defaults?.model || !getProviderModels(selectedProvider).some(m => m.id === defaults.model)) return;
+        setSelectedModel(defaults.model);
+        if (defaults.thinking_level && getModelThinkingModes(defaults.model, selectedProvider).some(m => m.id === defaults.thinking_level)) {
+          setSelectedThinkingMode(defaults.thinking_level as ThinkingMode);
+        }
+      })
+      .catch((error) => console.error('Failed to load project model defaults:', error));
+    return () => {
+      cancelled = true;
+    };
+  }, [projectPath, selectedProvider, modelConfigsLoaded, getProviderModels, getModelThinkingModes]);
+
+  const isProjectModelDefault = projectModelDefaults?.model === selectedModel
+    && projectModelDefaults?.thinking_level === selectedThinkingMode;
+
+  const saveProjectModelDefaults = async () => {
+    if (!projectPath) return;
+    try {
+      await SetProjectModelDefaults(projectPath, selectedProvider, selectedModel, selectedThinkingMode);
+      setProjectModelDefaults({ model: selectedModel, thinking_level: selectedThinkingMode });
+      setModelPickerOpen(false);
+    } catch (error) {
+      console.error('Failed to save project model defaults:', error);
+      setUploadError('保存项目默认模型失败');
+    }
+  };
+
+  const projectModelDefaultButton = projectPath ? (
+    <button
+      onClick={saveProjectModelDefaults}
+      disabled={isProjectModelDefault}
+      className="w-full mt-1 border-t px-3 pt-2 pb-1 text-left text-xs text-muted-foreground hover:text-foreground disabled:hover:text-muted-foreground"
+    >
+      {isProjectModelDefault ? "Project default" : "Use as project default"}
+    </button>
+  ) : null;
+
   // Reset thinking mode when provider or model changes
   useEffect(() => {
     const modes = getModelThinkingModes(selectedModel, selectedProvider);
@@ -885,6 +954,50 @@ const FloatingPromptInputInner = (
     return ['png', 'jpg', 'jpeg', 'gif', 'svg', 'webp', 'ico', 'bmp'].includes(ext || '');
   };
 
+  // Dropped files other than images that are copied into the session's attachments
+  // (keep in sync with internal/attachments)
+  const isAttachableFile = (path: string): boolean => {
+    const ext = path.split('.').pop()?.toLowerCase();
+    return [
+      'pdf',
+      'txt', 'log', 'md', 'markdown', 'csv', 'tsv', 'json', 'jsonl', 'yaml', 'yml', 'toml', 'xml',
+      'html', 'css', 'js', 'jsx', 'ts', 'tsx', 'go', 'py', 'rs', 'java', 'c', 'h', 'cpp', 'rb', 'sh',
+      'sql', 'diff', 'patch',
+      'zip', 'tar', 'gz', 'tgz', 'bz2', 'xz', '7z',
+    ].includes(ext || '');
+  };
+
+  // Append text to the prompt and put the cursor at its end
+  const appendToPrompt = (text: string) => {
+    setPrompt(currentPrompt => {
+      const newPrompt = currentPrompt + (currentPrompt.endsWith(' ') || currentPrompt === '' ? '' : ' ') + text + ' ';
+      setTimeout(() => {
+        const target = isExpanded ? expandedTextareaRef.current : textareaRef.current;
+        target?.focus();
+        target?.setSelectionRange(newPrompt.length, newPrompt.length);
+      }, 0);
+      return newPrompt;
+    });
+  };
+
+  // Insert text (a snippet or a dictated prompt) at the cursor and put the
+  // cursor after it
+  const insertAtCursor = (body: string) => {
+    const position = Math.min(cursorPosition, prompt.length);
+    const before = prompt.substring(0, position);
+    const after = prompt.substring(position);
+    const separator = before === '' || /\s$/.test(before) ? '' : ' ';
+    const newPrompt = before + separator + body + after;
+    setPrompt(newPrompt);
+    setTimeout(() => {
+      const target = isExpanded ? expandedTextareaRef.current : textareaRef.current;
+      const newCursorPos = position + separator.length + body.length;
+      target?.focus();
+      target?.setSelectionRange(newCursorPos, newCursorPos);
+      setCursorPosition(newCursorPos);
+    }, 0);
+  };
+
   // Extract image paths from prompt text
   const extractImagePaths = (text: string): string[] => {
     // console.log('[extractImagePaths] Input text length:', text.length);
@@ -989,7 +1102,9 @@ const FloatingPromptInputInner = (
 
             const imagePaths = droppedPaths.filter(isImageFile);
 
-            if (imagePaths.length > 0) {
+            if (imagePaths.length > 0 && !supportsVisionRef.current) {
+              rejectImages();
+            } else if (imagePaths.length > 0) {
               setPrompt(currentPrompt => {
                 const existingPaths = extractImagePaths(currentPrompt);
                 const newPaths = imagePaths.filter(p => !existingPaths.includes(p));
@@ -1017,6 +1132,27 @@ const FloatingPromptInputInner = (
                 return newPrompt;
               });
             }
+
+            const attachablePaths = droppedPaths.filter(p => !isImageFile(p) && isAttachableFile(p));
+            if (attachablePaths.length > 0) {
+              const { provider, sessionId } = attachmentTargetRef.current;
+              Promise.allSettled(
+                attachablePaths.map(p => api.addAttachment(sessionId, provider, p))
+              ).then(results => {
+                const references = results
+                  .filter((r): r is PromiseFulfilledResult<main.AttachmentResult> => r.status === 'fulfilled')
+                  .map(r => r.value.reference);
+                const failed = results.filter(r => r.status === 'rejected');
+                if (failed.length > 0) {
+                  console.error('Failed to attach dropped files:', failed);
+                  setUploadError(`${failed.length} 个文件添加失败`);
+                  setTimeout(() => setUploadError(null), 5000);
+                }
+                if (references.length > 0) {
+                  appendToPrompt(references.join(' '));
+                }
+              });
+            }
           }
         });
 
@@ -1499,6 +1635,10 @@ const FloatingPromptInputInner = (
     for (const item of items) {
       if (item.type.startsWith('image/')) {
         e.preventDefault();
+        if (!supportsVision) {
+          rejectImages();
+          continue;
+        }
 
         // Get the image blob
         const blob = item.getAsFile();
@@ -1519,7 +1659,7 @@ const FloatingPromptInputInner = (
             try {
               // Call backend to save image and get file path
               // Pass empty string for filename to let backend auto-generate it
-              const imagePath = await api.savePastedImage(base64Data, "");
+              const { path: imagePath } = await api.savePastedImage(base64Data, "");
 
               // Add file path reference (consistent with drag & drop)
               setPrompt(currentPrompt => {
@@ -1575,10 +1715,15 @@ const FloatingPromptInputInner = (
 
   const handleAttachmentSelected = async (file: File) => {
     setUploadError(null);
+    if (file.type.startsWith('image/') && !supportsVision) {
+      rejectImages();
+      return;
+    }
     try {
-      const result = await uploadAttachment(file, projectPath);
-      // Insert file reference into prompt
-      setPrompt((prev) => `${prev}@${result.filePath} `);
+      const result = await uploadAttachment(file, projectPath, interactiveSessionId || undefined);
+      // Insert file reference into prompt, in the provider's syntax
+      const reference = await api.getAttachmentReference(effectiveProvider, result.filePath);
+      setPrompt((prev) => `${prev}${reference} `);
     } catch (error) {
       if (error instanceof UploadError) {
         setUploadError(error.message);
@@ -1737,6 +1882,7 @@ const FloatingPromptInputInner = (
                               </div>
                             </button>
                           ))}
+                          {projectModelDefaultButton}
                         </div>
                       }
                       open={modelPickerOpen}
@@ -1951,6 +2097,11 @@ const FloatingPromptInputInner = (
                         />
                       )}
 
+                      {/* Permission profile for new Claude / Codex sessions */}
+                      {projectPath && selectedProvider !== 'gemini' && (
+                        <PermissionProfileQuickSelector projectPath={projectPath} disabled={disabled} />
+                      )}
+
                       {/* Model Selector */}
                       <Popover
                         trigger={
@@ -2005,6 +2156,7 @@ const FloatingPromptInputInner = (
                               </div>
                             </button>
                           ))}
+                          {projectModelDefaultButton}
                         </div>
                       }
                       open={modelPickerOpen}
@@ -2122,6 +2274,17 @@ const FloatingPromptInputInner = (
                     </Button>
                   </TooltipSimple>
 
+                  <SnippetPicker
+                    onInsert={insertAtCursor}
+                    currentPrompt={prompt}
+                    disabled={disabled}
+                  />
+
+                  <DictationButton
+                    onTranscript={insertAtCursor}
+                    disabled={disabled}
+                  />
+
                   <AttachmentButton
                     onFileSelected={handleAttachmentSelected}
                     disabled={isLoading || disabled}
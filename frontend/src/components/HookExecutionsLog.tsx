@@ -0,0 +1,126 @@
+import React, { useState, useEffect } from "react";
+import { ChevronDown, ChevronRight, Loader2, RefreshCw, Trash2 } from "lucide-react";
+import { Button } from "@/components/ui/button";
+import { Badge } from "@/components/ui/badge";
+import { Label } from "@/components/ui/label";
+import { Switch } from "@/components/ui/switch";
+import { ScrollArea } from "@/components/ui/scroll-area";
+import { ListHookExecutions, ClearHookExecutions, type database } from "@/lib/rpc-client";
+import { cn } from "@/lib/utils";
+
+interface HookExecutionsLogProps {
+  /** 只显示该项目的记录 */
+  projectPath?: string;
+  className?: string;
+}
+
+const failed = (e: database.HookExecution) =>
+  (e.outcome !== "" && e.outcome !== "success") || (e.exit_code !== null && e.exit_code !== 0);
+
+/**
+ * Claude Code hook 的执行记录，用于排查阻止工具调用的 hook
+ */
+export const HookExecutionsLog: React.FC<HookExecutionsLogProps> = ({ projectPath, className }) => {
+  const [executions, setExecutions] = useState<database.HookExecution[]>([]);
+  const [loading, setLoading] = useState(true);
+  const [failedOnly, setFailedOnly] = useState(false);
+  const [expanded, setExpanded] = useState<number | null>(null);
+  const [error, setError] = useState<string | null>(null);
+
+  const load = async () => {
+    try {
+      setLoading(true);
+      setError(null);
+      setExecutions(await ListHookExecutions({ project_path: projectPath, failed_only: failedOnly }));
+    } catch (err) {
+      console.error("Failed to load hook executions:", err);
+      setError("Failed to load hook executions");
+    } finally {
+      setLoading(false);
+    }
+  };
+
+  useEffect(() => {
+    load();
+  }, [projectPath, failedOnly]);
+
+  const handleClear = async () => {
+    if (!confirm("Clear the hook execution log?")) return;
+    try {
+      await ClearHookExecutions();
+      setExecutions([]);
+    } catch (err) {
+      console.error("Failed to clear hook executions:", err);
+      setError("Failed to clear hook executions");
+    }
+  };
+
+  return (
+    <div className={cn("space-y-3", className)}>
+      <div className="flex items-center justify-between">
+        <div>
+          <h3 className="text-base font-semibold">Hook Executions</h3>
+          <p className="text-body-small text-muted-foreground">
+            Recent hook runs reported by Claude Code sessions
+          </p>
+        </div>
+        <div className="flex items-center gap-3">
+          <div className="flex items-center gap-2">
+            <Switch id="hook-failed-only" checked={failedOnly} onCheckedChange={setFailedOnly} />
+            <Label htmlFor="hook-failed-only" className="text-sm">Failed only</Label>
+          </div>
+          <Button variant="ghost" size="sm" onClick={load} disabled={loading}>
+            <RefreshCw className={cn("h-4 w-4", loading && "animate-spin")} />
+          </Button>
+          <Button variant="ghost" size="sm" onClick={handleClear} disabled={executions.length === 0}>
+            <Trash2 className="h-4 w-4" />
+          </Button>
+        </div>
+      </div>
+
+      {error && <p className="text-sm text-destructive">{error}</p>}
+
+      {loading && executions.length === 0 ? (
+        <div className="flex justify-center py-6">
+          <Loader2 className="h-5 w-5 animate-spin text-muted-foreground" />
+        </div>
+      ) : executions.length === 0 ? (
+        <p className="text-sm text-muted-foreground py-4 text-center">No hook executions recorded</p>
+      ) : (
+        <ScrollArea className="max-h-96">
+          <div className="space-y-1">
+            {executions.map((e) => (
+              <div key={e.id} className="rounded-md border">
+                <button
+                  className="w-full flex items-center gap-2 px-3 py-2 text-left text-sm hover:bg-muted/50"
+                  onClick={() => setExpanded(expanded === e.id ? null : e.id)}
+                >
+                  {expanded === e.id ? <ChevronDown className="h-4 w-4" /> : <ChevronRight className="h-4 w-4" />}
+                  <Badge variant="outline">{e.hook_event}</Badge>
+                  {e.matcher && <span className="font-mono text-xs">{e.matcher}</span>}
+                  <Badge variant={failed(e) ? "destructive" : "secondary"}>
+                    {e.exit_code !== null ? `exit ${e.exit_code}` : e.outcome || "unknown"}
+                  </Badge>
+                  <span className="ml-auto text-xs text-muted-foreground">
+                    {e.duration_ms} ms · {new Date(e.started_at).toLocaleString()}
+                  </span>
+                </button>
+                {expanded === e.id && (
+                  <div className="px-3 pb-3 space-y-2 text-xs">
+                    {e.command && (
+                      <pre className="bg-muted rounded p-2 whitespace-pre-wrap font-mono">{e.command}</pre>
+                    )}
+                    <pre className="bg-muted rounded p-2 whitespace-pre-wrap font-mono">
+                      {e.output || "(no output)"}
+                      {e.truncated && "\n… output truncated"}
+                    </pre>
+                  </div>
+                )}
+              </div>
+            ))}
+          </div>
+        </ScrollArea>
+      )}
+    </div>
+  );
+};
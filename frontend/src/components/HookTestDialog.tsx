@@ -0,0 +1,112 @@
+/**
+ * HookTestDialog runs a hook command against a sample event payload
+ */
+
+import React, { useState, useEffect } from 'react';
+import { Loader2, Play } from 'lucide-react';
+import { Button } from '@/components/ui/button';
+import { Label } from '@/components/ui/label';
+import { Textarea } from '@/components/ui/textarea';
+import { Badge } from '@/components/ui/badge';
+import {
+  Dialog,
+  DialogContent,
+  DialogDescription,
+  DialogHeader,
+  DialogTitle,
+} from '@/components/ui/dialog';
+import { RunHookTest, SampleHookPayload, type claude } from '@/lib/rpc-client';
+import type { HookCommand, HookEvent } from '@/types/hooks';
+
+interface HookTestDialogProps {
+  /** The hook to test; the dialog is open while set */
+  target: { event: HookEvent; matcher?: string; hook: HookCommand } | null;
+  projectPath?: string;
+  onClose: () => void;
+}
+
+// The tool a matcher pattern applies to, for the sample payload
+const sampleTool = (matcher?: string) => {
+  const tool = (matcher || '').split('|')[0];
+  return /^\w+$/.test(tool) ? tool : 'Bash';
+};
+
+export const HookTestDialog: React.FC<HookTestDialogProps> = ({ target, projectPath, onClose }) => {
+  const [payload, setPayload] = useState('');
+  const [running, setRunning] = useState(false);
+  const [result, setResult] = useState<claude.HookTestResult | null>(null);
+  const [error, setError] = useState<string | null>(null);
+
+  useEffect(() => {
+    if (!target) return;
+    setResult(null);
+    setError(null);
+    SampleHookPayload(target.event, sampleTool(target.matcher), projectPath || '')
+      .then(setPayload)
+      .catch((err) => setError(err instanceof Error ? err.message : String(err)));
+  }, [target, projectPath]);
+
+  const run = async () => {
+    if (!target) return;
+    try {
+      setRunning(true);
+      setError(null);
+      setResult(await RunHookTest({ type: 'command', command: target.hook.command, timeout: target.hook.timeout }, payload));
+    } catch (err) {
+      setResult(null);
+      setError(err instanceof Error ? err.message : String(err));
+    } finally {
+      setRunning(false);
+    }
+  };
+
+  return (
+    <Dialog open={target !== null} onOpenChange={(open) => !open && onClose()}>
+      <DialogContent className="max-w-2xl max-h-[80vh] overflow-y-auto">
+        <DialogHeader>
+          <DialogTitle>Test Hook</DialogTitle>
+          <DialogDescription>
+            Runs the command with the payload on stdin, the way Claude Code runs hooks
+          </DialogDescription>
+        </DialogHeader>
+
+        <div className="space-y-4">
+          <pre className="text-xs font-mono bg-muted rounded p-2 whitespace-pre-wrap">{target?.hook.command}</pre>
+
+          <div className="space-y-2">
+            <Label htmlFor="hook-test-payload">{target?.event} payload</Label>
+            <Textarea
+              id="hook-test-payload"
+              value={payload}
+              onChange={(e) => setPayload(e.target.value)}
+              className="font-mono text-xs min-h-[160px]"
+            />
+          </div>
+
+          <Button onClick={run} disabled={running || !payload}>
+            {running ? <Loader2 className="h-4 w-4 mr-2 animate-spin" /> : <Play className="h-4 w-4 mr-2" />}
+            Run
+          </Button>
+
+          {error && <p className="text-sm text-destructive">{error}</p>}
+
+          {result && (
+            <div className="space-y-2 text-xs">
+              <div className="flex items-center gap-2">
+                <Badge variant={result.exit_code === 0 ? 'secondary' : 'destructive'}>
+                  {result.timed_out ? 'timed out' : `exit ${result.exit_code}`}
+                </Badge>
+                {result.blocked && <Badge variant="destructive">blocks the tool call</Badge>}
+                <span className="text-muted-foreground">{result.duration_ms} ms</span>
+              </div>
+              <Label>stdout</Label>
+              <pre className="bg-muted rounded p-2 whitespace-pre-wrap font-mono">{result.stdout || '(empty)'}</pre>
+              <Label>stderr</Label>
+              <pre className="bg-muted rounded p-2 whitespace-pre-wrap font-mono">{result.stderr || '(empty)'}</pre>
+            </div>
+          )}
+        </div>
+      </DialogContent>
+    </Dialog>
+  );
+};
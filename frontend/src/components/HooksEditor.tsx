@@ -19,7 +19,8 @@ import {
   PlayCircle,
   Info,
   Save,
-  Loader2
+  Loader2,
+  Play
 } from 'lucide-react';
 import { Button } from '@/components/ui/button';
 import { Input } from '@/components/ui/input';
@@ -51,14 +52,14 @@ import {
 import { cn } from '@/lib/utils';
 import { HooksManager } from '@/lib/hooksManager';
 import { api } from '@/lib/api';
+import type { claude } from '@/lib/rpc-client';
+import { HookTestDialog } from './HookTestDialog';
 import {
   HooksConfiguration,
   HookEvent,
   HookMatcher,
   HookCommand,
-  HookTemplate,
   COMMON_TOOL_MATCHERS,
-  HOOK_TEMPLATES,
 } from '@/types/hooks';
 
 interface HooksEditorProps {
@@ -118,6 +119,8 @@ export const HooksEditor: React.FC<HooksEditorProps> = ({
 }) => {
   const [selectedEvent, setSelectedEvent] = useState<HookEvent>('PreToolUse');
   const [showTemplateDialog, setShowTemplateDialog] = useState(false);
+  const [templates, setTemplates] = useState<claude.HookTemplate[]>([]);
+  const [testTarget, setTestTarget] = useState<{ event: HookEvent; matcher?: string; hook: HookCommand } | null>(null);
   const [validationErrors, setValidationErrors] = useState<string[]>([]);
   const [validationWarnings, setValidationWarnings] = useState<string[]>([]);
   const isInitialMount = React.useRef(true);
@@ -402,8 +405,17 @@ export const HooksEditor: React.FC<HooksEditorProps> = ({
     }));
   };
 
-  const applyTemplate = (template: HookTemplate) => {
-    if (matcherEvents.includes(template.event as any)) {
+  useEffect(() => {
+    if (showTemplateDialog && templates.length === 0) {
+      api.getHookTemplates()
+        .then(setTemplates)
+        .catch((err) => console.error("Failed to load hook templates:", err));
+    }
+  }, [showTemplateDialog]);
+
+  const applyTemplate = (template: claude.HookTemplate) => {
+    const event = template.event as HookEvent;
+    if (matcherEvents.includes(event as any)) {
       // For events with matchers
       const newMatcher: EditableHookMatcher = {
         id: HooksManager.generateId(),
@@ -418,7 +430,7 @@ export const HooksEditor: React.FC<HooksEditorProps> = ({
       
       setEditableHooks(prev => ({
         ...prev,
-        [template.event]: [...(prev[template.event as 'PreToolUse' | 'PostToolUse'] as EditableHookMatcher[]), newMatcher]
+        [event]: [...(prev[event as 'PreToolUse' | 'PostToolUse'] as EditableHookMatcher[]), newMatcher]
       }));
     } else {
       // For direct events
@@ -430,11 +442,11 @@ export const HooksEditor: React.FC<HooksEditorProps> = ({
       
       setEditableHooks(prev => ({
         ...prev,
-        [template.event]: [...(prev[template.event as 'Notification' | 'Stop' | 'SubagentStop'] as EditableHookCommand[]), ...newCommands]
+        [event]: [...(prev[event as 'Notification' | 'Stop' | 'SubagentStop'] as EditableHookCommand[]), ...newCommands]
       }));
     }
     
-    setSelectedEvent(template.event);
+    setSelectedEvent(event);
     setShowTemplateDialog(false);
   };
 
@@ -634,6 +646,16 @@ export const HooksEditor: React.FC<HooksEditorProps> = ({
                               <span className="text-sm text-muted-foreground">seconds</span>
                             </div>
                             
+                            <Button
+                              variant="ghost"
+                              size="sm"
+                              onClick={() => setTestTarget({ event, matcher: matcher.matcher, hook })}
+                              disabled={!hook.command}
+                            >
+                              <Play className="h-4 w-4 mr-1" />
+                              Test
+                            </Button>
+
                             {!readOnly && (
                               <Button
                                 variant="ghost"
@@ -700,6 +722,16 @@ export const HooksEditor: React.FC<HooksEditorProps> = ({
               <span className="text-sm text-muted-foreground">seconds</span>
             </div>
             
+            <Button
+              variant="ghost"
+              size="sm"
+              onClick={() => setTestTarget({ event, hook: command })}
+              disabled={!command.command}
+            >
+              <Play className="h-4 w-4 mr-1" />
+              Test
+            </Button>
+
             {!readOnly && (
               <Button
                 variant="ghost"
@@ -900,7 +932,7 @@ export const HooksEditor: React.FC<HooksEditorProps> = ({
               </DialogHeader>
               
               <div className="space-y-4 py-4">
-                {HOOK_TEMPLATES.map(template => (
+                {templates.map(template => (
                   <Card
                     key={template.id}
                     className="p-4 cursor-pointer hover:bg-accent"
@@ -909,7 +941,7 @@ export const HooksEditor: React.FC<HooksEditorProps> = ({
                     <div className="space-y-2">
                       <div className="flex items-center justify-between">
                         <h4 className="font-medium">{template.name}</h4>
-                        <Badge>{EVENT_INFO[template.event].label}</Badge>
+                        <Badge>{EVENT_INFO[template.event as HookEvent]?.label ?? template.event}</Badge>
                       </div>
                       <p className="text-sm text-muted-foreground">{template.description}</p>
                       {matcherEvents.includes(template.event as any) && template.matcher && (
@@ -923,6 +955,12 @@ export const HooksEditor: React.FC<HooksEditorProps> = ({
               </div>
             </DialogContent>
           </Dialog>
+
+          <HookTestDialog
+            target={testTarget}
+            projectPath={projectPath}
+            onClose={() => setTestTarget(null)}
+          />
         </>
       )}
     </div>
@@ -0,0 +1,161 @@
+import React, { useEffect, useState } from "react";
+import { Loader2 } from "lucide-react";
+import { Button } from "@/components/ui/button";
+import {
+  Dialog,
+  DialogContent,
+  DialogDescription,
+  DialogFooter,
+  DialogHeader,
+  DialogTitle,
+} from "@/components/ui/dialog";
+import { DiscoverModels, ImportDiscoveredModels, type main } from "@/lib/rpc-client";
+import type { ProviderApiConfig } from "@/lib/api";
+import { cn } from "@/lib/utils";
+
+interface ModelDiscoveryDialogProps {
+  /**
+   * The API configuration whose endpoint is queried; the dialog is open while set
+   */
+  config: ProviderApiConfig | null;
+  onClose: () => void;
+  /**
+   * Called with the number of models added to the registry
+   */
+  onImported: (count: number) => void;
+}
+
+const STATUS_LABELS: Record<string, string> = {
+  new: "New",
+  exists: "Added",
+  unsupported: "Not a chat model",
+};
+
+/**
+ * Lists the models an API configuration's endpoint serves and adds the
+ * selected ones to the model registry
+ */
+export const ModelDiscoveryDialog: React.FC<ModelDiscoveryDialogProps> = ({
+  config,
+  onClose,
+  onImported,
+}) => {
+  const [discovery, setDiscovery] = useState<main.ModelDiscovery | null>(null);
+  const [selected, setSelected] = useState<Set<string>>(new Set());
+  const [loading, setLoading] = useState(false);
+  const [importing, setImporting] = useState(false);
+  const [error, setError] = useState<string | null>(null);
+
+  useEffect(() => {
+    if (!config?.id) return;
+    let cancelled = false;
+    setDiscovery(null);
+    setError(null);
+    setLoading(true);
+    DiscoverModels(config.id)
+      .then((result) => {
+        if (cancelled) return;
+        setDiscovery(result);
+        // New models are offered preselected
+        setSelected(new Set(result.models.filter((m) => m.status === "new").map((m) => m.model_id)));
+      })
+      .catch((err) => {
+        if (!cancelled) setError(err instanceof Error ? err.message : String(err));
+      })
+      .finally(() => {
+        if (!cancelled) setLoading(false);
+      });
+    return () => {
+      cancelled = true;
+    };
+  }, [config?.id]);
+
+  const toggle = (modelId: string) => {
+    setSelected((prev) => {
+      const next = new Set(prev);
+      if (next.has(modelId)) {
+        next.delete(modelId);
+      } else {
+        next.add(modelId);
+      }
+      return next;
+    });
+  };
+
+  const handleImport = async () => {
+    if (!config?.id) return;
+    try {
+      setImporting(true);
+      setError(null);
+      const imported = await ImportDiscoveredModels(config.id, Array.from(selected));
+      onImported(imported?.length || 0);
+      onClose();
+    } catch (err) {
+      setError(err instanceof Error ? err.message : String(err));
+    } finally {
+      setImporting(false);
+    }
+  };
+
+  return (
+    <Dialog open={config !== null} onOpenChange={(open) => !open && onClose()}>
+      <DialogContent className="max-w-lg max-h-[80vh] flex flex-col">
+        <DialogHeader>
+          <DialogTitle>Discover Models</DialogTitle>
+          <DialogDescription>
+            Models served by {config?.name}
+            {discovery?.base_url ? ` (${discovery.base_url})` : ""}. Select the ones to add.
+          </DialogDescription>
+        </DialogHeader>
+
+        <div className="flex-1 overflow-y-auto -mx-1 px-1">
+          {loading ? (
+            <div className="py-8 flex justify-center">
+              <Loader2 className="h-5 w-5 animate-spin text-muted-foreground" />
+            </div>
+          ) : discovery && discovery.models.length === 0 ? (
+            <p className="py-4 text-sm text-muted-foreground text-center">The endpoint lists no models</p>
+          ) : (
+            <div className="divide-y">
+              {discovery?.models.map((model) => {
+                const selectable = model.status === "new";
+                return (
+                  <label
+                    key={model.model_id}
+                    className={cn(
+                      "flex items-center gap-3 py-2 text-sm",
+                      selectable ? "cursor-pointer" : "opacity-60"
+                    )}
+                  >
+                    <input
+                      type="checkbox"
+                      checked={selected.has(model.model_id)}
+                      disabled={!selectable}
+                      onChange={() => toggle(model.model_id)}
+                    />
+                    <div className="flex-1 min-w-0">
+                      <div className="truncate">{model.display_name}</div>
+                      <code className="text-xs text-muted-foreground">{model.model_id}</code>
+                    </div>
+                    <span className="text-xs text-muted-foreground">{STATUS_LABELS[model.status]}</span>
+                  </label>
+                );
+              })}
+            </div>
+          )}
+          {error && <p className="mt-2 text-sm text-destructive">{error}</p>}
+        </div>
+
+        <DialogFooter>
+          <Button variant="outline" onClick={onClose}>
+            Cancel
+          </Button>
+          <Button onClick={handleImport} disabled={importing || selected.size === 0}>
+            {importing && <Loader2 className="h-4 w-4 mr-2 animate-spin" />}
+            Add {selected.size} Model{selected.size === 1 ? "" : "s"}
+          </Button>
+        </DialogFooter>
+      </DialogContent>
+    </Dialog>
+  );
+};
@@ -15,6 +15,7 @@ import {
   ChevronUp,
   Lock,
   RefreshCw,
+  Image as ImageIcon,
 } from "lucide-react";
 import { Button } from "@/components/ui/button";
 import { Input } from "@/components/ui/input";
@@ -29,6 +30,7 @@ import {
   SelectValue,
 } from "@/components/ui/select";
 import { api, type ModelConfig } from "@/lib/api";
+import { GetModelCapabilitiesURL, RefreshModelCapabilities } from "@/lib/rpc-client";
 import { cn } from "@/lib/utils";
 
 interface ModelManagerProps {
@@ -63,6 +65,7 @@ export const ModelManager: React.FC<ModelManagerProps> = ({ setToast }) => {
   const [models, setModels] = useState<ModelConfig[]>([]);
   const [loading, setLoading] = useState(true);
   const [syncingProvider, setSyncingProvider] = useState<string | null>(null);
+  const [refreshingCapabilities, setRefreshingCapabilities] = useState(false);
   const [expandedProvider, setExpandedProvider] = useState<string | null>("claude");
   const [isCreating, setIsCreating] = useState(false);
   const [editingId, setEditingId] = useState<string | null>(null);
@@ -178,6 +181,28 @@ export const ModelManager: React.FC<ModelManagerProps> = ({ setToast }) => {
     }
   };
 
+  // Fetch capability metadata from a catalog URL, defaulting to the last one used
+  const handleRefreshCapabilities = async () => {
+    const savedURL = await GetModelCapabilitiesURL().catch(() => "");
+    const url = window.prompt("Capabilities catalog URL (a JSON list of capability entries)", savedURL);
+    if (url === null) return;
+    try {
+      setRefreshingCapabilities(true);
+      const count = await RefreshModelCapabilities(url);
+      await loadModels();
+      setToast?.({ message: `Loaded capabilities for ${count} model pattern${count === 1 ? "" : "s"}`, type: "success" });
+    } catch (err) {
+      console.error("Failed to refresh model capabilities:", err);
+      const message = err instanceof Error && err.message ? err.message : "Failed to refresh model capabilities";
+      setToast?.({ message, type: "error" });
+    } finally {
+      setRefreshingCapabilities(false);
+    }
+  };
+
+  const formatTokens = (tokens: number) =>
+    tokens >= 1_000_000 ? `${+(tokens / 1_000_000).toFixed(1)}M` : `${Math.round(tokens / 1000)}K`;
+
   const getModelsByProvider = (providerID: string) => {
     return models.filter((m) => m.provider_id === providerID);
   };
@@ -232,16 +257,28 @@ export const ModelManager: React.FC<ModelManagerProps> = ({ setToast }) => {
             Configure AI models and their thinking levels
           </p>
         </div>
-        <Button
-          variant="outline"
-          size="sm"
-          onClick={() => setIsCreating(true)}
-          disabled={isCreating}
-          className="gap-2"
-        >
-          <Plus className="h-4 w-4" />
-          Add Model
-        </Button>
+        <div className="flex items-center gap-2">
+          <Button
+            variant="ghost"
+            size="sm"
+            onClick={handleRefreshCapabilities}
+            disabled={refreshingCapabilities}
+            className="gap-2"
+          >
+            <RefreshCw className={cn("h-4 w-4", refreshingCapabilities && "animate-spin")} />
+            Refresh Capabilities
+          </Button>
+          <Button
+            variant="outline"
+            size="sm"
+            onClick={() => setIsCreating(true)}
+            disabled={isCreating}
+            className="gap-2"
+          >
+            <Plus className="h-4 w-4" />
+            Add Model
+          </Button>
+        </div>
       </div>
 
       {/* Create New Model Form */}
@@ -489,6 +526,16 @@ export const ModelManager: React.FC<ModelManagerProps> = ({ setToast }) => {
                                       {model.thinking_levels.length} levels
                                     </span>
                                   )}
+                                  {model.capabilities && (
+                                    <span
+                                      className="text-xs text-muted-foreground flex items-center gap-1"
+                                      title={model.capabilities.last_verified ? `Last verified ${model.capabilities.last_verified}` : undefined}
+                                    >
+                                      {model.capabilities.context_window > 0 && `${formatTokens(model.capabilities.context_window)} context`}
+                                      {model.capabilities.max_output_tokens > 0 && ` · ${formatTokens(model.capabilities.max_output_tokens)} output`}
+                                      {model.capabilities.supports_vision && <ImageIcon className="h-3 w-3 ml-1" aria-label="Accepts images" />}
+                                    </span>
+                                  )}
                                 </div>
                                 {model.description && (
                                   <p className="text-xs text-muted-foreground mt-1">
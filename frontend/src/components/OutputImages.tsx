@@ -0,0 +1,42 @@
+import React from "react";
+import { convertFileSrc } from "@/lib/file-utils";
+
+/** 会话输出中的图片块：后端保存后的 url 来源，或尚未转换的 base64 来源 */
+export function imageBlockSrc(block: any): string | null {
+  if (block?.type !== "image" || !block.source) return null;
+  const { source } = block;
+  if (source.type === "url" && typeof source.url === "string") {
+    return convertFileSrc(source.url);
+  }
+  if (source.type === "base64" && source.data) {
+    return `data:${source.media_type || "image/png"};base64,${source.data}`;
+  }
+  return null;
+}
+
+export const isImageBlock = (block: any) => imageBlockSrc(block) !== null;
+
+/**
+ * 在对话记录中内联显示图片，点击在新窗口打开原图
+ */
+export const OutputImages: React.FC<{ blocks: any[] }> = ({ blocks }) => {
+  const images = blocks
+    .map(block => ({ src: imageBlockSrc(block), title: block?.source?.original_path || block?.source?.path }))
+    .filter((image): image is { src: string; title: any } => image.src !== null);
+  if (images.length === 0) return null;
+
+  return (
+    <div className="flex flex-wrap gap-2">
+      {images.map((image, idx) => (
+        <a key={idx} href={image.src} target="_blank" rel="noreferrer" title={image.title}>
+          <img
+            src={image.src}
+            alt={image.title || "Output image"}
+            loading="lazy"
+            className="max-h-80 max-w-full rounded-md border bg-background object-contain"
+          />
+        </a>
+      ))}
+    </div>
+  );
+};
@@ -0,0 +1,138 @@
+import React, { useState, useEffect } from "react";
+import { Check, ChevronUp, ShieldCheck } from "lucide-react";
+import { Button } from "@/components/ui/button";
+import { Popover } from "@/components/ui/popover";
+import { Tooltip, TooltipTrigger, TooltipContent } from "@/components/ui/tooltip-modern";
+import {
+  ListPermissionProfiles,
+  GetProjectPermissionProfile,
+  GetSessionPermissionProfile,
+  SetSessionPermissionProfile,
+  type database
+} from "@/lib/rpc-client";
+import { cn } from "@/lib/utils";
+
+interface PermissionProfileQuickSelectorProps {
+  projectPath: string;
+  disabled?: boolean;
+  className?: string;
+}
+
+/**
+ * 输入框中的权限配置选择器，覆盖项目配置，对之后启动的会话生效
+ */
+export const PermissionProfileQuickSelector: React.FC<PermissionProfileQuickSelectorProps> = ({
+  projectPath,
+  disabled = false,
+  className
+}) => {
+  const [open, setOpen] = useState(false);
+  const [profiles, setProfiles] = useState<database.PermissionProfile[]>([]);
+  const [projectProfileId, setProjectProfileId] = useState("");
+  const [currentId, setCurrentId] = useState("");
+
+  const load = async () => {
+    try {
+      const [list, projectId, sessionId] = await Promise.all([
+        ListPermissionProfiles(),
+        GetProjectPermissionProfile(projectPath),
+        GetSessionPermissionProfile(projectPath)
+      ]);
+      setProfiles(list || []);
+      setProjectProfileId(projectId);
+      setCurrentId(sessionId);
+    } catch (err) {
+      console.error("Failed to load permission profiles:", err);
+    }
+  };
+
+  useEffect(() => {
+    load();
+  }, [projectPath]);
+
+  const handleOpenChange = (next: boolean) => {
+    setOpen(next);
+    // 配置可能在项目设置中被修改，打开时重新加载
+    if (next) load();
+  };
+
+  const handleSelect = async (id: string) => {
+    setOpen(false);
+    try {
+      // 选回项目配置时清除覆盖
+      await SetSessionPermissionProfile(projectPath, id === projectProfileId ? "" : id);
+      setCurrentId(id);
+    } catch (err) {
+      console.error("Failed to select permission profile:", err);
+    }
+  };
+
+  // 未选择配置时 Claude 以完全访问权限运行，Codex 使用沙箱设置
+  const effectiveId = currentId;
+  const current = profiles.find(p => p.id === effectiveId);
+  const restricted = !!effectiveId && effectiveId !== "full";
+
+  return (
+    <Popover
+      trigger={
+        <Tooltip>
+          <TooltipTrigger asChild>
+            <Button
+              variant="ghost"
+              size="sm"
+              disabled={disabled}
+              className={cn("h-9 px-1.5 hover:bg-accent/50 gap-0.5", className)}
+            >
+              <ShieldCheck className={cn("h-3.5 w-3.5", restricted ? "text-primary" : "text-muted-foreground")} />
+              <ChevronUp className="h-3 w-3 opacity-50" />
+            </Button>
+          </TooltipTrigger>
+          <TooltipContent side="top">
+            <p className="text-xs font-medium">Permissions: {current?.name || "Default"}</p>
+          </TooltipContent>
+        </Tooltip>
+      }
+      content={
+        <div className="w-[280px] p-1">
+          <div className="px-2 py-1.5 text-xs font-medium text-muted-foreground border-b mb-1">
+            Permissions for new sessions
+          </div>
+          {profiles.map(profile => (
+            <button
+              key={profile.id}
+              onClick={() => handleSelect(profile.id)}
+              className={cn(
+                "w-full flex items-start gap-3 p-2 rounded-md transition-colors text-left",
+                "hover:bg-accent",
+                effectiveId === profile.id && "bg-accent"
+              )}
+            >
+              <div className="mt-0.5">
+                {effectiveId === profile.id ? (
+                  <Check className="h-4 w-4 text-primary" />
+                ) : (
+                  <div className="h-4 w-4" />
+                )}
+              </div>
+              <div className="flex-1 min-w-0">
+                <div className="text-sm font-medium">
+                  {profile.name}
+                  {profile.id === projectProfileId && (
+                    <span className="ml-1.5 text-[10px] text-muted-foreground">project</span>
+                  )}
+                </div>
+                {profile.description && (
+                  <div className="text-xs text-muted-foreground">{profile.description}</div>
+                )}
+              </div>
+            </button>
+          ))}
+        </div>
+      }
+      open={open}
+      onOpenChange={handleOpenChange}
+      align="start"
+      side="top"
+    />
+  );
+};
@@ -0,0 +1,313 @@
+import React, { useState, useEffect } from "react";
+import { Loader2, Pencil, Plus, Save, Trash2, X } from "lucide-react";
+import { Button } from "@/components/ui/button";
+import { Input } from "@/components/ui/input";
+import { Label } from "@/components/ui/label";
+import { Switch } from "@/components/ui/switch";
+import {
+  Select,
+  SelectContent,
+  SelectItem,
+  SelectTrigger,
+  SelectValue,
+} from "@/components/ui/select";
+import {
+  ListPermissionProfiles,
+  SavePermissionProfile,
+  DeletePermissionProfile,
+  GetProjectPermissionProfile,
+  SetProjectPermissionProfile,
+  type database
+} from "@/lib/rpc-client";
+import { cn } from "@/lib/utils";
+
+interface PermissionProfilesEditorProps {
+  projectPath: string;
+  onSaved?: (message: string) => void;
+  className?: string;
+}
+
+type Profile = database.PermissionProfile;
+
+// Select 不支持空字符串作为值，用它表示"未选择配置"
+const NO_PROFILE = "__none__";
+
+export const CLAUDE_PERMISSION_MODES: { value: Profile["claude_permission_mode"]; label: string }[] = [
+  { value: "bypassPermissions", label: "Bypass permissions" },
+  { value: "acceptEdits", label: "Accept edits" },
+  { value: "dontAsk", label: "Allowed tools only" },
+  { value: "plan", label: "Plan only" },
+];
+
+export const CODEX_SANDBOXES: { value: Profile["codex_sandbox"]; label: string }[] = [
+  { value: "danger-full-access", label: "Full access" },
+  { value: "workspace-write", label: "Workspace write" },
+  { value: "read-only", label: "Read-only" },
+];
+
+const emptyProfile = (): Partial<Profile> => ({
+  id: "",
+  name: "",
+  description: "",
+  claude_permission_mode: "bypassPermissions",
+  claude_allowed_tools: [],
+  claude_disallowed_tools: [],
+  codex_sandbox: "workspace-write",
+  codex_network_access: true,
+});
+
+const splitTools = (value: string) => value.split(",").map(t => t.trim()).filter(Boolean);
+
+/**
+ * 权限配置：选择项目会话使用的权限配置，并管理自定义配置
+ */
+export const PermissionProfilesEditor: React.FC<PermissionProfilesEditorProps> = ({
+  projectPath,
+  onSaved,
+  className
+}) => {
+  const [profiles, setProfiles] = useState<Profile[]>([]);
+  const [selectedId, setSelectedId] = useState("");
+  const [editing, setEditing] = useState<Partial<Profile> | null>(null);
+  const [allowedTools, setAllowedTools] = useState("");
+  const [disallowedTools, setDisallowedTools] = useState("");
+  const [loading, setLoading] = useState(true);
+  const [saving, setSaving] = useState(false);
+  const [error, setError] = useState<string | null>(null);
+
+  const load = async () => {
+    try {
+      setLoading(true);
+      setError(null);
+      const [list, selected] = await Promise.all([
+        ListPermissionProfiles(),
+        GetProjectPermissionProfile(projectPath)
+      ]);
+      setProfiles(list || []);
+      setSelectedId(selected);
+    } catch (err) {
+      console.error("Failed to load permission profiles:", err);
+      setError("Failed to load permission profiles");
+    } finally {
+      setLoading(false);
+    }
+  };
+
+  useEffect(() => {
+    load();
+  }, [projectPath]);
+
+  const handleSelect = async (value: string) => {
+    const id = value === NO_PROFILE ? "" : value;
+    try {
+      setError(null);
+      await SetProjectPermissionProfile(projectPath, id);
+      setSelectedId(id);
+      onSaved?.("Permission profile updated");
+    } catch (err) {
+      console.error("Failed to select permission profile:", err);
+      setError(`Failed to select permission profile: ${err}`);
+    }
+  };
+
+  const startEdit = (profile: Partial<Profile>) => {
+    setEditing(profile);
+    setAllowedTools((profile.claude_allowed_tools || []).join(", "));
+    setDisallowedTools((profile.claude_disallowed_tools || []).join(", "));
+  };
+
+  const handleSave = async () => {
+    if (!editing) return;
+    try {
+      setSaving(true);
+      setError(null);
+      const saved = await SavePermissionProfile({
+        ...editing,
+        claude_allowed_tools: splitTools(allowedTools),
+        claude_disallowed_tools: splitTools(disallowedTools)
+      });
+      setEditing(null);
+      await load();
+      onSaved?.(`Saved ${saved.name}`);
+    } catch (err) {
+      console.error("Failed to save permission profile:", err);
+      setError(`Failed to save permission profile: ${err}`);
+    } finally {
+      setSaving(false);
+    }
+  };
+
+  const handleDelete = async (profile: Profile) => {
+    if (!confirm(`Delete permission profile ${profile.name}?`)) return;
+    try {
+      setError(null);
+      await DeletePermissionProfile(profile.id);
+      await load();
+    } catch (err) {
+      console.error("Failed to delete permission profile:", err);
+      setError(`Failed to delete ${profile.name}: ${err}`);
+    }
+  };
+
+  if (loading) {
+    return (
+      <div className="flex items-center justify-center py-8">
+        <Loader2 className="h-5 w-5 animate-spin text-muted-foreground" />
+      </div>
+    );
+  }
+
+  const modeLabel = (mode: string) =>
+    CLAUDE_PERMISSION_MODES.find(m => m.value === mode)?.label || "Bypass permissions";
+  const sandboxLabel = (sandbox: string) =>
+    CODEX_SANDBOXES.find(s => s.value === sandbox)?.label || "Sandbox setting";
+
+  return (
+    <div className={cn("space-y-4", className)}>
+      {error && <p className="text-sm text-destructive">{error}</p>}
+
+      <div className="space-y-2">
+        <Label>Profile for this project</Label>
+        <Select value={selectedId || NO_PROFILE} onValueChange={handleSelect}>
+          <SelectTrigger className="w-72">
+            <SelectValue />
+          </SelectTrigger>
+          <SelectContent>
+            <SelectItem value={NO_PROFILE}>Default</SelectItem>
+            {profiles.map(profile => (
+              <SelectItem key={profile.id} value={profile.id}>{profile.name}</SelectItem>
+            ))}
+          </SelectContent>
+        </Select>
+      </div>
+
+      <div className="space-y-2">
+        {profiles.map(profile => (
+          <div key={profile.id} className="flex items-start justify-between gap-3 rounded-md border p-3">
+            <div className="min-w-0 space-y-1">
+              <div className="flex items-center gap-2">
+                <span className="text-sm font-medium">{profile.name}</span>
+                {profile.builtin && (
+                  <span className="rounded bg-muted px-1.5 text-[10px] text-muted-foreground">built-in</span>
+                )}
+              </div>
+              {profile.description && (
+                <p className="text-xs text-muted-foreground">{profile.description}</p>
+              )}
+              <p className="text-xs text-muted-foreground">
+                Claude: {modeLabel(profile.claude_permission_mode)}
+                {profile.claude_allowed_tools.length > 0 && ` · allows ${profile.claude_allowed_tools.join(", ")}`}
+                {profile.claude_disallowed_tools.length > 0 && ` · blocks ${profile.claude_disallowed_tools.join(", ")}`}
+                {" — "}Codex: {sandboxLabel(profile.codex_sandbox)}
+                {profile.codex_sandbox === "workspace-write" && (profile.codex_network_access ? ", network" : ", no network")}
+              </p>
+            </div>
+            {!profile.builtin && (
+              <div className="flex shrink-0 gap-1">
+                <Button variant="ghost" size="icon" className="h-7 w-7" onClick={() => startEdit(profile)}>
+                  <Pencil className="h-3.5 w-3.5" />
+                </Button>
+                <Button variant="ghost" size="icon" className="h-7 w-7 text-destructive" onClick={() => handleDelete(profile)}>
+                  <Trash2 className="h-3.5 w-3.5" />
+                </Button>
+              </div>
+            )}
+          </div>
+        ))}
+      </div>
+
+      {editing ? (
+        <div className="space-y-3 rounded-md border p-4">
+          <div className="flex items-center justify-between">
+            <span className="text-sm font-medium">{editing.id ? "Edit Profile" : "New Profile"}</span>
+            <Button variant="ghost" size="icon" className="h-7 w-7" onClick={() => setEditing(null)}>
+              <X className="h-4 w-4" />
+            </Button>
+          </div>
+          <div className="grid grid-cols-2 gap-3">
+            <div className="space-y-1">
+              <Label>Name</Label>
+              <Input value={editing.name || ""} onChange={e => setEditing({ ...editing, name: e.target.value })} />
+            </div>
+            <div className="space-y-1">
+              <Label>Description</Label>
+              <Input
+                value={editing.description || ""}
+                onChange={e => setEditing({ ...editing, description: e.target.value })}
+              />
+            </div>
+            <div className="space-y-1">
+              <Label>Claude permission mode</Label>
+              <Select
+                value={editing.claude_permission_mode || "bypassPermissions"}
+                onValueChange={(value: Profile["claude_permission_mode"]) =>
+                  setEditing({ ...editing, claude_permission_mode: value })}
+              >
+                <SelectTrigger>
+                  <SelectValue />
+                </SelectTrigger>
+                <SelectContent>
+                  {CLAUDE_PERMISSION_MODES.map(m => (
+                    <SelectItem key={m.value} value={m.value}>{m.label}</SelectItem>
+                  ))}
+                </SelectContent>
+              </Select>
+            </div>
+            <div className="space-y-1">
+              <Label>Codex sandbox</Label>
+              <Select
+                value={editing.codex_sandbox || "danger-full-access"}
+                onValueChange={(value: Profile["codex_sandbox"]) => setEditing({ ...editing, codex_sandbox: value })}
+              >
+                <SelectTrigger>
+                  <SelectValue />
+                </SelectTrigger>
+                <SelectContent>
+                  {CODEX_SANDBOXES.map(s => (
+                    <SelectItem key={s.value} value={s.value}>{s.label}</SelectItem>
+                  ))}
+                </SelectContent>
+              </Select>
+            </div>
+            <div className="space-y-1">
+              <Label>Claude allowed tools</Label>
+              <Input
+                value={allowedTools}
+                onChange={e => setAllowedTools(e.target.value)}
+                placeholder="Read, Grep, Bash(git:*)"
+                className="font-mono text-sm"
+              />
+            </div>
+            <div className="space-y-1">
+              <Label>Claude blocked tools</Label>
+              <Input
+                value={disallowedTools}
+                onChange={e => setDisallowedTools(e.target.value)}
+                placeholder="WebFetch, WebSearch"
+                className="font-mono text-sm"
+              />
+            </div>
+          </div>
+          <div className="flex items-center justify-between">
+            <div className="flex items-center gap-2">
+              <Switch
+                checked={editing.codex_network_access ?? true}
+                onCheckedChange={checked => setEditing({ ...editing, codex_network_access: checked })}
+              />
+              <Label className="text-sm">Codex network access</Label>
+            </div>
+            <Button size="sm" onClick={handleSave} disabled={saving || !editing.name?.trim()}>
+              {saving ? <Loader2 className="h-4 w-4 mr-2 animate-spin" /> : <Save className="h-4 w-4 mr-2" />}
+              Save
+            </Button>
+          </div>
+        </div>
+      ) : (
+        <Button variant="outline" size="sm" onClick={() => startEdit(emptyProfile())}>
+          <Plus className="h-4 w-4 mr-2" />
+          New Profile
+        </Button>
+      )}
+    </div>
+  );
+};
@@ -15,8 +15,12 @@ import {
   FolderOpen,
   AlertCircle,
   RefreshCw,
+  Download,
+  Trash2,
+  Pin,
 } from "lucide-react";
 import { Button } from "@/components/ui/button";
+import { Input } from "@/components/ui/input";
 import { Card } from "@/components/ui/card";
 import { Badge } from "@/components/ui/badge";
 import { ScrollArea } from "@/components/ui/scroll-area";
@@ -25,6 +29,8 @@ import {
   type InstalledPlugin,
   type PluginContents,
 } from "@/lib/api";
+import { EventsOn } from "@/lib/rpc-events";
+import type { plugin as pluginTypes } from "@/lib/rpc-client";
 import { cn } from "@/lib/utils";
 
 interface PluginsManagerProps {
@@ -37,9 +43,14 @@ export const PluginsManager: React.FC<PluginsManagerProps> = ({ setToast }) => {
   const [expandedPlugin, setExpandedPlugin] = useState<string | null>(null);
   const [pluginContents, setPluginContents] = useState<Record<string, PluginContents>>({});
   const [loadingContents, setLoadingContents] = useState<string | null>(null);
+  const [installSource, setInstallSource] = useState("");
+  // 正在执行的操作："install" 或插件 ID
+  const [busy, setBusy] = useState<string | null>(null);
+  const [progress, setProgress] = useState<string | null>(null);
 
   useEffect(() => {
     loadPlugins();
+    return EventsOn("plugin:progress", (p: pluginTypes.Progress) => setProgress(p.message));
   }, []);
 
   const loadPlugins = async () => {
@@ -78,6 +89,44 @@ export const PluginsManager: React.FC<PluginsManagerProps> = ({ setToast }) => {
     }
   };
 
+  const runAction = async (key: string, action: () => Promise<unknown>, success: string) => {
+    setBusy(key);
+    setProgress(null);
+    try {
+      await action();
+      setToast({ message: success, type: "success" });
+      setPluginContents((prev) => {
+        const next = { ...prev };
+        delete next[key];
+        return next;
+      });
+      await loadPlugins();
+    } catch (error) {
+      console.error(`Plugin ${key} failed:`, error);
+      setToast({ message: error instanceof Error ? error.message : String(error), type: "error" });
+    } finally {
+      setBusy(null);
+      setProgress(null);
+    }
+  };
+
+  const handleInstall = () => {
+    const source = installSource.trim();
+    if (!source) return;
+    runAction("install", async () => {
+      await api.installPlugin(source);
+      setInstallSource("");
+    }, `Installed ${source}`);
+  };
+
+  const handleUpdate = (plugin: InstalledPlugin) =>
+    runAction(plugin.id, () => api.updatePlugin(plugin.id), `Updated ${plugin.metadata.name}`);
+
+  const handleUninstall = (plugin: InstalledPlugin) => {
+    if (!confirm(`Uninstall plugin "${plugin.metadata.name}"?`)) return;
+    runAction(plugin.id, () => api.uninstallPlugin(plugin.id), `Uninstalled ${plugin.metadata.name}`);
+  };
+
   const formatDate = (dateStr: string) => {
     try {
       return new Date(dateStr).toLocaleDateString(undefined, {
@@ -105,7 +154,7 @@ export const PluginsManager: React.FC<PluginsManagerProps> = ({ setToast }) => {
         <div>
           <h3 className="text-heading-4 mb-2">Installed Plugins</h3>
           <p className="text-body-small text-muted-foreground">
-            Browse Claude Code plugins installed via{" "}
+            Install Claude Code plugins from a marketplace, or via{" "}
             <code className="px-1.5 py-0.5 bg-muted rounded text-xs">/plugins install</code>
           </p>
         </div>
@@ -115,13 +164,36 @@ export const PluginsManager: React.FC<PluginsManagerProps> = ({ setToast }) => {
         </Button>
       </div>
 
+      {/* Install */}
+      <div className="space-y-2">
+        <div className="flex gap-2">
+          <Input
+            placeholder="plugin@marketplace, e.g. name@owner/repo#v1.0.0"
+            value={installSource}
+            onChange={(e) => setInstallSource(e.target.value)}
+            onKeyDown={(e) => e.key === "Enter" && handleInstall()}
+            disabled={busy !== null}
+            className="font-mono text-sm"
+          />
+          <Button onClick={handleInstall} disabled={busy !== null || !installSource.trim()} className="gap-2">
+            {busy === "install" ? <Loader2 className="h-4 w-4 animate-spin" /> : <Download className="h-4 w-4" />}
+            Install
+          </Button>
+        </div>
+        <p className="text-xs text-muted-foreground">
+          {busy && progress
+            ? progress
+            : "The marketplace can be an added marketplace, a GitHub owner/repo, a git URL or a local path. Add #tag, #branch or #commit to pin a version."}
+        </p>
+      </div>
+
       {/* Plugin List */}
       {plugins.length === 0 ? (
         <Card className="p-8 text-center text-muted-foreground">
           <Package className="h-12 w-12 mx-auto mb-4 opacity-50" />
           <p className="font-medium">No plugins installed</p>
           <p className="text-sm mt-2">
-            Install plugins using Claude Code CLI:{" "}
+            Install one above or with the Claude Code CLI:{" "}
             <code className="px-1.5 py-0.5 bg-muted rounded text-xs">/plugins install [name]</code>
           </p>
         </Card>
@@ -155,6 +227,12 @@ export const PluginsManager: React.FC<PluginsManagerProps> = ({ setToast }) => {
                             Local
                           </Badge>
                         )}
+                        {plugin.pinned_ref && (
+                          <Badge variant="outline" className="text-xs gap-1" title="Pinned version">
+                            <Pin className="h-3 w-3" />
+                            {plugin.pinned_ref}
+                          </Badge>
+                        )}
                       </div>
                       <p className="text-sm text-muted-foreground">
                         {plugin.metadata.description || plugin.marketplace || "No description"}
@@ -162,7 +240,7 @@ export const PluginsManager: React.FC<PluginsManagerProps> = ({ setToast }) => {
                     </div>
                   </div>
                   <div className="flex items-center gap-2">
-                    {loadingContents === plugin.id ? (
+                    {loadingContents === plugin.id || busy === plugin.id ? (
                       <Loader2 className="h-4 w-4 animate-spin text-muted-foreground" />
                     ) : expandedPlugin === plugin.id ? (
                       <ChevronDown className="h-5 w-5 text-muted-foreground" />
@@ -216,6 +294,33 @@ export const PluginsManager: React.FC<PluginsManagerProps> = ({ setToast }) => {
                           </div>
                         </div>
 
+                        {/* Actions */}
+                        <div className="flex gap-2">
+                          <Button
+                            variant="outline"
+                            size="sm"
+                            onClick={() => handleUpdate(plugin)}
+                            disabled={busy !== null}
+                            className="gap-2"
+                          >
+                            <RefreshCw className="h-4 w-4" />
+                            Update
+                          </Button>
+                          <Button
+                            variant="outline"
+                            size="sm"
+                            onClick={() => handleUninstall(plugin)}
+                            disabled={busy !== null}
+                            className="gap-2 text-destructive hover:text-destructive"
+                          >
+                            <Trash2 className="h-4 w-4" />
+                            Uninstall
+                          </Button>
+                          {busy === plugin.id && progress && (
+                            <span className="self-center text-xs text-muted-foreground">{progress}</span>
+                          )}
+                        </div>
+
                         {/* Keywords */}
                         {plugin.metadata?.keywords && plugin.metadata.keywords.length > 0 && (
                           <div className="flex flex-wrap gap-1">
@@ -283,8 +388,9 @@ export const PluginsManager: React.FC<PluginsManagerProps> = ({ setToast }) => {
             <p className="text-xs font-medium">About Claude Code Plugins</p>
             <ul className="text-xs text-muted-foreground space-y-0.5">
               <li>
-                Plugins are installed via Claude Code CLI:{" "}
-                <code className="px-1 py-0.5 bg-background rounded">/plugins install [name]</code>
+                Plugins installed here or via{" "}
+                <code className="px-1 py-0.5 bg-background rounded">/plugins install [name]</code>{" "}
+                are shared with the Claude Code CLI
               </li>
               <li>
                 Plugin commands use format:{" "}
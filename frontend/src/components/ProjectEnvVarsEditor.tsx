@@ -0,0 +1,153 @@
+import React, { useState, useEffect } from "react";
+import { Loader2, Plus, Save, Trash2 } from "lucide-react";
+import { Button } from "@/components/ui/button";
+import { Input } from "@/components/ui/input";
+import { Label } from "@/components/ui/label";
+import { Switch } from "@/components/ui/switch";
+import { ListProjectEnvVars, SaveProjectEnvVar, DeleteProjectEnvVar, type database } from "@/lib/rpc-client";
+import { cn } from "@/lib/utils";
+
+interface ProjectEnvVarsEditorProps {
+  projectPath: string;
+  onSaved?: (message: string) => void;
+  className?: string;
+}
+
+// 编辑中的变量；新变量的 id 为 0，key 用于区分多个未保存的新变量
+type EnvVarRow = database.ProjectEnvVar & { key: string; dirty?: boolean };
+
+const toRow = (v: database.ProjectEnvVar): EnvVarRow => ({ ...v, key: String(v.id) });
+
+/**
+ * 项目环境变量，注入到该项目的会话、终端、命令和 Actions 中
+ */
+export const ProjectEnvVarsEditor: React.FC<ProjectEnvVarsEditorProps> = ({ projectPath, onSaved, className }) => {
+  const [rows, setRows] = useState<EnvVarRow[]>([]);
+  const [loading, setLoading] = useState(true);
+  const [error, setError] = useState<string | null>(null);
+
+  const load = async () => {
+    try {
+      setLoading(true);
+      setError(null);
+      setRows((await ListProjectEnvVars(projectPath)).map(toRow));
+    } catch (err) {
+      console.error("Failed to load environment variables:", err);
+      setError("Failed to load environment variables");
+    } finally {
+      setLoading(false);
+    }
+  };
+
+  useEffect(() => {
+    load();
+  }, [projectPath]);
+
+  const update = (key: string, changes: Partial<EnvVarRow>) => {
+    setRows(prev => prev.map(r => (r.key === key ? { ...r, ...changes, dirty: true } : r)));
+  };
+
+  const handleAdd = () => {
+    setRows(prev => [
+      ...prev,
+      { id: 0, key: `new-${Date.now()}`, project_path: projectPath, name: "", value: "", secret: false, updated_at: 0, dirty: true }
+    ]);
+  };
+
+  const handleSave = async (row: EnvVarRow) => {
+    try {
+      setError(null);
+      const saved = await SaveProjectEnvVar({
+        id: row.id,
+        project_path: projectPath,
+        name: row.name.trim(),
+        value: row.value,
+        secret: row.secret
+      });
+      setRows(prev => prev.map(r => (r.key === row.key ? toRow(saved) : r)));
+      onSaved?.(`Saved ${saved.name}`);
+    } catch (err) {
+      console.error("Failed to save environment variable:", err);
+      setError(`Failed to save ${row.name || "variable"}: ${err}`);
+    }
+  };
+
+  const handleDelete = async (row: EnvVarRow) => {
+    if (row.id === 0) {
+      setRows(prev => prev.filter(r => r.key !== row.key));
+      return;
+    }
+    if (!confirm(`Delete environment variable ${row.name}?`)) return;
+    try {
+      await DeleteProjectEnvVar(row.id);
+      setRows(prev => prev.filter(r => r.key !== row.key));
+    } catch (err) {
+      console.error("Failed to delete environment variable:", err);
+      setError(`Failed to delete ${row.name}`);
+    }
+  };
+
+  if (loading) {
+    return (
+      <div className="flex items-center justify-center py-8">
+        <Loader2 className="h-5 w-5 animate-spin text-muted-foreground" />
+      </div>
+    );
+  }
+
+  return (
+    <div className={cn("space-y-3", className)}>
+      {error && <p className="text-sm text-destructive">{error}</p>}
+
+      {rows.length === 0 ? (
+        <p className="text-sm text-muted-foreground py-4 text-center">
+          No environment variables for this project yet.
+        </p>
+      ) : (
+        <div className="space-y-2">
+          {rows.map(row => (
+            <div key={row.key} className="flex items-center gap-2">
+              <Input
+                className="w-56 font-mono text-sm"
+                value={row.name}
+                onChange={e => update(row.key, { name: e.target.value })}
+                placeholder="NAME"
+              />
+              <Input
+                className="flex-1 font-mono text-sm"
+                type={row.secret ? "password" : "text"}
+                value={row.value}
+                onChange={e => update(row.key, { value: e.target.value })}
+                placeholder="value"
+              />
+              <div className="flex items-center gap-2 px-2">
+                <Switch
+                  id={`secret-${row.key}`}
+                  checked={row.secret}
+                  onCheckedChange={checked => update(row.key, { secret: checked })}
+                />
+                <Label htmlFor={`secret-${row.key}`} className="text-xs">Secret</Label>
+              </div>
+              <Button
+                size="sm"
+                variant="ghost"
+                disabled={!row.dirty || !row.name.trim()}
+                onClick={() => handleSave(row)}
+              >
+                <Save className="h-4 w-4" />
+              </Button>
+              <Button size="sm" variant="ghost" onClick={() => handleDelete(row)}>
+                <Trash2 className="h-4 w-4 text-destructive" />
+              </Button>
+            </div>
+          ))}
+        </div>
+      )}
+
+      <Button size="sm" variant="outline" onClick={handleAdd}>
+        <Plus className="h-4 w-4 mr-2" />
+        Add Variable
+      </Button>
+    </div>
+  );
+};
@@ -823,6 +823,20 @@ export const ProjectList: React.FC<ProjectListProps> = ({
       return;
     }
 
+    // With workspace protection on, removal needs an explicit confirmation
+    let override = false;
+    if (await api.getWorkspaceProtectionEnabled().catch(() => false)) {
+      if (!window.confirm(`Workspace protection is on. Remove workspace "${workspaceId}" anyway?`)) {
+        setPendingDeleteWorkspaces(prev => {
+          const newSet = new Set(prev);
+          newSet.delete(workspaceId);
+          return newSet;
+        });
+        return;
+      }
+      override = true;
+    }
+
     try {
       // Mark workspace as being removed
       setRemovingWorkspaces(prev => new Set(prev).add(workspaceId));
@@ -841,7 +855,7 @@ export const ProjectList: React.FC<ProjectListProps> = ({
       tabsToClose.forEach(tab => removeTab(tab.id));
 
       // Remove the workspace
-      await api.removeWorkspace(workspaceId);
+      await api.removeWorkspace(workspaceId, override);
 
       // Clear workspace todos from context
       clearWorkspace(workspacePath);
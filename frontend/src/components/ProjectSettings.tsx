@@ -6,6 +6,9 @@ import React, { useState, useEffect } from 'react';
 import { HooksEditor } from '@/components/HooksEditor';
 import { SlashCommandsManager } from '@/components/SlashCommandsManager';
 import { ProviderApiSelector } from '@/components/ProviderApiSelector';
+import { ProjectEnvVarsEditor } from '@/components/ProjectEnvVarsEditor';
+import { SessionSystemPromptEditor } from '@/components/SessionSystemPromptEditor';
+import { PermissionProfilesEditor } from '@/components/PermissionProfilesEditor';
 import { api } from '@/lib/api';
 import {
   AlertTriangle,
@@ -15,7 +18,10 @@ import {
   GitBranch,
   Shield,
   Command,
-  Globe
+  Globe,
+  Variable,
+  ScrollText,
+  ShieldCheck
 } from 'lucide-react';
 import { Button } from '@/components/ui/button';
 import { Card } from '@/components/ui/card';
@@ -114,6 +120,18 @@ export const ProjectSettings: React.FC<ProjectSettingsProps> = ({
                 <Globe className="h-4 w-4" />
                 Provider APIs
               </TabsTrigger>
+              <TabsTrigger value="environment" className="gap-2">
+                <Variable className="h-4 w-4" />
+                Environment
+              </TabsTrigger>
+              <TabsTrigger value="system-prompt" className="gap-2">
+                <ScrollText className="h-4 w-4" />
+                System Prompt
+              </TabsTrigger>
+              <TabsTrigger value="permissions" className="gap-2">
+                <ShieldCheck className="h-4 w-4" />
+                Permissions
+              </TabsTrigger>
               <TabsTrigger value="commands" className="gap-2">
                 <Command className="h-4 w-4" />
                 Slash Commands
@@ -164,6 +182,68 @@ export const ProjectSettings: React.FC<ProjectSettingsProps> = ({
               </Card>
             </TabsContent>
 
+            <TabsContent value="environment" className="space-y-6">
+              <Card className="p-6">
+                <div className="space-y-4">
+                  <div>
+                    <h3 className="text-lg font-semibold mb-2">Environment Variables</h3>
+                    <p className="text-sm text-muted-foreground mb-4">
+                      Added to the environment of AI sessions, terminals, commands and actions run in this
+                      project, e.g. proxy settings or tokens. They are stored on this machine only; secret
+                      values are never shown again once saved.
+                    </p>
+                  </div>
+
+                  <ProjectEnvVarsEditor
+                    projectPath={project.path}
+                    onSaved={(message) => setToast({ message, type: 'success' })}
+                  />
+                </div>
+              </Card>
+            </TabsContent>
+
+            <TabsContent value="system-prompt" className="space-y-6">
+              <Card className="p-6">
+                <div className="space-y-4">
+                  <div>
+                    <h3 className="text-lg font-semibold mb-2">Session System Prompt</h3>
+                    <p className="text-sm text-muted-foreground mb-4">
+                      Added to the system prompt of Claude and Codex sessions started in this project, replacing
+                      the global session system prompt. Stored in
+                      <code className="mx-1 px-2 py-1 bg-muted rounded text-xs">.claude/system-prompt.md</code>
+                    </p>
+                  </div>
+
+                  <SessionSystemPromptEditor
+                    scope="project"
+                    projectPath={project.path}
+                    onSaved={(message) => setToast({ message, type: 'success' })}
+                  />
+                </div>
+              </Card>
+            </TabsContent>
+
+            <TabsContent value="permissions" className="space-y-6">
+              <Card className="p-6">
+                <div className="space-y-4">
+                  <div>
+                    <h3 className="text-lg font-semibold mb-2">Permission Profile</h3>
+                    <p className="text-sm text-muted-foreground mb-4">
+                      Limits what Claude and Codex sessions started in this project may do. Claude gets the
+                      profile's permission mode and tool lists, Codex its sandbox. The profile picked in the
+                      prompt input overrides this one until ropcode restarts. By default Claude runs with full
+                      access and Codex uses the sandbox from Settings. Gemini sessions are not affected.
+                    </p>
+                  </div>
+
+                  <PermissionProfilesEditor
+                    projectPath={project.path}
+                    onSaved={(message) => setToast({ message, type: 'success' })}
+                  />
+                </div>
+              </Card>
+            </TabsContent>
+
             <TabsContent value="commands" className="space-y-6">
               <Card className="p-6">
                 <div className="space-y-4">
@@ -9,6 +9,10 @@ import {
   Globe,
   Key,
   Star,
+  Search,
+  ArrowUp,
+  ArrowDown,
+  Shuffle,
 } from "lucide-react";
 import { Button } from "@/components/ui/button";
 import { Input } from "@/components/ui/input";
@@ -27,9 +31,11 @@ import {
   api,
   type ProviderApiConfig,
 } from "@/lib/api";
+import { GetProviderFailoverChain, SetProviderFailoverChain } from "@/lib/rpc-client";
 import { cn } from "@/lib/utils";
 import { Toast, ToastContainer } from "@/components/ui/toast";
 import { ModelManager } from "./ModelManager";
+import { ModelDiscoveryDialog } from "./ModelDiscoveryDialog";
 
 interface ProviderApiManagerProps {
   className?: string;
@@ -65,6 +71,11 @@ export const ProviderApiManager: React.FC<ProviderApiManagerProps> = ({
     is_default: false,
   });
   const [saving, setSaving] = useState(false);
+  const [discoveryConfig, setDiscoveryConfig] = useState<ProviderApiConfig | null>(null);
+  // Bumped to make ModelManager reload after models are added here
+  const [modelsVersion, setModelsVersion] = useState(0);
+  // Failover chain of each provider, config IDs in order
+  const [failoverChains, setFailoverChains] = useState<Record<string, string[]>>({});
   const [localToast, setLocalToast] = useState<{ message: string; type: 'success' | 'error' } | null>(null);
 
   const showToast = (message: string, type: 'success' | 'error') => {
@@ -84,6 +95,9 @@ export const ProviderApiManager: React.FC<ProviderApiManagerProps> = ({
       setLoading(true);
       const loadedConfigs = await api.listProviderApiConfigs();
       setConfigs(loadedConfigs);
+      const providerIds = Array.from(new Set(loadedConfigs.map((c) => c.provider_id)));
+      const chains = await Promise.all(providerIds.map((id) => GetProviderFailoverChain(id).catch(() => [] as string[])));
+      setFailoverChains(Object.fromEntries(providerIds.map((id, i) => [id, chains[i] || []])));
     } catch (error) {
       console.error("Failed to load provider API configs:", error);
       showToast("Failed to load API configurations", "error");
@@ -194,6 +208,50 @@ export const ProviderApiManager: React.FC<ProviderApiManagerProps> = ({
     }
   };
 
+  const saveFailoverChain = async (providerId: string, chain: string[]) => {
+    try {
+      await SetProviderFailoverChain(providerId, chain);
+      // A single config is no chain
+      setFailoverChains((prev) => ({ ...prev, [providerId]: chain.length < 2 ? [] : chain }));
+    } catch (error) {
+      console.error("Failed to save failover chain:", error);
+      showToast("Failed to save failover order", "error");
+    }
+  };
+
+  // Position of a config in its provider's failover chain, -1 when not in it
+  const failoverPosition = (config: ProviderApiConfig) =>
+    config.id ? (failoverChains[config.provider_id] || []).indexOf(config.id) : -1;
+
+  const toggleFailover = (config: ProviderApiConfig) => {
+    if (!config.id) return;
+    const chain = failoverChains[config.provider_id] || [];
+    if (chain.includes(config.id)) {
+      saveFailoverChain(config.provider_id, chain.filter((id) => id !== config.id));
+      return;
+    }
+    // The first config added starts the chain together with the default
+    const base = chain.length === 0
+      ? configs
+        .filter((c) => c.provider_id === config.provider_id && c.is_default && c.id && c.id !== config.id)
+        .map((c) => c.id as string)
+      : chain;
+    if (base.length === 0) {
+      showToast("Add another configuration of this provider to the failover order first", "error");
+      return;
+    }
+    saveFailoverChain(config.provider_id, [...base, config.id]);
+  };
+
+  const moveFailover = (config: ProviderApiConfig, offset: number) => {
+    const chain = [...(failoverChains[config.provider_id] || [])];
+    const from = failoverPosition(config);
+    const to = from + offset;
+    if (from < 0 || to < 0 || to >= chain.length) return;
+    [chain[from], chain[to]] = [chain[to], chain[from]];
+    saveFailoverChain(config.provider_id, chain);
+  };
+
   // Group configs by provider
   const configsByProvider = configs.reduce((acc, config) => {
     if (!acc[config.provider_id]) {
@@ -237,6 +295,11 @@ export const ProviderApiManager: React.FC<ProviderApiManagerProps> = ({
           {Object.entries(configsByProvider).map(([providerId, providerConfigs]) => (
             <div key={providerId} className="space-y-3">
               <h4 className="text-label capitalize">{providerId} API Configurations</h4>
+              {(failoverChains[providerId] || []).length > 0 && (
+                <p className="text-caption text-muted-foreground">
+                  Sessions that fail with an authentication or rate-limit error retry on the next configuration in the failover order.
+                </p>
+              )}
               <div className="grid gap-3">
                 {providerConfigs.map((config) => (
                   <motion.div
@@ -264,6 +327,12 @@ export const ProviderApiManager: React.FC<ProviderApiManagerProps> = ({
                                 Built-in
                               </div>
                             )}
+                            {failoverPosition(config) >= 0 && (
+                              <div className="flex items-center gap-1 px-2 py-0.5 rounded-full bg-muted text-muted-foreground text-xs">
+                                <Shuffle className="h-3 w-3" />
+                                Failover #{failoverPosition(config) + 1}
+                              </div>
+                            )}
                           </div>
 
                           {config.base_url && (
@@ -295,6 +364,50 @@ export const ProviderApiManager: React.FC<ProviderApiManagerProps> = ({
                               Set Default
                             </Button>
                           )}
+                          {failoverPosition(config) >= 0 && (
+                            <>
+                              <Button
+                                variant="ghost"
+                                size="icon"
+                                onClick={() => moveFailover(config, -1)}
+                                disabled={failoverPosition(config) === 0}
+                                className="h-8 w-8"
+                                title="Move up in failover order"
+                              >
+                                <ArrowUp className="h-4 w-4" />
+                              </Button>
+                              <Button
+                                variant="ghost"
+                                size="icon"
+                                onClick={() => moveFailover(config, 1)}
+                                disabled={failoverPosition(config) === failoverChains[providerId].length - 1}
+                                className="h-8 w-8"
+                                title="Move down in failover order"
+                              >
+                                <ArrowDown className="h-4 w-4" />
+                              </Button>
+                            </>
+                          )}
+                          {providerConfigs.length > 1 && (
+                            <Button
+                              variant="ghost"
+                              size="icon"
+                              onClick={() => toggleFailover(config)}
+                              className={cn("h-8 w-8", failoverPosition(config) >= 0 && "text-primary")}
+                              title={failoverPosition(config) >= 0 ? "Remove from failover order" : "Add to failover order"}
+                            >
+                              <Shuffle className="h-4 w-4" />
+                            </Button>
+                          )}
+                          <Button
+                            variant="ghost"
+                            size="icon"
+                            onClick={() => setDiscoveryConfig(config)}
+                            className="h-8 w-8"
+                            title="Discover models"
+                          >
+                            <Search className="h-4 w-4" />
+                          </Button>
                           <Button
                             variant="ghost"
                             size="icon"
@@ -439,11 +552,20 @@ export const ProviderApiManager: React.FC<ProviderApiManagerProps> = ({
         </DialogContent>
       </Dialog>
 
+      <ModelDiscoveryDialog
+        config={discoveryConfig}
+        onClose={() => setDiscoveryConfig(null)}
+        onImported={(count) => {
+          showToast(count === 0 ? "No models added" : `Added ${count} model${count === 1 ? "" : "s"}`, "success");
+          setModelsVersion((v) => v + 1);
+        }}
+      />
+
       {/* Separator */}
       <div className="border-t border-border my-8" />
 
       {/* Model Configuration Section */}
-      <ModelManager setToast={setToast || ((t) => setLocalToast(t))} />
+      <ModelManager key={modelsVersion} setToast={setToast || ((t) => setLocalToast(t))} />
 
       {/* Toast Notification (if not using parent toast) */}
       {!setToast && (
@@ -0,0 +1,174 @@
+import React, { useState, useEffect, useRef, useCallback } from "react";
+import { CheckCircle2, Download, Loader2, XCircle } from "lucide-react";
+import { Button } from "@/components/ui/button";
+import { Label } from "@/components/ui/label";
+import {
+  Select,
+  SelectContent,
+  SelectItem,
+  SelectTrigger,
+  SelectValue,
+} from "@/components/ui/select";
+import { listen } from "@/lib/api";
+import {
+  ListInstallMethods,
+  InstallProviderBinary,
+  ListProviderInstallations,
+  SwitchProviderInstallation,
+  GetProviderCapabilities,
+  type clicompat,
+  type cliinstall,
+  type jobs,
+  type main,
+} from "@/lib/rpc-client";
+
+const PROVIDERS = [
+  { id: "claude", name: "Claude Code" },
+  { id: "codex", name: "Codex" },
+  { id: "gemini", name: "Gemini CLI" },
+];
+
+/** A provider CLI's installations, their health, and installing it */
+const ProviderInstall: React.FC<{ provider: string; name: string }> = ({ provider, name }) => {
+  const [installations, setInstallations] = useState<main.ProviderInstallation[] | null>(null);
+  const [capabilities, setCapabilities] = useState<clicompat.Capabilities | null>(null);
+  const [methods, setMethods] = useState<cliinstall.Method[]>([]);
+  const [method, setMethod] = useState("");
+  const [jobKey, setJobKey] = useState<string | null>(null);
+  const [output, setOutput] = useState("");
+  const [result, setResult] = useState<string | null>(null);
+  const jobKeyRef = useRef<string | null>(null);
+
+  const refresh = useCallback(() => {
+    ListProviderInstallations(provider).then(setInstallations).catch(() => setInstallations([]));
+    GetProviderCapabilities(provider).then(setCapabilities).catch(() => setCapabilities(null));
+  }, [provider]);
+
+  useEffect(() => {
+    refresh();
+    ListInstallMethods(provider).then((list) => {
+      setMethods(list);
+      if (list.length > 0) setMethod(list[0].id);
+    }).catch(() => {});
+  }, [provider, refresh]);
+
+  useEffect(() => {
+    const unlistenOutput = listen("job-output", (event: jobs.Output) => {
+      if (event.job_key !== jobKeyRef.current || event.output_type === "exit") return;
+      setOutput(prev => (prev + event.content).slice(-20000));
+    });
+    const unlistenFinished = listen("provider-install:finished", (event: main.ProviderInstallFinishedEvent) => {
+      if (event.job_key !== jobKeyRef.current) return;
+      jobKeyRef.current = null;
+      setJobKey(null);
+      if (event.status !== "succeeded") {
+        setResult(`Install ${event.status}`);
+      } else if (event.path) {
+        setResult(`Installed ${event.version || name} at ${event.path}`);
+      } else {
+        setResult("Installed, but the CLI was not found; restart ropcode or pick it in the installation list");
+      }
+      refresh();
+    });
+    return () => {
+      unlistenOutput();
+      unlistenFinished();
+    };
+  }, [name, refresh]);
+
+  const install = async () => {
+    setOutput("");
+    setResult(null);
+    try {
+      const key = await InstallProviderBinary(provider, method);
+      jobKeyRef.current = key;
+      setJobKey(key);
+    } catch (err) {
+      setResult(String(err));
+    }
+  };
+
+  const use = async (path: string) => {
+    setResult(null);
+    try {
+      await SwitchProviderInstallation(provider, path);
+      refresh();
+    } catch (err) {
+      setResult(String(err));
+    }
+  };
+
+  const selected = methods.find(m => m.id === method);
+
+  return (
+    <div className="space-y-2">
+      <div className="flex items-center justify-between gap-2">
+        <Label>{name}</Label>
+        <div className="flex items-center gap-2">
+          <Select value={method} onValueChange={setMethod} disabled={jobKey !== null}>
+            <SelectTrigger className="w-44">
+              <SelectValue />
+            </SelectTrigger>
+            <SelectContent>
+              {methods.map(m => (
+                <SelectItem key={m.id} value={m.id}>{m.label}</SelectItem>
+              ))}
+            </SelectContent>
+          </Select>
+          <Button size="sm" variant="outline" onClick={install} disabled={!method || jobKey !== null}>
+            {jobKey ? <Loader2 className="h-3 w-3 mr-1.5 animate-spin" /> : <Download className="h-3 w-3 mr-1.5" />}
+            Install
+          </Button>
+        </div>
+      </div>
+      {installations && installations.length === 0 && (
+        <p className="text-caption text-muted-foreground">Not installed</p>
+      )}
+      {installations?.map(installation => (
+        <div key={installation.path} className="flex items-center justify-between gap-2 text-xs">
+          <div className="flex items-center gap-1.5 min-w-0">
+            {installation.error
+              ? <XCircle className="h-3 w-3 shrink-0 text-destructive" />
+              : <CheckCircle2 className="h-3 w-3 shrink-0 text-green-500" />}
+            <span className="font-mono truncate" title={installation.path}>{installation.path}</span>
+            <span className="text-muted-foreground shrink-0">
+              {installation.installation_type}{installation.version ? ` · ${installation.version}` : ""}
+            </span>
+          </div>
+          {installation.active
+            ? <span className="text-muted-foreground shrink-0">In use</span>
+            : (
+              <Button size="sm" variant="ghost" className="h-6 px-2 text-xs" onClick={() => use(installation.path)}
+                disabled={!!installation.error}>
+                Use
+              </Button>
+            )}
+        </div>
+      ))}
+      {capabilities?.features.filter(f => !f.supported).map(feature => (
+        <p key={feature.id} className="text-xs text-destructive">
+          {feature.description} ({feature.flag}) needs {feature.min_version} or newer; update to use it
+        </p>
+      ))}
+      {selected && <p className="text-caption text-muted-foreground font-mono">{selected.command}</p>}
+      {output && (
+        <pre className="max-h-40 overflow-auto rounded bg-muted p-2 text-xs font-mono whitespace-pre-wrap">{output}</pre>
+      )}
+      {result && <p className="text-xs text-muted-foreground">{result}</p>}
+    </div>
+  );
+};
+
+/** The provider CLIs sessions run: what is installed, which is used, and installing them */
+export const ProviderInstallSettings: React.FC = () => (
+  <div className="space-y-4">
+    <div>
+      <h3 className="text-heading-4 mb-2">Provider CLIs</h3>
+      <p className="text-body-small text-muted-foreground">
+        The installations found of each CLI and the one sessions use. Installing with the official installer, npm
+        or Homebrew switches sessions to the new installation unless another one was chosen.
+      </p>
+    </div>
+    {PROVIDERS.map(p => <ProviderInstall key={p.id} provider={p.id} name={p.name} />)}
+  </div>
+);
@@ -1,8 +1,11 @@
 import { useState, useEffect } from 'react';
+import { Loader2, PlugZap } from 'lucide-react';
+import { Button } from '@/components/ui/button';
 import { Input } from '@/components/ui/input';
 import { Label } from '@/components/ui/label';
 import { Switch } from '@/components/ui/switch';
 import { api } from '@/lib/api';
+import type { netproxy } from '@/lib/rpc-client';
 
 export interface ProxySettings {
   http_proxy: string | null;
@@ -33,10 +36,27 @@ export function ProxySettings({ setToast, onChange }: ProxySettingsProps) {
     enabled: false,
   });
 
+  const [testing, setTesting] = useState(false);
+  const [testResult, setTestResult] = useState<netproxy.TestResult | null>(null);
+
   useEffect(() => {
     loadSettings();
   }, []);
 
+  // Test the settings as entered, before they are saved
+  const testConnection = async () => {
+    setTesting(true);
+    setTestResult(null);
+    try {
+      setTestResult(await api.testProxy(settings, ''));
+    } catch (error) {
+      console.error('Failed to test proxy:', error);
+      setTestResult({ ok: false, url: '', proxy: '', duration_ms: 0, error: String(error) });
+    } finally {
+      setTesting(false);
+    }
+  };
+
   // Save settings function
   const saveSettings = async () => {
     try {
@@ -96,7 +116,7 @@ export function ProxySettings({ setToast, onChange }: ProxySettingsProps) {
       <div>
         <h3 className="text-lg font-medium">Proxy Settings</h3>
         <p className="text-sm text-muted-foreground">
-          Configure proxy settings for Claude API requests
+          Configure the proxy used by provider CLIs, GitHub fetches, update checks and MCP servers
         </p>
       </div>
 
@@ -105,7 +125,7 @@ export function ProxySettings({ setToast, onChange }: ProxySettingsProps) {
           <div className="space-y-0.5">
             <Label htmlFor="proxy-enabled">Enable Proxy</Label>
             <p className="text-sm text-muted-foreground">
-              Use proxy for all Claude API requests
+              Route outbound requests through the proxy
             </p>
           </div>
           <Switch
@@ -165,6 +185,20 @@ export function ProxySettings({ setToast, onChange }: ProxySettingsProps) {
               Proxy URL to use for all protocols if protocol-specific proxies are not set
             </p>
           </div>
+
+          <div className="flex items-center gap-3">
+            <Button variant="outline" size="sm" onClick={testConnection} disabled={!settings.enabled || testing}>
+              {testing ? <Loader2 className="h-4 w-4 mr-2 animate-spin" /> : <PlugZap className="h-4 w-4 mr-2" />}
+              Test Connection
+            </Button>
+            {testResult && (
+              <p className={testResult.ok ? 'text-xs text-green-600 dark:text-green-400' : 'text-xs text-destructive'}>
+                {testResult.ok
+                  ? `Reached ${testResult.url} (HTTP ${testResult.status_code}) in ${testResult.duration_ms} ms${testResult.proxy ? ` via ${testResult.proxy}` : ' directly'}`
+                  : `Failed: ${testResult.error}`}
+              </p>
+            )}
+          </div>
         </div>
 
       </div>
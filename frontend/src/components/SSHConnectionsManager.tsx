@@ -1,6 +1,7 @@
 import React from 'react';
 import { Button } from '@/components/ui/button';
-import { api, SSHAuthMethod } from '@/lib/api';
+import { api } from '@/lib/api';
+import type { ssh } from '@/lib/rpc-client';
 
 interface SSHConnectionsManagerProps {
   isOpen: boolean;
@@ -13,14 +14,22 @@ export const SSHConnectionsManager: React.FC<SSHConnectionsManagerProps> = ({ is
   const [editing, setEditing] = React.useState<any|null>(null);
   const [error, setError] = React.useState<string|null>(null);
   const [testing, setTesting] = React.useState(false);
+  const [agent, setAgent] = React.useState<ssh.AgentStatus|null>(null);
 
   const load = async () => {
     try { setList(await api.listGlobalSshConnections()); } catch {}
   };
   React.useEffect(()=>{ if (isOpen) load(); }, [isOpen]);
 
+  const loadAgent = async () => {
+    try { setAgent(await api.getSshAgentStatus()); } catch {}
+  };
+
   if (!isOpen) return null;
 
+  const authLabel = (item: ssh.SshConnection) =>
+    item.auth_method==='password' ? 'Password' : item.auth_method==='agent' ? 'SSH Agent' : 'SSH Key';
+
   const save = async () => {
     if (!editing) return;
 
@@ -30,28 +39,30 @@ export const SSHConnectionsManager: React.FC<SSHConnectionsManagerProps> = ({ is
       return;
     }
 
-    if (editing.authType === 'password' && !editing.password) {
+    if (editing.authType === 'password' && !editing.password && !editing.has_password) {
       setError('Password is required');
       return;
     }
 
-    if (editing.authType === 'privateKey' && !editing.keyPath) {
-      setError('Key path is required');
-      return;
-    }
-
-    const authMethod: SSHAuthMethod = editing.authType==='password'
-      ? { type:'password', password: editing.password||'' }
-      : { type:'privateKey', keyPath: editing.keyPath||'~/.ssh/id_rsa', passphrase: editing.passphrase||undefined };
+    const conn: ssh.SshConnection = {
+      name: editing.name,
+      host: editing.host,
+      port: Number(editing.port)||22,
+      user: editing.username,
+      auth_method: editing.authType==='password' ? 'password' : editing.authType==='agent' ? 'agent' : 'key',
+      key_path: editing.authType==='privateKey' ? (editing.keyPath||undefined) : undefined,
+      password: editing.authType==='password' ? (editing.password||undefined) : undefined,
+      proxy_jump: editing.proxyJump||undefined,
+      forward_agent: !!editing.forwardAgent,
+      host_key_checking: editing.host_key_checking,
+    };
 
     try {
-      await api.addGlobalSshConnection({
-        name: editing.name,
-        host: editing.host,
-        port: Number(editing.port)||22,
-        username: editing.username,
-        auth_method: authMethod,
-      });
+      if (editing.original) {
+        await api.updateGlobalSshConnection(editing.original, conn);
+      } else {
+        await api.addGlobalSshConnection(conn);
+      }
       await load();
       setEditing(null);
       setError(null);
@@ -66,13 +77,8 @@ export const SSHConnectionsManager: React.FC<SSHConnectionsManagerProps> = ({ is
   const test = async (item: any) => {
     setTesting(true); setError(null);
     try {
-      // Use the authMethod directly from the saved connection
-      await api.testSshConnection({
-        host: item.host,
-        port: item.port,
-        username: item.username,
-        authMethod: item.auth_method
-      });
+      // Saved passwords are looked up in the keychain by connection name
+      await api.testSshConnection(item);
       setError('Connection successful');
     } catch (e:any) {
       setError(typeof e==='string'?e:(e?.message||'Connection failed'));
@@ -98,10 +104,10 @@ export const SSHConnectionsManager: React.FC<SSHConnectionsManagerProps> = ({ is
                 <div key={item.name} className="border rounded p-3 flex items-center gap-3">
                   <div className="flex-1">
                     <div className="font-medium text-sm">{item.name}</div>
-                    <div className="text-xs text-muted-foreground">{item.username}@{item.host}:{item.port} · {item.auth_method?.type==='password'?'Password':'SSH Key'}</div>
+                    <div className="text-xs text-muted-foreground">{item.user}@{item.host}:{item.port} · {authLabel(item)}{item.proxy_jump ? ` · via ${item.proxy_jump}` : ''}</div>
                   </div>
                   <Button size="sm" variant="outline" onClick={()=>test(item)} disabled={testing}>Test</Button>
-                  <Button size="sm" variant="outline" onClick={()=>setEditing({ ...item, authType: item.auth_method?.type==='password'?'password':'privateKey', password: item.auth_method?.password, keyPath: item.auth_method?.keyPath, passphrase: item.auth_method?.passphrase })}>Edit</Button>
+                  <Button size="sm" variant="outline" onClick={()=>setEditing({ ...item, original: item.name, username: item.user, authType: item.auth_method==='password'?'password':item.auth_method==='agent'?'agent':'privateKey', keyPath: item.key_path, proxyJump: item.proxy_jump, forwardAgent: item.forward_agent })}>Edit</Button>
                   <Button size="sm" variant="destructive" onClick={()=>del(item.name)}>Delete</Button>
                 </div>
               ))}
@@ -122,15 +128,27 @@ export const SSHConnectionsManager: React.FC<SSHConnectionsManagerProps> = ({ is
                 <label className="flex items-center gap-2 text-sm">
                   <input type="radio" checked={editing.authType==='privateKey'} onChange={()=>setEditing({...editing, authType:'privateKey'})} /> SSH Key
                 </label>
+                <label className="flex items-center gap-2 text-sm">
+                  <input type="radio" checked={editing.authType==='agent'} onChange={()=>{ setEditing({...editing, authType:'agent'}); loadAgent(); }} /> SSH Agent
+                </label>
               </div>
               {editing.authType==='password' ? (
-                <input className="w-full px-2 py-1.5 border rounded text-sm" type="password" placeholder="Password" value={editing.password||''} onChange={(e)=>setEditing({...editing, password:e.target.value})} />
-              ) : (
-                <div className="grid grid-cols-2 gap-2">
-                  <input className="px-2 py-1.5 border rounded text-sm" placeholder="~/.ssh/id_rsa" value={editing.keyPath||''} onChange={(e)=>setEditing({...editing, keyPath:e.target.value})} />
-                  <input className="px-2 py-1.5 border rounded text-sm" type="password" placeholder="Passphrase (optional)" value={editing.passphrase||''} onChange={(e)=>setEditing({...editing, passphrase:e.target.value})} />
+                <input className="w-full px-2 py-1.5 border rounded text-sm" type="password" placeholder={editing.has_password ? 'Stored in keychain (leave empty to keep)' : 'Password'} value={editing.password||''} onChange={(e)=>setEditing({...editing, password:e.target.value})} />
+              ) : editing.authType==='agent' ? (
+                <div className="text-xs text-muted-foreground">
+                  {!agent ? 'Checking ssh-agent…'
+                    : !agent.available ? `No ssh-agent available${agent.error ? `: ${agent.error}` : ''}`
+                    : `ssh-agent${agent.forwarded ? ' (forwarded)' : ''} holds ${agent.keys.length} key${agent.keys.length===1?'':'s'}`}
                 </div>
+              ) : (
+                <input className="w-full px-2 py-1.5 border rounded text-sm" placeholder="~/.ssh/id_rsa (optional)" value={editing.keyPath||''} onChange={(e)=>setEditing({...editing, keyPath:e.target.value})} />
               )}
+              <div className="grid grid-cols-3 gap-2 items-center">
+                <input className="px-2 py-1.5 border rounded text-sm col-span-2" placeholder="Jump host (optional), e.g. user@bastion:22" value={editing.proxyJump||''} onChange={(e)=>setEditing({...editing, proxyJump:e.target.value})} />
+                <label className="flex items-center gap-2 text-sm">
+                  <input type="checkbox" checked={!!editing.forwardAgent} onChange={(e)=>setEditing({...editing, forwardAgent:e.target.checked})} /> Forward agent
+                </label>
+              </div>
               <div className="flex justify-end gap-2">
                 <Button size="sm" variant="outline" onClick={()=>setEditing(null)}>Cancel</Button>
                 <Button size="sm" onClick={save}>Save</Button>
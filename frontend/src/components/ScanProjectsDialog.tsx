@@ -0,0 +1,242 @@
+import React, { useState } from 'react';
+import { Search, AlertCircle, Loader2, X } from 'lucide-react';
+import { Button } from '@/components/ui/button';
+import {
+  ScanForProjects,
+  AddProjectsToIndex,
+  type projectscan,
+  type main,
+} from '@/lib/rpc-client';
+
+interface ScanProjectsDialogProps {
+  /** Whether the dialog is open */
+  isOpen: boolean;
+  /** Callback when dialog is closed */
+  onClose: () => void;
+  /** Callback when projects were added */
+  onSuccess?: () => void;
+}
+
+/**
+ * Dialog for finding the git repositories under some folders and adding them as projects in bulk
+ */
+export const ScanProjectsDialog: React.FC<ScanProjectsDialogProps> = ({
+  isOpen,
+  onClose,
+  onSuccess,
+}) => {
+  const [roots, setRoots] = useState('~');
+  const [candidates, setCandidates] = useState<projectscan.Candidate[] | null>(null);
+  const [selected, setSelected] = useState<Set<string>>(new Set());
+  const [scanning, setScanning] = useState(false);
+  const [adding, setAdding] = useState(false);
+  const [error, setError] = useState<string | null>(null);
+  const [result, setResult] = useState<main.ProjectsAddResult | null>(null);
+
+  const busy = scanning || adding;
+
+  const handleScan = async () => {
+    const rootDirs = roots.split(/[\n,]/).map((r) => r.trim()).filter(Boolean);
+    if (rootDirs.length === 0) {
+      setError('Enter at least one folder to scan');
+      return;
+    }
+    setScanning(true);
+    setError(null);
+    setResult(null);
+    try {
+      const found = await ScanForProjects(rootDirs);
+      setCandidates(found);
+      // Projects whose name is taken would update the indexed one, so they start unchecked
+      setSelected(new Set(found.filter((c) => !c.name_in_use).map((c) => c.path)));
+    } catch (err) {
+      setError(String(err));
+    } finally {
+      setScanning(false);
+    }
+  };
+
+  const toggle = (path: string) => {
+    setSelected((prev) => {
+      const next = new Set(prev);
+      if (next.has(path)) next.delete(path);
+      else next.add(path);
+      return next;
+    });
+  };
+
+  const handleAdd = async () => {
+    if (selected.size === 0) return;
+    setAdding(true);
+    setError(null);
+    try {
+      const added = await AddProjectsToIndex([...selected]);
+      setResult(added);
+      setCandidates((prev) => prev?.filter((c) => !added.added.includes(c.path)) ?? null);
+      setSelected(new Set());
+      if (added.added.length > 0) onSuccess?.();
+    } catch (err) {
+      setError(String(err));
+    } finally {
+      setAdding(false);
+    }
+  };
+
+  const handleCancel = () => {
+    if (busy) return;
+    setCandidates(null);
+    setSelected(new Set());
+    setError(null);
+    setResult(null);
+    onClose();
+  };
+
+  if (!isOpen) return null;
+
+  return (
+    <div className="fixed inset-0 z-50 flex items-center justify-center p-4">
+      {/* Backdrop */}
+      <div
+        className="absolute inset-0 bg-black/80"
+        onClick={handleCancel}
+      />
+
+      {/* Content */}
+      <div className="relative bg-background border rounded-lg shadow-lg w-full max-w-[600px] max-h-[90vh] overflow-hidden flex flex-col">
+        {/* Header */}
+        <div className="flex items-center justify-between p-6 pb-4 border-b">
+          <div className="flex items-center gap-2">
+            <Search className="h-5 w-5 text-primary" />
+            <div>
+              <h2 className="text-lg font-semibold leading-none tracking-tight">
+                Scan for Projects
+              </h2>
+              <p className="text-sm text-muted-foreground mt-1">
+                Find git repositories that aren't projects yet and add them at once
+              </p>
+            </div>
+          </div>
+          <Button
+            variant="ghost"
+            size="icon"
+            onClick={handleCancel}
+            disabled={busy}
+            className="h-6 w-6"
+          >
+            <X className="h-4 w-4" />
+          </Button>
+        </div>
+
+        {/* Body */}
+        <div className="flex-1 overflow-y-auto p-6 space-y-4">
+          <div>
+            <label className="text-xs text-muted-foreground">Folders to scan (comma or newline separated)</label>
+            <div className="flex gap-2 mt-1">
+              <textarea
+                value={roots}
+                onChange={(e) => setRoots(e.target.value)}
+                rows={2}
+                placeholder="~/code, ~/work"
+                className="flex-1 px-3 py-2 text-sm border rounded-md bg-background font-mono resize-none"
+                disabled={busy}
+              />
+              <Button size="sm" variant="outline" onClick={handleScan} disabled={busy}>
+                {scanning ? <Loader2 className="h-4 w-4 animate-spin" /> : 'Scan'}
+              </Button>
+            </div>
+          </div>
+
+          {candidates && candidates.length === 0 && (
+            <p className="text-sm text-muted-foreground">No new git repositories found</p>
+          )}
+
+          {candidates && candidates.length > 0 && (
+            <div className="space-y-1">
+              <div className="flex items-center justify-between text-xs text-muted-foreground">
+                <span>{candidates.length} repositories found</span>
+                <button
+                  type="button"
+                  className="hover:text-foreground"
+                  onClick={() => setSelected(selected.size === candidates.length
+                    ? new Set()
+                    : new Set(candidates.map((c) => c.path)))}
+                >
+                  {selected.size === candidates.length ? 'Select none' : 'Select all'}
+                </button>
+              </div>
+              {candidates.map((candidate) => (
+                <label
+                  key={candidate.path}
+                  className="flex items-start gap-2 p-2 rounded-md hover:bg-muted/50 cursor-pointer"
+                >
+                  <input
+                    type="checkbox"
+                    checked={selected.has(candidate.path)}
+                    onChange={() => toggle(candidate.path)}
+                    disabled={busy}
+                    className="mt-1"
+                  />
+                  <div className="flex-1 min-w-0">
+                    <div className="text-sm font-medium">{candidate.name}</div>
+                    <div className="text-xs text-muted-foreground font-mono truncate" title={candidate.path}>
+                      {candidate.path}
+                    </div>
+                    {(candidate.languages.length > 0 || candidate.frameworks.length > 0) && (
+                      <div className="flex flex-wrap gap-1 mt-1">
+                        {[...candidate.languages, ...candidate.frameworks].map((tag) => (
+                          <span key={tag} className="px-1.5 py-0.5 text-[10px] rounded bg-muted text-muted-foreground">
+                            {tag}
+                          </span>
+                        ))}
+                      </div>
+                    )}
+                    {candidate.name_in_use && (
+                      <p className="text-xs text-amber-600 dark:text-amber-400 mt-1">
+                        A project named {candidate.name} already exists; adding this one updates it
+                      </p>
+                    )}
+                  </div>
+                </label>
+              ))}
+            </div>
+          )}
+
+          {result && (
+            <div className="text-sm space-y-1">
+              <p>Added {result.added.length} projects</p>
+              {result.failed.map((failure) => (
+                <p key={failure.path} className="text-xs text-destructive">
+                  {failure.path}: {failure.error}
+                </p>
+              ))}
+            </div>
+          )}
+
+          {error && (
+            <div className="flex items-start gap-2 p-3 bg-destructive/10 rounded-md">
+              <AlertCircle className="h-4 w-4 text-destructive mt-0.5" />
+              <p className="text-sm text-destructive/80">{error}</p>
+            </div>
+          )}
+        </div>
+
+        {/* Footer */}
+        <div className="flex items-center justify-end gap-3 p-6 pt-4 border-t">
+          <Button variant="outline" onClick={handleCancel} disabled={busy}>
+            Close
+          </Button>
+          <Button onClick={handleAdd} disabled={busy || selected.size === 0}>
+            {adding ? (
+              <>
+                <Loader2 className="h-4 w-4 mr-2 animate-spin" />
+                Adding...
+              </>
+            ) : (
+              `Add ${selected.size} Projects`
+            )}
+          </Button>
+        </div>
+      </div>
+    </div>
+  );
+};
@@ -0,0 +1,140 @@
+import React, { useState, useEffect, useCallback } from "react";
+import { Archive } from "lucide-react";
+import { Button } from "@/components/ui/button";
+import { Input } from "@/components/ui/input";
+import { Label } from "@/components/ui/label";
+import {
+  GetSettings,
+  UpdateSettings,
+  GetSessionRetentionStats,
+  ArchiveOldSessions,
+  type retention,
+} from "@/lib/rpc-client";
+
+function formatBytes(n: number) {
+  return n >= 1 << 20 ? `${(n / (1 << 20)).toFixed(1)} MB` : `${Math.round(n / 1024)} KB`;
+}
+
+/** Session transcripts: how much space each provider's take, when they are archived, and a manual archival */
+export const SessionRetentionSettings: React.FC = () => {
+  const [stats, setStats] = useState<retention.Stats | null>(null);
+  const [retentionDays, setRetentionDays] = useState("");
+  const [maxMB, setMaxMB] = useState("");
+  const [message, setMessage] = useState<string | null>(null);
+  const [busy, setBusy] = useState(false);
+
+  const refresh = useCallback(() => {
+    GetSessionRetentionStats().then(setStats).catch(() => {});
+  }, []);
+
+  useEffect(() => {
+    GetSettings().then((values) => {
+      setRetentionDays(String(values.session_retention_days ?? ""));
+      setMaxMB(String(values.session_retention_max_mb ?? ""));
+    }).catch(() => {});
+    refresh();
+  }, [refresh]);
+
+  const save = async (key: string, value: string) => {
+    const n = Number(value);
+    if (value.trim() === "" || !Number.isFinite(n) || n < 0) {
+      setMessage("Enter a number of at least 0");
+      return;
+    }
+    setMessage(null);
+    try {
+      await UpdateSettings({ [key]: n });
+      refresh();
+    } catch (err) {
+      setMessage(String(err));
+    }
+  };
+
+  const archive = async () => {
+    setBusy(true);
+    try {
+      const result = await ArchiveOldSessions();
+      setStats(result.stats);
+      const failed = result.failed.length > 0 ? `, ${result.failed.length} failed` : "";
+      setMessage(result.archived === 0
+        ? `Nothing to archive${failed}`
+        : `Archived ${result.archived} transcripts, freed ${formatBytes(result.freed_bytes)}${failed}`);
+    } catch (err) {
+      setMessage(String(err));
+    } finally {
+      setBusy(false);
+    }
+  };
+
+  return (
+    <div className="space-y-4">
+      <div>
+        <h3 className="text-heading-4 mb-2">Session Transcripts</h3>
+        <p className="text-body-small text-muted-foreground">
+          Claude, Codex and Gemini keep every session's transcript. Old transcripts can be compressed
+          into ~/.ropcode/session-archive, which removes them from the session lists. Transcripts
+          written in the last day are never archived.
+        </p>
+      </div>
+
+      <div className="grid grid-cols-2 gap-4">
+        <div className="space-y-1">
+          <Label htmlFor="session-retention-days">Archive after (days, 0 = never)</Label>
+          <Input
+            id="session-retention-days"
+            type="number"
+            min={0}
+            value={retentionDays}
+            onChange={(e) => setRetentionDays(e.target.value)}
+            onBlur={() => save("session_retention_days", retentionDays)}
+          />
+        </div>
+        <div className="space-y-1">
+          <Label htmlFor="session-retention-max-mb">Size limit (MB, 0 = none)</Label>
+          <Input
+            id="session-retention-max-mb"
+            type="number"
+            min={0}
+            value={maxMB}
+            onChange={(e) => setMaxMB(e.target.value)}
+            onBlur={() => save("session_retention_max_mb", maxMB)}
+          />
+        </div>
+      </div>
+
+      {stats && (
+        <div className="space-y-1">
+          {stats.providers.map((provider) => (
+            <div key={provider.provider} className="flex justify-between text-xs text-muted-foreground">
+              <span className="capitalize">{provider.provider}</span>
+              <span>
+                {provider.files} transcripts, {formatBytes(provider.bytes)}
+                {provider.reclaimable_files > 0 && ` — ${formatBytes(provider.reclaimable_bytes)} to archive`}
+              </span>
+            </div>
+          ))}
+        </div>
+      )}
+
+      <div className="flex items-center gap-2">
+        <span className="text-xs text-muted-foreground flex-1">
+          {stats
+            ? `${stats.archived_files} archived, ${formatBytes(stats.archived_bytes)}`
+            : "Loading…"}
+        </span>
+        <Button
+          variant="outline"
+          size="sm"
+          className="gap-1.5"
+          disabled={busy || !stats || stats.reclaimable_files === 0}
+          onClick={archive}
+        >
+          <Archive className="h-3 w-3" />
+          Archive now
+        </Button>
+      </div>
+
+      {message && <p className="text-xs text-muted-foreground">{message}</p>}
+    </div>
+  );
+};
@@ -0,0 +1,137 @@
+import React, { useState, useEffect } from "react";
+import { Eye, Loader2, Save } from "lucide-react";
+import { Button } from "@/components/ui/button";
+import { Textarea } from "@/components/ui/textarea";
+import {
+  GetSessionSystemPrompt,
+  SaveSessionSystemPrompt,
+  PreviewSessionSystemPrompt,
+  type sysprompt
+} from "@/lib/rpc-client";
+import { cn } from "@/lib/utils";
+
+interface SessionSystemPromptEditorProps {
+  scope: "global" | "project";
+  /** project 作用域必填；global 作用域下用于预览 */
+  projectPath?: string;
+  onSaved?: (message: string) => void;
+  className?: string;
+}
+
+const VARIABLES = ["{{branch}}", "{{date}}", "{{projectName}}", "{{projectPath}}"];
+
+/**
+ * 会话系统提示词模板，会话启动时解析变量后追加到 Claude / Codex 的系统提示词
+ */
+export const SessionSystemPromptEditor: React.FC<SessionSystemPromptEditorProps> = ({
+  scope,
+  projectPath = "",
+  onSaved,
+  className
+}) => {
+  const [content, setContent] = useState("");
+  const [originalContent, setOriginalContent] = useState("");
+  const [loading, setLoading] = useState(true);
+  const [saving, setSaving] = useState(false);
+  const [preview, setPreview] = useState<sysprompt.Resolved | null>(null);
+  const [error, setError] = useState<string | null>(null);
+
+  useEffect(() => {
+    const load = async () => {
+      try {
+        setLoading(true);
+        setError(null);
+        const template = await GetSessionSystemPrompt(scope, projectPath);
+        setContent(template);
+        setOriginalContent(template);
+      } catch (err) {
+        console.error("Failed to load system prompt:", err);
+        setError("Failed to load system prompt");
+      } finally {
+        setLoading(false);
+      }
+    };
+    load();
+    setPreview(null);
+  }, [scope, projectPath]);
+
+  const handleSave = async () => {
+    try {
+      setSaving(true);
+      setError(null);
+      await SaveSessionSystemPrompt(scope, projectPath, content);
+      setOriginalContent(content);
+      setPreview(null);
+      onSaved?.("System prompt saved");
+    } catch (err) {
+      console.error("Failed to save system prompt:", err);
+      setError("Failed to save system prompt");
+    } finally {
+      setSaving(false);
+    }
+  };
+
+  const handlePreview = async () => {
+    try {
+      setError(null);
+      setPreview(await PreviewSessionSystemPrompt(projectPath));
+    } catch (err) {
+      console.error("Failed to preview system prompt:", err);
+      setError("Failed to preview system prompt");
+    }
+  };
+
+  if (loading) {
+    return (
+      <div className="flex items-center justify-center py-8">
+        <Loader2 className="h-5 w-5 animate-spin text-muted-foreground" />
+      </div>
+    );
+  }
+
+  return (
+    <div className={cn("space-y-3", className)}>
+      {error && <p className="text-sm text-destructive">{error}</p>}
+
+      <Textarea
+        value={content}
+        onChange={e => setContent(e.target.value)}
+        placeholder={scope === "project"
+          ? "Instructions for sessions in this project, e.g. You are working on {{projectName}} on branch {{branch}}."
+          : "Instructions for sessions in every project without a system prompt of its own"}
+        rows={8}
+        className="font-mono text-sm"
+      />
+      <p className="text-xs text-muted-foreground">
+        Variables: {VARIABLES.map(v => <code key={v} className="mx-1 px-1 bg-muted rounded">{v}</code>)}
+      </p>
+
+      <div className="flex items-center gap-2">
+        <Button size="sm" onClick={handleSave} disabled={content === originalContent || saving}>
+          {saving ? <Loader2 className="h-4 w-4 mr-2 animate-spin" /> : <Save className="h-4 w-4 mr-2" />}
+          Save
+        </Button>
+        <Button size="sm" variant="outline" onClick={handlePreview} disabled={content !== originalContent}>
+          <Eye className="h-4 w-4 mr-2" />
+          Preview
+        </Button>
+        {content !== originalContent && (
+          <span className="text-xs text-muted-foreground">Save to preview</span>
+        )}
+      </div>
+
+      {preview && (
+        <div className="rounded-md border bg-muted/30 p-3 space-y-2">
+          <p className="text-xs text-muted-foreground">
+            {preview.scope === ""
+              ? "Sessions get no additional system prompt."
+              : `Sessions${projectPath ? " in this project" : ""} get the ${preview.scope} system prompt:`}
+          </p>
+          {preview.prompt && (
+            <pre className="text-xs font-mono whitespace-pre-wrap break-words">{preview.prompt}</pre>
+          )}
+        </div>
+      )}
+    </div>
+  );
+};
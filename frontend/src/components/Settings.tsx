@@ -22,17 +22,32 @@ import {
   type ClaudeInstallation,
 } from "@/lib/api";
 import type { TitleProviderOption } from "@/lib/rpc-client";
+import { EventsOn } from "@/lib/rpc-events";
 import { cn } from "@/lib/utils";
 import { Toast, ToastContainer } from "@/components/ui/toast";
 import { ClaudeVersionSelector } from "./ClaudeVersionSelector";
 import { StorageTab } from "./StorageTab";
 import { HooksEditor } from "./HooksEditor";
+import { HookExecutionsLog } from "./HookExecutionsLog";
+import { SessionSystemPromptEditor } from "./SessionSystemPromptEditor";
+import { ClaudeFlagProfiles } from "./ClaudeFlagProfiles";
 import { SlashCommandsManager } from "./SlashCommandsManager";
 import { ProxySettings } from "./ProxySettings";
 import { ProviderApiManager } from "./ProviderApiManager";
 import { ClaudeAgentsManager } from "./ClaudeAgentsManager";
 import { PluginsManager } from "./PluginsManager";
 import { DebugLogs } from "./DebugLogs";
+import { BackendLogs } from "./BackendLogs";
+import { AppUpdates } from "./AppUpdates";
+import { TelemetrySettings } from "./TelemetrySettings";
+import { CodexSandboxSettings } from "./CodexSandboxSettings";
+import { TempImagesSettings } from "./TempImagesSettings";
+import { TrashSettings } from "./TrashSettings";
+import { SessionRetentionSettings } from "./SessionRetentionSettings";
+import { StorageReportSettings } from "./StorageReportSettings";
+import { ShellSettings } from "./ShellSettings";
+import { ProviderInstallSettings } from "./ProviderInstallSettings";
+import { DictationSettings } from "./DictationSettings";
 import { useTheme, useTrackEvent } from "@/hooks";
 import { analytics } from "@/lib/analytics";
 import { TabPersistenceService } from "@/services/tabPersistence";
@@ -103,6 +118,10 @@ export const Settings: React.FC<SettingsProps> = ({
   const [tabPersistenceEnabled, setTabPersistenceEnabled] = useState(true);
   // Startup intro preference
   const [startupIntroEnabled, setStartupIntroEnabled] = useState(true);
+  // Keep .bak copies of files saved from the editor
+  const [fileWriteBackup, setFileWriteBackup] = useState(false);
+  const [sessionCheckpoints, setSessionCheckpoints] = useState(false);
+  const [workspaceProtection, setWorkspaceProtection] = useState(false);
   const [sessionTitleModel, setSessionTitleModel] = useState("");
   const [sessionTitleProviderApiId, setSessionTitleProviderApiId] = useState("");
   const [titleProviderOptions, setTitleProviderOptions] = useState<TitleProviderOption[]>([]);
@@ -120,6 +139,17 @@ export const Settings: React.FC<SettingsProps> = ({
       const pref = await api.getSetting('startup_intro_enabled');
       setStartupIntroEnabled(pref === null ? true : pref === 'true');
     })();
+    (async () => {
+      const pref = await api.getSetting('file_write_backup');
+      setFileWriteBackup(pref === 'true');
+    })();
+    (async () => {
+      const pref = await api.getSetting('session_checkpoints_enabled');
+      setSessionCheckpoints(pref === 'true');
+    })();
+    api.getWorkspaceProtectionEnabled()
+      .then(setWorkspaceProtection)
+      .catch(() => setWorkspaceProtection(false));
     (async () => {
       const [model, providerApiId, providers] = await Promise.all([
         api.getSetting('session_title_model'),
@@ -138,6 +168,31 @@ export const Settings: React.FC<SettingsProps> = ({
     })();
   }, []);
 
+  // Key order differs between what we saved and what the backend reads back
+  const stableJson = (value: unknown) => JSON.stringify(value, (_key, v) =>
+    v && typeof v === "object" && !Array.isArray(v)
+      ? Object.fromEntries(Object.entries(v).sort(([a], [b]) => a.localeCompare(b)))
+      : v
+  );
+
+  // settings.json edited outside ropcode: reload it unless the edit is our own save
+  const settingsRef = React.useRef(settings);
+  settingsRef.current = settings;
+  useEffect(() => {
+    return EventsOn('claude-settings:changed', async (change: { valid: boolean }) => {
+      if (!change.valid) return;
+      try {
+        const onDisk = await api.getClaudeSettings();
+        if (stableJson(onDisk) === stableJson(settingsRef.current)) return;
+        await loadSettings();
+        setTitleProviderOptions(await api.GetSessionTitleProviderOptions() || []);
+        setToast({ message: "settings.json changed on disk and was reloaded", type: "success" });
+      } catch (err) {
+        console.error("Failed to reload Claude settings:", err);
+      }
+    });
+  }, []);
+
   /**
    * Loads analytics settings
    */
@@ -246,7 +301,7 @@ export const Settings: React.FC<SettingsProps> = ({
 
       // Save Claude binary path if changed
       if (binaryPathChanged && selectedInstallation) {
-        await api.setClaudeBinaryPath(selectedInstallation.path);
+        await api.switchClaudeInstallation(selectedInstallation.path);
         setCurrentBinaryPath(selectedInstallation.path);
         setBinaryPathChanged(false);
       }
@@ -873,9 +928,117 @@ export const Settings: React.FC<SettingsProps> = ({
                       />
                     </div>
 
+                    {/* File Backup Toggle */}
+                    <div className="flex items-center justify-between">
+                      <div className="space-y-1">
+                        <Label htmlFor="file-write-backup">Keep Backups of Saved Files</Label>
+                        <p className="text-caption text-muted-foreground">
+                          Save the previous version of a file edited in the viewer next to it as .bak
+                        </p>
+                      </div>
+                      <Switch
+                        id="file-write-backup"
+                        checked={fileWriteBackup}
+                        onCheckedChange={async (checked) => {
+                          setFileWriteBackup(checked);
+                          try {
+                            await api.saveSetting('file_write_backup', checked ? 'true' : 'false');
+                            trackEvent.settingsChanged('file_write_backup', checked);
+                          } catch (e) {
+                            setFileWriteBackup(!checked);
+                            setToast({ message: 'Failed to update preference', type: 'error' });
+                          }
+                        }}
+                      />
+                    </div>
+
+                    {/* Session Checkpoints Toggle */}
+                    <div className="flex items-center justify-between">
+                      <div className="space-y-1">
+                        <Label htmlFor="session-checkpoints">Session Checkpoints</Label>
+                        <p className="text-caption text-muted-foreground">
+                          Snapshot a git project's files before each session runs, so everything it changed can be rolled back
+                        </p>
+                      </div>
+                      <Switch
+                        id="session-checkpoints"
+                        checked={sessionCheckpoints}
+                        onCheckedChange={async (checked) => {
+                          setSessionCheckpoints(checked);
+                          try {
+                            await api.saveSetting('session_checkpoints_enabled', checked ? 'true' : 'false');
+                            trackEvent.settingsChanged('session_checkpoints_enabled', checked);
+                          } catch (e) {
+                            setSessionCheckpoints(!checked);
+                            setToast({ message: 'Failed to update preference', type: 'error' });
+                          }
+                        }}
+                      />
+                    </div>
+
+                    {/* Workspace Protection Toggle */}
+                    <div className="flex items-center justify-between">
+                      <div className="space-y-1">
+                        <Label htmlFor="workspace-protection">Workspace Protection</Label>
+                        <p className="text-caption text-muted-foreground">
+                          Ask for confirmation before cleaning up or removing a workspace, which throws away its changes
+                        </p>
+                      </div>
+                      <Switch
+                        id="workspace-protection"
+                        checked={workspaceProtection}
+                        onCheckedChange={async (checked) => {
+                          setWorkspaceProtection(checked);
+                          try {
+                            await api.setWorkspaceProtectionEnabled(checked);
+                            trackEvent.settingsChanged('workspace_protection_enabled', checked);
+                          } catch (e) {
+                            setWorkspaceProtection(!checked);
+                            setToast({ message: 'Failed to update preference', type: 'error' });
+                          }
+                        }}
+                      />
+                    </div>
+
                   </div>
                 </div>
               </Card>
+
+              <Card className="p-6">
+                <TempImagesSettings />
+              </Card>
+
+              <Card className="p-6">
+                <TrashSettings />
+              </Card>
+
+              <Card className="p-6">
+                <SessionRetentionSettings />
+              </Card>
+
+              <Card className="p-6">
+                <StorageReportSettings />
+              </Card>
+
+              <Card className="p-6">
+                <ShellSettings />
+              </Card>
+
+              <Card className="p-6">
+                <ProviderInstallSettings />
+              </Card>
+
+              <Card className="p-6">
+                <DictationSettings />
+              </Card>
+
+              <Card className="p-6">
+                <AppUpdates />
+              </Card>
+
+              <Card className="p-6">
+                <TelemetrySettings />
+              </Card>
             </TabsContent>
             
             {/* Permissions Settings */}
@@ -999,6 +1162,10 @@ export const Settings: React.FC<SettingsProps> = ({
                   </div>
                 </div>
               </Card>
+
+              <Card className="p-6">
+                <CodexSandboxSettings />
+              </Card>
             </TabsContent>
             
             {/* Environment Variables */}
@@ -1112,6 +1279,26 @@ export const Settings: React.FC<SettingsProps> = ({
                   </div>
                 </div>
               </Card>
+
+              <Card className="p-6">
+                <div className="space-y-4">
+                  <div>
+                    <h3 className="text-base font-semibold mb-2">Session System Prompt</h3>
+                    <p className="text-sm text-muted-foreground">
+                      Added to the system prompt of Claude and Codex sessions in projects without a session
+                      system prompt of their own. Variables are filled in when a session starts.
+                    </p>
+                  </div>
+                  <SessionSystemPromptEditor
+                    scope="global"
+                    onSaved={(message) => setToast({ message, type: "success" })}
+                  />
+                </div>
+              </Card>
+
+              <Card className="p-6">
+                <ClaudeFlagProfiles onSaved={(message) => setToast({ message, type: "success" })} />
+              </Card>
             </TabsContent>
             
             {/* Hooks Settings */}
@@ -1138,6 +1325,9 @@ export const Settings: React.FC<SettingsProps> = ({
                   />
                 </div>
               </Card>
+              <Card className="p-6">
+                <HookExecutionsLog />
+              </Card>
             </TabsContent>
             
             {/* Commands Tab */}
@@ -1187,10 +1377,13 @@ export const Settings: React.FC<SettingsProps> = ({
             </TabsContent>
 
             {/* Debug Logs */}
-            <TabsContent value="debug">
+            <TabsContent value="debug" className="space-y-6">
               <Card className="p-6">
                 <DebugLogs />
               </Card>
+              <Card className="p-6">
+                <BackendLogs />
+              </Card>
             </TabsContent>
 
           </Tabs>
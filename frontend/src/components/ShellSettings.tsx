@@ -0,0 +1,164 @@
+import React, { useState, useEffect } from "react";
+import { RefreshCw } from "lucide-react";
+import { Button } from "@/components/ui/button";
+import { Label } from "@/components/ui/label";
+import { Switch } from "@/components/ui/switch";
+import {
+  Select,
+  SelectContent,
+  SelectItem,
+  SelectTrigger,
+  SelectValue,
+} from "@/components/ui/select";
+import {
+  GetSettings,
+  UpdateSettings,
+  GetShellInfo,
+  GetWSLInfo,
+  ReloadEnvironment,
+  type main,
+} from "@/lib/rpc-client";
+
+type ShellPreference = { path?: string; login?: boolean };
+
+const DETECTED = "__detected__";
+const WINDOWS = "__windows__";
+
+/** Shell that commands and terminals run in on this OS */
+export const ShellSettings: React.FC = () => {
+  const [info, setInfo] = useState<main.ShellInfo | null>(null);
+  const [wsl, setWsl] = useState<main.WSLInfo | null>(null);
+  const [preferences, setPreferences] = useState<Record<string, ShellPreference>>({});
+  const [message, setMessage] = useState<string | null>(null);
+  const [reloading, setReloading] = useState(false);
+
+  useEffect(() => {
+    GetShellInfo().then(setInfo).catch(() => {});
+    GetWSLInfo().then(setWsl).catch(() => {});
+    GetSettings().then((values) => {
+      setPreferences((values.shell_preference || {}) as Record<string, ShellPreference>);
+    }).catch(() => {});
+  }, []);
+
+  if (!info) return null;
+  const current = preferences[info.os] || {};
+  const shells = current.path && !info.available.includes(current.path)
+    ? [...info.available, current.path]
+    : info.available;
+
+  const update = async (change: ShellPreference) => {
+    const previous = preferences;
+    const next = { ...preferences, [info.os]: { ...current, ...change } };
+    setPreferences(next);
+    setMessage(null);
+    try {
+      await UpdateSettings({ shell_preference: next });
+      setInfo(await GetShellInfo());
+    } catch (err) {
+      setPreferences(previous);
+      setMessage(String(err));
+    }
+  };
+
+  const updateDistro = async (distro: string) => {
+    setMessage(null);
+    try {
+      await UpdateSettings({ wsl_distro: distro });
+      setWsl(await GetWSLInfo());
+    } catch (err) {
+      setMessage(String(err));
+    }
+  };
+
+  const reload = async () => {
+    setReloading(true);
+    setMessage(null);
+    try {
+      const snapshot = await ReloadEnvironment();
+      setMessage(snapshot.error
+        ? `Could not read the login environment: ${snapshot.error}`
+        : `Loaded ${snapshot.variables} variables from ${snapshot.shell} in ${snapshot.duration_ms} ms`);
+    } catch (err) {
+      setMessage(String(err));
+    } finally {
+      setReloading(false);
+    }
+  };
+
+  return (
+    <div className="space-y-4">
+      <div>
+        <h3 className="text-heading-4 mb-2">Shell</h3>
+        <p className="text-body-small text-muted-foreground">
+          The shell new terminals open and project commands run in on this machine. Other operating systems keep
+          their own choice.
+        </p>
+      </div>
+
+      <div className="flex items-center justify-between">
+        <Label>Shell</Label>
+        <Select
+          value={current.path || DETECTED}
+          onValueChange={(value) => update({ path: value === DETECTED ? "" : value })}
+        >
+          <SelectTrigger className="w-72">
+            <SelectValue />
+          </SelectTrigger>
+          <SelectContent>
+            <SelectItem value={DETECTED}>Detected ({info.detected})</SelectItem>
+            {shells.map(shell => (
+              <SelectItem key={shell} value={shell}>{shell}</SelectItem>
+            ))}
+          </SelectContent>
+        </Select>
+      </div>
+
+      <div className="flex items-center justify-between">
+        <div>
+          <Label htmlFor="shell-login">Login shell</Label>
+          <p className="text-caption text-muted-foreground mt-1">
+            Loads your full profile, so tools installed through it are on PATH; terminals may open slower
+          </p>
+        </div>
+        <Switch id="shell-login" checked={current.login === true} onCheckedChange={(checked) => update({ login: checked })} />
+      </div>
+
+      {wsl?.supported && (
+        <div className="flex items-center justify-between">
+          <div>
+            <Label>Run in WSL</Label>
+            <p className="text-caption text-muted-foreground mt-1">
+              {wsl.error || "Provider sessions, new terminals and git run inside this distro, using its CLIs"}
+            </p>
+          </div>
+          <Select value={wsl.distro || WINDOWS} onValueChange={(value) => updateDistro(value === WINDOWS ? "" : value)}>
+            <SelectTrigger className="w-72">
+              <SelectValue />
+            </SelectTrigger>
+            <SelectContent>
+              <SelectItem value={WINDOWS}>Windows</SelectItem>
+              {wsl.distros.map(distro => (
+                <SelectItem key={distro} value={distro}>{distro}</SelectItem>
+              ))}
+            </SelectContent>
+          </Select>
+        </div>
+      )}
+
+      <div className="flex items-center justify-between">
+        <div>
+          <Label>Login environment</Label>
+          <p className="text-caption text-muted-foreground mt-1">
+            PATH and variables from your shell profile, read once at startup; reload after changing the profile
+          </p>
+        </div>
+        <Button size="sm" variant="outline" onClick={reload} disabled={reloading}>
+          <RefreshCw className={`h-3 w-3 mr-1.5 ${reloading ? "animate-spin" : ""}`} />
+          Reload
+        </Button>
+      </div>
+
+      {message && <p className="text-xs text-muted-foreground">{message}</p>}
+    </div>
+  );
+};
@@ -9,6 +9,7 @@ import { useContainerContext } from '@/contexts/ContainerContext';
 import { SyncFromSSHDialog } from '@/components/SyncFromSSHDialog';
 import { CloneFromURLDialog } from '@/components/CloneFromURLDialog';
 import { OpenProjectDialog } from '@/components/OpenProjectDialog';
+import { ScanProjectsDialog } from '@/components/ScanProjectsDialog';
 import { SidebarRail, type SidebarPanelMode } from '@/components/sidebar/SidebarRail';
 import { SessionPanel } from '@/components/sidebar/SessionPanel';
 import { findSelectedSpace, selectedSpaceFromProject, type SelectedSpace } from '@/components/sidebar/sidebarSelection';
@@ -87,6 +88,7 @@ export const Sidebar: React.FC<SidebarProps> = ({
   const [selectedSpace, setSelectedSpace] = useState<SelectedSpace | null>(null);
   const [showSSHDialog, setShowSSHDialog] = useState(false);
   const [showCloneDialog, setShowCloneDialog] = useState(false);
+  const [showScanDialog, setShowScanDialog] = useState(false);
   const [showOpenDialog, setShowOpenDialog] = useState(false);
 
   const { tabs, activeTabId } = useTabContext();
@@ -288,6 +290,7 @@ export const Sidebar: React.FC<SidebarProps> = ({
         onToggleCollapse={toggleCollapse}
         onToggleRightSidebar={() => window.dispatchEvent(new CustomEvent('toggle-right-sidebar'))}
         onOpenProject={() => setShowOpenDialog(true)}
+        onScanProjects={() => setShowScanDialog(true)}
         onCloneProject={() => setShowCloneDialog(true)}
         onSyncFromSSH={() => setShowSSHDialog(true)}
         onAgentsClick={onAgentsClick}
@@ -346,6 +349,12 @@ export const Sidebar: React.FC<SidebarProps> = ({
         onSuccess={loadProjects}
       />
 
+      <ScanProjectsDialog
+        isOpen={showScanDialog}
+        onClose={() => setShowScanDialog(false)}
+        onSuccess={loadProjects}
+      />
+
       <OpenProjectDialog
         isOpen={showOpenDialog}
         onClose={() => setShowOpenDialog(false)}
@@ -0,0 +1,148 @@
+import React, { useEffect, useState } from "react";
+import { AlertCircle, Loader2, Play, ShieldAlert } from "lucide-react";
+import { Button } from "@/components/ui/button";
+import { Input } from "@/components/ui/input";
+import { Label } from "@/components/ui/label";
+import { Switch } from "@/components/ui/switch";
+import { Dialog, DialogContent, DialogHeader, DialogTitle } from "@/components/ui/dialog";
+import { api, type SlashCommand } from "@/lib/api";
+import type { slashexpand } from "@/lib/rpc-client";
+
+interface SlashCommandPreviewDialogProps {
+  command: SlashCommand | null;
+  projectPath?: string;
+  onClose: () => void;
+}
+
+/**
+ * 预览自定义命令实际发送的提示词：填入参数、执行 !`bash` 块并内联 @file
+ */
+export const SlashCommandPreviewDialog: React.FC<SlashCommandPreviewDialogProps> = ({
+  command,
+  projectPath,
+  onClose,
+}) => {
+  const [args, setArgs] = useState("");
+  const [allowUnsandboxed, setAllowUnsandboxed] = useState(false);
+  const [expansion, setExpansion] = useState<slashexpand.Expansion | null>(null);
+  const [error, setError] = useState<string | null>(null);
+  const [loading, setLoading] = useState(false);
+
+  useEffect(() => {
+    setArgs("");
+    setAllowUnsandboxed(false);
+    setExpansion(null);
+    setError(null);
+  }, [command?.id]);
+
+  const hasShell = command?.content.includes("!`") && command.command_type === "claude";
+
+  const expand = async () => {
+    if (!command) return;
+    setLoading(true);
+    setError(null);
+    try {
+      const name = (command.full_command || `/${command.name}`).replace(/^\//, "");
+      setExpansion(await api.expandSlashCommand(name, args, projectPath || "", allowUnsandboxed));
+    } catch (err) {
+      setError(err instanceof Error ? err.message : String(err));
+    } finally {
+      setLoading(false);
+    }
+  };
+
+  return (
+    <Dialog open={!!command} onOpenChange={(open) => !open && onClose()}>
+      <DialogContent className="max-w-3xl max-h-[85vh] overflow-y-auto">
+        <DialogHeader>
+          <DialogTitle>Preview {command?.full_command || `/${command?.name}`}</DialogTitle>
+        </DialogHeader>
+
+        <div className="space-y-4">
+          <div className="space-y-2">
+            <Label htmlFor="slash-preview-args">Arguments</Label>
+            <div className="flex gap-2">
+              <Input
+                id="slash-preview-args"
+                placeholder={command?.argument_hint || "$ARGUMENTS"}
+                value={args}
+                onChange={(e) => setArgs(e.target.value)}
+                onKeyDown={(e) => e.key === "Enter" && expand()}
+                className="font-mono text-sm"
+              />
+              <Button onClick={expand} disabled={loading} className="gap-1.5">
+                {loading ? <Loader2 className="h-4 w-4 animate-spin" /> : <Play className="h-4 w-4" />}
+                Expand
+              </Button>
+            </div>
+            {hasShell && (
+              <>
+                <p className="text-xs text-muted-foreground">
+                  Expanding runs the command's shell blocks in the project directory.
+                </p>
+                <div className="flex items-center gap-2">
+                  <Switch id="slash-preview-unsandboxed" checked={allowUnsandboxed} onCheckedChange={setAllowUnsandboxed} />
+                  <Label htmlFor="slash-preview-unsandboxed" className="text-xs">
+                    Run shell blocks even when no sandbox is available
+                  </Label>
+                </div>
+              </>
+            )}
+          </div>
+
+          {error && (
+            <div className="flex items-center gap-2 p-3 rounded-lg bg-destructive/10 text-destructive text-sm">
+              <AlertCircle className="h-4 w-4" />
+              {error}
+            </div>
+          )}
+
+          {expansion && (
+            <>
+              {expansion.shell.length > 0 && !expansion.sandboxed && (
+                <div className="flex items-center gap-2 text-xs text-muted-foreground">
+                  <ShieldAlert className="h-3.5 w-3.5" />
+                  {expansion.shell.some((run) => run.error === "skipped: no sandbox available")
+                    ? "No sandbox is available here, so shell blocks were skipped."
+                    : "No sandbox is available here, so shell blocks ran with normal file access."}
+                </div>
+              )}
+
+              {(expansion.shell.length > 0 || expansion.files.length > 0) && (
+                <div className="space-y-1 text-xs font-mono">
+                  {expansion.shell.map((run, i) => (
+                    <div key={`shell-${i}`} className="flex gap-2">
+                      <span className={run.error || run.exit_code !== 0 ? "text-destructive" : "text-muted-foreground"}>
+                        {run.error ? "✗" : run.exit_code === 0 ? "✓" : `exit ${run.exit_code}`}
+                      </span>
+                      <span className="truncate">!`{run.command}`</span>
+                      {run.error && <span className="text-destructive">{run.error}</span>}
+                      {run.truncated && <span className="text-muted-foreground">(output truncated)</span>}
+                    </div>
+                  ))}
+                  {expansion.files.map((file, i) => (
+                    <div key={`file-${i}`} className="flex gap-2">
+                      <span className={file.inlined ? "text-muted-foreground" : "text-destructive"}>
+                        {file.inlined ? "✓" : "✗"}
+                      </span>
+                      <span className="truncate">{file.reference}</span>
+                      {file.error && <span className="text-destructive">{file.error}</span>}
+                      {file.truncated && <span className="text-muted-foreground">(truncated)</span>}
+                    </div>
+                  ))}
+                </div>
+              )}
+
+              <div className="space-y-2">
+                <Label>Prompt</Label>
+                <pre className="p-3 bg-muted rounded-md text-xs whitespace-pre-wrap break-words max-h-[45vh] overflow-y-auto">
+                  {expansion.prompt}
+                </pre>
+              </div>
+            </>
+          )}
+        </div>
+      </DialogContent>
+    </Dialog>
+  );
+};
@@ -17,7 +17,8 @@ import {
   Search,
   ChevronDown,
   ChevronRight,
-  ArrowRightLeft
+  ArrowRightLeft,
+  Eye
 } from "lucide-react";
 import { Button } from "@/components/ui/button";
 import { Input } from "@/components/ui/input";
@@ -32,6 +33,7 @@ import { api, type SlashCommand, type CommandType } from "@/lib/api";
 import { cn } from "@/lib/utils";
 import { COMMON_TOOL_MATCHERS } from "@/types/hooks";
 import { useTrackEvent } from "@/hooks";
+import { SlashCommandPreviewDialog } from "./SlashCommandPreviewDialog";
 
 interface SlashCommandsManagerProps {
   projectPath?: string;
@@ -155,6 +157,9 @@ export const SlashCommandsManager: React.FC<SlashCommandsManagerProps> = ({
   const [convertDialogOpen, setConvertDialogOpen] = useState(false);
   const [commandToConvert, setCommandToConvert] = useState<SlashCommand | null>(null);
   const [converting, setConverting] = useState(false);
+
+  // Preview dialog state
+  const [commandToPreview, setCommandToPreview] = useState<SlashCommand | null>(null);
   
   // Analytics tracking
   const trackEvent = useTrackEvent();
@@ -213,13 +218,19 @@ export const SlashCommandsManager: React.FC<SlashCommandsManagerProps> = ({
       setSaving(true);
       setError(null);
 
-      await api.slashCommandSave(
-        commandForm.name,
-        commandForm.content,
-        commandForm.scope,
-        commandForm.scope === 'project' ? projectPath : undefined
-      );
-      
+      await api.saveSlashCommand2({
+        name: commandForm.name.trim(),
+        namespace: commandForm.namespace.trim(),
+        content: commandForm.content,
+        description: commandForm.description,
+        allowed_tools: commandForm.allowedTools,
+        argument_hint: commandForm.argumentHint,
+        scope: commandForm.scope,
+        command_type: commandForm.commandType,
+        project_path: projectPath || '',
+        original_file_path: editingCommand?.file_path,
+      });
+
       // Track command creation
       trackEvent.slashCommandCreated({
         command_type: editingCommand ? 'custom' : 'custom',
@@ -247,7 +258,7 @@ export const SlashCommandsManager: React.FC<SlashCommandsManagerProps> = ({
     try {
       setDeleting(true);
       setError(null);
-      await api.slashCommandDelete(commandToDelete.id, projectPath);
+      await api.deleteSlashCommandFile(commandToDelete.file_path, projectPath || '');
       setDeleteDialogOpen(false);
       setCommandToDelete(null);
       await loadCommands();
@@ -294,12 +305,18 @@ export const SlashCommandsManager: React.FC<SlashCommandsManagerProps> = ({
       }
 
       // Save as new command type
-      await api.slashCommandSave(
-        commandToConvert.name,
-        commandToConvert.content,
-        commandToConvert.scope as 'project' | 'user',
-        commandToConvert.scope === 'project' ? projectPath : undefined
-      );
+      await api.saveSlashCommand2({
+        name: commandToConvert.name,
+        // Codex 只读取 prompts 目录顶层的文件
+        namespace: targetType === 'codex' ? '' : commandToConvert.namespace || '',
+        content: commandToConvert.content,
+        description: commandToConvert.description || '',
+        allowed_tools: convertedAllowedTools,
+        argument_hint: convertedArgumentHint,
+        scope: commandToConvert.scope as 'project' | 'user',
+        command_type: targetType,
+        project_path: projectPath || '',
+      });
 
       setConvertDialogOpen(false);
       setCommandToConvert(null);
@@ -603,6 +620,17 @@ export const SlashCommandsManager: React.FC<SlashCommandsManagerProps> = ({
                                 {command.plugin_name}
                               </Badge>
                             )}
+                            {command.scope !== 'default' && (
+                              <Button
+                                variant="ghost"
+                                size="icon"
+                                onClick={() => setCommandToPreview(command)}
+                                className="h-8 w-8"
+                                title="Preview expanded prompt"
+                              >
+                                <Eye className="h-4 w-4" />
+                              </Button>
+                            )}
                             {/* Only show edit/delete for user/project commands */}
                             {command.scope !== 'plugin' && command.scope !== 'default' && (
                               <>
@@ -743,8 +771,9 @@ export const SlashCommandsManager: React.FC<SlashCommandsManagerProps> = ({
               <div className="space-y-2">
                 <Label>Namespace (Optional)</Label>
                 <Input
-                  placeholder="e.g., frontend, backend"
+                  placeholder={commandForm.commandType === 'codex' ? "Not supported for Codex" : "e.g., frontend, ops:release"}
                   value={commandForm.namespace}
+                  disabled={commandForm.commandType === 'codex'}
                   onChange={(e) => setCommandForm(prev => ({ ...prev, namespace: e.target.value }))}
                 />
               </div>
@@ -997,6 +1026,12 @@ export const SlashCommandsManager: React.FC<SlashCommandsManagerProps> = ({
           </DialogFooter>
         </DialogContent>
       </Dialog>
+
+      <SlashCommandPreviewDialog
+        command={commandToPreview}
+        projectPath={projectPath}
+        onClose={() => setCommandToPreview(null)}
+      />
     </div>
   );
-}; 
+};
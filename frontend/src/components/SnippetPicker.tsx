@@ -0,0 +1,294 @@
+import React, { useState, useEffect, useRef } from "react";
+import { BookMarked, Loader2, Pencil, Plus, Save, Trash2, X } from "lucide-react";
+import { Button } from "@/components/ui/button";
+import { Input } from "@/components/ui/input";
+import { Textarea } from "@/components/ui/textarea";
+import { Popover } from "@/components/ui/popover";
+import { TooltipSimple } from "@/components/ui/tooltip-modern";
+import {
+  SearchSnippets,
+  SaveSnippet,
+  DeleteSnippet,
+  UseSnippet,
+  type database
+} from "@/lib/rpc-client";
+import { cn } from "@/lib/utils";
+
+interface SnippetPickerProps {
+  /** 选中片段时插入其正文 */
+  onInsert: (body: string) => void;
+  /** 当前输入框内容，新建片段时作为默认正文 */
+  currentPrompt?: string;
+  disabled?: boolean;
+}
+
+interface SnippetDraft {
+  id: number;
+  title: string;
+  tags: string;
+  body: string;
+}
+
+const emptyDraft: SnippetDraft = { id: 0, title: "", tags: "", body: "" };
+
+/**
+ * 提示词片段库：模糊搜索常用的提示词片段并插入到任意会话的输入框
+ */
+export const SnippetPicker: React.FC<SnippetPickerProps> = ({
+  onInsert,
+  currentPrompt = "",
+  disabled = false
+}) => {
+  const [open, setOpen] = useState(false);
+  const [query, setQuery] = useState("");
+  const [snippets, setSnippets] = useState<database.Snippet[]>([]);
+  const [selectedIndex, setSelectedIndex] = useState(0);
+  const [loading, setLoading] = useState(false);
+  const [draft, setDraft] = useState<SnippetDraft | null>(null);
+  const [saving, setSaving] = useState(false);
+  const [error, setError] = useState<string | null>(null);
+  const searchRef = useRef<HTMLInputElement>(null);
+
+  useEffect(() => {
+    if (!open || draft) return;
+    let cancelled = false;
+    const timer = setTimeout(async () => {
+      try {
+        setLoading(true);
+        const results = await SearchSnippets(query);
+        if (!cancelled) {
+          setSnippets(results || []);
+          setSelectedIndex(0);
+        }
+      } catch (err) {
+        console.error("Failed to search snippets:", err);
+        if (!cancelled) setError("Failed to search snippets");
+      } finally {
+        if (!cancelled) setLoading(false);
+      }
+    }, 120);
+    return () => {
+      cancelled = true;
+      clearTimeout(timer);
+    };
+  }, [open, query, draft]);
+
+  useEffect(() => {
+    if (open && !draft) {
+      setTimeout(() => searchRef.current?.focus(), 0);
+    }
+  }, [open, draft]);
+
+  const handleOpenChange = (next: boolean) => {
+    setOpen(next);
+    if (!next) {
+      setQuery("");
+      setDraft(null);
+      setError(null);
+    }
+  };
+
+  const handleInsert = async (snippet: database.Snippet) => {
+    handleOpenChange(false);
+    onInsert(snippet.body);
+    try {
+      await UseSnippet(snippet.id);
+    } catch (err) {
+      console.error("Failed to record snippet use:", err);
+    }
+  };
+
+  const handleSearchKeyDown = (e: React.KeyboardEvent) => {
+    if (e.key === "ArrowDown") {
+      e.preventDefault();
+      setSelectedIndex(i => Math.min(i + 1, snippets.length - 1));
+    } else if (e.key === "ArrowUp") {
+      e.preventDefault();
+      setSelectedIndex(i => Math.max(i - 1, 0));
+    } else if (e.key === "Enter" && snippets[selectedIndex]) {
+      e.preventDefault();
+      handleInsert(snippets[selectedIndex]);
+    } else if (e.key === "Escape") {
+      e.preventDefault();
+      handleOpenChange(false);
+    }
+  };
+
+  const handleSave = async () => {
+    if (!draft) return;
+    try {
+      setSaving(true);
+      setError(null);
+      await SaveSnippet({
+        id: draft.id,
+        title: draft.title,
+        body: draft.body,
+        tags: draft.tags.split(",").map(t => t.trim()).filter(Boolean)
+      });
+      setDraft(null);
+    } catch (err) {
+      console.error("Failed to save snippet:", err);
+      setError(err instanceof Error ? err.message : "Failed to save snippet");
+    } finally {
+      setSaving(false);
+    }
+  };
+
+  const handleDelete = async (snippet: database.Snippet) => {
+    if (!confirm(`Delete snippet "${snippet.title}"?`)) return;
+    try {
+      await DeleteSnippet(snippet.id);
+      setSnippets(prev => prev.filter(s => s.id !== snippet.id));
+    } catch (err) {
+      console.error("Failed to delete snippet:", err);
+      setError("Failed to delete snippet");
+    }
+  };
+
+  const renderEditor = (d: SnippetDraft) => (
+    <div className="space-y-2 p-2">
+      <div className="flex items-center justify-between">
+        <span className="text-sm font-medium">{d.id ? "Edit Snippet" : "New Snippet"}</span>
+        <Button variant="ghost" size="icon" className="h-6 w-6" onClick={() => setDraft(null)}>
+          <X className="h-3.5 w-3.5" />
+        </Button>
+      </div>
+      <Input
+        value={d.title}
+        onChange={e => setDraft({ ...d, title: e.target.value })}
+        placeholder="Title, e.g. Bug report"
+        className="h-8 text-sm"
+        autoFocus
+      />
+      <Input
+        value={d.tags}
+        onChange={e => setDraft({ ...d, tags: e.target.value })}
+        placeholder="Tags, comma separated"
+        className="h-8 text-sm"
+      />
+      <Textarea
+        value={d.body}
+        onChange={e => setDraft({ ...d, body: e.target.value })}
+        placeholder="Snippet text"
+        rows={6}
+        className="font-mono text-xs"
+      />
+      <div className="flex justify-end">
+        <Button size="sm" onClick={handleSave} disabled={saving || !d.title.trim() || !d.body.trim()}>
+          {saving ? <Loader2 className="h-4 w-4 mr-2 animate-spin" /> : <Save className="h-4 w-4 mr-2" />}
+          Save
+        </Button>
+      </div>
+    </div>
+  );
+
+  const renderList = () => (
+    <div className="space-y-1">
+      <div className="flex items-center gap-1 p-1">
+        <Input
+          ref={searchRef}
+          value={query}
+          onChange={e => setQuery(e.target.value)}
+          onKeyDown={handleSearchKeyDown}
+          placeholder="Search snippets..."
+          className="h-8 text-sm"
+        />
+        <TooltipSimple content="New snippet" side="top">
+          <Button
+            variant="ghost"
+            size="icon"
+            className="h-8 w-8 shrink-0"
+            onClick={() => setDraft({ ...emptyDraft, body: currentPrompt })}
+          >
+            <Plus className="h-4 w-4" />
+          </Button>
+        </TooltipSimple>
+      </div>
+
+      <div className="max-h-[50vh] overflow-y-auto">
+        {loading && snippets.length === 0 ? (
+          <div className="flex justify-center py-4">
+            <Loader2 className="h-4 w-4 animate-spin text-muted-foreground" />
+          </div>
+        ) : snippets.length === 0 ? (
+          <p className="px-3 py-4 text-center text-xs text-muted-foreground">
+            {query ? "No matching snippets" : "No snippets yet. Save recurring prompts with +."}
+          </p>
+        ) : (
+          snippets.map((snippet, index) => (
+            <div
+              key={snippet.id}
+              onClick={() => handleInsert(snippet)}
+              onMouseEnter={() => setSelectedIndex(index)}
+              className={cn(
+                "group flex items-start gap-2 rounded-md px-2 py-1.5 cursor-pointer",
+                index === selectedIndex && "bg-accent"
+              )}
+            >
+              <div className="min-w-0 flex-1">
+                <div className="flex items-center gap-1.5">
+                  <span className="truncate text-sm font-medium">{snippet.title}</span>
+                  {snippet.tags.map(tag => (
+                    <span key={tag} className="rounded bg-muted px-1 text-[10px] text-muted-foreground">{tag}</span>
+                  ))}
+                </div>
+                <p className="truncate text-xs text-muted-foreground">{snippet.body}</p>
+              </div>
+              <div className="flex shrink-0 opacity-0 group-hover:opacity-100">
+                <Button
+                  variant="ghost"
+                  size="icon"
+                  className="h-6 w-6"
+                  onClick={e => {
+                    e.stopPropagation();
+                    setDraft({ id: snippet.id, title: snippet.title, tags: snippet.tags.join(", "), body: snippet.body });
+                  }}
+                >
+                  <Pencil className="h-3 w-3" />
+                </Button>
+                <Button
+                  variant="ghost"
+                  size="icon"
+                  className="h-6 w-6 text-destructive"
+                  onClick={e => {
+                    e.stopPropagation();
+                    handleDelete(snippet);
+                  }}
+                >
+                  <Trash2 className="h-3 w-3" />
+                </Button>
+              </div>
+            </div>
+          ))
+        )}
+      </div>
+    </div>
+  );
+
+  return (
+    <Popover
+      trigger={
+        <TooltipSimple content="Insert snippet" side="top">
+          <Button
+            variant="ghost"
+            size="icon"
+            disabled={disabled}
+            className="h-8 w-8 hover:bg-accent/50 transition-colors active:scale-[0.97]"
+          >
+            <BookMarked className="h-3.5 w-3.5" />
+          </Button>
+        </TooltipSimple>
+      }
+      content={
+        <div className="w-[360px]">
+          {error && <p className="px-2 pt-2 text-xs text-destructive">{error}</p>}
+          {draft ? renderEditor(draft) : renderList()}
+        </div>
+      }
+      open={open}
+      onOpenChange={handleOpenChange}
+      align="end"
+      side="top"
+    />
+  );
+};
@@ -0,0 +1,127 @@
+import React, { useState, useEffect, useCallback } from "react";
+import { HardDrive, RefreshCw } from "lucide-react";
+import { Button } from "@/components/ui/button";
+import { GetStorageReport, CleanupStorage, type main } from "@/lib/rpc-client";
+
+function formatBytes(n: number) {
+  if (n >= 1 << 30) return `${(n / (1 << 30)).toFixed(1)} GB`;
+  return n >= 1 << 20 ? `${(n / (1 << 20)).toFixed(1)} MB` : `${Math.round(n / 1024)} KB`;
+}
+
+const categoryLabels: Record<string, string> = {
+  claude_sessions: "Claude sessions",
+  codex_sessions: "Codex sessions",
+  gemini_sessions: "Gemini sessions",
+  session_archive: "Session archive",
+  temp_images: "Pasted images",
+  trash: "Trash",
+  logs: "Logs",
+  database: "Database",
+};
+
+const cleanupLabels: Record<string, string> = {
+  archive_sessions: "Archive old",
+  temp_images: "Clean up",
+  trash: "Purge expired",
+};
+
+/** Disk used by session transcripts and ropcode's data, per category and per project */
+export const StorageReportSettings: React.FC = () => {
+  const [report, setReport] = useState<main.StorageReport | null>(null);
+  const [busy, setBusy] = useState<string | null>(null);
+  const [message, setMessage] = useState<string | null>(null);
+
+  const refresh = useCallback(() => {
+    setBusy("refresh");
+    GetStorageReport()
+      .then(setReport)
+      .catch((err) => setMessage(String(err)))
+      .finally(() => setBusy(null));
+  }, []);
+
+  useEffect(() => {
+    refresh();
+  }, [refresh]);
+
+  const cleanup = async (action: string) => {
+    setBusy(action);
+    setMessage(null);
+    try {
+      const before = report?.total_bytes ?? 0;
+      const next = await CleanupStorage(action);
+      setReport(next);
+      const freed = before - next.total_bytes;
+      setMessage(freed > 0 ? `Freed ${formatBytes(freed)}` : "Nothing to clean up");
+    } catch (err) {
+      setMessage(String(err));
+    } finally {
+      setBusy(null);
+    }
+  };
+
+  return (
+    <div className="space-y-4">
+      <div className="flex items-start justify-between gap-4">
+        <div>
+          <h3 className="text-heading-4 mb-2">Storage</h3>
+          <p className="text-body-small text-muted-foreground">
+            Disk used by provider session transcripts and ropcode's own data.
+            {report && ` ${formatBytes(report.total_bytes)} in total.`}
+          </p>
+        </div>
+        <Button variant="ghost" size="sm" disabled={busy !== null} onClick={refresh}>
+          <RefreshCw className={`h-3 w-3 ${busy === "refresh" ? "animate-spin" : ""}`} />
+        </Button>
+      </div>
+
+      {report && (
+        <div className="space-y-1">
+          {report.categories.map((category) => (
+            <div key={category.id} className="flex items-center gap-2 text-xs text-muted-foreground">
+              <HardDrive className="h-3 w-3 shrink-0" />
+              <span className="flex-1 truncate" title={category.path}>
+                {categoryLabels[category.id] ?? category.id}
+              </span>
+              <span>
+                {formatBytes(category.bytes)}, {category.files} files
+              </span>
+              {category.cleanup && (
+                <Button
+                  variant="outline"
+                  size="sm"
+                  className="h-6 px-2 text-xs"
+                  disabled={busy !== null || category.bytes === 0}
+                  onClick={() => cleanup(category.cleanup!)}
+                >
+                  {cleanupLabels[category.cleanup] ?? category.cleanup}
+                </Button>
+              )}
+            </div>
+          ))}
+        </div>
+      )}
+
+      {report && report.projects.length > 0 && (
+        <div className="space-y-1">
+          <h4 className="text-sm font-medium">Transcripts by project</h4>
+          {report.projects.slice(0, 20).map((project) => (
+            <div
+              key={`${project.path}:${project.name}`}
+              className="flex justify-between gap-2 text-xs text-muted-foreground"
+            >
+              <span className={`truncate ${project.indexed ? "text-foreground" : ""}`} title={project.path || project.name}>
+                {project.name}
+              </span>
+              <span className="shrink-0">
+                {project.transcripts} transcripts, {formatBytes(project.bytes)}
+                {project.reclaimable_bytes > 0 && ` — ${formatBytes(project.reclaimable_bytes)} to archive`}
+              </span>
+            </div>
+          ))}
+        </div>
+      )}
+
+      {message && <p className="text-xs text-muted-foreground">{message}</p>}
+    </div>
+  );
+};
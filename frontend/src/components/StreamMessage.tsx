@@ -49,6 +49,7 @@ import {
 } from "./ToolWidgets";
 import { getUserMessagePresentation } from "./ai-code-session/utils/messagePresentation";
 import { summarizeRuntimeMessage } from "./ai-code-session/utils/runtimePresentation";
+import { OutputImages, isImageBlock } from "./OutputImages";
 
 export interface StreamMessageContext {
   toolResults: Map<string, any>;
@@ -729,6 +730,12 @@ const StreamMessageComponent: React.FC<StreamMessageProps> = ({ message, classNa
                     );
                   }
                   
+                  // Image content - stored images are loaded from their file
+                  if (content.type === "image") {
+                    renderedSomething = true;
+                    return <OutputImages key={idx} blocks={[content]} />;
+                  }
+
                   // Tool use - render custom widgets based on tool name
                   if (content.type === "tool_use" || content.type === "server_tool_use") {
                     const toolName = content.name?.toLowerCase();
@@ -964,6 +971,12 @@ const StreamMessageComponent: React.FC<StreamMessageProps> = ({ message, classNa
 
                 {/* Handle content that is an array of parts */}
                 {Array.isArray(msg.content) && msg.content.map((content: any, idx: number) => {
+                  if (content.type === "image") {
+                    if (!isImageBlock(content)) return null;
+                    renderedSomething = true;
+                    return <OutputImages key={idx} blocks={[content]} />;
+                  }
+
                   if (content.type === "text") {
                     const textContent = typeof content.text === 'string'
                       ? content.text
@@ -1016,8 +1029,13 @@ const StreamMessageComponent: React.FC<StreamMessageProps> = ({ message, classNa
                       }
                     }
 
+                    // Images in the result are shown even next to a widget
+                    const resultImages = Array.isArray(content.content) ? content.content.filter(isImageBlock) : [];
+
                     if (hasCorrespondingWidget) {
-                      return null;
+                      if (resultImages.length === 0) return null;
+                      renderedSomething = true;
+                      return <div key={idx} className="ml-6"><OutputImages blocks={resultImages} /></div>;
                     }
                     // Extract the actual content string
                     let contentText = '';
@@ -1034,8 +1052,9 @@ const StreamMessageComponent: React.FC<StreamMessageProps> = ({ message, classNa
                       } else if (content.content.content) {
                         contentText = content.content.content;
                       } else if (Array.isArray(content.content)) {
-                        // Handle array of content blocks
+                        // Handle array of content blocks; images are shown below the text
                         contentText = content.content
+                          .filter((c: any) => !isImageBlock(c))
                           .map((c: any) => {
                             if (typeof c === 'string') return c;
                             if (c.text) return c.text;
@@ -1095,6 +1114,28 @@ const StreamMessageComponent: React.FC<StreamMessageProps> = ({ message, classNa
                       contentText = String(content.content || '');
                     }
                     
+                    if (resultImages.length > 0) {
+                      renderedSomething = true;
+                      return (
+                        <div key={idx} className="space-y-2">
+                          <div className="flex items-center gap-2">
+                            <CheckCircle2 className="h-4 w-4 text-green-500" />
+                            <span className="text-sm font-medium">Tool Result</span>
+                          </div>
+                          {contentText.trim() && (
+                            <div className="ml-6 p-2 bg-background rounded-md border">
+                              <pre className="text-xs font-mono overflow-x-auto whitespace-pre-wrap">
+                                {contentText}
+                              </pre>
+                            </div>
+                          )}
+                          <div className="ml-6">
+                            <OutputImages blocks={resultImages} />
+                          </div>
+                        </div>
+                      );
+                    }
+
                     // Always show system reminders regardless of widget status
                     const reminderMatch = contentText.match(/<system-reminder>(.*?)<\/system-reminder>/s);
                     if (reminderMatch) {
@@ -0,0 +1,113 @@
+import React, { useState, useEffect, useCallback } from "react";
+import { Send, Trash2 } from "lucide-react";
+import { Button } from "@/components/ui/button";
+import { Label } from "@/components/ui/label";
+import { Switch } from "@/components/ui/switch";
+import {
+  GetSettings,
+  UpdateSettings,
+  ViewPendingTelemetry,
+  FlushTelemetry,
+  ClearTelemetry,
+  type main,
+} from "@/lib/rpc-client";
+
+/** Opt-in anonymous telemetry: the switch, and the report waiting to be sent */
+export const TelemetrySettings: React.FC = () => {
+  const [enabled, setEnabled] = useState(false);
+  const [endpoint, setEndpoint] = useState("");
+  const [report, setReport] = useState<main.TelemetryReport | null>(null);
+  const [message, setMessage] = useState<string | null>(null);
+  const [busy, setBusy] = useState(false);
+
+  const refresh = useCallback(() => {
+    ViewPendingTelemetry().then(setReport).catch(() => {});
+  }, []);
+
+  useEffect(() => {
+    GetSettings().then((values) => {
+      setEnabled(values.telemetry_enabled === true);
+      setEndpoint(typeof values.telemetry_endpoint === "string" ? values.telemetry_endpoint : "");
+    }).catch(() => {});
+    refresh();
+  }, [refresh]);
+
+  const handleToggle = async (checked: boolean) => {
+    setEnabled(checked);
+    setMessage(null);
+    try {
+      await UpdateSettings({ telemetry_enabled: checked });
+      refresh();
+    } catch (err) {
+      setEnabled(!checked);
+      setMessage(String(err));
+    }
+  };
+
+  const run = async (action: () => Promise<string>) => {
+    setBusy(true);
+    try {
+      setMessage(await action());
+    } catch (err) {
+      setMessage(String(err));
+    } finally {
+      setBusy(false);
+      refresh();
+    }
+  };
+
+  const pending = report?.metrics.length ?? 0;
+
+  return (
+    <div className="space-y-4">
+      <div className="flex items-center justify-between">
+        <div>
+          <Label htmlFor="telemetry-enabled">Anonymous usage statistics</Label>
+          <p className="text-caption text-muted-foreground mt-1">
+            Counts of features used and how long sessions ran, kept on this machine until you send them.
+            No prompts, output, paths or model names.
+          </p>
+        </div>
+        <Switch id="telemetry-enabled" checked={enabled} onCheckedChange={handleToggle} />
+      </div>
+
+      {enabled && (
+        <>
+          <div className="flex items-center gap-2">
+            <span className="text-xs text-muted-foreground flex-1">
+              {pending === 0 ? "Nothing waiting to be sent" : `${pending} metrics waiting to be sent`}
+            </span>
+            <Button
+              variant="outline"
+              size="sm"
+              className="gap-1.5"
+              disabled={busy || pending === 0 || !endpoint}
+              title={endpoint ? `Send to ${endpoint}` : "No telemetry endpoint is configured"}
+              onClick={() => run(async () => `Sent ${(await FlushTelemetry()).sent} metrics`)}
+            >
+              <Send className="h-3 w-3" />
+              Send now
+            </Button>
+            <Button
+              variant="ghost"
+              size="sm"
+              className="gap-1.5"
+              disabled={busy || pending === 0}
+              onClick={() => run(async () => { await ClearTelemetry(); return "Discarded pending metrics"; })}
+            >
+              <Trash2 className="h-3 w-3" />
+              Discard
+            </Button>
+          </div>
+          {pending > 0 && (
+            <pre className="max-h-64 overflow-auto rounded-lg border bg-black/30 p-2 font-mono text-xs">
+              {JSON.stringify(report, null, 2)}
+            </pre>
+          )}
+        </>
+      )}
+
+      {message && <p className="text-xs text-muted-foreground">{message}</p>}
+    </div>
+  );
+};
@@ -0,0 +1,185 @@
+import React, { useState, useEffect, useCallback } from "react";
+import { Trash2 } from "lucide-react";
+import { Button } from "@/components/ui/button";
+import { Input } from "@/components/ui/input";
+import { Label } from "@/components/ui/label";
+import { Select, SelectContent, SelectItem, SelectTrigger, SelectValue } from "@/components/ui/select";
+import {
+  GetSettings,
+  UpdateSettings,
+  GetTempImagesStats,
+  CleanupTempImages,
+  type main,
+} from "@/lib/rpc-client";
+
+function formatBytes(n: number) {
+  return n >= 1 << 20 ? `${(n / (1 << 20)).toFixed(1)} MB` : `${Math.round(n / 1024)} KB`;
+}
+
+/** Pasted images: how they are shrunk, how much space they take, how long they are kept, and a manual cleanup */
+export const TempImagesSettings: React.FC = () => {
+  const [stats, setStats] = useState<main.TempImagesStats | null>(null);
+  const [retentionDays, setRetentionDays] = useState("");
+  const [maxMB, setMaxMB] = useState("");
+  const [format, setFormat] = useState("original");
+  const [maxWidth, setMaxWidth] = useState("");
+  const [maxHeight, setMaxHeight] = useState("");
+  const [quality, setQuality] = useState("");
+  const [message, setMessage] = useState<string | null>(null);
+  const [busy, setBusy] = useState(false);
+
+  const refresh = useCallback(() => {
+    GetTempImagesStats().then(setStats).catch(() => {});
+  }, []);
+
+  useEffect(() => {
+    GetSettings().then((values) => {
+      setRetentionDays(String(values.temp_images_retention_days ?? ""));
+      setMaxMB(String(values.temp_images_max_mb ?? ""));
+      setFormat(String(values.paste_image_format ?? "original"));
+      setMaxWidth(String(values.paste_image_max_width ?? ""));
+      setMaxHeight(String(values.paste_image_max_height ?? ""));
+      setQuality(String(values.paste_image_quality ?? ""));
+    }).catch(() => {});
+    refresh();
+  }, [refresh]);
+
+  const update = async (key: string, value: number | string) => {
+    setMessage(null);
+    try {
+      await UpdateSettings({ [key]: value });
+    } catch (err) {
+      setMessage(String(err));
+    }
+  };
+
+  const save = (key: string, value: string, min = 0, max = Infinity) => {
+    const n = Number(value);
+    if (value.trim() === "" || !Number.isFinite(n) || n < min || n > max) {
+      setMessage(max === Infinity ? `Enter a number of at least ${min}` : `Enter a number from ${min} to ${max}`);
+      return;
+    }
+    update(key, n);
+  };
+
+  const cleanup = async () => {
+    setBusy(true);
+    try {
+      const result = await CleanupTempImages();
+      setStats(result.stats);
+      setMessage(result.removed === 0
+        ? "Nothing to remove"
+        : `Removed ${result.removed} images, ${formatBytes(result.freed_bytes)}`);
+    } catch (err) {
+      setMessage(String(err));
+    } finally {
+      setBusy(false);
+    }
+  };
+
+  return (
+    <div className="space-y-4">
+      <div>
+        <h3 className="text-heading-4 mb-2">Pasted Images</h3>
+        <p className="text-body-small text-muted-foreground">
+          Images pasted or dropped into prompts are saved so the CLI can read them.
+          Large pasted images can be scaled down and converted to JPEG to keep prompts small;
+          WebP images are saved as pasted. Images no session used are removed after a day.
+        </p>
+      </div>
+
+      <div className="grid grid-cols-2 gap-4">
+        <div className="space-y-1">
+          <Label>Save pasted images as</Label>
+          <Select
+            value={format}
+            onValueChange={(value) => {
+              setFormat(value);
+              update("paste_image_format", value);
+            }}
+          >
+            <SelectTrigger>
+              <SelectValue />
+            </SelectTrigger>
+            <SelectContent>
+              <SelectItem value="original">Original format</SelectItem>
+              <SelectItem value="jpeg">JPEG</SelectItem>
+              <SelectItem value="png">PNG</SelectItem>
+            </SelectContent>
+          </Select>
+        </div>
+        <div className="space-y-1">
+          <Label htmlFor="paste-image-quality">JPEG quality (1–100)</Label>
+          <Input
+            id="paste-image-quality"
+            type="number"
+            min={1}
+            max={100}
+            value={quality}
+            disabled={format !== "jpeg"}
+            onChange={(e) => setQuality(e.target.value)}
+            onBlur={() => save("paste_image_quality", quality, 1, 100)}
+          />
+        </div>
+        <div className="space-y-1">
+          <Label htmlFor="paste-image-max-width">Max width (px, 0 = none)</Label>
+          <Input
+            id="paste-image-max-width"
+            type="number"
+            min={0}
+            value={maxWidth}
+            onChange={(e) => setMaxWidth(e.target.value)}
+            onBlur={() => save("paste_image_max_width", maxWidth)}
+          />
+        </div>
+        <div className="space-y-1">
+          <Label htmlFor="paste-image-max-height">Max height (px, 0 = none)</Label>
+          <Input
+            id="paste-image-max-height"
+            type="number"
+            min={0}
+            value={maxHeight}
+            onChange={(e) => setMaxHeight(e.target.value)}
+            onBlur={() => save("paste_image_max_height", maxHeight)}
+          />
+        </div>
+        <div className="space-y-1">
+          <Label htmlFor="temp-images-retention">Keep used images (days, 0 = forever)</Label>
+          <Input
+            id="temp-images-retention"
+            type="number"
+            min={0}
+            value={retentionDays}
+            onChange={(e) => setRetentionDays(e.target.value)}
+            onBlur={() => save("temp_images_retention_days", retentionDays)}
+          />
+        </div>
+        <div className="space-y-1">
+          <Label htmlFor="temp-images-max-mb">Size limit (MB, 0 = none)</Label>
+          <Input
+            id="temp-images-max-mb"
+            type="number"
+            min={0}
+            value={maxMB}
+            onChange={(e) => setMaxMB(e.target.value)}
+            onBlur={() => save("temp_images_max_mb", maxMB)}
+          />
+        </div>
+      </div>
+
+      <div className="flex items-center gap-2">
+        <span className="text-xs text-muted-foreground flex-1">
+          {stats
+            ? `${stats.count} images, ${formatBytes(stats.bytes)} — ${stats.referenced} used by sessions`
+            : "Loading…"}
+        </span>
+        <Button variant="outline" size="sm" className="gap-1.5" disabled={busy} onClick={cleanup}>
+          <Trash2 className="h-3 w-3" />
+          Clean up now
+        </Button>
+      </div>
+
+      {message && <p className="text-xs text-muted-foreground">{message}</p>}
+    </div>
+  );
+};
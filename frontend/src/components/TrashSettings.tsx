@@ -0,0 +1,136 @@
+import React, { useState, useEffect, useCallback } from "react";
+import { RotateCcw } from "lucide-react";
+import { Button } from "@/components/ui/button";
+import { Input } from "@/components/ui/input";
+import { Label } from "@/components/ui/label";
+import { Switch } from "@/components/ui/switch";
+import {
+  GetSettings,
+  UpdateSettings,
+  ListTrash,
+  RestoreFromTrash,
+  type trash,
+} from "@/lib/rpc-client";
+
+function formatBytes(n: number) {
+  return n >= 1 << 20 ? `${(n / (1 << 20)).toFixed(1)} MB` : `${Math.round(n / 1024)} KB`;
+}
+
+/** Trash for files discarded by workspace cleanups and rejected edits, with restore */
+export const TrashSettings: React.FC = () => {
+  const [enabled, setEnabled] = useState(false);
+  const [retentionDays, setRetentionDays] = useState("");
+  const [items, setItems] = useState<trash.Item[]>([]);
+  const [message, setMessage] = useState<string | null>(null);
+  const [restoring, setRestoring] = useState<string | null>(null);
+
+  const refresh = useCallback(() => {
+    ListTrash().then(setItems).catch(() => {});
+  }, []);
+
+  useEffect(() => {
+    GetSettings().then((values) => {
+      setEnabled(values.trash_discarded_files === true);
+      setRetentionDays(String(values.trash_retention_days ?? ""));
+    }).catch(() => {});
+    refresh();
+  }, [refresh]);
+
+  const update = async (key: string, value: number | boolean) => {
+    setMessage(null);
+    try {
+      await UpdateSettings({ [key]: value });
+      return true;
+    } catch (err) {
+      setMessage(String(err));
+      return false;
+    }
+  };
+
+  const saveRetention = () => {
+    const n = Number(retentionDays);
+    if (retentionDays.trim() === "" || !Number.isFinite(n) || n < 0) {
+      setMessage("Enter a number of at least 0");
+      return;
+    }
+    update("trash_retention_days", n);
+  };
+
+  const restore = async (item: trash.Item) => {
+    setRestoring(item.id);
+    setMessage(null);
+    try {
+      await RestoreFromTrash(item.id);
+      setMessage(`Restored ${item.path}`);
+      refresh();
+    } catch (err) {
+      setMessage(String(err));
+    } finally {
+      setRestoring(null);
+    }
+  };
+
+  return (
+    <div className="space-y-4">
+      <div className="flex items-center justify-between">
+        <div className="space-y-1">
+          <h3 className="text-heading-4">Trash</h3>
+          <p className="text-body-small text-muted-foreground">
+            Move the untracked files a workspace cleanup removes, and copies of the changed files it
+            or a rejected edit overwrites, to ~/.ropcode/trash instead of deleting them.
+          </p>
+        </div>
+        <Switch
+          checked={enabled}
+          onCheckedChange={async (checked) => {
+            setEnabled(checked);
+            if (!(await update("trash_discarded_files", checked))) setEnabled(!checked);
+          }}
+        />
+      </div>
+
+      <div className="space-y-1">
+        <Label htmlFor="trash-retention">Keep trashed files (days, 0 = forever)</Label>
+        <Input
+          id="trash-retention"
+          type="number"
+          min={0}
+          value={retentionDays}
+          onChange={(e) => setRetentionDays(e.target.value)}
+          onBlur={saveRetention}
+        />
+      </div>
+
+      {items.length === 0 ? (
+        <p className="text-xs text-muted-foreground">The trash is empty</p>
+      ) : (
+        <div className="max-h-64 overflow-y-auto space-y-1">
+          {items.map((item) => (
+            <div key={item.id} className="flex items-center gap-2 text-xs">
+              <div className="flex-1 min-w-0">
+                <div className="truncate font-mono" title={item.path}>
+                  {item.path}{item.dir ? "/" : ""}
+                </div>
+                <div className="text-muted-foreground">
+                  {item.reason} · {new Date(item.trashed_at).toLocaleString()} · {formatBytes(item.size)}
+                </div>
+              </div>
+              <Button
+                variant="outline"
+                size="sm"
+                className="gap-1.5"
+                disabled={restoring !== null}
+                onClick={() => restore(item)}
+              >
+                <RotateCcw className="h-3 w-3" />
+                Restore
+              </Button>
+            </div>
+          ))}
+        </div>
+      )}
+
+      {message && <p className="text-xs text-muted-foreground">{message}</p>}
+    </div>
+  );
+};
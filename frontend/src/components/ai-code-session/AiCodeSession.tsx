@@ -48,6 +48,9 @@ import { buildSessionStatusBarModel, type SessionStatusPromptConfig, type Sessio
 import { classifyPromptSubmit } from "./utils/promptSubmitClassification";
 import { generateSessionTitleViaEvent } from "@/lib/titleGeneration";
 import { MessageStreamView } from "./MessageStreamView";
+import { CheckpointRestoreButton } from "./CheckpointRestoreButton";
+import { ChangedFilesButton } from "./ChangedFilesButton";
+import { ShareSessionButton } from "./ShareSessionButton";
 import { useWorkspaceTodo } from "@/contexts/WorkspaceTodoContext";
 
 // Import refactored hooks and types
@@ -1512,6 +1515,21 @@ ${message ? `**说明**:\n${message}` : ''}`;
 
   const copyConversationMenu = React.useMemo(() => (
     messagesState.messages.length > 0 ? (
+      <>
+      <ChangedFilesButton
+        sessionId={sessionState.claudeSessionId}
+        projectPath={sessionState.projectPath}
+        isRunning={processState.isLoading}
+      />
+      <CheckpointRestoreButton
+        sessionId={sessionState.claudeSessionId}
+        isRunning={processState.isLoading}
+      />
+      <ShareSessionButton
+        sessionId={sessionState.claudeSessionId}
+        projectPath={sessionState.projectPath}
+        provider={defaultProvider}
+      />
       <Popover
         trigger={
           <TooltipSimple content="Copy conversation" side="top">
@@ -1549,8 +1567,9 @@ ${message ? `**说明**:\n${message}` : ''}`;
         side="top"
         align="end"
       />
+      </>
     ) : undefined
-  ), [copyPopoverOpen, handleCopyAsJsonl, handleCopyAsMarkdown, messagesState.messages.length]);
+  ), [copyPopoverOpen, defaultProvider, handleCopyAsJsonl, handleCopyAsMarkdown, messagesState.messages.length, processState.isLoading, sessionState.claudeSessionId, sessionState.projectPath]);
 
   const handlePromptConfigChange = useCallback((config: SessionStatusPromptConfig) => {
     setPromptConfig(config);
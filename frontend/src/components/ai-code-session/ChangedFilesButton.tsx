@@ -0,0 +1,240 @@
+import React, { useCallback, useEffect, useState } from "react";
+import { FileDiff, Check, Undo2, ChevronRight, ChevronDown } from "lucide-react";
+import { Button } from "@/components/ui/button";
+import { Popover } from "@/components/ui/popover";
+import { TooltipSimple } from "@/components/ui/tooltip-modern";
+import {
+  GetSessionChangedFiles,
+  GetSessionEditHunks,
+  AcceptSessionEdit,
+  RejectSessionEdit,
+  type filechanges,
+  type git,
+} from "@/lib/rpc-client";
+import { getDiffFilePaths } from "@/lib/diffPath";
+import { cn } from "@/lib/utils";
+
+interface ChangedFilesButtonProps {
+  /** 提供商的会话 ID */
+  sessionId: string | null;
+  projectPath: string;
+  /** 运行结束后刷新列表；运行中不允许审查 */
+  isRunning: boolean;
+}
+
+const KIND_LABELS: Record<filechanges.File["kind"], { label: string; className: string }> = {
+  created: { label: "A", className: "text-green-500" },
+  modified: { label: "M", className: "text-yellow-500" },
+  deleted: { label: "D", className: "text-red-500" },
+};
+
+const GIT_STATUS = { created: "added", modified: "modified", deleted: "deleted" } as const;
+
+const formatRanges = (file: filechanges.File) =>
+  file.ranges
+    .slice(0, 4)
+    .map(r => (r.start === r.end ? `${r.start}` : `${r.start}-${r.end}`))
+    .join(", ") + (file.ranges.length > 4 ? "…" : "");
+
+const hunkLineClass = (line: string) =>
+  line.startsWith("+") ? "text-green-600 dark:text-green-400"
+    : line.startsWith("-") ? "text-red-600 dark:text-red-400"
+      : "text-muted-foreground";
+
+/**
+ * 本次对话修改过的文件：点击在右侧栏打开 diff，
+ * 并可逐个文件或逐个 hunk 接受 / 拒绝（拒绝即还原到会话开始前的检查点）
+ */
+export const ChangedFilesButton: React.FC<ChangedFilesButtonProps> = ({ sessionId, projectPath, isRunning }) => {
+  const [files, setFiles] = useState<filechanges.File[]>([]);
+  const [open, setOpen] = useState(false);
+  const [expanded, setExpanded] = useState<string | null>(null);
+  const [hunks, setHunks] = useState<git.CheckpointHunk[]>([]);
+  const [busy, setBusy] = useState(false);
+  const [error, setError] = useState<string | null>(null);
+
+  const refresh = useCallback(() => {
+    if (!sessionId) {
+      setFiles([]);
+      return () => {};
+    }
+    let cancelled = false;
+    GetSessionChangedFiles(sessionId)
+      .then(list => { if (!cancelled) setFiles(list || []); })
+      .catch(() => { if (!cancelled) setFiles([]); });
+    return () => { cancelled = true; };
+  }, [sessionId]);
+
+  useEffect(() => {
+    if (isRunning) return;
+    return refresh();
+  }, [isRunning, refresh]);
+
+  const loadHunks = useCallback(async (path: string) => {
+    if (!sessionId) return;
+    try {
+      setHunks(await GetSessionEditHunks(sessionId, path) || []);
+    } catch (err) {
+      setHunks([]);
+      setError(err instanceof Error ? err.message : String(err));
+    }
+  }, [sessionId]);
+
+  const handleOpenChange = useCallback((next: boolean) => {
+    setOpen(next);
+    if (next) {
+      setError(null);
+      refresh();
+    }
+  }, [refresh]);
+
+  const toggleExpanded = useCallback((path: string) => {
+    setError(null);
+    if (expanded === path) {
+      setExpanded(null);
+      return;
+    }
+    setExpanded(path);
+    setHunks([]);
+    loadHunks(path);
+  }, [expanded, loadHunks]);
+
+  // 接受 / 拒绝后刷新文件列表和展开的 hunk
+  const review = useCallback(async (action: () => Promise<void>) => {
+    setBusy(true);
+    setError(null);
+    try {
+      await action();
+      refresh();
+      if (expanded) await loadHunks(expanded);
+    } catch (err) {
+      setError(err instanceof Error ? err.message : String(err));
+    } finally {
+      setBusy(false);
+    }
+  }, [expanded, loadHunks, refresh]);
+
+  const openDiff = useCallback((file: filechanges.File) => {
+    window.dispatchEvent(new CustomEvent("open-diff", {
+      detail: { filePath: file.path, projectPath, gitStatus: GIT_STATUS[file.kind] },
+    }));
+    setOpen(false);
+  }, [projectPath]);
+
+  if (!sessionId || files.length === 0) return null;
+  const reviewDisabled = busy || isRunning;
+
+  return (
+    <Popover
+      trigger={
+        <TooltipSimple content="Files modified in this conversation" side="top">
+          <Button
+            variant="ghost"
+            size="icon"
+            className="relative h-9 w-9 text-muted-foreground hover:text-foreground active:scale-[0.97]"
+          >
+            <FileDiff className="h-3.5 w-3.5" />
+            <span className="absolute right-1 top-1 text-[9px] leading-none">{files.length}</span>
+          </Button>
+        </TooltipSimple>
+      }
+      content={
+        <div className="max-h-96 w-[28rem] overflow-y-auto p-1">
+          {files.map(file => {
+            const kind = KIND_LABELS[file.kind];
+            const { gitPath } = getDiffFilePaths(file.path, projectPath);
+            const isExpanded = expanded === file.path;
+            return (
+              <div key={file.path}>
+                <div className="group flex items-center gap-1 rounded px-1 text-xs hover:bg-accent">
+                  <button
+                    onClick={() => toggleExpanded(file.path)}
+                    className="shrink-0 p-0.5 text-muted-foreground"
+                    aria-label="Show hunks"
+                  >
+                    {isExpanded ? <ChevronDown className="h-3 w-3" /> : <ChevronRight className="h-3 w-3" />}
+                  </button>
+                  <button
+                    onClick={() => openDiff(file)}
+                    title={`${file.path}\n${file.changes.length} change${file.changes.length === 1 ? "" : "s"}`}
+                    className="flex min-w-0 flex-1 items-center gap-2 py-1 text-left"
+                  >
+                    <span className={cn("w-3 shrink-0 font-mono", kind.className)}>{kind.label}</span>
+                    <span className={cn("min-w-0 flex-1 truncate", file.review === "rejected" && "line-through text-muted-foreground")}>
+                      {gitPath || file.path}
+                    </span>
+                    {file.ranges.length > 0 && (
+                      <span className="shrink-0 font-mono text-muted-foreground">{formatRanges(file)}</span>
+                    )}
+                  </button>
+                  {file.review && (
+                    <span className="shrink-0 text-[10px] text-muted-foreground">{file.review}</span>
+                  )}
+                  <TooltipSimple content="Accept" side="top">
+                    <Button
+                      variant="ghost"
+                      size="icon"
+                      className="h-6 w-6 shrink-0"
+                      disabled={reviewDisabled || file.review === "accepted"}
+                      onClick={() => review(() => AcceptSessionEdit(sessionId, file.path))}
+                    >
+                      <Check className="h-3 w-3" />
+                    </Button>
+                  </TooltipSimple>
+                  <TooltipSimple content="Reject: restore the file from before this session" side="top">
+                    <Button
+                      variant="ghost"
+                      size="icon"
+                      className="h-6 w-6 shrink-0"
+                      disabled={reviewDisabled || file.review === "rejected"}
+                      onClick={() => {
+                        if (!confirm(`Restore ${gitPath || file.path} to how it was before this session?`)) return;
+                        review(() => RejectSessionEdit(sessionId, file.path, -1));
+                      }}
+                    >
+                      <Undo2 className="h-3 w-3" />
+                    </Button>
+                  </TooltipSimple>
+                </div>
+                {isExpanded && (
+                  <div className="mb-1 ml-5 space-y-1">
+                    {hunks.length === 0 && !error && (
+                      <div className="px-1 py-1 text-xs text-muted-foreground">No changes left</div>
+                    )}
+                    {hunks.map((hunk, idx) => (
+                      <div key={`${hunk.header}-${idx}`} className="rounded border">
+                        <div className="flex items-center justify-between bg-muted/50 px-2 py-0.5">
+                          <span className="truncate font-mono text-[10px] text-muted-foreground">{hunk.header}</span>
+                          <Button
+                            variant="ghost"
+                            size="sm"
+                            className="h-5 px-1.5 text-[10px]"
+                            disabled={reviewDisabled}
+                            onClick={() => review(() => RejectSessionEdit(sessionId, file.path, idx))}
+                          >
+                            Reject hunk
+                          </Button>
+                        </div>
+                        <pre className="max-h-40 overflow-auto px-2 py-1 font-mono text-[10px] leading-4">
+                          {hunk.lines.map((line, i) => (
+                            <div key={i} className={hunkLineClass(line)}>{line || " "}</div>
+                          ))}
+                        </pre>
+                      </div>
+                    ))}
+                  </div>
+                )}
+              </div>
+            );
+          })}
+          {isRunning && <div className="px-2 py-1 text-xs text-muted-foreground">Stop the session to review its edits.</div>}
+          {error && <div className="px-2 py-1 text-xs text-destructive">{error}</div>}
+        </div>
+      }
+      open={open}
+      onOpenChange={handleOpenChange}
+      side="top"
+      align="end"
+    />
+  );
+};
@@ -0,0 +1,107 @@
+import React, { useCallback, useEffect, useState } from "react";
+import { Undo2, Loader2 } from "lucide-react";
+import { Button } from "@/components/ui/button";
+import { Popover } from "@/components/ui/popover";
+import { TooltipSimple } from "@/components/ui/tooltip-modern";
+import { ListCheckpoints, RestoreCheckpoint, type database, type git } from "@/lib/rpc-client";
+
+interface CheckpointRestoreButtonProps {
+  /** 提供商的会话 ID */
+  sessionId: string | null;
+  /** 会话运行中时不允许回滚 */
+  isRunning: boolean;
+}
+
+/**
+ * 会话检查点：把项目文件回滚到会话第一次运行之前的状态
+ * 只在会话有检查点时显示（需开启 session_checkpoints_enabled）
+ */
+export const CheckpointRestoreButton: React.FC<CheckpointRestoreButtonProps> = ({ sessionId, isRunning }) => {
+  const [checkpoints, setCheckpoints] = useState<database.SessionCheckpoint[]>([]);
+  const [open, setOpen] = useState(false);
+  const [restoring, setRestoring] = useState(false);
+  const [result, setResult] = useState<git.RestoreResult | null>(null);
+  const [error, setError] = useState<string | null>(null);
+
+  // 新会话的检查点在会话报告 ID 后才关联上，每次运行结束后重新加载
+  useEffect(() => {
+    if (!sessionId || isRunning) return;
+    let cancelled = false;
+    ListCheckpoints(sessionId)
+      .then(list => { if (!cancelled) setCheckpoints(list || []); })
+      .catch(() => { if (!cancelled) setCheckpoints([]); });
+    return () => { cancelled = true; };
+  }, [sessionId, isRunning]);
+
+  const handleOpenChange = useCallback((next: boolean) => {
+    setOpen(next);
+    if (next) {
+      setResult(null);
+      setError(null);
+    }
+  }, []);
+
+  const handleRestore = useCallback(async () => {
+    if (!sessionId || isRunning) return;
+    if (!confirm("Roll back every file change made in this session? Changes made since the session started, by you or the agent, are replaced.")) return;
+    setRestoring(true);
+    setError(null);
+    try {
+      setResult(await RestoreCheckpoint(sessionId));
+      setCheckpoints(await ListCheckpoints(sessionId));
+    } catch (err) {
+      setError(err instanceof Error ? err.message : String(err));
+    } finally {
+      setRestoring(false);
+    }
+  }, [sessionId, isRunning]);
+
+  if (!sessionId || checkpoints.length === 0) return null;
+  const first = checkpoints[0];
+
+  return (
+    <Popover
+      trigger={
+        <TooltipSimple content="Checkpoint" side="top">
+          <Button
+            variant="ghost"
+            size="icon"
+            className="h-9 w-9 text-muted-foreground hover:text-foreground active:scale-[0.97]"
+          >
+            <Undo2 className="h-3.5 w-3.5" />
+          </Button>
+        </TooltipSimple>
+      }
+      content={
+        <div className="w-64 space-y-2 p-2 text-xs">
+          <div className="text-muted-foreground">
+            Files snapshotted {new Date(first.created_at).toLocaleString()}
+            {first.restored_at && <> · last restored {new Date(first.restored_at).toLocaleString()}</>}
+          </div>
+          <Button
+            variant="outline"
+            size="sm"
+            onClick={handleRestore}
+            disabled={isRunning || restoring}
+            className="w-full justify-start text-xs"
+          >
+            {restoring && <Loader2 className="mr-2 h-3 w-3 animate-spin" />}
+            Restore files to before this session
+          </Button>
+          {isRunning && <div className="text-muted-foreground">Stop the session to restore.</div>}
+          {result && (
+            <div className="text-muted-foreground">
+              Restored {result.restored.length} file{result.restored.length === 1 ? "" : "s"}, removed {result.removed.length}.
+              The files from before the restore are kept at refs/ropcode/checkpoints/before-restore.
+            </div>
+          )}
+          {error && <div className="text-destructive">{error}</div>}
+        </div>
+      }
+      open={open}
+      onOpenChange={handleOpenChange}
+      side="top"
+      align="end"
+    />
+  );
+};
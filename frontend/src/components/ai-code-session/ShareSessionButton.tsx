@@ -0,0 +1,97 @@
+import React, { useCallback, useState } from "react";
+import { Share2, Loader2 } from "lucide-react";
+import { Button } from "@/components/ui/button";
+import { Popover } from "@/components/ui/popover";
+import { Switch } from "@/components/ui/switch";
+import { TooltipSimple } from "@/components/ui/tooltip-modern";
+import { ShareSession } from "@/lib/rpc-client";
+
+interface ShareSessionButtonProps {
+  /** 提供商的会话 ID */
+  sessionId: string | null;
+  projectPath: string;
+  provider: string;
+}
+
+/**
+ * 分享会话：把对话、改动的 diff 和用量导出为一个独立的 HTML 文件，
+ * 可附到 PR 上或发给同事；可选隐藏项目路径和主目录
+ */
+export const ShareSessionButton: React.FC<ShareSessionButtonProps> = ({ sessionId, projectPath, provider }) => {
+  const [open, setOpen] = useState(false);
+  const [redactPaths, setRedactPaths] = useState(true);
+  const [exporting, setExporting] = useState(false);
+  const [error, setError] = useState<string | null>(null);
+
+  const handleOpenChange = useCallback((next: boolean) => {
+    setOpen(next);
+    if (next) setError(null);
+  }, []);
+
+  const handleExport = useCallback(async () => {
+    if (!sessionId) return;
+    setExporting(true);
+    setError(null);
+    try {
+      const share = await ShareSession(sessionId, projectPath, provider, redactPaths);
+      // 在浏览器里下载生成的文件
+      const url = URL.createObjectURL(new Blob([share.html], { type: "text/html" }));
+      const link = document.createElement("a");
+      link.href = url;
+      link.download = share.file_name;
+      document.body.appendChild(link);
+      link.click();
+      link.remove();
+      URL.revokeObjectURL(url);
+      setOpen(false);
+    } catch (err) {
+      setError(err instanceof Error ? err.message : String(err));
+    } finally {
+      setExporting(false);
+    }
+  }, [sessionId, projectPath, provider, redactPaths]);
+
+  if (!sessionId) return null;
+
+  return (
+    <Popover
+      trigger={
+        <TooltipSimple content="Share session" side="top">
+          <Button
+            variant="ghost"
+            size="icon"
+            className="h-9 w-9 text-muted-foreground hover:text-foreground active:scale-[0.97]"
+          >
+            <Share2 className="h-3.5 w-3.5" />
+          </Button>
+        </TooltipSimple>
+      }
+      content={
+        <div className="w-64 space-y-2 p-2 text-xs">
+          <div className="text-muted-foreground">
+            Save the transcript, diffs and usage as one HTML file.
+          </div>
+          <label className="flex items-center justify-between gap-2">
+            <span>Redact file paths</span>
+            <Switch checked={redactPaths} onCheckedChange={setRedactPaths} />
+          </label>
+          <Button
+            variant="outline"
+            size="sm"
+            onClick={handleExport}
+            disabled={exporting}
+            className="w-full justify-start text-xs"
+          >
+            {exporting && <Loader2 className="mr-2 h-3 w-3 animate-spin" />}
+            Download HTML
+          </Button>
+          {error && <div className="text-destructive">{error}</div>}
+        </div>
+      }
+      open={open}
+      onOpenChange={handleOpenChange}
+      side="top"
+      align="end"
+    />
+  );
+};
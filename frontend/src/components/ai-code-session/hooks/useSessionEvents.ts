@@ -8,6 +8,8 @@
 import { useEffect, useCallback, useRef } from "react";
 import type { ClaudeStreamMessage, Session, SessionInfo, SessionRuntimeTracker } from "../types";
 import { api } from "@/lib/api";
+import { EventsOn } from "@/lib/rpc-events";
+import type { main } from "@/lib/rpc-client";
 import { SessionPersistenceService } from "@/services/sessionPersistence";
 import { useWorkspaceTodo, type TodoItem } from "@/contexts/WorkspaceTodoContext";
 import {
@@ -548,12 +550,29 @@ export function useSessionEvents(options: UseSessionEventsOptions): UseSessionEv
       processComplete(customEvent.detail);
     };
 
+    // A session that failed on its provider API config was started again on
+    // the next config of the failover chain; its output follows in this view
+    const handleFailover = (failover: main.ProviderFailover) => {
+      if (failover.project_path !== projectPath) return;
+      addMessage({
+        type: "error",
+        error: `API configuration failed (${failover.reason}), retrying with the next configuration in the failover order`,
+        cwd: failover.project_path,
+        provider: failover.provider,
+        timestamp: new Date().toISOString()
+      } as ClaudeStreamMessage);
+      hasActiveSessionRef.current = true;
+      setIsLoading(true);
+    };
+
     // Listen for events specific to this cwd
     window.addEventListener(`claude-output:${projectPath}`, handleOutput);
     window.addEventListener(`claude-error:${projectPath}`, handleError);
     window.addEventListener(`claude-complete:${projectPath}`, handleComplete);
+    const unlistenFailover = EventsOn('provider-session:failover', handleFailover);
 
     return () => {
+      unlistenFailover();
       window.removeEventListener(`claude-output:${projectPath}`, handleOutput);
       window.removeEventListener(`claude-error:${projectPath}`, handleError);
       window.removeEventListener(`claude-complete:${projectPath}`, handleComplete);
@@ -2,6 +2,7 @@ import React, { useState, useRef } from 'react';
 import { Paperclip } from 'lucide-react';
 import { cn } from '@/lib/utils';
 import { AttachmentMenu } from './AttachmentMenu';
+import { ATTACHMENT_ACCEPT } from '../../utils/uploadAttachment';
 
 interface AttachmentButtonProps {
   onFileSelected: (file: File) => void;
@@ -58,7 +59,7 @@ export const AttachmentButton: React.FC<AttachmentButtonProps> = ({
       <input
         ref={mobileFileInputRef}
         type="file"
-        accept="*/*"
+        accept={ATTACHMENT_ACCEPT}
         className="hidden"
         onChange={handleMobileFileChange}
       />
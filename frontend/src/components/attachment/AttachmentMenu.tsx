@@ -1,6 +1,7 @@
 import React, { useRef, useEffect, useState } from 'react';
 import { FolderOpen, Camera, Image } from 'lucide-react';
 import { cn } from '@/lib/utils';
+import { ATTACHMENT_ACCEPT } from '../../utils/uploadAttachment';
 
 interface AttachmentMenuProps {
   isOpen: boolean;
@@ -112,7 +113,7 @@ export const AttachmentMenu: React.FC<AttachmentMenuProps> = ({
       <input
         ref={fileInputRef}
         type="file"
-        accept="*/*"
+        accept={ATTACHMENT_ACCEPT}
         className="hidden"
         onChange={handleFileChange}
       />
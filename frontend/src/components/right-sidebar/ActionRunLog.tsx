@@ -0,0 +1,145 @@
+import React, { useState, useEffect, useCallback } from 'react';
+import { Square, History, CheckCircle2, XCircle, Loader2 } from 'lucide-react';
+import { Button } from '@/components/ui/button';
+import { cn } from '@/lib/utils';
+import { api } from '@/lib/api';
+import { EventsOn } from '@/lib/rpc-events';
+import type { actionrun, database } from '@/lib/rpc-client';
+
+interface ActionRunLogProps {
+  /** Actions 的运行目录，只显示该目录的记录 */
+  cwd: string;
+  /** 刚启动的 run，启动后自动选中 */
+  activeRunId?: string;
+  className?: string;
+}
+
+// 每个 run 在内存中保留的输出上限
+const MAX_OUTPUT = 64 * 1024;
+
+export const ActionRunLog: React.FC<ActionRunLogProps> = ({ cwd, activeRunId, className }) => {
+  const [runs, setRuns] = useState<database.ActionRun[]>([]);
+  // 实时输出，按 run_id 缓存（事件可能早于 RunAction 返回）
+  const [outputs, setOutputs] = useState<Record<string, string>>({});
+  const [selectedRunId, setSelectedRunId] = useState<string | undefined>(activeRunId);
+  const [showHistory, setShowHistory] = useState(false);
+
+  const loadRuns = useCallback(async () => {
+    try {
+      setRuns(await api.listActionRuns({ cwd }));
+    } catch (error) {
+      console.error('[ActionRunLog] Failed to load action runs:', error);
+    }
+  }, [cwd]);
+
+  useEffect(() => {
+    loadRuns();
+  }, [loadRuns]);
+
+  useEffect(() => {
+    if (activeRunId) {
+      setSelectedRunId(activeRunId);
+      loadRuns();
+    }
+  }, [activeRunId, loadRuns]);
+
+  useEffect(() => {
+    return EventsOn('action-output', (o: actionrun.Output) => {
+      if (o.output_type === 'exit') {
+        loadRuns();
+        return;
+      }
+      setOutputs(prev => ({
+        ...prev,
+        [o.run_id]: ((prev[o.run_id] || '') + o.content).slice(-MAX_OUTPUT)
+      }));
+    });
+  }, [loadRuns]);
+
+  const selectedRun = runs.find(r => r.id === selectedRunId);
+  if (!selectedRun && runs.length === 0) {
+    return null;
+  }
+
+  const output = (selectedRunId && outputs[selectedRunId]) || selectedRun?.output || '';
+  // 移除 ANSI 转义序列（颜色、光标控制等）
+  const cleanOutput = output.replace(/\x1b\[[0-9;]*[a-zA-Z]/g, '');
+
+  const handleStop = async () => {
+    if (!selectedRun) return;
+    try {
+      await api.stopAction(selectedRun.id);
+    } catch (error) {
+      console.error('[ActionRunLog] Failed to stop action:', error);
+    }
+  };
+
+  const statusIcon = (run: database.ActionRun) => {
+    switch (run.status) {
+      case 'running':
+        return <Loader2 className="h-3 w-3 animate-spin" />;
+      case 'succeeded':
+        return <CheckCircle2 className="h-3 w-3 text-green-600 dark:text-green-400" />;
+      default:
+        return <XCircle className="h-3 w-3 text-destructive" />;
+    }
+  };
+
+  return (
+    <div className={cn("border rounded-md overflow-hidden", className)}>
+      <div className="flex items-center gap-2 px-3 py-2 border-b bg-muted/30 text-xs">
+        {selectedRun && statusIcon(selectedRun)}
+        <span className="font-medium truncate">{selectedRun?.action_name || 'Action runs'}</span>
+        {selectedRun && selectedRun.status !== 'running' && (
+          <span className="text-muted-foreground">
+            {selectedRun.status}{selectedRun.exit_code !== null && ` (exit ${selectedRun.exit_code})`}
+          </span>
+        )}
+        <div className="ml-auto flex items-center gap-1">
+          {selectedRun?.status === 'running' && (
+            <Button variant="ghost" size="sm" className="h-6 px-2" onClick={handleStop}>
+              <Square className="h-3 w-3 mr-1" />
+              Stop
+            </Button>
+          )}
+          <Button
+            variant="ghost"
+            size="sm"
+            className={cn("h-6 px-2", showHistory && "bg-muted")}
+            onClick={() => setShowHistory(!showHistory)}
+          >
+            <History className="h-3 w-3" />
+          </Button>
+        </div>
+      </div>
+
+      {showHistory && (
+        <div className="max-h-40 overflow-y-auto border-b">
+          {runs.map(run => (
+            <button
+              key={run.id}
+              className={cn(
+                "w-full flex items-center gap-2 px-3 py-1 text-xs text-left hover:bg-muted/50",
+                run.id === selectedRunId && "bg-muted"
+              )}
+              onClick={() => setSelectedRunId(run.id)}
+            >
+              {statusIcon(run)}
+              <span className="truncate">{run.action_name}</span>
+              <span className="ml-auto text-muted-foreground whitespace-nowrap">
+                {new Date(run.started_at).toLocaleString()}
+              </span>
+            </button>
+          ))}
+        </div>
+      )}
+
+      {selectedRun && (
+        <pre className="max-h-64 overflow-auto p-3 text-xs font-mono whitespace-pre-wrap break-all">
+          {selectedRun.truncated && !outputs[selectedRun.id] && '… (earlier output dropped)\n'}
+          {cleanOutput || <span className="text-muted-foreground">(no output)</span>}
+        </pre>
+      )}
+    </div>
+  );
+};
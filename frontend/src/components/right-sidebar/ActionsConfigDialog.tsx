@@ -10,6 +10,7 @@ import { Button } from '@/components/ui/button';
 import { Input } from '@/components/ui/input';
 import { Textarea } from '@/components/ui/textarea';
 import { Label } from '@/components/ui/label';
+import { Switch } from '@/components/ui/switch';
 import {
   Select,
   SelectContent,
@@ -17,8 +18,10 @@ import {
   SelectTrigger,
   SelectValue,
 } from '@/components/ui/select';
-import { Plus, Trash2, GripVertical, Save, Terminal, Globe } from 'lucide-react';
+import { Plus, Trash2, GripVertical, Save, Terminal, Globe, ArrowUp, ArrowDown, ShieldAlert } from 'lucide-react';
 import { api, type Action } from '@/lib/api';
+import { formatKeybinding } from '@/lib/keybindings';
+import { usesMetaKeyForAppShortcuts } from '@/lib/platform';
 
 interface ActionsConfigDialogProps {
   open: boolean;
@@ -37,6 +40,7 @@ export const ActionsConfigDialog: React.FC<ActionsConfigDialogProps> = ({
 }) => {
   const [actions, setActions] = useState<Action[]>([]);
   const [editingAction, setEditingAction] = useState<Action | null>(null);
+  const isMac = usesMetaKeyForAppShortcuts();
 
   // Load actions
   useEffect(() => {
@@ -71,9 +75,12 @@ export const ActionsConfigDialog: React.FC<ActionsConfigDialogProps> = ({
 
   const handleSave = async () => {
     try {
-      const globalActions = actions.filter(a => a.type === 'global');
-      const projectActions = actions.filter(a => a.type === 'project');
-      const workspaceActions = actions.filter(a => a.type === 'workspace');
+      // 列表中的顺序即每个 scope 内的执行顺序
+      const inScope = (type: Action['type']) =>
+        actions.filter(a => a.type === type).map((a, order) => ({ ...a, order }));
+      const globalActions = inScope('global');
+      const projectActions = inScope('project');
+      const workspaceActions = inScope('workspace');
 
       await api.updateGlobalActions(globalActions);
       await api.updateProjectActions(projectName, projectActions);
@@ -95,7 +102,9 @@ export const ActionsConfigDialog: React.FC<ActionsConfigDialogProps> = ({
       command: '',
       type,
       shared: type === 'project' ? true : undefined,
-      actionType: 'script' // 默认为 script 类型
+      actionType: 'script', // 默认为 script 类型
+      runIn: 'terminal',
+      order: actions.length
     };
     setEditingAction(newAction);
   };
@@ -104,6 +113,22 @@ export const ActionsConfigDialog: React.FC<ActionsConfigDialogProps> = ({
     setActions(actions.filter(a => a.id !== id));
   };
 
+  // 在同一 scope 内上移/下移
+  const handleMoveAction = (id: string, direction: -1 | 1) => {
+    const index = actions.findIndex(a => a.id === id);
+    let target = index + direction;
+    while (target >= 0 && target < actions.length && actions[target].type !== actions[index].type) {
+      target += direction;
+    }
+    if (index < 0 || target < 0 || target >= actions.length) return;
+    const updated = [...actions];
+    [updated[index], updated[target]] = [updated[target], updated[index]];
+    setActions(updated);
+  };
+
+  const canMove = (index: number, direction: -1 | 1) =>
+    actions.some((a, i) => a.type === actions[index].type && (direction < 0 ? i < index : i > index));
+
   const handleSaveEditingAction = () => {
     if (!editingAction) return;
 
@@ -157,7 +182,7 @@ export const ActionsConfigDialog: React.FC<ActionsConfigDialogProps> = ({
                   No actions configured yet. Click "Add Action" to create one.
                 </div>
               ) : (
-                actions.map((action) => (
+                actions.map((action, index) => (
                   <div
                     key={action.id}
                     className="flex items-center gap-2 p-3 border rounded-lg hover:bg-muted/50"
@@ -188,11 +213,35 @@ export const ActionsConfigDialog: React.FC<ActionsConfigDialogProps> = ({
                             Workspace Only
                           </span>
                         )}
+                        {action.confirm && (
+                          <ShieldAlert className="w-3 h-3 text-muted-foreground" aria-label="Asks for confirmation" />
+                        )}
+                        {action.keybinding && (
+                          <kbd className="text-xs text-muted-foreground border rounded px-1.5 py-0.5 font-mono">
+                            {formatKeybinding(action.keybinding, isMac)}
+                          </kbd>
+                        )}
                       </div>
                       <p className="text-xs text-muted-foreground truncate mt-1">
                         {action.command}
                       </p>
                     </div>
+                    <Button
+                      size="sm"
+                      variant="ghost"
+                      disabled={!canMove(index, -1)}
+                      onClick={() => handleMoveAction(action.id, -1)}
+                    >
+                      <ArrowUp className="w-4 h-4" />
+                    </Button>
+                    <Button
+                      size="sm"
+                      variant="ghost"
+                      disabled={!canMove(index, 1)}
+                      onClick={() => handleMoveAction(action.id, 1)}
+                    >
+                      <ArrowDown className="w-4 h-4" />
+                    </Button>
                     <Button
                       size="sm"
                       variant="ghost"
@@ -335,7 +384,7 @@ export const ActionsConfigDialog: React.FC<ActionsConfigDialogProps> = ({
                     rows={3}
                   />
                   <p className="text-xs text-muted-foreground">
-                    This command will be executed in the terminal
+                    {'Supports {{projectPath}} and {{branch}} placeholders'}
                   </p>
                 </div>
               ) : (
@@ -354,6 +403,55 @@ export const ActionsConfigDialog: React.FC<ActionsConfigDialogProps> = ({
                 </div>
               )}
 
+              {(editingAction.actionType || 'script') === 'script' && (
+                <div className="space-y-2">
+                  <Label htmlFor="runIn">Run In</Label>
+                  <Select
+                    value={editingAction.runIn || 'terminal'}
+                    onValueChange={(value: 'terminal' | 'background') => {
+                      setEditingAction({ ...editingAction, runIn: value });
+                    }}
+                  >
+                    <SelectTrigger id="runIn">
+                      <SelectValue />
+                    </SelectTrigger>
+                    <SelectContent>
+                      <SelectItem value="terminal">Terminal - Type the command into the terminal</SelectItem>
+                      <SelectItem value="background">Background - Run with output in the Run tab</SelectItem>
+                    </SelectContent>
+                  </Select>
+                </div>
+              )}
+
+              <div className="space-y-2">
+                <Label htmlFor="keybinding">Keyboard Shortcut</Label>
+                <Input
+                  id="keybinding"
+                  value={editingAction.keybinding || ''}
+                  onChange={(e) => setEditingAction({ ...editingAction, keybinding: e.target.value.trim() || undefined })}
+                  placeholder="e.g., mod+shift+b"
+                />
+                <p className="text-xs text-muted-foreground">
+                  {editingAction.keybinding
+                    ? formatKeybinding(editingAction.keybinding, isMac)
+                    : 'Modifiers: mod (⌘ on macOS, Ctrl elsewhere), ctrl, cmd, alt, shift'}
+                </p>
+              </div>
+
+              <div className="flex items-center justify-between">
+                <div>
+                  <Label htmlFor="confirm">Ask Before Running</Label>
+                  <p className="text-xs text-muted-foreground">
+                    Show a confirmation before running this action
+                  </p>
+                </div>
+                <Switch
+                  id="confirm"
+                  checked={!!editingAction.confirm}
+                  onCheckedChange={(checked) => setEditingAction({ ...editingAction, confirm: checked || undefined })}
+                />
+              </div>
+
               {editingAction.type === 'global' && (
                 <div className="pt-2">
                   <p className="text-xs text-muted-foreground">
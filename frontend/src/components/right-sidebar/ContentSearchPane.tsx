@@ -0,0 +1,259 @@
+import React, { useState, useRef, useCallback, useEffect, useMemo } from 'react';
+import { Search, Regex, X } from 'lucide-react';
+import { cn } from '@/lib/utils';
+import { Input } from '@/components/ui/input';
+import { useEventSubscription } from '@/hooks';
+import { normalizePath } from '@/lib/pathUtils';
+import { SearchFileContents, CancelFileContentSearch, type main } from '@/lib/rpc-client';
+
+interface ContentSearchPaneProps {
+  workspacePath?: string;
+  onFileClick?: (filePath: string) => void;
+  className?: string;
+}
+
+// 搜索结果事件可能先于 SearchFileContents 的返回值到达，先按 search_id 暂存
+interface BufferedSearch {
+  matches: main.ContentSearchMatch[];
+  done?: main.ContentSearchDoneEvent;
+}
+
+/**
+ * ContentSearchPane 组件 - 在项目文件内容中搜索
+ */
+export const ContentSearchPane: React.FC<ContentSearchPaneProps> = ({
+  workspacePath,
+  onFileClick,
+  className
+}) => {
+  const [query, setQuery] = useState('');
+  const [regex, setRegex] = useState(false);
+  const [include, setInclude] = useState('');
+  const [matches, setMatches] = useState<main.ContentSearchMatch[]>([]);
+  const [done, setDone] = useState<main.ContentSearchDoneEvent | null>(null);
+  const [searching, setSearching] = useState(false);
+  const [error, setError] = useState<string | null>(null);
+
+  const searchIdRef = useRef<number | null>(null);
+  const awaitingIdRef = useRef(false);
+  const bufferRef = useRef<Map<number, BufferedSearch>>(new Map());
+
+  const buffered = (searchId: number): BufferedSearch => {
+    let entry = bufferRef.current.get(searchId);
+    if (!entry) {
+      entry = { matches: [] };
+      bufferRef.current.set(searchId, entry);
+    }
+    return entry;
+  };
+
+  const applyDone = useCallback((event: main.ContentSearchDoneEvent) => {
+    setDone(event);
+    setSearching(false);
+    if (event.error) setError(event.error);
+    searchIdRef.current = null;
+  }, []);
+
+  useEventSubscription<main.ContentSearchMatchesEvent>('content-search:matches', (event) => {
+    if (event.search_id === searchIdRef.current) {
+      setMatches(prev => prev.concat(event.matches));
+    } else if (awaitingIdRef.current) {
+      buffered(event.search_id).matches.push(...event.matches);
+    }
+  });
+
+  useEventSubscription<main.ContentSearchDoneEvent>('content-search:done', (event) => {
+    if (event.search_id === searchIdRef.current) {
+      applyDone(event);
+    } else if (awaitingIdRef.current) {
+      buffered(event.search_id).done = event;
+    }
+  });
+
+  const cancelRunning = useCallback(() => {
+    if (searchIdRef.current !== null) {
+      CancelFileContentSearch(searchIdRef.current).catch(() => {});
+      searchIdRef.current = null;
+    }
+  }, []);
+
+  const runSearch = useCallback(async () => {
+    cancelRunning();
+    bufferRef.current.clear();
+    setMatches([]);
+    setDone(null);
+    setError(null);
+    if (!workspacePath || !query) {
+      setSearching(false);
+      return;
+    }
+
+    setSearching(true);
+    awaitingIdRef.current = true;
+    const globs = include.split(',').map(g => g.trim()).filter(Boolean);
+    try {
+      const searchId = await SearchFileContents(workspacePath, query, regex, globs, 0);
+      awaitingIdRef.current = false;
+      searchIdRef.current = searchId;
+      const early = bufferRef.current.get(searchId);
+      bufferRef.current.clear();
+      if (early) {
+        setMatches(early.matches);
+        if (early.done) applyDone(early.done);
+      }
+    } catch (err) {
+      awaitingIdRef.current = false;
+      setSearching(false);
+      setError(err instanceof Error ? err.message : String(err));
+    }
+  }, [workspacePath, query, regex, include, cancelRunning, applyDone]);
+
+  // 切换项目或卸载时停止搜索
+  useEffect(() => {
+    return cancelRunning;
+  }, [workspacePath, cancelRunning]);
+
+  // 按文件分组
+  const groups = useMemo(() => {
+    const byFile = new Map<string, main.ContentSearchMatch[]>();
+    for (const match of matches) {
+      const list = byFile.get(match.file);
+      if (list) {
+        list.push(match);
+      } else {
+        byFile.set(match.file, [match]);
+      }
+    }
+    for (const list of byFile.values()) {
+      list.sort((a, b) => a.line - b.line);
+    }
+    return Array.from(byFile.entries()).sort(([a], [b]) => a.localeCompare(b));
+  }, [matches]);
+
+  const relativePath = (file: string) => {
+    if (!workspacePath) return file;
+    const root = normalizePath(workspacePath).replace(/[\\/]+$/, '');
+    const path = normalizePath(file);
+    return path.startsWith(root) ? path.slice(root.length + 1) : path;
+  };
+
+  const renderLine = (match: main.ContentSearchMatch) => {
+    const start = match.column - 1;
+    const end = start + match.length;
+    if (start < 0 || end > match.text.length) {
+      return <span>{match.text}</span>;
+    }
+    return (
+      <span>
+        {match.text.slice(0, start)}
+        <mark className="bg-yellow-500/40 text-foreground rounded-sm">{match.text.slice(start, end)}</mark>
+        {match.text.slice(end)}
+      </span>
+    );
+  };
+
+  return (
+    <div className={cn("flex flex-col h-full", className)}>
+      {/* 搜索输入 */}
+      <div className="p-2 space-y-1.5 border-b border-white/10 bg-black/20">
+        <div className="flex items-center gap-1">
+          <Input
+            value={query}
+            onChange={(e) => setQuery(e.target.value)}
+            onKeyDown={(e) => {
+              if (e.key === 'Enter') runSearch();
+            }}
+            placeholder="Search in files"
+            className="h-7 text-xs"
+            disabled={!workspacePath}
+          />
+          <button
+            onClick={() => setRegex(r => !r)}
+            className={cn(
+              "p-1.5 rounded hover:bg-muted",
+              regex ? "text-primary bg-muted" : "text-muted-foreground"
+            )}
+            title="Use regular expression"
+          >
+            <Regex className="w-3.5 h-3.5" />
+          </button>
+          {searching ? (
+            <button
+              onClick={() => {
+                cancelRunning();
+                setSearching(false);
+              }}
+              className="p-1.5 rounded hover:bg-muted text-muted-foreground"
+              title="Stop search"
+            >
+              <X className="w-3.5 h-3.5" />
+            </button>
+          ) : (
+            <button
+              onClick={runSearch}
+              className="p-1.5 rounded hover:bg-muted text-muted-foreground"
+              title="Search"
+              disabled={!workspacePath || !query}
+            >
+              <Search className="w-3.5 h-3.5" />
+            </button>
+          )}
+        </div>
+        <Input
+          value={include}
+          onChange={(e) => setInclude(e.target.value)}
+          onKeyDown={(e) => {
+            if (e.key === 'Enter') runSearch();
+          }}
+          placeholder="Files to include, e.g. *.ts, src/**"
+          className="h-7 text-xs"
+          disabled={!workspacePath}
+        />
+        <div className="text-[11px] text-muted-foreground h-4">
+          {error ? (
+            <span className="text-red-400">{error}</span>
+          ) : searching ? (
+            `Searching… ${matches.length} matches`
+          ) : done ? (
+            `${done.matches} matches in ${done.files_matched} files` +
+            (done.truncated ? ' (stopped at the result limit)' : '') +
+            (done.cancelled ? ' (stopped)' : '')
+          ) : null}
+        </div>
+      </div>
+
+      {/* 结果 */}
+      <div className="flex-1 overflow-y-auto scrollbar-thin scrollbar-thumb-white/15 scrollbar-track-transparent">
+        {!workspacePath ? (
+          <div className="p-4 text-sm text-foreground/50 text-center">
+            Please select a project first
+          </div>
+        ) : (
+          groups.map(([file, fileMatches]) => (
+            <div key={file} className="py-0.5">
+              <div
+                className="px-3 py-1 text-xs font-medium text-foreground/80 truncate cursor-pointer hover:bg-white/10"
+                title={file}
+                onClick={() => onFileClick?.(file)}
+              >
+                {relativePath(file)}
+                <span className="ml-1.5 text-muted-foreground">{fileMatches.length}</span>
+              </div>
+              {fileMatches.map(match => (
+                <div
+                  key={`${file}:${match.line}`}
+                  className="flex gap-2 pl-5 pr-2 py-0.5 font-mono text-[11px] cursor-pointer hover:bg-white/10"
+                  title={[...(match.before ?? []), match.text, ...(match.after ?? [])].join('\n')}
+                  onClick={() => onFileClick?.(file)}
+                >
+                  <span className="text-muted-foreground w-8 text-right flex-shrink-0">{match.line}</span>
+                  <span className="truncate whitespace-pre">{renderLine(match)}</span>
+                </div>
+              ))}
+            </div>
+          ))
+        )}
+      </div>
+    </div>
+  );
+};
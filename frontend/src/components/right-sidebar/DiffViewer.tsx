@@ -7,7 +7,8 @@ import { cn } from '@/lib/utils';
 import { api } from '@/lib/api';
 import { getLanguageByFilename } from '@/lib/file-icons';
 import { getDiffFilePaths } from '@/lib/diffPath';
-import { basename } from '@/lib/pathUtils';
+import { basename, normalizePath } from '@/lib/pathUtils';
+import { useFsChanged, type FsChangedEvent } from '@/hooks';
 
 // 使用本地 monaco-editor 而非 CDN
 loader.config({ monaco });
@@ -110,57 +111,77 @@ export const DiffViewer: React.FC<DiffViewerProps> = ({
     diffEditorRef.current?.revealLineInCenter(targetLine);
   }, [changes, currentChangeIndex]);
 
-  // 获取文件的两个版本
-  useEffect(() => {
-    const fetchContent = async () => {
-      setLoading(true);
-      setError(null);
-      setIsBinary(false);
-      setIsLargeFile(false);
-
-      try {
-        const { absolutePath, gitPath } = getDiffFilePaths(filePath, workspacePath);
-        if (gitStatus === 'deleted') {
-          const oldFileContent = await api.readGitFileAtHead(workspacePath, gitPath);
-          setFileSize(oldFileContent.length);
-          setOldContent(oldFileContent || '');
-          setNewContent('');
-          setLoading(false);
-          return;
-        }
-
-        const metadata = await api.getFileMetadata(absolutePath);
-
-        setFileSize(metadata.size);
-
-        if (metadata.size > MAX_FILE_SIZE) {
-          setIsLargeFile(true);
-          setLoading(false);
-          return;
-        }
-
-        if (metadata.is_binary) {
-          setIsBinary(true);
-          setLoading(false);
-          return;
-        }
-
-        const newFileContent = await api.readFile(absolutePath);
-        const oldFileContent = await api.readGitFileAtHead(workspacePath, gitPath);
+  // 获取文件的两个版本；refresh 为 true 时保留当前视图，不显示加载状态
+  const fetchContent = useCallback(async (refresh = false) => {
+    if (!refresh) setLoading(true);
+    setError(null);
+    setIsBinary(false);
+    setIsLargeFile(false);
 
+    try {
+      const { absolutePath, gitPath } = getDiffFilePaths(filePath, workspacePath);
+      if (gitStatus === 'deleted') {
+        const oldFileContent = await api.readGitFileAtHead(workspacePath, gitPath);
+        setFileSize(oldFileContent.length);
         setOldContent(oldFileContent || '');
-        setNewContent(newFileContent || '');
+        setNewContent('');
         setLoading(false);
-      } catch (err) {
-        console.error('Failed to fetch content:', err);
-        setError(err instanceof Error ? err.message : 'Unknown error');
+        return;
+      }
+
+      const metadata = await api.getFileMetadata(absolutePath);
+
+      setFileSize(metadata.size);
+
+      if (metadata.size > MAX_FILE_SIZE) {
+        setIsLargeFile(true);
         setLoading(false);
+        return;
       }
-    };
 
-    fetchContent();
+      const newFile = await api.readFileSmart(absolutePath, MAX_FILE_SIZE);
+
+      // 文件可能在两次调用之间变大
+      if (newFile.truncated) {
+        setFileSize(newFile.size);
+        setIsLargeFile(true);
+        setLoading(false);
+        return;
+      }
+
+      if (newFile.is_binary) {
+        setIsBinary(true);
+        setLoading(false);
+        return;
+      }
+
+      const oldFileContent = await api.readGitFileAtHead(workspacePath, gitPath);
+
+      setOldContent(oldFileContent || '');
+      setNewContent(newFile.content);
+      setLoading(false);
+    } catch (err) {
+      console.error('Failed to fetch content:', err);
+      setError(err instanceof Error ? err.message : 'Unknown error');
+      setLoading(false);
+    }
   }, [filePath, workspacePath, gitStatus]);
 
+  useEffect(() => {
+    fetchContent();
+  }, [fetchContent]);
+
+  // 文件在磁盘上变化时（例如 agent 编辑后）自动刷新 diff
+  const handleFsChanged = useCallback((event: FsChangedEvent) => {
+    const { absolutePath } = getDiffFilePaths(filePath, workspacePath);
+    const target = normalizePath(absolutePath);
+    if (event.truncated || event.changes.some(change => normalizePath(change.path) === target)) {
+      fetchContent(true);
+    }
+  }, [filePath, workspacePath, fetchContent]);
+
+  useFsChanged(workspacePath, handleFsChanged);
+
   // 渲染二进制文件提示
   const renderBinaryNotice = () => (
     <div className="flex-1 flex items-center justify-center text-muted-foreground">
@@ -1,14 +1,25 @@
 import React, { useState, useEffect, useCallback } from 'react';
-import { ChevronRight, ChevronDown } from 'lucide-react';
+import { ChevronRight, ChevronDown, MoreHorizontal } from 'lucide-react';
 import { cn } from '@/lib/utils';
 import { api } from '@/lib/api';
 import { getFileIconConfig } from '@/lib/file-icons';
+import { normalizePath, parentPath, joinPath } from '@/lib/pathUtils';
+import {
+  DropdownMenu,
+  DropdownMenuContent,
+  DropdownMenuItem,
+  DropdownMenuSeparator,
+  DropdownMenuTrigger,
+} from '@/components/ui/dropdown-menu';
+import { useFsChanged, type FsChangedEvent } from '@/hooks';
 
 export interface FileNode {
   name: string;
   path: string;
   type: 'file' | 'directory';
   children?: FileNode[];
+  // 目录中（未被 gitignore 隐藏的）条目数，未加载子节点时也可用
+  childCount?: number;
 }
 
 interface FileTreeBrowserProps {
@@ -17,6 +28,8 @@ interface FileTreeBrowserProps {
   className?: string;
 }
 
+type FileAction = 'rename' | 'duplicate' | 'delete';
+
 /**
  * 递归组件：渲染文件树节点
  */
@@ -26,6 +39,7 @@ interface FileTreeNodeProps {
   onFileClick?: (filePath: string) => void;
   expandedDirs: Set<string>;
   onToggleDir: (path: string) => void;
+  onAction: (action: FileAction, node: FileNode) => void;
 }
 
 const FileTreeNode: React.FC<FileTreeNodeProps> = ({
@@ -33,7 +47,8 @@ const FileTreeNode: React.FC<FileTreeNodeProps> = ({
   level,
   onFileClick,
   expandedDirs,
-  onToggleDir
+  onToggleDir,
+  onAction
 }) => {
   const isExpanded = expandedDirs.has(node.path);
   const isDirectory = node.type === 'directory';
@@ -63,8 +78,8 @@ const FileTreeNode: React.FC<FileTreeNodeProps> = ({
         style={{ paddingLeft: `${level * 14 + 6}px` }}
         onClick={handleClick}
       >
-        {/* 展开/折叠图标 */}
-        {isDirectory && (
+        {/* 展开/折叠图标（空目录不显示） */}
+        {isDirectory && node.childCount !== 0 && (
           <div className="w-4 h-4 flex items-center justify-center flex-shrink-0 opacity-60 group-hover:opacity-100">
             {isExpanded ? (
               <ChevronDown className="w-3.5 h-3.5" />
@@ -73,7 +88,7 @@ const FileTreeNode: React.FC<FileTreeNodeProps> = ({
             )}
           </div>
         )}
-        {!isDirectory && <div className="w-4" />}
+        {(!isDirectory || node.childCount === 0) && <div className="w-4" />}
 
         {/* 文件/文件夹图标 */}
         <div className="w-4 h-4 flex items-center justify-center flex-shrink-0">
@@ -90,6 +105,27 @@ const FileTreeNode: React.FC<FileTreeNodeProps> = ({
         )}>
           {node.name}
         </span>
+
+        {/* 文件操作菜单 */}
+        <DropdownMenu>
+          <DropdownMenuTrigger asChild>
+            <button
+              onClick={(e) => e.stopPropagation()}
+              className="w-4 h-4 flex items-center justify-center flex-shrink-0 opacity-0 group-hover:opacity-60 hover:!opacity-100 data-[state=open]:opacity-100"
+              title="More actions"
+            >
+              <MoreHorizontal className="w-3.5 h-3.5" />
+            </button>
+          </DropdownMenuTrigger>
+          <DropdownMenuContent align="end" onClick={(e) => e.stopPropagation()}>
+            <DropdownMenuItem onSelect={() => onAction('rename', node)}>Rename…</DropdownMenuItem>
+            <DropdownMenuItem onSelect={() => onAction('duplicate', node)}>Duplicate</DropdownMenuItem>
+            <DropdownMenuSeparator />
+            <DropdownMenuItem className="text-red-400" onSelect={() => onAction('delete', node)}>
+              Move to Trash
+            </DropdownMenuItem>
+          </DropdownMenuContent>
+        </DropdownMenu>
       </div>
 
       {/* 子节点 */}
@@ -103,6 +139,7 @@ const FileTreeNode: React.FC<FileTreeNodeProps> = ({
               onFileClick={onFileClick}
               expandedDirs={expandedDirs}
               onToggleDir={onToggleDir}
+              onAction={onAction}
             />
           ))}
         </div>
@@ -123,18 +160,21 @@ export const FileTreeBrowser: React.FC<FileTreeBrowserProps> = ({
   const [error, setError] = useState<string | null>(null);
   const [tree, setTree] = useState<FileNode[]>([]);
   const [expandedDirs, setExpandedDirs] = useState<Set<string>>(new Set());
+  const [actionError, setActionError] = useState<string | null>(null);
 
   // 递归加载目录树
   const loadDirectoryTree = useCallback(async (dirPath: string): Promise<FileNode[]> => {
     try {
-      const entries = await api.listDirectoryContents(dirPath);
+      // 只加载一层，隐藏 gitignore 忽略的文件；子目录展开时再加载
+      const dirTree = await api.getDirectoryTree(dirPath, 1, true);
 
       // 转换为 FileNode 格式
-      const nodes: FileNode[] = entries.map(entry => ({
+      const nodes: FileNode[] = (dirTree.children ?? []).map(entry => ({
         name: entry.name,
         path: entry.path,
         type: entry.is_directory ? 'directory' : 'file',
-        children: entry.is_directory ? [] : undefined
+        children: entry.is_directory ? [] : undefined,
+        childCount: entry.is_directory ? entry.child_count : undefined
       }));
 
       // 排序：目录在前，文件在后
@@ -164,6 +204,14 @@ export const FileTreeBrowser: React.FC<FileTreeBrowserProps> = ({
     });
   }, []);
 
+  // 用重新读取的子节点替换旧的，保留已加载目录的子树
+  const mergeChildren = useCallback((prev: FileNode[] | undefined, next: FileNode[]): FileNode[] => {
+    return next.map(node => {
+      const old = prev?.find(p => p.path === node.path);
+      return old && old.type === node.type && old.children ? { ...node, children: old.children } : node;
+    });
+  }, []);
+
   // 懒加载节点的子内容
   const loadChildrenForNode = useCallback(async (nodePath: string) => {
     try {
@@ -192,6 +240,83 @@ export const FileTreeBrowser: React.FC<FileTreeBrowserProps> = ({
     });
   }, [loadChildrenForNode]);
 
+  // 项目文件变化时重新读取受影响的目录（根目录和已展开的目录）
+  const handleFsChanged = useCallback(async (event: FsChangedEvent) => {
+    if (!workspacePath) return;
+    const root = normalizePath(workspacePath);
+
+    const dirs = new Set<string>();
+    if (event.truncated) {
+      dirs.add(root);
+      expandedDirs.forEach(dir => dirs.add(normalizePath(dir)));
+    } else {
+      event.changes.forEach(change => dirs.add(normalizePath(parentPath(change.path))));
+    }
+
+    for (const dir of dirs) {
+      if (dir === root) {
+        const nodes = await loadDirectoryTree(workspacePath);
+        setTree(prevTree => mergeChildren(prevTree, nodes));
+        continue;
+      }
+      const expanded = Array.from(expandedDirs).find(path => normalizePath(path) === dir);
+      if (!expanded) continue;
+      const children = await loadDirectoryTree(expanded);
+      const merge = (nodes: FileNode[]): FileNode[] => nodes.map(node => {
+        if (node.path === expanded) {
+          return { ...node, children: mergeChildren(node.children, children) };
+        }
+        if (node.children && node.type === 'directory') {
+          return { ...node, children: merge(node.children) };
+        }
+        return node;
+      });
+      setTree(prevTree => merge(prevTree));
+    }
+  }, [workspacePath, expandedDirs, loadDirectoryTree, mergeChildren]);
+
+  useFsChanged(workspacePath, handleFsChanged);
+
+  // 重命名、复制、删除；树由 fs:changed 事件刷新
+  const handleFileAction = useCallback(async (action: FileAction, node: FileNode) => {
+    const dir = parentPath(node.path);
+    const isDirectory = node.type === 'directory';
+    setActionError(null);
+    try {
+      if (action === 'rename') {
+        const name = window.prompt('New name', node.name)?.trim();
+        if (!name || name === node.name) return;
+        await api.renamePath(node.path, joinPath(dir, name));
+      } else if (action === 'duplicate') {
+        const dot = isDirectory ? -1 : node.name.lastIndexOf('.');
+        const stem = dot > 0 ? node.name.slice(0, dot) : node.name;
+        const ext = dot > 0 ? node.name.slice(dot) : '';
+        for (let i = 1; ; i++) {
+          const name = `${stem} copy${i > 1 ? ` ${i}` : ''}${ext}`;
+          try {
+            await api.getFileMetadata(joinPath(dir, name));
+          } catch {
+            await api.copyPath(node.path, joinPath(dir, name));
+            break;
+          }
+        }
+      } else {
+        if (!window.confirm(`Move "${node.name}" to the trash?`)) return;
+        const remove = isDirectory ? api.deleteDirectory : api.deleteFile;
+        try {
+          await remove(node.path, false);
+        } catch (err) {
+          // 没有可用的回收站（如远程服务器），询问是否永久删除
+          const message = err instanceof Error ? err.message : String(err);
+          if (!window.confirm(`${message}\n\nDelete "${node.name}" permanently?`)) return;
+          await remove(node.path, true);
+        }
+      }
+    } catch (err) {
+      setActionError(err instanceof Error ? err.message : String(err));
+    }
+  }, []);
+
   // 加载文件树
   useEffect(() => {
     const loadFileTree = async () => {
@@ -228,6 +353,16 @@ export const FileTreeBrowser: React.FC<FileTreeBrowserProps> = ({
         )}
       </div>
 
+      {actionError && (
+        <div
+          className="px-3 py-1.5 text-xs text-red-400 border-b border-white/10 cursor-pointer"
+          title="Dismiss"
+          onClick={() => setActionError(null)}
+        >
+          {actionError}
+        </div>
+      )}
+
       {/* 内容区域 */}
       <div className="flex-1 overflow-y-auto scrollbar-thin scrollbar-thumb-white/15 scrollbar-track-transparent">
         {error ? (
@@ -257,6 +392,7 @@ export const FileTreeBrowser: React.FC<FileTreeBrowserProps> = ({
                 onFileClick={onFileClick}
                 expandedDirs={expandedDirs}
                 onToggleDir={handleToggleDir}
+                onAction={handleFileAction}
               />
             ))}
           </div>
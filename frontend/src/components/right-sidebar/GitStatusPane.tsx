@@ -1,7 +1,7 @@
 import React, { useState, useCallback } from 'react';
 import { cn } from '@/lib/utils';
 import { api } from '@/lib/api';
-import { usePageVisibilityPolling } from '@/hooks';
+import { usePageVisibilityPolling, useGitStatus } from '@/hooks';
 
 export interface GitFileChange {
   path: string;
@@ -111,7 +111,10 @@ export const GitStatusPane: React.FC<GitStatusPaneProps> = ({
     }
   }, [workspacePath]);
 
-  // 页面可见性轮询 - 定期刷新 Git 状态
+  // 后端推送的 Git 状态变化（提交、切换分支、文件编辑）触发刷新
+  const gitStatusLive = useGitStatus(workspacePath, fetchGitStatus);
+
+  // 页面可见性轮询 - 无法实时监听时定期刷新 Git 状态
   usePageVisibilityPolling(
     async () => {
       if (!workspacePath) return;
@@ -119,7 +122,7 @@ export const GitStatusPane: React.FC<GitStatusPaneProps> = ({
     },
     {
       interval: 3000, // 每 3 秒轮询一次
-      enabled: !!workspacePath, // 只在有工作区路径时启用
+      enabled: !!workspacePath && !gitStatusLive, // 只在有工作区路径且没有实时监听时启用
       immediate: true, // 页面可见时立即执行一次
     }
   );
@@ -4,12 +4,16 @@ import { Button } from '@/components/ui/button';
 import { cn } from '@/lib/utils';
 import { ScrollArea } from '@/components/ui/scroll-area';
 import type { Action } from '@/lib/api';
+import { ActionRunLog } from './ActionRunLog';
 
 interface RunTabPaneProps {
   actions: Action[];
   onExecute: (action: Action) => void;
   runningActionId?: string;
-  isTerminalRunning?: boolean;
+  /** 运行 script action 的目录 */
+  cwd?: string;
+  /** 最近启动的 action run */
+  activeRunId?: string;
   className?: string;
   onActionsConfig?: () => void;
   onOpenWebView?: () => void;
@@ -19,7 +23,8 @@ export const RunTabPane: React.FC<RunTabPaneProps> = ({
   actions,
   onExecute,
   runningActionId,
-  isTerminalRunning = false,
+  cwd,
+  activeRunId,
   className,
   onActionsConfig,
   onOpenWebView
@@ -91,7 +96,7 @@ export const RunTabPane: React.FC<RunTabPaneProps> = ({
                 variant="outline"
                 size="lg"
                 onClick={() => onExecute(action)}
-                disabled={isRunning || (isTerminalRunning && !isWebAction)}
+                disabled={isRunning}
                 className={cn(
                   "w-full h-auto min-h-[60px] flex flex-col items-start gap-2 p-4",
                   "hover:bg-muted/50 transition-colors",
@@ -115,6 +120,9 @@ export const RunTabPane: React.FC<RunTabPaneProps> = ({
             );
           })
         )}
+
+        {/* Script action 的输出和运行记录 */}
+        {cwd && <ActionRunLog cwd={cwd} activeRunId={activeRunId} className="mt-4" />}
       </div>
     </ScrollArea>
   );
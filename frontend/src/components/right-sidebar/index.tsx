@@ -1,5 +1,5 @@
 import React, { useState, useCallback, useEffect, useRef } from 'react';
-import { Terminal, FolderTree, ListChecks } from 'lucide-react';
+import { Terminal, FolderTree, Search, ListChecks } from 'lucide-react';
 import { cn } from '@/lib/utils';
 import { ResizeHandle } from './ResizeHandle';
 import { VerticalResizeHandle } from './VerticalResizeHandle';
@@ -11,6 +11,7 @@ import { TerminalInput } from './TerminalInput';
 import { XtermTerminal } from './XtermTerminal';
 import { RunTabPane } from './RunTabPane';
 import { FileTreeBrowser } from './FileTreeBrowser';
+import { ContentSearchPane } from './ContentSearchPane';
 import { ClaudeActivityPane } from './ClaudeActivityPane';
 import { api, listen, type Action } from '@/lib/api';
 import { useWorkspaceTabContext } from '@/contexts/WorkspaceTabContext';
@@ -22,9 +23,11 @@ import {
   loadTerminalState,
 } from '@/lib/terminalUtils';
 import { usesMetaKeyForAppShortcuts } from '@/lib/platform';
+import { matchesKeybinding } from '@/lib/keybindings';
 import { basename, normalizePath } from '@/lib/pathUtils';
 import { activityBadgeCount } from '@/lib/claudeActivity';
-import type { main } from '@/lib/rpc-client';
+import type { main, actionrun } from '@/lib/rpc-client';
+import { EventsOn } from '@/lib/rpc-events';
 
 interface RightSidebarProps {
   isOpen?: boolean;
@@ -59,7 +62,7 @@ export const RightSidebar: React.FC<RightSidebarProps> = ({
 }) => {
   const [widthPercent, setWidthPercent] = useState(defaultWidthPercent);
   const [hasGitSupport, setHasGitSupport] = useState(false);
-  const [activeRightTab, setActiveRightTab] = useState<'console' | 'files' | 'tasks'>('console');
+  const [activeRightTab, setActiveRightTab] = useState<'console' | 'files' | 'search' | 'tasks'>('console');
   const [activitySnapshot, setActivitySnapshot] = useState<main.ClaudeActivitySnapshot | null>(null);
 
   // 广播右侧栏宽度变化
@@ -216,6 +219,7 @@ export const RightSidebar: React.FC<RightSidebarProps> = ({
   // Actions 状态
   const [actions, setActions] = useState<Action[]>([]);
   const [runningActionId, setRunningActionId] = useState<string>();
+  const [activeActionRunId, setActiveActionRunId] = useState<string>();
   const [showActionsConfig, setShowActionsConfig] = useState(false);
 
   // Run Tab 状态
@@ -397,6 +401,17 @@ export const RightSidebar: React.FC<RightSidebarProps> = ({
     createFileTab(filePath, currentProjectPath);
   }, [currentProjectPath, createFileTab]);
 
+  // 会话中点击修改过的文件 - 创建 Diff Tab
+  useEffect(() => {
+    const handleOpenDiff = (event: Event) => {
+      const detail = (event as CustomEvent<{ filePath: string; projectPath: string; gitStatus?: GitFileChange['status'] }>).detail;
+      if (!detail?.filePath || detail.projectPath !== currentProjectPath) return;
+      createDiffTab(detail.filePath, detail.projectPath, detail.gitStatus);
+    };
+    window.addEventListener('open-diff', handleOpenDiff);
+    return () => window.removeEventListener('open-diff', handleOpenDiff);
+  }, [currentProjectPath, createDiffTab]);
+
   // 关闭终端会话
   const handleCloseSession = useCallback(async (id: string) => {
     const currentState = getCurrentState();
@@ -501,32 +516,8 @@ export const RightSidebar: React.FC<RightSidebarProps> = ({
     }
   }, [getCurrentState, currentProjectPath]);
 
-  // 执行 Action
-  const handleExecuteAction = useCallback(async (action: Action) => {
-    // 判断 action 类型：默认为 'script'
-    const actionType = action.actionType || 'script';
-
-    if (actionType === 'web') {
-      // Web action: 打开 WebViewer Tab
-      if (!action.command) {
-        console.error('[RightSidebar] Web action has no URL:', action);
-        return;
-      }
-
-      if (!currentProjectPath) {
-        console.error('[RightSidebar] Cannot open web viewer: no project path');
-        return;
-      }
-
-      try {
-        createWebViewerTab(action.command, currentProjectPath);
-      } catch (error) {
-        console.error('[RightSidebar] Failed to create web viewer tab:', error);
-      }
-      return;
-    }
-
-    // Script action: 执行命令
+  // 在第一个终端中执行 Action 的命令
+  const runActionInTerminal = useCallback(async (action: Action) => {
     setRunningActionId(action.id);
 
     // 切换到第一个 Terminal
@@ -545,28 +536,78 @@ export const RightSidebar: React.FC<RightSidebarProps> = ({
         if (firstTerminal.isPty) {
           // 检查 PTY 会话是否存活
           const isAlive = await api.isPtySessionAlive(firstTerminal.id);
-          if (isAlive) {
-            await api.writeToPty(firstTerminal.id, action.command + '\n');
-          } else {
+          if (!isAlive) {
             console.warn('[RightSidebar] PTY session not ready yet:', firstTerminal.id);
             // 等待一下再重试
             await new Promise(resolve => setTimeout(resolve, 500));
-            await api.writeToPty(firstTerminal.id, action.command + '\n');
           }
+          await api.writeToPty(firstTerminal.id, action.command + '\n');
         } else {
           // 旧的命令执行方式
           await handleSubmitCommand(action.command);
         }
       } catch (error) {
         console.error('[RightSidebar] Failed to execute action:', error);
-      } finally {
-        // 延迟清除运行状态
-        setTimeout(() => {
-          setRunningActionId(undefined);
-        }, 500);
       }
     }
-  }, [handleSubmitCommand, getCurrentState, triggerUpdate, createWebViewerTab, currentProjectPath]);
+
+    // 延迟清除运行状态
+    setTimeout(() => {
+      setRunningActionId(undefined);
+    }, 500);
+  }, [handleSubmitCommand, getCurrentState, triggerUpdate]);
+
+  // 执行 Action
+  const handleExecuteAction = useCallback(async (action: Action) => {
+    // 判断 action 类型：默认为 'script'
+    const actionType = action.actionType || 'script';
+
+    if (actionType === 'web') {
+      // Web action: 打开 WebViewer Tab
+      if (!action.command) {
+        console.error('[RightSidebar] Web action has no URL:', action);
+        return;
+      }
+
+      if (!currentProjectPath) {
+        console.error('[RightSidebar] Cannot open web viewer: no project path');
+        return;
+      }
+
+      try {
+        createWebViewerTab(action.command, currentProjectPath);
+      } catch (error) {
+        console.error('[RightSidebar] Failed to create web viewer tab:', error);
+      }
+      return;
+    }
+
+    // 需要确认的 action（通常是破坏性的）先询问
+    if (action.confirm && !confirm(`Run "${action.name}"?\n\n${action.command}`)) {
+      return;
+    }
+
+    if (action.runIn === 'terminal') {
+      await runActionInTerminal(action);
+      return;
+    }
+
+    // Script action: 交给后端在项目目录中运行，输出通过 action-output 事件流入 Run tab
+    if (!currentProjectPath) {
+      console.error('[RightSidebar] Cannot run action: no project path');
+      return;
+    }
+
+    setRunningActionId(action.id);
+    try {
+      const run = await api.runAction(action.id, action.scope || action.type || 'global', currentProjectPath);
+      setActiveActionRunId(run.id);
+      setIsRunTabActive(true); // 在 Run tab 中查看输出
+    } catch (error) {
+      console.error('[RightSidebar] Failed to execute action:', error);
+      setRunningActionId(undefined);
+    }
+  }, [createWebViewerTab, currentProjectPath, runActionInTerminal]);
 
   // 停止当前运行的命令
   const handleStopCommand = useCallback(async () => {
@@ -628,6 +669,15 @@ export const RightSidebar: React.FC<RightSidebarProps> = ({
     setIsRunTabActive(true);
   }, []);
 
+  // Action 运行结束时清除运行状态
+  useEffect(() => {
+    return EventsOn('action-output', (o: actionrun.Output) => {
+      if (o.output_type === 'exit') {
+        setRunningActionId(prev => (prev === o.action_id ? undefined : prev));
+      }
+    });
+  }, []);
+
   // 监听终端输出事件
   useEffect(() => {
     const unlisten = listen('terminal-output', (payload: {
@@ -730,6 +780,16 @@ export const RightSidebar: React.FC<RightSidebarProps> = ({
         return;
       }
 
+      // Action 快捷键
+      const isMac = usesMetaKeyForAppShortcuts();
+      const bound = actions.find(a => a.keybinding && matchesKeybinding(e, a.keybinding, isMac));
+      if (bound) {
+        e.preventDefault();
+        e.stopPropagation();
+        handleExecuteAction(bound);
+        return;
+      }
+
       // Ctrl+C: 停止当前命令（macOS 和其他平台都使用 Ctrl）
       // 必须在终端打开且有命令运行时才拦截
       const currentState = getCurrentState();
@@ -748,7 +808,7 @@ export const RightSidebar: React.FC<RightSidebarProps> = ({
     // 使用 capture 阶段确保在其他事件处理器之前捕获
     window.addEventListener('keydown', handleKeyDown, true);
     return () => window.removeEventListener('keydown', handleKeyDown, true);
-  }, [onToggle, isOpen, handleStopCommand, getCurrentState]);
+  }, [onToggle, isOpen, handleStopCommand, getCurrentState, actions, handleExecuteAction]);
 
   // 条件渲染必须在所有 hooks 之后
   if (!isOpen) {
@@ -801,6 +861,18 @@ export const RightSidebar: React.FC<RightSidebarProps> = ({
           <FolderTree className="w-4 h-4" />
           Files
         </button>
+        <button
+          onClick={() => setActiveRightTab('search')}
+          className={cn(
+            "flex-1 px-4 py-2 text-sm font-medium transition-colors flex items-center justify-center gap-2",
+            activeRightTab === 'search'
+              ? "bg-background text-foreground border-b-2 border-primary"
+              : "text-muted-foreground hover:text-foreground hover:bg-muted/30"
+          )}
+        >
+          <Search className="w-4 h-4" />
+          Search
+        </button>
         <button
           onClick={() => setActiveRightTab('tasks')}
           disabled={!activeClaudeChatTab}
@@ -865,7 +937,8 @@ export const RightSidebar: React.FC<RightSidebarProps> = ({
             actions={actions}
             onExecute={handleExecuteAction}
             runningActionId={runningActionId}
-            isTerminalRunning={isCurrentSessionRunning}
+            cwd={currentProjectPath}
+            activeRunId={activeActionRunId}
             className="flex-1"
             onActionsConfig={() => setShowActionsConfig(true)}
             onOpenWebView={handleOpenWebView}
@@ -923,6 +996,16 @@ export const RightSidebar: React.FC<RightSidebarProps> = ({
         </div>
       )}
 
+      {/* Tab 内容 - Search */}
+      {activeRightTab === 'search' && (
+        <div className="flex-1 flex flex-col overflow-hidden">
+          <ContentSearchPane
+            workspacePath={currentProjectPath}
+            onFileClick={handleFileTreeClick}
+          />
+        </div>
+      )}
+
       {activeRightTab === 'tasks' && (
         <div className="flex-1 flex flex-col overflow-hidden">
           {activeClaudeChatTab ? (
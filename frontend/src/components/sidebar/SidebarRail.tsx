@@ -13,6 +13,7 @@ import {
   Network,
   PanelRight,
   Plus,
+  Search,
   Server,
   Settings,
 } from 'lucide-react';
@@ -36,6 +37,7 @@ interface SidebarRailProps {
   onToggleCollapse: () => void;
   onToggleRightSidebar: () => void;
   onOpenProject: () => void;
+  onScanProjects: () => void;
   onCloneProject: () => void;
   onSyncFromSSH: () => void;
   onAgentsClick?: () => void;
@@ -79,6 +81,7 @@ export const SidebarRail: React.FC<SidebarRailProps> = ({
   onToggleCollapse,
   onToggleRightSidebar,
   onOpenProject,
+  onScanProjects,
   onCloneProject,
   onSyncFromSSH,
   onAgentsClick,
@@ -140,6 +143,10 @@ export const SidebarRail: React.FC<SidebarRailProps> = ({
                 <FolderOpen className="mr-2 h-4 w-4" />
                 Open Project
               </DropdownMenuItem>
+              <DropdownMenuItem onClick={onScanProjects}>
+                <Search className="mr-2 h-4 w-4" />
+                Scan for Projects
+              </DropdownMenuItem>
               <DropdownMenuItem onClick={onCloneProject}>
                 <GitBranch className="mr-2 h-4 w-4" />
                 Clone from URL
@@ -28,4 +28,5 @@ export { TAB_SCREEN_NAMES } from './useAnalytics';
 export * from './useEventSubscription';
 export * from './usePageVisibilityPolling';
 export { useIsMobile } from './useIsMobile';
+export { useAttention } from './useAttention';
 export { useSubagentTranscriptSync } from './useSubagentTranscriptSync';
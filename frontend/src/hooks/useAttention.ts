@@ -0,0 +1,81 @@
+/**
+ * Attention Hook
+ *
+ * 统计在用户没看时完成的会话（窗口不在前台或对应项目不是当前工作区），
+ * 通过 SetAttentionCount 上报给后端；切换到该项目或窗口重新获得焦点时清除。
+ * 后端广播 attention:changed，由桌面壳层显示 Dock 角标 / 任务栏叠加图标。
+ */
+
+import { useCallback, useEffect, useRef } from 'react';
+import { EventsOn } from '@/lib/rpc-events';
+import { NotifySessionComplete, SetAttentionCount, type main } from '@/lib/rpc-client';
+import { WindowSetBadgeCount } from '@/lib/rpc-window';
+import { useTabContext } from '@/contexts/TabContext';
+import { useEventSubscription } from './useEventSubscription';
+
+export function useAttention(): void {
+  const { currentWorkspaceId } = useTabContext();
+  // session (or project when the session is unknown) → project path
+  const awaitingRef = useRef(new Map<string, string>());
+  const workspaceRef = useRef(currentWorkspaceId);
+  workspaceRef.current = currentWorkspaceId;
+
+  const publish = useCallback(() => {
+    SetAttentionCount(awaitingRef.current.size).catch((err) => {
+      console.warn('[Attention] Failed to report count:', err);
+    });
+  }, []);
+
+  const clearWorkspace = useCallback(() => {
+    const workspace = workspaceRef.current;
+    if (!workspace || document.hidden || !document.hasFocus()) return;
+    let changed = false;
+    for (const [key, cwd] of awaitingRef.current) {
+      if (cwd === workspace) {
+        awaitingRef.current.delete(key);
+        changed = true;
+      }
+    }
+    if (changed) publish();
+  }, [publish]);
+
+  useEffect(() => {
+    return EventsOn('claude-complete', (payload: string) => {
+      let msg: any;
+      try {
+        msg = JSON.parse(payload);
+      } catch {
+        return;
+      }
+      const cwd: string | undefined = msg?.cwd;
+      if (!cwd) return;
+      const looking = !document.hidden && document.hasFocus() && cwd === workspaceRef.current;
+      if (looking) return;
+
+      awaitingRef.current.set(msg.session_id || cwd, cwd);
+      publish();
+      if (msg.session_id) {
+        NotifySessionComplete(msg.provider || 'claude', msg.session_id, cwd, msg.success !== false).catch(() => {
+          // 宿主机无法显示系统通知（如远程服务器），忽略
+        });
+      }
+    });
+  }, [publish]);
+
+  useEffect(() => {
+    clearWorkspace();
+  }, [currentWorkspaceId, clearWorkspace]);
+
+  useEffect(() => {
+    window.addEventListener('focus', clearWorkspace);
+    document.addEventListener('visibilitychange', clearWorkspace);
+    return () => {
+      window.removeEventListener('focus', clearWorkspace);
+      document.removeEventListener('visibilitychange', clearWorkspace);
+    };
+  }, [clearWorkspace]);
+
+  useEventSubscription<main.AttentionChangedEvent>('attention:changed', (event) => {
+    WindowSetBadgeCount(event.badge ? event.count : 0);
+  });
+}
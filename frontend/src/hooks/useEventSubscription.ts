@@ -4,8 +4,10 @@
  * 提供基于 WebSocket RPC 事件系统的 React hooks，用于订阅后端推送的事件。
  */
 
-import { useEffect, useCallback, useRef } from 'react';
-import { EventsOn, EventsOff } from '@/lib/rpc-events';
+import { useEffect, useCallback, useRef, useState } from 'react';
+import { EventsOn } from '@/lib/rpc-events';
+import { WatchProjectFiles, UnwatchProjectFiles, WatchGitStatus, UnwatchGitStatus, type main } from '@/lib/rpc-client';
+import { normalizePath } from '@/lib/pathUtils';
 
 // ============ 事件类型定义 ============
 
@@ -17,6 +19,14 @@ export interface GitChangedEvent {
   status: Record<string, string>;
 }
 
+export interface GitStatusEvent {
+  path: string;
+  status?: main.GitRepoStatus;
+  unpushed_count: number;
+  unpushed_to_remote_count: number;
+  error?: string;
+}
+
 export interface ProcessChangedEvent {
   pid: number;
   cwd: string;
@@ -42,6 +52,17 @@ export interface WorktreeChangedEvent {
   worktrees: WorktreeInfo[];
 }
 
+export interface FsChange {
+  path: string;
+  op: 'create' | 'modify' | 'delete' | 'rename';
+}
+
+export interface FsChangedEvent {
+  root: string;
+  changes: FsChange[];
+  truncated?: boolean;
+}
+
 // ============ 基础 Hook ============
 
 /**
@@ -69,11 +90,8 @@ export function useEventSubscription<T>(
       });
     };
 
-    EventsOn(eventName, wrappedHandler);
-
-    return () => {
-      EventsOff(eventName);
-    };
+    // 只移除自己的监听，同一事件可能有多个订阅者
+    return EventsOn(eventName, wrappedHandler);
   }, [eventName, enabled]);
 }
 
@@ -100,6 +118,52 @@ export function useGitChanged(
   useEventSubscription('git:changed', stableCallback, true);
 }
 
+/**
+ * 订阅 Git 状态事件，挂载期间由后端监听该项目的 HEAD、index、refs 和文件
+ * @param path 项目路径，为 undefined 时不监听
+ * @param callback 状态回调（提交、切换分支、push 或文件编辑后推送）
+ * @returns 是否在实时监听；为 false 时（例如远程项目）调用方应退回轮询
+ */
+export function useGitStatus(
+  path: string | undefined,
+  callback: (event: GitStatusEvent) => void
+): boolean {
+  const [live, setLive] = useState(false);
+
+  useEffect(() => {
+    setLive(false);
+    if (!path) return;
+    let cancelled = false;
+    const watching = WatchGitStatus(path).then(
+      () => {
+        if (!cancelled) setLive(true);
+        return true;
+      },
+      () => false
+    );
+    return () => {
+      cancelled = true;
+      // 只释放成功建立的监听
+      watching.then((ok) => {
+        if (ok) UnwatchGitStatus(path).catch(() => {});
+      });
+    };
+  }, [path]);
+
+  const stableCallback = useCallback(
+    (event: GitStatusEvent) => {
+      if (path && normalizePath(event.path) === normalizePath(path)) {
+        callback(event);
+      }
+    },
+    [path, callback]
+  );
+
+  useEventSubscription('git:status', stableCallback, !!path);
+
+  return live;
+}
+
 // ============ 进程事件 ============
 
 /**
@@ -168,3 +232,43 @@ export function useWorktreeChanged(
 
   useEventSubscription('worktree:changed', stableCallback, true);
 }
+
+// ============ 文件系统事件 ============
+
+/**
+ * 订阅项目文件变化事件，挂载期间由后端监听该项目
+ * @param root 项目路径，为 undefined 时不监听
+ * @param callback 变化回调（已防抖，一次包含一批变化）
+ */
+export function useFsChanged(
+  root: string | undefined,
+  callback: (event: FsChangedEvent) => void
+): void {
+  useEffect(() => {
+    if (!root) return;
+    const watching = WatchProjectFiles(root).then(
+      () => true,
+      (err) => {
+        console.warn('[useFsChanged] Failed to watch project files:', root, err);
+        return false;
+      }
+    );
+    return () => {
+      // 只释放成功建立的监听
+      watching.then((ok) => {
+        if (ok) UnwatchProjectFiles(root).catch(() => {});
+      });
+    };
+  }, [root]);
+
+  const stableCallback = useCallback(
+    (event: FsChangedEvent) => {
+      if (root && normalizePath(event.root) === normalizePath(root)) {
+        callback(event);
+      }
+    },
+    [root, callback]
+  );
+
+  useEventSubscription('fs:changed', stableCallback, !!root);
+}
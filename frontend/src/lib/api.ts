@@ -217,6 +217,9 @@ const api = new Proxy({ ...rpcMethods }, {
       // Plugin
       listInstalledPlugins: 'ListInstalledPlugins',
       getPluginContents: 'GetPluginContents',
+      installPlugin: 'InstallPlugin',
+      updatePlugin: 'UpdatePlugin',
+      uninstallPlugin: 'UninstallPlugin',
       // Model
       getAllModelConfigs: 'GetAllModelConfigs',
       syncProviderModelsFromAPI: 'SyncProviderModelsFromAPI',
@@ -226,13 +229,16 @@ const api = new Proxy({ ...rpcMethods }, {
       setModelConfigDefault: 'SetModelConfigDefault',
       // File operations
       executeCommand: 'ExecuteCommand',
+      getDirectoryTree: 'GetDirectoryTree',
       readFile: 'ReadFile',
+      readFileSmart: 'ReadFileSmart',
       writeFile: 'WriteFile',
       getFileMetadata: 'GetFileMetadata',
+      deleteFile: 'DeleteFile',
+      deleteDirectory: 'DeleteDirectory',
+      renamePath: 'RenamePath',
+      copyPath: 'CopyPath',
       readGitFileAtHead: 'ReadGitFileAtHead',
-      // Hooks
-      getHooksConfig: 'GetHooks',
-      updateHooksConfig: 'SaveHooks',
       // Slash commands
       slashCommandsList: 'ListSlashCommands',
       slashCommandSave: 'SaveSlashCommand',
@@ -309,6 +315,7 @@ const api = new Proxy({ ...rpcMethods }, {
       getProjectSessions: 'GetProjectSessions',
       getClaudeBinaryPath: 'GetClaudeBinaryPath',
       setClaudeBinaryPath: 'SetClaudeBinaryPath',
+      switchClaudeInstallation: 'SwitchClaudeInstallation',
       getClaudeSettings: 'GetClaudeSettings',
       saveClaudeSettings: 'SaveClaudeSettings',
       getProviderSystemPrompt: 'GetProviderSystemPrompt',
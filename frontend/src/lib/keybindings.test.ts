@@ -0,0 +1,37 @@
+import { strict as assert } from "node:assert";
+import { describe, it } from "node:test";
+import { formatKeybinding, matchesKeybinding } from "./keybindings";
+
+const press = (key: string, mods: Partial<{ ctrlKey: boolean; metaKey: boolean; altKey: boolean; shiftKey: boolean }> = {}) => ({
+  key,
+  ctrlKey: false,
+  metaKey: false,
+  altKey: false,
+  shiftKey: false,
+  ...mods,
+});
+
+describe("matchesKeybinding", () => {
+  it("maps mod to Cmd on macOS and Ctrl elsewhere", () => {
+    assert.equal(matchesKeybinding(press("B", { metaKey: true, shiftKey: true }), "mod+shift+b", true), true);
+    assert.equal(matchesKeybinding(press("B", { ctrlKey: true, shiftKey: true }), "mod+shift+b", true), false);
+    assert.equal(matchesKeybinding(press("b", { ctrlKey: true, shiftKey: true }), "Mod+Shift+B", false), true);
+  });
+
+  it("requires exactly the bound modifiers", () => {
+    assert.equal(matchesKeybinding(press("b", { ctrlKey: true, altKey: true }), "ctrl+b", false), false);
+    assert.equal(matchesKeybinding(press("b"), "b", false), false);
+  });
+
+  it("understands named keys", () => {
+    assert.equal(matchesKeybinding(press("ArrowUp", { altKey: true }), "alt+up", false), true);
+    assert.equal(matchesKeybinding(press("F5", { altKey: true }), "alt+f5", false), true);
+  });
+});
+
+describe("formatKeybinding", () => {
+  it("uses symbols on macOS", () => {
+    assert.equal(formatKeybinding("mod+shift+b", true), "⌘⇧B");
+    assert.equal(formatKeybinding("mod+shift+b", false), "Ctrl+Shift+B");
+  });
+});
@@ -0,0 +1,69 @@
+/**
+ * Action keybindings: "+"-joined modifiers followed by a key, e.g.
+ * "mod+shift+b". "mod" is Cmd on macOS and Ctrl elsewhere.
+ */
+
+interface KeyEventLike {
+  key: string;
+  ctrlKey: boolean;
+  metaKey: boolean;
+  altKey: boolean;
+  shiftKey: boolean;
+}
+
+const KEY_NAMES: Record<string, string> = {
+  ' ': 'space',
+  arrowup: 'up',
+  arrowdown: 'down',
+  arrowleft: 'left',
+  arrowright: 'right',
+  esc: 'escape',
+  del: 'delete',
+};
+
+/** Whether a keyboard event presses binding; isMac decides what "mod" is */
+export function matchesKeybinding(e: KeyEventLike, binding: string, isMac: boolean): boolean {
+  const parts = binding.toLowerCase().replace(/\s+/g, '').split('+');
+  const key = parts.pop();
+  if (!key || parts.length === 0) return false;
+
+  const want = { ctrl: false, meta: false, alt: false, shift: false };
+  for (const modifier of parts) {
+    switch (modifier) {
+      case 'mod':
+        if (isMac) want.meta = true;
+        else want.ctrl = true;
+        break;
+      case 'cmd':
+        want.meta = true;
+        break;
+      case 'ctrl':
+        want.ctrl = true;
+        break;
+      case 'alt':
+        want.alt = true;
+        break;
+      case 'shift':
+        want.shift = true;
+        break;
+      default:
+        return false;
+    }
+  }
+  if (e.ctrlKey !== want.ctrl || e.metaKey !== want.meta || e.altKey !== want.alt || e.shiftKey !== want.shift) {
+    return false;
+  }
+
+  const pressed = e.key.toLowerCase();
+  return (KEY_NAMES[pressed] ?? pressed) === key;
+}
+
+/** Formats a keybinding for display, e.g. "⌘⇧B" on macOS */
+export function formatKeybinding(binding: string, isMac: boolean): string {
+  const parts = binding.toLowerCase().split('+');
+  const key = (parts.pop() || '').toUpperCase();
+  const symbols: Record<string, string> = isMac
+    ? { mod: '⌘', cmd: '⌘', ctrl: '⌃', alt: '⌥', shift: '⇧' }
+    : { mod: 'Ctrl+', cmd: 'Win+', ctrl: 'Ctrl+', alt: 'Alt+', shift: 'Shift+' };
+  return parts.map(m => symbols[m] ?? `${m}+`).join('') + key;
+}
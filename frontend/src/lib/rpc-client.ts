@@ -29,27 +29,206 @@ export interface ClaudeCapabilityLayers {
 }
 
 // 类型定义 - 与 Go 后端保持一致
+export namespace logging {
+  export interface Entry {
+    time: string;
+    level: 'debug' | 'info' | 'warn' | 'error';
+    component?: string;
+    message: string;
+    attrs?: Record<string, string>;
+  }
+}
+
+export namespace settings {
+  export interface Field {
+    key: string;
+    type: 'bool' | 'string' | 'number' | 'object';
+    default: unknown;
+    enum?: string[];
+    description: string;
+  }
+  /** Known setting keys → values; also the payload of settings:changed */
+  export type Values = Record<string, unknown>;
+}
+
+export namespace netproxy {
+  // Settings is the proxy_settings setting
+  export interface Settings {
+    enabled: boolean;
+    http_proxy: string | null;
+    https_proxy: string | null;
+    no_proxy: string | null;
+    all_proxy: string | null;
+  }
+  // TestResult is the outcome of TestProxy
+  export interface TestResult {
+    ok: boolean;
+    url: string;
+    proxy: string;  // empty for a direct connection
+    status_code?: number;
+    duration_ms: number;
+    error?: string;
+  }
+}
+
+export namespace sysprompt {
+  // Resolved is the prompt a session in a project gets
+  export interface Resolved {
+    scope: '' | 'global' | 'project';  // scope of the template used
+    template: string;
+    prompt: string;
+    variables: Record<string, string>;
+  }
+}
+
 export namespace ssh {
   export interface SshConnection {
     name: string;
     host: string;
     port: number;
     user: string;
-    identity_file?: string;
+    key_path?: string;
+    remote_path?: string;
+    host_key_checking?: 'strict' | 'accept-new' | 'off';
+    auth_method?: 'key' | 'password' | 'agent';
+    /** Only sent when saving or testing; saved passwords stay in the keychain */
     password?: string;
+    has_password?: boolean;
+    proxy_jump?: string;
+    forward_agent?: boolean;
+  }
+  export interface AgentKey {
+    bits: string;
+    fingerprint: string;
+    comment: string;
+    type: string;
+  }
+  export interface AgentStatus {
+    available: boolean;
+    socket?: string;
+    forwarded: boolean;
+    keys: AgentKey[];
+    error?: string;
+  }
+  export interface HostKeyInfo {
+    host: string;
+    port: number;
+    key_type: string;
+    fingerprint: string;
+    status: 'known' | 'unknown' | 'changed' | 'revoked';
+    known_fingerprints?: string[];
+  }
+  export interface KnownHost {
+    hosts: string[];
+    key_type: string;
+    fingerprint: string;
+    revoked: boolean;
+  }
+  export interface SyncProgress {
+    local_path: string;
     remote_path?: string;
+    connection?: string;
+    direction?: 'upload' | 'download';
+    stage: 'idle' | 'planning' | 'transferring' | 'completed' | 'error';
+    current_file?: string;
+    file_bytes: number;
+    file_percent: number;
+    bytes_transferred: number;
+    total_bytes: number;
+    files_transferred: number;
+    total_files: number;
+    files_remaining: number;
+    percent: number;
+    bytes_per_second: number;
+    started_at?: number;
+    updated_at?: number;
+    error?: string;
+  }
+  export interface SyncRules {
+    local_path: string;
+    remote_path: string;
+    connection: string;
+    include: string[];
+    exclude: string[];
+    respect_gitignore: boolean;
+  }
+  export interface FileState {
+    size: number;
+    mtime: number;
+  }
+  export interface SyncConflict {
+    path: string;
+    /** Absent when the file was deleted on that side */
+    local?: FileState;
+    remote?: FileState;
+    detected_at: number;
+  }
+  export interface SyncConflictsEvent {
+    local_path: string;
+    remote_path: string;
+    connection: string;
+    conflicts: SyncConflict[];
+  }
+  export type ConflictResolution = 'keep_local' | 'keep_remote' | 'keep_both';
+  export interface SyncFile {
+    local_path: string;
+    direction: 'upload' | 'download';
+    file: string;
+    bytes: number;
   }
   export interface AutoSyncStatus {
-    running: boolean;
     project_path: string;
-    ssh_connection_name: string;
-    branch: string;
-    last_sync?: string;
+    is_running: boolean;
+    is_paused: boolean;
+    mode?: 'push' | 'two-way';
+    conflicts: number;
+    last_sync_time?: number;
+    error?: string;
+  }
+  export interface RemoteEntry {
+    name: string;
+    path: string;
+    is_directory: boolean;
+    size: number;
+    extension?: string;
+    mode: number;
+    mod_time: string;
   }
 }
 
 export namespace main {
+  export interface ModelDiscovery {
+    provider_api_id: string;
+    provider_id: string;
+    base_url: string;
+    models: models.DiscoveredModel[];
+  }
+  export interface ProjectModelDefaults {
+    model: string;
+    thinking_level: string;
+  }
+  export interface ProviderFailover {
+    provider: string;
+    project_path: string;
+    failed_session_id?: string;
+    session_id: string;
+    from_provider_api_id: string;
+    provider_api_id: string;
+    reason: string;
+  }
   export interface PtySessionInfo { sessionId: string; pid: number; }
+  export interface ProjectMemoryDraft {
+    path: string;
+    exists: boolean;
+    content: string;
+    survey: projectmemory.Survey;
+  }
+  export interface SessionShare {
+    file_name: string;
+    html: string;
+    messages: number;
+    files: number;
+  }
   export interface ProcessInfo {
     key?: string;
     pid: string | number;
@@ -73,6 +252,13 @@ export namespace main {
     type?: 'global' | 'project' | 'workspace';
     actionType?: 'script' | 'web';
     shared?: boolean;
+    order?: number;
+    confirm?: boolean;  // ask before running
+    keybinding?: string;  // e.g. "mod+shift+b"
+    runIn?: 'terminal' | 'background';
+    // added to the environment of script actions; values may use
+    // {{projectPath}} and {{branch}} like the command
+    env?: Record<string, string>;
   }
   export interface ActionsResult {
     global_actions: Action[];
@@ -80,13 +266,256 @@ export namespace main {
     workspace_actions: Action[];
   }
   export interface ClaudeVersionInfo { version: string; path: string; }
+  export interface SubsystemHealth {
+    name: string;
+    available: boolean;
+    error?: string;
+  }
+  export interface AppHealth {
+    ok: boolean;
+    subsystems: SubsystemHealth[];
+  }
+  export interface DoctorCheck {
+    id: string;
+    name: string;
+    status: 'ok' | 'warning' | 'error';
+    detail: string;
+    hint?: string;
+  }
+  export interface DoctorReport {
+    ok: boolean;
+    checks: DoctorCheck[];
+    checked_at: string;
+  }
+  export interface AppVersionInfo {
+    version: string;
+    commit?: string;
+    go_version: string;
+    os: string;
+    arch: string;
+  }
+  export interface UpdateStatus {
+    state: 'idle' | 'up_to_date' | 'available' | 'downloading' | 'staged' | 'installing' | 'error';
+    current_version: string;
+    latest_version?: string;
+    release_name?: string;
+    release_notes?: string;
+    release_url?: string;
+    published_at?: string;
+    asset?: string;
+    staged_path?: string;
+    restart_required?: boolean;
+    error?: string;
+    checked_at?: string;
+  }
+  /** Payload of update:progress */
+  export interface UpdateProgress {
+    version: string;
+    downloaded: number;
+    total: number;
+  }
+  export interface TelemetryMetric {
+    day: string;
+    kind: 'feature' | 'session';
+    name: string;
+    count: number;
+    duration_ms?: number;
+  }
+  export interface TelemetryReport {
+    install_id: string;
+    app_version: string;
+    os: string;
+    arch: string;
+    metrics: TelemetryMetric[];
+  }
+  export interface TelemetryFlushResult {
+    sent: number;
+  }
+  export interface DiagnosticsSummary {
+    path: string;
+    sections: string[];
+    log_files: number;
+  }
+  export interface RemoteProject {
+    connection: string;
+    remote_path: string;
+    claude_binary?: string;
+    codex_binary?: string;
+  }
+  export interface RemoteServer {
+    url: string;
+    /** Only sent to SetRemoteServer; stored in the keychain */
+    auth_key?: string;
+    has_auth_key?: boolean;
+    remote_path?: string;
+  }
+  /** Spend limits in USD; 0 disables a limit */
+  export interface UsageQuota {
+    daily_usd: number;
+    weekly_usd: number;
+    monthly_usd: number;
+    notify: boolean;
+  }
+  export interface UsageQuotaPeriod {
+    period: 'daily' | 'weekly' | 'monthly';
+    start: string;
+    limit_usd: number;
+    spent_usd: number;
+    percent: number;
+  }
+  export interface NotificationRules {
+    enabled: boolean;
+    session_complete: boolean;
+    agent_run_failed: boolean;
+    command_finished: boolean;
+    long_command_seconds: number;
+  }
+  export interface AttentionSettings {
+    badge: boolean;
+    /** event → "default", a system sound name or a sound file path */
+    sounds: Partial<Record<'session_complete' | 'agent_run_failed' | 'command_finished' | 'usage_quota', string>>;
+  }
+  /** Payload of the attention:changed event */
+  export interface AttentionChangedEvent {
+    count: number;
+    badge: boolean;
+  }
+  /** Payload of the usage-quota:alert event */
+  export interface UsageQuotaAlert extends UsageQuotaPeriod {
+    threshold: 80 | 100;
+  }
   export interface ClaudeInstallation {
     path: string;
     version: string;
     installation_type?: string;
     source?: string;
   }
-  export interface GitRepoStatus { branch: string; dirty: boolean; }
+  export interface ContentSearchMatch {
+    file: string;
+    line: number;
+    column: number;
+    length: number;
+    text: string;
+    before?: string[];
+    after?: string[];
+  }
+  export interface ContentSearchMatchesEvent {
+    search_id: number;
+    matches: ContentSearchMatch[];
+  }
+  export interface ContentSearchDoneEvent {
+    search_id: number;
+    matches: number;
+    files_searched: number;
+    files_matched: number;
+    truncated: boolean;
+    cancelled: boolean;
+    error?: string;
+  }
+  export interface SessionHistoryTail {
+    messages: claude.Message[];
+    offset: number;
+  }
+  export interface SessionHistoryMessagesEvent {
+    follow_id: number;
+    session_id: string;
+    messages: claude.Message[];
+    offset: number;
+  }
+  export interface SessionHistoryStoppedEvent {
+    follow_id: number;
+    session_id: string;
+    error?: string;
+  }
+  export interface StorageCategory {
+    id: string;
+    path: string;
+    bytes: number;
+    files: number;
+    // CleanupStorage action that frees space here
+    cleanup?: string;
+  }
+  export interface ProjectStorage {
+    // empty when the transcripts could not be matched to a project
+    path: string;
+    name: string;
+    indexed: boolean;
+    bytes: number;
+    transcripts: number;
+    providers: Record<string, number>;
+    reclaimable_bytes: number;
+  }
+  export interface StorageReport {
+    categories: StorageCategory[];
+    projects: ProjectStorage[];
+    total_bytes: number;
+    generated_at: string;
+  }
+  export interface ProviderTerminal {
+    // PTY session the CLI runs in
+    terminal_id: string;
+    provider: string;
+    project_path: string;
+    // set once the CLI has written its transcript
+    session_id?: string;
+    transcript_path?: string;
+  }
+  export interface ProviderTerminalExitedEvent {
+    terminal_id: string;
+    session_id?: string;
+  }
+  export interface ProviderVersionInfo {
+    provider: string;
+    is_installed: boolean;
+    path?: string;
+    version?: string;
+    // the version line, or why the CLI was not found or did not run
+    output: string;
+  }
+  export interface ProviderInstallation {
+    path: string;
+    // path, homebrew, npm-global, nvm, cargo, bun, native, scoop or custom
+    source: string;
+    installation_type: string;
+    version?: string;
+    // why the binary did not run
+    error?: string;
+    // the installation sessions use
+    active: boolean;
+  }
+  export interface ProviderInstallFinishedEvent {
+    provider: string;
+    job_key: string;
+    status: string;
+    // the installation sessions use now, when there is one
+    path?: string;
+    version?: string;
+  }
+  export interface ShellInfo {
+    os: string;
+    // shell used while the preference has no path
+    detected: string;
+    available: string[];
+    // what commands and terminals run in
+    shell: string;
+    login: boolean;
+  }
+  export interface WSLInfo {
+    // WSL only exists on Windows
+    supported: boolean;
+    distros: string[];
+    // where sessions, terminals and git run; empty for Windows
+    distro: string;
+    error?: string;
+  }
+  export interface GitFileStatus { Path: string; Status: string; }
+  export interface GitRepoStatus {
+    branch: string;
+    modified: GitFileStatus[];
+    staged: GitFileStatus[];
+    untracked: GitFileStatus[];
+    is_clean: boolean;
+  }
   export interface CloneRepositoryResult { success: boolean; path: string; }
   export interface WorktreeInfo {
     current_path: string;
@@ -108,9 +537,79 @@ export namespace main {
     is_binary: boolean;
     extension?: string;
   }
+  export interface DirectoryTreeNode {
+    name: string;
+    path: string;
+    is_directory: boolean;
+    size: number;
+    extension?: string;
+    child_count: number;
+    // null for files and for directories not loaded yet
+    children: DirectoryTreeNode[] | null;
+  }
+  export interface ProjectAddFailure {
+    path: string;
+    error: string;
+  }
+  export interface ProjectsAddResult {
+    added: string[];
+    failed: ProjectAddFailure[];
+  }
+  export interface TempImagesStats {
+    dir: string;
+    count: number;
+    bytes: number;
+    // images session history still refers to
+    referenced: number;
+    referenced_bytes: number;
+  }
+  export interface TempImagesCleanupResult {
+    removed: number;
+    freed_bytes: number;
+    stats: TempImagesStats;
+  }
+  export interface PastedImage {
+    path: string;
+    width: number;
+    height: number;
+    size: number;
+  }
+  export interface RecordedAudio {
+    path: string;
+    size: number;
+  }
+  export interface TranscriptionResult {
+    text: string;
+    language: string;
+  }
+  export interface AttachmentResult {
+    path: string;
+    name: string;
+    kind: 'image' | 'pdf' | 'text' | 'archive';
+    mime_type: string;
+    size: number;
+    // 插入到提示词中的文件引用，按 provider 的语法生成
+    reference: string;
+  }
+  export interface SmartFileContent {
+    content: string;
+    // utf-8, utf-16le, utf-16be or latin-1; absent for binary files
+    encoding?: string;
+    is_binary: boolean;
+    truncated: boolean;
+    size: number;
+  }
   export interface MCPAddResult { success: boolean; message: string; }
-  export interface MCPImportResult { imported: string[]; errors: string[]; }
-  export interface MCPProjectConfig { mcpServers: Record<string, any>; }
+  export interface MCPImportResult { success: boolean; imported_count: number; failed_count: number; messages: string[]; }
+  export interface MCPProjectConfig { servers: Record<string, mcp.MCPServerConfig>; enabled?: Record<string, boolean>; }
+  export interface ProjectMcpServer {
+    name: string;
+    scope: 'global' | 'project';
+    transport: string;
+    command?: string;
+    url?: string;
+    enabled: boolean;
+  }
   export interface ProviderSession {
     id: string;
     project_id: string;
@@ -143,6 +642,17 @@ export namespace main {
     pid?: number;
     provider: string;
   }
+  export interface ResourceUsage {
+    kind: 'session' | 'process';
+    id: string;
+    provider?: string;
+    cwd?: string;
+    pid: number;
+    cpu_percent: number;
+    rss_bytes: number;
+    process_count: number;
+    sampled_at: string;
+  }
   export interface ClaudeActivityUsage {
     input_tokens?: number;
     output_tokens?: number;
@@ -210,9 +720,217 @@ export namespace main {
   export interface ClaudeAgentEntry { name: string; category: string; }
   export interface Skill { name: string; description: string; }
   export interface CommandResult { stdout: string; stderr: string; exitCode: number; }
+  export interface MarketplaceAgent {
+    source_id: string;
+    source_name: string;
+    file_name: string;
+    path: string;
+    download_url: string;
+    sha: string;
+    name: string;
+    icon: string;
+    model: string;
+    system_prompt: string;
+    default_task?: string;
+    error?: string;
+    installed: boolean;
+    installed_agent_id?: number;
+    update_available: boolean;
+  }
+  export interface AgentMarketplaceIndex {
+    agents: MarketplaceAgent[];
+    source_errors?: Record<string, string>;
+    synced_at: number;
+  }
+  export interface AgentSourceUpdate {
+    agent_id: number;
+    source_url: string;
+    changed: boolean;
+    applied: boolean;
+    prompt_diff: github.DiffLine[];
+    remote: github.AgentContent;
+    agent: database.Agent;
+  }
+  export interface AppConfigSummary {
+    version: number;
+    exported_at: number;
+    includes_tokens: boolean;
+    provider_apis: number;
+    agents: number;
+    model_configs: number;
+    global_actions: number;
+    settings: number;
+    project_indexes: number;
+  }
+  export type SettingsSyncStrategy = '' | 'local' | 'remote';
+  export interface SettingsSyncConfig {
+    repo_url: string;
+    branch: string;
+    last_synced_at?: number;
+    last_commit?: string;
+  }
+  export interface SettingsSyncResult {
+    committed: boolean;
+    pushed: boolean;
+    conflicts?: string[];
+    agents: number;
+    actions: number;
+    commands: number;
+    models: number;
+    commit?: string;
+    synced_at: number;
+  }
+}
+
+export namespace actionrun {
+  // Output is a chunk of action run output, or its end when output_type
+  // is "exit"
+  export interface Output {
+    run_id: string;
+    action_id: string;
+    output_type: 'stdout' | 'stderr' | 'exit';
+    content: string;
+    exit_code?: number;
+    status?: database.ActionRun['status'];
+  }
 }
 
 export namespace database {
+  // ActionRun is one run of a script action
+  export interface ActionRun {
+    id: string;
+    action_id: string;
+    action_name: string;
+    scope: string;
+    cwd: string;
+    command: string;
+    status: 'running' | 'succeeded' | 'failed' | 'stopped' | 'interrupted';
+    exit_code: number | null;
+    output: string;
+    truncated: boolean;
+    started_at: string;
+    finished_at: string | null;
+  }
+  // ActionRunFilter selects action runs; empty fields match all
+  export interface ActionRunFilter {
+    action_id?: string;
+    cwd?: string;
+    limit?: number;
+  }
+  // ProjectEnvVar is an environment variable given to the sessions, terminals
+  // and commands of a project
+  export interface ProjectEnvVar {
+    id: number;
+    project_path: string;
+    name: string;
+    value: string;  // "********" for secret variables
+    secret: boolean;
+    updated_at: number;
+  }
+  // Snippet is a saved prompt fragment that can be inserted into any session
+  export interface Snippet {
+    id: number;
+    title: string;
+    body: string;
+    tags: string[];
+    usage_count: number;
+    last_used_at?: string | null;
+    created_at: string;
+    updated_at: string;
+  }
+  // LineRange is a range of lines in a file, 1-based and inclusive
+  export interface LineRange {
+    start: number;
+    end: number;
+  }
+  // SessionFileChange is a change a session's tool call made to a file
+  export interface SessionFileChange {
+    id: number;
+    session_id: string;
+    // the tool call, and so the message, that made the change
+    tool_use_id: string;
+    tool: string;
+    path: string;
+    kind: 'created' | 'modified' | 'deleted';
+    // lines changed, in the file as it was after the change
+    ranges: LineRange[];
+    created_at: string;
+  }
+  // SessionCheckpoint is a snapshot of a project's files taken before a session ran
+  export interface SessionCheckpoint {
+    id: number;
+    // empty until the session reports its ID
+    session_id: string;
+    provider: string;
+    project_path: string;
+    commit: string;
+    ref: string;
+    created_at: string;
+    restored_at?: string | null;
+  }
+  // PermissionProfile limits what the agents of a session may do
+  export interface PermissionProfile {
+    id: string;
+    name: string;
+    description: string;
+    // Claude --permission-mode; "" or "bypassPermissions" skips permission checks
+    claude_permission_mode: '' | 'bypassPermissions' | 'acceptEdits' | 'dontAsk' | 'plan';
+    claude_allowed_tools: string[];
+    claude_disallowed_tools: string[];
+    // Codex --sandbox; "" leaves it to the codex_sandbox_mode setting
+    codex_sandbox: '' | 'read-only' | 'workspace-write' | 'danger-full-access';
+    codex_network_access: boolean;
+    builtin: boolean;
+    created_at: string;
+    updated_at: string;
+  }
+  // HookExecution is one recorded run of a Claude Code hook
+  export interface HookExecution {
+    id: number;
+    session_id: string;
+    project_path: string;
+    hook_event: string;
+    matcher: string;
+    command: string;
+    exit_code: number | null;
+    outcome: string;
+    duration_ms: number;
+    output: string;
+    truncated: boolean;
+    started_at: string;
+  }
+  // HookExecutionFilter selects hook executions; empty fields match all
+  export interface HookExecutionFilter {
+    session_id?: string;
+    project_path?: string;
+    hook_event?: string;
+    failed_only?: boolean;
+    limit?: number;
+  }
+  // ModelPricing prices models whose ID contains model_pattern, in USD per
+  // million tokens, for usage on or after effective_date
+  export interface ModelPricing {
+    id?: number;
+    model_pattern: string;
+    input_price: number;
+    output_price: number;
+    cache_write_price: number;
+    cache_read_price: number;
+    effective_date?: string;
+    source?: 'builtin' | 'remote' | 'user';
+    updated_at?: number;
+  }
+  // ModelCapabilities describes models whose ID contains model_pattern
+  export interface ModelCapabilities {
+    model_pattern: string;
+    context_window: number;
+    max_output_tokens: number;
+    supports_vision: boolean;
+    supports_thinking: boolean;
+    pricing_pattern?: string;
+    last_verified?: string;
+    source: 'builtin' | 'remote';
+  }
   export interface ProviderApiConfig {
     id?: string;
     name: string;
@@ -224,12 +942,24 @@ export namespace database {
     created_at?: string;
     updated_at?: string;
   }
+  // SessionProviderApi records the provider API config a session was started with
+  export interface SessionProviderApi {
+    session_id: string;
+    provider: string;
+    project_path: string;
+    provider_api_id: string;
+    failed_over_from?: string;
+    reason?: string;
+    created_at: string;
+  }
   // ProviderInfo stores provider configuration for a project
   export interface ProviderInfo {
     id: string;
     provider_id: string;
     path: string;
     provider_api_id?: string;
+    default_model?: string;
+    default_thinking_level?: string;
   }
   // WorkspaceIndex stores workspace metadata
   export interface WorkspaceIndex {
@@ -274,6 +1004,8 @@ export namespace database {
     thinking_levels?: ThinkingLevel[];
     created_at?: string;
     updated_at?: string;
+    // Looked up by model ID; absent for models the catalog doesn't know
+    capabilities?: ModelCapabilities;
   }
   export interface ThinkingLevel {
     id: string;
@@ -291,8 +1023,10 @@ export namespace database {
     system_prompt: string;
     default_task?: string;
     model: string;
+    provider?: string;
     provider_api_id?: string;
     hooks?: string;
+    source_url?: string;
     created_at: string;
     updated_at: string;
   }
@@ -303,6 +1037,22 @@ export namespace database {
     input_tokens?: number;
     output_tokens?: number;
   }
+  export interface AgentMetricsSummary {
+    agent_id: number;
+    agent_name: string;
+    run_count: number;
+    completed_runs: number;
+    failed_runs: number;
+    cancelled_runs: number;
+    input_tokens: number;
+    output_tokens: number;
+    total_tokens: number;
+    cost_usd: number;
+    duration_ms: number;
+    avg_duration_ms: number;
+    avg_tokens: number;
+    avg_cost_usd: number;
+  }
   export interface AgentRun {
     id: number;
     agent_id: number;
@@ -310,6 +1060,7 @@ export namespace database {
     agent_icon: string;
     task: string;
     model: string;
+    provider?: string;
     project_path: string;
     session_id: string;
     status: string;
@@ -322,10 +1073,136 @@ export namespace database {
     duration_ms?: number;
     total_tokens?: number;
   }
+  export interface AgentBatchItem {
+    project_path: string;
+    agent_run_id?: number;
+    status: string;
+    error?: string;
+  }
+  export interface AgentBatch {
+    id: number;
+    agent_id: number;
+    agent_name: string;
+    task: string;
+    status: string;
+    concurrency: number;
+    items: AgentBatchItem[];
+    created_at: string;
+    completed_at?: string;
+  }
+  export interface ProviderComparisonItem {
+    provider: string;
+    workspace_path?: string;
+    branch?: string;
+    base_commit?: string;
+    session_id?: string;
+    status: string;
+    error?: string;
+    started_at?: string;
+    completed_at?: string;
+    metrics: AgentRunMetrics;
+    diff?: git.TreeDiff;
+  }
+  export interface ProviderComparison {
+    id: number;
+    project_path: string;
+    prompt: string;
+    status: string;
+    items: ProviderComparisonItem[];
+    created_at: string;
+    completed_at?: string;
+  }
+  export interface PipelineStep {
+    agent_id: number;
+    task?: string;
+    model?: string;
+    input_mode?: 'result' | 'output';
+  }
+  export interface Pipeline {
+    id: number;
+    name: string;
+    description: string;
+    steps: PipelineStep[];
+    created_at: string;
+    updated_at: string;
+  }
+  export interface PipelineStepRun {
+    agent_id: number;
+    agent_name?: string;
+    agent_run_id?: number;
+    status: string;
+    error?: string;
+  }
+  export interface PipelineRun {
+    id: number;
+    pipeline_id: number;
+    pipeline_name: string;
+    project_path: string;
+    task: string;
+    status: string;
+    current_step: number;
+    steps: PipelineStepRun[];
+    error?: string;
+    created_at: string;
+    completed_at?: string;
+  }
   export interface TableData { columns: string[]; rows: any[][]; }
 }
 
+export namespace usage {
+  export interface ReportRow {
+    key: string;
+    input_tokens: number;
+    output_tokens: number;
+    cache_creation_tokens: number;
+    cache_read_tokens: number;
+    total_tokens: number;
+    cost_usd: number;
+    sessions: number;
+    requests: number;
+  }
+  export interface MonthlySummary {
+    month: string;
+    total: ReportRow;
+    by_model: ReportRow[];
+    by_project: ReportRow[];
+    by_day: ReportRow[];
+    by_workspace: ReportRow[];
+    /** Keyed "<workspace>@<branch>" */
+    by_branch: ReportRow[];
+    previous_month_cost_usd: number;
+  }
+  export interface TimelineSession {
+    session_id: string;
+    project_path: string;
+    total_tokens: number;
+    cost_usd: number;
+  }
+  export interface TimelinePoint extends ReportRow {
+    burn_rate_usd_per_hour: number;
+    top_sessions: TimelineSession[];
+  }
+  export interface Timeline {
+    bucket: 'hour' | 'day';
+    start: string;
+    end: string;
+    points: TimelinePoint[];
+    peak_index: number;
+  }
+}
+
 export namespace claude {
+  // SessionSummary is a Claude session listed by GetProjectSessions
+  export interface SessionSummary {
+    id: string;
+    project_id: string;
+    project_path: string;
+    created_at: number;
+    message_timestamp?: string;
+    first_message?: string;
+    message_count: number;
+    updated_at: number;
+  }
   export interface Message {
     role: string;
     content: string;
@@ -347,14 +1224,40 @@ export namespace claude {
     pid?: number;
     runtime?: unknown;
   }
-  export interface HooksConfig {
-    preCommit?: HookMatcher[];
-    postCommit?: HookMatcher[];
+  export interface Hook {
+    type: string;
+    command?: string;
+    script?: string;
+    timeout?: number;
   }
   export interface HookMatcher {
-    pattern: string;
-    command: string;
-    description?: string;
+    matcher: string;
+    hooks: Hook[];
+  }
+  export interface HooksConfig {
+    PreToolUse?: HookMatcher[];
+    PostToolUse?: HookMatcher[];
+    Notification?: HookMatcher[];
+    Stop?: HookMatcher[];
+    SubagentStop?: HookMatcher[];
+  }
+  // HookTemplate is a ready-made hook users can add to their settings
+  export interface HookTemplate {
+    id: string;
+    name: string;
+    description: string;
+    event: string;
+    matcher?: string;
+    commands: string[];
+  }
+  // HookTestResult is the outcome of running a hook on a sample payload
+  export interface HookTestResult {
+    stdout: string;
+    stderr: string;
+    exit_code: number;
+    duration_ms: number;
+    timed_out: boolean;
+    blocked: boolean;
   }
   export interface SlashCommand {
     id: string;
@@ -371,8 +1274,23 @@ export namespace claude {
     accepts_arguments: boolean;
     scope: 'project' | 'user' | 'plugin' | 'default';
     full_command?: string;
+    file_path: string;
     plugin_name?: string;
   }
+  export interface SlashCommandInput {
+    name: string;
+    // 子目录命名空间，如 "frontend" 或 "ops:release"
+    namespace?: string;
+    content: string;
+    description?: string;
+    allowed_tools?: string[];
+    argument_hint?: string;
+    scope: 'project' | 'user';
+    command_type: 'claude' | 'codex';
+    project_path?: string;
+    // 正在编辑的命令文件；名称、命名空间、范围或类型变化时命令会被移动
+    original_file_path?: string;
+  }
   export interface ClaudeAgent {
     category?: string;
     name: string;
@@ -393,6 +1311,55 @@ export namespace claude {
     size?: number;
     modified?: string;
   }
+  // MemorySection is one memory file as it contributes to the effective memory
+  export interface MemorySection {
+    scope: 'managed' | 'user' | 'project' | 'local' | 'nested';
+    path: string;
+    imported_from?: string;
+    content: string;
+    on_demand?: boolean;
+  }
+  export interface EffectiveMemory {
+    sections: MemorySection[];
+    content: string;
+  }
+}
+
+export namespace scheduler {
+  export interface Limits {
+    global: number;
+    per_provider?: Record<string, number>;
+  }
+  export interface QueueEntry {
+    id: string;
+    provider: string;
+    project_path: string;
+    position: number;
+    queued_at: string;
+  }
+}
+
+export namespace github {
+  export interface AgentSource {
+    id: string;
+    name: string;
+    owner: string;
+    repo: string;
+    branch: string;
+    path: string;
+    enabled: boolean;
+  }
+  export interface AgentContent {
+    name: string;
+    icon: string;
+    model: string;
+    system_prompt: string;
+    default_task?: string;
+  }
+  export interface DiffLine {
+    op: 'equal' | 'insert' | 'delete';
+    text: string;
+  }
 }
 
 export namespace mcp {
@@ -405,15 +1372,19 @@ export namespace mcp {
     args?: string[];
     env?: Record<string, string>;
     url?: string;
+    headers?: Record<string, string>;
     scope?: string;
     is_active?: boolean;
     status?: MCPServerStatus | 'running' | 'connected' | string;
   }
+  export type Transport = 'stdio' | 'sse' | 'http';
   export interface MCPServerConfig {
+    type?: Transport;
     command?: string;
     args?: string[];
     env?: Record<string, string>;
     url?: string;
+    headers?: Record<string, string>;
   }
   export interface MCPServerStatus {
     running: boolean;
@@ -421,6 +1392,83 @@ export namespace mcp {
     error?: string;
     last_checked?: number;
   }
+  export type RestartPolicy = 'never' | 'on-failure' | 'always';
+  export interface ServerInfo {
+    name: string;
+    version: string;
+  }
+  export interface RuntimeStatus {
+    name: string;
+    state: 'starting' | 'running' | 'unhealthy' | 'stopped' | 'failed';
+    pid?: number;
+    restart_policy: RestartPolicy;
+    restarts: number;
+    last_error?: string;
+    exit_code?: number;
+    server_info?: ServerInfo;
+    started_at?: number;
+    last_health_check?: number;
+  }
+  export interface Tool {
+    name: string;
+    title?: string;
+    description?: string;
+    inputSchema?: Record<string, any>;
+    annotations?: Record<string, any>;
+  }
+  export interface ToolContent {
+    type: string;
+    text?: string;
+    data?: string;
+    mimeType?: string;
+    resource?: Record<string, any>;
+  }
+  export interface CallToolResult {
+    content: ToolContent[];
+    structuredContent?: Record<string, any>;
+    isError: boolean;
+  }
+  export interface RegistryInput {
+    name: string;
+    description?: string;
+    isRequired?: boolean;
+    isSecret?: boolean;
+    default?: string;
+    value?: string;
+  }
+  export interface RegistryArgument extends RegistryInput {
+    type: 'positional' | 'named';
+    valueHint?: string;
+  }
+  export interface RegistryPackage {
+    registryType: string;
+    identifier: string;
+    version?: string;
+    runtimeHint?: string;
+    transport: { type: string; url?: string };
+    runtimeArguments?: RegistryArgument[];
+    packageArguments?: RegistryArgument[];
+    environmentVariables?: RegistryInput[];
+  }
+  export interface RegistryRemote {
+    type: string;
+    url: string;
+    headers?: RegistryInput[];
+  }
+  export interface RegistryServer {
+    name: string;
+    title?: string;
+    description?: string;
+    version?: string;
+    websiteUrl?: string;
+    repository?: { url: string; source?: string };
+    packages?: RegistryPackage[];
+    remotes?: RegistryRemote[];
+  }
+  export interface RegistrySearchResult {
+    servers: RegistryServer[];
+    next_cursor?: string;
+  }
 }
 
 export namespace plugin {
@@ -448,6 +1496,13 @@ export namespace plugin {
     git_commit_sha?: string;
     is_local?: boolean;
     marketplace?: string;
+    pinned_ref?: string;
+  }
+  /** Payload of plugin:progress */
+  export interface Progress {
+    plugin_id: string;
+    stage: 'marketplace' | 'fetch' | 'install' | 'register' | 'done';
+    message: string;
   }
   export interface PluginContents {
     plugin: Plugin;
@@ -485,12 +1540,229 @@ export namespace plugin {
     file_path: string;
     full_command: string;
   }
-  export interface PluginHook {
-    event_type: string;
-    matcher?: string;
-    command: string;
-    plugin_id: string;
-    plugin_name: string;
+  export interface PluginHook {
+    event_type: string;
+    matcher?: string;
+    command: string;
+    plugin_id: string;
+    plugin_name: string;
+  }
+}
+
+export namespace slashexpand {
+  export interface ShellRun {
+    command: string;
+    output: string;
+    exit_code: number;
+    timed_out: boolean;
+    truncated: boolean;
+    error?: string;
+  }
+  export interface FileRef {
+    reference: string;
+    path: string;
+    inlined: boolean;
+    truncated: boolean;
+    error?: string;
+  }
+  export interface Expansion {
+    prompt: string;
+    shell: ShellRun[];
+    files: FileRef[];
+    sandboxed: boolean;
+  }
+}
+
+export namespace filechanges {
+  // File sums up the changes a session made to one file
+  export interface File {
+    path: string;
+    kind: 'created' | 'modified' | 'deleted';
+    ranges: database.LineRange[];
+    changes: database.SessionFileChange[];
+    changed_at: string;
+    // verdict on the changes, absent until reviewed and once the file changes again
+    review?: 'accepted' | 'rejected';
+  }
+}
+
+export namespace models {
+  // DiscoveredModel is a model ID listed by a provider endpoint: "new" ones
+  // can be imported, "exists" ones already are, "unsupported" ones never are
+  export interface DiscoveredModel {
+    model_id: string;
+    display_name: string;
+    status: 'new' | 'exists' | 'unsupported';
+    capabilities?: database.ModelCapabilities;
+  }
+}
+
+export namespace projectmemory {
+  export interface Language {
+    name: string;
+    files: number;
+  }
+  export interface Excerpt {
+    path: string;
+    content: string;
+    truncated?: boolean;
+  }
+  // Survey describes a repository: languages, manifests and layout
+  export interface Survey {
+    name: string;
+    files: number;
+    languages: Language[];
+    manifests: Excerpt[];
+    layout: string[];
+    readme?: Excerpt;
+  }
+}
+
+export namespace git {
+  // DiffFileStat counts the lines a diff changes in one file
+  export interface DiffFileStat {
+    path: string;
+    additions: number;
+    deletions: number;
+    binary?: boolean;
+  }
+  // TreeDiff is the difference between a commit and a work tree
+  export interface TreeDiff {
+    files: DiffFileStat[];
+    additions: number;
+    deletions: number;
+    patch: string;
+    truncated?: boolean;
+  }
+  // CheckpointHunk is one hunk of the changes made to a file since a checkpoint
+  export interface CheckpointHunk {
+    header: string;
+    lines: string[];
+  }
+  // RestoreResult lists the files a checkpoint restore changed, relative to the repository
+  export interface RestoreResult {
+    restored: string[];
+    removed: string[];
+  }
+}
+
+export namespace projectscan {
+  // Candidate is a git repository found by ScanForProjects
+  export interface Candidate {
+    path: string;
+    name: string;
+    languages: string[];
+    frameworks: string[];
+    // an indexed project already has the name; adding this one would update it
+    name_in_use: boolean;
+  }
+}
+
+export namespace trash {
+  // Item is a trashed file or directory, restored to path
+  export interface Item {
+    id: string;
+    path: string;
+    dir: boolean;
+    size: number;
+    reason: string;
+    trashed_at: string;
+  }
+}
+
+export namespace retention {
+  export interface ProviderStats {
+    provider: string;
+    root: string;
+    files: number;
+    bytes: number;
+    // transcripts the retention settings would archive
+    reclaimable_files: number;
+    reclaimable_bytes: number;
+  }
+  export interface Stats {
+    providers: ProviderStats[];
+    files: number;
+    bytes: number;
+    reclaimable_files: number;
+    reclaimable_bytes: number;
+    archive_dir: string;
+    archived_files: number;
+    archived_bytes: number;
+  }
+  export interface ArchiveResult {
+    archived: number;
+    // size of the archived transcripts less the size of their archives
+    freed_bytes: number;
+    failed: string[];
+    stats: Stats;
+  }
+}
+
+export namespace jobs {
+  export interface Job {
+    key: string;
+    command: string;
+    // set for programs run without a shell
+    args?: string[];
+    cwd: string;
+    started_at: string;
+    finished_at?: string;
+    // running, succeeded, failed, cancelled or timed_out
+    status: string;
+    exit_code?: number;
+  }
+  export interface Output {
+    job_key: string;
+    // stdout, stderr or exit
+    output_type: string;
+    content: string;
+    exit_code?: number;
+    // with exit
+    status?: string;
+  }
+}
+
+export namespace cliinstall {
+  export interface Method {
+    // native, npm or brew
+    id: string;
+    label: string;
+    command: string;
+    shell?: string;
+  }
+}
+
+export namespace clicompat {
+  export interface FeatureSupport {
+    id: string;
+    flag: string;
+    description: string;
+    // the first release known to have flag
+    min_version: string;
+    supported: boolean;
+  }
+  export interface Capabilities {
+    provider: string;
+    path?: string;
+    // the line the CLI printed for --version
+    version?: string;
+    // false when the version could not be read; every feature is then assumed supported
+    known: boolean;
+    error?: string;
+    features: FeatureSupport[];
+  }
+}
+
+export namespace loginenv {
+  export interface Snapshot {
+    shell?: string;
+    captured_at: string;
+    duration_ms: number;
+    // how many variables were captured; their values are not exposed
+    variables: number;
+    // why nothing was captured
+    error?: string;
   }
 }
 
@@ -508,6 +1780,29 @@ export function CreatePtySession(sessionId: string, cwd: string, rows: number, c
   return wsClient.call('CreatePtySession', sessionId, cwd, rows, cols, shell);
 }
 
+export function GetShellInfo(): Promise<main.ShellInfo> {
+  return wsClient.call('GetShellInfo');
+}
+
+export function GetWSLInfo(): Promise<main.WSLInfo> {
+  return wsClient.call('GetWSLInfo');
+}
+
+export function ReloadEnvironment(): Promise<loginenv.Snapshot> {
+  return wsClient.call('ReloadEnvironment');
+}
+
+export function StartProviderTerminal(
+  terminalId: string,
+  provider: string,
+  projectPath: string,
+  resumeSessionId: string,
+  rows: number,
+  cols: number
+): Promise<main.ProviderTerminal> {
+  return wsClient.call('StartProviderTerminal', terminalId, provider, projectPath, resumeSessionId, rows, cols);
+}
+
 export function WriteToPty(sessionId: string, data: string): Promise<void> {
   return wsClient.call('WriteToPty', sessionId, data);
 }
@@ -580,6 +1875,70 @@ export function GetSetting(key: string): Promise<string> {
   return wsClient.call('GetSetting', key);
 }
 
+export function GetSessionSystemPrompt(scope: 'global' | 'project', projectPath = ''): Promise<string> {
+  return wsClient.call('GetSessionSystemPrompt', scope, projectPath);
+}
+
+export function SaveSessionSystemPrompt(scope: 'global' | 'project', projectPath: string, content: string): Promise<void> {
+  return wsClient.call('SaveSessionSystemPrompt', scope, projectPath, content);
+}
+
+export function PreviewSessionSystemPrompt(projectPath: string): Promise<sysprompt.Resolved> {
+  return wsClient.call('PreviewSessionSystemPrompt', projectPath);
+}
+
+export function TestProxy(settings: netproxy.Settings, target = ''): Promise<netproxy.TestResult> {
+  return wsClient.call('TestProxy', settings, target);
+}
+
+export function GetSettings(): Promise<settings.Values> {
+  return wsClient.call('GetSettings');
+}
+
+export function UpdateSettings(values: settings.Values): Promise<settings.Values> {
+  return wsClient.call('UpdateSettings', values);
+}
+
+export function GetSettingsSchema(): Promise<settings.Field[]> {
+  return wsClient.call('GetSettingsSchema');
+}
+
+export function GetRecentLogs(level: string, limit: number): Promise<logging.Entry[]> {
+  return wsClient.call('GetRecentLogs', level, limit);
+}
+
+export function GetLogLevel(): Promise<string> {
+  return wsClient.call('GetLogLevel');
+}
+
+export function SetLogLevel(level: string): Promise<void> {
+  return wsClient.call('SetLogLevel', level);
+}
+
+export function ExportAppConfig(filePath: string, includeTokens: boolean): Promise<main.AppConfigSummary> {
+  return wsClient.call('ExportAppConfig', filePath, includeTokens);
+}
+
+export function ImportAppConfig(filePath: string): Promise<main.AppConfigSummary> {
+  return wsClient.call('ImportAppConfig', filePath);
+}
+
+export function GetSettingsSyncConfig(): Promise<main.SettingsSyncConfig | null> {
+  return wsClient.call('GetSettingsSyncConfig');
+}
+
+export function SetSettingsSyncConfig(repoUrl: string, branch: string): Promise<main.SettingsSyncConfig> {
+  return wsClient.call('SetSettingsSyncConfig', repoUrl, branch);
+}
+
+export function PullSettingsSync(strategy: main.SettingsSyncStrategy = ''): Promise<main.SettingsSyncResult> {
+  return wsClient.call('PullSettingsSync', strategy);
+}
+
+export function PushSettingsSync(strategy: main.SettingsSyncStrategy = ''): Promise<main.SettingsSyncResult> {
+  return wsClient.call('PushSettingsSync', strategy);
+}
+
 export function GenerateSessionTitle(prompt: string): Promise<string> {
   return wsClient.call('GenerateSessionTitle', prompt);
 }
@@ -653,6 +2012,18 @@ export function GetProviderApiConfig(id: string): Promise<database.ProviderApiCo
   return wsClient.call('GetProviderApiConfig', id);
 }
 
+export function GetProviderFailoverChain(providerId: string): Promise<string[]> {
+  return wsClient.call('GetProviderFailoverChain', providerId);
+}
+
+export function SetProviderFailoverChain(providerId: string, providerApiIds: string[]): Promise<void> {
+  return wsClient.call('SetProviderFailoverChain', providerId, providerApiIds);
+}
+
+export function GetSessionProviderApi(sessionId: string): Promise<database.SessionProviderApi | null> {
+  return wsClient.call('GetSessionProviderApi', sessionId);
+}
+
 export function GetAllProviderApiConfigs(): Promise<database.ProviderApiConfig[]> {
   return wsClient.call('GetAllProviderApiConfigs');
 }
@@ -665,6 +2036,14 @@ export function SetProjectProviderApiConfig(projectPath: string, providerName: s
   return wsClient.call('SetProjectProviderApiConfig', projectPath, providerName, configId);
 }
 
+export function GetProjectModelDefaults(projectPath: string, providerName: string): Promise<main.ProjectModelDefaults> {
+  return wsClient.call('GetProjectModelDefaults', projectPath, providerName);
+}
+
+export function SetProjectModelDefaults(projectPath: string, providerName: string, model: string, thinkingLevel: string): Promise<void> {
+  return wsClient.call('SetProjectModelDefaults', projectPath, providerName, model, thinkingLevel);
+}
+
 export function SaveProviderApiConfig(config: database.ProviderApiConfig): Promise<void> {
   return wsClient.call('SaveProviderApiConfig', config);
 }
@@ -691,6 +2070,14 @@ export function AddProjectToIndex(path: string): Promise<void> {
   return wsClient.call('AddProjectToIndex', path);
 }
 
+export function ScanForProjects(rootDirs: string[]): Promise<projectscan.Candidate[]> {
+  return wsClient.call('ScanForProjects', rootDirs);
+}
+
+export function AddProjectsToIndex(paths: string[]): Promise<main.ProjectsAddResult> {
+  return wsClient.call('AddProjectsToIndex', paths);
+}
+
 export function UpdateProjectAccessTime(path: string): Promise<void> {
   return wsClient.call('UpdateProjectAccessTime', path);
 }
@@ -727,8 +2114,8 @@ export function OpenNewSession(projectPath: string): Promise<string> {
   return wsClient.call('OpenNewSession', projectPath);
 }
 
-export function GetProjectSessions(projectPath: string): Promise<string[]> {
-  return wsClient.call('GetProjectSessions', projectPath);
+export function GetProjectSessions(projectId: string): Promise<claude.SessionSummary[]> {
+  return wsClient.call('GetProjectSessions', projectId);
 }
 
 export function ListSpaceSessions(projectPath: string, limit: number): Promise<main.SpaceSessionsResult> {
@@ -739,6 +2126,10 @@ export function ListRunningProviderSessions(): Promise<main.LiveProviderSession[
   return wsClient.call('ListRunningProviderSessions');
 }
 
+export function GetSessionResourceUsage(): Promise<main.ResourceUsage[]> {
+  return wsClient.call('GetSessionResourceUsage');
+}
+
 export function GetActions(projectPath: string, workspaceId: string): Promise<main.ActionsResult> {
   return wsClient.call('GetActions', projectPath, workspaceId);
 }
@@ -755,18 +2146,100 @@ export function UpdateGlobalActions(actions: main.Action[]): Promise<void> {
   return wsClient.call('UpdateGlobalActions', actions);
 }
 
+export function RunAction(actionID: string, scope: string, cwd: string): Promise<database.ActionRun> {
+  return wsClient.call('RunAction', actionID, scope, cwd);
+}
+
+export function StopAction(runID: string): Promise<void> {
+  return wsClient.call('StopAction', runID);
+}
+
+export function ListActionRuns(filter: database.ActionRunFilter = {}): Promise<database.ActionRun[]> {
+  return wsClient.call('ListActionRuns', filter);
+}
+
+export function ListProjectEnvVars(projectPath: string): Promise<database.ProjectEnvVar[]> {
+  return wsClient.call('ListProjectEnvVars', projectPath);
+}
+
+export function SaveProjectEnvVar(v: Partial<database.ProjectEnvVar>): Promise<database.ProjectEnvVar> {
+  return wsClient.call('SaveProjectEnvVar', v);
+}
+
+export function DeleteProjectEnvVar(id: number): Promise<void> {
+  return wsClient.call('DeleteProjectEnvVar', id);
+}
+
+export function ListSnippets(): Promise<database.Snippet[]> {
+  return wsClient.call('ListSnippets');
+}
+
+export function SaveSnippet(snippet: Partial<database.Snippet>): Promise<database.Snippet> {
+  return wsClient.call('SaveSnippet', snippet);
+}
+
+export function DeleteSnippet(id: number): Promise<void> {
+  return wsClient.call('DeleteSnippet', id);
+}
+
+export function SearchSnippets(query: string, limit = 0): Promise<database.Snippet[]> {
+  return wsClient.call('SearchSnippets', query, limit);
+}
+
+export function UseSnippet(id: number): Promise<database.Snippet> {
+  return wsClient.call('UseSnippet', id);
+}
+
+export function ListPermissionProfiles(): Promise<database.PermissionProfile[]> {
+  return wsClient.call('ListPermissionProfiles');
+}
+
+export function SavePermissionProfile(profile: Partial<database.PermissionProfile>): Promise<database.PermissionProfile> {
+  return wsClient.call('SavePermissionProfile', profile);
+}
+
+export function DeletePermissionProfile(id: string): Promise<void> {
+  return wsClient.call('DeletePermissionProfile', id);
+}
+
+export function GetProjectPermissionProfile(projectPath: string): Promise<string> {
+  return wsClient.call('GetProjectPermissionProfile', projectPath);
+}
+
+export function SetProjectPermissionProfile(projectPath: string, profileId: string): Promise<void> {
+  return wsClient.call('SetProjectPermissionProfile', projectPath, profileId);
+}
+
+export function GetSessionPermissionProfile(projectPath: string): Promise<string> {
+  return wsClient.call('GetSessionPermissionProfile', projectPath);
+}
+
+export function SetSessionPermissionProfile(projectPath: string, profileId: string): Promise<void> {
+  return wsClient.call('SetSessionPermissionProfile', projectPath, profileId);
+}
+
 // ==================== Workspace 管理 ====================
 
 export function CreateWorkspace(projectPath: string, branch: string, sessionId: string): Promise<void> {
   return wsClient.call('CreateWorkspace', projectPath, branch, sessionId);
 }
 
-export function RemoveWorkspace(workspaceId: string): Promise<void> {
-  return wsClient.call('RemoveWorkspace', workspaceId);
+// With workspace protection on, RemoveWorkspace and CleanupWorkspace reject
+// with a "protection_enabled" error unless override is true
+export function RemoveWorkspace(workspaceId: string, override: boolean): Promise<void> {
+  return wsClient.call('RemoveWorkspace', workspaceId, override);
+}
+
+export function CleanupWorkspace(workspaceId: string, override: boolean): Promise<string> {
+  return wsClient.call('CleanupWorkspace', workspaceId, override);
+}
+
+export function GetWorkspaceProtectionEnabled(): Promise<boolean> {
+  return wsClient.call('GetWorkspaceProtectionEnabled');
 }
 
-export function CleanupWorkspace(workspaceId: string): Promise<string> {
-  return wsClient.call('CleanupWorkspace', workspaceId);
+export function SetWorkspaceProtectionEnabled(enabled: boolean): Promise<void> {
+  return wsClient.call('SetWorkspaceProtectionEnabled', enabled);
 }
 
 export function CheckWorkspaceClean(workspaceId: string): Promise<void> {
@@ -902,6 +2375,22 @@ export function ResumeProviderSession(
   return wsClient.call('ResumeProviderSession', provider, projectPath, prompt, model, sessionId, providerApiId || '', reasoningEffort || '');
 }
 
+export function GetSessionConcurrencyLimits(): Promise<scheduler.Limits> {
+  return wsClient.call('GetSessionConcurrencyLimits');
+}
+
+export function SetSessionConcurrencyLimits(limits: scheduler.Limits): Promise<void> {
+  return wsClient.call('SetSessionConcurrencyLimits', limits);
+}
+
+export function ListQueuedProviderSessions(): Promise<scheduler.QueueEntry[]> {
+  return wsClient.call('ListQueuedProviderSessions');
+}
+
+export function CancelQueuedProviderSession(queueId: string): Promise<void> {
+  return wsClient.call('CancelQueuedProviderSession', queueId);
+}
+
 export function UpdateProviderSession(projectPath: string, sessionId: string, thinkingLevel: string): Promise<void> {
   return wsClient.call('UpdateProviderSession', projectPath, sessionId, thinkingLevel);
 }
@@ -950,6 +2439,22 @@ export function GetSessionMessagesRange(
   return wsClient.call('GetSessionMessagesRange', projectPath, sessionId, start, end);
 }
 
+export function TailSessionHistory(
+  projectPath: string,
+  sessionId: string,
+  count: number
+): Promise<main.SessionHistoryTail> {
+  return wsClient.call('TailSessionHistory', projectPath, sessionId, count);
+}
+
+export function FollowSessionHistory(projectPath: string, sessionId: string, offset: number): Promise<number> {
+  return wsClient.call('FollowSessionHistory', projectPath, sessionId, offset);
+}
+
+export function StopFollowingSessionHistory(followId: number): Promise<void> {
+  return wsClient.call('StopFollowingSessionHistory', followId);
+}
+
 export function GetSessionStats(projectPath: string, sessionId: string): Promise<any[]> {
   return wsClient.call('GetSessionStats', projectPath, sessionId);
 }
@@ -1016,10 +2521,99 @@ export function SetClaudeBinaryPath(path: string): Promise<void> {
   return wsClient.call('SetClaudeBinaryPath', path);
 }
 
+export function SwitchClaudeInstallation(path: string): Promise<void> {
+  return wsClient.call('SwitchClaudeInstallation', path);
+}
+
+export function SwitchProviderInstallation(provider: string, path: string): Promise<void> {
+  return wsClient.call('SwitchProviderInstallation', provider, path);
+}
+
+export function GetProviderCapabilities(provider: string): Promise<clicompat.Capabilities> {
+  return wsClient.call('GetProviderCapabilities', provider);
+}
+
+export function CheckProviderVersion(provider: string): Promise<main.ProviderVersionInfo> {
+  return wsClient.call('CheckProviderVersion', provider);
+}
+
+export function ListProviderInstallations(provider: string): Promise<main.ProviderInstallation[]> {
+  return wsClient.call('ListProviderInstallations', provider);
+}
+
+export function ListInstallMethods(provider: string): Promise<cliinstall.Method[]> {
+  return wsClient.call('ListInstallMethods', provider);
+}
+
+export function InstallProviderBinary(provider: string, method: string): Promise<string> {
+  return wsClient.call('InstallProviderBinary', provider, method);
+}
+
+export function InstallClaudeBinary(method: string): Promise<string> {
+  return wsClient.call('InstallClaudeBinary', method);
+}
+
 export function CheckClaudeVersion(): Promise<main.ClaudeVersionInfo> {
   return wsClient.call('CheckClaudeVersion');
 }
 
+export function Doctor(): Promise<main.DoctorReport> {
+  return wsClient.call('Doctor');
+}
+
+export function GetAppHealth(): Promise<main.AppHealth> {
+  return wsClient.call('GetAppHealth');
+}
+
+export function GetAppVersion(): Promise<main.AppVersionInfo> {
+  return wsClient.call('GetAppVersion');
+}
+
+export function GetUpdateStatus(): Promise<main.UpdateStatus> {
+  return wsClient.call('GetUpdateStatus');
+}
+
+export function CheckForUpdates(): Promise<main.UpdateStatus> {
+  return wsClient.call('CheckForUpdates');
+}
+
+/** Downloads, verifies and stages the newest release; progress arrives as update:progress */
+export function DownloadUpdate(): Promise<main.UpdateStatus> {
+  return wsClient.call('DownloadUpdate');
+}
+
+export function InstallUpdate(): Promise<main.UpdateStatus> {
+  return wsClient.call('InstallUpdate');
+}
+
+/** Counts one use of a feature while telemetry is on; names are short identifiers */
+export function RecordTelemetryFeature(name: string): Promise<void> {
+  return wsClient.call('RecordTelemetryFeature', name);
+}
+
+export function ViewPendingTelemetry(): Promise<main.TelemetryReport> {
+  return wsClient.call('ViewPendingTelemetry');
+}
+
+export function FlushTelemetry(): Promise<main.TelemetryFlushResult> {
+  return wsClient.call('FlushTelemetry');
+}
+
+export function ClearTelemetry(): Promise<void> {
+  return wsClient.call('ClearTelemetry');
+}
+
+/** Writes a zip of logs, version, OS info and anonymized settings to path */
+export function ExportDiagnostics(path: string): Promise<main.DiagnosticsSummary> {
+  return wsClient.call('ExportDiagnostics', path);
+}
+
+/** Whether an RPC error comes from a subsystem that failed to initialize */
+export function isSubsystemUnavailable(err: unknown): boolean {
+  const message = err instanceof Error ? err.message : String(err);
+  return / unavailable: /.test(message);
+}
+
 export function ListClaudeInstallations(): Promise<main.ClaudeInstallation[]> {
   return wsClient.call('ListClaudeInstallations');
 }
@@ -1074,6 +2668,22 @@ export function UnwatchGitWorkspace(workspaceId: string): Promise<void> {
   return wsClient.call('UnwatchGitWorkspace', workspaceId);
 }
 
+export function WatchProjectFiles(projectPath: string): Promise<void> {
+  return wsClient.call('WatchProjectFiles', projectPath);
+}
+
+export function UnwatchProjectFiles(projectPath: string): Promise<void> {
+  return wsClient.call('UnwatchProjectFiles', projectPath);
+}
+
+export function WatchGitStatus(projectPath: string): Promise<void> {
+  return wsClient.call('WatchGitStatus', projectPath);
+}
+
+export function UnwatchGitStatus(projectPath: string): Promise<void> {
+  return wsClient.call('UnwatchGitStatus', projectPath);
+}
+
 export function DetectWorktree(projectPath: string): Promise<main.WorktreeInfo> {
   return wsClient.call('DetectWorktree', projectPath);
 }
@@ -1088,52 +2698,175 @@ export function ListDirectoryContents(path: string): Promise<main.FileEntry[]> {
   return wsClient.call('ListDirectoryContents', path);
 }
 
-export function ReadFile(path: string): Promise<string> {
-  return wsClient.call('ReadFile', path);
+export function GetDirectoryTree(basePath: string, depth: number, respectGitignore: boolean): Promise<main.DirectoryTreeNode> {
+  return wsClient.call('GetDirectoryTree', basePath, depth, respectGitignore);
+}
+
+export function ReadFile(path: string): Promise<string> {
+  return wsClient.call('ReadFile', path);
+}
+
+export function ReadFileSmart(path: string, maxBytes: number): Promise<main.SmartFileContent> {
+  return wsClient.call('ReadFileSmart', path, maxBytes);
+}
+
+export function WriteFile(path: string, content: string): Promise<void> {
+  return wsClient.call('WriteFile', path, content);
+}
+
+export function DeleteFile(path: string, permanent: boolean): Promise<void> {
+  return wsClient.call('DeleteFile', path, permanent);
+}
+
+export function DeleteDirectory(path: string, permanent: boolean): Promise<void> {
+  return wsClient.call('DeleteDirectory', path, permanent);
+}
+
+export function RenamePath(oldPath: string, newPath: string): Promise<void> {
+  return wsClient.call('RenamePath', oldPath, newPath);
+}
+
+export function CopyPath(srcPath: string, dstPath: string): Promise<void> {
+  return wsClient.call('CopyPath', srcPath, dstPath);
+}
+
+export function GetFileMetadata(path: string): Promise<main.FileMetadata> {
+  return wsClient.call('GetFileMetadata', path);
+}
+
+export function ReadGitFileAtHead(workspacePath: string, gitPath: string): Promise<string> {
+  return wsClient.call('ReadGitFileAtHead', workspacePath, gitPath);
+}
+
+export function SearchFiles(path: string, query: string): Promise<main.FileEntry[]> {
+  return wsClient.call('SearchFiles', path, query);
+}
+
+export function SearchFileContents(
+  basePath: string,
+  query: string,
+  regex: boolean,
+  includeGlobs: string[],
+  maxResults: number
+): Promise<number> {
+  return wsClient.call('SearchFileContents', basePath, query, regex, includeGlobs, maxResults);
+}
+
+export function CancelFileContentSearch(searchId: number): Promise<void> {
+  return wsClient.call('CancelFileContentSearch', searchId);
+}
+
+export function OpenInEditor(path: string): Promise<void> {
+  return wsClient.call('OpenInEditor', path);
+}
+
+export function OpenInExternalApp(appType: string, path: string): Promise<void> {
+  return wsClient.call('OpenInExternalApp', appType, path);
+}
+
+export function OpenInTerminal(path: string): Promise<void> {
+  return wsClient.call('OpenInTerminal', path);
+}
+
+export function ListOpenInApps(): Promise<string[]> {
+  return wsClient.call('ListOpenInApps');
+}
+
+export function OpenFileDialog(title: string, defaultPath: string, filters: any[]): Promise<string> {
+  return wsClient.call('OpenFileDialog', title, defaultPath, filters);
+}
+
+export function OpenDirectoryDialog(title: string, defaultPath: string): Promise<string> {
+  return wsClient.call('OpenDirectoryDialog', title, defaultPath);
+}
+
+export function SavePastedImage(base64Data: string, filename: string): Promise<main.PastedImage> {
+  return wsClient.call('SavePastedImage', base64Data, filename);
+}
+
+export function SaveRecordedAudio(base64Data: string, filename: string): Promise<main.RecordedAudio> {
+  return wsClient.call('SaveRecordedAudio', base64Data, filename);
+}
+
+export function TranscribeAudio(path: string): Promise<main.TranscriptionResult> {
+  return wsClient.call('TranscribeAudio', path);
+}
+
+export function GetEffectiveMemory(projectPath: string): Promise<claude.EffectiveMemory> {
+  return wsClient.call('GetEffectiveMemory', projectPath);
+}
+
+export function GenerateProjectMemory(projectPath: string, provider: string): Promise<main.ProjectMemoryDraft> {
+  return wsClient.call('GenerateProjectMemory', projectPath, provider);
+}
+
+export function ShareSession(sessionId: string, projectPath: string, provider: string, redactPaths: boolean): Promise<main.SessionShare> {
+  return wsClient.call('ShareSession', sessionId, projectPath, provider, redactPaths);
+}
+
+export function GetSessionChangedFiles(sessionId: string): Promise<filechanges.File[]> {
+  return wsClient.call('GetSessionChangedFiles', sessionId);
+}
+
+export function GetSessionEditHunks(sessionId: string, file: string): Promise<git.CheckpointHunk[]> {
+  return wsClient.call('GetSessionEditHunks', sessionId, file);
+}
+
+export function AcceptSessionEdit(sessionId: string, file: string): Promise<void> {
+  return wsClient.call('AcceptSessionEdit', sessionId, file);
+}
+
+// hunk < 0 reverts the whole file
+export function RejectSessionEdit(sessionId: string, file: string, hunk: number): Promise<void> {
+  return wsClient.call('RejectSessionEdit', sessionId, file, hunk);
+}
+
+export function ListCheckpoints(sessionId: string): Promise<database.SessionCheckpoint[]> {
+  return wsClient.call('ListCheckpoints', sessionId);
 }
 
-export function WriteFile(path: string, content: string): Promise<void> {
-  return wsClient.call('WriteFile', path, content);
+export function RestoreCheckpoint(sessionId: string): Promise<git.RestoreResult> {
+  return wsClient.call('RestoreCheckpoint', sessionId);
 }
 
-export function GetFileMetadata(path: string): Promise<main.FileMetadata> {
-  return wsClient.call('GetFileMetadata', path);
+export function GetTempImagesStats(): Promise<main.TempImagesStats> {
+  return wsClient.call('GetTempImagesStats');
 }
 
-export function ReadGitFileAtHead(workspacePath: string, gitPath: string): Promise<string> {
-  return wsClient.call('ReadGitFileAtHead', workspacePath, gitPath);
+export function CleanupTempImages(): Promise<main.TempImagesCleanupResult> {
+  return wsClient.call('CleanupTempImages');
 }
 
-export function SearchFiles(path: string, query: string): Promise<main.FileEntry[]> {
-  return wsClient.call('SearchFiles', path, query);
+export function ListTrash(): Promise<trash.Item[]> {
+  return wsClient.call('ListTrash');
 }
 
-export function OpenInEditor(path: string): Promise<void> {
-  return wsClient.call('OpenInEditor', path);
+export function RestoreFromTrash(id: string): Promise<trash.Item> {
+  return wsClient.call('RestoreFromTrash', id);
 }
 
-export function OpenInExternalApp(appType: string, path: string): Promise<void> {
-  return wsClient.call('OpenInExternalApp', appType, path);
+export function GetSessionRetentionStats(): Promise<retention.Stats> {
+  return wsClient.call('GetSessionRetentionStats');
 }
 
-export function OpenInTerminal(path: string): Promise<void> {
-  return wsClient.call('OpenInTerminal', path);
+export function ArchiveOldSessions(): Promise<retention.ArchiveResult> {
+  return wsClient.call('ArchiveOldSessions');
 }
 
-export function ListOpenInApps(): Promise<string[]> {
-  return wsClient.call('ListOpenInApps');
+export function GetStorageReport(): Promise<main.StorageReport> {
+  return wsClient.call('GetStorageReport');
 }
 
-export function OpenFileDialog(title: string, defaultPath: string, filters: any[]): Promise<string> {
-  return wsClient.call('OpenFileDialog', title, defaultPath, filters);
+export function CleanupStorage(action: string): Promise<main.StorageReport> {
+  return wsClient.call('CleanupStorage', action);
 }
 
-export function OpenDirectoryDialog(title: string, defaultPath: string): Promise<string> {
-  return wsClient.call('OpenDirectoryDialog', title, defaultPath);
+export function AddAttachment(sessionId: string, provider: string, sourcePath: string): Promise<main.AttachmentResult> {
+  return wsClient.call('AddAttachment', sessionId, provider, sourcePath);
 }
 
-export function SavePastedImage(projectPath: string, imageData: string): Promise<string> {
-  return wsClient.call('SavePastedImage', projectPath, imageData);
+export function GetAttachmentReference(provider: string, path: string): Promise<string> {
+  return wsClient.call('GetAttachmentReference', provider, path);
 }
 
 // ==================== Model 配置 ====================
@@ -1150,6 +2883,14 @@ export function SyncProviderModelsFromAPI(providerId: string, providerApiId = ''
   return wsClient.call('SyncProviderModelsFromAPI', providerId, providerApiId);
 }
 
+export function DiscoverModels(providerApiId: string): Promise<main.ModelDiscovery> {
+  return wsClient.call('DiscoverModels', providerApiId);
+}
+
+export function ImportDiscoveredModels(providerApiId: string, modelIds: string[]): Promise<database.ModelConfig[]> {
+  return wsClient.call('ImportDiscoveredModels', providerApiId, modelIds);
+}
+
 export function CreateModelConfig(config: database.ModelConfig): Promise<void> {
   return wsClient.call('CreateModelConfig', config);
 }
@@ -1264,6 +3005,90 @@ export function CancelAgentRun(id: number): Promise<void> {
   return wsClient.call('CancelAgentRun', id);
 }
 
+export function GetAgentRunMetrics(id: number): Promise<database.AgentRunMetrics | null> {
+  return wsClient.call('GetAgentRunMetrics', id);
+}
+
+export function GetAgentMetricsSummary(agentId: number): Promise<database.AgentMetricsSummary[]> {
+  return wsClient.call('GetAgentMetricsSummary', agentId);
+}
+
+export function ExecuteAgentOnProjects(agentId: number, projectPaths: string[], task: string): Promise<database.AgentBatch> {
+  return wsClient.call('ExecuteAgentOnProjects', agentId, projectPaths, task);
+}
+
+export function GetAgentBatch(id: number): Promise<database.AgentBatch> {
+  return wsClient.call('GetAgentBatch', id);
+}
+
+export function ListAgentBatches(agentId: number, limit: number): Promise<database.AgentBatch[]> {
+  return wsClient.call('ListAgentBatches', agentId, limit);
+}
+
+export function CancelAgentBatch(id: number): Promise<void> {
+  return wsClient.call('CancelAgentBatch', id);
+}
+
+export function DeleteAgentBatch(id: number): Promise<void> {
+  return wsClient.call('DeleteAgentBatch', id);
+}
+
+export function CompareProviders(projectPath: string, prompt: string, providers: string[]): Promise<database.ProviderComparison> {
+  return wsClient.call('CompareProviders', projectPath, prompt, providers);
+}
+
+export function GetProviderComparison(id: number): Promise<database.ProviderComparison> {
+  return wsClient.call('GetProviderComparison', id);
+}
+
+export function ListProviderComparisons(projectPath: string, limit: number): Promise<database.ProviderComparison[]> {
+  return wsClient.call('ListProviderComparisons', projectPath, limit);
+}
+
+export function CancelProviderComparison(id: number): Promise<void> {
+  return wsClient.call('CancelProviderComparison', id);
+}
+
+export function DeleteProviderComparison(id: number): Promise<void> {
+  return wsClient.call('DeleteProviderComparison', id);
+}
+
+export function ListPipelines(): Promise<database.Pipeline[]> {
+  return wsClient.call('ListPipelines');
+}
+
+export function GetPipeline(id: number): Promise<database.Pipeline> {
+  return wsClient.call('GetPipeline', id);
+}
+
+export function CreatePipeline(name: string, description: string, steps: database.PipelineStep[]): Promise<database.Pipeline> {
+  return wsClient.call('CreatePipeline', name, description, steps);
+}
+
+export function UpdatePipeline(id: number, name: string, description: string, steps: database.PipelineStep[]): Promise<database.Pipeline> {
+  return wsClient.call('UpdatePipeline', id, name, description, steps);
+}
+
+export function DeletePipeline(id: number): Promise<void> {
+  return wsClient.call('DeletePipeline', id);
+}
+
+export function ExecutePipeline(pipelineId: number, projectPath: string, task: string): Promise<database.PipelineRun> {
+  return wsClient.call('ExecutePipeline', pipelineId, projectPath, task);
+}
+
+export function GetPipelineRun(id: number): Promise<database.PipelineRun> {
+  return wsClient.call('GetPipelineRun', id);
+}
+
+export function ListPipelineRuns(pipelineId: number, limit: number): Promise<database.PipelineRun[]> {
+  return wsClient.call('ListPipelineRuns', pipelineId, limit);
+}
+
+export function CancelPipelineRun(id: number): Promise<void> {
+  return wsClient.call('CancelPipelineRun', id);
+}
+
 export function ExportAgent(id: number): Promise<string> {
   return wsClient.call('ExportAgent', id);
 }
@@ -1292,6 +3117,22 @@ export function FetchGitHubAgentContent(url: string): Promise<any> {
   return wsClient.call('FetchGitHubAgentContent', url);
 }
 
+export function GetAgentSources(): Promise<github.AgentSource[]> {
+  return wsClient.call('GetAgentSources');
+}
+
+export function SetAgentSources(sources: github.AgentSource[]): Promise<github.AgentSource[]> {
+  return wsClient.call('SetAgentSources', sources);
+}
+
+export function ListMarketplaceAgents(refresh: boolean): Promise<main.AgentMarketplaceIndex> {
+  return wsClient.call('ListMarketplaceAgents', refresh);
+}
+
+export function UpdateAgentFromSource(agentId: number, apply: boolean): Promise<main.AgentSourceUpdate> {
+  return wsClient.call('UpdateAgentFromSource', agentId, apply);
+}
+
 export function LoadAgentSessionHistory(sessionId: string): Promise<claude.Message[]> {
   return wsClient.call('LoadAgentSessionHistory', sessionId);
 }
@@ -1324,6 +3165,16 @@ export function McpAdd(
   return wsClient.call('McpAdd', name, command, args, env, projectPath);
 }
 
+export function McpAddRemote(
+  name: string,
+  transport: 'sse' | 'http',
+  url: string,
+  headers: Record<string, string>,
+  projectPath: string
+): Promise<main.MCPAddResult> {
+  return wsClient.call('McpAddRemote', name, transport, url, headers, projectPath);
+}
+
 export function McpAddJson(name: string, jsonConfig: string): Promise<main.MCPAddResult> {
   return wsClient.call('McpAddJson', name, jsonConfig);
 }
@@ -1348,6 +3199,42 @@ export function McpSaveProjectConfig(projectPath: string, config: main.MCPProjec
   return wsClient.call('McpSaveProjectConfig', projectPath, config);
 }
 
+export function ListProjectMcpServers(projectPath: string): Promise<main.ProjectMcpServer[]> {
+  return wsClient.call('ListProjectMcpServers', projectPath);
+}
+
+export function SetProjectMcpServerEnabled(projectPath: string, name: string, enabled: boolean): Promise<void> {
+  return wsClient.call('SetProjectMcpServerEnabled', projectPath, name, enabled);
+}
+
+export function GetMcpRegistryURL(): Promise<string> {
+  return wsClient.call('GetMcpRegistryURL');
+}
+
+export function SetMcpRegistryURL(url: string): Promise<void> {
+  return wsClient.call('SetMcpRegistryURL', url);
+}
+
+export function SearchMcpRegistry(query: string, cursor: string = ''): Promise<mcp.RegistrySearchResult> {
+  return wsClient.call('SearchMcpRegistry', query, cursor);
+}
+
+export function InstallMcpServerFromRegistry(
+  serverName: string,
+  installName: string,
+  values: Record<string, string>
+): Promise<mcp.MCPServer> {
+  return wsClient.call('InstallMcpServerFromRegistry', serverName, installName, values);
+}
+
+export function McpExportServers(path: string): Promise<number> {
+  return wsClient.call('McpExportServers', path);
+}
+
+export function McpImportServers(path: string): Promise<main.MCPImportResult> {
+  return wsClient.call('McpImportServers', path);
+}
+
 export function McpResetProjectChoices(): Promise<string> {
   return wsClient.call('McpResetProjectChoices');
 }
@@ -1356,6 +3243,30 @@ export function GetMcpServerStatus(name: string): Promise<mcp.MCPServerStatus> {
   return wsClient.call('GetMcpServerStatus', name);
 }
 
+export function StartMcpServer(name: string, restartPolicy: mcp.RestartPolicy | '' = ''): Promise<mcp.RuntimeStatus> {
+  return wsClient.call('StartMcpServer', name, restartPolicy);
+}
+
+export function StopMcpServer(name: string): Promise<void> {
+  return wsClient.call('StopMcpServer', name);
+}
+
+export function RestartMcpServer(name: string, restartPolicy: mcp.RestartPolicy | '' = ''): Promise<mcp.RuntimeStatus> {
+  return wsClient.call('RestartMcpServer', name, restartPolicy);
+}
+
+export function ListRunningMcpServers(): Promise<mcp.RuntimeStatus[]> {
+  return wsClient.call('ListRunningMcpServers');
+}
+
+export function McpListTools(name: string): Promise<mcp.Tool[]> {
+  return wsClient.call('McpListTools', name);
+}
+
+export function McpCallTool(name: string, tool: string, argsJson: string): Promise<mcp.CallToolResult> {
+  return wsClient.call('McpCallTool', name, tool, argsJson);
+}
+
 // ==================== Hooks ====================
 
 export function GetHooks(): Promise<claude.HooksConfig> {
@@ -1374,6 +3285,52 @@ export function GetMergedHooksConfig(projectPath: string): Promise<claude.HooksC
   return wsClient.call('GetMergedHooksConfig', projectPath);
 }
 
+export function GetProjectHooks(projectPath: string, scope: 'project' | 'local' | '' = ''): Promise<claude.HooksConfig> {
+  return wsClient.call('GetProjectHooks', projectPath, scope);
+}
+
+export function SaveProjectHooks(projectPath: string, hooks: claude.HooksConfig, scope: 'project' | 'local' | '' = ''): Promise<void> {
+  return wsClient.call('SaveProjectHooks', projectPath, hooks, scope);
+}
+
+export function ListHookExecutions(filter: database.HookExecutionFilter = {}): Promise<database.HookExecution[]> {
+  return wsClient.call('ListHookExecutions', filter);
+}
+
+export function ClearHookExecutions(): Promise<void> {
+  return wsClient.call('ClearHookExecutions');
+}
+
+export function DeleteHookMatcher(scope: 'user' | 'project' | 'local', projectPath: string, hookType: string, matcher: string): Promise<void> {
+  return wsClient.call('DeleteHookMatcher', scope, projectPath, hookType, matcher);
+}
+
+/** Loads the hooks of a scope; project and local scopes need projectPath */
+export function GetHooksConfig(scope: 'user' | 'project' | 'local', projectPath?: string): Promise<claude.HooksConfig> {
+  return scope === 'user' ? GetHooks() : GetProjectHooks(projectPath || '', scope);
+}
+
+/** Saves the hooks of a scope; project and local scopes need projectPath */
+export function UpdateHooksConfig(scope: 'user' | 'project' | 'local', hooks: claude.HooksConfig, projectPath?: string): Promise<void> {
+  return scope === 'user' ? SaveHooks(hooks) : SaveProjectHooks(projectPath || '', hooks, scope);
+}
+
+export function GetHookTemplates(): Promise<claude.HookTemplate[]> {
+  return wsClient.call('GetHookTemplates');
+}
+
+export function InsertHookTemplate(scope: 'user' | 'project' | 'local', projectPath: string, templateID: string): Promise<claude.HooksConfig> {
+  return wsClient.call('InsertHookTemplate', scope, projectPath, templateID);
+}
+
+export function SampleHookPayload(event: string, toolName: string, cwd: string): Promise<string> {
+  return wsClient.call('SampleHookPayload', event, toolName, cwd);
+}
+
+export function RunHookTest(hook: claude.Hook, samplePayload: string): Promise<claude.HookTestResult> {
+  return wsClient.call('RunHookTest', hook, samplePayload);
+}
+
 export function ValidateHookCommand(command: string): Promise<main.HookValidationResult> {
   return wsClient.call('ValidateHookCommand', command);
 }
@@ -1404,12 +3361,24 @@ export function GetSlashCommand(projectPath: string, name: string): Promise<clau
   return wsClient.call('GetSlashCommand', projectPath, name);
 }
 
-export function SaveSlashCommand(projectPath: string, name: string, command: string, description: string): Promise<void> {
-  return wsClient.call('SaveSlashCommand', projectPath, name, command, description);
+export function SaveSlashCommand(name: string, content: string, scope: string, projectPath: string, commandType: 'claude' | 'codex' | '' = ''): Promise<void> {
+  return wsClient.call('SaveSlashCommand', name, content, scope, projectPath, commandType);
+}
+
+export function SaveSlashCommand2(input: claude.SlashCommandInput): Promise<claude.SlashCommand> {
+  return wsClient.call('SaveSlashCommand2', input);
+}
+
+export function DeleteSlashCommand(name: string, scope: string, projectPath: string, commandType: 'claude' | 'codex' | '' = ''): Promise<void> {
+  return wsClient.call('DeleteSlashCommand', name, scope, projectPath, commandType);
+}
+
+export function DeleteSlashCommandFile(filePath: string, projectPath: string): Promise<void> {
+  return wsClient.call('DeleteSlashCommandFile', filePath, projectPath);
 }
 
-export function DeleteSlashCommand(projectPath: string, name: string, command: string): Promise<void> {
-  return wsClient.call('DeleteSlashCommand', projectPath, name, command);
+export function ExpandSlashCommand(name: string, args: string, projectPath: string, allowUnsandboxed: boolean): Promise<slashexpand.Expansion> {
+  return wsClient.call('ExpandSlashCommand', name, args, projectPath, allowUnsandboxed);
 }
 
 // ==================== SSH 管理 ====================
@@ -1426,10 +3395,78 @@ export function DeleteGlobalSshConnection(name: string): Promise<void> {
   return wsClient.call('DeleteGlobalSshConnection', name);
 }
 
+export function UpdateGlobalSshConnection(name: string, conn: ssh.SshConnection): Promise<void> {
+  return wsClient.call('UpdateGlobalSshConnection', name, conn);
+}
+
 export function TestSshConnection(conn: ssh.SshConnection): Promise<void> {
   return wsClient.call('TestSshConnection', conn);
 }
 
+export function SetSshConnectionPassword(name: string, password: string): Promise<void> {
+  return wsClient.call('SetSshConnectionPassword', name, password);
+}
+
+export function GetSshAgentStatus(): Promise<ssh.AgentStatus> {
+  return wsClient.call('GetSshAgentStatus');
+}
+
+export function GetHostKeyFingerprint(host: string, port: number): Promise<ssh.HostKeyInfo> {
+  return wsClient.call('GetHostKeyFingerprint', host, port);
+}
+
+export function AcceptHostKey(host: string, port: number, fingerprint: string): Promise<void> {
+  return wsClient.call('AcceptHostKey', host, port, fingerprint);
+}
+
+export function RejectHostKey(host: string, port: number, fingerprint: string): Promise<void> {
+  return wsClient.call('RejectHostKey', host, port, fingerprint);
+}
+
+export function ForgetHostKey(host: string, port: number): Promise<void> {
+  return wsClient.call('ForgetHostKey', host, port);
+}
+
+export function ListKnownHosts(): Promise<ssh.KnownHost[]> {
+  return wsClient.call('ListKnownHosts');
+}
+
+export function GetSyncProgress(localPath: string): Promise<ssh.SyncProgress> {
+  return wsClient.call('GetSyncProgress', localPath);
+}
+
+export function ListSshSyncRules(): Promise<ssh.SyncRules[]> {
+  return wsClient.call('ListSshSyncRules');
+}
+
+export function GetSshSyncRules(localPath: string, remotePath: string, connectionName: string): Promise<ssh.SyncRules> {
+  return wsClient.call('GetSshSyncRules', localPath, remotePath, connectionName);
+}
+
+export function SetSshSyncRules(rules: ssh.SyncRules): Promise<void> {
+  return wsClient.call('SetSshSyncRules', rules);
+}
+
+export function ResetSshSyncRules(localPath: string, remotePath: string, connectionName: string): Promise<void> {
+  return wsClient.call('ResetSshSyncRules', localPath, remotePath, connectionName);
+}
+
+export function StartTwoWaySync(localPath: string, remotePath: string, connectionName: string): Promise<void> {
+  return wsClient.call('StartTwoWaySync', localPath, remotePath, connectionName);
+}
+
+export function SyncTwoWay(localPath: string, remotePath: string, connectionName: string): Promise<void> {
+  return wsClient.call('SyncTwoWay', localPath, remotePath, connectionName);
+}
+
+export function ListSshSyncConflicts(localPath: string): Promise<ssh.SyncConflict[]> {
+  return wsClient.call('ListSshSyncConflicts', localPath);
+}
+
+export function ResolveSshSyncConflict(localPath: string, filePath: string, resolution: ssh.ConflictResolution): Promise<void> {
+  return wsClient.call('ResolveSshSyncConflict', localPath, filePath, resolution);
+}
+
 export function SyncFromSSH(projectPath: string, sshConnectionName: string, branch: string): Promise<void> {
   return wsClient.call('SyncFromSSH', projectPath, sshConnectionName, branch);
 }
@@ -1462,6 +3499,50 @@ export function GetAutoSyncStatus(projectPath: string): Promise<ssh.AutoSyncStat
   return wsClient.call('GetAutoSyncStatus', projectPath);
 }
 
+export function ListRemoteDirectory(connectionName: string, path: string): Promise<ssh.RemoteEntry[]> {
+  return wsClient.call('ListRemoteDirectory', connectionName, path);
+}
+
+export function ReadRemoteFile(connectionName: string, path: string): Promise<string> {
+  return wsClient.call('ReadRemoteFile', connectionName, path);
+}
+
+export function WriteRemoteFile(connectionName: string, path: string, content: string): Promise<void> {
+  return wsClient.call('WriteRemoteFile', connectionName, path, content);
+}
+
+export function ListRemoteProjects(): Promise<Record<string, main.RemoteProject>> {
+  return wsClient.call('ListRemoteProjects');
+}
+
+export function GetRemoteProject(projectPath: string): Promise<main.RemoteProject | null> {
+  return wsClient.call('GetRemoteProject', projectPath);
+}
+
+export function SetRemoteProject(projectPath: string, remote: main.RemoteProject): Promise<void> {
+  return wsClient.call('SetRemoteProject', projectPath, remote);
+}
+
+export function ClearRemoteProject(projectPath: string): Promise<void> {
+  return wsClient.call('ClearRemoteProject', projectPath);
+}
+
+export function ListRemoteServers(): Promise<Record<string, main.RemoteServer>> {
+  return wsClient.call('ListRemoteServers');
+}
+
+export function GetRemoteServer(projectPath: string): Promise<main.RemoteServer | null> {
+  return wsClient.call('GetRemoteServer', projectPath);
+}
+
+export function SetRemoteServer(projectPath: string, server: main.RemoteServer): Promise<void> {
+  return wsClient.call('SetRemoteServer', projectPath, server);
+}
+
+export function ClearRemoteServer(projectPath: string): Promise<void> {
+  return wsClient.call('ClearRemoteServer', projectPath);
+}
+
 // ==================== Plugin ====================
 
 export function ListInstalledPlugins(): Promise<plugin.Plugin[]> {
@@ -1504,6 +3585,19 @@ export function ListPluginHooks(pluginName: string): Promise<plugin.PluginHook[]
   return wsClient.call('ListPluginHooks', pluginName);
 }
 
+/** Installs and enables "name@marketplace[#ref]"; progress arrives as plugin:progress */
+export function InstallPlugin(source: string): Promise<plugin.Plugin> {
+  return wsClient.call('InstallPlugin', source);
+}
+
+export function UpdatePlugin(id: string): Promise<plugin.Plugin> {
+  return wsClient.call('UpdatePlugin', id);
+}
+
+export function UninstallPlugin(id: string): Promise<void> {
+  return wsClient.call('UninstallPlugin', id);
+}
+
 // ==================== Usage ====================
 
 export function GetUsageStats(): Promise<main.UsageStats> {
@@ -1518,6 +3612,99 @@ export function GetUsageDetails(id: number): Promise<any[]> {
   return wsClient.call('GetUsageDetails', id);
 }
 
+export function ExportUsageReport(format: 'csv' | 'json', startDate: string, endDate: string, groupBy: 'day' | 'month' | 'model' | 'project' | 'workspace' | 'branch'): Promise<string> {
+  return wsClient.call('ExportUsageReport', format, startDate, endDate, groupBy);
+}
+
+export function GetMonthlyUsageSummary(month: string): Promise<usage.MonthlySummary> {
+  return wsClient.call('GetMonthlyUsageSummary', month);
+}
+
+export function ExportMonthlyUsageReport(month: string, format: 'csv' | 'json'): Promise<string> {
+  return wsClient.call('ExportMonthlyUsageReport', month, format);
+}
+
+export function GetUsageTimeline(bucket: 'hour' | 'day' = 'hour', timeRange: string = ''): Promise<usage.Timeline> {
+  return wsClient.call('GetUsageTimeline', bucket, timeRange);
+}
+
+export function GetUsageQuota(): Promise<main.UsageQuota> {
+  return wsClient.call('GetUsageQuota');
+}
+
+export function SetUsageQuota(quota: main.UsageQuota): Promise<void> {
+  return wsClient.call('SetUsageQuota', quota);
+}
+
+export function GetUsageQuotaStatus(): Promise<main.UsageQuotaPeriod[]> {
+  return wsClient.call('GetUsageQuotaStatus');
+}
+
+export function GetNotificationRules(): Promise<main.NotificationRules> {
+  return wsClient.call('GetNotificationRules');
+}
+
+export function SetNotificationRules(rules: main.NotificationRules): Promise<void> {
+  return wsClient.call('SetNotificationRules', rules);
+}
+
+/** Rejects when the host cannot show desktop notifications */
+export function GetAttentionSettings(): Promise<main.AttentionSettings> {
+  return wsClient.call('GetAttentionSettings');
+}
+
+export function SetAttentionSettings(settings: main.AttentionSettings): Promise<void> {
+  return wsClient.call('SetAttentionSettings', settings);
+}
+
+export function SetAttentionCount(count: number): Promise<void> {
+  return wsClient.call('SetAttentionCount', count);
+}
+
+export function GetAttentionCount(): Promise<number> {
+  return wsClient.call('GetAttentionCount');
+}
+
+export function PreviewAttentionSound(sound: string): Promise<void> {
+  return wsClient.call('PreviewAttentionSound', sound);
+}
+
+export function NotifySessionComplete(provider: string, sessionId: string, projectPath: string, success: boolean): Promise<void> {
+  return wsClient.call('NotifySessionComplete', provider, sessionId, projectPath, success);
+}
+
+export function ListModelPricing(): Promise<database.ModelPricing[]> {
+  return wsClient.call('ListModelPricing');
+}
+
+export function SaveModelPricing(price: database.ModelPricing): Promise<database.ModelPricing> {
+  return wsClient.call('SaveModelPricing', price);
+}
+
+export function DeleteModelPricing(id: number): Promise<void> {
+  return wsClient.call('DeleteModelPricing', id);
+}
+
+export function GetModelPricingURL(): Promise<string> {
+  return wsClient.call('GetModelPricingURL');
+}
+
+export function RefreshModelPricing(url: string): Promise<number> {
+  return wsClient.call('RefreshModelPricing', url);
+}
+
+export function ListModelCapabilities(): Promise<database.ModelCapabilities[]> {
+  return wsClient.call('ListModelCapabilities');
+}
+
+export function GetModelCapabilitiesURL(): Promise<string> {
+  return wsClient.call('GetModelCapabilitiesURL');
+}
+
+export function RefreshModelCapabilities(url: string): Promise<number> {
+  return wsClient.call('RefreshModelCapabilities', url);
+}
+
 // ==================== Storage ====================
 
 export function StorageListTables(): Promise<string[]> {
@@ -1602,16 +3789,40 @@ export function SkillGet(projectPath: string, skillName: string): Promise<main.S
 
 // ==================== 命令执行 ====================
 
+/** @deprecated Blocks until the command exits, with no timeout; use RunJob */
 export function ExecuteCommand(command: string, cwd: string): Promise<main.CommandResult> {
   return wsClient.call('ExecuteCommand', command, cwd);
 }
 
-export function ExecuteCommandWithArgs(cwd: string, args: string[], env: string): Promise<string> {
-  return wsClient.call('ExecuteCommandWithArgs', cwd, args, env);
+/** Runs command in the background; its output follows as job-output events */
+export function RunJob(
+  command: string,
+  cwd: string,
+  timeoutSeconds: number,
+  env: Record<string, string>
+): Promise<string> {
+  return wsClient.call('RunJob', command, cwd, timeoutSeconds, env);
+}
+
+export function CancelJob(key: string): Promise<void> {
+  return wsClient.call('CancelJob', key);
+}
+
+export function ListJobs(): Promise<jobs.Job[]> {
+  return wsClient.call('ListJobs');
+}
+
+export function GetJob(key: string): Promise<jobs.Job> {
+  return wsClient.call('GetJob', key);
+}
+
+export function ExecuteCommandWithArgs(command: string, args: string[], cwd: string): Promise<string> {
+  return wsClient.call('ExecuteCommandWithArgs', command, args, cwd);
 }
 
-export function ExecuteCommandAsync(cwd: string, args: string[], env: string): Promise<string> {
-  return wsClient.call('ExecuteCommandAsync', cwd, args, env);
+/** Starts command as a job and returns its key, unique to this run */
+export function ExecuteCommandAsync(command: string, args: string[], cwd: string): Promise<string> {
+  return wsClient.call('ExecuteCommandAsync', command, args, cwd);
 }
 
 // ==================== 其他工具 ====================
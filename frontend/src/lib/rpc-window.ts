@@ -117,4 +117,9 @@ export function Quit(): void {
   window.electronAPI?.quit?.();
 }
 
+/** Shows count on the dock icon / taskbar button; 0 clears it */
+export function WindowSetBadgeCount(count: number): void {
+  window.electronAPI?.setBadgeCount?.(count);
+}
+
 // electronAPI 类型已在 vite-env.d.ts 中声明
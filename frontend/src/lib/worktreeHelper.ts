@@ -46,7 +46,7 @@ Your work should take place in the ${worktreeInfo.current_path}, which has been
 
 Do NOT read or write files outside the workspace directory. DO NOT EVER read or write files at ${worktreeInfo.root_path}. EVERY absolute path you use should start with ${worktreeInfo.current_path}.
 
-Exception: you may read pasted/dragged images stored under ~/.ropcode/temp-images/ (read-only).
+Exception: you may read pasted/dragged images stored under ~/.ropcode/temp-images/ and files attached to the prompt under ~/.ropcode/attachments/ (read-only).
 
 The user has indicated their remote target for this repository is branch ${worktreeInfo.main_branch}. Use this for actions like creating new PRs, bisecting, etc., unless explicitly told to use another branch by the user.
 
@@ -19,10 +19,19 @@ export interface WSEvent {
 }
 
 export interface WSMessage {
-  kind: 'rpc_request' | 'rpc_response' | 'event';
+  kind: 'rpc_request' | 'rpc_response' | 'event' | 'subscribe' | 'unsubscribe' | 'subscriptions' | 'resume' | 'resumed';
   request?: RPCRequest;
   response?: RPCResponse;
   event?: WSEvent;
+  /** session:<id>, project:<path>, pty:<id>, event:<type> or * */
+  topics?: string[];
+  error?: string;
+  /** Event sequence number; on resume/resumed the last seen/current one */
+  seq?: number;
+  /** Server instance the sequence numbers belong to */
+  epoch?: string;
+  /** Some missed events could not be replayed */
+  gap?: boolean;
 }
 
 type EventHandler = (payload: any) => void;
@@ -62,6 +71,30 @@ function getRpcTimeout(method: string): number {
   if (method === 'StartInteractiveClaudeSession') {
     return 45000;
   }
+  if (method === 'ListMarketplaceAgents') {
+    return 120000; // may sync every configured agent source
+  }
+  if (method === 'SearchMcpRegistry' || method === 'InstallMcpServerFromRegistry') {
+    return 90000; // registry requests time out after 60s on the backend
+  }
+  if (method === 'McpTestConnection') {
+    return 45000; // remote servers are tested with a 30s handshake
+  }
+  if (method === 'McpListTools' || method === 'McpCallTool') {
+    return 90000; // may start the server for the request
+  }
+  if (method === 'PullSettingsSync' || method === 'PushSettingsSync') {
+    return 120000; // clones, fetches and pushes the settings sync repository
+  }
+  if (method === 'SyncFromSSH' || method === 'SyncToSSH' || method === 'SyncTwoWay') {
+    return 60 * 60 * 1000; // large trees; progress arrives as ssh-sync:progress events
+  }
+  if (method === 'ReadRemoteFile' || method === 'WriteRemoteFile') {
+    return 120000; // up to 10 MB over an SFTP session
+  }
+  if (method === 'StartProviderSession' || method === 'ResumeProviderSession') {
+    return 30 * 60 * 1000; // may wait in the session concurrency queue
+  }
   return 30000;
 }
 
@@ -81,6 +114,11 @@ class WSRpcClient {
   private reconnectTimer: ReturnType<typeof setTimeout> | null = null;
   // Guard against concurrent doConnect() calls
   private connecting = false;
+  // Event topics; empty means every event is delivered
+  private topics: Set<string> = new Set();
+  // Last event seen, so a reconnect can replay what was missed
+  private lastSeq = 0;
+  private epoch = '';
 
   /**
    * 初始化连接
@@ -245,6 +283,12 @@ class WSRpcClient {
           // 通知所有等待连接的 resolvers
           this.connectResolvers.forEach(r => r.resolve());
           this.connectResolvers = [];
+          if (this.topics.size > 0) {
+            this.sendSubscription('subscribe', [...this.topics]);
+          }
+          // Ask for the session events missed while disconnected
+          const resume: WSMessage = { kind: 'resume', seq: this.lastSeq, epoch: this.epoch };
+          ws.send(JSON.stringify(resume));
           // Fire onConnect callbacks (e.g. to reload data after reconnect)
           this.onConnectCallbacks.forEach(cb => { try { cb(); } catch (e) { console.error('[WSRpc] onConnect callback error:', e); } });
           resolve();
@@ -355,6 +399,11 @@ class WSRpcClient {
           }
         }
       } else if (msg.kind === 'event' && msg.event) {
+        if (msg.seq) {
+          // Already delivered before a reconnect
+          if (msg.seq <= this.lastSeq) return;
+          this.lastSeq = msg.seq;
+        }
         const { type, payload } = msg.event;
         const listeners = this.eventListeners.get(type);
         if (listeners) {
@@ -366,6 +415,14 @@ class WSRpcClient {
             }
           });
         }
+      } else if (msg.kind === 'subscriptions' && msg.error) {
+        console.warn('[WSRpc] Subscription refused:', msg.error);
+      } else if (msg.kind === 'resumed') {
+        if (msg.gap) {
+          console.warn('[WSRpc] Some events were missed while disconnected');
+        }
+        this.epoch = msg.epoch ?? '';
+        this.lastSeq = msg.seq ?? 0;
       }
     } catch (e) {
       console.error('[WSRpc] Failed to parse message:', e);
@@ -442,6 +499,27 @@ class WSRpcClient {
     };
   }
 
+  /**
+   * Limits pushed events to the given topics (plus app-wide events). Kept
+   * across reconnects. Without any subscription every event is delivered.
+   */
+  subscribe(topics: string[]) {
+    topics.forEach(topic => this.topics.add(topic));
+    this.sendSubscription('subscribe', topics);
+  }
+
+  unsubscribe(topics: string[]) {
+    topics.forEach(topic => this.topics.delete(topic));
+    this.sendSubscription('unsubscribe', topics);
+  }
+
+  private sendSubscription(kind: 'subscribe' | 'unsubscribe', topics: string[]) {
+    if (this.ws && this.ws.readyState === WebSocket.OPEN && topics.length > 0) {
+      const msg: WSMessage = { kind, topics };
+      this.ws.send(JSON.stringify(msg));
+    }
+  }
+
   /**
    * 移除事件监听
    */
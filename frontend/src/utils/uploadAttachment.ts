@@ -1,8 +1,19 @@
 const MAX_FILE_SIZE = 50 * 1024 * 1024; // 50MB
 
+// File types the server accepts as attachments (see internal/attachments)
+export const ATTACHMENT_ACCEPT = [
+  'image/*', '.pdf',
+  '.txt', '.log', '.md', '.markdown', '.csv', '.tsv', '.json', '.jsonl', '.yaml', '.yml', '.toml', '.xml',
+  '.html', '.css', '.js', '.jsx', '.ts', '.tsx', '.go', '.py', '.rs', '.java', '.c', '.h', '.cpp', '.rb', '.sh',
+  '.sql', '.diff', '.patch',
+  '.zip', '.tar', '.gz', '.tgz', '.bz2', '.xz', '.7z',
+].join(',');
+
 export interface UploadResult {
   filePath: string;
   filename: string;
+  kind: 'image' | 'pdf' | 'text' | 'archive';
+  mimeType: string;
 }
 
 export class UploadError extends Error {
@@ -16,10 +27,12 @@ export class UploadError extends Error {
  * Uploads a file to the Go server via HTTP multipart/form-data.
  * The user always accesses the app through the Go server port,
  * so window.location.port is always the correct server port.
+ * Files are stored per session; pass the session ID once there is one.
  */
 export async function uploadAttachment(
   file: File,
   projectPath?: string,
+  sessionId?: string,
 ): Promise<UploadResult> {
   // Validate file size
   if (file.size > MAX_FILE_SIZE) {
@@ -31,6 +44,9 @@ export async function uploadAttachment(
   if (projectPath) {
     formData.append('projectPath', projectPath);
   }
+  if (sessionId) {
+    formData.append('sessionId', sessionId);
+  }
 
   const controller = new AbortController();
   const timeoutId = setTimeout(() => controller.abort(), 30_000);
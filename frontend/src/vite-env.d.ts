@@ -31,6 +31,7 @@ interface Window {
     setMaxSize: (width: number, height: number) => Promise<void>;
     setAlwaysOnTop: (flag: boolean) => Promise<void>;
     quit: () => Promise<void>;
+    setBadgeCount?: (count: number) => Promise<void>;
     // 文件对话框
     openDirectory: () => Promise<{ canceled: boolean; filePaths?: string[] }>;
     openFile: (options?: { multiple?: boolean }) => Promise<{ canceled: boolean; filePaths?: string[] }>;
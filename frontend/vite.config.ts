@@ -78,6 +78,20 @@ export default defineConfig({
                   '.svg': 'image/svg+xml',
                   '.ico': 'image/x-icon',
                   '.bmp': 'image/bmp',
+                  '.pdf': 'application/pdf',
+                  '.txt': 'text/plain; charset=utf-8',
+                  '.log': 'text/plain; charset=utf-8',
+                  '.md': 'text/markdown; charset=utf-8',
+                  '.csv': 'text/csv; charset=utf-8',
+                  '.json': 'application/json',
+                  '.yaml': 'application/yaml',
+                  '.yml': 'application/yaml',
+                  '.xml': 'application/xml',
+                  '.zip': 'application/zip',
+                  '.tar': 'application/x-tar',
+                  '.gz': 'application/gzip',
+                  '.tgz': 'application/gzip',
+                  '.7z': 'application/x-7z-compressed',
                 };
 
                 const contentType = mimeTypes[ext] || 'application/octet-stream';
@@ -0,0 +1,117 @@
+// git_status.go
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"ropcode/internal/eventhub"
+)
+
+// gitStatusDebounce is how long a burst of git or file changes is waited
+// out before the status is recomputed
+const gitStatusDebounce = 300 * time.Millisecond
+
+// GitStatusEvent is the payload of "git:status"
+type GitStatusEvent struct {
+	Path   string         `json:"path"`
+	Status *GitRepoStatus `json:"status,omitempty"`
+	// UnpushedCount is the number of commits of a worktree child not yet
+	// in the main worktree's branch
+	UnpushedCount int `json:"unpushed_count"`
+	// UnpushedToRemoteCount is the number of commits not on origin
+	UnpushedToRemoteCount int    `json:"unpushed_to_remote_count"`
+	Error                 string `json:"error,omitempty"`
+}
+
+// WatchGitStatus starts emitting "git:status" events for a local project.
+// Every call needs a matching UnwatchGitStatus.
+func (a *App) WatchGitStatus(path string) error {
+	if a.gitStatusWatcher == nil {
+		return a.unavailable(subsystemGitWatcher)
+	}
+	if target, err := a.remoteServerFor(path); err != nil || target != nil {
+		return fmt.Errorf("live git status is not available for remote projects")
+	}
+	if err := a.gitStatusWatcher.Watch(path); err != nil {
+		return err
+	}
+	// Edits to the working tree change the status too
+	if a.fsWatcher != nil {
+		if err := a.fsWatcher.Watch(path); err != nil {
+			a.gitStatusWatcher.Unwatch(path)
+			return err
+		}
+	}
+	return nil
+}
+
+// UnwatchGitStatus releases one WatchGitStatus
+func (a *App) UnwatchGitStatus(path string) {
+	if a.gitStatusWatcher == nil || !a.gitStatusWatcher.Watching(path) {
+		return
+	}
+	a.gitStatusWatcher.Unwatch(path)
+	if a.fsWatcher != nil {
+		a.fsWatcher.Unwatch(path)
+	}
+	if !a.gitStatusWatcher.Watching(path) {
+		a.mu.Lock()
+		delete(a.gitStatusCache, filepath.Clean(path))
+		a.mu.Unlock()
+	}
+}
+
+// refreshGitStatus recomputes the status of a watched project, caches it
+// and emits it
+func (a *App) refreshGitStatus(path string) {
+	event := &GitStatusEvent{Path: path}
+	if status, err := gitRepoStatus(path); err != nil {
+		event.Error = err.Error()
+	} else {
+		event.Status = status
+	}
+	event.UnpushedCount, _ = a.unpushedCommitsCount(path)
+	event.UnpushedToRemoteCount, _ = unpushedToRemoteCount(path)
+
+	if a.gitStatusWatcher == nil || !a.gitStatusWatcher.Watching(path) {
+		return
+	}
+	a.mu.Lock()
+	if a.gitStatusCache == nil {
+		a.gitStatusCache = make(map[string]*GitStatusEvent)
+	}
+	a.gitStatusCache[filepath.Clean(path)] = event
+	a.mu.Unlock()
+
+	if a.eventHub != nil {
+		a.eventHub.Emit("git:status", event)
+	}
+}
+
+// cachedGitStatus returns the last status computed for a watched project,
+// or nil when the project isn't watched
+func (a *App) cachedGitStatus(path string) *GitStatusEvent {
+	if a.gitStatusWatcher == nil || path == "" || !a.gitStatusWatcher.Watching(path) {
+		return nil
+	}
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.gitStatusCache[filepath.Clean(path)]
+}
+
+// fsChangeEmitter forwards project file changes to the frontend and lets
+// the git status of the project catch up with edits to its working tree
+type fsChangeEmitter struct {
+	app *App
+}
+
+func (e *fsChangeEmitter) EmitFsChanged(event eventhub.FsChangedEvent) {
+	if e.app.eventHub != nil {
+		e.app.eventHub.EmitFsChanged(event)
+	}
+	if e.app.gitStatusWatcher != nil {
+		e.app.gitStatusWatcher.Invalidate(event.Root)
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"ropcode/internal/eventhub"
+	"ropcode/internal/git"
+)
+
+type gitStatusRecorder struct {
+	mu     sync.Mutex
+	events []*GitStatusEvent
+}
+
+func (r *gitStatusRecorder) BroadcastEvent(eventType string, payload interface{}) {
+	if eventType != "git:status" {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, payload.(*GitStatusEvent))
+}
+
+// waitFor returns the first event after the first skip that satisfies ok
+func (r *gitStatusRecorder) waitFor(t *testing.T, skip int, ok func(*GitStatusEvent) bool) *GitStatusEvent {
+	t.Helper()
+	deadline := time.Now().Add(3 * time.Second)
+	for time.Now().Before(deadline) {
+		r.mu.Lock()
+		for _, event := range r.events[min(skip, len(r.events)):] {
+			if ok(event) {
+				r.mu.Unlock()
+				return event
+			}
+		}
+		r.mu.Unlock()
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatal("no matching git:status event")
+	return nil
+}
+
+func (r *gitStatusRecorder) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.events)
+}
+
+func runGit(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v: %v: %s", args, err, out)
+	}
+}
+
+func TestWatchGitStatusEmitsAndCaches(t *testing.T) {
+	repo := t.TempDir()
+	runGit(t, repo, "init", "-b", "main")
+	runGit(t, repo, "config", "user.name", "Test User")
+	runGit(t, repo, "config", "user.email", "test@example.com")
+	os.WriteFile(filepath.Join(repo, "README.md"), []byte("hello"), 0644)
+	runGit(t, repo, "add", ".")
+	runGit(t, repo, "commit", "-m", "init")
+
+	hub := eventhub.New(nil)
+	recorder := &gitStatusRecorder{}
+	hub.SetBroadcaster(recorder)
+	app := &App{eventHub: hub}
+	app.gitStatusWatcher = git.NewStatusWatcher(20*time.Millisecond, app.refreshGitStatus)
+	defer app.gitStatusWatcher.Close()
+
+	if err := app.WatchGitStatus(repo); err != nil {
+		t.Fatalf("WatchGitStatus() error = %v", err)
+	}
+	first := recorder.waitFor(t, 0, func(e *GitStatusEvent) bool { return e.Status != nil })
+	if first.Status.Branch != "main" || !first.Status.IsClean || first.UnpushedToRemoteCount != 1 {
+		t.Fatalf("initial status = %+v, %+v", first, first.Status)
+	}
+
+	// An edit reported by the file watcher refreshes the cached status
+	skip := recorder.count()
+	os.WriteFile(filepath.Join(repo, "notes.txt"), []byte("todo"), 0644)
+	(&fsChangeEmitter{app: app}).EmitFsChanged(eventhub.FsChangedEvent{Root: repo})
+	recorder.waitFor(t, skip, func(e *GitStatusEvent) bool { return e.Status != nil && len(e.Status.Untracked) == 1 })
+
+	status, err := app.GetGitStatus(repo)
+	if err != nil || len(status.Untracked) != 1 {
+		t.Fatalf("GetGitStatus() = %+v, %v, want the cached status", status, err)
+	}
+
+	// A commit is picked up from the git directory
+	skip = recorder.count()
+	runGit(t, repo, "add", ".")
+	runGit(t, repo, "commit", "-m", "notes")
+	recorder.waitFor(t, skip, func(e *GitStatusEvent) bool { return e.UnpushedToRemoteCount == 2 && e.Status.IsClean })
+	if count, _ := app.GetUnpushedToRemoteCount(repo); count != 2 {
+		t.Errorf("GetUnpushedToRemoteCount() = %d, want 2", count)
+	}
+
+	app.UnwatchGitStatus(repo)
+	if app.cachedGitStatus(repo) != nil || len(app.gitStatusCache) != 0 {
+		t.Error("UnwatchGitStatus() kept the cached status")
+	}
+}
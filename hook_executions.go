@@ -0,0 +1,56 @@
+// hook_executions.go
+package main
+
+import (
+	"ropcode/internal/claude"
+	"ropcode/internal/database"
+	"ropcode/internal/hookaudit"
+)
+
+// claudeObservers passes Claude session events to several observers
+type claudeObservers []claude.ActivityObserver
+
+func (o claudeObservers) ObserveClaudeEvent(sessionID string, event map[string]interface{}) {
+	for _, observer := range o {
+		observer.ObserveClaudeEvent(sessionID, event)
+	}
+}
+
+func (o claudeObservers) CompleteSession(sessionID string) {
+	for _, observer := range o {
+		observer.CompleteSession(sessionID)
+	}
+}
+
+func (o claudeObservers) HandleControlResponse(sessionID string, response map[string]interface{}) {
+	for _, observer := range o {
+		observer.HandleControlResponse(sessionID, response)
+	}
+}
+
+// newHookRecorder records hook runs of Claude sessions in the database
+func (a *App) newHookRecorder() *hookaudit.Recorder {
+	return hookaudit.NewRecorder(a.dbManager, a.config.ClaudeDir, func(sessionID string) string {
+		if status := a.claudeManager.GetSession(sessionID); status != nil {
+			return status.ProjectPath
+		}
+		return ""
+	})
+}
+
+// ListHookExecutions returns recorded hook runs matching filter, newest
+// first
+func (a *App) ListHookExecutions(filter database.HookExecutionFilter) ([]*database.HookExecution, error) {
+	if a.dbManager == nil {
+		return []*database.HookExecution{}, a.unavailable(subsystemDatabase)
+	}
+	return a.dbManager.ListHookExecutions(filter)
+}
+
+// ClearHookExecutions deletes the hook execution log
+func (a *App) ClearHookExecutions() error {
+	if a.dbManager == nil {
+		return a.unavailable(subsystemDatabase)
+	}
+	return a.dbManager.ClearHookExecutions()
+}
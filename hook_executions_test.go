@@ -0,0 +1,39 @@
+package main
+
+import (
+	"testing"
+
+	"ropcode/internal/database"
+	"ropcode/internal/hookaudit"
+)
+
+func TestListHookExecutionsRecordsObservedHooks(t *testing.T) {
+	app := &App{dbManager: openAppConfigTestDB(t)}
+	recorder := hookaudit.NewRecorder(app.dbManager, t.TempDir(), nil)
+	observers := claudeObservers{recorder}
+
+	observers.ObserveClaudeEvent("s1", map[string]interface{}{
+		"type": "system", "subtype": "hook_response", "hook_id": "h1",
+		"hook_name": "PreToolUse:Bash", "exit_code": float64(2), "outcome": "error",
+	})
+	observers.ObserveClaudeEvent("s2", map[string]interface{}{
+		"type": "system", "subtype": "hook_response", "hook_id": "h2",
+		"hook_name": "Stop", "exit_code": float64(0), "outcome": "success",
+	})
+
+	all, err := app.ListHookExecutions(database.HookExecutionFilter{})
+	if err != nil || len(all) != 2 {
+		t.Fatalf("ListHookExecutions() = %d runs, %v", len(all), err)
+	}
+	failed, err := app.ListHookExecutions(database.HookExecutionFilter{FailedOnly: true})
+	if err != nil || len(failed) != 1 || failed[0].Matcher != "Bash" || failed[0].SessionID != "s1" {
+		t.Fatalf("failed runs = %+v, %v", failed, err)
+	}
+
+	if err := app.ClearHookExecutions(); err != nil {
+		t.Fatal(err)
+	}
+	if all, _ := app.ListHookExecutions(database.HookExecutionFilter{}); len(all) != 0 {
+		t.Errorf("runs after clear = %d", len(all))
+	}
+}
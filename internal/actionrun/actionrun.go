@@ -0,0 +1,246 @@
+// Package actionrun runs script actions. A run is a job (see package jobs)
+// executing the action's command in the platform shell; its output is
+// streamed as action events and a history of runs is kept with the end of
+// their output. Commands and environment values may use {{projectPath}}
+// and {{branch}} placeholders.
+package actionrun
+
+import (
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"ropcode/internal/database"
+	"ropcode/internal/git"
+	"ropcode/internal/jobs"
+	"ropcode/internal/process"
+)
+
+// OutputEvent is emitted for each chunk of run output and once when the
+// run ends
+const OutputEvent = "action-output"
+
+// MaxOutput caps the output kept in a run's history
+const MaxOutput = 16 << 10
+
+// Output is the payload of OutputEvent
+type Output struct {
+	RunID      string `json:"run_id"`
+	ActionID   string `json:"action_id"`
+	OutputType string `json:"output_type"` // stdout, stderr or exit
+	Content    string `json:"content"`
+	ExitCode   *int   `json:"exit_code,omitempty"`
+	Status     string `json:"status,omitempty"` // with exit
+}
+
+// Spec describes the action to run
+type Spec struct {
+	ActionID   string
+	ActionName string
+	Scope      string
+	Cwd        string
+	Command    string
+	Env        map[string]string
+}
+
+// Store keeps the run history
+type Store interface {
+	AddActionRun(r *database.ActionRun) error
+	FinishActionRun(r *database.ActionRun) error
+}
+
+// Runner starts and stops action runs
+type Runner struct {
+	jobs    *jobs.Runner
+	store   Store
+	emitter jobs.Emitter
+
+	mu      sync.Mutex
+	running map[string]*run // by job key
+}
+
+// run is a run in progress
+type run struct {
+	record database.ActionRun
+	mu     sync.Mutex
+	output []byte
+	cut    bool
+}
+
+// New creates a runner; store may be nil to keep no history
+func New(procs *process.Manager, store Store, emitter jobs.Emitter) *Runner {
+	r := &Runner{
+		store:   store,
+		emitter: emitter,
+		running: make(map[string]*run),
+	}
+	r.jobs = jobs.New(procs, jobOutput{r})
+	return r
+}
+
+// Start runs spec and returns the run as recorded; its output follows
+// as OutputEvent events
+func (r *Runner) Start(spec Spec) (*database.ActionRun, error) {
+	if strings.TrimSpace(spec.Command) == "" {
+		return nil, fmt.Errorf("action %s has no command", spec.ActionID)
+	}
+	vars := Vars(spec.Cwd)
+	ru := &run{record: database.ActionRun{
+		ID:         uuid.New().String(),
+		ActionID:   spec.ActionID,
+		ActionName: spec.ActionName,
+		Scope:      spec.Scope,
+		Cwd:        spec.Cwd,
+		Command:    Expand(spec.Command, vars),
+		Status:     database.ActionRunRunning,
+		StartedAt:  time.Now(),
+	}}
+	env := make(map[string]string, len(spec.Env))
+	for name, value := range spec.Env {
+		env[name] = Expand(value, vars)
+	}
+
+	// Record the run before it starts so that it can't end unrecorded
+	started := ru.record
+	key := jobKey(started.ID)
+	r.mu.Lock()
+	r.running[key] = ru
+	r.mu.Unlock()
+	if r.store != nil {
+		if err := r.store.AddActionRun(&started); err != nil {
+			slog.Error("failed to record run", "component", "actionrun", "action", spec.ActionID, "error", err)
+		}
+	}
+
+	_, err := r.jobs.Start(jobs.Spec{
+		Command: started.Command,
+		Cwd:     spec.Cwd,
+		Env:     env,
+		Key:     key,
+		Done:    func(job jobs.Job) { r.finish(key, ru, *job.ExitCode, job.Status) },
+	})
+	if err != nil {
+		err = fmt.Errorf("failed to start action %s: %w", spec.ActionID, err)
+		ru.output = []byte(err.Error())
+		r.finish(key, ru, -1, jobs.StatusFailed)
+		return nil, err
+	}
+	return &started, nil
+}
+
+// Stop stops a run; it returns jobs.ErrNotRunning for runs that already
+// ended
+func (r *Runner) Stop(runID string) error {
+	return r.jobs.Cancel(jobKey(runID))
+}
+
+// Running returns the runs in progress
+func (r *Runner) Running() []*database.ActionRun {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	runs := make([]*database.ActionRun, 0, len(r.running))
+	for _, ru := range r.running {
+		ru.mu.Lock()
+		record := ru.record
+		ru.mu.Unlock()
+		runs = append(runs, &record)
+	}
+	return runs
+}
+
+// finish records the outcome of an ended run, given the job's status
+func (r *Runner) finish(key string, ru *run, exitCode int, status string) {
+	finishedAt := time.Now()
+
+	ru.mu.Lock()
+	record := &ru.record
+	record.ExitCode = &exitCode
+	record.FinishedAt = &finishedAt
+	record.Output = strings.ToValidUTF8(string(ru.output), "")
+	record.Truncated = ru.cut
+	switch status {
+	case jobs.StatusSucceeded:
+		record.Status = database.ActionRunSucceeded
+	case jobs.StatusCancelled, jobs.StatusTimedOut:
+		record.Status = database.ActionRunStopped
+	default:
+		record.Status = database.ActionRunFailed
+	}
+	ru.mu.Unlock()
+
+	r.mu.Lock()
+	delete(r.running, key)
+	r.mu.Unlock()
+	if r.store != nil {
+		if err := r.store.FinishActionRun(record); err != nil {
+			slog.Error("failed to record end of run", "component", "actionrun", "action", record.ActionID, "error", err)
+		}
+	}
+	r.emit(Output{RunID: record.ID, ActionID: record.ActionID, OutputType: "exit", ExitCode: &exitCode, Status: record.Status})
+}
+
+func (r *Runner) emit(output Output) {
+	if r.emitter != nil {
+		r.emitter.Emit(OutputEvent, output)
+	}
+}
+
+// jobOutput turns the output of run jobs into action events and keeps its
+// end for the history. The exit event is left to finish, which sends it
+// once the run is recorded.
+type jobOutput struct{ r *Runner }
+
+func (o jobOutput) Emit(_ string, payload interface{}) {
+	output, ok := payload.(jobs.Output)
+	if !ok || output.OutputType == "exit" {
+		return
+	}
+	o.r.mu.Lock()
+	ru := o.r.running[output.JobKey]
+	o.r.mu.Unlock()
+	if ru == nil {
+		return
+	}
+	ru.mu.Lock()
+	ru.output = append(ru.output, output.Content...)
+	if len(ru.output) > MaxOutput {
+		ru.output = ru.output[len(ru.output)-MaxOutput:]
+		ru.cut = true
+	}
+	ru.mu.Unlock()
+	o.r.emit(Output{RunID: ru.record.ID, ActionID: ru.record.ActionID, OutputType: output.OutputType, Content: output.Content})
+}
+
+// jobKey is the key of a run's job, which is also its process key
+func jobKey(runID string) string {
+	return "action-" + runID
+}
+
+// Vars returns the placeholder values of actions run in cwd
+func Vars(cwd string) map[string]string {
+	vars := map[string]string{"projectPath": cwd, "branch": ""}
+	cmd := git.Command("branch", "--show-current")
+	cmd.Dir = cwd
+	if out, err := cmd.Output(); err == nil {
+		vars["branch"] = strings.TrimSpace(string(out))
+	}
+	return vars
+}
+
+var placeholder = regexp.MustCompile(`\{\{\s*(\w+)\s*\}\}`)
+
+// Expand replaces the {{name}} placeholders of s with vars; unknown
+// placeholders are left as they are
+func Expand(s string, vars map[string]string) string {
+	return placeholder.ReplaceAllStringFunc(s, func(m string) string {
+		if value, ok := vars[placeholder.FindStringSubmatch(m)[1]]; ok {
+			return value
+		}
+		return m
+	})
+}
@@ -0,0 +1,145 @@
+package actionrun
+
+import (
+	"context"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"ropcode/internal/database"
+	"ropcode/internal/jobs"
+	"ropcode/internal/process"
+)
+
+type memoryStore struct {
+	mu   sync.Mutex
+	runs map[string]database.ActionRun
+}
+
+func (s *memoryStore) AddActionRun(r *database.ActionRun) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.runs[r.ID] = *r
+	return nil
+}
+
+func (s *memoryStore) FinishActionRun(r *database.ActionRun) error {
+	return s.AddActionRun(r)
+}
+
+// outputs receives a runner's events
+type outputs chan Output
+
+func (o outputs) Emit(_ string, payload interface{}) { o <- payload.(Output) }
+
+func newRunner(t *testing.T) (*Runner, *memoryStore, outputs) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("test commands use sh")
+	}
+	store := &memoryStore{runs: make(map[string]database.ActionRun)}
+	emitted := make(outputs, 64)
+	return New(process.NewManager(context.Background()), store, emitted), store, emitted
+}
+
+// waitExit returns a run's exit event and its output text by stream
+func waitExit(t *testing.T, emitted outputs) (Output, map[string]string) {
+	t.Helper()
+	text := make(map[string]string)
+	for {
+		select {
+		case output := <-emitted:
+			if output.OutputType == "exit" {
+				return output, text
+			}
+			text[output.OutputType] += output.Content
+		case <-time.After(10 * time.Second):
+			t.Fatal("action run did not end")
+			return Output{}, nil
+		}
+	}
+}
+
+func TestRunStreamsOutputAndRecordsRun(t *testing.T) {
+	r, store, emitted := newRunner(t)
+	cwd := t.TempDir()
+
+	run, err := r.Start(Spec{
+		ActionID: "build", ActionName: "Build", Scope: "project", Cwd: cwd,
+		Command: `echo "in {{projectPath}} as $GREETING"; echo oops >&2; exit 3`,
+		Env:     map[string]string{"GREETING": "hi from {{ projectPath }}"},
+	})
+	if err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	if run.Status != database.ActionRunRunning || !strings.Contains(run.Command, cwd) {
+		t.Errorf("started run = %+v", run)
+	}
+
+	exit, text := waitExit(t, emitted)
+	if exit.RunID != run.ID || *exit.ExitCode != 3 || exit.Status != database.ActionRunFailed {
+		t.Errorf("exit event = %+v", exit)
+	}
+	if got, want := text["stdout"], "in "+cwd+" as hi from "+cwd+"\n"; got != want {
+		t.Errorf("stdout = %q, want %q", got, want)
+	}
+	if got := text["stderr"]; got != "oops\n" {
+		t.Errorf("stderr = %q", got)
+	}
+
+	recorded := store.runs[run.ID]
+	if recorded.Status != database.ActionRunFailed || *recorded.ExitCode != 3 || recorded.FinishedAt == nil || !strings.Contains(recorded.Output, "oops") {
+		t.Errorf("recorded run = %+v", recorded)
+	}
+	if running := r.Running(); len(running) != 0 {
+		t.Errorf("runs still running: %+v", running)
+	}
+}
+
+func TestStopRun(t *testing.T) {
+	r, store, emitted := newRunner(t)
+
+	run, err := r.Start(Spec{ActionID: "serve", Cwd: t.TempDir(), Command: "exec sleep 30"})
+	if err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	if running := r.Running(); len(running) != 1 || running[0].ID != run.ID {
+		t.Errorf("Running() = %+v", running)
+	}
+	if err := r.Stop(run.ID); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+	if exit, _ := waitExit(t, emitted); exit.Status != database.ActionRunStopped {
+		t.Errorf("exit event = %+v", exit)
+	}
+	if store.runs[run.ID].Status != database.ActionRunStopped {
+		t.Errorf("recorded run = %+v", store.runs[run.ID])
+	}
+	if err := r.Stop(run.ID); err != jobs.ErrNotRunning {
+		t.Errorf("second Stop() error = %v", err)
+	}
+}
+
+func TestVarsAndExpand(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	repo := t.TempDir()
+	if out, err := exec.Command("git", "-C", repo, "init", "-q", "-b", "feature/x").CombinedOutput(); err != nil {
+		t.Fatalf("git init: %v\n%s", err, out)
+	}
+
+	vars := Vars(repo)
+	if vars["projectPath"] != repo || vars["branch"] != "feature/x" {
+		t.Errorf("Vars() = %v", vars)
+	}
+	if got := Expand("cd {{projectPath}} && deploy {{branch}} {{unknown}}", vars); got != "cd "+repo+" && deploy feature/x {{unknown}}" {
+		t.Errorf("Expand() = %q", got)
+	}
+	if vars := Vars(t.TempDir()); vars["branch"] != "" {
+		t.Errorf("branch outside a repository = %q", vars["branch"])
+	}
+}
@@ -0,0 +1,234 @@
+// Package attachments stores the files dropped or uploaded into a prompt:
+// images, PDFs, text files and archives. Each session gets its own directory
+// under ~/.ropcode/attachments, and every attachment is referenced from the
+// prompt in the form its provider CLI understands.
+package attachments
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Kinds of attachment
+const (
+	KindImage   = "image"
+	KindPDF     = "pdf"
+	KindText    = "text"
+	KindArchive = "archive"
+)
+
+const (
+	// MaxSize is the largest file accepted
+	MaxSize = 50 << 20
+	// maxNameLength limits stored file names
+	maxNameLength = 200
+	// defaultName replaces a name with nothing usable left
+	defaultName = "unnamed_file"
+	// unsortedSession holds attachments added before a session has an ID
+	unsortedSession = "unsorted"
+)
+
+// ErrUnsupported is returned for a file type that can't be attached
+var ErrUnsupported = errors.New("unsupported attachment type")
+
+// ErrTooLarge is returned for a file over MaxSize
+var ErrTooLarge = fmt.Errorf("attachments can't be larger than %d MB", MaxSize>>20)
+
+type fileType struct {
+	kind     string
+	mimeType string
+}
+
+// fileTypes maps extensions to their kind and MIME type. The table is fixed
+// rather than taken from the system's MIME database, which differs between
+// platforms and labels source files oddly (.ts as video/mp2t).
+var fileTypes = map[string]fileType{
+	".png":  {KindImage, "image/png"},
+	".jpg":  {KindImage, "image/jpeg"},
+	".jpeg": {KindImage, "image/jpeg"},
+	".gif":  {KindImage, "image/gif"},
+	".webp": {KindImage, "image/webp"},
+	".svg":  {KindImage, "image/svg+xml"},
+	".bmp":  {KindImage, "image/bmp"},
+	".ico":  {KindImage, "image/x-icon"},
+
+	".pdf": {KindPDF, "application/pdf"},
+
+	".txt":      {KindText, "text/plain; charset=utf-8"},
+	".log":      {KindText, "text/plain; charset=utf-8"},
+	".md":       {KindText, "text/markdown; charset=utf-8"},
+	".markdown": {KindText, "text/markdown; charset=utf-8"},
+	".csv":      {KindText, "text/csv; charset=utf-8"},
+	".tsv":      {KindText, "text/tab-separated-values; charset=utf-8"},
+	".json":     {KindText, "application/json"},
+	".jsonl":    {KindText, "application/jsonl"},
+	".yaml":     {KindText, "application/yaml"},
+	".yml":      {KindText, "application/yaml"},
+	".toml":     {KindText, "application/toml"},
+	".xml":      {KindText, "application/xml"},
+	".html":     {KindText, "text/html; charset=utf-8"},
+	".css":      {KindText, "text/css; charset=utf-8"},
+	".js":       {KindText, "text/javascript; charset=utf-8"},
+	".jsx":      {KindText, "text/javascript; charset=utf-8"},
+	".ts":       {KindText, "text/plain; charset=utf-8"},
+	".tsx":      {KindText, "text/plain; charset=utf-8"},
+	".go":       {KindText, "text/plain; charset=utf-8"},
+	".py":       {KindText, "text/x-python; charset=utf-8"},
+	".rs":       {KindText, "text/plain; charset=utf-8"},
+	".java":     {KindText, "text/plain; charset=utf-8"},
+	".c":        {KindText, "text/plain; charset=utf-8"},
+	".h":        {KindText, "text/plain; charset=utf-8"},
+	".cpp":      {KindText, "text/plain; charset=utf-8"},
+	".rb":       {KindText, "text/plain; charset=utf-8"},
+	".sh":       {KindText, "text/plain; charset=utf-8"},
+	".sql":      {KindText, "text/plain; charset=utf-8"},
+	".diff":     {KindText, "text/plain; charset=utf-8"},
+	".patch":    {KindText, "text/plain; charset=utf-8"},
+
+	".zip": {KindArchive, "application/zip"},
+	".tar": {KindArchive, "application/x-tar"},
+	".gz":  {KindArchive, "application/gzip"},
+	".tgz": {KindArchive, "application/gzip"},
+	".bz2": {KindArchive, "application/x-bzip2"},
+	".xz":  {KindArchive, "application/x-xz"},
+	".7z":  {KindArchive, "application/x-7z-compressed"},
+}
+
+// Attachment is a stored file
+type Attachment struct {
+	Path     string `json:"path"`
+	Name     string `json:"name"`
+	Kind     string `json:"kind"`
+	MIMEType string `json:"mime_type"`
+	Size     int64  `json:"size"`
+}
+
+// Classify returns the kind and MIME type of a file name, or false for a
+// type that can't be attached
+func Classify(name string) (kind, mimeType string, ok bool) {
+	t, ok := fileTypes[strings.ToLower(filepath.Ext(name))]
+	return t.kind, t.mimeType, ok
+}
+
+// SessionDir returns the directory of a session's attachments under root.
+// Attachments added before the session has an ID go to a shared directory.
+func SessionDir(root, sessionID string) string {
+	if sessionID = SanitizeName(sessionID); sessionID == defaultName {
+		sessionID = unsortedSession
+	}
+	return filepath.Join(root, sessionID)
+}
+
+// Save stores the content of r as name in dir, prefixing the name with the
+// time so repeated names don't collide
+func Save(dir, name string, r io.Reader) (*Attachment, error) {
+	kind, mimeType, ok := Classify(name)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupported, filepath.Ext(name))
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create attachments directory: %w", err)
+	}
+
+	stored := time.Now().Format("20060102-150405") + "_" + SanitizeName(name)
+	path := filepath.Join(dir, stored)
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if os.IsExist(err) {
+		f, err = os.CreateTemp(dir, strings.TrimSuffix(stored, filepath.Ext(stored))+"-*"+filepath.Ext(stored))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to save attachment: %w", err)
+	}
+	path = f.Name()
+
+	written, err := io.Copy(f, io.LimitReader(r, MaxSize+1))
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil && written > MaxSize {
+		err = ErrTooLarge
+	}
+	if err != nil {
+		os.Remove(path)
+		return nil, err
+	}
+	return &Attachment{
+		Path:     path,
+		Name:     filepath.Base(path),
+		Kind:     kind,
+		MIMEType: mimeType,
+		Size:     written,
+	}, nil
+}
+
+// Copy stores a copy of the local file src in dir
+func Copy(dir, src string) (*Attachment, error) {
+	info, err := os.Stat(src)
+	if err != nil {
+		return nil, err
+	}
+	if !info.Mode().IsRegular() {
+		return nil, fmt.Errorf("%s is not a file", src)
+	}
+	if info.Size() > MaxSize {
+		return nil, ErrTooLarge
+	}
+	f, err := os.Open(src)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Save(dir, filepath.Base(src), f)
+}
+
+// Reference returns the text that points provider's CLI at the file at
+// path. Claude and Gemini read files mentioned with "@"; archives, which
+// they can't read that way, and every file for Codex, which has no such
+// syntax, are named so the agent can open them itself.
+func Reference(provider, path, kind string) string {
+	switch {
+	case provider == "codex" || kind == KindArchive:
+		return fmt.Sprintf("[attached %s: %s]", kind, path)
+	case provider == "gemini":
+		return "@" + strings.ReplaceAll(path, " ", `\ `)
+	case strings.Contains(path, " "):
+		return `@"` + path + `"`
+	default:
+		return "@" + path
+	}
+}
+
+// SanitizeName reduces a file name to letters, digits, dots, hyphens and
+// underscores, so it can't escape the directory it's stored in
+func SanitizeName(name string) string {
+	name = filepath.Base(name)
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsNumber(r) || r == '.' || r == '-' || r == '_' {
+			return r
+		}
+		return '_'
+	}, name)
+
+	if len(name) > maxNameLength {
+		ext := filepath.Ext(name)
+		stem := strings.TrimSuffix(name, ext)
+		if len(ext) > maxNameLength/2 {
+			ext = ext[:maxNameLength/2]
+		}
+		if limit := maxNameLength - len(ext); len(stem) > limit {
+			stem = stem[:limit]
+		}
+		name = stem + ext
+	}
+
+	if name == "" || name == "." || name == ".." {
+		name = defaultName
+	}
+	return name
+}
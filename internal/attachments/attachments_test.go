@@ -0,0 +1,102 @@
+package attachments
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name string
+		kind string
+		mime string
+		ok   bool
+	}{
+		{"report.PDF", KindPDF, "application/pdf", true},
+		{"notes.md", KindText, "text/markdown; charset=utf-8", true},
+		{"main.ts", KindText, "text/plain; charset=utf-8", true},
+		{"logs.tar.gz", KindArchive, "application/gzip", true},
+		{"shot.png", KindImage, "image/png", true},
+		{"setup.exe", "", "", false},
+		{"Makefile", "", "", false},
+	}
+	for _, tt := range tests {
+		kind, mime, ok := Classify(tt.name)
+		if kind != tt.kind || mime != tt.mime || ok != tt.ok {
+			t.Errorf("Classify(%q) = %q, %q, %v", tt.name, kind, mime, ok)
+		}
+	}
+}
+
+func TestSave(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "session")
+
+	first, err := Save(dir, "../../report.pdf", strings.NewReader("%PDF-1.7"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Dir(first.Path) != dir || !strings.HasSuffix(first.Name, "_report.pdf") {
+		t.Errorf("saved to %s", first.Path)
+	}
+	if first.Kind != KindPDF || first.MIMEType != "application/pdf" || first.Size != 8 {
+		t.Errorf("Save() = %+v", first)
+	}
+
+	// The same name in the same second doesn't overwrite the first file
+	second, err := Save(dir, "report.pdf", strings.NewReader("other"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.Path == first.Path {
+		t.Fatalf("second save reused %s", first.Path)
+	}
+	if data, _ := os.ReadFile(first.Path); string(data) != "%PDF-1.7" {
+		t.Errorf("first file now holds %q", data)
+	}
+
+	if _, err := Save(dir, "tool.exe", strings.NewReader("MZ")); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("Save(.exe) error = %v", err)
+	}
+}
+
+func TestSessionDir(t *testing.T) {
+	if got := SessionDir("/root", ""); got != filepath.Join("/root", unsortedSession) {
+		t.Errorf("SessionDir(empty) = %s", got)
+	}
+	if got := SessionDir("/root", "../x"); got != filepath.Join("/root", "x") {
+		t.Errorf("SessionDir(../x) = %s", got)
+	}
+}
+
+func TestReference(t *testing.T) {
+	tests := []struct {
+		provider, path, kind, want string
+	}{
+		{"claude", "/a/b.pdf", KindPDF, "@/a/b.pdf"},
+		{"claude", "/a b/c.txt", KindText, `@"/a b/c.txt"`},
+		{"gemini", "/a b/c.txt", KindText, `@/a\ b/c.txt`},
+		{"codex", "/a/b.pdf", KindPDF, "[attached pdf: /a/b.pdf]"},
+		{"claude", "/a/logs.zip", KindArchive, "[attached archive: /a/logs.zip]"},
+	}
+	for _, tt := range tests {
+		if got := Reference(tt.provider, tt.path, tt.kind); got != tt.want {
+			t.Errorf("Reference(%s, %s) = %s, want %s", tt.provider, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestSanitizeName(t *testing.T) {
+	if got := SanitizeName("../../etc/pass wd"); got != "pass_wd" {
+		t.Errorf("SanitizeName() = %q", got)
+	}
+	if got := SanitizeName(".."); got != defaultName {
+		t.Errorf("SanitizeName(..) = %q", got)
+	}
+	long := SanitizeName(strings.Repeat("a", 300) + ".txt")
+	if len(long) != maxNameLength || !strings.HasSuffix(long, ".txt") {
+		t.Errorf("long name = %d bytes", len(long))
+	}
+}
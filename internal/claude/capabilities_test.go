@@ -335,6 +335,39 @@ func TestCapabilityDiscoveryCache(t *testing.T) {
 	assertStageCalls(t, transport.calls, projectA, 3, 4, 4)
 }
 
+func TestInvalidateUserRediscoversUserAndProjectLayers(t *testing.T) {
+	projectPath := "/tmp/project-invalidate"
+	transport := &stubDiscoveryTransport{
+		snapshots: map[DiscoveryStage]CapabilitySnapshot{
+			DiscoveryStageSystem: {Stage: "system", Commands: []CommandSummary{{Name: "review"}}},
+			DiscoveryStageUser:   {Stage: "user", Commands: []CommandSummary{{Name: "review"}, {Name: "user-cmd"}}},
+		},
+		projectSnapshots: map[string]CapabilitySnapshot{
+			projectPath: {Stage: "project", Commands: []CommandSummary{{Name: "review"}, {Name: "user-cmd"}}},
+		},
+	}
+
+	service := NewCapabilityDiscoveryService(transport)
+	service.claudeVersion = func() (string, error) { return "1.0.0", nil }
+	service.userCacheGeneration = func() (string, error) { return "gen-1", nil }
+
+	if _, err := service.Discover(projectPath); err != nil {
+		t.Fatalf("expected discover to succeed, got %v", err)
+	}
+	if _, ok := service.Cached(projectPath); !ok {
+		t.Fatal("expected layers to be cached after discover")
+	}
+
+	service.InvalidateUser()
+	if _, ok := service.Cached(projectPath); ok {
+		t.Fatal("expected no cached layers after invalidation")
+	}
+	if _, err := service.Discover(projectPath); err != nil {
+		t.Fatalf("expected discover after invalidation to succeed, got %v", err)
+	}
+	assertStageCalls(t, transport.calls, projectPath, 1, 2, 2)
+}
+
 func TestRefreshCapabilityLayers(t *testing.T) {
 	projectPath := "/tmp/project-refresh"
 	transport := &stubDiscoveryTransport{
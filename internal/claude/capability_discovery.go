@@ -12,6 +12,8 @@ import (
 	"strings"
 	"sync"
 	"time"
+
+	"ropcode/internal/loginenv"
 )
 
 type DiscoveryStage string
@@ -38,6 +40,7 @@ type CapabilityDiscovery interface {
 	PrewarmSystem() bool
 	PrewarmUser() bool
 	PrewarmProject(projectPath string) bool
+	InvalidateUser()
 }
 
 type CapabilityDiscoveryService struct {
@@ -171,6 +174,19 @@ func (s *CapabilityDiscoveryService) PrewarmProject(projectPath string) bool {
 	return err == nil
 }
 
+// InvalidateUser drops the user and project snapshots, so the next discovery
+// runs again with the current user settings. The system snapshot only
+// depends on the Claude version and is kept.
+func (s *CapabilityDiscoveryService) InvalidateUser() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.cachedUserGen = ""
+	s.cachedUserGenErr = nil
+	s.userCache = userCache{}
+	s.projectCache = make(map[string]projectCacheEntry)
+}
+
 func (s *CapabilityDiscoveryService) currentSystemKey() string {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -594,12 +610,12 @@ func (t *ClaudeCapabilityDiscoveryTransport) buildCommand(ctx context.Context, s
 		cleanup = func() {
 			_ = os.RemoveAll(emptyCwd)
 		}
-		env = ensureFullShellPath(os.Environ())
+		env = loginenv.Environ()
 	case DiscoveryStageProject:
 		if strings.TrimSpace(projectPath) == "" {
 			return nil, nil, errors.New("project discovery stage requires a project path")
 		}
-		env = ensureFullShellPath(os.Environ())
+		env = loginenv.Environ()
 	default:
 		return nil, nil, fmt.Errorf("unsupported discovery stage %q", stage)
 	}
@@ -645,5 +661,5 @@ func discoveryBaseEnv() []string {
 			env = append(env, key+"="+value)
 		}
 	}
-	return ensureFullShellPath(env)
+	return loginenv.MergePATH(env)
 }
@@ -2,9 +2,11 @@ package claude
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 
 	"gopkg.in/yaml.v3"
@@ -496,40 +498,67 @@ func GetSlashCommand(name, projectPath string) (*SlashCommand, error) {
 	return nil, fmt.Errorf("command not found: %s", name)
 }
 
+// ResolveSlashCommand finds the command name invokes, "/ns:name" or
+// "ns:name", the way the CLI does: a custom command wins over a built-in of
+// the same name, a project command over a user one, and the full
+// namespaced name over a bare one
+func ResolveSlashCommand(name, projectPath string) (*SlashCommand, error) {
+	commands, err := ListSlashCommands(projectPath)
+	if err != nil {
+		return nil, err
+	}
+	name = strings.TrimPrefix(strings.TrimSpace(name), "/")
+	var byName, builtin *SlashCommand
+	for i := range commands {
+		cmd := &commands[i]
+		switch {
+		case cmd.Scope == "default":
+			if builtin == nil && cmd.Name == name {
+				builtin = cmd
+			}
+		case cmd.FullCommand == "/"+name:
+			return cmd, nil
+		case byName == nil && cmd.Name == name:
+			byName = cmd
+		}
+	}
+	if byName != nil {
+		return byName, nil
+	}
+	if builtin != nil {
+		return builtin, nil
+	}
+	return nil, fmt.Errorf("command not found: %s", name)
+}
+
 // SaveSlashCommand saves a slash command to the appropriate location
-// scope should be "user" or "project"
-func SaveSlashCommand(name, content, scope, projectPath string) error {
+// scope should be "user" or "project"; commandType picks .claude/commands
+// or .codex/prompts, Claude when empty. name may carry a namespace as
+// "ns:name", which is stored as ns/name.md.
+func SaveSlashCommand(name, content, scope, projectPath string, commandType CommandType) error {
 	if name == "" {
 		return fmt.Errorf("command name cannot be empty")
 	}
 
-	var dir string
-
-	switch scope {
-	case "user", "global":
-		homeDir, err := os.UserHomeDir()
-		if err != nil {
-			return fmt.Errorf("failed to get home directory: %w", err)
-		}
-		dir = filepath.Join(homeDir, ".claude", "commands")
-
-	case "project":
-		if projectPath == "" {
-			return fmt.Errorf("project path is required for project-level commands")
-		}
-		dir = filepath.Join(projectPath, ".claude", "commands")
-
-	default:
-		return fmt.Errorf("invalid scope: %s (must be 'user' or 'project')", scope)
+	dir, err := commandsDir(scope, commandType, projectPath)
+	if err != nil {
+		return err
+	}
+	namespace, name := splitCommandName(name)
+	if namespace != "" && commandType == CommandTypeCodex {
+		return errCodexNamespace
+	}
+	filePath, err := commandFilePath(dir, namespace, name)
+	if err != nil {
+		return err
 	}
 
 	// Create directory if it doesn't exist
-	if err := os.MkdirAll(dir, 0755); err != nil {
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
 		return fmt.Errorf("failed to create commands directory: %w", err)
 	}
 
 	// Write command file
-	filePath := filepath.Join(dir, name+".md")
 	if err := os.WriteFile(filePath, []byte(content), 0644); err != nil {
 		return fmt.Errorf("failed to write command file: %w", err)
 	}
@@ -537,41 +566,362 @@ func SaveSlashCommand(name, content, scope, projectPath string) error {
 	return nil
 }
 
-// DeleteSlashCommand deletes a slash command
-func DeleteSlashCommand(name, scope, projectPath string) error {
+// DeleteSlashCommand deletes a slash command of commandType, Claude when
+// empty. name may carry a namespace as "ns:name".
+func DeleteSlashCommand(name, scope, projectPath string, commandType CommandType) error {
 	if name == "" {
 		return fmt.Errorf("command name cannot be empty")
 	}
 
-	var filePath string
+	dir, err := commandsDir(scope, commandType, projectPath)
+	if err != nil {
+		return err
+	}
+	namespace, base := splitCommandName(name)
+	filePath, err := commandFilePath(dir, namespace, base)
+	if err != nil {
+		return err
+	}
+
+	// Check if file exists
+	if _, err := os.Stat(filePath); os.IsNotExist(err) {
+		return fmt.Errorf("command not found: %s", name)
+	}
+
+	// Delete the file
+	if err := os.Remove(filePath); err != nil {
+		return fmt.Errorf("failed to delete command file: %w", err)
+	}
+	removeEmptyDirs(filepath.Dir(filePath), dir)
+
+	return nil
+}
+
+// DeleteSlashCommandFile deletes the user or project command stored at
+// filePath, of either command type, refusing plugin commands
+func DeleteSlashCommandFile(filePath, projectPath string) error {
+	dir, err := writableCommandDir(filePath, projectPath)
+	if err != nil {
+		return err
+	}
+	if filepath.Ext(filePath) != ".md" {
+		return fmt.Errorf("%s is not a command file", filePath)
+	}
+	if err := os.Remove(filePath); err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("command not found: %s", filePath)
+		}
+		return fmt.Errorf("failed to delete command file: %w", err)
+	}
+	removeEmptyDirs(filepath.Dir(filePath), dir)
+	return nil
+}
+
+// ErrPluginCommandReadOnly is returned for changes to a command installed
+// by a plugin, which the next plugin update would overwrite
+var ErrPluginCommandReadOnly = errors.New("plugin commands are read-only; copy the command to user or project scope to change it")
+
+// errCodexNamespace is returned for a namespaced Codex prompt, since Codex
+// only reads the top of its prompts directory
+var errCodexNamespace = errors.New("codex prompts can't be namespaced; codex only reads prompts at the top of its prompts directory")
+
+// SlashCommandInput is a command to create, or the new state of one being
+// edited
+type SlashCommandInput struct {
+	Name string `json:"name"`
+	// Namespace nests the command in subdirectories, "ns" or "ns:sub"
+	// (also "ns/sub"), and makes it /ns:sub:name
+	Namespace    string      `json:"namespace"`
+	Content      string      `json:"content"`
+	Description  string      `json:"description"`
+	AllowedTools []string    `json:"allowed_tools"`
+	ArgumentHint string      `json:"argument_hint"`
+	Scope        string      `json:"scope"`
+	CommandType  CommandType `json:"command_type"`
+	ProjectPath  string      `json:"project_path"`
+	// OriginalFilePath is the file of the command being edited, empty for
+	// a new one. The command moves when its name, namespace, scope or type
+	// changed.
+	OriginalFilePath string `json:"original_file_path,omitempty"`
+}
+
+// SaveSlashCommand2 creates or updates a command from its fields, writing
+// description, allowed-tools and argument-hint as frontmatter and keeping
+// other frontmatter keys of the file being edited
+func SaveSlashCommand2(input SlashCommandInput) (*SlashCommand, error) {
+	if input.CommandType == "" {
+		input.CommandType = CommandTypeClaude
+	}
+	if strings.TrimSpace(input.Name) == "" {
+		return nil, fmt.Errorf("command name cannot be empty")
+	}
+	dir, err := commandsDir(input.Scope, input.CommandType, input.ProjectPath)
+	if err != nil {
+		return nil, err
+	}
+	namespace := strings.ReplaceAll(strings.Trim(input.Namespace, ":/"), "/", ":")
+	if namespace != "" && input.CommandType == CommandTypeCodex {
+		return nil, errCodexNamespace
+	}
+	filePath, err := commandFilePath(dir, namespace, input.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	var existing []byte
+	var originalDir string
+	moving, caseOnly := false, false
+	if input.OriginalFilePath != "" {
+		originalDir, err = writableCommandDir(input.OriginalFilePath, input.ProjectPath)
+		if err != nil {
+			return nil, err
+		}
+		existing, err = os.ReadFile(input.OriginalFilePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read command file: %w", err)
+		}
+		moving = filepath.Clean(input.OriginalFilePath) != filePath
+		if moving {
+			// A rename must not overwrite another command; a case-only
+			// rename on a case-insensitive filesystem finds the same file
+			if info, err := os.Stat(filePath); err == nil {
+				original, err := os.Stat(input.OriginalFilePath)
+				if err != nil || !os.SameFile(info, original) {
+					return nil, fmt.Errorf("a command already exists at %s", filePath)
+				}
+				caseOnly = true
+			}
+		}
+	} else if data, err := os.ReadFile(filePath); err == nil {
+		existing = data
+	}
+
+	content, err := renderCommandFile(existing, input)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create commands directory: %w", err)
+	}
+	if caseOnly {
+		if err := os.Rename(input.OriginalFilePath, filePath); err != nil {
+			return nil, fmt.Errorf("failed to rename command file: %w", err)
+		}
+	}
+	if err := os.WriteFile(filePath, content, 0644); err != nil {
+		return nil, fmt.Errorf("failed to write command file: %w", err)
+	}
+	// The new file is written before the old one goes, and by copy rather
+	// than rename since a scope change can cross filesystems
+	if moving && !caseOnly {
+		if err := os.Remove(input.OriginalFilePath); err != nil {
+			return nil, fmt.Errorf("saved %s but failed to remove the old command file: %w", filePath, err)
+		}
+		removeEmptyDirs(filepath.Dir(input.OriginalFilePath), originalDir)
+	}
+
+	cmd, err := loadCommandFromFile(filePath, dir, commandScopeName(input.Scope), input.CommandType)
+	if err != nil {
+		return nil, err
+	}
+	return &cmd, nil
+}
+
+// commandsDir returns the directory the commands of a writable scope live
+// in
+func commandsDir(scope string, cmdType CommandType, projectPath string) (string, error) {
+	sub := filepath.Join(".claude", "commands")
+	switch cmdType {
+	case CommandTypeClaude, "":
+	case CommandTypeCodex:
+		sub = filepath.Join(".codex", "prompts")
+	default:
+		return "", fmt.Errorf("invalid command type: %s", cmdType)
+	}
 
 	switch scope {
 	case "user", "global":
 		homeDir, err := os.UserHomeDir()
 		if err != nil {
-			return fmt.Errorf("failed to get home directory: %w", err)
+			return "", fmt.Errorf("failed to get home directory: %w", err)
 		}
-		filePath = filepath.Join(homeDir, ".claude", "commands", name+".md")
-
+		return filepath.Join(homeDir, sub), nil
 	case "project":
 		if projectPath == "" {
-			return fmt.Errorf("project path is required for project-level commands")
+			return "", fmt.Errorf("project path is required for project-level commands")
 		}
-		filePath = filepath.Join(projectPath, ".claude", "commands", name+".md")
-
+		return filepath.Join(projectPath, sub), nil
+	case "plugin":
+		return "", ErrPluginCommandReadOnly
+	case "default":
+		return "", fmt.Errorf("built-in commands can't be changed")
 	default:
-		return fmt.Errorf("invalid scope: %s (must be 'user' or 'project')", scope)
+		return "", fmt.Errorf("invalid scope: %s (must be 'user' or 'project')", scope)
 	}
+}
 
-	// Check if file exists
-	if _, err := os.Stat(filePath); os.IsNotExist(err) {
-		return fmt.Errorf("command not found: %s", name)
+// commandScopeName maps the "global" alias to the scope commands are
+// listed under
+func commandScopeName(scope string) string {
+	if scope == "global" {
+		return "user"
 	}
+	return scope
+}
 
-	// Delete the file
-	if err := os.Remove(filePath); err != nil {
-		return fmt.Errorf("failed to delete command file: %w", err)
+// writableCommandDir returns the user or project commands directory that
+// holds filePath, refusing files anywhere else such as a plugin's
+func writableCommandDir(filePath, projectPath string) (string, error) {
+	filePath = filepath.Clean(filePath)
+	var dirs []string
+	for _, cmdType := range []CommandType{CommandTypeClaude, CommandTypeCodex} {
+		for _, scope := range []string{"user", "project"} {
+			if dir, err := commandsDir(scope, cmdType, projectPath); err == nil {
+				dirs = append(dirs, dir)
+			}
+		}
+	}
+	for _, dir := range dirs {
+		if rel, err := filepath.Rel(dir, filePath); err == nil && rel != "." && !strings.HasPrefix(rel, "..") {
+			return dir, nil
+		}
+	}
+	if homeDir, err := os.UserHomeDir(); err == nil {
+		pluginsDir := filepath.Join(homeDir, ".claude", "plugins")
+		if rel, err := filepath.Rel(pluginsDir, filePath); err == nil && !strings.HasPrefix(rel, "..") {
+			return "", ErrPluginCommandReadOnly
+		}
 	}
+	return "", fmt.Errorf("%s is not a user or project command", filePath)
+}
 
-	return nil
+// validCommandSegment matches a command name or one level of namespace
+var validCommandSegment = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.-]*$`)
+
+// splitCommandName splits "ns:sub:name" into its namespace and name
+func splitCommandName(name string) (string, string) {
+	name = strings.TrimPrefix(name, "/")
+	if i := strings.LastIndex(name, ":"); i >= 0 {
+		return name[:i], name[i+1:]
+	}
+	return "", name
+}
+
+// commandFilePath returns the file of a command in dir, checking that the
+// name and every namespace level are plain file names
+func commandFilePath(dir, namespace, name string) (string, error) {
+	name = strings.TrimSuffix(name, ".md")
+	if !validCommandSegment.MatchString(name) {
+		return "", fmt.Errorf("invalid command name %q: use letters, digits, '-', '_' and '.'", name)
+	}
+	parts := []string{dir}
+	if namespace != "" {
+		for _, segment := range strings.Split(namespace, ":") {
+			if !validCommandSegment.MatchString(segment) {
+				return "", fmt.Errorf("invalid namespace %q: use letters, digits, '-', '_' and '.' separated by ':'", namespace)
+			}
+			parts = append(parts, segment)
+		}
+	}
+	return filepath.Join(append(parts, name+".md")...), nil
+}
+
+// removeEmptyDirs removes dir and its parents while they're empty, stopping
+// at root
+func removeEmptyDirs(dir, root string) {
+	for {
+		rel, err := filepath.Rel(root, dir)
+		if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+			return
+		}
+		if os.Remove(dir) != nil {
+			return
+		}
+		dir = filepath.Dir(dir)
+	}
+}
+
+// renderCommandFile returns the file of a command: the frontmatter of
+// existing with the input's fields set, then the content
+func renderCommandFile(existing []byte, input SlashCommandInput) ([]byte, error) {
+	var doc yaml.Node
+	if frontmatter, ok := frontmatterBlock(string(existing)); ok {
+		if err := yaml.Unmarshal([]byte(frontmatter), &doc); err != nil {
+			// Unreadable frontmatter is replaced rather than kept
+			doc = yaml.Node{}
+		}
+	}
+	var mapping *yaml.Node
+	if doc.Kind == yaml.DocumentNode && len(doc.Content) == 1 && doc.Content[0].Kind == yaml.MappingNode {
+		mapping = doc.Content[0]
+	} else {
+		mapping = &yaml.Node{Kind: yaml.MappingNode}
+	}
+
+	setKey := func(key string, value interface{}, empty bool) error {
+		for i := 0; i+1 < len(mapping.Content); i += 2 {
+			if mapping.Content[i].Value != key {
+				continue
+			}
+			if empty {
+				mapping.Content = append(mapping.Content[:i], mapping.Content[i+2:]...)
+				return nil
+			}
+			return mapping.Content[i+1].Encode(value)
+		}
+		if empty {
+			return nil
+		}
+		var node yaml.Node
+		if err := node.Encode(value); err != nil {
+			return err
+		}
+		mapping.Content = append(mapping.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, &node)
+		return nil
+	}
+	tools := make([]string, 0, len(input.AllowedTools))
+	for _, tool := range input.AllowedTools {
+		if tool = strings.TrimSpace(tool); tool != "" {
+			tools = append(tools, tool)
+		}
+	}
+	description := strings.TrimSpace(input.Description)
+	hint := strings.TrimSpace(input.ArgumentHint)
+	for _, err := range []error{
+		setKey("description", description, description == ""),
+		setKey("allowed-tools", tools, len(tools) == 0),
+		setKey("argument-hint", hint, hint == ""),
+	} {
+		if err != nil {
+			return nil, fmt.Errorf("failed to write frontmatter: %w", err)
+		}
+	}
+
+	var out strings.Builder
+	if len(mapping.Content) > 0 {
+		data, err := yaml.Marshal(mapping)
+		if err != nil {
+			return nil, fmt.Errorf("failed to write frontmatter: %w", err)
+		}
+		out.WriteString("---\n")
+		out.Write(data)
+		out.WriteString("---\n")
+	}
+	out.WriteString(input.Content)
+	return []byte(out.String()), nil
+}
+
+// frontmatterBlock returns the YAML between the leading "---" lines of a
+// command file
+func frontmatterBlock(content string) (string, bool) {
+	lines := strings.Split(content, "\n")
+	if len(lines) == 0 || lines[0] != "---" {
+		return "", false
+	}
+	for i := 1; i < len(lines); i++ {
+		if lines[i] == "---" {
+			return strings.Join(lines[1:i], "\n"), true
+		}
+	}
+	return "", false
 }
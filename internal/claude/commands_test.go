@@ -1,8 +1,10 @@
 package claude
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -28,7 +30,7 @@ func TestSlashCommands(t *testing.T) {
 
 	t.Run("SaveAndGetGlobalCommand", func(t *testing.T) {
 		// Save a global command
-		err := SaveSlashCommand("test-global", "# Test Global Command\nThis is a test.", "global", "")
+		err := SaveSlashCommand("test-global", "# Test Global Command\nThis is a test.", "global", "", "")
 		if err != nil {
 			t.Fatalf("Failed to save global command: %v", err)
 		}
@@ -52,7 +54,7 @@ func TestSlashCommands(t *testing.T) {
 
 	t.Run("SaveAndGetProjectCommand", func(t *testing.T) {
 		// Save a project command
-		err := SaveSlashCommand("test-project", "# Test Project Command\nProject specific.", "project", projectPath)
+		err := SaveSlashCommand("test-project", "# Test Project Command\nProject specific.", "project", projectPath, "")
 		if err != nil {
 			t.Fatalf("Failed to save project command: %v", err)
 		}
@@ -114,7 +116,7 @@ func TestSlashCommands(t *testing.T) {
 
 	t.Run("DeleteGlobalCommand", func(t *testing.T) {
 		// Delete global command
-		err := DeleteSlashCommand("test-global", "global", "")
+		err := DeleteSlashCommand("test-global", "global", "", "")
 		if err != nil {
 			t.Fatalf("Failed to delete global command: %v", err)
 		}
@@ -128,7 +130,7 @@ func TestSlashCommands(t *testing.T) {
 
 	t.Run("DeleteProjectCommand", func(t *testing.T) {
 		// Delete project command
-		err := DeleteSlashCommand("test-project", "project", projectPath)
+		err := DeleteSlashCommand("test-project", "project", projectPath, "")
 		if err != nil {
 			t.Fatalf("Failed to delete project command: %v", err)
 		}
@@ -142,7 +144,7 @@ func TestSlashCommands(t *testing.T) {
 
 	t.Run("InvalidScope", func(t *testing.T) {
 		// Try to save with invalid scope
-		err := SaveSlashCommand("test", "content", "invalid", "")
+		err := SaveSlashCommand("test", "content", "invalid", "", "")
 		if err == nil {
 			t.Error("Expected error for invalid scope")
 		}
@@ -150,7 +152,7 @@ func TestSlashCommands(t *testing.T) {
 
 	t.Run("EmptyName", func(t *testing.T) {
 		// Try to save with empty name
-		err := SaveSlashCommand("", "content", "global", "")
+		err := SaveSlashCommand("", "content", "global", "", "")
 		if err == nil {
 			t.Error("Expected error for empty name")
 		}
@@ -158,9 +160,188 @@ func TestSlashCommands(t *testing.T) {
 
 	t.Run("ProjectScopeWithoutPath", func(t *testing.T) {
 		// Try to save project command without project path
-		err := SaveSlashCommand("test", "content", "project", "")
+		err := SaveSlashCommand("test", "content", "project", "", "")
 		if err == nil {
 			t.Error("Expected error for project scope without path")
 		}
 	})
 }
+
+func TestSaveSlashCommand2(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	project := filepath.Join(home, "project")
+	userDir := filepath.Join(home, ".claude", "commands")
+
+	cmd, err := SaveSlashCommand2(SlashCommandInput{
+		Name:         "deploy",
+		Namespace:    "ops/release",
+		Content:      "Deploy $ARGUMENTS\n",
+		Description:  "Deploy a service",
+		AllowedTools: []string{"Bash", " "},
+		ArgumentHint: "<service>",
+		Scope:        "user",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantPath := filepath.Join(userDir, "ops", "release", "deploy.md")
+	if cmd.FilePath != wantPath || cmd.FullCommand != "/ops:release:deploy" {
+		t.Fatalf("saved %s as %s", cmd.FilePath, cmd.FullCommand)
+	}
+	if cmd.Description == nil || *cmd.Description != "Deploy a service" || len(cmd.AllowedTools) != 1 ||
+		cmd.ArgumentHint == nil || *cmd.ArgumentHint != "<service>" || cmd.Content != "Deploy $ARGUMENTS\n" {
+		t.Fatalf("SaveSlashCommand2() = %+v", cmd)
+	}
+
+	// Frontmatter keys the form doesn't know about survive an edit
+	data, _ := os.ReadFile(wantPath)
+	os.WriteFile(wantPath, []byte(strings.Replace(string(data), "---\n", "---\nmodel: opus\n", 1)), 0644)
+
+	// Renaming into the project moves the file and removes the emptied
+	// namespace directories
+	cmd, err = SaveSlashCommand2(SlashCommandInput{
+		Name:             "ship",
+		Content:          "Ship it\n",
+		Scope:            "project",
+		ProjectPath:      project,
+		OriginalFilePath: wantPath,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cmd.FilePath != filepath.Join(project, ".claude", "commands", "ship.md") || cmd.Scope != "project" || cmd.Description != nil {
+		t.Fatalf("renamed command = %+v", cmd)
+	}
+	if _, err := os.Stat(filepath.Join(userDir, "ops")); !os.IsNotExist(err) {
+		t.Errorf("empty namespace dir left behind: %v", err)
+	}
+	if data, _ := os.ReadFile(cmd.FilePath); string(data) != "---\nmodel: opus\n---\nShip it\n" {
+		t.Errorf("renamed file = %q", data)
+	}
+
+	// A rename doesn't overwrite another command
+	SaveSlashCommand2(SlashCommandInput{Name: "other", Content: "x", Scope: "project", ProjectPath: project})
+	if _, err := SaveSlashCommand2(SlashCommandInput{
+		Name: "other", Content: "y", Scope: "project", ProjectPath: project, OriginalFilePath: cmd.FilePath,
+	}); err == nil {
+		t.Error("rename overwrote an existing command")
+	}
+
+	for _, input := range []SlashCommandInput{
+		{Name: "../escape", Scope: "user"},
+		{Name: "ok", Namespace: "a:..", Scope: "user"},
+		{Name: "ok", Namespace: "ns", Scope: "user", CommandType: CommandTypeCodex},
+	} {
+		if _, err := SaveSlashCommand2(input); err == nil {
+			t.Errorf("SaveSlashCommand2(%+v) succeeded", input)
+		}
+	}
+}
+
+func TestSlashCommandPluginScopeIsReadOnly(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	pluginFile := filepath.Join(home, ".claude", "plugins", "cache", "tools", "commands", "lint.md")
+	os.MkdirAll(filepath.Dir(pluginFile), 0755)
+	os.WriteFile(pluginFile, []byte("Lint"), 0644)
+
+	if _, err := SaveSlashCommand2(SlashCommandInput{Name: "lint", Scope: "plugin"}); !errors.Is(err, ErrPluginCommandReadOnly) {
+		t.Errorf("save in plugin scope = %v", err)
+	}
+	if _, err := SaveSlashCommand2(SlashCommandInput{Name: "lint", Scope: "user", OriginalFilePath: pluginFile}); !errors.Is(err, ErrPluginCommandReadOnly) {
+		t.Errorf("moving a plugin command = %v", err)
+	}
+	if err := DeleteSlashCommand("lint", "plugin", "", ""); !errors.Is(err, ErrPluginCommandReadOnly) {
+		t.Errorf("delete in plugin scope = %v", err)
+	}
+	if err := DeleteSlashCommandFile(pluginFile, ""); !errors.Is(err, ErrPluginCommandReadOnly) {
+		t.Errorf("delete of a plugin file = %v", err)
+	}
+	if _, err := os.Stat(pluginFile); err != nil {
+		t.Errorf("plugin command touched: %v", err)
+	}
+}
+
+func TestDeleteNamespacedSlashCommand(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+
+	if err := SaveSlashCommand("git:commit", "Commit", "user", "", ""); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(home, ".claude", "commands", "git", "commit.md")
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("namespaced command not at %s: %v", path, err)
+	}
+	if err := DeleteSlashCommand("git:commit", "user", "", ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Dir(path)); !os.IsNotExist(err) {
+		t.Errorf("namespace dir left behind: %v", err)
+	}
+
+	// Codex prompts are saved and deleted by type
+	if err := SaveSlashCommand("explain", "Explain", "user", "", CommandTypeCodex); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(home, ".codex", "prompts", "explain.md")); err != nil {
+		t.Fatalf("codex prompt not written: %v", err)
+	}
+	if err := SaveSlashCommand("ns:explain", "Explain", "user", "", CommandTypeCodex); err == nil {
+		t.Error("SaveSlashCommand() namespaced a codex prompt")
+	}
+	if err := DeleteSlashCommand("explain", "user", "", CommandTypeCodex); err != nil {
+		t.Fatal(err)
+	}
+
+	// and by file
+	cmd, err := SaveSlashCommand2(SlashCommandInput{Name: "review", Content: "Review", Scope: "user", CommandType: CommandTypeCodex})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cmd.FilePath != filepath.Join(home, ".codex", "prompts", "review.md") {
+		t.Fatalf("codex prompt saved at %s", cmd.FilePath)
+	}
+	if err := DeleteSlashCommandFile(cmd.FilePath, ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := DeleteSlashCommandFile(filepath.Join(home, "notes.md"), ""); err == nil {
+		t.Error("DeleteSlashCommandFile() accepted a file outside the commands directories")
+	}
+}
+
+func TestResolveSlashCommand(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	project := filepath.Join(home, "project")
+
+	SaveSlashCommand("review", "user review", "user", "", "")
+	SaveSlashCommand("review", "project review", "project", project, "")
+	SaveSlashCommand("git:review", "git review", "user", "", "")
+
+	tests := []struct{ name, content string }{
+		{"/review", "project review"},
+		{"review", "project review"},
+		{"/git:review", "git review"},
+	}
+	for _, tt := range tests {
+		cmd, err := ResolveSlashCommand(tt.name, project)
+		if err != nil {
+			t.Fatalf("ResolveSlashCommand(%s): %v", tt.name, err)
+		}
+		if cmd.Content != tt.content {
+			t.Errorf("ResolveSlashCommand(%s) = %q, want %q", tt.name, cmd.Content, tt.content)
+		}
+	}
+	if cmd, err := ResolveSlashCommand("clear", project); err != nil || cmd.Scope != "default" {
+		t.Errorf("built-in = %+v, %v", cmd, err)
+	}
+	if _, err := ResolveSlashCommand("nope", project); err == nil {
+		t.Error("found a missing command")
+	}
+}
@@ -508,6 +508,70 @@ func ListProjectSessionsLimit(claudeDir, projectPath string, limit int) (Project
 	return ProjectSessionsResult{Sessions: sessions, HasMore: hasMore}, nil
 }
 
+// SessionSummary is a session's metadata with its size and last activity
+type SessionSummary struct {
+	SessionInfo
+	MessageCount int   `json:"message_count"`
+	UpdatedAt    int64 `json:"updated_at"`
+}
+
+// ListProjectSessionSummaries lists the sessions of a project like
+// ListProjectSessions, most recent first, counting the messages of each.
+// It reads every transcript in full, so it is for one project at a time.
+func ListProjectSessionSummaries(claudeDir, projectPath string) ([]SessionSummary, error) {
+	sessions, err := ListProjectSessions(claudeDir, projectPath)
+	if err != nil {
+		return nil, err
+	}
+	summaries := make([]SessionSummary, 0, len(sessions))
+	for _, session := range sessions {
+		filePath := GetSessionFilePath(claudeDir, session.ProjectID, session.ID)
+		count, err := countMessages(filePath)
+		if err != nil {
+			continue
+		}
+		summary := SessionSummary{SessionInfo: session, MessageCount: count, UpdatedAt: session.CreatedAt}
+		if t, err := parseTimestamp(session.MessageTimestamp); err == nil {
+			summary.UpdatedAt = t.Unix()
+		}
+		summaries = append(summaries, summary)
+	}
+	return summaries, nil
+}
+
+// countMessages counts the user and assistant messages of the main
+// conversation in a JSONL file, tool results included. Lines are read
+// without a length limit.
+func countMessages(filePath string) (int, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	count := 0
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 {
+			var entry struct {
+				Type        string `json:"type"`
+				IsSidechain bool   `json:"isSidechain"`
+			}
+			if json.Unmarshal(line, &entry) == nil && !entry.IsSidechain &&
+				(entry.Type == "user" || entry.Type == "assistant") {
+				count++
+			}
+		}
+		if err == io.EOF {
+			return count, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+}
+
 // extractClaudeSessionInfo reads a JSONL file to extract session metadata
 // Only reads the first few lines and the file stat for timestamps
 func extractClaudeSessionInfo(filePath, sessionID, projectHash, projectPath string) (*SessionInfo, error) {
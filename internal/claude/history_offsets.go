@@ -0,0 +1,229 @@
+// internal/claude/history_offsets.go
+package claude
+
+import (
+	"bufio"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+)
+
+// OffsetIndex records where each line of a JSONL file starts, so a range of
+// messages is read without scanning the lines before it. Session files are
+// only appended to, so the index of a file that grew is extended from where
+// it ended rather than rebuilt.
+type OffsetIndex struct {
+	// Size and ModTime (Unix nanoseconds) are those of the file indexed
+	Size    int64 `json:"size"`
+	ModTime int64 `json:"mod_time"`
+	// Offsets holds where each complete line starts
+	Offsets []int64 `json:"offsets"`
+	// End is where the last complete line ends
+	End int64 `json:"end"`
+}
+
+// Lines returns the number of lines of the indexed file, counting a
+// trailing line without a newline
+func (x *OffsetIndex) Lines() int {
+	if x.Size > x.End {
+		return len(x.Offsets) + 1
+	}
+	return len(x.Offsets)
+}
+
+// Fresh reports whether x still describes the file with info
+func (x *OffsetIndex) Fresh(info fs.FileInfo) bool {
+	return x.Size == info.Size() && x.ModTime == info.ModTime().UnixNano()
+}
+
+// UpdateOffsetIndex returns the index of filePath. prev, an earlier index
+// of the file or nil, is returned as is when the file is unchanged and
+// extended when the file only grew; otherwise the file is indexed again.
+func UpdateOffsetIndex(filePath string, prev *OffsetIndex) (*OffsetIndex, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	stat, err := file.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat file: %w", err)
+	}
+	if prev != nil && prev.Fresh(stat) {
+		return prev, nil
+	}
+
+	index := &OffsetIndex{Size: stat.Size(), ModTime: stat.ModTime().UnixNano()}
+	if prev != nil && appendedTo(file, prev, stat.Size()) {
+		index.Offsets = slices.Clone(prev.Offsets)
+		index.End = prev.End
+	}
+
+	// Only the offsets are needed, so long lines are skipped a buffer at a
+	// time instead of being read whole
+	reader := bufio.NewReaderSize(io.NewSectionReader(file, index.End, index.Size-index.End), 64*1024)
+	pos, lineStart := index.End, index.End
+	for {
+		chunk, err := reader.ReadSlice('\n')
+		pos += int64(len(chunk))
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error scanning file: %w", err)
+		}
+		index.Offsets = append(index.Offsets, lineStart)
+		lineStart = pos
+	}
+	index.End = lineStart
+	return index, nil
+}
+
+// appendedTo reports whether a file of size still ends its first prev.End
+// bytes with the newline prev was built up to
+func appendedTo(file *os.File, prev *OffsetIndex, size int64) bool {
+	if prev.End > size {
+		return false
+	}
+	if prev.End == 0 {
+		return true
+	}
+	last := make([]byte, 1)
+	_, err := file.ReadAt(last, prev.End-1)
+	return err == nil && last[0] == '\n'
+}
+
+// ReadIndexedMessages reads the messages of lines start to end (1-based,
+// inclusive) of a JSONL file through its index, seeking to the first line
+// rather than scanning up to it
+func ReadIndexedMessages(filePath string, index *OffsetIndex, start, end int) ([]Message, error) {
+	if start < 1 || end < start {
+		return nil, fmt.Errorf("invalid range: start=%d, end=%d", start, end)
+	}
+	end = min(end, index.Lines())
+	if start > end {
+		return []Message{}, nil
+	}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	from := lineOffset(index, start-1)
+	to := index.Size
+	if end < len(index.Offsets) {
+		to = index.Offsets[end]
+	}
+
+	messages := []Message{}
+	reader := bufio.NewReader(io.NewSectionReader(file, from, to-from))
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 {
+			var msg Message
+			if json.Unmarshal(line, &msg) == nil {
+				messages = append(messages, msg)
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading file: %w", err)
+		}
+	}
+	return messages, nil
+}
+
+// lineOffset returns where the 0-based line i starts
+func lineOffset(index *OffsetIndex, i int) int64 {
+	if i < len(index.Offsets) {
+		return index.Offsets[i]
+	}
+	return index.End
+}
+
+// OffsetIndexCache keeps the offset indexes of session files in memory and,
+// when it has a directory, on disk so they outlive a restart
+type OffsetIndexCache struct {
+	dir     string
+	mu      sync.Mutex
+	indexes map[string]*OffsetIndex
+}
+
+// NewOffsetIndexCache creates a cache persisting its indexes in dir; an
+// empty dir keeps them in memory only
+func NewOffsetIndexCache(dir string) *OffsetIndexCache {
+	return &OffsetIndexCache{dir: dir, indexes: make(map[string]*OffsetIndex)}
+}
+
+// Get returns the up-to-date index of filePath, updating the cached one
+func (c *OffsetIndexCache) Get(filePath string) (*OffsetIndex, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	prev, ok := c.indexes[filePath]
+	if !ok {
+		prev = c.load(filePath)
+	}
+	index, err := UpdateOffsetIndex(filePath, prev)
+	if err != nil {
+		delete(c.indexes, filePath)
+		return nil, err
+	}
+	c.indexes[filePath] = index
+	if index != prev {
+		c.save(filePath, index)
+	}
+	return index, nil
+}
+
+// cacheFile returns where the index of filePath is persisted
+func (c *OffsetIndexCache) cacheFile(filePath string) string {
+	sum := md5.Sum([]byte(filePath))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+func (c *OffsetIndexCache) load(filePath string) *OffsetIndex {
+	if c.dir == "" {
+		return nil
+	}
+	data, err := os.ReadFile(c.cacheFile(filePath))
+	if err != nil {
+		return nil
+	}
+	var index OffsetIndex
+	if json.Unmarshal(data, &index) != nil {
+		return nil
+	}
+	return &index
+}
+
+// save persists index; a failure only costs a rescan after a restart
+func (c *OffsetIndexCache) save(filePath string, index *OffsetIndex) {
+	if c.dir == "" {
+		return
+	}
+	data, err := json.Marshal(index)
+	if err != nil || os.MkdirAll(c.dir, 0o755) != nil {
+		return
+	}
+	path := c.cacheFile(filePath)
+	tmp := path + ".tmp"
+	if os.WriteFile(tmp, data, 0o644) != nil || os.Rename(tmp, path) != nil {
+		os.Remove(tmp)
+	}
+}
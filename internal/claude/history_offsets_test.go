@@ -0,0 +1,72 @@
+package claude
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestOffsetIndexExtendsAppendedFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.jsonl")
+	// Line 2 is longer than the reader's buffer
+	os.WriteFile(path, []byte(tailTestLine(1)+tailTestLine(2)+strings.Repeat(" ", 70000)+"\n"+tailTestLine(3)), 0644)
+
+	index, err := UpdateOffsetIndex(path, nil)
+	if err != nil || index.Lines() != 4 || index.End != index.Size {
+		t.Fatalf("UpdateOffsetIndex() = %+v, %v", index, err)
+	}
+	if again, _ := UpdateOffsetIndex(path, index); again != index {
+		t.Fatal("UpdateOffsetIndex() rebuilt the index of an unchanged file")
+	}
+	messages, err := ReadIndexedMessages(path, index, 2, 4)
+	if err != nil || uuids(messages) != "m2,m3" {
+		t.Fatalf("ReadIndexedMessages(2, 4) = %s, %v", uuids(messages), err)
+	}
+
+	// A line still being written counts but isn't part of the extendable index
+	file, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	file.WriteString(`{"type":"user","uuid":"m4"}`)
+	file.Close()
+	grown, err := UpdateOffsetIndex(path, index)
+	if err != nil || grown.Lines() != 5 || grown.End != index.End {
+		t.Fatalf("UpdateOffsetIndex(grown) = %+v, %v", grown, err)
+	}
+	if messages, _ := ReadIndexedMessages(path, grown, 4, 100); uuids(messages) != "m3,m4" {
+		t.Fatalf("ReadIndexedMessages(4, 100) = %s", uuids(messages))
+	}
+
+	// A rewritten file is indexed again
+	os.WriteFile(path, []byte(tailTestLine(7)), 0644)
+	rewritten, _ := UpdateOffsetIndex(path, grown)
+	if messages, _ := ReadIndexedMessages(path, rewritten, 1, 10); rewritten.Lines() != 1 || uuids(messages) != "m7" {
+		t.Fatalf("rewritten index = %+v, messages %s", rewritten, uuids(messages))
+	}
+	if _, err := ReadIndexedMessages(path, rewritten, 3, 2); err == nil {
+		t.Error("ReadIndexedMessages() accepted an inverted range")
+	}
+}
+
+func TestOffsetIndexCachePersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.jsonl")
+	os.WriteFile(path, []byte(tailTestLine(1)+tailTestLine(2)), 0644)
+	dir := t.TempDir()
+
+	index, err := NewOffsetIndexCache(dir).Get(path)
+	if err != nil || index.Lines() != 2 {
+		t.Fatalf("Get() = %+v, %v", index, err)
+	}
+	cache := NewOffsetIndexCache(dir)
+	loaded := cache.load(path)
+	if loaded == nil || loaded.Lines() != 2 || loaded.End != index.End {
+		t.Fatalf("persisted index = %+v", loaded)
+	}
+	if again, _ := cache.Get(path); again.Lines() != 2 {
+		t.Fatalf("Get() from the persisted index = %+v", again)
+	}
+
+	os.Remove(path)
+	if _, err := cache.Get(path); err == nil {
+		t.Error("Get() succeeded for a removed file")
+	}
+}
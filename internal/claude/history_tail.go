@@ -0,0 +1,167 @@
+// internal/claude/history_tail.go
+package claude
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// tailChunkSize is how much of a file ReadLastMessages reads at a time
+const tailChunkSize = 64 * 1024
+
+// ReadLastMessages returns the messages of the last n lines of a JSONL file.
+// It reads the file backwards from the end, so the cost depends on n rather
+// than on the size of the file. The offset returned is where the last
+// complete line ends; ReadMessagesFrom picks up from there. A trailing line
+// without a newline is still being written and is left for later.
+func ReadLastMessages(filePath string, n int) ([]Message, int64, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	stat, err := file.Stat()
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to stat file: %w", err)
+	}
+
+	// Chunks are collected from the end, so they are in reverse order
+	var chunks [][]byte
+	end := int64(-1)
+	newlines := 0
+	for pos := stat.Size(); pos > 0 && newlines <= n; {
+		size := min(int64(tailChunkSize), pos)
+		pos -= size
+		chunk := make([]byte, size)
+		if _, err := file.ReadAt(chunk, pos); err != nil && err != io.EOF {
+			return nil, 0, fmt.Errorf("failed to read file: %w", err)
+		}
+		if end < 0 {
+			i := bytes.LastIndexByte(chunk, '\n')
+			if i < 0 {
+				continue
+			}
+			end = pos + int64(i) + 1
+			chunk = chunk[:i+1]
+		}
+		chunks = append(chunks, chunk)
+		newlines += bytes.Count(chunk, []byte{'\n'})
+	}
+	if end < 0 || n <= 0 {
+		return []Message{}, max(end, 0), nil
+	}
+
+	var data []byte
+	for i := len(chunks) - 1; i >= 0; i-- {
+		data = append(data, chunks[i]...)
+	}
+	lines := bytes.Split(data[:len(data)-1], []byte{'\n'})
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+
+	messages := make([]Message, 0, len(lines))
+	for _, line := range lines {
+		var msg Message
+		if err := json.Unmarshal(line, &msg); err != nil {
+			// Skip malformed lines but continue processing
+			continue
+		}
+		messages = append(messages, msg)
+	}
+	return messages, end, nil
+}
+
+// ReadMessagesFrom returns the messages of the complete lines written after
+// offset and the offset where the last of them ends. A file shorter than
+// offset was rewritten and is read from the start.
+func ReadMessagesFrom(filePath string, offset int64) ([]Message, int64, error) {
+	lines, next, err := ReadLinesFrom(filePath, offset)
+	messages := make([]Message, 0, len(lines))
+	for _, line := range lines {
+		var msg Message
+		if err := json.Unmarshal(line, &msg); err != nil {
+			continue
+		}
+		messages = append(messages, msg)
+	}
+	return messages, next, err
+}
+
+// ReadLinesFrom returns the complete lines written after offset, newlines
+// included, and the offset where the last of them ends. A file shorter
+// than offset was rewritten and is read from the start.
+func ReadLinesFrom(filePath string, offset int64) ([][]byte, int64, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, offset, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	stat, err := file.Stat()
+	if err != nil {
+		return nil, offset, fmt.Errorf("failed to stat file: %w", err)
+	}
+	if offset < 0 || stat.Size() < offset {
+		offset = 0
+	}
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return nil, offset, fmt.Errorf("failed to seek file: %w", err)
+	}
+
+	var lines [][]byte
+	reader := bufio.NewReader(file)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			// A line without a newline is still being written
+			break
+		}
+		if err != nil {
+			return lines, offset, fmt.Errorf("failed to read file: %w", err)
+		}
+		offset += int64(len(line))
+		lines = append(lines, line)
+	}
+	return lines, offset, nil
+}
+
+// FollowMessages polls a JSONL file every interval and calls emit with the
+// messages appended after offset, together with the offset they end at,
+// until ctx is done. Only the new bytes are read on each poll.
+func FollowMessages(ctx context.Context, filePath string, offset int64, interval time.Duration, emit func([]Message, int64)) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		stat, err := os.Stat(filePath)
+		if err != nil {
+			return fmt.Errorf("failed to stat file: %w", err)
+		}
+		if stat.Size() == offset {
+			continue
+		}
+		messages, next, err := ReadMessagesFrom(filePath, offset)
+		if err != nil {
+			return err
+		}
+		if next == offset {
+			continue
+		}
+		offset = next
+		emit(messages, offset)
+	}
+}
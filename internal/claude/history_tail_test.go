@@ -0,0 +1,98 @@
+package claude
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func tailTestLine(i int) string {
+	// Long enough that the lines span several chunks
+	return fmt.Sprintf(`{"type":"user","uuid":"m%d","message":{"role":"user","content":"%s"}}`+"\n", i, strings.Repeat("x", 10000))
+}
+
+func uuids(messages []Message) string {
+	ids := make([]string, len(messages))
+	for i, msg := range messages {
+		ids[i] = msg.UUID
+	}
+	return strings.Join(ids, ",")
+}
+
+func TestReadLastMessagesAndReadMessagesFrom(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.jsonl")
+	var content strings.Builder
+	for i := 1; i <= 20; i++ {
+		content.WriteString(tailTestLine(i))
+	}
+	complete := int64(content.Len())
+	content.WriteString(`{"type":"user","uuid":"partial"`)
+	if err := os.WriteFile(path, []byte(content.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	messages, offset, err := ReadLastMessages(path, 3)
+	if err != nil || uuids(messages) != "m18,m19,m20" || offset != complete {
+		t.Fatalf("ReadLastMessages(3) = %s, %d, %v, want m18,m19,m20 at %d", uuids(messages), offset, err, complete)
+	}
+	if messages, _, _ := ReadLastMessages(path, 100); len(messages) != 20 || messages[0].UUID != "m1" {
+		t.Fatalf("ReadLastMessages(100) = %s", uuids(messages))
+	}
+	if messages, offset, _ := ReadLastMessages(path, 0); len(messages) != 0 || offset != complete {
+		t.Fatalf("ReadLastMessages(0) = %s, %d", uuids(messages), offset)
+	}
+
+	// Finishing the partial line and appending another makes both readable
+	file, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	file.WriteString("}\n" + tailTestLine(21))
+	file.Close()
+	messages, next, err := ReadMessagesFrom(path, offset)
+	if err != nil || uuids(messages) != "partial,m21" {
+		t.Fatalf("ReadMessagesFrom() = %s, %v", uuids(messages), err)
+	}
+	if messages, again, _ := ReadMessagesFrom(path, next); len(messages) != 0 || again != next {
+		t.Fatalf("ReadMessagesFrom(end) = %s, %d, want nothing at %d", uuids(messages), again, next)
+	}
+
+	// A rewritten, shorter file is read from the start
+	os.WriteFile(path, []byte(tailTestLine(1)), 0644)
+	if messages, _, _ := ReadMessagesFrom(path, next); uuids(messages) != "m1" {
+		t.Fatalf("ReadMessagesFrom(truncated) = %s", uuids(messages))
+	}
+}
+
+func TestFollowMessages(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.jsonl")
+	os.WriteFile(path, []byte(tailTestLine(1)), 0644)
+	_, offset, _ := ReadLastMessages(path, 10)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	received := make(chan []Message, 10)
+	done := make(chan error, 1)
+	go func() {
+		done <- FollowMessages(ctx, path, offset, 10*time.Millisecond, func(messages []Message, _ int64) {
+			received <- messages
+		})
+	}()
+
+	file, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	file.WriteString(tailTestLine(2) + tailTestLine(3))
+	file.Close()
+	select {
+	case messages := <-received:
+		if uuids(messages) != "m2,m3" {
+			t.Fatalf("followed %s, want m2,m3", uuids(messages))
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no messages followed")
+	}
+
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Fatalf("FollowMessages() = %v, want context.Canceled", err)
+	}
+}
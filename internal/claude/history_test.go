@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestExtractClaudeSessionInfoDoesNotReadPastPreviewWindowForTitle(t *testing.T) {
@@ -38,3 +39,38 @@ func TestGetProjectHashNormalizesWindowsPathsForClaudeProjectDirs(t *testing.T)
 		t.Fatalf("GetProjectHash() = %q, want %q", got, want)
 	}
 }
+
+func TestListProjectSessionSummariesCountsMessagesNewestFirst(t *testing.T) {
+	claudeDir := t.TempDir()
+	projectPath := "/src/demo"
+	projectDir := filepath.Join(claudeDir, "projects", GetProjectHash(projectPath))
+	if err := os.MkdirAll(projectDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	older := `{"type":"summary","summary":"Fix tests"}` + "\n" +
+		`{"timestamp":"2026-05-18T06:00:00Z","type":"user","message":{"role":"user","content":"fix the tests"}}` + "\n" +
+		`{"timestamp":"2026-05-18T06:00:05Z","type":"assistant","message":{"role":"assistant","content":[{"type":"text","text":"` + strings.Repeat("x", maxScanCapacity) + `"}]}}` + "\n" +
+		`{"timestamp":"2026-05-18T06:00:06Z","type":"assistant","isSidechain":true,"message":{"role":"assistant","content":"side"}}` + "\n"
+	newer := `{"timestamp":"2026-05-19T06:00:00Z","type":"user","message":{"role":"user","content":[{"type":"text","text":"add a flag"}]}}`
+	os.WriteFile(filepath.Join(projectDir, "older.jsonl"), []byte(older), 0644)
+	os.WriteFile(filepath.Join(projectDir, "newer.jsonl"), []byte(newer), 0644)
+	past := time.Now().Add(-time.Hour)
+	os.Chtimes(filepath.Join(projectDir, "older.jsonl"), past, past)
+
+	summaries, err := ListProjectSessionSummaries(claudeDir, projectPath)
+	if err != nil {
+		t.Fatalf("ListProjectSessionSummaries() error = %v", err)
+	}
+	if len(summaries) != 2 || summaries[0].ID != "newer" || summaries[1].ID != "older" {
+		t.Fatalf("summaries = %+v, want newer then older", summaries)
+	}
+	if summaries[0].MessageCount != 1 || summaries[0].FirstMessage != "add a flag" {
+		t.Errorf("newer = %+v", summaries[0])
+	}
+	if summaries[1].MessageCount != 2 || summaries[1].FirstMessage != "fix the tests" {
+		t.Errorf("older = %+v", summaries[1])
+	}
+	if summaries[1].UpdatedAt != past.Unix() {
+		t.Errorf("older updated at %d, want %d", summaries[1].UpdatedAt, past.Unix())
+	}
+}
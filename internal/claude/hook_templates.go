@@ -0,0 +1,219 @@
+package claude
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"time"
+
+	"ropcode/internal/command"
+)
+
+// HookTemplate is a ready-made hook users can add to their settings
+type HookTemplate struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Event       string   `json:"event"`
+	Matcher     string   `json:"matcher,omitempty"`
+	Commands    []string `json:"commands"`
+}
+
+// hookTemplates is the template library; hook commands get the event
+// payload as JSON on stdin and block tool calls by exiting with 2
+var hookTemplates = []HookTemplate{
+	{
+		ID:          "format-on-write",
+		Name:        "Auto-format Code",
+		Description: "Run code formatters after file modifications",
+		Event:       "PostToolUse",
+		Matcher:     "Write|Edit|MultiEdit",
+		Commands: []string{
+			`f="$(jq -r .tool_input.file_path)"; case "$f" in *.ts|*.tsx|*.js|*.jsx) prettier --write "$f" ;; esac`,
+			`f="$(jq -r .tool_input.file_path)"; case "$f" in *.go) gofmt -w "$f" ;; esac`,
+		},
+	},
+	{
+		ID:          "block-dangerous-bash",
+		Name:        "Block Dangerous Commands",
+		Description: "Stop rm -rf on / or ~, force pushes and disk formatting before they run",
+		Event:       "PreToolUse",
+		Matcher:     "Bash",
+		Commands: []string{
+			`cmd="$(jq -r .tool_input.command)"; if printf '%s' "$cmd" | grep -Eq 'rm -[a-zA-Z]*[rf][a-zA-Z]* +(/|~)( |$)|git push .*(-f|--force)|mkfs|dd if=.* of=/dev/'; then echo "Blocked dangerous command: $cmd" >&2; exit 2; fi`,
+		},
+	},
+	{
+		ID:          "log-bash-commands",
+		Name:        "Log Shell Commands",
+		Description: "Log all bash commands to a file for auditing",
+		Event:       "PreToolUse",
+		Matcher:     "Bash",
+		Commands:    []string{`jq -r '"\(.tool_input.command) - \(.tool_input.description // "No description")"' >> ~/.claude/bash-command-log.txt`},
+	},
+	{
+		ID:          "git-commit-guard",
+		Name:        "Protect Main Branch",
+		Description: "Prevent direct commits to main/master branch",
+		Event:       "PreToolUse",
+		Matcher:     "Bash",
+		Commands:    []string{`if jq -r .tool_input.command | grep -q "git commit" && git branch --show-current 2>/dev/null | grep -Eq '^(main|master)$'; then echo "Direct commits to main/master branch are not allowed" >&2; exit 2; fi`},
+	},
+	{
+		ID:          "custom-notification",
+		Name:        "Custom Notifications",
+		Description: "Send custom notifications when Claude needs attention",
+		Event:       "Notification",
+		Commands:    []string{`osascript -e "display notification \"$(jq -r .message)\" with title \"$(jq -r .title)\" sound name \"Glass\""`},
+	},
+	{
+		ID:          "continue-on-tests",
+		Name:        "Auto-continue on Test Success",
+		Description: "Automatically continue when tests pass",
+		Event:       "Stop",
+		Commands:    []string{`if grep -q "All tests passed" "$(jq -r .transcript_path)"; then echo '{"decision": "block", "reason": "All tests passed. Continue with next task."}'; fi`},
+	},
+}
+
+// HookTemplates returns the hook template library
+func HookTemplates() []HookTemplate {
+	return append([]HookTemplate(nil), hookTemplates...)
+}
+
+// InsertHookTemplate adds the hook template id to one scope and returns
+// the scope's hooks
+func InsertHookTemplate(claudeDir, scope, projectPath, id string) (*HooksConfig, error) {
+	var template *HookTemplate
+	for i := range hookTemplates {
+		if hookTemplates[i].ID == id {
+			template = &hookTemplates[i]
+		}
+	}
+	if template == nil {
+		return nil, fmt.Errorf("unknown hook template: %q", id)
+	}
+
+	hooks, err := GetScopedHooks(claudeDir, scope, projectPath)
+	if err != nil {
+		return nil, err
+	}
+	matcher := HookMatcher{Matcher: template.Matcher}
+	for _, c := range template.Commands {
+		matcher.Hooks = append(matcher.Hooks, Hook{Type: "command", Command: c})
+	}
+	matchers := hooks.Event(template.Event)
+	*matchers = append(*matchers, matcher)
+	if err := SaveScopedHooks(claudeDir, scope, projectPath, hooks); err != nil {
+		return nil, err
+	}
+	return hooks, nil
+}
+
+// SampleHookPayload returns a synthetic payload of a hook event, as the
+// CLI would pass it on stdin, for a call of toolName in cwd
+func SampleHookPayload(event, toolName, cwd string) string {
+	payload := map[string]interface{}{
+		"session_id":      "00000000-0000-0000-0000-000000000000",
+		"transcript_path": "/dev/null",
+		"cwd":             cwd,
+		"hook_event_name": event,
+	}
+	var input map[string]interface{}
+	switch toolName {
+	case "Bash":
+		input = map[string]interface{}{"command": "ls -la", "description": "List files"}
+	case "Write":
+		input = map[string]interface{}{"file_path": "example.txt", "content": "hello\n"}
+	case "Edit", "MultiEdit":
+		input = map[string]interface{}{"file_path": "example.txt", "old_string": "hello", "new_string": "hi"}
+	default:
+		input = map[string]interface{}{}
+	}
+	switch event {
+	case "PreToolUse", "PostToolUse":
+		payload["tool_name"] = toolName
+		payload["tool_input"] = input
+		if event == "PostToolUse" {
+			payload["tool_response"] = map[string]interface{}{"success": true}
+		}
+	case "Notification":
+		payload["message"] = "Claude needs your permission to use " + toolName
+		payload["title"] = "Claude Code"
+	case "Stop", "SubagentStop":
+		payload["stop_hook_active"] = false
+	}
+	data, _ := json.MarshalIndent(payload, "", "  ")
+	return string(data)
+}
+
+// defaultHookTimeout is the CLI's timeout for hooks without one
+const defaultHookTimeout = 60 * time.Second
+
+// HookTestResult is the outcome of running a hook on a sample payload
+type HookTestResult struct {
+	Stdout     string `json:"stdout"`
+	Stderr     string `json:"stderr"`
+	ExitCode   int    `json:"exit_code"` // -1 when the hook didn't exit by itself
+	DurationMS int64  `json:"duration_ms"`
+	TimedOut   bool   `json:"timed_out"`
+	Blocked    bool   `json:"blocked"` // exit code 2, which blocks the tool call
+}
+
+// RunHookTest runs hook with payload on stdin the way the CLI runs hooks:
+// in the payload's cwd, with CLAUDE_PROJECT_DIR set and the hook's timeout
+func RunHookTest(ctx context.Context, hook Hook, payload string) (*HookTestResult, error) {
+	var fields struct {
+		Cwd string `json:"cwd"`
+	}
+	if err := json.Unmarshal([]byte(payload), &fields); err != nil {
+		return nil, fmt.Errorf("invalid hook payload: %w", err)
+	}
+	line := hook.Command
+	if line == "" {
+		line = hook.Script
+	}
+	if line == "" {
+		return nil, errors.New("hook has no command")
+	}
+	timeout := defaultHookTimeout
+	if hook.Timeout > 0 {
+		timeout = time.Duration(hook.Timeout) * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	cmd := command.Shell(ctx, line)
+	cmd.Dir = fields.Cwd
+	cmd.Env = append(os.Environ(), "CLAUDE_PROJECT_DIR="+fields.Cwd)
+	cmd.Stdin = bytes.NewReader([]byte(payload))
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	// Don't wait on background processes the hook left holding the pipes
+	cmd.WaitDelay = time.Second
+
+	start := time.Now()
+	err := cmd.Run()
+	result := &HookTestResult{
+		Stdout:     stdout.String(),
+		Stderr:     stderr.String(),
+		DurationMS: time.Since(start).Milliseconds(),
+		TimedOut:   errors.Is(ctx.Err(), context.DeadlineExceeded),
+	}
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+	case errors.As(err, &exitErr):
+		result.ExitCode = exitErr.ExitCode()
+	case result.TimedOut:
+		result.ExitCode = -1
+	default:
+		return nil, fmt.Errorf("failed to run hook: %w", err)
+	}
+	result.Blocked = result.ExitCode == 2
+	return result, nil
+}
@@ -0,0 +1,107 @@
+package claude
+
+import (
+	"context"
+	"encoding/json"
+	"os/exec"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestInsertHookTemplate(t *testing.T) {
+	claudeDir, projectPath := t.TempDir(), t.TempDir()
+
+	hooks, err := InsertHookTemplate(claudeDir, HookScopeLocal, projectPath, "block-dangerous-bash")
+	if err != nil {
+		t.Fatalf("InsertHookTemplate failed: %v", err)
+	}
+	if len(hooks.PreToolUse) != 1 || hooks.PreToolUse[0].Matcher != "Bash" || hooks.PreToolUse[0].Hooks[0].Type != "command" {
+		t.Errorf("Unexpected hooks: %+v", hooks)
+	}
+	if _, err := InsertHookTemplate(claudeDir, HookScopeLocal, projectPath, "format-on-write"); err != nil {
+		t.Fatalf("InsertHookTemplate failed: %v", err)
+	}
+	saved, _ := GetScopedHooks(claudeDir, HookScopeLocal, projectPath)
+	if len(saved.PreToolUse) != 1 || len(saved.PostToolUse) != 1 || len(saved.PostToolUse[0].Hooks) != 2 {
+		t.Errorf("Unexpected saved hooks: %+v", saved)
+	}
+
+	if _, err := InsertHookTemplate(claudeDir, HookScopeLocal, projectPath, "nope"); err == nil {
+		t.Error("Expected an error for an unknown template")
+	}
+	for _, template := range HookTemplates() {
+		if (&HooksConfig{}).Event(template.Event) == nil {
+			t.Errorf("Template %s has unknown event %s", template.ID, template.Event)
+		}
+	}
+}
+
+func TestSampleHookPayload(t *testing.T) {
+	var payload map[string]interface{}
+	if err := json.Unmarshal([]byte(SampleHookPayload("PreToolUse", "Bash", "/work")), &payload); err != nil {
+		t.Fatalf("Invalid payload: %v", err)
+	}
+	input, _ := payload["tool_input"].(map[string]interface{})
+	if payload["cwd"] != "/work" || payload["tool_name"] != "Bash" || input["command"] == nil {
+		t.Errorf("Unexpected payload: %v", payload)
+	}
+}
+
+func TestRunHookTest(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hook commands use sh")
+	}
+	ctx := context.Background()
+	cwd := t.TempDir()
+	payload := SampleHookPayload("PreToolUse", "Bash", cwd)
+
+	result, err := RunHookTest(ctx, Hook{Type: "command", Command: `cat; pwd; echo "$CLAUDE_PROJECT_DIR" >&2`}, payload)
+	if err != nil {
+		t.Fatalf("RunHookTest failed: %v", err)
+	}
+	if !strings.HasPrefix(result.Stdout, payload) || !strings.Contains(result.Stdout, cwd) || strings.TrimSpace(result.Stderr) != cwd || result.ExitCode != 0 {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+
+	result, err = RunHookTest(ctx, Hook{Type: "command", Command: "echo no >&2; exit 2"}, payload)
+	if err != nil || !result.Blocked || result.ExitCode != 2 || result.Stderr != "no\n" {
+		t.Errorf("Unexpected blocking result: %+v, %v", result, err)
+	}
+
+	result, err = RunHookTest(ctx, Hook{Type: "command", Command: "sleep 5", Timeout: 1}, payload)
+	if err != nil || !result.TimedOut || result.ExitCode != -1 {
+		t.Errorf("Unexpected timeout result: %+v, %v", result, err)
+	}
+
+	if _, err := RunHookTest(ctx, Hook{Type: "command", Command: "true"}, "not json"); err == nil {
+		t.Error("Expected an error for an invalid payload")
+	}
+}
+
+func TestBlockDangerousBashTemplate(t *testing.T) {
+	if _, err := exec.LookPath("jq"); err != nil || runtime.GOOS == "windows" {
+		t.Skip("needs sh and jq")
+	}
+	var hook Hook
+	for _, template := range HookTemplates() {
+		if template.ID == "block-dangerous-bash" {
+			hook = Hook{Type: "command", Command: template.Commands[0]}
+		}
+	}
+	tests := map[string]bool{
+		"rm -rf /":                  true,
+		"rm -rf ~":                  true,
+		"git push --force origin x": true,
+		"rm -rf ./build":            false,
+		"git push origin main":      false,
+		"ls -la":                    false,
+	}
+	for bash, blocked := range tests {
+		payload, _ := json.Marshal(map[string]interface{}{"cwd": t.TempDir(), "tool_name": "Bash", "tool_input": map[string]string{"command": bash}})
+		result, err := RunHookTest(context.Background(), hook, string(payload))
+		if err != nil || result.Blocked != blocked {
+			t.Errorf("%q: result = %+v, %v; want blocked %v", bash, result, err, blocked)
+		}
+	}
+}
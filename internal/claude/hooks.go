@@ -2,6 +2,7 @@ package claude
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
 )
@@ -11,6 +12,7 @@ type Hook struct {
 	Type    string `json:"type"`              // "command" or "script"
 	Command string `json:"command,omitempty"` // Command to execute
 	Script  string `json:"script,omitempty"`  // Script path to execute
+	Timeout int    `json:"timeout,omitempty"` // Timeout in seconds, 0 for the CLI default
 }
 
 // HookMatcher represents a hook matcher with its associated hooks
@@ -25,12 +27,102 @@ type HooksConfig struct {
 	PostToolUse  []HookMatcher `json:"PostToolUse,omitempty"`
 	Notification []HookMatcher `json:"Notification,omitempty"`
 	Stop         []HookMatcher `json:"Stop,omitempty"`
+	SubagentStop []HookMatcher `json:"SubagentStop,omitempty"`
+}
+
+// Hook scopes, each stored in its own settings file
+const (
+	HookScopeUser    = "user"    // ~/.claude/settings.json
+	HookScopeProject = "project" // <project>/.claude/settings.json
+	HookScopeLocal   = "local"   // <project>/.claude/settings.local.json, not committed
+)
+
+// HooksSettingsPath returns the settings file holding the hooks of a scope
+func HooksSettingsPath(claudeDir, scope, projectPath string) (string, error) {
+	switch scope {
+	case HookScopeUser:
+		return filepath.Join(claudeDir, "settings.json"), nil
+	case HookScopeProject, HookScopeLocal:
+		if projectPath == "" {
+			return "", fmt.Errorf("%s hooks need a project path", scope)
+		}
+		if scope == HookScopeLocal {
+			return filepath.Join(projectPath, ".claude", "settings.local.json"), nil
+		}
+		return filepath.Join(projectPath, ".claude", "settings.json"), nil
+	default:
+		return "", fmt.Errorf("unknown hook scope: %q", scope)
+	}
 }
 
 // GetHooks loads all hooks from ~/.claude/settings.json
 func GetHooks(claudeDir string) (*HooksConfig, error) {
-	settingsPath := filepath.Join(claudeDir, "settings.json")
+	return loadHooks(filepath.Join(claudeDir, "settings.json"))
+}
 
+// SaveHooks saves the hooks configuration to ~/.claude/settings.json
+func SaveHooks(claudeDir string, hooks *HooksConfig) error {
+	return saveHooks(filepath.Join(claudeDir, "settings.json"), hooks)
+}
+
+// GetScopedHooks loads the hooks of one scope
+func GetScopedHooks(claudeDir, scope, projectPath string) (*HooksConfig, error) {
+	path, err := HooksSettingsPath(claudeDir, scope, projectPath)
+	if err != nil {
+		return nil, err
+	}
+	return loadHooks(path)
+}
+
+// SaveScopedHooks replaces the hooks of one scope
+func SaveScopedHooks(claudeDir, scope, projectPath string, hooks *HooksConfig) error {
+	path, err := HooksSettingsPath(claudeDir, scope, projectPath)
+	if err != nil {
+		return err
+	}
+	return saveHooks(path, hooks)
+}
+
+// DeleteHookMatcher removes the matchers of hookType whose pattern is
+// matcher from one scope. It edits the settings as JSON, so hook events
+// and fields HooksConfig doesn't know about are kept.
+func DeleteHookMatcher(claudeDir, scope, projectPath, hookType, matcher string) error {
+	path, err := HooksSettingsPath(claudeDir, scope, projectPath)
+	if err != nil {
+		return err
+	}
+	settings, err := LoadSettings(path)
+	if err != nil {
+		return err
+	}
+	hooks, _ := settings["hooks"].(map[string]interface{})
+	matchers, _ := hooks[hookType].([]interface{})
+
+	kept := make([]interface{}, 0, len(matchers))
+	for _, m := range matchers {
+		entry, _ := m.(map[string]interface{})
+		pattern, _ := entry["matcher"].(string)
+		if entry != nil && pattern == matcher {
+			continue
+		}
+		kept = append(kept, m)
+	}
+	if len(kept) == len(matchers) {
+		return fmt.Errorf("no %s hook with matcher %q in %s settings", hookType, matcher, scope)
+	}
+
+	if len(kept) > 0 {
+		hooks[hookType] = kept
+	} else {
+		delete(hooks, hookType)
+	}
+	if len(hooks) == 0 {
+		delete(settings, "hooks")
+	}
+	return SaveSettings(path, settings)
+}
+
+func loadHooks(settingsPath string) (*HooksConfig, error) {
 	// Load settings
 	settings, err := LoadSettings(settingsPath)
 	if err != nil {
@@ -58,23 +150,43 @@ func GetHooks(claudeDir string) (*HooksConfig, error) {
 	return &hooks, nil
 }
 
-// SaveHooks saves the hooks configuration to ~/.claude/settings.json
-func SaveHooks(claudeDir string, hooks *HooksConfig) error {
-	settingsPath := filepath.Join(claudeDir, "settings.json")
-
+// saveHooks writes hooks into a settings file, keeping its other settings
+// and the hook events HooksConfig doesn't cover
+func saveHooks(settingsPath string, hooks *HooksConfig) error {
 	// Load existing settings
 	settings, err := LoadSettings(settingsPath)
 	if err != nil {
 		return err
 	}
 
+	hooksJSON, err := json.Marshal(hooks)
+	if err != nil {
+		return err
+	}
+	updated := map[string]interface{}{}
+	if err := json.Unmarshal(hooksJSON, &updated); err != nil {
+		return err
+	}
+	if existing, ok := settings["hooks"].(map[string]interface{}); ok {
+		for event, matchers := range existing {
+			if !knownHookEvents[event] {
+				updated[event] = matchers
+			}
+		}
+	}
+
 	// Update hooks field
-	settings["hooks"] = hooks
+	settings["hooks"] = updated
 
 	// Save settings back
 	return SaveSettings(settingsPath, settings)
 }
 
+// knownHookEvents are the events HooksConfig holds
+var knownHookEvents = map[string]bool{
+	"PreToolUse": true, "PostToolUse": true, "Notification": true, "Stop": true, "SubagentStop": true,
+}
+
 // GetHooksByType retrieves hooks for a specific type
 func GetHooksByType(claudeDir string, hookType string) ([]HookMatcher, error) {
 	hooks, err := GetHooks(claudeDir)
@@ -82,17 +194,27 @@ func GetHooksByType(claudeDir string, hookType string) ([]HookMatcher, error) {
 		return nil, err
 	}
 
+	if matchers := hooks.Event(hookType); matchers != nil {
+		return *matchers, nil
+	}
+	return []HookMatcher{}, nil
+}
+
+// Event returns the matchers of a hook event, nil for unknown events
+func (h *HooksConfig) Event(hookType string) *[]HookMatcher {
 	switch hookType {
 	case "PreToolUse":
-		return hooks.PreToolUse, nil
+		return &h.PreToolUse
 	case "PostToolUse":
-		return hooks.PostToolUse, nil
+		return &h.PostToolUse
 	case "Notification":
-		return hooks.Notification, nil
+		return &h.Notification
 	case "Stop":
-		return hooks.Stop, nil
+		return &h.Stop
+	case "SubagentStop":
+		return &h.SubagentStop
 	default:
-		return []HookMatcher{}, nil
+		return nil
 	}
 }
 
@@ -303,3 +303,63 @@ func TestGetHooksByType(t *testing.T) {
 		t.Errorf("Expected 0 hooks for invalid type, got %d", len(hooks))
 	}
 }
+
+func TestScopedHooks(t *testing.T) {
+	claudeDir := t.TempDir()
+	projectPath := t.TempDir()
+
+	// A project file with an event HooksConfig doesn't model
+	projectSettings := filepath.Join(projectPath, ".claude", "settings.json")
+	os.MkdirAll(filepath.Dir(projectSettings), 0755)
+	os.WriteFile(projectSettings, []byte(`{"hooks":{"SessionStart":[{"hooks":[{"type":"command","command":"hello"}]}]},"model":"opus"}`), 0644)
+
+	hooks := &HooksConfig{
+		PreToolUse: []HookMatcher{
+			{Matcher: "Bash", Hooks: []Hook{{Type: "command", Command: "check-bash", Timeout: 5}}},
+			{Matcher: "Edit", Hooks: []Hook{{Type: "command", Command: "check-edit"}}},
+		},
+	}
+	if err := SaveScopedHooks(claudeDir, HookScopeProject, projectPath, hooks); err != nil {
+		t.Fatalf("SaveScopedHooks(project) failed: %v", err)
+	}
+	if err := SaveScopedHooks(claudeDir, HookScopeLocal, projectPath, &HooksConfig{Stop: []HookMatcher{{Hooks: []Hook{{Type: "command", Command: "bye"}}}}}); err != nil {
+		t.Fatalf("SaveScopedHooks(local) failed: %v", err)
+	}
+
+	saved, err := GetScopedHooks(claudeDir, HookScopeProject, projectPath)
+	if err != nil {
+		t.Fatalf("GetScopedHooks(project) failed: %v", err)
+	}
+	if len(saved.PreToolUse) != 2 || saved.PreToolUse[0].Hooks[0].Timeout != 5 || len(saved.Stop) != 0 {
+		t.Errorf("Unexpected project hooks: %+v", saved)
+	}
+	local, err := GetScopedHooks(claudeDir, HookScopeLocal, projectPath)
+	if err != nil || len(local.Stop) != 1 {
+		t.Errorf("Unexpected local hooks: %+v, %v", local, err)
+	}
+	if user, _ := GetScopedHooks(claudeDir, HookScopeUser, ""); len(user.PreToolUse) != 0 {
+		t.Errorf("Project hooks leaked into user scope: %+v", user)
+	}
+
+	if err := DeleteHookMatcher(claudeDir, HookScopeProject, projectPath, "PreToolUse", "Bash"); err != nil {
+		t.Fatalf("DeleteHookMatcher failed: %v", err)
+	}
+	if err := DeleteHookMatcher(claudeDir, HookScopeProject, projectPath, "PreToolUse", "Bash"); err == nil {
+		t.Error("Expected an error deleting a missing matcher")
+	}
+	settings, _ := LoadSettings(projectSettings)
+	events := settings["hooks"].(map[string]interface{})
+	if _, ok := events["SessionStart"]; !ok || settings["model"] != "opus" {
+		t.Errorf("Expected other settings and events to be kept, got %v", settings)
+	}
+	if matchers := events["PreToolUse"].([]interface{}); len(matchers) != 1 {
+		t.Errorf("Expected 1 PreToolUse matcher left, got %v", matchers)
+	}
+
+	if _, err := GetScopedHooks(claudeDir, HookScopeProject, ""); err == nil {
+		t.Error("Expected an error for project scope without a project path")
+	}
+	if _, err := GetScopedHooks(claudeDir, "team", projectPath); err == nil {
+		t.Error("Expected an error for an unknown scope")
+	}
+}
@@ -10,6 +10,8 @@ import (
 	"path/filepath"
 	"sync"
 	"time"
+
+	"ropcode/internal/clicompat"
 )
 
 func discoverClaudeBinaryPath() (string, error) {
@@ -77,6 +79,21 @@ func (m *SessionManager) GetBinaryPath() string {
 	return m.binaryPath
 }
 
+// ResolveBinaryPath returns the binary path, discovering and remembering
+// it when none is set
+func (m *SessionManager) ResolveBinaryPath() (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.binaryPath == "" {
+		path, err := m.discoverBinary()
+		if err != nil {
+			return "", fmt.Errorf("claude binary not configured: %w", err)
+		}
+		m.binaryPath = path
+	}
+	return m.binaryPath, nil
+}
+
 // SetProcessEmitter sets the process changed emitter
 func (m *SessionManager) SetProcessEmitter(emitter ProcessChangedEmitter) {
 	m.mu.Lock()
@@ -100,14 +117,19 @@ func (m *SessionManager) StartSession(config SessionConfig) (string, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	// Check if binary path is set
-	if m.binaryPath == "" {
+	// Check if binary path is set; remote sessions use the host's binary
+	if m.binaryPath == "" && config.Remote == nil {
 		path, err := m.discoverBinary()
 		if err != nil {
 			return "", fmt.Errorf("claude binary not configured: %w", err)
 		}
 		m.binaryPath = path
 	}
+	if config.Remote == nil {
+		if err := clicompat.Require("claude", m.binaryPath, cliFeatures(buildClaudeArgs(config))...); err != nil {
+			return "", err
+		}
+	}
 
 	// Check if there's already a running session for this project
 	if config.ProjectPath != "" {
@@ -233,6 +255,28 @@ func (m *SessionManager) GetSessionOutput(sessionID string) (string, error) {
 	return session.GetOutput(), nil
 }
 
+// WaitForSession blocks until the session's process exits or ctx is done and
+// returns the final session status ("completed", "failed" or "cancelled").
+func (m *SessionManager) WaitForSession(ctx context.Context, sessionID string) (string, error) {
+	m.mu.RLock()
+	session, exists := m.sessions[sessionID]
+	m.mu.RUnlock()
+
+	if !exists {
+		return "", fmt.Errorf("session not found: %s", sessionID)
+	}
+
+	select {
+	case <-session.done:
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+
+	session.mu.RLock()
+	defer session.mu.RUnlock()
+	return session.Status, nil
+}
+
 // ListRunningSessions returns a list of all running sessions
 func (m *SessionManager) ListRunningSessions() []*SessionStatus {
 	m.mu.RLock()
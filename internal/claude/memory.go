@@ -0,0 +1,220 @@
+package claude
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// Memory scopes, in the order Claude loads them
+const (
+	MemoryScopeManaged = "managed" // organization policy, set by administrators
+	MemoryScopeUser    = "user"    // ~/.claude/CLAUDE.md, for every project
+	MemoryScopeProject = "project" // CLAUDE.md of the project and its parents
+	MemoryScopeLocal   = "local"   // CLAUDE.local.md, not checked in
+	MemoryScopeNested  = "nested"  // CLAUDE.md below the project, read on demand
+)
+
+// maxMemoryImportDepth is how many @import hops Claude follows
+const maxMemoryImportDepth = 5
+
+// maxNestedMemoryFiles caps the nested CLAUDE.md files looked for
+const maxNestedMemoryFiles = 100
+
+// managedMemoryPath is where administrators put the policy CLAUDE.md
+var managedMemoryPath = func() string {
+	switch runtime.GOOS {
+	case "darwin":
+		return "/Library/Application Support/ClaudeCode/CLAUDE.md"
+	case "windows":
+		return `C:\ProgramData\ClaudeCode\CLAUDE.md`
+	default:
+		return "/etc/claude-code/CLAUDE.md"
+	}
+}()
+
+// nestedMemorySkipDirs are not searched for nested CLAUDE.md files
+var nestedMemorySkipDirs = map[string]bool{
+	"node_modules": true, "vendor": true, "dist": true, "build": true, "target": true,
+	"__pycache__": true, "venv": true,
+}
+
+// memoryImportPattern finds @path imports: ~/, / and ./ paths or plain
+// relative paths with a file extension
+var memoryImportPattern = regexp.MustCompile(`(?:^|\s)@((?:~/|/|\.{1,2}/)[^\s]+|[\w.-]+(?:/[\w.-]+)*\.\w+)`)
+
+// MemorySection is one memory file as it contributes to the effective memory
+type MemorySection struct {
+	Scope string `json:"scope"`
+	Path  string `json:"path"`
+	// ImportedFrom is the file whose @import pulled this one in
+	ImportedFrom string `json:"imported_from,omitempty"`
+	Content      string `json:"content"`
+	// OnDemand files are only read once Claude works in their directory
+	OnDemand bool `json:"on_demand,omitempty"`
+}
+
+// EffectiveMemory is the memory Claude sees in a project
+type EffectiveMemory struct {
+	Sections []MemorySection `json:"sections"`
+	// Content joins the sections in order, each under a header naming its source
+	Content string `json:"content"`
+}
+
+// ResolveMemory collects the memory files Claude reads for projectPath in
+// its resolution order: the managed policy, the user's, those of the
+// project's parents from the top down, the project's own and finally the
+// nested ones below it. Imports follow the file that imports them.
+func ResolveMemory(claudeDir, projectPath string) (*EffectiveMemory, error) {
+	projectPath, err := filepath.Abs(projectPath)
+	if err != nil {
+		return nil, err
+	}
+	r := &memoryResolver{seen: make(map[string]bool)}
+	r.add(managedMemoryPath, MemoryScopeManaged, "", false, 0)
+	r.add(filepath.Join(claudeDir, "CLAUDE.md"), MemoryScopeUser, "", false, 0)
+
+	var dirs []string
+	for dir := projectPath; ; dir = filepath.Dir(dir) {
+		dirs = append(dirs, dir)
+		if filepath.Dir(dir) == dir {
+			break
+		}
+	}
+	for i := len(dirs) - 1; i >= 0; i-- {
+		r.add(filepath.Join(dirs[i], "CLAUDE.md"), MemoryScopeProject, "", false, 0)
+		r.add(filepath.Join(dirs[i], ".claude", "CLAUDE.md"), MemoryScopeProject, "", false, 0)
+		r.add(filepath.Join(dirs[i], "CLAUDE.local.md"), MemoryScopeLocal, "", false, 0)
+	}
+
+	for _, path := range nestedMemoryFiles(projectPath) {
+		r.add(path, MemoryScopeNested, "", true, 0)
+	}
+
+	memory := &EffectiveMemory{Sections: r.sections}
+	if memory.Sections == nil {
+		memory.Sections = []MemorySection{}
+	}
+	var b strings.Builder
+	for _, section := range memory.Sections {
+		if b.Len() > 0 {
+			b.WriteString("\n\n")
+		}
+		fmt.Fprintf(&b, "Contents of %s (%s):\n\n%s", section.Path, memoryScopeLabel(section), strings.TrimRight(section.Content, "\n"))
+	}
+	memory.Content = b.String()
+	return memory, nil
+}
+
+type memoryResolver struct {
+	sections []MemorySection
+	seen     map[string]bool
+}
+
+// add appends the file at path, if there is one, followed by its imports
+func (r *memoryResolver) add(path, scope, importedFrom string, onDemand bool, depth int) {
+	path = filepath.Clean(path)
+	if r.seen[path] {
+		return
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+	r.seen[path] = true
+	content := string(data)
+	r.sections = append(r.sections, MemorySection{
+		Scope:        scope,
+		Path:         path,
+		ImportedFrom: importedFrom,
+		Content:      content,
+		OnDemand:     onDemand,
+	})
+	if depth >= maxMemoryImportDepth {
+		return
+	}
+	for _, imported := range memoryImports(content) {
+		r.add(resolveMemoryImport(path, imported), scope, path, onDemand, depth+1)
+	}
+}
+
+// memoryImports returns the @import paths of a memory file, skipping code
+// blocks and code spans
+func memoryImports(content string) []string {
+	var imports []string
+	inFence := false
+	for _, line := range strings.Split(content, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			continue
+		}
+		parts := strings.Split(line, "`")
+		for i := 0; i < len(parts); i += 2 {
+			for _, match := range memoryImportPattern.FindAllStringSubmatch(parts[i], -1) {
+				imports = append(imports, strings.TrimRight(match[1], ".,;:)"))
+			}
+		}
+	}
+	return imports
+}
+
+// resolveMemoryImport resolves an import of the file at from
+func resolveMemoryImport(from, imported string) string {
+	if rest, ok := strings.CutPrefix(imported, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, filepath.FromSlash(rest))
+		}
+	}
+	if filepath.IsAbs(imported) {
+		return imported
+	}
+	return filepath.Join(filepath.Dir(from), filepath.FromSlash(imported))
+}
+
+// nestedMemoryFiles finds the CLAUDE.md files in subdirectories of root,
+// skipping hidden and dependency directories
+func nestedMemoryFiles(root string) []string {
+	var files []string
+	filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if path != root && (strings.HasPrefix(d.Name(), ".") || nestedMemorySkipDirs[d.Name()]) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.Name() == "CLAUDE.md" && filepath.Dir(path) != root {
+			files = append(files, path)
+			if len(files) == maxNestedMemoryFiles {
+				return filepath.SkipAll
+			}
+		}
+		return nil
+	})
+	sort.Strings(files)
+	return files
+}
+
+func memoryScopeLabel(section MemorySection) string {
+	label := map[string]string{
+		MemoryScopeManaged: "managed policy instructions",
+		MemoryScopeUser:    "user's private global instructions for all projects",
+		MemoryScopeProject: "project instructions, checked into the codebase",
+		MemoryScopeLocal:   "user's private project instructions, not checked in",
+		MemoryScopeNested:  "instructions for a subdirectory, read when working there",
+	}[section.Scope]
+	if section.ImportedFrom != "" {
+		label += ", imported by " + section.ImportedFrom
+	}
+	return label
+}
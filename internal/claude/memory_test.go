@@ -0,0 +1,65 @@
+package claude
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeMemoryFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestResolveMemoryFollowsClaudeOrder(t *testing.T) {
+	root := t.TempDir()
+	managed := filepath.Join(root, "etc", "CLAUDE.md")
+	oldManaged := managedMemoryPath
+	managedMemoryPath = managed
+	t.Cleanup(func() { managedMemoryPath = oldManaged })
+
+	claudeDir := filepath.Join(root, "home", ".claude")
+	workspace := filepath.Join(root, "work")
+	project := filepath.Join(workspace, "shop")
+	writeMemoryFile(t, managed, "policy")
+	writeMemoryFile(t, filepath.Join(claudeDir, "CLAUDE.md"), "user")
+	writeMemoryFile(t, filepath.Join(workspace, "CLAUDE.md"), "parent")
+	writeMemoryFile(t, filepath.Join(project, "CLAUDE.md"), "project, see @docs/style.md and `@ignored.md`\n```\n@also-ignored.md\n```\n")
+	writeMemoryFile(t, filepath.Join(project, "docs", "style.md"), "style, back to @../CLAUDE.md")
+	writeMemoryFile(t, filepath.Join(project, ".claude", "CLAUDE.md"), "dot claude")
+	writeMemoryFile(t, filepath.Join(project, "CLAUDE.local.md"), "local")
+	writeMemoryFile(t, filepath.Join(project, "web", "CLAUDE.md"), "web")
+	writeMemoryFile(t, filepath.Join(project, "node_modules", "x", "CLAUDE.md"), "dependency")
+	writeMemoryFile(t, filepath.Join(project, "ignored.md"), "ignored")
+
+	memory, err := ResolveMemory(claudeDir, project)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, section := range memory.Sections {
+		got = append(got, section.Scope+":"+section.Content[:strings.IndexAny(section.Content+",", ",")])
+	}
+	want := []string{
+		"managed:policy", "user:user", "project:parent", "project:project",
+		"project:style", "project:dot claude", "local:local", "nested:web",
+	}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Fatalf("sections = %q", got)
+	}
+	if style := memory.Sections[4]; style.ImportedFrom != filepath.Join(project, "CLAUDE.md") {
+		t.Fatalf("style imported from %q", style.ImportedFrom)
+	}
+	if web := memory.Sections[7]; !web.OnDemand {
+		t.Fatal("nested memory not marked on demand")
+	}
+	if !strings.HasPrefix(memory.Content, "Contents of "+managed+" (managed policy instructions):\n\npolicy\n\nContents of") {
+		t.Fatalf("content = %q", memory.Content)
+	}
+}
@@ -8,13 +8,16 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"log/slog"
 	"os"
 	"os/exec"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/google/uuid"
+	"ropcode/internal/loginenv"
 	"ropcode/internal/sessionproc"
 )
 
@@ -40,6 +43,27 @@ type SessionConfig struct {
 	// API configuration from ProviderApiConfig
 	BaseURL   string `json:"base_url,omitempty"`
 	AuthToken string `json:"auth_token,omitempty"`
+	// McpConfig is an --mcp-config JSON document with MCP servers added for
+	// this session only
+	McpConfig string `json:"mcp_config,omitempty"`
+	// DisallowedTools are removed from the model's context, e.g. "mcp__github"
+	// hides every tool of that MCP server
+	DisallowedTools []string `json:"disallowed_tools,omitempty"`
+	// AppendSystemPrompt is added to Claude's default system prompt
+	AppendSystemPrompt string `json:"append_system_prompt,omitempty"`
+	// PermissionMode is the --permission-mode of the session; empty or
+	// "bypassPermissions" skips permission checks
+	PermissionMode string `json:"permission_mode,omitempty"`
+	// AllowedTools are allowed without asking, e.g. "Read" or "Bash(git:*)"
+	AllowedTools []string `json:"allowed_tools,omitempty"`
+	// ExtraArgs are appended to the CLI arguments as is, for CLI options
+	// ropcode has no setting for
+	ExtraArgs []string `json:"extra_args,omitempty"`
+	// Remote runs the CLI on an SSH host for remote projects
+	Remote *sessionproc.Remote `json:"-"`
+	// Env holds the project's environment variables; the API configuration
+	// above takes precedence over them
+	Env map[string]string `json:"-"`
 }
 
 // ToolProgress holds progress info for an active tool call.
@@ -191,35 +215,55 @@ func (s *Session) Start(ctx context.Context, binaryPath string, emitter EventEmi
 		args,
 	)
 
-	// Create command - use project path as working directory (NOT as --project-path arg)
-	s.cmd = exec.CommandContext(ctx, binaryPath, args...)
-	if err := sessionproc.Configure(s.cmd); err != nil {
-		return fmt.Errorf("failed to configure command: %w", err)
-	}
-
-	// Set working directory to project path
-	if s.Config.ProjectPath != "" {
-		s.cmd.Dir = s.Config.ProjectPath
-	}
-
-	// Inherit current environment and ensure full shell PATH
-	s.cmd.Env = os.Environ()
-	s.cmd.Env = ensureFullShellPath(s.cmd.Env)
-
 	// Add custom API configuration via environment variables
 	// This is the recommended approach and avoids FSWatcher issues with --settings
+	var sessionEnv []string
+	envNames := make([]string, 0, len(s.Config.Env))
+	for name := range s.Config.Env {
+		envNames = append(envNames, name)
+	}
+	sort.Strings(envNames)
+	for _, name := range envNames {
+		sessionEnv = append(sessionEnv, name+"="+s.Config.Env[name])
+	}
 	if s.Config.BaseURL != "" {
 		log.Printf("[Session] Using custom base URL via env var: %s", s.Config.BaseURL)
-		s.cmd.Env = append(s.cmd.Env, fmt.Sprintf("ANTHROPIC_BASE_URL=%s", s.Config.BaseURL))
+		sessionEnv = append(sessionEnv, fmt.Sprintf("ANTHROPIC_BASE_URL=%s", s.Config.BaseURL))
 	}
 	if s.Config.AuthToken != "" {
 		log.Printf("[Session] Using custom auth token via env var")
-		s.cmd.Env = append(s.cmd.Env, fmt.Sprintf("ANTHROPIC_AUTH_TOKEN=%s", s.Config.AuthToken))
+		sessionEnv = append(sessionEnv, fmt.Sprintf("ANTHROPIC_AUTH_TOKEN=%s", s.Config.AuthToken))
 	}
 
 	// Disable non-essential network traffic (telemetry, update checks, etc.)
 	// This ensures Claude Code runs in a more controlled/private mode
-	s.cmd.Env = append(s.cmd.Env, "CLAUDE_CODE_DISABLE_NONESSENTIAL_TRAFFIC=true")
+	sessionEnv = append(sessionEnv, "CLAUDE_CODE_DISABLE_NONESSENTIAL_TRAFFIC=true")
+
+	if remote := s.Config.Remote; remote != nil {
+		// Remote projects run the CLI where the files live; ssh carries the
+		// JSONL stream both ways
+		log.Printf("[Session] Running Claude remotely: dir=%q binary=%q", remote.Dir, remote.BinaryOr("claude"))
+		cmd, err := remote.Command(ctx, remote.Dir, append([]string{remote.BinaryOr("claude")}, args...), sessionEnv)
+		if err != nil {
+			return fmt.Errorf("failed to prepare remote command: %w", err)
+		}
+		s.cmd = cmd
+	} else {
+		// Create command - use project path as working directory (NOT as --project-path arg)
+		s.cmd = exec.CommandContext(ctx, binaryPath, args...)
+
+		// Set working directory to project path
+		if s.Config.ProjectPath != "" {
+			s.cmd.Dir = s.Config.ProjectPath
+		}
+
+		// Inherit current environment with the login shell's added
+		s.cmd.Env = loginenv.Environ()
+		s.cmd.Env = append(s.cmd.Env, sessionEnv...)
+	}
+	if err := sessionproc.Configure(s.cmd); err != nil {
+		return fmt.Errorf("failed to configure command: %w", err)
+	}
 
 	// Setup pipes
 	var err error
@@ -353,8 +397,27 @@ func buildClaudeArgs(config SessionConfig) []string {
 	// Add verbose flag
 	args = append(args, "--verbose")
 
-	// Skip permission checks for automated execution
-	args = append(args, "--dangerously-skip-permissions")
+	// Skip permission checks for automated execution unless the session
+	// has a permission mode of its own
+	if config.PermissionMode == "" || config.PermissionMode == "bypassPermissions" {
+		args = append(args, "--dangerously-skip-permissions")
+	} else {
+		args = append(args, "--permission-mode", config.PermissionMode)
+	}
+	if len(config.AllowedTools) > 0 {
+		args = append(args, "--allowed-tools", strings.Join(config.AllowedTools, ","))
+	}
+
+	// Per-project MCP server selection
+	if config.McpConfig != "" {
+		args = append(args, "--mcp-config", config.McpConfig)
+	}
+	if len(config.DisallowedTools) > 0 {
+		args = append(args, "--disallowed-tools", strings.Join(config.DisallowedTools, ","))
+	}
+	if config.AppendSystemPrompt != "" {
+		args = append(args, "--append-system-prompt", config.AppendSystemPrompt)
+	}
 
 	// Add ~/.claude/ to allowed directories for file access
 	homeDir, err := os.UserHomeDir()
@@ -365,7 +428,23 @@ func buildClaudeArgs(config SessionConfig) []string {
 		}
 	}
 
-	return args
+	return append(args, config.ExtraArgs...)
+}
+
+// cliFeatures names the clicompat features buildClaudeArgs relies on
+func cliFeatures(args []string) []string {
+	features := []string{"stream-json-output"}
+	for _, arg := range args {
+		switch arg {
+		case "--input-format":
+			features = append(features, "stream-json-input")
+		case "--permission-mode":
+			features = append(features, "permission-mode")
+		case "--add-dir":
+			features = append(features, "add-dir")
+		}
+	}
+	return features
 }
 
 // sendInitialize sends the control_request to initialize the interactive session
@@ -391,7 +470,7 @@ func controlRequestMessage(requestID, subtype, taskID string) map[string]interfa
 func (s *Session) writeControlRequest(controlRequest map[string]interface{}, label string) error {
 	jsonBytes, err := json.Marshal(controlRequest)
 	if err != nil {
-		log.Printf("[Session] Failed to marshal %s request: %v", label, err)
+		slog.Error("Failed to marshal request", "component", "Session", "request", label, "error", err)
 		return fmt.Errorf("failed to marshal %s request: %w", label, err)
 	}
 	jsonBytes = append(jsonBytes, '\n')
@@ -405,7 +484,7 @@ func (s *Session) writeControlRequest(controlRequest map[string]interface{}, lab
 	s.mu.Unlock()
 
 	if _, err := stdin.Write(jsonBytes); err != nil {
-		log.Printf("[Session] Failed to send %s request: %v", label, err)
+		slog.Error("Failed to send request", "component", "Session", "request", label, "error", err)
 		return fmt.Errorf("failed to send %s request: %w", label, err)
 	}
 	return nil
@@ -1124,7 +1203,7 @@ func (s *Session) waitForCompletion(emitter EventEmitter) {
 		s.Status = "cancelled"
 	} else if err != nil {
 		s.Status = "failed"
-		log.Printf("[Session] Claude CLI failed with error: %s", err.Error())
+		slog.Error("Claude CLI failed", "component", "Session", "error", err)
 	} else {
 		s.Status = "completed"
 	}
@@ -1172,7 +1251,7 @@ func (s *Session) waitForCompletion(emitter EventEmitter) {
 			"provider":   "claude",
 		}
 		errJSON, _ := json.Marshal(errMsg)
-		log.Printf("[Session] Emitting claude-error: %v", err)
+		slog.Error("Emitting claude-error", "component", "Session", "error", err)
 		emitter.Emit("claude-error", string(errJSON))
 	}
 
@@ -1200,7 +1279,7 @@ func (s *Session) watchProcessExit(emitter EventEmitter) {
 		s.Status = "cancelled"
 	} else if err != nil {
 		s.Status = "failed"
-		log.Printf("[Session] Interactive Claude CLI exited with error: %s", err.Error())
+		slog.Error("Interactive Claude CLI exited with error", "component", "Session", "error", err)
 	} else {
 		s.Status = "completed"
 	}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"io"
+	"slices"
 	"strings"
 	"sync"
 	"testing"
@@ -621,3 +622,56 @@ func TestUpdateEnvironmentVariablesRejectsBeforeInitialized(t *testing.T) {
 		t.Fatalf("expected uninitialized error, got %v", err)
 	}
 }
+
+func TestBuildClaudeArgsIncludesMcpSelection(t *testing.T) {
+	args := buildClaudeArgs(SessionConfig{
+		Prompt:          "hello",
+		McpConfig:       `{"mcpServers":{}}`,
+		DisallowedTools: []string{"mcp__github", "mcp__docs"},
+	})
+
+	if !argValue(args, "--mcp-config", `{"mcpServers":{}}`) {
+		t.Fatalf("expected --mcp-config in %#v", args)
+	}
+	if !argValue(args, "--disallowed-tools", "mcp__github,mcp__docs") {
+		t.Fatalf("expected --disallowed-tools in %#v", args)
+	}
+}
+
+func TestBuildClaudeArgsAppendsSystemPrompt(t *testing.T) {
+	args := buildClaudeArgs(SessionConfig{Prompt: "hello", AppendSystemPrompt: "Work on shop"})
+	if !argValue(args, "--append-system-prompt", "Work on shop") {
+		t.Fatalf("expected --append-system-prompt in %#v", args)
+	}
+	for _, arg := range buildClaudeArgs(SessionConfig{Prompt: "hello"}) {
+		if arg == "--append-system-prompt" {
+			t.Fatal("unexpected --append-system-prompt without a prompt")
+		}
+	}
+}
+
+func TestBuildClaudeArgsAppliesPermissionMode(t *testing.T) {
+	args := buildClaudeArgs(SessionConfig{Prompt: "hello"})
+	if !slices.Contains(args, "--dangerously-skip-permissions") {
+		t.Fatalf("expected permission checks to be skipped by default: %#v", args)
+	}
+
+	args = buildClaudeArgs(SessionConfig{
+		Prompt:         "hello",
+		PermissionMode: "dontAsk",
+		AllowedTools:   []string{"Read", "Grep"},
+	})
+	if slices.Contains(args, "--dangerously-skip-permissions") {
+		t.Fatalf("unexpected --dangerously-skip-permissions with a permission mode: %#v", args)
+	}
+	if !argValue(args, "--permission-mode", "dontAsk") || !argValue(args, "--allowed-tools", "Read,Grep") {
+		t.Fatalf("expected permission flags in %#v", args)
+	}
+}
+
+func TestBuildClaudeArgsAppendsExtraArgs(t *testing.T) {
+	args := buildClaudeArgs(SessionConfig{Prompt: "hello", ExtraArgs: []string{"--add-dir", "/srv/shared"}})
+	if len(args) < 2 || args[len(args)-2] != "--add-dir" || args[len(args)-1] != "/srv/shared" {
+		t.Fatalf("expected the extra args last in %#v", args)
+	}
+}
@@ -1,7 +0,0 @@
-//go:build windows
-
-package claude
-
-func ensureFullShellPath(env []string) []string {
-	return env
-}
@@ -0,0 +1,176 @@
+// Package clicompat knows which provider CLI versions support the flags
+// ropcode passes. A CLI older than a feature's minimum fails mid-session
+// with an unknown-flag error, so sessions check the table before they
+// start and the settings screen shows what an outdated CLI can't do. The
+// version comes from running the CLI with --version once per binary.
+package clicompat
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// Feature is something ropcode drives a provider CLI with
+type Feature struct {
+	ID          string `json:"id"`
+	Flag        string `json:"flag"`
+	Description string `json:"description"`
+	// MinVersion is the first release known to have Flag
+	MinVersion string `json:"min_version"`
+}
+
+// features lists each provider's features; sessions name the ones they
+// use by ID
+var features = map[string][]Feature{
+	"claude": {
+		{ID: "stream-json-output", Flag: "--output-format stream-json", Description: "Streamed JSON output", MinVersion: "1.0.0"},
+		{ID: "stream-json-input", Flag: "--input-format stream-json", Description: "Interactive sessions", MinVersion: "1.0.0"},
+		{ID: "permission-mode", Flag: "--permission-mode", Description: "Permission profiles", MinVersion: "1.0.0"},
+		{ID: "add-dir", Flag: "--add-dir", Description: "Access to ~/.claude", MinVersion: "1.0.18"},
+	},
+	"codex": {
+		{ID: "json", Flag: "exec --json", Description: "JSON event output", MinVersion: "0.44.0"},
+	},
+	"gemini": {
+		{ID: "stream-json", Flag: "--output-format stream-json", Description: "Streamed JSON output", MinVersion: "0.11.0"},
+		{ID: "approval-mode", Flag: "--approval-mode", Description: "Unattended tool approval", MinVersion: "0.3.0"},
+	},
+}
+
+// Features returns provider's features
+func Features(provider string) []Feature {
+	return features[provider]
+}
+
+// Version is a parsed major.minor.patch version
+type Version struct {
+	Major, Minor, Patch int
+}
+
+var versionPattern = regexp.MustCompile(`(\d+)\.(\d+)(?:\.(\d+))?`)
+
+// ParseVersion finds the first version in a version line like
+// "codex-cli 0.44.0" or "1.0.83 (Claude Code)"
+func ParseVersion(line string) (Version, bool) {
+	match := versionPattern.FindStringSubmatch(line)
+	if match == nil {
+		return Version{}, false
+	}
+	var v Version
+	v.Major, _ = strconv.Atoi(match[1])
+	v.Minor, _ = strconv.Atoi(match[2])
+	if match[3] != "" {
+		v.Patch, _ = strconv.Atoi(match[3])
+	}
+	return v, true
+}
+
+// Less reports whether v is older than other
+func (v Version) Less(other Version) bool {
+	if v.Major != other.Major {
+		return v.Major < other.Major
+	}
+	if v.Minor != other.Minor {
+		return v.Minor < other.Minor
+	}
+	return v.Patch < other.Patch
+}
+
+func (v Version) String() string {
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+// FeatureSupport is whether a CLI supports a feature
+type FeatureSupport struct {
+	Feature
+	Supported bool `json:"supported"`
+}
+
+// Capabilities is what a provider CLI supports
+type Capabilities struct {
+	Provider string `json:"provider"`
+	Path     string `json:"path,omitempty"`
+	// Version is the line the CLI printed for --version
+	Version string `json:"version,omitempty"`
+	// Known is false when the version could not be read; every feature is
+	// then assumed supported
+	Known bool `json:"known"`
+	// Error says why the version could not be read
+	Error    string           `json:"error,omitempty"`
+	Features []FeatureSupport `json:"features"`
+}
+
+// Supports reports whether the CLI supports feature id
+func (c *Capabilities) Supports(id string) bool {
+	for _, feature := range c.Features {
+		if feature.ID == id {
+			return feature.Supported
+		}
+	}
+	return true
+}
+
+// Report matches provider's features against the version line a CLI
+// printed
+func Report(provider, versionLine string) *Capabilities {
+	c := &Capabilities{Provider: provider, Version: versionLine, Features: []FeatureSupport{}}
+	version, known := ParseVersion(versionLine)
+	c.Known = known
+	for _, feature := range features[provider] {
+		supported := true
+		if min, ok := ParseVersion(feature.MinVersion); known && ok {
+			supported = !version.Less(min)
+		}
+		c.Features = append(c.Features, FeatureSupport{Feature: feature, Supported: supported})
+	}
+	return c
+}
+
+// UnsupportedError is returned for a session that needs a feature its CLI
+// is too old for
+type UnsupportedError struct {
+	Provider string
+	Version  string
+	Feature  Feature
+}
+
+func (e *UnsupportedError) Error() string {
+	return fmt.Sprintf("%s %s does not support %s (%s), which needs version %s or newer; update the %s CLI",
+		e.Provider, e.Version, e.Feature.Flag, e.Feature.Description, e.Feature.MinVersion, e.Provider)
+}
+
+// Require checks the CLI at path supports every feature in ids. Only a
+// version already probed is checked, so an unknown CLI is let through
+// rather than held up.
+func Require(provider, path string, ids ...string) error {
+	line, ok := known(path)
+	if !ok {
+		return nil
+	}
+	c := Report(provider, line)
+	for _, id := range ids {
+		if c.Supports(id) {
+			continue
+		}
+		for _, feature := range c.Features {
+			if feature.ID == id {
+				version, _ := ParseVersion(line)
+				return &UnsupportedError{Provider: provider, Version: version.String(), Feature: feature.Feature}
+			}
+		}
+	}
+	return nil
+}
+
+// Check reports what the CLI at path supports, probing its version when
+// it isn't known yet
+func Check(provider, path string) *Capabilities {
+	line, err := Detect(path)
+	c := Report(provider, line)
+	c.Path = path
+	if err != nil {
+		c.Error = err.Error()
+	}
+	return c
+}
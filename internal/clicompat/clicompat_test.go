@@ -0,0 +1,87 @@
+package clicompat
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		line string
+		want Version
+		ok   bool
+	}{
+		{"codex-cli 0.44.0", Version{0, 44, 0}, true},
+		{"1.0.83 (Claude Code)", Version{1, 0, 83}, true},
+		{"gemini 0.11", Version{0, 11, 0}, true},
+		{"unknown", Version{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := ParseVersion(tt.line)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("ParseVersion(%q) = %v, %v; want %v, %v", tt.line, got, ok, tt.want, tt.ok)
+		}
+	}
+	if !(Version{0, 9, 9}).Less(Version{0, 10, 0}) || (Version{1, 0, 0}).Less(Version{0, 99, 0}) {
+		t.Error("Less() compares numerically by component")
+	}
+}
+
+func TestReport(t *testing.T) {
+	old := Report("codex", "codex-cli 0.30.2")
+	if !old.Known || old.Supports("json") {
+		t.Errorf("codex 0.30.2 = %+v", old)
+	}
+	if c := Report("codex", "codex-cli 0.44.0"); !c.Supports("json") {
+		t.Errorf("codex 0.44.0 = %+v", c)
+	}
+	// A version that can't be read doesn't gate anything
+	if c := Report("gemini", "garbage"); c.Known || !c.Supports("stream-json") {
+		t.Errorf("unreadable version = %+v", c)
+	}
+	for provider, list := range features {
+		for _, feature := range list {
+			if _, ok := ParseVersion(feature.MinVersion); !ok || feature.Flag == "" {
+				t.Errorf("%s feature %+v", provider, feature)
+			}
+		}
+	}
+}
+
+func writeCLI(t *testing.T, path, version string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte("#!/bin/sh\necho '"+version+"'\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRequireChecksProbedVersions(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake CLI is a shell script")
+	}
+	path := filepath.Join(t.TempDir(), "codex")
+	writeCLI(t, path, "codex-cli 0.30.0")
+
+	// Nothing is known before a probe
+	if err := Require("codex", path, "json"); err != nil {
+		t.Fatalf("Require() before a probe = %v", err)
+	}
+
+	Probe(path)
+	var unsupported *UnsupportedError
+	if err := Require("codex", path, "json"); !errors.As(err, &unsupported) || unsupported.Feature.MinVersion != "0.44.0" {
+		t.Fatalf("Require() = %v", err)
+	}
+
+	// An update is probed again
+	writeCLI(t, path, "codex-cli 0.44.0 (updated)")
+	if c := Check("codex", path); c.Version != "codex-cli 0.44.0 (updated)" || !c.Supports("json") {
+		t.Fatalf("Check() after update = %+v", c)
+	}
+	if err := Require("codex", path, "json"); err != nil {
+		t.Errorf("Require() after update = %v", err)
+	}
+}
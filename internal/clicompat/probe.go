@@ -0,0 +1,95 @@
+package clicompat
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"ropcode/internal/loginenv"
+)
+
+// probeTimeout bounds a CLI that hangs on --version
+const probeTimeout = 10 * time.Second
+
+// probe is a --version run of one build of a binary
+type probe struct {
+	modTime time.Time
+	size    int64
+	done    chan struct{}
+	line    string
+	err     error
+}
+
+var (
+	probesMu sync.Mutex
+	probes   = map[string]*probe{}
+)
+
+// Probe starts reading the version of the CLI at path in the background,
+// unless it is known for the binary there now
+func Probe(path string) {
+	start(path)
+}
+
+// Detect returns the version line the CLI at path prints, probing it when
+// it isn't known yet
+func Detect(path string) (string, error) {
+	p := start(path)
+	<-p.done
+	return p.line, p.err
+}
+
+// known returns the version line of a CLI probed before, waiting for a
+// probe under way
+func known(path string) (string, bool) {
+	probesMu.Lock()
+	p := probes[path]
+	probesMu.Unlock()
+	if p == nil || p.stale(path) {
+		return "", false
+	}
+	<-p.done
+	return p.line, p.err == nil
+}
+
+// start returns the probe of the binary at path, running a new one when
+// there is none or the binary changed since, e.g. by an update
+func start(path string) *probe {
+	probesMu.Lock()
+	defer probesMu.Unlock()
+	if p := probes[path]; p != nil && !p.stale(path) {
+		return p
+	}
+	p := &probe{done: make(chan struct{})}
+	if info, err := os.Stat(path); err == nil {
+		p.modTime, p.size = info.ModTime(), info.Size()
+	}
+	probes[path] = p
+	go func() {
+		defer close(p.done)
+		p.line, p.err = runVersion(path)
+	}()
+	return p
+}
+
+func (p *probe) stale(path string) bool {
+	info, err := os.Stat(path)
+	return err != nil || !info.ModTime().Equal(p.modTime) || info.Size() != p.size
+}
+
+func runVersion(path string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), probeTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, path, "--version")
+	// CLIs run by node need the login PATH to find it
+	cmd.Env = loginenv.Environ()
+	output, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+	line, _, _ := strings.Cut(strings.TrimSpace(string(output)), "\n")
+	return strings.TrimSpace(line), nil
+}
@@ -0,0 +1,39 @@
+// Package cliinstall knows how the provider CLIs ropcode drives are
+// installed: the official installer where there is one, npm and Homebrew.
+// Each method is a shell command line, run by the caller so its output can
+// be streamed. Discover finds the installations already on the machine.
+package cliinstall
+
+import "fmt"
+
+// Providers are the CLIs that can be installed
+var Providers = []string{"claude", "codex", "gemini"}
+
+// Method is a way to install a provider CLI
+type Method struct {
+	ID      string `json:"id"`
+	Label   string `json:"label"`
+	Command string `json:"command"`
+	// Shell runs Command when it needs a particular one; empty for any
+	Shell string `json:"shell,omitempty"`
+}
+
+// Methods returns the ways provider's CLI installs on this OS, the
+// recommended one first
+func Methods(provider string) []Method {
+	return methods[provider]
+}
+
+// Find returns provider's install method id
+func Find(provider, id string) (Method, error) {
+	list, ok := methods[provider]
+	if !ok {
+		return Method{}, fmt.Errorf("unknown provider %q", provider)
+	}
+	for _, method := range list {
+		if method.ID == id {
+			return method, nil
+		}
+	}
+	return Method{}, fmt.Errorf("%s cannot be installed with %q here", provider, id)
+}
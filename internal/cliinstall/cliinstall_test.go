@@ -0,0 +1,59 @@
+package cliinstall
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestEveryProviderHasAnNpmMethod(t *testing.T) {
+	for _, provider := range Providers {
+		method, err := Find(provider, "npm")
+		if err != nil {
+			t.Fatalf("Find(%s, npm) error = %v", provider, err)
+		}
+		if method.Command == "" || len(Methods(provider)) == 0 {
+			t.Errorf("%s: method = %+v", provider, method)
+		}
+	}
+	if _, err := Find("claude", "apt"); err == nil {
+		t.Error("Find() with an unknown method should fail")
+	}
+	if _, err := Find("cursor", "npm"); err == nil {
+		t.Error("Find() with an unknown provider should fail")
+	}
+}
+
+func TestDiscoverFindsInstallationsOnce(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses Unix install locations")
+	}
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	cargo := filepath.Join(home, ".cargo", "bin", "codex")
+	nvm := filepath.Join(home, ".nvm", "versions", "node", "v22.1.0", "bin", "codex")
+	for _, path := range []string{cargo, nvm} {
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte("#!/bin/sh\n"), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// PATH holds a link to the cargo build, which is listed under PATH only
+	bin := t.TempDir()
+	if err := os.Symlink(cargo, filepath.Join(bin, "codex")); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin)
+
+	sources := map[string]string{}
+	for _, installation := range Discover("codex") {
+		sources[installation.Path] = installation.Source
+	}
+	if sources[filepath.Join(bin, "codex")] != "path" || sources[nvm] != "nvm" {
+		t.Errorf("Discover() = %v", sources)
+	}
+	if _, listed := sources[cargo]; listed {
+		t.Errorf("the cargo build is linked from PATH and should be listed once: %v", sources)
+	}
+}
@@ -0,0 +1,66 @@
+package cliinstall
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+)
+
+// Installation is a provider CLI found on this machine
+type Installation struct {
+	Path string `json:"path"`
+	// Source is where it was found: path, homebrew, npm-global, nvm,
+	// cargo, bun, native or scoop
+	Source           string `json:"source"`
+	InstallationType string `json:"installation_type"`
+}
+
+// searchDir is a directory CLIs get installed to
+type searchDir struct {
+	dir    string
+	source string
+	label  string
+}
+
+// Discover lists the installations of provider's CLI, the one on PATH
+// first; links to the same binary are listed once
+func Discover(provider string) []Installation {
+	var found []Installation
+	seen := make(map[string]bool)
+	add := func(path, source, label string) {
+		info, err := os.Stat(path)
+		if err != nil || info.IsDir() {
+			return
+		}
+		resolved, err := filepath.EvalSymlinks(path)
+		if err != nil {
+			resolved = path
+		}
+		if seen[resolved] {
+			return
+		}
+		seen[resolved] = true
+		found = append(found, Installation{Path: path, Source: source, InstallationType: label})
+	}
+
+	if path, err := exec.LookPath(provider); err == nil {
+		add(path, "path", "System PATH")
+	}
+	home, _ := os.UserHomeDir()
+	for _, dir := range searchDirs(home) {
+		for _, name := range executableNames(provider) {
+			add(filepath.Join(dir.dir, name), dir.source, dir.label)
+		}
+	}
+	if home != "" {
+		versions, _ := filepath.Glob(filepath.Join(home, ".nvm", "versions", "node", "*"))
+		sort.Sort(sort.Reverse(sort.StringSlice(versions)))
+		for _, version := range versions {
+			for _, name := range executableNames(provider) {
+				add(filepath.Join(version, "bin", name), "nvm", "NVM Node "+filepath.Base(version))
+			}
+		}
+	}
+	return found
+}
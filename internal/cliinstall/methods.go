@@ -0,0 +1,44 @@
+//go:build !windows
+
+package cliinstall
+
+import "path/filepath"
+
+var methods = map[string][]Method{
+	"claude": {
+		{ID: "native", Label: "Official installer", Command: "curl -fsSL https://claude.ai/install.sh | bash"},
+		{ID: "npm", Label: "npm", Command: "npm install -g @anthropic-ai/claude-code"},
+		{ID: "brew", Label: "Homebrew", Command: "brew install --cask claude-code"},
+	},
+	"codex": {
+		{ID: "npm", Label: "npm", Command: "npm install -g @openai/codex"},
+		{ID: "brew", Label: "Homebrew", Command: "brew install --cask codex"},
+	},
+	"gemini": {
+		{ID: "npm", Label: "npm", Command: "npm install -g @google/gemini-cli"},
+		{ID: "brew", Label: "Homebrew", Command: "brew install gemini-cli"},
+	},
+}
+
+func searchDirs(home string) []searchDir {
+	dirs := []searchDir{
+		{"/opt/homebrew/bin", "homebrew", "Homebrew"},
+		{"/usr/local/bin", "homebrew", "Homebrew"},
+		{"/home/linuxbrew/.linuxbrew/bin", "homebrew", "Homebrew"},
+	}
+	if home == "" {
+		return dirs
+	}
+	return append(dirs,
+		searchDir{filepath.Join(home, ".local", "bin"), "native", "Native installer"},
+		searchDir{filepath.Join(home, ".npm-global", "bin"), "npm-global", "npm Global"},
+		searchDir{filepath.Join(home, ".npm", "bin"), "npm-global", "npm Global"},
+		searchDir{filepath.Join(home, ".local", "share", "npm", "bin"), "npm-global", "npm Global"},
+		searchDir{filepath.Join(home, ".cargo", "bin"), "cargo", "Cargo"},
+		searchDir{filepath.Join(home, ".bun", "bin"), "bun", "Bun"},
+	)
+}
+
+func executableNames(provider string) []string {
+	return []string{provider}
+}
@@ -0,0 +1,41 @@
+//go:build windows
+
+package cliinstall
+
+import (
+	"os"
+	"path/filepath"
+)
+
+var methods = map[string][]Method{
+	"claude": {
+		{ID: "native", Label: "Official installer", Command: "irm https://claude.ai/install.ps1 | iex", Shell: "powershell.exe"},
+		{ID: "npm", Label: "npm", Command: "npm install -g @anthropic-ai/claude-code"},
+	},
+	"codex": {
+		{ID: "npm", Label: "npm", Command: "npm install -g @openai/codex"},
+	},
+	"gemini": {
+		{ID: "npm", Label: "npm", Command: "npm install -g @google/gemini-cli"},
+	},
+}
+
+func searchDirs(home string) []searchDir {
+	var dirs []searchDir
+	if appData := os.Getenv("APPDATA"); appData != "" {
+		dirs = append(dirs, searchDir{filepath.Join(appData, "npm"), "npm-global", "npm Global"})
+	}
+	if home == "" {
+		return dirs
+	}
+	return append(dirs,
+		searchDir{filepath.Join(home, ".local", "bin"), "native", "Native installer"},
+		searchDir{filepath.Join(home, ".cargo", "bin"), "cargo", "Cargo"},
+		searchDir{filepath.Join(home, ".bun", "bin"), "bun", "Bun"},
+		searchDir{filepath.Join(home, "scoop", "shims"), "scoop", "Scoop"},
+	)
+}
+
+func executableNames(provider string) []string {
+	return []string{provider + ".exe", provider + ".cmd"}
+}
@@ -0,0 +1,241 @@
+// internal/codex/catalog.go
+package codex
+
+import (
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"ropcode/internal/claude"
+	"ropcode/internal/database"
+)
+
+// catalogVersion is bumped whenever extractSessionInfo changes so entries
+// catalogued by older versions are read again
+const catalogVersion = 1
+
+// CatalogStore persists the session catalog
+type CatalogStore interface {
+	ListCodexSessionCatalog() ([]*database.CodexSessionEntry, []*database.CodexSessionDir, error)
+	UpdateCodexSessionCatalog(save []*database.CodexSessionEntry, remove []string, saveDirs []*database.CodexSessionDir, removeDirs []string) error
+}
+
+// Catalog answers session lookups from a persisted catalog of the rollout
+// files under ~/.codex/sessions instead of opening every file on each call.
+// A refresh only lists the directories whose modification time changed and
+// only reads the files whose size or modification time changed; the other
+// files cost a stat.
+type Catalog struct {
+	codexDir string
+	store    CatalogStore
+}
+
+// NewCatalog creates a catalog of the sessions in codexDir kept in store
+func NewCatalog(codexDir string, store CatalogStore) *Catalog {
+	return &Catalog{codexDir: codexDir, store: store}
+}
+
+// FindSessionFile returns the rollout file of sessionID
+func (c *Catalog) FindSessionFile(sessionID string) (string, error) {
+	sessionsDir := filepath.Join(c.codexDir, "sessions")
+	if _, err := os.Stat(sessionsDir); os.IsNotExist(err) {
+		return "", fmt.Errorf("sessions directory does not exist: %s", sessionsDir)
+	}
+
+	entries := c.refresh()
+	for _, entry := range entries {
+		if entry.SessionID == sessionID {
+			return entry.Path, nil
+		}
+	}
+	// Like FindSessionFile, fall back to the ID in the file name
+	for _, entry := range entries {
+		if strings.Contains(filepath.Base(entry.Path), sessionID) {
+			return entry.Path, nil
+		}
+	}
+	return "", fmt.Errorf("session file not found for session: %s", sessionID)
+}
+
+// LoadSessionHistory loads the history of a Codex session
+func (c *Catalog) LoadSessionHistory(projectID, sessionID string) ([]claude.Message, error) {
+	filePath, err := c.FindSessionFile(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	return LoadSessionHistoryFile(filePath, projectID)
+}
+
+// ListProjectSessionsLimit lists the sessions of projectPath, most recently
+// written first; a positive limit caps how many are returned
+func (c *Catalog) ListProjectSessionsLimit(projectPath string, limit int) (ProjectSessionsResult, error) {
+	var matched []*database.CodexSessionEntry
+	for _, entry := range c.refresh() {
+		if entry.SessionID == "" {
+			continue
+		}
+		if projectPath != "" && !sameCodexProjectPath(entry.ProjectPath, projectPath) {
+			continue
+		}
+		matched = append(matched, entry)
+	}
+	sort.SliceStable(matched, func(i, j int) bool {
+		return matched[i].ModTime > matched[j].ModTime
+	})
+
+	hasMore := false
+	if limit > 0 && len(matched) > limit {
+		matched, hasMore = matched[:limit], true
+	}
+	sessions := make([]SessionInfo, 0, len(matched))
+	for _, entry := range matched {
+		sessions = append(sessions, SessionInfo{
+			ID:               entry.SessionID,
+			ProjectID:        entry.ProjectPath,
+			ProjectPath:      entry.ProjectPath,
+			CreatedAt:        entry.CreatedAt,
+			MessageTimestamp: entry.MessageTimestamp,
+			FirstMessage:     entry.FirstMessage,
+		})
+	}
+
+	log.Printf("[Codex History] Found %d catalogued sessions for project: %s", len(sessions), projectPath)
+	return ProjectSessionsResult{Sessions: sessions, HasMore: hasMore}, nil
+}
+
+// ProjectPaths maps each catalogued rollout file to the path of the
+// project its session ran in
+func (c *Catalog) ProjectPaths() map[string]string {
+	paths := make(map[string]string)
+	for _, entry := range c.refresh() {
+		if entry.ProjectPath != "" {
+			paths[entry.Path] = entry.ProjectPath
+		}
+	}
+	return paths
+}
+
+// catalogRefresh is the state of one refresh of the catalog
+type catalogRefresh struct {
+	entries    map[string]*database.CodexSessionEntry
+	dirs       map[string]int64
+	files      map[string][]string // directory → catalogued files
+	subdirs    map[string][]string // directory → catalogued subdirectories
+	seen       map[string]bool
+	current    []*database.CodexSessionEntry
+	save       []*database.CodexSessionEntry
+	saveDirs   []*database.CodexSessionDir
+	removed    []string
+	removedDir []string
+}
+
+// refresh brings the catalog up to date with the sessions directory and
+// returns its entries. A catalog that cannot be read or written only costs
+// a rescan.
+func (c *Catalog) refresh() []*database.CodexSessionEntry {
+	sessionsDir := filepath.Join(c.codexDir, "sessions")
+	r := &catalogRefresh{
+		entries: make(map[string]*database.CodexSessionEntry),
+		dirs:    make(map[string]int64),
+		files:   make(map[string][]string),
+		subdirs: make(map[string][]string),
+		seen:    make(map[string]bool),
+	}
+	if entries, dirs, err := c.store.ListCodexSessionCatalog(); err == nil {
+		for _, entry := range entries {
+			r.entries[entry.Path] = entry
+			r.files[filepath.Dir(entry.Path)] = append(r.files[filepath.Dir(entry.Path)], entry.Path)
+		}
+		for _, dir := range dirs {
+			r.dirs[dir.Path] = dir.ModTime
+			if dir.Path != sessionsDir {
+				r.subdirs[filepath.Dir(dir.Path)] = append(r.subdirs[filepath.Dir(dir.Path)], dir.Path)
+			}
+		}
+	}
+
+	r.visitDir(sessionsDir)
+
+	for path := range r.entries {
+		if !r.seen[path] {
+			r.removed = append(r.removed, path)
+		}
+	}
+	for path := range r.dirs {
+		if !r.seen[path] {
+			r.removedDir = append(r.removedDir, path)
+		}
+	}
+	if err := c.store.UpdateCodexSessionCatalog(r.save, r.removed, r.saveDirs, r.removedDir); err != nil {
+		slog.Error("Failed to update session catalog", "component", "Codex History", "error", err)
+	}
+	return r.current
+}
+
+// visitDir catalogues the files under dir, listing it again only when its
+// modification time changed
+func (r *catalogRefresh) visitDir(dir string) {
+	// Stat before listing so a file added meanwhile changes the time
+	// recorded for the next refresh
+	info, err := os.Stat(dir)
+	if err != nil || !info.IsDir() {
+		return
+	}
+	r.seen[dir] = true
+	modTime := info.ModTime().UnixNano()
+
+	files, subdirs := r.files[dir], r.subdirs[dir]
+	if cached, ok := r.dirs[dir]; !ok || cached != modTime {
+		list, err := os.ReadDir(dir)
+		if err != nil {
+			return
+		}
+		files, subdirs = nil, nil
+		for _, entry := range list {
+			path := filepath.Join(dir, entry.Name())
+			if entry.IsDir() {
+				subdirs = append(subdirs, path)
+			} else if strings.HasSuffix(entry.Name(), ".jsonl") {
+				files = append(files, path)
+			}
+		}
+		r.saveDirs = append(r.saveDirs, &database.CodexSessionDir{Path: dir, ModTime: modTime})
+	}
+
+	for _, path := range files {
+		r.visitFile(path)
+	}
+	for _, path := range subdirs {
+		r.visitDir(path)
+	}
+}
+
+// visitFile catalogues path, reading it only when it changed
+func (r *catalogRefresh) visitFile(path string) {
+	info, err := os.Stat(path)
+	if err != nil || info.IsDir() {
+		return
+	}
+	r.seen[path] = true
+
+	size, modTime := info.Size(), info.ModTime().UnixNano()
+	if entry, ok := r.entries[path]; ok && entry.Size == size && entry.ModTime == modTime && entry.Version == catalogVersion {
+		r.current = append(r.current, entry)
+		return
+	}
+
+	entry := &database.CodexSessionEntry{Path: path, Size: size, ModTime: modTime, Version: catalogVersion}
+	if session, err := extractSessionInfo(path, ""); err == nil && session != nil {
+		entry.SessionID = session.ID
+		entry.ProjectPath = session.ProjectPath
+		entry.CreatedAt = session.CreatedAt
+		entry.MessageTimestamp = session.MessageTimestamp
+		entry.FirstMessage = session.FirstMessage
+	}
+	r.current = append(r.current, entry)
+	r.save = append(r.save, entry)
+}
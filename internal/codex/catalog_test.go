@@ -0,0 +1,101 @@
+package codex
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"ropcode/internal/database"
+)
+
+// countingCatalogStore records which files each refresh saved
+type countingCatalogStore struct {
+	*database.Database
+	saved []string
+}
+
+func (s *countingCatalogStore) UpdateCodexSessionCatalog(save []*database.CodexSessionEntry, remove []string, saveDirs []*database.CodexSessionDir, removeDirs []string) error {
+	for _, entry := range save {
+		s.saved = append(s.saved, filepath.Base(entry.Path))
+	}
+	return s.Database.UpdateCodexSessionCatalog(save, remove, saveDirs, removeDirs)
+}
+
+func writeCatalogRollout(t *testing.T, dir, sessionID, cwd string, modTime time.Time) string {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "rollout-2026-05-17T21-21-40-"+sessionID+".jsonl")
+	content := `{"timestamp":"2026-05-17T13:21:50.490Z","type":"session_meta","payload":{"id":"` + sessionID + `","timestamp":"2026-05-17T13:21:40.249Z","cwd":"` + cwd + `"}}
+{"timestamp":"2026-05-17T13:22:00.000Z","type":"response_item","payload":{"type":"message","role":"user","content":[{"type":"input_text","text":"hello ` + sessionID + `"}]}}
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	os.Chtimes(path, modTime, modTime)
+	return path
+}
+
+func TestCatalogReadsOnlyChangedFiles(t *testing.T) {
+	codexDir := t.TempDir()
+	day1 := filepath.Join(codexDir, "sessions", "2026", "05", "17")
+	day2 := filepath.Join(codexDir, "sessions", "2026", "05", "18")
+	now := time.Now()
+	writeCatalogRollout(t, day1, "s1", "/work/app", now.Add(-3*time.Hour))
+	s2 := writeCatalogRollout(t, day1, "s2", "/work/app", now.Add(-2*time.Hour))
+	writeCatalogRollout(t, day2, "s3", "/work/other", now.Add(-time.Hour))
+	os.WriteFile(filepath.Join(day2, "rollout-broken.jsonl"), []byte("not json\n"), 0644)
+
+	db, err := database.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer db.Close()
+	store := &countingCatalogStore{Database: db}
+	catalog := NewCatalog(codexDir, store)
+
+	result, err := catalog.ListProjectSessionsLimit("/work/app", 1)
+	if err != nil || len(result.Sessions) != 1 || result.Sessions[0].ID != "s2" || !result.HasMore {
+		t.Fatalf("ListProjectSessionsLimit() = %+v, %v, want s2 with more", result, err)
+	}
+	if result.Sessions[0].FirstMessage != "hello s2" || result.Sessions[0].CreatedAt == 0 {
+		t.Fatalf("session = %+v", result.Sessions[0])
+	}
+	if len(store.saved) != 4 {
+		t.Fatalf("first refresh saved %v, want every file", store.saved)
+	}
+
+	// Nothing changed: nothing is read again, the broken file included
+	store.saved = nil
+	if path, err := catalog.FindSessionFile("s3"); err != nil || filepath.Dir(path) != day2 {
+		t.Fatalf("FindSessionFile(s3) = %q, %v", path, err)
+	}
+	if len(store.saved) != 0 {
+		t.Fatalf("unchanged refresh saved %v", store.saved)
+	}
+
+	// An appended session and a new one are the only files read
+	file, _ := os.OpenFile(s2, os.O_APPEND|os.O_WRONLY, 0644)
+	file.WriteString(`{"timestamp":"2026-05-17T13:23:00.000Z","type":"event_msg","payload":{}}` + "\n")
+	file.Close()
+	writeCatalogRollout(t, filepath.Join(codexDir, "sessions", "2026", "05", "19"), "s4", "/work/app", now.Add(time.Hour))
+	os.Remove(filepath.Join(day2, "rollout-broken.jsonl"))
+	store.saved = nil
+	result, _ = NewCatalog(codexDir, store).ListProjectSessionsLimit("/work/app", 0)
+	if len(result.Sessions) != 3 || result.Sessions[0].ID != "s4" || result.Sessions[1].ID != "s2" {
+		t.Fatalf("sessions after changes = %+v", result.Sessions)
+	}
+	if len(store.saved) != 2 {
+		t.Fatalf("refresh after changes saved %v, want s2 and s4", store.saved)
+	}
+	entries, _, _ := db.ListCodexSessionCatalog()
+	if len(entries) != 4 {
+		t.Fatalf("catalog holds %d files after a removal, want 4", len(entries))
+	}
+
+	if _, err := catalog.FindSessionFile("missing"); err == nil {
+		t.Error("FindSessionFile() found an unknown session")
+	}
+}
@@ -116,6 +116,7 @@ type codexProviderEntry struct {
 type codexConfig struct {
 	modelProvider string
 	providers     map[string]codexProviderEntry
+	mcpServers    map[string]bool
 }
 
 // parseCodexConfig is a deliberately tiny TOML reader that handles only the
@@ -124,7 +125,7 @@ type codexConfig struct {
 // We don't bring in a TOML library because the surface we touch is two
 // fields per active provider.
 func parseCodexConfig(input string) codexConfig {
-	cfg := codexConfig{providers: map[string]codexProviderEntry{}}
+	cfg := codexConfig{providers: map[string]codexProviderEntry{}, mcpServers: map[string]bool{}}
 
 	currentSection := ""
 	for _, raw := range strings.Split(input, "\n") {
@@ -142,6 +143,9 @@ func parseCodexConfig(input string) codexConfig {
 				continue
 			}
 			currentSection = strings.TrimSpace(line[1 : len(line)-1])
+			if name := mcpServerSectionName(currentSection); name != "" {
+				cfg.mcpServers[name] = true
+			}
 			continue
 		}
 
@@ -170,6 +174,23 @@ func parseCodexConfig(input string) codexConfig {
 	return cfg
 }
 
+// mcpServerSectionName returns the server name of a [mcp_servers.<name>]
+// section header, or "" for any other section.
+func mcpServerSectionName(section string) string {
+	rest, ok := strings.CutPrefix(section, "mcp_servers.")
+	if !ok || rest == "" {
+		return ""
+	}
+	if rest[0] == '"' || rest[0] == '\'' {
+		if end := strings.IndexByte(rest[1:], rest[0]); end >= 0 {
+			return rest[1 : 1+end]
+		}
+		return ""
+	}
+	name, _, _ := strings.Cut(rest, ".")
+	return strings.TrimSpace(name)
+}
+
 func stripCodexComment(line string) string {
 	// Strip `#` to end of line, but not when it appears inside a quoted string.
 	inSingle, inDouble := false, false
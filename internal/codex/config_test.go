@@ -160,3 +160,26 @@ func writeCodexFiles(t *testing.T, dir, configTOML, authJSON string) {
 		}
 	}
 }
+
+func TestConfiguredMcpServers(t *testing.T) {
+	dir := t.TempDir()
+	writeCodexFiles(t, dir, `
+[mcp_servers.github]
+command = "npx"
+
+[mcp_servers.github.env]
+TOKEN = "x"
+
+[mcp_servers."docs.site"]
+url = "https://docs.example/mcp"
+`, `{}`)
+	t.Setenv("CODEX_HOME", dir)
+
+	got, err := ConfiguredMcpServers()
+	if err != nil {
+		t.Fatalf("ConfiguredMcpServers: %v", err)
+	}
+	if len(got) != 2 || got[0] != "docs.site" || got[1] != "github" {
+		t.Errorf("got %q, want [docs.site github]", got)
+	}
+}
@@ -74,7 +74,11 @@ func LoadSessionHistory(codexDir, projectID, sessionID string) ([]claude.Message
 	if err != nil {
 		return nil, err
 	}
+	return LoadSessionHistoryFile(filePath, projectID)
+}
 
+// LoadSessionHistoryFile loads the history of the Codex session in filePath
+func LoadSessionHistoryFile(filePath, projectID string) ([]claude.Message, error) {
 	log.Printf("[Codex History] Loading session from: %s", filePath)
 
 	file, err := os.Open(filePath)
@@ -114,6 +118,16 @@ func LoadSessionHistory(codexDir, projectID, sessionID string) ([]claude.Message
 	return messages, nil
 }
 
+// HistoryMessages converts one line of a rollout file to Claude history
+// messages, none when the line is not a conversation event
+func HistoryMessages(line []byte, projectID string) []claude.Message {
+	var event map[string]interface{}
+	if err := json.Unmarshal(line, &event); err != nil {
+		return nil
+	}
+	return codexEventToClaudeHistory(event, projectID)
+}
+
 // codexEventToClaudeHistory converts a Codex event to Claude history format
 // Based on Tauri version's codex_event_to_claude_history function
 func codexEventToClaudeHistory(event map[string]interface{}, projectID string) []claude.Message {
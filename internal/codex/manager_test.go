@@ -1,9 +1,11 @@
 package codex
 
 import (
+	"context"
 	"path/filepath"
 	"runtime"
 	"testing"
+	"time"
 )
 
 func TestWindowsCodexBinaryCandidatesPreferNativeExeBeforeNpmShim(t *testing.T) {
@@ -37,3 +39,39 @@ func TestWindowsCodexBinaryCandidatesPreferNativeExeBeforeNpmShim(t *testing.T)
 		t.Fatalf("expected native codex.exe before npm shim, native=%d npm=%d", nativeIndex, npmShimIndex)
 	}
 }
+
+func TestWaitForSessionReturnsFinalStatus(t *testing.T) {
+	manager := &SessionManager{sessions: make(map[string]*Session)}
+	session := NewSession(SessionConfig{ProjectPath: t.TempDir()})
+	manager.sessions[session.ID] = session
+
+	go func() {
+		session.mu.Lock()
+		session.Status = "failed"
+		session.mu.Unlock()
+		close(session.done)
+	}()
+
+	status, err := manager.WaitForSession(context.Background(), session.ID)
+	if err != nil {
+		t.Fatalf("WaitForSession: %v", err)
+	}
+	if status != "failed" {
+		t.Fatalf("status = %q, want failed", status)
+	}
+}
+
+func TestWaitForSessionHonoursContextAndUnknownIDs(t *testing.T) {
+	manager := &SessionManager{sessions: make(map[string]*Session)}
+	session := NewSession(SessionConfig{ProjectPath: t.TempDir()})
+	manager.sessions[session.ID] = session
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := manager.WaitForSession(ctx, session.ID); err == nil {
+		t.Fatal("expected context error for a session that never finishes")
+	}
+	if _, err := manager.WaitForSession(context.Background(), "missing"); err == nil {
+		t.Fatal("expected error for unknown session")
+	}
+}
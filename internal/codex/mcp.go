@@ -0,0 +1,115 @@
+// internal/codex/mcp.go
+package codex
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// McpServer is an MCP server handed to a session through -c overrides. Codex
+// launches stdio servers (Command) and talks streamable HTTP to remote
+// servers (URL).
+type McpServer struct {
+	Command string            `json:"command,omitempty"`
+	Args    []string          `json:"args,omitempty"`
+	Env     map[string]string `json:"env,omitempty"`
+	URL     string            `json:"url,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// ConfiguredMcpServers returns the names of the MCP servers declared in
+// config.toml. A missing config yields no servers.
+func ConfiguredMcpServers() ([]string, error) {
+	codexDir, err := CodexDir()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(filepath.Join(codexDir, "config.toml"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []string{}, nil
+		}
+		return nil, err
+	}
+	parsed := parseCodexConfig(string(data))
+	names := make([]string, 0, len(parsed.mcpServers))
+	for name := range parsed.mcpServers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// mcpOverrides renders the MCP server selection as -c arguments.
+func (c SessionConfig) mcpOverrides() []string {
+	var args []string
+
+	names := make([]string, 0, len(c.McpServers))
+	for name := range c.McpServers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		server := c.McpServers[name]
+		var fields []string
+		if server.Command != "" {
+			fields = append(fields, "command = "+tomlString(server.Command))
+			if len(server.Args) > 0 {
+				values := make([]string, len(server.Args))
+				for i, arg := range server.Args {
+					values[i] = tomlString(arg)
+				}
+				fields = append(fields, "args = ["+strings.Join(values, ", ")+"]")
+			}
+			if len(server.Env) > 0 {
+				fields = append(fields, "env = "+tomlTable(server.Env))
+			}
+		} else {
+			fields = append(fields, "url = "+tomlString(server.URL))
+			if len(server.Headers) > 0 {
+				fields = append(fields, "http_headers = "+tomlTable(server.Headers))
+			}
+		}
+		args = append(args, "-c", "mcp_servers."+tomlKey(name)+"={ "+strings.Join(fields, ", ")+" }")
+	}
+
+	for _, name := range c.DisabledMcpServers {
+		args = append(args, "-c", "mcp_servers."+tomlKey(name)+".enabled=false")
+	}
+	return args
+}
+
+var bareTOMLKey = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// tomlKey quotes a key unless it is a valid bare key.
+func tomlKey(key string) string {
+	if bareTOMLKey.MatchString(key) {
+		return key
+	}
+	return tomlString(key)
+}
+
+// tomlString renders a TOML basic string. JSON string escapes are a subset
+// of the escapes TOML accepts.
+func tomlString(value string) string {
+	data, _ := json.Marshal(value)
+	return string(data)
+}
+
+// tomlTable renders a string map as a TOML inline table.
+func tomlTable(values map[string]string) string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	entries := make([]string, len(keys))
+	for i, key := range keys {
+		entries[i] = tomlKey(key) + " = " + tomlString(values[key])
+	}
+	return "{ " + strings.Join(entries, ", ") + " }"
+}
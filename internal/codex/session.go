@@ -8,17 +8,27 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"log/slog"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/google/uuid"
+	"ropcode/internal/loginenv"
 	"ropcode/internal/sessionproc"
 )
 
+// Sandbox modes of codex exec
+const (
+	SandboxReadOnly         = "read-only"
+	SandboxWorkspaceWrite   = "workspace-write"
+	SandboxDangerFullAccess = "danger-full-access"
+)
+
 type SessionConfig struct {
 	ProjectPath     string `json:"project_path"`
 	Prompt          string `json:"prompt"`
@@ -29,6 +39,25 @@ type SessionConfig struct {
 	Resume          bool   `json:"resume,omitempty"`
 	AuthToken       string `json:"auth_token,omitempty"`
 	BaseURL         string `json:"base_url,omitempty"`
+	// McpServers are added to the MCP servers configured in config.toml
+	McpServers map[string]McpServer `json:"mcp_servers,omitempty"`
+	// DisabledMcpServers are config.toml MCP servers hidden from the session
+	DisabledMcpServers []string `json:"disabled_mcp_servers,omitempty"`
+	// DeveloperInstructions are added to the instructions Codex sends the model
+	DeveloperInstructions string `json:"developer_instructions,omitempty"`
+	// SandboxMode is the --sandbox of the session: "read-only",
+	// "workspace-write" or "danger-full-access" (the default)
+	SandboxMode string `json:"sandbox_mode,omitempty"`
+	// DisableNetwork keeps commands from reaching the network in the
+	// workspace-write sandbox
+	DisableNetwork bool `json:"disable_network,omitempty"`
+	// ConfigOverrides are extra -c key=value overrides, applied last
+	ConfigOverrides []string `json:"config_overrides,omitempty"`
+	// Remote runs the CLI on an SSH host for remote projects
+	Remote *sessionproc.Remote `json:"-"`
+	// Env holds the project's environment variables; the API configuration
+	// above takes precedence over them
+	Env map[string]string `json:"-"`
 }
 
 type SessionStatus struct {
@@ -110,23 +139,34 @@ func (s *Session) Start(ctx context.Context, binaryPath string, emitter EventEmi
 
 	log.Printf("[Codex Session] Starting Codex with args: %v", args)
 
-	// Create command
-	s.cmd = exec.CommandContext(ctx, binaryPath, args...)
+	if remote := s.Config.Remote; remote != nil {
+		// Remote projects run the CLI where the files live; ssh carries the
+		// JSONL stream back
+		log.Printf("[Codex Session] Running Codex remotely: dir=%q binary=%q", remote.Dir, remote.BinaryOr("codex"))
+		cmd, err := remote.Command(ctx, remote.Dir, append([]string{remote.BinaryOr("codex")}, args...), s.Config.applyEnv(nil))
+		if err != nil {
+			return fmt.Errorf("failed to prepare remote command: %w", err)
+		}
+		s.cmd = cmd
+	} else {
+		// Create command
+		s.cmd = exec.CommandContext(ctx, binaryPath, args...)
+
+		// Set working directory to project path
+		if s.Config.ProjectPath != "" {
+			s.cmd.Dir = s.Config.ProjectPath
+		}
+
+		// Inherit environment variables from parent process and enhance PATH
+		// This is critical for production (.app) builds where PATH is very limited
+		// when launched via double-click (vs `open -a` from terminal)
+		// Codex gets API key (CRS_OAI_KEY) from ~/.claude/settings.json env section
+		s.cmd.Env = s.Config.applyEnv(enhanceEnvForProduction())
+	}
 	if err := sessionproc.Configure(s.cmd); err != nil {
 		return fmt.Errorf("failed to configure command: %w", err)
 	}
 
-	// Set working directory to project path
-	if s.Config.ProjectPath != "" {
-		s.cmd.Dir = s.Config.ProjectPath
-	}
-
-	// Inherit environment variables from parent process and enhance PATH
-	// This is critical for production (.app) builds where PATH is very limited
-	// when launched via double-click (vs `open -a` from terminal)
-	// Codex gets API key (CRS_OAI_KEY) from ~/.claude/settings.json env section
-	s.cmd.Env = s.Config.applyProviderApiEnv(enhanceEnvForProduction())
-
 	// Setup pipes
 	var err error
 	s.stdout, err = s.cmd.StdoutPipe()
@@ -187,16 +227,27 @@ func (s *Session) Start(ctx context.Context, binaryPath string, emitter EventEmi
 }
 
 func (c SessionConfig) buildArgs() []string {
+	sandbox := c.SandboxMode
+	if sandbox == "" {
+		sandbox = SandboxDangerFullAccess // 完全访问权限（已去除工作空间限制）
+	}
 	args := []string{
 		"exec",
-		"--sandbox", "danger-full-access", // 完全访问权限（已去除工作空间限制）
+		"--sandbox", sandbox,
 	}
 
 	// Set approval policy to never (no user interaction)
 	args = append(args, "-c", "approval_policy=\"never\"")
 
 	// Enable network access for commands like pip, npm, curl, wget, etc.
-	args = append(args, "-c", "sandbox_danger_full_access.network_access=true")
+	switch sandbox {
+	case SandboxDangerFullAccess:
+		if !c.DisableNetwork {
+			args = append(args, "-c", "sandbox_danger_full_access.network_access=true")
+		}
+	case SandboxWorkspaceWrite:
+		args = append(args, "-c", fmt.Sprintf("sandbox_workspace_write.network_access=%t", !c.DisableNetwork))
+	}
 
 	// Add model parameter
 	if c.Model != "" {
@@ -207,8 +258,21 @@ func (c SessionConfig) buildArgs() []string {
 		args = append(args, "-c", fmt.Sprintf("model_reasoning_effort=%q", c.ReasoningEffort))
 	}
 
+	// Per-project MCP server selection
+	args = append(args, c.mcpOverrides()...)
+
+	if c.DeveloperInstructions != "" {
+		args = append(args, "-c", "developer_instructions="+tomlString(c.DeveloperInstructions))
+	}
+
+	for _, override := range c.ConfigOverrides {
+		args = append(args, "-c", override)
+	}
+
 	// Set working directory
-	if c.ProjectPath != "" {
+	if c.Remote != nil {
+		args = append(args, "-C", c.Remote.Dir)
+	} else if c.ProjectPath != "" {
 		args = append(args, "-C", c.ProjectPath)
 	}
 
@@ -224,6 +288,11 @@ func (c SessionConfig) buildArgs() []string {
 	return args
 }
 
+// cliFeatures names the clicompat features buildArgs relies on
+func (c SessionConfig) cliFeatures() []string {
+	return []string{"json"}
+}
+
 // readOutput reads output from stdout or stderr
 func (s *Session) readOutput(reader io.ReadCloser, outputType string, emitter EventEmitter) {
 	scanner := bufio.NewScanner(reader)
@@ -655,7 +724,7 @@ func (s *Session) waitForCompletion(emitter EventEmitter) {
 			"provider":   "codex",
 		}
 		errJSON, _ := json.Marshal(errMsg)
-		log.Printf("[Codex Session] Emitting claude-error: %v", err)
+		slog.Error("Emitting claude-error", "component", "Codex Session", "error", err)
 		emitter.Emit("claude-error", string(errJSON))
 	}
 
@@ -717,7 +786,7 @@ func (s *Session) GetPID() int {
 // This is critical for production .app builds where PATH is very limited
 // and API keys are not inherited when launched via double-click.
 func enhanceEnvForProduction() []string {
-	env := os.Environ()
+	env := loginenv.Environ()
 
 	// Additional paths to add for production builds
 	// These are common locations for CLI tools in GUI-launched builds.
@@ -781,7 +850,7 @@ func enhanceEnvForProduction() []string {
 		"CRS_OAI_KEY":    {"CRS_OAI_KEY"},
 	})
 
-	// Fallback: Load missing API keys from launchctl or login shell
+	// Fallback: Load missing API keys from the login environment or launchctl
 	env = loadMissingEnvFromSystem(env, []string{
 		"OPENAI_API_KEY",
 		"CRS_OAI_KEY",
@@ -823,6 +892,20 @@ func windowsNodePaths() []string {
 	return paths
 }
 
+// applyEnv sets the project's environment variables and then the provider
+// API configuration in env
+func (c SessionConfig) applyEnv(env []string) []string {
+	names := make([]string, 0, len(c.Env))
+	for name := range c.Env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		env = setEnvVar(env, name, c.Env[name])
+	}
+	return c.applyProviderApiEnv(env)
+}
+
 func (c SessionConfig) applyProviderApiEnv(env []string) []string {
 	if c.AuthToken != "" {
 		env = setEnvVar(env, "OPENAI_API_KEY", c.AuthToken)
@@ -855,13 +938,13 @@ func loadEnvFromClaudeSettings(env []string, varMappings map[string][]string) []
 	settingsPath := homeDir + "/.claude/settings.json"
 	data, err := os.ReadFile(settingsPath)
 	if err != nil {
-		log.Printf("[Codex Session] Could not read Claude settings: %v", err)
+		slog.Warn("Could not read Claude settings", "component", "Codex Session", "error", err)
 		return env
 	}
 
 	var settings map[string]interface{}
 	if err := json.Unmarshal(data, &settings); err != nil {
-		log.Printf("[Codex Session] Could not parse Claude settings: %v", err)
+		slog.Warn("Could not parse Claude settings", "component", "Codex Session", "error", err)
 		return env
 	}
 
@@ -898,7 +981,8 @@ func loadEnvFromClaudeSettings(env []string, varMappings map[string][]string) []
 	return env
 }
 
-// loadMissingEnvFromSystem loads missing environment variables from launchctl or login shell
+// loadMissingEnvFromSystem loads missing environment variables from the
+// captured login environment or launchctl
 func loadMissingEnvFromSystem(env []string, vars []string) []string {
 	// Check which vars are already set
 	existingVars := make(map[string]bool)
@@ -924,32 +1008,8 @@ func loadMissingEnvFromSystem(env []string, vars []string) []string {
 	}
 
 	for _, varName := range missingVars {
-		var value string
-
-		// First, try launchctl (macOS system-wide environment)
-		cmd := exec.Command("launchctl", "getenv", varName)
-		output, err := cmd.Output()
-		if err == nil && len(output) > 0 {
-			value = string(output)
-			if len(value) > 0 && value[len(value)-1] == '\n' {
-				value = value[:len(value)-1]
-			}
-		}
-
-		// If not in launchctl, try login shell
-		if value == "" {
-			shell := os.Getenv("SHELL")
-			if shell == "" {
-				shell = "/bin/zsh"
-			}
-			cmd = exec.Command(shell, "-l", "-c", "echo $"+varName)
-			output, err = cmd.Output()
-			if err == nil && len(output) > 1 {
-				value = string(output)
-				value = value[:len(value)-1] // Remove trailing newline
-			}
-		}
-
+		// The login environment is captured once; launchctl answers are cached
+		value, _ := loginenv.Lookup(varName)
 		if value != "" {
 			log.Printf("[Codex Session] Loaded %s from system environment", varName)
 			env = append(env, varName+"="+value)
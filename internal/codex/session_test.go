@@ -26,6 +26,21 @@ func TestSessionConfigApplyProviderApiEnvOverridesCodexCredentials(t *testing.T)
 	assertEnvValue(t, got, "OPENAI_BASE_URL", "https://api.example/v1")
 }
 
+func TestSessionConfigApplyEnvLetsProviderApiWin(t *testing.T) {
+	config := SessionConfig{
+		AuthToken: "provider-token",
+		Env: map[string]string{
+			"HTTPS_PROXY":    "http://proxy:8080",
+			"OPENAI_API_KEY": "project-token",
+		},
+	}
+
+	got := config.applyEnv([]string{"HTTPS_PROXY=http://old:1"})
+
+	assertEnvValue(t, got, "HTTPS_PROXY", "http://proxy:8080")
+	assertEnvValue(t, got, "OPENAI_API_KEY", "provider-token")
+}
+
 func TestSessionConfigBuildArgsIncludesReasoningEffort(t *testing.T) {
 	config := SessionConfig{
 		ProjectPath:     `E:\bit_master\ropcode`,
@@ -41,6 +56,33 @@ func TestSessionConfigBuildArgsIncludesReasoningEffort(t *testing.T) {
 	assertContainsSequence(t, got, "--", "hello")
 }
 
+func TestSessionConfigBuildArgsIncludesDeveloperInstructions(t *testing.T) {
+	config := SessionConfig{Prompt: "hello", DeveloperInstructions: "Work on \"shop\"\nbranch main"}
+
+	assertContainsSequence(t, config.buildArgs(), "-c", `developer_instructions="Work on \"shop\"\nbranch main"`)
+}
+
+func TestSessionConfigBuildArgsAppliesSandbox(t *testing.T) {
+	got := SessionConfig{Prompt: "hello"}.buildArgs()
+	assertContainsSequence(t, got, "--sandbox", "danger-full-access")
+	assertContainsSequence(t, got, "-c", "sandbox_danger_full_access.network_access=true")
+
+	got = SessionConfig{Prompt: "hello", SandboxMode: SandboxWorkspaceWrite, DisableNetwork: true}.buildArgs()
+	assertContainsSequence(t, got, "--sandbox", "workspace-write")
+	assertContainsSequence(t, got, "-c", "sandbox_workspace_write.network_access=false")
+
+	got = SessionConfig{Prompt: "hello", ConfigOverrides: []string{`model_verbosity="low"`}}.buildArgs()
+	assertContainsSequence(t, got, "-c", `model_verbosity="low"`)
+
+	got = SessionConfig{Prompt: "hello", SandboxMode: SandboxReadOnly}.buildArgs()
+	assertContainsSequence(t, got, "--sandbox", "read-only")
+	for _, arg := range got {
+		if strings.Contains(arg, "network_access") {
+			t.Fatalf("unexpected network override for the read-only sandbox: %#v", got)
+		}
+	}
+}
+
 func TestEnhanceEnvForProductionAddsWindowsNodePaths(t *testing.T) {
 	if runtime.GOOS != "windows" {
 		t.Skip("windows-only PATH enhancement")
@@ -127,3 +169,21 @@ func indexOf(values []string, want string) int {
 	}
 	return -1
 }
+
+func TestSessionConfigBuildArgsIncludesMcpOverrides(t *testing.T) {
+	config := SessionConfig{
+		Prompt: "hello",
+		McpServers: map[string]McpServer{
+			"local":     {Command: "npx", Args: []string{"-y", "server"}, Env: map[string]string{"TOKEN": "a\"b"}},
+			"docs.site": {URL: "https://docs.example/mcp", Headers: map[string]string{"Authorization": "Bearer t"}},
+		},
+		DisabledMcpServers: []string{"github"},
+	}
+
+	got := config.buildArgs()
+
+	assertContainsSequence(t, got, "-c", `mcp_servers."docs.site"={ url = "https://docs.example/mcp", http_headers = { Authorization = "Bearer t" } }`)
+	assertContainsSequence(t, got, "-c", `mcp_servers.local={ command = "npx", args = ["-y", "server"], env = { TOKEN = "a\"b" } }`)
+	assertContainsSequence(t, got, "-c", "mcp_servers.github.enabled=false")
+	assertContainsSequence(t, got, "--", "hello")
+}
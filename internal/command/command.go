@@ -4,13 +4,28 @@ package command
 
 import (
 	"bytes"
+	"context"
 	"os/exec"
+
+	"ropcode/internal/loginenv"
 )
 
 // Execute runs a shell command synchronously and returns the output.
 func Execute(command string, cwd string) Result {
-	shellCmd := exec.Command("sh", "-c", command)
-	return run(shellCmd, cwd)
+	return run(Shell(context.Background(), command), cwd)
+}
+
+// ExecuteWithEnv is Execute with env added to the inherited and login
+// environment.
+func ExecuteWithEnv(command string, cwd string, env []string) Result {
+	cmd := Shell(context.Background(), command)
+	cmd.Env = append(loginenv.Environ(), env...)
+	return run(cmd, cwd)
+}
+
+// Shell returns a command running command in the platform shell.
+func Shell(ctx context.Context, command string) *exec.Cmd {
+	return exec.CommandContext(ctx, "sh", "-c", command)
 }
 
 func run(shellCmd *exec.Cmd, cwd string) Result {
@@ -0,0 +1,88 @@
+package command
+
+import (
+	"context"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"ropcode/internal/loginenv"
+)
+
+// Preference is the shell commands run in
+type Preference struct {
+	// Path is the shell's path or name; empty for the platform shell
+	Path string
+	// Login starts the shell as a login shell, so commands see the
+	// environment the user's profile sets up
+	Login bool
+}
+
+// shellKind returns how a shell takes a command: posix, powershell or cmd
+func shellKind(path string) string {
+	// Split on either separator so Windows paths are read the same anywhere
+	base := strings.ToLower(path[strings.LastIndexAny(path, `/\`)+1:])
+	base = strings.TrimSuffix(base, ".exe")
+	switch base {
+	case "cmd":
+		return "cmd"
+	case "powershell", "pwsh":
+		return "powershell"
+	}
+	return "posix"
+}
+
+// Command returns a command running line in the preferred shell
+func (p Preference) Command(ctx context.Context, line string) *exec.Cmd {
+	if p.Path == "" {
+		return Shell(ctx, line)
+	}
+	switch shellKind(p.Path) {
+	case "cmd":
+		return exec.CommandContext(ctx, p.Path, "/C", line)
+	case "powershell":
+		if p.Login {
+			return exec.CommandContext(ctx, p.Path, "-NoLogo", "-NonInteractive", "-Command", line)
+		}
+		return exec.CommandContext(ctx, p.Path, "-NoLogo", "-NoProfile", "-NonInteractive", "-Command", line)
+	}
+	if p.Login {
+		return exec.CommandContext(ctx, p.Path, "-l", "-c", line)
+	}
+	return exec.CommandContext(ctx, p.Path, "-c", line)
+}
+
+// Program returns a command running program with args. With Login set it
+// goes through the shell, so the program is found on the login PATH and
+// sees the profile's environment; otherwise, and for cmd, which has no
+// login environment, it runs directly.
+func (p Preference) Program(ctx context.Context, program string, args []string) *exec.Cmd {
+	if !p.Login || p.Path == "" {
+		return exec.CommandContext(ctx, program, args...)
+	}
+	switch shellKind(p.Path) {
+	case "cmd":
+		return exec.CommandContext(ctx, program, args...)
+	case "powershell":
+		quoted := make([]string, 0, len(args)+1)
+		for _, arg := range append([]string{program}, args...) {
+			quoted = append(quoted, "'"+strings.ReplaceAll(arg, "'", "''")+"'")
+		}
+		return exec.CommandContext(ctx, p.Path, "-NoLogo", "-NonInteractive", "-Command", "& "+strings.Join(quoted, " "))
+	}
+	// The program and its arguments are passed as the script's positional
+	// parameters, which need no quoting; fish has them in $argv
+	script := `exec "$0" "$@"`
+	if strings.EqualFold(filepath.Base(p.Path), "fish") {
+		script = "exec $argv"
+	}
+	return exec.CommandContext(ctx, p.Path, append([]string{"-l", "-c", script, program}, args...)...)
+}
+
+// ExecuteIn runs command in the preferred shell synchronously, with env
+// added to the inherited and login environment, and returns the output
+func ExecuteIn(p Preference, command string, cwd string, env []string) Result {
+	cmd := p.Command(context.Background(), command)
+	cmd.Env = append(loginenv.Environ(), env...)
+	return run(cmd, cwd)
+}
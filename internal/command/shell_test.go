@@ -0,0 +1,68 @@
+package command
+
+import (
+	"context"
+	"os/exec"
+	"runtime"
+	"testing"
+)
+
+func TestPreferenceCommandArgs(t *testing.T) {
+	ctx := context.Background()
+	for _, tc := range []struct {
+		preference Preference
+		want       []string
+	}{
+		{Preference{Path: "/bin/zsh"}, []string{"/bin/zsh", "-c", "ls"}},
+		{Preference{Path: "/bin/zsh", Login: true}, []string{"/bin/zsh", "-l", "-c", "ls"}},
+		{Preference{Path: `C:\Windows\System32\cmd.exe`, Login: true}, []string{`C:\Windows\System32\cmd.exe`, "/C", "ls"}},
+		{Preference{Path: "pwsh.exe"}, []string{"pwsh.exe", "-NoLogo", "-NoProfile", "-NonInteractive", "-Command", "ls"}},
+	} {
+		cmd := tc.preference.Command(ctx, "ls")
+		if got := cmd.Args; !equal(got, tc.want) {
+			t.Errorf("%+v: Command() args = %q, want %q", tc.preference, got, tc.want)
+		}
+	}
+
+	program := Preference{Path: "pwsh.exe", Login: true}.Program(ctx, "git", []string{"log", "it's"})
+	if want := []string{"pwsh.exe", "-NoLogo", "-NonInteractive", "-Command", "& 'git' 'log' 'it''s'"}; !equal(program.Args, want) {
+		t.Errorf("PowerShell Program() args = %q, want %q", program.Args, want)
+	}
+	if direct := (Preference{Path: "/bin/zsh"}).Program(ctx, "git", []string{"log"}); !equal(direct.Args, []string{"git", "log"}) {
+		t.Errorf("Program() without a login shell args = %q", direct.Args)
+	}
+}
+
+func TestLoginShellRunsProgramWithArgsIntact(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("no sh")
+	}
+	out, err := Preference{Path: sh, Login: true}.Program(context.Background(), "echo", []string{"a  b", "it's", "$HOME"}).Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(out); got != "a  b it's $HOME\n" {
+		t.Errorf("output = %q", got)
+	}
+
+	r := ExecuteIn(Preference{Path: sh}, `echo "$GREETING"`, t.TempDir(), []string{"GREETING=hi"})
+	if !r.Success || r.Output != "hi\n" {
+		t.Errorf("ExecuteIn() = %+v", r)
+	}
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
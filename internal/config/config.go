@@ -56,3 +56,9 @@ func (c *Config) GetSessionPath(projectPath, sessionID string) string {
 func (c *Config) CLIContextPath() string {
 	return filepath.Join(c.RopcodeDir, "cli-context.json")
 }
+
+// SessionIndexDir returns the directory holding the cached offset indexes
+// of session files.
+func (c *Config) SessionIndexDir() string {
+	return filepath.Join(c.RopcodeDir, "session-index")
+}
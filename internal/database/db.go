@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"sync"
 	"time"
 
 	_ "modernc.org/sqlite"
@@ -16,8 +17,17 @@ import (
 // Database wraps the SQLite database connection
 type Database struct {
 	db *sql.DB
+
+	// projectLocks serialize the writes to each project index, keyed by
+	// project name
+	projectLocksMu sync.Mutex
+	projectLocks   map[string]*sync.Mutex
 }
 
+// ErrWorkspaceNotFound is returned by PatchWorkspaceIndex when no project
+// holds the workspace
+var ErrWorkspaceNotFound = errors.New("workspace not found")
+
 // Open creates or opens a SQLite database at the given path
 func Open(path string) (*Database, error) {
 	db, err := sql.Open("sqlite", path+"?_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)")
@@ -86,8 +96,10 @@ func (d *Database) init() error {
 		system_prompt TEXT NOT NULL,
 		default_task TEXT,
 		model TEXT NOT NULL DEFAULT 'sonnet',
+		provider TEXT NOT NULL DEFAULT 'claude',
 		provider_api_id TEXT,
 		hooks TEXT,
+		source_url TEXT NOT NULL DEFAULT '',
 		created_at INTEGER NOT NULL,
 		updated_at INTEGER NOT NULL
 	);
@@ -99,6 +111,7 @@ func (d *Database) init() error {
 		agent_icon TEXT NOT NULL,
 		task TEXT NOT NULL,
 		model TEXT NOT NULL,
+		provider TEXT NOT NULL DEFAULT 'claude',
 		project_path TEXT NOT NULL,
 		session_id TEXT NOT NULL,
 		status TEXT NOT NULL DEFAULT 'pending',
@@ -106,9 +119,247 @@ func (d *Database) init() error {
 		process_started_at INTEGER,
 		created_at INTEGER NOT NULL,
 		completed_at INTEGER,
+		tokens_in INTEGER NOT NULL DEFAULT 0,
+		tokens_out INTEGER NOT NULL DEFAULT 0,
+		cost_usd REAL NOT NULL DEFAULT 0,
+		duration_ms INTEGER NOT NULL DEFAULT 0,
+		FOREIGN KEY (agent_id) REFERENCES agents(id)
+	);
+
+	CREATE TABLE IF NOT EXISTS agent_batches (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		agent_id INTEGER NOT NULL,
+		agent_name TEXT NOT NULL,
+		task TEXT NOT NULL,
+		status TEXT NOT NULL DEFAULT 'pending',
+		concurrency INTEGER NOT NULL DEFAULT 1,
+		items TEXT NOT NULL,
+		created_at INTEGER NOT NULL,
+		completed_at INTEGER,
 		FOREIGN KEY (agent_id) REFERENCES agents(id)
 	);
 
+	CREATE INDEX IF NOT EXISTS idx_agent_batches_agent ON agent_batches(agent_id);
+
+	CREATE TABLE IF NOT EXISTS provider_comparisons (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		project_path TEXT NOT NULL,
+		prompt TEXT NOT NULL,
+		status TEXT NOT NULL DEFAULT 'pending',
+		items TEXT NOT NULL,
+		created_at INTEGER NOT NULL,
+		completed_at INTEGER
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_provider_comparisons_project ON provider_comparisons(project_path);
+
+	CREATE TABLE IF NOT EXISTS pipelines (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL,
+		description TEXT,
+		steps TEXT NOT NULL,
+		created_at INTEGER NOT NULL,
+		updated_at INTEGER NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS pipeline_runs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		pipeline_id INTEGER NOT NULL,
+		pipeline_name TEXT NOT NULL,
+		project_path TEXT NOT NULL,
+		task TEXT NOT NULL,
+		status TEXT NOT NULL DEFAULT 'pending',
+		current_step INTEGER NOT NULL DEFAULT 0,
+		steps TEXT NOT NULL,
+		error TEXT,
+		created_at INTEGER NOT NULL,
+		completed_at INTEGER,
+		FOREIGN KEY (pipeline_id) REFERENCES pipelines(id)
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_pipeline_runs_pipeline ON pipeline_runs(pipeline_id);
+
+	CREATE TABLE IF NOT EXISTS usage_file_cache (
+		path TEXT PRIMARY KEY,
+		size INTEGER NOT NULL,
+		mod_time INTEGER NOT NULL,
+		version INTEGER NOT NULL,
+		entries TEXT NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS codex_session_catalog (
+		path TEXT PRIMARY KEY,
+		session_id TEXT NOT NULL,
+		project_path TEXT NOT NULL,
+		created_at INTEGER NOT NULL,
+		message_timestamp TEXT NOT NULL,
+		first_message TEXT NOT NULL,
+		size INTEGER NOT NULL,
+		mod_time INTEGER NOT NULL,
+		version INTEGER NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS codex_session_dirs (
+		path TEXT PRIMARY KEY,
+		mod_time INTEGER NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS telemetry_metrics (
+		day TEXT NOT NULL,
+		kind TEXT NOT NULL,
+		name TEXT NOT NULL,
+		count INTEGER NOT NULL DEFAULT 0,
+		duration_ms INTEGER NOT NULL DEFAULT 0,
+		PRIMARY KEY (day, kind, name)
+	);
+
+	CREATE TABLE IF NOT EXISTS hook_executions (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		session_id TEXT NOT NULL,
+		project_path TEXT NOT NULL DEFAULT '',
+		hook_event TEXT NOT NULL,
+		matcher TEXT NOT NULL DEFAULT '',
+		command TEXT NOT NULL DEFAULT '',
+		exit_code INTEGER,
+		outcome TEXT NOT NULL DEFAULT '',
+		duration_ms INTEGER NOT NULL DEFAULT 0,
+		output TEXT NOT NULL DEFAULT '',
+		truncated INTEGER NOT NULL DEFAULT 0,
+		started_at DATETIME NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_hook_executions_session ON hook_executions(session_id);
+
+	CREATE TABLE IF NOT EXISTS action_runs (
+		id TEXT PRIMARY KEY,
+		action_id TEXT NOT NULL,
+		action_name TEXT NOT NULL DEFAULT '',
+		scope TEXT NOT NULL DEFAULT '',
+		cwd TEXT NOT NULL DEFAULT '',
+		command TEXT NOT NULL,
+		status TEXT NOT NULL,
+		exit_code INTEGER,
+		output TEXT NOT NULL DEFAULT '',
+		truncated INTEGER NOT NULL DEFAULT 0,
+		started_at DATETIME NOT NULL,
+		finished_at DATETIME
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_action_runs_started ON action_runs(started_at);
+
+	CREATE TABLE IF NOT EXISTS prompt_snippets (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		title TEXT NOT NULL,
+		body TEXT NOT NULL,
+		tags TEXT NOT NULL DEFAULT '[]',
+		usage_count INTEGER NOT NULL DEFAULT 0,
+		last_used_at INTEGER,
+		created_at INTEGER NOT NULL,
+		updated_at INTEGER NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS permission_profiles (
+		id TEXT PRIMARY KEY,
+		name TEXT NOT NULL,
+		description TEXT NOT NULL DEFAULT '',
+		claude_permission_mode TEXT NOT NULL DEFAULT '',
+		claude_allowed_tools TEXT NOT NULL DEFAULT '[]',
+		claude_disallowed_tools TEXT NOT NULL DEFAULT '[]',
+		codex_sandbox TEXT NOT NULL DEFAULT '',
+		codex_network_access INTEGER NOT NULL DEFAULT 1,
+		created_at INTEGER NOT NULL,
+		updated_at INTEGER NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS project_permission_profiles (
+		project_path TEXT PRIMARY KEY,
+		profile_id TEXT NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS session_file_changes (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		session_id TEXT NOT NULL,
+		tool_use_id TEXT NOT NULL DEFAULT '',
+		tool TEXT NOT NULL DEFAULT '',
+		path TEXT NOT NULL,
+		kind TEXT NOT NULL,
+		ranges TEXT NOT NULL DEFAULT '[]',
+		created_at INTEGER NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_session_file_changes_session ON session_file_changes(session_id);
+
+	CREATE TABLE IF NOT EXISTS session_edit_reviews (
+		session_id TEXT NOT NULL,
+		path TEXT NOT NULL,
+		status TEXT NOT NULL,
+		reviewed_at INTEGER NOT NULL,
+		PRIMARY KEY (session_id, path)
+	);
+
+	CREATE TABLE IF NOT EXISTS session_checkpoints (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		session_id TEXT NOT NULL DEFAULT '',
+		provider TEXT NOT NULL,
+		project_path TEXT NOT NULL,
+		commit_sha TEXT NOT NULL,
+		ref TEXT NOT NULL,
+		created_at INTEGER NOT NULL,
+		restored_at INTEGER
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_session_checkpoints_session ON session_checkpoints(session_id);
+	CREATE INDEX IF NOT EXISTS idx_session_checkpoints_project ON session_checkpoints(project_path, created_at);
+
+	CREATE TABLE IF NOT EXISTS project_env_vars (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		project_path TEXT NOT NULL,
+		name TEXT NOT NULL,
+		value TEXT NOT NULL DEFAULT '',
+		secret INTEGER NOT NULL DEFAULT 0,
+		updated_at INTEGER NOT NULL,
+		UNIQUE(project_path, name)
+	);
+
+	CREATE TABLE IF NOT EXISTS model_pricing (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		model_pattern TEXT NOT NULL,
+		input_price REAL NOT NULL DEFAULT 0,
+		output_price REAL NOT NULL DEFAULT 0,
+		cache_write_price REAL NOT NULL DEFAULT 0,
+		cache_read_price REAL NOT NULL DEFAULT 0,
+		effective_date TEXT NOT NULL DEFAULT '',
+		source TEXT NOT NULL DEFAULT 'user',
+		updated_at INTEGER NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS model_capabilities (
+		model_pattern TEXT PRIMARY KEY,
+		context_window INTEGER NOT NULL DEFAULT 0,
+		max_output_tokens INTEGER NOT NULL DEFAULT 0,
+		supports_vision INTEGER NOT NULL DEFAULT 0,
+		supports_thinking INTEGER NOT NULL DEFAULT 0,
+		pricing_pattern TEXT NOT NULL DEFAULT '',
+		last_verified TEXT NOT NULL DEFAULT ''
+	);
+
+	CREATE TABLE IF NOT EXISTS provider_api_failover (
+		provider_id TEXT NOT NULL,
+		provider_api_id TEXT NOT NULL,
+		position INTEGER NOT NULL,
+		PRIMARY KEY (provider_id, provider_api_id)
+	);
+
+	CREATE TABLE IF NOT EXISTS session_provider_apis (
+		session_id TEXT PRIMARY KEY,
+		provider TEXT NOT NULL,
+		project_path TEXT NOT NULL,
+		provider_api_id TEXT NOT NULL,
+		failed_over_from TEXT NOT NULL DEFAULT '',
+		reason TEXT NOT NULL DEFAULT '',
+		created_at INTEGER NOT NULL
+	);
+
 	CREATE TABLE IF NOT EXISTS model_configs (
 		id TEXT PRIMARY KEY,
 		model_id TEXT NOT NULL,
@@ -128,7 +379,85 @@ func (d *Database) init() error {
 	CREATE INDEX IF NOT EXISTS idx_model_configs_default ON model_configs(is_default);
 	`
 
-	_, err := d.db.Exec(schema)
+	if _, err := d.db.Exec(schema); err != nil {
+		return err
+	}
+	return d.migrate()
+}
+
+// schemaColumn describes a column added after the initial schema shipped.
+type schemaColumn struct {
+	table      string
+	column     string
+	definition string
+}
+
+// addedColumns lists columns that older databases may be missing. CREATE
+// TABLE IF NOT EXISTS leaves existing tables untouched, so these are added
+// with ALTER TABLE on open.
+var addedColumns = []schemaColumn{
+	{"agents", "provider", "TEXT NOT NULL DEFAULT 'claude'"},
+	{"agents", "source_url", "TEXT NOT NULL DEFAULT ''"},
+	{"agent_runs", "provider", "TEXT NOT NULL DEFAULT 'claude'"},
+	{"agent_runs", "tokens_in", "INTEGER NOT NULL DEFAULT 0"},
+	{"agent_runs", "tokens_out", "INTEGER NOT NULL DEFAULT 0"},
+	{"agent_runs", "cost_usd", "REAL NOT NULL DEFAULT 0"},
+	{"agent_runs", "duration_ms", "INTEGER NOT NULL DEFAULT 0"},
+}
+
+// SchemaVersion is the schema this build creates: the initial schema plus
+// one for each added column. Migrated databases record it as user_version.
+func SchemaVersion() int {
+	return 1 + len(addedColumns)
+}
+
+// migrate applies additive schema changes to databases created by older versions
+func (d *Database) migrate() error {
+	for _, c := range addedColumns {
+		if err := d.ensureColumn(c.table, c.column, c.definition); err != nil {
+			return fmt.Errorf("migrate %s.%s: %w", c.table, c.column, err)
+		}
+	}
+	// A newer build may have migrated further; never lower the version
+	if version, err := d.UserVersion(); err != nil || version >= SchemaVersion() {
+		return err
+	}
+	_, err := d.db.Exec(fmt.Sprintf("PRAGMA user_version = %d", SchemaVersion()))
+	return err
+}
+
+// UserVersion returns the schema version recorded in the database file
+func (d *Database) UserVersion() (int, error) {
+	var version int
+	err := d.db.QueryRow("PRAGMA user_version").Scan(&version)
+	return version, err
+}
+
+// ensureColumn adds column to table unless it already exists
+func (d *Database) ensureColumn(table, column, definition string) error {
+	rows, err := d.db.Query("PRAGMA table_info(" + table + ")")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var cid, notNull, pk int
+		var name, colType string
+		var defaultValue sql.NullString
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &defaultValue, &pk); err != nil {
+			return err
+		}
+		if name == column {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	rows.Close()
+
+	_, err = d.db.Exec("ALTER TABLE " + table + " ADD COLUMN " + column + " " + definition)
 	return err
 }
 
@@ -198,12 +527,101 @@ func (d *Database) GetAllProviderApiConfigs() ([]*ProviderApiConfig, error) {
 	return configs, rows.Err()
 }
 
-// DeleteProviderApiConfig deletes a provider API config by ID
+// DeleteProviderApiConfig deletes a provider API config by ID and drops it
+// from its failover chain
 func (d *Database) DeleteProviderApiConfig(id string) error {
-	_, err := d.db.Exec("DELETE FROM provider_api_configs WHERE id = ?", id)
+	tx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM provider_api_failover WHERE provider_api_id = ?", id); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM provider_api_configs WHERE id = ?", id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetProviderFailoverChain returns the IDs of the provider API configs a
+// provider's sessions fail over through, in order
+func (d *Database) GetProviderFailoverChain(providerID string) ([]string, error) {
+	rows, err := d.db.Query(`
+		SELECT provider_api_id FROM provider_api_failover
+		WHERE provider_id = ? ORDER BY position`, providerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	chain := make([]string, 0)
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		chain = append(chain, id)
+	}
+	return chain, rows.Err()
+}
+
+// SetProviderFailoverChain replaces the failover chain of a provider; an
+// empty chain turns failover off
+func (d *Database) SetProviderFailoverChain(providerID string, providerApiIDs []string) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM provider_api_failover WHERE provider_id = ?", providerID); err != nil {
+		return err
+	}
+	for i, id := range providerApiIDs {
+		if _, err := tx.Exec(`
+			INSERT INTO provider_api_failover (provider_id, provider_api_id, position) VALUES (?, ?, ?)`,
+			providerID, id, i); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// RecordSessionProviderApi records which provider API config a session
+// was started with
+func (d *Database) RecordSessionProviderApi(record *SessionProviderApi) error {
+	record.CreatedAt = time.Now()
+	_, err := d.db.Exec(`
+		INSERT OR REPLACE INTO session_provider_apis
+		(session_id, provider, project_path, provider_api_id, failed_over_from, reason, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		record.SessionID, record.Provider, record.ProjectPath, record.ProviderApiID,
+		record.FailedOverFrom, record.Reason, record.CreatedAt.Unix())
 	return err
 }
 
+// GetSessionProviderApi returns the provider API config record of a
+// session, nil when none was recorded
+func (d *Database) GetSessionProviderApi(sessionID string) (*SessionProviderApi, error) {
+	record := &SessionProviderApi{}
+	var createdAt int64
+	err := d.db.QueryRow(`
+		SELECT session_id, provider, project_path, provider_api_id, failed_over_from, reason, created_at
+		FROM session_provider_apis WHERE session_id = ?`, sessionID).Scan(
+		&record.SessionID, &record.Provider, &record.ProjectPath, &record.ProviderApiID,
+		&record.FailedOverFrom, &record.Reason, &createdAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	record.CreatedAt = time.Unix(createdAt, 0)
+	return record, nil
+}
+
 // GetDefaultProviderApiConfig retrieves the default config for a provider
 // Returns nil if no default config found (no error)
 func (d *Database) GetDefaultProviderApiConfig(providerID string) (*ProviderApiConfig, error) {
@@ -247,8 +665,117 @@ func (d *Database) GetSetting(key string) (string, error) {
 	return value, nil
 }
 
-// SaveProjectIndex saves a project index
+// GetAllSettings retrieves all settings as a key/value map
+func (d *Database) GetAllSettings() (map[string]string, error) {
+	rows, err := d.db.Query("SELECT key, value FROM settings ORDER BY key")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	settings := make(map[string]string)
+	for rows.Next() {
+		var key, value string
+		if err := rows.Scan(&key, &value); err != nil {
+			return nil, err
+		}
+		settings[key] = value
+	}
+	return settings, rows.Err()
+}
+
+// SaveProjectIndex saves a project index, replacing the stored one. Use
+// PatchProjectIndex to change part of an index.
 func (d *Database) SaveProjectIndex(project *ProjectIndex) error {
+	defer d.lockProject(project.Name)()
+	return d.saveProjectIndex(project)
+}
+
+// PatchProjectIndex applies patch to the stored index of project name and
+// saves the result. Patches and saves of one project run one at a time, so
+// concurrent updates don't lose each other's changes. When patch returns an
+// error the index is left as it was.
+func (d *Database) PatchProjectIndex(name string, patch func(*ProjectIndex) error) (*ProjectIndex, error) {
+	defer d.lockProject(name)()
+	project, err := d.GetProjectIndex(name)
+	if err != nil {
+		return nil, err
+	}
+	if err := patch(project); err != nil {
+		return nil, err
+	}
+	if err := d.saveProjectIndex(project); err != nil {
+		return nil, err
+	}
+	return project, nil
+}
+
+// CreateOrPatchProjectIndex saves the index create returns when project
+// name has none, and otherwise patches the stored one like
+// PatchProjectIndex. The check and the write happen under the project's
+// lock, so concurrent calls create the index once.
+func (d *Database) CreateOrPatchProjectIndex(name string, create func() *ProjectIndex, patch func(*ProjectIndex) error) (*ProjectIndex, error) {
+	defer d.lockProject(name)()
+	project, err := d.GetProjectIndex(name)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		project = create()
+	case err != nil:
+		return nil, err
+	default:
+		if err := patch(project); err != nil {
+			return nil, err
+		}
+	}
+	if err := d.saveProjectIndex(project); err != nil {
+		return nil, err
+	}
+	return project, nil
+}
+
+// PatchWorkspaceIndex applies patch to workspace name and the project
+// holding it, like PatchProjectIndex
+func (d *Database) PatchWorkspaceIndex(name string, patch func(*ProjectIndex, *WorkspaceIndex) error) (*ProjectIndex, error) {
+	projects, err := d.GetAllProjectIndexes()
+	if err != nil {
+		return nil, err
+	}
+	for _, project := range projects {
+		for _, workspace := range project.Workspaces {
+			if workspace.Name != name {
+				continue
+			}
+			return d.PatchProjectIndex(project.Name, func(project *ProjectIndex) error {
+				// Find the workspace again in the index as it is now
+				for i := range project.Workspaces {
+					if project.Workspaces[i].Name == name {
+						return patch(project, &project.Workspaces[i])
+					}
+				}
+				return fmt.Errorf("%w: %s", ErrWorkspaceNotFound, name)
+			})
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrWorkspaceNotFound, name)
+}
+
+// lockProject locks the project index name and returns the unlock
+func (d *Database) lockProject(name string) func() {
+	d.projectLocksMu.Lock()
+	if d.projectLocks == nil {
+		d.projectLocks = make(map[string]*sync.Mutex)
+	}
+	lock, ok := d.projectLocks[name]
+	if !ok {
+		lock = &sync.Mutex{}
+		d.projectLocks[name] = lock
+	}
+	d.projectLocksMu.Unlock()
+	lock.Lock()
+	return lock.Unlock
+}
+
+func (d *Database) saveProjectIndex(project *ProjectIndex) error {
 	data, err := json.Marshal(project)
 	if err != nil {
 		return err
@@ -299,6 +826,7 @@ func (d *Database) GetAllProjectIndexes() ([]*ProjectIndex, error) {
 
 // DeleteProjectIndex deletes a project index by name
 func (d *Database) DeleteProjectIndex(name string) error {
+	defer d.lockProject(name)()
 	_, err := d.db.Exec("DELETE FROM project_indexes WHERE name = ?", name)
 	return err
 }
@@ -405,7 +933,7 @@ func scanInstanceRecord(scanner interface{ Scan(...any) error }) (*InstanceRecor
 // ListAgents retrieves all agents from the database
 func (d *Database) ListAgents() ([]*Agent, error) {
 	rows, err := d.db.Query(`
-		SELECT id, name, icon, system_prompt, default_task, model, provider_api_id, hooks, created_at, updated_at
+		SELECT id, name, icon, system_prompt, default_task, model, provider, provider_api_id, hooks, source_url, created_at, updated_at
 		FROM agents ORDER BY name`)
 	if err != nil {
 		return nil, err
@@ -417,7 +945,7 @@ func (d *Database) ListAgents() ([]*Agent, error) {
 		agent := &Agent{}
 		var createdAt, updatedAt int64
 		err := rows.Scan(&agent.ID, &agent.Name, &agent.Icon, &agent.SystemPrompt,
-			&agent.DefaultTask, &agent.Model, &agent.ProviderApiID, &agent.Hooks,
+			&agent.DefaultTask, &agent.Model, &agent.Provider, &agent.ProviderApiID, &agent.Hooks, &agent.SourceURL,
 			&createdAt, &updatedAt)
 		if err != nil {
 			return nil, err
@@ -432,13 +960,13 @@ func (d *Database) ListAgents() ([]*Agent, error) {
 // GetAgent retrieves an agent by ID
 func (d *Database) GetAgent(id int64) (*Agent, error) {
 	row := d.db.QueryRow(`
-		SELECT id, name, icon, system_prompt, default_task, model, provider_api_id, hooks, created_at, updated_at
+		SELECT id, name, icon, system_prompt, default_task, model, provider, provider_api_id, hooks, source_url, created_at, updated_at
 		FROM agents WHERE id = ?`, id)
 
 	agent := &Agent{}
 	var createdAt, updatedAt int64
 	err := row.Scan(&agent.ID, &agent.Name, &agent.Icon, &agent.SystemPrompt,
-		&agent.DefaultTask, &agent.Model, &agent.ProviderApiID, &agent.Hooks,
+		&agent.DefaultTask, &agent.Model, &agent.Provider, &agent.ProviderApiID, &agent.Hooks, &agent.SourceURL,
 		&createdAt, &updatedAt)
 	if err != nil {
 		return nil, err
@@ -453,12 +981,15 @@ func (d *Database) CreateAgent(agent *Agent) (int64, error) {
 	now := time.Now()
 	agent.CreatedAt = now
 	agent.UpdatedAt = now
+	if agent.Provider == "" {
+		agent.Provider = DefaultAgentProvider
+	}
 
 	result, err := d.db.Exec(`
-		INSERT INTO agents (name, icon, system_prompt, default_task, model, provider_api_id, hooks, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
-		agent.Name, agent.Icon, agent.SystemPrompt, agent.DefaultTask, agent.Model,
-		agent.ProviderApiID, agent.Hooks, agent.CreatedAt.Unix(), agent.UpdatedAt.Unix())
+		INSERT INTO agents (name, icon, system_prompt, default_task, model, provider, provider_api_id, hooks, source_url, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		agent.Name, agent.Icon, agent.SystemPrompt, agent.DefaultTask, agent.Model, agent.Provider,
+		agent.ProviderApiID, agent.Hooks, agent.SourceURL, agent.CreatedAt.Unix(), agent.UpdatedAt.Unix())
 	if err != nil {
 		return 0, err
 	}
@@ -474,12 +1005,15 @@ func (d *Database) CreateAgent(agent *Agent) (int64, error) {
 // UpdateAgent updates an existing agent in the database
 func (d *Database) UpdateAgent(agent *Agent) error {
 	agent.UpdatedAt = time.Now()
+	if agent.Provider == "" {
+		agent.Provider = DefaultAgentProvider
+	}
 
 	_, err := d.db.Exec(`
 		UPDATE agents SET name = ?, icon = ?, system_prompt = ?, default_task = ?,
-		model = ?, provider_api_id = ?, hooks = ?, updated_at = ?
+		model = ?, provider = ?, provider_api_id = ?, hooks = ?, updated_at = ?
 		WHERE id = ?`,
-		agent.Name, agent.Icon, agent.SystemPrompt, agent.DefaultTask, agent.Model,
+		agent.Name, agent.Icon, agent.SystemPrompt, agent.DefaultTask, agent.Model, agent.Provider,
 		agent.ProviderApiID, agent.Hooks, agent.UpdatedAt.Unix(), agent.ID)
 	return err
 }
@@ -500,6 +1034,7 @@ type AgentExport struct {
 		SystemPrompt string `json:"system_prompt"`
 		DefaultTask  string `json:"default_task,omitempty"`
 		Model        string `json:"model"`
+		Provider     string `json:"provider,omitempty"`
 		Hooks        string `json:"hooks,omitempty"`
 	} `json:"agent"`
 }
@@ -520,6 +1055,7 @@ func (d *Database) ExportAgent(id int64) (string, error) {
 	export.Agent.SystemPrompt = agent.SystemPrompt
 	export.Agent.DefaultTask = agent.DefaultTask
 	export.Agent.Model = agent.Model
+	export.Agent.Provider = agent.Provider
 	export.Agent.Hooks = agent.Hooks
 
 	data, err := json.MarshalIndent(export, "", "  ")
@@ -553,6 +1089,7 @@ func (d *Database) ImportAgent(data string) (*Agent, error) {
 		SystemPrompt: export.Agent.SystemPrompt,
 		DefaultTask:  export.Agent.DefaultTask,
 		Model:        export.Agent.Model,
+		Provider:     export.Agent.Provider,
 		Hooks:        export.Agent.Hooks,
 	}
 
@@ -581,11 +1118,14 @@ func (d *Database) ImportAgentFromFile(path string) (*Agent, error) {
 func (d *Database) CreateAgentRun(run *AgentRun) (int64, error) {
 	now := time.Now()
 	run.CreatedAt = now
+	if run.Provider == "" {
+		run.Provider = DefaultAgentProvider
+	}
 
 	result, err := d.db.Exec(`
-		INSERT INTO agent_runs (agent_id, agent_name, agent_icon, task, model, project_path, session_id, status, pid, process_started_at, created_at, completed_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
-		run.AgentID, run.AgentName, run.AgentIcon, run.Task, run.Model, run.ProjectPath,
+		INSERT INTO agent_runs (agent_id, agent_name, agent_icon, task, model, provider, project_path, session_id, status, pid, process_started_at, created_at, completed_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		run.AgentID, run.AgentName, run.AgentIcon, run.Task, run.Model, run.Provider, run.ProjectPath,
 		run.SessionID, run.Status, run.PID, nullableTime(run.ProcessStartedAt),
 		run.CreatedAt.Unix(), nullableTime(run.CompletedAt))
 	if err != nil {
@@ -603,7 +1143,8 @@ func (d *Database) CreateAgentRun(run *AgentRun) (int64, error) {
 // GetAgentRun retrieves an agent run by ID
 func (d *Database) GetAgentRun(id int64) (*AgentRun, error) {
 	row := d.db.QueryRow(`
-		SELECT id, agent_id, agent_name, agent_icon, task, model, project_path, session_id, status, pid, process_started_at, created_at, completed_at
+		SELECT id, agent_id, agent_name, agent_icon, task, model, provider, project_path, session_id, status, pid, process_started_at, created_at, completed_at,
+		tokens_in, tokens_out, cost_usd, duration_ms
 		FROM agent_runs WHERE id = ?`, id)
 
 	return scanAgentRun(row)
@@ -612,7 +1153,8 @@ func (d *Database) GetAgentRun(id int64) (*AgentRun, error) {
 // GetAgentRunBySessionID retrieves an agent run by session ID
 func (d *Database) GetAgentRunBySessionID(sessionID string) (*AgentRun, error) {
 	row := d.db.QueryRow(`
-		SELECT id, agent_id, agent_name, agent_icon, task, model, project_path, session_id, status, pid, process_started_at, created_at, completed_at
+		SELECT id, agent_id, agent_name, agent_icon, task, model, provider, project_path, session_id, status, pid, process_started_at, created_at, completed_at,
+		tokens_in, tokens_out, cost_usd, duration_ms
 		FROM agent_runs WHERE session_id = ?`, sessionID)
 
 	return scanAgentRun(row)
@@ -624,11 +1166,13 @@ func (d *Database) ListAgentRuns(agentID *int64, limit int) ([]*AgentRun, error)
 	var args []interface{}
 
 	if agentID != nil {
-		query = `SELECT id, agent_id, agent_name, agent_icon, task, model, project_path, session_id, status, pid, process_started_at, created_at, completed_at
+		query = `SELECT id, agent_id, agent_name, agent_icon, task, model, provider, project_path, session_id, status, pid, process_started_at, created_at, completed_at,
+			tokens_in, tokens_out, cost_usd, duration_ms
 			FROM agent_runs WHERE agent_id = ? ORDER BY created_at DESC LIMIT ?`
 		args = []interface{}{*agentID, limit}
 	} else {
-		query = `SELECT id, agent_id, agent_name, agent_icon, task, model, project_path, session_id, status, pid, process_started_at, created_at, completed_at
+		query = `SELECT id, agent_id, agent_name, agent_icon, task, model, provider, project_path, session_id, status, pid, process_started_at, created_at, completed_at,
+			tokens_in, tokens_out, cost_usd, duration_ms
 			FROM agent_runs ORDER BY created_at DESC LIMIT ?`
 		args = []interface{}{limit}
 	}
@@ -653,7 +1197,8 @@ func (d *Database) ListAgentRuns(agentID *int64, limit int) ([]*AgentRun, error)
 // ListRunningAgentRuns retrieves all currently running agent runs
 func (d *Database) ListRunningAgentRuns() ([]*AgentRun, error) {
 	rows, err := d.db.Query(`
-		SELECT id, agent_id, agent_name, agent_icon, task, model, project_path, session_id, status, pid, process_started_at, created_at, completed_at
+		SELECT id, agent_id, agent_name, agent_icon, task, model, provider, project_path, session_id, status, pid, process_started_at, created_at, completed_at,
+		tokens_in, tokens_out, cost_usd, duration_ms
 		FROM agent_runs WHERE status = 'running' ORDER BY created_at DESC`)
 	if err != nil {
 		return nil, err
@@ -680,6 +1225,64 @@ func (d *Database) UpdateAgentRunStatus(id int64, status string, pid int, proces
 	return err
 }
 
+// UpdateAgentRunMetrics records token usage, cost and duration for a finished run
+func (d *Database) UpdateAgentRunMetrics(id int64, metrics AgentRunMetrics) error {
+	_, err := d.db.Exec(`
+		UPDATE agent_runs SET tokens_in = ?, tokens_out = ?, cost_usd = ?, duration_ms = ?
+		WHERE id = ?`,
+		metrics.InputTokens, metrics.OutputTokens, metrics.CostUSD, metrics.DurationMS, id)
+	return err
+}
+
+// GetAgentMetricsSummaries aggregates run metrics per agent, optionally for a
+// single agent. Only finished runs are counted.
+func (d *Database) GetAgentMetricsSummaries(agentID *int64) ([]*AgentMetricsSummary, error) {
+	query := `
+		SELECT agent_id, MAX(agent_name), COUNT(*),
+			SUM(CASE WHEN status = 'completed' THEN 1 ELSE 0 END),
+			SUM(CASE WHEN status = 'failed' THEN 1 ELSE 0 END),
+			SUM(CASE WHEN status = 'cancelled' THEN 1 ELSE 0 END),
+			SUM(tokens_in), SUM(tokens_out), SUM(cost_usd), SUM(duration_ms)
+		FROM agent_runs WHERE completed_at IS NOT NULL`
+	var args []interface{}
+	if agentID != nil {
+		query += ` AND agent_id = ?`
+		args = append(args, *agentID)
+	}
+	query += ` GROUP BY agent_id ORDER BY agent_id`
+
+	rows, err := d.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	summaries := make([]*AgentMetricsSummary, 0)
+	for rows.Next() {
+		summary := &AgentMetricsSummary{}
+		err := rows.Scan(&summary.AgentID, &summary.AgentName, &summary.RunCount,
+			&summary.CompletedRuns, &summary.FailedRuns, &summary.CancelledRuns,
+			&summary.InputTokens, &summary.OutputTokens, &summary.CostUSD, &summary.DurationMS)
+		if err != nil {
+			return nil, err
+		}
+		summary.TotalTokens = summary.InputTokens + summary.OutputTokens
+		if summary.RunCount > 0 {
+			summary.AvgDurationMS = summary.DurationMS / int64(summary.RunCount)
+			summary.AvgTokens = summary.TotalTokens / int64(summary.RunCount)
+			summary.AvgCostUSD = summary.CostUSD / float64(summary.RunCount)
+		}
+		summaries = append(summaries, summary)
+	}
+	return summaries, rows.Err()
+}
+
+// UpdateAgentRunSession links an agent run to the provider session executing it
+func (d *Database) UpdateAgentRunSession(id int64, sessionID string) error {
+	_, err := d.db.Exec(`UPDATE agent_runs SET session_id = ? WHERE id = ?`, sessionID, id)
+	return err
+}
+
 // DeleteAgentRun deletes an agent run by ID
 func (d *Database) DeleteAgentRun(id int64) error {
 	_, err := d.db.Exec("DELETE FROM agent_runs WHERE id = ?", id)
@@ -692,9 +1295,1352 @@ func (d *Database) DeleteAgentRunsByAgentID(agentID int64) error {
 	return err
 }
 
-// Helper functions
+// ===== AgentBatch CRUD =====
 
-func nullableTime(t *time.Time) interface{} {
+// CreateAgentBatch creates a new agent batch record
+func (d *Database) CreateAgentBatch(batch *AgentBatch) (int64, error) {
+	items, err := json.Marshal(agentBatchItems(batch.Items))
+	if err != nil {
+		return 0, err
+	}
+	batch.CreatedAt = time.Now()
+
+	result, err := d.db.Exec(`
+		INSERT INTO agent_batches (agent_id, agent_name, task, status, concurrency, items, created_at, completed_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		batch.AgentID, batch.AgentName, batch.Task, batch.Status, batch.Concurrency, string(items),
+		batch.CreatedAt.Unix(), nullableTime(batch.CompletedAt))
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	batch.ID = id
+	return id, nil
+}
+
+// UpdateAgentBatch persists the progress of an agent batch
+func (d *Database) UpdateAgentBatch(batch *AgentBatch) error {
+	items, err := json.Marshal(agentBatchItems(batch.Items))
+	if err != nil {
+		return err
+	}
+	_, err = d.db.Exec(`
+		UPDATE agent_batches SET status = ?, items = ?, completed_at = ?
+		WHERE id = ?`,
+		batch.Status, string(items), nullableTime(batch.CompletedAt), batch.ID)
+	return err
+}
+
+// GetAgentBatch retrieves an agent batch by ID
+func (d *Database) GetAgentBatch(id int64) (*AgentBatch, error) {
+	row := d.db.QueryRow(`
+		SELECT id, agent_id, agent_name, task, status, concurrency, items, created_at, completed_at
+		FROM agent_batches WHERE id = ?`, id)
+	return scanAgentBatch(row)
+}
+
+// ListAgentBatches retrieves agent batches, optionally filtered by agent ID
+func (d *Database) ListAgentBatches(agentID *int64, limit int) ([]*AgentBatch, error) {
+	var query string
+	var args []interface{}
+
+	if agentID != nil {
+		query = `SELECT id, agent_id, agent_name, task, status, concurrency, items, created_at, completed_at
+			FROM agent_batches WHERE agent_id = ? ORDER BY created_at DESC, id DESC LIMIT ?`
+		args = []interface{}{*agentID, limit}
+	} else {
+		query = `SELECT id, agent_id, agent_name, task, status, concurrency, items, created_at, completed_at
+			FROM agent_batches ORDER BY created_at DESC, id DESC LIMIT ?`
+		args = []interface{}{limit}
+	}
+
+	rows, err := d.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	batches := make([]*AgentBatch, 0)
+	for rows.Next() {
+		batch, err := scanAgentBatch(rows)
+		if err != nil {
+			return nil, err
+		}
+		batches = append(batches, batch)
+	}
+	return batches, rows.Err()
+}
+
+// DeleteAgentBatch deletes an agent batch record; its agent runs are kept
+func (d *Database) DeleteAgentBatch(id int64) error {
+	_, err := d.db.Exec("DELETE FROM agent_batches WHERE id = ?", id)
+	return err
+}
+
+// ===== Provider Comparison CRUD =====
+
+// CreateProviderComparison creates a new provider comparison record
+func (d *Database) CreateProviderComparison(comparison *ProviderComparison) (int64, error) {
+	items, err := json.Marshal(providerComparisonItems(comparison.Items))
+	if err != nil {
+		return 0, err
+	}
+	comparison.CreatedAt = time.Now()
+
+	result, err := d.db.Exec(`
+		INSERT INTO provider_comparisons (project_path, prompt, status, items, created_at, completed_at)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		comparison.ProjectPath, comparison.Prompt, comparison.Status, string(items),
+		comparison.CreatedAt.Unix(), nullableTime(comparison.CompletedAt))
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	comparison.ID = id
+	return id, nil
+}
+
+// UpdateProviderComparison persists the progress of a provider comparison
+func (d *Database) UpdateProviderComparison(comparison *ProviderComparison) error {
+	items, err := json.Marshal(providerComparisonItems(comparison.Items))
+	if err != nil {
+		return err
+	}
+	_, err = d.db.Exec(`
+		UPDATE provider_comparisons SET status = ?, items = ?, completed_at = ?
+		WHERE id = ?`,
+		comparison.Status, string(items), nullableTime(comparison.CompletedAt), comparison.ID)
+	return err
+}
+
+// GetProviderComparison retrieves a provider comparison by ID
+func (d *Database) GetProviderComparison(id int64) (*ProviderComparison, error) {
+	row := d.db.QueryRow(`
+		SELECT id, project_path, prompt, status, items, created_at, completed_at
+		FROM provider_comparisons WHERE id = ?`, id)
+	return scanProviderComparison(row)
+}
+
+// ListProviderComparisons retrieves provider comparisons, newest first,
+// optionally filtered by project path
+func (d *Database) ListProviderComparisons(projectPath string, limit int) ([]*ProviderComparison, error) {
+	query := `SELECT id, project_path, prompt, status, items, created_at, completed_at
+		FROM provider_comparisons`
+	var args []interface{}
+	if projectPath != "" {
+		query += " WHERE project_path = ?"
+		args = append(args, projectPath)
+	}
+	query += " ORDER BY created_at DESC, id DESC LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := d.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	comparisons := make([]*ProviderComparison, 0)
+	for rows.Next() {
+		comparison, err := scanProviderComparison(rows)
+		if err != nil {
+			return nil, err
+		}
+		comparisons = append(comparisons, comparison)
+	}
+	return comparisons, rows.Err()
+}
+
+// DeleteProviderComparison deletes a provider comparison record; its
+// worktrees and sessions are kept
+func (d *Database) DeleteProviderComparison(id int64) error {
+	_, err := d.db.Exec("DELETE FROM provider_comparisons WHERE id = ?", id)
+	return err
+}
+
+// ===== Pipeline CRUD =====
+
+// ListPipelines retrieves all pipelines ordered by name
+func (d *Database) ListPipelines() ([]*Pipeline, error) {
+	rows, err := d.db.Query(`
+		SELECT id, name, description, steps, created_at, updated_at
+		FROM pipelines ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	pipelines := make([]*Pipeline, 0)
+	for rows.Next() {
+		pipeline, err := scanPipeline(rows)
+		if err != nil {
+			return nil, err
+		}
+		pipelines = append(pipelines, pipeline)
+	}
+	return pipelines, rows.Err()
+}
+
+// GetPipeline retrieves a pipeline by ID
+func (d *Database) GetPipeline(id int64) (*Pipeline, error) {
+	row := d.db.QueryRow(`
+		SELECT id, name, description, steps, created_at, updated_at
+		FROM pipelines WHERE id = ?`, id)
+	return scanPipeline(row)
+}
+
+// CreatePipeline creates a new pipeline
+func (d *Database) CreatePipeline(pipeline *Pipeline) (int64, error) {
+	steps, err := json.Marshal(pipelineSteps(pipeline.Steps))
+	if err != nil {
+		return 0, err
+	}
+	now := time.Now()
+	pipeline.CreatedAt = now
+	pipeline.UpdatedAt = now
+
+	result, err := d.db.Exec(`
+		INSERT INTO pipelines (name, description, steps, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?)`,
+		pipeline.Name, pipeline.Description, string(steps), now.Unix(), now.Unix())
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	pipeline.ID = id
+	return id, nil
+}
+
+// UpdatePipeline updates an existing pipeline
+func (d *Database) UpdatePipeline(pipeline *Pipeline) error {
+	steps, err := json.Marshal(pipelineSteps(pipeline.Steps))
+	if err != nil {
+		return err
+	}
+	pipeline.UpdatedAt = time.Now()
+
+	_, err = d.db.Exec(`
+		UPDATE pipelines SET name = ?, description = ?, steps = ?, updated_at = ?
+		WHERE id = ?`,
+		pipeline.Name, pipeline.Description, string(steps), pipeline.UpdatedAt.Unix(), pipeline.ID)
+	return err
+}
+
+// DeletePipeline deletes a pipeline and its run history
+func (d *Database) DeletePipeline(id int64) error {
+	if _, err := d.db.Exec("DELETE FROM pipeline_runs WHERE pipeline_id = ?", id); err != nil {
+		return err
+	}
+	_, err := d.db.Exec("DELETE FROM pipelines WHERE id = ?", id)
+	return err
+}
+
+// CreatePipelineRun creates a new pipeline run record
+func (d *Database) CreatePipelineRun(run *PipelineRun) (int64, error) {
+	steps, err := json.Marshal(pipelineStepRuns(run.Steps))
+	if err != nil {
+		return 0, err
+	}
+	run.CreatedAt = time.Now()
+
+	result, err := d.db.Exec(`
+		INSERT INTO pipeline_runs (pipeline_id, pipeline_name, project_path, task, status, current_step, steps, error, created_at, completed_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		run.PipelineID, run.PipelineName, run.ProjectPath, run.Task, run.Status, run.CurrentStep,
+		string(steps), run.Error, run.CreatedAt.Unix(), nullableTime(run.CompletedAt))
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	run.ID = id
+	return id, nil
+}
+
+// UpdatePipelineRun persists the progress of a pipeline run
+func (d *Database) UpdatePipelineRun(run *PipelineRun) error {
+	steps, err := json.Marshal(pipelineStepRuns(run.Steps))
+	if err != nil {
+		return err
+	}
+	_, err = d.db.Exec(`
+		UPDATE pipeline_runs SET status = ?, current_step = ?, steps = ?, error = ?, completed_at = ?
+		WHERE id = ?`,
+		run.Status, run.CurrentStep, string(steps), run.Error, nullableTime(run.CompletedAt), run.ID)
+	return err
+}
+
+// GetPipelineRun retrieves a pipeline run by ID
+func (d *Database) GetPipelineRun(id int64) (*PipelineRun, error) {
+	row := d.db.QueryRow(`
+		SELECT id, pipeline_id, pipeline_name, project_path, task, status, current_step, steps, error, created_at, completed_at
+		FROM pipeline_runs WHERE id = ?`, id)
+	return scanPipelineRun(row)
+}
+
+// ListPipelineRuns retrieves pipeline runs, optionally filtered by pipeline ID
+func (d *Database) ListPipelineRuns(pipelineID *int64, limit int) ([]*PipelineRun, error) {
+	var query string
+	var args []interface{}
+
+	if pipelineID != nil {
+		query = `SELECT id, pipeline_id, pipeline_name, project_path, task, status, current_step, steps, error, created_at, completed_at
+			FROM pipeline_runs WHERE pipeline_id = ? ORDER BY created_at DESC, id DESC LIMIT ?`
+		args = []interface{}{*pipelineID, limit}
+	} else {
+		query = `SELECT id, pipeline_id, pipeline_name, project_path, task, status, current_step, steps, error, created_at, completed_at
+			FROM pipeline_runs ORDER BY created_at DESC, id DESC LIMIT ?`
+		args = []interface{}{limit}
+	}
+
+	rows, err := d.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	runs := make([]*PipelineRun, 0)
+	for rows.Next() {
+		run, err := scanPipelineRun(rows)
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, run)
+	}
+	return runs, rows.Err()
+}
+
+// Helper functions
+
+// ListModelPricing returns every pricing row
+func (d *Database) ListModelPricing() ([]*ModelPricing, error) {
+	rows, err := d.db.Query(`
+		SELECT id, model_pattern, input_price, output_price, cache_write_price, cache_read_price,
+			effective_date, source, updated_at
+		FROM model_pricing ORDER BY model_pattern, effective_date, id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var prices []*ModelPricing
+	for rows.Next() {
+		price, err := scanModelPricing(rows)
+		if err != nil {
+			return nil, err
+		}
+		prices = append(prices, price)
+	}
+	return prices, rows.Err()
+}
+
+// GetModelPricing retrieves a pricing row by ID
+func (d *Database) GetModelPricing(id int64) (*ModelPricing, error) {
+	row := d.db.QueryRow(`
+		SELECT id, model_pattern, input_price, output_price, cache_write_price, cache_read_price,
+			effective_date, source, updated_at
+		FROM model_pricing WHERE id = ?`, id)
+	return scanModelPricing(row)
+}
+
+// SaveModelPricing inserts a pricing row when its ID is 0 and updates it
+// otherwise
+func (d *Database) SaveModelPricing(price *ModelPricing) error {
+	price.UpdatedAt = time.Now().Unix()
+	if price.ID == 0 {
+		result, err := d.db.Exec(`
+			INSERT INTO model_pricing (model_pattern, input_price, output_price, cache_write_price,
+				cache_read_price, effective_date, source, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+			price.ModelPattern, price.InputPrice, price.OutputPrice, price.CacheWritePrice,
+			price.CacheReadPrice, price.EffectiveDate, price.Source, price.UpdatedAt)
+		if err != nil {
+			return err
+		}
+		price.ID, err = result.LastInsertId()
+		return err
+	}
+	_, err := d.db.Exec(`
+		UPDATE model_pricing SET model_pattern = ?, input_price = ?, output_price = ?,
+			cache_write_price = ?, cache_read_price = ?, effective_date = ?, source = ?, updated_at = ?
+		WHERE id = ?`,
+		price.ModelPattern, price.InputPrice, price.OutputPrice, price.CacheWritePrice,
+		price.CacheReadPrice, price.EffectiveDate, price.Source, price.UpdatedAt, price.ID)
+	return err
+}
+
+// DeleteModelPricing deletes a pricing row by ID
+func (d *Database) DeleteModelPricing(id int64) error {
+	_, err := d.db.Exec("DELETE FROM model_pricing WHERE id = ?", id)
+	return err
+}
+
+// ReplaceModelPricing replaces every row of one source in one transaction
+func (d *Database) ReplaceModelPricing(source string, prices []*ModelPricing) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM model_pricing WHERE source = ?", source); err != nil {
+		return err
+	}
+	now := time.Now().Unix()
+	for _, price := range prices {
+		if _, err := tx.Exec(`
+			INSERT INTO model_pricing (model_pattern, input_price, output_price, cache_write_price,
+				cache_read_price, effective_date, source, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+			price.ModelPattern, price.InputPrice, price.OutputPrice, price.CacheWritePrice,
+			price.CacheReadPrice, price.EffectiveDate, source, now); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func scanModelPricing(scanner interface{ Scan(...any) error }) (*ModelPricing, error) {
+	price := &ModelPricing{}
+	err := scanner.Scan(&price.ID, &price.ModelPattern, &price.InputPrice, &price.OutputPrice,
+		&price.CacheWritePrice, &price.CacheReadPrice, &price.EffectiveDate, &price.Source, &price.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return price, nil
+}
+
+// ListModelCapabilities returns the fetched capability rows
+func (d *Database) ListModelCapabilities() ([]*ModelCapabilities, error) {
+	rows, err := d.db.Query(`
+		SELECT model_pattern, context_window, max_output_tokens, supports_vision, supports_thinking,
+			pricing_pattern, last_verified
+		FROM model_capabilities ORDER BY model_pattern`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var capabilities []*ModelCapabilities
+	for rows.Next() {
+		c := &ModelCapabilities{Source: PricingSourceRemote}
+		if err := rows.Scan(&c.ModelPattern, &c.ContextWindow, &c.MaxOutputTokens, &c.SupportsVision,
+			&c.SupportsThinking, &c.PricingPattern, &c.LastVerified); err != nil {
+			return nil, err
+		}
+		capabilities = append(capabilities, c)
+	}
+	return capabilities, rows.Err()
+}
+
+// ReplaceModelCapabilities replaces the fetched capability rows in one
+// transaction
+func (d *Database) ReplaceModelCapabilities(capabilities []*ModelCapabilities) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM model_capabilities"); err != nil {
+		return err
+	}
+	for _, c := range capabilities {
+		if _, err := tx.Exec(`
+			INSERT OR REPLACE INTO model_capabilities (model_pattern, context_window, max_output_tokens,
+				supports_vision, supports_thinking, pricing_pattern, last_verified)
+			VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			c.ModelPattern, c.ContextWindow, c.MaxOutputTokens, c.SupportsVision,
+			c.SupportsThinking, c.PricingPattern, c.LastVerified); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// ListUsageFileCache returns every cached usage log file
+func (d *Database) ListUsageFileCache() ([]*UsageFileCache, error) {
+	rows, err := d.db.Query("SELECT path, size, mod_time, version, entries FROM usage_file_cache")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var files []*UsageFileCache
+	for rows.Next() {
+		file := &UsageFileCache{}
+		if err := rows.Scan(&file.Path, &file.Size, &file.ModTime, &file.Version, &file.Entries); err != nil {
+			return nil, err
+		}
+		files = append(files, file)
+	}
+	return files, rows.Err()
+}
+
+// UpdateUsageFileCache saves changed files and removes deleted ones in one
+// transaction
+func (d *Database) UpdateUsageFileCache(save []*UsageFileCache, remove []string) error {
+	if len(save) == 0 && len(remove) == 0 {
+		return nil
+	}
+	tx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, file := range save {
+		if _, err := tx.Exec(`
+			INSERT OR REPLACE INTO usage_file_cache (path, size, mod_time, version, entries)
+			VALUES (?, ?, ?, ?, ?)`, file.Path, file.Size, file.ModTime, file.Version, file.Entries); err != nil {
+			return err
+		}
+	}
+	for _, path := range remove {
+		if _, err := tx.Exec("DELETE FROM usage_file_cache WHERE path = ?", path); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// ListCodexSessionCatalog returns every catalogued Codex session file and
+// the directories they were listed from
+func (d *Database) ListCodexSessionCatalog() ([]*CodexSessionEntry, []*CodexSessionDir, error) {
+	rows, err := d.db.Query(`
+		SELECT path, session_id, project_path, created_at, message_timestamp, first_message, size, mod_time, version
+		FROM codex_session_catalog`)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	var entries []*CodexSessionEntry
+	for rows.Next() {
+		entry := &CodexSessionEntry{}
+		if err := rows.Scan(&entry.Path, &entry.SessionID, &entry.ProjectPath, &entry.CreatedAt,
+			&entry.MessageTimestamp, &entry.FirstMessage, &entry.Size, &entry.ModTime, &entry.Version); err != nil {
+			return nil, nil, err
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	dirRows, err := d.db.Query("SELECT path, mod_time FROM codex_session_dirs")
+	if err != nil {
+		return nil, nil, err
+	}
+	defer dirRows.Close()
+
+	var dirs []*CodexSessionDir
+	for dirRows.Next() {
+		dir := &CodexSessionDir{}
+		if err := dirRows.Scan(&dir.Path, &dir.ModTime); err != nil {
+			return nil, nil, err
+		}
+		dirs = append(dirs, dir)
+	}
+	return entries, dirs, dirRows.Err()
+}
+
+// UpdateCodexSessionCatalog saves changed session files and directories and
+// removes deleted ones in one transaction
+func (d *Database) UpdateCodexSessionCatalog(save []*CodexSessionEntry, remove []string, saveDirs []*CodexSessionDir, removeDirs []string) error {
+	if len(save) == 0 && len(remove) == 0 && len(saveDirs) == 0 && len(removeDirs) == 0 {
+		return nil
+	}
+	tx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, entry := range save {
+		if _, err := tx.Exec(`
+			INSERT OR REPLACE INTO codex_session_catalog
+				(path, session_id, project_path, created_at, message_timestamp, first_message, size, mod_time, version)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			entry.Path, entry.SessionID, entry.ProjectPath, entry.CreatedAt, entry.MessageTimestamp,
+			entry.FirstMessage, entry.Size, entry.ModTime, entry.Version); err != nil {
+			return err
+		}
+	}
+	for _, path := range remove {
+		if _, err := tx.Exec("DELETE FROM codex_session_catalog WHERE path = ?", path); err != nil {
+			return err
+		}
+	}
+	for _, dir := range saveDirs {
+		if _, err := tx.Exec("INSERT OR REPLACE INTO codex_session_dirs (path, mod_time) VALUES (?, ?)", dir.Path, dir.ModTime); err != nil {
+			return err
+		}
+	}
+	for _, path := range removeDirs {
+		if _, err := tx.Exec("DELETE FROM codex_session_dirs WHERE path = ?", path); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// AddTelemetryMetric adds m's count and duration to its day's total
+func (d *Database) AddTelemetryMetric(m *TelemetryMetric) error {
+	_, err := d.db.Exec(`
+		INSERT INTO telemetry_metrics (day, kind, name, count, duration_ms)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (day, kind, name) DO UPDATE SET
+			count = count + excluded.count,
+			duration_ms = duration_ms + excluded.duration_ms`,
+		m.Day, m.Kind, m.Name, m.Count, m.DurationMS)
+	return err
+}
+
+// ListTelemetryMetrics returns the buffered telemetry metrics
+func (d *Database) ListTelemetryMetrics() ([]*TelemetryMetric, error) {
+	rows, err := d.db.Query(`
+		SELECT day, kind, name, count, duration_ms
+		FROM telemetry_metrics ORDER BY day, kind, name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var metrics []*TelemetryMetric
+	for rows.Next() {
+		m := &TelemetryMetric{}
+		if err := rows.Scan(&m.Day, &m.Kind, &m.Name, &m.Count, &m.DurationMS); err != nil {
+			return nil, err
+		}
+		metrics = append(metrics, m)
+	}
+	return metrics, rows.Err()
+}
+
+// RemoveTelemetryMetrics subtracts sent metrics from the buffer in one
+// transaction, keeping whatever was added since they were listed
+func (d *Database) RemoveTelemetryMetrics(sent []*TelemetryMetric) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, m := range sent {
+		if _, err := tx.Exec(`
+			UPDATE telemetry_metrics SET count = count - ?, duration_ms = duration_ms - ?
+			WHERE day = ? AND kind = ? AND name = ?`,
+			m.Count, m.DurationMS, m.Day, m.Kind, m.Name); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.Exec("DELETE FROM telemetry_metrics WHERE count <= 0 AND duration_ms <= 0"); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ClearTelemetryMetrics deletes every buffered telemetry metric
+func (d *Database) ClearTelemetryMetrics() error {
+	_, err := d.db.Exec("DELETE FROM telemetry_metrics")
+	return err
+}
+
+// ===== Hook executions =====
+
+// MaxHookExecutions is how many hook executions are kept; older ones are
+// dropped as new ones are added
+const MaxHookExecutions = 5000
+
+// AddHookExecution records a hook run, dropping the oldest runs past
+// MaxHookExecutions
+func (d *Database) AddHookExecution(e *HookExecution) error {
+	result, err := d.db.Exec(`
+		INSERT INTO hook_executions
+		(session_id, project_path, hook_event, matcher, command, exit_code, outcome, duration_ms, output, truncated, started_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		e.SessionID, e.ProjectPath, e.HookEvent, e.Matcher, e.Command, e.ExitCode, e.Outcome,
+		e.DurationMS, e.Output, e.Truncated, e.StartedAt)
+	if err != nil {
+		return err
+	}
+	if e.ID, err = result.LastInsertId(); err != nil {
+		return err
+	}
+	_, err = d.db.Exec("DELETE FROM hook_executions WHERE id <= ?", e.ID-MaxHookExecutions)
+	return err
+}
+
+// ListHookExecutions returns recorded hook runs matching filter, newest first
+func (d *Database) ListHookExecutions(filter HookExecutionFilter) ([]*HookExecution, error) {
+	query := `SELECT id, session_id, project_path, hook_event, matcher, command, exit_code, outcome,
+		duration_ms, output, truncated, started_at FROM hook_executions WHERE 1 = 1`
+	var args []interface{}
+	if filter.SessionID != "" {
+		query += " AND session_id = ?"
+		args = append(args, filter.SessionID)
+	}
+	if filter.ProjectPath != "" {
+		query += " AND project_path = ?"
+		args = append(args, filter.ProjectPath)
+	}
+	if filter.HookEvent != "" {
+		query += " AND hook_event = ?"
+		args = append(args, filter.HookEvent)
+	}
+	if filter.FailedOnly {
+		query += " AND (outcome NOT IN ('', 'success') OR COALESCE(exit_code, 0) != 0)"
+	}
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = 200
+	}
+	query += " ORDER BY id DESC LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := d.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	executions := make([]*HookExecution, 0)
+	for rows.Next() {
+		e := &HookExecution{}
+		var exitCode sql.NullInt64
+		if err := rows.Scan(&e.ID, &e.SessionID, &e.ProjectPath, &e.HookEvent, &e.Matcher, &e.Command,
+			&exitCode, &e.Outcome, &e.DurationMS, &e.Output, &e.Truncated, &e.StartedAt); err != nil {
+			return nil, err
+		}
+		if exitCode.Valid {
+			code := int(exitCode.Int64)
+			e.ExitCode = &code
+		}
+		executions = append(executions, e)
+	}
+	return executions, rows.Err()
+}
+
+// ClearHookExecutions deletes every recorded hook run
+func (d *Database) ClearHookExecutions() error {
+	_, err := d.db.Exec("DELETE FROM hook_executions")
+	return err
+}
+
+// MaxActionRuns is how many action runs are kept; older ones are dropped
+const MaxActionRuns = 500
+
+// AddActionRun records a started action run, dropping the oldest runs past
+// MaxActionRuns
+func (d *Database) AddActionRun(r *ActionRun) error {
+	_, err := d.db.Exec(`
+		INSERT INTO action_runs
+		(id, action_id, action_name, scope, cwd, command, status, exit_code, output, truncated, started_at, finished_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		r.ID, r.ActionID, r.ActionName, r.Scope, r.Cwd, r.Command, r.Status, r.ExitCode, r.Output,
+		r.Truncated, r.StartedAt, r.FinishedAt)
+	if err != nil {
+		return err
+	}
+	_, err = d.db.Exec(`DELETE FROM action_runs WHERE id NOT IN
+		(SELECT id FROM action_runs ORDER BY started_at DESC LIMIT ?)`, MaxActionRuns)
+	return err
+}
+
+// FinishActionRun stores the outcome of an action run
+func (d *Database) FinishActionRun(r *ActionRun) error {
+	_, err := d.db.Exec(`
+		UPDATE action_runs SET status = ?, exit_code = ?, output = ?, truncated = ?, finished_at = ?
+		WHERE id = ?`,
+		r.Status, r.ExitCode, r.Output, r.Truncated, r.FinishedAt, r.ID)
+	return err
+}
+
+// InterruptActionRuns marks runs still recorded as running, left over by a
+// previous app instance, as interrupted
+func (d *Database) InterruptActionRuns() error {
+	_, err := d.db.Exec("UPDATE action_runs SET status = ? WHERE status = ?",
+		ActionRunInterrupted, ActionRunRunning)
+	return err
+}
+
+// ListActionRuns returns action runs matching filter, newest first
+func (d *Database) ListActionRuns(filter ActionRunFilter) ([]*ActionRun, error) {
+	query := `SELECT id, action_id, action_name, scope, cwd, command, status, exit_code, output,
+		truncated, started_at, finished_at FROM action_runs WHERE 1 = 1`
+	var args []interface{}
+	if filter.ActionID != "" {
+		query += " AND action_id = ?"
+		args = append(args, filter.ActionID)
+	}
+	if filter.Cwd != "" {
+		query += " AND cwd = ?"
+		args = append(args, filter.Cwd)
+	}
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = 50
+	}
+	query += " ORDER BY started_at DESC LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := d.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	runs := make([]*ActionRun, 0)
+	for rows.Next() {
+		r := &ActionRun{}
+		var exitCode sql.NullInt64
+		var finishedAt sql.NullTime
+		if err := rows.Scan(&r.ID, &r.ActionID, &r.ActionName, &r.Scope, &r.Cwd, &r.Command, &r.Status,
+			&exitCode, &r.Output, &r.Truncated, &r.StartedAt, &finishedAt); err != nil {
+			return nil, err
+		}
+		if exitCode.Valid {
+			code := int(exitCode.Int64)
+			r.ExitCode = &code
+		}
+		if finishedAt.Valid {
+			r.FinishedAt = &finishedAt.Time
+		}
+		runs = append(runs, r)
+	}
+	return runs, rows.Err()
+}
+
+// ===== Prompt Snippet CRUD =====
+
+// ListSnippets retrieves all prompt snippets, most used first
+func (d *Database) ListSnippets() ([]*Snippet, error) {
+	rows, err := d.db.Query(`
+		SELECT id, title, body, tags, usage_count, last_used_at, created_at, updated_at
+		FROM prompt_snippets ORDER BY usage_count DESC, title`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	snippets := make([]*Snippet, 0)
+	for rows.Next() {
+		snippet, err := scanSnippet(rows)
+		if err != nil {
+			return nil, err
+		}
+		snippets = append(snippets, snippet)
+	}
+	return snippets, rows.Err()
+}
+
+// GetSnippet retrieves a prompt snippet by ID
+func (d *Database) GetSnippet(id int64) (*Snippet, error) {
+	row := d.db.QueryRow(`
+		SELECT id, title, body, tags, usage_count, last_used_at, created_at, updated_at
+		FROM prompt_snippets WHERE id = ?`, id)
+	return scanSnippet(row)
+}
+
+// CreateSnippet creates a new prompt snippet
+func (d *Database) CreateSnippet(snippet *Snippet) (int64, error) {
+	tags, err := json.Marshal(stringList(snippet.Tags))
+	if err != nil {
+		return 0, err
+	}
+	now := time.Now()
+	snippet.CreatedAt = now
+	snippet.UpdatedAt = now
+
+	result, err := d.db.Exec(`
+		INSERT INTO prompt_snippets (title, body, tags, usage_count, created_at, updated_at)
+		VALUES (?, ?, ?, 0, ?, ?)`,
+		snippet.Title, snippet.Body, string(tags), now.Unix(), now.Unix())
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	snippet.ID = id
+	return id, nil
+}
+
+// UpdateSnippet updates the title, body and tags of a prompt snippet
+func (d *Database) UpdateSnippet(snippet *Snippet) error {
+	tags, err := json.Marshal(stringList(snippet.Tags))
+	if err != nil {
+		return err
+	}
+	snippet.UpdatedAt = time.Now()
+
+	_, err = d.db.Exec(`
+		UPDATE prompt_snippets SET title = ?, body = ?, tags = ?, updated_at = ?
+		WHERE id = ?`,
+		snippet.Title, snippet.Body, string(tags), snippet.UpdatedAt.Unix(), snippet.ID)
+	return err
+}
+
+// RecordSnippetUse counts one use of a prompt snippet
+func (d *Database) RecordSnippetUse(id int64) error {
+	_, err := d.db.Exec(`
+		UPDATE prompt_snippets SET usage_count = usage_count + 1, last_used_at = ?
+		WHERE id = ?`, time.Now().Unix(), id)
+	return err
+}
+
+// DeleteSnippet deletes a prompt snippet
+func (d *Database) DeleteSnippet(id int64) error {
+	_, err := d.db.Exec("DELETE FROM prompt_snippets WHERE id = ?", id)
+	return err
+}
+
+// stringList returns values, or an empty list for nil so that JSON
+// columns hold [] rather than null
+func stringList(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
+
+func scanSnippet(scanner interface{ Scan(...any) error }) (*Snippet, error) {
+	snippet := &Snippet{}
+	var tags string
+	var lastUsedAt sql.NullInt64
+	var createdAt, updatedAt int64
+	err := scanner.Scan(&snippet.ID, &snippet.Title, &snippet.Body, &tags, &snippet.UsageCount,
+		&lastUsedAt, &createdAt, &updatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(tags), &snippet.Tags); err != nil {
+		return nil, fmt.Errorf("decode tags for snippet %d: %w", snippet.ID, err)
+	}
+	snippet.Tags = stringList(snippet.Tags)
+	if lastUsedAt.Valid {
+		t := time.Unix(lastUsedAt.Int64, 0)
+		snippet.LastUsedAt = &t
+	}
+	snippet.CreatedAt = time.Unix(createdAt, 0)
+	snippet.UpdatedAt = time.Unix(updatedAt, 0)
+	return snippet, nil
+}
+
+// ===== Permission Profile CRUD =====
+
+// ListPermissionProfiles retrieves the saved permission profiles by name
+func (d *Database) ListPermissionProfiles() ([]*PermissionProfile, error) {
+	rows, err := d.db.Query(`
+		SELECT id, name, description, claude_permission_mode, claude_allowed_tools,
+			claude_disallowed_tools, codex_sandbox, codex_network_access, created_at, updated_at
+		FROM permission_profiles ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	profiles := make([]*PermissionProfile, 0)
+	for rows.Next() {
+		profile, err := scanPermissionProfile(rows)
+		if err != nil {
+			return nil, err
+		}
+		profiles = append(profiles, profile)
+	}
+	return profiles, rows.Err()
+}
+
+// GetPermissionProfile retrieves a saved permission profile by ID
+func (d *Database) GetPermissionProfile(id string) (*PermissionProfile, error) {
+	row := d.db.QueryRow(`
+		SELECT id, name, description, claude_permission_mode, claude_allowed_tools,
+			claude_disallowed_tools, codex_sandbox, codex_network_access, created_at, updated_at
+		FROM permission_profiles WHERE id = ?`, id)
+	return scanPermissionProfile(row)
+}
+
+// SavePermissionProfile creates or replaces a permission profile
+func (d *Database) SavePermissionProfile(profile *PermissionProfile) error {
+	allowed, err := json.Marshal(stringList(profile.ClaudeAllowedTools))
+	if err != nil {
+		return err
+	}
+	disallowed, err := json.Marshal(stringList(profile.ClaudeDisallowedTools))
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	if profile.CreatedAt.IsZero() {
+		profile.CreatedAt = now
+	}
+	profile.UpdatedAt = now
+
+	_, err = d.db.Exec(`
+		INSERT INTO permission_profiles (id, name, description, claude_permission_mode,
+			claude_allowed_tools, claude_disallowed_tools, codex_sandbox, codex_network_access,
+			created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET
+			name = excluded.name,
+			description = excluded.description,
+			claude_permission_mode = excluded.claude_permission_mode,
+			claude_allowed_tools = excluded.claude_allowed_tools,
+			claude_disallowed_tools = excluded.claude_disallowed_tools,
+			codex_sandbox = excluded.codex_sandbox,
+			codex_network_access = excluded.codex_network_access,
+			updated_at = excluded.updated_at`,
+		profile.ID, profile.Name, profile.Description, profile.ClaudePermissionMode,
+		string(allowed), string(disallowed), profile.CodexSandbox, profile.CodexNetworkAccess,
+		profile.CreatedAt.Unix(), profile.UpdatedAt.Unix())
+	return err
+}
+
+// DeletePermissionProfile deletes a permission profile and the project
+// selections that use it
+func (d *Database) DeletePermissionProfile(id string) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM project_permission_profiles WHERE profile_id = ?", id); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM permission_profiles WHERE id = ?", id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetProjectPermissionProfile returns the ID of the profile selected for a
+// project, empty when none is
+func (d *Database) GetProjectPermissionProfile(projectPath string) (string, error) {
+	var id string
+	err := d.db.QueryRow("SELECT profile_id FROM project_permission_profiles WHERE project_path = ?",
+		projectPath).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	return id, err
+}
+
+// SetProjectPermissionProfile selects the profile of a project; an empty ID
+// clears the selection
+func (d *Database) SetProjectPermissionProfile(projectPath, profileID string) error {
+	if profileID == "" {
+		_, err := d.db.Exec("DELETE FROM project_permission_profiles WHERE project_path = ?", projectPath)
+		return err
+	}
+	_, err := d.db.Exec(`
+		INSERT INTO project_permission_profiles (project_path, profile_id) VALUES (?, ?)
+		ON CONFLICT (project_path) DO UPDATE SET profile_id = excluded.profile_id`,
+		projectPath, profileID)
+	return err
+}
+
+func scanPermissionProfile(scanner interface{ Scan(...any) error }) (*PermissionProfile, error) {
+	profile := &PermissionProfile{}
+	var allowed, disallowed string
+	var createdAt, updatedAt int64
+	err := scanner.Scan(&profile.ID, &profile.Name, &profile.Description, &profile.ClaudePermissionMode,
+		&allowed, &disallowed, &profile.CodexSandbox, &profile.CodexNetworkAccess, &createdAt, &updatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(allowed), &profile.ClaudeAllowedTools); err != nil {
+		return nil, fmt.Errorf("decode allowed tools for profile %s: %w", profile.ID, err)
+	}
+	if err := json.Unmarshal([]byte(disallowed), &profile.ClaudeDisallowedTools); err != nil {
+		return nil, fmt.Errorf("decode disallowed tools for profile %s: %w", profile.ID, err)
+	}
+	profile.ClaudeAllowedTools = stringList(profile.ClaudeAllowedTools)
+	profile.ClaudeDisallowedTools = stringList(profile.ClaudeDisallowedTools)
+	profile.CreatedAt = time.Unix(createdAt, 0)
+	profile.UpdatedAt = time.Unix(updatedAt, 0)
+	return profile, nil
+}
+
+// ===== Session file changes =====
+
+// MaxSessionFileChanges is how many file changes are kept; older ones are
+// dropped as new ones are added
+const MaxSessionFileChanges = 50000
+
+// AddSessionFileChange records a file change made by a tool call, dropping
+// the oldest changes past MaxSessionFileChanges
+func (d *Database) AddSessionFileChange(c *SessionFileChange) error {
+	if c.Ranges == nil {
+		c.Ranges = []LineRange{}
+	}
+	ranges, err := json.Marshal(c.Ranges)
+	if err != nil {
+		return err
+	}
+	result, err := d.db.Exec(`
+		INSERT INTO session_file_changes (session_id, tool_use_id, tool, path, kind, ranges, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		c.SessionID, c.ToolUseID, c.Tool, c.Path, c.Kind, string(ranges), c.CreatedAt.Unix())
+	if err != nil {
+		return err
+	}
+	if c.ID, err = result.LastInsertId(); err != nil {
+		return err
+	}
+	_, err = d.db.Exec("DELETE FROM session_file_changes WHERE id <= ?", c.ID-MaxSessionFileChanges)
+	return err
+}
+
+// ListSessionFileChanges returns the file changes of a session, oldest first
+func (d *Database) ListSessionFileChanges(sessionID string) ([]*SessionFileChange, error) {
+	rows, err := d.db.Query(`
+		SELECT id, session_id, tool_use_id, tool, path, kind, ranges, created_at
+		FROM session_file_changes WHERE session_id = ? ORDER BY id`, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	changes := make([]*SessionFileChange, 0)
+	for rows.Next() {
+		c := &SessionFileChange{}
+		var ranges string
+		var createdAt int64
+		if err := rows.Scan(&c.ID, &c.SessionID, &c.ToolUseID, &c.Tool, &c.Path, &c.Kind, &ranges, &createdAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(ranges), &c.Ranges); err != nil || c.Ranges == nil {
+			c.Ranges = []LineRange{}
+		}
+		c.CreatedAt = time.Unix(createdAt, 0)
+		changes = append(changes, c)
+	}
+	return changes, rows.Err()
+}
+
+// SaveSessionEditReview records the review of a session's changes to a
+// file, replacing any earlier one
+func (d *Database) SaveSessionEditReview(review *SessionEditReview) error {
+	review.ReviewedAt = time.Now()
+	_, err := d.db.Exec(`
+		INSERT INTO session_edit_reviews (session_id, path, status, reviewed_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT (session_id, path) DO UPDATE SET status = excluded.status, reviewed_at = excluded.reviewed_at`,
+		review.SessionID, review.Path, review.Status, review.ReviewedAt.Unix())
+	return err
+}
+
+// ListSessionEditReviews returns the reviews of a session's file changes
+func (d *Database) ListSessionEditReviews(sessionID string) ([]*SessionEditReview, error) {
+	rows, err := d.db.Query(`
+		SELECT session_id, path, status, reviewed_at FROM session_edit_reviews
+		WHERE session_id = ? ORDER BY path`, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reviews := make([]*SessionEditReview, 0)
+	for rows.Next() {
+		review := &SessionEditReview{}
+		var reviewedAt int64
+		if err := rows.Scan(&review.SessionID, &review.Path, &review.Status, &reviewedAt); err != nil {
+			return nil, err
+		}
+		review.ReviewedAt = time.Unix(reviewedAt, 0)
+		reviews = append(reviews, review)
+	}
+	return reviews, rows.Err()
+}
+
+// ===== Session Checkpoint CRUD =====
+
+const sessionCheckpointColumns = `id, session_id, provider, project_path, commit_sha, ref, created_at, restored_at`
+
+// CreateSessionCheckpoint records a checkpoint taken before a session ran
+func (d *Database) CreateSessionCheckpoint(checkpoint *SessionCheckpoint) (int64, error) {
+	checkpoint.CreatedAt = time.Now()
+	result, err := d.db.Exec(`
+		INSERT INTO session_checkpoints (session_id, provider, project_path, commit_sha, ref, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		checkpoint.SessionID, checkpoint.Provider, checkpoint.ProjectPath, checkpoint.Commit, checkpoint.Ref,
+		checkpoint.CreatedAt.Unix())
+	if err != nil {
+		return 0, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	checkpoint.ID = id
+	return id, nil
+}
+
+// ListSessionCheckpoints retrieves the checkpoints of a session, oldest first
+func (d *Database) ListSessionCheckpoints(sessionID string) ([]*SessionCheckpoint, error) {
+	return d.querySessionCheckpoints(`
+		SELECT `+sessionCheckpointColumns+` FROM session_checkpoints
+		WHERE session_id = ? ORDER BY created_at, id`, sessionID)
+}
+
+// ListProjectCheckpointsBefore retrieves the checkpoints of a project taken
+// before t
+func (d *Database) ListProjectCheckpointsBefore(projectPath string, t time.Time) ([]*SessionCheckpoint, error) {
+	return d.querySessionCheckpoints(`
+		SELECT `+sessionCheckpointColumns+` FROM session_checkpoints
+		WHERE project_path = ? AND created_at < ? ORDER BY created_at, id`, projectPath, t.Unix())
+}
+
+// LinkSessionCheckpoint gives the newest checkpoint of projectPath that has
+// no session yet, if it was taken since since, to sessionID. It reports
+// whether there was one.
+func (d *Database) LinkSessionCheckpoint(projectPath, sessionID string, since time.Time) (bool, error) {
+	result, err := d.db.Exec(`
+		UPDATE session_checkpoints SET session_id = ?
+		WHERE id = (
+			SELECT id FROM session_checkpoints
+			WHERE project_path = ? AND session_id = '' AND created_at >= ?
+			ORDER BY created_at DESC, id DESC LIMIT 1
+		)`, sessionID, projectPath, since.Unix())
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// MarkSessionCheckpointRestored records that the work tree was put back to
+// a checkpoint
+func (d *Database) MarkSessionCheckpointRestored(id int64) error {
+	_, err := d.db.Exec("UPDATE session_checkpoints SET restored_at = ? WHERE id = ?", time.Now().Unix(), id)
+	return err
+}
+
+// DeleteSessionCheckpoint deletes a checkpoint record
+func (d *Database) DeleteSessionCheckpoint(id int64) error {
+	_, err := d.db.Exec("DELETE FROM session_checkpoints WHERE id = ?", id)
+	return err
+}
+
+func (d *Database) querySessionCheckpoints(query string, args ...any) ([]*SessionCheckpoint, error) {
+	rows, err := d.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	checkpoints := make([]*SessionCheckpoint, 0)
+	for rows.Next() {
+		checkpoint, err := scanSessionCheckpoint(rows)
+		if err != nil {
+			return nil, err
+		}
+		checkpoints = append(checkpoints, checkpoint)
+	}
+	return checkpoints, rows.Err()
+}
+
+func scanSessionCheckpoint(scanner interface{ Scan(...any) error }) (*SessionCheckpoint, error) {
+	checkpoint := &SessionCheckpoint{}
+	var createdAt int64
+	var restoredAt sql.NullInt64
+	err := scanner.Scan(&checkpoint.ID, &checkpoint.SessionID, &checkpoint.Provider, &checkpoint.ProjectPath,
+		&checkpoint.Commit, &checkpoint.Ref, &createdAt, &restoredAt)
+	if err != nil {
+		return nil, err
+	}
+	checkpoint.CreatedAt = time.Unix(createdAt, 0)
+	if restoredAt.Valid {
+		t := time.Unix(restoredAt.Int64, 0)
+		checkpoint.RestoredAt = &t
+	}
+	return checkpoint, nil
+}
+
+// ListProjectEnvVars returns the environment variables of a project by name
+func (d *Database) ListProjectEnvVars(projectPath string) ([]*ProjectEnvVar, error) {
+	rows, err := d.db.Query(`
+		SELECT id, project_path, name, value, secret, updated_at
+		FROM project_env_vars WHERE project_path = ? ORDER BY name`, projectPath)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	vars := make([]*ProjectEnvVar, 0)
+	for rows.Next() {
+		v, err := scanProjectEnvVar(rows)
+		if err != nil {
+			return nil, err
+		}
+		vars = append(vars, v)
+	}
+	return vars, rows.Err()
+}
+
+// GetProjectEnvVar retrieves a project environment variable by ID
+func (d *Database) GetProjectEnvVar(id int64) (*ProjectEnvVar, error) {
+	row := d.db.QueryRow(`
+		SELECT id, project_path, name, value, secret, updated_at
+		FROM project_env_vars WHERE id = ?`, id)
+	return scanProjectEnvVar(row)
+}
+
+// SaveProjectEnvVar inserts a project environment variable when its ID is
+// 0 and updates it otherwise
+func (d *Database) SaveProjectEnvVar(v *ProjectEnvVar) error {
+	v.UpdatedAt = time.Now().Unix()
+	if v.ID == 0 {
+		result, err := d.db.Exec(`
+			INSERT INTO project_env_vars (project_path, name, value, secret, updated_at)
+			VALUES (?, ?, ?, ?, ?)`,
+			v.ProjectPath, v.Name, v.Value, v.Secret, v.UpdatedAt)
+		if err != nil {
+			return err
+		}
+		v.ID, err = result.LastInsertId()
+		return err
+	}
+	_, err := d.db.Exec(`
+		UPDATE project_env_vars SET project_path = ?, name = ?, value = ?, secret = ?, updated_at = ?
+		WHERE id = ?`,
+		v.ProjectPath, v.Name, v.Value, v.Secret, v.UpdatedAt, v.ID)
+	return err
+}
+
+// DeleteProjectEnvVar deletes a project environment variable by ID
+func (d *Database) DeleteProjectEnvVar(id int64) error {
+	_, err := d.db.Exec("DELETE FROM project_env_vars WHERE id = ?", id)
+	return err
+}
+
+func scanProjectEnvVar(scanner interface{ Scan(...any) error }) (*ProjectEnvVar, error) {
+	v := &ProjectEnvVar{}
+	if err := scanner.Scan(&v.ID, &v.ProjectPath, &v.Name, &v.Value, &v.Secret, &v.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func nullableTime(t *time.Time) interface{} {
 	if t == nil {
 		return nil
 	}
@@ -707,9 +2653,12 @@ func scanAgentRun(row *sql.Row) (*AgentRun, error) {
 	var processStartedAt, completedAt sql.NullInt64
 	var pid sql.NullInt64
 
+	var metrics AgentRunMetrics
+
 	err := row.Scan(&run.ID, &run.AgentID, &run.AgentName, &run.AgentIcon, &run.Task,
-		&run.Model, &run.ProjectPath, &run.SessionID, &run.Status, &pid,
-		&processStartedAt, &createdAt, &completedAt)
+		&run.Model, &run.Provider, &run.ProjectPath, &run.SessionID, &run.Status, &pid,
+		&processStartedAt, &createdAt, &completedAt,
+		&metrics.InputTokens, &metrics.OutputTokens, &metrics.CostUSD, &metrics.DurationMS)
 	if err != nil {
 		return nil, err
 	}
@@ -725,6 +2674,8 @@ func scanAgentRun(row *sql.Row) (*AgentRun, error) {
 	if completedAt.Valid {
 		t := time.Unix(completedAt.Int64, 0)
 		run.CompletedAt = &t
+		metrics.TotalTokens = metrics.InputTokens + metrics.OutputTokens
+		run.Metrics = &metrics
 	}
 
 	return run, nil
@@ -736,9 +2687,12 @@ func scanAgentRunRow(rows *sql.Rows) (*AgentRun, error) {
 	var processStartedAt, completedAt sql.NullInt64
 	var pid sql.NullInt64
 
+	var metrics AgentRunMetrics
+
 	err := rows.Scan(&run.ID, &run.AgentID, &run.AgentName, &run.AgentIcon, &run.Task,
-		&run.Model, &run.ProjectPath, &run.SessionID, &run.Status, &pid,
-		&processStartedAt, &createdAt, &completedAt)
+		&run.Model, &run.Provider, &run.ProjectPath, &run.SessionID, &run.Status, &pid,
+		&processStartedAt, &createdAt, &completedAt,
+		&metrics.InputTokens, &metrics.OutputTokens, &metrics.CostUSD, &metrics.DurationMS)
 	if err != nil {
 		return nil, err
 	}
@@ -754,8 +2708,127 @@ func scanAgentRunRow(rows *sql.Rows) (*AgentRun, error) {
 	if completedAt.Valid {
 		t := time.Unix(completedAt.Int64, 0)
 		run.CompletedAt = &t
+		metrics.TotalTokens = metrics.InputTokens + metrics.OutputTokens
+		run.Metrics = &metrics
+	}
+
+	return run, nil
+}
+
+// agentBatchItems keeps nil item lists from being stored as JSON null
+func agentBatchItems(items []AgentBatchItem) []AgentBatchItem {
+	if items == nil {
+		return []AgentBatchItem{}
+	}
+	return items
+}
+
+func scanAgentBatch(scanner interface{ Scan(...any) error }) (*AgentBatch, error) {
+	batch := &AgentBatch{}
+	var items string
+	var createdAt int64
+	var completedAt sql.NullInt64
+	err := scanner.Scan(&batch.ID, &batch.AgentID, &batch.AgentName, &batch.Task, &batch.Status,
+		&batch.Concurrency, &items, &createdAt, &completedAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(items), &batch.Items); err != nil {
+		return nil, fmt.Errorf("decode items for agent batch %d: %w", batch.ID, err)
+	}
+	batch.Items = agentBatchItems(batch.Items)
+	batch.CreatedAt = time.Unix(createdAt, 0)
+	if completedAt.Valid {
+		t := time.Unix(completedAt.Int64, 0)
+		batch.CompletedAt = &t
+	}
+	return batch, nil
+}
+
+func scanProviderComparison(scanner interface{ Scan(...any) error }) (*ProviderComparison, error) {
+	comparison := &ProviderComparison{}
+	var items string
+	var createdAt int64
+	var completedAt sql.NullInt64
+	err := scanner.Scan(&comparison.ID, &comparison.ProjectPath, &comparison.Prompt, &comparison.Status,
+		&items, &createdAt, &completedAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(items), &comparison.Items); err != nil {
+		return nil, fmt.Errorf("decode items for provider comparison %d: %w", comparison.ID, err)
+	}
+	comparison.Items = providerComparisonItems(comparison.Items)
+	comparison.CreatedAt = time.Unix(createdAt, 0)
+	if completedAt.Valid {
+		t := time.Unix(completedAt.Int64, 0)
+		comparison.CompletedAt = &t
+	}
+	return comparison, nil
+}
+
+// providerComparisonItems keeps nil item lists from being stored as JSON null
+func providerComparisonItems(items []ProviderComparisonItem) []ProviderComparisonItem {
+	if items == nil {
+		return []ProviderComparisonItem{}
+	}
+	return items
+}
+
+// pipelineSteps keeps nil step lists from being stored as JSON null
+func pipelineSteps(steps []PipelineStep) []PipelineStep {
+	if steps == nil {
+		return []PipelineStep{}
+	}
+	return steps
+}
+
+func pipelineStepRuns(steps []PipelineStepRun) []PipelineStepRun {
+	if steps == nil {
+		return []PipelineStepRun{}
+	}
+	return steps
+}
+
+func scanPipeline(scanner interface{ Scan(...any) error }) (*Pipeline, error) {
+	pipeline := &Pipeline{}
+	var description sql.NullString
+	var steps string
+	var createdAt, updatedAt int64
+	if err := scanner.Scan(&pipeline.ID, &pipeline.Name, &description, &steps, &createdAt, &updatedAt); err != nil {
+		return nil, err
 	}
+	pipeline.Description = description.String
+	if err := json.Unmarshal([]byte(steps), &pipeline.Steps); err != nil {
+		return nil, fmt.Errorf("decode steps for pipeline %d: %w", pipeline.ID, err)
+	}
+	pipeline.Steps = pipelineSteps(pipeline.Steps)
+	pipeline.CreatedAt = time.Unix(createdAt, 0)
+	pipeline.UpdatedAt = time.Unix(updatedAt, 0)
+	return pipeline, nil
+}
 
+func scanPipelineRun(scanner interface{ Scan(...any) error }) (*PipelineRun, error) {
+	run := &PipelineRun{}
+	var steps string
+	var runErr sql.NullString
+	var createdAt int64
+	var completedAt sql.NullInt64
+	err := scanner.Scan(&run.ID, &run.PipelineID, &run.PipelineName, &run.ProjectPath, &run.Task,
+		&run.Status, &run.CurrentStep, &steps, &runErr, &createdAt, &completedAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(steps), &run.Steps); err != nil {
+		return nil, fmt.Errorf("decode steps for pipeline run %d: %w", run.ID, err)
+	}
+	run.Steps = pipelineStepRuns(run.Steps)
+	run.Error = runErr.String
+	run.CreatedAt = time.Unix(createdAt, 0)
+	if completedAt.Valid {
+		t := time.Unix(completedAt.Int64, 0)
+		run.CompletedAt = &t
+	}
 	return run, nil
 }
 
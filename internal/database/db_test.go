@@ -2,10 +2,18 @@
 package database
 
 import (
+	"database/sql"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 	"reflect"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
+
+	"ropcode/internal/git"
 )
 
 func openTestDB(t *testing.T) *Database {
@@ -185,6 +193,14 @@ func TestDatabase_Settings(t *testing.T) {
 	if value != "dark" {
 		t.Errorf("Expected 'dark', got '%s'", value)
 	}
+
+	all, err := db.GetAllSettings()
+	if err != nil {
+		t.Fatalf("GetAllSettings failed: %v", err)
+	}
+	if all["theme"] != "dark" {
+		t.Errorf("Expected theme 'dark' in all settings, got %v", all)
+	}
 }
 
 func TestDatabase_ProjectIndex(t *testing.T) {
@@ -221,6 +237,102 @@ func TestDatabase_ProjectIndex(t *testing.T) {
 	}
 }
 
+func TestDatabase_PatchProjectIndexKeepsConcurrentUpdates(t *testing.T) {
+	db := openTestDB(t)
+	if err := db.SaveProjectIndex(&ProjectIndex{
+		Name:       "demo",
+		Workspaces: []WorkspaceIndex{{Name: "feature"}},
+	}); err != nil {
+		t.Fatalf("SaveProjectIndex failed: %v", err)
+	}
+
+	// Every patch adds a provider while others update the workspace; none
+	// may be lost
+	const writers = 20
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			_, err := db.PatchProjectIndex("demo", func(project *ProjectIndex) error {
+				project.Providers = append(project.Providers, ProviderInfo{ProviderID: fmt.Sprint(i)})
+				return nil
+			})
+			if err != nil {
+				t.Errorf("PatchProjectIndex failed: %v", err)
+			}
+		}(i)
+		go func(i int) {
+			defer wg.Done()
+			_, err := db.PatchWorkspaceIndex("feature", func(_ *ProjectIndex, workspace *WorkspaceIndex) error {
+				workspace.Providers = append(workspace.Providers, ProviderInfo{ProviderID: fmt.Sprint(i)})
+				return nil
+			})
+			if err != nil {
+				t.Errorf("PatchWorkspaceIndex failed: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	project, err := db.GetProjectIndex("demo")
+	if err != nil {
+		t.Fatalf("GetProjectIndex failed: %v", err)
+	}
+	if len(project.Providers) != writers || len(project.Workspaces[0].Providers) != writers {
+		t.Fatalf("got %d project and %d workspace providers, want %d each",
+			len(project.Providers), len(project.Workspaces[0].Providers), writers)
+	}
+
+	// A failing patch saves nothing
+	if _, err := db.PatchProjectIndex("demo", func(project *ProjectIndex) error {
+		project.Providers = nil
+		return errors.New("boom")
+	}); err == nil {
+		t.Fatal("expected the patch error")
+	}
+	if project, _ := db.GetProjectIndex("demo"); len(project.Providers) != writers {
+		t.Fatalf("failed patch was saved: %d providers", len(project.Providers))
+	}
+	if _, err := db.PatchWorkspaceIndex("missing", func(*ProjectIndex, *WorkspaceIndex) error { return nil }); !errors.Is(err, ErrWorkspaceNotFound) {
+		t.Fatalf("PatchWorkspaceIndex(missing) error = %v", err)
+	}
+}
+
+func TestDatabase_CreateOrPatchProjectIndexCreatesOnce(t *testing.T) {
+	db := openTestDB(t)
+
+	// Concurrent adds of a new project create it once and patch it after
+	const writers = 20
+	var created atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, err := db.CreateOrPatchProjectIndex("demo", func() *ProjectIndex {
+				created.Add(1)
+				return &ProjectIndex{Name: "demo", Providers: []ProviderInfo{{ProviderID: fmt.Sprint(i)}}}
+			}, func(project *ProjectIndex) error {
+				project.Providers = append(project.Providers, ProviderInfo{ProviderID: fmt.Sprint(i)})
+				return nil
+			})
+			if err != nil {
+				t.Errorf("CreateOrPatchProjectIndex failed: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	project, err := db.GetProjectIndex("demo")
+	if err != nil {
+		t.Fatalf("GetProjectIndex failed: %v", err)
+	}
+	if created.Load() != 1 || len(project.Providers) != writers {
+		t.Fatalf("created %d times with %d providers, want once with %d", created.Load(), len(project.Providers), writers)
+	}
+}
+
 func TestDatabase_AgentCRUD(t *testing.T) {
 	tmpDir := t.TempDir()
 	dbPath := filepath.Join(tmpDir, "test.db")
@@ -401,3 +513,722 @@ func TestDatabase_AgentExportImport(t *testing.T) {
 		t.Errorf("Expected default_task 'Test export functionality', got '%s'", imported2.DefaultTask)
 	}
 }
+
+func TestDatabase_AgentProviderDefaultsAndRoundTrip(t *testing.T) {
+	db := openTestDB(t)
+
+	legacyID, err := db.CreateAgent(&Agent{Name: "Legacy", Icon: "🤖", SystemPrompt: "p", Model: "sonnet"})
+	if err != nil {
+		t.Fatalf("CreateAgent failed: %v", err)
+	}
+	legacy, err := db.GetAgent(legacyID)
+	if err != nil {
+		t.Fatalf("GetAgent failed: %v", err)
+	}
+	if legacy.Provider != DefaultAgentProvider {
+		t.Fatalf("expected default provider %q, got %q", DefaultAgentProvider, legacy.Provider)
+	}
+
+	codexID, err := db.CreateAgent(&Agent{Name: "Codex", Icon: "🧪", SystemPrompt: "p", Model: "gpt-5", Provider: "codex"})
+	if err != nil {
+		t.Fatalf("CreateAgent failed: %v", err)
+	}
+	runID, err := db.CreateAgentRun(&AgentRun{
+		AgentID: codexID, AgentName: "Codex", AgentIcon: "🧪", Task: "t", Model: "gpt-5",
+		Provider: "codex", ProjectPath: "/repo", SessionID: "s-1", Status: "running",
+	})
+	if err != nil {
+		t.Fatalf("CreateAgentRun failed: %v", err)
+	}
+	run, err := db.GetAgentRun(runID)
+	if err != nil {
+		t.Fatalf("GetAgentRun failed: %v", err)
+	}
+	if run.Provider != "codex" {
+		t.Fatalf("expected run provider codex, got %q", run.Provider)
+	}
+
+	if err := db.UpdateAgentRunSession(runID, "s-2"); err != nil {
+		t.Fatalf("UpdateAgentRunSession failed: %v", err)
+	}
+	linked, err := db.GetAgentRunBySessionID("s-2")
+	if err != nil || linked.ID != runID {
+		t.Fatalf("expected run %d linked to s-2, got %#v (err=%v)", runID, linked, err)
+	}
+
+	exported, err := db.ExportAgent(codexID)
+	if err != nil {
+		t.Fatalf("ExportAgent failed: %v", err)
+	}
+	imported, err := db.ImportAgent(exported)
+	if err != nil {
+		t.Fatalf("ImportAgent failed: %v", err)
+	}
+	if imported.Provider != "codex" {
+		t.Fatalf("expected imported provider codex, got %q", imported.Provider)
+	}
+}
+
+func TestDatabase_OpenMigratesLegacyAgentTables(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "legacy.db")
+	raw, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		t.Fatalf("sql.Open failed: %v", err)
+	}
+	_, err = raw.Exec(`
+		CREATE TABLE agents (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL,
+			icon TEXT NOT NULL DEFAULT '🤖',
+			system_prompt TEXT NOT NULL,
+			default_task TEXT,
+			model TEXT NOT NULL DEFAULT 'sonnet',
+			provider_api_id TEXT,
+			hooks TEXT,
+			created_at INTEGER NOT NULL,
+			updated_at INTEGER NOT NULL
+		);
+		INSERT INTO agents (name, system_prompt, default_task, provider_api_id, hooks, created_at, updated_at)
+		VALUES ('Old', 'prompt', '', '', '', 1, 1);`)
+	raw.Close()
+	if err != nil {
+		t.Fatalf("failed to create legacy schema: %v", err)
+	}
+
+	db, err := Open(dbPath)
+	if err != nil {
+		t.Fatalf("Open failed on legacy database: %v", err)
+	}
+	defer db.Close()
+
+	agents, err := db.ListAgents()
+	if err != nil {
+		t.Fatalf("ListAgents failed: %v", err)
+	}
+	if len(agents) != 1 || agents[0].Provider != DefaultAgentProvider {
+		t.Fatalf("expected migrated agent with default provider, got %#v", agents)
+	}
+	if version, err := db.UserVersion(); err != nil || version != SchemaVersion() {
+		t.Fatalf("expected user_version %d after migration, got %d, %v", SchemaVersion(), version, err)
+	}
+}
+
+func TestDatabase_PipelineCRUD(t *testing.T) {
+	db := openTestDB(t)
+
+	pipeline := &Pipeline{
+		Name:        "Review then fix",
+		Description: "reviewer feeds the fixer",
+		Steps: []PipelineStep{
+			{AgentID: 1, Task: "Review the diff"},
+			{AgentID: 2, Task: "Fix these findings:\n{{previous}}", InputMode: "output"},
+		},
+	}
+	id, err := db.CreatePipeline(pipeline)
+	if err != nil {
+		t.Fatalf("CreatePipeline failed: %v", err)
+	}
+
+	got, err := db.GetPipeline(id)
+	if err != nil {
+		t.Fatalf("GetPipeline failed: %v", err)
+	}
+	if got.Name != pipeline.Name || len(got.Steps) != 2 || got.Steps[1].InputMode != "output" {
+		t.Fatalf("unexpected pipeline: %#v", got)
+	}
+
+	got.Steps = got.Steps[:1]
+	got.Description = ""
+	if err := db.UpdatePipeline(got); err != nil {
+		t.Fatalf("UpdatePipeline failed: %v", err)
+	}
+	pipelines, err := db.ListPipelines()
+	if err != nil {
+		t.Fatalf("ListPipelines failed: %v", err)
+	}
+	if len(pipelines) != 1 || len(pipelines[0].Steps) != 1 || pipelines[0].Description != "" {
+		t.Fatalf("unexpected pipelines after update: %#v", pipelines)
+	}
+
+	if err := db.DeletePipeline(id); err != nil {
+		t.Fatalf("DeletePipeline failed: %v", err)
+	}
+	if _, err := db.GetPipeline(id); err == nil {
+		t.Fatal("expected error for deleted pipeline")
+	}
+}
+
+func TestDatabase_PipelineRunProgress(t *testing.T) {
+	db := openTestDB(t)
+
+	pipelineID, err := db.CreatePipeline(&Pipeline{Name: "p", Steps: []PipelineStep{{AgentID: 1}}})
+	if err != nil {
+		t.Fatalf("CreatePipeline failed: %v", err)
+	}
+	run := &PipelineRun{
+		PipelineID:   pipelineID,
+		PipelineName: "p",
+		ProjectPath:  "/repo",
+		Task:         "start",
+		Status:       "running",
+		Steps:        []PipelineStepRun{{AgentID: 1, Status: "pending"}},
+	}
+	runID, err := db.CreatePipelineRun(run)
+	if err != nil {
+		t.Fatalf("CreatePipelineRun failed: %v", err)
+	}
+
+	now := time.Now()
+	run.Status = "failed"
+	run.Error = "step 1 failed"
+	run.Steps[0].Status = "failed"
+	run.Steps[0].AgentRunID = 7
+	run.CompletedAt = &now
+	if err := db.UpdatePipelineRun(run); err != nil {
+		t.Fatalf("UpdatePipelineRun failed: %v", err)
+	}
+
+	got, err := db.GetPipelineRun(runID)
+	if err != nil {
+		t.Fatalf("GetPipelineRun failed: %v", err)
+	}
+	if got.Status != "failed" || got.Error != "step 1 failed" || got.CompletedAt == nil {
+		t.Fatalf("unexpected run: %#v", got)
+	}
+	if len(got.Steps) != 1 || got.Steps[0].AgentRunID != 7 || got.Steps[0].Status != "failed" {
+		t.Fatalf("unexpected step results: %#v", got.Steps)
+	}
+
+	runs, err := db.ListPipelineRuns(&pipelineID, 10)
+	if err != nil {
+		t.Fatalf("ListPipelineRuns failed: %v", err)
+	}
+	if len(runs) != 1 || runs[0].ID != runID {
+		t.Fatalf("unexpected runs: %#v", runs)
+	}
+
+	if err := db.DeletePipeline(pipelineID); err != nil {
+		t.Fatalf("DeletePipeline failed: %v", err)
+	}
+	if runs, _ := db.ListPipelineRuns(nil, 10); len(runs) != 0 {
+		t.Fatalf("expected runs to be deleted with pipeline, got %d", len(runs))
+	}
+}
+
+func TestDatabase_AgentRunMetricsAndSummaries(t *testing.T) {
+	db := openTestDB(t)
+
+	agentID, err := db.CreateAgent(&Agent{Name: "Reviewer", Icon: "🔍", SystemPrompt: "p", Model: "sonnet"})
+	if err != nil {
+		t.Fatalf("CreateAgent failed: %v", err)
+	}
+	newRun := func(status string) int64 {
+		id, err := db.CreateAgentRun(&AgentRun{
+			AgentID: agentID, AgentName: "Reviewer", AgentIcon: "🔍", Task: "t", Model: "sonnet",
+			ProjectPath: "/repo", Status: status,
+		})
+		if err != nil {
+			t.Fatalf("CreateAgentRun failed: %v", err)
+		}
+		return id
+	}
+
+	running := newRun("running")
+	if run, _ := db.GetAgentRun(running); run.Metrics != nil {
+		t.Fatalf("expected no metrics for unfinished run, got %#v", run.Metrics)
+	}
+
+	now := time.Now()
+	first := newRun("running")
+	if err := db.UpdateAgentRunStatus(first, "completed", 0, nil, &now); err != nil {
+		t.Fatalf("UpdateAgentRunStatus failed: %v", err)
+	}
+	if err := db.UpdateAgentRunMetrics(first, AgentRunMetrics{DurationMS: 4000, InputTokens: 1000, OutputTokens: 200, CostUSD: 0.5}); err != nil {
+		t.Fatalf("UpdateAgentRunMetrics failed: %v", err)
+	}
+	second := newRun("running")
+	if err := db.UpdateAgentRunStatus(second, "failed", 0, nil, &now); err != nil {
+		t.Fatalf("UpdateAgentRunStatus failed: %v", err)
+	}
+	if err := db.UpdateAgentRunMetrics(second, AgentRunMetrics{DurationMS: 2000, InputTokens: 500, OutputTokens: 100, CostUSD: 0.25}); err != nil {
+		t.Fatalf("UpdateAgentRunMetrics failed: %v", err)
+	}
+
+	run, err := db.GetAgentRun(first)
+	if err != nil {
+		t.Fatalf("GetAgentRun failed: %v", err)
+	}
+	if run.Metrics == nil || run.Metrics.TotalTokens != 1200 || run.Metrics.DurationMS != 4000 || run.Metrics.CostUSD != 0.5 {
+		t.Fatalf("unexpected metrics: %#v", run.Metrics)
+	}
+
+	summaries, err := db.GetAgentMetricsSummaries(&agentID)
+	if err != nil {
+		t.Fatalf("GetAgentMetricsSummaries failed: %v", err)
+	}
+	if len(summaries) != 1 {
+		t.Fatalf("expected 1 summary, got %d", len(summaries))
+	}
+	got := summaries[0]
+	if got.RunCount != 2 || got.CompletedRuns != 1 || got.FailedRuns != 1 {
+		t.Fatalf("unexpected run counts: %#v", got)
+	}
+	if got.TotalTokens != 1800 || got.AvgDurationMS != 3000 || got.AvgCostUSD != 0.375 {
+		t.Fatalf("unexpected aggregates: %#v", got)
+	}
+}
+
+func TestDatabase_AgentBatchCRUD(t *testing.T) {
+	db := openTestDB(t)
+
+	batch := &AgentBatch{
+		AgentID:     3,
+		AgentName:   "Migrator",
+		Task:        "bump go version",
+		Status:      "running",
+		Concurrency: 2,
+		Items: []AgentBatchItem{
+			{ProjectPath: "/repos/a", Status: "pending"},
+			{ProjectPath: "/repos/b", Status: "pending"},
+		},
+	}
+	id, err := db.CreateAgentBatch(batch)
+	if err != nil {
+		t.Fatalf("CreateAgentBatch failed: %v", err)
+	}
+
+	now := time.Now()
+	batch.Items[0] = AgentBatchItem{ProjectPath: "/repos/a", AgentRunID: 11, Status: "completed"}
+	batch.Items[1] = AgentBatchItem{ProjectPath: "/repos/b", Status: "failed", Error: "boom"}
+	batch.Status = "failed"
+	batch.CompletedAt = &now
+	if err := db.UpdateAgentBatch(batch); err != nil {
+		t.Fatalf("UpdateAgentBatch failed: %v", err)
+	}
+
+	got, err := db.GetAgentBatch(id)
+	if err != nil {
+		t.Fatalf("GetAgentBatch failed: %v", err)
+	}
+	if got.Status != "failed" || got.Concurrency != 2 || got.CompletedAt == nil {
+		t.Fatalf("unexpected batch: %#v", got)
+	}
+	if !reflect.DeepEqual(got.Items, batch.Items) {
+		t.Fatalf("items mismatch: got %#v want %#v", got.Items, batch.Items)
+	}
+
+	agentID := int64(3)
+	batches, err := db.ListAgentBatches(&agentID, 10)
+	if err != nil {
+		t.Fatalf("ListAgentBatches failed: %v", err)
+	}
+	if len(batches) != 1 || batches[0].ID != id {
+		t.Fatalf("unexpected batches: %#v", batches)
+	}
+
+	if err := db.DeleteAgentBatch(id); err != nil {
+		t.Fatalf("DeleteAgentBatch failed: %v", err)
+	}
+	if batches, _ := db.ListAgentBatches(nil, 10); len(batches) != 0 {
+		t.Fatalf("expected no batches after delete, got %d", len(batches))
+	}
+}
+
+func TestDatabase_ProviderComparisonCRUD(t *testing.T) {
+	db := openTestDB(t)
+
+	comparison := &ProviderComparison{
+		ProjectPath: "/repos/a",
+		Prompt:      "add a health check",
+		Status:      "running",
+		Items: []ProviderComparisonItem{
+			{Provider: "claude", Status: "pending"},
+			{Provider: "codex", Status: "pending"},
+		},
+	}
+	id, err := db.CreateProviderComparison(comparison)
+	if err != nil {
+		t.Fatalf("CreateProviderComparison failed: %v", err)
+	}
+
+	now := time.Unix(time.Now().Unix(), 0).UTC()
+	comparison.Items[0] = ProviderComparisonItem{
+		Provider: "claude", SessionID: "s1", Status: "completed", StartedAt: &now, CompletedAt: &now,
+		Metrics: AgentRunMetrics{DurationMS: 1500, TotalTokens: 30, CostUSD: 0.25},
+		Diff:    &git.TreeDiff{Files: []git.DiffFileStat{{Path: "main.go", Additions: 3}}, Additions: 3, Patch: "+x"},
+	}
+	comparison.Items[1] = ProviderComparisonItem{Provider: "codex", Status: "failed", Error: "boom"}
+	comparison.Status = "failed"
+	comparison.CompletedAt = &now
+	if err := db.UpdateProviderComparison(comparison); err != nil {
+		t.Fatalf("UpdateProviderComparison failed: %v", err)
+	}
+
+	got, err := db.GetProviderComparison(id)
+	if err != nil {
+		t.Fatalf("GetProviderComparison failed: %v", err)
+	}
+	if got.Status != "failed" || got.Prompt != "add a health check" || got.CompletedAt == nil {
+		t.Fatalf("unexpected comparison: %#v", got)
+	}
+	if !reflect.DeepEqual(got.Items, comparison.Items) {
+		t.Fatalf("items mismatch: got %#v want %#v", got.Items, comparison.Items)
+	}
+
+	if list, err := db.ListProviderComparisons("/repos/a", 10); err != nil || len(list) != 1 || list[0].ID != id {
+		t.Fatalf("ListProviderComparisons = %#v, %v", list, err)
+	}
+	if list, _ := db.ListProviderComparisons("/repos/b", 10); len(list) != 0 {
+		t.Fatalf("expected no comparisons for another project, got %d", len(list))
+	}
+
+	if err := db.DeleteProviderComparison(id); err != nil {
+		t.Fatalf("DeleteProviderComparison failed: %v", err)
+	}
+	if list, _ := db.ListProviderComparisons("", 10); len(list) != 0 {
+		t.Fatalf("expected no comparisons after delete, got %d", len(list))
+	}
+}
+
+func TestDatabase_TelemetryMetrics(t *testing.T) {
+	db := openTestDB(t)
+
+	for _, m := range []*TelemetryMetric{
+		{Day: "2026-10-17", Kind: "feature", Name: "session.start.claude", Count: 1},
+		{Day: "2026-10-17", Kind: "feature", Name: "session.start.claude", Count: 1},
+		{Day: "2026-10-17", Kind: "session", Name: "claude", Count: 1, DurationMS: 5000},
+	} {
+		if err := db.AddTelemetryMetric(m); err != nil {
+			t.Fatalf("AddTelemetryMetric failed: %v", err)
+		}
+	}
+	sent, err := db.ListTelemetryMetrics()
+	if err != nil {
+		t.Fatalf("ListTelemetryMetrics failed: %v", err)
+	}
+	if len(sent) != 2 || sent[0].Count != 2 || sent[1].DurationMS != 5000 {
+		t.Fatalf("unexpected metrics: %+v %+v", sent[0], sent[1])
+	}
+
+	// Recorded after the list was taken, so it must survive the removal
+	if err := db.AddTelemetryMetric(&TelemetryMetric{Day: "2026-10-17", Kind: "feature", Name: "session.start.claude", Count: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.RemoveTelemetryMetrics(sent); err != nil {
+		t.Fatalf("RemoveTelemetryMetrics failed: %v", err)
+	}
+	left, _ := db.ListTelemetryMetrics()
+	if len(left) != 1 || left[0].Name != "session.start.claude" || left[0].Count != 1 {
+		t.Fatalf("expected one later use to remain, got %+v", left)
+	}
+
+	if err := db.ClearTelemetryMetrics(); err != nil {
+		t.Fatalf("ClearTelemetryMetrics failed: %v", err)
+	}
+	if left, _ := db.ListTelemetryMetrics(); len(left) != 0 {
+		t.Fatalf("expected no metrics after clear, got %d", len(left))
+	}
+}
+
+func TestDatabase_HookExecutions(t *testing.T) {
+	db := openTestDB(t)
+
+	ok, blocked := 0, 2
+	start := time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)
+	for _, e := range []*HookExecution{
+		{SessionID: "s1", ProjectPath: "/p", HookEvent: "PreToolUse", Matcher: "Bash", Command: "guard", ExitCode: &blocked, Outcome: "error", Output: "no rm", StartedAt: start},
+		{SessionID: "s1", ProjectPath: "/p", HookEvent: "PostToolUse", Matcher: "Edit", Command: "fmt", ExitCode: &ok, Outcome: "success", DurationMS: 40, StartedAt: start},
+		{SessionID: "s2", ProjectPath: "/q", HookEvent: "Stop", Outcome: "cancelled", StartedAt: start},
+	} {
+		if err := db.AddHookExecution(e); err != nil {
+			t.Fatalf("AddHookExecution failed: %v", err)
+		}
+	}
+
+	all, err := db.ListHookExecutions(HookExecutionFilter{})
+	if err != nil {
+		t.Fatalf("ListHookExecutions failed: %v", err)
+	}
+	if len(all) != 3 || all[0].HookEvent != "Stop" || all[0].ExitCode != nil || *all[2].ExitCode != 2 || !all[2].StartedAt.Equal(start) {
+		t.Fatalf("unexpected executions: %+v", all)
+	}
+	failed, _ := db.ListHookExecutions(HookExecutionFilter{SessionID: "s1", FailedOnly: true})
+	if len(failed) != 1 || failed[0].Command != "guard" {
+		t.Fatalf("expected the blocking PreToolUse run, got %+v", failed)
+	}
+	if byEvent, _ := db.ListHookExecutions(HookExecutionFilter{ProjectPath: "/p", HookEvent: "PostToolUse"}); len(byEvent) != 1 {
+		t.Fatalf("expected 1 PostToolUse run, got %d", len(byEvent))
+	}
+
+	if err := db.ClearHookExecutions(); err != nil {
+		t.Fatalf("ClearHookExecutions failed: %v", err)
+	}
+	if left, _ := db.ListHookExecutions(HookExecutionFilter{}); len(left) != 0 {
+		t.Fatalf("expected no executions after clear, got %d", len(left))
+	}
+}
+
+func TestDatabase_ActionRuns(t *testing.T) {
+	db := openTestDB(t)
+
+	start := time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)
+	for i, r := range []*ActionRun{
+		{ID: "r1", ActionID: "build", ActionName: "Build", Scope: "project", Cwd: "/p", Command: "make", Status: ActionRunRunning, StartedAt: start},
+		{ID: "r2", ActionID: "test", ActionName: "Test", Scope: "global", Cwd: "/p", Command: "go test", Status: ActionRunRunning, StartedAt: start.Add(time.Minute)},
+		{ID: "r3", ActionID: "build", ActionName: "Build", Scope: "project", Cwd: "/q", Command: "make", Status: ActionRunRunning, StartedAt: start.Add(2 * time.Minute)},
+	} {
+		if err := db.AddActionRun(r); err != nil {
+			t.Fatalf("AddActionRun %d failed: %v", i, err)
+		}
+	}
+
+	exitCode, finished := 1, start.Add(30*time.Second)
+	if err := db.FinishActionRun(&ActionRun{ID: "r1", Status: ActionRunFailed, ExitCode: &exitCode, Output: "boom", FinishedAt: &finished}); err != nil {
+		t.Fatalf("FinishActionRun failed: %v", err)
+	}
+	if err := db.InterruptActionRuns(); err != nil {
+		t.Fatalf("InterruptActionRuns failed: %v", err)
+	}
+
+	runs, err := db.ListActionRuns(ActionRunFilter{ActionID: "build"})
+	if err != nil {
+		t.Fatalf("ListActionRuns failed: %v", err)
+	}
+	if len(runs) != 2 || runs[0].ID != "r3" || runs[0].Status != ActionRunInterrupted || runs[0].FinishedAt != nil {
+		t.Fatalf("unexpected runs: %+v", runs)
+	}
+	if r := runs[1]; r.Status != ActionRunFailed || r.ExitCode == nil || *r.ExitCode != 1 || r.Output != "boom" || r.FinishedAt == nil || !r.FinishedAt.Equal(finished) {
+		t.Fatalf("unexpected finished run: %+v", r)
+	}
+	if inCwd, _ := db.ListActionRuns(ActionRunFilter{Cwd: "/p"}); len(inCwd) != 2 || inCwd[0].ID != "r2" {
+		t.Fatalf("unexpected runs in /p: %+v", inCwd)
+	}
+}
+
+func TestDatabase_ProjectEnvVars(t *testing.T) {
+	db := openTestDB(t)
+
+	proxy := &ProjectEnvVar{ProjectPath: "/p", Name: "HTTPS_PROXY", Value: "http://proxy:8080"}
+	token := &ProjectEnvVar{ProjectPath: "/p", Name: "API_TOKEN", Value: "s3cret", Secret: true}
+	for _, v := range []*ProjectEnvVar{proxy, token, {ProjectPath: "/q", Name: "HTTPS_PROXY", Value: "other"}} {
+		if err := db.SaveProjectEnvVar(v); err != nil {
+			t.Fatalf("SaveProjectEnvVar %s failed: %v", v.Name, err)
+		}
+	}
+	if err := db.SaveProjectEnvVar(&ProjectEnvVar{ProjectPath: "/p", Name: "API_TOKEN"}); err == nil {
+		t.Fatal("expected a duplicate name in one project to fail")
+	}
+
+	token.Value = "rotated"
+	if err := db.SaveProjectEnvVar(token); err != nil {
+		t.Fatalf("SaveProjectEnvVar update failed: %v", err)
+	}
+	vars, err := db.ListProjectEnvVars("/p")
+	if err != nil {
+		t.Fatalf("ListProjectEnvVars failed: %v", err)
+	}
+	if len(vars) != 2 || vars[0].Name != "API_TOKEN" || vars[0].Value != "rotated" || !vars[0].Secret || vars[1].ID != proxy.ID {
+		t.Fatalf("unexpected vars: %+v", vars)
+	}
+
+	if err := db.DeleteProjectEnvVar(proxy.ID); err != nil {
+		t.Fatalf("DeleteProjectEnvVar failed: %v", err)
+	}
+	if _, err := db.GetProjectEnvVar(proxy.ID); err == nil {
+		t.Fatal("expected the deleted variable to be gone")
+	}
+	if other, _ := db.ListProjectEnvVars("/q"); len(other) != 1 || other[0].Value != "other" {
+		t.Fatalf("unexpected vars in /q: %+v", other)
+	}
+}
+
+func TestDatabase_Snippets(t *testing.T) {
+	db := openTestDB(t)
+
+	review := &Snippet{Title: "Code review", Body: "Review for bugs", Tags: []string{"review"}}
+	if _, err := db.CreateSnippet(review); err != nil {
+		t.Fatalf("CreateSnippet failed: %v", err)
+	}
+	bug := &Snippet{Title: "Bug report", Body: "Steps to reproduce"}
+	if _, err := db.CreateSnippet(bug); err != nil {
+		t.Fatalf("CreateSnippet failed: %v", err)
+	}
+
+	bug.Tags = []string{"bug", "template"}
+	bug.Body = "Steps to reproduce:\n1."
+	if err := db.UpdateSnippet(bug); err != nil {
+		t.Fatalf("UpdateSnippet failed: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := db.RecordSnippetUse(bug.ID); err != nil {
+			t.Fatalf("RecordSnippetUse failed: %v", err)
+		}
+	}
+
+	snippets, err := db.ListSnippets()
+	if err != nil {
+		t.Fatalf("ListSnippets failed: %v", err)
+	}
+	if len(snippets) != 2 || snippets[0].ID != bug.ID || snippets[0].UsageCount != 2 || snippets[0].LastUsedAt == nil ||
+		len(snippets[0].Tags) != 2 || snippets[0].Body != bug.Body {
+		t.Fatalf("unexpected snippets: %+v", snippets)
+	}
+	if snippets[1].LastUsedAt != nil || snippets[1].Tags[0] != "review" {
+		t.Fatalf("unexpected unused snippet: %+v", snippets[1])
+	}
+
+	if err := db.DeleteSnippet(review.ID); err != nil {
+		t.Fatalf("DeleteSnippet failed: %v", err)
+	}
+	if _, err := db.GetSnippet(review.ID); err == nil {
+		t.Fatal("expected the deleted snippet to be gone")
+	}
+}
+
+func TestDatabase_PermissionProfiles(t *testing.T) {
+	db := openTestDB(t)
+
+	profile := &PermissionProfile{
+		ID:                   "review",
+		Name:                 "Review",
+		ClaudePermissionMode: "dontAsk",
+		ClaudeAllowedTools:   []string{"Read", "Grep"},
+		CodexSandbox:         "read-only",
+	}
+	if err := db.SavePermissionProfile(profile); err != nil {
+		t.Fatalf("SavePermissionProfile failed: %v", err)
+	}
+	profile.CodexNetworkAccess = true
+	profile.ClaudeDisallowedTools = []string{"WebFetch"}
+	if err := db.SavePermissionProfile(profile); err != nil {
+		t.Fatalf("SavePermissionProfile update failed: %v", err)
+	}
+
+	got, err := db.GetPermissionProfile("review")
+	if err != nil {
+		t.Fatalf("GetPermissionProfile failed: %v", err)
+	}
+	if got.ClaudePermissionMode != "dontAsk" || len(got.ClaudeAllowedTools) != 2 ||
+		len(got.ClaudeDisallowedTools) != 1 || !got.CodexNetworkAccess || got.CodexSandbox != "read-only" {
+		t.Fatalf("unexpected profile: %+v", got)
+	}
+
+	if id, err := db.GetProjectPermissionProfile("/p"); err != nil || id != "" {
+		t.Fatalf("GetProjectPermissionProfile without selection = %q, %v", id, err)
+	}
+	if err := db.SetProjectPermissionProfile("/p", "review"); err != nil {
+		t.Fatalf("SetProjectPermissionProfile failed: %v", err)
+	}
+	if id, _ := db.GetProjectPermissionProfile("/p"); id != "review" {
+		t.Fatalf("GetProjectPermissionProfile = %q, want review", id)
+	}
+
+	if err := db.DeletePermissionProfile("review"); err != nil {
+		t.Fatalf("DeletePermissionProfile failed: %v", err)
+	}
+	if id, _ := db.GetProjectPermissionProfile("/p"); id != "" {
+		t.Fatalf("project still selects deleted profile %q", id)
+	}
+	profiles, err := db.ListPermissionProfiles()
+	if err != nil || len(profiles) != 0 {
+		t.Fatalf("ListPermissionProfiles = %+v, %v", profiles, err)
+	}
+}
+
+func TestDatabase_SessionCheckpoints(t *testing.T) {
+	db := openTestDB(t)
+
+	resumed := &SessionCheckpoint{SessionID: "s1", Provider: "claude", ProjectPath: "/p", Commit: "aaa", Ref: "refs/ropcode/checkpoints/1"}
+	if _, err := db.CreateSessionCheckpoint(resumed); err != nil {
+		t.Fatalf("CreateSessionCheckpoint failed: %v", err)
+	}
+	started := &SessionCheckpoint{Provider: "codex", ProjectPath: "/p", Commit: "bbb", Ref: "refs/ropcode/checkpoints/2"}
+	if _, err := db.CreateSessionCheckpoint(started); err != nil {
+		t.Fatalf("CreateSessionCheckpoint failed: %v", err)
+	}
+
+	// A new session gets its ID once it reports it
+	if linked, err := db.LinkSessionCheckpoint("/other", "s2", time.Now().Add(-time.Minute)); err != nil || linked {
+		t.Fatalf("LinkSessionCheckpoint for another project = %v, %v", linked, err)
+	}
+	if linked, err := db.LinkSessionCheckpoint("/p", "s2", time.Now().Add(-time.Minute)); err != nil || !linked {
+		t.Fatalf("LinkSessionCheckpoint = %v, %v", linked, err)
+	}
+	if linked, _ := db.LinkSessionCheckpoint("/p", "s3", time.Now().Add(-time.Minute)); linked {
+		t.Fatal("a checkpoint was linked twice")
+	}
+
+	checkpoints, err := db.ListSessionCheckpoints("s2")
+	if err != nil {
+		t.Fatalf("ListSessionCheckpoints failed: %v", err)
+	}
+	if len(checkpoints) != 1 || checkpoints[0].ID != started.ID || checkpoints[0].Commit != "bbb" || checkpoints[0].RestoredAt != nil {
+		t.Fatalf("unexpected checkpoints: %+v", checkpoints)
+	}
+
+	if err := db.MarkSessionCheckpointRestored(started.ID); err != nil {
+		t.Fatalf("MarkSessionCheckpointRestored failed: %v", err)
+	}
+	old, err := db.ListProjectCheckpointsBefore("/p", time.Now().Add(time.Minute))
+	if err != nil || len(old) != 2 || old[1].RestoredAt == nil {
+		t.Fatalf("ListProjectCheckpointsBefore = %+v, %v", old, err)
+	}
+
+	if err := db.DeleteSessionCheckpoint(resumed.ID); err != nil {
+		t.Fatalf("DeleteSessionCheckpoint failed: %v", err)
+	}
+	if checkpoints, _ := db.ListSessionCheckpoints("s1"); len(checkpoints) != 0 {
+		t.Fatalf("checkpoint not deleted: %+v", checkpoints)
+	}
+}
+
+func TestDatabase_SessionFileChanges(t *testing.T) {
+	db := openTestDB(t)
+
+	for _, c := range []*SessionFileChange{
+		{SessionID: "s1", ToolUseID: "t1", Tool: "Write", Path: "/p/a.go", Kind: "created", Ranges: []LineRange{{Start: 1, End: 3}}},
+		{SessionID: "s2", ToolUseID: "t2", Tool: "Edit", Path: "/p/b.go", Kind: "modified"},
+		{SessionID: "s1", ToolUseID: "t3", Tool: "Edit", Path: "/p/a.go", Kind: "modified", Ranges: []LineRange{{Start: 2, End: 2}}},
+	} {
+		c.CreatedAt = time.Now()
+		if err := db.AddSessionFileChange(c); err != nil {
+			t.Fatalf("AddSessionFileChange failed: %v", err)
+		}
+	}
+
+	changes, err := db.ListSessionFileChanges("s1")
+	if err != nil {
+		t.Fatalf("ListSessionFileChanges failed: %v", err)
+	}
+	if len(changes) != 2 || changes[0].ToolUseID != "t1" || changes[1].ToolUseID != "t3" {
+		t.Fatalf("unexpected changes: %+v", changes)
+	}
+	if len(changes[0].Ranges) != 1 || changes[0].Ranges[0] != (LineRange{Start: 1, End: 3}) {
+		t.Fatalf("ranges = %+v", changes[0].Ranges)
+	}
+	if other, _ := db.ListSessionFileChanges("s2"); len(other) != 1 || other[0].Ranges == nil {
+		t.Fatalf("changes without ranges = %+v", other)
+	}
+}
+
+func TestDatabase_SessionEditReviews(t *testing.T) {
+	db := openTestDB(t)
+
+	if err := db.SaveSessionEditReview(&SessionEditReview{SessionID: "s1", Path: "/p/a.go", Status: "accepted"}); err != nil {
+		t.Fatalf("SaveSessionEditReview failed: %v", err)
+	}
+	if err := db.SaveSessionEditReview(&SessionEditReview{SessionID: "s1", Path: "/p/a.go", Status: "rejected"}); err != nil {
+		t.Fatalf("SaveSessionEditReview failed: %v", err)
+	}
+	if err := db.SaveSessionEditReview(&SessionEditReview{SessionID: "s2", Path: "/p/a.go", Status: "accepted"}); err != nil {
+		t.Fatalf("SaveSessionEditReview failed: %v", err)
+	}
+
+	reviews, err := db.ListSessionEditReviews("s1")
+	if err != nil {
+		t.Fatalf("ListSessionEditReviews failed: %v", err)
+	}
+	if len(reviews) != 1 || reviews[0].Status != "rejected" || reviews[0].ReviewedAt.IsZero() {
+		t.Fatalf("unexpected reviews: %+v", reviews)
+	}
+}
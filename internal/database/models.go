@@ -1,7 +1,11 @@
 // internal/database/models.go
 package database
 
-import "time"
+import (
+	"time"
+
+	"ropcode/internal/git"
+)
 
 // ProviderApiConfig stores API configuration for AI providers
 type ProviderApiConfig struct {
@@ -16,6 +20,21 @@ type ProviderApiConfig struct {
 	UpdatedAt  time.Time `json:"updated_at"`
 }
 
+// SessionProviderApi records the provider API config a session was
+// started with
+type SessionProviderApi struct {
+	SessionID     string `json:"session_id"`
+	Provider      string `json:"provider"`
+	ProjectPath   string `json:"project_path"`
+	ProviderApiID string `json:"provider_api_id"`
+	// FailedOverFrom is the session that failed on the previous config of
+	// the failover chain, empty for a first attempt
+	FailedOverFrom string `json:"failed_over_from,omitempty"`
+	// Reason is the error that made the previous config fail
+	Reason    string    `json:"reason,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
 // ProjectIndex stores project metadata
 type ProjectIndex struct {
 	// Computed fields for frontend compatibility (populated from first provider)
@@ -41,6 +60,10 @@ type ProviderInfo struct {
 	ProviderID    string `json:"provider_id"`
 	Path          string `json:"path"`
 	ProviderApiID string `json:"provider_api_id,omitempty"`
+	// DefaultModel and DefaultThinkingLevel are pre-selected for new
+	// sessions of this provider; empty uses the registry's defaults
+	DefaultModel         string `json:"default_model,omitempty"`
+	DefaultThinkingLevel string `json:"default_thinking_level,omitempty"`
 }
 
 // WorkspaceIndex stores workspace metadata
@@ -77,6 +100,10 @@ type Setting struct {
 	UpdatedAt time.Time `json:"updated_at"`
 }
 
+// DefaultAgentProvider is the provider used by agents created before the
+// provider column existed.
+const DefaultAgentProvider = "claude"
+
 // Agent represents a CC Agent stored in the database
 type Agent struct {
 	ID            int64     `json:"id"`
@@ -85,27 +112,149 @@ type Agent struct {
 	SystemPrompt  string    `json:"system_prompt"`
 	DefaultTask   string    `json:"default_task,omitempty"`
 	Model         string    `json:"model"`
+	Provider      string    `json:"provider"` // "claude", "codex", "gemini"
 	ProviderApiID string    `json:"provider_api_id,omitempty"`
 	Hooks         string    `json:"hooks,omitempty"`
+	SourceURL     string    `json:"source_url,omitempty"` // download URL when installed from an agent source
 	CreatedAt     time.Time `json:"created_at"`
 	UpdatedAt     time.Time `json:"updated_at"`
 }
 
 // AgentRun represents a single agent execution run
 type AgentRun struct {
-	ID               int64      `json:"id"`
-	AgentID          int64      `json:"agent_id"`
-	AgentName        string     `json:"agent_name"`
-	AgentIcon        string     `json:"agent_icon"`
-	Task             string     `json:"task"`
-	Model            string     `json:"model"`
-	ProjectPath      string     `json:"project_path"`
-	SessionID        string     `json:"session_id"`
-	Status           string     `json:"status"` // pending, running, completed, failed, cancelled
-	PID              int        `json:"pid,omitempty"`
-	ProcessStartedAt *time.Time `json:"process_started_at,omitempty"`
-	CreatedAt        time.Time  `json:"created_at"`
-	CompletedAt      *time.Time `json:"completed_at,omitempty"`
+	ID               int64            `json:"id"`
+	AgentID          int64            `json:"agent_id"`
+	AgentName        string           `json:"agent_name"`
+	AgentIcon        string           `json:"agent_icon"`
+	Task             string           `json:"task"`
+	Model            string           `json:"model"`
+	Provider         string           `json:"provider"`
+	ProjectPath      string           `json:"project_path"`
+	SessionID        string           `json:"session_id"`
+	Status           string           `json:"status"` // pending, running, completed, failed, cancelled
+	PID              int              `json:"pid,omitempty"`
+	ProcessStartedAt *time.Time       `json:"process_started_at,omitempty"`
+	CreatedAt        time.Time        `json:"created_at"`
+	CompletedAt      *time.Time       `json:"completed_at,omitempty"`
+	Metrics          *AgentRunMetrics `json:"metrics,omitempty"` // set once the run has finished
+}
+
+// AgentRunMetrics stores resource usage for a finished agent run
+type AgentRunMetrics struct {
+	DurationMS   int64   `json:"duration_ms"`
+	InputTokens  int64   `json:"input_tokens"`
+	OutputTokens int64   `json:"output_tokens"`
+	TotalTokens  int64   `json:"total_tokens"`
+	CostUSD      float64 `json:"cost_usd"` // 0 when the provider does not report cost
+}
+
+// AgentMetricsSummary aggregates the metrics of all finished runs of an agent
+type AgentMetricsSummary struct {
+	AgentID       int64   `json:"agent_id"`
+	AgentName     string  `json:"agent_name"`
+	RunCount      int     `json:"run_count"`
+	CompletedRuns int     `json:"completed_runs"`
+	FailedRuns    int     `json:"failed_runs"`
+	CancelledRuns int     `json:"cancelled_runs"`
+	InputTokens   int64   `json:"input_tokens"`
+	OutputTokens  int64   `json:"output_tokens"`
+	TotalTokens   int64   `json:"total_tokens"`
+	CostUSD       float64 `json:"cost_usd"`
+	DurationMS    int64   `json:"duration_ms"`
+	AvgDurationMS int64   `json:"avg_duration_ms"`
+	AvgTokens     int64   `json:"avg_tokens"`
+	AvgCostUSD    float64 `json:"avg_cost_usd"`
+}
+
+// AgentBatchItem records the run of a batch's agent on one project
+type AgentBatchItem struct {
+	ProjectPath string `json:"project_path"`
+	AgentRunID  int64  `json:"agent_run_id,omitempty"`
+	Status      string `json:"status"` // pending, running, completed, failed, cancelled
+	Error       string `json:"error,omitempty"`
+}
+
+// AgentBatch represents one agent executed on several projects
+type AgentBatch struct {
+	ID          int64            `json:"id"`
+	AgentID     int64            `json:"agent_id"`
+	AgentName   string           `json:"agent_name"`
+	Task        string           `json:"task"`
+	Status      string           `json:"status"` // pending, running, completed, failed, cancelled
+	Concurrency int              `json:"concurrency"`
+	Items       []AgentBatchItem `json:"items"`
+	CreatedAt   time.Time        `json:"created_at"`
+	CompletedAt *time.Time       `json:"completed_at,omitempty"`
+}
+
+// ProviderComparisonItem records one provider's run of a comparison, made in
+// a worktree of its own
+type ProviderComparisonItem struct {
+	Provider      string          `json:"provider"`
+	WorkspacePath string          `json:"workspace_path,omitempty"`
+	Branch        string          `json:"branch,omitempty"`
+	BaseCommit    string          `json:"base_commit,omitempty"`
+	SessionID     string          `json:"session_id,omitempty"`
+	Status        string          `json:"status"` // pending, running, completed, failed, cancelled
+	Error         string          `json:"error,omitempty"`
+	StartedAt     *time.Time      `json:"started_at,omitempty"`
+	CompletedAt   *time.Time      `json:"completed_at,omitempty"`
+	Metrics       AgentRunMetrics `json:"metrics"`
+	Diff          *git.TreeDiff   `json:"diff,omitempty"`
+}
+
+// ProviderComparison represents one prompt run by several providers side by side
+type ProviderComparison struct {
+	ID          int64                    `json:"id"`
+	ProjectPath string                   `json:"project_path"`
+	Prompt      string                   `json:"prompt"`
+	Status      string                   `json:"status"` // pending, running, completed, failed, cancelled
+	Items       []ProviderComparisonItem `json:"items"`
+	CreatedAt   time.Time                `json:"created_at"`
+	CompletedAt *time.Time               `json:"completed_at,omitempty"`
+}
+
+// PipelineStep is one agent invocation in a pipeline. Task may reference the
+// previous step's output with {{previous}}; an empty Task passes it through.
+type PipelineStep struct {
+	AgentID   int64  `json:"agent_id"`
+	Task      string `json:"task,omitempty"`
+	Model     string `json:"model,omitempty"`
+	InputMode string `json:"input_mode,omitempty"` // "result" (default) or "output"
+}
+
+// Pipeline chains agents so that each step's output becomes the next task
+type Pipeline struct {
+	ID          int64          `json:"id"`
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	Steps       []PipelineStep `json:"steps"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+}
+
+// PipelineStepRun records the execution of a single pipeline step
+type PipelineStepRun struct {
+	AgentID    int64  `json:"agent_id"`
+	AgentName  string `json:"agent_name,omitempty"`
+	AgentRunID int64  `json:"agent_run_id,omitempty"`
+	Status     string `json:"status"` // pending, running, completed, failed, cancelled, skipped
+	Error      string `json:"error,omitempty"`
+}
+
+// PipelineRun represents a single pipeline execution
+type PipelineRun struct {
+	ID           int64             `json:"id"`
+	PipelineID   int64             `json:"pipeline_id"`
+	PipelineName string            `json:"pipeline_name"`
+	ProjectPath  string            `json:"project_path"`
+	Task         string            `json:"task"`
+	Status       string            `json:"status"` // pending, running, completed, failed, cancelled
+	CurrentStep  int               `json:"current_step"`
+	Steps        []PipelineStepRun `json:"steps"`
+	Error        string            `json:"error,omitempty"`
+	CreatedAt    time.Time         `json:"created_at"`
+	CompletedAt  *time.Time        `json:"completed_at,omitempty"`
 }
 
 // ThinkingLevel represents a thinking depth configuration for a model
@@ -129,4 +278,228 @@ type ModelConfig struct {
 	ThinkingLevels []ThinkingLevel `json:"thinking_levels"` // Available thinking levels (empty = no thinking support)
 	CreatedAt      time.Time       `json:"created_at"`
 	UpdatedAt      time.Time       `json:"updated_at"`
+	// Capabilities is looked up by model ID when the config is read, not
+	// stored with it; nil for models the catalog doesn't know
+	Capabilities *ModelCapabilities `json:"capabilities,omitempty"`
+}
+
+// ModelCapabilities describes what the models whose ID contains
+// ModelPattern can do. Builtin rows ship with the app (Source
+// PricingSourceBuiltin); fetched rows are stored with PricingSourceRemote.
+type ModelCapabilities struct {
+	ModelPattern     string `json:"model_pattern"`
+	ContextWindow    int    `json:"context_window"`    // tokens; 0 when unknown
+	MaxOutputTokens  int    `json:"max_output_tokens"` // 0 when unknown
+	SupportsVision   bool   `json:"supports_vision"`
+	SupportsThinking bool   `json:"supports_thinking"`
+	// PricingPattern is the model_pricing pattern the model is billed by
+	PricingPattern string `json:"pricing_pattern,omitempty"`
+	LastVerified   string `json:"last_verified,omitempty"` // YYYY-MM-DD
+	Source         string `json:"source"`
+}
+
+// Sources of model pricing rows
+const (
+	PricingSourceBuiltin = "builtin" // shipped with the app, replaced on start
+	PricingSourceRemote  = "remote"  // fetched from a pricing URL
+	PricingSourceUser    = "user"    // entered by the user
+)
+
+// ModelPricing is the price of the models whose ID contains ModelPattern,
+// in USD per million tokens, for usage on or after EffectiveDate
+type ModelPricing struct {
+	ID              int64   `json:"id"`
+	ModelPattern    string  `json:"model_pattern"`
+	InputPrice      float64 `json:"input_price"`
+	OutputPrice     float64 `json:"output_price"`
+	CacheWritePrice float64 `json:"cache_write_price"`
+	CacheReadPrice  float64 `json:"cache_read_price"`
+	EffectiveDate   string  `json:"effective_date"` // YYYY-MM-DD; empty means always
+	Source          string  `json:"source"`
+	UpdatedAt       int64   `json:"updated_at"`
+}
+
+// UsageFileCache stores the usage entries parsed from one Claude JSONL log,
+// valid while the file keeps its size and modification time
+type UsageFileCache struct {
+	Path    string `json:"path"`
+	Size    int64  `json:"size"`
+	ModTime int64  `json:"mod_time"` // UnixNano
+	Version int    `json:"version"`  // parser version that produced Entries
+	Entries string `json:"entries"`  // JSON array of usage entries
+}
+
+// CodexSessionEntry is what the Codex session catalog knows of one rollout
+// file, valid while the file keeps its size and modification time. Files
+// without a session_meta record are kept with an empty SessionID so they
+// aren't read again.
+type CodexSessionEntry struct {
+	Path             string `json:"path"`
+	SessionID        string `json:"session_id"`
+	ProjectPath      string `json:"project_path"`
+	CreatedAt        int64  `json:"created_at"` // Unix seconds
+	MessageTimestamp string `json:"message_timestamp"`
+	FirstMessage     string `json:"first_message"`
+	Size             int64  `json:"size"`
+	ModTime          int64  `json:"mod_time"` // UnixNano
+	Version          int    `json:"version"`  // reader version that produced the entry
+}
+
+// CodexSessionDir is a directory of the Codex sessions tree as last listed
+type CodexSessionDir struct {
+	Path    string `json:"path"`
+	ModTime int64  `json:"mod_time"` // UnixNano
+}
+
+// TelemetryMetric is one day's total of an anonymous usage metric
+type TelemetryMetric struct {
+	Day        string `json:"day"`  // YYYY-MM-DD, UTC
+	Kind       string `json:"kind"` // feature or session
+	Name       string `json:"name"`
+	Count      int64  `json:"count"`
+	DurationMS int64  `json:"duration_ms,omitempty"`
+}
+
+// HookExecution is one run of a Claude Code hook, as reported by the CLI
+type HookExecution struct {
+	ID          int64     `json:"id"`
+	SessionID   string    `json:"session_id"`
+	ProjectPath string    `json:"project_path"`
+	HookEvent   string    `json:"hook_event"` // PreToolUse, Stop…
+	Matcher     string    `json:"matcher"`    // what the hook matched, e.g. the tool name
+	Command     string    `json:"command"`    // the configured commands, one per line
+	ExitCode    *int      `json:"exit_code"`  // nil when the CLI didn't report one
+	Outcome     string    `json:"outcome"`    // success, error or cancelled
+	DurationMS  int64     `json:"duration_ms"`
+	Output      string    `json:"output"`
+	Truncated   bool      `json:"truncated"`
+	StartedAt   time.Time `json:"started_at"`
+}
+
+// HookExecutionFilter selects hook executions; empty fields match all
+type HookExecutionFilter struct {
+	SessionID   string `json:"session_id"`
+	ProjectPath string `json:"project_path"`
+	HookEvent   string `json:"hook_event"`
+	FailedOnly  bool   `json:"failed_only"`
+	Limit       int    `json:"limit"` // 200 when 0
+}
+
+// Action run statuses
+const (
+	ActionRunRunning     = "running"
+	ActionRunSucceeded   = "succeeded"
+	ActionRunFailed      = "failed"
+	ActionRunStopped     = "stopped"
+	ActionRunInterrupted = "interrupted" // the app quit while it ran
+)
+
+// ActionRun is one run of a script action
+type ActionRun struct {
+	ID         string     `json:"id"`
+	ActionID   string     `json:"action_id"`
+	ActionName string     `json:"action_name"`
+	Scope      string     `json:"scope"` // global, project or workspace
+	Cwd        string     `json:"cwd"`
+	Command    string     `json:"command"` // after templating
+	Status     string     `json:"status"`
+	ExitCode   *int       `json:"exit_code"` // nil while running
+	Output     string     `json:"output"`    // the end of the output
+	Truncated  bool       `json:"truncated"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at"`
+}
+
+// ActionRunFilter selects action runs; empty fields match all
+type ActionRunFilter struct {
+	ActionID string `json:"action_id"`
+	Cwd      string `json:"cwd"`
+	Limit    int    `json:"limit"` // 50 when 0
+}
+
+// Snippet is a saved prompt fragment that can be inserted into any session
+type Snippet struct {
+	ID         int64      `json:"id"`
+	Title      string     `json:"title"`
+	Body       string     `json:"body"`
+	Tags       []string   `json:"tags"`
+	UsageCount int        `json:"usage_count"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// PermissionProfile limits what the agents of a session may do. It is
+// translated into CLI flags when a Claude or Codex session starts.
+type PermissionProfile struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// ClaudePermissionMode is a Claude --permission-mode; empty or
+	// "bypassPermissions" skips permission checks
+	ClaudePermissionMode  string   `json:"claude_permission_mode"`
+	ClaudeAllowedTools    []string `json:"claude_allowed_tools"`
+	ClaudeDisallowedTools []string `json:"claude_disallowed_tools"`
+	// CodexSandbox is a Codex --sandbox mode; empty leaves the sandbox to the
+	// Codex sandbox settings
+	CodexSandbox       string    `json:"codex_sandbox"`
+	CodexNetworkAccess bool      `json:"codex_network_access"`
+	Builtin            bool      `json:"builtin"` // built into ropcode, never stored
+	CreatedAt          time.Time `json:"created_at"`
+	UpdatedAt          time.Time `json:"updated_at"`
+}
+
+// LineRange is a range of lines in a file, 1-based and inclusive
+type LineRange struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// SessionFileChange is a change a session's tool call made to a file
+type SessionFileChange struct {
+	ID        int64  `json:"id"`
+	SessionID string `json:"session_id"`
+	// ToolUseID identifies the tool call, and so the message, that made it
+	ToolUseID string `json:"tool_use_id"`
+	Tool      string `json:"tool"`
+	Path      string `json:"path"`
+	Kind      string `json:"kind"` // created, modified or deleted
+	// Ranges are the lines changed, in the file as it was after the change
+	Ranges    []LineRange `json:"ranges"`
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+// SessionEditReview is the user's verdict on the changes a session made to
+// a file: accepted or rejected
+type SessionEditReview struct {
+	SessionID  string    `json:"session_id"`
+	Path       string    `json:"path"`
+	Status     string    `json:"status"`
+	ReviewedAt time.Time `json:"reviewed_at"`
+}
+
+// SessionCheckpoint is a snapshot of a project's work tree taken before a
+// session ran, kept as a commit at Ref
+type SessionCheckpoint struct {
+	ID int64 `json:"id"`
+	// SessionID is the provider's session ID, empty until the session
+	// reports it
+	SessionID   string     `json:"session_id"`
+	Provider    string     `json:"provider"`
+	ProjectPath string     `json:"project_path"`
+	Commit      string     `json:"commit"`
+	Ref         string     `json:"ref"`
+	CreatedAt   time.Time  `json:"created_at"`
+	RestoredAt  *time.Time `json:"restored_at"`
+}
+
+// ProjectEnvVar is an environment variable given to the sessions, terminals
+// and commands of a project
+type ProjectEnvVar struct {
+	ID          int64  `json:"id"`
+	ProjectPath string `json:"project_path"`
+	Name        string `json:"name"`
+	Value       string `json:"value"`
+	Secret      bool   `json:"secret"` // masked when listed
+	UpdatedAt   int64  `json:"updated_at"`
 }
@@ -57,12 +57,34 @@ type ProcessChangedEvent struct {
 	Cwd      string `json:"cwd"`
 	State    string `json:"state"` // "running", "stopped"
 	ExitCode *int   `json:"exitCode,omitempty"`
+	Command  string `json:"command,omitempty"`
 }
 
 func (h *EventHub) EmitProcessChanged(event ProcessChangedEvent) {
 	h.emit("process:changed", event)
 }
 
+// 资源使用事件
+type ResourceUsage struct {
+	Kind         string  `json:"kind"` // "session", "process"
+	ID           string  `json:"id"`
+	Provider     string  `json:"provider,omitempty"`
+	Cwd          string  `json:"cwd,omitempty"`
+	PID          int     `json:"pid"`
+	CPUPercent   float64 `json:"cpu_percent"`
+	RSSBytes     uint64  `json:"rss_bytes"`
+	ProcessCount int     `json:"process_count"`
+}
+
+type ResourceUsageEvent struct {
+	Samples   []ResourceUsage `json:"samples"`
+	SampledAt int64           `json:"sampledAt"`
+}
+
+func (h *EventHub) EmitResourceUsage(event ResourceUsageEvent) {
+	h.emit("resource:usage", event)
+}
+
 // 会话相关事件
 type SessionChangedEvent struct {
 	ID       string `json:"id"`
@@ -75,6 +97,23 @@ func (h *EventHub) EmitSessionChanged(event SessionChangedEvent) {
 	h.emit("session:changed", event)
 }
 
+// 会话排队事件
+type SessionQueueEntry struct {
+	ID          string `json:"id"`
+	Provider    string `json:"provider"`
+	ProjectPath string `json:"project_path"`
+	Position    int    `json:"position"`
+	QueuedAt    int64  `json:"queued_at"`
+}
+
+type SessionQueueChangedEvent struct {
+	Entries []SessionQueueEntry `json:"entries"`
+}
+
+func (h *EventHub) EmitSessionQueueChanged(event SessionQueueChangedEvent) {
+	h.emit("session-queue:changed", event)
+}
+
 // Worktree 相关事件
 type WorktreeInfo struct {
 	Path   string `json:"path"`
@@ -91,6 +130,23 @@ func (h *EventHub) EmitWorktreeChanged(event WorktreeChangedEvent) {
 	h.emit("worktree:changed", event)
 }
 
+// 项目文件变化事件
+type FsChange struct {
+	Path string `json:"path"` // 绝对路径
+	Op   string `json:"op"`   // create / modify / delete / rename
+}
+
+type FsChangedEvent struct {
+	Root    string     `json:"root"`
+	Changes []FsChange `json:"changes"`
+	// Truncated 表示变化过多，Changes 只包含一部分，应整体刷新
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+func (h *EventHub) EmitFsChanged(event FsChangedEvent) {
+	h.emit("fs:changed", event)
+}
+
 // Claude 输出事件
 func (h *EventHub) EmitClaudeOutput(sessionID string, output interface{}) {
 	h.emit("claude-output", map[string]interface{}{
@@ -0,0 +1,485 @@
+// Package filechanges attributes file changes to the tool calls that made
+// them. The Tracker reads the claude-output stream of every provider, in
+// the Claude format Codex and Gemini are converted to, and pairs Edit,
+// MultiEdit, Write, NotebookEdit and apply_patch tool calls with their
+// results. A change is stored once its tool call succeeded, with the line
+// ranges it touched in the file as it was then: from the patch Claude
+// reports when there is one, otherwise by finding the new text in the file.
+//
+// Codex and Gemini lines carry no session ID, so they are attributed to
+// the session whose init line last named their working directory.
+package filechanges
+
+import (
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"ropcode/internal/database"
+)
+
+// Change kinds
+const (
+	KindCreated  = "created"
+	KindModified = "modified"
+	KindDeleted  = "deleted"
+)
+
+// Review verdicts on the changes to a file
+const (
+	ReviewAccepted = "accepted"
+	ReviewRejected = "rejected"
+)
+
+// maxPending bounds the tool calls waiting for their results; calls whose
+// result never comes are dropped past it
+const maxPending = 512
+
+// maxLocateSize is the largest file searched for the text an edit wrote
+const maxLocateSize = 4 << 20
+
+// Store keeps recorded changes
+type Store interface {
+	AddSessionFileChange(c *database.SessionFileChange) error
+}
+
+// Tracker records the file changes of sessions from their output
+type Tracker struct {
+	store Store
+	now   func() time.Time
+
+	mu       sync.Mutex
+	pending  map[string]pendingCall // by tool_use ID
+	sessions map[string]string      // session ID by working directory
+}
+
+type pendingCall struct {
+	sessionID string
+	tool      string
+	edits     []edit
+}
+
+// edit is what a tool call does to one file
+type edit struct {
+	path string
+	kind string
+	// hunks are the changed parts of the file, when known
+	hunks []hunk
+	// all marks edits applied to every occurrence of their text
+	all bool
+}
+
+// hunk is a changed part of a file: lines that stay (' ') and lines added
+// ('+') in the order they appear in the new file, and removed lines ('-')
+// where they were
+type hunk []hunkLine
+
+type hunkLine struct {
+	op   byte
+	text string
+}
+
+// NewTracker creates a tracker storing changes in store
+func NewTracker(store Store) *Tracker {
+	return &Tracker{
+		store:    store,
+		now:      time.Now,
+		pending:  make(map[string]pendingCall),
+		sessions: make(map[string]string),
+	}
+}
+
+// Observe reads one claude-output line
+func (t *Tracker) Observe(line string) {
+	if !strings.Contains(line, `"tool_use"`) && !strings.Contains(line, `"tool_result"`) && !strings.Contains(line, `"init"`) {
+		return
+	}
+	var event struct {
+		Type          string                 `json:"type"`
+		Subtype       string                 `json:"subtype"`
+		SessionID     string                 `json:"session_id"`
+		Cwd           string                 `json:"cwd"`
+		Message       *message               `json:"message"`
+		ToolUseResult map[string]interface{} `json:"tool_use_result"`
+	}
+	if json.Unmarshal([]byte(line), &event) != nil {
+		return
+	}
+	cwd := filepath.Clean(event.Cwd)
+
+	t.mu.Lock()
+	if event.Type == "system" && event.Subtype == "init" && event.SessionID != "" && event.Cwd != "" {
+		t.sessions[cwd] = event.SessionID
+	}
+	sessionID := event.SessionID
+	if sessionID == "" && event.Cwd != "" {
+		sessionID = t.sessions[cwd]
+	}
+	t.mu.Unlock()
+	if event.Message == nil || sessionID == "" {
+		return
+	}
+
+	for _, block := range event.Message.Content {
+		switch block.Type {
+		case "tool_use":
+			edits := parseToolUse(block.Name, block.Input, event.Cwd)
+			if len(edits) == 0 {
+				continue
+			}
+			t.mu.Lock()
+			if len(t.pending) >= maxPending {
+				t.pending = make(map[string]pendingCall)
+			}
+			t.pending[block.ID] = pendingCall{sessionID: sessionID, tool: block.Name, edits: edits}
+			t.mu.Unlock()
+		case "tool_result":
+			t.mu.Lock()
+			call, ok := t.pending[block.ToolUseID]
+			delete(t.pending, block.ToolUseID)
+			t.mu.Unlock()
+			if ok && !block.IsError {
+				t.record(block.ToolUseID, call, event.ToolUseResult)
+			}
+		}
+	}
+}
+
+type message struct {
+	Content []contentBlock `json:"content"`
+}
+
+type contentBlock struct {
+	Type      string                 `json:"type"`
+	ID        string                 `json:"id"`
+	Name      string                 `json:"name"`
+	Input     map[string]interface{} `json:"input"`
+	ToolUseID string                 `json:"tool_use_id"`
+	IsError   bool                   `json:"is_error"`
+}
+
+// record stores the changes of a tool call that succeeded
+func (t *Tracker) record(toolUseID string, call pendingCall, result map[string]interface{}) {
+	for _, e := range call.edits {
+		change := &database.SessionFileChange{
+			SessionID: call.sessionID,
+			ToolUseID: toolUseID,
+			Tool:      call.tool,
+			Path:      e.path,
+			Kind:      e.kind,
+			Ranges:    []database.LineRange{},
+			CreatedAt: t.now(),
+		}
+		if e.kind != KindDeleted {
+			if ranges, ok := patchRanges(result); ok && len(call.edits) == 1 {
+				change.Ranges = ranges
+			} else {
+				change.Ranges = locate(e)
+			}
+		}
+		if err := t.store.AddSessionFileChange(change); err != nil {
+			slog.Error("failed to record change", "component", "filechanges", "path", e.path, "error", err)
+		}
+	}
+}
+
+// parseToolUse returns the edits a tool call makes, nil for tools that
+// don't change files
+func parseToolUse(name string, input map[string]interface{}, cwd string) []edit {
+	path := func(key string) string {
+		p, _ := input[key].(string)
+		if p == "" {
+			return ""
+		}
+		if !filepath.IsAbs(p) && cwd != "" {
+			p = filepath.Join(cwd, p)
+		}
+		return filepath.Clean(p)
+	}
+	if patch := patchText(input); patch != "" {
+		return parsePatch(patch, cwd)
+	}
+
+	switch name {
+	case "Write":
+		p := path("file_path")
+		if p == "" {
+			return nil
+		}
+		kind := KindModified
+		if _, err := os.Stat(p); os.IsNotExist(err) {
+			kind = KindCreated
+		}
+		content, _ := input["content"].(string)
+		return []edit{{path: p, kind: kind, hunks: []hunk{added(content)}}}
+	case "Edit":
+		p := path("file_path")
+		if p == "" {
+			return nil
+		}
+		newText, _ := input["new_string"].(string)
+		all, _ := input["replace_all"].(bool)
+		return []edit{{path: p, kind: KindModified, hunks: []hunk{added(newText)}, all: all}}
+	case "MultiEdit":
+		p := path("file_path")
+		if p == "" {
+			return nil
+		}
+		e := edit{path: p, kind: KindModified}
+		edits, _ := input["edits"].([]interface{})
+		for _, item := range edits {
+			item, _ := item.(map[string]interface{})
+			newText, _ := item["new_string"].(string)
+			e.hunks = append(e.hunks, added(newText))
+		}
+		return []edit{e}
+	case "NotebookEdit":
+		if p := path("notebook_path"); p != "" {
+			return []edit{{path: p, kind: KindModified}}
+		}
+	}
+	return nil
+}
+
+// patchText returns the apply_patch patch in a tool input: Codex passes it
+// as "input" for custom tool calls and as the last "command" argument for
+// function calls
+func patchText(input map[string]interface{}) string {
+	for _, key := range []string{"input", "patch"} {
+		if s, ok := input[key].(string); ok && strings.Contains(s, "*** Begin Patch") {
+			return s
+		}
+	}
+	if command, ok := input["command"].([]interface{}); ok && len(command) > 0 {
+		if s, ok := command[len(command)-1].(string); ok && strings.Contains(s, "*** Begin Patch") {
+			return s
+		}
+	}
+	return ""
+}
+
+// parsePatch reads the files an apply_patch patch changes
+func parsePatch(patch, cwd string) []edit {
+	var edits []edit
+	var current *edit
+	resolve := func(p string) string {
+		p = strings.TrimSpace(p)
+		if !filepath.IsAbs(p) && cwd != "" {
+			p = filepath.Join(cwd, p)
+		}
+		return filepath.Clean(p)
+	}
+	start := func(path, kind string) {
+		edits = append(edits, edit{path: resolve(path), kind: kind})
+		current = &edits[len(edits)-1]
+	}
+	for _, line := range strings.Split(patch, "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		switch {
+		case strings.HasPrefix(line, "*** Add File: "):
+			start(strings.TrimPrefix(line, "*** Add File: "), KindCreated)
+			current.hunks = []hunk{{}}
+		case strings.HasPrefix(line, "*** Update File: "):
+			start(strings.TrimPrefix(line, "*** Update File: "), KindModified)
+		case strings.HasPrefix(line, "*** Delete File: "):
+			start(strings.TrimPrefix(line, "*** Delete File: "), KindDeleted)
+			current = nil
+		case strings.HasPrefix(line, "*** Move to: ") && current != nil:
+			current.path = resolve(strings.TrimPrefix(line, "*** Move to: "))
+		case strings.HasPrefix(line, "***"):
+			// *** Begin Patch, *** End Patch, *** End of File
+		case strings.HasPrefix(line, "@@") && current != nil:
+			current.hunks = append(current.hunks, hunk{})
+		case current != nil && line != "" && strings.ContainsRune(" +-", rune(line[0])):
+			if len(current.hunks) == 0 {
+				current.hunks = append(current.hunks, hunk{})
+			}
+			h := &current.hunks[len(current.hunks)-1]
+			*h = append(*h, hunkLine{op: line[0], text: line[1:]})
+		}
+	}
+	return edits
+}
+
+// added is a hunk of only new lines
+func added(text string) hunk {
+	text = strings.TrimSuffix(text, "\n")
+	if text == "" {
+		return nil
+	}
+	var h hunk
+	for _, line := range strings.Split(text, "\n") {
+		h = append(h, hunkLine{op: '+', text: line})
+	}
+	return h
+}
+
+// patchRanges reads the ranges of the structured patch Claude reports with
+// the result of its Edit, MultiEdit and Write calls
+func patchRanges(result map[string]interface{}) ([]database.LineRange, bool) {
+	patch, ok := result["structuredPatch"].([]interface{})
+	if !ok || len(patch) == 0 {
+		return nil, false
+	}
+	var ranges []database.LineRange
+	for _, item := range patch {
+		item, _ := item.(map[string]interface{})
+		newStart, _ := item["newStart"].(float64)
+		lines, _ := item["lines"].([]interface{})
+		var h hunk
+		for _, line := range lines {
+			if s, ok := line.(string); ok && s != "" {
+				h = append(h, hunkLine{op: s[0], text: s[1:]})
+			}
+		}
+		ranges = append(ranges, h.ranges(int(newStart))...)
+	}
+	return merge(ranges), true
+}
+
+// locate finds the ranges of an edit by searching the file for the text of
+// its hunks
+func locate(e edit) []database.LineRange {
+	info, err := os.Stat(e.path)
+	if err != nil || !info.Mode().IsRegular() || info.Size() > maxLocateSize {
+		return []database.LineRange{}
+	}
+	data, err := os.ReadFile(e.path)
+	if err != nil {
+		return []database.LineRange{}
+	}
+	content := string(data)
+	var ranges []database.LineRange
+	for _, h := range e.hunks {
+		text := h.newText()
+		if text == "" {
+			continue
+		}
+		for offset := 0; offset < len(content); {
+			i := strings.Index(content[offset:], text)
+			if i < 0 {
+				break
+			}
+			i += offset
+			ranges = append(ranges, h.ranges(strings.Count(content[:i], "\n")+1)...)
+			if !e.all {
+				break
+			}
+			offset = i + len(text)
+		}
+	}
+	if e.kind == KindCreated && len(ranges) == 0 && content != "" {
+		ranges = []database.LineRange{{Start: 1, End: strings.Count(strings.TrimSuffix(content, "\n"), "\n") + 1}}
+	}
+	return merge(ranges)
+}
+
+// newText is the text of the hunk in the new file
+func (h hunk) newText() string {
+	var lines []string
+	for _, line := range h {
+		if line.op != '-' {
+			lines = append(lines, line.text)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// ranges returns the lines the hunk adds, or the line where it removed
+// some, given the line its new text starts at
+func (h hunk) ranges(start int) []database.LineRange {
+	var ranges []database.LineRange
+	line := start
+	for _, l := range h {
+		switch l.op {
+		case '+':
+			if n := len(ranges); n > 0 && ranges[n-1].End == line-1 {
+				ranges[n-1].End = line
+			} else {
+				ranges = append(ranges, database.LineRange{Start: line, End: line})
+			}
+			line++
+		case '-':
+			at := line
+			if at < 1 {
+				at = 1
+			}
+			if n := len(ranges); n == 0 || ranges[n-1].End < at {
+				ranges = append(ranges, database.LineRange{Start: at, End: at})
+			}
+		case ' ':
+			line++
+		}
+	}
+	return ranges
+}
+
+// merge sorts ranges and joins those that overlap or touch
+func merge(ranges []database.LineRange) []database.LineRange {
+	if len(ranges) == 0 {
+		return []database.LineRange{}
+	}
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i].Start < ranges[j].Start })
+	merged := []database.LineRange{ranges[0]}
+	for _, r := range ranges[1:] {
+		last := &merged[len(merged)-1]
+		if r.Start <= last.End+1 {
+			if r.End > last.End {
+				last.End = r.End
+			}
+			continue
+		}
+		merged = append(merged, r)
+	}
+	return merged
+}
+
+// File sums up the changes a session made to one file
+type File struct {
+	Path string `json:"path"`
+	// Kind is created when the session created the file, deleted when it
+	// last deleted it and modified otherwise
+	Kind string `json:"kind"`
+	// Ranges are the lines the changes touched, each in the file as it was
+	// after that change
+	Ranges  []database.LineRange          `json:"ranges"`
+	Changes []*database.SessionFileChange `json:"changes"`
+	// ChangedAt is the time of the last change
+	ChangedAt time.Time `json:"changed_at"`
+	// Review is the verdict on the changes, empty until they are reviewed
+	// and again once the file changes after that
+	Review string `json:"review,omitempty"`
+}
+
+// Summarize groups changes, oldest first, by file, most recently changed
+// file first
+func Summarize(changes []*database.SessionFileChange) []*File {
+	byPath := make(map[string]*File)
+	var files []*File
+	for _, c := range changes {
+		f := byPath[c.Path]
+		if f == nil {
+			f = &File{Path: c.Path, Kind: c.Kind, Ranges: []database.LineRange{}}
+			byPath[c.Path] = f
+			files = append(files, f)
+		} else if c.Kind == KindDeleted || f.Kind == KindDeleted {
+			f.Kind = c.Kind
+		}
+		f.Changes = append(f.Changes, c)
+		f.Ranges = append(f.Ranges, c.Ranges...)
+		f.ChangedAt = c.CreatedAt
+	}
+	for _, f := range files {
+		f.Ranges = merge(f.Ranges)
+	}
+	sort.SliceStable(files, func(i, j int) bool { return files[i].ChangedAt.After(files[j].ChangedAt) })
+	if files == nil {
+		files = []*File{}
+	}
+	return files
+}
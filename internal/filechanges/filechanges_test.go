@@ -0,0 +1,151 @@
+package filechanges
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"ropcode/internal/database"
+)
+
+type memoryStore struct {
+	changes []*database.SessionFileChange
+}
+
+func (m *memoryStore) AddSessionFileChange(c *database.SessionFileChange) error {
+	m.changes = append(m.changes, c)
+	return nil
+}
+
+func line(t *testing.T, event map[string]interface{}) string {
+	t.Helper()
+	data, err := json.Marshal(event)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func toolUse(cwd, sessionID, id, name string, input map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"type": "assistant", "cwd": cwd, "session_id": sessionID,
+		"message": map[string]interface{}{"content": []interface{}{
+			map[string]interface{}{"type": "tool_use", "id": id, "name": name, "input": input},
+		}},
+	}
+}
+
+func toolResult(cwd, sessionID, id string, isError bool) map[string]interface{} {
+	return map[string]interface{}{
+		"type": "user", "cwd": cwd, "session_id": sessionID,
+		"message": map[string]interface{}{"content": []interface{}{
+			map[string]interface{}{"type": "tool_result", "tool_use_id": id, "content": "ok", "is_error": isError},
+		}},
+	}
+}
+
+func TestTrackerUsesClaudeStructuredPatch(t *testing.T) {
+	store := &memoryStore{}
+	tracker := NewTracker(store)
+	dir := t.TempDir()
+
+	tracker.Observe(line(t, toolUse(dir, "s1", "t1", "Edit", map[string]interface{}{
+		"file_path": "main.go", "old_string": "a", "new_string": "b",
+	})))
+	result := toolResult(dir, "s1", "t1", false)
+	result["tool_use_result"] = map[string]interface{}{
+		"structuredPatch": []interface{}{map[string]interface{}{
+			"oldStart": 10, "oldLines": 3, "newStart": 10, "newLines": 4,
+			"lines": []interface{}{" ctx", "-a", "+b", "+c", " ctx"},
+		}},
+	}
+	tracker.Observe(line(t, result))
+
+	if len(store.changes) != 1 {
+		t.Fatalf("changes = %+v", store.changes)
+	}
+	c := store.changes[0]
+	if c.SessionID != "s1" || c.ToolUseID != "t1" || c.Path != filepath.Join(dir, "main.go") || c.Kind != KindModified {
+		t.Fatalf("change = %+v", c)
+	}
+	if want := []database.LineRange{{Start: 11, End: 12}}; !reflect.DeepEqual(c.Ranges, want) {
+		t.Fatalf("ranges = %+v, want %+v", c.Ranges, want)
+	}
+}
+
+func TestTrackerAttributesCodexPatchesByWorkingDirectory(t *testing.T) {
+	store := &memoryStore{}
+	tracker := NewTracker(store)
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "app.py"), []byte("import os\n\ndef main():\n    print('hi')\n    return 0\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "new.txt"), []byte("one\ntwo\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tracker.Observe(line(t, map[string]interface{}{"type": "system", "subtype": "init", "cwd": dir, "session_id": "thread-1"}))
+	patch := "*** Begin Patch\n" +
+		"*** Update File: app.py\n" +
+		"@@ def main():\n" +
+		"-    print('hello')\n" +
+		"+    print('hi')\n" +
+		"     return 0\n" +
+		"*** Add File: new.txt\n" +
+		"+one\n" +
+		"+two\n" +
+		"*** Delete File: old.txt\n" +
+		"*** End Patch"
+	tracker.Observe(line(t, toolUse(dir, "", "c1", "Edit", map[string]interface{}{"input": patch})))
+	tracker.Observe(line(t, toolResult(dir, "", "c1", false)))
+
+	// A failed call changes nothing
+	tracker.Observe(line(t, toolUse(dir, "", "c2", "Write", map[string]interface{}{"file_path": "x.txt", "content": "x"})))
+	tracker.Observe(line(t, toolResult(dir, "", "c2", true)))
+
+	got := map[string]*database.SessionFileChange{}
+	for _, c := range store.changes {
+		if c.SessionID != "thread-1" || c.ToolUseID != "c1" {
+			t.Fatalf("change = %+v", c)
+		}
+		got[filepath.Base(c.Path)] = c
+	}
+	if len(got) != 3 {
+		t.Fatalf("changes = %+v", store.changes)
+	}
+	if c := got["app.py"]; c.Kind != KindModified || !reflect.DeepEqual(c.Ranges, []database.LineRange{{Start: 4, End: 4}}) {
+		t.Fatalf("app.py = %+v", c)
+	}
+	if c := got["new.txt"]; c.Kind != KindCreated || !reflect.DeepEqual(c.Ranges, []database.LineRange{{Start: 1, End: 2}}) {
+		t.Fatalf("new.txt = %+v", c)
+	}
+	if c := got["old.txt"]; c.Kind != KindDeleted || len(c.Ranges) != 0 {
+		t.Fatalf("old.txt = %+v", c)
+	}
+}
+
+func TestSummarizeGroupsChangesByFile(t *testing.T) {
+	changes := []*database.SessionFileChange{
+		{ToolUseID: "t1", Path: "/p/a.go", Kind: KindCreated, Ranges: []database.LineRange{{Start: 1, End: 5}}},
+		{ToolUseID: "t2", Path: "/p/b.go", Kind: KindModified, Ranges: []database.LineRange{{Start: 3, End: 3}}},
+		{ToolUseID: "t3", Path: "/p/a.go", Kind: KindModified, Ranges: []database.LineRange{{Start: 6, End: 9}}},
+		{ToolUseID: "t4", Path: "/p/b.go", Kind: KindDeleted, Ranges: []database.LineRange{}},
+	}
+	start := time.Now()
+	for i, c := range changes {
+		c.CreatedAt = start.Add(time.Duration(i) * time.Second)
+	}
+	files := Summarize(changes)
+	if len(files) != 2 || files[0].Path != "/p/b.go" || files[1].Path != "/p/a.go" {
+		t.Fatalf("files = %+v", files)
+	}
+	if files[0].Kind != KindDeleted || files[1].Kind != KindCreated || len(files[1].Changes) != 2 {
+		t.Fatalf("kinds = %s, %s", files[0].Kind, files[1].Kind)
+	}
+	if want := []database.LineRange{{Start: 1, End: 9}}; !reflect.DeepEqual(files[1].Ranges, want) {
+		t.Fatalf("ranges = %+v", files[1].Ranges)
+	}
+}
@@ -0,0 +1,139 @@
+// Package fileops deletes, renames and copies files for the file explorer.
+// Deleting moves files to the system trash by default: the Finder trash on
+// macOS, the freedesktop trash on Linux and the Recycle Bin on Windows.
+package fileops
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrTrashUnavailable is returned when a file can't be moved to the trash,
+// e.g. on a headless server or another filesystem than the trash's. The
+// caller may delete it permanently instead.
+var ErrTrashUnavailable = errors.New("the trash is not available for this file")
+
+// Delete removes path, moving it to the trash unless permanent is set
+func Delete(path string, permanent bool) error {
+	path, err := checkPath(path)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Lstat(path); err != nil {
+		return err
+	}
+	if permanent {
+		return os.RemoveAll(path)
+	}
+	return trash(path)
+}
+
+// Rename moves oldPath to newPath, refusing to replace an existing file
+func Rename(oldPath, newPath string) error {
+	oldPath, err := checkPath(oldPath)
+	if err != nil {
+		return err
+	}
+	if newPath, err = checkPath(newPath); err != nil {
+		return err
+	}
+	if _, err := os.Lstat(oldPath); err != nil {
+		return err
+	}
+	// A case-only rename is the same file on case-insensitive filesystems
+	if target, err := os.Lstat(newPath); err == nil {
+		if source, _ := os.Lstat(oldPath); !os.SameFile(source, target) {
+			return fmt.Errorf("%s already exists", newPath)
+		}
+	}
+	return os.Rename(oldPath, newPath)
+}
+
+// Copy copies a file or a directory tree from src to dst, keeping modes and
+// copying symlinks as links. dst must not exist yet.
+func Copy(src, dst string) error {
+	src, err := checkPath(src)
+	if err != nil {
+		return err
+	}
+	if dst, err = checkPath(dst); err != nil {
+		return err
+	}
+	if _, err := os.Lstat(dst); err == nil {
+		return fmt.Errorf("%s already exists", dst)
+	}
+	if dst == src || strings.HasPrefix(dst, src+string(filepath.Separator)) {
+		return fmt.Errorf("can't copy %s into itself", src)
+	}
+	return copyTree(src, dst)
+}
+
+// checkPath cleans an absolute path and rejects a filesystem root
+func checkPath(path string) (string, error) {
+	if !filepath.IsAbs(path) {
+		return "", fmt.Errorf("path must be absolute: %q", path)
+	}
+	path = filepath.Clean(path)
+	if filepath.Dir(path) == path {
+		return "", fmt.Errorf("refusing to modify %s", path)
+	}
+	return path, nil
+}
+
+func copyTree(src, dst string) error {
+	info, err := os.Lstat(src)
+	if err != nil {
+		return err
+	}
+	switch {
+	case info.Mode()&fs.ModeSymlink != 0:
+		target, err := os.Readlink(src)
+		if err != nil {
+			return err
+		}
+		return os.Symlink(target, dst)
+	case info.IsDir():
+		entries, err := os.ReadDir(src)
+		if err != nil {
+			return err
+		}
+		if err := os.Mkdir(dst, info.Mode().Perm()|0700); err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if err := copyTree(filepath.Join(src, entry.Name()), filepath.Join(dst, entry.Name())); err != nil {
+				return err
+			}
+		}
+		return os.Chmod(dst, info.Mode().Perm())
+	case info.Mode().IsRegular():
+		return copyFile(src, dst, info.Mode().Perm())
+	default:
+		return fmt.Errorf("can't copy %s: not a regular file", src)
+	}
+}
+
+func copyFile(src, dst string, mode fs.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, mode)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(out, in)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(dst)
+	}
+	return err
+}
@@ -0,0 +1,100 @@
+package fileops
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestCopyTree(t *testing.T) {
+	root := t.TempDir()
+	src := filepath.Join(root, "src")
+	os.MkdirAll(filepath.Join(src, "sub"), 0755)
+	os.WriteFile(filepath.Join(src, "run.sh"), []byte("#!/bin/sh"), 0755)
+	os.WriteFile(filepath.Join(src, "sub", "a.txt"), []byte("a"), 0644)
+
+	dst := filepath.Join(root, "copy")
+	if err := Copy(src, dst); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dst, "sub", "a.txt")); string(data) != "a" {
+		t.Errorf("copied content = %q", data)
+	}
+	if runtime.GOOS != "windows" {
+		if info, _ := os.Stat(filepath.Join(dst, "run.sh")); info.Mode().Perm() != 0755 {
+			t.Errorf("copied mode = %v", info.Mode().Perm())
+		}
+	}
+
+	if err := Copy(src, dst); err == nil {
+		t.Error("Copy() replaced an existing destination")
+	}
+	if err := Copy(src, filepath.Join(src, "sub", "loop")); err == nil {
+		t.Error("Copy() copied a directory into itself")
+	}
+}
+
+func TestRenameAndDelete(t *testing.T) {
+	root := t.TempDir()
+	a, b := filepath.Join(root, "a.txt"), filepath.Join(root, "b.txt")
+	os.WriteFile(a, []byte("a"), 0644)
+	os.WriteFile(b, []byte("b"), 0644)
+
+	if err := Rename(a, b); err == nil {
+		t.Error("Rename() replaced an existing file")
+	}
+	c := filepath.Join(root, "c.txt")
+	if err := Rename(a, c); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(c); err != nil {
+		t.Errorf("renamed file missing: %v", err)
+	}
+
+	if err := Delete(c, true); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(c); !os.IsNotExist(err) {
+		t.Error("Delete() left the file behind")
+	}
+	if err := Delete("relative.txt", true); err == nil {
+		t.Error("Delete() accepted a relative path")
+	}
+	if err := Delete(string(filepath.Separator), true); err == nil || !strings.Contains(err.Error(), "refusing") {
+		t.Errorf("Delete(root) error = %v", err)
+	}
+}
+
+func TestTrashFreedesktop(t *testing.T) {
+	if runtime.GOOS == "darwin" || runtime.GOOS == "windows" {
+		t.Skip("freedesktop trash only")
+	}
+	root := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", filepath.Join(root, "data"))
+
+	for i := 0; i < 2; i++ {
+		path := filepath.Join(root, "note.txt")
+		os.WriteFile(path, []byte("x"), 0644)
+		err := Delete(path, false)
+		if errors.Is(err, ErrTrashUnavailable) {
+			t.Skip(err)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	trash := filepath.Join(root, "data", "Trash")
+	for _, name := range []string{"files/note.txt", "files/note.txt.2", "info/note.txt.trashinfo", "info/note.txt.2.trashinfo"} {
+		if _, err := os.Stat(filepath.Join(trash, filepath.FromSlash(name))); err != nil {
+			t.Errorf("%s missing from the trash: %v", name, err)
+		}
+	}
+	info, _ := os.ReadFile(filepath.Join(trash, "info", "note.txt.trashinfo"))
+	if !strings.Contains(string(info), "Path="+filepath.ToSlash(filepath.Join(root, "note.txt"))) {
+		t.Errorf("trashinfo = %s", info)
+	}
+}
@@ -0,0 +1,80 @@
+//go:build !darwin && !windows
+
+package fileops
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// Files go to the home trash of the freedesktop.org trash specification,
+// where file managers can restore them: the file under files/ and a
+// .trashinfo record of where it came from under info/
+
+// trashDir returns $XDG_DATA_HOME/Trash
+func trashDir() (string, error) {
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dataHome = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(dataHome, "Trash"), nil
+}
+
+func trash(path string) error {
+	dir, err := trashDir()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrTrashUnavailable, err)
+	}
+	filesDir, infoDir := filepath.Join(dir, "files"), filepath.Join(dir, "info")
+	if err := os.MkdirAll(filesDir, 0700); err != nil {
+		return fmt.Errorf("%w: %v", ErrTrashUnavailable, err)
+	}
+	if err := os.MkdirAll(infoDir, 0700); err != nil {
+		return fmt.Errorf("%w: %v", ErrTrashUnavailable, err)
+	}
+
+	info := fmt.Sprintf("[Trash Info]\nPath=%s\nDeletionDate=%s\n",
+		(&url.URL{Path: path}).EscapedPath(), time.Now().Format("2006-01-02T15:04:05"))
+
+	// Claim a free name by creating its .trashinfo exclusively
+	base := filepath.Base(path)
+	for i := 1; ; i++ {
+		name := base
+		if i > 1 {
+			name = base + "." + strconv.Itoa(i)
+		}
+		infoPath := filepath.Join(infoDir, name+".trashinfo")
+		f, err := os.OpenFile(infoPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if errors.Is(err, os.ErrExist) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrTrashUnavailable, err)
+		}
+		_, err = f.WriteString(info)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err == nil {
+			err = os.Rename(path, filepath.Join(filesDir, name))
+		}
+		if err != nil {
+			os.Remove(infoPath)
+			if errors.Is(err, syscall.EXDEV) {
+				return fmt.Errorf("%w: %s is on another filesystem than %s", ErrTrashUnavailable, path, dir)
+			}
+			return err
+		}
+		return nil
+	}
+}
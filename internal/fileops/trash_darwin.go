@@ -0,0 +1,21 @@
+//go:build darwin
+
+package fileops
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// trash asks Finder to delete the file, which keeps "Put Back" working.
+// The path is passed as an argument so it needs no AppleScript quoting.
+func trash(path string) error {
+	script := `on run argv
+	tell application "Finder" to delete POSIX file (item 1 of argv)
+end run`
+	if output, err := exec.Command("osascript", "-e", script, path).CombinedOutput(); err != nil {
+		return fmt.Errorf("%w: %v: %s", ErrTrashUnavailable, err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
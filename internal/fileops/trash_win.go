@@ -0,0 +1,38 @@
+//go:build windows
+
+package fileops
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+)
+
+// createNoWindow keeps PowerShell from flashing a console window
+const createNoWindow = 0x08000000
+
+// recycleScript sends a file or directory to the Recycle Bin; the path
+// comes from the environment so it needs no PowerShell quoting
+const recycleScript = `
+Add-Type -AssemblyName Microsoft.VisualBasic
+$path = $env:ROPCODE_TRASH_PATH
+$ui = [Microsoft.VisualBasic.FileIO.UIOption]::OnlyErrorDialogs
+$bin = [Microsoft.VisualBasic.FileIO.RecycleOption]::SendToRecycleBin
+if (Test-Path -LiteralPath $path -PathType Container) {
+	[Microsoft.VisualBasic.FileIO.FileSystem]::DeleteDirectory($path, $ui, $bin)
+} else {
+	[Microsoft.VisualBasic.FileIO.FileSystem]::DeleteFile($path, $ui, $bin)
+}
+`
+
+func trash(path string) error {
+	cmd := exec.Command("powershell.exe", "-NoProfile", "-NonInteractive", "-Command", recycleScript)
+	cmd.Env = append(os.Environ(), "ROPCODE_TRASH_PATH="+path)
+	cmd.SysProcAttr = &syscall.SysProcAttr{CreationFlags: createNoWindow}
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%w: %v: %s", ErrTrashUnavailable, err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
@@ -0,0 +1,175 @@
+// Package filetree builds the nested directory trees shown in the file
+// explorer. A tree is read a few levels deep at once, and directories below
+// that are left for the caller to expand later. Entries git ignores can be
+// left out.
+package filetree
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"ropcode/internal/git"
+)
+
+const (
+	// MaxDepth caps how many levels are read at once
+	MaxDepth = 8
+	// maxNodes stops a deep read of a huge tree; directories past it are
+	// returned unloaded
+	maxNodes = 20000
+)
+
+// Node is a file or directory in a tree
+type Node struct {
+	Name        string `json:"name"`
+	Path        string `json:"path"`
+	IsDirectory bool   `json:"is_directory"`
+	Size        int64  `json:"size"`
+	Extension   string `json:"extension,omitempty"`
+	// ChildCount is the number of entries shown in a directory, known even
+	// when its children aren't loaded
+	ChildCount int `json:"child_count"`
+	// Children is nil for files and for directories that still have to be
+	// expanded
+	Children []*Node `json:"children"`
+}
+
+// Loaded reports whether the children of a directory were read
+func (n *Node) Loaded() bool {
+	return n.Children != nil
+}
+
+// Read returns the tree under root with depth levels of children loaded
+// (1 lists root itself). With respectGitignore, entries ignored by git and
+// the .git directory are left out; outside a repository nothing is.
+func Read(root string, depth int, respectGitignore bool) (*Node, error) {
+	root = filepath.Clean(root)
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", root)
+	}
+	depth = min(max(depth, 1), MaxDepth)
+
+	tree := &Node{Name: filepath.Base(root), Path: root, IsDirectory: true}
+	// Read one level past depth so the directories left unloaded still
+	// know how many entries they have
+	level := []*Node{tree}
+	nodes := 0
+	for d := 0; d <= depth && len(level) > 0; d++ {
+		var next []*Node
+		var entries []*Node
+		for _, dir := range level {
+			children := readDir(dir.Path)
+			dir.Children = children
+			entries = append(entries, children...)
+		}
+		if respectGitignore {
+			ignored := ignoredPaths(root, entries)
+			for _, dir := range level {
+				dir.Children = visible(dir.Children, ignored)
+			}
+		}
+		for _, dir := range level {
+			dir.ChildCount = len(dir.Children)
+			if d == depth || nodes >= maxNodes {
+				dir.Children = nil
+				continue
+			}
+			nodes += len(dir.Children)
+			for _, child := range dir.Children {
+				if child.IsDirectory {
+					next = append(next, child)
+				}
+			}
+		}
+		level = next
+	}
+	return tree, nil
+}
+
+// readDir lists a directory, directories first and then by name. An
+// unreadable directory is shown empty.
+func readDir(dir string) []*Node {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return []*Node{}
+	}
+	nodes := make([]*Node, 0, len(entries))
+	for _, entry := range entries {
+		node := &Node{
+			Name:        entry.Name(),
+			Path:        filepath.Join(dir, entry.Name()),
+			IsDirectory: entry.IsDir(),
+		}
+		if !node.IsDirectory {
+			if info, err := entry.Info(); err == nil {
+				node.Size = info.Size()
+			}
+			node.Extension = strings.TrimPrefix(filepath.Ext(node.Name), ".")
+		}
+		nodes = append(nodes, node)
+	}
+	sort.SliceStable(nodes, func(i, j int) bool {
+		if nodes[i].IsDirectory != nodes[j].IsDirectory {
+			return nodes[i].IsDirectory
+		}
+		return strings.ToLower(nodes[i].Name) < strings.ToLower(nodes[j].Name)
+	})
+	return nodes
+}
+
+func visible(nodes []*Node, ignored map[string]bool) []*Node {
+	shown := nodes[:0]
+	for _, node := range nodes {
+		if node.Name == ".git" || ignored[node.Path] {
+			continue
+		}
+		shown = append(shown, node)
+	}
+	return shown
+}
+
+// ignoredPaths asks git which of the nodes are ignored, in one call. Tracked
+// files are never reported.
+func ignoredPaths(root string, nodes []*Node) map[string]bool {
+	ignored := map[string]bool{}
+	if len(nodes) == 0 {
+		return ignored
+	}
+	var stdin bytes.Buffer
+	// Relative paths keep git from rejecting a root reached through a
+	// symlink as outside the repository
+	for _, node := range nodes {
+		rel, err := filepath.Rel(root, node.Path)
+		if err != nil {
+			continue
+		}
+		stdin.WriteString(filepath.ToSlash(rel))
+		stdin.WriteByte(0)
+	}
+	cmd := git.Command("check-ignore", "--stdin", "-z")
+	cmd.Dir = root
+	cmd.Stdin = &stdin
+	output, err := cmd.Output()
+	// Exit status 1 means nothing is ignored; anything else (not a
+	// repository, git missing) means nothing is filtered
+	var exitErr *exec.ExitError
+	if err != nil && !(errors.As(err, &exitErr) && exitErr.ExitCode() == 1) {
+		return ignored
+	}
+	for _, path := range strings.Split(string(output), "\x00") {
+		if path != "" {
+			ignored[filepath.Join(root, filepath.FromSlash(path))] = true
+		}
+	}
+	return ignored
+}
@@ -0,0 +1,100 @@
+package filetree
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func writeFiles(t *testing.T, root string, files ...string) {
+	t.Helper()
+	for _, name := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func names(nodes []*Node) []string {
+	var out []string
+	for _, node := range nodes {
+		out = append(out, node.Name)
+	}
+	return out
+}
+
+func TestReadDepthAndChildCounts(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, "b.txt", "A.txt", "src/main.go", "src/pkg/util.go", "src/pkg/more.go")
+	os.Mkdir(filepath.Join(root, "empty"), 0755)
+
+	tree, err := Read(root, 1, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := names(tree.Children); len(got) != 4 || got[0] != "empty" || got[1] != "src" || got[2] != "A.txt" || got[3] != "b.txt" {
+		t.Fatalf("root children = %v", got)
+	}
+	src := tree.Children[1]
+	if src.Loaded() || src.ChildCount != 2 {
+		t.Errorf("src = loaded %v, %d children; want unloaded with 2", src.Loaded(), src.ChildCount)
+	}
+	if empty := tree.Children[0]; empty.ChildCount != 0 {
+		t.Errorf("empty dir has %d children", empty.ChildCount)
+	}
+
+	tree, err = Read(root, 2, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	src = tree.Children[1]
+	if !src.Loaded() || len(src.Children) != 2 {
+		t.Fatalf("src children at depth 2 = %v", names(src.Children))
+	}
+	if pkg := src.Children[0]; pkg.Name != "pkg" || pkg.Loaded() || pkg.ChildCount != 2 {
+		t.Errorf("pkg = %+v", pkg)
+	}
+
+	if _, err := Read(filepath.Join(root, "b.txt"), 1, false); err == nil {
+		t.Error("Read() accepted a file")
+	}
+}
+
+func TestReadRespectsGitignore(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	root := t.TempDir()
+	if out, err := exec.Command("git", "init", "-q", root).CombinedOutput(); err != nil {
+		t.Fatalf("git init: %v %s", err, out)
+	}
+	writeFiles(t, root, ".gitignore", "main.go", "debug.log", "build/out.bin", "src/gen/x.go", "src/keep.go")
+	os.WriteFile(filepath.Join(root, ".gitignore"), []byte("*.log\nbuild/\ngen/\n"), 0644)
+
+	tree, err := Read(root, 2, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := names(tree.Children); len(got) != 3 || got[0] != "src" || got[1] != ".gitignore" || got[2] != "main.go" {
+		t.Fatalf("root children = %v", got)
+	}
+	if src := tree.Children[0]; src.ChildCount != 1 || src.Children[0].Name != "keep.go" {
+		t.Errorf("src children = %v", names(src.Children))
+	}
+
+	// Outside a repository nothing is filtered
+	plain := t.TempDir()
+	writeFiles(t, plain, "debug.log")
+	tree, err = Read(plain, 1, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tree.ChildCount != 1 {
+		t.Errorf("plain dir children = %v", names(tree.Children))
+	}
+}
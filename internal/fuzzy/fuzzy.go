@@ -0,0 +1,107 @@
+// Package fuzzy scores how well a typed query matches a piece of text. A
+// query matches when its characters appear in the text in order, ignoring
+// case; matches that are consecutive, start words or start the text score
+// higher, so "cr" ranks "Code review" above "Bug report: crash".
+package fuzzy
+
+import (
+	"math"
+	"unicode"
+)
+
+const (
+	scoreMatch       = 1
+	bonusConsecutive = 4
+	bonusWordStart   = 6
+	bonusPrefix      = 8
+	// a gap between two matched characters costs one point per skipped
+	// character, up to maxGapPenalty
+	maxGapPenalty = 3
+)
+
+const none = math.MinInt / 2
+
+// Score returns the score of the best alignment of pattern in text and
+// whether it matches at all. An empty pattern matches everything with a
+// zero score.
+func Score(pattern, text string) (int, bool) {
+	if pattern == "" {
+		return 0, true
+	}
+	p := []rune(pattern)
+	t := []rune(text)
+	if len(p) > len(t) {
+		return 0, false
+	}
+	for i := range p {
+		p[i] = unicode.ToLower(p[i])
+	}
+
+	// bonus[j] is what matching text[j] is worth on its own
+	bonus := make([]int, len(t))
+	for j, r := range t {
+		bonus[j] = scoreMatch
+		switch {
+		case j == 0:
+			bonus[j] += bonusPrefix
+		case isWordStart(t[j-1], r):
+			bonus[j] += bonusWordStart
+		}
+	}
+
+	// prev[j] is the best score of the pattern so far with its last
+	// character matched at text[j]
+	prev := make([]int, len(t))
+	cur := make([]int, len(t))
+	for j, r := range t {
+		prev[j] = none
+		if unicode.ToLower(r) == p[0] {
+			prev[j] = bonus[j]
+		}
+	}
+	for i := 1; i < len(p); i++ {
+		// far is the best prev[k] that pays the full gap penalty
+		far := none
+		for j, r := range t {
+			if k := j - 1 - maxGapPenalty; k >= 0 {
+				far = max(far, prev[k])
+			}
+			cur[j] = none
+			if j == 0 || unicode.ToLower(r) != p[i] {
+				continue
+			}
+			best := far - maxGapPenalty
+			if prev[j-1] != none {
+				best = max(best, prev[j-1]+bonusConsecutive)
+			}
+			for gap := 1; gap < maxGapPenalty && j-1-gap >= 0; gap++ {
+				if prev[j-1-gap] != none {
+					best = max(best, prev[j-1-gap]-gap)
+				}
+			}
+			if best > none/2 {
+				cur[j] = best + bonus[j]
+			}
+		}
+		prev, cur = cur, prev
+	}
+
+	score, ok := none, false
+	for _, s := range prev {
+		if s != none {
+			score, ok = max(score, s), true
+		}
+	}
+	if !ok {
+		return 0, false
+	}
+	return score, true
+}
+
+// isWordStart reports whether r begins a word after prev
+func isWordStart(prev, r rune) bool {
+	if !unicode.IsLetter(prev) && !unicode.IsDigit(prev) {
+		return unicode.IsLetter(r) || unicode.IsDigit(r)
+	}
+	return unicode.IsLower(prev) && unicode.IsUpper(r)
+}
@@ -0,0 +1,45 @@
+package fuzzy
+
+import "testing"
+
+func TestScoreMatches(t *testing.T) {
+	tests := []struct {
+		pattern, text string
+		want          bool
+	}{
+		{"", "anything", true},
+		{"cr", "Code review", true},
+		{"CODE", "code review", true},
+		{"crv", "Code review", true},
+		{"rc", "Code review", false},
+		{"codes", "code", false},
+		{"ü", "Über", true},
+	}
+	for _, tt := range tests {
+		if _, got := Score(tt.pattern, tt.text); got != tt.want {
+			t.Errorf("Score(%q, %q) matched = %v, want %v", tt.pattern, tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestScoreRanking(t *testing.T) {
+	better := []struct{ pattern, better, worse string }{
+		{"cr", "Code review", "Bug report: crash"},
+		{"bug", "Bug report", "Debugging notes"},
+		{"rev", "review checklist", "pre-vetted"},
+		{"rt", "reportTemplate", "report"},
+	}
+	for _, tt := range better {
+		b, ok := Score(tt.pattern, tt.better)
+		if !ok {
+			t.Fatalf("Score(%q, %q) did not match", tt.pattern, tt.better)
+		}
+		w, ok := Score(tt.pattern, tt.worse)
+		if !ok {
+			t.Fatalf("Score(%q, %q) did not match", tt.pattern, tt.worse)
+		}
+		if b <= w {
+			t.Errorf("Score(%q): %q = %d, want more than %q = %d", tt.pattern, tt.better, b, tt.worse, w)
+		}
+	}
+}
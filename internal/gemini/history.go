@@ -2,6 +2,8 @@
 package gemini
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"log"
@@ -22,6 +24,13 @@ func GeminiDir() (string, error) {
 	return filepath.Join(home, ".gemini"), nil
 }
 
+// GetProjectHash returns the name of the directory under ~/.gemini/tmp
+// that holds the sessions of projectPath, the SHA-256 of the path
+func GetProjectHash(projectPath string) string {
+	sum := sha256.Sum256([]byte(projectPath))
+	return hex.EncodeToString(sum[:])
+}
+
 // FindSessionFile searches for a session file in the Gemini sessions directory
 // Gemini stores sessions in ~/.gemini/tmp/{project_hash}/chats/session-{session_id}.json
 func FindSessionFile(geminiDir, projectID, sessionID string) (string, error) {
@@ -8,6 +8,8 @@ import (
 	"os/exec"
 	"path/filepath"
 	"sync"
+
+	"ropcode/internal/clicompat"
 )
 
 type SessionManager struct {
@@ -49,6 +51,21 @@ func (m *SessionManager) GetBinaryPath() string {
 	return m.binaryPath
 }
 
+// ResolveBinaryPath returns the binary path, discovering and remembering
+// it when none is set
+func (m *SessionManager) ResolveBinaryPath() (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.binaryPath == "" {
+		path, err := m.discoverBinary()
+		if err != nil {
+			return "", fmt.Errorf("gemini binary not configured: %w", err)
+		}
+		m.binaryPath = path
+	}
+	return m.binaryPath, nil
+}
+
 // SetProcessEmitter sets the process changed emitter
 func (m *SessionManager) SetProcessEmitter(emitter ProcessChangedEmitter) {
 	m.mu.Lock()
@@ -88,14 +105,19 @@ func (m *SessionManager) StartSession(config SessionConfig) (string, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	// Check if binary path is set
-	if m.binaryPath == "" {
+	// Check if binary path is set; remote sessions use their own binary
+	if m.binaryPath == "" && config.Remote == nil {
 		path, err := m.discoverBinary()
 		if err != nil {
 			return "", fmt.Errorf("gemini binary not configured: %w", err)
 		}
 		m.binaryPath = path
 	}
+	if config.Remote == nil {
+		if err := clicompat.Require("gemini", m.binaryPath, config.cliFeatures()...); err != nil {
+			return "", err
+		}
+	}
 
 	// Check if there's already a running session for this project
 	if config.ProjectPath != "" {
@@ -206,6 +228,28 @@ func (m *SessionManager) GetSessionOutput(sessionID string) (string, error) {
 	return session.GetOutput(), nil
 }
 
+// WaitForSession blocks until the session's process exits or ctx is done and
+// returns the final session status ("completed", "failed" or "cancelled").
+func (m *SessionManager) WaitForSession(ctx context.Context, sessionID string) (string, error) {
+	m.mu.RLock()
+	session, exists := m.sessions[sessionID]
+	m.mu.RUnlock()
+
+	if !exists {
+		return "", fmt.Errorf("session not found: %s", sessionID)
+	}
+
+	select {
+	case <-session.done:
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+
+	session.mu.RLock()
+	defer session.mu.RUnlock()
+	return session.Status, nil
+}
+
 // ListRunningSessions returns a list of all running sessions
 func (m *SessionManager) ListRunningSessions() []*SessionStatus {
 	m.mu.RLock()
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"log/slog"
 	"os"
 	"os/exec"
 	"strings"
@@ -15,6 +16,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"ropcode/internal/loginenv"
 	"ropcode/internal/sessionproc"
 )
 
@@ -28,6 +30,11 @@ type SessionConfig struct {
 	// API configuration from ProviderApiConfig
 	AuthToken string `json:"auth_token,omitempty"`
 	BaseURL   string `json:"base_url,omitempty"`
+	// Env holds the project's environment variables; the API configuration
+	// above takes precedence over them
+	Env map[string]string `json:"-"`
+	// Remote runs the CLI elsewhere, e.g. inside a WSL distro
+	Remote *sessionproc.Remote `json:"-"`
 }
 
 type SessionStatus struct {
@@ -93,6 +100,34 @@ func NewSession(config SessionConfig) *Session {
 	}
 }
 
+// applyEnv adds the project's variables and the API configuration to env
+func (c SessionConfig) applyEnv(env []string) []string {
+	for name, value := range c.Env {
+		env = setEnvVar(env, name, value)
+	}
+
+	// If AuthToken is provided from database ProviderApiConfig, set it as GEMINI_API_KEY
+	// This takes priority over environment variables loaded from settings.json or system
+	// Note: Gemini CLI uses GEMINI_API_KEY (not GOOGLE_API_KEY)
+	if c.AuthToken != "" {
+		log.Printf("[Gemini Session] Using AuthToken from ProviderApiConfig")
+		env = setEnvVar(env, "GEMINI_API_KEY", c.AuthToken)
+	}
+
+	// If BaseURL is provided from database ProviderApiConfig, set GOOGLE_GEMINI_BASE_URL
+	if c.BaseURL != "" {
+		log.Printf("[Gemini Session] Using BaseURL from ProviderApiConfig: %s", c.BaseURL)
+		env = setEnvVar(env, "GOOGLE_GEMINI_BASE_URL", c.BaseURL)
+		env = setEnvVar(env, "GOOGLE_GENAI_USE_GCA", "true")
+	}
+	return env
+}
+
+// cliFeatures names the clicompat features Start relies on
+func (c SessionConfig) cliFeatures() []string {
+	return []string{"stream-json", "approval-mode"}
+}
+
 // Start starts the Gemini session
 func (s *Session) Start(ctx context.Context, binaryPath string, emitter EventEmitter, processEmitter ProcessChangedEmitter) error {
 	s.mu.Lock()
@@ -124,39 +159,31 @@ func (s *Session) Start(ctx context.Context, binaryPath string, emitter EventEmi
 
 	log.Printf("[Gemini Session] Starting Gemini with args: %v", args)
 
-	// Create command
-	s.cmd = exec.CommandContext(ctx, binaryPath, args...)
-	if err := sessionproc.Configure(s.cmd); err != nil {
-		return fmt.Errorf("failed to configure command: %w", err)
-	}
-
-	// Set working directory to project path
-	if s.Config.ProjectPath != "" {
-		s.cmd.Dir = s.Config.ProjectPath
-	}
+	if remote := s.Config.Remote; remote != nil {
+		log.Printf("[Gemini Session] Running Gemini remotely: dir=%q binary=%q", remote.Dir, remote.BinaryOr("gemini"))
+		cmd, err := remote.Command(ctx, remote.Dir, append([]string{remote.BinaryOr("gemini")}, args...), s.Config.applyEnv(nil))
+		if err != nil {
+			return fmt.Errorf("failed to prepare remote command: %w", err)
+		}
+		s.cmd = cmd
+	} else {
+		// Create command
+		s.cmd = exec.CommandContext(ctx, binaryPath, args...)
 
-	// Inherit environment variables from parent process and enhance PATH
-	// This is critical for production (.app) builds where PATH is very limited
-	// when launched via double-click (vs `open -a` from terminal)
-	enhancedEnv := enhanceEnvForProduction()
+		// Set working directory to project path
+		if s.Config.ProjectPath != "" {
+			s.cmd.Dir = s.Config.ProjectPath
+		}
 
-	// If AuthToken is provided from database ProviderApiConfig, set it as GEMINI_API_KEY
-	// This takes priority over environment variables loaded from settings.json or system
-	// Note: Gemini CLI uses GEMINI_API_KEY (not GOOGLE_API_KEY)
-	if s.Config.AuthToken != "" {
-		log.Printf("[Gemini Session] Using AuthToken from ProviderApiConfig")
-		enhancedEnv = setEnvVar(enhancedEnv, "GEMINI_API_KEY", s.Config.AuthToken)
+		// Inherit environment variables from parent process and enhance PATH
+		// This is critical for production (.app) builds where PATH is very limited
+		// when launched via double-click (vs `open -a` from terminal)
+		s.cmd.Env = s.Config.applyEnv(enhanceEnvForProduction())
 	}
-
-	// If BaseURL is provided from database ProviderApiConfig, set GOOGLE_GEMINI_BASE_URL
-	if s.Config.BaseURL != "" {
-		log.Printf("[Gemini Session] Using BaseURL from ProviderApiConfig: %s", s.Config.BaseURL)
-		enhancedEnv = setEnvVar(enhancedEnv, "GOOGLE_GEMINI_BASE_URL", s.Config.BaseURL)
-		enhancedEnv = setEnvVar(enhancedEnv, "GOOGLE_GENAI_USE_GCA", "true")
+	if err := sessionproc.Configure(s.cmd); err != nil {
+		return fmt.Errorf("failed to configure command: %w", err)
 	}
 
-	s.cmd.Env = enhancedEnv
-
 	// Setup pipes
 	var err error
 	s.stdout, err = s.cmd.StdoutPipe()
@@ -435,7 +462,7 @@ func (s *Session) transformToUnified(line string) string {
 		// If status is error and we have an error message, emit as error type
 		// so frontend can display it to user
 		if status == "error" && errorMsg != "" {
-			log.Printf("[Gemini Session] Emitting error event: %s", errorMsg)
+			slog.Error("Emitting error event", "component", "Gemini Session", "error", errorMsg)
 			unified := map[string]interface{}{
 				"cwd":      s.Config.ProjectPath,
 				"provider": "gemini",
@@ -547,7 +574,7 @@ func (s *Session) waitForCompletion(emitter EventEmitter) {
 			"provider":   "gemini",
 		}
 		errJSON, _ := json.Marshal(errMsg)
-		log.Printf("[Gemini Session] Emitting claude-error: %v", err)
+		slog.Error("Emitting claude-error", "component", "Gemini Session", "error", err)
 		emitter.Emit("claude-error", string(errJSON))
 	}
 
@@ -610,7 +637,7 @@ func (s *Session) GetPID() int {
 // Note: API keys (GOOGLE_API_KEY) are provided from ProviderApiConfig database,
 // not from settings.json or system environment.
 func enhanceEnvForProduction() []string {
-	env := os.Environ()
+	env := loginenv.Environ()
 
 	// Additional paths to add for production builds
 	// These are common locations for CLI tools on macOS
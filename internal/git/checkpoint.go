@@ -0,0 +1,323 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// CheckpointRefPrefix is where checkpoint commits are kept: out of sight of
+// branches, tags and the stash, but safe from garbage collection
+const CheckpointRefPrefix = "refs/ropcode/checkpoints/"
+
+// beforeRestoreRef keeps the work tree as it was before the last restore,
+// so a restore can itself be undone
+const beforeRestoreRef = CheckpointRefPrefix + "before-restore"
+
+// ErrNotRepository is returned for a directory outside any git work tree
+var ErrNotRepository = errors.New("not a git repository")
+
+// checkpointIdentity signs checkpoint commits, which are never pushed, so
+// they work without a configured git identity
+var checkpointIdentity = []string{
+	"GIT_AUTHOR_NAME=" + fallbackCommitName,
+	"GIT_AUTHOR_EMAIL=" + fallbackCommitEmail,
+	"GIT_COMMITTER_NAME=" + fallbackCommitName,
+	"GIT_COMMITTER_EMAIL=" + fallbackCommitEmail,
+}
+
+// Checkpoint is a snapshot of the work tree of a repository
+type Checkpoint struct {
+	// Commit holds the snapshot; its tree has every file that isn't ignored
+	Commit string
+	// Ref points at Commit
+	Ref string
+}
+
+// RestoreResult lists what a restore changed, relative to the top of the
+// work tree
+type RestoreResult struct {
+	// Restored files got their checkpoint content back, deleted ones included
+	Restored []string `json:"restored"`
+	// Removed files were created after the checkpoint
+	Removed []string `json:"removed"`
+}
+
+// CreateCheckpoint snapshots the work tree containing dir, staged,
+// unstaged and untracked changes alike, into a commit kept at
+// CheckpointRefPrefix+name. The index, the stash and HEAD are left alone,
+// so it makes no difference whether the work tree is clean.
+func CreateCheckpoint(dir, name, message string) (*Checkpoint, error) {
+	top, err := workTreeTop(dir)
+	if err != nil {
+		return nil, err
+	}
+	tree, err := snapshotTree(top)
+	if err != nil {
+		return nil, err
+	}
+	commit, err := commitTree(top, tree, message)
+	if err != nil {
+		return nil, err
+	}
+	ref := CheckpointRefPrefix + name
+	if _, err := runGit(top, "update-ref", ref, commit); err != nil {
+		return nil, err
+	}
+	return &Checkpoint{Commit: commit, Ref: ref}, nil
+}
+
+// RestoreCheckpoint puts the work tree containing dir back to the snapshot
+// in commit: changed and deleted files get their content back and files
+// created since are removed. Ignored files are not touched, and neither are
+// the index and HEAD. The work tree as it was before is kept at a ref of
+// its own.
+func RestoreCheckpoint(dir, commit string) (*RestoreResult, error) {
+	top, err := workTreeTop(dir)
+	if err != nil {
+		return nil, err
+	}
+	target, err := runGit(top, "rev-parse", "--verify", commit+"^{tree}")
+	if err != nil {
+		return nil, err
+	}
+	current, err := snapshotTree(top)
+	if err != nil {
+		return nil, err
+	}
+	if backup, err := commitTree(top, current, "Before restoring checkpoint"); err == nil {
+		runGit(top, "update-ref", beforeRestoreRef, backup)
+	}
+
+	out, err := runGit(top, "diff-tree", "-r", "-z", "--name-status", "--no-renames", target, current)
+	if err != nil {
+		return nil, err
+	}
+	result := &RestoreResult{Restored: []string{}, Removed: []string{}}
+	fields := strings.Split(strings.Trim(out, "\x00"), "\x00")
+	for i := 0; i+1 < len(fields); i += 2 {
+		status, path := fields[i], fields[i+1]
+		if status == "A" {
+			result.Removed = append(result.Removed, path)
+		} else {
+			result.Restored = append(result.Restored, path)
+		}
+	}
+
+	if err := restorePaths(top, target, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// CheckpointHunk is one hunk of the changes made to a file since a
+// checkpoint
+type CheckpointHunk struct {
+	// Header is the hunk's @@ line
+	Header string `json:"header"`
+	// Lines are the hunk's lines, each starting with ' ', '+' or '-'
+	Lines []string `json:"lines"`
+}
+
+// CheckpointFileHunks returns the hunks changing file, a path in the work
+// tree containing dir, from its content in commit to its content now.
+// They are empty when the file is unchanged.
+func CheckpointFileHunks(dir, commit, file string) ([]CheckpointHunk, error) {
+	top, rel, err := checkpointPath(dir, file)
+	if err != nil {
+		return nil, err
+	}
+	_, hunks, err := checkpointFileDiff(top, commit, rel)
+	return hunks, err
+}
+
+// RevertCheckpointFile puts file back to its content in commit, removing
+// it when it didn't exist then
+func RevertCheckpointFile(dir, commit, file string) error {
+	top, rel, err := checkpointPath(dir, file)
+	if err != nil {
+		return err
+	}
+	target, err := runGit(top, "rev-parse", "--verify", commit+"^{tree}")
+	if err != nil {
+		return err
+	}
+	result := &RestoreResult{}
+	if _, err := runGit(top, "cat-file", "-e", target+":"+rel); err == nil {
+		result.Restored = []string{rel}
+	} else {
+		result.Removed = []string{rel}
+	}
+	return restorePaths(top, target, result)
+}
+
+// RevertCheckpointHunk undoes the hunk at index of the changes to file
+// since commit, leaving its other changes in place
+func RevertCheckpointHunk(dir, commit, file string, index int) error {
+	top, rel, err := checkpointPath(dir, file)
+	if err != nil {
+		return err
+	}
+	header, hunks, err := checkpointFileDiff(top, commit, rel)
+	if err != nil {
+		return err
+	}
+	if index < 0 || index >= len(hunks) {
+		return fmt.Errorf("%s has no hunk %d", rel, index)
+	}
+	hunk := hunks[index]
+	patch := header + hunk.Header + "\n" + strings.Join(hunk.Lines, "\n") + "\n"
+	_, err = runGitEnv(top, nil, strings.NewReader(patch), "apply", "-R", "--whitespace=nowarn", "-")
+	return err
+}
+
+// checkpointPath returns the top of the work tree containing dir and
+// file's path in it
+func checkpointPath(dir, file string) (string, string, error) {
+	top, err := workTreeTop(dir)
+	if err != nil {
+		return "", "", err
+	}
+	if !filepath.IsAbs(file) {
+		file = filepath.Join(top, file)
+	}
+	rel, err := filepath.Rel(top, file)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return "", "", fmt.Errorf("%s is outside the repository", file)
+	}
+	return top, filepath.ToSlash(rel), nil
+}
+
+// checkpointFileDiff diffs rel from commit to the work tree, returning the
+// patch header and the hunks
+func checkpointFileDiff(top, commit, rel string) (string, []CheckpointHunk, error) {
+	target, err := runGit(top, "rev-parse", "--verify", commit+"^{tree}")
+	if err != nil {
+		return "", nil, err
+	}
+	current, err := snapshotTree(top)
+	if err != nil {
+		return "", nil, err
+	}
+	out, err := runGitRaw(top, nil, nil, "diff-tree", "-p", "--no-color", "--no-renames", "--no-ext-diff", target, current, "--", rel)
+	if err != nil {
+		return "", nil, err
+	}
+	var header strings.Builder
+	hunks := []CheckpointHunk{}
+	if out == "" {
+		return "", hunks, nil
+	}
+	for _, line := range strings.Split(strings.TrimSuffix(out, "\n"), "\n") {
+		switch {
+		case strings.HasPrefix(line, "@@"):
+			hunks = append(hunks, CheckpointHunk{Header: line, Lines: []string{}})
+		case len(hunks) > 0:
+			h := &hunks[len(hunks)-1]
+			h.Lines = append(h.Lines, line)
+		case strings.HasPrefix(line, "Binary files"):
+			return "", nil, fmt.Errorf("%s is a binary file", rel)
+		case line != "":
+			header.WriteString(line + "\n")
+		}
+	}
+	return header.String(), hunks, nil
+}
+
+// restorePaths checks result.Restored out of the tree target and removes
+// result.Removed, relative to top
+func restorePaths(top, target string, result *RestoreResult) error {
+	for _, path := range result.Removed {
+		full := filepath.Join(top, filepath.FromSlash(path))
+		if err := os.Remove(full); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		removeEmptyParents(top, filepath.Dir(full))
+	}
+	if len(result.Restored) == 0 {
+		return nil
+	}
+	// Check the files out of a throwaway index so the real one keeps what
+	// the user staged
+	return withTempIndex(func(env []string) error {
+		if _, err := runGitEnv(top, env, nil, "read-tree", target); err != nil {
+			return err
+		}
+		paths := strings.Join(result.Restored, "\x00") + "\x00"
+		_, err := runGitEnv(top, env, strings.NewReader(paths), "checkout-index", "-f", "-z", "--stdin")
+		return err
+	})
+}
+
+// DeleteCheckpoint drops the ref of a checkpoint, letting git collect it
+func DeleteCheckpoint(dir, ref string) error {
+	if !strings.HasPrefix(ref, CheckpointRefPrefix) {
+		return errors.New("not a checkpoint ref: " + ref)
+	}
+	top, err := workTreeTop(dir)
+	if err != nil {
+		return err
+	}
+	_, err = runGit(top, "update-ref", "-d", ref)
+	return err
+}
+
+func workTreeTop(dir string) (string, error) {
+	top, err := runGit(dir, "rev-parse", "--show-toplevel")
+	if err != nil || top == "" {
+		return "", ErrNotRepository
+	}
+	return hostPath(top), nil
+}
+
+// snapshotTree writes a tree of the work tree's files that aren't ignored,
+// through a throwaway index. Tracked files stay in it even when ignored.
+func snapshotTree(top string) (string, error) {
+	var tree string
+	err := withTempIndex(func(env []string) error {
+		if _, err := runGit(top, "rev-parse", "--verify", "--quiet", "HEAD"); err == nil {
+			if _, err := runGitEnv(top, env, nil, "read-tree", "HEAD"); err != nil {
+				return err
+			}
+		}
+		if _, err := runGitEnv(top, env, nil, "add", "-A"); err != nil {
+			return err
+		}
+		var err error
+		tree, err = runGitEnv(top, env, nil, "write-tree")
+		return err
+	})
+	return tree, err
+}
+
+// commitTree commits tree with HEAD, when there is one, as its parent
+func commitTree(top, tree, message string) (string, error) {
+	args := []string{"commit-tree", tree, "-m", message}
+	if head, err := runGit(top, "rev-parse", "--verify", "--quiet", "HEAD"); err == nil && head != "" {
+		args = append(args, "-p", head)
+	}
+	return runGitEnv(top, checkpointIdentity, nil, args...)
+}
+
+// withTempIndex runs fn with GIT_INDEX_FILE set to a new index file
+func withTempIndex(fn func(env []string) error) error {
+	dir, err := os.MkdirTemp("", "ropcode-checkpoint-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	return fn([]string{"GIT_INDEX_FILE=" + filepath.Join(dir, "index")})
+}
+
+// removeEmptyParents removes dir and its parents up to top while they are
+// empty
+func removeEmptyParents(top, dir string) {
+	for dir != top && strings.HasPrefix(dir, top) {
+		if os.Remove(dir) != nil {
+			return
+		}
+		dir = filepath.Dir(dir)
+	}
+}
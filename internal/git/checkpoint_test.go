@@ -0,0 +1,191 @@
+package git
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func runTestGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	out, err := runGitEnv(dir, checkpointIdentity, nil, args...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func writeCheckpointFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readCheckpointFile(t *testing.T, dir, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestCheckpointRestoresDirtyWorkTree(t *testing.T) {
+	dir := t.TempDir()
+	if out, err := exec.Command("git", "init", dir).CombinedOutput(); err != nil {
+		t.Fatalf("git init: %v: %s", err, out)
+	}
+	writeCheckpointFile(t, dir, ".gitignore", "build/\n")
+	writeCheckpointFile(t, dir, "main.go", "v1")
+	writeCheckpointFile(t, dir, "gone.go", "keep me")
+	runTestGit(t, dir, "add", "-A")
+	runTestGit(t, dir, "commit", "-m", "initial")
+
+	// Uncommitted work from before the session: a staged edit and an
+	// untracked file
+	writeCheckpointFile(t, dir, "main.go", "v2 staged")
+	runTestGit(t, dir, "add", "main.go")
+	writeCheckpointFile(t, dir, "notes.txt", "mine")
+
+	checkpoint, err := CreateCheckpoint(dir, "s1", "Before session s1")
+	if err != nil {
+		t.Fatalf("CreateCheckpoint: %v", err)
+	}
+	if got := runTestGit(t, dir, "rev-parse", checkpoint.Ref); got != checkpoint.Commit {
+		t.Fatalf("ref %s = %s, want %s", checkpoint.Ref, got, checkpoint.Commit)
+	}
+	if status := runTestGit(t, dir, "status", "--porcelain"); status != "M  main.go\n?? notes.txt" {
+		t.Fatalf("checkpoint changed the status:\n%s", status)
+	}
+
+	// The agent edits, deletes and creates files
+	writeCheckpointFile(t, dir, "main.go", "agent")
+	writeCheckpointFile(t, dir, "notes.txt", "agent")
+	os.Remove(filepath.Join(dir, "gone.go"))
+	writeCheckpointFile(t, dir, "pkg/new/new.go", "agent")
+	writeCheckpointFile(t, dir, "build/out.bin", "ignored")
+
+	result, err := RestoreCheckpoint(filepath.Join(dir, "pkg"), checkpoint.Commit)
+	if err != nil {
+		t.Fatalf("RestoreCheckpoint: %v", err)
+	}
+	if !reflect.DeepEqual(result.Restored, []string{"gone.go", "main.go", "notes.txt"}) ||
+		!reflect.DeepEqual(result.Removed, []string{"pkg/new/new.go"}) {
+		t.Fatalf("result = %+v", result)
+	}
+	for name, want := range map[string]string{
+		"main.go":       "v2 staged",
+		"notes.txt":     "mine",
+		"gone.go":       "keep me",
+		"build/out.bin": "ignored",
+	} {
+		if got := readCheckpointFile(t, dir, name); got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "pkg")); !os.IsNotExist(err) {
+		t.Errorf("empty directories of removed files left behind: %v", err)
+	}
+	if status := runTestGit(t, dir, "status", "--porcelain"); status != "M  main.go\n?? notes.txt" {
+		t.Fatalf("status after restore:\n%s", status)
+	}
+	if _, err := runGit(dir, "rev-parse", "--verify", beforeRestoreRef); err != nil {
+		t.Errorf("no backup of the work tree before the restore: %v", err)
+	}
+
+	if err := DeleteCheckpoint(dir, checkpoint.Ref); err != nil {
+		t.Fatalf("DeleteCheckpoint: %v", err)
+	}
+	if _, err := runGit(dir, "rev-parse", "--verify", checkpoint.Ref); err == nil {
+		t.Error("checkpoint ref still exists")
+	}
+}
+
+func TestCheckpointWithoutCommitsOrRepository(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := CreateCheckpoint(dir, "s1", "x"); err != ErrNotRepository {
+		t.Fatalf("outside a repository: err = %v", err)
+	}
+
+	if out, err := exec.Command("git", "init", dir).CombinedOutput(); err != nil {
+		t.Fatalf("git init: %v: %s", err, out)
+	}
+	writeCheckpointFile(t, dir, "a.txt", "a")
+	checkpoint, err := CreateCheckpoint(dir, "s1", "Before session s1")
+	if err != nil {
+		t.Fatalf("CreateCheckpoint without HEAD: %v", err)
+	}
+	writeCheckpointFile(t, dir, "a.txt", "changed")
+	if _, err := RestoreCheckpoint(dir, checkpoint.Commit); err != nil {
+		t.Fatal(err)
+	}
+	if got := readCheckpointFile(t, dir, "a.txt"); got != "a" {
+		t.Fatalf("a.txt = %q", got)
+	}
+}
+
+func TestCheckpointRevertsSingleFilesAndHunks(t *testing.T) {
+	dir := t.TempDir()
+	if out, err := exec.Command("git", "init", dir).CombinedOutput(); err != nil {
+		t.Fatalf("git init: %v: %s", err, out)
+	}
+	lines := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n"
+	writeCheckpointFile(t, dir, "a.txt", lines)
+	writeCheckpointFile(t, dir, "b.txt", "b\n")
+	runTestGit(t, dir, "add", "-A")
+	runTestGit(t, dir, "commit", "-m", "initial")
+
+	checkpoint, err := CreateCheckpoint(dir, "s1", "Before session s1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeCheckpointFile(t, dir, "a.txt", "one\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\ntwelve\n")
+	writeCheckpointFile(t, dir, "b.txt", "agent\n")
+	writeCheckpointFile(t, dir, "c.txt", "new\n")
+
+	hunks, err := CheckpointFileHunks(dir, checkpoint.Commit, filepath.Join(dir, "a.txt"))
+	if err != nil {
+		t.Fatalf("CheckpointFileHunks: %v", err)
+	}
+	if len(hunks) != 2 || !strings.HasPrefix(hunks[1].Header, "@@") {
+		t.Fatalf("hunks = %+v", hunks)
+	}
+
+	// Rejecting the second hunk keeps the first
+	if err := RevertCheckpointHunk(dir, checkpoint.Commit, "a.txt", 1); err != nil {
+		t.Fatalf("RevertCheckpointHunk: %v", err)
+	}
+	if got := readCheckpointFile(t, dir, "a.txt"); got != "one\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n" {
+		t.Fatalf("a.txt = %q", got)
+	}
+	if err := RevertCheckpointHunk(dir, checkpoint.Commit, "a.txt", 1); err == nil {
+		t.Fatal("reverted a hunk that is gone")
+	}
+
+	if err := RevertCheckpointFile(dir, checkpoint.Commit, filepath.Join(dir, "b.txt")); err != nil {
+		t.Fatalf("RevertCheckpointFile: %v", err)
+	}
+	if got := readCheckpointFile(t, dir, "b.txt"); got != "b\n" {
+		t.Fatalf("b.txt = %q", got)
+	}
+	if err := RevertCheckpointFile(dir, checkpoint.Commit, "c.txt"); err != nil {
+		t.Fatalf("RevertCheckpointFile for a new file: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "c.txt")); !os.IsNotExist(err) {
+		t.Fatalf("c.txt not removed: %v", err)
+	}
+	if hunks, err := CheckpointFileHunks(dir, checkpoint.Commit, "b.txt"); err != nil || len(hunks) != 0 {
+		t.Fatalf("hunks of a reverted file = %+v, %v", hunks, err)
+	}
+	if _, err := CheckpointFileHunks(dir, checkpoint.Commit, "/elsewhere/x.txt"); err == nil {
+		t.Fatal("diffed a file outside the repository")
+	}
+}
@@ -0,0 +1,65 @@
+package git
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+
+	"ropcode/internal/wsl"
+)
+
+var (
+	wslMu     sync.RWMutex
+	wslDistro string
+)
+
+// UseWSL makes git commands run inside distro, where the repositories of
+// WSL users live with their git config and credentials; empty runs the
+// host's git
+func UseWSL(distro string) {
+	wslMu.Lock()
+	defer wslMu.Unlock()
+	wslDistro = distro
+}
+
+// WSLDistro returns the distro git commands run in, if any
+func WSLDistro() string {
+	wslMu.RLock()
+	defer wslMu.RUnlock()
+	return wslDistro
+}
+
+// Command returns a command running git with args, inside the distro set
+// with UseWSL when there is one
+func Command(args ...string) *exec.Cmd {
+	return CommandContext(context.Background(), args...)
+}
+
+// CommandContext is Command with a command killed when ctx is done
+func CommandContext(ctx context.Context, args ...string) *exec.Cmd {
+	if distro := WSLDistro(); distro != "" {
+		return wsl.ExecContext(ctx, distro, "git", args...)
+	}
+	return exec.CommandContext(ctx, "git", args...)
+}
+
+// CommandEnv returns the environment of a git command that adds env to
+// the process environment, shared into the WSL distro when git runs there
+func CommandEnv(env []string) []string {
+	full := append(os.Environ(), env...)
+	if WSLDistro() != "" {
+		return wsl.ShareEnv(full, env)
+	}
+	return full
+}
+
+// hostPath returns a path git printed in the form the host uses: paths
+// inside the WSL distro become Windows paths
+func hostPath(path string) string {
+	if distro := WSLDistro(); distro != "" && strings.HasPrefix(path, "/") {
+		return wsl.ToWindows(distro, path)
+	}
+	return path
+}
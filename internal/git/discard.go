@@ -0,0 +1,44 @@
+package git
+
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// DiscardedPaths returns what cleaning up the work tree containing dir
+// throws away, as absolute paths: the files a hard reset to HEAD overwrites
+// or removes, staged and unstaged changes alike, and the untracked files
+// and directories git clean -fd removes when run in dir
+func DiscardedPaths(dir string) (overwritten, untracked []string, err error) {
+	top, err := workTreeTop(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	// Files deleted from the work tree have nothing left to keep
+	out, err := runGitRaw(top, nil, nil, "diff", "-z", "--name-only", "--no-renames", "--diff-filter=d", "HEAD")
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, path := range strings.Split(strings.Trim(out, "\x00"), "\x00") {
+		if path != "" {
+			overwritten = append(overwritten, filepath.Join(top, filepath.FromSlash(path)))
+		}
+	}
+
+	out, err = runGit(dir, "-c", "core.quotePath=false", "clean", "-n", "-d")
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, line := range strings.Split(out, "\n") {
+		path, ok := strings.CutPrefix(line, "Would remove ")
+		if !ok {
+			continue
+		}
+		if unquoted, err := strconv.Unquote(path); err == nil {
+			path = unquoted
+		}
+		untracked = append(untracked, filepath.Join(dir, filepath.FromSlash(strings.TrimSuffix(path, "/"))))
+	}
+	return overwritten, untracked, nil
+}
@@ -0,0 +1,40 @@
+package git
+
+import (
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestDiscardedPaths(t *testing.T) {
+	dir := t.TempDir()
+	if out, err := exec.Command("git", "init", dir).CombinedOutput(); err != nil {
+		t.Fatalf("git init: %v: %s", err, out)
+	}
+	dir, _ = filepath.EvalSymlinks(dir)
+	writeCheckpointFile(t, dir, ".gitignore", "build/\n")
+	writeCheckpointFile(t, dir, "main.go", "v1")
+	writeCheckpointFile(t, dir, "gone.go", "v1")
+	runTestGit(t, dir, "add", "-A")
+	runTestGit(t, dir, "commit", "-m", "initial")
+
+	writeCheckpointFile(t, dir, "main.go", "v2")
+	writeCheckpointFile(t, dir, "staged.go", "new")
+	runTestGit(t, dir, "add", "staged.go")
+	runTestGit(t, dir, "rm", "-q", "gone.go")
+	writeCheckpointFile(t, dir, "notes one.txt", "mine")
+	writeCheckpointFile(t, dir, "scratch/a.txt", "mine")
+	writeCheckpointFile(t, dir, "build/out.bin", "ignored")
+
+	overwritten, untracked, err := DiscardedPaths(dir)
+	if err != nil {
+		t.Fatalf("DiscardedPaths: %v", err)
+	}
+	if want := []string{filepath.Join(dir, "main.go"), filepath.Join(dir, "staged.go")}; !reflect.DeepEqual(overwritten, want) {
+		t.Errorf("overwritten = %v, want %v", overwritten, want)
+	}
+	if want := []string{filepath.Join(dir, "notes one.txt"), filepath.Join(dir, "scratch")}; !reflect.DeepEqual(untracked, want) {
+		t.Errorf("untracked = %v, want %v", untracked, want)
+	}
+}
@@ -3,7 +3,6 @@ package git
 import (
 	"bytes"
 	"fmt"
-	"os/exec"
 	"strings"
 
 	"github.com/go-git/go-git/v5"
@@ -117,7 +116,7 @@ func mapStatusCode(code git.StatusCode) string {
 // CurrentBranch returns the name of the current branch
 // Uses git command instead of go-git because go-git doesn't handle worktrees correctly
 func (r *Repo) CurrentBranch() (string, error) {
-	cmd := exec.Command("git", "rev-parse", "--abbrev-ref", "HEAD")
+	cmd := Command("rev-parse", "--abbrev-ref", "HEAD")
 	cmd.Dir = r.path
 
 	output, err := cmd.Output()
@@ -135,7 +134,7 @@ func (r *Repo) CurrentBranch() (string, error) {
 
 // RunGitCommand executes a git command and returns the output
 func (r *Repo) RunGitCommand(args ...string) (string, error) {
-	cmd := exec.Command("git", args...)
+	cmd := Command(args...)
 	cmd.Dir = r.path
 
 	var stdout, stderr bytes.Buffer
@@ -0,0 +1,210 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"ropcode/internal/watcher"
+)
+
+// StatusWatcher 监听工作区的 HEAD、index 和 refs，变化（提交、切换分支、
+// fetch、push）后防抖回调一次，由调用方重新计算状态
+type StatusWatcher struct {
+	debounce time.Duration
+	onChange func(workspacePath string)
+	repos    map[string]*statusWatch
+	mu       sync.Mutex
+}
+
+type statusWatch struct {
+	watcher *watcher.Watcher
+	refs    int
+	timer   *time.Timer
+	closed  bool
+}
+
+// NewStatusWatcher 创建 StatusWatcher，onChange 在最后一次变化 debounce 之后调用
+func NewStatusWatcher(debounce time.Duration, onChange func(workspacePath string)) *StatusWatcher {
+	return &StatusWatcher{
+		debounce: debounce,
+		onChange: onChange,
+		repos:    make(map[string]*statusWatch),
+	}
+}
+
+// Watch 开始监听工作区，已在监听时增加引用计数。开始监听时立即回调一次
+func (s *StatusWatcher) Watch(workspacePath string) error {
+	workspacePath = filepath.Clean(workspacePath)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r, exists := s.repos[workspacePath]; exists {
+		r.refs++
+		return nil
+	}
+
+	gitDir, commonDir, err := resolveGitDirs(workspacePath)
+	if err != nil {
+		return err
+	}
+
+	r := &statusWatch{refs: 1}
+	// HEAD 和 index 在工作区自己的 git 目录，refs 和 packed-refs 在共享目录（worktree 时不同）
+	w, err := watcher.New(gitDir, 50*time.Millisecond, func(e watcher.Event) {
+		if strings.HasSuffix(e.Path, ".lock") {
+			return
+		}
+		if e.Type == watcher.EventCreate {
+			if info, err := os.Stat(e.Path); err == nil && info.IsDir() && isRefsDir(commonDir, e.Path) {
+				addDirs(r.watcher, e.Path) // 新建的分支命名空间，如 refs/heads/feature
+			}
+		}
+		s.Invalidate(workspacePath)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to watch git dir: %w", err)
+	}
+	r.watcher = w
+
+	if commonDir != gitDir {
+		_ = w.AddPath(commonDir)
+	}
+	// refs 本身也要监听，refs/remotes 在第一次 fetch 时才创建
+	_ = w.AddPath(filepath.Join(commonDir, "refs"))
+	for _, sub := range []string{"refs/heads", "refs/remotes"} {
+		addDirs(w, filepath.Join(commonDir, sub))
+	}
+
+	if err := w.Start(); err != nil {
+		w.Close()
+		return fmt.Errorf("failed to start watcher: %w", err)
+	}
+	s.repos[workspacePath] = r
+
+	go s.onChange(workspacePath)
+	return nil
+}
+
+// Unwatch 减少引用计数，归零时停止监听
+func (s *StatusWatcher) Unwatch(workspacePath string) {
+	workspacePath = filepath.Clean(workspacePath)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, exists := s.repos[workspacePath]
+	if !exists {
+		return
+	}
+	r.refs--
+	if r.refs > 0 {
+		return
+	}
+	r.close()
+	delete(s.repos, workspacePath)
+}
+
+// Watching 返回工作区是否在监听中
+func (s *StatusWatcher) Watching(workspacePath string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, exists := s.repos[filepath.Clean(workspacePath)]
+	return exists
+}
+
+// Invalidate 安排一次（防抖的）回调，用于 git 目录之外的变化，例如工作区文件被编辑。
+// 工作区不在监听中时不做任何事
+func (s *StatusWatcher) Invalidate(workspacePath string) {
+	workspacePath = filepath.Clean(workspacePath)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, exists := s.repos[workspacePath]
+	if !exists || r.closed {
+		return
+	}
+	if r.timer != nil {
+		r.timer.Reset(s.debounce)
+		return
+	}
+	r.timer = time.AfterFunc(s.debounce, func() {
+		s.mu.Lock()
+		r.timer = nil
+		closed := r.closed
+		s.mu.Unlock()
+		if !closed {
+			s.onChange(workspacePath)
+		}
+	})
+}
+
+// Close 关闭所有监听
+func (s *StatusWatcher) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, r := range s.repos {
+		r.close()
+	}
+	s.repos = make(map[string]*statusWatch)
+}
+
+func (r *statusWatch) close() {
+	r.closed = true
+	if r.timer != nil {
+		r.timer.Stop()
+		r.timer = nil
+	}
+	r.watcher.Close()
+}
+
+// resolveGitDirs 返回工作区的 git 目录和共享的 git 目录，worktree 时两者不同
+func resolveGitDirs(workspacePath string) (gitDir, commonDir string, err error) {
+	cmd := Command("rev-parse", "--absolute-git-dir", "--git-common-dir")
+	cmd.Dir = workspacePath
+	output, err := cmd.Output()
+	if err != nil {
+		return "", "", fmt.Errorf("not a git repository: %s", workspacePath)
+	}
+	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	if len(lines) != 2 {
+		return "", "", fmt.Errorf("unexpected git rev-parse output: %q", output)
+	}
+	gitDir = hostPath(strings.TrimSpace(lines[0]))
+	commonDir = hostPath(strings.TrimSpace(lines[1]))
+	if !filepath.IsAbs(commonDir) {
+		commonDir = filepath.Join(workspacePath, commonDir)
+	}
+	return filepath.Clean(gitDir), filepath.Clean(commonDir), nil
+}
+
+// addDirs 监听 dir 及其下所有子目录，忽略不存在的目录
+func addDirs(w *watcher.Watcher, dir string) {
+	filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err == nil && d.IsDir() {
+			_ = w.AddPath(path)
+		}
+		return nil
+	})
+}
+
+// isRefsDir 判断 path 是否是 refs/heads、refs/remotes 或其下的目录
+func isRefsDir(commonDir, path string) bool {
+	rel, err := filepath.Rel(commonDir, path)
+	if err != nil {
+		return false
+	}
+	rel = filepath.ToSlash(rel)
+	for _, prefix := range []string{"refs/heads", "refs/remotes"} {
+		if rel == prefix || strings.HasPrefix(rel, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
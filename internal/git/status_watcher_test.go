@@ -0,0 +1,127 @@
+package git
+
+import (
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+type statusCalls struct {
+	mu    sync.Mutex
+	count int
+}
+
+func (c *statusCalls) onChange(string) {
+	c.mu.Lock()
+	c.count++
+	c.mu.Unlock()
+}
+
+func (c *statusCalls) get() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.count
+}
+
+// waitForMore waits until more than after callbacks have happened
+func (c *statusCalls) waitForMore(t *testing.T, after int) int {
+	t.Helper()
+	deadline := time.Now().Add(3 * time.Second)
+	for time.Now().Before(deadline) {
+		if n := c.get(); n > after {
+			return n
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatalf("no status callback after %d", after)
+	return 0
+}
+
+func TestStatusWatcherCommitAndWorktree(t *testing.T) {
+	repoPath, cleanup := setupTestRepo(t)
+	defer cleanup()
+	commitFile(t, repoPath, "README.md", "hello")
+
+	calls := &statusCalls{}
+	s := NewStatusWatcher(50*time.Millisecond, calls.onChange)
+	defer s.Close()
+
+	if err := s.Watch(repoPath); err != nil {
+		t.Fatalf("Watch() error = %v", err)
+	}
+	// The current state is reported right away
+	calls.waitForMore(t, 0)
+	time.Sleep(200 * time.Millisecond)
+	n := calls.get()
+
+	// A commit changes the index and refs/heads
+	commitFile(t, repoPath, "main.go", "package main")
+	calls.waitForMore(t, n)
+
+	// A linked worktree shares refs with the main repository
+	worktreePath := filepath.Join(t.TempDir(), "feature")
+	cmd := exec.Command("git", "worktree", "add", "-b", "feature", worktreePath)
+	cmd.Dir = repoPath
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git worktree add: %v: %s", err, out)
+	}
+	wtCalls := &statusCalls{}
+	wt := NewStatusWatcher(50*time.Millisecond, wtCalls.onChange)
+	defer wt.Close()
+	if err := wt.Watch(worktreePath); err != nil {
+		t.Fatalf("Watch(worktree) error = %v", err)
+	}
+	wtCalls.waitForMore(t, 0)
+	time.Sleep(200 * time.Millisecond)
+	m := wtCalls.get()
+
+	commitFile(t, worktreePath, "feature.go", "package main")
+	wtCalls.waitForMore(t, m)
+}
+
+func TestStatusWatcherInvalidateAndUnwatch(t *testing.T) {
+	repoPath, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	calls := &statusCalls{}
+	s := NewStatusWatcher(50*time.Millisecond, calls.onChange)
+	defer s.Close()
+
+	// Not watched: nothing happens
+	s.Invalidate(repoPath)
+
+	if err := s.Watch(repoPath); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Watch(repoPath + "/"); err != nil {
+		t.Fatal(err)
+	}
+	calls.waitForMore(t, 0)
+	time.Sleep(100 * time.Millisecond)
+	n := calls.get()
+
+	// Several invalidations within the debounce coalesce into one callback
+	for i := 0; i < 5; i++ {
+		s.Invalidate(repoPath)
+	}
+	n = calls.waitForMore(t, n)
+	time.Sleep(150 * time.Millisecond)
+	if got := calls.get(); got != n {
+		t.Errorf("callbacks = %d, want %d", got, n)
+	}
+
+	s.Unwatch(repoPath)
+	if !s.Watching(repoPath) {
+		t.Fatal("Unwatch() dropped a watch that still has a reference")
+	}
+	s.Unwatch(repoPath)
+	if s.Watching(repoPath) {
+		t.Fatal("Unwatch() left the last reference watching")
+	}
+
+	if err := s.Watch(t.TempDir()); err == nil {
+		t.Fatal("Watch() should fail outside a git repository")
+	}
+}
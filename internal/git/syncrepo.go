@@ -0,0 +1,206 @@
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// MergeStrategy selects how conflicting changes are resolved when merging
+type MergeStrategy string
+
+const (
+	// MergeReportConflicts aborts a conflicting merge and reports the files
+	MergeReportConflicts MergeStrategy = ""
+	// MergePreferLocal resolves conflicting hunks with the local version
+	MergePreferLocal MergeStrategy = "local"
+	// MergePreferRemote resolves conflicting hunks with the remote version
+	MergePreferRemote MergeStrategy = "remote"
+)
+
+// fallbackCommitName and fallbackCommitEmail are used when the user has no
+// git identity configured.
+const (
+	fallbackCommitName  = "Ropcode"
+	fallbackCommitEmail = "ropcode@localhost"
+)
+
+// SyncRepo is a local clone used to exchange files with a remote repository
+// on a single branch.
+type SyncRepo struct {
+	Dir       string
+	RemoteURL string
+	Branch    string
+}
+
+// NewSyncRepo creates a sync repo for remoteURL cloned into dir
+func NewSyncRepo(dir, remoteURL, branch string) *SyncRepo {
+	if branch == "" {
+		branch = "main"
+	}
+	return &SyncRepo{Dir: dir, RemoteURL: remoteURL, Branch: branch}
+}
+
+func (s *SyncRepo) run(args ...string) (string, error) {
+	return runGit(s.Dir, args...)
+}
+
+func runGit(dir string, args ...string) (string, error) {
+	return runGitEnv(dir, nil, nil, args...)
+}
+
+// runGitEnv runs git with extra environment variables and, when stdin is
+// not nil, input
+func runGitEnv(dir string, env []string, stdin io.Reader, args ...string) (string, error) {
+	out, err := runGitRaw(dir, env, stdin, args...)
+	return strings.TrimSpace(out), err
+}
+
+// runGitRaw is runGitEnv without trimming the output, for output whose
+// whitespace matters such as patches
+func runGitRaw(dir string, env []string, stdin io.Reader, args ...string) (string, error) {
+	cmd := Command(args...)
+	cmd.Dir = dir
+	if env != nil {
+		cmd.Env = CommandEnv(env)
+	}
+	cmd.Stdin = stdin
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("git %s failed: %w, stderr: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
+
+func (s *SyncRepo) refExists(ref string) bool {
+	_, err := s.run("rev-parse", "--verify", "--quiet", ref)
+	return err == nil
+}
+
+// Ensure clones the remote when the local clone does not exist yet, points
+// origin at RemoteURL, fetches and checks out Branch. It reports whether the
+// clone was created by this call.
+func (s *SyncRepo) Ensure() (bool, error) {
+	if s.RemoteURL == "" {
+		return false, fmt.Errorf("remote URL is required")
+	}
+
+	cloned := false
+	if _, err := os.Stat(filepath.Join(s.Dir, ".git")); os.IsNotExist(err) {
+		parent := filepath.Dir(s.Dir)
+		if err := os.MkdirAll(parent, 0755); err != nil {
+			return false, fmt.Errorf("failed to create sync directory: %w", err)
+		}
+		if _, err := runGit(parent, "clone", s.RemoteURL, s.Dir); err != nil {
+			return false, err
+		}
+		cloned = true
+	} else if _, err := s.run("remote", "set-url", "origin", s.RemoteURL); err != nil {
+		return false, err
+	}
+
+	if _, err := s.run("fetch", "origin"); err != nil {
+		return cloned, err
+	}
+
+	remoteBranch := "origin/" + s.Branch
+	var err error
+	switch {
+	case s.refExists("refs/heads/" + s.Branch):
+		_, err = s.run("checkout", s.Branch)
+	case s.refExists(remoteBranch):
+		_, err = s.run("checkout", "-B", s.Branch, remoteBranch)
+	default:
+		// Empty remote or new branch: start it from scratch.
+		_, err = s.run("checkout", "--orphan", s.Branch)
+	}
+	return cloned, err
+}
+
+// CommitAll stages every change in the clone and commits it. It reports
+// whether a commit was created.
+func (s *SyncRepo) CommitAll(message string) (bool, error) {
+	if _, err := s.run("add", "-A"); err != nil {
+		return false, err
+	}
+	status, err := s.run("status", "--porcelain")
+	if err != nil {
+		return false, err
+	}
+	if status == "" {
+		return false, nil
+	}
+
+	args := []string{}
+	if email, _ := s.run("config", "user.email"); email == "" {
+		args = append(args, "-c", "user.name="+fallbackCommitName, "-c", "user.email="+fallbackCommitEmail)
+	}
+	args = append(args, "commit", "-m", message)
+	if _, err := s.run(args...); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Merge merges the fetched remote branch into the local branch. With
+// MergeReportConflicts a conflicting merge is aborted and the conflicting
+// paths are returned; the other strategies resolve conflicting hunks in
+// favour of one side.
+func (s *SyncRepo) Merge(strategy MergeStrategy) ([]string, error) {
+	remoteBranch := "origin/" + s.Branch
+	if !s.refExists(remoteBranch) {
+		return nil, nil
+	}
+
+	args := []string{}
+	if email, _ := s.run("config", "user.email"); email == "" {
+		args = append(args, "-c", "user.name="+fallbackCommitName, "-c", "user.email="+fallbackCommitEmail)
+	}
+	args = append(args, "merge", "--no-edit", "--allow-unrelated-histories")
+	switch strategy {
+	case MergeReportConflicts:
+	case MergePreferLocal:
+		args = append(args, "-X", "ours")
+	case MergePreferRemote:
+		args = append(args, "-X", "theirs")
+	default:
+		return nil, fmt.Errorf("unsupported merge strategy: %s", strategy)
+	}
+	args = append(args, remoteBranch)
+
+	_, mergeErr := s.run(args...)
+	if mergeErr == nil {
+		return nil, nil
+	}
+
+	output, err := s.run("diff", "--name-only", "--diff-filter=U")
+	if err != nil || output == "" {
+		s.run("merge", "--abort")
+		return nil, mergeErr
+	}
+	s.run("merge", "--abort")
+	return strings.Split(output, "\n"), nil
+}
+
+// Push pushes the local branch to the remote
+func (s *SyncRepo) Push() error {
+	_, err := s.run("push", "-u", "origin", "HEAD:refs/heads/"+s.Branch)
+	return err
+}
+
+// Head returns the commit hash the local branch points at, or "" when the
+// branch has no commits yet
+func (s *SyncRepo) Head() string {
+	head, err := s.run("rev-parse", "--verify", "--quiet", "HEAD")
+	if err != nil {
+		return ""
+	}
+	return head
+}
@@ -0,0 +1,121 @@
+package git
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// setupSyncRemote creates an empty bare repository to sync through
+func setupSyncRemote(t *testing.T) string {
+	t.Helper()
+
+	remote := filepath.Join(t.TempDir(), "remote.git")
+	if out, err := exec.Command("git", "init", "--bare", remote).CombinedOutput(); err != nil {
+		t.Fatalf("Failed to init bare repo: %v: %s", err, out)
+	}
+	return remote
+}
+
+func writeSyncFile(t *testing.T, repo *SyncRepo, name, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(repo.Dir, name), []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+}
+
+func TestSyncRepo_PushAndMerge(t *testing.T) {
+	remote := setupSyncRemote(t)
+
+	first := NewSyncRepo(filepath.Join(t.TempDir(), "first"), remote, "")
+	cloned, err := first.Ensure()
+	if err != nil {
+		t.Fatalf("Ensure failed: %v", err)
+	}
+	if !cloned {
+		t.Error("Expected first Ensure to clone")
+	}
+	if first.Head() != "" {
+		t.Error("Expected empty remote to have no commits")
+	}
+
+	writeSyncFile(t, first, "agents.json", "[1]\n")
+	committed, err := first.CommitAll("add agents")
+	if err != nil || !committed {
+		t.Fatalf("CommitAll = %v, %v; want commit", committed, err)
+	}
+	if committed, _ := first.CommitAll("nothing"); committed {
+		t.Error("Expected no commit without changes")
+	}
+	if err := first.Push(); err != nil {
+		t.Fatalf("Push failed: %v", err)
+	}
+
+	second := NewSyncRepo(filepath.Join(t.TempDir(), "second"), remote, "main")
+	if _, err := second.Ensure(); err != nil {
+		t.Fatalf("Ensure failed: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(second.Dir, "agents.json"))
+	if err != nil || string(data) != "[1]\n" {
+		t.Fatalf("Expected pushed file in second clone, got %q, %v", data, err)
+	}
+
+	cloned, err = second.Ensure()
+	if err != nil || cloned {
+		t.Fatalf("Ensure on existing clone = %v, %v; want no clone", cloned, err)
+	}
+}
+
+func TestSyncRepo_MergeConflicts(t *testing.T) {
+	remote := setupSyncRemote(t)
+
+	first := NewSyncRepo(filepath.Join(t.TempDir(), "first"), remote, "main")
+	if _, err := first.Ensure(); err != nil {
+		t.Fatalf("Ensure failed: %v", err)
+	}
+	writeSyncFile(t, first, "actions.json", "base\n")
+	first.CommitAll("base")
+	if err := first.Push(); err != nil {
+		t.Fatalf("Push failed: %v", err)
+	}
+
+	second := NewSyncRepo(filepath.Join(t.TempDir(), "second"), remote, "main")
+	if _, err := second.Ensure(); err != nil {
+		t.Fatalf("Ensure failed: %v", err)
+	}
+
+	writeSyncFile(t, first, "actions.json", "first\n")
+	first.CommitAll("first edit")
+	if err := first.Push(); err != nil {
+		t.Fatalf("Push failed: %v", err)
+	}
+
+	writeSyncFile(t, second, "actions.json", "second\n")
+	second.CommitAll("second edit")
+	if _, err := second.Ensure(); err != nil {
+		t.Fatalf("Ensure failed: %v", err)
+	}
+
+	conflicts, err := second.Merge(MergeReportConflicts)
+	if err != nil {
+		t.Fatalf("Merge failed: %v", err)
+	}
+	if want := []string{"actions.json"}; !reflect.DeepEqual(conflicts, want) {
+		t.Fatalf("Merge conflicts = %v, want %v", conflicts, want)
+	}
+	data, _ := os.ReadFile(filepath.Join(second.Dir, "actions.json"))
+	if string(data) != "second\n" {
+		t.Errorf("Expected aborted merge to keep local file, got %q", data)
+	}
+
+	conflicts, err = second.Merge(MergePreferRemote)
+	if err != nil || len(conflicts) != 0 {
+		t.Fatalf("Merge(remote) = %v, %v; want clean merge", conflicts, err)
+	}
+	data, _ = os.ReadFile(filepath.Join(second.Dir, "actions.json"))
+	if string(data) != "first\n" {
+		t.Errorf("Expected remote version after merge, got %q", data)
+	}
+}
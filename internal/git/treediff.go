@@ -0,0 +1,80 @@
+package git
+
+import (
+	"strconv"
+	"strings"
+)
+
+// maxTreeDiffPatch caps the patch kept by WorkTreeDiff, in bytes
+const maxTreeDiffPatch = 256 * 1024
+
+// DiffFileStat counts the lines a diff changes in one file
+type DiffFileStat struct {
+	Path      string `json:"path"`
+	Additions int    `json:"additions"`
+	Deletions int    `json:"deletions"`
+	// Binary files have no line counts
+	Binary bool `json:"binary,omitempty"`
+}
+
+// TreeDiff is the difference between a commit and a work tree
+type TreeDiff struct {
+	Files     []DiffFileStat `json:"files"`
+	Additions int            `json:"additions"`
+	Deletions int            `json:"deletions"`
+	Patch     string         `json:"patch"`
+	// Truncated is set when Patch was cut at maxTreeDiffPatch bytes
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// WorkTreeDiff diffs the work tree containing dir, committed or not and
+// untracked files included, against base. Like CreateCheckpoint it leaves
+// the index alone.
+func WorkTreeDiff(dir, base string) (*TreeDiff, error) {
+	top, err := workTreeTop(dir)
+	if err != nil {
+		return nil, err
+	}
+	target, err := runGit(top, "rev-parse", "--verify", base+"^{tree}")
+	if err != nil {
+		return nil, err
+	}
+	current, err := snapshotTree(top)
+	if err != nil {
+		return nil, err
+	}
+
+	numstat, err := runGitRaw(top, nil, nil, "diff-tree", "-r", "-z", "--numstat", "--no-renames", target, current)
+	if err != nil {
+		return nil, err
+	}
+	diff := &TreeDiff{Files: []DiffFileStat{}}
+	for _, entry := range strings.Split(strings.Trim(numstat, "\x00"), "\x00") {
+		// Each entry is "<added>\t<deleted>\t<path>"
+		fields := strings.SplitN(entry, "\t", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		stat := DiffFileStat{Path: fields[2]}
+		if fields[0] == "-" {
+			stat.Binary = true
+		} else {
+			stat.Additions, _ = strconv.Atoi(fields[0])
+			stat.Deletions, _ = strconv.Atoi(fields[1])
+		}
+		diff.Additions += stat.Additions
+		diff.Deletions += stat.Deletions
+		diff.Files = append(diff.Files, stat)
+	}
+
+	patch, err := runGitRaw(top, nil, nil, "diff-tree", "-p", "--no-color", "--no-renames", "--no-ext-diff", target, current)
+	if err != nil {
+		return nil, err
+	}
+	if len(patch) > maxTreeDiffPatch {
+		patch = patch[:maxTreeDiffPatch]
+		diff.Truncated = true
+	}
+	diff.Patch = patch
+	return diff, nil
+}
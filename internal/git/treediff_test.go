@@ -0,0 +1,53 @@
+package git
+
+import (
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWorkTreeDiffCountsCommittedAndUncommittedChanges(t *testing.T) {
+	dir := t.TempDir()
+	if out, err := exec.Command("git", "init", dir).CombinedOutput(); err != nil {
+		t.Fatalf("git init: %v: %s", err, out)
+	}
+	writeCheckpointFile(t, dir, "a.txt", "1\n2\n3\n")
+	writeCheckpointFile(t, dir, "b.txt", "b\n")
+	runTestGit(t, dir, "add", "-A")
+	runTestGit(t, dir, "commit", "-m", "initial")
+	base := runTestGit(t, dir, "rev-parse", "HEAD")
+
+	// One change is committed, the others are left in the work tree
+	writeCheckpointFile(t, dir, "a.txt", "1\ntwo\n3\n4\n")
+	runTestGit(t, dir, "commit", "-am", "edit a")
+	writeCheckpointFile(t, dir, "dir/new.txt", "new\n")
+	runTestGit(t, dir, "rm", "-q", "b.txt")
+
+	diff, err := WorkTreeDiff(dir, base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []DiffFileStat{
+		{Path: "a.txt", Additions: 2, Deletions: 1},
+		{Path: "b.txt", Deletions: 1},
+		{Path: "dir/new.txt", Additions: 1},
+	}
+	if !reflect.DeepEqual(diff.Files, want) {
+		t.Fatalf("files = %+v", diff.Files)
+	}
+	if diff.Additions != 3 || diff.Deletions != 2 || diff.Truncated {
+		t.Fatalf("diff = %+v", diff)
+	}
+	if !strings.Contains(diff.Patch, "+two") || !strings.Contains(diff.Patch, "diff --git a/dir/new.txt b/dir/new.txt") {
+		t.Fatalf("patch = %s", diff.Patch)
+	}
+	// The index still matches what was staged
+	if staged := runTestGit(t, dir, "diff", "--cached", "--name-only"); staged != "b.txt" {
+		t.Fatalf("staged = %q", staged)
+	}
+
+	if _, err := WorkTreeDiff(t.TempDir(), base); err != ErrNotRepository {
+		t.Fatalf("err = %v", err)
+	}
+}
@@ -3,7 +3,6 @@ package git
 import (
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strconv"
 	"strings"
@@ -125,14 +124,14 @@ func (g *GitWatcher) getGitStatus(workspacePath string) eventhub.GitChangedEvent
 	}
 
 	// 获取当前分支
-	cmd := exec.Command("git", "rev-parse", "--abbrev-ref", "HEAD")
+	cmd := Command("rev-parse", "--abbrev-ref", "HEAD")
 	cmd.Dir = workspacePath
 	if output, err := cmd.Output(); err == nil {
 		event.Branch = strings.TrimSpace(string(output))
 	}
 
 	// 获取 ahead/behind
-	cmd = exec.Command("git", "rev-list", "--left-right", "--count", "HEAD...@{upstream}")
+	cmd = Command("rev-list", "--left-right", "--count", "HEAD...@{upstream}")
 	cmd.Dir = workspacePath
 	if output, err := cmd.Output(); err == nil {
 		parts := strings.Fields(string(output))
@@ -143,7 +142,7 @@ func (g *GitWatcher) getGitStatus(workspacePath string) eventhub.GitChangedEvent
 	}
 
 	// 获取文件状态 (使用 git status --porcelain)
-	cmd = exec.Command("git", "status", "--porcelain")
+	cmd = Command("status", "--porcelain")
 	cmd.Dir = workspacePath
 	if output, err := cmd.Output(); err == nil {
 		lines := strings.Split(string(output), "\n")
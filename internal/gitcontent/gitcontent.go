@@ -7,6 +7,7 @@ import (
 	"os/exec"
 	"strings"
 
+	"ropcode/internal/git"
 	"ropcode/internal/pathutil"
 )
 
@@ -19,7 +20,7 @@ func ReadGitFileAtHead(workspacePath, gitPath string) (string, error) {
 		return "", fmt.Errorf("invalid git path: %s", gitPath)
 	}
 
-	cmd := exec.Command("git", "show", "HEAD:"+gitPath)
+	cmd := git.Command("show", "HEAD:"+gitPath)
 	cmd.Dir = workspacePath
 
 	var stdout, stderr bytes.Buffer
@@ -40,6 +40,7 @@ type AgentMetadata struct {
 	DownloadURL string `json:"download_url"`
 	Size        int    `json:"size"`
 	SHA         string `json:"sha"`
+	SourceID    string `json:"source_id,omitempty"`
 }
 
 // AgentContent represents the full content of an agent from GitHub
@@ -144,6 +145,11 @@ func FetchAgentExportFile(url string) (*AgentExportFile, error) {
 		return nil, fmt.Errorf("failed to read response body: %w", err)
 	}
 
+	return parseAgentExportFile(body)
+}
+
+// parseAgentExportFile parses and validates an agent export file
+func parseAgentExportFile(body []byte) (*AgentExportFile, error) {
 	// Parse the exported agent file format
 	var exportFile AgentExportFile
 	if err := json.Unmarshal(body, &exportFile); err != nil {
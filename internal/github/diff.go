@@ -0,0 +1,46 @@
+// internal/github/diff.go
+package github
+
+import (
+	"strings"
+
+	"github.com/go-git/go-git/v5/utils/diff"
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+// DiffLine is one line of a line-oriented text diff
+type DiffLine struct {
+	Op   string `json:"op"` // "equal", "insert", "delete"
+	Text string `json:"text"`
+}
+
+// DiffLines returns the line-oriented changes that turn oldText into newText
+func DiffLines(oldText, newText string) []DiffLine {
+	lines := make([]DiffLine, 0)
+	for _, chunk := range diff.Do(oldText, newText) {
+		op := "equal"
+		switch chunk.Type {
+		case diffmatchpatch.DiffInsert:
+			op = "insert"
+		case diffmatchpatch.DiffDelete:
+			op = "delete"
+		}
+		for _, line := range strings.SplitAfter(chunk.Text, "\n") {
+			if line == "" {
+				continue
+			}
+			lines = append(lines, DiffLine{Op: op, Text: strings.TrimSuffix(line, "\n")})
+		}
+	}
+	return lines
+}
+
+// HasChanges reports whether a diff contains any insertions or deletions
+func HasChanges(lines []DiffLine) bool {
+	for _, line := range lines {
+		if line.Op != "equal" {
+			return true
+		}
+	}
+	return false
+}
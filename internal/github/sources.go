@@ -0,0 +1,230 @@
+// internal/github/sources.go
+package github
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// APIBaseURL is the GitHub REST API root
+	APIBaseURL = "https://api.github.com"
+	// RawBaseURL is the root for raw file content
+	RawBaseURL = "https://raw.githubusercontent.com"
+)
+
+// AgentSource is a directory in a GitHub repository that publishes agent
+// export files (*.opcode.json).
+type AgentSource struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Owner   string `json:"owner"`
+	Repo    string `json:"repo"`
+	Branch  string `json:"branch"`
+	Path    string `json:"path"`
+	Enabled bool   `json:"enabled"`
+}
+
+// DefaultAgentSource returns the source behind DefaultAgentsURL
+func DefaultAgentSource() AgentSource {
+	source := AgentSource{
+		Name:    "opcode",
+		Owner:   "getAsterisk",
+		Repo:    "opcode",
+		Branch:  "main",
+		Path:    "cc_agents",
+		Enabled: true,
+	}
+	source.ID = source.defaultID()
+	return source
+}
+
+func (s AgentSource) defaultID() string {
+	id := s.Owner + "/" + s.Repo + "@" + s.Branch
+	if s.Path != "" {
+		id += ":" + s.Path
+	}
+	return id
+}
+
+// Normalize trims fields and fills in the default branch, ID and name
+func (s AgentSource) Normalize() AgentSource {
+	s.Owner = strings.TrimSpace(s.Owner)
+	s.Repo = strings.TrimSpace(s.Repo)
+	s.Branch = strings.TrimSpace(s.Branch)
+	s.Path = strings.Trim(strings.TrimSpace(s.Path), "/")
+	s.Name = strings.TrimSpace(s.Name)
+	if s.Branch == "" {
+		s.Branch = "main"
+	}
+	if strings.TrimSpace(s.ID) == "" {
+		s.ID = s.defaultID()
+	}
+	if s.Name == "" {
+		s.Name = s.Owner + "/" + s.Repo
+	}
+	return s
+}
+
+// Validate checks that the source identifies a repository
+func (s AgentSource) Validate() error {
+	if s.Owner == "" || s.Repo == "" {
+		return fmt.Errorf("agent source %q: owner and repo are required", s.ID)
+	}
+	for _, part := range []string{s.Owner, s.Repo, s.Branch} {
+		if strings.ContainsAny(part, "/ ?#") {
+			return fmt.Errorf("agent source %q: invalid owner, repo or branch", s.ID)
+		}
+	}
+	return nil
+}
+
+// NormalizeAgentSources normalizes and validates sources, rejecting duplicate IDs
+func NormalizeAgentSources(sources []AgentSource) ([]AgentSource, error) {
+	result := make([]AgentSource, 0, len(sources))
+	seen := make(map[string]bool, len(sources))
+	for _, source := range sources {
+		source = source.Normalize()
+		if err := source.Validate(); err != nil {
+			return nil, err
+		}
+		if seen[source.ID] {
+			return nil, fmt.Errorf("duplicate agent source: %s", source.ID)
+		}
+		seen[source.ID] = true
+		result = append(result, source)
+	}
+	return result, nil
+}
+
+type cachedResponse struct {
+	etag string
+	body []byte
+}
+
+// Client fetches agent sources and caches responses by ETag so repeated syncs
+// are answered with 304 Not Modified and do not count against rate limits.
+type Client struct {
+	httpClient *http.Client
+	apiBaseURL string
+	rawBaseURL string
+
+	mu    sync.Mutex
+	cache map[string]cachedResponse
+}
+
+// NewClient creates a client for the public GitHub endpoints
+func NewClient() *Client {
+	return &Client{
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		apiBaseURL: APIBaseURL,
+		rawBaseURL: RawBaseURL,
+		cache:      make(map[string]cachedResponse),
+	}
+}
+
+// ContentsURL returns the GitHub API URL that lists the source directory
+func (c *Client) ContentsURL(source AgentSource) string {
+	path := ""
+	if source.Path != "" {
+		path = "/" + source.Path
+	}
+	return fmt.Sprintf("%s/repos/%s/%s/contents%s?ref=%s",
+		c.apiBaseURL, source.Owner, source.Repo, path, url.QueryEscape(source.Branch))
+}
+
+// RawURL returns the raw content URL of a file in the source repository
+func (c *Client) RawURL(source AgentSource, filePath string) string {
+	return fmt.Sprintf("%s/%s/%s/%s/%s", c.rawBaseURL, source.Owner, source.Repo, source.Branch, filePath)
+}
+
+// get fetches url, sending If-None-Match for previously seen responses.
+func (c *Client) get(url, accept string) ([]byte, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	// Set User-Agent header required by GitHub API
+	req.Header.Set("User-Agent", "Ropcode-App")
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+
+	c.mu.Lock()
+	cached, hasCached := c.cache[url]
+	c.mu.Unlock()
+	if hasCached && cached.etag != "" {
+		req.Header.Set("If-None-Match", cached.etag)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified && hasCached {
+		return cached.body, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+	if etag := resp.Header.Get("ETag"); etag != "" {
+		c.mu.Lock()
+		c.cache[url] = cachedResponse{etag: etag, body: body}
+		c.mu.Unlock()
+	}
+	return body, nil
+}
+
+// FetchSourceAgents lists the agent files published by source
+func (c *Client) FetchSourceAgents(source AgentSource) ([]AgentMetadata, error) {
+	body, err := c.get(c.ContentsURL(source), "application/vnd.github.v3+json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch agents list from %s: %w", source.ID, err)
+	}
+
+	var files []GitHubFile
+	if err := json.Unmarshal(body, &files); err != nil {
+		return nil, fmt.Errorf("failed to parse agents list from %s: %w", source.ID, err)
+	}
+
+	agents := make([]AgentMetadata, 0, len(files))
+	for _, file := range files {
+		if file.Type == "file" && strings.HasSuffix(file.Name, ".opcode.json") {
+			agents = append(agents, AgentMetadata{
+				Name:        file.Name,
+				Path:        file.Path,
+				DownloadURL: c.RawURL(source, file.Path),
+				Size:        file.Size,
+				SHA:         file.SHA,
+				SourceID:    source.ID,
+			})
+		}
+	}
+	return agents, nil
+}
+
+// FetchAgentExportFile fetches and parses an agent export file, reusing the
+// cached copy when it has not changed.
+func (c *Client) FetchAgentExportFile(url string) (*AgentExportFile, error) {
+	if url == "" {
+		return nil, fmt.Errorf("agent URL is required")
+	}
+	body, err := c.get(url, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch agent content: %w", err)
+	}
+	return parseAgentExportFile(body)
+}
@@ -0,0 +1,122 @@
+// internal/github/sources_test.go
+package github
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func newTestClient(server *httptest.Server) *Client {
+	client := NewClient()
+	client.apiBaseURL = server.URL
+	client.rawBaseURL = server.URL + "/raw"
+	return client
+}
+
+func TestNormalizeAgentSources(t *testing.T) {
+	sources, err := NormalizeAgentSources([]AgentSource{
+		{Owner: " acme ", Repo: "agents", Path: "/team/agents/", Enabled: true},
+	})
+	if err != nil {
+		t.Fatalf("NormalizeAgentSources: %v", err)
+	}
+	got := sources[0]
+	if got.Branch != "main" || got.Path != "team/agents" || got.ID != "acme/agents@main:team/agents" || got.Name != "acme/agents" {
+		t.Fatalf("unexpected normalized source: %#v", got)
+	}
+
+	if _, err := NormalizeAgentSources([]AgentSource{{Owner: "acme"}}); err == nil {
+		t.Fatal("expected error for source without repo")
+	}
+	if _, err := NormalizeAgentSources([]AgentSource{{Owner: "a", Repo: "b"}, {Owner: "a", Repo: "b"}}); err == nil {
+		t.Fatal("expected error for duplicate sources")
+	}
+}
+
+func TestDefaultAgentSourceMatchesDefaultURL(t *testing.T) {
+	client := NewClient()
+	if got := client.ContentsURL(DefaultAgentSource()); got != DefaultAgentsURL+"?ref=main" {
+		t.Fatalf("ContentsURL = %q", got)
+	}
+}
+
+func TestFetchSourceAgentsUsesETagCache(t *testing.T) {
+	var requests, notModified int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if r.URL.Path != "/repos/acme/agents/contents/agents" || r.URL.Query().Get("ref") != "dev" {
+			t.Errorf("unexpected request: %s", r.URL.String())
+		}
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			atomic.AddInt32(&notModified, 1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte(`[
+			{"name":"reviewer.opcode.json","path":"agents/reviewer.opcode.json","type":"file","sha":"abc","size":10},
+			{"name":"README.md","path":"agents/README.md","type":"file"},
+			{"name":"nested","path":"agents/nested","type":"dir"}
+		]`))
+	}))
+	defer server.Close()
+
+	client := newTestClient(server)
+	source := AgentSource{Owner: "acme", Repo: "agents", Branch: "dev", Path: "agents"}.Normalize()
+
+	for i := 0; i < 2; i++ {
+		agents, err := client.FetchSourceAgents(source)
+		if err != nil {
+			t.Fatalf("FetchSourceAgents: %v", err)
+		}
+		if len(agents) != 1 {
+			t.Fatalf("expected 1 agent, got %#v", agents)
+		}
+		if agents[0].SourceID != source.ID || agents[0].DownloadURL != server.URL+"/raw/acme/agents/dev/agents/reviewer.opcode.json" {
+			t.Fatalf("unexpected agent metadata: %#v", agents[0])
+		}
+	}
+	if requests != 2 || notModified != 1 {
+		t.Fatalf("expected second request to be answered from cache, requests=%d notModified=%d", requests, notModified)
+	}
+}
+
+func TestClientFetchAgentExportFile(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"version":1,"agent":{"name":"Reviewer","system_prompt":"Review code","model":"claude-3-opus"}}`))
+	}))
+	defer server.Close()
+
+	exportFile, err := newTestClient(server).FetchAgentExportFile(server.URL + "/reviewer.opcode.json")
+	if err != nil {
+		t.Fatalf("FetchAgentExportFile: %v", err)
+	}
+	if exportFile.Agent.Name != "Reviewer" || exportFile.Agent.Model != "opus" || exportFile.Agent.Icon != "🤖" {
+		t.Fatalf("unexpected agent: %#v", exportFile.Agent)
+	}
+}
+
+func TestDiffLines(t *testing.T) {
+	lines := DiffLines("You review code.\nBe terse.\n", "You review code.\nBe thorough.\n")
+	want := []DiffLine{
+		{Op: "equal", Text: "You review code."},
+		{Op: "delete", Text: "Be terse."},
+		{Op: "insert", Text: "Be thorough."},
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("DiffLines = %#v", lines)
+	}
+	for i := range want {
+		if lines[i] != want[i] {
+			t.Fatalf("line %d = %#v, want %#v", i, lines[i], want[i])
+		}
+	}
+	if !HasChanges(lines) {
+		t.Fatal("expected HasChanges to report changes")
+	}
+	if HasChanges(DiffLines("same", "same")) {
+		t.Fatal("expected no changes for identical text")
+	}
+}
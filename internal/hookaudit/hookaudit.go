@@ -0,0 +1,191 @@
+// Package hookaudit records the Claude Code hooks the CLI runs. The CLI
+// reports each hook with a hook_started and a hook_response system event;
+// the recorder pairs the two, looks up the commands configured for the
+// hook and stores the run, so users can see why a hook, say a PreToolUse
+// hook, blocks tool calls.
+package hookaudit
+
+import (
+	"log/slog"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"ropcode/internal/claude"
+	"ropcode/internal/database"
+)
+
+// MaxOutput caps the hook output kept per run
+const MaxOutput = 8 << 10
+
+// Store keeps recorded runs
+type Store interface {
+	AddHookExecution(e *database.HookExecution) error
+}
+
+// Recorder is a claude.ActivityObserver that records hook runs
+type Recorder struct {
+	store       Store
+	claudeDir   string
+	projectPath func(sessionID string) string
+	now         func() time.Time
+
+	mu      sync.Mutex
+	started map[string]started // by hook_id
+}
+
+type started struct {
+	sessionID string
+	at        time.Time
+}
+
+// NewRecorder creates a recorder; projectPath returns the project a
+// session runs in, whose hook settings are searched for commands
+func NewRecorder(store Store, claudeDir string, projectPath func(sessionID string) string) *Recorder {
+	return &Recorder{
+		store:       store,
+		claudeDir:   claudeDir,
+		projectPath: projectPath,
+		now:         time.Now,
+		started:     make(map[string]started),
+	}
+}
+
+// ObserveClaudeEvent records hook_response events, timed from their
+// hook_started event
+func (r *Recorder) ObserveClaudeEvent(sessionID string, event map[string]interface{}) {
+	if stringField(event, "type") != "system" {
+		return
+	}
+	hookID := stringField(event, "hook_id")
+	switch stringField(event, "subtype") {
+	case "hook_started":
+		r.mu.Lock()
+		r.started[hookID] = started{sessionID: sessionID, at: r.now()}
+		r.mu.Unlock()
+	case "hook_response":
+		r.mu.Lock()
+		start, ok := r.started[hookID]
+		delete(r.started, hookID)
+		r.mu.Unlock()
+
+		execution := r.execution(sessionID, event)
+		if ok {
+			execution.StartedAt = start.at
+			execution.DurationMS = r.now().Sub(start.at).Milliseconds()
+		}
+		if err := r.store.AddHookExecution(execution); err != nil {
+			slog.Error("failed to record hook", "component", "hookaudit", "hook_event", execution.HookEvent, "error", err)
+		}
+	}
+}
+
+// CompleteSession forgets hooks of the session that never finished
+func (r *Recorder) CompleteSession(sessionID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, start := range r.started {
+		if start.sessionID == sessionID {
+			delete(r.started, id)
+		}
+	}
+}
+
+// HandleControlResponse is part of claude.ActivityObserver; hooks don't
+// use control requests
+func (r *Recorder) HandleControlResponse(sessionID string, response map[string]interface{}) {}
+
+// execution builds the record of a hook_response event
+func (r *Recorder) execution(sessionID string, event map[string]interface{}) *database.HookExecution {
+	// hook_name is "Event" or "Event:target", the target being what the
+	// hook matched: a tool name, a session source…
+	name := stringField(event, "hook_name")
+	hookEvent, target, _ := strings.Cut(name, ":")
+	if e := stringField(event, "hook_event"); e != "" {
+		hookEvent = e
+	}
+
+	output := stringField(event, "output")
+	if output == "" {
+		output = stringField(event, "stdout") + stringField(event, "stderr")
+	}
+	truncated := len(output) > MaxOutput
+	if truncated {
+		output = strings.ToValidUTF8(output[:MaxOutput], "")
+	}
+
+	execution := &database.HookExecution{
+		SessionID: sessionID,
+		HookEvent: hookEvent,
+		Matcher:   target,
+		Outcome:   stringField(event, "outcome"),
+		Output:    output,
+		Truncated: truncated,
+		StartedAt: r.now(),
+	}
+	if code, ok := event["exit_code"].(float64); ok {
+		exitCode := int(code)
+		execution.ExitCode = &exitCode
+	}
+	if r.projectPath != nil {
+		execution.ProjectPath = r.projectPath(sessionID)
+	}
+	execution.Command = strings.Join(r.commands(execution.ProjectPath, hookEvent, target), "\n")
+	return execution
+}
+
+// commands returns the hook commands configured for event whose matcher
+// matches target, from the user, project and local settings in that order
+func (r *Recorder) commands(projectPath, event, target string) []string {
+	scopes := []string{claude.HookScopeUser}
+	if projectPath != "" {
+		scopes = append(scopes, claude.HookScopeProject, claude.HookScopeLocal)
+	}
+	var commands []string
+	for _, scope := range scopes {
+		path, err := claude.HooksSettingsPath(r.claudeDir, scope, projectPath)
+		if err != nil {
+			continue
+		}
+		settings, err := claude.LoadSettings(path)
+		if err != nil {
+			continue
+		}
+		hooks, _ := settings["hooks"].(map[string]interface{})
+		matchers, _ := hooks[event].([]interface{})
+		for _, m := range matchers {
+			entry, _ := m.(map[string]interface{})
+			if !matches(stringField(entry, "matcher"), target) {
+				continue
+			}
+			list, _ := entry["hooks"].([]interface{})
+			for _, h := range list {
+				hook, _ := h.(map[string]interface{})
+				if command := stringField(hook, "command"); command != "" {
+					commands = append(commands, command)
+				}
+			}
+		}
+	}
+	return commands
+}
+
+// matches reports whether a hook matcher applies to target the way the
+// CLI matches them: empty and "*" match everything, other patterns are
+// regular expressions over the whole target
+func matches(pattern, target string) bool {
+	if pattern == "" || pattern == "*" || target == "" {
+		return true
+	}
+	re, err := regexp.Compile("^(?:" + pattern + ")$")
+	if err != nil {
+		return pattern == target
+	}
+	return re.MatchString(target)
+}
+
+func stringField(m map[string]interface{}, key string) string {
+	s, _ := m[key].(string)
+	return s
+}
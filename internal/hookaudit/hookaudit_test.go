@@ -0,0 +1,85 @@
+package hookaudit
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"ropcode/internal/database"
+)
+
+type memoryStore struct {
+	executions []*database.HookExecution
+}
+
+func (s *memoryStore) AddHookExecution(e *database.HookExecution) error {
+	s.executions = append(s.executions, e)
+	return nil
+}
+
+func event(line string) map[string]interface{} {
+	var m map[string]interface{}
+	json.Unmarshal([]byte(line), &m)
+	return m
+}
+
+func TestRecorderRecordsHookRuns(t *testing.T) {
+	claudeDir, project := t.TempDir(), t.TempDir()
+	os.WriteFile(filepath.Join(claudeDir, "settings.json"),
+		[]byte(`{"hooks":{"PreToolUse":[{"matcher":"Edit|Write","hooks":[{"type":"command","command":"fmt"}]},{"matcher":"Bash","hooks":[{"type":"command","command":"guard-user"}]}]}}`), 0644)
+	os.MkdirAll(filepath.Join(project, ".claude"), 0755)
+	os.WriteFile(filepath.Join(project, ".claude", "settings.local.json"),
+		[]byte(`{"hooks":{"PreToolUse":[{"matcher":"*","hooks":[{"type":"command","command":"guard-local"}]}]}}`), 0644)
+
+	store := &memoryStore{}
+	r := NewRecorder(store, claudeDir, func(string) string { return project })
+	clock := time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)
+	r.now = func() time.Time { return clock }
+
+	r.ObserveClaudeEvent("s1", event(`{"type":"system","subtype":"hook_started","hook_id":"h1","hook_name":"PreToolUse:Bash","hook_event":"PreToolUse"}`))
+	clock = clock.Add(1500 * time.Millisecond)
+	r.ObserveClaudeEvent("s1", event(`{"type":"system","subtype":"hook_response","hook_id":"h1","hook_name":"PreToolUse:Bash","hook_event":"PreToolUse","output":"rm is not allowed\n","exit_code":2,"outcome":"error"}`))
+	// A response without its start, and an event that isn't a hook
+	r.ObserveClaudeEvent("s1", event(`{"type":"system","subtype":"hook_response","hook_id":"h2","hook_name":"Stop","stdout":"bye","exit_code":0,"outcome":"success"}`))
+	r.ObserveClaudeEvent("s1", event(`{"type":"system","subtype":"init"}`))
+
+	if len(store.executions) != 2 {
+		t.Fatalf("recorded %d runs, want 2", len(store.executions))
+	}
+	blocked := store.executions[0]
+	if blocked.HookEvent != "PreToolUse" || blocked.Matcher != "Bash" || blocked.Outcome != "error" ||
+		blocked.ExitCode == nil || *blocked.ExitCode != 2 || blocked.DurationMS != 1500 || blocked.ProjectPath != project {
+		t.Errorf("blocked run = %+v", blocked)
+	}
+	if blocked.Command != "guard-user\nguard-local" {
+		t.Errorf("commands = %q", blocked.Command)
+	}
+	if stop := store.executions[1]; stop.HookEvent != "Stop" || stop.Output != "bye" || stop.DurationMS != 0 {
+		t.Errorf("stop run = %+v", stop)
+	}
+}
+
+func TestRecorderTruncatesOutput(t *testing.T) {
+	store := &memoryStore{}
+	r := NewRecorder(store, t.TempDir(), nil)
+	output, _ := json.Marshal(strings.Repeat("x", MaxOutput+10))
+	r.ObserveClaudeEvent("s1", event(`{"type":"system","subtype":"hook_response","hook_id":"h1","hook_name":"PostToolUse:Edit","output":`+string(output)+`}`))
+
+	e := store.executions[0]
+	if !e.Truncated || len(e.Output) != MaxOutput || e.ExitCode != nil {
+		t.Errorf("truncated=%v len=%d exit=%v", e.Truncated, len(e.Output), e.ExitCode)
+	}
+}
+
+func TestRecorderForgetsUnfinishedHooks(t *testing.T) {
+	r := NewRecorder(&memoryStore{}, t.TempDir(), nil)
+	r.ObserveClaudeEvent("s1", event(`{"type":"system","subtype":"hook_started","hook_id":"h1","hook_name":"Stop"}`))
+	r.ObserveClaudeEvent("s2", event(`{"type":"system","subtype":"hook_started","hook_id":"h2","hook_name":"Stop"}`))
+	r.CompleteSession("s1")
+	if _, ok := r.started["h1"]; ok || len(r.started) != 1 {
+		t.Errorf("started = %v", r.started)
+	}
+}
@@ -0,0 +1,168 @@
+// Package imageconv shrinks pasted images before they're saved for a
+// prompt: it scales them down to fit a maximum size and can re-encode them
+// as JPEG or PNG. Only the standard library's codecs are available, so
+// PNG, JPEG and GIF images can be converted; anything else (WebP among
+// them) is kept as it was pasted.
+package imageconv
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+)
+
+// Output formats
+const (
+	FormatOriginal = "original"
+	FormatJPEG     = "jpeg"
+	FormatPNG      = "png"
+)
+
+// DefaultQuality is the JPEG quality used when none is set
+const DefaultQuality = 85
+
+// Options says how to convert an image. Zero values leave that aspect
+// alone.
+type Options struct {
+	// MaxWidth and MaxHeight bound the size; the aspect ratio is kept
+	MaxWidth  int
+	MaxHeight int
+	// Format is FormatOriginal, FormatJPEG or FormatPNG
+	Format string
+	// Quality is the JPEG quality, 1 to 100
+	Quality int
+}
+
+// Result is a converted image
+type Result struct {
+	Data []byte
+	// Ext is the extension matching Data, with the dot; empty when the
+	// format isn't known
+	Ext    string
+	Width  int
+	Height int
+	// Converted is false when Data is the input unchanged
+	Converted bool
+}
+
+// Convert applies opts to the encoded image data. Data that can't be
+// decoded is returned unchanged with a zero size, and so is an image the
+// conversion would only make bigger.
+func Convert(data []byte, opts Options) (*Result, error) {
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return &Result{Data: data}, nil
+	}
+	original := &Result{Data: data, Ext: extension(format), Width: img.Bounds().Dx(), Height: img.Bounds().Dy()}
+
+	target := opts.Format
+	if target == "" || target == FormatOriginal {
+		target = format
+	}
+	width, height := fit(original.Width, original.Height, opts.MaxWidth, opts.MaxHeight)
+	resized := width != original.Width || height != original.Height
+	if !resized && target == format {
+		return original, nil
+	}
+	if resized {
+		img = scale(img, width, height)
+	}
+
+	var buf bytes.Buffer
+	switch target {
+	case FormatJPEG:
+		quality := opts.Quality
+		if quality <= 0 || quality > 100 {
+			quality = DefaultQuality
+		}
+		err = jpeg.Encode(&buf, flatten(img), &jpeg.Options{Quality: quality})
+	case FormatPNG:
+		err = (&png.Encoder{CompressionLevel: png.BestCompression}).Encode(&buf, img)
+	case "gif":
+		err = gif.Encode(&buf, img, nil)
+	default:
+		return nil, fmt.Errorf("unsupported image format %q", target)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !resized && buf.Len() >= len(data) {
+		return original, nil
+	}
+	return &Result{Data: buf.Bytes(), Ext: extension(target), Width: width, Height: height, Converted: true}, nil
+}
+
+func extension(format string) string {
+	if format == FormatJPEG {
+		return ".jpg"
+	}
+	return "." + format
+}
+
+// fit returns the size of a width x height image scaled down to fit
+// maxWidth x maxHeight, never up
+func fit(width, height, maxWidth, maxHeight int) (int, int) {
+	ratio := 1.0
+	if maxWidth > 0 && width > maxWidth {
+		ratio = float64(maxWidth) / float64(width)
+	}
+	if maxHeight > 0 && height > maxHeight {
+		ratio = min(ratio, float64(maxHeight)/float64(height))
+	}
+	if ratio == 1 {
+		return width, height
+	}
+	return max(1, int(float64(width)*ratio+0.5)), max(1, int(float64(height)*ratio+0.5))
+}
+
+// scale shrinks img to width x height, averaging the source pixels each
+// target pixel covers so text in screenshots stays readable
+func scale(img image.Image, width, height int) *image.NRGBA {
+	src := image.NewNRGBA(img.Bounds())
+	draw.Draw(src, src.Bounds(), img, img.Bounds().Min, draw.Src)
+	srcW, srcH := src.Bounds().Dx(), src.Bounds().Dy()
+
+	dst := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0, y1 := y*srcH/height, max((y+1)*srcH/height, y*srcH/height+1)
+		for x := 0; x < width; x++ {
+			x0, x1 := x*srcW/width, max((x+1)*srcW/width, x*srcW/width+1)
+			// Weight colours by alpha so transparent pixels don't darken
+			// the edges
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				row := src.Pix[sy*src.Stride:]
+				for sx := x0; sx < x1; sx++ {
+					p := row[sx*4 : sx*4+4]
+					pa := uint64(p[3])
+					r += uint64(p[0]) * pa
+					g += uint64(p[1]) * pa
+					b += uint64(p[2]) * pa
+					a += pa
+					n++
+				}
+			}
+			i := y*dst.Stride + x*4
+			if a > 0 {
+				dst.Pix[i] = uint8(r / a)
+				dst.Pix[i+1] = uint8(g / a)
+				dst.Pix[i+2] = uint8(b / a)
+			}
+			dst.Pix[i+3] = uint8(a / n)
+		}
+	}
+	return dst
+}
+
+// flatten draws img over white, since JPEG has no transparency
+func flatten(img image.Image) image.Image {
+	out := image.NewRGBA(img.Bounds())
+	draw.Draw(out, out.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(out, out.Bounds(), img, img.Bounds().Min, draw.Over)
+	return out
+}
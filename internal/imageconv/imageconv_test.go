@@ -0,0 +1,116 @@
+package imageconv
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"math/rand"
+	"testing"
+)
+
+func encodePNG(t *testing.T, width, height int, fill color.Color) []byte {
+	t.Helper()
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			// Noise keeps the PNG from compressing to almost nothing
+			if (x*7+y*13)%5 == 0 {
+				img.Set(x, y, color.Black)
+			} else {
+				img.Set(x, y, fill)
+			}
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestConvertDownscales(t *testing.T) {
+	data := encodePNG(t, 400, 200, color.White)
+
+	got, err := Convert(data, Options{MaxWidth: 100, MaxHeight: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Converted || got.Ext != ".png" || got.Width != 100 || got.Height != 50 {
+		t.Fatalf("Convert() = %s %dx%d converted %v", got.Ext, got.Width, got.Height, got.Converted)
+	}
+	cfg, err := png.DecodeConfig(bytes.NewReader(got.Data))
+	if err != nil || cfg.Width != 100 || cfg.Height != 50 {
+		t.Errorf("encoded %dx%d, %v", cfg.Width, cfg.Height, err)
+	}
+
+	// Images already small enough are left alone
+	got, err = Convert(data, Options{MaxWidth: 1000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Converted || !bytes.Equal(got.Data, data) || got.Width != 400 {
+		t.Errorf("Convert() within bounds = converted %v, %dx%d", got.Converted, got.Width, got.Height)
+	}
+}
+
+func TestConvertToJPEG(t *testing.T) {
+	// A noisy gradient, like a photo, which JPEG stores far smaller than PNG
+	rng := rand.New(rand.NewSource(1))
+	img := image.NewNRGBA(image.Rect(0, 0, 300, 300))
+	for y := 0; y < 300; y++ {
+		for x := 0; x < 300; x++ {
+			img.SetNRGBA(x, y, color.NRGBA{uint8(x/2 + rng.Intn(40)), uint8(y/2 + rng.Intn(40)), uint8(rng.Intn(255)), 255})
+		}
+	}
+	// A transparent corner becomes white
+	for y := 0; y < 16; y++ {
+		for x := 0; x < 16; x++ {
+			img.SetNRGBA(x, y, color.NRGBA{})
+		}
+	}
+	var buf bytes.Buffer
+	png.Encode(&buf, img)
+
+	got, err := Convert(buf.Bytes(), Options{Format: FormatJPEG, Quality: 60})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Converted || got.Ext != ".jpg" || len(got.Data) >= buf.Len() {
+		t.Fatalf("Convert() to jpeg = %s, %d bytes from %d", got.Ext, len(got.Data), buf.Len())
+	}
+	decoded, err := jpeg.Decode(bytes.NewReader(got.Data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r, g, b, _ := decoded.At(4, 4).RGBA(); r>>8 < 200 || g>>8 < 200 || b>>8 < 200 {
+		t.Errorf("transparent pixel = %d,%d,%d; want near white", r>>8, g>>8, b>>8)
+	}
+}
+
+func TestConvertKeepsUnknownData(t *testing.T) {
+	data := []byte("RIFF\x00\x00\x00\x00WEBPVP8 ")
+	got, err := Convert(data, Options{Format: FormatJPEG, MaxWidth: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Converted || got.Ext != "" || !bytes.Equal(got.Data, data) {
+		t.Errorf("Convert() of undecodable data = %+v", got)
+	}
+}
+
+func TestFit(t *testing.T) {
+	tests := []struct{ w, h, maxW, maxH, wantW, wantH int }{
+		{3000, 2000, 1500, 0, 1500, 1000},
+		{3000, 2000, 0, 500, 750, 500},
+		{3000, 2000, 1000, 1000, 1000, 667},
+		{800, 600, 1000, 1000, 800, 600},
+		{5000, 1, 100, 0, 100, 1},
+	}
+	for _, tt := range tests {
+		if w, h := fit(tt.w, tt.h, tt.maxW, tt.maxH); w != tt.wantW || h != tt.wantH {
+			t.Errorf("fit(%d, %d, %d, %d) = %d, %d", tt.w, tt.h, tt.maxW, tt.maxH, w, h)
+		}
+	}
+}
@@ -0,0 +1,300 @@
+// Package jobs runs commands in the background. A job runs its command in
+// the preferred shell, or a program with its arguments, under the process
+// manager, keyed by a UUID so that runs of the same command don't collide.
+// It streams its output as events, can be cancelled or given a timeout and
+// reports its exit status. Finished jobs are kept for a while so their
+// outcome can be looked up after the exit event.
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"ropcode/internal/command"
+	"ropcode/internal/loginenv"
+	"ropcode/internal/process"
+)
+
+// OutputEvent is emitted for each chunk of job output and once when the
+// job ends
+const OutputEvent = "job-output"
+
+// keepFinished is how many finished jobs are kept
+const keepFinished = 50
+
+var (
+	// ErrNotRunning is returned by Cancel for jobs that already ended
+	ErrNotRunning = errors.New("job is not running")
+	// ErrNotFound is returned for jobs that are unknown or no longer kept
+	ErrNotFound = errors.New("job not found")
+)
+
+// Job statuses
+const (
+	StatusRunning   = "running"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+	StatusCancelled = "cancelled"
+	StatusTimedOut  = "timed_out"
+)
+
+// Job describes a job and, once it ended, how
+type Job struct {
+	Key        string     `json:"key"`
+	Command    string     `json:"command"`
+	Args       []string   `json:"args,omitempty"`
+	Cwd        string     `json:"cwd"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	Status     string     `json:"status"`
+	ExitCode   *int       `json:"exit_code,omitempty"`
+}
+
+// Output is the payload of OutputEvent
+type Output struct {
+	JobKey     string `json:"job_key"`
+	OutputType string `json:"output_type"` // stdout, stderr or exit
+	Content    string `json:"content"`
+	ExitCode   *int   `json:"exit_code,omitempty"`
+	Status     string `json:"status,omitempty"` // with exit
+}
+
+// Spec describes the command to run
+type Spec struct {
+	Command string
+	// Args, when not nil, run Command as a program with these arguments
+	// instead of as a shell command line
+	Args []string
+	// Shell runs the command line, or the program when it asks for a login
+	// shell; the zero value is the platform shell
+	Shell command.Preference
+	Cwd   string
+	// Env is added to the inherited environment
+	Env map[string]string
+	// Timeout cancels the job when it runs longer; 0 means no limit
+	Timeout time.Duration
+	// Prefix starts the job's key, which ends in a UUID; "job" by default
+	Prefix string
+	// Key, when set, is the job's key instead of one made from Prefix
+	Key string
+	// Done is called with the finished job after its exit event
+	Done func(Job)
+}
+
+// Emitter receives job output events
+type Emitter interface {
+	Emit(eventName string, payload interface{})
+}
+
+// Runner starts and cancels jobs
+type Runner struct {
+	procs   *process.Manager
+	emitter Emitter
+
+	mu       sync.Mutex
+	jobs     map[string]*job
+	finished []string // keys of the kept finished jobs, oldest first
+}
+
+// job is a job and the reason it was stopped, if it was
+type job struct {
+	mu      sync.Mutex
+	record  Job
+	stopped string
+	timer   *time.Timer
+	done    func(Job)
+}
+
+// New creates a runner
+func New(procs *process.Manager, emitter Emitter) *Runner {
+	return &Runner{
+		procs:   procs,
+		emitter: emitter,
+		jobs:    make(map[string]*job),
+	}
+}
+
+// Start runs spec and returns the job; its output follows as OutputEvent
+// events
+func (r *Runner) Start(spec Spec) (*Job, error) {
+	if strings.TrimSpace(spec.Command) == "" {
+		return nil, fmt.Errorf("job has no command")
+	}
+	if spec.Timeout < 0 {
+		return nil, fmt.Errorf("job timeout must not be negative")
+	}
+	key := spec.Key
+	if key == "" {
+		prefix := spec.Prefix
+		if prefix == "" {
+			prefix = "job"
+		}
+		key = prefix + "-" + uuid.New().String()
+	}
+	j := &job{record: Job{
+		Key:       key,
+		Command:   spec.Command,
+		Args:      spec.Args,
+		Cwd:       spec.Cwd,
+		StartedAt: time.Now(),
+		Status:    StatusRunning,
+	}, done: spec.Done}
+
+	cmd := spec.Shell.Command(context.Background(), spec.Command)
+	if spec.Args != nil {
+		cmd = spec.Shell.Program(context.Background(), spec.Command, spec.Args)
+	}
+	cmd.Dir = spec.Cwd
+	cmd.Env = loginenv.Environ()
+	for name, value := range spec.Env {
+		cmd.Env = append(cmd.Env, name+"="+value)
+	}
+	cmd.Stdout = &stream{r: r, key: key, outputType: "stdout"}
+	cmd.Stderr = &stream{r: r, key: key, outputType: "stderr"}
+	// Don't wait on background processes left holding the output pipes
+	cmd.WaitDelay = time.Second
+
+	r.mu.Lock()
+	if _, taken := r.jobs[key]; taken {
+		r.mu.Unlock()
+		return nil, fmt.Errorf("job %s already exists", key)
+	}
+	r.jobs[key] = j
+	r.mu.Unlock()
+
+	proc, err := r.procs.SpawnCommand(key, cmd)
+	if err != nil {
+		r.mu.Lock()
+		delete(r.jobs, key)
+		r.mu.Unlock()
+		return nil, fmt.Errorf("failed to start job: %w", err)
+	}
+	if spec.Timeout > 0 {
+		j.mu.Lock()
+		j.timer = time.AfterFunc(spec.Timeout, func() { r.stop(key, StatusTimedOut) })
+		j.mu.Unlock()
+	}
+	go func() {
+		proc.Wait()
+		r.finish(j, proc.ExitCode())
+	}()
+
+	started := j.snapshot()
+	return &started, nil
+}
+
+// Cancel stops a running job
+func (r *Runner) Cancel(key string) error {
+	return r.stop(key, StatusCancelled)
+}
+
+// stop kills a running job, which ends with status
+func (r *Runner) stop(key, status string) error {
+	r.mu.Lock()
+	j, ok := r.jobs[key]
+	r.mu.Unlock()
+	if !ok {
+		return ErrNotRunning
+	}
+	j.mu.Lock()
+	if j.record.Status != StatusRunning {
+		j.mu.Unlock()
+		return ErrNotRunning
+	}
+	if j.stopped == "" {
+		j.stopped = status
+	}
+	j.mu.Unlock()
+	return r.procs.Kill(key)
+}
+
+// Get returns a running or recently finished job
+func (r *Runner) Get(key string) (*Job, bool) {
+	r.mu.Lock()
+	j, ok := r.jobs[key]
+	r.mu.Unlock()
+	if !ok {
+		return nil, false
+	}
+	record := j.snapshot()
+	return &record, true
+}
+
+// List returns the running and recently finished jobs, newest first
+func (r *Runner) List() []*Job {
+	r.mu.Lock()
+	list := make([]*Job, 0, len(r.jobs))
+	for _, j := range r.jobs {
+		record := j.snapshot()
+		list = append(list, &record)
+	}
+	r.mu.Unlock()
+	sort.Slice(list, func(i, k int) bool { return list[i].StartedAt.After(list[k].StartedAt) })
+	return list
+}
+
+// finish records the outcome of an ended job and emits its exit event
+func (r *Runner) finish(j *job, exitCode int) {
+	finishedAt := time.Now()
+
+	j.mu.Lock()
+	if j.timer != nil {
+		j.timer.Stop()
+	}
+	record := &j.record
+	record.ExitCode = &exitCode
+	record.FinishedAt = &finishedAt
+	switch {
+	case j.stopped != "":
+		record.Status = j.stopped
+	case exitCode == 0:
+		record.Status = StatusSucceeded
+	default:
+		record.Status = StatusFailed
+	}
+	key, status, finished := record.Key, record.Status, *record
+	j.mu.Unlock()
+
+	r.mu.Lock()
+	r.finished = append(r.finished, key)
+	if len(r.finished) > keepFinished {
+		delete(r.jobs, r.finished[0])
+		r.finished = r.finished[1:]
+	}
+	r.mu.Unlock()
+	r.emit(Output{JobKey: key, OutputType: "exit", ExitCode: &exitCode, Status: status})
+	if j.done != nil {
+		j.done(finished)
+	}
+}
+
+func (j *job) snapshot() Job {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.record
+}
+
+func (r *Runner) emit(output Output) {
+	if r.emitter != nil {
+		r.emitter.Emit(OutputEvent, output)
+	}
+}
+
+// stream forwards one of a job's output streams
+type stream struct {
+	r          *Runner
+	key        string
+	outputType string
+}
+
+func (s *stream) Write(p []byte) (int, error) {
+	s.r.emit(Output{JobKey: s.key, OutputType: s.outputType, Content: string(p)})
+	return len(p), nil
+}
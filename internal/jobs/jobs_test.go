@@ -0,0 +1,204 @@
+package jobs
+
+import (
+	"context"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"ropcode/internal/command"
+	"ropcode/internal/process"
+)
+
+// events collects output events and signals the exit ones
+type events struct {
+	mu      sync.Mutex
+	outputs []Output
+	exited  chan Output
+}
+
+func (e *events) Emit(eventName string, payload interface{}) {
+	output := payload.(Output)
+	e.mu.Lock()
+	e.outputs = append(e.outputs, output)
+	e.mu.Unlock()
+	if output.OutputType == "exit" {
+		e.exited <- output
+	}
+}
+
+func (e *events) text(outputType string) string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	var b strings.Builder
+	for _, o := range e.outputs {
+		if o.OutputType == outputType {
+			b.WriteString(o.Content)
+		}
+	}
+	return b.String()
+}
+
+func newRunner(t *testing.T) (*Runner, *events) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("test commands use sh")
+	}
+	emitted := &events{exited: make(chan Output, 1)}
+	return New(process.NewManager(context.Background()), emitted), emitted
+}
+
+func waitExit(t *testing.T, emitted *events) Output {
+	t.Helper()
+	select {
+	case output := <-emitted.exited:
+		return output
+	case <-time.After(10 * time.Second):
+		t.Fatal("job did not end")
+		return Output{}
+	}
+}
+
+func TestJobStreamsOutputAndExitStatus(t *testing.T) {
+	r, emitted := newRunner(t)
+	cwd := t.TempDir()
+
+	job, err := r.Start(Spec{
+		Command: `echo "in $(pwd) as $GREETING"; echo oops >&2; exit 3`,
+		Cwd:     cwd,
+		Env:     map[string]string{"GREETING": "hi"},
+	})
+	if err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	if job.Status != StatusRunning || !strings.HasPrefix(job.Key, "job-") {
+		t.Errorf("started job = %+v", job)
+	}
+
+	exit := waitExit(t, emitted)
+	if exit.JobKey != job.Key || *exit.ExitCode != 3 || exit.Status != StatusFailed {
+		t.Errorf("exit event = %+v", exit)
+	}
+	if got := emitted.text("stdout"); !strings.HasSuffix(got, " as hi\n") {
+		t.Errorf("stdout = %q", got)
+	}
+	if got := emitted.text("stderr"); got != "oops\n" {
+		t.Errorf("stderr = %q", got)
+	}
+	if finished, ok := r.Get(job.Key); !ok || finished.Status != StatusFailed || finished.FinishedAt == nil {
+		t.Errorf("Get() = %+v, %v", finished, ok)
+	}
+}
+
+func TestCancelJob(t *testing.T) {
+	r, emitted := newRunner(t)
+
+	job, err := r.Start(Spec{Command: "exec sleep 30", Cwd: t.TempDir()})
+	if err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	if err := r.Cancel(job.Key); err != nil {
+		t.Fatalf("Cancel() error = %v", err)
+	}
+	if exit := waitExit(t, emitted); exit.Status != StatusCancelled {
+		t.Errorf("exit event = %+v", exit)
+	}
+	if err := r.Cancel(job.Key); err != ErrNotRunning {
+		t.Errorf("second Cancel() error = %v", err)
+	}
+}
+
+func TestJobTimeout(t *testing.T) {
+	r, emitted := newRunner(t)
+
+	started := time.Now()
+	if _, err := r.Start(Spec{Command: "exec sleep 30", Cwd: t.TempDir(), Timeout: 100 * time.Millisecond}); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	if exit := waitExit(t, emitted); exit.Status != StatusTimedOut {
+		t.Errorf("exit event = %+v", exit)
+	}
+	if elapsed := time.Since(started); elapsed > 5*time.Second {
+		t.Errorf("timed out job took %v to end", elapsed)
+	}
+
+	if _, err := r.Start(Spec{Command: " "}); err == nil {
+		t.Error("Start() without a command should fail")
+	}
+}
+
+func TestProgramJobsGetUniqueKeysAndAreListed(t *testing.T) {
+	r, emitted := newRunner(t)
+	emitted.exited = make(chan Output, 2)
+	cwd := t.TempDir()
+
+	spec := Spec{Command: "echo", Args: []string{"$NOT_EXPANDED"}, Cwd: cwd, Prefix: "async-echo"}
+	first, err := r.Start(spec)
+	if err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	second, err := r.Start(spec)
+	if err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	if first.Key == second.Key || !strings.HasPrefix(first.Key, "async-echo-") {
+		t.Fatalf("keys = %q, %q", first.Key, second.Key)
+	}
+	waitExit(t, emitted)
+	waitExit(t, emitted)
+	// Arguments reach the program as they are, without a shell
+	if got := emitted.text("stdout"); got != "$NOT_EXPANDED\n$NOT_EXPANDED\n" {
+		t.Errorf("stdout = %q", got)
+	}
+
+	list := r.List()
+	if len(list) != 2 || list[0].Key != second.Key || list[1].Key != first.Key {
+		t.Fatalf("List() = %+v", list)
+	}
+	if job := list[0]; job.Command != "echo" || job.Cwd != cwd || job.Status != StatusSucceeded || job.StartedAt.IsZero() {
+		t.Errorf("listed job = %+v", job)
+	}
+}
+
+func TestLoginShellJobRunsProgram(t *testing.T) {
+	r, emitted := newRunner(t)
+
+	spec := Spec{
+		Command: "echo",
+		Args:    []string{"it's", "$NOT_EXPANDED"},
+		Cwd:     t.TempDir(),
+		Shell:   command.Preference{Path: "/bin/sh", Login: true},
+	}
+	done := make(chan Job, 1)
+	spec.Done = func(job Job) { done <- job }
+	if _, err := r.Start(spec); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	if exit := waitExit(t, emitted); exit.Status != StatusSucceeded {
+		t.Errorf("exit event = %+v", exit)
+	}
+	if got := emitted.text("stdout"); got != "it's $NOT_EXPANDED\n" {
+		t.Errorf("stdout = %q", got)
+	}
+	if job := <-done; job.Status != StatusSucceeded || job.FinishedAt == nil {
+		t.Errorf("Done() got %+v", job)
+	}
+}
+
+func TestJobWithGivenKey(t *testing.T) {
+	r, emitted := newRunner(t)
+
+	job, err := r.Start(Spec{Command: "exec sleep 30", Cwd: t.TempDir(), Key: "action-1"})
+	if err != nil || job.Key != "action-1" {
+		t.Fatalf("Start() = %+v, %v", job, err)
+	}
+	if _, err := r.Start(Spec{Command: "true", Key: "action-1"}); err == nil {
+		t.Error("Start() with a key in use should fail")
+	}
+	if err := r.Cancel("action-1"); err != nil {
+		t.Fatalf("Cancel() error = %v", err)
+	}
+	waitExit(t, emitted)
+}
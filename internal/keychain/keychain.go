@@ -0,0 +1,43 @@
+// Package keychain stores secrets in the operating system's credential store:
+// the login keychain on macOS, the Secret Service on Linux and the Credential
+// Manager on Windows.
+package keychain
+
+import "errors"
+
+// service groups every ropcode secret under one name in the credential store
+const service = "ropcode"
+
+// ErrNotFound is returned when no secret is stored for an account
+var ErrNotFound = errors.New("secret not found in keychain")
+
+// Set stores the secret for an account, replacing any previous one
+func Set(account, secret string) error {
+	return set(account, secret)
+}
+
+// Get returns the secret stored for an account
+func Get(account string) (string, error) {
+	return get(account)
+}
+
+// Delete removes the secret stored for an account
+func Delete(account string) error {
+	return remove(account)
+}
+
+// Store is somewhere secrets can be kept; tests substitute an in-memory one
+type Store interface {
+	Set(account, secret string) error
+	Get(account string) (string, error)
+	Delete(account string) error
+}
+
+// System is the Store backed by the operating system's credential store
+var System Store = systemStore{}
+
+type systemStore struct{}
+
+func (systemStore) Set(account, secret string) error   { return Set(account, secret) }
+func (systemStore) Get(account string) (string, error) { return Get(account) }
+func (systemStore) Delete(account string) error        { return Delete(account) }
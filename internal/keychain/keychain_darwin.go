@@ -0,0 +1,51 @@
+//go:build darwin
+
+package keychain
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// errSecItemNotFound is the exit status of security(1) for a missing item
+const errSecItemNotFound = 44
+
+func set(account, secret string) error {
+	// Commands are fed through stdin so the secret never shows up in ps
+	cmd := exec.Command("security", "-i")
+	cmd.Stdin = strings.NewReader(fmt.Sprintf("add-generic-password -U -s %q -a %q -X %q\n",
+		service, account, hex.EncodeToString([]byte(secret))))
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to store secret in keychain: %v: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+func get(account string) (string, error) {
+	output, err := exec.Command("security", "find-generic-password", "-s", service, "-a", account, "-w").Output()
+	if err != nil {
+		if notFound(err) {
+			return "", ErrNotFound
+		}
+		return "", fmt.Errorf("failed to read secret from keychain: %w", err)
+	}
+	return strings.TrimSuffix(string(output), "\n"), nil
+}
+
+func remove(account string) error {
+	if err := exec.Command("security", "delete-generic-password", "-s", service, "-a", account).Run(); err != nil {
+		if notFound(err) {
+			return ErrNotFound
+		}
+		return fmt.Errorf("failed to delete secret from keychain: %w", err)
+	}
+	return nil
+}
+
+func notFound(err error) bool {
+	var exitErr *exec.ExitError
+	return errors.As(err, &exitErr) && exitErr.ExitCode() == errSecItemNotFound
+}
@@ -0,0 +1,103 @@
+//go:build windows
+
+package keychain
+
+import (
+	"errors"
+	"fmt"
+	"syscall"
+	"unsafe"
+)
+
+const (
+	credTypeGeneric         = 1
+	credPersistLocalMachine = 2
+	errorNotFound           = syscall.Errno(1168)
+)
+
+var (
+	advapi32       = syscall.NewLazyDLL("advapi32.dll")
+	procCredWrite  = advapi32.NewProc("CredWriteW")
+	procCredRead   = advapi32.NewProc("CredReadW")
+	procCredDelete = advapi32.NewProc("CredDeleteW")
+	procCredFree   = advapi32.NewProc("CredFree")
+)
+
+// credential mirrors the CREDENTIALW structure
+type credential struct {
+	Flags              uint32
+	Type               uint32
+	TargetName         *uint16
+	Comment            *uint16
+	LastWritten        syscall.Filetime
+	CredentialBlobSize uint32
+	CredentialBlob     *byte
+	Persist            uint32
+	AttributeCount     uint32
+	Attributes         uintptr
+	TargetAlias        *uint16
+	UserName           *uint16
+}
+
+func target(account string) (*uint16, error) {
+	return syscall.UTF16PtrFromString(service + ":" + account)
+}
+
+func set(account, secret string) error {
+	name, err := target(account)
+	if err != nil {
+		return err
+	}
+	user, err := syscall.UTF16PtrFromString(account)
+	if err != nil {
+		return err
+	}
+	blob := []byte(secret)
+	cred := credential{
+		Type:               credTypeGeneric,
+		TargetName:         name,
+		CredentialBlobSize: uint32(len(blob)),
+		Persist:            credPersistLocalMachine,
+		UserName:           user,
+	}
+	if len(blob) > 0 {
+		cred.CredentialBlob = &blob[0]
+	}
+	if ret, _, err := procCredWrite.Call(uintptr(unsafe.Pointer(&cred)), 0); ret == 0 {
+		return fmt.Errorf("failed to store secret in credential manager: %w", err)
+	}
+	return nil
+}
+
+func get(account string) (string, error) {
+	name, err := target(account)
+	if err != nil {
+		return "", err
+	}
+	var cred *credential
+	if ret, _, err := procCredRead.Call(uintptr(unsafe.Pointer(name)), credTypeGeneric, 0, uintptr(unsafe.Pointer(&cred))); ret == 0 {
+		if errors.Is(err, errorNotFound) {
+			return "", ErrNotFound
+		}
+		return "", fmt.Errorf("failed to read secret from credential manager: %w", err)
+	}
+	defer procCredFree.Call(uintptr(unsafe.Pointer(cred)))
+	if cred.CredentialBlobSize == 0 {
+		return "", nil
+	}
+	return string(unsafe.Slice(cred.CredentialBlob, cred.CredentialBlobSize)), nil
+}
+
+func remove(account string) error {
+	name, err := target(account)
+	if err != nil {
+		return err
+	}
+	if ret, _, err := procCredDelete.Call(uintptr(unsafe.Pointer(name)), credTypeGeneric, 0); ret == 0 {
+		if errors.Is(err, errorNotFound) {
+			return ErrNotFound
+		}
+		return fmt.Errorf("failed to delete secret from credential manager: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+//go:build !darwin && !windows
+
+package keychain
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// The Secret Service is reached through secret-tool from libsecret, which
+// ships with GNOME Keyring and KWallet setups alike
+
+func set(account, secret string) error {
+	cmd := exec.Command("secret-tool", "store", "--label", service+" "+account, "service", service, "account", account)
+	cmd.Stdin = strings.NewReader(secret)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to store secret in keychain: %v: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+func get(account string) (string, error) {
+	output, err := exec.Command("secret-tool", "lookup", "service", service, "account", account).Output()
+	if err != nil {
+		// secret-tool exits with 1 and prints nothing for a missing item
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 && len(output) == 0 {
+			return "", ErrNotFound
+		}
+		return "", fmt.Errorf("failed to read secret from keychain: %w", err)
+	}
+	return string(output), nil
+}
+
+func remove(account string) error {
+	if _, err := get(account); err != nil {
+		return err
+	}
+	if output, err := exec.Command("secret-tool", "clear", "service", service, "account", account).CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to delete secret from keychain: %v: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
@@ -0,0 +1,213 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// maxRecentEntries is how many records the in-memory viewer keeps
+const maxRecentEntries = 2000
+
+// Entry is a log record kept for the in-app viewer
+type Entry struct {
+	Time      string            `json:"time"` // RFC3339 with milliseconds
+	Level     string            `json:"level"`
+	Component string            `json:"component,omitempty"`
+	Message   string            `json:"message"`
+	Attrs     map[string]string `json:"attrs,omitempty"`
+}
+
+var (
+	level  = new(slog.LevelVar) // shared by every configured handler
+	recent = &ring{}
+)
+
+// ParseLevel parses debug, info, warn or error
+func ParseLevel(name string) (slog.Level, error) {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(name)); err != nil {
+		return 0, fmt.Errorf("unknown log level %q", name)
+	}
+	return l, nil
+}
+
+// SetLevel sets the lowest level that is logged
+func SetLevel(name string) error {
+	l, err := ParseLevel(name)
+	if err != nil {
+		return err
+	}
+	level.Set(l)
+	return nil
+}
+
+// Level returns the lowest level that is logged
+func Level() string {
+	return strings.ToLower(level.Level().String())
+}
+
+// Recent returns up to limit of the newest records at minLevel or above,
+// oldest first
+func Recent(minLevel string, limit int) ([]Entry, error) {
+	min := slog.LevelDebug
+	if minLevel != "" {
+		var err error
+		if min, err = ParseLevel(minLevel); err != nil {
+			return nil, err
+		}
+	}
+	return recent.since(min, limit), nil
+}
+
+// handler writes text records and keeps them for the viewer. Errors and
+// warnings are logged with slog.Error and slog.Warn and a "component"
+// attribute. Lines still logged through the standard log package arrive as
+// attribute-less Info records; their level is inferred from the message as
+// a fallback and a leading "[component]" tag becomes the component.
+type handler struct {
+	text  slog.Handler
+	attrs []slog.Attr
+}
+
+func newHandler(w io.Writer) *handler {
+	return &handler{text: slog.NewTextHandler(w, &slog.HandlerOptions{
+		AddSource:   true,
+		Level:       slog.LevelDebug, // filtered in Handle, after inference
+		ReplaceAttr: shortSource,
+	})}
+}
+
+// shortSource logs file:line like log.Lshortfile
+func shortSource(_ []string, a slog.Attr) slog.Attr {
+	if source, ok := a.Value.Any().(*slog.Source); ok && a.Key == slog.SourceKey {
+		return slog.String(slog.SourceKey, fmt.Sprintf("%s:%d", filepath.Base(source.File), source.Line))
+	}
+	return a
+}
+
+// Enabled lets Info through at any level so bridged lines can be raised
+// to Warn or Error before filtering
+func (h *handler) Enabled(_ context.Context, l slog.Level) bool {
+	return l >= level.Level() || l == slog.LevelInfo
+}
+
+func (h *handler) Handle(ctx context.Context, r slog.Record) error {
+	component, message := splitComponent(r.Message)
+	if r.Level == slog.LevelInfo && r.NumAttrs() == 0 && len(h.attrs) == 0 {
+		r.Level = inferLevel(message)
+	}
+	if r.Level < level.Level() {
+		return nil
+	}
+
+	entry := Entry{
+		Time:      r.Time.Format("2006-01-02T15:04:05.000Z07:00"),
+		Level:     strings.ToLower(r.Level.String()),
+		Component: component,
+		Message:   message,
+	}
+	addAttr := func(a slog.Attr) bool {
+		if a.Key == "component" {
+			entry.Component = a.Value.String()
+			return true
+		}
+		if entry.Attrs == nil {
+			entry.Attrs = make(map[string]string)
+		}
+		entry.Attrs[a.Key] = a.Value.String()
+		return true
+	}
+	for _, a := range h.attrs {
+		addAttr(a)
+	}
+	r.Attrs(addAttr)
+	recent.add(entry)
+
+	if component != "" && r.NumAttrs() == 0 && len(h.attrs) == 0 {
+		out := slog.NewRecord(r.Time, r.Level, message, r.PC)
+		out.AddAttrs(slog.String("component", component))
+		r = out
+	}
+	return h.text.Handle(ctx, r)
+}
+
+func (h *handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &handler{
+		text:  h.text.WithAttrs(attrs),
+		attrs: append(append([]slog.Attr(nil), h.attrs...), attrs...),
+	}
+}
+
+func (h *handler) WithGroup(name string) slog.Handler {
+	return &handler{text: h.text.WithGroup(name), attrs: h.attrs}
+}
+
+var componentPattern = regexp.MustCompile(`^\[([\w.:/-]+)\]\s*`)
+
+// splitComponent separates a leading "[component]" tag from a message
+func splitComponent(message string) (component, rest string) {
+	match := componentPattern.FindStringSubmatchIndex(message)
+	if match == nil {
+		return "", message
+	}
+	return message[match[2]:match[3]], message[match[1]:]
+}
+
+var (
+	errorWords   = regexp.MustCompile(`(?i)\b(error|failed|failure|panic|fatal)\b`)
+	warningWords = regexp.MustCompile(`(?i)\b(warn|warning|unable|cannot|ignoring|skipping|timed out)\b`)
+)
+
+// inferLevel guesses the level of a plain log line; structured records
+// carry their own
+func inferLevel(message string) slog.Level {
+	switch {
+	case errorWords.MatchString(message):
+		return slog.LevelError
+	case warningWords.MatchString(message):
+		return slog.LevelWarn
+	}
+	return slog.LevelInfo
+}
+
+// ring keeps the newest entries
+type ring struct {
+	mu      sync.Mutex
+	entries []Entry
+	next    int
+}
+
+func (r *ring) add(e Entry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.entries) < maxRecentEntries {
+		r.entries = append(r.entries, e)
+		return
+	}
+	r.entries[r.next] = e
+	r.next = (r.next + 1) % maxRecentEntries
+}
+
+func (r *ring) since(min slog.Level, limit int) []Entry {
+	r.mu.Lock()
+	ordered := append(append([]Entry(nil), r.entries[r.next:]...), r.entries[:r.next]...)
+	r.mu.Unlock()
+
+	entries := []Entry{}
+	for i := len(ordered) - 1; i >= 0 && (limit <= 0 || len(entries) < limit); i-- {
+		var l slog.Level
+		if l.UnmarshalText([]byte(ordered[i].Level)) == nil && l >= min {
+			entries = append(entries, ordered[i])
+		}
+	}
+	for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+		entries[i], entries[j] = entries[j], entries[i]
+	}
+	return entries
+}
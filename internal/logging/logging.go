@@ -4,13 +4,26 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"strings"
 	"time"
 )
 
+// ConfigureServerLogging sends the log and log/slog packages to a new
+// rotated file under ~/.ropcode/logs and to stderr
 func ConfigureServerLogging() (string, func(), error) {
+	return configureLogging(os.Stderr)
+}
+
+// ConfigureHeadlessLogging logs to the server log file only, keeping stderr
+// free for the output of headless CLI commands.
+func ConfigureHeadlessLogging() (string, func(), error) {
+	return configureLogging(nil)
+}
+
+func configureLogging(console io.Writer) (string, func(), error) {
 	home, err := os.UserHomeDir()
 	if err != nil {
 		return "", nil, err
@@ -21,22 +34,26 @@ func ConfigureServerLogging() (string, func(), error) {
 		return "", nil, err
 	}
 
-	logName := timestampedServerLogName(time.Now())
-	logPath := filepath.Join(logDir, logName)
-	file, err := os.OpenFile(logPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
-	for suffix := 1; os.IsExist(err); suffix++ {
-		logPath = filepath.Join(logDir, timestampedServerLogNameWithSuffix(logName, suffix))
-		file, err = os.OpenFile(logPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
-	}
+	file, err := openRotatingFile(logDir, timestampedServerLogName(time.Now()), maxLogFileSize)
 	if err != nil {
 		return "", nil, err
 	}
+	logPath := file.Path()
 
-	log.SetFlags(log.LstdFlags | log.Lmicroseconds | log.Lshortfile)
-	log.SetOutput(io.MultiWriter(os.Stderr, file))
+	var out io.Writer = file
+	if console != nil {
+		out = io.MultiWriter(console, file)
+	}
+	// Flags are read by slog.SetDefault to decide whether lines from the
+	// log package carry their source location
+	log.SetFlags(log.Lshortfile)
+	previous := slog.Default()
+	slog.SetDefault(slog.New(newHandler(out)))
 
 	cleanup := func() {
+		slog.SetDefault(previous)
 		log.SetOutput(os.Stderr)
+		log.SetFlags(log.LstdFlags)
 		_ = file.Close()
 	}
 
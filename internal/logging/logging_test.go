@@ -2,6 +2,7 @@ package logging
 
 import (
 	"log"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"regexp"
@@ -68,3 +69,98 @@ func TestConfigureServerLoggingCreatesNewFileForEachStartup(t *testing.T) {
 		t.Fatalf("second log file missing: %v", err)
 	}
 }
+
+func TestHandlerInfersLevelsAndComponents(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("USERPROFILE", home)
+	t.Setenv("HOME", home)
+	t.Cleanup(func() { SetLevel("info") })
+
+	logPath, cleanup, err := ConfigureHeadlessLogging()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+
+	log.Printf("[usage-quota] check failed: %v", "boom")
+	log.Printf("[mcp] ignoring invalid config")
+	log.Print("plain startup line")
+	if err := SetLevel("warn"); err != nil {
+		t.Fatal(err)
+	}
+	log.Print("quiet line after raising the level")
+
+	entries, err := Recent("warn", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tail []Entry
+	for _, e := range entries {
+		if e.Component == "usage-quota" || e.Component == "mcp" {
+			tail = append(tail, e)
+		}
+	}
+	if len(tail) != 2 || tail[0].Level != "error" || tail[0].Message != "check failed: boom" || tail[1].Level != "warn" {
+		t.Fatalf("Recent(warn) = %+v", entries)
+	}
+
+	content, _ := os.ReadFile(logPath)
+	if !strings.Contains(string(content), "component=usage-quota") || strings.Contains(string(content), "quiet line after raising the level") {
+		t.Errorf("unexpected log file content %q", content)
+	}
+	if err := SetLevel("verbose"); err == nil {
+		t.Error("Expected an unknown level to be rejected")
+	}
+}
+
+func TestStructuredRecordsKeepTheirLevel(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("USERPROFILE", home)
+	t.Setenv("HOME", home)
+
+	_, cleanup, err := ConfigureHeadlessLogging()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+
+	slog.Info("retry failed over to the next config", "component", "failover-info")
+	slog.Warn("check failed", "component", "usage-quota-warn", "error", "boom")
+
+	entries, err := Recent("info", maxRecentEntries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	levels := map[string]string{}
+	for _, e := range entries {
+		levels[e.Component] = e.Level
+	}
+	if levels["failover-info"] != "info" || levels["usage-quota-warn"] != "warn" {
+		t.Fatalf("levels = %v, want the levels the records were logged at", levels)
+	}
+}
+
+func TestRotatingFileRotatesAndPrunes(t *testing.T) {
+	dir := t.TempDir()
+	for i := 0; i < maxLogFiles; i++ {
+		os.WriteFile(filepath.Join(dir, strings.Repeat("a", i+1)+".log"), nil, 0644)
+	}
+	file, err := openRotatingFile(dir, "ropcode-server-test.log", 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	file.Write([]byte("first line\n"))
+	file.Write([]byte("second line\n"))
+	if filepath.Base(file.Path()) != "ropcode-server-test-1.log" {
+		t.Errorf("Expected a rotation past the size limit, writing %s", file.Path())
+	}
+	logs, _ := filepath.Glob(filepath.Join(dir, "*.log"))
+	if len(logs) != maxLogFiles {
+		t.Errorf("Expected %d log files kept, got %d", maxLogFiles, len(logs))
+	}
+	if _, err := os.Stat(filepath.Join(dir, "ropcode-server-test-1.log")); err != nil {
+		t.Errorf("Expected the current file to survive pruning: %v", err)
+	}
+}
@@ -0,0 +1,138 @@
+package logging
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	// maxLogFileSize is the size at which a log file is rotated
+	maxLogFileSize = 10 << 20
+	// maxLogFiles is how many log files the log directory keeps
+	maxLogFiles = 20
+)
+
+// rotatingFile writes to a log file, continuing in a numbered sibling once
+// the file reaches maxSize and pruning the oldest files of the directory
+type rotatingFile struct {
+	mu      sync.Mutex
+	dir     string
+	name    string // first file; rotations add a -N suffix
+	maxSize int64
+	file    *os.File
+	size    int64
+	suffix  int
+}
+
+// openRotatingFile creates a new log file named name in dir, adding a
+// suffix when one already exists
+func openRotatingFile(dir, name string, maxSize int64) (*rotatingFile, error) {
+	r := &rotatingFile{dir: dir, name: name, maxSize: maxSize}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	pruneLogFiles(dir, maxLogFiles)
+	return r, nil
+}
+
+func (r *rotatingFile) open() error {
+	path := filepath.Join(r.dir, r.fileName())
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	for os.IsExist(err) {
+		r.suffix++
+		path = filepath.Join(r.dir, r.fileName())
+		file, err = os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	}
+	if err != nil {
+		return err
+	}
+	r.file, r.size = file, 0
+	return nil
+}
+
+func (r *rotatingFile) fileName() string {
+	if r.suffix == 0 {
+		return r.name
+	}
+	return timestampedServerLogNameWithSuffix(r.name, r.suffix)
+}
+
+// Path returns the file being written
+func (r *rotatingFile) Path() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.file.Name()
+}
+
+func (r *rotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file == nil {
+		return 0, os.ErrClosed
+	}
+	if r.size > 0 && r.size+int64(len(p)) > r.maxSize {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+func (r *rotatingFile) rotate() error {
+	if err := r.file.Close(); err != nil {
+		return err
+	}
+	r.suffix++
+	if err := r.open(); err != nil {
+		r.file = nil
+		return err
+	}
+	pruneLogFiles(r.dir, maxLogFiles)
+	return nil
+}
+
+func (r *rotatingFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file == nil {
+		return nil
+	}
+	err := r.file.Close()
+	r.file = nil
+	return err
+}
+
+// pruneLogFiles deletes the oldest .log files of dir beyond keep
+func pruneLogFiles(dir string, keep int) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	type logFile struct {
+		path    string
+		modTime int64
+	}
+	var files []logFile
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".log") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, logFile{filepath.Join(dir, entry.Name()), info.ModTime().UnixNano()})
+	}
+	if len(files) <= keep {
+		return
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].modTime > files[j].modTime })
+	for _, f := range files[keep:] {
+		_ = os.Remove(f.path)
+	}
+}
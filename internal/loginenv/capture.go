@@ -0,0 +1,66 @@
+//go:build !windows
+
+package loginenv
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"log/slog"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// capture runs shell as an interactive login shell, which reads both the
+// profile and the rc file, and records the environment it ends up with
+func capture(shell string) *Snapshot {
+	if shell == "" {
+		shell = "/bin/sh"
+	}
+	started := time.Now()
+	snapshot := &Snapshot{Shell: shell, CapturedAt: started}
+
+	ctx, cancel := context.WithTimeout(context.Background(), captureTimeout)
+	defer cancel()
+	script := fmt.Sprintf("echo %s; env; echo %s", marker, marker)
+	if strings.HasSuffix(filepath.Base(shell), "fish") {
+		script = fmt.Sprintf("echo %s; and env; and echo %s", marker, marker)
+	}
+	cmd := exec.CommandContext(ctx, shell, "-l", "-i", "-c", script)
+	// A session of its own keeps the interactive shell off the terminal
+	// ropcode may have been started from
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+	cmd.WaitDelay = time.Second
+	output, err := cmd.Output()
+	snapshot.DurationMs = time.Since(started).Milliseconds()
+
+	vars := parse(string(output))
+	if vars == nil {
+		if err == nil {
+			err = fmt.Errorf("no environment in the shell's output")
+		}
+		snapshot.Error = err.Error()
+		slog.Warn("capturing the login environment failed", "component", "loginenv", "shell", shell, "error", err)
+		return snapshot
+	}
+	snapshot.vars = vars
+	snapshot.Variables = len(vars)
+	log.Printf("[loginenv] captured %d variables from %s in %dms", len(vars), shell, snapshot.DurationMs)
+	return snapshot
+}
+
+// launchctlGetenv returns a variable set with launchctl setenv on macOS
+func launchctlGetenv(name string) string {
+	if runtime.GOOS != "darwin" {
+		return ""
+	}
+	output, err := exec.Command("launchctl", "getenv", name).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSuffix(string(output), "\n")
+}
@@ -0,0 +1,15 @@
+//go:build windows
+
+package loginenv
+
+import "time"
+
+// capture records nothing: Windows apps get the user's environment from
+// the registry however they are started
+func capture(shell string) *Snapshot {
+	return &Snapshot{Shell: shell, CapturedAt: time.Now()}
+}
+
+func launchctlGetenv(name string) string {
+	return ""
+}
@@ -0,0 +1,211 @@
+// Package loginenv captures the environment the user's login shell sets up.
+// Apps launched from the Dock or a desktop menu inherit a bare environment
+// without the PATH and API keys of the user's profile, so ropcode runs the
+// login shell once, keeps what it printed and hands it to every provider
+// session, terminal and command instead of asking a shell each time.
+package loginenv
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// captureTimeout bounds how long a slow profile can hold up a capture
+const captureTimeout = 10 * time.Second
+
+// marker brackets the env output, so what the profile prints is skipped
+const marker = "__ROPCODE_LOGIN_ENV__"
+
+// skipped are shell bookkeeping variables that don't describe the
+// environment
+var skipped = map[string]bool{"_": true, "PWD": true, "OLDPWD": true, "SHLVL": true}
+
+// Snapshot is a captured login environment
+type Snapshot struct {
+	Shell      string    `json:"shell,omitempty"`
+	CapturedAt time.Time `json:"captured_at"`
+	DurationMs int64     `json:"duration_ms"`
+	// Variables is how many variables were captured; their values may hold
+	// secrets and are not exposed
+	Variables int `json:"variables"`
+	// Error says why nothing was captured
+	Error string `json:"error,omitempty"`
+
+	vars map[string]string
+}
+
+var (
+	mu       sync.Mutex
+	current  *Snapshot
+	pending  chan struct{} // closed when the running capture is done
+	launchd  = make(map[string]string)
+	launchMu sync.Mutex
+)
+
+// Capture starts capturing shell's login environment in the background;
+// the cached snapshot is replaced once it is done
+func Capture(shell string) {
+	mu.Lock()
+	defer mu.Unlock()
+	if pending != nil {
+		return
+	}
+	pending = make(chan struct{})
+	go finish(shell, pending)
+}
+
+// Reload captures shell's login environment again and waits for it
+func Reload(shell string) *Snapshot {
+	mu.Lock()
+	done := pending
+	if done == nil {
+		done = make(chan struct{})
+		pending = done
+		go finish(shell, done)
+	}
+	mu.Unlock()
+	<-done
+
+	launchMu.Lock()
+	launchd = make(map[string]string)
+	launchMu.Unlock()
+	return Current()
+}
+
+func finish(shell string, done chan struct{}) {
+	snapshot := capture(shell)
+	mu.Lock()
+	current = snapshot
+	pending = nil
+	mu.Unlock()
+	close(done)
+}
+
+// Current returns the cached snapshot; before the first capture is done it
+// waits for it, starting one with $SHELL when none was
+func Current() *Snapshot {
+	mu.Lock()
+	snapshot, done := current, pending
+	mu.Unlock()
+	if snapshot != nil {
+		return snapshot
+	}
+	if done == nil {
+		Capture(os.Getenv("SHELL"))
+		mu.Lock()
+		done = pending
+		mu.Unlock()
+	}
+	if done != nil {
+		<-done
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	return current
+}
+
+// Environ returns the process environment with the login environment's
+// variables the process lacks added and the login PATH merged in
+func Environ() []string {
+	snapshot := Current()
+	env := os.Environ()
+	have := make(map[string]bool, len(env))
+	for _, entry := range env {
+		name, _, _ := strings.Cut(entry, "=")
+		have[name] = true
+	}
+	for name, value := range snapshot.vars {
+		if !have[name] {
+			env = append(env, name+"="+value)
+		}
+	}
+	return mergePATH(env, snapshot.vars["PATH"])
+}
+
+// MergePATH puts the login PATH's directories ahead of env's own
+func MergePATH(env []string) []string {
+	return mergePATH(env, Current().vars["PATH"])
+}
+
+func mergePATH(env []string, loginPATH string) []string {
+	if loginPATH == "" {
+		return env
+	}
+	index, processPATH := -1, ""
+	for i, entry := range env {
+		if strings.HasPrefix(entry, "PATH=") {
+			index, processPATH = i, entry[len("PATH="):]
+			break
+		}
+	}
+	dirs := filepath.SplitList(loginPATH)
+	seen := make(map[string]bool, len(dirs))
+	for _, dir := range dirs {
+		seen[dir] = true
+	}
+	for _, dir := range filepath.SplitList(processPATH) {
+		if dir != "" && !seen[dir] {
+			seen[dir] = true
+			dirs = append(dirs, dir)
+		}
+	}
+	merged := "PATH=" + strings.Join(dirs, string(os.PathListSeparator))
+	if index < 0 {
+		return append(env, merged)
+	}
+	out := append([]string(nil), env...)
+	out[index] = merged
+	return out
+}
+
+// Lookup returns a variable of the process or login environment, or,
+// on macOS, one set with launchctl setenv
+func Lookup(name string) (string, bool) {
+	if value, ok := os.LookupEnv(name); ok && value != "" {
+		return value, true
+	}
+	if value := Current().vars[name]; value != "" {
+		return value, true
+	}
+	launchMu.Lock()
+	value, asked := launchd[name]
+	launchMu.Unlock()
+	if !asked {
+		value = launchctlGetenv(name)
+		launchMu.Lock()
+		launchd[name] = value
+		launchMu.Unlock()
+	}
+	return value, value != ""
+}
+
+// parse reads the env output between the markers; a line without =
+// continues the value before it
+func parse(output string) map[string]string {
+	start := strings.Index(output, marker)
+	end := strings.LastIndex(output, marker)
+	if start < 0 || end <= start {
+		return nil
+	}
+	body := strings.Trim(output[start+len(marker):end], "\n")
+	vars := make(map[string]string)
+	last := ""
+	for _, line := range strings.Split(body, "\n") {
+		name, value, ok := strings.Cut(line, "=")
+		if !ok || name == "" || strings.ContainsAny(name, " \t") {
+			if last != "" {
+				vars[last] += "\n" + line
+			}
+			continue
+		}
+		last = name
+		vars[name] = value
+	}
+	for name := range skipped {
+		delete(vars, name)
+	}
+	return vars
+}
@@ -0,0 +1,76 @@
+package loginenv
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestParseSkipsProfileOutputAndJoinsMultilineValues(t *testing.T) {
+	output := "Welcome back!\n" + marker + "\nHOME=/home/me\nGREETING=line one\nline two\nSHLVL=2\n" + marker + "\n"
+	vars := parse(output)
+	if vars["HOME"] != "/home/me" || vars["GREETING"] != "line one\nline two" {
+		t.Errorf("parse() = %q", vars)
+	}
+	if _, ok := vars["SHLVL"]; ok {
+		t.Error("shell bookkeeping variables should be skipped")
+	}
+	if parse("no markers here") != nil {
+		t.Error("parse() without markers should find nothing")
+	}
+}
+
+func TestMergePATHPutsLoginDirectoriesFirst(t *testing.T) {
+	sep := string(os.PathListSeparator)
+	env := []string{"HOME=/home/me", "PATH=/usr/bin" + sep + "/bin"}
+	merged := mergePATH(env, "/opt/homebrew/bin"+sep+"/usr/bin")
+	if want := "PATH=/opt/homebrew/bin" + sep + "/usr/bin" + sep + "/bin"; merged[1] != want {
+		t.Errorf("PATH = %q, want %q", merged[1], want)
+	}
+	if env[1] != "PATH=/usr/bin"+sep+"/bin" {
+		t.Error("mergePATH() should not change its argument")
+	}
+	if got := mergePATH([]string{"HOME=/home/me"}, "/opt/bin"); len(got) != 2 || got[1] != "PATH=/opt/bin" {
+		t.Errorf("mergePATH() without a PATH = %q", got)
+	}
+}
+
+func TestReloadCapturesLoginShellEnvironment(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("no login shell on Windows")
+	}
+	// Stands in for a login shell whose profile prints a banner and exports
+	// a variable; the script to run is its fourth argument
+	shell := filepath.Join(t.TempDir(), "fakesh")
+	script := `#!/bin/sh
+echo "Last login: today"
+export ROPCODE_LOGIN_TEST=from-profile
+export PATH="/opt/login/bin:$PATH"
+exec /bin/sh -c "$4"
+`
+	if err := os.WriteFile(shell, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	snapshot := Reload(shell)
+	if snapshot.Error != "" || snapshot.Shell != shell || snapshot.Variables == 0 {
+		t.Fatalf("Reload() = %+v", snapshot)
+	}
+	if Current() != snapshot {
+		t.Error("Current() should return the cached snapshot")
+	}
+	env := strings.Join(Environ(), "\n")
+	if !strings.Contains(env, "ROPCODE_LOGIN_TEST=from-profile") || !strings.Contains(env, "PATH=/opt/login/bin") {
+		t.Errorf("Environ() lacks the login environment:\n%s", env)
+	}
+	if value, ok := Lookup("ROPCODE_LOGIN_TEST"); !ok || value != "from-profile" {
+		t.Errorf("Lookup() = %q, %v", value, ok)
+	}
+
+	broken := Reload(filepath.Join(t.TempDir(), "missing"))
+	if broken.Error == "" {
+		t.Errorf("Reload() with a missing shell = %+v, want an error", broken)
+	}
+}
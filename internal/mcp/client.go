@@ -0,0 +1,266 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+)
+
+// ProtocolVersion is the MCP protocol revision sent during initialization
+const ProtocolVersion = "2024-11-05"
+
+// ErrClientClosed is returned for calls on a client whose connection is gone
+var ErrClientClosed = errors.New("mcp connection closed")
+
+// ServerInfo identifies an MCP server implementation
+type ServerInfo struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// InitializeResult is the server's answer to the initialize request
+type InitializeResult struct {
+	ProtocolVersion string                 `json:"protocolVersion"`
+	Capabilities    map[string]interface{} `json:"capabilities"`
+	ServerInfo      ServerInfo             `json:"serverInfo"`
+	Instructions    string                 `json:"instructions,omitempty"`
+}
+
+// RPCError is a JSON-RPC error returned by the server
+type RPCError struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("mcp error %d: %s", e.Code, e.Message)
+}
+
+type rpcMessage struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *RPCError       `json:"error,omitempty"`
+}
+
+type rpcRequest struct {
+	JSONRPC string      `json:"jsonrpc"`
+	ID      *int64      `json:"id,omitempty"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params,omitempty"`
+}
+
+type rpcReply struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *RPCError       `json:"error,omitempty"`
+}
+
+// Client speaks MCP (JSON-RPC 2.0) with a server over newline-delimited
+// messages, as used by the stdio transport.
+type Client struct {
+	send   func([]byte) error
+	closer func() error
+
+	mu      sync.Mutex
+	nextID  int64
+	pending map[int64]chan *rpcMessage
+	closed  chan struct{}
+	err     error
+}
+
+// NewStdioClient creates a client that writes requests to w and reads
+// responses from r until r is exhausted.
+func NewStdioClient(r io.Reader, w io.Writer) *Client {
+	var writeMu sync.Mutex
+	c := newClient(func(data []byte) error {
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		_, err := w.Write(append(data, '\n'))
+		return err
+	})
+	go c.readLoop(r)
+	return c
+}
+
+func newClient(send func([]byte) error) *Client {
+	return &Client{
+		send:    send,
+		pending: make(map[int64]chan *rpcMessage),
+		closed:  make(chan struct{}),
+	}
+}
+
+func (c *Client) readLoop(r io.Reader) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		c.handleMessage(line)
+	}
+	err := scanner.Err()
+	if err == nil {
+		err = io.EOF
+	}
+	c.close(err)
+}
+
+// handleMessage dispatches one message received from the server.
+func (c *Client) handleMessage(data []byte) {
+	var msg rpcMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return // not JSON-RPC, e.g. log output on stdout
+	}
+
+	if msg.Method != "" {
+		if len(msg.ID) == 0 {
+			return // notification
+		}
+		// Requests from the server: answer pings, reject everything else.
+		reply := rpcReply{JSONRPC: "2.0", ID: msg.ID}
+		if msg.Method == "ping" {
+			reply.Result = struct{}{}
+		} else {
+			reply.Error = &RPCError{Code: -32601, Message: "method not found: " + msg.Method}
+		}
+		if data, err := json.Marshal(reply); err == nil {
+			c.send(data)
+		}
+		return
+	}
+
+	id, err := strconv.ParseInt(string(msg.ID), 10, 64)
+	if err != nil {
+		return
+	}
+	c.mu.Lock()
+	ch, ok := c.pending[id]
+	delete(c.pending, id)
+	c.mu.Unlock()
+	if ok {
+		ch <- &msg
+	}
+}
+
+// close fails all pending calls with err.
+func (c *Client) close(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	select {
+	case <-c.closed:
+		return
+	default:
+	}
+	c.err = err
+	close(c.closed)
+	c.pending = make(map[int64]chan *rpcMessage)
+}
+
+// Close closes the connection and releases the transport, e.g. stops a
+// server process started by Connect
+func (c *Client) Close() error {
+	c.close(ErrClientClosed)
+	if c.closer != nil {
+		return c.closer()
+	}
+	return nil
+}
+
+// Done is closed when the connection to the server is lost
+func (c *Client) Done() <-chan struct{} {
+	return c.closed
+}
+
+// Call sends a request and decodes the result into result, which may be nil
+func (c *Client) Call(ctx context.Context, method string, params, result interface{}) error {
+	c.mu.Lock()
+	select {
+	case <-c.closed:
+		c.mu.Unlock()
+		return ErrClientClosed
+	default:
+	}
+	c.nextID++
+	id := c.nextID
+	ch := make(chan *rpcMessage, 1)
+	c.pending[id] = ch
+	c.mu.Unlock()
+
+	data, err := json.Marshal(rpcRequest{JSONRPC: "2.0", ID: &id, Method: method, Params: params})
+	if err != nil {
+		c.forget(id)
+		return err
+	}
+	if err := c.send(data); err != nil {
+		c.forget(id)
+		return fmt.Errorf("failed to send %s: %w", method, err)
+	}
+
+	select {
+	case msg := <-ch:
+		if msg.Error != nil {
+			return msg.Error
+		}
+		if result == nil || len(msg.Result) == 0 {
+			return nil
+		}
+		if err := json.Unmarshal(msg.Result, result); err != nil {
+			return fmt.Errorf("invalid %s result: %w", method, err)
+		}
+		return nil
+	case <-c.closed:
+		return ErrClientClosed
+	case <-ctx.Done():
+		c.forget(id)
+		return ctx.Err()
+	}
+}
+
+func (c *Client) forget(id int64) {
+	c.mu.Lock()
+	delete(c.pending, id)
+	c.mu.Unlock()
+}
+
+// Notify sends a notification, which has no response
+func (c *Client) Notify(method string, params interface{}) error {
+	data, err := json.Marshal(rpcRequest{JSONRPC: "2.0", Method: method, Params: params})
+	if err != nil {
+		return err
+	}
+	return c.send(data)
+}
+
+// Initialize performs the MCP initialize handshake
+func (c *Client) Initialize(ctx context.Context) (*InitializeResult, error) {
+	params := map[string]interface{}{
+		"protocolVersion": ProtocolVersion,
+		"capabilities":    map[string]interface{}{},
+		"clientInfo":      map[string]string{"name": "ropcode", "version": "1.0.0"},
+	}
+	var result InitializeResult
+	if err := c.Call(ctx, "initialize", params, &result); err != nil {
+		return nil, fmt.Errorf("initialize failed: %w", err)
+	}
+	if err := c.Notify("notifications/initialized", nil); err != nil {
+		return nil, fmt.Errorf("initialize failed: %w", err)
+	}
+	return &result, nil
+}
+
+// Ping checks that the server still answers requests
+func (c *Client) Ping(ctx context.Context) error {
+	return c.Call(ctx, "ping", nil, nil)
+}
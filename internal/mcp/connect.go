@@ -0,0 +1,323 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// serverCommand builds the command that runs a stdio server.
+func serverCommand(server *MCPServer) *exec.Cmd {
+	cmd := exec.Command(server.Command, server.Args...)
+	cmd.Env = os.Environ()
+	for key, value := range server.Env {
+		cmd.Env = append(cmd.Env, key+"="+value)
+	}
+	return cmd
+}
+
+// Connect opens a short-lived, initialized connection to a server: stdio
+// servers are started as a child process that is stopped by Close, remote
+// servers are reached over the SSE or streamable HTTP transport.
+func Connect(ctx context.Context, server *MCPServer) (*Client, *InitializeResult, error) {
+	var client *Client
+	var err error
+	switch {
+	case server.Transport == TransportHTTP:
+		client, err = DialHTTP(server.URL, server.Headers)
+	case server.Transport == TransportSSE || (server.Command == "" && server.URL != ""):
+		client, err = DialSSE(ctx, server.URL, server.Headers)
+	case server.Command != "":
+		client, err = startStdio(server)
+	default:
+		err = fmt.Errorf("server %s has no command or URL configured", server.Name)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	result, err := client.Initialize(ctx)
+	if err != nil {
+		client.Close()
+		return nil, nil, err
+	}
+	return client, result, nil
+}
+
+// startStdio starts a stdio server that is not supervised.
+func startStdio(server *MCPServer) (*Client, error) {
+	cmd := serverCommand(server)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	stderr := &stderrTail{}
+	cmd.Stderr = stderr
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start %s: %w", server.Command, err)
+	}
+
+	exited := make(chan struct{})
+	go func() {
+		cmd.Wait()
+		close(exited)
+	}()
+
+	client := NewStdioClient(stdout, stdin)
+	client.closer = func() error {
+		// Closing stdin asks a well-behaved server to exit.
+		stdin.Close()
+		select {
+		case <-exited:
+		case <-time.After(2 * time.Second):
+			cmd.Process.Kill()
+			<-exited
+		}
+		return nil
+	}
+	return client, nil
+}
+
+// DialSSE connects to a server using the HTTP+SSE transport: responses arrive
+// on an event stream and requests are POSTed to the endpoint the server
+// announces in its first "endpoint" event.
+func DialSSE(ctx context.Context, rawURL string, headers map[string]string) (*Client, error) {
+	base, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid server URL: %w", err)
+	}
+
+	streamCtx, cancel := context.WithCancel(context.Background())
+	req, err := http.NewRequestWithContext(streamCtx, "GET", rawURL, nil)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	setHeaders(req, headers)
+	req.Header.Set("Accept", "text/event-stream")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("failed to connect to %s: %w", rawURL, err)
+	}
+	if resp.StatusCode != http.StatusOK || !strings.Contains(resp.Header.Get("Content-Type"), "text/event-stream") {
+		resp.Body.Close()
+		cancel()
+		return nil, fmt.Errorf("%s is not an MCP SSE endpoint (HTTP %d, %s)", rawURL, resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+
+	httpClient := &http.Client{Timeout: 60 * time.Second}
+	var endpoint string
+	client := newClient(func(data []byte) error {
+		req, err := http.NewRequest("POST", endpoint, bytes.NewReader(data))
+		if err != nil {
+			return err
+		}
+		setHeaders(req, headers)
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return httpStatusError(resp)
+		}
+		io.Copy(io.Discard, resp.Body)
+		return nil
+	})
+	client.closer = func() error {
+		cancel()
+		return nil
+	}
+
+	endpoints := make(chan string, 1)
+	go func() {
+		defer resp.Body.Close()
+		err := readSSE(resp.Body, func(event, data string) {
+			switch event {
+			case "endpoint":
+				ref, err := url.Parse(strings.TrimSpace(data))
+				if err != nil {
+					return
+				}
+				select {
+				case endpoints <- base.ResolveReference(ref).String():
+				default:
+				}
+			case "message":
+				client.handleMessage([]byte(data))
+			}
+		})
+		if err == nil {
+			err = io.EOF
+		}
+		client.close(err)
+	}()
+
+	select {
+	case endpoint = <-endpoints:
+		return client, nil
+	case <-client.Done():
+		cancel()
+		return nil, fmt.Errorf("event stream closed before the server announced its endpoint")
+	case <-ctx.Done():
+		client.Close()
+		return nil, ctx.Err()
+	}
+}
+
+// sessionHeader carries the session ID of the streamable HTTP transport.
+const sessionHeader = "Mcp-Session-Id"
+
+// DialHTTP returns a client for a server using the streamable HTTP transport:
+// every message is POSTed to the server URL, which answers with either a JSON
+// body or an event stream carrying the response.
+func DialHTTP(rawURL string, headers map[string]string) (*Client, error) {
+	if _, err := url.Parse(rawURL); err != nil {
+		return nil, fmt.Errorf("invalid server URL: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var sessionMu sync.Mutex
+	sessionID := ""
+
+	var client *Client
+	client = newClient(func(data []byte) error {
+		req, err := http.NewRequestWithContext(ctx, "POST", rawURL, bytes.NewReader(data))
+		if err != nil {
+			return err
+		}
+		setHeaders(req, headers)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json, text/event-stream")
+		sessionMu.Lock()
+		if sessionID != "" {
+			req.Header.Set(sessionHeader, sessionID)
+		}
+		sessionMu.Unlock()
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			defer resp.Body.Close()
+			return httpStatusError(resp)
+		}
+		if id := resp.Header.Get(sessionHeader); id != "" {
+			sessionMu.Lock()
+			sessionID = id
+			sessionMu.Unlock()
+		}
+
+		if strings.Contains(resp.Header.Get("Content-Type"), "text/event-stream") {
+			// The response arrives on the stream; read it without blocking
+			// the caller, which waits for the reply itself.
+			go func() {
+				defer resp.Body.Close()
+				readSSE(resp.Body, func(event, data string) {
+					if event == "message" {
+						client.handleMessage([]byte(data))
+					}
+				})
+			}()
+			return nil
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return err
+		}
+		if len(bytes.TrimSpace(body)) > 0 {
+			client.handleMessage(body)
+		}
+		return nil
+	})
+	client.closer = func() error {
+		defer cancel()
+		sessionMu.Lock()
+		id := sessionID
+		sessionMu.Unlock()
+		if id == "" {
+			return nil
+		}
+		// Tell the server the session is over; servers may not support it.
+		req, err := http.NewRequest("DELETE", rawURL, nil)
+		if err != nil {
+			return nil
+		}
+		setHeaders(req, headers)
+		req.Header.Set(sessionHeader, id)
+		httpClient := &http.Client{Timeout: 5 * time.Second}
+		if resp, err := httpClient.Do(req); err == nil {
+			resp.Body.Close()
+		}
+		return nil
+	}
+	return client, nil
+}
+
+// setHeaders adds the configured headers, e.g. Authorization, to a request.
+func setHeaders(req *http.Request, headers map[string]string) {
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+}
+
+// httpStatusError describes a failed HTTP response, including the start of
+// its body.
+func httpStatusError(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	if text := strings.TrimSpace(string(body)); text != "" {
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, text)
+	}
+	return fmt.Errorf("HTTP %d", resp.StatusCode)
+}
+
+// readSSE parses a server-sent event stream and calls dispatch per event.
+func readSSE(r io.Reader, dispatch func(event, data string)) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	event := ""
+	var data []string
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			if len(data) > 0 {
+				if event == "" {
+					event = "message"
+				}
+				dispatch(event, strings.Join(data, "\n"))
+			}
+			event = ""
+			data = nil
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			continue // comment / keep-alive
+		}
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "event":
+			event = value
+		case "data":
+			data = append(data, value)
+		}
+	}
+	return scanner.Err()
+}
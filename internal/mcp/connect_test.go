@@ -0,0 +1,231 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadSSE(t *testing.T) {
+	stream := ": keep-alive\n\nevent: endpoint\ndata: /messages?session=1\n\ndata: {\"a\":1}\ndata: {\"b\":2}\n\n"
+	var events []string
+	if err := readSSE(strings.NewReader(stream), func(event, data string) {
+		events = append(events, event+"|"+data)
+	}); err != nil {
+		t.Fatalf("readSSE failed: %v", err)
+	}
+	want := []string{"endpoint|/messages?session=1", "message|{\"a\":1}\n{\"b\":2}"}
+	if len(events) != len(want) || events[0] != want[0] || events[1] != want[1] {
+		t.Errorf("Got events %q, want %q", events, want)
+	}
+}
+
+// helperReply answers a JSON-RPC request like the stdio helper server; nil
+// means the message was a notification.
+func helperReply(t *testing.T, body []byte) []byte {
+	var req struct {
+		ID     *int64          `json:"id"`
+		Method string          `json:"method"`
+		Params json.RawMessage `json:"params"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		t.Errorf("Invalid request %q: %v", body, err)
+		return nil
+	}
+	if req.ID == nil {
+		return nil
+	}
+	reply := map[string]interface{}{"jsonrpc": "2.0", "id": *req.ID}
+	if req.Method == "initialize" {
+		reply["result"] = map[string]interface{}{
+			"protocolVersion": ProtocolVersion,
+			"serverInfo":      map[string]string{"name": "remote-helper", "version": "1.0"},
+		}
+	} else if result, ok := helperToolResult(req.Method, req.Params); ok {
+		reply["result"] = result
+	} else {
+		reply["error"] = map[string]interface{}{"code": -32601, "message": "method not found"}
+	}
+	data, _ := json.Marshal(reply)
+	return data
+}
+
+// requireToken rejects requests without the test bearer token.
+func requireToken(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// newSSEServer serves the HTTP+SSE transport, answering requests with the
+// same behaviour as the stdio helper server.
+func newSSEServer(t *testing.T) *httptest.Server {
+	messages := make(chan []byte, 16)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/sse", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		flusher := w.(http.Flusher)
+		fmt.Fprint(w, "event: endpoint\ndata: /messages\n\n")
+		flusher.Flush()
+		for {
+			select {
+			case msg := <-messages:
+				fmt.Fprintf(w, "event: message\ndata: %s\n\n", msg)
+				flusher.Flush()
+			case <-r.Context().Done():
+				return
+			}
+		}
+	})
+	mux.HandleFunc("/messages", requireToken(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusAccepted)
+		if reply := helperReply(t, body); reply != nil {
+			messages <- reply
+		}
+	}))
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestConnect_SSE(t *testing.T) {
+	server := newSSEServer(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	client, info, err := Connect(ctx, &MCPServer{
+		Name:      "remote",
+		Transport: TransportSSE,
+		URL:       server.URL + "/sse",
+		Headers:   map[string]string{"Authorization": "Bearer secret"},
+	})
+	if err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer client.Close()
+	if info.ServerInfo.Name != "remote-helper" {
+		t.Errorf("Unexpected server info: %#v", info.ServerInfo)
+	}
+
+	tools, err := client.ListTools(ctx)
+	if err != nil {
+		t.Fatalf("ListTools failed: %v", err)
+	}
+	if len(tools) != 2 {
+		t.Fatalf("Expected 2 tools, got %#v", tools)
+	}
+	result, err := client.CallTool(ctx, "echo", json.RawMessage(`{"text":"over sse"}`))
+	if err != nil {
+		t.Fatalf("CallTool failed: %v", err)
+	}
+	if len(result.Content) != 1 || result.Content[0].Text != "over sse" {
+		t.Errorf("Unexpected echo result: %#v", result)
+	}
+}
+
+func TestDialSSE_RejectsNonEventStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte("{}"))
+	}))
+	defer server.Close()
+	if _, err := DialSSE(context.Background(), server.URL, nil); err == nil {
+		t.Fatal("Expected error for a non-SSE endpoint")
+	}
+}
+
+// newHTTPServer serves the streamable HTTP transport. Tool calls are answered
+// on an event stream, everything else with a JSON body.
+func newHTTPServer(t *testing.T, sessions *[]string) *httptest.Server {
+	server := httptest.NewServer(requireToken(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "DELETE" {
+			*sessions = append(*sessions, "deleted:"+r.Header.Get(sessionHeader))
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		if strings.Contains(string(body), `"initialize"`) {
+			w.Header().Set(sessionHeader, "session-1")
+		} else {
+			*sessions = append(*sessions, r.Header.Get(sessionHeader))
+		}
+		reply := helperReply(t, body)
+		if reply == nil {
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		if strings.Contains(string(body), `"tools/call"`) {
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprintf(w, "event: message\ndata: %s\n\n", reply)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(reply)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestConnect_StreamableHTTP(t *testing.T) {
+	var sessions []string
+	server := newHTTPServer(t, &sessions)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	client, info, err := Connect(ctx, &MCPServer{
+		Name:      "remote",
+		Transport: TransportHTTP,
+		URL:       server.URL,
+		Headers:   map[string]string{"Authorization": "Bearer secret"},
+	})
+	if err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	if info.ServerInfo.Name != "remote-helper" {
+		t.Errorf("Unexpected server info: %#v", info.ServerInfo)
+	}
+
+	tools, err := client.ListTools(ctx)
+	if err != nil {
+		t.Fatalf("ListTools failed: %v", err)
+	}
+	if len(tools) != 2 {
+		t.Fatalf("Expected 2 tools, got %#v", tools)
+	}
+	result, err := client.CallTool(ctx, "echo", json.RawMessage(`{"text":"streamed"}`))
+	if err != nil {
+		t.Fatalf("CallTool failed: %v", err)
+	}
+	if len(result.Content) != 1 || result.Content[0].Text != "streamed" {
+		t.Errorf("Unexpected echo result: %#v", result)
+	}
+
+	client.Close()
+	for _, session := range sessions[:len(sessions)-1] {
+		if session != "session-1" {
+			t.Errorf("Expected requests to carry the session ID, got %q", session)
+		}
+	}
+	if last := sessions[len(sessions)-1]; last != "deleted:session-1" {
+		t.Errorf("Expected the session to be deleted on close, got %q", last)
+	}
+}
+
+func TestConnect_HTTPReportsStatus(t *testing.T) {
+	var sessions []string
+	server := newHTTPServer(t, &sessions)
+	_, _, err := Connect(context.Background(), &MCPServer{Name: "remote", Transport: TransportHTTP, URL: server.URL})
+	if err == nil || !strings.Contains(err.Error(), "HTTP 401") {
+		t.Fatalf("Expected HTTP 401 error, got %v", err)
+	}
+}
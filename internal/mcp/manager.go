@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"log/slog"
+	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -12,12 +14,57 @@ import (
 	"sync"
 )
 
-// MCPServerConfig represents an individual MCP server configuration
+// Transports supported for MCP servers
+const (
+	TransportStdio = "stdio"
+	TransportSSE   = "sse"
+	TransportHTTP  = "http"
+)
+
+// MCPServerConfig represents an individual MCP server configuration.
+// Type selects the transport; when empty it is stdio for servers with a
+// command and SSE for servers with a URL.
 type MCPServerConfig struct {
+	Type    string            `json:"type,omitempty"`
 	Command string            `json:"command,omitempty"`
 	Args    []string          `json:"args,omitempty"`
 	Env     map[string]string `json:"env,omitempty"`
 	URL     string            `json:"url,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// normalizeTransport maps transport aliases to their canonical name
+func normalizeTransport(transport string) string {
+	transport = strings.ToLower(strings.TrimSpace(transport))
+	if transport == "streamable-http" || transport == "streamable_http" {
+		return TransportHTTP
+	}
+	return transport
+}
+
+// Transport validates the configuration and returns its transport
+func (c *MCPServerConfig) Transport() (string, error) {
+	transport := normalizeTransport(c.Type)
+	if transport == "" {
+		transport = TransportStdio
+		if c.Command == "" && c.URL != "" {
+			transport = TransportSSE
+		}
+	}
+	switch transport {
+	case TransportStdio:
+		if c.Command == "" {
+			return "", fmt.Errorf("stdio server requires a command")
+		}
+	case TransportSSE, TransportHTTP:
+		parsed, err := url.Parse(c.URL)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return "", fmt.Errorf("%s server requires an http(s) URL", transport)
+		}
+	default:
+		return "", fmt.Errorf("unknown transport %q", c.Type)
+	}
+	return transport, nil
 }
 
 // MCPServerStatus represents the runtime status of an MCP server
@@ -35,6 +82,7 @@ type MCPServer struct {
 	Args      []string          `json:"args,omitempty"`
 	Env       map[string]string `json:"env,omitempty"`
 	URL       string            `json:"url,omitempty"`
+	Headers   map[string]string `json:"headers,omitempty"`
 	Scope     string            `json:"scope"`
 	IsActive  bool              `json:"is_active"`
 	Status    MCPServerStatus   `json:"status"`
@@ -137,7 +185,7 @@ func (m *Manager) findClaudeBinary() string {
 		return path
 	}
 
-	log.Printf("[MCP] WARNING: claude binary not found in any location")
+	slog.Warn("claude binary not found in any location", "component", "MCP")
 	return ""
 }
 
@@ -183,7 +231,7 @@ func (m *Manager) ListMcpServers() ([]*MCPServer, error) {
 
 	output, err := m.executeClaudeMcpCommand([]string{"list"})
 	if err != nil {
-		log.Printf("[MCP] Failed to execute claude mcp list: %v", err)
+		slog.Error("Failed to execute claude mcp list", "component", "MCP", "error", err)
 		// Fallback to reading from settings.json
 		return m.listMcpServersFromSettings()
 	}
@@ -270,26 +318,31 @@ func (m *Manager) parseServerLine(line string) *MCPServer {
 		return nil
 	}
 
-	// Parse command and args
-	cmdParts := parseCommandLine(commandPart)
-	if len(cmdParts) == 0 {
-		return nil
-	}
-
 	server := &MCPServer{
-		Name:      name,
-		Transport: "stdio",
-		Command:   cmdParts[0],
-		Env:       make(map[string]string), // Initialize empty env map to prevent frontend errors
-		Scope:     "user",
-		IsActive:  true,
+		Name:     name,
+		Env:      make(map[string]string), // Initialize empty env map to prevent frontend errors
+		Scope:    "user",
+		IsActive: true,
 		Status: MCPServerStatus{
 			Running: false,
 		},
 	}
 
-	if len(cmdParts) > 1 {
-		server.Args = cmdParts[1:]
+	// Remote servers are listed as "name: https://host/path (HTTP)"
+	if match := remoteServerLine.FindStringSubmatch(commandPart); match != nil {
+		server.URL = match[1]
+		server.Transport = strings.ToLower(match[2])
+	} else {
+		// Parse command and args
+		cmdParts := parseCommandLine(commandPart)
+		if len(cmdParts) == 0 {
+			return nil
+		}
+		server.Transport = TransportStdio
+		server.Command = cmdParts[0]
+		if len(cmdParts) > 1 {
+			server.Args = cmdParts[1:]
+		}
 	}
 
 	// Parse status
@@ -306,6 +359,9 @@ func (m *Manager) parseServerLine(line string) *MCPServer {
 	return server
 }
 
+// remoteServerLine matches the command part of a remote server in `claude mcp list`
+var remoteServerLine = regexp.MustCompile(`^(https?://\S+)\s+\((SSE|HTTP)\)$`)
+
 // parseCommandLine parses a command line string into command and args
 func parseCommandLine(cmdLine string) []string {
 	// Simple parsing - split by spaces, respecting quotes
@@ -379,52 +435,7 @@ func (m *Manager) listMcpServersFromSettings() ([]*MCPServer, error) {
 			continue
 		}
 
-		server := &MCPServer{
-			Name:     name,
-			Scope:    "user",
-			IsActive: true,
-		}
-
-		// Parse command
-		if cmd, ok := configMap["command"].(string); ok {
-			server.Command = cmd
-			server.Transport = "stdio"
-		}
-
-		// Parse args
-		if argsData, ok := configMap["args"].([]interface{}); ok {
-			args := make([]string, 0, len(argsData))
-			for _, arg := range argsData {
-				if argStr, ok := arg.(string); ok {
-					args = append(args, argStr)
-				}
-			}
-			server.Args = args
-		}
-
-		// Parse env
-		if envData, ok := configMap["env"].(map[string]interface{}); ok {
-			env := make(map[string]string)
-			for key, value := range envData {
-				if valStr, ok := value.(string); ok {
-					env[key] = valStr
-				}
-			}
-			server.Env = env
-		}
-
-		// Parse URL (for SSE transport)
-		if url, ok := configMap["url"].(string); ok {
-			server.URL = url
-			server.Transport = "sse"
-		}
-
-		// Initialize status
-		server.Status = MCPServerStatus{
-			Running: false,
-		}
-
-		servers = append(servers, server)
+		servers = append(servers, serverFromConfig(name, configMap))
 	}
 
 	return servers, nil
@@ -450,6 +461,11 @@ func (m *Manager) GetMcpServer(name string) (*MCPServer, error) {
 		return nil, fmt.Errorf("server %s not found", name)
 	}
 
+	return serverFromConfig(name, configData), nil
+}
+
+// serverFromConfig builds a server from its settings.json entry
+func serverFromConfig(name string, configData map[string]interface{}) *MCPServer {
 	server := &MCPServer{
 		Name:     name,
 		Scope:    "user",
@@ -462,7 +478,7 @@ func (m *Manager) GetMcpServer(name string) (*MCPServer, error) {
 	// Parse command
 	if cmd, ok := configData["command"].(string); ok {
 		server.Command = cmd
-		server.Transport = "stdio"
+		server.Transport = TransportStdio
 	}
 
 	// Parse args
@@ -476,28 +492,45 @@ func (m *Manager) GetMcpServer(name string) (*MCPServer, error) {
 		server.Args = args
 	}
 
-	// Parse env
-	if envData, ok := configData["env"].(map[string]interface{}); ok {
-		env := make(map[string]string)
-		for key, value := range envData {
-			if valStr, ok := value.(string); ok {
-				env[key] = valStr
-			}
-		}
-		server.Env = env
+	server.Env = stringMap(configData["env"])
+	server.Headers = stringMap(configData["headers"])
+
+	// Parse URL (SSE unless the type says otherwise)
+	if rawURL, ok := configData["url"].(string); ok {
+		server.URL = rawURL
+		server.Transport = TransportSSE
 	}
 
-	// Parse URL
-	if url, ok := configData["url"].(string); ok {
-		server.URL = url
-		server.Transport = "sse"
+	// Parse explicit transport type
+	if transport, ok := configData["type"].(string); ok && transport != "" {
+		server.Transport = normalizeTransport(transport)
 	}
 
-	return server, nil
+	return server
+}
+
+// stringMap converts a decoded JSON object to a string map
+func stringMap(data interface{}) map[string]string {
+	object, ok := data.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	result := make(map[string]string)
+	for key, value := range object {
+		if valStr, ok := value.(string); ok {
+			result[key] = valStr
+		}
+	}
+	return result
 }
 
 // SaveMcpServer saves or updates an MCP server configuration
 func (m *Manager) SaveMcpServer(name string, config *MCPServerConfig) error {
+	transport, err := config.Transport()
+	if err != nil {
+		return err
+	}
+
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -515,6 +548,10 @@ func (m *Manager) SaveMcpServer(name string, config *MCPServerConfig) error {
 	// Build config map
 	configMap := make(map[string]interface{})
 
+	if transport != TransportStdio {
+		configMap["type"] = transport
+	}
+
 	if config.Command != "" {
 		configMap["command"] = config.Command
 	}
@@ -531,6 +568,10 @@ func (m *Manager) SaveMcpServer(name string, config *MCPServerConfig) error {
 		configMap["url"] = config.URL
 	}
 
+	if len(config.Headers) > 0 {
+		configMap["headers"] = config.Headers
+	}
+
 	mcpServers[name] = configMap
 
 	return m.saveSettings(settings)
@@ -560,6 +601,35 @@ func (m *Manager) DeleteMcpServer(name string) error {
 	return m.saveSettings(settings)
 }
 
+// ServerConfigs returns the configurations stored in settings.json by name
+func (m *Manager) ServerConfigs() (map[string]MCPServerConfig, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	settings, err := m.loadSettings()
+	if err != nil {
+		return nil, err
+	}
+
+	configs := make(map[string]MCPServerConfig)
+	mcpServers, ok := settings["mcpServers"].(map[string]interface{})
+	if !ok {
+		return configs, nil
+	}
+	for name, configData := range mcpServers {
+		data, err := json.Marshal(configData)
+		if err != nil {
+			continue
+		}
+		var config MCPServerConfig
+		if err := json.Unmarshal(data, &config); err != nil {
+			continue
+		}
+		configs[name] = config
+	}
+	return configs, nil
+}
+
 // GetMcpServerStatus returns the runtime status of an MCP server
 // Note: This is a placeholder implementation - actual status checking
 // would require process management integration
@@ -307,3 +307,75 @@ func TestExistingSettingsFile(t *testing.T) {
 		t.Error("Existing settings were not preserved")
 	}
 }
+
+func TestSaveRemoteServerWithHeaders(t *testing.T) {
+	tmpDir := t.TempDir()
+	manager := NewManager(tmpDir)
+	manager.SetClaudeBinary("/definitely/missing/claude")
+
+	config := &MCPServerConfig{
+		Type:    "streamable-http",
+		URL:     "https://mcp.example.com/mcp",
+		Headers: map[string]string{"Authorization": "Bearer token"},
+	}
+	if err := manager.SaveMcpServer("remote", config); err != nil {
+		t.Fatalf("SaveMcpServer failed: %v", err)
+	}
+
+	server, err := manager.GetMcpServer("remote")
+	if err != nil {
+		t.Fatalf("GetMcpServer failed: %v", err)
+	}
+	if server.Transport != TransportHTTP {
+		t.Errorf("Expected transport 'http', got '%s'", server.Transport)
+	}
+	if server.Headers["Authorization"] != "Bearer token" {
+		t.Errorf("Expected headers to be persisted, got %v", server.Headers)
+	}
+
+	settings, err := manager.loadSettings()
+	if err != nil {
+		t.Fatalf("loadSettings failed: %v", err)
+	}
+	entry := settings["mcpServers"].(map[string]interface{})["remote"].(map[string]interface{})
+	if entry["type"] != "http" {
+		t.Errorf("Expected type 'http' in settings, got %v", entry["type"])
+	}
+}
+
+func TestSaveMcpServer_ValidatesTransport(t *testing.T) {
+	manager := NewManager(t.TempDir())
+	manager.SetClaudeBinary("/definitely/missing/claude")
+
+	invalid := []*MCPServerConfig{
+		{},
+		{Type: "sse"},
+		{Type: "http", URL: "ftp://example.com"},
+		{Type: "websocket", URL: "https://example.com"},
+	}
+	for _, config := range invalid {
+		if err := manager.SaveMcpServer("bad", config); err == nil {
+			t.Errorf("Expected error saving %#v", config)
+		}
+	}
+}
+
+func TestParseServerLine_Remote(t *testing.T) {
+	manager := NewManager(t.TempDir())
+
+	server := manager.parseServerLine("docs: https://mcp.example.com/mcp (HTTP) - ✓ Connected")
+	if server == nil {
+		t.Fatal("Expected server to be parsed")
+	}
+	if server.Transport != TransportHTTP || server.URL != "https://mcp.example.com/mcp" || server.Command != "" {
+		t.Errorf("Unexpected remote server: %#v", server)
+	}
+	if !server.Status.Running {
+		t.Error("Expected server to be connected")
+	}
+
+	server = manager.parseServerLine("events: https://mcp.example.com/sse (SSE) - ✗ Failed to connect")
+	if server == nil || server.Transport != TransportSSE {
+		t.Errorf("Unexpected SSE server: %#v", server)
+	}
+}
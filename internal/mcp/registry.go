@@ -0,0 +1,424 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultRegistryURL is the official MCP server registry
+const DefaultRegistryURL = "https://registry.modelcontextprotocol.io"
+
+// RegistryServer is a server published in an MCP registry
+type RegistryServer struct {
+	Name        string              `json:"name"`
+	Title       string              `json:"title,omitempty"`
+	Description string              `json:"description,omitempty"`
+	Version     string              `json:"version,omitempty"`
+	WebsiteURL  string              `json:"websiteUrl,omitempty"`
+	Repository  *RegistryRepository `json:"repository,omitempty"`
+	Packages    []RegistryPackage   `json:"packages,omitempty"`
+	Remotes     []RegistryRemote    `json:"remotes,omitempty"`
+}
+
+// RegistryRepository is the source repository of a registry server
+type RegistryRepository struct {
+	URL    string `json:"url"`
+	Source string `json:"source,omitempty"`
+}
+
+// RegistryPackage is a locally runnable distribution of a registry server
+type RegistryPackage struct {
+	RegistryType         string             `json:"registryType"` // npm, pypi, oci, ...
+	Identifier           string             `json:"identifier"`
+	Version              string             `json:"version,omitempty"`
+	RuntimeHint          string             `json:"runtimeHint,omitempty"`
+	Transport            RegistryTransport  `json:"transport"`
+	RuntimeArguments     []RegistryArgument `json:"runtimeArguments,omitempty"`
+	PackageArguments     []RegistryArgument `json:"packageArguments,omitempty"`
+	EnvironmentVariables []RegistryInput    `json:"environmentVariables,omitempty"`
+}
+
+// RegistryTransport is the transport a package speaks once started
+type RegistryTransport struct {
+	Type string `json:"type"`
+	URL  string `json:"url,omitempty"`
+}
+
+// RegistryInput is a value the user may have to provide, e.g. an API key
+type RegistryInput struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	IsRequired  bool   `json:"isRequired,omitempty"`
+	IsSecret    bool   `json:"isSecret,omitempty"`
+	Default     string `json:"default,omitempty"`
+	Value       string `json:"value,omitempty"`
+}
+
+// RegistryArgument is a command line argument of a package
+type RegistryArgument struct {
+	RegistryInput
+	Type      string `json:"type"` // "positional" or "named"
+	ValueHint string `json:"valueHint,omitempty"`
+}
+
+// RegistryRemote is a hosted endpoint of a registry server
+type RegistryRemote struct {
+	Type    string          `json:"type"` // "streamable-http" or "sse"
+	URL     string          `json:"url"`
+	Headers []RegistryInput `json:"headers,omitempty"`
+}
+
+// RegistrySearchResult is one page of registry search results
+type RegistrySearchResult struct {
+	Servers    []RegistryServer `json:"servers"`
+	NextCursor string           `json:"next_cursor,omitempty"`
+}
+
+// RegistryClient reads an MCP registry. The URL is either the base of a
+// registry API (GET /v0/servers) or a static JSON index with the same
+// {"servers": [...]} shape, which is searched locally.
+type RegistryClient struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewRegistryClient creates a client for the registry at baseURL; empty
+// means the official registry
+func NewRegistryClient(baseURL string) *RegistryClient {
+	baseURL = strings.TrimRight(strings.TrimSpace(baseURL), "/")
+	if baseURL == "" {
+		baseURL = DefaultRegistryURL
+	}
+	return &RegistryClient{
+		baseURL:    baseURL,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (c *RegistryClient) isStaticIndex() bool {
+	parsed, err := url.Parse(c.baseURL)
+	return err == nil && strings.HasSuffix(strings.ToLower(parsed.Path), ".json")
+}
+
+// Search returns servers matching query (by name or description). cursor
+// continues a previous search; static indexes return everything at once.
+func (c *RegistryClient) Search(ctx context.Context, query, cursor string, limit int) (*RegistrySearchResult, error) {
+	if c.isStaticIndex() {
+		page, err := c.fetch(ctx, c.baseURL)
+		if err != nil {
+			return nil, err
+		}
+		result := &RegistrySearchResult{Servers: []RegistryServer{}}
+		needle := strings.ToLower(strings.TrimSpace(query))
+		for _, server := range page.Servers {
+			if needle == "" || strings.Contains(strings.ToLower(server.Name), needle) ||
+				strings.Contains(strings.ToLower(server.Title), needle) ||
+				strings.Contains(strings.ToLower(server.Description), needle) {
+				result.Servers = append(result.Servers, server)
+			}
+		}
+		return result, nil
+	}
+
+	params := url.Values{}
+	params.Set("version", "latest")
+	if query = strings.TrimSpace(query); query != "" {
+		params.Set("search", query)
+	}
+	if cursor != "" {
+		params.Set("cursor", cursor)
+	}
+	if limit > 0 {
+		params.Set("limit", strconv.Itoa(limit))
+	}
+	return c.fetch(ctx, c.baseURL+"/v0/servers?"+params.Encode())
+}
+
+// Find returns the server with exactly the given name
+func (c *RegistryClient) Find(ctx context.Context, name string) (*RegistryServer, error) {
+	cursor := ""
+	for page := 0; page < 20; page++ {
+		result, err := c.Search(ctx, name, cursor, 100)
+		if err != nil {
+			return nil, err
+		}
+		for i := range result.Servers {
+			if result.Servers[i].Name == name {
+				return &result.Servers[i], nil
+			}
+		}
+		if result.NextCursor == "" {
+			break
+		}
+		cursor = result.NextCursor
+	}
+	return nil, fmt.Errorf("server %s not found in registry", name)
+}
+
+// registryEntry is a list item of the registry API; older registries and
+// static indexes list bare servers instead.
+type registryEntry struct {
+	Server json.RawMessage `json:"server"`
+	Meta   struct {
+		Official struct {
+			IsLatest *bool `json:"isLatest"`
+		} `json:"io.modelcontextprotocol.registry/official"`
+	} `json:"_meta"`
+}
+
+func (c *RegistryClient) fetch(ctx context.Context, rawURL string) (*RegistrySearchResult, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach MCP registry: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("MCP registry returned %w", httpStatusError(resp))
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 32*1024*1024))
+	if err != nil {
+		return nil, err
+	}
+
+	var body struct {
+		Servers  []json.RawMessage `json:"servers"`
+		Metadata struct {
+			NextCursor string `json:"nextCursor"`
+		} `json:"metadata"`
+	}
+	if err := json.Unmarshal(data, &body); err != nil {
+		// A static index may be a bare array of servers
+		if arrErr := json.Unmarshal(data, &body.Servers); arrErr != nil {
+			return nil, fmt.Errorf("invalid registry response: %w", err)
+		}
+	}
+
+	result := &RegistrySearchResult{Servers: []RegistryServer{}, NextCursor: body.Metadata.NextCursor}
+	seen := make(map[string]int)
+	for _, raw := range body.Servers {
+		var entry registryEntry
+		json.Unmarshal(raw, &entry)
+		serverJSON := raw
+		if len(entry.Server) > 0 {
+			serverJSON = entry.Server
+		}
+		var server RegistryServer
+		if err := json.Unmarshal(serverJSON, &server); err != nil || server.Name == "" {
+			continue
+		}
+		if latest := entry.Meta.Official.IsLatest; latest != nil && !*latest {
+			continue
+		}
+		// Keep one entry per server, the last listed version
+		if i, ok := seen[server.Name]; ok {
+			result.Servers[i] = server
+			continue
+		}
+		seen[server.Name] = len(result.Servers)
+		result.Servers = append(result.Servers, server)
+	}
+	return result, nil
+}
+
+// DefaultInstallName derives a local server name from a registry name, e.g.
+// "io.github.acme/weather" becomes "weather"
+func DefaultInstallName(registryName string) string {
+	name := registryName
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	return name
+}
+
+// InstallConfig synthesizes the configuration to run a registry server.
+// Locally runnable packages (npx, uvx or docker) are preferred over hosted
+// remotes. values provides environment variables, arguments and headers by
+// name; required values without a default must be present.
+func (s *RegistryServer) InstallConfig(values map[string]string) (*MCPServerConfig, error) {
+	var firstErr error
+	for _, pkg := range s.Packages {
+		config, err := pkg.config(values)
+		if err == nil {
+			return config, nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	for _, remote := range s.Remotes {
+		config, err := remote.config(values)
+		if err == nil {
+			return config, nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	if firstErr == nil {
+		firstErr = fmt.Errorf("server %s has no packages or remotes", s.Name)
+	}
+	return nil, firstErr
+}
+
+func (p RegistryPackage) config(values map[string]string) (*MCPServerConfig, error) {
+	if p.Transport.Type != "" && p.Transport.Type != TransportStdio {
+		return nil, fmt.Errorf("%s package %s uses the %s transport, which cannot be launched", p.RegistryType, p.Identifier, p.Transport.Type)
+	}
+
+	env := make(map[string]string)
+	for _, variable := range p.EnvironmentVariables {
+		value, err := resolveInput(variable, values)
+		if err != nil {
+			return nil, err
+		}
+		if value != "" {
+			env[variable.Name] = value
+		}
+	}
+	runtimeArgs, err := resolveArguments(p.RuntimeArguments, values)
+	if err != nil {
+		return nil, err
+	}
+	packageArgs, err := resolveArguments(p.PackageArguments, values)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &MCPServerConfig{Env: env}
+	switch strings.ToLower(p.RegistryType) {
+	case "npm":
+		config.Command = "npx"
+		spec := p.Identifier
+		if p.Version != "" {
+			spec += "@" + p.Version
+		}
+		config.Args = append(append([]string{"-y"}, runtimeArgs...), spec)
+	case "pypi":
+		config.Command = "uvx"
+		spec := p.Identifier
+		if p.Version != "" {
+			spec += "==" + p.Version
+		}
+		config.Args = append(runtimeArgs, spec)
+	case "oci", "docker":
+		config.Command = "docker"
+		config.Args = []string{"run", "-i", "--rm"}
+		for _, variable := range p.EnvironmentVariables {
+			if _, ok := env[variable.Name]; ok {
+				config.Args = append(config.Args, "-e", variable.Name)
+			}
+		}
+		image := p.Identifier
+		if p.Version != "" && !strings.Contains(image, ":") && !strings.Contains(image, "@") {
+			image += ":" + p.Version
+		}
+		config.Args = append(append(config.Args, runtimeArgs...), image)
+	default:
+		return nil, fmt.Errorf("unsupported package type %q for %s", p.RegistryType, p.Identifier)
+	}
+	if p.RuntimeHint != "" {
+		config.Command = p.RuntimeHint
+	}
+	config.Args = append(config.Args, packageArgs...)
+	return config, nil
+}
+
+func (r RegistryRemote) config(values map[string]string) (*MCPServerConfig, error) {
+	transport := normalizeTransport(r.Type)
+	if transport != TransportHTTP && transport != TransportSSE {
+		return nil, fmt.Errorf("unsupported remote transport %q", r.Type)
+	}
+	config := &MCPServerConfig{
+		Type: transport,
+		URL:  substituteValues(r.URL, values),
+	}
+	for _, header := range r.Headers {
+		value, err := resolveInput(header, values)
+		if err != nil {
+			return nil, err
+		}
+		if value != "" {
+			if config.Headers == nil {
+				config.Headers = make(map[string]string)
+			}
+			config.Headers[header.Name] = value
+		}
+	}
+	return config, nil
+}
+
+// resolveInput picks the user's value, else the fixed or default value.
+func resolveInput(input RegistryInput, values map[string]string) (string, error) {
+	if value, ok := values[input.Name]; ok && value != "" {
+		return value, nil
+	}
+	if input.Value != "" {
+		return substituteValues(input.Value, values), nil
+	}
+	if input.Default != "" {
+		return input.Default, nil
+	}
+	if input.IsRequired {
+		return "", &MissingInputError{Name: input.Name, Description: input.Description}
+	}
+	return "", nil
+}
+
+func resolveArguments(arguments []RegistryArgument, values map[string]string) ([]string, error) {
+	var args []string
+	for _, argument := range arguments {
+		input := argument.RegistryInput
+		if input.Name == "" {
+			input.Name = argument.ValueHint
+		}
+		value, err := resolveInput(input, values)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case argument.Type == "named" && value != "":
+			args = append(args, argument.Name, value)
+		case value != "":
+			args = append(args, value)
+		}
+	}
+	return args, nil
+}
+
+var registryPlaceholder = regexp.MustCompile(`\{([A-Za-z0-9_.-]+)\}`)
+
+// substituteValues replaces {name} placeholders with provided values.
+func substituteValues(template string, values map[string]string) string {
+	return registryPlaceholder.ReplaceAllStringFunc(template, func(match string) string {
+		if value, ok := values[match[1:len(match)-1]]; ok {
+			return value
+		}
+		return match
+	})
+}
+
+// MissingInputError reports a required value that was not provided
+type MissingInputError struct {
+	Name        string
+	Description string
+}
+
+func (e *MissingInputError) Error() string {
+	if e.Description != "" {
+		return fmt.Sprintf("missing required value %s (%s)", e.Name, e.Description)
+	}
+	return fmt.Sprintf("missing required value %s", e.Name)
+}
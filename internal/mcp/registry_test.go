@@ -0,0 +1,132 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const registryPage = `{
+  "servers": [
+    {"server": {"name": "io.github.acme/weather", "description": "Weather forecasts", "version": "1.0.0",
+      "packages": [{"registryType": "npm", "identifier": "@acme/weather", "version": "1.0.0",
+        "transport": {"type": "stdio"},
+        "environmentVariables": [{"name": "WEATHER_KEY", "isRequired": true, "isSecret": true}],
+        "packageArguments": [{"type": "named", "name": "--units", "default": "metric"}]}]},
+     "_meta": {"io.modelcontextprotocol.registry/official": {"isLatest": true}}},
+    {"server": {"name": "io.github.acme/weather", "version": "0.9.0"},
+     "_meta": {"io.modelcontextprotocol.registry/official": {"isLatest": false}}},
+    {"server": {"name": "com.example/hosted", "description": "Hosted docs",
+      "remotes": [{"type": "streamable-http", "url": "https://mcp.example.com/mcp",
+        "headers": [{"name": "Authorization", "value": "Bearer {api_key}", "isRequired": true}]}]}}
+  ],
+  "metadata": {"nextCursor": "next-page"}
+}`
+
+func TestRegistryClient_Search(t *testing.T) {
+	var query string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v0/servers" {
+			http.NotFound(w, r)
+			return
+		}
+		query = r.URL.RawQuery
+		w.Write([]byte(registryPage))
+	}))
+	defer server.Close()
+
+	result, err := NewRegistryClient(server.URL+"/").Search(context.Background(), "weather", "abc", 10)
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if !strings.Contains(query, "search=weather") || !strings.Contains(query, "cursor=abc") || !strings.Contains(query, "limit=10") {
+		t.Errorf("Unexpected query %q", query)
+	}
+	if len(result.Servers) != 2 || result.Servers[0].Version != "1.0.0" {
+		t.Fatalf("Expected the latest version of each server, got %#v", result.Servers)
+	}
+	if result.NextCursor != "next-page" {
+		t.Errorf("Expected next cursor, got %q", result.NextCursor)
+	}
+}
+
+func TestRegistryClient_StaticIndex(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(registryPage))
+	}))
+	defer server.Close()
+
+	client := NewRegistryClient(server.URL + "/index.json")
+	result, err := client.Search(context.Background(), "docs", "", 0)
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if len(result.Servers) != 1 || result.Servers[0].Name != "com.example/hosted" {
+		t.Fatalf("Expected local filtering of the index, got %#v", result.Servers)
+	}
+
+	found, err := client.Find(context.Background(), "io.github.acme/weather")
+	if err != nil || found.Version != "1.0.0" {
+		t.Fatalf("Find = %#v, %v", found, err)
+	}
+	if _, err := client.Find(context.Background(), "missing"); err == nil {
+		t.Error("Expected error for unknown server")
+	}
+}
+
+func TestRegistryServer_InstallConfig(t *testing.T) {
+	npm := RegistryServer{Name: "npm", Packages: []RegistryPackage{{
+		RegistryType: "npm", Identifier: "@acme/weather", Version: "1.0.0",
+		EnvironmentVariables: []RegistryInput{{Name: "WEATHER_KEY", IsRequired: true}},
+		PackageArguments:     []RegistryArgument{{Type: "named", RegistryInput: RegistryInput{Name: "--units", Default: "metric"}}},
+	}}}
+	_, err := npm.InstallConfig(nil)
+	var missing *MissingInputError
+	if !errors.As(err, &missing) || missing.Name != "WEATHER_KEY" {
+		t.Fatalf("Expected missing WEATHER_KEY, got %v", err)
+	}
+	config, err := npm.InstallConfig(map[string]string{"WEATHER_KEY": "k"})
+	if err != nil {
+		t.Fatalf("InstallConfig failed: %v", err)
+	}
+	if config.Command != "npx" || strings.Join(config.Args, " ") != "-y @acme/weather@1.0.0 --units metric" || config.Env["WEATHER_KEY"] != "k" {
+		t.Errorf("Unexpected npm config: %#v", config)
+	}
+
+	pypi := RegistryServer{Packages: []RegistryPackage{{RegistryType: "pypi", Identifier: "mcp-time", Version: "2.0"}}}
+	if config, err := pypi.InstallConfig(nil); err != nil || config.Command != "uvx" || config.Args[0] != "mcp-time==2.0" {
+		t.Errorf("Unexpected pypi config: %#v, %v", config, err)
+	}
+
+	oci := RegistryServer{Packages: []RegistryPackage{{
+		RegistryType: "oci", Identifier: "ghcr.io/acme/server", Version: "1",
+		EnvironmentVariables: []RegistryInput{{Name: "TOKEN"}},
+	}}}
+	config, err = oci.InstallConfig(map[string]string{"TOKEN": "t"})
+	if err != nil || strings.Join(config.Args, " ") != "run -i --rm -e TOKEN ghcr.io/acme/server:1" {
+		t.Errorf("Unexpected oci config: %#v, %v", config, err)
+	}
+
+	remote := RegistryServer{Remotes: []RegistryRemote{{
+		Type: "streamable-http", URL: "https://mcp.example.com/mcp",
+		Headers: []RegistryInput{{Name: "Authorization", Value: "Bearer {api_key}", IsRequired: true}},
+	}}}
+	config, err = remote.InstallConfig(map[string]string{"api_key": "secret"})
+	if err != nil || config.Type != TransportHTTP || config.Headers["Authorization"] != "Bearer secret" {
+		t.Errorf("Unexpected remote config: %#v, %v", config, err)
+	}
+
+	unsupported := RegistryServer{Name: "nuget", Packages: []RegistryPackage{{RegistryType: "nuget", Identifier: "X"}}}
+	if _, err := unsupported.InstallConfig(nil); err == nil {
+		t.Error("Expected error for unsupported package type")
+	}
+}
+
+func TestDefaultInstallName(t *testing.T) {
+	if got := DefaultInstallName("io.github.acme/weather"); got != "weather" {
+		t.Errorf("DefaultInstallName = %q", got)
+	}
+}
@@ -0,0 +1,470 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"ropcode/internal/process"
+)
+
+// RestartPolicy controls whether a supervised server is restarted when it exits
+type RestartPolicy string
+
+const (
+	RestartNever     RestartPolicy = "never"
+	RestartOnFailure RestartPolicy = "on-failure"
+	RestartAlways    RestartPolicy = "always"
+)
+
+// Server states reported by the supervisor
+const (
+	ServerStopped   = "stopped"
+	ServerStarting  = "starting"
+	ServerRunning   = "running"
+	ServerUnhealthy = "unhealthy"
+	ServerFailed    = "failed"
+)
+
+// RuntimeStatus is the supervised state of a locally launched MCP server
+type RuntimeStatus struct {
+	Name            string        `json:"name"`
+	State           string        `json:"state"`
+	PID             int           `json:"pid,omitempty"`
+	RestartPolicy   RestartPolicy `json:"restart_policy"`
+	Restarts        int           `json:"restarts"`
+	LastError       string        `json:"last_error,omitempty"`
+	ExitCode        *int          `json:"exit_code,omitempty"`
+	ServerInfo      *ServerInfo   `json:"server_info,omitempty"`
+	StartedAt       int64         `json:"started_at,omitempty"`
+	LastHealthCheck int64         `json:"last_health_check,omitempty"`
+}
+
+// maxStderrTail caps how much stderr output is kept for error reports.
+const maxStderrTail = 2048
+
+// stderrTail keeps the last bytes written to it.
+type stderrTail struct {
+	mu  sync.Mutex
+	buf []byte
+}
+
+func (t *stderrTail) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.buf = append(t.buf, p...)
+	if len(t.buf) > maxStderrTail {
+		t.buf = t.buf[len(t.buf)-maxStderrTail:]
+	}
+	return len(p), nil
+}
+
+func (t *stderrTail) String() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return strings.TrimSpace(string(t.buf))
+}
+
+type supervisedServer struct {
+	server *MCPServer
+	policy RestartPolicy
+	status RuntimeStatus
+	stop   chan struct{}
+	done   chan struct{}
+	client *Client
+}
+
+// Supervisor launches stdio MCP servers through the process manager, checks
+// their health with the initialize handshake and periodic pings, and restarts
+// them according to their restart policy.
+type Supervisor struct {
+	procs  *process.Manager
+	notify func(RuntimeStatus)
+
+	initTimeout    time.Duration
+	healthInterval time.Duration
+	healthTimeout  time.Duration
+	maxHealthFails int
+	backoffBase    time.Duration
+	backoffMax     time.Duration
+	maxRestarts    int
+	stableAfter    time.Duration
+
+	mu      sync.Mutex
+	servers map[string]*supervisedServer
+}
+
+// NewSupervisor creates a supervisor. notify, if set, is called with the new
+// status whenever a server changes state.
+func NewSupervisor(procs *process.Manager, notify func(RuntimeStatus)) *Supervisor {
+	return &Supervisor{
+		procs:          procs,
+		notify:         notify,
+		initTimeout:    15 * time.Second,
+		healthInterval: 30 * time.Second,
+		healthTimeout:  10 * time.Second,
+		maxHealthFails: 2,
+		backoffBase:    time.Second,
+		backoffMax:     30 * time.Second,
+		maxRestarts:    5,
+		stableAfter:    time.Minute,
+		servers:        make(map[string]*supervisedServer),
+	}
+}
+
+func processKey(name string) string {
+	return "mcp:" + name
+}
+
+// ParseRestartPolicy validates a restart policy; "" means on-failure
+func ParseRestartPolicy(policy string) (RestartPolicy, error) {
+	switch RestartPolicy(policy) {
+	case "":
+		return RestartOnFailure, nil
+	case RestartNever, RestartOnFailure, RestartAlways:
+		return RestartPolicy(policy), nil
+	}
+	return "", fmt.Errorf("unsupported restart policy: %s", policy)
+}
+
+// update changes the status of ss under the lock and notifies listeners.
+func (s *Supervisor) update(ss *supervisedServer, fn func(status *RuntimeStatus)) {
+	s.mu.Lock()
+	fn(&ss.status)
+	status := ss.status
+	s.mu.Unlock()
+	if s.notify != nil {
+		s.notify(status)
+	}
+}
+
+// Start launches a stdio server and supervises it. Starting a server that is
+// already supervised returns its current status.
+func (s *Supervisor) Start(server *MCPServer, policy RestartPolicy) (*RuntimeStatus, error) {
+	if server.Command == "" {
+		if server.URL != "" {
+			return nil, fmt.Errorf("server %s uses a remote transport and is not launched locally", server.Name)
+		}
+		return nil, fmt.Errorf("server %s has no command configured", server.Name)
+	}
+	if _, err := exec.LookPath(server.Command); err != nil {
+		return nil, fmt.Errorf("command not found: %s", server.Command)
+	}
+
+	s.mu.Lock()
+	if existing, ok := s.servers[server.Name]; ok {
+		status := existing.status
+		s.mu.Unlock()
+		return &status, nil
+	}
+	ss := &supervisedServer{
+		server: server,
+		policy: policy,
+		status: RuntimeStatus{Name: server.Name, State: ServerStarting, RestartPolicy: policy},
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	s.servers[server.Name] = ss
+	status := ss.status
+	s.mu.Unlock()
+
+	go s.supervise(ss)
+	return &status, nil
+}
+
+// Stop stops a supervised server and waits for it to exit
+func (s *Supervisor) Stop(name string) error {
+	if !s.stopServer(name) {
+		return fmt.Errorf("server %s is not running", name)
+	}
+	return nil
+}
+
+// stopServer stops name if it is supervised and reports whether it was.
+func (s *Supervisor) stopServer(name string) bool {
+	s.mu.Lock()
+	ss, ok := s.servers[name]
+	if ok {
+		delete(s.servers, name)
+	}
+	s.mu.Unlock()
+	if !ok {
+		return false
+	}
+	close(ss.stop)
+	<-ss.done
+	return true
+}
+
+// Restart stops the server if it is supervised and starts it again
+func (s *Supervisor) Restart(server *MCPServer, policy RestartPolicy) (*RuntimeStatus, error) {
+	s.stopServer(server.Name)
+	return s.Start(server, policy)
+}
+
+// Status returns the status of a supervised server
+func (s *Supervisor) Status(name string) (*RuntimeStatus, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ss, ok := s.servers[name]
+	if !ok {
+		return nil, false
+	}
+	status := ss.status
+	return &status, true
+}
+
+// List returns the status of every supervised server, sorted by name
+func (s *Supervisor) List() []RuntimeStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result := make([]RuntimeStatus, 0, len(s.servers))
+	for _, ss := range s.servers {
+		result = append(result, ss.status)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+// Client returns the protocol client of a running server
+func (s *Supervisor) Client(name string) (*Client, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ss, ok := s.servers[name]
+	if !ok || ss.client == nil || ss.status.State != ServerRunning {
+		return nil, false
+	}
+	return ss.client, true
+}
+
+// StopAll stops every supervised server without restarting them
+func (s *Supervisor) StopAll() {
+	s.mu.Lock()
+	names := make([]string, 0, len(s.servers))
+	for name := range s.servers {
+		names = append(names, name)
+	}
+	s.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, name := range names {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			s.stopServer(name)
+		}(name)
+	}
+	wg.Wait()
+}
+
+// supervise runs the server until it is stopped or its restart policy gives up.
+func (s *Supervisor) supervise(ss *supervisedServer) {
+	defer close(ss.done)
+
+	failures := 0
+	for {
+		startedAt := time.Now()
+		err := s.runOnce(ss)
+
+		select {
+		case <-ss.stop:
+			s.update(ss, func(status *RuntimeStatus) {
+				status.State = ServerStopped
+				status.PID = 0
+			})
+			return
+		default:
+		}
+
+		if time.Since(startedAt) >= s.stableAfter {
+			failures = 0
+		}
+		policy := ss.policy
+		restart := policy == RestartAlways || (policy == RestartOnFailure && err != nil)
+		if !restart {
+			s.finish(ss, err)
+			return
+		}
+		failures++
+		if failures > s.maxRestarts {
+			s.finish(ss, fmt.Errorf("giving up after %d restarts: %v", s.maxRestarts, err))
+			return
+		}
+
+		delay := s.backoffBase << (failures - 1)
+		if delay > s.backoffMax {
+			delay = s.backoffMax
+		}
+		select {
+		case <-ss.stop:
+			s.update(ss, func(status *RuntimeStatus) {
+				status.State = ServerStopped
+				status.PID = 0
+			})
+			return
+		case <-time.After(delay):
+		}
+		s.update(ss, func(status *RuntimeStatus) {
+			status.Restarts++
+			status.State = ServerStarting
+		})
+	}
+}
+
+// finish records the final state of a server that will not be restarted and
+// stops tracking it.
+func (s *Supervisor) finish(ss *supervisedServer, err error) {
+	s.mu.Lock()
+	if s.servers[ss.server.Name] == ss {
+		delete(s.servers, ss.server.Name)
+	}
+	s.mu.Unlock()
+	s.update(ss, func(status *RuntimeStatus) {
+		status.PID = 0
+		status.State = ServerStopped
+		if err != nil {
+			status.State = ServerFailed
+			status.LastError = err.Error()
+		}
+	})
+}
+
+// runOnce launches the server, performs the handshake and health checks it
+// until it exits or is stopped. It returns nil for a clean exit.
+func (s *Supervisor) runOnce(ss *supervisedServer) error {
+	server := ss.server
+	cmd := serverCommand(server)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	stderr := &stderrTail{}
+	cmd.Stderr = stderr
+
+	proc, err := s.procs.SpawnCommand(processKey(server.Name), cmd)
+	if err != nil {
+		s.update(ss, func(status *RuntimeStatus) { status.LastError = err.Error() })
+		return err
+	}
+	client := NewStdioClient(stdout, stdin)
+
+	s.mu.Lock()
+	ss.client = client
+	s.mu.Unlock()
+	s.update(ss, func(status *RuntimeStatus) {
+		status.State = ServerStarting
+		status.PID = proc.Pid()
+		status.StartedAt = time.Now().UnixMilli()
+		status.ExitCode = nil
+	})
+
+	// exited reports why the process ended, including its stderr tail.
+	exited := func() error {
+		proc.Wait()
+		code := proc.ExitCode()
+		s.update(ss, func(status *RuntimeStatus) { status.ExitCode = &code })
+		if code == 0 {
+			return nil
+		}
+		err := fmt.Errorf("exited with code %d", code)
+		if tail := stderr.String(); tail != "" {
+			err = fmt.Errorf("%w: %s", err, tail)
+		}
+		return err
+	}
+	fail := func(err error) error {
+		s.update(ss, func(status *RuntimeStatus) {
+			status.State = ServerUnhealthy
+			status.LastError = err.Error()
+		})
+		s.procs.Kill(processKey(server.Name))
+		proc.Wait()
+		return err
+	}
+
+	initCtx, cancel := context.WithTimeout(context.Background(), s.initTimeout)
+	initDone := make(chan struct{})
+	go func() {
+		select {
+		case <-ss.stop:
+			cancel()
+		case <-initDone:
+		}
+	}()
+	result, err := client.Initialize(initCtx)
+	close(initDone)
+	cancel()
+	select {
+	case <-ss.stop:
+		s.procs.Kill(processKey(server.Name))
+		proc.Wait()
+		return nil
+	default:
+	}
+	if err != nil {
+		// A closed connection usually means the process is exiting; report
+		// its exit status rather than the handshake error.
+		wait := time.After(0)
+		if errors.Is(err, ErrClientClosed) {
+			wait = time.After(time.Second)
+		}
+		select {
+		case <-proc.Done():
+			return exited()
+		case <-wait:
+		}
+		return fail(err)
+	}
+
+	s.update(ss, func(status *RuntimeStatus) {
+		status.State = ServerRunning
+		status.LastError = ""
+		status.ServerInfo = &result.ServerInfo
+		status.LastHealthCheck = time.Now().UnixMilli()
+	})
+
+	ticker := time.NewTicker(s.healthInterval)
+	defer ticker.Stop()
+	healthFails := 0
+	for {
+		select {
+		case <-ss.stop:
+			s.procs.Kill(processKey(server.Name))
+			proc.Wait()
+			return nil
+		case <-proc.Done():
+			return exited()
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), s.healthTimeout)
+			err := client.Ping(ctx)
+			cancel()
+			// An error reply still proves the server is responsive.
+			var rpcErr *RPCError
+			if err == nil || errors.As(err, &rpcErr) {
+				healthFails = 0
+				s.update(ss, func(status *RuntimeStatus) {
+					status.State = ServerRunning
+					status.LastHealthCheck = time.Now().UnixMilli()
+				})
+				continue
+			}
+			healthFails++
+			if healthFails >= s.maxHealthFails {
+				return fail(fmt.Errorf("health check failed: %w", err))
+			}
+			s.update(ss, func(status *RuntimeStatus) {
+				status.State = ServerUnhealthy
+				status.LastError = err.Error()
+			})
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"ropcode/internal/process"
+)
+
+// helperServerEnv selects the behaviour of the fake MCP server run by
+// TestHelperMCPServer.
+const helperServerEnv = "ROPCODE_MCP_HELPER"
+
+// TestHelperMCPServer is not a real test: when helperServerEnv is set the test
+// binary acts as a stdio MCP server.
+func TestHelperMCPServer(t *testing.T) {
+	mode := os.Getenv(helperServerEnv)
+	if mode == "" {
+		return
+	}
+	if mode == "crash" {
+		fmt.Fprintln(os.Stderr, "boom")
+		os.Exit(1)
+	}
+
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		var req struct {
+			ID     *int64          `json:"id"`
+			Method string          `json:"method"`
+			Params json.RawMessage `json:"params"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil || req.ID == nil {
+			continue
+		}
+		reply := map[string]interface{}{"jsonrpc": "2.0", "id": *req.ID}
+		switch req.Method {
+		case "initialize":
+			reply["result"] = map[string]interface{}{
+				"protocolVersion": ProtocolVersion,
+				"capabilities":    map[string]interface{}{"tools": map[string]interface{}{}},
+				"serverInfo":      map[string]string{"name": "helper", "version": "1.0"},
+			}
+		case "ping":
+			reply["result"] = map[string]interface{}{}
+		default:
+			if result, ok := helperToolResult(req.Method, req.Params); ok {
+				reply["result"] = result
+				break
+			}
+			reply["error"] = map[string]interface{}{"code": -32601, "message": "method not found"}
+		}
+		data, _ := json.Marshal(reply)
+		fmt.Println(string(data))
+	}
+	os.Exit(0)
+}
+
+func helperServer(name, mode string) *MCPServer {
+	return &MCPServer{
+		Name:      name,
+		Transport: "stdio",
+		Command:   os.Args[0],
+		Args:      []string{"-test.run=TestHelperMCPServer"},
+		Env:       map[string]string{helperServerEnv: mode},
+	}
+}
+
+// waitForState waits until a status with the wanted state is reported.
+func waitForState(t *testing.T, statuses <-chan RuntimeStatus, state string) RuntimeStatus {
+	t.Helper()
+	timeout := time.After(10 * time.Second)
+	for {
+		select {
+		case status := <-statuses:
+			if status.State == state {
+				return status
+			}
+		case <-timeout:
+			t.Fatalf("Timed out waiting for state %q", state)
+		}
+	}
+}
+
+func TestClient_InitializeAndPing(t *testing.T) {
+	server := helperServer("client", "serve")
+	status := make(chan RuntimeStatus, 64)
+	supervisor := NewSupervisor(process.NewManager(context.Background()), func(s RuntimeStatus) { status <- s })
+	if _, err := supervisor.Start(server, RestartNever); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer supervisor.StopAll()
+	waitForState(t, status, ServerRunning)
+
+	client, ok := supervisor.Client("client")
+	if !ok {
+		t.Fatal("Expected a client for the running server")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx); err != nil {
+		t.Fatalf("Ping failed: %v", err)
+	}
+	err := client.Call(ctx, "unknown/method", nil, nil)
+	if rpcErr, ok := err.(*RPCError); !ok || rpcErr.Code != -32601 {
+		t.Fatalf("Expected method-not-found RPC error, got %v", err)
+	}
+}
+
+func TestSupervisor_StartStop(t *testing.T) {
+	procs := process.NewManager(context.Background())
+	status := make(chan RuntimeStatus, 64)
+	supervisor := NewSupervisor(procs, func(s RuntimeStatus) { status <- s })
+
+	if _, err := supervisor.Start(helperServer("helper", "serve"), RestartNever); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	running := waitForState(t, status, ServerRunning)
+	if running.ServerInfo == nil || running.ServerInfo.Name != "helper" || running.PID == 0 {
+		t.Fatalf("Unexpected running status: %#v", running)
+	}
+	if !procs.IsAlive(processKey("helper")) {
+		t.Error("Expected the server process to be tracked by the process manager")
+	}
+
+	if err := supervisor.Stop("helper"); err != nil {
+		t.Fatalf("Stop failed: %v", err)
+	}
+	waitForState(t, status, ServerStopped)
+	if _, ok := supervisor.Status("helper"); ok {
+		t.Error("Expected stopped server to be removed")
+	}
+	if err := supervisor.Stop("helper"); err == nil {
+		t.Error("Expected error stopping a server that is not running")
+	}
+}
+
+func TestSupervisor_RestartsOnFailureThenGivesUp(t *testing.T) {
+	status := make(chan RuntimeStatus, 64)
+	supervisor := NewSupervisor(process.NewManager(context.Background()), func(s RuntimeStatus) { status <- s })
+	supervisor.backoffBase = 10 * time.Millisecond
+	supervisor.maxRestarts = 2
+
+	if _, err := supervisor.Start(helperServer("crashy", "crash"), RestartOnFailure); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	failed := waitForState(t, status, ServerFailed)
+	if failed.Restarts != 2 {
+		t.Errorf("Expected 2 restarts, got %d", failed.Restarts)
+	}
+	if !strings.Contains(failed.LastError, "boom") {
+		t.Errorf("Expected stderr in last error, got %q", failed.LastError)
+	}
+}
+
+func TestSupervisor_RejectsRemoteServers(t *testing.T) {
+	supervisor := NewSupervisor(process.NewManager(context.Background()), nil)
+	_, err := supervisor.Start(&MCPServer{Name: "remote", Transport: "sse", URL: "http://localhost:1/sse"}, RestartNever)
+	if err == nil {
+		t.Fatal("Expected error starting a remote server")
+	}
+}
+
+func TestParseRestartPolicy(t *testing.T) {
+	if policy, err := ParseRestartPolicy(""); err != nil || policy != RestartOnFailure {
+		t.Errorf("ParseRestartPolicy(\"\") = %v, %v", policy, err)
+	}
+	if _, err := ParseRestartPolicy("sometimes"); err == nil {
+		t.Error("Expected error for unknown policy")
+	}
+}
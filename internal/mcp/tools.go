@@ -0,0 +1,76 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// maxToolPages bounds tools/list pagination against misbehaving servers.
+const maxToolPages = 100
+
+// Tool is a tool advertised by an MCP server
+type Tool struct {
+	Name        string          `json:"name"`
+	Title       string          `json:"title,omitempty"`
+	Description string          `json:"description,omitempty"`
+	InputSchema json.RawMessage `json:"inputSchema,omitempty"`
+	Annotations json.RawMessage `json:"annotations,omitempty"`
+}
+
+// ToolContent is one content item of a tool result
+type ToolContent struct {
+	Type     string          `json:"type"`
+	Text     string          `json:"text,omitempty"`
+	Data     string          `json:"data,omitempty"`
+	MimeType string          `json:"mimeType,omitempty"`
+	Resource json.RawMessage `json:"resource,omitempty"`
+}
+
+// CallToolResult is the result of tools/call
+type CallToolResult struct {
+	Content           []ToolContent   `json:"content"`
+	StructuredContent json.RawMessage `json:"structuredContent,omitempty"`
+	IsError           bool            `json:"isError"`
+}
+
+// ListTools returns every tool of the server, following pagination cursors
+func (c *Client) ListTools(ctx context.Context) ([]Tool, error) {
+	tools := make([]Tool, 0)
+	cursor := ""
+	for page := 0; page < maxToolPages; page++ {
+		var params interface{}
+		if cursor != "" {
+			params = map[string]string{"cursor": cursor}
+		}
+		var result struct {
+			Tools      []Tool `json:"tools"`
+			NextCursor string `json:"nextCursor"`
+		}
+		if err := c.Call(ctx, "tools/list", params, &result); err != nil {
+			return nil, fmt.Errorf("tools/list failed: %w", err)
+		}
+		tools = append(tools, result.Tools...)
+		if result.NextCursor == "" {
+			return tools, nil
+		}
+		cursor = result.NextCursor
+	}
+	return tools, nil
+}
+
+// CallTool invokes a tool. arguments must be a JSON object or empty.
+func (c *Client) CallTool(ctx context.Context, name string, arguments json.RawMessage) (*CallToolResult, error) {
+	if len(arguments) == 0 {
+		arguments = json.RawMessage("{}")
+	}
+	params := map[string]interface{}{"name": name, "arguments": arguments}
+	var result CallToolResult
+	if err := c.Call(ctx, "tools/call", params, &result); err != nil {
+		return nil, fmt.Errorf("tools/call %s failed: %w", name, err)
+	}
+	if result.Content == nil {
+		result.Content = []ToolContent{}
+	}
+	return &result, nil
+}
@@ -0,0 +1,93 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// helperToolResult answers the tool requests of the fake MCP server: two
+// pages of tools and an "echo" tool that returns its "text" argument.
+func helperToolResult(method string, params json.RawMessage) (interface{}, bool) {
+	var p struct {
+		Cursor    string            `json:"cursor"`
+		Name      string            `json:"name"`
+		Arguments map[string]string `json:"arguments"`
+	}
+	json.Unmarshal(params, &p)
+	switch method {
+	case "tools/list":
+		if p.Cursor == "" {
+			return map[string]interface{}{
+				"tools": []map[string]interface{}{{
+					"name":        "echo",
+					"description": "Echoes its input",
+					"inputSchema": map[string]interface{}{"type": "object"},
+				}},
+				"nextCursor": "page-2",
+			}, true
+		}
+		return map[string]interface{}{
+			"tools": []map[string]interface{}{{"name": "fail"}},
+		}, true
+	case "tools/call":
+		if p.Name == "echo" {
+			return map[string]interface{}{
+				"content": []map[string]string{{"type": "text", "text": p.Arguments["text"]}},
+			}, true
+		}
+		return map[string]interface{}{
+			"content": []map[string]string{{"type": "text", "text": "unknown tool"}},
+			"isError": true,
+		}, true
+	}
+	return nil, false
+}
+
+func TestConnect_ListAndCallTools(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	client, info, err := Connect(ctx, helperServer("tools", "serve"))
+	if err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer client.Close()
+	if info.ServerInfo.Name != "helper" {
+		t.Errorf("Unexpected server info: %#v", info.ServerInfo)
+	}
+
+	tools, err := client.ListTools(ctx)
+	if err != nil {
+		t.Fatalf("ListTools failed: %v", err)
+	}
+	if len(tools) != 2 || tools[0].Name != "echo" || tools[1].Name != "fail" {
+		t.Fatalf("Expected tools from both pages, got %#v", tools)
+	}
+	if len(tools[0].InputSchema) == 0 {
+		t.Error("Expected the input schema to be kept")
+	}
+
+	result, err := client.CallTool(ctx, "echo", json.RawMessage(`{"text":"hello"}`))
+	if err != nil {
+		t.Fatalf("CallTool failed: %v", err)
+	}
+	if result.IsError || len(result.Content) != 1 || result.Content[0].Text != "hello" {
+		t.Errorf("Unexpected echo result: %#v", result)
+	}
+
+	result, err = client.CallTool(ctx, "fail", nil)
+	if err != nil {
+		t.Fatalf("CallTool failed: %v", err)
+	}
+	if !result.IsError {
+		t.Error("Expected tool error to be reported in the result")
+	}
+}
+
+func TestConnect_RequiresCommandOrURL(t *testing.T) {
+	if _, _, err := Connect(context.Background(), &MCPServer{Name: "empty"}); err == nil {
+		t.Fatal("Expected error for a server without command or URL")
+	}
+}
@@ -0,0 +1,92 @@
+// internal/models/capabilities.go
+package models
+
+import (
+	"strings"
+
+	"ropcode/internal/database"
+)
+
+// oneMContextWindow is the context window of the "[1m]" model variants
+const oneMContextWindow = 1_000_000
+
+// BuiltinCapabilities returns the capabilities shipped with this version.
+// Like pricing patterns, later releases of a family get a longer pattern.
+func BuiltinCapabilities() []*database.ModelCapabilities {
+	capability := func(pattern string, contextWindow, maxOutput int, vision, thinking bool, pricing string) *database.ModelCapabilities {
+		return &database.ModelCapabilities{
+			ModelPattern:     pattern,
+			ContextWindow:    contextWindow,
+			MaxOutputTokens:  maxOutput,
+			SupportsVision:   vision,
+			SupportsThinking: thinking,
+			PricingPattern:   pricing,
+			Source:           database.PricingSourceBuiltin,
+		}
+	}
+	return []*database.ModelCapabilities{
+		capability("sonnet", 200_000, 64_000, true, true, "sonnet"),
+		capability("opus", 200_000, 32_000, true, true, "opus"),
+		capability("opus-4-5", 200_000, 64_000, true, true, "opus-4-5"),
+		// The haiku alias has no thinking levels, see BuiltinModels
+		capability("haiku", 200_000, 8_192, true, false, "haiku"),
+		capability("haiku-4-5", 200_000, 64_000, true, true, "haiku-4-5"),
+		capability("gpt-5", 400_000, 128_000, true, true, ""),
+		capability("gemini-2.5", 1_048_576, 65_536, true, true, ""),
+		capability("gemini-3", 1_048_576, 65_536, true, true, ""),
+	}
+}
+
+var capabilitySourceRank = map[string]int{
+	database.PricingSourceBuiltin: 0,
+	database.PricingSourceRemote:  1,
+}
+
+// LookupCapabilities returns a copy of the capabilities of a model, or nil
+// for an unknown model. The longest matching pattern wins, then fetched
+// over builtin rows. "[1m]" variants take the capabilities of their base
+// model with a 1M token context window.
+func LookupCapabilities(capabilities []*database.ModelCapabilities, modelID string) *database.ModelCapabilities {
+	model := strings.ToLower(strings.TrimSpace(modelID))
+	base, oneM := strings.CutSuffix(model, "[1m]")
+
+	var best *database.ModelCapabilities
+	for _, c := range capabilities {
+		pattern := strings.ToLower(c.ModelPattern)
+		if pattern == "" || !strings.Contains(base, pattern) {
+			continue
+		}
+		if best == nil || len(c.ModelPattern) > len(best.ModelPattern) ||
+			len(c.ModelPattern) == len(best.ModelPattern) && capabilitySourceRank[c.Source] > capabilitySourceRank[best.Source] {
+			best = c
+		}
+	}
+	if best == nil {
+		return nil
+	}
+	found := *best
+	if oneM {
+		found.ContextWindow = oneMContextWindow
+	}
+	return &found
+}
+
+// Capabilities returns the builtin capability rows followed by the fetched
+// ones
+func (r *Registry) Capabilities() []*database.ModelCapabilities {
+	capabilities := BuiltinCapabilities()
+	if fetched, err := r.db.ListModelCapabilities(); err == nil {
+		capabilities = append(capabilities, fetched...)
+	}
+	return capabilities
+}
+
+// withCapabilities sets the capabilities of each config
+func (r *Registry) withCapabilities(configs ...*database.ModelConfig) {
+	capabilities := r.Capabilities()
+	for _, config := range configs {
+		if config != nil {
+			config.Capabilities = LookupCapabilities(capabilities, config.ModelID)
+		}
+	}
+}
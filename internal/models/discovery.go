@@ -0,0 +1,61 @@
+// internal/models/discovery.go
+package models
+
+import (
+	"sort"
+	"strings"
+
+	"ropcode/internal/database"
+)
+
+// Statuses of a model ID listed by a provider endpoint
+const (
+	DiscoveredNew         = "new"         // SyncProviderModels would add it
+	DiscoveredExists      = "exists"      // already a user-defined model
+	DiscoveredUnsupported = "unsupported" // not a chat model, never synced
+)
+
+// DiscoveredModel is a model ID listed by a provider endpoint, as
+// SyncProviderModels would treat it
+type DiscoveredModel struct {
+	ModelID     string `json:"model_id"`
+	DisplayName string `json:"display_name"`
+	Status      string `json:"status"`
+	// Capabilities are those the model would get, nil when unknown
+	Capabilities *database.ModelCapabilities `json:"capabilities,omitempty"`
+}
+
+// Discover classifies the model IDs a provider endpoint listed without
+// changing the registry. Models are returned sorted by ID, once each.
+func (r *Registry) Discover(providerID string, modelIDs []string) ([]*DiscoveredModel, error) {
+	capabilities := r.Capabilities()
+	discovered := make([]*DiscoveredModel, 0, len(modelIDs))
+	seen := make(map[string]bool)
+	for _, rawID := range modelIDs {
+		modelID := strings.TrimSpace(rawID)
+		if modelID == "" || seen[modelID] {
+			continue
+		}
+		seen[modelID] = true
+
+		model := &DiscoveredModel{
+			ModelID:      modelID,
+			DisplayName:  displayNameFromModelID(modelID),
+			Status:       DiscoveredNew,
+			Capabilities: LookupCapabilities(capabilities, modelID),
+		}
+		if builtin := GetBuiltinModel(modelID); builtin != nil {
+			model.DisplayName = builtin.DisplayName
+		}
+		if !isSupportedProviderModel(providerID, modelID) {
+			model.Status = DiscoveredUnsupported
+		} else if exists, err := r.db.ModelConfigExists(modelID); err != nil {
+			return nil, err
+		} else if exists {
+			model.Status = DiscoveredExists
+		}
+		discovered = append(discovered, model)
+	}
+	sort.Slice(discovered, func(i, j int) bool { return discovered[i].ModelID < discovered[j].ModelID })
+	return discovered, nil
+}
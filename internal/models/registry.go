@@ -342,6 +342,7 @@ func (r *Registry) GetAllModels() ([]*database.ModelConfig, error) {
 		result = append(result, m)
 	}
 
+	r.withCapabilities(result...)
 	return result, nil
 }
 
@@ -414,6 +415,7 @@ func (r *Registry) GetEnabledModels() ([]*database.ModelConfig, error) {
 		result = append(result, m)
 	}
 
+	r.withCapabilities(result...)
 	return result, nil
 }
 
@@ -454,6 +456,7 @@ func (r *Registry) GetModelsByProvider(providerID string) ([]*database.ModelConf
 		result = append(result, m)
 	}
 
+	r.withCapabilities(result...)
 	return result, nil
 }
 
@@ -462,22 +465,34 @@ func (r *Registry) GetModelsByProvider(providerID string) ([]*database.ModelConf
 func (r *Registry) GetModel(id string) (*database.ModelConfig, error) {
 	// Check builtin models first (use model_id as lookup)
 	if builtin := GetBuiltinModel(id); builtin != nil {
+		r.withCapabilities(builtin)
 		return builtin, nil
 	}
 
 	// Check database for user-defined model
-	return r.db.GetModelConfig(id)
+	config, err := r.db.GetModelConfig(id)
+	if err != nil {
+		return nil, err
+	}
+	r.withCapabilities(config)
+	return config, nil
 }
 
 // GetModelByModelID returns a model config by model_id
 func (r *Registry) GetModelByModelID(modelID string) (*database.ModelConfig, error) {
 	// Check builtin models first
 	if builtin := GetBuiltinModel(modelID); builtin != nil {
+		r.withCapabilities(builtin)
 		return builtin, nil
 	}
 
 	// Check database for user-defined model
-	return r.db.GetModelConfigByModelID(modelID)
+	config, err := r.db.GetModelConfigByModelID(modelID)
+	if err != nil {
+		return nil, err
+	}
+	r.withCapabilities(config)
+	return config, nil
 }
 
 // defaultModelSettingKey returns the settings key for storing a provider's default model
@@ -501,12 +516,18 @@ func (r *Registry) GetDefaultModel(providerID string) (*database.ModelConfig, er
 	// Fall back to builtin default
 	for _, m := range BuiltinModels() {
 		if m.ProviderID == providerID && m.IsDefault {
+			r.withCapabilities(m)
 			return m, nil
 		}
 	}
 
 	// Check database for user-defined default model
-	return r.db.GetDefaultModelConfig(providerID)
+	config, err := r.db.GetDefaultModelConfig(providerID)
+	if err != nil {
+		return nil, err
+	}
+	r.withCapabilities(config)
+	return config, nil
 }
 
 // CreateModel creates a new user-defined model
@@ -479,3 +479,81 @@ func TestGetAllModelsRestoresBuiltinsAfterAllUserModelsRemoved(t *testing.T) {
 		t.Fatal("expected builtin claude models to be visible again after user models removed")
 	}
 }
+
+func TestModelsCarryCapabilitiesFromBuiltinAndFetchedRows(t *testing.T) {
+	registry := newTestRegistry(t)
+
+	sonnet, err := registry.GetModelByModelID("sonnet[1m]")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := sonnet.Capabilities; c == nil || c.ContextWindow != 1_000_000 || !c.SupportsVision || !c.SupportsThinking || c.PricingPattern != "sonnet" {
+		t.Fatalf("sonnet[1m] capabilities = %+v", c)
+	}
+	if c := LookupCapabilities(BuiltinCapabilities(), "claude-haiku-4-5-20251001"); c == nil || c.ModelPattern != "haiku-4-5" {
+		t.Fatalf("haiku 4.5 capabilities = %+v", c)
+	}
+	if c := LookupCapabilities(BuiltinCapabilities(), "deepseek-chat"); c != nil {
+		t.Fatalf("unknown model capabilities = %+v", c)
+	}
+
+	if err := registry.db.ReplaceModelCapabilities([]*database.ModelCapabilities{
+		{ModelPattern: "sonnet", ContextWindow: 300_000, MaxOutputTokens: 64_000, SupportsThinking: true, LastVerified: "2026-10-01"},
+		{ModelPattern: "deepseek", ContextWindow: 128_000, MaxOutputTokens: 8_000},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := registry.SyncProviderModels("claude", []string{"deepseek-chat"}); err != nil {
+		t.Fatal(err)
+	}
+	all, err := registry.GetAllModels()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var deepseek *database.ModelConfig
+	for _, m := range all {
+		if m.ModelID == "deepseek-chat" {
+			deepseek = m
+		}
+	}
+	if deepseek == nil || deepseek.Capabilities == nil || deepseek.Capabilities.SupportsVision || deepseek.Capabilities.Source != database.PricingSourceRemote {
+		t.Fatalf("deepseek-chat = %+v", deepseek)
+	}
+
+	sonnet, err = registry.GetModelByModelID("sonnet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := sonnet.Capabilities; c.ContextWindow != 300_000 || c.SupportsVision || c.LastVerified != "2026-10-01" {
+		t.Fatalf("fetched sonnet capabilities = %+v", c)
+	}
+}
+
+func TestDiscoverClassifiesListedModelsWithoutSyncing(t *testing.T) {
+	registry := newTestRegistry(t)
+	if _, err := registry.SyncProviderModels("codex", []string{"gpt-5.5-fast"}); err != nil {
+		t.Fatal(err)
+	}
+
+	discovered, err := registry.Discover("codex", []string{"text-embedding-3-large", "gpt-5.5-fast", " gpt-5.5 ", "gpt-5.5", ""})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, m := range discovered {
+		got = append(got, m.ModelID+":"+m.Status)
+	}
+	want := []string{"gpt-5.5:new", "gpt-5.5-fast:exists", "text-embedding-3-large:unsupported"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("discovered = %v", got)
+	}
+	if discovered[0].DisplayName != "GPT-5.5" {
+		t.Fatalf("display name = %q", discovered[0].DisplayName)
+	}
+	if discovered[0].Capabilities == nil || discovered[0].Capabilities.ModelPattern != "gpt-5" {
+		t.Fatalf("capabilities = %+v", discovered[0].Capabilities)
+	}
+	if exists, _ := registry.db.ModelConfigExists("gpt-5.5"); exists {
+		t.Fatal("Discover synced a model")
+	}
+}
@@ -0,0 +1,214 @@
+// Package netproxy applies the user's proxy settings. Install routes every
+// request of http.DefaultTransport, and so of every client that doesn't
+// bring its own transport, through the current settings; Env exports them
+// to child processes such as the provider CLIs. While the settings are
+// disabled the usual proxy environment variables apply.
+package netproxy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/net/http/httpproxy"
+)
+
+// DefaultTestURL is requested by Test when no target is given
+const DefaultTestURL = "https://api.anthropic.com"
+
+// Settings is the proxy_settings setting. AllProxy is used for the
+// schemes without a proxy of their own; socks5 URLs are supported.
+type Settings struct {
+	Enabled    bool   `json:"enabled"`
+	HTTPProxy  string `json:"http_proxy"`
+	HTTPSProxy string `json:"https_proxy"`
+	NoProxy    string `json:"no_proxy"`
+	AllProxy   string `json:"all_proxy"`
+}
+
+// Parse decodes a stored proxy_settings value; empty means disabled
+func Parse(value string) (Settings, error) {
+	var s Settings
+	if strings.TrimSpace(value) == "" {
+		return s, nil
+	}
+	if err := json.Unmarshal([]byte(value), &s); err != nil {
+		return Settings{}, fmt.Errorf("invalid proxy settings: %w", err)
+	}
+	return s, nil
+}
+
+// Validate checks that the proxy URLs are usable
+func (s Settings) Validate() error {
+	for _, proxy := range []struct{ name, value string }{
+		{"HTTP proxy", s.HTTPProxy},
+		{"HTTPS proxy", s.HTTPSProxy},
+		{"all proxy", s.AllProxy},
+	} {
+		if proxy.value == "" {
+			continue
+		}
+		u, err := url.Parse(proxy.value)
+		if err != nil || u.Host == "" {
+			return fmt.Errorf("invalid %s: %q", proxy.name, proxy.value)
+		}
+		switch u.Scheme {
+		case "http", "https", "socks5", "socks5h":
+		default:
+			return fmt.Errorf("unsupported %s scheme: %q", proxy.name, u.Scheme)
+		}
+	}
+	return nil
+}
+
+func (s Settings) httpProxy() string  { return firstNonEmpty(s.HTTPProxy, s.AllProxy) }
+func (s Settings) httpsProxy() string { return firstNonEmpty(s.HTTPSProxy, s.AllProxy) }
+
+// ProxyFunc returns the proxy for each request under s, as if enabled
+func (s Settings) ProxyFunc() func(*url.URL) (*url.URL, error) {
+	config := httpproxy.Config{
+		HTTPProxy:  s.httpProxy(),
+		HTTPSProxy: s.httpsProxy(),
+		NoProxy:    s.NoProxy,
+	}
+	return config.ProxyFunc()
+}
+
+// Env returns the proxy environment variables of s, in both the upper and
+// lower case spellings tools look for; nil when disabled
+func (s Settings) Env() []string {
+	if !s.Enabled {
+		return nil
+	}
+	var env []string
+	for _, v := range []struct{ name, value string }{
+		{"HTTP_PROXY", s.httpProxy()},
+		{"HTTPS_PROXY", s.httpsProxy()},
+		{"ALL_PROXY", s.AllProxy},
+		{"NO_PROXY", s.NoProxy},
+	} {
+		if v.value != "" {
+			env = append(env, v.name+"="+v.value, strings.ToLower(v.name)+"="+v.value)
+		}
+	}
+	return env
+}
+
+type state struct {
+	settings Settings
+	proxy    func(*url.URL) (*url.URL, error)
+}
+
+var (
+	current     atomic.Pointer[state]
+	installOnce sync.Once
+)
+
+// Set makes s the current settings
+func Set(s Settings) {
+	current.Store(&state{settings: s, proxy: s.ProxyFunc()})
+}
+
+// Current returns the current settings
+func Current() Settings {
+	if st := current.Load(); st != nil {
+		return st.settings
+	}
+	return Settings{}
+}
+
+// Env returns the proxy environment variables of the current settings
+func Env() []string {
+	return Current().Env()
+}
+
+// Proxy is an http.Transport Proxy function following the current settings
+func Proxy(req *http.Request) (*url.URL, error) {
+	if st := current.Load(); st != nil && st.settings.Enabled {
+		return st.proxy(req.URL)
+	}
+	return http.ProxyFromEnvironment(req)
+}
+
+// Install makes http.DefaultTransport follow the current settings
+func Install() {
+	installOnce.Do(func() {
+		if transport, ok := http.DefaultTransport.(*http.Transport); ok {
+			transport.Proxy = Proxy
+		}
+	})
+}
+
+// TestResult is the outcome of Test
+type TestResult struct {
+	OK         bool   `json:"ok"`
+	URL        string `json:"url"`
+	Proxy      string `json:"proxy"` // empty for a direct connection
+	StatusCode int    `json:"status_code,omitempty"`
+	DurationMS int64  `json:"duration_ms"`
+	Error      string `json:"error,omitempty"`
+}
+
+// Test requests target, DefaultTestURL when empty, through s as if it
+// were enabled. Any HTTP response counts as success: it shows that the
+// proxy let the request through.
+func Test(ctx context.Context, s Settings, target string) TestResult {
+	if target == "" {
+		target = DefaultTestURL
+	}
+	result := TestResult{URL: target}
+	if err := s.Validate(); err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	u, err := url.Parse(target)
+	if err != nil || u.Host == "" {
+		result.Error = fmt.Sprintf("invalid URL: %q", target)
+		return result
+	}
+	proxy, err := s.ProxyFunc()(u)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	if proxy != nil {
+		result.Proxy = proxy.Redacted()
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = func(*http.Request) (*url.URL, error) { return proxy, nil }
+	defer transport.CloseIdleConnections()
+	client := &http.Client{Transport: transport, Timeout: 15 * time.Second}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, target, nil)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	start := time.Now()
+	resp, err := client.Do(req)
+	result.DurationMS = time.Since(start).Milliseconds()
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	resp.Body.Close()
+	result.OK = true
+	result.StatusCode = resp.StatusCode
+	return result
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,89 @@
+package netproxy
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestParseAndEnv(t *testing.T) {
+	s, err := Parse(`{"enabled":true,"http_proxy":null,"https_proxy":"http://proxy:8080","no_proxy":"localhost,.corp","all_proxy":"socks5://socks:1080"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"HTTP_PROXY=socks5://socks:1080", "http_proxy=socks5://socks:1080",
+		"HTTPS_PROXY=http://proxy:8080", "https_proxy=http://proxy:8080",
+		"ALL_PROXY=socks5://socks:1080", "all_proxy=socks5://socks:1080",
+		"NO_PROXY=localhost,.corp", "no_proxy=localhost,.corp",
+	}
+	if got := s.Env(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Env() = %q, want %q", got, want)
+	}
+
+	s.Enabled = false
+	if env := s.Env(); env != nil {
+		t.Errorf("Env() while disabled = %q", env)
+	}
+	if s, err := Parse(""); err != nil || s.Enabled {
+		t.Errorf("Parse(\"\") = %+v, %v", s, err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	for _, s := range []Settings{
+		{HTTPProxy: "proxy:8080"},
+		{AllProxy: "ftp://proxy:21"},
+	} {
+		if err := s.Validate(); err == nil {
+			t.Errorf("Validate(%+v) accepted", s)
+		}
+	}
+	if err := (Settings{HTTPSProxy: "http://user:pw@proxy:8080", AllProxy: "socks5h://socks:1080"}).Validate(); err != nil {
+		t.Errorf("Validate() = %v", err)
+	}
+}
+
+func TestProxyFollowsCurrentSettings(t *testing.T) {
+	t.Cleanup(func() { Set(Settings{}) })
+	t.Setenv("HTTPS_PROXY", "")
+	t.Setenv("https_proxy", "")
+	req, _ := http.NewRequest(http.MethodGet, "https://api.example.com/v1", nil)
+
+	Set(Settings{Enabled: true, HTTPSProxy: "http://proxy:8080", NoProxy: ".internal"})
+	if u, err := Proxy(req); err != nil || u == nil || u.Host != "proxy:8080" {
+		t.Fatalf("Proxy() = %v, %v", u, err)
+	}
+	bypassed, _ := http.NewRequest(http.MethodGet, "https://git.internal/repo", nil)
+	if u, _ := Proxy(bypassed); u != nil {
+		t.Errorf("Proxy() for a no_proxy host = %v", u)
+	}
+
+	Set(Settings{HTTPSProxy: "http://proxy:8080"})
+	if u, _ := Proxy(req); u != nil {
+		t.Errorf("Proxy() while disabled = %v", u)
+	}
+}
+
+func TestTestGoesThroughProxy(t *testing.T) {
+	var requested string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = r.URL.String()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer proxy.Close()
+
+	result := Test(context.Background(), Settings{HTTPProxy: proxy.URL}, "http://upstream.example/ping")
+	if !result.OK || result.StatusCode != http.StatusNoContent || result.Proxy != proxy.URL {
+		t.Fatalf("Test() = %+v", result)
+	}
+	if requested != "http://upstream.example/ping" {
+		t.Errorf("proxy received %q", requested)
+	}
+
+	if result := Test(context.Background(), Settings{HTTPProxy: "nope"}, ""); result.OK || result.Error == "" {
+		t.Errorf("Test() with an invalid proxy = %+v", result)
+	}
+}
@@ -0,0 +1,48 @@
+//go:build !darwin && !windows
+
+package notify
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Notifications go through notify-send from libnotify, which talks to
+// whichever notification daemon the desktop runs
+
+func send(title, body string) error {
+	if os.Getenv("DISPLAY") == "" && os.Getenv("WAYLAND_DISPLAY") == "" {
+		return ErrUnavailable
+	}
+	path, err := exec.LookPath("notify-send")
+	if err != nil {
+		return ErrUnavailable
+	}
+	if output, err := exec.Command(path, "--app-name", appName, "--", title, body).CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to show notification: %v: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+func soundCommand(sound string) (*exec.Cmd, error) {
+	if filepath.IsAbs(sound) {
+		for _, player := range []string{"paplay", "pw-play", "aplay"} {
+			if path, err := exec.LookPath(player); err == nil {
+				return exec.Command(path, sound), nil
+			}
+		}
+		return nil, ErrUnavailable
+	}
+	// Named sounds come from the freedesktop sound theme
+	if sound == DefaultSound {
+		sound = "complete"
+	}
+	path, err := exec.LookPath("canberra-gtk-play")
+	if err != nil {
+		return nil, ErrUnavailable
+	}
+	return exec.Command(path, "--id", sound), nil
+}
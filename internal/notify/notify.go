@@ -0,0 +1,42 @@
+// Package notify shows desktop notifications through the operating system:
+// Notification Center on macOS, the freedesktop notification service on
+// Linux and toast notifications on Windows. It also plays alert sounds.
+package notify
+
+import (
+	"errors"
+	"fmt"
+)
+
+// appName is the sender shown with every notification
+const appName = "ropcode"
+
+// DefaultSound is the system's own alert sound
+const DefaultSound = "default"
+
+// ErrUnavailable is returned when the system has no way to show
+// notifications, e.g. a headless server
+var ErrUnavailable = errors.New("desktop notifications are not available")
+
+// Send shows a notification with a title and a body
+func Send(title, body string) error {
+	return send(title, body)
+}
+
+// PlaySound starts playing a sound without waiting for it: DefaultSound, a
+// system sound name (e.g. "Glass" on macOS, "complete" on Linux) or the path
+// of a sound file. An empty sound plays nothing.
+func PlaySound(sound string) error {
+	if sound == "" {
+		return nil
+	}
+	cmd, err := soundCommand(sound)
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to play sound: %w", err)
+	}
+	go cmd.Wait()
+	return nil
+}
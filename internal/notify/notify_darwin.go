@@ -0,0 +1,32 @@
+//go:build darwin
+
+package notify
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+func send(title, body string) error {
+	// Text is passed as arguments so it needs no AppleScript quoting
+	script := `on run argv
+	display notification (item 2 of argv) with title (item 1 of argv)
+end run`
+	if output, err := exec.Command("osascript", "-e", script, title, body).CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to show notification: %v: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+func soundCommand(sound string) (*exec.Cmd, error) {
+	path := sound
+	switch {
+	case sound == DefaultSound:
+		path = "/System/Library/Sounds/Glass.aiff"
+	case !filepath.IsAbs(sound):
+		path = filepath.Join("/System/Library/Sounds", sound+".aiff")
+	}
+	return exec.Command("afplay", path), nil
+}
@@ -0,0 +1,65 @@
+//go:build windows
+
+package notify
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+// createNoWindow keeps PowerShell from flashing a console window
+const createNoWindow = 0x08000000
+
+// toastScript shows a two-line toast; the text comes from the environment
+// so it needs no PowerShell quoting
+const toastScript = `
+[Windows.UI.Notifications.ToastNotificationManager, Windows.UI.Notifications, ContentType = WindowsRuntime] > $null
+$template = [Windows.UI.Notifications.ToastNotificationManager]::GetTemplateContent([Windows.UI.Notifications.ToastTemplateType]::ToastText02)
+$texts = $template.GetElementsByTagName('text')
+$texts.Item(0).AppendChild($template.CreateTextNode($env:ROPCODE_NOTIFY_TITLE)) > $null
+$texts.Item(1).AppendChild($template.CreateTextNode($env:ROPCODE_NOTIFY_BODY)) > $null
+[Windows.UI.Notifications.ToastNotificationManager]::CreateToastNotifier($env:ROPCODE_NOTIFY_APP).Show([Windows.UI.Notifications.ToastNotification]::new($template))
+`
+
+func send(title, body string) error {
+	cmd := exec.Command("powershell.exe", "-NoProfile", "-NonInteractive", "-Command", toastScript)
+	cmd.Env = append(os.Environ(),
+		"ROPCODE_NOTIFY_APP="+appName,
+		"ROPCODE_NOTIFY_TITLE="+title,
+		"ROPCODE_NOTIFY_BODY="+body,
+	)
+	cmd.SysProcAttr = &syscall.SysProcAttr{CreationFlags: createNoWindow}
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to show notification: %v: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// soundScript plays a system sound by name or a WAV file by path, both
+// passed through the environment
+const soundScript = `
+if ($env:ROPCODE_SOUND_FILE) {
+	(New-Object System.Media.SoundPlayer $env:ROPCODE_SOUND_FILE).PlaySync()
+} else {
+	[System.Media.SystemSounds]::($env:ROPCODE_SOUND_NAME).Play()
+	Start-Sleep -Milliseconds 500
+}
+`
+
+func soundCommand(sound string) (*exec.Cmd, error) {
+	cmd := exec.Command("powershell.exe", "-NoProfile", "-NonInteractive", "-Command", soundScript)
+	switch {
+	case sound == DefaultSound:
+		cmd.Env = append(os.Environ(), "ROPCODE_SOUND_NAME=Asterisk")
+	case filepath.IsAbs(sound):
+		cmd.Env = append(os.Environ(), "ROPCODE_SOUND_FILE="+sound)
+	default:
+		cmd.Env = append(os.Environ(), "ROPCODE_SOUND_NAME="+sound)
+	}
+	cmd.SysProcAttr = &syscall.SysProcAttr{CreationFlags: createNoWindow}
+	return cmd, nil
+}
@@ -0,0 +1,237 @@
+// Package outputimages moves the images in session output out of the
+// transcript so the UI can show them inline. Base64 image blocks, which
+// Claude's Read tool and MCP screenshot tools return, are written to the
+// session's attachments directory; tool results that name an image file on
+// disk, such as a plot or screenshot a command saved, get an image block
+// for a copy of it. Either way the block then points at the stored file
+// with a /local-file/ URL, which keeps megabytes of base64 out of the
+// events sent to the UI and keeps the image after the original is gone.
+//
+// Messages are expected in the Claude format the Codex and Gemini sessions
+// are converted to. Files are named after their content, so rewriting the
+// same transcript again stores nothing new.
+package outputimages
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"ropcode/internal/attachments"
+)
+
+// maxFileImages limits the image files taken from one tool result
+const maxFileImages = 8
+
+// extensions maps the image types rewritten to the extension they're
+// saved with
+var extensions = map[string]string{
+	"image/png":  ".png",
+	"image/jpeg": ".jpg",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+// imagePathPattern finds absolute paths of image files in tool output
+var imagePathPattern = regexp.MustCompile(`(?i)(?:^|[\s"'(\[=:])((?:[a-z]:[\\/]|/)[^\s"'()\[\]<>|*?]*\.(?:png|jpe?g|gif|webp))\b`)
+
+// Store writes output images below Root, a directory per session
+type Store struct {
+	Root string
+}
+
+// LocalFileURL returns the URL the UI loads the file at path from
+func LocalFileURL(path string) string {
+	return "/local-file/" + url.PathEscape(path)
+}
+
+// RewriteLine rewrites the images of one claude-output JSON line, returning
+// the line unchanged when it has none
+func (s *Store) RewriteLine(line string) string {
+	if !mayHaveImages(line) {
+		return line
+	}
+	var event map[string]interface{}
+	if err := json.Unmarshal([]byte(line), &event); err != nil {
+		return line
+	}
+	message, _ := event["message"].(map[string]interface{})
+	sessionID, _ := event["session_id"].(string)
+	if message == nil || !s.RewriteMessage(sessionID, message) {
+		return line
+	}
+	rewritten, err := json.Marshal(event)
+	if err != nil {
+		return line
+	}
+	return string(rewritten)
+}
+
+// RewriteMessage rewrites the images in the content of message, a
+// {"role", "content"} map, and reports whether it changed anything
+func (s *Store) RewriteMessage(sessionID string, message map[string]interface{}) bool {
+	blocks, _ := message["content"].([]interface{})
+	changed := false
+	for _, block := range blocks {
+		block, _ := block.(map[string]interface{})
+		switch block["type"] {
+		case "image":
+			changed = s.rewriteImage(sessionID, block) || changed
+		case "tool_result":
+			changed = s.rewriteToolResult(sessionID, block) || changed
+		}
+	}
+	return changed
+}
+
+// mayHaveImages is a cheap check that skips decoding lines that can't
+// contain an image
+func mayHaveImages(line string) bool {
+	if strings.Contains(line, `"base64"`) {
+		return true
+	}
+	lower := strings.ToLower(line)
+	for _, ext := range []string{".png", ".jpg", ".jpeg", ".gif", ".webp"} {
+		if strings.Contains(lower, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// rewriteImage stores a base64 image block and points it at the file
+func (s *Store) rewriteImage(sessionID string, block map[string]interface{}) bool {
+	source, _ := block["source"].(map[string]interface{})
+	if source == nil || source["type"] != "base64" {
+		return false
+	}
+	mediaType, _ := source["media_type"].(string)
+	ext, ok := extensions[mediaType]
+	if !ok {
+		return false
+	}
+	encoded, _ := source["data"].(string)
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(data) == 0 {
+		return false
+	}
+	path, err := s.store(sessionID, data, ext)
+	if err != nil {
+		return false
+	}
+	block["source"] = urlSource(path)
+	return true
+}
+
+// rewriteToolResult rewrites the image blocks of a tool result, or when it
+// has none adds one for each image file its text names
+func (s *Store) rewriteToolResult(sessionID string, block map[string]interface{}) bool {
+	var text []string
+	var content []interface{}
+	switch c := block["content"].(type) {
+	case string:
+		text = append(text, c)
+		content = []interface{}{map[string]interface{}{"type": "text", "text": c}}
+	case []interface{}:
+		hasImages, changed := false, false
+		for _, item := range c {
+			item, _ := item.(map[string]interface{})
+			switch item["type"] {
+			case "image":
+				hasImages = true
+				changed = s.rewriteImage(sessionID, item) || changed
+			case "text":
+				if t, ok := item["text"].(string); ok {
+					text = append(text, t)
+				}
+			}
+		}
+		if hasImages {
+			return changed
+		}
+		content = c
+	default:
+		return false
+	}
+
+	images := s.fileImages(sessionID, strings.Join(text, "\n"))
+	if len(images) == 0 {
+		return false
+	}
+	block["content"] = append(content, images...)
+	return true
+}
+
+// fileImages stores the image files named in text and returns an image
+// block for each
+func (s *Store) fileImages(sessionID, text string) []interface{} {
+	var images []interface{}
+	seen := make(map[string]bool)
+	for _, match := range imagePathPattern.FindAllStringSubmatch(text, -1) {
+		if len(images) == maxFileImages {
+			break
+		}
+		path := filepath.Clean(match[1])
+		if seen[path] || s.isStored(path) {
+			continue
+		}
+		seen[path] = true
+		info, err := os.Stat(path)
+		if err != nil || !info.Mode().IsRegular() || info.Size() > attachments.MaxSize {
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		stored, err := s.store(sessionID, data, strings.ToLower(filepath.Ext(path)))
+		if err != nil {
+			continue
+		}
+		source := urlSource(stored)
+		source["original_path"] = path
+		images = append(images, map[string]interface{}{"type": "image", "source": source})
+	}
+	return images
+}
+
+// isStored reports whether path is already one of the stored images
+func (s *Store) isStored(path string) bool {
+	rel, err := filepath.Rel(s.Root, path)
+	return err == nil && !strings.HasPrefix(rel, "..")
+}
+
+// store writes data to the session's directory, named after its hash, and
+// returns the path
+func (s *Store) store(sessionID string, data []byte, ext string) (string, error) {
+	if ext == ".jpeg" {
+		ext = ".jpg"
+	}
+	sum := sha256.Sum256(data)
+	dir := attachments.SessionDir(s.Root, sessionID)
+	path := filepath.Join(dir, "output-"+hex.EncodeToString(sum[:8])+ext)
+	if _, err := os.Stat(path); err == nil {
+		return path, nil
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+func urlSource(path string) map[string]interface{} {
+	return map[string]interface{}{
+		"type": "url",
+		"url":  LocalFileURL(path),
+		"path": path,
+	}
+}
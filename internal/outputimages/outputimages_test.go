@@ -0,0 +1,102 @@
+package outputimages
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// pngData is a 1x1 PNG
+var pngData, _ = base64.StdEncoding.DecodeString(
+	"iVBORw0KGgoAAAANSUhEUgAAAAEAAAABCAYAAAAfFcSJAAAADUlEQVR42mNk+M9QDwADhgGAWjR9awAAAABJRU5ErkJggg==")
+
+func TestRewriteLineStoresBase64Images(t *testing.T) {
+	store := &Store{Root: t.TempDir()}
+	line := `{"type":"user","session_id":"s1","message":{"role":"user","content":[` +
+		`{"type":"tool_result","tool_use_id":"t1","content":[{"type":"image","source":{"type":"base64","media_type":"image/png","data":"` +
+		base64.StdEncoding.EncodeToString(pngData) + `"}}]}]}}`
+
+	rewritten := store.RewriteLine(line)
+	if strings.Contains(rewritten, `"base64"`) {
+		t.Fatalf("base64 left in %s", rewritten)
+	}
+	var event struct {
+		Message struct {
+			Content []struct {
+				Content []struct {
+					Source map[string]string `json:"source"`
+				} `json:"content"`
+			} `json:"content"`
+		} `json:"message"`
+	}
+	if err := json.Unmarshal([]byte(rewritten), &event); err != nil {
+		t.Fatal(err)
+	}
+	source := event.Message.Content[0].Content[0].Source
+	path := source["path"]
+	if source["type"] != "url" || source["url"] != LocalFileURL(path) || filepath.Dir(path) != filepath.Join(store.Root, "s1") {
+		t.Fatalf("source = %v", source)
+	}
+	if data, err := os.ReadFile(path); err != nil || string(data) != string(pngData) {
+		t.Fatalf("stored image: %v", err)
+	}
+
+	// The same image is stored once
+	if again := store.RewriteLine(line); again != rewritten {
+		t.Fatalf("second rewrite differs:\n%s\n%s", again, rewritten)
+	}
+	entries, _ := os.ReadDir(filepath.Join(store.Root, "s1"))
+	if len(entries) != 1 {
+		t.Fatalf("stored %d files, want 1", len(entries))
+	}
+}
+
+func TestRewriteMessageAddsImagesForFilesInToolOutput(t *testing.T) {
+	store := &Store{Root: filepath.Join(t.TempDir(), "attachments")}
+	plot := filepath.Join(t.TempDir(), "plot.png")
+	if err := os.WriteFile(plot, pngData, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	message := map[string]interface{}{
+		"role": "user",
+		"content": []interface{}{
+			map[string]interface{}{
+				"type":        "tool_result",
+				"tool_use_id": "t1",
+				"content":     "Saved figure to " + plot + "\nmissing: /nowhere/else.png",
+			},
+		},
+	}
+	if !store.RewriteMessage("s1", message) {
+		t.Fatal("RewriteMessage reported no change")
+	}
+	result := message["content"].([]interface{})[0].(map[string]interface{})
+	content := result["content"].([]interface{})
+	if len(content) != 2 {
+		t.Fatalf("content = %v", content)
+	}
+	if text := content[0].(map[string]interface{})["text"]; !strings.HasPrefix(text.(string), "Saved figure") {
+		t.Fatalf("text block = %v", content[0])
+	}
+	source := content[1].(map[string]interface{})["source"].(map[string]interface{})
+	if source["original_path"] != plot || !strings.HasPrefix(source["path"].(string), store.Root) {
+		t.Fatalf("image source = %v", source)
+	}
+}
+
+func TestRewriteLineLeavesOtherOutputAlone(t *testing.T) {
+	store := &Store{Root: t.TempDir()}
+	for _, line := range []string{
+		`{"type":"assistant","session_id":"s1","message":{"content":[{"type":"text","text":"hello"}]}}`,
+		`{"type":"user","session_id":"s1","message":{"content":[{"type":"tool_result","content":"see /nowhere/x.png"}]}}`,
+		`not json .png`,
+	} {
+		if got := store.RewriteLine(line); got != line {
+			t.Errorf("RewriteLine(%s) = %s", line, got)
+		}
+	}
+}
@@ -0,0 +1,653 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"ropcode/internal/fileops"
+	"ropcode/internal/git"
+)
+
+// Install sources take the form "name@marketplace", optionally pinned with
+// "#ref" (a tag, branch or commit). The marketplace is the name of a known
+// marketplace, a GitHub "owner/repo", a git URL or a local directory;
+// unknown marketplaces are cloned and registered the way the CLI's
+// "/plugin marketplace add" does.
+
+// ErrNotInstalled is returned for a plugin ID with no install record
+var ErrNotInstalled = errors.New("plugin is not installed")
+
+// Progress is one step of an install, update or uninstall
+type Progress struct {
+	PluginID string `json:"plugin_id"`
+	Stage    string `json:"stage"` // marketplace, fetch, install, register, done
+	Message  string `json:"message"`
+}
+
+// ProgressFunc receives progress as an operation advances
+type ProgressFunc func(Progress)
+
+// MarketplaceSource says where a marketplace comes from
+type MarketplaceSource struct {
+	Source string `json:"source"` // github, git or directory
+	Repo   string `json:"repo,omitempty"`
+	URL    string `json:"url,omitempty"`
+	Path   string `json:"path,omitempty"`
+}
+
+// KnownMarketplace is an entry of known_marketplaces.json
+type KnownMarketplace struct {
+	Source          MarketplaceSource `json:"source"`
+	InstallLocation string            `json:"installLocation"`
+	LastUpdated     string            `json:"lastUpdated"`
+}
+
+var (
+	githubRepo   = regexp.MustCompile(`^[A-Za-z0-9_.-]+/[A-Za-z0-9_.-]+$`)
+	validSegment = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+)
+
+// Install installs a plugin from a marketplace source and enables it. An
+// installed plugin is reinstalled, which is how a pin is added or changed.
+func (m *Manager) Install(ctx context.Context, source string, progress ProgressFunc) (*Plugin, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	name, spec, ref, err := parseSource(source)
+	if err != nil {
+		return nil, err
+	}
+	report := reporter(name+"@"+spec, progress)
+	mkt, err := m.resolveMarketplace(ctx, spec, report)
+	if err != nil {
+		return nil, err
+	}
+	return m.install(ctx, name, mkt, ref, report)
+}
+
+// Update reinstalls a plugin from its marketplace. A pinned plugin stays
+// on its ref, which still moves when the ref is a branch.
+func (m *Manager) Update(ctx context.Context, id string, progress ProgressFunc) (*Plugin, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	record, err := m.installRecord(id)
+	if err != nil {
+		return nil, err
+	}
+	name, mktName, ok := strings.Cut(id, "@")
+	if !ok {
+		return nil, fmt.Errorf("invalid plugin ID format: %s", id)
+	}
+	report := reporter(id, progress)
+	mkt, err := m.resolveMarketplace(ctx, mktName, report)
+	if err != nil {
+		return nil, err
+	}
+	return m.install(ctx, name, mkt, record.PinnedRef, report)
+}
+
+// Uninstall removes a plugin's install records, its files under the plugin
+// cache and its enabledPlugins entry
+func (m *Manager) Uninstall(id string, progress ProgressFunc) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	report := reporter(id, progress)
+	file, err := m.readInstalledFile()
+	if err != nil {
+		return err
+	}
+	raw, ok := file.Plugins[id]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrNotInstalled, id)
+	}
+	var records []InstalledPluginRecord
+	if err := json.Unmarshal(raw, &records); err != nil {
+		return fmt.Errorf("failed to parse install record of %s: %w", id, err)
+	}
+
+	delete(file.Plugins, id)
+	report("register", "Removing install record")
+	if err := m.writeInstalledFile(file); err != nil {
+		return err
+	}
+	if err := m.setEnabled(id, false); err != nil {
+		return err
+	}
+	for _, record := range records {
+		m.removeCached(record.InstallPath)
+	}
+	report("done", "Uninstalled")
+	return nil
+}
+
+// marketplace is a marketplace checkout ready to install from
+type marketplace struct {
+	name string
+	dir  string
+	git  bool
+	file MarketplaceFile
+}
+
+// resolveMarketplace finds the marketplace named by spec, cloning and
+// registering it when it isn't known yet, and refreshes git checkouts
+func (m *Manager) resolveMarketplace(ctx context.Context, spec string, report func(string, string)) (*marketplace, error) {
+	known, err := m.readKnownMarketplaces()
+	if err != nil {
+		return nil, err
+	}
+
+	var mkt *marketplace
+	if entry, ok := known[spec]; ok {
+		mkt = &marketplace{name: spec, dir: entry.InstallLocation, git: entry.Source.Source != "directory"}
+		if mkt.git {
+			report("marketplace", "Updating marketplace "+spec)
+			if _, err := runGit(ctx, mkt.dir, "pull", "--ff-only", "--quiet"); err != nil {
+				// An offline install can still use the checkout on disk
+				report("marketplace", fmt.Sprintf("Couldn't update marketplace %s, using the local copy: %v", spec, err))
+			}
+		}
+	} else {
+		source, err := parseMarketplaceSource(spec)
+		if err != nil {
+			return nil, err
+		}
+		if mkt, err = m.addMarketplace(ctx, source, known, report); err != nil {
+			return nil, err
+		}
+	}
+
+	data, err := os.ReadFile(filepath.Join(mkt.dir, ".claude-plugin", "marketplace.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read marketplace.json of %s: %w", mkt.name, err)
+	}
+	if err := json.Unmarshal(data, &mkt.file); err != nil {
+		return nil, fmt.Errorf("failed to parse marketplace.json of %s: %w", mkt.name, err)
+	}
+	return mkt, nil
+}
+
+// addMarketplace clones or links a new marketplace under the name its
+// marketplace.json gives and records it in known_marketplaces.json
+func (m *Manager) addMarketplace(ctx context.Context, source MarketplaceSource, known map[string]KnownMarketplace, report func(string, string)) (*marketplace, error) {
+	mkt := &marketplace{dir: source.Path}
+	if source.Source != "directory" {
+		url := source.URL
+		if source.Source == "github" {
+			url = "https://github.com/" + source.Repo + ".git"
+		}
+		report("marketplace", "Cloning marketplace "+url)
+		root := filepath.Join(m.pluginsDir, "marketplaces")
+		if err := os.MkdirAll(root, 0755); err != nil {
+			return nil, err
+		}
+		tmp, err := os.MkdirTemp(root, ".clone-")
+		if err != nil {
+			return nil, err
+		}
+		defer os.RemoveAll(tmp)
+		if _, err := runGit(ctx, "", "clone", "--quiet", "--", url, tmp); err != nil {
+			return nil, fmt.Errorf("failed to clone marketplace %s: %w", url, err)
+		}
+		mkt.dir, mkt.git = tmp, true
+	}
+
+	name, err := marketplaceName(mkt.dir)
+	if err != nil {
+		return nil, err
+	}
+	if entry, ok := known[name]; ok && entry.Source != source {
+		return nil, fmt.Errorf("a different marketplace named %s is already added", name)
+	}
+	mkt.name = name
+	if mkt.git {
+		dir := filepath.Join(m.pluginsDir, "marketplaces", name)
+		if err := os.RemoveAll(dir); err != nil {
+			return nil, err
+		}
+		if err := os.Rename(mkt.dir, dir); err != nil {
+			return nil, err
+		}
+		mkt.dir = dir
+	}
+
+	known[name] = KnownMarketplace{Source: source, InstallLocation: mkt.dir, LastUpdated: now()}
+	if err := m.writeKnownMarketplaces(known); err != nil {
+		return nil, err
+	}
+	return mkt, nil
+}
+
+// install fetches a plugin out of a marketplace into the plugin cache and
+// records it, replacing an earlier install
+func (m *Manager) install(ctx context.Context, name string, mkt *marketplace, pin string, report func(string, string)) (*Plugin, error) {
+	id := name + "@" + mkt.name
+	var entry *MarketplacePlugin
+	for i := range mkt.file.Plugins {
+		if mkt.file.Plugins[i].Name == name {
+			entry = &mkt.file.Plugins[i]
+			break
+		}
+	}
+	if entry == nil {
+		return nil, fmt.Errorf("plugin %s not found in marketplace %s", name, mkt.name)
+	}
+
+	pluginDir := filepath.Join(m.pluginsDir, "cache", mkt.name, name)
+	if err := os.MkdirAll(pluginDir, 0755); err != nil {
+		return nil, err
+	}
+	staging, err := os.MkdirTemp(pluginDir, ".staging-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(staging)
+
+	report("fetch", "Fetching "+id)
+	fetched := filepath.Join(staging, "plugin")
+	sha, err := fetchPlugin(ctx, entry.Source, mkt, pin, fetched)
+	if err != nil {
+		return nil, err
+	}
+	os.RemoveAll(filepath.Join(fetched, ".git"))
+
+	version := entry.Version
+	if metadata, err := m.readPluginMetadata(fetched); err == nil && metadata.Version != "" && metadata.Name == name {
+		version = metadata.Version
+	}
+	if version == "" && sha != "" {
+		version = sha[:min(len(sha), 12)]
+	}
+	if version == "" {
+		version = "unknown"
+	}
+	if !validSegment.MatchString(version) {
+		return nil, fmt.Errorf("plugin %s has an invalid version %q", id, version)
+	}
+
+	previous, _ := m.installRecord(id)
+	if previous != nil && previous.GitCommitSha != "" && previous.GitCommitSha == sha &&
+		previous.Version == version && previous.PinnedRef == pin {
+		report("done", "Already up to date")
+		return m.GetDetails(id)
+	}
+
+	report("install", "Installing version "+version)
+	installPath := filepath.Join(pluginDir, version)
+	if err := os.RemoveAll(installPath); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(fetched, installPath); err != nil {
+		return nil, err
+	}
+
+	record := InstalledPluginRecord{
+		Scope:        "user",
+		InstallPath:  installPath,
+		Version:      version,
+		InstalledAt:  now(),
+		LastUpdated:  now(),
+		GitCommitSha: sha,
+		IsLocal:      !mkt.git,
+		PinnedRef:    pin,
+	}
+	if previous != nil && previous.InstalledAt != "" {
+		record.InstalledAt = previous.InstalledAt
+	}
+	report("register", "Registering "+id)
+	if err := m.writeRecord(id, record); err != nil {
+		return nil, err
+	}
+	if err := m.setEnabled(id, true); err != nil {
+		return nil, err
+	}
+	if previous != nil && previous.InstallPath != installPath {
+		m.removeCached(previous.InstallPath)
+	}
+	report("done", "Installed "+id+" "+version)
+	return m.GetDetails(id)
+}
+
+// fetchPlugin copies a plugin at pin (or the ref its entry gives) into dst
+// and returns the commit it came from, when it came from git
+func fetchPlugin(ctx context.Context, source any, mkt *marketplace, pin, dst string) (string, error) {
+	var subdir, url, ref string
+	switch s := source.(type) {
+	case string:
+		subdir = s
+	case map[string]any:
+		str := func(key string) string { v, _ := s[key].(string); return v }
+		switch str("source") {
+		case "github":
+			url = "https://github.com/" + str("repo") + ".git"
+		case "url", "git":
+			url = str("url")
+		default:
+			return "", fmt.Errorf("unsupported plugin source %q", str("source"))
+		}
+		ref = str("sha")
+		if ref == "" {
+			ref = str("ref")
+		}
+	default:
+		return "", fmt.Errorf("unsupported plugin source %v", source)
+	}
+	if pin != "" {
+		ref = pin
+	}
+	if err := validateRef(ref); err != nil {
+		return "", err
+	}
+
+	if url != "" {
+		if _, err := runGit(ctx, "", "clone", "--quiet", "--", url, dst); err != nil {
+			return "", fmt.Errorf("failed to clone %s: %w", url, err)
+		}
+		return checkout(ctx, dst, ref)
+	}
+
+	subdir = filepath.Clean(filepath.FromSlash(subdir))
+	if filepath.IsAbs(subdir) || subdir == ".." || strings.HasPrefix(subdir, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("plugin source %q is outside its marketplace", subdir)
+	}
+	if ref == "" {
+		if err := fileops.Copy(filepath.Join(mkt.dir, subdir), dst); err != nil {
+			return "", err
+		}
+		if !mkt.git {
+			return "", nil
+		}
+		return runGit(ctx, mkt.dir, "rev-parse", "HEAD")
+	}
+	if !mkt.git {
+		return "", fmt.Errorf("plugins from the local marketplace %s can't be pinned", mkt.name)
+	}
+	// A pinned plugin comes from a local clone of the marketplace at the ref
+	clone := dst + ".marketplace"
+	defer os.RemoveAll(clone)
+	if _, err := runGit(ctx, "", "clone", "--quiet", "--no-checkout", "--", mkt.dir, clone); err != nil {
+		return "", err
+	}
+	sha, err := checkout(ctx, clone, ref)
+	if err != nil {
+		return "", err
+	}
+	return sha, fileops.Copy(filepath.Join(clone, subdir), dst)
+}
+
+// checkout checks out ref, when given, and returns the commit checked out
+func checkout(ctx context.Context, dir, ref string) (string, error) {
+	if ref != "" {
+		if _, err := runGit(ctx, dir, "checkout", "--quiet", ref); err != nil {
+			return "", fmt.Errorf("failed to check out %s: %w", ref, err)
+		}
+	}
+	return runGit(ctx, dir, "rev-parse", "HEAD")
+}
+
+func runGit(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := git.CommandContext(ctx, args...)
+	cmd.Dir = dir
+	cmd.Env = git.CommandEnv([]string{"GIT_TERMINAL_PROMPT=0"})
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return "", fmt.Errorf("%w: %s", err, msg)
+		}
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// validateRef rejects refs git would read as an option
+func validateRef(ref string) error {
+	if strings.HasPrefix(ref, "-") {
+		return fmt.Errorf("invalid git ref %q", ref)
+	}
+	return nil
+}
+
+// parseSource splits "name@marketplace#ref"
+func parseSource(source string) (name, marketplace, ref string, err error) {
+	source = strings.TrimSpace(source)
+	if i := strings.LastIndex(source, "#"); i >= 0 {
+		source, ref = source[:i], source[i+1:]
+		if ref == "" {
+			return "", "", "", fmt.Errorf("empty version pin in %q", source)
+		}
+		if err := validateRef(ref); err != nil {
+			return "", "", "", err
+		}
+	}
+	name, marketplace, ok := strings.Cut(source, "@")
+	if !ok || marketplace == "" {
+		return "", "", "", fmt.Errorf("plugin source must be name@marketplace, got %q", source)
+	}
+	if !validSegment.MatchString(name) {
+		return "", "", "", fmt.Errorf("invalid plugin name %q", name)
+	}
+	return name, marketplace, ref, nil
+}
+
+// parseMarketplaceSource tells a git URL, a GitHub repo and a local
+// directory apart
+func parseMarketplaceSource(spec string) (MarketplaceSource, error) {
+	switch {
+	case strings.Contains(spec, "://") || strings.HasPrefix(spec, "git@") || strings.HasSuffix(spec, ".git"):
+		return MarketplaceSource{Source: "git", URL: spec}, nil
+	case filepath.IsAbs(spec) || strings.HasPrefix(spec, "."):
+		abs, err := filepath.Abs(spec)
+		if err != nil {
+			return MarketplaceSource{}, err
+		}
+		if info, err := os.Stat(abs); err != nil || !info.IsDir() {
+			return MarketplaceSource{}, fmt.Errorf("marketplace directory not found: %s", spec)
+		}
+		return MarketplaceSource{Source: "directory", Path: abs}, nil
+	case githubRepo.MatchString(spec):
+		return MarketplaceSource{Source: "github", Repo: spec}, nil
+	default:
+		return MarketplaceSource{}, fmt.Errorf("unknown marketplace %q", spec)
+	}
+}
+
+// marketplaceName is the name a marketplace.json gives its marketplace
+func marketplaceName(dir string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(dir, ".claude-plugin", "marketplace.json"))
+	if err != nil {
+		return "", fmt.Errorf("not a plugin marketplace: %w", err)
+	}
+	var file MarketplaceFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return "", fmt.Errorf("failed to parse marketplace.json: %w", err)
+	}
+	if !validSegment.MatchString(file.Name) {
+		return "", fmt.Errorf("marketplace has an invalid name %q", file.Name)
+	}
+	return file.Name, nil
+}
+
+func (m *Manager) readKnownMarketplaces() (map[string]KnownMarketplace, error) {
+	known := map[string]KnownMarketplace{}
+	data, err := os.ReadFile(filepath.Join(m.pluginsDir, "known_marketplaces.json"))
+	if os.IsNotExist(err) {
+		return known, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read known_marketplaces.json: %w", err)
+	}
+	if err := json.Unmarshal(data, &known); err != nil {
+		return nil, fmt.Errorf("failed to parse known_marketplaces.json: %w", err)
+	}
+	return known, nil
+}
+
+func (m *Manager) writeKnownMarketplaces(known map[string]KnownMarketplace) error {
+	return writeJSON(filepath.Join(m.pluginsDir, "known_marketplaces.json"), known)
+}
+
+// installedFile is installed_plugins.json with the records of other
+// plugins kept as they are
+type installedFile struct {
+	Version int                        `json:"version"`
+	Plugins map[string]json.RawMessage `json:"plugins"`
+}
+
+func (m *Manager) readInstalledFile() (*installedFile, error) {
+	file := &installedFile{Version: 2, Plugins: map[string]json.RawMessage{}}
+	data, err := os.ReadFile(filepath.Join(m.pluginsDir, "installed_plugins.json"))
+	if os.IsNotExist(err) {
+		return file, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read installed_plugins.json: %w", err)
+	}
+	if err := json.Unmarshal(data, file); err != nil {
+		return nil, fmt.Errorf("failed to parse installed_plugins.json: %w", err)
+	}
+	if file.Plugins == nil {
+		file.Plugins = map[string]json.RawMessage{}
+	}
+	return file, nil
+}
+
+func (m *Manager) writeInstalledFile(file *installedFile) error {
+	return writeJSON(filepath.Join(m.pluginsDir, "installed_plugins.json"), file)
+}
+
+// installRecord returns the user-scope record of an installed plugin
+func (m *Manager) installRecord(id string) (*InstalledPluginRecord, error) {
+	file, err := m.readInstalledFile()
+	if err != nil {
+		return nil, err
+	}
+	var records []InstalledPluginRecord
+	if raw, ok := file.Plugins[id]; ok {
+		if err := json.Unmarshal(raw, &records); err != nil {
+			return nil, fmt.Errorf("failed to parse install record of %s: %w", id, err)
+		}
+	}
+	for i := range records {
+		if records[i].Scope == "user" || records[i].Scope == "" {
+			return &records[i], nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrNotInstalled, id)
+}
+
+// writeRecord replaces the user-scope record of a plugin, keeping records
+// of other scopes
+func (m *Manager) writeRecord(id string, record InstalledPluginRecord) error {
+	file, err := m.readInstalledFile()
+	if err != nil {
+		return err
+	}
+	var records []InstalledPluginRecord
+	if raw, ok := file.Plugins[id]; ok {
+		json.Unmarshal(raw, &records)
+	}
+	kept := []InstalledPluginRecord{record}
+	for _, r := range records {
+		if r.Scope != "user" && r.Scope != "" {
+			kept = append(kept, r)
+		}
+	}
+	raw, err := json.Marshal(kept)
+	if err != nil {
+		return err
+	}
+	file.Plugins[id] = raw
+	return m.writeInstalledFile(file)
+}
+
+// setEnabled adds or removes a plugin in enabledPlugins of the Claude
+// settings.json, which the CLI reads to load it
+func (m *Manager) setEnabled(id string, enabled bool) error {
+	path := filepath.Join(filepath.Dir(m.pluginsDir), "settings.json")
+	settings := map[string]any{}
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read settings.json: %w", err)
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &settings); err != nil {
+			return fmt.Errorf("failed to parse settings.json: %w", err)
+		}
+	}
+	plugins, _ := settings["enabledPlugins"].(map[string]any)
+	if plugins == nil {
+		if !enabled {
+			return nil
+		}
+		plugins = map[string]any{}
+	}
+	if enabled {
+		plugins[id] = true
+	} else {
+		delete(plugins, id)
+	}
+	settings["enabledPlugins"] = plugins
+	return writeJSON(path, settings)
+}
+
+// removeCached removes an install directory when it lies in the plugin
+// cache, along with parent directories left empty; installs pointing into
+// a marketplace or a local directory are left alone
+func (m *Manager) removeCached(installPath string) {
+	cache := filepath.Join(m.pluginsDir, "cache")
+	rel, err := filepath.Rel(cache, installPath)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return
+	}
+	os.RemoveAll(installPath)
+	for dir := filepath.Dir(installPath); dir != cache && strings.HasPrefix(dir, cache); dir = filepath.Dir(dir) {
+		if os.Remove(dir) != nil {
+			break
+		}
+	}
+}
+
+// writeJSON writes v indented through a temp file, so a failed write
+// doesn't leave a truncated file behind
+func writeJSON(path string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func reporter(id string, progress ProgressFunc) func(stage, message string) {
+	return func(stage, message string) {
+		if progress != nil {
+			progress(Progress{PluginID: id, Stage: stage, Message: message})
+		}
+	}
+}
+
+func now() string {
+	return time.Now().UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,197 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func mustGit(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+		"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// newMarketplaceRepo creates a git marketplace "tools" with a plugin
+// "hello" at version 1.0.0, tagged v1
+func newMarketplaceRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	repo := t.TempDir()
+	mustGit(t, repo, "init", "-q", "-b", "main")
+	writeFile(t, filepath.Join(repo, ".claude-plugin", "marketplace.json"),
+		`{"name":"tools","owner":{"name":"Tools"},"plugins":[{"name":"hello","source":"./plugins/hello","version":"0.0.1"}]}`)
+	setPluginVersion(t, repo, "1.0.0")
+	writeFile(t, filepath.Join(repo, "plugins", "hello", "commands", "greet.md"), "Say hello")
+	mustGit(t, repo, "add", "-A")
+	mustGit(t, repo, "commit", "-q", "-m", "v1")
+	mustGit(t, repo, "tag", "v1")
+	return repo
+}
+
+func setPluginVersion(t *testing.T, repo, version string) {
+	writeFile(t, filepath.Join(repo, "plugins", "hello", ".claude-plugin", "plugin.json"),
+		`{"name":"hello","version":"`+version+`","description":"Greets"}`)
+}
+
+func TestInstallUpdateUninstall(t *testing.T) {
+	repo := newMarketplaceRepo(t)
+	claudeDir := t.TempDir()
+	writeFile(t, filepath.Join(claudeDir, "settings.json"), `{"model":"opus"}`)
+	m := NewManager(claudeDir)
+	ctx := context.Background()
+
+	var stages []string
+	p, err := m.Install(ctx, "hello@file://"+repo, func(p Progress) { stages = append(stages, p.Stage) })
+	if err != nil {
+		t.Fatalf("Install() error = %v", err)
+	}
+	if p.ID != "hello@tools" || p.Metadata.Version != "1.0.0" || p.Marketplace != "tools" || p.GitCommitSha == "" {
+		t.Fatalf("installed plugin = %+v", p)
+	}
+	wantPath := filepath.Join(claudeDir, "plugins", "cache", "tools", "hello", "1.0.0")
+	if p.InstallPath != wantPath {
+		t.Errorf("InstallPath = %s, want %s", p.InstallPath, wantPath)
+	}
+	if _, err := os.Stat(filepath.Join(wantPath, "commands", "greet.md")); err != nil {
+		t.Errorf("plugin files not installed: %v", err)
+	}
+	if len(stages) == 0 || stages[len(stages)-1] != "done" {
+		t.Errorf("progress stages = %v", stages)
+	}
+	settings := readSettings(t, claudeDir)
+	if settings["model"] != "opus" || settings["enabledPlugins"].(map[string]any)["hello@tools"] != true {
+		t.Errorf("settings.json = %v", settings)
+	}
+	known, _ := m.readKnownMarketplaces()
+	if known["tools"].Source.URL != "file://"+repo || known["tools"].InstallLocation != filepath.Join(claudeDir, "plugins", "marketplaces", "tools") {
+		t.Errorf("known marketplaces = %+v", known)
+	}
+
+	// A new release upstream, picked up by Update
+	setPluginVersion(t, repo, "2.0.0")
+	mustGit(t, repo, "commit", "-q", "-am", "v2")
+	p, err = m.Update(ctx, "hello@tools", nil)
+	if err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if p.Metadata.Version != "2.0.0" {
+		t.Errorf("updated version = %s", p.Metadata.Version)
+	}
+	if _, err := os.Stat(wantPath); !os.IsNotExist(err) {
+		t.Errorf("old version left behind: %v", err)
+	}
+
+	// Pinning to v1 goes back and stays there through updates
+	if p, err = m.Install(ctx, "hello@tools#v1", nil); err != nil {
+		t.Fatalf("pinned Install() error = %v", err)
+	}
+	if p.Metadata.Version != "1.0.0" || p.PinnedRef != "v1" {
+		t.Errorf("pinned plugin = %+v", p)
+	}
+	if p, err = m.Update(ctx, "hello@tools", nil); err != nil || p.Metadata.Version != "1.0.0" {
+		t.Errorf("Update() of pinned plugin = %+v, %v", p, err)
+	}
+
+	if err := m.Uninstall("hello@tools", nil); err != nil {
+		t.Fatalf("Uninstall() error = %v", err)
+	}
+	if plugins, _ := m.ListInstalled(); len(plugins) != 0 {
+		t.Errorf("plugins after uninstall = %+v", plugins)
+	}
+	if _, err := os.Stat(filepath.Join(claudeDir, "plugins", "cache", "tools")); !os.IsNotExist(err) {
+		t.Errorf("cache not cleaned up: %v", err)
+	}
+	if _, ok := readSettings(t, claudeDir)["enabledPlugins"].(map[string]any)["hello@tools"]; ok {
+		t.Error("plugin still enabled after uninstall")
+	}
+	if err := m.Uninstall("hello@tools", nil); !errors.Is(err, ErrNotInstalled) {
+		t.Errorf("second Uninstall() error = %v", err)
+	}
+}
+
+func TestInstallKeepsOtherRecords(t *testing.T) {
+	repo := newMarketplaceRepo(t)
+	claudeDir := t.TempDir()
+	other := `[{"scope":"project","installPath":"/x","version":"1","installedAt":"","lastUpdated":"","isLocal":false,"projectPath":"/p"}]`
+	writeFile(t, filepath.Join(claudeDir, "plugins", "installed_plugins.json"),
+		`{"version":2,"plugins":{"other@elsewhere":`+other+`}}`)
+	m := NewManager(claudeDir)
+
+	if _, err := m.Install(context.Background(), "hello@file://"+repo, nil); err != nil {
+		t.Fatalf("Install() error = %v", err)
+	}
+	file, err := m.readInstalledFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var kept []map[string]any
+	json.Unmarshal(file.Plugins["other@elsewhere"], &kept)
+	if len(kept) != 1 || kept[0]["projectPath"] != "/p" {
+		t.Errorf("other plugin's record = %s", file.Plugins["other@elsewhere"])
+	}
+}
+
+func TestParseSource(t *testing.T) {
+	tests := []struct {
+		source, name, marketplace, ref string
+		wantErr                        bool
+	}{
+		{source: "hello@tools", name: "hello", marketplace: "tools"},
+		{source: "hello@owner/repo#v1.2.0", name: "hello", marketplace: "owner/repo", ref: "v1.2.0"},
+		{source: "hello@git@github.com:o/r.git", name: "hello", marketplace: "git@github.com:o/r.git"},
+		{source: "hello", wantErr: true},
+		{source: "../x@tools", wantErr: true},
+		{source: "hello@tools#", wantErr: true},
+		{source: "hello@tools#--upload-pack=touch /tmp/x", wantErr: true},
+	}
+	for _, tt := range tests {
+		name, marketplace, ref, err := parseSource(tt.source)
+		if (err != nil) != tt.wantErr || name != tt.name || marketplace != tt.marketplace || ref != tt.ref {
+			t.Errorf("parseSource(%q) = %q, %q, %q, %v", tt.source, name, marketplace, ref, err)
+		}
+	}
+}
+
+func TestFetchPluginRejectsOptionRefs(t *testing.T) {
+	source := map[string]any{"source": "url", "url": t.TempDir(), "sha": "--orphan=x"}
+	if _, err := fetchPlugin(context.Background(), source, &marketplace{}, "", filepath.Join(t.TempDir(), "dst")); err == nil || !strings.Contains(err.Error(), "invalid git ref") {
+		t.Errorf("Expected a ref starting with - to be rejected, got %v", err)
+	}
+}
+
+func readSettings(t *testing.T, claudeDir string) map[string]any {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(claudeDir, "settings.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	settings := map[string]any{}
+	if err := json.Unmarshal(data, &settings); err != nil {
+		t.Fatal(err)
+	}
+	return settings
+}
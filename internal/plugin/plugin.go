@@ -7,6 +7,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 )
 
 // Plugin represents an installed plugin
@@ -16,6 +17,11 @@ type Plugin struct {
 	InstallPath string         `json:"install_path"`
 	Enabled     bool           `json:"enabled"`
 	InstalledAt string         `json:"installed_at"`
+	// Fields from the install record
+	GitCommitSha string `json:"git_commit_sha,omitempty"`
+	IsLocal      bool   `json:"is_local,omitempty"`
+	Marketplace  string `json:"marketplace,omitempty"`
+	PinnedRef    string `json:"pinned_ref,omitempty"`
 }
 
 // PluginMetadata contains plugin metadata from .claude-plugin/plugin.json
@@ -98,6 +104,8 @@ type InstalledPluginRecord struct {
 	LastUpdated  string `json:"lastUpdated"`
 	GitCommitSha string `json:"gitCommitSha,omitempty"`
 	IsLocal      bool   `json:"isLocal"`
+	// PinnedRef is the tag, branch or commit an install was pinned to
+	PinnedRef string `json:"pinnedRef,omitempty"`
 }
 
 // InstalledPluginsFile represents the structure of installed_plugins.json
@@ -109,6 +117,8 @@ type InstalledPluginsFile struct {
 // Manager provides plugin management functionality
 type Manager struct {
 	pluginsDir string
+	// mu serializes installs, updates and uninstalls
+	mu sync.Mutex
 }
 
 // NewManager creates a new plugin manager
@@ -149,6 +159,11 @@ func (m *Manager) ListInstalled() ([]Plugin, error) {
 				InstallPath: record.InstallPath,
 				Enabled:     true, // All installed plugins are enabled by default
 				InstalledAt: record.InstalledAt,
+
+				GitCommitSha: record.GitCommitSha,
+				IsLocal:      record.IsLocal,
+				Marketplace:  marketplaceOf(pluginID),
+				PinnedRef:    record.PinnedRef,
 			})
 		}
 	}
@@ -156,6 +171,12 @@ func (m *Manager) ListInstalled() ([]Plugin, error) {
 	return plugins, nil
 }
 
+// marketplaceOf returns the marketplace part of "plugin-name@marketplace-name"
+func marketplaceOf(pluginID string) string {
+	_, marketplace, _ := strings.Cut(pluginID, "@")
+	return marketplace
+}
+
 // GetDetails returns details for a specific plugin
 func (m *Manager) GetDetails(id string) (*Plugin, error) {
 	plugins, err := m.ListInstalled()
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os/exec"
 	"ropcode/internal/eventhub"
+	"strings"
 	"sync"
 )
 
@@ -39,6 +40,18 @@ func (m *Manager) SetEventHub(hub EventEmitter) {
 
 // Spawn starts a new process
 func (m *Manager) Spawn(key, command string, args []string, cwd string, env []string) (*Process, error) {
+	cmd := exec.CommandContext(m.ctx, command, args...)
+	cmd.Dir = cwd
+	if env != nil {
+		cmd.Env = env
+	}
+
+	return m.SpawnCommand(key, cmd)
+}
+
+// SpawnCommand starts a prepared command under key. Use it when the caller
+// needs to wire the command's stdio itself; cmd must not have been started.
+func (m *Manager) SpawnCommand(key string, cmd *exec.Cmd) (*Process, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -48,12 +61,13 @@ func (m *Manager) Spawn(key, command string, args []string, cwd string, env []st
 		delete(m.processes, key)
 	}
 
-	cmd := exec.CommandContext(m.ctx, command, args...)
-	cmd.Dir = cwd
-	if env != nil {
-		cmd.Env = env
-	}
+	return m.start(key, cmd)
+}
 
+// start runs cmd and tracks it under key. m.mu must be held.
+func (m *Manager) start(key string, cmd *exec.Cmd) (*Process, error) {
+	cwd := cmd.Dir
+	command := strings.Join(cmd.Args, " ")
 	proc := NewProcess(key, cmd)
 	if err := proc.Start(); err != nil {
 		return nil, err
@@ -64,9 +78,10 @@ func (m *Manager) Spawn(key, command string, args []string, cwd string, env []st
 	// Emit process started event
 	if m.eventHub != nil {
 		m.eventHub.EmitProcessChanged(eventhub.ProcessChangedEvent{
-			PID:   proc.Pid(),
-			Cwd:   cwd,
-			State: "running",
+			PID:     proc.Pid(),
+			Cwd:     cwd,
+			State:   "running",
+			Command: command,
 		})
 	}
 
@@ -82,11 +97,15 @@ func (m *Manager) Spawn(key, command string, args []string, cwd string, env []st
 				Cwd:      cwd,
 				State:    "stopped",
 				ExitCode: &exitCode,
+				Command:  command,
 			})
 		}
 
 		m.mu.Lock()
-		delete(m.processes, key)
+		// The key may already belong to a process spawned as a replacement.
+		if m.processes[key] == proc {
+			delete(m.processes, key)
+		}
 		m.mu.Unlock()
 	}()
 
@@ -3,6 +3,7 @@ package process
 
 import (
 	"context"
+	"os/exec"
 	"testing"
 	"time"
 )
@@ -119,3 +120,29 @@ func TestProcessManager_ReplaceProcess(t *testing.T) {
 
 	manager.KillAll()
 }
+
+func TestProcessManager_SpawnCommandReplacesKey(t *testing.T) {
+	manager := NewManager(context.Background())
+
+	first, err := manager.SpawnCommand("server", exec.Command("sleep", "10"))
+	if err != nil {
+		t.Fatalf("SpawnCommand failed: %v", err)
+	}
+	second, err := manager.SpawnCommand("server", exec.Command("sleep", "10"))
+	if err != nil {
+		t.Fatalf("SpawnCommand failed: %v", err)
+	}
+	defer manager.KillAll()
+
+	select {
+	case <-first.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("Replaced process should have exited")
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	// The exit of the replaced process must not untrack its replacement.
+	if proc, ok := manager.Get("server"); !ok || proc != second {
+		t.Error("Expected replacement process to stay tracked under the key")
+	}
+}
@@ -0,0 +1,282 @@
+// Package projectmemory surveys a repository to bootstrap its CLAUDE.md:
+// the languages it is written in, its package manifests and build files,
+// its directory layout and the start of its README. Survey.Prompt turns the
+// survey into the instructions for a model that drafts the file; the model
+// is given no tools, so everything it knows about the project is in there.
+package projectmemory
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"ropcode/internal/git"
+)
+
+const (
+	// maxFiles caps the files surveyed in a huge repository
+	maxFiles = 20000
+	// maxManifests caps the manifests quoted in the prompt
+	maxManifests = 8
+	// maxExcerpt caps each quoted file, in bytes
+	maxExcerpt = 3000
+	// maxLayoutDirs caps the directories listed in the layout
+	maxLayoutDirs = 60
+)
+
+// skipDirs are never surveyed when the project isn't a git repository
+var skipDirs = map[string]bool{
+	".git": true, ".hg": true, ".svn": true, ".ropcode": true, ".claude": true,
+	"node_modules": true, "vendor": true, "dist": true, "build": true, "target": true,
+	"out": true, ".next": true, ".venv": true, "venv": true, "__pycache__": true,
+}
+
+// manifestNames are the package manifests and build files worth quoting
+var manifestNames = map[string]bool{
+	"go.mod": true, "package.json": true, "Cargo.toml": true, "pyproject.toml": true,
+	"requirements.txt": true, "setup.py": true, "Pipfile": true, "pom.xml": true,
+	"build.gradle": true, "build.gradle.kts": true, "Gemfile": true, "composer.json": true,
+	"mix.exs": true, "pubspec.yaml": true, "Package.swift": true, "CMakeLists.txt": true,
+	"Makefile": true, "justfile": true, "Dockerfile": true, "docker-compose.yml": true,
+	"deno.json": true, "tsconfig.json": true,
+}
+
+// languages names the languages of source file extensions
+var languages = map[string]string{
+	".go": "Go", ".ts": "TypeScript", ".tsx": "TypeScript", ".js": "JavaScript",
+	".jsx": "JavaScript", ".mjs": "JavaScript", ".py": "Python", ".rs": "Rust",
+	".java": "Java", ".kt": "Kotlin", ".swift": "Swift", ".rb": "Ruby", ".php": "PHP",
+	".cs": "C#", ".c": "C", ".h": "C", ".cc": "C++", ".cpp": "C++", ".hpp": "C++",
+	".scala": "Scala", ".ex": "Elixir", ".exs": "Elixir", ".dart": "Dart",
+	".lua": "Lua", ".sh": "Shell", ".vue": "Vue", ".svelte": "Svelte",
+	".sql": "SQL", ".zig": "Zig", ".m": "Objective-C", ".hs": "Haskell",
+}
+
+// Language counts the source files of one language
+type Language struct {
+	Name  string `json:"name"`
+	Files int    `json:"files"`
+}
+
+// Excerpt is the start of a file, relative to the project
+type Excerpt struct {
+	Path      string `json:"path"`
+	Content   string `json:"content"`
+	Truncated bool   `json:"truncated,omitempty"`
+}
+
+// Survey describes a repository
+type Survey struct {
+	Name      string     `json:"name"`
+	Files     int        `json:"files"`
+	Languages []Language `json:"languages"`
+	Manifests []Excerpt  `json:"manifests"`
+	// Layout lists directories up to two levels deep with their file counts
+	Layout []string `json:"layout"`
+	README *Excerpt `json:"readme,omitempty"`
+}
+
+// Inspect surveys the project at root. Git repositories are surveyed
+// through git, so ignored files are left out.
+func Inspect(root string) (*Survey, error) {
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", root)
+	}
+	files, err := gitFiles(root)
+	if err != nil {
+		if files, err = walkFiles(root); err != nil {
+			return nil, err
+		}
+	}
+
+	survey := &Survey{Name: filepath.Base(root), Files: len(files), Languages: []Language{}, Manifests: []Excerpt{}, Layout: []string{}}
+	counts := make(map[string]int)
+	dirs := make(map[string]int)
+	var manifests, readmes []string
+	for _, file := range files {
+		if name := languages[strings.ToLower(filepath.Ext(file))]; name != "" {
+			counts[name]++
+		}
+		parts := strings.Split(file, "/")
+		for depth := 1; depth < len(parts) && depth <= 2; depth++ {
+			dirs[strings.Join(parts[:depth], "/")]++
+		}
+		base := parts[len(parts)-1]
+		// Manifests of the root and of its direct subprojects
+		if manifestNames[base] && len(parts) <= 2 {
+			manifests = append(manifests, file)
+		}
+		if len(parts) == 1 && strings.HasPrefix(strings.ToLower(base), "readme") {
+			readmes = append(readmes, file)
+		}
+	}
+
+	for name, count := range counts {
+		survey.Languages = append(survey.Languages, Language{Name: name, Files: count})
+	}
+	sort.Slice(survey.Languages, func(i, j int) bool {
+		a, b := survey.Languages[i], survey.Languages[j]
+		return a.Files > b.Files || a.Files == b.Files && a.Name < b.Name
+	})
+
+	// Root manifests first
+	sort.SliceStable(manifests, func(i, j int) bool {
+		return strings.Count(manifests[i], "/") < strings.Count(manifests[j], "/")
+	})
+	for _, path := range manifests {
+		if len(survey.Manifests) == maxManifests {
+			break
+		}
+		if excerpt, err := readExcerpt(root, path); err == nil {
+			survey.Manifests = append(survey.Manifests, *excerpt)
+		}
+	}
+	if len(readmes) > 0 {
+		sort.Strings(readmes)
+		survey.README, _ = readExcerpt(root, readmes[0])
+	}
+
+	paths := make([]string, 0, len(dirs))
+	for dir := range dirs {
+		paths = append(paths, dir)
+	}
+	sort.Strings(paths)
+	for _, dir := range paths {
+		if len(survey.Layout) == maxLayoutDirs {
+			survey.Layout = append(survey.Layout, "…")
+			break
+		}
+		survey.Layout = append(survey.Layout, fmt.Sprintf("%s/ (%d files)", dir, dirs[dir]))
+	}
+	return survey, nil
+}
+
+// gitFiles lists the tracked and untracked, not ignored files of a git
+// repository, with slash separated paths
+func gitFiles(root string) ([]string, error) {
+	cmd := git.Command("ls-files", "-z", "--cached", "--others", "--exclude-standard")
+	cmd.Dir = root
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, file := range strings.Split(strings.Trim(string(out), "\x00"), "\x00") {
+		if file == "" {
+			continue
+		}
+		if len(files) == maxFiles {
+			break
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+// walkFiles lists the files below root outside skipDirs, with slash
+// separated paths
+func walkFiles(root string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// Unreadable directories are left out
+			return nil
+		}
+		if d.IsDir() {
+			if path != root && skipDirs[d.Name()] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if len(files) == maxFiles {
+			return filepath.SkipAll
+		}
+		if rel, err := filepath.Rel(root, path); err == nil {
+			files = append(files, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	return files, err
+}
+
+func readExcerpt(root, path string) (*Excerpt, error) {
+	file, err := os.Open(filepath.Join(root, filepath.FromSlash(path)))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	buf := make([]byte, maxExcerpt+1)
+	n, err := file.Read(buf)
+	if err != nil && n == 0 {
+		return nil, err
+	}
+	excerpt := &Excerpt{Path: path, Content: string(buf[:n])}
+	if n > maxExcerpt {
+		excerpt.Content = string(buf[:maxExcerpt])
+		excerpt.Truncated = true
+	}
+	return excerpt, nil
+}
+
+// Prompt asks for a CLAUDE.md draft for the surveyed project
+func (s *Survey) Prompt() string {
+	var b strings.Builder
+	b.WriteString("Write a CLAUDE.md file for the repository described below. CLAUDE.md is read by coding agents at the start of every session, so it should tell them what they can't quickly see for themselves:\n")
+	b.WriteString("- a one-paragraph overview of what the project is\n")
+	b.WriteString("- how to build, run, test and lint it, with exact commands taken from the manifests\n")
+	b.WriteString("- the layout: what lives in which directory\n")
+	b.WriteString("- conventions worth following, as far as the manifests and layout show them\n")
+	b.WriteString("Keep it short and factual; don't invent commands or conventions the survey doesn't support. Output only the Markdown content of the file, without a surrounding code fence.\n\n")
+
+	fmt.Fprintf(&b, "# Repository survey: %s\n\n", s.Name)
+	fmt.Fprintf(&b, "%d files.\n", s.Files)
+	if len(s.Languages) > 0 {
+		names := make([]string, 0, len(s.Languages))
+		for _, language := range s.Languages {
+			names = append(names, fmt.Sprintf("%s (%d files)", language.Name, language.Files))
+		}
+		fmt.Fprintf(&b, "Languages: %s\n", strings.Join(names, ", "))
+	}
+	if len(s.Layout) > 0 {
+		b.WriteString("\n## Directories\n")
+		for _, dir := range s.Layout {
+			b.WriteString(dir + "\n")
+		}
+	}
+	for _, manifest := range s.Manifests {
+		writeExcerpt(&b, "Manifest", manifest)
+	}
+	if s.README != nil {
+		writeExcerpt(&b, "README", *s.README)
+	}
+	return b.String()
+}
+
+func writeExcerpt(b *strings.Builder, kind string, excerpt Excerpt) {
+	fmt.Fprintf(b, "\n## %s: %s\n", kind, excerpt.Path)
+	b.WriteString("```\n" + strings.TrimRight(excerpt.Content, "\n") + "\n")
+	if excerpt.Truncated {
+		b.WriteString("… (truncated)\n")
+	}
+	b.WriteString("```\n")
+}
+
+// CleanDraft strips a code fence a model wrapped the whole file in
+func CleanDraft(draft string) string {
+	draft = strings.TrimSpace(draft)
+	if !strings.HasPrefix(draft, "```") || !strings.HasSuffix(draft, "```") {
+		return draft
+	}
+	lines := strings.Split(draft, "\n")
+	if len(lines) < 2 {
+		return draft
+	}
+	return strings.TrimSpace(strings.Join(lines[1:len(lines)-1], "\n"))
+}
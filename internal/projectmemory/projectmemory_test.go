@@ -0,0 +1,94 @@
+package projectmemory
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeFile(t *testing.T, root, name, content string) {
+	t.Helper()
+	path := filepath.Join(root, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestInspectSurveysAGitRepository(t *testing.T) {
+	root := t.TempDir()
+	if out, err := exec.Command("git", "init", root).CombinedOutput(); err != nil {
+		t.Fatalf("git init: %v: %s", err, out)
+	}
+	writeFile(t, root, "go.mod", "module example.com/shop\n\ngo 1.24\n")
+	writeFile(t, root, "main.go", "package main\n")
+	writeFile(t, root, "internal/cart/cart.go", "package cart\n")
+	writeFile(t, root, "internal/cart/cart_test.go", "package cart\n")
+	writeFile(t, root, "web/package.json", `{"scripts":{"test":"vitest"}}`)
+	writeFile(t, root, "web/src/app.tsx", "export {}\n")
+	writeFile(t, root, "README.md", "# Shop\n\n"+strings.Repeat("x", maxExcerpt))
+	writeFile(t, root, ".gitignore", "dist/\n")
+	writeFile(t, root, "dist/bundle.js", "ignored")
+
+	survey, err := Inspect(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if survey.Files != 8 {
+		t.Fatalf("files = %d", survey.Files)
+	}
+	if len(survey.Languages) != 2 || survey.Languages[0] != (Language{Name: "Go", Files: 3}) || survey.Languages[1] != (Language{Name: "TypeScript", Files: 1}) {
+		t.Fatalf("languages = %+v", survey.Languages)
+	}
+	if len(survey.Manifests) != 2 || survey.Manifests[0].Path != "go.mod" || survey.Manifests[1].Path != "web/package.json" {
+		t.Fatalf("manifests = %+v", survey.Manifests)
+	}
+	if survey.README == nil || !survey.README.Truncated || !strings.HasPrefix(survey.README.Content, "# Shop") {
+		t.Fatalf("readme = %+v", survey.README)
+	}
+	want := []string{"internal/ (2 files)", "internal/cart/ (2 files)", "web/ (2 files)", "web/src/ (1 files)"}
+	if strings.Join(survey.Layout, "|") != strings.Join(want, "|") {
+		t.Fatalf("layout = %q", survey.Layout)
+	}
+
+	prompt := survey.Prompt()
+	for _, part := range []string{"Languages: Go (3 files), TypeScript (1 files)", "## Manifest: go.mod", "module example.com/shop", "## README: README.md", "… (truncated)"} {
+		if !strings.Contains(prompt, part) {
+			t.Errorf("prompt lacks %q", part)
+		}
+	}
+	if strings.Contains(prompt, "dist/") {
+		t.Error("prompt names an ignored directory")
+	}
+}
+
+func TestInspectWalksDirectoriesOutsideGit(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "app.py", "print('hi')\n")
+	writeFile(t, root, "requirements.txt", "flask\n")
+	writeFile(t, root, "node_modules/x/index.js", "")
+
+	survey, err := Inspect(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if survey.Files != 2 || len(survey.Manifests) != 1 || len(survey.Languages) != 1 || survey.Languages[0].Name != "Python" {
+		t.Fatalf("survey = %+v", survey)
+	}
+	if _, err := Inspect(filepath.Join(root, "app.py")); err == nil {
+		t.Fatal("surveyed a file")
+	}
+}
+
+func TestCleanDraft(t *testing.T) {
+	if got := CleanDraft("```markdown\n# Shop\n\nBuild with make.\n```\n"); got != "# Shop\n\nBuild with make." {
+		t.Fatalf("CleanDraft = %q", got)
+	}
+	if got := CleanDraft("# Shop\n\n```sh\nmake\n```"); got != "# Shop\n\n```sh\nmake\n```" {
+		t.Fatalf("CleanDraft = %q", got)
+	}
+}
@@ -0,0 +1,217 @@
+// Package projectscan finds git repositories under a set of directories and
+// guesses their languages and frameworks from the manifests at their roots,
+// so projects can be added to the index in bulk.
+package projectscan
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// DefaultMaxDepth is how many directories below a root a scan looks for
+// repositories
+const DefaultMaxDepth = 4
+
+// skippedDirs hold dependencies and build output, never projects of their own
+var skippedDirs = map[string]bool{
+	"node_modules": true,
+	"vendor":       true,
+	"target":       true,
+	"build":        true,
+	"dist":         true,
+	"venv":         true,
+	"__pycache__":  true,
+	"Library":      true,
+}
+
+// languageMarkers map files at a repository root to its languages
+var languageMarkers = []struct {
+	file     string
+	language string
+}{
+	{"go.mod", "Go"},
+	{"package.json", "JavaScript"},
+	{"tsconfig.json", "TypeScript"},
+	{"Cargo.toml", "Rust"},
+	{"pyproject.toml", "Python"},
+	{"requirements.txt", "Python"},
+	{"setup.py", "Python"},
+	{"pom.xml", "Java"},
+	{"build.gradle", "Java"},
+	{"build.gradle.kts", "Kotlin"},
+	{"Gemfile", "Ruby"},
+	{"composer.json", "PHP"},
+	{"Package.swift", "Swift"},
+	{"pubspec.yaml", "Dart"},
+	{"CMakeLists.txt", "C/C++"},
+	{"mix.exs", "Elixir"},
+}
+
+// frameworkMarkers map dependencies named in a manifest to frameworks
+var frameworkMarkers = map[string][]struct {
+	dependency string
+	framework  string
+}{
+	"package.json": {
+		{`"next"`, "Next.js"},
+		{`"react"`, "React"},
+		{`"vue"`, "Vue"},
+		{`"nuxt"`, "Nuxt"},
+		{`"svelte"`, "Svelte"},
+		{`"@angular/core"`, "Angular"},
+		{`"electron"`, "Electron"},
+		{`"express"`, "Express"},
+		{`"vite"`, "Vite"},
+	},
+	"requirements.txt": {{"django", "Django"}, {"flask", "Flask"}, {"fastapi", "FastAPI"}},
+	"pyproject.toml":   {{"django", "Django"}, {"flask", "Flask"}, {"fastapi", "FastAPI"}},
+	"go.mod": {
+		{"github.com/gin-gonic/gin", "Gin"},
+		{"github.com/labstack/echo", "Echo"},
+		{"github.com/gofiber/fiber", "Fiber"},
+		{"github.com/wailsapp/wails", "Wails"},
+	},
+	"Cargo.toml":    {{"tauri", "Tauri"}, {"actix-web", "Actix"}, {"axum", "Axum"}},
+	"Gemfile":       {{"rails", "Rails"}},
+	"composer.json": {{"laravel/framework", "Laravel"}, {"symfony/", "Symfony"}},
+	"pubspec.yaml":  {{"flutter:", "Flutter"}},
+	"pom.xml":       {{"spring-boot", "Spring Boot"}},
+	"build.gradle":  {{"spring-boot", "Spring Boot"}, {"com.android", "Android"}},
+}
+
+// maxManifestSize bounds how much of a manifest is read for frameworks
+const maxManifestSize = 1 << 20
+
+// Candidate is a repository found by a scan
+type Candidate struct {
+	Path       string   `json:"path"`
+	Name       string   `json:"name"`
+	Languages  []string `json:"languages"`
+	Frameworks []string `json:"frameworks"`
+	// NameInUse is set by callers when an indexed project already has the
+	// name
+	NameInUse bool `json:"name_in_use"`
+}
+
+// Scan walks roots up to maxDepth directories deep and returns the git
+// repositories it finds, sorted by path. It does not look inside
+// repositories, hidden directories or dependency directories. Roots that
+// don't exist are skipped.
+func Scan(roots []string, maxDepth int) ([]Candidate, error) {
+	seen := make(map[string]bool)
+	candidates := []Candidate{}
+	for _, root := range roots {
+		root = filepath.Clean(root)
+		if info, err := os.Stat(root); err != nil || !info.IsDir() {
+			continue
+		}
+		err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				// Unreadable directories are skipped, not fatal
+				if entry != nil && entry.IsDir() && path != root {
+					return fs.SkipDir
+				}
+				return nil
+			}
+			if !entry.IsDir() {
+				return nil
+			}
+			if path != root {
+				name := entry.Name()
+				if strings.HasPrefix(name, ".") || skippedDirs[name] {
+					return fs.SkipDir
+				}
+			}
+			if isRepository(path) {
+				if !seen[path] {
+					seen[path] = true
+					candidates = append(candidates, Detect(path))
+				}
+				return fs.SkipDir
+			}
+			if depth(root, path) >= maxDepth {
+				return fs.SkipDir
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].Path < candidates[j].Path })
+	return candidates, nil
+}
+
+// Detect describes the project at path from the manifests at its root
+func Detect(path string) Candidate {
+	candidate := Candidate{
+		Path:       path,
+		Name:       filepath.Base(path),
+		Languages:  []string{},
+		Frameworks: []string{},
+	}
+	for _, marker := range languageMarkers {
+		if fileExists(filepath.Join(path, marker.file)) && !slices.Contains(candidate.Languages, marker.language) {
+			candidate.Languages = append(candidate.Languages, marker.language)
+		}
+	}
+	if slices.Contains(candidate.Languages, "TypeScript") {
+		// A TypeScript project's package.json doesn't make it JavaScript
+		candidate.Languages = slices.DeleteFunc(candidate.Languages, func(language string) bool {
+			return language == "JavaScript"
+		})
+	}
+	for manifest, markers := range frameworkMarkers {
+		content := readManifest(filepath.Join(path, manifest))
+		if content == "" {
+			continue
+		}
+		for _, marker := range markers {
+			if strings.Contains(content, strings.ToLower(marker.dependency)) && !slices.Contains(candidate.Frameworks, marker.framework) {
+				candidate.Frameworks = append(candidate.Frameworks, marker.framework)
+			}
+		}
+	}
+	sort.Strings(candidate.Frameworks)
+	return candidate
+}
+
+// isRepository reports whether dir is the top of a git work tree; .git is
+// a file in worktrees and submodules
+func isRepository(dir string) bool {
+	_, err := os.Stat(filepath.Join(dir, ".git"))
+	return err == nil
+}
+
+func depth(root, path string) int {
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == "." {
+		return 0
+	}
+	return strings.Count(rel, string(filepath.Separator)) + 1
+}
+
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
+
+// readManifest returns the lowercased start of a manifest, empty when it
+// can't be read
+func readManifest(path string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	data, err := io.ReadAll(io.LimitReader(f, maxManifestSize))
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(string(data))
+}
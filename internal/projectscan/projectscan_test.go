@@ -0,0 +1,66 @@
+package projectscan
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestScan(t *testing.T) {
+	root := t.TempDir()
+	// A TypeScript React app
+	os.MkdirAll(filepath.Join(root, "web", ".git"), 0755)
+	writeFile(t, filepath.Join(root, "web", "package.json"), `{"dependencies":{"react":"^18","react-dom":"^18"},"devDependencies":{"vite":"^5"}}`)
+	writeFile(t, filepath.Join(root, "web", "tsconfig.json"), `{}`)
+	// Repositories inside a repository belong to it
+	os.MkdirAll(filepath.Join(root, "web", "packages", "lib", ".git"), 0755)
+	// A Go service, nested, with .git as a file like in a worktree
+	writeFile(t, filepath.Join(root, "work", "api", ".git"), "gitdir: /elsewhere")
+	writeFile(t, filepath.Join(root, "work", "api", "go.mod"), "module api\n\nrequire github.com/gin-gonic/gin v1.9.0\n")
+	// Skipped: dependencies, hidden directories and anything too deep
+	os.MkdirAll(filepath.Join(root, "node_modules", "dep", ".git"), 0755)
+	os.MkdirAll(filepath.Join(root, ".cache", "repo", ".git"), 0755)
+	os.MkdirAll(filepath.Join(root, "a", "b", "c", "d", "deep", ".git"), 0755)
+	// Not a repository
+	writeFile(t, filepath.Join(root, "notes", "requirements.txt"), "django\n")
+
+	candidates, err := Scan([]string{root, filepath.Join(root, "work"), filepath.Join(root, "missing")}, DefaultMaxDepth)
+	if err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+	want := []Candidate{
+		{
+			Path:       filepath.Join(root, "web"),
+			Name:       "web",
+			Languages:  []string{"TypeScript"},
+			Frameworks: []string{"React", "Vite"},
+		},
+		{
+			Path:       filepath.Join(root, "work", "api"),
+			Name:       "api",
+			Languages:  []string{"Go"},
+			Frameworks: []string{"Gin"},
+		},
+	}
+	if !reflect.DeepEqual(candidates, want) {
+		t.Fatalf("Scan() = %+v, want %+v", candidates, want)
+	}
+}
+
+func TestDetectWithoutManifests(t *testing.T) {
+	candidate := Detect(t.TempDir())
+	if len(candidate.Languages) != 0 || len(candidate.Frameworks) != 0 {
+		t.Fatalf("Detect() = %+v", candidate)
+	}
+}
@@ -49,6 +49,28 @@ func NewManager(ctx context.Context, emitter EventEmitter) *Manager {
 // The actual shell startup happens asynchronously in a goroutine.
 // A "pty-ready" event will be emitted when the PTY is ready or failed.
 func (m *Manager) CreateSession(id, cwd string, rows, cols int, shell string) (*Session, error) {
+	return m.CreateSessionWithEnv(id, cwd, rows, cols, shell, nil)
+}
+
+// CreateSessionWithEnv creates a PTY session whose shell gets env on top of
+// the inherited environment
+func (m *Manager) CreateSessionWithEnv(id, cwd string, rows, cols int, shell string, env []string) (*Session, error) {
+	return m.createSession(id, cwd, rows, cols, shell, nil, env)
+}
+
+// CreateCommandSession creates a PTY session running program with args
+// rather than a shell; it ends when the program exits
+func (m *Manager) CreateCommandSession(id, cwd string, rows, cols int, program string, args, env []string) (*Session, error) {
+	if program == "" {
+		return nil, fmt.Errorf("no program to run")
+	}
+	if args == nil {
+		args = []string{}
+	}
+	return m.createSession(id, cwd, rows, cols, program, args, env)
+}
+
+func (m *Manager) createSession(id, cwd string, rows, cols int, shell string, args, env []string) (*Session, error) {
 	m.mu.Lock()
 
 	if _, exists := m.sessions[id]; exists {
@@ -61,6 +83,8 @@ func (m *Manager) CreateSession(id, cwd string, rows, cols int, shell string) (*
 		m.mu.Unlock()
 		return nil, err
 	}
+	session.Env = env
+	session.Args = args
 
 	// Store session immediately (before Start) so we can return quickly
 	m.sessions[id] = session
@@ -110,9 +134,9 @@ func (m *Manager) CreateSession(id, cwd string, rows, cols int, shell string) (*
 //
 // Two paths flush the pending buffer:
 //
-//   - Read goroutine: when the read returns and the accumulator has filled
-//     past the high-water mark, OR the flush timer fires for the next 16ms
-//     boundary.
+//   - Output goroutine: when a read pushes the accumulator past the
+//     high-water mark, OR the flush timer fires 16ms after the first read
+//     of a batch.
 //   - Session shutdown: residual bytes are flushed before the goroutine
 //     exits so the user sees the final lines.
 const (
@@ -123,9 +147,32 @@ const (
 )
 
 func (m *Manager) readOutput(session *Session) {
-	buf := make([]byte, 8192)
-	pending := make([]byte, 0, ptyFlushHighWater)
+	// Reads block until the program writes again, so they run on their own
+	// goroutine; otherwise the last burst would wait for the next one
+	chunks := make(chan []byte, 16)
+	go func() {
+		defer close(chunks)
+		buf := make([]byte, 8192)
+		for {
+			n, err := session.Read(buf)
+			if n > 0 {
+				chunk := make([]byte, n)
+				copy(chunk, buf[:n])
+				select {
+				case chunks <- chunk:
+				case <-session.Done():
+					return
+				case <-m.ctx.Done():
+					return
+				}
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
 
+	pending := make([]byte, 0, ptyFlushHighWater)
 	flush := func() {
 		if len(pending) == 0 || m.emitter == nil {
 			pending = pending[:0]
@@ -139,51 +186,12 @@ func (m *Manager) readOutput(session *Session) {
 		pending = pending[:0]
 	}
 
-	// Reads run on this goroutine; the timer fires on the runtime timer
-	// goroutine but only signals via flushReady so we don't race with the
-	// in-flight Read call.
-	flushReady := make(chan struct{}, 1)
-	readDone := make(chan struct{})
 	timer := time.NewTimer(ptyFlushInterval)
-	if !timer.Stop() {
-		<-timer.C
-	}
-	timerArmed := false
-	armTimer := func() {
-		if timerArmed {
-			return
-		}
-		timer.Reset(ptyFlushInterval)
-		timerArmed = true
-	}
-	go func() {
-		for {
-			select {
-			case <-session.Done():
-				return
-			case <-m.ctx.Done():
-				return
-			case <-readDone:
-				return
-			case <-timer.C:
-				select {
-				case flushReady <- struct{}{}:
-				default:
-				}
-			}
-		}
-	}()
-
-	defer func() {
-		close(readDone)
-		if !timer.Stop() {
-			select {
-			case <-timer.C:
-			default:
-			}
-		}
-		flush()
-	}()
+	timer.Stop()
+	defer timer.Stop()
+	// flushDue is the armed timer's channel, nil while nothing is pending
+	var flushDue <-chan time.Time
+	defer flush()
 
 	for {
 		select {
@@ -191,30 +199,24 @@ func (m *Manager) readOutput(session *Session) {
 			return
 		case <-m.ctx.Done():
 			return
-		case <-flushReady:
-			timerArmed = false
+		case <-flushDue:
+			flushDue = nil
 			flush()
-		default:
-			n, err := session.Read(buf)
-			if err != nil {
+		case chunk, ok := <-chunks:
+			if !ok {
 				return
 			}
-			if n == 0 {
-				continue
-			}
-			pending = append(pending, buf[:n]...)
+			pending = append(pending, chunk...)
 			if len(pending) >= ptyFlushHighWater {
 				flush()
-				timerArmed = false
-				if !timer.Stop() {
-					select {
-					case <-timer.C:
-					default:
-					}
-				}
+				timer.Stop()
+				flushDue = nil
 				continue
 			}
-			armTimer()
+			if flushDue == nil {
+				timer.Reset(ptyFlushInterval)
+				flushDue = timer.C
+			}
 		}
 	}
 }
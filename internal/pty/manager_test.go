@@ -3,6 +3,8 @@ package pty
 
 import (
 	"context"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -116,3 +118,66 @@ func TestPtyManager_CloseAll(t *testing.T) {
 		t.Errorf("Expected 0 sessions after CloseAll, got %d", len(sessions))
 	}
 }
+
+func TestPtyManager_CreateSessionWithEnv(t *testing.T) {
+	manager := NewManager(context.Background(), nil)
+
+	session, err := manager.CreateSessionWithEnv("test-env", "/tmp", 24, 80, "", []string{"HTTPS_PROXY=http://proxy:8080"})
+	if err != nil {
+		t.Fatalf("CreateSessionWithEnv failed: %v", err)
+	}
+	defer manager.CloseSession("test-env")
+	waitForSessionStart(t, session)
+
+	env := session.buildShellEnv()
+	if env[len(env)-1] != "HTTPS_PROXY=http://proxy:8080" {
+		t.Fatalf("expected the session env last, got %q", env[len(env)-3:])
+	}
+}
+
+type recordingEmitter struct {
+	mu     sync.Mutex
+	output strings.Builder
+}
+
+func (e *recordingEmitter) Emit(eventName string, data interface{}) {
+	if output, ok := data.(PtyOutput); ok && eventName == "pty-output" {
+		e.mu.Lock()
+		e.output.WriteString(output.Content)
+		e.mu.Unlock()
+	}
+}
+
+func (e *recordingEmitter) String() string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.output.String()
+}
+
+func TestPtyManager_CreateCommandSession(t *testing.T) {
+	emitter := &recordingEmitter{}
+	manager := NewManager(context.Background(), emitter)
+	defer manager.CloseAll()
+
+	session, err := manager.CreateCommandSession("test-command", "/tmp", 24, 80, "/bin/sh", []string{"-c", "echo command-output"}, nil)
+	if err != nil {
+		t.Fatalf("CreateCommandSession failed: %v", err)
+	}
+	select {
+	case <-session.Exited():
+	case <-time.After(5 * time.Second):
+		t.Fatal("program did not exit")
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !strings.Contains(emitter.String(), "command-output") {
+		if time.Now().After(deadline) {
+			t.Fatalf("output = %q", emitter.String())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if _, err := manager.CreateCommandSession("test-empty", "/tmp", 24, 80, "", nil, nil); err == nil {
+		t.Fatal("CreateCommandSession without a program should fail")
+	}
+}
@@ -3,7 +3,11 @@
 // internal/pty/session.go
 package pty
 
-import "os"
+import (
+	"bufio"
+	"os"
+	"strings"
+)
 
 func normalizeCwd(cwd string) string {
 	return cwd
@@ -39,3 +43,30 @@ func detectDefaultShell() string {
 	// Last resort
 	return "/bin/sh"
 }
+
+// AvailableShells lists the login shells installed, from /etc/shells
+func AvailableShells() []string {
+	f, err := os.Open("/etc/shells")
+	if err != nil {
+		return []string{getDefaultShell()}
+	}
+	defer f.Close()
+
+	var shells []string
+	seen := make(map[string]bool)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		shell := strings.TrimSpace(scanner.Text())
+		if shell == "" || strings.HasPrefix(shell, "#") || seen[shell] {
+			continue
+		}
+		if _, err := os.Stat(shell); err == nil {
+			seen[shell] = true
+			shells = append(shells, shell)
+		}
+	}
+	if len(shells) == 0 {
+		return []string{getDefaultShell()}
+	}
+	return shells
+}
@@ -9,6 +9,8 @@ import (
 	"sync"
 
 	gopty "github.com/aymanbagabas/go-pty"
+
+	"ropcode/internal/loginenv"
 )
 
 // Shell type constants
@@ -33,6 +35,11 @@ type Session struct {
 	Shell string
 	Rows  int
 	Cols  int
+	// Env is added to the inherited environment
+	Env []string
+	// Args, when set, run Shell as a program with these arguments instead
+	// of as an interactive shell
+	Args []string
 
 	pty     gopty.Pty
 	cmd     *gopty.Cmd
@@ -41,6 +48,7 @@ type Session struct {
 	started bool // indicates if Start() has completed successfully
 
 	doneCh chan struct{}
+	exitCh chan struct{}
 }
 
 // NewSession creates a new PTY session
@@ -56,6 +64,7 @@ func NewSession(id, cwd string, rows, cols int, shell string) (*Session, error)
 		Rows:   rows,
 		Cols:   cols,
 		doneCh: make(chan struct{}),
+		exitCh: make(chan struct{}),
 	}
 
 	return s, nil
@@ -121,8 +130,9 @@ func (s *Session) buildShellArgs() []string {
 
 // buildShellEnv builds the environment variables for the shell
 func (s *Session) buildShellEnv() []string {
-	env := os.Environ()
+	env := loginenv.Environ()
 	env = append(env, "TERM=xterm-256color")
+	env = append(env, s.Env...)
 
 	shellType := getShellType(s.Shell)
 
@@ -153,7 +163,10 @@ func (s *Session) Start() error {
 
 	// Build optimized shell arguments based on shell type
 	// This avoids full login shell initialization which can be slow
-	args := s.buildShellArgs()
+	args := s.Args
+	if args == nil {
+		args = s.buildShellArgs()
+	}
 	cmd := p.Command(s.Shell, args...)
 	cmd.Dir = s.Cwd
 	cmd.Env = s.buildShellEnv()
@@ -166,6 +179,10 @@ func (s *Session) Start() error {
 	s.pty = p
 	s.cmd = cmd
 	s.started = true
+	go func() {
+		cmd.Wait()
+		close(s.exitCh)
+	}()
 
 	return nil
 }
@@ -236,6 +253,27 @@ func (s *Session) Done() <-chan struct{} {
 	return s.doneCh
 }
 
+// Exited returns a channel that is closed when the shell or program exits
+func (s *Session) Exited() <-chan struct{} {
+	return s.exitCh
+}
+
+// DefaultShell returns the shell terminals start when none is given
+func DefaultShell() string {
+	return getDefaultShell()
+}
+
+// LoginShellArgs returns the arguments that start shell as an interactive
+// login shell, which loads the user's full profile
+func LoginShellArgs(shell string) []string {
+	switch getShellType(shell) {
+	case ShellTypeBash, ShellTypeZsh, ShellTypeFish, ShellTypeSh:
+		return []string{"-l", "-i"}
+	}
+	// PowerShell loads its profile anyway and cmd has none
+	return (&Session{Shell: shell}).buildShellArgs()
+}
+
 // getDefaultShell returns the cached default shell path
 // This avoids repeated file system checks on each terminal creation
 func getDefaultShell() string {
@@ -43,3 +43,17 @@ func detectDefaultShell() string {
 	}
 	return "cmd.exe"
 }
+
+// AvailableShells lists the shells found on PATH
+func AvailableShells() []string {
+	var shells []string
+	for _, shell := range []string{"pwsh.exe", "powershell.exe", "cmd.exe"} {
+		if path, err := exec.LookPath(shell); err == nil {
+			shells = append(shells, path)
+		}
+	}
+	if len(shells) == 0 {
+		return []string{getDefaultShell()}
+	}
+	return shells
+}
@@ -0,0 +1,244 @@
+package resource
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/shirou/gopsutil/v4/process"
+)
+
+// DefaultInterval is how often the monitor samples its targets when running.
+const DefaultInterval = 5 * time.Second
+
+// Target kinds
+const (
+	KindSession = "session"
+	KindProcess = "process"
+)
+
+// Target identifies a root process to sample. Child processes of the target
+// are aggregated into its usage so that helper subprocesses spawned by the
+// provider CLIs (node, shells, MCP servers) are accounted for.
+type Target struct {
+	Kind     string
+	ID       string
+	Provider string
+	Cwd      string
+	PID      int
+}
+
+// Usage is a single resource sample for a target and its process tree.
+type Usage struct {
+	Kind         string    `json:"kind"`
+	ID           string    `json:"id"`
+	Provider     string    `json:"provider,omitempty"`
+	Cwd          string    `json:"cwd,omitempty"`
+	PID          int       `json:"pid"`
+	CPUPercent   float64   `json:"cpu_percent"`
+	RSSBytes     uint64    `json:"rss_bytes"`
+	ProcessCount int       `json:"process_count"`
+	SampledAt    time.Time `json:"sampled_at"`
+}
+
+// TargetSource returns the processes that should be sampled right now.
+type TargetSource func() []Target
+
+// UsageEmitter receives periodic usage samples.
+type UsageEmitter interface {
+	EmitResourceUsage(samples []Usage)
+}
+
+type cpuSample struct {
+	total float64
+	at    time.Time
+}
+
+// Monitor samples CPU and RSS for a dynamic set of target processes.
+type Monitor struct {
+	source   TargetSource
+	interval time.Duration
+
+	mu      sync.Mutex
+	emitter UsageEmitter
+	prevCPU map[int32]cpuSample
+	latest  []Usage
+	cancel  context.CancelFunc
+	done    chan struct{}
+}
+
+// NewMonitor creates a monitor that samples the targets returned by source.
+func NewMonitor(source TargetSource, interval time.Duration) *Monitor {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	return &Monitor{
+		source:   source,
+		interval: interval,
+		prevCPU:  make(map[int32]cpuSample),
+	}
+}
+
+// SetEmitter sets the emitter used for periodic usage events.
+func (m *Monitor) SetEmitter(emitter UsageEmitter) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.emitter = emitter
+}
+
+// Start begins periodic sampling until Stop is called or ctx is cancelled.
+func (m *Monitor) Start(ctx context.Context) {
+	m.mu.Lock()
+	if m.cancel != nil {
+		m.mu.Unlock()
+		return
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	m.cancel = cancel
+	m.done = make(chan struct{})
+	done := m.done
+	m.mu.Unlock()
+
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(m.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				samples := m.Sample()
+				m.mu.Lock()
+				emitter := m.emitter
+				m.mu.Unlock()
+				if emitter != nil && len(samples) > 0 {
+					emitter.EmitResourceUsage(samples)
+				}
+			}
+		}
+	}()
+}
+
+// Stop halts periodic sampling and waits for the sampler goroutine to exit.
+func (m *Monitor) Stop() {
+	m.mu.Lock()
+	cancel := m.cancel
+	done := m.done
+	m.cancel = nil
+	m.done = nil
+	m.mu.Unlock()
+
+	if cancel == nil {
+		return
+	}
+	cancel()
+	<-done
+}
+
+// Latest returns the most recent samples without triggering a new sample.
+func (m *Monitor) Latest() []Usage {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	result := make([]Usage, len(m.latest))
+	copy(result, m.latest)
+	return result
+}
+
+// Sample takes a fresh sample of every current target. CPU percentages are
+// computed from the delta since the previous sample of the same PID, so the
+// first sample of a new process reports its average since process start.
+func (m *Monitor) Sample() []Usage {
+	var targets []Target
+	if m.source != nil {
+		targets = m.source()
+	}
+
+	now := time.Now()
+	seen := make(map[int32]cpuSample)
+	result := make([]Usage, 0, len(targets))
+
+	m.mu.Lock()
+	prev := m.prevCPU
+	m.mu.Unlock()
+
+	for _, target := range targets {
+		if target.PID <= 0 {
+			continue
+		}
+		root, err := process.NewProcess(int32(target.PID))
+		if err != nil {
+			continue
+		}
+
+		usage := Usage{
+			Kind:      target.Kind,
+			ID:        target.ID,
+			Provider:  target.Provider,
+			Cwd:       target.Cwd,
+			PID:       target.PID,
+			SampledAt: now,
+		}
+		for _, proc := range processTree(root) {
+			if mem, err := proc.MemoryInfo(); err == nil && mem != nil {
+				usage.RSSBytes += mem.RSS
+			}
+			usage.ProcessCount++
+
+			times, err := proc.Times()
+			if err != nil || times == nil {
+				continue
+			}
+			total := times.User + times.System
+			seen[proc.Pid] = cpuSample{total: total, at: now}
+			if last, ok := prev[proc.Pid]; ok {
+				if elapsed := now.Sub(last.at).Seconds(); elapsed > 0 && total >= last.total {
+					usage.CPUPercent += (total - last.total) / elapsed * 100
+				}
+			} else if created, err := proc.CreateTime(); err == nil {
+				if elapsed := now.Sub(time.UnixMilli(created)).Seconds(); elapsed > 0 {
+					usage.CPUPercent += total / elapsed * 100
+				}
+			}
+		}
+		result = append(result, usage)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Kind != result[j].Kind {
+			return result[i].Kind > result[j].Kind
+		}
+		return result[i].ID < result[j].ID
+	})
+
+	m.mu.Lock()
+	m.prevCPU = seen
+	m.latest = result
+	m.mu.Unlock()
+
+	return result
+}
+
+// processTree returns root followed by all of its descendants.
+func processTree(root *process.Process) []*process.Process {
+	tree := []*process.Process{root}
+	visited := map[int32]bool{root.Pid: true}
+	for i := 0; i < len(tree); i++ {
+		children, err := tree[i].Children()
+		if err != nil {
+			continue
+		}
+		for _, child := range children {
+			if visited[child.Pid] {
+				continue
+			}
+			visited[child.Pid] = true
+			tree = append(tree, child)
+		}
+	}
+	return tree
+}
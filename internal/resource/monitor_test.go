@@ -0,0 +1,114 @@
+package resource
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+)
+
+type recordingEmitter struct {
+	mu      sync.Mutex
+	batches [][]Usage
+}
+
+func (e *recordingEmitter) EmitResourceUsage(samples []Usage) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.batches = append(e.batches, samples)
+}
+
+func (e *recordingEmitter) count() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return len(e.batches)
+}
+
+func TestSampleReportsCurrentProcess(t *testing.T) {
+	monitor := NewMonitor(func() []Target {
+		return []Target{{Kind: KindSession, ID: "self", Provider: "claude", PID: os.Getpid()}}
+	}, time.Second)
+
+	samples := monitor.Sample()
+	if len(samples) != 1 {
+		t.Fatalf("got %d samples, want 1", len(samples))
+	}
+	if samples[0].ID != "self" || samples[0].Provider != "claude" {
+		t.Fatalf("unexpected sample identity: %#v", samples[0])
+	}
+	if samples[0].RSSBytes == 0 {
+		t.Fatal("expected non-zero RSS for the test process")
+	}
+	if samples[0].ProcessCount < 1 {
+		t.Fatalf("expected at least one process in tree, got %d", samples[0].ProcessCount)
+	}
+	if latest := monitor.Latest(); len(latest) != 1 || latest[0].PID != os.Getpid() {
+		t.Fatalf("Latest did not return the last sample: %#v", latest)
+	}
+}
+
+func TestSampleSkipsMissingAndInvalidPIDs(t *testing.T) {
+	monitor := NewMonitor(func() []Target {
+		return []Target{
+			{Kind: KindProcess, ID: "zero", PID: 0},
+			{Kind: KindProcess, ID: "gone", PID: 1 << 30},
+		}
+	}, time.Second)
+
+	if samples := monitor.Sample(); len(samples) != 0 {
+		t.Fatalf("expected no samples, got %#v", samples)
+	}
+}
+
+func TestSampleAggregatesChildProcesses(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sleep binary")
+	}
+	child := exec.Command("sleep", "5")
+	if err := child.Start(); err != nil {
+		t.Skipf("sleep unavailable: %v", err)
+	}
+	defer func() {
+		_ = child.Process.Kill()
+		_ = child.Wait()
+	}()
+
+	monitor := NewMonitor(func() []Target {
+		return []Target{{Kind: KindSession, ID: "self", PID: os.Getpid()}}
+	}, time.Second)
+
+	samples := monitor.Sample()
+	if len(samples) != 1 {
+		t.Fatalf("got %d samples, want 1", len(samples))
+	}
+	if samples[0].ProcessCount < 2 {
+		t.Fatalf("expected child process to be counted, got %d", samples[0].ProcessCount)
+	}
+}
+
+func TestStartEmitsPeriodicSamples(t *testing.T) {
+	emitter := &recordingEmitter{}
+	monitor := NewMonitor(func() []Target {
+		return []Target{{Kind: KindSession, ID: "self", PID: os.Getpid()}}
+	}, 20*time.Millisecond)
+	monitor.SetEmitter(emitter)
+
+	monitor.Start(context.Background())
+	deadline := time.Now().Add(2 * time.Second)
+	for emitter.count() < 2 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	monitor.Stop()
+
+	if emitter.count() < 2 {
+		t.Fatalf("expected at least 2 periodic emissions, got %d", emitter.count())
+	}
+	stopped := emitter.count()
+	time.Sleep(60 * time.Millisecond)
+	if emitter.count() != stopped {
+		t.Fatal("monitor kept emitting after Stop")
+	}
+}
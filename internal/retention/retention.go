@@ -0,0 +1,318 @@
+// Package retention archives the session transcripts the provider CLIs
+// leave behind, which otherwise grow without bound. A policy picks the
+// transcripts older than a maximum age and then the oldest ones while the
+// rest are over a size cap. Archived transcripts are compressed with zstd
+// into the archive directory, under the provider's name and their path
+// relative to the provider's session directory, and removed from it.
+package retention
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Grace keeps transcripts written recently, whose sessions may still be in
+// use, whatever the policy
+const Grace = 24 * time.Hour
+
+// archiveExt is appended to the name of an archived transcript
+const archiveExt = ".zst"
+
+// Source is a provider's session directory
+type Source struct {
+	Provider string
+	Root     string
+	// Exts are the extensions of its transcripts
+	Exts []string
+}
+
+// File is one session transcript
+type File struct {
+	Provider string    `json:"provider"`
+	Path     string    `json:"path"`
+	Size     int64     `json:"size"`
+	ModTime  time.Time `json:"mod_time"`
+	root     string
+}
+
+// Policy says which transcripts are archived
+type Policy struct {
+	// MaxAge archives transcripts last written longer ago; 0 keeps them
+	MaxAge time.Duration
+	// MaxBytes archives the oldest transcripts until the rest fit; 0 means
+	// no cap
+	MaxBytes int64
+}
+
+// ProviderStats summarizes a provider's transcripts
+type ProviderStats struct {
+	Provider string `json:"provider"`
+	Root     string `json:"root"`
+	Files    int    `json:"files"`
+	Bytes    int64  `json:"bytes"`
+	// Reclaimable counts the transcripts the policy would archive
+	ReclaimableFiles int   `json:"reclaimable_files"`
+	ReclaimableBytes int64 `json:"reclaimable_bytes"`
+}
+
+// Stats summarizes the transcripts of every provider and the archive
+type Stats struct {
+	Providers        []ProviderStats `json:"providers"`
+	Files            int             `json:"files"`
+	Bytes            int64           `json:"bytes"`
+	ReclaimableFiles int             `json:"reclaimable_files"`
+	ReclaimableBytes int64           `json:"reclaimable_bytes"`
+	ArchiveDir       string          `json:"archive_dir"`
+	ArchivedFiles    int             `json:"archived_files"`
+	ArchivedBytes    int64           `json:"archived_bytes"`
+}
+
+// ArchiveResult reports what an archival run did
+type ArchiveResult struct {
+	Archived int `json:"archived"`
+	// FreedBytes is the size of the archived transcripts less the size of
+	// their archives
+	FreedBytes int64    `json:"freed_bytes"`
+	Failed     []string `json:"failed"`
+	Stats      *Stats   `json:"stats"`
+}
+
+// Manager applies retention policies to the transcripts of its sources
+type Manager struct {
+	Sources    []Source
+	ArchiveDir string
+}
+
+// List returns the transcripts of every source, oldest first. Missing
+// session directories have none.
+func (m *Manager) List() ([]File, error) {
+	var files []File
+	for _, source := range m.Sources {
+		err := filepath.WalkDir(source.Root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if path == source.Root {
+					return fs.SkipDir
+				}
+				return nil
+			}
+			if !d.Type().IsRegular() || !hasExt(path, source.Exts) {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				return nil
+			}
+			files = append(files, File{
+				Provider: source.Provider,
+				Path:     path,
+				Size:     info.Size(),
+				ModTime:  info.ModTime(),
+				root:     source.Root,
+			})
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.SliceStable(files, func(i, j int) bool { return files[i].ModTime.Before(files[j].ModTime) })
+	return files, nil
+}
+
+func hasExt(path string, exts []string) bool {
+	for _, ext := range exts {
+		if filepath.Ext(path) == ext {
+			return true
+		}
+	}
+	return false
+}
+
+// Select returns the transcripts policy archives. files must be oldest
+// first, as from List.
+func Select(files []File, policy Policy, now time.Time) []File {
+	var selected, kept []File
+	var total int64
+	for _, file := range files {
+		age := now.Sub(file.ModTime)
+		if age > Grace && policy.MaxAge > 0 && age > policy.MaxAge {
+			selected = append(selected, file)
+			continue
+		}
+		kept = append(kept, file)
+		total += file.Size
+	}
+
+	if policy.MaxBytes > 0 {
+		for _, file := range kept {
+			if total <= policy.MaxBytes {
+				break
+			}
+			if now.Sub(file.ModTime) > Grace {
+				selected = append(selected, file)
+				total -= file.Size
+			}
+		}
+	}
+	return selected
+}
+
+// Stats summarizes the transcripts and what policy would archive
+func (m *Manager) Stats(policy Policy, now time.Time) (*Stats, error) {
+	files, err := m.List()
+	if err != nil {
+		return nil, err
+	}
+	return m.summarize(files, Select(files, policy, now)), nil
+}
+
+func (m *Manager) summarize(files, selected []File) *Stats {
+	stats := &Stats{Providers: []ProviderStats{}, ArchiveDir: m.ArchiveDir}
+	byProvider := make(map[string]*ProviderStats)
+	for _, source := range m.Sources {
+		stats.Providers = append(stats.Providers, ProviderStats{Provider: source.Provider, Root: source.Root})
+	}
+	for i := range stats.Providers {
+		byProvider[stats.Providers[i].Provider] = &stats.Providers[i]
+	}
+	for _, file := range files {
+		provider := byProvider[file.Provider]
+		provider.Files++
+		provider.Bytes += file.Size
+		stats.Files++
+		stats.Bytes += file.Size
+	}
+	for _, file := range selected {
+		provider := byProvider[file.Provider]
+		provider.ReclaimableFiles++
+		provider.ReclaimableBytes += file.Size
+		stats.ReclaimableFiles++
+		stats.ReclaimableBytes += file.Size
+	}
+
+	filepath.WalkDir(m.ArchiveDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() || filepath.Ext(path) != archiveExt {
+			return nil
+		}
+		if info, err := d.Info(); err == nil {
+			stats.ArchivedFiles++
+			stats.ArchivedBytes += info.Size()
+		}
+		return nil
+	})
+	return stats
+}
+
+// Archive compresses the transcripts policy selects into the archive
+// directory and removes them. A transcript that fails, or is written to
+// while it is compressed, is left in place and reported.
+func (m *Manager) Archive(policy Policy, now time.Time) (*ArchiveResult, error) {
+	files, err := m.List()
+	if err != nil {
+		return nil, err
+	}
+	result := &ArchiveResult{Failed: []string{}}
+	archived := make(map[string]bool)
+	for _, file := range Select(files, policy, now) {
+		size, err := m.archive(file)
+		if err != nil {
+			result.Failed = append(result.Failed, fmt.Sprintf("%s: %v", file.Path, err))
+			continue
+		}
+		archived[file.Path] = true
+		result.Archived++
+		result.FreedBytes += file.Size - size
+	}
+
+	remaining := files[:0]
+	for _, file := range files {
+		if !archived[file.Path] {
+			remaining = append(remaining, file)
+		}
+	}
+	result.Stats = m.summarize(remaining, Select(remaining, policy, now))
+	return result, nil
+}
+
+// archivePath returns where file is archived
+func (m *Manager) archivePath(file File) (string, error) {
+	rel, err := filepath.Rel(file.root, file.Path)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(m.ArchiveDir, file.Provider, rel+archiveExt), nil
+}
+
+// archive compresses file into the archive and removes it, returning the
+// size of the archive
+func (m *Manager) archive(file File) (int64, error) {
+	dst, err := m.archivePath(file)
+	if err != nil {
+		return 0, err
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return 0, err
+	}
+	tmp := dst + ".tmp"
+	size, err := compressFile(file.Path, tmp)
+	if err == nil {
+		// The session was resumed meanwhile; keep it
+		if info, statErr := os.Stat(file.Path); statErr != nil || info.Size() != file.Size || !info.ModTime().Equal(file.ModTime) {
+			err = fmt.Errorf("changed while archiving")
+		}
+	}
+	if err == nil {
+		err = os.Rename(tmp, dst)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return 0, err
+	}
+	os.Chtimes(dst, file.ModTime, file.ModTime)
+	if err := os.Remove(file.Path); err != nil {
+		return 0, err
+	}
+	return size, nil
+}
+
+// compressFile writes src compressed with zstd to dst and returns the
+// compressed size
+func compressFile(src, dst string) (int64, error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return 0, err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return 0, err
+	}
+	enc, err := zstd.NewWriter(out)
+	if err == nil {
+		_, err = io.Copy(enc, in)
+		if closeErr := enc.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if syncErr := out.Sync(); err == nil {
+		err = syncErr
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return 0, err
+	}
+	info, err := os.Stat(dst)
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
@@ -0,0 +1,111 @@
+package retention
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+func writeTranscript(t *testing.T, path string, size int, modTime time.Time) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(strings.Repeat("x", size)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	os.Chtimes(path, modTime, modTime)
+}
+
+func TestSelect(t *testing.T) {
+	now := time.Now()
+	files := []File{
+		{Path: "old", Size: 100, ModTime: now.Add(-40 * 24 * time.Hour)},
+		{Path: "month", Size: 100, ModTime: now.Add(-20 * 24 * time.Hour)},
+		{Path: "week", Size: 100, ModTime: now.Add(-7 * 24 * time.Hour)},
+		{Path: "today", Size: 100, ModTime: now.Add(-time.Hour)},
+	}
+	paths := func(files []File) string {
+		var names []string
+		for _, file := range files {
+			names = append(names, file.Path)
+		}
+		return strings.Join(names, ",")
+	}
+
+	tests := []struct {
+		policy Policy
+		want   string
+	}{
+		{Policy{}, ""},
+		{Policy{MaxAge: 30 * 24 * time.Hour}, "old"},
+		{Policy{MaxBytes: 250}, "old,month"},
+		{Policy{MaxAge: 30 * 24 * time.Hour, MaxBytes: 150}, "old,month,week"},
+		// Recent transcripts are kept even over the cap
+		{Policy{MaxAge: time.Minute, MaxBytes: 1}, "old,month,week"},
+	}
+	for _, tt := range tests {
+		if got := paths(Select(files, tt.policy, now)); got != tt.want {
+			t.Errorf("Select(%+v) = %q, want %q", tt.policy, got, tt.want)
+		}
+	}
+}
+
+func TestArchive(t *testing.T) {
+	root := t.TempDir()
+	claudeRoot := filepath.Join(root, "claude", "projects")
+	geminiRoot := filepath.Join(root, "gemini", "tmp")
+	now := time.Now()
+	oldClaude := filepath.Join(claudeRoot, "-work-app", "s1.jsonl")
+	writeTranscript(t, oldClaude, 5000, now.Add(-60*24*time.Hour))
+	writeTranscript(t, filepath.Join(claudeRoot, "-work-app", "s2.jsonl"), 100, now.Add(-time.Hour))
+	writeTranscript(t, filepath.Join(claudeRoot, "-work-app", "notes.txt"), 100, now.Add(-60*24*time.Hour))
+	writeTranscript(t, filepath.Join(geminiRoot, "hash", "chats", "session-g1.json"), 300, now.Add(-45*24*time.Hour))
+
+	manager := &Manager{
+		Sources: []Source{
+			{Provider: "claude", Root: claudeRoot, Exts: []string{".jsonl"}},
+			{Provider: "codex", Root: filepath.Join(root, "codex", "missing"), Exts: []string{".jsonl"}},
+			{Provider: "gemini", Root: geminiRoot, Exts: []string{".json"}},
+		},
+		ArchiveDir: filepath.Join(root, "archive"),
+	}
+	policy := Policy{MaxAge: 30 * 24 * time.Hour}
+
+	stats, err := manager.Stats(policy, now)
+	if err != nil || stats.Files != 3 || stats.ReclaimableFiles != 2 || stats.ReclaimableBytes != 5300 {
+		t.Fatalf("Stats() = %+v, %v", stats, err)
+	}
+	if claude := stats.Providers[0]; claude.Files != 2 || claude.ReclaimableBytes != 5000 {
+		t.Fatalf("claude stats = %+v", claude)
+	}
+
+	result, err := manager.Archive(policy, now)
+	if err != nil || result.Archived != 2 || len(result.Failed) != 0 || result.FreedBytes <= 0 {
+		t.Fatalf("Archive() = %+v, %v", result, err)
+	}
+	if _, err := os.Stat(oldClaude); !os.IsNotExist(err) {
+		t.Fatalf("archived transcript still exists: %v", err)
+	}
+	if result.Stats.Files != 1 || result.Stats.ReclaimableFiles != 0 || result.Stats.ArchivedFiles != 2 {
+		t.Fatalf("stats after archiving = %+v", result.Stats)
+	}
+
+	data, err := os.ReadFile(filepath.Join(manager.ArchiveDir, "claude", "-work-app", "s1.jsonl.zst"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dec, _ := zstd.NewReader(nil)
+	defer dec.Close()
+	original, err := dec.DecodeAll(data, nil)
+	if err != nil || len(original) != 5000 {
+		t.Fatalf("archive decodes to %d bytes, %v", len(original), err)
+	}
+	if _, err := os.Stat(filepath.Join(manager.ArchiveDir, "gemini", "hash", "chats", "session-g1.json.zst")); err != nil {
+		t.Fatalf("gemini archive: %v", err)
+	}
+}
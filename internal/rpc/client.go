@@ -31,6 +31,18 @@ type responseEnvelope struct {
 
 type EventHandler func(payload json.RawMessage)
 
+// ForwardHandler receives every event in the order the server sent it
+type ForwardHandler func(eventType string, payload json.RawMessage)
+
+// forwardBufferSize bounds the events waiting for the forward handler;
+// further events are dropped rather than stalling responses
+const forwardBufferSize = 1024
+
+type forwardedEvent struct {
+	eventType string
+	payload   json.RawMessage
+}
+
 type Client struct {
 	conn *websocket.Conn
 
@@ -40,6 +52,7 @@ type Client struct {
 
 	pending  map[string]chan responseEnvelope
 	handlers map[string][]EventHandler
+	forward  chan forwardedEvent
 	closeCh  chan struct{}
 	doneCh   chan struct{}
 }
@@ -143,6 +156,53 @@ func (c *Client) OnEvent(eventType string, handler func(payload json.RawMessage)
 	c.handlers[eventType] = append(c.handlers[eventType], handler)
 }
 
+// Forward delivers every event to handler, one at a time and in order.
+// Unlike OnEvent handlers it suits streams such as session output. Only
+// the first call has an effect.
+func (c *Client) Forward(handler ForwardHandler) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed || c.forward != nil {
+		return
+	}
+	c.forward = make(chan forwardedEvent, forwardBufferSize)
+	go c.forwardLoop(c.forward, handler)
+}
+
+func (c *Client) forwardLoop(events <-chan forwardedEvent, handler ForwardHandler) {
+	for {
+		select {
+		case event := <-events:
+			c.runForwardHandler(handler, event)
+		case <-c.closeCh:
+			return
+		}
+	}
+}
+
+func (c *Client) runForwardHandler(handler ForwardHandler, event forwardedEvent) {
+	defer func() {
+		_ = recover()
+	}()
+	handler(event.eventType, event.payload)
+}
+
+// Subscribe limits the events the server pushes to the given topics (see
+// the websocket package)
+func (c *Client) Subscribe(topics []string) error {
+	return c.writeJSON(&ws.WSMessage{Kind: "subscribe", Topics: topics})
+}
+
+// Unsubscribe stops the events of the given topics
+func (c *Client) Unsubscribe(topics []string) error {
+	return c.writeJSON(&ws.WSMessage{Kind: "unsubscribe", Topics: topics})
+}
+
+// Done is closed once the connection is gone
+func (c *Client) Done() <-chan struct{} {
+	return c.doneCh
+}
+
 func (c *Client) Close() error {
 	c.mu.Lock()
 	if c.closed {
@@ -229,6 +289,7 @@ func (c *Client) handleEvent(event *ws.WSEvent) {
 
 	c.mu.RLock()
 	handlers := append([]EventHandler(nil), c.handlers[event.Type]...)
+	forward := c.forward
 	c.mu.RUnlock()
 
 	payload, err := json.Marshal(event.Payload)
@@ -236,6 +297,13 @@ func (c *Client) handleEvent(event *ws.WSEvent) {
 		return
 	}
 
+	if forward != nil {
+		select {
+		case forward <- forwardedEvent{eventType: event.Type, payload: payload}:
+		default:
+		}
+	}
+
 	for _, handler := range handlers {
 		go c.runEventHandler(handler, payload)
 	}
@@ -310,3 +310,46 @@ func TestRPCClient_CloseDoesNotHangOnBlockingEventHandler(t *testing.T) {
 
 	close(releaseHandler)
 }
+
+func TestRPCClient_ForwardKeepsEventOrder(t *testing.T) {
+	app := &testApp{}
+	server := ws.NewServer(app)
+	port := startTestServer(t, server)
+
+	client, err := Dial(fmt.Sprintf("ws://127.0.0.1:%d/ws", port), server.GetAuthKey())
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer client.Close()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for server.ClientCount() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for server to register client")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	const count = 50
+	received := make(chan int, count)
+	client.Forward(func(eventType string, payload json.RawMessage) {
+		var n int
+		if eventType == "tick" && json.Unmarshal(payload, &n) == nil {
+			received <- n
+		}
+	})
+	for i := 0; i < count; i++ {
+		server.BroadcastEvent("tick", i)
+	}
+
+	for want := 0; want < count; want++ {
+		select {
+		case got := <-received:
+			if got != want {
+				t.Fatalf("event %d arrived as %d", want, got)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("timed out waiting for event %d", want)
+		}
+	}
+}
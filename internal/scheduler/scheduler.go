@@ -0,0 +1,318 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrCancelled is returned by Acquire when a queued request is cancelled.
+var ErrCancelled = errors.New("queued session cancelled")
+
+// DefaultPollInterval is how often a non-empty queue re-checks capacity.
+const DefaultPollInterval = 500 * time.Millisecond
+
+// Limits caps the number of concurrently running provider sessions.
+// A zero or missing value means unlimited.
+type Limits struct {
+	Global      int            `json:"global"`
+	PerProvider map[string]int `json:"per_provider,omitempty"`
+}
+
+// Validate rejects negative limits.
+func (l Limits) Validate() error {
+	if l.Global < 0 {
+		return fmt.Errorf("global limit must not be negative: %d", l.Global)
+	}
+	for provider, limit := range l.PerProvider {
+		if limit < 0 {
+			return fmt.Errorf("limit for %s must not be negative: %d", provider, limit)
+		}
+	}
+	return nil
+}
+
+// QueueEntry describes a session start request waiting for a free slot.
+type QueueEntry struct {
+	ID          string    `json:"id"`
+	Provider    string    `json:"provider"`
+	ProjectPath string    `json:"project_path"`
+	Position    int       `json:"position"`
+	QueuedAt    time.Time `json:"queued_at"`
+}
+
+// QueueEmitter receives the full queue whenever it changes.
+type QueueEmitter interface {
+	EmitSessionQueueChanged(entries []QueueEntry)
+}
+
+// RunningCounter reports the number of running sessions per provider.
+type RunningCounter func() map[string]int
+
+type waiter struct {
+	entry QueueEntry
+	ready chan struct{}
+	err   error
+}
+
+// Scheduler gates provider session starts behind global and per-provider
+// concurrency limits. Requests beyond the limit wait in FIFO order; a waiter
+// blocked only by its own provider limit does not hold back other providers.
+type Scheduler struct {
+	counter      RunningCounter
+	pollInterval time.Duration
+
+	mu       sync.Mutex
+	limits   Limits
+	starting map[string]int
+	queue    []*waiter
+	emitter  QueueEmitter
+	polling  bool
+}
+
+// New creates a scheduler that uses counter to observe running sessions.
+func New(counter RunningCounter) *Scheduler {
+	return &Scheduler{
+		counter:      counter,
+		pollInterval: DefaultPollInterval,
+		starting:     make(map[string]int),
+	}
+}
+
+// SetEmitter sets the emitter used for queue change events.
+func (s *Scheduler) SetEmitter(emitter QueueEmitter) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.emitter = emitter
+}
+
+// Limits returns the current limits.
+func (s *Scheduler) Limits() Limits {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return copyLimits(s.limits)
+}
+
+// SetLimits replaces the current limits and admits any waiters that now fit.
+func (s *Scheduler) SetLimits(limits Limits) error {
+	if err := limits.Validate(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.limits = copyLimits(limits)
+	s.mu.Unlock()
+	s.Notify()
+	return nil
+}
+
+// Acquire blocks until a session for provider may start, ctx is done, or the
+// request is cancelled. The returned release func must be called once the
+// session has been registered with its manager (or failed to start).
+func (s *Scheduler) Acquire(ctx context.Context, provider, projectPath string) (func(), error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	running := s.runningCounts()
+
+	s.mu.Lock()
+	if len(s.queue) == 0 && s.hasCapacityLocked(running, provider) {
+		s.starting[provider]++
+		s.mu.Unlock()
+		return s.releaseFunc(provider), nil
+	}
+
+	w := &waiter{
+		entry: QueueEntry{
+			ID:          uuid.New().String(),
+			Provider:    provider,
+			ProjectPath: projectPath,
+			QueuedAt:    time.Now(),
+		},
+		ready: make(chan struct{}),
+	}
+	s.queue = append(s.queue, w)
+	entries, emitter := s.snapshotLocked()
+	startPoll := !s.polling
+	s.polling = true
+	s.mu.Unlock()
+
+	if emitter != nil {
+		emitter.EmitSessionQueueChanged(entries)
+	}
+	if startPoll {
+		go s.pollLoop()
+	}
+
+	select {
+	case <-w.ready:
+		if w.err != nil {
+			return nil, w.err
+		}
+		return s.releaseFunc(provider), nil
+	case <-ctx.Done():
+		if s.remove(w.entry.ID, ctx.Err()) {
+			return nil, ctx.Err()
+		}
+		// Granted concurrently with cancellation; honour the grant.
+		<-w.ready
+		if w.err != nil {
+			return nil, w.err
+		}
+		return s.releaseFunc(provider), nil
+	}
+}
+
+// Cancel removes a queued request. It returns false if id is not queued.
+func (s *Scheduler) Cancel(id string) bool {
+	return s.remove(id, ErrCancelled)
+}
+
+// Queue returns the current queue in admission order.
+func (s *Scheduler) Queue() []QueueEntry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entries, _ := s.snapshotLocked()
+	return entries
+}
+
+// Notify re-checks capacity and admits waiters that now fit.
+func (s *Scheduler) Notify() {
+	running := s.runningCounts()
+
+	s.mu.Lock()
+	if len(s.queue) == 0 {
+		s.mu.Unlock()
+		return
+	}
+	granted := false
+	remaining := s.queue[:0]
+	for _, w := range s.queue {
+		if s.hasCapacityLocked(running, w.entry.Provider) {
+			s.starting[w.entry.Provider]++
+			close(w.ready)
+			granted = true
+			continue
+		}
+		remaining = append(remaining, w)
+	}
+	s.queue = remaining
+	var entries []QueueEntry
+	var emitter QueueEmitter
+	if granted {
+		entries, emitter = s.snapshotLocked()
+	}
+	s.mu.Unlock()
+
+	if emitter != nil {
+		emitter.EmitSessionQueueChanged(entries)
+	}
+}
+
+func (s *Scheduler) pollLoop() {
+	ticker := time.NewTicker(s.pollInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		s.Notify()
+		s.mu.Lock()
+		if len(s.queue) == 0 {
+			s.polling = false
+			s.mu.Unlock()
+			return
+		}
+		s.mu.Unlock()
+	}
+}
+
+func (s *Scheduler) remove(id string, err error) bool {
+	s.mu.Lock()
+	index := -1
+	for i, w := range s.queue {
+		if w.entry.ID == id {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		s.mu.Unlock()
+		return false
+	}
+	w := s.queue[index]
+	s.queue = append(s.queue[:index], s.queue[index+1:]...)
+	w.err = err
+	close(w.ready)
+	entries, emitter := s.snapshotLocked()
+	s.mu.Unlock()
+
+	if emitter != nil {
+		emitter.EmitSessionQueueChanged(entries)
+	}
+	return true
+}
+
+func (s *Scheduler) releaseFunc(provider string) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			s.mu.Lock()
+			if s.starting[provider] > 0 {
+				s.starting[provider]--
+			}
+			s.mu.Unlock()
+			s.Notify()
+		})
+	}
+}
+
+func (s *Scheduler) runningCounts() map[string]int {
+	if s.counter == nil {
+		return map[string]int{}
+	}
+	return s.counter()
+}
+
+func (s *Scheduler) hasCapacityLocked(running map[string]int, provider string) bool {
+	if s.limits.Global > 0 {
+		total := 0
+		for _, count := range running {
+			total += count
+		}
+		for _, count := range s.starting {
+			total += count
+		}
+		if total >= s.limits.Global {
+			return false
+		}
+	}
+	if limit := s.limits.PerProvider[provider]; limit > 0 {
+		if running[provider]+s.starting[provider] >= limit {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *Scheduler) snapshotLocked() ([]QueueEntry, QueueEmitter) {
+	entries := make([]QueueEntry, 0, len(s.queue))
+	for i, w := range s.queue {
+		entry := w.entry
+		entry.Position = i + 1
+		entries = append(entries, entry)
+	}
+	return entries, s.emitter
+}
+
+func copyLimits(limits Limits) Limits {
+	result := Limits{Global: limits.Global}
+	if len(limits.PerProvider) > 0 {
+		result.PerProvider = make(map[string]int, len(limits.PerProvider))
+		for provider, limit := range limits.PerProvider {
+			result.PerProvider[provider] = limit
+		}
+	}
+	return result
+}
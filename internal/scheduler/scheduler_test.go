@@ -0,0 +1,218 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeRunning struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+func (f *fakeRunning) set(provider string, count int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.counts[provider] = count
+}
+
+func (f *fakeRunning) count() map[string]int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	result := make(map[string]int, len(f.counts))
+	for k, v := range f.counts {
+		result[k] = v
+	}
+	return result
+}
+
+type recordingEmitter struct {
+	mu      sync.Mutex
+	updates [][]QueueEntry
+}
+
+func (e *recordingEmitter) EmitSessionQueueChanged(entries []QueueEntry) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.updates = append(e.updates, entries)
+}
+
+func (e *recordingEmitter) last() []QueueEntry {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if len(e.updates) == 0 {
+		return nil
+	}
+	return e.updates[len(e.updates)-1]
+}
+
+func newTestScheduler(running *fakeRunning) *Scheduler {
+	s := New(running.count)
+	s.pollInterval = 10 * time.Millisecond
+	return s
+}
+
+func waitForQueueLen(t *testing.T, s *Scheduler, want int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if len(s.Queue()) == want {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("queue length = %d, want %d", len(s.Queue()), want)
+}
+
+func TestAcquireWithoutLimitsNeverQueues(t *testing.T) {
+	running := &fakeRunning{counts: map[string]int{"claude": 10}}
+	s := newTestScheduler(running)
+
+	release, err := s.Acquire(context.Background(), "claude", "/repo")
+	if err != nil {
+		t.Fatalf("Acquire: %v", err)
+	}
+	release()
+	if len(s.Queue()) != 0 {
+		t.Fatalf("expected empty queue, got %#v", s.Queue())
+	}
+}
+
+func TestAcquireQueuesBeyondGlobalLimitAndAdmitsWhenSlotFrees(t *testing.T) {
+	running := &fakeRunning{counts: map[string]int{"claude": 1, "codex": 1}}
+	s := newTestScheduler(running)
+	emitter := &recordingEmitter{}
+	s.SetEmitter(emitter)
+	if err := s.SetLimits(Limits{Global: 2}); err != nil {
+		t.Fatalf("SetLimits: %v", err)
+	}
+
+	acquired := make(chan error, 1)
+	go func() {
+		release, err := s.Acquire(context.Background(), "gemini", "/repo")
+		if err == nil {
+			release()
+		}
+		acquired <- err
+	}()
+
+	waitForQueueLen(t, s, 1)
+	queued := emitter.last()
+	if len(queued) != 1 || queued[0].Position != 1 || queued[0].Provider != "gemini" {
+		t.Fatalf("unexpected queue event: %#v", queued)
+	}
+
+	running.set("codex", 0)
+	select {
+	case err := <-acquired:
+		if err != nil {
+			t.Fatalf("Acquire: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("queued request was not admitted after a slot freed")
+	}
+	if last := emitter.last(); len(last) != 0 {
+		t.Fatalf("expected empty queue event after admission, got %#v", last)
+	}
+}
+
+func TestPerProviderLimitDoesNotBlockOtherProviders(t *testing.T) {
+	running := &fakeRunning{counts: map[string]int{"claude": 1}}
+	s := newTestScheduler(running)
+	if err := s.SetLimits(Limits{PerProvider: map[string]int{"claude": 1}}); err != nil {
+		t.Fatalf("SetLimits: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	blocked := make(chan error, 1)
+	go func() {
+		_, err := s.Acquire(ctx, "claude", "/a")
+		blocked <- err
+	}()
+	waitForQueueLen(t, s, 1)
+
+	release, err := s.Acquire(context.Background(), "codex", "/b")
+	if err != nil {
+		t.Fatalf("codex should not wait behind claude: %v", err)
+	}
+	release()
+
+	cancel()
+	if err := <-blocked; !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context cancellation, got %v", err)
+	}
+	waitForQueueLen(t, s, 0)
+}
+
+func TestStartingReservationsCountTowardLimit(t *testing.T) {
+	running := &fakeRunning{counts: map[string]int{}}
+	s := newTestScheduler(running)
+	if err := s.SetLimits(Limits{Global: 1}); err != nil {
+		t.Fatalf("SetLimits: %v", err)
+	}
+
+	release, err := s.Acquire(context.Background(), "claude", "/a")
+	if err != nil {
+		t.Fatalf("Acquire: %v", err)
+	}
+
+	second := make(chan error, 1)
+	go func() {
+		r, err := s.Acquire(context.Background(), "claude", "/b")
+		if err == nil {
+			r()
+		}
+		second <- err
+	}()
+	waitForQueueLen(t, s, 1)
+
+	release()
+	select {
+	case err := <-second:
+		if err != nil {
+			t.Fatalf("second Acquire: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("second request was not admitted after release")
+	}
+}
+
+func TestCancelRemovesQueuedRequest(t *testing.T) {
+	running := &fakeRunning{counts: map[string]int{"claude": 1}}
+	s := newTestScheduler(running)
+	if err := s.SetLimits(Limits{Global: 1}); err != nil {
+		t.Fatalf("SetLimits: %v", err)
+	}
+
+	result := make(chan error, 1)
+	go func() {
+		_, err := s.Acquire(context.Background(), "claude", "/a")
+		result <- err
+	}()
+	waitForQueueLen(t, s, 1)
+
+	id := s.Queue()[0].ID
+	if !s.Cancel(id) {
+		t.Fatal("Cancel returned false for queued id")
+	}
+	if err := <-result; !errors.Is(err, ErrCancelled) {
+		t.Fatalf("expected ErrCancelled, got %v", err)
+	}
+	if s.Cancel(id) {
+		t.Fatal("Cancel should return false once removed")
+	}
+}
+
+func TestSetLimitsRejectsNegativeValues(t *testing.T) {
+	s := New(nil)
+	if err := s.SetLimits(Limits{Global: -1}); err == nil {
+		t.Fatal("expected error for negative global limit")
+	}
+	if err := s.SetLimits(Limits{PerProvider: map[string]int{"codex": -2}}); err == nil {
+		t.Fatal("expected error for negative provider limit")
+	}
+}
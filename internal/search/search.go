@@ -0,0 +1,315 @@
+// Package search finds text in the files of a project. Files are read by a
+// pool of workers while the tree is walked, and matches are handed to the
+// caller in batches as they are found.
+package search
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// DefaultMaxResults is used when Options.MaxResults is not set
+	DefaultMaxResults = 1000
+	// maxMaxResults caps Options.MaxResults
+	maxMaxResults = 10000
+	// maxFileSize skips files too big to be source code
+	maxFileSize = 4 << 20
+	// binarySniffLen is how much of a file is checked for NUL bytes
+	binarySniffLen = 8000
+	// maxLineLen truncates very long lines (minified code) in results
+	maxLineLen = 500
+	// batchSize and batchInterval bound how long matches are held back
+	batchSize     = 100
+	batchInterval = 100 * time.Millisecond
+)
+
+// Options describes a search
+type Options struct {
+	Query string
+	// Regex treats Query as a Go regular expression. Otherwise it is
+	// literal text, matched case-insensitively unless it has an upper case
+	// letter.
+	Regex bool
+	// Include limits the search to files whose base name or path relative
+	// to the root matches one of these globs; empty searches every file
+	Include []string
+	// MaxResults stops the search after this many matches
+	MaxResults int
+	// ContextLines is the number of lines kept before and after a match
+	ContextLines int
+}
+
+// Match is one matching line
+type Match struct {
+	File   string   `json:"file"`
+	Line   int      `json:"line"`
+	Column int      `json:"column"` // 1-based byte offset of the match in the line
+	Length int      `json:"length"` // byte length of the match
+	Text   string   `json:"text"`
+	Before []string `json:"before,omitempty"`
+	After  []string `json:"after,omitempty"`
+}
+
+// Result summarizes a finished search
+type Result struct {
+	Matches       int  `json:"matches"`
+	FilesSearched int  `json:"files_searched"`
+	FilesMatched  int  `json:"files_matched"`
+	Truncated     bool `json:"truncated"`
+}
+
+// SkipDir reports whether a directory is left out of searches: hidden
+// directories, dependencies and caches
+func SkipDir(name string) bool {
+	return strings.HasPrefix(name, ".") || name == "node_modules" || name == "vendor" || name == "__pycache__"
+}
+
+// Compile returns the matcher for opts
+func Compile(opts Options) (*regexp.Regexp, error) {
+	if opts.Query == "" {
+		return nil, fmt.Errorf("search query is empty")
+	}
+	if opts.Regex {
+		re, err := regexp.Compile(opts.Query)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regular expression: %w", err)
+		}
+		return re, nil
+	}
+	pattern := regexp.QuoteMeta(opts.Query)
+	if !strings.ContainsFunc(opts.Query, unicode.IsUpper) {
+		pattern = "(?i)" + pattern
+	}
+	return regexp.MustCompile(pattern), nil
+}
+
+// Search looks for opts.Query in the files under root. emit is called from a
+// single goroutine with batches of matches in no particular order. The
+// search stops early when ctx is done, returning ctx.Err().
+func Search(ctx context.Context, root string, opts Options, emit func([]Match)) (*Result, error) {
+	re, err := Compile(opts)
+	if err != nil {
+		return nil, err
+	}
+	for _, glob := range opts.Include {
+		if _, err := path.Match(filepath.ToSlash(glob), ""); err != nil {
+			return nil, fmt.Errorf("invalid include glob %q: %w", glob, err)
+		}
+	}
+	if info, err := os.Stat(root); err != nil {
+		return nil, err
+	} else if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", root)
+	}
+	maxResults := opts.MaxResults
+	if maxResults <= 0 {
+		maxResults = DefaultMaxResults
+	}
+	maxResults = min(maxResults, maxMaxResults)
+
+	ctx, stop := context.WithCancel(ctx)
+	defer stop()
+
+	files := make(chan string, 256)
+	found := make(chan []Match, 64)
+	searched := make(chan int, 1)
+
+	// Walk the tree
+	go func() {
+		defer close(files)
+		filepath.WalkDir(root, func(file string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return nil // Skip unreadable entries
+			}
+			if d.IsDir() {
+				if file != root && SkipDir(d.Name()) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !d.Type().IsRegular() || !included(root, file, opts.Include) {
+				return nil
+			}
+			select {
+			case files <- file:
+				return nil
+			case <-ctx.Done():
+				return filepath.SkipAll
+			}
+		})
+	}()
+
+	// Search files in parallel
+	var wg sync.WaitGroup
+	var count sync.Mutex
+	total := 0
+	for i := 0; i < runtime.NumCPU(); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			n := 0
+			for file := range files {
+				if ctx.Err() != nil {
+					continue // Drain so the walker can finish
+				}
+				n++
+				if matches := searchFile(file, re, opts.ContextLines); len(matches) > 0 {
+					select {
+					case found <- matches:
+					case <-ctx.Done():
+					}
+				}
+			}
+			count.Lock()
+			total += n
+			count.Unlock()
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(found)
+		searched <- total
+	}()
+
+	// Collect, cap and batch the matches
+	result := &Result{}
+	var pending []Match
+	flush := func() {
+		if len(pending) > 0 {
+			emit(pending)
+			pending = nil
+		}
+	}
+	ticker := time.NewTicker(batchInterval)
+	defer ticker.Stop()
+collect:
+	for {
+		select {
+		case matches, ok := <-found:
+			if !ok {
+				break collect
+			}
+			if result.Truncated {
+				continue
+			}
+			if room := maxResults - result.Matches; len(matches) > room {
+				matches = matches[:room]
+				result.Truncated = true
+				stop()
+			}
+			result.Matches += len(matches)
+			result.FilesMatched++
+			pending = append(pending, matches...)
+			if len(pending) >= batchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+	flush()
+	result.FilesSearched = <-searched
+
+	if !result.Truncated {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// included reports whether file matches one of the include globs. Globs
+// use forward slashes on every platform.
+func included(root, file string, include []string) bool {
+	if len(include) == 0 {
+		return true
+	}
+	rel, err := filepath.Rel(root, file)
+	if err != nil {
+		return false
+	}
+	rel = filepath.ToSlash(rel)
+	name := filepath.Base(file)
+	for _, glob := range include {
+		glob = filepath.ToSlash(glob)
+		if ok, _ := path.Match(glob, name); ok {
+			return true
+		}
+		if ok, _ := path.Match(glob, rel); ok {
+			return true
+		}
+		// "dir/**" and "**/*.go" style globs
+		if prefix, ok := strings.CutSuffix(glob, "/**"); ok && (rel == prefix || strings.HasPrefix(rel, prefix+"/")) {
+			return true
+		}
+		if suffix, ok := strings.CutPrefix(glob, "**/"); ok {
+			if ok, _ := path.Match(suffix, name); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// searchFile returns the matches in one file, skipping large and binary files
+func searchFile(file string, re *regexp.Regexp, contextLines int) []Match {
+	info, err := os.Stat(file)
+	if err != nil || info.Size() > maxFileSize {
+		return nil
+	}
+	data, err := os.ReadFile(file)
+	if err != nil || bytes.IndexByte(data[:min(len(data), binarySniffLen)], 0) >= 0 {
+		return nil
+	}
+
+	lines := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	var matches []Match
+	for i, line := range lines {
+		loc := re.FindStringIndex(line)
+		if loc == nil {
+			continue
+		}
+		m := Match{
+			File:   file,
+			Line:   i + 1,
+			Column: loc[0] + 1,
+			Length: loc[1] - loc[0],
+			Text:   truncate(line),
+		}
+		if contextLines > 0 {
+			for _, l := range lines[max(0, i-contextLines):i] {
+				m.Before = append(m.Before, truncate(l))
+			}
+			for _, l := range lines[i+1 : min(len(lines), i+1+contextLines)] {
+				m.After = append(m.After, truncate(l))
+			}
+		}
+		matches = append(matches, m)
+	}
+	return matches
+}
+
+func truncate(line string) string {
+	if len(line) <= maxLineLen {
+		return line
+	}
+	// Don't cut a UTF-8 sequence in half
+	cut := maxLineLen
+	for cut > 0 && !utf8.RuneStart(line[cut]) {
+		cut--
+	}
+	return line[:cut] + "…"
+}
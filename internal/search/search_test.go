@@ -0,0 +1,130 @@
+package search
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// writeTree creates files (relative path → content) under a temp root
+func writeTree(t *testing.T, files map[string]string) string {
+	t.Helper()
+	root := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func collect(t *testing.T, root string, opts Options) ([]Match, *Result) {
+	t.Helper()
+	var matches []Match
+	result, err := Search(context.Background(), root, opts, func(batch []Match) {
+		matches = append(matches, batch...)
+	})
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].File != matches[j].File {
+			return matches[i].File < matches[j].File
+		}
+		return matches[i].Line < matches[j].Line
+	})
+	return matches, result
+}
+
+func TestSearchLiteral(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		"main.go":                "package main\n\nfunc main() {\n\tTODO()\n}\n",
+		"lib/util.go":            "package lib\n// todo: tidy\n",
+		"README.md":              "Nothing to do\n",
+		"node_modules/x/todo.js": "// TODO\n",
+		".git/todo":              "TODO\n",
+		"image.png":              "TODO\x00\x01",
+	})
+
+	matches, result := collect(t, root, Options{Query: "todo", ContextLines: 1})
+	if len(matches) != 2 || result.Matches != 2 || result.FilesMatched != 2 || result.Truncated {
+		t.Fatalf("matches = %+v, result = %+v", matches, result)
+	}
+	first := matches[0]
+	if first.File != filepath.Join(root, "lib", "util.go") || first.Line != 2 || first.Column != 4 || first.Length != 4 {
+		t.Errorf("first match = %+v", first)
+	}
+	if len(first.Before) != 1 || first.Before[0] != "package lib" {
+		t.Errorf("context before = %q", first.Before)
+	}
+	if second := matches[1]; second.Text != "\tTODO()" || len(second.After) != 1 || second.After[0] != "}" {
+		t.Errorf("second match = %+v", second)
+	}
+
+	// An upper case letter makes a literal query case-sensitive
+	if matches, _ := collect(t, root, Options{Query: "TODO"}); len(matches) != 1 {
+		t.Errorf("case-sensitive matches = %+v", matches)
+	}
+}
+
+func TestSearchRegexIncludeAndLimit(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		"a.go":       "func A() {}\nfunc B() {}\nfunc C() {}\n",
+		"web/app.ts": "function a() {}\n",
+		"docs/x.md":  "func in prose\n",
+	})
+
+	matches, _ := collect(t, root, Options{Query: `^func \w+\(`, Regex: true})
+	if len(matches) != 3 {
+		t.Fatalf("regex matches = %+v", matches)
+	}
+
+	matches, _ = collect(t, root, Options{Query: "func", Include: []string{"*.ts", "docs/**"}})
+	if len(matches) != 2 {
+		t.Fatalf("included matches = %+v", matches)
+	}
+	for _, m := range matches {
+		if strings.HasSuffix(m.File, ".go") {
+			t.Errorf("file outside the include globs searched: %s", m.File)
+		}
+	}
+
+	matches, result := collect(t, root, Options{Query: "func", MaxResults: 2})
+	if len(matches) != 2 || !result.Truncated {
+		t.Fatalf("limited matches = %d, result = %+v", len(matches), result)
+	}
+
+	if _, err := Search(context.Background(), root, Options{Query: "(", Regex: true}, func([]Match) {}); err == nil {
+		t.Error("Search() accepted an invalid regular expression")
+	}
+	if _, err := Search(context.Background(), root, Options{}, func([]Match) {}); err == nil {
+		t.Error("Search() accepted an empty query")
+	}
+}
+
+func TestSearchCancelled(t *testing.T) {
+	root := writeTree(t, map[string]string{"a.txt": "needle\n"})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := Search(ctx, root, Options{Query: "needle"}, func([]Match) {}); err != context.Canceled {
+		t.Errorf("Search() error = %v, want context.Canceled", err)
+	}
+}
+
+func TestTruncateLongLines(t *testing.T) {
+	line := strings.Repeat("é", maxLineLen)
+	got := truncate(line)
+	if !strings.HasSuffix(got, "…") || len(got) > maxLineLen+len("…") {
+		t.Fatalf("truncate() length = %d", len(got))
+	}
+	if !strings.HasPrefix(line, strings.TrimSuffix(got, "…")) {
+		t.Error("truncate() cut a character in half")
+	}
+}
@@ -10,12 +10,20 @@ import (
 // HistoryManager handles session history operations
 type HistoryManager struct {
 	claudeDir string
+	offsets   *claude.OffsetIndexCache
 }
 
 // NewHistoryManager creates a new HistoryManager
 func NewHistoryManager(claudeDir string) *HistoryManager {
+	return NewHistoryManagerWithIndexDir(claudeDir, "")
+}
+
+// NewHistoryManagerWithIndexDir creates a HistoryManager that keeps the
+// offset indexes of session files in indexDir across restarts
+func NewHistoryManagerWithIndexDir(claudeDir, indexDir string) *HistoryManager {
 	return &HistoryManager{
 		claudeDir: claudeDir,
+		offsets:   claude.NewOffsetIndexCache(indexDir),
 	}
 }
 
@@ -26,12 +34,16 @@ func (h *HistoryManager) GetMessageIndex(projectID, sessionID string) ([]int, er
 		return nil, fmt.Errorf("failed to find session file: %w", err)
 	}
 
-	index, err := claude.BuildMessageIndex(filePath)
+	index, err := h.offsets.Get(filePath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to build message index: %w", err)
 	}
 
-	return index.LineNumbers, nil
+	lineNumbers := make([]int, index.Lines())
+	for i := range lineNumbers {
+		lineNumbers[i] = i + 1
+	}
+	return lineNumbers, nil
 }
 
 // GetMessagesRange returns messages between start and end indices
@@ -46,7 +58,12 @@ func (h *HistoryManager) GetMessagesRange(projectID, sessionID string, start, en
 		start = 1
 	}
 
-	messages, err := claude.ReadMessagesRange(filePath, start, end)
+	index, err := h.offsets.Get(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build message index: %w", err)
+	}
+
+	messages, err := claude.ReadIndexedMessages(filePath, index, start, end)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read messages range: %w", err)
 	}
@@ -54,6 +71,22 @@ func (h *HistoryManager) GetMessagesRange(projectID, sessionID string, start, en
 	return messages, nil
 }
 
+// TailSessionHistory returns the last n messages of a session and the
+// offset where the messages written after them start
+func (h *HistoryManager) TailSessionHistory(projectID, sessionID string, n int) ([]claude.Message, int64, error) {
+	filePath, err := claude.FindSessionFile(h.claudeDir, projectID, sessionID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to find session file: %w", err)
+	}
+
+	messages, offset, err := claude.ReadLastMessages(filePath, n)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read last messages: %w", err)
+	}
+
+	return messages, offset, nil
+}
+
 // LoadSessionHistory loads all messages for a session
 func (h *HistoryManager) LoadSessionHistory(projectID, sessionID string) ([]claude.Message, error) {
 	filePath, err := claude.FindSessionFile(h.claudeDir, projectID, sessionID)
@@ -0,0 +1,25 @@
+package sessionproc
+
+import (
+	"context"
+	"os/exec"
+)
+
+// Remote runs a provider CLI on another host instead of locally.
+type Remote struct {
+	// Dir is the working directory on the remote host.
+	Dir string
+	// Binary is the CLI to run there; empty uses the provider's default name.
+	Binary string
+	// Command builds the local command that runs argv in Dir with env added
+	// to the remote environment.
+	Command func(ctx context.Context, dir string, argv, env []string) (*exec.Cmd, error)
+}
+
+// BinaryOr returns the remote binary, falling back to name.
+func (r *Remote) BinaryOr(name string) string {
+	if r.Binary != "" {
+		return r.Binary
+	}
+	return name
+}
@@ -0,0 +1,440 @@
+// Package sessionshare renders a session into one self-contained HTML file
+// that can be attached to a pull request or sent to someone without
+// ropcode: the transcript, the diffs of the files the session changed and
+// its token usage. Styles are inlined and images embedded as data URLs, and
+// the page has no scripts; tool calls, their results and thinking fold
+// away in <details> elements.
+//
+// Messages are expected in the Claude format the Codex and Gemini sessions
+// are converted to, as loaded from disk, before images are moved out of
+// them. With Options.RedactPaths every occurrence of the project path and
+// the home directory is replaced, so the file doesn't reveal where the
+// project lives.
+package sessionshare
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"ropcode/internal/claude"
+	"ropcode/internal/git"
+)
+
+// maxBlockText caps the text shown for one tool input or result
+const maxBlockText = 20000
+
+// imageTypes are the image media types embedded in the page
+var imageTypes = map[string]bool{
+	"image/png":  true,
+	"image/jpeg": true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+var base64Pattern = regexp.MustCompile(`^[A-Za-z0-9+/]+=*$`)
+
+// File is a file the session changed
+type File struct {
+	Path string
+	Kind string // created, modified or deleted
+	// Hunks are empty when there's no checkpoint to diff against
+	Hunks []git.CheckpointHunk
+}
+
+// Usage totals the tokens of a session
+type Usage struct {
+	Models              []string
+	InputTokens         int64
+	OutputTokens        int64
+	CacheCreationTokens int64
+	CacheReadTokens     int64
+	CostUSD             float64
+}
+
+// CostFunc prices token usage of a model, see usage.Pricing.Cost
+type CostFunc func(model string, at time.Time, inputTokens, outputTokens, cacheCreation, cacheRead int64) float64
+
+// Document is what a share file shows
+type Document struct {
+	SessionID   string
+	Provider    string
+	ProjectPath string
+	GeneratedAt time.Time
+	Messages    []claude.Message
+	Files       []File
+	Usage       Usage
+}
+
+// Options controls how a document is rendered
+type Options struct {
+	// RedactPaths replaces the project path and Home in every text
+	RedactPaths bool
+	Home        string
+}
+
+// CollectUsage totals the usage reported by the assistant messages. Claude
+// writes one line per content block of a message, each with the same
+// usage, so messages are counted once by ID.
+func CollectUsage(messages []claude.Message, cost CostFunc) Usage {
+	var total Usage
+	seen := make(map[string]bool)
+	models := make(map[string]bool)
+	for _, message := range messages {
+		if message.Type != "assistant" || message.Message == nil {
+			continue
+		}
+		usage, ok := message.Message["usage"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if id, _ := message.Message["id"].(string); id != "" {
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+		}
+		input := tokens(usage, "input_tokens")
+		output := tokens(usage, "output_tokens")
+		cacheCreation := tokens(usage, "cache_creation_input_tokens")
+		cacheRead := tokens(usage, "cache_read_input_tokens")
+		total.InputTokens += input
+		total.OutputTokens += output
+		total.CacheCreationTokens += cacheCreation
+		total.CacheReadTokens += cacheRead
+
+		model, _ := message.Message["model"].(string)
+		if model == "" || model == "<synthetic>" {
+			continue
+		}
+		models[model] = true
+		if cost != nil {
+			at, _ := time.Parse(time.RFC3339, message.Timestamp)
+			total.CostUSD += cost(model, at, input, output, cacheCreation, cacheRead)
+		}
+	}
+	total.Models = make([]string, 0, len(models))
+	for model := range models {
+		total.Models = append(total.Models, model)
+	}
+	sort.Strings(total.Models)
+	return total
+}
+
+func tokens(usage map[string]interface{}, key string) int64 {
+	value, _ := usage[key].(float64)
+	return int64(value)
+}
+
+// Write renders doc as an HTML page to w
+func Write(w io.Writer, doc *Document, opts Options) error {
+	redact := redactor(doc.ProjectPath, opts)
+	page := page{
+		Title:       redact(title(doc)),
+		SessionID:   doc.SessionID,
+		Provider:    doc.Provider,
+		ProjectPath: redact(doc.ProjectPath),
+		GeneratedAt: doc.GeneratedAt.Format("2006-01-02 15:04"),
+		Usage:       doc.Usage,
+		HasUsage:    doc.Usage.InputTokens+doc.Usage.OutputTokens > 0,
+		Models:      strings.Join(doc.Usage.Models, ", "),
+	}
+	for _, message := range doc.Messages {
+		if entry, ok := newEntry(message, redact); ok {
+			page.Entries = append(page.Entries, entry)
+		}
+	}
+	for _, file := range doc.Files {
+		f := pageFile{Path: redact(file.Path), Kind: file.Kind}
+		for _, hunk := range file.Hunks {
+			h := pageHunk{Header: redact(hunk.Header)}
+			for _, line := range hunk.Lines {
+				h.Lines = append(h.Lines, pageLine{Text: redact(line), Class: lineClass(line)})
+			}
+			f.Hunks = append(f.Hunks, h)
+		}
+		page.Files = append(page.Files, f)
+	}
+	return pageTemplate.Execute(w, page)
+}
+
+// redactor returns the function applied to every text of the page
+func redactor(projectPath string, opts Options) func(string) string {
+	if !opts.RedactPaths {
+		return func(text string) string { return text }
+	}
+	type replacement struct{ from, to string }
+	var replacements []replacement
+	add := func(path, to string) {
+		path = strings.TrimRight(path, `/\`)
+		if path == "" {
+			return
+		}
+		replacements = append(replacements, replacement{path, to})
+		// Paths inside JSON tool input have their backslashes escaped
+		if escaped, err := json.Marshal(path); err == nil {
+			if inner := strings.Trim(string(escaped), `"`); inner != path {
+				replacements = append(replacements, replacement{inner, to})
+			}
+		}
+	}
+	// The project usually lives below home, so it goes first
+	add(projectPath, "<project>")
+	add(opts.Home, "~")
+	return func(text string) string {
+		for _, r := range replacements {
+			text = strings.ReplaceAll(text, r.from, r.to)
+		}
+		return text
+	}
+}
+
+// title is the start of the first prompt
+func title(doc *Document) string {
+	for _, message := range doc.Messages {
+		if message.Type != "user" || message.Message == nil {
+			continue
+		}
+		for _, block := range contentBlocks(message.Message["content"]) {
+			if block["type"] != "text" {
+				continue
+			}
+			text := strings.TrimSpace(fmt.Sprint(block["text"]))
+			if text == "" {
+				continue
+			}
+			line, _, _ := strings.Cut(text, "\n")
+			if runes := []rune(line); len(runes) > 80 {
+				return string(runes[:80]) + "…"
+			}
+			return line
+		}
+	}
+	return "Session " + doc.SessionID
+}
+
+type page struct {
+	Title       string
+	SessionID   string
+	Provider    string
+	ProjectPath string
+	GeneratedAt string
+	Entries     []entry
+	Files       []pageFile
+	Usage       Usage
+	HasUsage    bool
+	Models      string
+}
+
+type entry struct {
+	Role   string
+	Time   string
+	Blocks []block
+}
+
+type block struct {
+	Kind  string // text, thinking, tool_use, tool_result, image
+	Title string
+	Text  string
+	Error bool
+	Image template.URL
+}
+
+type pageFile struct {
+	Path  string
+	Kind  string
+	Hunks []pageHunk
+}
+
+type pageHunk struct {
+	Header string
+	Lines  []pageLine
+}
+
+type pageLine struct {
+	Text  string
+	Class string
+}
+
+func lineClass(line string) string {
+	switch {
+	case strings.HasPrefix(line, "+"):
+		return "add"
+	case strings.HasPrefix(line, "-"):
+		return "del"
+	default:
+		return ""
+	}
+}
+
+// newEntry turns a message into the blocks shown for it; messages with
+// nothing to show, such as summaries, are skipped
+func newEntry(message claude.Message, redact func(string) string) (entry, bool) {
+	if (message.Type != "user" && message.Type != "assistant") || message.Message == nil || message.IsSidechain {
+		return entry{}, false
+	}
+	e := entry{Role: message.Type}
+	if t, err := time.Parse(time.RFC3339, message.Timestamp); err == nil {
+		e.Time = t.Local().Format("15:04:05")
+	}
+	for _, b := range contentBlocks(message.Message["content"]) {
+		switch b["type"] {
+		case "text":
+			if text := strings.TrimSpace(fmt.Sprint(b["text"])); text != "" {
+				e.Blocks = append(e.Blocks, block{Kind: "text", Text: redact(text)})
+			}
+		case "thinking":
+			if text := strings.TrimSpace(fmt.Sprint(b["thinking"])); text != "" {
+				e.Blocks = append(e.Blocks, block{Kind: "thinking", Title: "Thinking", Text: redact(text)})
+			}
+		case "tool_use":
+			input, _ := json.MarshalIndent(b["input"], "", "  ")
+			e.Blocks = append(e.Blocks, block{Kind: "tool_use", Title: redact(fmt.Sprint(b["name"])), Text: redact(clip(string(input)))})
+		case "tool_result":
+			isError, _ := b["is_error"].(bool)
+			result := block{Kind: "tool_result", Title: "Result", Error: isError}
+			var texts []string
+			var images []block
+			for _, part := range contentBlocks(b["content"]) {
+				switch part["type"] {
+				case "text":
+					texts = append(texts, fmt.Sprint(part["text"]))
+				case "image":
+					if src, ok := imageURL(part); ok {
+						images = append(images, block{Kind: "image", Image: src})
+					}
+				}
+			}
+			result.Text = redact(clip(strings.Join(texts, "\n")))
+			if isError {
+				result.Title = "Error"
+			}
+			e.Blocks = append(e.Blocks, result)
+			e.Blocks = append(e.Blocks, images...)
+		case "image":
+			if src, ok := imageURL(b); ok {
+				e.Blocks = append(e.Blocks, block{Kind: "image", Image: src})
+			}
+		}
+	}
+	return e, len(e.Blocks) > 0
+}
+
+// contentBlocks returns message content as blocks; plain string content
+// becomes one text block
+func contentBlocks(content interface{}) []map[string]interface{} {
+	switch c := content.(type) {
+	case string:
+		return []map[string]interface{}{{"type": "text", "text": c}}
+	case []interface{}:
+		blocks := make([]map[string]interface{}, 0, len(c))
+		for _, item := range c {
+			if b, ok := item.(map[string]interface{}); ok {
+				blocks = append(blocks, b)
+			}
+		}
+		return blocks
+	}
+	return nil
+}
+
+// imageURL returns a data URL for a base64 image block of a known type
+func imageURL(b map[string]interface{}) (template.URL, bool) {
+	source, ok := b["source"].(map[string]interface{})
+	if !ok || source["type"] != "base64" {
+		return "", false
+	}
+	mediaType, _ := source["media_type"].(string)
+	data, _ := source["data"].(string)
+	if !imageTypes[mediaType] || !base64Pattern.MatchString(data) {
+		return "", false
+	}
+	// The data is checked above, so the URL can't break out of the attribute
+	return template.URL("data:" + mediaType + ";base64," + data), true
+}
+
+func clip(text string) string {
+	if len(text) <= maxBlockText {
+		return text
+	}
+	return text[:maxBlockText] + fmt.Sprintf("\n… %d more bytes", len(text)-maxBlockText)
+}
+
+var pageTemplate = template.Must(template.New("share").Funcs(template.FuncMap{
+	"tokens": func(n int64) string {
+		s := fmt.Sprint(n)
+		for i := len(s) - 3; i > 0; i -= 3 {
+			s = s[:i] + "," + s[i:]
+		}
+		return s
+	},
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+:root{color-scheme:light dark;--fg:#1f2328;--muted:#656d76;--bg:#fff;--panel:#f6f8fa;--border:#d0d7de;--user:#ddf4ff;--add:#dafbe1;--del:#ffebe9;--err:#cf222e}
+@media (prefers-color-scheme:dark){:root{--fg:#e6edf3;--muted:#8d96a0;--bg:#0d1117;--panel:#161b22;--border:#30363d;--user:#0c2d4b;--add:#12361f;--del:#3d1417;--err:#f85149}}
+*{box-sizing:border-box}
+body{margin:0 auto;max-width:960px;padding:24px 16px;font:14px/1.5 -apple-system,BlinkMacSystemFont,"Segoe UI",Helvetica,Arial,sans-serif;color:var(--fg);background:var(--bg)}
+h1{font-size:20px;margin:0 0 4px}
+h2{font-size:16px;margin:32px 0 8px;padding-bottom:4px;border-bottom:1px solid var(--border)}
+.meta{color:var(--muted);font-size:12px}
+pre{margin:0;white-space:pre-wrap;word-break:break-word;font:12px/1.45 ui-monospace,SFMono-Regular,Menlo,Consolas,monospace}
+.msg{margin:12px 0;padding:10px 12px;border:1px solid var(--border);border-radius:8px}
+.msg.user{background:var(--user)}
+.role{font-size:12px;font-weight:600;color:var(--muted);margin-bottom:4px}
+.text{white-space:pre-wrap;word-break:break-word}
+details{margin:6px 0;border:1px solid var(--border);border-radius:6px;background:var(--panel)}
+summary{cursor:pointer;padding:4px 8px;font-size:12px;color:var(--muted)}
+details>pre{padding:6px 8px;border-top:1px solid var(--border);max-height:480px;overflow:auto}
+.error summary{color:var(--err)}
+img{display:block;max-width:100%;margin:6px 0;border:1px solid var(--border);border-radius:6px}
+table{border-collapse:collapse;font-size:13px}
+td{padding:2px 16px 2px 0}
+.diff pre div{padding:0 8px}
+.add{background:var(--add)}
+.del{background:var(--del)}
+.hunk{color:var(--muted);background:var(--panel);padding:2px 8px}
+.kind{font-family:ui-monospace,monospace;font-size:12px;color:var(--muted)}
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<div class="meta">{{.Provider}} session {{.SessionID}}{{if .ProjectPath}} · {{.ProjectPath}}{{end}} · shared {{.GeneratedAt}}</div>
+
+<h2>Usage</h2>
+{{if .HasUsage}}<table>
+{{if .Models}}<tr><td>Models</td><td>{{.Models}}</td></tr>{{end}}
+<tr><td>Input tokens</td><td>{{tokens .Usage.InputTokens}}</td></tr>
+<tr><td>Output tokens</td><td>{{tokens .Usage.OutputTokens}}</td></tr>
+{{if .Usage.CacheCreationTokens}}<tr><td>Cache write tokens</td><td>{{tokens .Usage.CacheCreationTokens}}</td></tr>{{end}}
+{{if .Usage.CacheReadTokens}}<tr><td>Cache read tokens</td><td>{{tokens .Usage.CacheReadTokens}}</td></tr>{{end}}
+{{if .Usage.CostUSD}}<tr><td>Estimated cost</td><td>${{printf "%.4f" .Usage.CostUSD}}</td></tr>{{end}}
+</table>{{else}}<div class="meta">The provider did not report usage for this session.</div>{{end}}
+
+{{if .Files}}<h2>Changed files</h2>
+{{range .Files}}<details class="diff"{{if .Hunks}} open{{end}}>
+<summary><span class="kind">{{.Kind}}</span> {{.Path}}</summary>
+{{if .Hunks}}<pre>{{range .Hunks}}<div class="hunk">{{.Header}}</div>{{range .Lines}}<div class="{{.Class}}">{{.Text}}</div>{{end}}{{end}}</pre>{{end}}
+</details>
+{{end}}{{end}}
+
+<h2>Transcript</h2>
+{{range .Entries}}<div class="msg {{.Role}}">
+<div class="role">{{.Role}}{{if .Time}} · {{.Time}}{{end}}</div>
+{{range .Blocks}}{{if eq .Kind "text"}}<div class="text">{{.Text}}</div>
+{{else if eq .Kind "image"}}<img src="{{.Image}}" alt="">
+{{else}}<details{{if .Error}} class="error"{{end}}><summary>{{.Title}}</summary><pre>{{.Text}}</pre></details>
+{{end}}{{end}}</div>
+{{end}}
+</body>
+</html>
+`))
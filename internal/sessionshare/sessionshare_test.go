@@ -0,0 +1,111 @@
+package sessionshare
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"ropcode/internal/claude"
+	"ropcode/internal/git"
+)
+
+func testMessages() []claude.Message {
+	return []claude.Message{
+		{Type: "summary"},
+		{Type: "user", Timestamp: "2026-10-18T10:00:00Z", Message: map[string]interface{}{
+			"role": "user", "content": "Fix the <login> bug in /home/dev/shop/auth.go\nplease",
+		}},
+		{Type: "assistant", Timestamp: "2026-10-18T10:00:05Z", Message: map[string]interface{}{
+			"id": "m1", "model": "claude-sonnet-4-5",
+			"usage":   map[string]interface{}{"input_tokens": 100.0, "output_tokens": 20.0, "cache_read_input_tokens": 1000.0},
+			"content": []interface{}{map[string]interface{}{"type": "text", "text": "Looking at it."}},
+		}},
+		// The same message again, with its next content block
+		{Type: "assistant", Timestamp: "2026-10-18T10:00:05Z", Message: map[string]interface{}{
+			"id": "m1", "model": "claude-sonnet-4-5",
+			"usage": map[string]interface{}{"input_tokens": 100.0, "output_tokens": 20.0, "cache_read_input_tokens": 1000.0},
+			"content": []interface{}{map[string]interface{}{
+				"type": "tool_use", "id": "t1", "name": "Read", "input": map[string]interface{}{"file_path": "/home/dev/shop/auth.go"},
+			}},
+		}},
+		{Type: "user", Timestamp: "2026-10-18T10:00:06Z", Message: map[string]interface{}{
+			"role": "user", "content": []interface{}{map[string]interface{}{
+				"type": "tool_result", "tool_use_id": "t1", "content": []interface{}{
+					map[string]interface{}{"type": "text", "text": "package auth"},
+					map[string]interface{}{"type": "image", "source": map[string]interface{}{"type": "base64", "media_type": "image/png", "data": "iVBORw0KGgo="}},
+					map[string]interface{}{"type": "image", "source": map[string]interface{}{"type": "base64", "media_type": "image/png", "data": `x" onerror="alert(1)`}},
+				},
+			}},
+		}},
+		{Type: "assistant", Timestamp: "2026-10-18T10:00:09Z", Message: map[string]interface{}{
+			"id": "m2", "model": "claude-sonnet-4-5",
+			"usage":   map[string]interface{}{"input_tokens": 50.0, "output_tokens": 30.0},
+			"content": []interface{}{map[string]interface{}{"type": "text", "text": "Fixed."}},
+		}},
+	}
+}
+
+func TestCollectUsageCountsEachMessageOnce(t *testing.T) {
+	var priced []string
+	usage := CollectUsage(testMessages(), func(model string, at time.Time, input, output, cacheCreation, cacheRead int64) float64 {
+		priced = append(priced, model)
+		return float64(input+output) / 1000
+	})
+	if usage.InputTokens != 150 || usage.OutputTokens != 50 || usage.CacheReadTokens != 1000 {
+		t.Fatalf("usage = %+v", usage)
+	}
+	if len(priced) != 2 || usage.CostUSD != 0.2 || len(usage.Models) != 1 || usage.Models[0] != "claude-sonnet-4-5" {
+		t.Fatalf("usage = %+v, priced %v", usage, priced)
+	}
+}
+
+func TestWriteRendersASelfContainedPage(t *testing.T) {
+	doc := &Document{
+		SessionID:   "s1",
+		Provider:    "claude",
+		ProjectPath: "/home/dev/shop",
+		GeneratedAt: time.Date(2026, 10, 18, 11, 0, 0, 0, time.UTC),
+		Messages:    testMessages(),
+		Files: []File{{Path: "/home/dev/shop/auth.go", Kind: "modified", Hunks: []git.CheckpointHunk{
+			{Header: "@@ -1 +1 @@", Lines: []string{"-old", "+new"}},
+		}}},
+	}
+	doc.Usage = CollectUsage(doc.Messages, nil)
+
+	var buf bytes.Buffer
+	if err := Write(&buf, doc, Options{}); err != nil {
+		t.Fatal(err)
+	}
+	page := buf.String()
+	for _, want := range []string{
+		"<title>Fix the &lt;login&gt; bug in /home/dev/shop/auth.go</title>",
+		`<img src="data:image/png;base64,iVBORw0KGgo="`,
+		`<div class="add">&#43;new</div>`,
+		"<summary>Read</summary>",
+		"Fixed.",
+		"1,000",
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("page lacks %q", want)
+		}
+	}
+	if strings.Contains(page, "onerror") || strings.Contains(page, "<script") {
+		t.Error("page runs scripts")
+	}
+	if strings.Count(page, "Looking at it.") != 1 {
+		t.Error("message repeated")
+	}
+
+	buf.Reset()
+	if err := Write(&buf, doc, Options{RedactPaths: true, Home: "/home/dev"}); err != nil {
+		t.Fatal(err)
+	}
+	page = buf.String()
+	if strings.Contains(page, "/home/dev") {
+		t.Error("redacted page names the project path")
+	}
+	if !strings.Contains(page, "&lt;project&gt;/auth.go") {
+		t.Error("redacted page lacks the project placeholder")
+	}
+}
@@ -0,0 +1,610 @@
+// Package settings gives the raw key-value settings table a schema: the
+// known keys with their type, default and validation, read and written as
+// typed JSON values.
+//
+// Values keep the string form the frontend has always stored, so raw
+// GetSetting/SaveSetting callers and typed callers see the same data: bools
+// as "true"/"false", numbers in decimal, strings as is and objects as JSON.
+// An empty stored value means the default.
+package settings
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Value types
+const (
+	TypeBool   = "bool"
+	TypeString = "string"
+	TypeNumber = "number"
+	TypeObject = "object"
+)
+
+// Field describes one known setting
+type Field struct {
+	Key         string      `json:"key"`
+	Type        string      `json:"type"`
+	Default     interface{} `json:"default"`
+	Enum        []string    `json:"enum,omitempty"`
+	Description string      `json:"description"`
+
+	validate func(value interface{}) error
+}
+
+var schema = []*Field{
+	{
+		Key:         "theme_preference",
+		Type:        TypeString,
+		Default:     "gray",
+		Enum:        []string{"dark", "gray", "light", "system", "custom"},
+		Description: "Color theme",
+	},
+	{
+		Key:         "theme_custom_colors",
+		Type:        TypeObject,
+		Default:     map[string]interface{}{},
+		Description: "Colors of the custom theme",
+	},
+	{
+		Key:         "startup_intro_enabled",
+		Type:        TypeBool,
+		Default:     true,
+		Description: "Show the intro animation on start",
+	},
+	{
+		Key:  "proxy_settings",
+		Type: TypeObject,
+		Default: map[string]interface{}{
+			"enabled":     false,
+			"http_proxy":  nil,
+			"https_proxy": nil,
+			"no_proxy":    nil,
+			"all_proxy":   nil,
+		},
+		Description: "Proxy passed to provider CLIs",
+	},
+	{
+		Key:         "session_title_model",
+		Type:        TypeString,
+		Default:     "",
+		Description: "Model that generates session titles",
+	},
+	{
+		Key:         "session_title_provider_api_id",
+		Type:        TypeString,
+		Default:     "",
+		Description: "Provider API configuration used for session titles",
+	},
+	{
+		Key:         "log_level",
+		Type:        TypeString,
+		Default:     "info",
+		Enum:        []string{"debug", "info", "warn", "error"},
+		Description: "Lowest level written to the log",
+	},
+	{
+		Key:         "file_write_backup",
+		Type:        TypeBool,
+		Default:     false,
+		Description: "Keep a .bak copy of files saved from the editor",
+	},
+	{
+		Key:         "temp_images_retention_days",
+		Type:        TypeNumber,
+		Default:     30.0,
+		Description: "Days pasted images used by a session are kept; 0 keeps them",
+		validate:    validateNonNegative,
+	},
+	{
+		Key:         "temp_images_max_mb",
+		Type:        TypeNumber,
+		Default:     1024.0,
+		Description: "Megabytes of pasted images kept before the oldest are removed; 0 for no limit",
+		validate:    validateNonNegative,
+	},
+	{
+		Key:         "paste_image_format",
+		Type:        TypeString,
+		Default:     "original",
+		Enum:        []string{"original", "jpeg", "png"},
+		Description: "Format pasted images are saved in",
+	},
+	{
+		Key:         "paste_image_max_width",
+		Type:        TypeNumber,
+		Default:     0.0,
+		Description: "Width pasted images are scaled down to fit; 0 for no limit",
+		validate:    validateNonNegative,
+	},
+	{
+		Key:         "paste_image_max_height",
+		Type:        TypeNumber,
+		Default:     0.0,
+		Description: "Height pasted images are scaled down to fit; 0 for no limit",
+		validate:    validateNonNegative,
+	},
+	{
+		Key:         "paste_image_quality",
+		Type:        TypeNumber,
+		Default:     85.0,
+		Description: "JPEG quality of converted pasted images, 1 to 100",
+		validate:    validateQuality,
+	},
+	{
+		Key:         "mcp_registry_url",
+		Type:        TypeString,
+		Default:     "",
+		Description: "MCP registry to browse; empty for the official one",
+		validate:    validateURL,
+	},
+	{
+		Key:         "model_pricing_url",
+		Type:        TypeString,
+		Default:     "",
+		Description: "URL model prices are refreshed from",
+		validate:    validateURL,
+	},
+	{
+		Key:         "model_capabilities_url",
+		Type:        TypeString,
+		Default:     "",
+		Description: "URL model capabilities are refreshed from",
+		validate:    validateURL,
+	},
+	{
+		Key:         "update_auto_check",
+		Type:        TypeBool,
+		Default:     true,
+		Description: "Check for new ropcode releases in the background",
+	},
+	{
+		Key:         "update_feed_url",
+		Type:        TypeString,
+		Default:     "",
+		Description: "Release feed updates come from; empty for GitHub releases",
+		validate:    validateURL,
+	},
+	{
+		Key:         "claude_flag_profiles",
+		Type:        TypeObject,
+		Default:     map[string]interface{}{},
+		Description: "Named lists of extra Claude CLI arguments",
+		validate:    validateFlagProfiles,
+	},
+	{
+		Key:         "claude_flag_profile",
+		Type:        TypeString,
+		Default:     "",
+		Description: "Flag profile added to every Claude session; empty for none",
+	},
+	{
+		Key:         "codex_sandbox_mode",
+		Type:        TypeString,
+		Default:     "danger-full-access",
+		Enum:        []string{"read-only", "workspace-write", "danger-full-access"},
+		Description: "Sandbox Codex sessions run in unless their permission profile sets one",
+	},
+	{
+		Key:         "codex_network_access",
+		Type:        TypeBool,
+		Default:     true,
+		Description: "Let commands of sandboxed Codex sessions reach the network",
+	},
+	{
+		Key:         "codex_config_overrides",
+		Type:        TypeString,
+		Default:     "",
+		Description: "Extra Codex -c overrides, one key=value per line",
+		validate:    validateConfigOverrides,
+	},
+	{
+		Key:         "dictation_engine",
+		Type:        TypeString,
+		Default:     "off",
+		Enum:        []string{"off", "whisper_cpp", "api"},
+		Description: "What turns recorded prompts into text: a local whisper.cpp binary or a transcription API",
+	},
+	{
+		Key:         "dictation_whisper_binary",
+		Type:        TypeString,
+		Default:     "whisper-cli",
+		Description: "whisper.cpp executable, a path or a name on PATH",
+	},
+	{
+		Key:         "dictation_whisper_model",
+		Type:        TypeString,
+		Default:     "",
+		Description: "ggml model file whisper.cpp transcribes with",
+	},
+	{
+		Key:         "dictation_provider_api_id",
+		Type:        TypeString,
+		Default:     "",
+		Description: "Provider API configuration of the transcription API",
+	},
+	{
+		Key:         "dictation_api_model",
+		Type:        TypeString,
+		Default:     "whisper-1",
+		Description: "Model the transcription API uses",
+	},
+	{
+		Key:         "dictation_language",
+		Type:        TypeString,
+		Default:     "auto",
+		Description: "Language of recorded prompts as an ISO 639-1 code; auto detects it",
+		validate:    validateLanguage,
+	},
+	{
+		Key:         "session_checkpoints_enabled",
+		Type:        TypeBool,
+		Default:     false,
+		Description: "Snapshot a git project's files before each session so its changes can be rolled back",
+	},
+	{
+		Key:         "trash_discarded_files",
+		Type:        TypeBool,
+		Default:     false,
+		Description: "Keep the files workspace cleanups and rejected edits discard in ~/.ropcode/trash",
+	},
+	{
+		Key:         "trash_retention_days",
+		Type:        TypeNumber,
+		Default:     7.0,
+		Description: "Days discarded files are kept in the trash; 0 keeps them",
+		validate:    validateNonNegative,
+	},
+	{
+		Key:         "session_retention_days",
+		Type:        TypeNumber,
+		Default:     0.0,
+		Description: "Days after their last message that session transcripts are archived to ~/.ropcode/session-archive; 0 keeps them",
+		validate:    validateNonNegative,
+	},
+	{
+		Key:         "session_retention_max_mb",
+		Type:        TypeNumber,
+		Default:     0.0,
+		Description: "Megabytes of session transcripts kept before the oldest are archived; 0 for no limit",
+		validate:    validateNonNegative,
+	},
+	{
+		Key:         "workspace_protection_enabled",
+		Type:        TypeBool,
+		Default:     false,
+		Description: "Ask before cleaning up or removing a workspace, which throws away its changes",
+	},
+	{
+		Key:         "telemetry_enabled",
+		Type:        TypeBool,
+		Default:     false,
+		Description: "Collect anonymous feature usage counts and session durations",
+	},
+	{
+		Key:         "telemetry_endpoint",
+		Type:        TypeString,
+		Default:     "",
+		Description: "Where flushed telemetry is sent; nothing is sent while empty",
+		validate:    validateURL,
+	},
+	{
+		Key:         "shell_preference",
+		Type:        TypeObject,
+		Default:     map[string]interface{}{},
+		Description: "Shell commands and terminals run in per OS, as a path and whether to start it as a login shell; an empty path detects it",
+		validate:    validateShellPreference,
+	},
+	{
+		Key:         "claude_binary_path",
+		Type:        TypeString,
+		Default:     "",
+		Description: "Claude CLI installation sessions run with; empty finds one on PATH or in the usual places",
+	},
+	{
+		Key:         "codex_binary_path",
+		Type:        TypeString,
+		Default:     "",
+		Description: "Codex CLI installation sessions run with; empty finds one on PATH or in the usual places",
+	},
+	{
+		Key:         "gemini_binary_path",
+		Type:        TypeString,
+		Default:     "",
+		Description: "Gemini CLI installation sessions run with; empty finds one on PATH or in the usual places",
+	},
+	{
+		Key:         "wsl_distro",
+		Type:        TypeString,
+		Default:     "",
+		Description: "WSL distro provider sessions, terminals and git run in on Windows; empty runs them on Windows",
+	},
+}
+
+var fieldsByKey = func() map[string]*Field {
+	fields := make(map[string]*Field, len(schema))
+	for _, field := range schema {
+		fields[field.Key] = field
+	}
+	return fields
+}()
+
+// Schema returns the known settings sorted by key
+func Schema() []*Field {
+	fields := append([]*Field(nil), schema...)
+	sort.Slice(fields, func(i, j int) bool { return fields[i].Key < fields[j].Key })
+	return fields
+}
+
+// Lookup returns the field of a known key, or nil
+func Lookup(key string) *Field {
+	return fieldsByKey[key]
+}
+
+func validateURL(value interface{}) error {
+	url, _ := value.(string)
+	if url != "" && !strings.HasPrefix(url, "https://") && !strings.HasPrefix(url, "http://") {
+		return fmt.Errorf("want an http(s) URL")
+	}
+	return nil
+}
+
+// reservedClaudeFlags are set by ropcode itself; passing them again would
+// break how sessions are run and read
+var reservedClaudeFlags = []string{
+	"-p", "--print", "--output-format", "--input-format", "-r", "--resume",
+	"-c", "--continue", "--session-id", "--replay-user-messages",
+}
+
+func validateFlagProfiles(value interface{}) error {
+	profiles, _ := value.(map[string]interface{})
+	for name, args := range profiles {
+		if strings.TrimSpace(name) == "" {
+			return fmt.Errorf("flag profile needs a name")
+		}
+		list, ok := args.([]interface{})
+		if !ok {
+			return fmt.Errorf("flag profile %s: want a list of arguments", name)
+		}
+		for _, arg := range list {
+			s, ok := arg.(string)
+			if !ok {
+				return fmt.Errorf("flag profile %s: want string arguments", name)
+			}
+			flag, _, _ := strings.Cut(s, "=")
+			if contains(reservedClaudeFlags, flag) {
+				return fmt.Errorf("flag profile %s: %s is set by ropcode", name, flag)
+			}
+		}
+	}
+	return nil
+}
+
+// shellPreferenceOS are the operating systems a shell preference can be
+// set for
+var shellPreferenceOS = []string{"darwin", "linux", "windows"}
+
+func validateShellPreference(value interface{}) error {
+	preferences, _ := value.(map[string]interface{})
+	for goos, preference := range preferences {
+		if !contains(shellPreferenceOS, goos) {
+			return fmt.Errorf("want one of %s, not %s", strings.Join(shellPreferenceOS, ", "), goos)
+		}
+		entry, ok := preference.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s: want an object with path and login", goos)
+		}
+		if path, ok := entry["path"]; ok {
+			if _, ok := path.(string); !ok {
+				return fmt.Errorf("%s: want a string path", goos)
+			}
+		}
+		if login, ok := entry["login"]; ok {
+			if _, ok := login.(bool); !ok {
+				return fmt.Errorf("%s: want login to be true or false", goos)
+			}
+		}
+	}
+	return nil
+}
+
+func validateConfigOverrides(value interface{}) error {
+	text, _ := value.(string)
+	_, err := ParseConfigOverrides(text)
+	return err
+}
+
+// ParseConfigOverrides splits key=value lines into overrides, skipping
+// blank lines and # comments
+func ParseConfigOverrides(text string) ([]string, error) {
+	var overrides []string
+	for i, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok || strings.TrimSpace(key) == "" || strings.TrimSpace(value) == "" {
+			return nil, fmt.Errorf("line %d: want key=value", i+1)
+		}
+		overrides = append(overrides, strings.TrimSpace(key)+"="+strings.TrimSpace(value))
+	}
+	return overrides, nil
+}
+
+func validateLanguage(value interface{}) error {
+	language, _ := value.(string)
+	if language == "" || language == "auto" {
+		return nil
+	}
+	invalid := len(language) < 2 || len(language) > 3
+	for _, r := range language {
+		invalid = invalid || r < 'a' || r > 'z'
+	}
+	if invalid {
+		return fmt.Errorf("want auto or a language code like en")
+	}
+	return nil
+}
+
+func validateNonNegative(value interface{}) error {
+	if n, _ := value.(float64); n < 0 {
+		return fmt.Errorf("want a number of at least 0")
+	}
+	return nil
+}
+
+func validateQuality(value interface{}) error {
+	if n, _ := value.(float64); n < 1 || n > 100 {
+		return fmt.Errorf("want a number from 1 to 100")
+	}
+	return nil
+}
+
+// Decode converts a stored value to the field's type; an empty or
+// unreadable value yields the default
+func (f *Field) Decode(raw string) interface{} {
+	if raw == "" {
+		return f.Default
+	}
+	switch f.Type {
+	case TypeBool:
+		if value, err := strconv.ParseBool(raw); err == nil {
+			return value
+		}
+	case TypeNumber:
+		if value, err := strconv.ParseFloat(raw, 64); err == nil {
+			return value
+		}
+	case TypeString:
+		return raw
+	case TypeObject:
+		var value interface{}
+		if json.Unmarshal([]byte(raw), &value) == nil {
+			return value
+		}
+	}
+	return f.Default
+}
+
+// Encode validates a value and converts it to its stored form; nil resets
+// the setting to its default
+func (f *Field) Encode(value interface{}) (string, error) {
+	if value == nil {
+		return "", nil
+	}
+	var raw string
+	switch f.Type {
+	case TypeBool:
+		b, ok := value.(bool)
+		if !ok {
+			return "", fmt.Errorf("%s: want a boolean", f.Key)
+		}
+		raw = strconv.FormatBool(b)
+	case TypeNumber:
+		n, ok := value.(float64)
+		if !ok {
+			return "", fmt.Errorf("%s: want a number", f.Key)
+		}
+		raw = strconv.FormatFloat(n, 'f', -1, 64)
+	case TypeString:
+		s, ok := value.(string)
+		if !ok {
+			return "", fmt.Errorf("%s: want a string", f.Key)
+		}
+		if len(f.Enum) > 0 && !contains(f.Enum, s) {
+			return "", fmt.Errorf("%s: want one of %s", f.Key, strings.Join(f.Enum, ", "))
+		}
+		raw = s
+	case TypeObject:
+		if _, ok := value.(map[string]interface{}); !ok {
+			return "", fmt.Errorf("%s: want an object", f.Key)
+		}
+		data, err := json.Marshal(value)
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", f.Key, err)
+		}
+		raw = string(data)
+	}
+	if f.validate != nil {
+		if err := f.validate(value); err != nil {
+			return "", fmt.Errorf("%s: %w", f.Key, err)
+		}
+	}
+	return raw, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Store holds the raw settings
+type Store interface {
+	GetSetting(key string) (string, error)
+	SaveSetting(key, value string) error
+}
+
+// Service reads and writes the known settings as typed values. Callers
+// serialize updates.
+type Service struct {
+	store Store
+}
+
+// NewService creates a settings service over store
+func NewService(store Store) *Service {
+	return &Service{store: store}
+}
+
+// GetAll returns every known setting, defaults filled in
+func (s *Service) GetAll() (map[string]interface{}, error) {
+	values := make(map[string]interface{}, len(schema))
+	for _, field := range schema {
+		raw, err := s.store.GetSetting(field.Key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", field.Key, err)
+		}
+		values[field.Key] = field.Decode(raw)
+	}
+	return values, nil
+}
+
+// Update validates every value before saving any and returns the settings
+// that changed with their new values. A nil value resets a setting.
+func (s *Service) Update(values map[string]interface{}) (map[string]interface{}, error) {
+	encoded := make(map[string]string, len(values))
+	for key, value := range values {
+		field := Lookup(key)
+		if field == nil {
+			return nil, fmt.Errorf("unknown setting %q", key)
+		}
+		raw, err := field.Encode(value)
+		if err != nil {
+			return nil, err
+		}
+		encoded[key] = raw
+	}
+
+	changed := make(map[string]interface{})
+	for key, raw := range encoded {
+		previous, err := s.store.GetSetting(key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", key, err)
+		}
+		if previous == raw {
+			continue
+		}
+		if err := s.store.SaveSetting(key, raw); err != nil {
+			return nil, fmt.Errorf("failed to save %s: %w", key, err)
+		}
+		changed[key] = Lookup(key).Decode(raw)
+	}
+	return changed, nil
+}
@@ -0,0 +1,144 @@
+package settings
+
+import (
+	"testing"
+)
+
+type memoryStore map[string]string
+
+func (m memoryStore) GetSetting(key string) (string, error) { return m[key], nil }
+
+func (m memoryStore) SaveSetting(key, value string) error {
+	m[key] = value
+	return nil
+}
+
+func TestGetAllFillsDefaultsAndDecodesStoredValues(t *testing.T) {
+	store := memoryStore{
+		"startup_intro_enabled": "false",
+		"proxy_settings":        `{"enabled":true,"http_proxy":"http://proxy:8080"}`,
+		"theme_custom_colors":   "not json",
+	}
+	values, err := NewService(store).GetAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if values["theme_preference"] != "gray" {
+		t.Errorf("theme_preference = %v, want the default", values["theme_preference"])
+	}
+	if values["startup_intro_enabled"] != false {
+		t.Errorf("startup_intro_enabled = %v, want false", values["startup_intro_enabled"])
+	}
+	proxy, _ := values["proxy_settings"].(map[string]interface{})
+	if proxy["enabled"] != true || proxy["http_proxy"] != "http://proxy:8080" {
+		t.Errorf("proxy_settings = %v", values["proxy_settings"])
+	}
+	if colors, ok := values["theme_custom_colors"].(map[string]interface{}); !ok || len(colors) != 0 {
+		t.Errorf("Expected an unreadable value to fall back to the default, got %v", values["theme_custom_colors"])
+	}
+}
+
+func TestUpdateValidatesBeforeSaving(t *testing.T) {
+	store := memoryStore{}
+	service := NewService(store)
+
+	for _, values := range []map[string]interface{}{
+		{"theme_preference": "light", "unknown_key": true},
+		{"theme_preference": "light", "startup_intro_enabled": "yes"},
+		{"theme_preference": "purple"},
+		{"mcp_registry_url": "ftp://registry"},
+		{"proxy_settings": []interface{}{}},
+	} {
+		if _, err := service.Update(values); err == nil {
+			t.Errorf("Update(%v) succeeded, want an error", values)
+		}
+	}
+	if len(store) != 0 {
+		t.Fatalf("Expected rejected updates to save nothing, store = %v", store)
+	}
+
+	changed, err := service.Update(map[string]interface{}{
+		"theme_preference":      "light",
+		"startup_intro_enabled": true,
+		"theme_custom_colors":   map[string]interface{}{"background": "#000"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changed) != 3 || store["startup_intro_enabled"] != "true" || store["theme_custom_colors"] != `{"background":"#000"}` {
+		t.Errorf("Update() changed %v, store %v", changed, store)
+	}
+
+	changed, err = service.Update(map[string]interface{}{"theme_preference": "light", "startup_intro_enabled": nil})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changed) != 1 || changed["startup_intro_enabled"] != true || store["startup_intro_enabled"] != "" {
+		t.Errorf("Expected only the reset to change, changed %v, store %v", changed, store)
+	}
+}
+
+func TestParseConfigOverrides(t *testing.T) {
+	overrides, err := ParseConfigOverrides("# tuning\nmodel_verbosity = \"low\"\n\nhide_agent_reasoning=true\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(overrides) != 2 || overrides[0] != `model_verbosity="low"` || overrides[1] != "hide_agent_reasoning=true" {
+		t.Fatalf("ParseConfigOverrides() = %q", overrides)
+	}
+	if _, err := Lookup("codex_config_overrides").Encode("not an override"); err == nil {
+		t.Fatal("expected a line without = to be rejected")
+	}
+}
+
+func TestFlagProfilesRejectReservedFlags(t *testing.T) {
+	field := Lookup("claude_flag_profiles")
+	valid := map[string]interface{}{"Extra dirs": []interface{}{"--add-dir", "/srv/shared"}}
+	if _, err := field.Encode(valid); err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	for _, args := range [][]interface{}{
+		{"--output-format=text"},
+		{"--resume", "abc"},
+		{42.0},
+	} {
+		if _, err := field.Encode(map[string]interface{}{"Bad": args}); err == nil {
+			t.Errorf("Encode(%v) succeeded, want an error", args)
+		}
+	}
+}
+
+func TestDictationLanguage(t *testing.T) {
+	field := Lookup("dictation_language")
+	for _, language := range []string{"auto", "en", "yue"} {
+		if _, err := field.Encode(language); err != nil {
+			t.Errorf("Encode(%q) error = %v", language, err)
+		}
+	}
+	for _, language := range []string{"english", "EN", "e"} {
+		if _, err := field.Encode(language); err == nil {
+			t.Errorf("Encode(%q) succeeded, want an error", language)
+		}
+	}
+}
+
+func TestShellPreference(t *testing.T) {
+	field := Lookup("shell_preference")
+	valid := map[string]interface{}{
+		"darwin":  map[string]interface{}{"path": "/bin/zsh", "login": true},
+		"windows": map[string]interface{}{"path": "pwsh.exe"},
+	}
+	if _, err := field.Encode(valid); err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	for _, preference := range []map[string]interface{}{
+		{"plan9": map[string]interface{}{"path": "rc"}},
+		{"linux": "/bin/bash"},
+		{"linux": map[string]interface{}{"path": 1.0}},
+		{"linux": map[string]interface{}{"login": "yes"}},
+	} {
+		if _, err := field.Encode(preference); err == nil {
+			t.Errorf("Encode(%v) succeeded, want an error", preference)
+		}
+	}
+}
@@ -0,0 +1,8 @@
+//go:build windows
+
+package slashexpand
+
+// sandbox has nothing to offer on Windows
+func sandbox(dir string) (func(string, ...string) (string, []string), bool) {
+	return plain, false
+}
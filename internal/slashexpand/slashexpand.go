@@ -0,0 +1,359 @@
+// Package slashexpand previews what a custom slash command sends: it fills
+// in the arguments, runs the command's !`bash` blocks and inlines the files
+// it mentions with @, the way the CLI does when the command is used.
+//
+// The shell blocks run with a time limit, a capped output, no stdin and an
+// environment without credentials, in a sandbox that can't write to the
+// filesystem (sandbox-exec on macOS, bwrap on Linux when installed). Where
+// there is no sandbox they're skipped unless the caller allows running them
+// unsandboxed; Expansion.Sandboxed reports whether they were sandboxed.
+package slashexpand
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"ropcode/internal/sessionproc"
+	"ropcode/internal/textfile"
+)
+
+const (
+	// CommandTimeout limits one shell block
+	CommandTimeout = 10 * time.Second
+	// TotalTimeout limits all shell blocks of a command together
+	TotalTimeout = 30 * time.Second
+	// maxOutput caps the output kept from one shell block
+	maxOutput = 32 << 10
+	// maxShellBlocks caps how many shell blocks run
+	maxShellBlocks = 20
+	// maxFileBytes caps an inlined file
+	maxFileBytes = 256 << 10
+)
+
+// Expansion is the prompt a command expands to and how it got there
+type Expansion struct {
+	Prompt string      `json:"prompt"`
+	Shell  []*ShellRun `json:"shell"`
+	Files  []*FileRef  `json:"files"`
+	// Sandboxed reports whether shell blocks ran without write access
+	Sandboxed bool `json:"sandboxed"`
+}
+
+// ShellRun is one !`bash` block
+type ShellRun struct {
+	Command   string `json:"command"`
+	Output    string `json:"output"`
+	ExitCode  int    `json:"exit_code"`
+	TimedOut  bool   `json:"timed_out"`
+	Truncated bool   `json:"truncated"`
+	Error     string `json:"error,omitempty"`
+}
+
+// FileRef is one @file reference
+type FileRef struct {
+	Reference string `json:"reference"`
+	Path      string `json:"path"`
+	Inlined   bool   `json:"inlined"`
+	Truncated bool   `json:"truncated"`
+	Error     string `json:"error,omitempty"`
+}
+
+var (
+	shellBlock  = regexp.MustCompile("!`([^`\n]+)`")
+	positional  = regexp.MustCompile(`\$([1-9][0-9]*)`)
+	namedArg    = regexp.MustCompile(`\$([A-Z][A-Z0-9_]*)`)
+	fileMention = regexp.MustCompile(`(^|[\s(])@([^\s@()"'\x00` + "`" + `]+)`)
+)
+
+// Expand expands a command's prompt for args. Claude commands get all three
+// steps; Codex prompts only take arguments ($1…$9, $ARGUMENTS and KEY=value
+// pairs as $KEY), so nothing is run or inlined for them. allowUnsandboxed
+// lets shell blocks run where no sandbox is available.
+func Expand(ctx context.Context, content, commandType, args, projectPath string, allowUnsandboxed bool) *Expansion {
+	exp := &Expansion{Shell: []*ShellRun{}, Files: []*FileRef{}}
+	if commandType == "codex" {
+		exp.Prompt = substituteCodexArgs(content, args)
+		return exp
+	}
+
+	// Shell blocks see $ARGUMENTS but not positional arguments, which
+	// would clash with the shell's own $1
+	content = strings.ReplaceAll(content, "$ARGUMENTS", args)
+	content = substitutePositional(content, strings.Fields(args), true)
+
+	ctx, cancel := context.WithTimeout(ctx, TotalTimeout)
+	defer cancel()
+	shell := newShell(projectPath)
+	exp.Sandboxed = shell.sandboxed
+	// Blocks become placeholders until files are inlined, so an @ in a
+	// command's output isn't taken for a file reference
+	content = shellBlock.ReplaceAllStringFunc(content, func(block string) string {
+		command := shellBlock.FindStringSubmatch(block)[1]
+		run := &ShellRun{Command: command}
+		exp.Shell = append(exp.Shell, run)
+		switch {
+		case len(exp.Shell) > maxShellBlocks:
+			run.Error = fmt.Sprintf("skipped: only the first %d shell blocks run", maxShellBlocks)
+		case !shell.sandboxed && !allowUnsandboxed:
+			run.Error = "skipped: no sandbox available"
+		case ctx.Err() != nil:
+			run.Error = "skipped: the time for running shell blocks ran out"
+			run.TimedOut = true
+		default:
+			shell.run(ctx, run)
+		}
+		return placeholder(len(exp.Shell) - 1)
+	})
+
+	content = fileMention.ReplaceAllStringFunc(content, func(match string) string {
+		m := fileMention.FindStringSubmatch(match)
+		ref := inlineFile(m[2], projectPath)
+		exp.Files = append(exp.Files, ref.FileRef)
+		return m[1] + ref.text
+	})
+	for i, run := range exp.Shell {
+		content = strings.Replace(content, placeholder(i), run.Output, 1)
+	}
+	exp.Prompt = content
+	return exp
+}
+
+// placeholder stands in for the output of shell block i
+func placeholder(i int) string {
+	return fmt.Sprintf("\x00shell-%d\x00", i)
+}
+
+// substitutePositional replaces $1, $2… with the arguments. With
+// skipShell, text inside !`…` blocks is left alone.
+func substitutePositional(content string, fields []string, skipShell bool) string {
+	replace := func(s string) string {
+		return positional.ReplaceAllStringFunc(s, func(ref string) string {
+			n, _ := strconv.Atoi(ref[1:])
+			if n <= len(fields) {
+				return fields[n-1]
+			}
+			return ""
+		})
+	}
+	if !skipShell {
+		return replace(content)
+	}
+	var out strings.Builder
+	last := 0
+	for _, loc := range shellBlock.FindAllStringIndex(content, -1) {
+		out.WriteString(replace(content[last:loc[0]]))
+		out.WriteString(content[loc[0]:loc[1]])
+		last = loc[1]
+	}
+	out.WriteString(replace(content[last:]))
+	return out.String()
+}
+
+// substituteCodexArgs fills in a Codex prompt: KEY=value arguments set $KEY,
+// the others are positional, and $ARGUMENTS is all of them
+func substituteCodexArgs(content, args string) string {
+	fields := strings.Fields(args)
+	named := map[string]string{}
+	var plain []string
+	for _, field := range fields {
+		if key, value, ok := strings.Cut(field, "="); ok && namedArg.MatchString("$"+key) {
+			named[key] = value
+			continue
+		}
+		plain = append(plain, field)
+	}
+	content = strings.ReplaceAll(content, "$ARGUMENTS", args)
+	content = substitutePositional(content, plain, false)
+	return namedArg.ReplaceAllStringFunc(content, func(ref string) string {
+		if value, ok := named[ref[1:]]; ok {
+			return value
+		}
+		return ref
+	})
+}
+
+type inlined struct {
+	*FileRef
+	text string
+}
+
+// inlineFile returns the text that replaces an @path reference: the file's
+// content, or the reference itself when it can't be read
+func inlineFile(reference, projectPath string) inlined {
+	path := reference
+	if strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(home, path[2:])
+		}
+	} else if !filepath.IsAbs(path) && projectPath != "" {
+		path = filepath.Join(projectPath, path)
+	}
+	// Trailing punctuation usually ends the sentence, not the path
+	if _, err := os.Stat(path); err != nil {
+		if trimmed := strings.TrimRight(path, ".,;:!?"); trimmed != path {
+			if _, err := os.Stat(trimmed); err == nil {
+				suffix := path[len(trimmed):]
+				ref := inlineFile(strings.TrimSuffix(reference, suffix), projectPath)
+				ref.text += suffix
+				return ref
+			}
+		}
+	}
+
+	ref := &FileRef{Reference: "@" + reference, Path: path}
+	keep := inlined{FileRef: ref, text: ref.Reference}
+	info, err := os.Stat(path)
+	switch {
+	case err != nil:
+		ref.Error = "not found"
+		return keep
+	case info.IsDir():
+		ref.Error = "is a directory"
+		return keep
+	}
+	content, err := textfile.Read(path, maxFileBytes)
+	if err != nil {
+		ref.Error = err.Error()
+		return keep
+	}
+	if content.IsBinary {
+		ref.Error = "binary file"
+		return keep
+	}
+	ref.Inlined = true
+	ref.Truncated = content.Truncated
+	body := content.Content
+	if !strings.HasSuffix(body, "\n") {
+		body += "\n"
+	}
+	return inlined{FileRef: ref, text: fmt.Sprintf("<file path=%q>\n%s</file>", reference, body)}
+}
+
+// shell runs shell blocks in the project directory
+type shell struct {
+	dir       string
+	env       []string
+	sandboxed bool
+	wrap      func(name string, args ...string) (string, []string)
+}
+
+func newShell(dir string) *shell {
+	s := &shell{dir: dir, env: scrubbedEnv()}
+	s.wrap, s.sandboxed = sandbox(dir)
+	return s
+}
+
+func shellCommand(command string) (string, []string) {
+	if runtime.GOOS == "windows" {
+		return "cmd", []string{"/C", command}
+	}
+	return "/bin/sh", []string{"-c", command}
+}
+
+func plain(name string, args ...string) (string, []string) {
+	return name, args
+}
+
+// run runs one block, filling in run
+func (s *shell) run(ctx context.Context, run *ShellRun) {
+	ctx, cancel := context.WithTimeout(ctx, CommandTimeout)
+	defer cancel()
+
+	name, args := shellCommand(run.Command)
+	name, args = s.wrap(name, args...)
+	cmd := exec.Command(name, args...)
+	cmd.Dir = s.dir
+	cmd.Env = s.env
+	output := &cappedBuffer{max: maxOutput}
+	cmd.Stdout = output
+	cmd.Stderr = output
+	if err := sessionproc.Configure(cmd); err != nil {
+		run.Error = err.Error()
+		return
+	}
+	if err := sessionproc.Start(cmd); err != nil {
+		run.Error = err.Error()
+		return
+	}
+
+	done := make(chan struct{})
+	var waitErr error
+	go func() {
+		waitErr = cmd.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		run.TimedOut = true
+		sessionproc.Terminate(cmd, done)
+		<-done
+	}
+	sessionproc.Cleanup(cmd)
+
+	run.Output = strings.TrimRight(output.String(), "\n")
+	run.Truncated = output.truncated
+	if exitErr, ok := waitErr.(*exec.ExitError); ok {
+		run.ExitCode = exitErr.ExitCode()
+	} else if waitErr != nil {
+		run.Error = waitErr.Error()
+	}
+	if run.TimedOut {
+		run.Error = "timed out"
+	}
+}
+
+// scrubbedEnv is the environment of shell blocks: enough to find tools and
+// format output, without tokens, keys or secrets
+func scrubbedEnv() []string {
+	var env []string
+	for _, kv := range os.Environ() {
+		key, _, _ := strings.Cut(kv, "=")
+		upper := strings.ToUpper(key)
+		if strings.Contains(upper, "TOKEN") || strings.Contains(upper, "SECRET") ||
+			strings.Contains(upper, "PASSWORD") || strings.Contains(upper, "KEY") ||
+			strings.HasPrefix(upper, "ANTHROPIC_") || strings.HasPrefix(upper, "OPENAI_") ||
+			strings.HasPrefix(upper, "GEMINI_") || strings.HasPrefix(upper, "AWS_") ||
+			strings.HasPrefix(upper, "ROPCODE_") {
+			continue
+		}
+		env = append(env, kv)
+	}
+	return append(env, "TERM=dumb", "NO_COLOR=1", "GIT_TERMINAL_PROMPT=0")
+}
+
+// cappedBuffer keeps the first max bytes written to it
+type cappedBuffer struct {
+	mu        sync.Mutex
+	buf       bytes.Buffer
+	max       int
+	truncated bool
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if room := b.max - b.buf.Len(); len(p) > room {
+		b.buf.Write(p[:max(room, 0)])
+		b.truncated = true
+	} else {
+		b.buf.Write(p)
+	}
+	return len(p), nil
+}
+
+func (b *cappedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
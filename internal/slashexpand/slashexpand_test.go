@@ -0,0 +1,95 @@
+package slashexpand
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExpandArgumentsAndFiles(t *testing.T) {
+	project := t.TempDir()
+	os.WriteFile(filepath.Join(project, "main.go"), []byte("package main"), 0644)
+
+	content := "Review $1 for $2 ($ARGUMENTS).\nSee @main.go, @missing.go and mail me@example.com"
+	exp := Expand(context.Background(), content, "claude", "auth perf", project, false)
+
+	want := "Review auth for perf (auth perf).\nSee <file path=\"main.go\">\npackage main\n</file>, @missing.go and mail me@example.com"
+	if exp.Prompt != want {
+		t.Errorf("Prompt =\n%s\nwant\n%s", exp.Prompt, want)
+	}
+	if len(exp.Files) != 2 || !exp.Files[0].Inlined || exp.Files[1].Inlined || exp.Files[1].Error != "not found" {
+		t.Errorf("Files = %+v %+v", exp.Files[0], exp.Files[1])
+	}
+}
+
+func TestExpandRunsShellBlocks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh syntax")
+	}
+	project := t.TempDir()
+	t.Setenv("ANTHROPIC_API_KEY", "sk-secret")
+
+	content := "Branch: !`echo main; echo $1`\nKey: !`echo ${ANTHROPIC_API_KEY:-none}`\nFail: !`echo oops >&2; exit 3`\nUser @!`echo x`"
+	exp := Expand(context.Background(), content, "claude", "ignored", project, true)
+
+	if len(exp.Shell) != 4 {
+		t.Fatalf("ran %d blocks", len(exp.Shell))
+	}
+	// The shell's own $1 is left to the shell, which has no arguments
+	if exp.Shell[0].Output != "main" {
+		t.Errorf("first block output = %q", exp.Shell[0].Output)
+	}
+	if exp.Shell[1].Output != "none" {
+		t.Errorf("credentials reached the shell: %q", exp.Shell[1].Output)
+	}
+	if exp.Shell[2].ExitCode != 3 || exp.Shell[2].Output != "oops" {
+		t.Errorf("failing block = %+v", exp.Shell[2])
+	}
+	if !strings.HasPrefix(exp.Prompt, "Branch: main\nKey: none\nFail: oops\n") {
+		t.Errorf("Prompt = %q", exp.Prompt)
+	}
+	if len(exp.Files) != 0 {
+		t.Errorf("shell output taken for files: %+v", exp.Files)
+	}
+}
+
+func TestExpandSkipsUnsandboxedShellBlocks(t *testing.T) {
+	exp := Expand(context.Background(), "Branch: !`echo main`", "claude", "", t.TempDir(), false)
+	if exp.Sandboxed {
+		t.Skip("a sandbox is available here")
+	}
+	if exp.Shell[0].Error != "skipped: no sandbox available" || exp.Shell[0].Output != "" || exp.Prompt != "Branch: " {
+		t.Errorf("unsandboxed block = %+v, prompt %q", exp.Shell[0], exp.Prompt)
+	}
+}
+
+func TestExpandTimesOut(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh syntax")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	exp := Expand(ctx, "!`sleep 5; echo late` !`echo skipped`", "claude", "", t.TempDir(), true)
+
+	if time.Since(start) > 3*time.Second {
+		t.Fatalf("Expand() took %s", time.Since(start))
+	}
+	if !exp.Shell[0].TimedOut || exp.Shell[0].Output != "" {
+		t.Errorf("slow block = %+v", exp.Shell[0])
+	}
+	if !exp.Shell[1].TimedOut || !strings.HasPrefix(exp.Shell[1].Error, "skipped") {
+		t.Errorf("block after the budget = %+v", exp.Shell[1])
+	}
+}
+
+func TestExpandCodex(t *testing.T) {
+	exp := Expand(context.Background(), "Review $FILE for $FOCUS, then $1. !`rm -rf /` @x", "codex", "FILE=a.go FOCUS=bugs extra", "", false)
+	if exp.Prompt != "Review a.go for bugs, then extra. !`rm -rf /` @x" || len(exp.Shell) != 0 {
+		t.Errorf("codex expansion = %+v", exp)
+	}
+}
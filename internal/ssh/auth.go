@@ -0,0 +1,245 @@
+package ssh
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"ropcode/internal/keychain"
+)
+
+// Authentication methods of a connection
+const (
+	AuthKey      = "key"
+	AuthPassword = "password"
+	AuthAgent    = "agent"
+)
+
+// Environment ssh hands to ropcode when it is run as SSH_ASKPASS
+const (
+	askpassEnv        = "ROPCODE_SSH_ASKPASS"
+	askpassAccountEnv = "ROPCODE_SSH_ASKPASS_ACCOUNT"
+	askpassSecretEnv  = "ROPCODE_SSH_ASKPASS_SECRET"
+)
+
+// SecretStore keeps connection passwords out of ssh_connections.json
+type SecretStore = keychain.Store
+
+// passwordAccount is the keychain account holding a connection's password
+func passwordAccount(name string) string {
+	return "ssh:" + name
+}
+
+// validateAuth checks the authentication and jump host settings of a connection
+func validateAuth(conn *SshConnection) error {
+	switch conn.AuthMethod {
+	case "", AuthKey, AuthPassword, AuthAgent:
+	default:
+		return fmt.Errorf("invalid authentication method '%s'", conn.AuthMethod)
+	}
+	if conn.ProxyJump != "" && (strings.HasPrefix(conn.ProxyJump, "-") || strings.ContainsAny(conn.ProxyJump, " \t\r\n'\"")) {
+		return fmt.Errorf("invalid jump host '%s'", conn.ProxyJump)
+	}
+	return nil
+}
+
+// SetConnectionPassword stores the password of a saved connection in the
+// keychain, or removes it when password is empty
+func (m *Manager) SetConnectionPassword(name, password string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, c := range m.connections {
+		if c.Name != name {
+			continue
+		}
+		if password == "" {
+			if err := m.secrets.Delete(passwordAccount(name)); err != nil && !errors.Is(err, keychain.ErrNotFound) {
+				return err
+			}
+		} else if err := m.secrets.Set(passwordAccount(name), password); err != nil {
+			return err
+		}
+		m.connections[i].HasPassword = password != ""
+		return m.saveConnections()
+	}
+	return fmt.Errorf("connection '%s' not found", name)
+}
+
+// SSHOptions returns the ssh arguments that select the port, authentication,
+// jump host, agent forwarding and host key checking of a connection
+func (m *Manager) SSHOptions(conn *SshConnection) []string {
+	args := []string{"-p", fmt.Sprintf("%d", conn.Port)}
+	switch conn.AuthMethod {
+	case AuthPassword:
+		// BatchMode would disable SSH_ASKPASS, so cap the prompts instead
+		args = append(args,
+			"-o", "PreferredAuthentications=password,keyboard-interactive",
+			"-o", "PubkeyAuthentication=no",
+			"-o", "NumberOfPasswordPrompts=1",
+		)
+	case AuthAgent:
+		args = append(args, "-o", "BatchMode=yes", "-o", "PreferredAuthentications=publickey")
+	default:
+		args = append(args, "-o", "BatchMode=yes")
+		if conn.KeyPath != "" {
+			args = append(args, "-i", conn.KeyPath)
+		}
+	}
+	if conn.ProxyJump != "" {
+		args = append(args, "-J", conn.ProxyJump)
+	}
+	if conn.ForwardAgent {
+		args = append(args, "-A")
+	}
+	return append(args, m.HostKeyOptions(conn)...)
+}
+
+// CommandEnv returns the environment for an ssh or rsync process talking to
+// the connection. Password connections get ropcode as SSH_ASKPASS, which
+// answers the prompt from the keychain; others inherit the environment (nil).
+func (m *Manager) CommandEnv(conn *SshConnection) ([]string, error) {
+	if conn.AuthMethod != AuthPassword {
+		return nil, nil
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("failed to locate ropcode for SSH_ASKPASS: %w", err)
+	}
+
+	env := append(os.Environ(),
+		"SSH_ASKPASS="+exe,
+		"SSH_ASKPASS_REQUIRE=force",
+		askpassEnv+"=1",
+	)
+	// OpenSSH before 8.4 only uses SSH_ASKPASS when DISPLAY is set
+	if os.Getenv("DISPLAY") == "" {
+		env = append(env, "DISPLAY=ropcode")
+	}
+
+	if conn.Password != "" {
+		// An unsaved connection being tested carries its password inline
+		return append(env, askpassSecretEnv+"="+conn.Password), nil
+	}
+	if _, err := m.secrets.Get(passwordAccount(conn.Name)); err != nil {
+		if errors.Is(err, keychain.ErrNotFound) {
+			return nil, fmt.Errorf("no password stored for connection '%s'", conn.Name)
+		}
+		return nil, err
+	}
+	return append(env, askpassAccountEnv+"="+passwordAccount(conn.Name)), nil
+}
+
+// RunAskpassIfRequested answers ssh's password prompt and exits when ropcode
+// was started as SSH_ASKPASS. Entrypoints call it before anything else.
+func RunAskpassIfRequested() {
+	if os.Getenv(askpassEnv) != "1" {
+		return
+	}
+	os.Exit(runAskpass(strings.Join(os.Args[1:], " "), os.Stdout, keychain.System))
+}
+
+// runAskpass writes the answer to an ssh prompt and returns the exit status
+func runAskpass(prompt string, w io.Writer, secrets SecretStore) int {
+	lower := strings.ToLower(prompt)
+	if strings.Contains(lower, "yes/no") {
+		// Host keys are confirmed in the UI, never through ssh's prompt
+		fmt.Fprintln(w, "no")
+		return 0
+	}
+	if !strings.Contains(lower, "password") {
+		// Passphrases and one-time codes are not stored
+		fmt.Fprintf(os.Stderr, "ropcode: cannot answer ssh prompt %q\n", prompt)
+		return 1
+	}
+
+	secret := os.Getenv(askpassSecretEnv)
+	if secret == "" {
+		var err error
+		secret, err = secrets.Get(os.Getenv(askpassAccountEnv))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ropcode: %v\n", err)
+			return 1
+		}
+	}
+	fmt.Fprintln(w, secret)
+	return 0
+}
+
+// AgentKey is an identity held by the ssh-agent
+type AgentKey struct {
+	Bits        string `json:"bits"`
+	Fingerprint string `json:"fingerprint"`
+	Comment     string `json:"comment"`
+	Type        string `json:"type"`
+}
+
+// AgentStatus reports whether an ssh-agent is reachable for agent
+// authentication and forwarding
+type AgentStatus struct {
+	Available bool   `json:"available"`
+	Socket    string `json:"socket,omitempty"`
+	// Forwarded is set when ropcode itself runs in an SSH session whose
+	// client forwarded its agent
+	Forwarded bool       `json:"forwarded"`
+	Keys      []AgentKey `json:"keys"`
+	Error     string     `json:"error,omitempty"`
+}
+
+// GetAgentStatus detects the ssh-agent and lists the keys it holds
+func GetAgentStatus() *AgentStatus {
+	status := &AgentStatus{
+		Socket: os.Getenv("SSH_AUTH_SOCK"),
+		Keys:   []AgentKey{},
+	}
+	status.Forwarded = status.Socket != "" && os.Getenv("SSH_CONNECTION") != ""
+
+	var stderr bytes.Buffer
+	cmd := exec.Command("ssh-add", "-l")
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		switch {
+		case errors.As(err, &exitErr) && exitErr.ExitCode() == 1:
+			// The agent is running but holds no identities
+			status.Available = true
+		case errors.As(err, &exitErr):
+			status.Error = strings.TrimSpace(stderr.String())
+		default:
+			status.Error = fmt.Sprintf("ssh-add is not available: %v", err)
+		}
+		return status
+	}
+
+	status.Available = true
+	status.Keys = parseAgentKeys(string(output))
+	return status
+}
+
+// parseAgentKeys parses `ssh-add -l` lines like
+// "256 SHA256:abc user@host (ED25519)"
+func parseAgentKeys(output string) []AgentKey {
+	keys := []AgentKey{}
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 3 {
+			continue
+		}
+		key := AgentKey{Bits: fields[0], Fingerprint: fields[1]}
+		rest := fields[2:]
+		if last := rest[len(rest)-1]; strings.HasPrefix(last, "(") && strings.HasSuffix(last, ")") {
+			key.Type = strings.Trim(last, "()")
+			rest = rest[:len(rest)-1]
+		}
+		key.Comment = strings.Join(rest, " ")
+		keys = append(keys, key)
+	}
+	return keys
+}
@@ -0,0 +1,194 @@
+package ssh
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"ropcode/internal/keychain"
+)
+
+type memoryStore map[string]string
+
+func (s memoryStore) Set(account, secret string) error { s[account] = secret; return nil }
+
+func (s memoryStore) Get(account string) (string, error) {
+	secret, ok := s[account]
+	if !ok {
+		return "", keychain.ErrNotFound
+	}
+	return secret, nil
+}
+
+func (s memoryStore) Delete(account string) error {
+	if _, ok := s[account]; !ok {
+		return keychain.ErrNotFound
+	}
+	delete(s, account)
+	return nil
+}
+
+func TestPasswordIsKeptInSecretStore(t *testing.T) {
+	m := newTestManager(t)
+	secrets := m.secrets.(memoryStore)
+
+	err := m.AddGlobalConnection(SshConnection{Name: "corp", Host: "h", User: "u", AuthMethod: AuthPassword, Password: "hunter2"})
+	if err != nil {
+		t.Fatalf("AddGlobalConnection failed: %v", err)
+	}
+	if secrets[passwordAccount("corp")] != "hunter2" {
+		t.Errorf("Expected password in secret store, got %v", secrets)
+	}
+	data, err := os.ReadFile(m.configPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "hunter2") {
+		t.Errorf("Password was written to disk: %s", data)
+	}
+	var saved []SshConnection
+	if err := json.Unmarshal(data, &saved); err != nil || len(saved) != 1 || !saved[0].HasPassword {
+		t.Errorf("Expected saved connection to report a stored password: %s", data)
+	}
+
+	env, err := m.CommandEnv(&saved[0])
+	if err != nil {
+		t.Fatalf("CommandEnv failed: %v", err)
+	}
+	joined := strings.Join(env, "\n")
+	if !strings.Contains(joined, "SSH_ASKPASS_REQUIRE=force") || !strings.Contains(joined, askpassAccountEnv+"=ssh:corp") || strings.Contains(joined, "hunter2") {
+		t.Errorf("Unexpected askpass environment")
+	}
+
+	if err := m.SetConnectionPassword("corp", ""); err != nil {
+		t.Fatalf("SetConnectionPassword failed: %v", err)
+	}
+	if _, err := m.CommandEnv(&saved[0]); err == nil {
+		t.Error("Expected an error once the password is removed")
+	}
+
+	if err := m.AddGlobalConnection(SshConnection{Name: "nopw", Host: "h", User: "u", AuthMethod: AuthPassword}); err == nil {
+		t.Error("Expected password connection without password to be rejected")
+	}
+
+	if err := m.SetConnectionPassword("corp", "again"); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.DeleteGlobalConnection("corp"); err != nil {
+		t.Fatal(err)
+	}
+	if len(secrets) != 0 {
+		t.Errorf("Expected password to be deleted with the connection, got %v", secrets)
+	}
+}
+
+func TestSSHOptions(t *testing.T) {
+	m := newTestManager(t)
+
+	args := strings.Join(m.SSHOptions(&SshConnection{Port: 2222, KeyPath: "/k", ProxyJump: "me@bastion:22", ForwardAgent: true}), " ")
+	for _, want := range []string{"-p 2222", "BatchMode=yes", "-i /k", "-J me@bastion:22", "-A", "StrictHostKeyChecking=yes"} {
+		if !strings.Contains(args, want) {
+			t.Errorf("Expected %q in %s", want, args)
+		}
+	}
+
+	args = strings.Join(m.SSHOptions(&SshConnection{Port: 22, AuthMethod: AuthPassword, KeyPath: "/k"}), " ")
+	if strings.Contains(args, "BatchMode") || strings.Contains(args, "-i /k") || !strings.Contains(args, "PreferredAuthentications=password") {
+		t.Errorf("Unexpected password options: %s", args)
+	}
+
+	for _, jump := range []string{"-oProxyCommand=x", "a b"} {
+		if err := m.AddGlobalConnection(SshConnection{Name: "n", Host: "h", User: "u", ProxyJump: jump}); err == nil {
+			t.Errorf("Expected jump host %q to be rejected", jump)
+		}
+	}
+	if err := m.AddGlobalConnection(SshConnection{Name: "n", Host: "h", User: "u", AuthMethod: "kerberos"}); err == nil {
+		t.Error("Expected unknown authentication method to be rejected")
+	}
+}
+
+func TestRunAskpass(t *testing.T) {
+	secrets := memoryStore{"ssh:corp": "hunter2"}
+	t.Setenv(askpassAccountEnv, "ssh:corp")
+	t.Setenv(askpassSecretEnv, "")
+
+	var out bytes.Buffer
+	if code := runAskpass("u@h's password: ", &out, secrets); code != 0 || out.String() != "hunter2\n" {
+		t.Errorf("Expected stored password, got %d %q", code, out.String())
+	}
+
+	out.Reset()
+	t.Setenv(askpassSecretEnv, "inline")
+	if code := runAskpass("Password:", &out, secrets); code != 0 || out.String() != "inline\n" {
+		t.Errorf("Expected inline password, got %d %q", code, out.String())
+	}
+
+	out.Reset()
+	if code := runAskpass("Are you sure you want to continue connecting (yes/no/[fingerprint])?", &out, secrets); code != 0 || out.String() != "no\n" {
+		t.Errorf("Expected host key prompt to be refused, got %d %q", code, out.String())
+	}
+
+	if code := runAskpass("Enter passphrase for key '/k': ", &out, secrets); code == 0 {
+		t.Error("Expected passphrase prompt to fail")
+	}
+}
+
+func TestParseAgentKeys(t *testing.T) {
+	keys := parseAgentKeys("256 SHA256:abc me@laptop (ED25519)\n3072 SHA256:def work key (RSA)\n")
+	if len(keys) != 2 {
+		t.Fatalf("Expected 2 keys, got %v", keys)
+	}
+	if keys[0].Fingerprint != "SHA256:abc" || keys[0].Type != "ED25519" || keys[1].Comment != "work key" {
+		t.Errorf("Unexpected keys: %+v", keys)
+	}
+}
+
+func TestUpdateGlobalConnectionKeepsPassword(t *testing.T) {
+	m := newTestManager(t)
+	secrets := m.secrets.(memoryStore)
+	if err := m.AddGlobalConnection(SshConnection{Name: "a", Host: "h", User: "u", AuthMethod: AuthPassword, Password: "pw"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.UpdateGlobalConnection("a", SshConnection{Name: "b", Host: "h2", User: "u", AuthMethod: AuthPassword}); err != nil {
+		t.Fatalf("UpdateGlobalConnection failed: %v", err)
+	}
+	if secrets[passwordAccount("b")] != "pw" || len(secrets) != 1 {
+		t.Errorf("Expected password to follow the rename, got %v", secrets)
+	}
+
+	if err := m.UpdateGlobalConnection("b", SshConnection{Name: "b", Host: "h2", User: "u", AuthMethod: AuthAgent}); err != nil {
+		t.Fatal(err)
+	}
+	conns, _ := m.ListGlobalConnections()
+	if len(secrets) != 0 || conns[0].HasPassword || conns[0].Host != "h2" {
+		t.Errorf("Expected password to be dropped when switching to agent auth: %v %+v", secrets, conns)
+	}
+}
+
+func TestRemoteScript(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("remote scripts run under a POSIX shell")
+	}
+	home := t.TempDir()
+	if err := os.Mkdir(filepath.Join(home, "my proj"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	// Run the script the way sshd would, with a local shell standing in
+	script := remoteScript("~/my proj", []string{"sh", "-c", `printf '%s|%s|%s' "$PWD" "$TOKEN" "$1"`, "sh", "it's \"quoted\""}, []string{"TOKEN=a b"})
+	cmd := exec.Command("/bin/sh", "-c", script)
+	cmd.Env = append(os.Environ(), "HOME="+home, "SHELL=/bin/sh")
+	output, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("script failed: %v\n%s", err, script)
+	}
+	want := filepath.Join(home, "my proj") + `|a b|it's "quoted"`
+	if string(output) != want {
+		t.Errorf("got %q, want %q", output, want)
+	}
+}
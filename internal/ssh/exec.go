@@ -0,0 +1,52 @@
+package ssh
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// RemoteCommand returns a command that runs argv on the host of a saved
+// connection, in dir and with env added to the remote environment.
+//
+// ssh allocates a PTY (-tt) so the remote process gets SIGHUP when the
+// local command is killed. The PTY is put in raw mode without echo so
+// stdin and stdout pass through byte for byte; the remote stderr shares it.
+// argv runs under the user's login shell to get the same PATH as an
+// interactive login.
+func (m *Manager) RemoteCommand(ctx context.Context, connectionName, dir string, argv, env []string) (*exec.Cmd, error) {
+	if len(argv) == 0 {
+		return nil, fmt.Errorf("no remote command given")
+	}
+	conn, err := m.getConnection(connectionName)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.VerifyHostKey(conn); err != nil {
+		return nil, err
+	}
+	sshEnv, err := m.CommandEnv(conn)
+	if err != nil {
+		return nil, err
+	}
+
+	args := []string{"-tt", "-o", "ConnectTimeout=10", "-o", "ServerAliveInterval=30"}
+	args = append(args, m.SSHOptions(conn)...)
+	args = append(args, fmt.Sprintf("%s@%s", conn.User, conn.Host), remoteScript(dir, argv, env))
+
+	cmd := exec.CommandContext(ctx, "ssh", args...)
+	cmd.Env = sshEnv
+	return cmd, nil
+}
+
+// remoteScript builds the remote shell command of RemoteCommand
+func remoteScript(dir string, argv, env []string) string {
+	inner := make([]string, 0, len(argv)+len(env)+2)
+	inner = append(inner, "exec", "env")
+	for _, entry := range append(append([]string{}, env...), argv...) {
+		inner = append(inner, shellQuote(entry))
+	}
+	return fmt.Sprintf(`stty raw -echo 2>/dev/null; cd -- %s && exec "${SHELL:-/bin/sh}" -lc %s`,
+		remoteDir(dir), shellQuote(strings.Join(inner, " ")))
+}
@@ -0,0 +1,371 @@
+package ssh
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	gossh "golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// Host key checking modes of a connection
+const (
+	HostKeyStrict    = "strict"     // only trusted host keys (default)
+	HostKeyAcceptNew = "accept-new" // trust unknown hosts on first use, refuse changed keys
+	HostKeyOff       = "off"        // accept any host key
+)
+
+// Host key states reported by GetHostKeyFingerprint
+const (
+	HostKeyKnown   = "known"   // the key is trusted
+	HostKeyUnknown = "unknown" // no key is trusted for the host yet
+	HostKeyChanged = "changed" // a different key is trusted for the host
+	HostKeyRevoked = "revoked" // the key was rejected
+)
+
+// HostKeyInfo describes the key a server presents and whether it is trusted
+type HostKeyInfo struct {
+	Host        string `json:"host"`
+	Port        int    `json:"port"`
+	KeyType     string `json:"key_type"`
+	Fingerprint string `json:"fingerprint"` // SHA256:...
+	Status      string `json:"status"`
+	// KnownFingerprints are the trusted keys when Status is "changed"
+	KnownFingerprints []string `json:"known_fingerprints,omitempty"`
+}
+
+// KnownHost is an entry of ropcode's known_hosts file
+type KnownHost struct {
+	Hosts       []string `json:"hosts"`
+	KeyType     string   `json:"key_type"`
+	Fingerprint string   `json:"fingerprint"`
+	Revoked     bool     `json:"revoked"`
+}
+
+// hostKeyTimeout bounds fetching a server's host key
+const hostKeyTimeout = 10 * time.Second
+
+// errHostKeyCaptured aborts the handshake once the host key is known.
+var errHostKeyCaptured = errors.New("host key captured")
+
+// knownHostsPath is ropcode's known_hosts file; accepted and rejected keys
+// are written here so the user's ~/.ssh/known_hosts is never modified.
+func (m *Manager) knownHostsPath() string {
+	return filepath.Join(m.ropcodeDir, "known_hosts")
+}
+
+// knownHostsFiles returns the known_hosts files consulted for verification,
+// ropcode's own first.
+func (m *Manager) knownHostsFiles() []string {
+	files := []string{m.knownHostsPath()}
+	if m.userKnownHosts != "" {
+		files = append(files, m.userKnownHosts)
+	}
+	return files
+}
+
+// FetchHostKey connects to a server and returns the host key it presents
+func FetchHostKey(host string, port int) (gossh.PublicKey, error) {
+	var hostKey gossh.PublicKey
+	config := &gossh.ClientConfig{
+		User: "ropcode",
+		HostKeyCallback: func(hostname string, remote net.Addr, key gossh.PublicKey) error {
+			hostKey = key
+			return errHostKeyCaptured
+		},
+		Timeout: hostKeyTimeout,
+	}
+	conn, err := net.DialTimeout("tcp", hostAddress(host, port), hostKeyTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w", hostAddress(host, port), err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(hostKeyTimeout))
+
+	_, _, _, err = gossh.NewClientConn(conn, hostAddress(host, port), config)
+	if hostKey == nil {
+		return nil, fmt.Errorf("failed to read host key of %s: %w", hostAddress(host, port), err)
+	}
+	return hostKey, nil
+}
+
+func hostAddress(host string, port int) string {
+	if port == 0 {
+		port = 22
+	}
+	return net.JoinHostPort(host, strconv.Itoa(port))
+}
+
+// GetHostKeyFingerprint fetches the host key of a server and reports whether
+// it is trusted
+func (m *Manager) GetHostKeyFingerprint(host string, port int) (*HostKeyInfo, error) {
+	if port == 0 {
+		port = 22
+	}
+	key, err := FetchHostKey(host, port)
+	if err != nil {
+		return nil, err
+	}
+	info := &HostKeyInfo{
+		Host:        host,
+		Port:        port,
+		KeyType:     key.Type(),
+		Fingerprint: gossh.FingerprintSHA256(key),
+	}
+	info.Status, info.KnownFingerprints, err = m.checkHostKey(host, port, key)
+	if err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// checkHostKey looks the key up in the known_hosts files.
+func (m *Manager) checkHostKey(host string, port int, key gossh.PublicKey) (string, []string, error) {
+	var files []string
+	for _, file := range m.knownHostsFiles() {
+		if _, err := os.Stat(file); err == nil {
+			files = append(files, file)
+		}
+	}
+	if len(files) == 0 {
+		return HostKeyUnknown, nil, nil
+	}
+	callback, err := knownhosts.New(files...)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to read known hosts: %w", err)
+	}
+
+	addr := &net.TCPAddr{IP: net.ParseIP(host), Port: port}
+	err = callback(hostAddress(host, port), addr, key)
+	var keyErr *knownhosts.KeyError
+	var revokedErr *knownhosts.RevokedError
+	switch {
+	case err == nil:
+		return HostKeyKnown, nil, nil
+	case errors.As(err, &revokedErr):
+		return HostKeyRevoked, nil, nil
+	case errors.As(err, &keyErr):
+		if len(keyErr.Want) == 0 {
+			return HostKeyUnknown, nil, nil
+		}
+		known := make([]string, 0, len(keyErr.Want))
+		for _, want := range keyErr.Want {
+			known = append(known, gossh.FingerprintSHA256(want.Key))
+		}
+		return HostKeyChanged, known, nil
+	default:
+		return "", nil, err
+	}
+}
+
+// AcceptHostKey trusts the host key a server presents, replacing any key
+// previously accepted for it and lifting an earlier rejection. fingerprint
+// is the fingerprint the user confirmed; it must match the key the server
+// presents now.
+func (m *Manager) AcceptHostKey(host string, port int, fingerprint string) error {
+	key, err := m.confirmedHostKey(host, port, fingerprint)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.removeKnownHostLines(host, port, func(marker string, existing gossh.PublicKey) bool {
+		return marker == "" || gossh.FingerprintSHA256(existing) == fingerprint
+	}); err != nil {
+		return err
+	}
+	return m.appendKnownHostLine(knownhosts.Line([]string{knownhosts.Normalize(hostAddress(host, port))}, key))
+}
+
+// RejectHostKey marks the host key a server presents as revoked, so it is
+// refused even where host key checking is relaxed
+func (m *Manager) RejectHostKey(host string, port int, fingerprint string) error {
+	key, err := m.confirmedHostKey(host, port, fingerprint)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.removeKnownHostLines(host, port, func(marker string, existing gossh.PublicKey) bool {
+		return gossh.FingerprintSHA256(existing) == fingerprint
+	}); err != nil {
+		return err
+	}
+	return m.appendKnownHostLine("@revoked " + knownhosts.Line([]string{knownhosts.Normalize(hostAddress(host, port))}, key))
+}
+
+// ForgetHostKey removes every accepted or rejected key of a host from
+// ropcode's known_hosts
+func (m *Manager) ForgetHostKey(host string, port int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.removeKnownHostLines(host, port, func(string, gossh.PublicKey) bool { return true })
+}
+
+// ListKnownHosts returns the entries of ropcode's known_hosts
+func (m *Manager) ListKnownHosts() ([]KnownHost, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	hosts := make([]KnownHost, 0)
+	err := m.scanKnownHosts(func(line string) {
+		marker, patterns, key, err := parseKnownHostLine(line)
+		if err == nil && key != nil {
+			hosts = append(hosts, KnownHost{
+				Hosts:       patterns,
+				KeyType:     key.Type(),
+				Fingerprint: gossh.FingerprintSHA256(key),
+				Revoked:     marker == "revoked",
+			})
+		}
+	})
+	return hosts, err
+}
+
+func (m *Manager) confirmedHostKey(host string, port int, fingerprint string) (gossh.PublicKey, error) {
+	if port == 0 {
+		port = 22
+	}
+	key, err := FetchHostKey(host, port)
+	if err != nil {
+		return nil, err
+	}
+	if actual := gossh.FingerprintSHA256(key); actual != fingerprint {
+		return nil, fmt.Errorf("host key of %s changed while confirming: expected %s, server presents %s", hostAddress(host, port), fingerprint, actual)
+	}
+	return key, nil
+}
+
+func (m *Manager) appendKnownHostLine(line string) error {
+	if err := os.MkdirAll(m.ropcodeDir, 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(m.knownHostsPath(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = fmt.Fprintln(f, line)
+	return err
+}
+
+// removeKnownHostLines drops the lines for host:port for which remove
+// returns true.
+func (m *Manager) removeKnownHostLines(host string, port int, remove func(marker string, key gossh.PublicKey) bool) error {
+	want := knownhosts.Normalize(hostAddress(host, port))
+	var kept []string
+	changed := false
+	err := m.scanKnownHosts(func(line string) {
+		marker, patterns, key, err := parseKnownHostLine(line)
+		if err == nil && key != nil {
+			for _, pattern := range patterns {
+				if pattern == want && remove(marker, key) {
+					changed = true
+					return
+				}
+			}
+		}
+		kept = append(kept, line)
+	})
+	if err != nil || !changed {
+		return err
+	}
+	data := strings.Join(kept, "\n")
+	if data != "" {
+		data += "\n"
+	}
+	return os.WriteFile(m.knownHostsPath(), []byte(data), 0600)
+}
+
+// scanKnownHosts calls fn for every line of ropcode's known_hosts.
+func (m *Manager) scanKnownHosts(fn func(line string)) error {
+	f, err := os.Open(m.knownHostsPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fn(scanner.Text())
+	}
+	return scanner.Err()
+}
+
+// parseKnownHostLine parses a known_hosts line; comments and blank lines
+// yield a nil key.
+func parseKnownHostLine(line string) (marker string, hosts []string, key gossh.PublicKey, err error) {
+	trimmed := strings.TrimSpace(line)
+	if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+		return "", nil, nil, nil
+	}
+	marker, hosts, key, _, _, err = gossh.ParseKnownHosts([]byte(trimmed))
+	return marker, hosts, key, err
+}
+
+// HostKeyOptions returns the ssh command line options that enforce the
+// connection's host key checking mode against the known_hosts files
+func (m *Manager) HostKeyOptions(conn *SshConnection) []string {
+	strict := "yes"
+	switch conn.HostKeyChecking {
+	case HostKeyAcceptNew:
+		strict = "accept-new"
+	case HostKeyOff:
+		strict = "no"
+	}
+	files := make([]string, 0, 2)
+	for _, file := range m.knownHostsFiles() {
+		files = append(files, `"`+file+`"`)
+	}
+	return []string{
+		"-o", "StrictHostKeyChecking=" + strict,
+		"-o", "UserKnownHostsFile=" + strings.Join(files, " "),
+	}
+}
+
+// HostKeyError reports a host key that must be confirmed before connecting
+type HostKeyError struct {
+	Info *HostKeyInfo
+}
+
+func (e *HostKeyError) Error() string {
+	switch e.Info.Status {
+	case HostKeyChanged:
+		return fmt.Sprintf("host key of %s has changed (now %s): it must be accepted again before connecting", hostAddress(e.Info.Host, e.Info.Port), e.Info.Fingerprint)
+	case HostKeyRevoked:
+		return fmt.Sprintf("host key of %s (%s) was rejected", hostAddress(e.Info.Host, e.Info.Port), e.Info.Fingerprint)
+	default:
+		return fmt.Sprintf("host key of %s (%s) is not trusted: it must be accepted before connecting", hostAddress(e.Info.Host, e.Info.Port), e.Info.Fingerprint)
+	}
+}
+
+// VerifyHostKey checks the server's host key against the connection's host
+// key checking mode, returning a *HostKeyError when the user has to confirm it
+func (m *Manager) VerifyHostKey(conn *SshConnection) error {
+	// Hosts behind a jump host are not reachable directly, so ssh itself
+	// enforces StrictHostKeyChecking for them
+	if conn.HostKeyChecking == HostKeyOff || conn.ProxyJump != "" {
+		return nil
+	}
+	info, err := m.GetHostKeyFingerprint(conn.Host, conn.Port)
+	if err != nil {
+		return err
+	}
+	switch {
+	case info.Status == HostKeyKnown:
+		return nil
+	case info.Status == HostKeyUnknown && conn.HostKeyChecking == HostKeyAcceptNew:
+		return nil
+	default:
+		return &HostKeyError{Info: info}
+	}
+}
@@ -0,0 +1,182 @@
+package ssh
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	gossh "golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// startTestServer runs an SSH server that completes key exchange with a
+// fresh ed25519 host key and returns its address and key.
+func startTestServer(t *testing.T) (string, int, gossh.PublicKey) {
+	t.Helper()
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := gossh.NewSignerFromKey(private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := &gossh.ServerConfig{NoClientAuth: true}
+	config.AddHostKey(signer)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				gossh.NewServerConn(conn, config)
+			}()
+		}
+	}()
+
+	addr := listener.Addr().(*net.TCPAddr)
+	return "127.0.0.1", addr.Port, signer.PublicKey()
+}
+
+func newTestManager(t *testing.T) *Manager {
+	dir := t.TempDir()
+	return &Manager{
+		ropcodeDir:     filepath.Join(dir, "ropcode"),
+		userKnownHosts: filepath.Join(dir, "user_known_hosts"),
+		secrets:        memoryStore{},
+		syncStates:     make(map[string]*SyncState),
+	}
+}
+
+func TestHostKeyAcceptFlow(t *testing.T) {
+	host, port, key := startTestServer(t)
+	m := newTestManager(t)
+
+	info, err := m.GetHostKeyFingerprint(host, port)
+	if err != nil {
+		t.Fatalf("GetHostKeyFingerprint failed: %v", err)
+	}
+	if info.Status != HostKeyUnknown || info.Fingerprint != gossh.FingerprintSHA256(key) || info.KeyType != "ssh-ed25519" {
+		t.Fatalf("Unexpected host key info: %#v", info)
+	}
+
+	conn := &SshConnection{Host: host, Port: port}
+	var hostKeyErr *HostKeyError
+	if err := m.VerifyHostKey(conn); !errors.As(err, &hostKeyErr) {
+		t.Fatalf("Expected strict checking to require confirmation, got %v", err)
+	}
+	conn.HostKeyChecking = HostKeyAcceptNew
+	if err := m.VerifyHostKey(conn); err != nil {
+		t.Errorf("Expected accept-new to allow an unknown host, got %v", err)
+	}
+
+	if err := m.AcceptHostKey(host, port, "SHA256:wrong"); err == nil {
+		t.Error("Expected a mismatching fingerprint to be refused")
+	}
+	if err := m.AcceptHostKey(host, port, info.Fingerprint); err != nil {
+		t.Fatalf("AcceptHostKey failed: %v", err)
+	}
+	if info, _ = m.GetHostKeyFingerprint(host, port); info.Status != HostKeyKnown {
+		t.Errorf("Expected key to be known after accepting, got %s", info.Status)
+	}
+	conn.HostKeyChecking = HostKeyStrict
+	if err := m.VerifyHostKey(conn); err != nil {
+		t.Errorf("Expected accepted key to verify, got %v", err)
+	}
+
+	hosts, err := m.ListKnownHosts()
+	if err != nil || len(hosts) != 1 || hosts[0].Fingerprint != info.Fingerprint || hosts[0].Revoked {
+		t.Fatalf("ListKnownHosts = %#v, %v", hosts, err)
+	}
+
+	if err := m.ForgetHostKey(host, port); err != nil {
+		t.Fatalf("ForgetHostKey failed: %v", err)
+	}
+	if hosts, _ := m.ListKnownHosts(); len(hosts) != 0 {
+		t.Errorf("Expected no known hosts after forgetting, got %#v", hosts)
+	}
+}
+
+func TestHostKeyChangedAndRejected(t *testing.T) {
+	host, port, _ := startTestServer(t)
+	m := newTestManager(t)
+
+	// The user's known_hosts trusts a different key for the host
+	_, otherPrivate, _ := ed25519.GenerateKey(rand.Reader)
+	otherSigner, _ := gossh.NewSignerFromKey(otherPrivate)
+	line := knownhosts.Line([]string{knownhosts.Normalize(hostAddress(host, port))}, otherSigner.PublicKey())
+	if err := os.WriteFile(m.userKnownHosts, []byte(line+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := m.GetHostKeyFingerprint(host, port)
+	if err != nil {
+		t.Fatalf("GetHostKeyFingerprint failed: %v", err)
+	}
+	if info.Status != HostKeyChanged || len(info.KnownFingerprints) != 1 || info.KnownFingerprints[0] != gossh.FingerprintSHA256(otherSigner.PublicKey()) {
+		t.Fatalf("Expected a changed key, got %#v", info)
+	}
+	if err := m.VerifyHostKey(&SshConnection{Host: host, Port: port, HostKeyChecking: HostKeyAcceptNew}); err == nil {
+		t.Error("Expected accept-new to refuse a changed key")
+	}
+
+	if err := m.RejectHostKey(host, port, info.Fingerprint); err != nil {
+		t.Fatalf("RejectHostKey failed: %v", err)
+	}
+	if info, _ = m.GetHostKeyFingerprint(host, port); info.Status != HostKeyRevoked {
+		t.Errorf("Expected rejected key to be revoked, got %s", info.Status)
+	}
+	hosts, _ := m.ListKnownHosts()
+	if len(hosts) != 1 || !hosts[0].Revoked {
+		t.Errorf("Expected a revoked entry, got %#v", hosts)
+	}
+
+	// Accepting the key later lifts the rejection
+	if err := m.AcceptHostKey(host, port, info.Fingerprint); err != nil {
+		t.Fatalf("AcceptHostKey failed: %v", err)
+	}
+	if info, _ = m.GetHostKeyFingerprint(host, port); info.Status != HostKeyKnown {
+		t.Errorf("Expected accepted key to be known despite the stale user entry, got %s", info.Status)
+	}
+	if hosts, _ := m.ListKnownHosts(); len(hosts) != 1 || hosts[0].Revoked {
+		t.Errorf("Expected only the accepted entry, got %#v", hosts)
+	}
+}
+
+func TestHostKeyOptions(t *testing.T) {
+	m := newTestManager(t)
+	args := strings.Join(m.HostKeyOptions(&SshConnection{}), " ")
+	if !strings.Contains(args, "StrictHostKeyChecking=yes") || !strings.Contains(args, m.knownHostsPath()) || !strings.Contains(args, m.userKnownHosts) {
+		t.Errorf("Unexpected strict options: %s", args)
+	}
+	args = strings.Join(m.HostKeyOptions(&SshConnection{HostKeyChecking: HostKeyAcceptNew}), " ")
+	if !strings.Contains(args, "StrictHostKeyChecking=accept-new") {
+		t.Errorf("Unexpected accept-new options: %s", args)
+	}
+
+	rsync := strings.Join(m.buildRsyncArgs(&SshConnection{Host: "h", User: "u", Port: 22}, DefaultSyncRules("/l", "/r", ""), "/l", "/r", true), " ")
+	if !strings.Contains(rsync, "'-o' 'StrictHostKeyChecking=yes'") {
+		t.Errorf("Expected rsync's ssh command to check host keys: %s", rsync)
+	}
+}
+
+func TestAddGlobalConnectionValidatesHostKeyChecking(t *testing.T) {
+	m := newTestManager(t)
+	err := m.AddGlobalConnection(SshConnection{Name: "n", Host: "h", User: "u", HostKeyChecking: "sometimes"})
+	if err == nil {
+		t.Error("Expected invalid host key checking mode to be rejected")
+	}
+}
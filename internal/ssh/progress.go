@@ -0,0 +1,355 @@
+package ssh
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Stages of a sync transfer
+const (
+	SyncStageIdle         = "idle"
+	SyncStagePlanning     = "planning"
+	SyncStageTransferring = "transferring"
+	SyncStageCompleted    = "completed"
+	SyncStageError        = "error"
+)
+
+// progressInterval throttles progress events while a file is being copied
+const progressInterval = 250 * time.Millisecond
+
+// SyncProgress is a snapshot of a running or finished rsync transfer
+type SyncProgress struct {
+	LocalPath  string `json:"local_path"`
+	RemotePath string `json:"remote_path,omitempty"`
+	Connection string `json:"connection,omitempty"`
+	// Direction is "upload" or "download"
+	Direction string `json:"direction,omitempty"`
+	Stage     string `json:"stage"`
+
+	CurrentFile string `json:"current_file,omitempty"`
+	FileBytes   int64  `json:"file_bytes"`
+	FilePercent int    `json:"file_percent"`
+
+	// Totals come from a dry run and stay zero when it fails
+	BytesTransferred int64   `json:"bytes_transferred"`
+	TotalBytes       int64   `json:"total_bytes"`
+	FilesTransferred int     `json:"files_transferred"`
+	TotalFiles       int     `json:"total_files"`
+	FilesRemaining   int     `json:"files_remaining"`
+	Percent          float64 `json:"percent"`
+	BytesPerSecond   float64 `json:"bytes_per_second"`
+
+	StartedAt int64  `json:"started_at,omitempty"`
+	UpdatedAt int64  `json:"updated_at,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// SyncFile reports a file that finished transferring
+type SyncFile struct {
+	LocalPath string `json:"local_path"`
+	Direction string `json:"direction"`
+	File      string `json:"file"`
+	Bytes     int64  `json:"bytes"`
+}
+
+// SyncEmitter receives sync progress as transfers advance and the conflicts
+// two-way syncs run into
+type SyncEmitter interface {
+	EmitSyncProgress(progress SyncProgress)
+	EmitSyncFile(file SyncFile)
+	EmitSyncConflicts(event SyncConflictsEvent)
+}
+
+// SetEmitter sets the emitter used for sync events
+func (m *Manager) SetEmitter(emitter SyncEmitter) {
+	m.progressMu.Lock()
+	defer m.progressMu.Unlock()
+	m.emitter = emitter
+}
+
+// GetSyncProgress returns the progress of the latest sync of a path
+func (m *Manager) GetSyncProgress(localPath string) *SyncProgress {
+	m.progressMu.Lock()
+	defer m.progressMu.Unlock()
+
+	tracker, exists := m.progress[localPath]
+	if !exists {
+		return &SyncProgress{LocalPath: localPath, Stage: SyncStageIdle}
+	}
+	progress := tracker.progress
+	return &progress
+}
+
+// runRsync transfers a tree with rsync, reporting progress along the way
+func (m *Manager) runRsync(conn *SshConnection, localPath, remotePath string, download bool) error {
+	env, err := m.CommandEnv(conn)
+	if err != nil {
+		return err
+	}
+
+	rules, err := m.GetSyncRules(localPath, remotePath, conn.Name)
+	if err != nil {
+		return err
+	}
+
+	tracker := m.startProgress(conn.Name, localPath, remotePath, download)
+	args := m.buildRsyncArgs(conn, rules, localPath, remotePath, download)
+
+	// A dry run first sizes the whole tree so the progress bar is meaningful
+	planCmd := exec.Command("rsync", append([]string{"--dry-run", "--stats"}, args...)...)
+	planCmd.Env = env
+	if output, err := planCmd.Output(); err == nil {
+		files, size := parseTransferStats(string(output))
+		tracker.plan(files, size)
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.Command("rsync", args...)
+	cmd.Env = env
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		tracker.fail(err)
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		tracker.fail(err)
+		return fmt.Errorf("rsync failed: %v", err)
+	}
+	tracker.consume(stdout)
+	if err := cmd.Wait(); err != nil {
+		err = fmt.Errorf("rsync failed: %v\n%s", err, stderr.String())
+		tracker.fail(err)
+		return err
+	}
+
+	tracker.finish()
+	return nil
+}
+
+// syncTracker turns rsync --progress output into progress snapshots
+type syncTracker struct {
+	m         *Manager
+	progress  SyncProgress // guarded by m.progressMu
+	doneBytes int64
+	started   time.Time
+	lastEmit  time.Time
+}
+
+func (m *Manager) startProgress(connection, localPath, remotePath string, download bool) *syncTracker {
+	direction := "upload"
+	if download {
+		direction = "download"
+	}
+	now := time.Now()
+	tracker := &syncTracker{
+		m:       m,
+		started: now,
+		progress: SyncProgress{
+			LocalPath:  localPath,
+			RemotePath: remotePath,
+			Connection: connection,
+			Direction:  direction,
+			Stage:      SyncStagePlanning,
+			StartedAt:  now.UnixMilli(),
+		},
+	}
+
+	m.progressMu.Lock()
+	if m.progress == nil {
+		m.progress = make(map[string]*syncTracker)
+	}
+	m.progress[localPath] = tracker
+	m.progressMu.Unlock()
+
+	tracker.update(true, func(p *SyncProgress) {})
+	return tracker
+}
+
+// update applies change and emits the new snapshot, at most every
+// progressInterval unless force is set
+func (t *syncTracker) update(force bool, change func(p *SyncProgress)) {
+	t.m.progressMu.Lock()
+	change(&t.progress)
+	now := time.Now()
+	t.progress.UpdatedAt = now.UnixMilli()
+	if elapsed := now.Sub(t.started).Seconds(); elapsed > 0 {
+		t.progress.BytesPerSecond = float64(t.progress.BytesTransferred) / elapsed
+	}
+	if t.progress.TotalBytes > 0 {
+		t.progress.Percent = min(100, float64(t.progress.BytesTransferred)*100/float64(t.progress.TotalBytes))
+	}
+	emit := force || now.Sub(t.lastEmit) >= progressInterval
+	if emit {
+		t.lastEmit = now
+	}
+	snapshot := t.progress
+	emitter := t.m.emitter
+	t.m.progressMu.Unlock()
+
+	if emit && emitter != nil {
+		emitter.EmitSyncProgress(snapshot)
+	}
+}
+
+func (t *syncTracker) plan(files int, size int64) {
+	t.update(true, func(p *SyncProgress) {
+		p.TotalFiles = files
+		p.TotalBytes = size
+		p.FilesRemaining = files
+	})
+}
+
+func (t *syncTracker) fail(err error) {
+	t.update(true, func(p *SyncProgress) {
+		p.Stage = SyncStageError
+		p.Error = err.Error()
+	})
+}
+
+func (t *syncTracker) finish() {
+	t.update(true, func(p *SyncProgress) {
+		p.Stage = SyncStageCompleted
+		p.CurrentFile = ""
+		p.FilesRemaining = 0
+		if p.TotalBytes > 0 {
+			p.Percent = 100
+		}
+	})
+}
+
+// progressLine matches rsync --progress updates such as
+// "  1,048,576  42%    1.20MB/s    0:00:03 (xfr#3, to-chk=17/42)"
+var progressLine = regexp.MustCompile(`^\s*([\d,]+)\s+(\d+)%\s+\S+/s\s+\S+(?:\s+\((?:xfr|xfer)#(\d+),\s*(?:to-chk|to-check|ir-chk)=(\d+)/(\d+)\))?`)
+
+// consume reads rsync's stdout until it closes
+func (t *syncTracker) consume(r io.Reader) {
+	t.update(true, func(p *SyncProgress) { p.Stage = SyncStageTransferring })
+
+	scanner := bufio.NewScanner(r)
+	scanner.Split(scanProgressLines)
+	for scanner.Scan() {
+		t.handleLine(scanner.Text())
+	}
+}
+
+func (t *syncTracker) handleLine(line string) {
+	if match := progressLine.FindStringSubmatch(line); match != nil {
+		fileBytes := parseCount(match[1])
+		percent, _ := strconv.Atoi(match[2])
+		if match[3] == "" {
+			t.update(false, func(p *SyncProgress) {
+				p.FileBytes = fileBytes
+				p.FilePercent = percent
+				p.BytesTransferred = t.doneBytes + fileBytes
+			})
+			return
+		}
+
+		// The line carrying xfr# closes the file
+		transferred, _ := strconv.Atoi(match[3])
+		toCheck, _ := strconv.Atoi(match[4])
+		t.doneBytes += fileBytes
+		var file SyncFile
+		t.update(true, func(p *SyncProgress) {
+			p.FileBytes = fileBytes
+			p.FilePercent = 100
+			p.BytesTransferred = t.doneBytes
+			p.FilesTransferred = transferred
+			if p.TotalFiles > 0 {
+				p.FilesRemaining = max(0, p.TotalFiles-transferred)
+			} else {
+				p.FilesRemaining = toCheck
+			}
+			file = SyncFile{LocalPath: p.LocalPath, Direction: p.Direction, File: p.CurrentFile, Bytes: fileBytes}
+		})
+		t.m.progressMu.Lock()
+		emitter := t.m.emitter
+		t.m.progressMu.Unlock()
+		if emitter != nil {
+			emitter.EmitSyncFile(file)
+		}
+		return
+	}
+
+	name := strings.TrimSpace(line)
+	if name == "" || strings.HasSuffix(name, "/") || isRsyncNotice(name) {
+		return
+	}
+	t.update(false, func(p *SyncProgress) {
+		p.CurrentFile = name
+		p.FileBytes = 0
+		p.FilePercent = 0
+	})
+}
+
+// rsyncNotices are the lines rsync prints besides file names and progress
+var rsyncNotices = []string{
+	"sending incremental file list",
+	"receiving incremental file list",
+	"building file list",
+	"receiving file list",
+	"created directory ",
+	"deleting ",
+	"sent ",
+	"total size is ",
+	"done",
+}
+
+func isRsyncNotice(line string) bool {
+	for _, notice := range rsyncNotices {
+		if strings.HasPrefix(line, notice) {
+			return true
+		}
+	}
+	return false
+}
+
+// scanProgressLines splits on both \n and the \r rsync uses to redraw
+// progress in place
+func scanProgressLines(data []byte, atEOF bool) (int, []byte, error) {
+	if i := bytes.IndexAny(data, "\r\n"); i >= 0 {
+		return i + 1, data[:i], nil
+	}
+	if atEOF && len(data) > 0 {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
+
+// parseTransferStats reads the file count and size from rsync --stats
+func parseTransferStats(output string) (int, int64) {
+	var files int
+	var size int64
+	for _, line := range strings.Split(output, "\n") {
+		key, value, ok := strings.Cut(strings.TrimSpace(line), ":")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(value)
+		if len(fields) == 0 {
+			continue
+		}
+		switch key {
+		// rsync 3 counts regular files separately; 2.x only has the total
+		case "Number of regular files transferred", "Number of files transferred":
+			files = int(parseCount(fields[0]))
+		case "Total transferred file size":
+			size = parseCount(fields[0])
+		}
+	}
+	return files, size
+}
+
+// parseCount parses numbers rsync prints with thousands separators
+func parseCount(value string) int64 {
+	n, _ := strconv.ParseInt(strings.ReplaceAll(value, ",", ""), 10, 64)
+	return n
+}
@@ -0,0 +1,138 @@
+package ssh
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type recordingEmitter struct {
+	mu        sync.Mutex
+	progress  []SyncProgress
+	files     []SyncFile
+	conflicts []SyncConflictsEvent
+}
+
+func (e *recordingEmitter) EmitSyncProgress(progress SyncProgress) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.progress = append(e.progress, progress)
+}
+
+func (e *recordingEmitter) EmitSyncFile(file SyncFile) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.files = append(e.files, file)
+}
+
+func (e *recordingEmitter) EmitSyncConflicts(event SyncConflictsEvent) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.conflicts = append(e.conflicts, event)
+}
+
+const rsyncOutput = "sending incremental file list\n" +
+	"./\n" +
+	"src/\n" +
+	"src/a.go\n" +
+	"          512  50%    1.00MB/s    0:00:00\r" +
+	"        1,024 100%    1.00MB/s    0:00:00 (xfr#1, to-chk=3/6)\n" +
+	"src/b.go\n" +
+	"        3,072 100%    2.00MB/s    0:00:00 (xfr#2, to-chk=0/6)\n" +
+	"deleting old.txt\n" +
+	"\n" +
+	"sent 4,321 bytes  received 64 bytes  8,770.00 bytes/sec\n" +
+	"total size is 4,096  speedup is 0.93\n"
+
+func TestSyncTrackerParsesRsyncProgress(t *testing.T) {
+	m := newTestManager(t)
+	emitter := &recordingEmitter{}
+	m.SetEmitter(emitter)
+
+	tracker := m.startProgress("conn", "/local", "/remote", false)
+	tracker.plan(2, 4096)
+	tracker.consume(strings.NewReader(rsyncOutput))
+	tracker.finish()
+
+	if len(emitter.files) != 2 || emitter.files[0].File != "src/a.go" || emitter.files[0].Bytes != 1024 || emitter.files[1].File != "src/b.go" {
+		t.Fatalf("Unexpected file events: %+v", emitter.files)
+	}
+
+	got := m.GetSyncProgress("/local")
+	if got.Stage != SyncStageCompleted || got.Direction != "upload" || got.Connection != "conn" {
+		t.Errorf("Unexpected final progress: %+v", got)
+	}
+	if got.BytesTransferred != 4096 || got.FilesTransferred != 2 || got.Percent != 100 || got.FilesRemaining != 0 {
+		t.Errorf("Unexpected totals: %+v", got)
+	}
+
+	var sawHalf bool
+	for _, p := range emitter.progress {
+		if p.FilesTransferred == 1 && p.BytesTransferred == 1024 && p.Percent == 25 && p.FilesRemaining == 1 {
+			sawHalf = true
+		}
+	}
+	if !sawHalf {
+		t.Errorf("Expected aggregate progress after the first file, got %+v", emitter.progress)
+	}
+
+	if idle := m.GetSyncProgress("/elsewhere"); idle.Stage != SyncStageIdle {
+		t.Errorf("Expected idle progress for unknown path, got %+v", idle)
+	}
+}
+
+func TestParseTransferStats(t *testing.T) {
+	files, size := parseTransferStats(`
+Number of files: 12 (reg: 10, dir: 2)
+Number of created files: 3
+Number of regular files transferred: 7
+Total file size: 99,999 bytes
+Total transferred file size: 12,345 bytes
+`)
+	if files != 7 || size != 12345 {
+		t.Errorf("rsync 3 stats: got %d files, %d bytes", files, size)
+	}
+
+	files, size = parseTransferStats("Number of files transferred: 4\nTotal transferred file size: 800 bytes\n")
+	if files != 4 || size != 800 {
+		t.Errorf("rsync 2 stats: got %d files, %d bytes", files, size)
+	}
+}
+
+func TestSyncToSSHReportsProgress(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake rsync is a shell script")
+	}
+	bin := t.TempDir()
+	script := "#!/bin/sh\n" +
+		"if [ \"$1\" = \"--dry-run\" ]; then\n" +
+		"  printf 'Number of regular files transferred: 2\\nTotal transferred file size: 4,096 bytes\\n'\n" +
+		"  exit 0\n" +
+		"fi\n" +
+		"printf '" + strings.NewReplacer("\n", `\n`, "\r", `\r`, "%", "%%").Replace(rsyncOutput) + "'\n"
+	if err := os.WriteFile(filepath.Join(bin, "rsync"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	m := newTestManager(t)
+	emitter := &recordingEmitter{}
+	m.SetEmitter(emitter)
+	if err := m.AddGlobalConnection(SshConnection{Name: "c", Host: "h", User: "u"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.SyncToSSH("/local", "/remote", "c"); err != nil {
+		t.Fatalf("SyncToSSH failed: %v", err)
+	}
+	got := m.GetSyncProgress("/local")
+	if got.Stage != SyncStageCompleted || got.TotalFiles != 2 || got.TotalBytes != 4096 || got.BytesTransferred != 4096 {
+		t.Errorf("Unexpected progress: %+v", got)
+	}
+	if len(emitter.files) != 2 {
+		t.Errorf("Expected 2 file events, got %+v", emitter.files)
+	}
+}
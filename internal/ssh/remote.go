@@ -0,0 +1,160 @@
+package ssh
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// remoteTree returns the remote side of a two-way sync pair
+func (m *Manager) remoteTree(conn *SshConnection, localPath, remotePath string) (remoteTree, error) {
+	if m.openTree != nil {
+		return m.openTree(conn, localPath, remotePath)
+	}
+	env, err := m.CommandEnv(conn)
+	if err != nil {
+		return nil, err
+	}
+	return &sshTree{m: m, conn: conn, env: env, localPath: localPath, remotePath: remotePath}, nil
+}
+
+// sshTree reaches the remote directory with ssh for listings and rsync for
+// copies. The remote side only needs a POSIX shell, find and sha256sum or
+// shasum.
+type sshTree struct {
+	m          *Manager
+	conn       *SshConnection
+	env        []string
+	localPath  string
+	remotePath string
+}
+
+// listScript prints "size mtime path" per file, using GNU find's -printf
+// where available and BSD stat otherwise
+const listScript = `cd -- %s || exit 1
+if find . -maxdepth 0 -printf '' >/dev/null 2>&1; then
+	find . -type f -printf '%%s %%T@ %%P\n'
+else
+	find . -type f -exec stat -f '%%z %%m %%N' {} +
+fi`
+
+// hashScript prints "sha256 path" for each path read from stdin
+const hashScript = `cd -- %s || exit 1
+while IFS= read -r f; do
+	if command -v sha256sum >/dev/null 2>&1; then h=$(sha256sum -- "$f" | cut -d' ' -f1); else h=$(shasum -a 256 -- "$f" | cut -d' ' -f1); fi
+	printf '%%s %%s\n' "$h" "$f"
+done`
+
+// removeScript deletes each path read from stdin
+const removeScript = `cd -- %s || exit 1
+while IFS= read -r f; do rm -f -- "$f"; done`
+
+func (t *sshTree) run(script string, paths []string) ([]byte, error) {
+	args := append([]string{"-o", "ConnectTimeout=10"}, t.m.SSHOptions(t.conn)...)
+	args = append(args, fmt.Sprintf("%s@%s", t.conn.User, t.conn.Host), fmt.Sprintf(script, remoteDir(t.remotePath)))
+
+	var stderr bytes.Buffer
+	cmd := exec.Command("ssh", args...)
+	cmd.Env = t.env
+	cmd.Stdin = strings.NewReader(strings.Join(paths, "\n") + "\n")
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("ssh command failed: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return output, nil
+}
+
+func (t *sshTree) List() (map[string]FileState, error) {
+	output, err := t.run(listScript, nil)
+	if err != nil {
+		return nil, err
+	}
+	return parseRemoteList(string(output)), nil
+}
+
+func (t *sshTree) Hash(paths []string) (map[string]string, error) {
+	output, err := t.run(hashScript, paths)
+	if err != nil {
+		return nil, err
+	}
+	hashes := make(map[string]string)
+	for _, line := range strings.Split(string(output), "\n") {
+		if hash, rel, ok := strings.Cut(line, " "); ok && hash != "" {
+			hashes[rel] = hash
+		}
+	}
+	return hashes, nil
+}
+
+func (t *sshTree) Remove(paths []string) error {
+	_, err := t.run(removeScript, paths)
+	return err
+}
+
+func (t *sshTree) Push(paths []string) error {
+	return t.rsync(paths, strings.TrimSuffix(t.localPath, "/")+"/", t.remoteSpec())
+}
+
+func (t *sshTree) Pull(paths []string) error {
+	return t.rsync(paths, t.remoteSpec(), strings.TrimSuffix(t.localPath, "/")+"/")
+}
+
+func (t *sshTree) remoteSpec() string {
+	return fmt.Sprintf("%s@%s:%s/", t.conn.User, t.conn.Host, strings.TrimSuffix(t.remotePath, "/"))
+}
+
+// rsync copies exactly the listed files, creating parent directories
+func (t *sshTree) rsync(paths []string, from, to string) error {
+	cmd := exec.Command("rsync", "-az", "--files-from=-", "-e", t.m.rsyncShell(t.conn), from, to)
+	cmd.Env = t.env
+	cmd.Stdin = strings.NewReader(strings.Join(paths, "\n") + "\n")
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("rsync failed: %v\n%s", err, string(output))
+	}
+	return nil
+}
+
+// parseRemoteList parses the output of listScript
+func parseRemoteList(output string) map[string]FileState {
+	files := make(map[string]FileState)
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.SplitN(line, " ", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		size, err := strconv.ParseInt(fields[0], 10, 64)
+		if err != nil {
+			continue
+		}
+		mtime, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil {
+			continue
+		}
+		rel := strings.TrimPrefix(fields[2], "./")
+		if rel == "" {
+			continue
+		}
+		files[rel] = FileState{Size: size, Mtime: int64(mtime)}
+	}
+	return files
+}
+
+// remoteDir quotes a remote directory for the shell, keeping a leading ~/
+// relative to the remote home
+func remoteDir(dir string) string {
+	if dir == "~" {
+		return `"$HOME"`
+	}
+	if rest, ok := strings.CutPrefix(dir, "~/"); ok {
+		return `"$HOME"/` + shellQuote(rest)
+	}
+	return shellQuote(dir)
+}
+
+// shellQuote quotes a string for a POSIX shell
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
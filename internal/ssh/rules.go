@@ -0,0 +1,275 @@
+package ssh
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// SyncRules are the include/exclude rules of one sync pair: a local path
+// synced with a remote path over a saved connection
+type SyncRules struct {
+	LocalPath  string `json:"local_path"`
+	RemotePath string `json:"remote_path"`
+	Connection string `json:"connection"`
+	// Include patterns win over Exclude and .gitignore rules
+	Include []string `json:"include"`
+	Exclude []string `json:"exclude"`
+	// RespectGitignore excludes whatever the .gitignore files of the synced
+	// tree ignore; "!" re-includes are not supported by rsync
+	RespectGitignore bool `json:"respect_gitignore"`
+}
+
+// defaultExcludes keep VCS metadata and dependencies off the wire until a
+// pair has its own rules
+var defaultExcludes = []string{".git/", "node_modules/"}
+
+// DefaultSyncRules returns the rules used by pairs without saved rules
+func DefaultSyncRules(localPath, remotePath, connection string) *SyncRules {
+	return &SyncRules{
+		LocalPath:        localPath,
+		RemotePath:       remotePath,
+		Connection:       connection,
+		Include:          []string{},
+		Exclude:          append([]string{}, defaultExcludes...),
+		RespectGitignore: true,
+	}
+}
+
+func (r *SyncRules) matches(localPath, remotePath, connection string) bool {
+	return r.LocalPath == localPath && r.RemotePath == remotePath && r.Connection == connection
+}
+
+// rulesPath returns the path to the sync rules file
+func (m *Manager) rulesPath() string {
+	return filepath.Join(m.ropcodeDir, "ssh_sync_rules.json")
+}
+
+// loadRules reads all saved sync rules
+func (m *Manager) loadRules() ([]SyncRules, error) {
+	data, err := os.ReadFile(m.rulesPath())
+	if os.IsNotExist(err) {
+		return []SyncRules{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	rules := make([]SyncRules, 0)
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", m.rulesPath(), err)
+	}
+	return rules, nil
+}
+
+// saveRules writes all sync rules
+func (m *Manager) saveRules(rules []SyncRules) error {
+	if err := os.MkdirAll(m.ropcodeDir, 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(rules, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(m.rulesPath(), data, 0644)
+}
+
+// ListSyncRules returns the rules saved for every sync pair
+func (m *Manager) ListSyncRules() ([]SyncRules, error) {
+	m.rulesMu.Lock()
+	defer m.rulesMu.Unlock()
+	return m.loadRules()
+}
+
+// GetSyncRules returns the rules of a sync pair, or the defaults when none
+// are saved
+func (m *Manager) GetSyncRules(localPath, remotePath, connection string) (*SyncRules, error) {
+	m.rulesMu.Lock()
+	defer m.rulesMu.Unlock()
+
+	rules, err := m.loadRules()
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range rules {
+		if r.matches(localPath, remotePath, connection) {
+			return &r, nil
+		}
+	}
+	return DefaultSyncRules(localPath, remotePath, connection), nil
+}
+
+// SetSyncRules saves the rules of a sync pair
+func (m *Manager) SetSyncRules(rules SyncRules) error {
+	if rules.LocalPath == "" || rules.RemotePath == "" || rules.Connection == "" {
+		return fmt.Errorf("invalid sync rules: local path, remote path and connection are required")
+	}
+	var err error
+	if rules.Include, err = cleanPatterns(rules.Include); err != nil {
+		return err
+	}
+	if rules.Exclude, err = cleanPatterns(rules.Exclude); err != nil {
+		return err
+	}
+
+	m.rulesMu.Lock()
+	defer m.rulesMu.Unlock()
+
+	saved, err := m.loadRules()
+	if err != nil {
+		return err
+	}
+	for i, r := range saved {
+		if r.matches(rules.LocalPath, rules.RemotePath, rules.Connection) {
+			saved[i] = rules
+			return m.saveRules(saved)
+		}
+	}
+	return m.saveRules(append(saved, rules))
+}
+
+// DeleteSyncRules drops the saved rules of a sync pair so it falls back to
+// the defaults
+func (m *Manager) DeleteSyncRules(localPath, remotePath, connection string) error {
+	m.rulesMu.Lock()
+	defer m.rulesMu.Unlock()
+
+	saved, err := m.loadRules()
+	if err != nil {
+		return err
+	}
+	for i, r := range saved {
+		if r.matches(localPath, remotePath, connection) {
+			return m.saveRules(append(saved[:i], saved[i+1:]...))
+		}
+	}
+	return nil
+}
+
+// cleanPatterns trims patterns and drops empty ones
+func cleanPatterns(patterns []string) ([]string, error) {
+	cleaned := make([]string, 0, len(patterns))
+	for _, pattern := range patterns {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+		if strings.ContainsAny(pattern, "\r\n") {
+			return nil, fmt.Errorf("invalid pattern %q", pattern)
+		}
+		cleaned = append(cleaned, pattern)
+	}
+	return cleaned, nil
+}
+
+// filterArgs turns sync rules into rsync filter arguments. rsync applies the
+// first matching rule, so includes come first.
+func (r *SyncRules) filterArgs() []string {
+	args := make([]string, 0, len(r.Include)+len(r.Exclude)+1)
+	for _, pattern := range r.Include {
+		args = append(args, "--include="+pattern)
+	}
+	for _, pattern := range r.Exclude {
+		args = append(args, "--exclude="+pattern)
+	}
+	if r.RespectGitignore {
+		// Merge every .gitignore found while walking the tree as excludes
+		args = append(args, "--filter=:- .gitignore")
+	}
+	return args
+}
+
+// syncMatcher decides which paths of a tree the rules leave out, mirroring
+// the rsync filters for syncs that walk the tree themselves
+type syncMatcher struct {
+	rules *SyncRules
+	// gitignores holds the patterns of each .gitignore, keyed by the
+	// slash-separated directory that contains it ("" for the root)
+	gitignores map[string][]string
+}
+
+func newSyncMatcher(rules *SyncRules) *syncMatcher {
+	return &syncMatcher{rules: rules, gitignores: make(map[string][]string)}
+}
+
+// loadGitignore reads the .gitignore of a directory of the local tree
+func (sm *syncMatcher) loadGitignore(root, dir string) {
+	if !sm.rules.RespectGitignore {
+		return
+	}
+	data, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(dir), ".gitignore"))
+	if err != nil {
+		return
+	}
+	var patterns []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		// Negations cannot be expressed as rsync excludes either
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "!") {
+			continue
+		}
+		patterns = append(patterns, line)
+	}
+	if len(patterns) > 0 {
+		sm.gitignores[dir] = patterns
+	}
+}
+
+// excludes reports whether a slash-separated relative path is left out,
+// either itself or through one of its parent directories
+func (sm *syncMatcher) excludes(rel string, isDir bool) bool {
+	parts := strings.Split(rel, "/")
+	for i := 1; i < len(parts); i++ {
+		if sm.excludesEntry(strings.Join(parts[:i], "/"), true) {
+			return true
+		}
+	}
+	return sm.excludesEntry(rel, isDir)
+}
+
+func (sm *syncMatcher) excludesEntry(rel string, isDir bool) bool {
+	for _, pattern := range sm.rules.Include {
+		if matchPattern(pattern, rel, isDir) {
+			return false
+		}
+	}
+	for _, pattern := range sm.rules.Exclude {
+		if matchPattern(pattern, rel, isDir) {
+			return true
+		}
+	}
+	for dir, patterns := range sm.gitignores {
+		sub := rel
+		if dir != "" {
+			if !strings.HasPrefix(rel, dir+"/") {
+				continue
+			}
+			sub = strings.TrimPrefix(rel, dir+"/")
+		}
+		for _, pattern := range patterns {
+			if matchPattern(pattern, sub, isDir) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matchPattern applies one rsync-style pattern: a trailing "/" only matches
+// directories, and patterns without a "/" match the name at any depth
+func matchPattern(pattern, rel string, isDir bool) bool {
+	if strings.HasSuffix(pattern, "/") {
+		if !isDir {
+			return false
+		}
+		pattern = strings.TrimSuffix(pattern, "/")
+	}
+	if strings.HasPrefix(pattern, "/") || strings.Contains(pattern, "/") {
+		ok, _ := path.Match(strings.TrimPrefix(pattern, "/"), rel)
+		return ok
+	}
+	ok, _ := path.Match(pattern, path.Base(rel))
+	return ok
+}
@@ -0,0 +1,60 @@
+package ssh
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSyncRulesDefaultsAndPersistence(t *testing.T) {
+	m := newTestManager(t)
+
+	rules, err := m.GetSyncRules("/l", "/r", "c")
+	if err != nil {
+		t.Fatalf("GetSyncRules failed: %v", err)
+	}
+	if !rules.RespectGitignore || strings.Join(rules.Exclude, ",") != ".git/,node_modules/" {
+		t.Errorf("Unexpected default rules: %+v", rules)
+	}
+
+	err = m.SetSyncRules(SyncRules{
+		LocalPath:  "/l",
+		RemotePath: "/r",
+		Connection: "c",
+		Include:    []string{" build/keep.txt ", ""},
+		Exclude:    []string{"build/", "*.log"},
+	})
+	if err != nil {
+		t.Fatalf("SetSyncRules failed: %v", err)
+	}
+
+	rules, err = m.GetSyncRules("/l", "/r", "c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rules.RespectGitignore || strings.Join(rules.Include, ",") != "build/keep.txt" || len(rules.Exclude) != 2 {
+		t.Errorf("Unexpected saved rules: %+v", rules)
+	}
+	if other, _ := m.GetSyncRules("/l", "/elsewhere", "c"); !other.RespectGitignore {
+		t.Errorf("Expected another pair to keep the defaults: %+v", other)
+	}
+
+	args := strings.Join(m.buildRsyncArgs(&SshConnection{Host: "h", User: "u", Port: 22}, rules, "/l", "/r", false), " ")
+	if !strings.Contains(args, "--include=build/keep.txt --exclude=build/ --exclude=*.log") || strings.Contains(args, ".gitignore") {
+		t.Errorf("Unexpected rsync filters: %s", args)
+	}
+	args = strings.Join(DefaultSyncRules("/l", "/r", "c").filterArgs(), " ")
+	if args != "--exclude=.git/ --exclude=node_modules/ --filter=:- .gitignore" {
+		t.Errorf("Unexpected default filters: %s", args)
+	}
+
+	if err := m.DeleteSyncRules("/l", "/r", "c"); err != nil {
+		t.Fatal(err)
+	}
+	if saved, _ := m.ListSyncRules(); len(saved) != 0 {
+		t.Errorf("Expected rules to be deleted, got %+v", saved)
+	}
+
+	if err := m.SetSyncRules(SyncRules{LocalPath: "/l"}); err == nil {
+		t.Error("Expected rules without a pair to be rejected")
+	}
+}
@@ -0,0 +1,568 @@
+package ssh
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MaxRemoteFileSize caps ReadRemoteFile so opening a huge file in the editor
+// cannot stall the connection
+const MaxRemoteFileSize = 10 << 20
+
+// RemoteEntry is a file or directory on an SSH host
+type RemoteEntry struct {
+	Name        string    `json:"name"`
+	Path        string    `json:"path"`
+	IsDirectory bool      `json:"is_directory"`
+	Size        int64     `json:"size"`
+	Extension   string    `json:"extension,omitempty"`
+	Mode        uint32    `json:"mode"`
+	ModTime     time.Time `json:"mod_time"`
+}
+
+// ListRemoteDirectory lists a directory on the host of a saved connection.
+// Relative paths and ~/ resolve against the remote home directory;
+// directories sort first.
+func (m *Manager) ListRemoteDirectory(connectionName, dir string) ([]RemoteEntry, error) {
+	var entries []RemoteEntry
+	err := m.withSftp(connectionName, func(c *sftpClient) error {
+		resolved, err := c.realpath(sftpPath(dir))
+		if err != nil {
+			return err
+		}
+		entries, err = c.readDir(resolved)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].IsDirectory != entries[j].IsDirectory {
+			return entries[i].IsDirectory
+		}
+		return entries[i].Name < entries[j].Name
+	})
+	return entries, nil
+}
+
+// ReadRemoteFile reads a file on the host of a saved connection
+func (m *Manager) ReadRemoteFile(connectionName, file string) ([]byte, error) {
+	var data []byte
+	err := m.withSftp(connectionName, func(c *sftpClient) error {
+		var err error
+		data, err = c.readFile(sftpPath(file), MaxRemoteFileSize)
+		return err
+	})
+	return data, err
+}
+
+// WriteRemoteFile replaces the contents of a file on the host of a saved
+// connection, creating it if needed. An existing file keeps its mode.
+func (m *Manager) WriteRemoteFile(connectionName, file string, data []byte) error {
+	return m.withSftp(connectionName, func(c *sftpClient) error {
+		return c.writeFile(sftpPath(file), data)
+	})
+}
+
+// sftpPath turns the ~ notation used elsewhere into a path the SFTP server
+// resolves against the home directory
+func sftpPath(p string) string {
+	if p == "" || p == "~" {
+		return "."
+	}
+	if rest, ok := strings.CutPrefix(p, "~/"); ok {
+		return rest
+	}
+	return p
+}
+
+// withSftp runs fn against an SFTP session on the connection's host
+func (m *Manager) withSftp(connectionName string, fn func(c *sftpClient) error) error {
+	conn, err := m.getConnection(connectionName)
+	if err != nil {
+		return err
+	}
+	open := m.openSftp
+	if open == nil {
+		open = m.startSftp
+	}
+	channel, err := open(conn)
+	if err != nil {
+		return err
+	}
+	client, err := newSftpClient(channel)
+	if err != nil {
+		channel.Close()
+		return err
+	}
+	err = fn(client)
+	if closeErr := channel.Close(); err == nil && closeErr != nil {
+		err = closeErr
+	}
+	return err
+}
+
+// startSftp starts the host's sftp subsystem through ssh
+func (m *Manager) startSftp(conn *SshConnection) (io.ReadWriteCloser, error) {
+	if err := m.VerifyHostKey(conn); err != nil {
+		return nil, err
+	}
+	env, err := m.CommandEnv(conn)
+	if err != nil {
+		return nil, err
+	}
+	args := append([]string{"-s", "-o", "ConnectTimeout=10"}, m.SSHOptions(conn)...)
+	args = append(args, fmt.Sprintf("%s@%s", conn.User, conn.Host), "sftp")
+
+	cmd := exec.Command("ssh", args...)
+	cmd.Env = env
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	stderr := &strings.Builder{}
+	cmd.Stderr = stderr
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start ssh: %w", err)
+	}
+	return &sftpProcess{cmd: cmd, stdin: stdin, stdout: stdout, stderr: stderr}, nil
+}
+
+// sftpProcess is an ssh process speaking SFTP on stdin and stdout
+type sftpProcess struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stdout io.Reader
+	stderr *strings.Builder
+	once   sync.Once
+}
+
+// wait reaps ssh; stderr is complete once it returns
+func (p *sftpProcess) wait() {
+	p.once.Do(func() {
+		p.stdin.Close()
+		p.cmd.Wait()
+	})
+}
+
+func (p *sftpProcess) Read(b []byte) (int, error) {
+	n, err := p.stdout.Read(b)
+	if err == io.EOF {
+		p.wait()
+		if message := strings.TrimSpace(p.stderr.String()); message != "" {
+			err = fmt.Errorf("ssh closed the SFTP session: %s", message)
+		}
+	}
+	return n, err
+}
+
+func (p *sftpProcess) Write(b []byte) (int, error) { return p.stdin.Write(b) }
+
+func (p *sftpProcess) Close() error {
+	p.wait()
+	return nil
+}
+
+// SFTP version 3 packet types and constants (draft-ietf-secsh-filexfer-02),
+// the version OpenSSH implements
+const (
+	sftpInit     = 1
+	sftpVersion  = 2
+	sftpOpen     = 3
+	sftpClose    = 4
+	sftpRead     = 5
+	sftpWrite    = 6
+	sftpOpendir  = 11
+	sftpReaddir  = 12
+	sftpRealpath = 16
+	sftpStat     = 17
+	sftpStatus   = 101
+	sftpHandle   = 102
+	sftpData     = 103
+	sftpName     = 104
+	sftpAttrs    = 105
+
+	sftpFlagRead  = 0x01
+	sftpFlagWrite = 0x02
+	sftpFlagCreat = 0x08
+	sftpFlagTrunc = 0x10
+
+	sftpAttrSize        = 0x01
+	sftpAttrUIDGID      = 0x02
+	sftpAttrPermissions = 0x04
+	sftpAttrACModTime   = 0x08
+	sftpAttrExtended    = 0x80000000
+
+	sftpStatusOK               = 0
+	sftpStatusEOF              = 1
+	sftpStatusNoSuchFile       = 2
+	sftpStatusPermissionDenied = 3
+
+	sftpChunkSize = 32 << 10
+	sftpModeDir   = 0o040000
+	sftpModeType  = 0o170000
+)
+
+// sftpClient is a minimal SFTP client issuing one request at a time, enough
+// to browse a tree and read or write single files
+type sftpClient struct {
+	mu     sync.Mutex
+	r      *bufio.Reader
+	w      io.Writer
+	nextID uint32
+}
+
+func newSftpClient(rw io.ReadWriter) (*sftpClient, error) {
+	c := &sftpClient{r: bufio.NewReader(rw), w: rw}
+	if err := c.send(sftpInit, uint32(3)); err != nil {
+		return nil, err
+	}
+	typ, payload, err := c.receive()
+	if err != nil {
+		return nil, fmt.Errorf("SFTP handshake failed: %w", err)
+	}
+	if typ != sftpVersion || len(payload) < 4 {
+		return nil, fmt.Errorf("SFTP handshake failed: unexpected packet %d", typ)
+	}
+	return c, nil
+}
+
+// send writes a packet whose fields are uint32, uint64, string or []byte
+func (c *sftpClient) send(typ byte, fields ...interface{}) error {
+	body := []byte{typ}
+	for _, field := range fields {
+		switch v := field.(type) {
+		case uint32:
+			body = binary.BigEndian.AppendUint32(body, v)
+		case uint64:
+			body = binary.BigEndian.AppendUint64(body, v)
+		case string:
+			body = binary.BigEndian.AppendUint32(body, uint32(len(v)))
+			body = append(body, v...)
+		case []byte:
+			body = binary.BigEndian.AppendUint32(body, uint32(len(v)))
+			body = append(body, v...)
+		default:
+			panic(fmt.Sprintf("sftp: unsupported field %T", field))
+		}
+	}
+	packet := binary.BigEndian.AppendUint32(nil, uint32(len(body)))
+	_, err := c.w.Write(append(packet, body...))
+	return err
+}
+
+func (c *sftpClient) receive() (byte, []byte, error) {
+	var header [4]byte
+	if _, err := io.ReadFull(c.r, header[:]); err != nil {
+		return 0, nil, err
+	}
+	length := binary.BigEndian.Uint32(header[:])
+	if length == 0 || length > 1<<24 {
+		return 0, nil, fmt.Errorf("invalid SFTP packet length %d", length)
+	}
+	packet := make([]byte, length)
+	if _, err := io.ReadFull(c.r, packet); err != nil {
+		return 0, nil, err
+	}
+	return packet[0], packet[1:], nil
+}
+
+// request sends a request and returns the response payload after its id
+func (c *sftpClient) request(typ byte, fields ...interface{}) (byte, *sftpBuffer, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.nextID++
+	id := c.nextID
+	if err := c.send(typ, append([]interface{}{id}, fields...)...); err != nil {
+		return 0, nil, err
+	}
+	respType, payload, err := c.receive()
+	if err != nil {
+		return 0, nil, err
+	}
+	buf := &sftpBuffer{data: payload}
+	if buf.uint32() != id || buf.err != nil {
+		return 0, nil, fmt.Errorf("SFTP response does not match request %d", id)
+	}
+	return respType, buf, nil
+}
+
+// statusError turns a STATUS response into an error, nil for OK
+func statusError(buf *sftpBuffer, p string) error {
+	code := buf.uint32()
+	message := buf.string()
+	switch code {
+	case sftpStatusOK:
+		return nil
+	case sftpStatusEOF:
+		return io.EOF
+	case sftpStatusNoSuchFile:
+		return &os.PathError{Op: "sftp", Path: p, Err: os.ErrNotExist}
+	case sftpStatusPermissionDenied:
+		return &os.PathError{Op: "sftp", Path: p, Err: os.ErrPermission}
+	}
+	if message == "" {
+		message = fmt.Sprintf("status %d", code)
+	}
+	return &os.PathError{Op: "sftp", Path: p, Err: errors.New(message)}
+}
+
+func unexpected(typ byte) error {
+	return fmt.Errorf("unexpected SFTP response %d", typ)
+}
+
+func (c *sftpClient) realpath(p string) (string, error) {
+	typ, buf, err := c.request(sftpRealpath, p)
+	if err != nil {
+		return "", err
+	}
+	switch typ {
+	case sftpName:
+		if buf.uint32() < 1 {
+			return "", fmt.Errorf("empty SFTP realpath response")
+		}
+		return buf.string(), buf.err
+	case sftpStatus:
+		return "", statusError(buf, p)
+	}
+	return "", unexpected(typ)
+}
+
+func (c *sftpClient) stat(p string) (sftpFileAttrs, error) {
+	typ, buf, err := c.request(sftpStat, p)
+	if err != nil {
+		return sftpFileAttrs{}, err
+	}
+	switch typ {
+	case sftpAttrs:
+		return buf.attrs(), buf.err
+	case sftpStatus:
+		return sftpFileAttrs{}, statusError(buf, p)
+	}
+	return sftpFileAttrs{}, unexpected(typ)
+}
+
+func (c *sftpClient) handle(typ byte, fields ...interface{}) (string, error) {
+	respType, buf, err := c.request(typ, fields...)
+	if err != nil {
+		return "", err
+	}
+	switch respType {
+	case sftpHandle:
+		return buf.string(), buf.err
+	case sftpStatus:
+		return "", statusError(buf, fields[0].(string))
+	}
+	return "", unexpected(respType)
+}
+
+func (c *sftpClient) status(p string, typ byte, fields ...interface{}) error {
+	respType, buf, err := c.request(typ, fields...)
+	if err != nil {
+		return err
+	}
+	if respType != sftpStatus {
+		return unexpected(respType)
+	}
+	return statusError(buf, p)
+}
+
+func (c *sftpClient) readDir(dir string) ([]RemoteEntry, error) {
+	h, err := c.handle(sftpOpendir, dir)
+	if err != nil {
+		return nil, err
+	}
+	defer c.status(dir, sftpClose, h)
+
+	var entries []RemoteEntry
+	for {
+		typ, buf, err := c.request(sftpReaddir, h)
+		if err != nil {
+			return nil, err
+		}
+		if typ == sftpStatus {
+			if err := statusError(buf, dir); err != io.EOF {
+				return nil, err
+			}
+			return entries, nil
+		}
+		if typ != sftpName {
+			return nil, unexpected(typ)
+		}
+		for count := buf.uint32(); count > 0 && buf.err == nil; count-- {
+			name := buf.string()
+			buf.string() // longname
+			attrs := buf.attrs()
+			if name == "." || name == ".." {
+				continue
+			}
+			ext := ""
+			if !attrs.isDir() {
+				ext = strings.TrimPrefix(path.Ext(name), ".")
+			}
+			entries = append(entries, RemoteEntry{
+				Name:        name,
+				Path:        path.Join(dir, name),
+				IsDirectory: attrs.isDir(),
+				Size:        int64(attrs.size),
+				Extension:   ext,
+				Mode:        attrs.mode & 0o7777,
+				ModTime:     time.Unix(int64(attrs.mtime), 0),
+			})
+		}
+		if buf.err != nil {
+			return nil, buf.err
+		}
+	}
+}
+
+func (c *sftpClient) readFile(p string, limit int64) ([]byte, error) {
+	attrs, err := c.stat(p)
+	if err != nil {
+		return nil, err
+	}
+	if attrs.isDir() {
+		return nil, fmt.Errorf("%s is a directory", p)
+	}
+	if attrs.flags&sftpAttrSize != 0 && int64(attrs.size) > limit {
+		return nil, fmt.Errorf("%s is too large to open (%d bytes)", p, attrs.size)
+	}
+
+	h, err := c.handle(sftpOpen, p, uint32(sftpFlagRead), uint32(0))
+	if err != nil {
+		return nil, err
+	}
+	defer c.status(p, sftpClose, h)
+
+	var data []byte
+	for {
+		typ, buf, err := c.request(sftpRead, h, uint64(len(data)), uint32(sftpChunkSize))
+		if err != nil {
+			return nil, err
+		}
+		if typ == sftpStatus {
+			if err := statusError(buf, p); err != io.EOF {
+				return nil, err
+			}
+			return data, nil
+		}
+		if typ != sftpData {
+			return nil, unexpected(typ)
+		}
+		data = append(data, buf.bytes()...)
+		if buf.err != nil {
+			return nil, buf.err
+		}
+		if int64(len(data)) > limit {
+			return nil, fmt.Errorf("%s is too large to open", p)
+		}
+	}
+}
+
+func (c *sftpClient) writeFile(p string, data []byte) error {
+	// New files get 0644; OPEN leaves the mode of an existing file alone
+	h, err := c.handle(sftpOpen, p, uint32(sftpFlagWrite|sftpFlagCreat|sftpFlagTrunc), uint32(sftpAttrPermissions), uint32(0o644))
+	if err != nil {
+		return err
+	}
+	for offset := 0; offset < len(data); offset += sftpChunkSize {
+		end := min(offset+sftpChunkSize, len(data))
+		if err := c.status(p, sftpWrite, h, uint64(offset), data[offset:end]); err != nil {
+			c.status(p, sftpClose, h)
+			return err
+		}
+	}
+	return c.status(p, sftpClose, h)
+}
+
+// sftpFileAttrs holds the ATTRS fields the browser uses
+type sftpFileAttrs struct {
+	flags uint32
+	size  uint64
+	mode  uint32
+	mtime uint32
+}
+
+func (a sftpFileAttrs) isDir() bool {
+	return a.flags&sftpAttrPermissions != 0 && a.mode&sftpModeType == sftpModeDir
+}
+
+// sftpBuffer decodes packet payloads; the first error sticks
+type sftpBuffer struct {
+	data []byte
+	err  error
+}
+
+func (b *sftpBuffer) take(n int) []byte {
+	if b.err != nil {
+		return nil
+	}
+	if n < 0 || len(b.data) < n {
+		b.err = fmt.Errorf("truncated SFTP packet")
+		return nil
+	}
+	v := b.data[:n]
+	b.data = b.data[n:]
+	return v
+}
+
+func (b *sftpBuffer) uint32() uint32 {
+	if v := b.take(4); v != nil {
+		return binary.BigEndian.Uint32(v)
+	}
+	return 0
+}
+
+func (b *sftpBuffer) uint64() uint64 {
+	if v := b.take(8); v != nil {
+		return binary.BigEndian.Uint64(v)
+	}
+	return 0
+}
+
+func (b *sftpBuffer) bytes() []byte {
+	return b.take(int(b.uint32()))
+}
+
+func (b *sftpBuffer) string() string {
+	return string(b.bytes())
+}
+
+func (b *sftpBuffer) attrs() sftpFileAttrs {
+	a := sftpFileAttrs{flags: b.uint32()}
+	if a.flags&sftpAttrSize != 0 {
+		a.size = b.uint64()
+	}
+	if a.flags&sftpAttrUIDGID != 0 {
+		b.uint32()
+		b.uint32()
+	}
+	if a.flags&sftpAttrPermissions != 0 {
+		a.mode = b.uint32()
+	}
+	if a.flags&sftpAttrACModTime != 0 {
+		b.uint32()
+		a.mtime = b.uint32()
+	}
+	if a.flags&sftpAttrExtended != 0 {
+		for count := b.uint32(); count > 0 && b.err == nil; count-- {
+			b.string()
+			b.string()
+		}
+	}
+	return a
+}
@@ -0,0 +1,189 @@
+package ssh
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// serveSftp answers the SFTP requests the client sends from a directory
+// standing in for the remote home
+func serveSftp(t *testing.T, conn net.Conn, home string) {
+	defer conn.Close()
+	client := &sftpClient{r: bufio.NewReader(conn), w: conn}
+	out := &sftpClient{w: conn}
+	handles := make(map[string]interface{})
+	resolve := func(p string) string {
+		if !filepath.IsAbs(p) {
+			p = filepath.Join(home, p)
+		}
+		return filepath.Clean(p)
+	}
+	status := func(id uint32, err error) {
+		code := uint32(sftpStatusOK)
+		switch {
+		case err == io.EOF:
+			code = sftpStatusEOF
+		case errors.Is(err, os.ErrNotExist):
+			code = sftpStatusNoSuchFile
+		case err != nil:
+			code = 4
+		}
+		out.send(sftpStatus, id, code, "", "")
+	}
+	attrs := func(info os.FileInfo) []interface{} {
+		mode := uint32(info.Mode().Perm())
+		if info.IsDir() {
+			mode |= sftpModeDir
+		} else {
+			mode |= 0o100000
+		}
+		return []interface{}{uint32(sftpAttrSize | sftpAttrPermissions | sftpAttrACModTime), uint64(info.Size()), mode, uint32(0), uint32(info.ModTime().Unix())}
+	}
+
+	for {
+		typ, payload, err := client.receive()
+		if err != nil {
+			return
+		}
+		buf := &sftpBuffer{data: payload}
+		if typ == sftpInit {
+			out.send(sftpVersion, uint32(3))
+			continue
+		}
+		id := buf.uint32()
+		switch typ {
+		case sftpRealpath:
+			out.send(sftpName, id, uint32(1), resolve(buf.string()), "", uint32(0))
+		case sftpStat:
+			info, err := os.Stat(resolve(buf.string()))
+			if err != nil {
+				status(id, err)
+				continue
+			}
+			out.send(sftpAttrs, append([]interface{}{id}, attrs(info)...)...)
+		case sftpOpendir:
+			entries, err := os.ReadDir(resolve(buf.string()))
+			if err != nil {
+				status(id, err)
+				continue
+			}
+			handles["d"] = entries
+			out.send(sftpHandle, id, "d")
+		case sftpReaddir:
+			entries, _ := handles["d"].([]os.DirEntry)
+			if len(entries) == 0 {
+				status(id, io.EOF)
+				continue
+			}
+			fields := []interface{}{id, uint32(len(entries))}
+			for _, entry := range entries {
+				info, _ := entry.Info()
+				fields = append(fields, entry.Name(), "longname")
+				fields = append(fields, attrs(info)...)
+			}
+			handles["d"] = nil
+			out.send(sftpName, fields...)
+		case sftpOpen:
+			p := resolve(buf.string())
+			pflags := buf.uint32()
+			flag := os.O_RDONLY
+			if pflags&sftpFlagWrite != 0 {
+				flag = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+			}
+			f, err := os.OpenFile(p, flag, 0644)
+			if err != nil {
+				status(id, err)
+				continue
+			}
+			handles["f"] = f
+			out.send(sftpHandle, id, "f")
+		case sftpRead:
+			buf.string()
+			offset := buf.uint64()
+			length := buf.uint32()
+			data := make([]byte, length)
+			n, err := handles["f"].(*os.File).ReadAt(data, int64(offset))
+			if n == 0 {
+				status(id, err)
+				continue
+			}
+			out.send(sftpData, id, data[:n])
+		case sftpWrite:
+			buf.string()
+			offset := buf.uint64()
+			_, err := handles["f"].(*os.File).WriteAt(buf.bytes(), int64(offset))
+			status(id, err)
+		case sftpClose:
+			if f, ok := handles[buf.string()].(*os.File); ok {
+				f.Close()
+			}
+			status(id, nil)
+		default:
+			t.Errorf("Unexpected SFTP request %d", typ)
+			status(id, errors.New("unsupported"))
+		}
+	}
+}
+
+func newSftpTestManager(t *testing.T) (*Manager, string) {
+	m := newTestManager(t)
+	m.connections = []SshConnection{{Name: "dev", Host: "h", User: "u", Port: 22}}
+	home := t.TempDir()
+	m.openSftp = func(conn *SshConnection) (io.ReadWriteCloser, error) {
+		client, server := net.Pipe()
+		go serveSftp(t, server, home)
+		return client, nil
+	}
+	return m, home
+}
+
+func TestRemoteFileBrowser(t *testing.T) {
+	m, home := newSftpTestManager(t)
+	if err := os.MkdirAll(filepath.Join(home, "project", "src"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	large := strings.Repeat("x", 3*sftpChunkSize+7)
+	if err := os.WriteFile(filepath.Join(home, "project", "main.go"), []byte(large), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := m.ListRemoteDirectory("dev", "~/project")
+	if err != nil {
+		t.Fatalf("ListRemoteDirectory failed: %v", err)
+	}
+	if len(entries) != 2 || entries[0].Name != "src" || !entries[0].IsDirectory || entries[1].Name != "main.go" || entries[1].IsDirectory {
+		t.Fatalf("Unexpected entries: %#v", entries)
+	}
+	if entries[1].Path != filepath.Join(home, "project", "main.go") || entries[1].Size != int64(len(large)) || entries[1].Mode != 0o600 {
+		t.Errorf("Unexpected file entry: %#v", entries[1])
+	}
+
+	data, err := m.ReadRemoteFile("dev", "project/main.go")
+	if err != nil || string(data) != large {
+		t.Fatalf("ReadRemoteFile returned %d bytes, %v", len(data), err)
+	}
+	if _, err := m.ReadRemoteFile("dev", "project/missing.go"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected a missing file to report ErrNotExist, got %v", err)
+	}
+	if _, err := m.ReadRemoteFile("dev", "project/src"); err == nil {
+		t.Error("Expected reading a directory to fail")
+	}
+
+	written := strings.Repeat("y", sftpChunkSize+1)
+	if err := m.WriteRemoteFile("dev", "~/project/src/new.go", []byte(written)); err != nil {
+		t.Fatalf("WriteRemoteFile failed: %v", err)
+	}
+	if got, _ := os.ReadFile(filepath.Join(home, "project", "src", "new.go")); string(got) != written {
+		t.Errorf("Remote file holds %d bytes, want %d", len(got), len(written))
+	}
+
+	if _, err := m.ListRemoteDirectory("missing", "~"); err == nil {
+		t.Error("Expected an unknown connection to fail")
+	}
+}
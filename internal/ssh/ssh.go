@@ -2,12 +2,15 @@ package ssh
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"sync"
 	"time"
+
+	"ropcode/internal/keychain"
 )
 
 // SshConnection represents a saved SSH connection configuration
@@ -17,6 +20,17 @@ type SshConnection struct {
 	Port    int    `json:"port"`
 	User    string `json:"user"`
 	KeyPath string `json:"key_path,omitempty"`
+	// HostKeyChecking is "strict" (default), "accept-new" or "off"
+	HostKeyChecking string `json:"host_key_checking,omitempty"`
+	// AuthMethod is "key" (default), "password" or "agent"
+	AuthMethod string `json:"auth_method,omitempty"`
+	// Password is only accepted when saving or testing a connection; saved
+	// passwords live in the keychain and are never written to disk
+	Password    string `json:"password,omitempty"`
+	HasPassword bool   `json:"has_password,omitempty"`
+	// ProxyJump is passed to ssh -J, e.g. "user@bastion:2222"
+	ProxyJump    string `json:"proxy_jump,omitempty"`
+	ForwardAgent bool   `json:"forward_agent,omitempty"`
 }
 
 // SyncState represents the state of an active sync operation
@@ -24,6 +38,7 @@ type SyncState struct {
 	LocalPath    string
 	RemotePath   string
 	Connection   string
+	Mode         string
 	IsRunning    bool
 	IsPaused     bool
 	LastSyncTime time.Time
@@ -33,10 +48,24 @@ type SyncState struct {
 
 // Manager manages SSH connections and sync operations
 type Manager struct {
-	ropcodeDir  string
-	connections []SshConnection
-	syncStates  map[string]*SyncState // keyed by localPath
-	mu          sync.RWMutex
+	ropcodeDir     string
+	userKnownHosts string
+	secrets        SecretStore
+	connections    []SshConnection
+	syncStates     map[string]*SyncState // keyed by localPath
+	mu             sync.RWMutex
+	rulesMu        sync.Mutex
+
+	progressMu sync.Mutex
+	progress   map[string]*syncTracker // keyed by localPath
+	emitter    SyncEmitter
+
+	pairsMu sync.Mutex
+	pairs   map[string]*twoWayPair // two-way sync pairs keyed by localPath
+	// openTree replaces the ssh-backed remote tree in tests
+	openTree func(conn *SshConnection, localPath, remotePath string) (remoteTree, error)
+	// openSftp replaces the ssh sftp subsystem in tests
+	openSftp func(conn *SshConnection) (io.ReadWriteCloser, error)
 }
 
 // NewManager creates a new SSH manager
@@ -45,9 +74,13 @@ func NewManager() *Manager {
 	ropcodeDir := filepath.Join(homeDir, ".ropcode")
 
 	m := &Manager{
-		ropcodeDir:  ropcodeDir,
-		connections: []SshConnection{},
-		syncStates:  make(map[string]*SyncState),
+		ropcodeDir:     ropcodeDir,
+		userKnownHosts: filepath.Join(homeDir, ".ssh", "known_hosts"),
+		secrets:        keychain.System,
+		connections:    []SshConnection{},
+		syncStates:     make(map[string]*SyncState),
+		progress:       make(map[string]*syncTracker),
+		pairs:          make(map[string]*twoWayPair),
 	}
 
 	// Load saved connections
@@ -98,11 +131,8 @@ func (m *Manager) ListGlobalConnections() ([]SshConnection, error) {
 	return m.connections, nil
 }
 
-// AddGlobalConnection adds a new SSH connection
-func (m *Manager) AddGlobalConnection(conn SshConnection) error {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-
+// validateConnection fills in defaults and checks a connection before saving
+func validateConnection(conn *SshConnection) error {
 	if conn.Name == "" || conn.Host == "" || conn.User == "" {
 		return fmt.Errorf("invalid connection: name, host, and user are required")
 	}
@@ -112,6 +142,39 @@ func (m *Manager) AddGlobalConnection(conn SshConnection) error {
 		conn.Port = 22
 	}
 
+	switch conn.HostKeyChecking {
+	case "", HostKeyStrict, HostKeyAcceptNew, HostKeyOff:
+	default:
+		return fmt.Errorf("invalid host key checking mode '%s'", conn.HostKeyChecking)
+	}
+	return validateAuth(conn)
+}
+
+// storePassword moves an inline password into the keychain
+func (m *Manager) storePassword(conn *SshConnection) error {
+	if conn.Password != "" {
+		if err := m.secrets.Set(passwordAccount(conn.Name), conn.Password); err != nil {
+			return err
+		}
+		conn.Password = ""
+		conn.HasPassword = true
+	}
+	if conn.AuthMethod == AuthPassword && !conn.HasPassword {
+		return fmt.Errorf("invalid connection: password authentication requires a password")
+	}
+	return nil
+}
+
+// AddGlobalConnection adds a new SSH connection
+func (m *Manager) AddGlobalConnection(conn SshConnection) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	conn.HasPassword = false
+	if err := validateConnection(&conn); err != nil {
+		return err
+	}
+
 	// Check for duplicates
 	for _, c := range m.connections {
 		if c.Name == conn.Name {
@@ -119,10 +182,60 @@ func (m *Manager) AddGlobalConnection(conn SshConnection) error {
 		}
 	}
 
+	if err := m.storePassword(&conn); err != nil {
+		return err
+	}
+
 	m.connections = append(m.connections, conn)
 	return m.saveConnections()
 }
 
+// UpdateGlobalConnection replaces the saved connection called name, keeping
+// its stored password unless a new one is given
+func (m *Manager) UpdateGlobalConnection(name string, conn SshConnection) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := validateConnection(&conn); err != nil {
+		return err
+	}
+
+	index := -1
+	for i, c := range m.connections {
+		if c.Name == name {
+			index = i
+		} else if c.Name == conn.Name {
+			return fmt.Errorf("connection with name '%s' already exists", conn.Name)
+		}
+	}
+	if index < 0 {
+		return fmt.Errorf("connection '%s' not found", name)
+	}
+
+	old := m.connections[index]
+	conn.HasPassword = old.HasPassword
+	if old.HasPassword && conn.Name != name && conn.Password == "" && conn.AuthMethod == AuthPassword {
+		// Carry the password over to the renamed connection
+		password, err := m.secrets.Get(passwordAccount(name))
+		if err != nil {
+			return err
+		}
+		conn.Password = password
+	}
+	if err := m.storePassword(&conn); err != nil {
+		return err
+	}
+	if old.HasPassword && (conn.Name != name || conn.AuthMethod != AuthPassword) {
+		if err := m.secrets.Delete(passwordAccount(name)); err != nil && !errors.Is(err, keychain.ErrNotFound) {
+			return err
+		}
+		conn.HasPassword = conn.Name != name && conn.AuthMethod == AuthPassword
+	}
+
+	m.connections[index] = conn
+	return m.saveConnections()
+}
+
 // DeleteGlobalConnection removes a saved SSH connection
 func (m *Manager) DeleteGlobalConnection(name string) error {
 	m.mu.Lock()
@@ -130,6 +243,11 @@ func (m *Manager) DeleteGlobalConnection(name string) error {
 
 	for i, c := range m.connections {
 		if c.Name == name {
+			if c.HasPassword {
+				if err := m.secrets.Delete(passwordAccount(name)); err != nil && !errors.Is(err, keychain.ErrNotFound) {
+					return err
+				}
+			}
 			m.connections = append(m.connections[:i], m.connections[i+1:]...)
 			return m.saveConnections()
 		}
@@ -150,19 +268,25 @@ func (m *Manager) getConnection(name string) (*SshConnection, error) {
 	return nil, fmt.Errorf("connection '%s' not found", name)
 }
 
-// buildRsyncArgs builds rsync command arguments for a sync operation
-func (m *Manager) buildRsyncArgs(conn *SshConnection, localPath, remotePath string, download bool) []string {
-	sshCmd := fmt.Sprintf("ssh -p %d", conn.Port)
-	if conn.KeyPath != "" {
-		sshCmd += fmt.Sprintf(" -i %s", conn.KeyPath)
+// rsyncShell returns the ssh command rsync should use for a connection
+func (m *Manager) rsyncShell(conn *SshConnection) string {
+	sshCmd := "ssh"
+	// rsync splits the -e command on whitespace but honours quotes
+	for _, option := range m.SSHOptions(conn) {
+		sshCmd += " '" + option + "'"
 	}
+	return sshCmd
+}
 
+// buildRsyncArgs builds rsync command arguments for a sync operation
+func (m *Manager) buildRsyncArgs(conn *SshConnection, rules *SyncRules, localPath, remotePath string, download bool) []string {
 	args := []string{
 		"-avz",
 		"--progress",
 		"--delete",
-		"-e", sshCmd,
+		"-e", m.rsyncShell(conn),
 	}
+	args = append(args, rules.filterArgs()...)
 
 	remote := fmt.Sprintf("%s@%s:%s", conn.User, conn.Host, remotePath)
 
@@ -182,15 +306,7 @@ func (m *Manager) SyncFromSSH(localPath, remotePath, connectionName string) erro
 		return err
 	}
 
-	args := m.buildRsyncArgs(conn, localPath, remotePath, true)
-	cmd := exec.Command("rsync", args...)
-
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("rsync failed: %v\n%s", err, string(output))
-	}
-
-	return nil
+	return m.runRsync(conn, localPath, remotePath, true)
 }
 
 // SyncToSSH uploads files from local to remote using rsync
@@ -200,19 +316,34 @@ func (m *Manager) SyncToSSH(localPath, remotePath, connectionName string) error
 		return err
 	}
 
-	args := m.buildRsyncArgs(conn, localPath, remotePath, false)
-	cmd := exec.Command("rsync", args...)
+	return m.runRsync(conn, localPath, remotePath, false)
+}
 
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("rsync failed: %v\n%s", err, string(output))
-	}
+// Auto-sync modes
+const (
+	// SyncModePush mirrors the local tree to the remote host
+	SyncModePush = "push"
+	// SyncModeTwoWay propagates changes both ways and reports conflicts
+	SyncModeTwoWay = "two-way"
+)
 
-	return nil
+// StartAutoSync starts automatically pushing local changes with rsync
+func (m *Manager) StartAutoSync(localPath, remotePath, connectionName string) error {
+	return m.startAutoSync(localPath, remotePath, connectionName, SyncModePush)
 }
 
-// StartAutoSync starts automatic bidirectional sync using fswatch + rsync
-func (m *Manager) StartAutoSync(localPath, remotePath, connectionName string) error {
+// StartTwoWaySync starts automatic two-way sync, which pulls remote changes
+// too and stops at files edited on both sides until they are resolved
+func (m *Manager) StartTwoWaySync(localPath, remotePath, connectionName string) error {
+	return m.startAutoSync(localPath, remotePath, connectionName, SyncModeTwoWay)
+}
+
+func (m *Manager) startAutoSync(localPath, remotePath, connectionName, mode string) error {
+	// Verify connection exists (before locking: getConnection takes the read lock)
+	if _, err := m.getConnection(connectionName); err != nil {
+		return err
+	}
+
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -221,16 +352,12 @@ func (m *Manager) StartAutoSync(localPath, remotePath, connectionName string) er
 		return fmt.Errorf("auto-sync already running for %s", localPath)
 	}
 
-	// Verify connection exists
-	if _, err := m.getConnection(connectionName); err != nil {
-		return err
-	}
-
 	// Create sync state
 	state := &SyncState{
 		LocalPath:    localPath,
 		RemotePath:   remotePath,
 		Connection:   connectionName,
+		Mode:         mode,
 		IsRunning:    true,
 		IsPaused:     false,
 		LastSyncTime: time.Now(),
@@ -259,8 +386,12 @@ func (m *Manager) runAutoSync(state *SyncState) {
 				continue
 			}
 
-			// Perform bidirectional sync
-			err := m.SyncToSSH(state.LocalPath, state.RemotePath, state.Connection)
+			var err error
+			if state.Mode == SyncModeTwoWay {
+				err = m.SyncTwoWay(state.LocalPath, state.RemotePath, state.Connection)
+			} else {
+				err = m.SyncToSSH(state.LocalPath, state.RemotePath, state.Connection)
+			}
 			if err != nil {
 				state.Error = err.Error()
 			} else {
@@ -326,6 +457,8 @@ type AutoSyncStatus struct {
 	ProjectPath  string `json:"project_path"`
 	IsRunning    bool   `json:"is_running"`
 	IsPaused     bool   `json:"is_paused"`
+	Mode         string `json:"mode,omitempty"`
+	Conflicts    int    `json:"conflicts"`
 	LastSyncTime int64  `json:"last_sync_time,omitempty"`
 	Error        string `json:"error,omitempty"`
 }
@@ -347,6 +480,8 @@ func (m *Manager) GetAutoSyncStatus(localPath string) (*AutoSyncStatus, error) {
 		ProjectPath:  localPath,
 		IsRunning:    state.IsRunning,
 		IsPaused:     state.IsPaused,
+		Mode:         state.Mode,
+		Conflicts:    len(m.ListSyncConflicts(localPath)),
 		LastSyncTime: state.LastSyncTime.Unix(),
 		Error:        state.Error,
 	}, nil
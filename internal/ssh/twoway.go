@@ -0,0 +1,532 @@
+package ssh
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FileState is the size and modification time of a synced file
+type FileState struct {
+	Size int64 `json:"size"`
+	// Mtime is in Unix seconds, the precision rsync preserves everywhere
+	Mtime int64 `json:"mtime"`
+}
+
+// Conflict resolutions
+const (
+	KeepLocal  = "keep_local"
+	KeepRemote = "keep_remote"
+	KeepBoth   = "keep_both"
+)
+
+// SyncConflict is a file changed on both sides since the last two-way sync.
+// A nil side means the file was deleted there.
+type SyncConflict struct {
+	Path       string     `json:"path"`
+	Local      *FileState `json:"local,omitempty"`
+	Remote     *FileState `json:"remote,omitempty"`
+	DetectedAt int64      `json:"detected_at"`
+}
+
+// SyncConflictsEvent carries the unresolved conflicts of a sync pair
+type SyncConflictsEvent struct {
+	LocalPath  string         `json:"local_path"`
+	RemotePath string         `json:"remote_path"`
+	Connection string         `json:"connection"`
+	Conflicts  []SyncConflict `json:"conflicts"`
+}
+
+// remoteTree is the remote side of a two-way sync. Paths are relative to
+// the synced directories and slash-separated.
+type remoteTree interface {
+	List() (map[string]FileState, error)
+	Hash(paths []string) (map[string]string, error)
+	Remove(paths []string) error
+	Push(paths []string) error
+	Pull(paths []string) error
+}
+
+// twoWayPair remembers what both sides of a sync pair agreed on after the
+// last sync, which tells edits on one side from edits on both
+type twoWayPair struct {
+	localPath  string
+	remotePath string
+	connection string
+	statePath  string
+
+	syncMu sync.Mutex // serializes syncs and resolutions
+	base   map[string]FileState
+
+	conflictsMu sync.Mutex
+	conflicts   map[string]SyncConflict
+}
+
+// pairState is the on-disk form of a twoWayPair
+type pairState struct {
+	LocalPath  string               `json:"local_path"`
+	RemotePath string               `json:"remote_path"`
+	Connection string               `json:"connection"`
+	Base       map[string]FileState `json:"base"`
+	Conflicts  []SyncConflict       `json:"conflicts"`
+}
+
+// twoWayPair returns the state of a sync pair, loading it from disk the
+// first time
+func (m *Manager) twoWayPair(localPath, remotePath, connection string) (*twoWayPair, error) {
+	m.pairsMu.Lock()
+	defer m.pairsMu.Unlock()
+
+	if pair, exists := m.pairs[localPath]; exists && pair.remotePath == remotePath && pair.connection == connection {
+		return pair, nil
+	}
+
+	sum := sha256.Sum256([]byte(localPath + "\x00" + remotePath + "\x00" + connection))
+	pair := &twoWayPair{
+		localPath:  localPath,
+		remotePath: remotePath,
+		connection: connection,
+		statePath:  filepath.Join(m.ropcodeDir, "ssh_sync_state", hex.EncodeToString(sum[:8])+".json"),
+		base:       make(map[string]FileState),
+		conflicts:  make(map[string]SyncConflict),
+	}
+	data, err := os.ReadFile(pair.statePath)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if err == nil {
+		var state pairState
+		if err := json.Unmarshal(data, &state); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", pair.statePath, err)
+		}
+		if state.Base != nil {
+			pair.base = state.Base
+		}
+		for _, c := range state.Conflicts {
+			pair.conflicts[c.Path] = c
+		}
+	}
+
+	if m.pairs == nil {
+		m.pairs = make(map[string]*twoWayPair)
+	}
+	m.pairs[localPath] = pair
+	return pair, nil
+}
+
+// SyncTwoWay runs one two-way sync of a pair: changes made on one side are
+// copied to the other, and files changed on both are held back as conflicts
+func (m *Manager) SyncTwoWay(localPath, remotePath, connectionName string) error {
+	conn, err := m.getConnection(connectionName)
+	if err != nil {
+		return err
+	}
+	rules, err := m.GetSyncRules(localPath, remotePath, connectionName)
+	if err != nil {
+		return err
+	}
+	tree, err := m.remoteTree(conn, localPath, remotePath)
+	if err != nil {
+		return err
+	}
+	pair, err := m.twoWayPair(localPath, remotePath, connectionName)
+	if err != nil {
+		return err
+	}
+
+	found, err := pair.reconcile(tree, rules)
+	if found {
+		m.emitConflicts(pair)
+	}
+	return err
+}
+
+// ListSyncConflicts returns the unresolved conflicts of a two-way synced path
+func (m *Manager) ListSyncConflicts(localPath string) []SyncConflict {
+	m.pairsMu.Lock()
+	pair, exists := m.pairs[localPath]
+	m.pairsMu.Unlock()
+	if !exists {
+		return []SyncConflict{}
+	}
+	return pair.conflictList()
+}
+
+// ResolveSyncConflict settles a conflict with KeepLocal, KeepRemote or
+// KeepBoth. KeepBoth moves the local file aside as a "(local ...)" copy,
+// takes the remote version and syncs the copy as a new file.
+func (m *Manager) ResolveSyncConflict(localPath, filePath, resolution string) error {
+	m.pairsMu.Lock()
+	pair, exists := m.pairs[localPath]
+	m.pairsMu.Unlock()
+	if !exists {
+		return fmt.Errorf("no two-way sync for %s", localPath)
+	}
+
+	conn, err := m.getConnection(pair.connection)
+	if err != nil {
+		return err
+	}
+	tree, err := m.remoteTree(conn, pair.localPath, pair.remotePath)
+	if err != nil {
+		return err
+	}
+
+	if err := pair.resolve(tree, filePath, resolution); err != nil {
+		return err
+	}
+	m.emitConflicts(pair)
+	return nil
+}
+
+func (m *Manager) emitConflicts(pair *twoWayPair) {
+	m.progressMu.Lock()
+	emitter := m.emitter
+	m.progressMu.Unlock()
+	if emitter == nil {
+		return
+	}
+	emitter.EmitSyncConflicts(SyncConflictsEvent{
+		LocalPath:  pair.localPath,
+		RemotePath: pair.remotePath,
+		Connection: pair.connection,
+		Conflicts:  pair.conflictList(),
+	})
+}
+
+func (p *twoWayPair) conflictList() []SyncConflict {
+	p.conflictsMu.Lock()
+	defer p.conflictsMu.Unlock()
+
+	list := make([]SyncConflict, 0, len(p.conflicts))
+	for _, c := range p.conflicts {
+		list = append(list, c)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Path < list[j].Path })
+	return list
+}
+
+func (p *twoWayPair) conflict(filePath string) (SyncConflict, bool) {
+	p.conflictsMu.Lock()
+	defer p.conflictsMu.Unlock()
+	c, ok := p.conflicts[filePath]
+	return c, ok
+}
+
+// reconcile syncs the pair once and reports whether new conflicts appeared
+func (p *twoWayPair) reconcile(tree remoteTree, rules *SyncRules) (bool, error) {
+	p.syncMu.Lock()
+	defer p.syncMu.Unlock()
+
+	matcher := newSyncMatcher(rules)
+	local, err := scanLocal(p.localPath, matcher)
+	if err != nil {
+		return false, err
+	}
+	remote, err := tree.List()
+	if err != nil {
+		return false, err
+	}
+	for rel := range remote {
+		if matcher.excludes(rel, false) {
+			delete(remote, rel)
+		}
+	}
+
+	paths := make(map[string]bool)
+	for _, files := range []map[string]FileState{local, remote, p.base} {
+		for rel := range files {
+			paths[rel] = true
+		}
+	}
+
+	var push, pull, removeRemote, removeLocal, compare []string
+	var found []SyncConflict
+	now := time.Now().UnixMilli()
+	for rel := range paths {
+		if _, conflicted := p.conflict(rel); conflicted {
+			continue
+		}
+		l, inLocal := local[rel]
+		r, inRemote := remote[rel]
+		b, inBase := p.base[rel]
+		localChanged := inLocal != inBase || (inLocal && l != b)
+		remoteChanged := inRemote != inBase || (inRemote && r != b)
+
+		switch {
+		case !localChanged && !remoteChanged:
+		case !remoteChanged && inLocal:
+			push = append(push, rel)
+		case !remoteChanged:
+			removeRemote = append(removeRemote, rel)
+		case !localChanged && inRemote:
+			pull = append(pull, rel)
+		case !localChanged:
+			removeLocal = append(removeLocal, rel)
+		case !inLocal && !inRemote:
+			delete(p.base, rel)
+		case inLocal && inRemote:
+			// Both sides wrote the file; identical content is no conflict
+			compare = append(compare, rel)
+		default:
+			found = append(found, newConflict(rel, local, remote, now))
+		}
+	}
+
+	if len(compare) > 0 {
+		hashes, err := tree.Hash(compare)
+		if err != nil {
+			return false, err
+		}
+		for _, rel := range compare {
+			if hash, err := hashFile(p.localFile(rel)); err == nil && hash == hashes[rel] {
+				p.base[rel] = local[rel]
+				continue
+			}
+			found = append(found, newConflict(rel, local, remote, now))
+		}
+	}
+
+	err = p.apply(tree, local, push, pull, removeRemote, removeLocal)
+	if len(found) > 0 {
+		p.conflictsMu.Lock()
+		for _, c := range found {
+			p.conflicts[c.Path] = c
+		}
+		p.conflictsMu.Unlock()
+	}
+	if saveErr := p.save(); err == nil {
+		err = saveErr
+	}
+	return len(found) > 0, err
+}
+
+func newConflict(rel string, local, remote map[string]FileState, now int64) SyncConflict {
+	c := SyncConflict{Path: rel, DetectedAt: now}
+	if l, ok := local[rel]; ok {
+		c.Local = &l
+	}
+	if r, ok := remote[rel]; ok {
+		c.Remote = &r
+	}
+	return c
+}
+
+// apply carries out the transfers and deletions of a sync, recording each
+// file both sides now agree on
+func (p *twoWayPair) apply(tree remoteTree, local map[string]FileState, push, pull, removeRemote, removeLocal []string) error {
+	if len(push) > 0 {
+		if err := tree.Push(push); err != nil {
+			return err
+		}
+		for _, rel := range push {
+			p.base[rel] = local[rel]
+		}
+	}
+	if len(pull) > 0 {
+		if err := tree.Pull(pull); err != nil {
+			return err
+		}
+		for _, rel := range pull {
+			p.recordLocal(rel)
+		}
+	}
+	if len(removeRemote) > 0 {
+		if err := tree.Remove(removeRemote); err != nil {
+			return err
+		}
+		for _, rel := range removeRemote {
+			delete(p.base, rel)
+		}
+	}
+	for _, rel := range removeLocal {
+		if err := os.Remove(p.localFile(rel)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		delete(p.base, rel)
+	}
+	return nil
+}
+
+// resolve settles one conflict
+func (p *twoWayPair) resolve(tree remoteTree, rel, resolution string) error {
+	p.syncMu.Lock()
+	defer p.syncMu.Unlock()
+
+	c, ok := p.conflict(rel)
+	if !ok {
+		return fmt.Errorf("no sync conflict for %s", rel)
+	}
+	_, statErr := os.Stat(p.localFile(rel))
+	localExists := statErr == nil
+	remoteExists := c.Remote != nil
+
+	var err error
+	switch resolution {
+	case KeepLocal:
+		if localExists {
+			err = p.pushFiles(tree, rel)
+		} else if err = tree.Remove([]string{rel}); err == nil {
+			delete(p.base, rel)
+		}
+	case KeepRemote:
+		if remoteExists {
+			err = p.pullFiles(tree, rel)
+		} else if err = os.Remove(p.localFile(rel)); err == nil || os.IsNotExist(err) {
+			err = nil
+			delete(p.base, rel)
+		}
+	case KeepBoth:
+		switch {
+		case localExists && remoteExists:
+			copyRel := conflictCopyPath(rel, time.Now())
+			if err = os.Rename(p.localFile(rel), p.localFile(copyRel)); err != nil {
+				break
+			}
+			if err = p.pullFiles(tree, rel); err == nil {
+				err = p.pushFiles(tree, copyRel)
+			}
+		case localExists:
+			err = p.pushFiles(tree, rel)
+		case remoteExists:
+			err = p.pullFiles(tree, rel)
+		default:
+			delete(p.base, rel)
+		}
+	default:
+		return fmt.Errorf("invalid conflict resolution '%s'", resolution)
+	}
+	if err != nil {
+		return err
+	}
+
+	p.conflictsMu.Lock()
+	delete(p.conflicts, rel)
+	p.conflictsMu.Unlock()
+	return p.save()
+}
+
+func (p *twoWayPair) pushFiles(tree remoteTree, paths ...string) error {
+	if err := tree.Push(paths); err != nil {
+		return err
+	}
+	for _, rel := range paths {
+		p.recordLocal(rel)
+	}
+	return nil
+}
+
+func (p *twoWayPair) pullFiles(tree remoteTree, paths ...string) error {
+	if err := tree.Pull(paths); err != nil {
+		return err
+	}
+	for _, rel := range paths {
+		p.recordLocal(rel)
+	}
+	return nil
+}
+
+// recordLocal records the local file as the state both sides agree on;
+// rsync copies modification times along with the content
+func (p *twoWayPair) recordLocal(rel string) {
+	if info, err := os.Stat(p.localFile(rel)); err == nil {
+		p.base[rel] = FileState{Size: info.Size(), Mtime: info.ModTime().Unix()}
+	}
+}
+
+func (p *twoWayPair) localFile(rel string) string {
+	return filepath.Join(p.localPath, filepath.FromSlash(rel))
+}
+
+// save writes the pair's state to disk
+func (p *twoWayPair) save() error {
+	state := pairState{
+		LocalPath:  p.localPath,
+		RemotePath: p.remotePath,
+		Connection: p.connection,
+		Base:       p.base,
+		Conflicts:  p.conflictList(),
+	}
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p.statePath), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(p.statePath, data, 0644)
+}
+
+// conflictCopyPath names the local copy KeepBoth sets aside, e.g.
+// "src/main (local 2024-05-01 093000).go"
+func conflictCopyPath(rel string, now time.Time) string {
+	ext := path.Ext(rel)
+	return strings.TrimSuffix(rel, ext) + " (local " + now.Format("2006-01-02 150405") + ")" + ext
+}
+
+// scanLocal lists the regular files of the local tree the rules keep
+func scanLocal(root string, matcher *syncMatcher) (map[string]FileState, error) {
+	files := make(map[string]FileState)
+	matcher.loadGitignore(root, "")
+	err := filepath.WalkDir(root, func(filePath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if filePath == root {
+			return nil
+		}
+		rel, err := filepath.Rel(root, filePath)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if d.IsDir() {
+			if matcher.excludes(rel, true) {
+				return filepath.SkipDir
+			}
+			matcher.loadGitignore(root, rel)
+			return nil
+		}
+		if !d.Type().IsRegular() || matcher.excludes(rel, false) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		files[rel] = FileState{Size: info.Size(), Mtime: info.ModTime().Unix()}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// hashFile returns the hex SHA-256 of a file
+func hashFile(filePath string) (string, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
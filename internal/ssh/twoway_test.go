@@ -0,0 +1,239 @@
+package ssh
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// dirTree is a remoteTree backed by a local directory
+type dirTree struct {
+	local, remote string
+}
+
+func (d *dirTree) List() (map[string]FileState, error) {
+	return scanLocal(d.remote, newSyncMatcher(&SyncRules{}))
+}
+
+func (d *dirTree) Hash(paths []string) (map[string]string, error) {
+	hashes := make(map[string]string)
+	for _, rel := range paths {
+		if hash, err := hashFile(filepath.Join(d.remote, rel)); err == nil {
+			hashes[rel] = hash
+		}
+	}
+	return hashes, nil
+}
+
+func (d *dirTree) Remove(paths []string) error {
+	for _, rel := range paths {
+		os.Remove(filepath.Join(d.remote, rel))
+	}
+	return nil
+}
+
+func (d *dirTree) Push(paths []string) error { return copyFiles(d.local, d.remote, paths) }
+func (d *dirTree) Pull(paths []string) error { return copyFiles(d.remote, d.local, paths) }
+
+func copyFiles(from, to string, paths []string) error {
+	for _, rel := range paths {
+		src := filepath.Join(from, rel)
+		data, err := os.ReadFile(src)
+		if err != nil {
+			return err
+		}
+		info, _ := os.Stat(src)
+		dst := filepath.Join(to, rel)
+		os.MkdirAll(filepath.Dir(dst), 0o755)
+		if err := os.WriteFile(dst, data, 0o644); err != nil {
+			return err
+		}
+		os.Chtimes(dst, info.ModTime(), info.ModTime())
+	}
+	return nil
+}
+
+// writeAt writes a file with a distinct modification time so edits within
+// the same second are still seen
+func writeAt(t *testing.T, root, rel, content string, age time.Duration) {
+	t.Helper()
+	file := filepath.Join(root, rel)
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	at := time.Now().Add(-age)
+	if err := os.Chtimes(file, at, at); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readFile(t *testing.T, root, rel string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(root, rel))
+	if err != nil {
+		return "<missing>"
+	}
+	return string(data)
+}
+
+func newTwoWayTest(t *testing.T) (*Manager, *recordingEmitter, string, string) {
+	m := newTestManager(t)
+	emitter := &recordingEmitter{}
+	m.SetEmitter(emitter)
+	local, remote := t.TempDir(), t.TempDir()
+	m.openTree = func(conn *SshConnection, localPath, remotePath string) (remoteTree, error) {
+		return &dirTree{local: localPath, remote: remote}, nil
+	}
+	if err := m.AddGlobalConnection(SshConnection{Name: "c", Host: "h", User: "u"}); err != nil {
+		t.Fatal(err)
+	}
+	return m, emitter, local, remote
+}
+
+func TestSyncTwoWayPropagatesChanges(t *testing.T) {
+	m, emitter, local, remote := newTwoWayTest(t)
+	writeAt(t, local, "a.txt", "local a", time.Hour)
+	writeAt(t, remote, "dir/b.txt", "remote b", time.Hour)
+	writeAt(t, local, "node_modules/x.js", "dep", time.Hour)
+	writeAt(t, local, ".gitignore", "*.log\n", time.Hour)
+	writeAt(t, local, "debug.log", "noise", time.Hour)
+
+	sync := func() {
+		t.Helper()
+		if err := m.SyncTwoWay(local, "/r", "c"); err != nil {
+			t.Fatalf("SyncTwoWay failed: %v", err)
+		}
+	}
+	sync()
+	if readFile(t, remote, "a.txt") != "local a" || readFile(t, local, "dir/b.txt") != "remote b" {
+		t.Fatal("Expected new files to be copied both ways")
+	}
+	if readFile(t, remote, "node_modules/x.js") != "<missing>" || readFile(t, remote, "debug.log") != "<missing>" {
+		t.Error("Expected excluded and gitignored files to stay local")
+	}
+
+	writeAt(t, remote, "a.txt", "remote edit", time.Minute)
+	os.Remove(filepath.Join(local, "dir", "b.txt"))
+	sync()
+	if readFile(t, local, "a.txt") != "remote edit" {
+		t.Error("Expected remote edit to be pulled")
+	}
+	if readFile(t, remote, "dir/b.txt") != "<missing>" {
+		t.Error("Expected local deletion to reach the remote")
+	}
+
+	if len(emitter.conflicts) != 0 || len(m.ListSyncConflicts(local)) != 0 {
+		t.Errorf("Expected no conflicts, got %+v", emitter.conflicts)
+	}
+}
+
+func TestSyncTwoWayConflicts(t *testing.T) {
+	m, emitter, local, remote := newTwoWayTest(t)
+	for _, name := range []string{"keep-local.txt", "keep-remote.txt", "keep-both.txt", "same.txt", "deleted.txt"} {
+		writeAt(t, local, name, "base", time.Hour)
+	}
+	if err := m.SyncTwoWay(local, "/r", "c"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"keep-local.txt", "keep-remote.txt", "keep-both.txt"} {
+		writeAt(t, local, name, "local edit", 2*time.Minute)
+		writeAt(t, remote, name, "remote edit", time.Minute)
+	}
+	writeAt(t, local, "same.txt", "same edit", 2*time.Minute)
+	writeAt(t, remote, "same.txt", "same edit", time.Minute)
+	writeAt(t, local, "deleted.txt", "local edit", time.Minute)
+	os.Remove(filepath.Join(remote, "deleted.txt"))
+
+	if err := m.SyncTwoWay(local, "/r", "c"); err != nil {
+		t.Fatal(err)
+	}
+	conflicts := m.ListSyncConflicts(local)
+	var paths []string
+	for _, c := range conflicts {
+		paths = append(paths, c.Path)
+	}
+	if strings.Join(paths, ",") != "deleted.txt,keep-both.txt,keep-local.txt,keep-remote.txt" {
+		t.Fatalf("Unexpected conflicts: %v", paths)
+	}
+	if conflicts[0].Remote != nil || conflicts[0].Local == nil {
+		t.Errorf("Expected deleted.txt to be missing remotely: %+v", conflicts[0])
+	}
+	if len(emitter.conflicts) != 1 || len(emitter.conflicts[0].Conflicts) != 4 {
+		t.Errorf("Expected one conflicts event, got %+v", emitter.conflicts)
+	}
+	if readFile(t, local, "keep-local.txt") != "local edit" || readFile(t, remote, "keep-local.txt") != "remote edit" {
+		t.Error("Conflicting files must not be overwritten")
+	}
+
+	// Conflicts stay put until resolved
+	if err := m.SyncTwoWay(local, "/r", "c"); err != nil || len(m.ListSyncConflicts(local)) != 4 {
+		t.Fatalf("Expected conflicts to persist: %v", err)
+	}
+
+	resolve := func(name, resolution string) {
+		t.Helper()
+		if err := m.ResolveSyncConflict(local, name, resolution); err != nil {
+			t.Fatalf("ResolveSyncConflict(%s, %s) failed: %v", name, resolution, err)
+		}
+	}
+	resolve("keep-local.txt", KeepLocal)
+	resolve("keep-remote.txt", KeepRemote)
+	resolve("keep-both.txt", KeepBoth)
+	resolve("deleted.txt", KeepRemote)
+	if err := m.ResolveSyncConflict(local, "keep-local.txt", KeepLocal); err == nil {
+		t.Error("Expected resolving twice to fail")
+	}
+
+	if readFile(t, remote, "keep-local.txt") != "local edit" || readFile(t, local, "keep-remote.txt") != "remote edit" {
+		t.Error("Expected KeepLocal / KeepRemote to copy the chosen side")
+	}
+	if readFile(t, local, "deleted.txt") != "<missing>" {
+		t.Error("Expected KeepRemote to apply the remote deletion")
+	}
+	if readFile(t, local, "keep-both.txt") != "remote edit" || readFile(t, remote, "keep-both.txt") != "remote edit" {
+		t.Error("Expected KeepBoth to take the remote version")
+	}
+	copies, _ := filepath.Glob(filepath.Join(remote, "keep-both (local *).txt"))
+	if len(copies) != 1 || readFile(t, remote, filepath.Base(copies[0])) != "local edit" {
+		t.Errorf("Expected KeepBoth to sync the local copy, got %v", copies)
+	}
+
+	if len(m.ListSyncConflicts(local)) != 0 {
+		t.Errorf("Expected all conflicts resolved")
+	}
+	if err := m.SyncTwoWay(local, "/r", "c"); err != nil || len(m.ListSyncConflicts(local)) != 0 {
+		t.Errorf("Expected a clean sync after resolving: %v", err)
+	}
+
+	// State survives a restart
+	m.pairs = make(map[string]*twoWayPair)
+	pair, err := m.twoWayPair(local, "/r", "c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var base []string
+	for rel := range pair.base {
+		base = append(base, rel)
+	}
+	sort.Strings(base)
+	if len(base) != 5 {
+		t.Errorf("Expected base to be reloaded, got %v", base)
+	}
+}
+
+func TestParseRemoteList(t *testing.T) {
+	files := parseRemoteList("12 1700000000.5 src/a b.go\n3 1700000001 ./c.txt\nbogus\n")
+	if files["src/a b.go"] != (FileState{Size: 12, Mtime: 1700000000}) || files["c.txt"].Size != 3 || len(files) != 2 {
+		t.Errorf("Unexpected listing: %+v", files)
+	}
+	if got := remoteDir("~/my 'proj'"); got != `"$HOME"/'my '\''proj'\'''` {
+		t.Errorf("Unexpected quoting: %s", got)
+	}
+}
@@ -0,0 +1,114 @@
+// Package sysprompt manages the system prompt ropcode adds to provider
+// sessions. The global template lives in the Claude directory and a
+// project's own template, which overrides it, in the project's .claude
+// directory. Templates may use {{branch}}, {{date}}, {{projectName}} and
+// {{projectPath}}, resolved when a session starts.
+package sysprompt
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"ropcode/internal/actionrun"
+)
+
+// FileName is the name of template files
+const FileName = "system-prompt.md"
+
+// Template scopes
+const (
+	ScopeGlobal  = "global"
+	ScopeProject = "project"
+)
+
+// Resolved is the prompt a session in a project gets
+type Resolved struct {
+	Scope     string            `json:"scope"` // scope of the template used; empty when there is none
+	Template  string            `json:"template"`
+	Prompt    string            `json:"prompt"`
+	Variables map[string]string `json:"variables"`
+}
+
+// Path returns the template file of scope
+func Path(claudeDir, scope, projectPath string) (string, error) {
+	switch scope {
+	case ScopeGlobal:
+		return filepath.Join(claudeDir, FileName), nil
+	case ScopeProject:
+		if projectPath == "" {
+			return "", fmt.Errorf("project system prompt needs a project")
+		}
+		return filepath.Join(projectPath, ".claude", FileName), nil
+	default:
+		return "", fmt.Errorf("unknown system prompt scope: %q", scope)
+	}
+}
+
+// Read returns the template of scope, empty when there is none
+func Read(claudeDir, scope, projectPath string) (string, error) {
+	path, err := Path(claudeDir, scope, projectPath)
+	if err != nil {
+		return "", err
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	return string(data), err
+}
+
+// Save writes the template of scope; a blank template removes the file
+func Save(claudeDir, scope, projectPath, content string) error {
+	path, err := Path(claudeDir, scope, projectPath)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(content) == "" {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(content), 0644)
+}
+
+// Vars returns the template variables of a session in projectPath
+// starting at now
+func Vars(projectPath string, now time.Time) map[string]string {
+	vars := actionrun.Vars(projectPath)
+	vars["date"] = now.Format("2006-01-02")
+	vars["projectName"] = ""
+	if projectPath != "" {
+		vars["projectName"] = filepath.Base(projectPath)
+	}
+	return vars
+}
+
+// Resolve returns the prompt of a session in projectPath starting at now:
+// the project's template when it has one and the global template otherwise
+func Resolve(claudeDir, projectPath string, now time.Time) (*Resolved, error) {
+	resolved := &Resolved{}
+	for _, scope := range []string{ScopeProject, ScopeGlobal} {
+		if scope == ScopeProject && projectPath == "" {
+			continue
+		}
+		template, err := Read(claudeDir, scope, projectPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s system prompt: %w", scope, err)
+		}
+		if strings.TrimSpace(template) != "" {
+			resolved.Scope = scope
+			resolved.Template = template
+			break
+		}
+	}
+	resolved.Variables = Vars(projectPath, now)
+	resolved.Prompt = strings.TrimSpace(actionrun.Expand(resolved.Template, resolved.Variables))
+	return resolved, nil
+}
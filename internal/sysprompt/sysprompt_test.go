@@ -0,0 +1,55 @@
+package sysprompt
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestResolvePrefersProjectTemplate(t *testing.T) {
+	claudeDir := t.TempDir()
+	project := filepath.Join(t.TempDir(), "shop")
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+
+	resolved, err := Resolve(claudeDir, project, now)
+	if err != nil || resolved.Scope != "" || resolved.Prompt != "" {
+		t.Fatalf("Resolve() without templates = %+v, %v", resolved, err)
+	}
+
+	if err := Save(claudeDir, ScopeGlobal, "", "Today is {{date}}.\n"); err != nil {
+		t.Fatal(err)
+	}
+	resolved, err = Resolve(claudeDir, project, now)
+	if err != nil || resolved.Scope != ScopeGlobal || resolved.Prompt != "Today is 2026-10-18." {
+		t.Fatalf("Resolve() with a global template = %+v, %v", resolved, err)
+	}
+
+	if err := Save(claudeDir, ScopeProject, project, "Work on {{projectName}} ({{branch}}), keep {{unknown}}"); err != nil {
+		t.Fatal(err)
+	}
+	resolved, err = Resolve(claudeDir, project, now)
+	if err != nil || resolved.Scope != ScopeProject || resolved.Prompt != "Work on shop (), keep {{unknown}}" {
+		t.Fatalf("Resolve() with a project template = %+v, %v", resolved, err)
+	}
+
+	// Saving a blank template removes it
+	if err := Save(claudeDir, ScopeProject, project, "  \n"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(project, ".claude", FileName)); !os.IsNotExist(err) {
+		t.Fatalf("project template still exists: %v", err)
+	}
+	if resolved, _ := Resolve(claudeDir, project, now); resolved.Scope != ScopeGlobal {
+		t.Fatalf("Resolve() after removing the project template = %+v", resolved)
+	}
+}
+
+func TestPathRejectsUnknownScope(t *testing.T) {
+	if _, err := Path(t.TempDir(), "workspace", "/p"); err == nil {
+		t.Error("expected an unknown scope to be rejected")
+	}
+	if _, err := Path(t.TempDir(), ScopeProject, ""); err == nil {
+		t.Error("expected a project scope without a project to be rejected")
+	}
+}
@@ -0,0 +1,235 @@
+// Package tempimages manages the images pasted or dropped into prompts,
+// which are saved under ~/.ropcode/temp-images so the provider CLIs can read
+// them. Images still referenced by session history are kept for the
+// retention period; images no prompt ever used are removed after a grace
+// period, and a size cap removes the oldest images first.
+package tempimages
+
+import (
+	"bytes"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// Grace keeps new unreferenced images, which may belong to a prompt that
+// hasn't been sent yet
+const Grace = 24 * time.Hour
+
+// marker precedes an image's name wherever a session refers to it
+var marker = []byte("temp-images")
+
+// Image is one saved image
+type Image struct {
+	Path       string    `json:"path"`
+	Name       string    `json:"name"`
+	Size       int64     `json:"size"`
+	ModTime    time.Time `json:"mod_time"`
+	Referenced bool      `json:"referenced"`
+}
+
+// Stats summarizes the images directory
+type Stats struct {
+	Dir             string `json:"dir"`
+	Count           int    `json:"count"`
+	Bytes           int64  `json:"bytes"`
+	Referenced      int    `json:"referenced"`
+	ReferencedBytes int64  `json:"referenced_bytes"`
+}
+
+// Policy says which images a cleanup removes
+type Policy struct {
+	// MaxAge removes images older than this even when referenced; 0 keeps
+	// referenced images forever
+	MaxAge time.Duration
+	// MaxBytes removes the oldest images until the rest fit; 0 means no cap
+	MaxBytes int64
+}
+
+// CleanupResult reports what a cleanup removed
+type CleanupResult struct {
+	Removed    int    `json:"removed"`
+	FreedBytes int64  `json:"freed_bytes"`
+	Stats      *Stats `json:"stats"`
+}
+
+// List returns the images in dir, oldest first. A missing dir has none.
+func List(dir string) ([]Image, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	images := make([]Image, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		images = append(images, Image{
+			Path:    filepath.Join(dir, entry.Name()),
+			Name:    entry.Name(),
+			Size:    info.Size(),
+			ModTime: info.ModTime(),
+		})
+	}
+	sort.Slice(images, func(i, j int) bool { return images[i].ModTime.Before(images[j].ModTime) })
+	return images, nil
+}
+
+// MarkReferenced sets Referenced on the images mentioned by a session
+// history file under one of the roots. Files last written before the
+// oldest image can't mention any and are skipped.
+func MarkReferenced(images []Image, roots []string) {
+	if len(images) == 0 {
+		return
+	}
+	byName := make(map[string]*Image, len(images))
+	oldest := images[0].ModTime
+	for i := range images {
+		byName[images[i].Name] = &images[i]
+		if images[i].ModTime.Before(oldest) {
+			oldest = images[i].ModTime
+		}
+	}
+	for _, root := range roots {
+		filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return nil
+			}
+			if ext := filepath.Ext(path); ext != ".jsonl" && ext != ".json" {
+				return nil
+			}
+			if info, err := d.Info(); err != nil || info.ModTime().Before(oldest) {
+				return nil
+			}
+			scanFile(path, byName)
+			return nil
+		})
+	}
+}
+
+// scanFile marks the images named after a "temp-images" path in a file. The
+// file is read in chunks since history lines can hold whole base64 images.
+func scanFile(path string, byName map[string]*Image) {
+	f, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	const chunkSize = 1 << 20
+	// overlap keeps a marker and name split across two chunks whole
+	const overlap = 512
+	buf := make([]byte, 0, chunkSize+overlap)
+	chunk := make([]byte, chunkSize)
+	for {
+		n, err := f.Read(chunk)
+		buf = append(buf, chunk[:n]...)
+		final := err != nil
+		// Leave the tail for the next chunk unless this is the last one
+		limit := len(buf)
+		if !final {
+			limit = max(0, len(buf)-overlap)
+		}
+		for i := 0; ; {
+			idx := bytes.Index(buf[i:], marker)
+			if idx < 0 || i+idx >= limit {
+				break
+			}
+			i += idx + len(marker)
+			if name := nameAfter(buf[i:]); name != "" {
+				if image := byName[name]; image != nil {
+					image.Referenced = true
+				}
+			}
+		}
+		if final {
+			return
+		}
+		buf = append(buf[:0], buf[limit:]...)
+	}
+}
+
+// nameAfter reads the file name that follows "temp-images" and its path
+// separators, which JSON may have escaped
+func nameAfter(data []byte) string {
+	i := 0
+	for i < len(data) && (data[i] == '/' || data[i] == '\\') {
+		i++
+	}
+	if i == 0 {
+		return ""
+	}
+	start := i
+	for i < len(data) {
+		switch data[i] {
+		case '"', '\'', '\\', '/', ' ', '\t', '\r', '\n', ')', ']', '>', ',', '`':
+			return string(data[start:i])
+		}
+		i++
+	}
+	return string(data[start:i])
+}
+
+// Summarize counts images and their sizes
+func Summarize(dir string, images []Image) *Stats {
+	stats := &Stats{Dir: dir}
+	for _, image := range images {
+		stats.Count++
+		stats.Bytes += image.Size
+		if image.Referenced {
+			stats.Referenced++
+			stats.ReferencedBytes += image.Size
+		}
+	}
+	return stats
+}
+
+// Cleanup removes the images policy doesn't keep. images must be oldest
+// first with references marked, as from List and MarkReferenced.
+func Cleanup(dir string, images []Image, policy Policy, now time.Time) *CleanupResult {
+	result := &CleanupResult{}
+	remove := func(image Image) bool {
+		if err := os.Remove(image.Path); err != nil && !os.IsNotExist(err) {
+			return false
+		}
+		result.Removed++
+		result.FreedBytes += image.Size
+		return true
+	}
+
+	var kept []Image
+	var total int64
+	for _, image := range images {
+		age := now.Sub(image.ModTime)
+		expired := age > Grace && (!image.Referenced || (policy.MaxAge > 0 && age > policy.MaxAge))
+		if expired && remove(image) {
+			continue
+		}
+		kept = append(kept, image)
+		total += image.Size
+	}
+
+	if policy.MaxBytes > 0 {
+		remaining := kept[:0]
+		for _, image := range kept {
+			if total > policy.MaxBytes && now.Sub(image.ModTime) > Grace && remove(image) {
+				total -= image.Size
+				continue
+			}
+			remaining = append(remaining, image)
+		}
+		kept = remaining
+	}
+
+	result.Stats = Summarize(dir, kept)
+	return result
+}
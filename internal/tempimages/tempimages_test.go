@@ -0,0 +1,75 @@
+package tempimages
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeImage(t *testing.T, dir, name string, size int, age time.Duration) {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, make([]byte, size), 0644); err != nil {
+		t.Fatal(err)
+	}
+	modTime := time.Now().Add(-age)
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMarkReferenced(t *testing.T) {
+	dir := t.TempDir()
+	writeImage(t, dir, "a.png", 10, time.Hour)
+	writeImage(t, dir, "b.png", 10, time.Hour)
+	writeImage(t, dir, "c.png", 10, time.Hour)
+
+	history := t.TempDir()
+	// A reference past the first chunk, with JSON-escaped Windows separators
+	padding := strings.Repeat("x", 1<<20-5)
+	line := `{"text":"look at C:\\Users\\me\\.ropcode\\temp-images\\b.png"}` + "\n"
+	os.WriteFile(filepath.Join(history, "s1.jsonl"), []byte(`{"text":"~/.ropcode/temp-images/a.png please"}`+"\n"+padding+line), 0644)
+	os.WriteFile(filepath.Join(history, "notes.txt"), []byte("temp-images/c.png"), 0644)
+
+	images, err := List(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	MarkReferenced(images, []string{history, filepath.Join(history, "missing")})
+	for _, image := range images {
+		if want := image.Name != "c.png"; image.Referenced != want {
+			t.Errorf("%s referenced = %v, want %v", image.Name, image.Referenced, want)
+		}
+	}
+}
+
+func TestCleanup(t *testing.T) {
+	dir := t.TempDir()
+	writeImage(t, dir, "old-unused.png", 10, 48*time.Hour)
+	writeImage(t, dir, "new-unused.png", 10, time.Hour)
+	writeImage(t, dir, "old-used.png", 10, 60*24*time.Hour)
+	writeImage(t, dir, "used-1.png", 100, 10*24*time.Hour)
+	writeImage(t, dir, "used-2.png", 100, 5*24*time.Hour)
+
+	images, _ := List(dir)
+	for i := range images {
+		images[i].Referenced = strings.Contains(images[i].Name, "used-") && !strings.Contains(images[i].Name, "unused")
+	}
+	result := Cleanup(dir, images, Policy{MaxAge: 30 * 24 * time.Hour, MaxBytes: 150}, time.Now())
+
+	left, _ := List(dir)
+	var names []string
+	for _, image := range left {
+		names = append(names, image.Name)
+	}
+	// old-unused is past the grace period, old-used past the retention
+	// period, and used-1 is the oldest image over the size cap
+	if strings.Join(names, ",") != "used-2.png,new-unused.png" {
+		t.Errorf("images left = %v", names)
+	}
+	if result.Removed != 3 || result.FreedBytes != 120 || result.Stats.Count != 2 || result.Stats.Bytes != 110 {
+		t.Errorf("result = %+v, stats = %+v", result, result.Stats)
+	}
+}
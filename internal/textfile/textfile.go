@@ -0,0 +1,194 @@
+// Package textfile reads files for display and writes edits back. Reading
+// detects binary files and the text encoding (UTF-8, UTF-16 or Latin-1) and
+// stops at a byte limit, so a huge or binary file can't be turned into a
+// giant or corrupted string. Writing replaces a file atomically.
+package textfile
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+const (
+	// DefaultMaxBytes is used when no limit is given
+	DefaultMaxBytes = 5 << 20
+	// maxMaxBytes caps the limit a caller can ask for
+	maxMaxBytes = 64 << 20
+	// sniffLen is how much of the content is checked to guess its encoding
+	sniffLen = 8000
+)
+
+// Encodings reported in Content.Encoding
+const (
+	UTF8    = "utf-8"
+	UTF16LE = "utf-16le"
+	UTF16BE = "utf-16be"
+	Latin1  = "latin-1"
+)
+
+// Content is a decoded file
+type Content struct {
+	Content string `json:"content"`
+	// Encoding is empty for binary files
+	Encoding string `json:"encoding,omitempty"`
+	IsBinary bool   `json:"is_binary"`
+	// Truncated is set when only the first bytes of the file were read
+	Truncated bool `json:"truncated"`
+	// Size is the size of the whole file in bytes
+	Size int64 `json:"size"`
+}
+
+// Read reads up to maxBytes of path (DefaultMaxBytes if maxBytes <= 0) and
+// decodes it. Binary files are returned without content.
+func Read(path string, maxBytes int64) (*Content, error) {
+	if maxBytes <= 0 {
+		maxBytes = DefaultMaxBytes
+	}
+	maxBytes = min(maxBytes, maxMaxBytes)
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return nil, fmt.Errorf("%s is a directory", path)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(file, maxBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	truncated := int64(len(data)) > maxBytes
+	if truncated {
+		data = data[:maxBytes]
+	}
+
+	content := Decode(data, truncated)
+	content.Size = max(info.Size(), int64(len(data)))
+	return content, nil
+}
+
+// Decode guesses the encoding of data and decodes it. truncated says data is
+// the start of a longer file, so an incomplete character at the end is
+// dropped rather than decoded as garbage.
+func Decode(data []byte, truncated bool) *Content {
+	result := &Content{Truncated: truncated, Size: int64(len(data))}
+
+	// A byte order mark decides the encoding. A UTF-8 BOM is kept so the
+	// content is written back unchanged.
+	switch {
+	case bytes.HasPrefix(data, []byte{0xEF, 0xBB, 0xBF}):
+		result.Encoding = UTF8
+		result.Content = string(trimIncompleteUTF8(data, truncated))
+		return result
+	case bytes.HasPrefix(data, []byte{0xFF, 0xFE}):
+		result.Encoding = UTF16LE
+		result.Content = decodeUTF16(data[2:], binary.LittleEndian)
+		return result
+	case bytes.HasPrefix(data, []byte{0xFE, 0xFF}):
+		result.Encoding = UTF16BE
+		result.Content = decodeUTF16(data[2:], binary.BigEndian)
+		return result
+	}
+
+	if order, ok := guessUTF16(data); ok {
+		if order == binary.LittleEndian {
+			result.Encoding = UTF16LE
+		} else {
+			result.Encoding = UTF16BE
+		}
+		result.Content = decodeUTF16(data, order)
+		return result
+	}
+
+	if bytes.IndexByte(data[:min(len(data), sniffLen)], 0) >= 0 {
+		result.IsBinary = true
+		return result
+	}
+
+	if text := trimIncompleteUTF8(data, truncated); utf8.Valid(text) {
+		result.Encoding = UTF8
+		result.Content = string(text)
+		return result
+	}
+
+	// Not UTF-8: every byte is a character in Latin-1
+	var b strings.Builder
+	b.Grow(len(data) * 2)
+	for _, c := range data {
+		b.WriteRune(rune(c))
+	}
+	result.Encoding = Latin1
+	result.Content = b.String()
+	return result
+}
+
+// guessUTF16 recognizes UTF-16 text without a BOM by its pattern of NUL
+// bytes: mostly ASCII text has a NUL in every high byte and none in the low
+// bytes. It returns false when data doesn't look like UTF-16.
+func guessUTF16(data []byte) (binary.ByteOrder, bool) {
+	sample := data[:min(len(data), sniffLen)]
+	pairs := len(sample) / 2
+	if pairs < 2 {
+		return nil, false
+	}
+	var evenNUL, oddNUL int
+	for i := 0; i+1 < len(sample); i += 2 {
+		if sample[i] == 0 {
+			evenNUL++
+		}
+		if sample[i+1] == 0 {
+			oddNUL++
+		}
+	}
+	// Require most pairs to carry a NUL on one side and almost none on the
+	// other, which binary formats rarely do
+	switch {
+	case oddNUL*10 >= pairs*7 && evenNUL*20 <= pairs:
+		return binary.LittleEndian, true
+	case evenNUL*10 >= pairs*7 && oddNUL*20 <= pairs:
+		return binary.BigEndian, true
+	}
+	return nil, false
+}
+
+func decodeUTF16(data []byte, order binary.ByteOrder) string {
+	units := make([]uint16, len(data)/2)
+	for i := range units {
+		units[i] = order.Uint16(data[2*i:])
+	}
+	// A lone high surrogate at the end was cut off by the byte limit
+	if n := len(units); n > 0 && utf16.IsSurrogate(rune(units[n-1])) && units[n-1] < 0xDC00 {
+		units = units[:n-1]
+	}
+	return string(utf16.Decode(units))
+}
+
+// trimIncompleteUTF8 drops a UTF-8 sequence cut in half at the end of a
+// truncated read
+func trimIncompleteUTF8(data []byte, truncated bool) []byte {
+	if !truncated {
+		return data
+	}
+	for i := len(data) - 1; i >= 0 && i >= len(data)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(data[i]) {
+			if !utf8.FullRune(data[i:]) {
+				return data[:i]
+			}
+			break
+		}
+	}
+	return data
+}
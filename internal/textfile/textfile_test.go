@@ -0,0 +1,89 @@
+package textfile
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf16"
+)
+
+func utf16Bytes(s string, bigEndian bool) []byte {
+	var out []byte
+	for _, u := range utf16.Encode([]rune(s)) {
+		if bigEndian {
+			out = append(out, byte(u>>8), byte(u))
+		} else {
+			out = append(out, byte(u), byte(u>>8))
+		}
+	}
+	return out
+}
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     []byte
+		content  string
+		encoding string
+		binary   bool
+	}{
+		{"utf-8", []byte("héllo\n"), "héllo\n", UTF8, false},
+		{"utf-8 bom", []byte("\xEF\xBB\xBFhi"), "\uFEFFhi", UTF8, false},
+		{"utf-16le bom", append([]byte{0xFF, 0xFE}, utf16Bytes("héllo", false)...), "héllo", UTF16LE, false},
+		{"utf-16be bom", append([]byte{0xFE, 0xFF}, utf16Bytes("héllo", true)...), "héllo", UTF16BE, false},
+		{"utf-16le", utf16Bytes("plain text\r\n", false), "plain text\r\n", UTF16LE, false},
+		{"utf-16be", utf16Bytes("plain text\r\n", true), "plain text\r\n", UTF16BE, false},
+		{"latin-1", []byte("caf\xe9"), "café", Latin1, false},
+		{"binary", []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"), "", "", true},
+		{"empty", nil, "", UTF8, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Decode(tt.data, false)
+			if got.Content != tt.content || got.Encoding != tt.encoding || got.IsBinary != tt.binary {
+				t.Errorf("Decode() = %+v, want content %q, encoding %q, binary %v", got, tt.content, tt.encoding, tt.binary)
+			}
+		})
+	}
+}
+
+func TestDecodeTruncatedDropsPartialCharacter(t *testing.T) {
+	data := []byte("abé")
+	if got := Decode(data[:len(data)-1], true); got.Content != "ab" || got.Encoding != UTF8 {
+		t.Errorf("truncated utf-8 = %+v", got)
+	}
+
+	units := append([]byte{0xFF, 0xFE}, utf16Bytes("a😀", false)...)
+	if got := Decode(units[:len(units)-2], true); got.Content != "a" || got.Encoding != UTF16LE {
+		t.Errorf("truncated utf-16 = %+v", got)
+	}
+}
+
+func TestRead(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "big.txt")
+	if err := os.WriteFile(path, []byte(strings.Repeat("x", 100)), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := Read(path, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Content != strings.Repeat("x", 10) || !got.Truncated || got.Size != 100 {
+		t.Errorf("Read() with limit = %+v", got)
+	}
+
+	got, err = Read(path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Content) != 100 || got.Truncated {
+		t.Errorf("Read() with default limit = %+v", got)
+	}
+
+	if _, err := Read(dir, 0); err == nil {
+		t.Error("Read() accepted a directory")
+	}
+}
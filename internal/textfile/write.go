@@ -0,0 +1,90 @@
+package textfile
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// BackupSuffix is appended to a file's name for the copy Write keeps
+const BackupSuffix = ".bak"
+
+// defaultMode is the mode of files Write creates
+const defaultMode = 0644
+
+// Write replaces the content of path without ever leaving it half written:
+// data goes to a temp file in the same directory, which is synced and
+// renamed over path. An existing file keeps its permissions, and a symlink
+// keeps pointing at the file it names. With backup set the previous content
+// is kept in path + BackupSuffix.
+func Write(path string, data []byte, backup bool) error {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+
+	mode := fs.FileMode(defaultMode)
+	info, err := os.Stat(path)
+	switch {
+	case err == nil && info.IsDir():
+		return fmt.Errorf("%s is a directory", path)
+	case err == nil:
+		mode = info.Mode().Perm()
+	case !errors.Is(err, fs.ErrNotExist):
+		return err
+	}
+
+	if backup && info != nil {
+		if err := copyFile(path, path+BackupSuffix, mode); err != nil {
+			return fmt.Errorf("failed to back up %s: %w", path, err)
+		}
+	}
+
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(data)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), mode)
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	syncDir(dir)
+	return nil
+}
+
+// copyFile writes the content of src to dst, itself through Write so a
+// crash can't leave a broken backup behind
+func copyFile(src, dst string, mode fs.FileMode) error {
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	if err := Write(dst, data, false); err != nil {
+		return err
+	}
+	return os.Chmod(dst, mode)
+}
+
+// syncDir makes a rename durable. Not every platform can sync a directory,
+// so errors are ignored.
+func syncDir(dir string) {
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+}
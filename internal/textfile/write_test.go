@@ -0,0 +1,65 @@
+package textfile
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestWriteKeepsModeAndBacksUp(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "run.sh")
+	if err := os.WriteFile(path, []byte("old"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Write(path, []byte("new"), true); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "new" {
+		t.Errorf("content = %q", data)
+	}
+	if data, _ := os.ReadFile(path + BackupSuffix); string(data) != "old" {
+		t.Errorf("backup content = %q", data)
+	}
+	if runtime.GOOS != "windows" {
+		if info, _ := os.Stat(path); info.Mode().Perm() != 0755 {
+			t.Errorf("mode = %v, want 0755", info.Mode().Perm())
+		}
+	}
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 2 {
+		t.Errorf("temp file left behind: %v", entries)
+	}
+}
+
+func TestWriteNewFileAndSymlink(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "new.txt")
+	if err := Write(path, []byte("hello"), true); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path + BackupSuffix); !os.IsNotExist(err) {
+		t.Error("backup written for a new file")
+	}
+
+	link := filepath.Join(dir, "link.txt")
+	if err := os.Symlink(path, link); err != nil {
+		t.Skip("symlinks not supported:", err)
+	}
+	if err := Write(link, []byte("via link"), false); err != nil {
+		t.Fatal(err)
+	}
+	if info, _ := os.Lstat(link); info.Mode()&os.ModeSymlink == 0 {
+		t.Error("Write() replaced the symlink")
+	}
+	if data, _ := os.ReadFile(path); string(data) != "via link" {
+		t.Errorf("target content = %q", data)
+	}
+
+	if err := Write(dir, []byte("x"), false); err == nil {
+		t.Error("Write() accepted a directory")
+	}
+}
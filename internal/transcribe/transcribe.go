@@ -0,0 +1,257 @@
+// Package transcribe turns recorded audio into prompt text. Two engines are
+// supported: a local whisper.cpp binary, which keeps the audio on the
+// machine, and an OpenAI-compatible /v1/audio/transcriptions endpoint. Both
+// detect the spoken language unless one is given.
+package transcribe
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// AutoLanguage asks the engine to detect the spoken language
+const AutoLanguage = "auto"
+
+// ErrNoSpeech is returned when the recording holds nothing to transcribe
+var ErrNoSpeech = errors.New("no speech recognized")
+
+// Result is a transcribed recording
+type Result struct {
+	Text string `json:"text"`
+	// Language is the detected or requested language, as the engine names
+	// it: an ISO 639-1 code for whisper.cpp, an English name for most APIs
+	Language string `json:"language"`
+}
+
+// Transcriber transcribes the audio file at path. language is an ISO 639-1
+// code, or empty or AutoLanguage to detect it.
+type Transcriber interface {
+	Transcribe(ctx context.Context, path, language string) (*Result, error)
+}
+
+// Whisper runs a whisper.cpp command line binary (whisper-cli, or main in
+// older releases). whisper.cpp reads 16 kHz WAV files.
+type Whisper struct {
+	// Binary is the path or name of the executable
+	Binary string
+	// Model is the path of the ggml model file
+	Model string
+	// Threads is the number of threads to use; 0 leaves it to whisper.cpp
+	Threads int
+}
+
+// whisperOutput is the part of whisper.cpp's --output-json file we use
+type whisperOutput struct {
+	Result struct {
+		Language string `json:"language"`
+	} `json:"result"`
+	Transcription []struct {
+		Text string `json:"text"`
+	} `json:"transcription"`
+}
+
+// Transcribe runs whisper.cpp on path and reads the JSON file it writes
+func (w *Whisper) Transcribe(ctx context.Context, path, language string) (*Result, error) {
+	if w.Binary == "" {
+		return nil, fmt.Errorf("whisper.cpp binary not configured")
+	}
+	if w.Model == "" {
+		return nil, fmt.Errorf("whisper.cpp model not configured")
+	}
+	if _, err := os.Stat(w.Model); err != nil {
+		return nil, fmt.Errorf("whisper.cpp model: %w", err)
+	}
+
+	outDir, err := os.MkdirTemp("", "ropcode-whisper-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(outDir)
+	outBase := filepath.Join(outDir, "transcript")
+
+	cmd := exec.CommandContext(ctx, w.Binary, w.args(path, language, outBase)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("whisper.cpp failed: %w: %s", err, lastLine(stderr.String()))
+	}
+
+	data, err := os.ReadFile(outBase + ".json")
+	if err != nil {
+		return nil, fmt.Errorf("read whisper.cpp output: %w", err)
+	}
+	return parseWhisperOutput(data)
+}
+
+func (w *Whisper) args(path, language, outBase string) []string {
+	if language == "" {
+		language = AutoLanguage
+	}
+	args := []string{
+		"--model", w.Model,
+		"--file", path,
+		"--language", language,
+		"--output-json",
+		"--output-file", outBase,
+		"--no-prints",
+	}
+	if w.Threads > 0 {
+		args = append(args, "--threads", fmt.Sprint(w.Threads))
+	}
+	return args
+}
+
+func parseWhisperOutput(data []byte) (*Result, error) {
+	var output whisperOutput
+	if err := json.Unmarshal(data, &output); err != nil {
+		return nil, fmt.Errorf("parse whisper.cpp output: %w", err)
+	}
+	segments := make([]string, 0, len(output.Transcription))
+	for _, segment := range output.Transcription {
+		if text := strings.TrimSpace(segment.Text); text != "" && !isNonSpeech(text) {
+			segments = append(segments, text)
+		}
+	}
+	if len(segments) == 0 {
+		return nil, ErrNoSpeech
+	}
+	return &Result{Text: strings.Join(segments, " "), Language: output.Result.Language}, nil
+}
+
+// isNonSpeech reports whether a segment is one of the markers whisper
+// writes for silence or noise, like [BLANK_AUDIO] or (music)
+func isNonSpeech(text string) bool {
+	return (strings.HasPrefix(text, "[") && strings.HasSuffix(text, "]")) ||
+		(strings.HasPrefix(text, "(") && strings.HasSuffix(text, ")"))
+}
+
+// API calls an OpenAI-compatible transcription endpoint
+type API struct {
+	// BaseURL is the API root, with or without /v1
+	BaseURL string
+	APIKey  string
+	// Model is the transcription model, whisper-1 when empty
+	Model string
+	// Client sends the request; a client with a two minute timeout when nil
+	Client *http.Client
+}
+
+// DefaultAPIModel is the model used when none is configured
+const DefaultAPIModel = "whisper-1"
+
+// Endpoint returns the URL recordings are posted to
+func (a *API) Endpoint() string {
+	base := strings.TrimRight(strings.TrimSpace(a.BaseURL), "/")
+	switch {
+	case strings.HasSuffix(base, "/audio/transcriptions"):
+		return base
+	case strings.HasSuffix(base, "/v1"):
+		return base + "/audio/transcriptions"
+	default:
+		return base + "/v1/audio/transcriptions"
+	}
+}
+
+// Transcribe uploads path and returns the text the API recognized
+func (a *API) Transcribe(ctx context.Context, path, language string) (*Result, error) {
+	if strings.TrimSpace(a.BaseURL) == "" || strings.TrimSpace(a.APIKey) == "" {
+		return nil, fmt.Errorf("transcription API missing base URL or key")
+	}
+	body, contentType, err := a.requestBody(path, language)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.Endpoint(), body)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(a.APIKey))
+
+	client := a.Client
+	if client == nil {
+		client = &http.Client{Timeout: 2 * time.Minute}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("transcription request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("API returned %d: %.500s", resp.StatusCode, string(respBody))
+	}
+
+	var result Result
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return nil, fmt.Errorf("parse response: %w (body: %.200s)", err, string(respBody))
+	}
+	result.Text = strings.TrimSpace(result.Text)
+	if result.Text == "" {
+		return nil, ErrNoSpeech
+	}
+	if result.Language == "" && language != AutoLanguage {
+		result.Language = language
+	}
+	return &result, nil
+}
+
+// requestBody builds the multipart form. verbose_json is asked for because
+// only it reports the detected language.
+func (a *API) requestBody(path, language string) (io.Reader, string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, "", err
+	}
+	defer file.Close()
+
+	var buf bytes.Buffer
+	form := multipart.NewWriter(&buf)
+	part, err := form.CreateFormFile("file", filepath.Base(path))
+	if err != nil {
+		return nil, "", err
+	}
+	if _, err := io.Copy(part, file); err != nil {
+		return nil, "", fmt.Errorf("read recording: %w", err)
+	}
+	model := a.Model
+	if model == "" {
+		model = DefaultAPIModel
+	}
+	fields := map[string]string{"model": model, "response_format": "verbose_json"}
+	if language != "" && language != AutoLanguage {
+		fields["language"] = language
+	}
+	for name, value := range fields {
+		if err := form.WriteField(name, value); err != nil {
+			return nil, "", err
+		}
+	}
+	if err := form.Close(); err != nil {
+		return nil, "", err
+	}
+	return &buf, form.FormDataContentType(), nil
+}
+
+// lastLine returns the last non-empty line of a command's output, which
+// is where whisper.cpp puts its error
+func lastLine(output string) string {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
@@ -0,0 +1,110 @@
+package transcribe
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestParseWhisperOutput(t *testing.T) {
+	data := []byte(`{
+		"result": {"language": "de"},
+		"transcription": [
+			{"text": " Hallo Welt."},
+			{"text": " [BLANK_AUDIO]"},
+			{"text": " Wie geht's?"}
+		]
+	}`)
+	result, err := parseWhisperOutput(data)
+	if err != nil {
+		t.Fatalf("parseWhisperOutput: %v", err)
+	}
+	if result.Text != "Hallo Welt. Wie geht's?" || result.Language != "de" {
+		t.Errorf("result = %+v", result)
+	}
+
+	_, err = parseWhisperOutput([]byte(`{"result":{"language":"en"},"transcription":[{"text":" (silence)"}]}`))
+	if !errors.Is(err, ErrNoSpeech) {
+		t.Errorf("silence: err = %v, want ErrNoSpeech", err)
+	}
+}
+
+func TestWhisperArgs(t *testing.T) {
+	w := &Whisper{Binary: "whisper-cli", Model: "/models/ggml-base.bin", Threads: 4}
+	args := w.args("/tmp/a.wav", "", "/tmp/out/transcript")
+	want := []string{
+		"--model", "/models/ggml-base.bin",
+		"--file", "/tmp/a.wav",
+		"--language", "auto",
+		"--output-json",
+		"--output-file", "/tmp/out/transcript",
+		"--no-prints",
+		"--threads", "4",
+	}
+	if !slices.Equal(args, want) {
+		t.Errorf("args = %v\nwant %v", args, want)
+	}
+}
+
+func TestAPIEndpoint(t *testing.T) {
+	for base, want := range map[string]string{
+		"https://api.openai.com":                        "https://api.openai.com/v1/audio/transcriptions",
+		"https://api.openai.com/v1/":                    "https://api.openai.com/v1/audio/transcriptions",
+		"https://proxy.example/v1/audio/transcriptions": "https://proxy.example/v1/audio/transcriptions",
+	} {
+		if got := (&API{BaseURL: base}).Endpoint(); got != want {
+			t.Errorf("Endpoint(%q) = %q, want %q", base, got, want)
+		}
+	}
+}
+
+func TestAPITranscribe(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/audio/transcriptions" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer sk-test" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		audio, _ := io.ReadAll(file)
+		if string(audio) != "RIFF" || r.FormValue("model") != "whisper-1" ||
+			r.FormValue("response_format") != "verbose_json" || r.FormValue("language") != "" {
+			http.Error(w, "unexpected form", http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{"text":" fix the failing test ","language":"english","duration":2.5}`))
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "recording.wav")
+	if err := os.WriteFile(path, []byte("RIFF"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	api := &API{BaseURL: server.URL, APIKey: "sk-test"}
+	result, err := api.Transcribe(context.Background(), path, AutoLanguage)
+	if err != nil {
+		t.Fatalf("Transcribe: %v", err)
+	}
+	if result.Text != "fix the failing test" || result.Language != "english" {
+		t.Errorf("result = %+v", result)
+	}
+
+	api.APIKey = "wrong"
+	if _, err := api.Transcribe(context.Background(), path, AutoLanguage); err == nil {
+		t.Error("expected an error for a rejected key")
+	}
+}
@@ -0,0 +1,18 @@
+//go:build windows
+
+package trash
+
+import (
+	"errors"
+	"syscall"
+)
+
+// errorNotSameDevice is ERROR_NOT_SAME_DEVICE, returned when moving a file
+// to another volume
+const errorNotSameDevice = syscall.Errno(17)
+
+// crossDevice reports whether a rename failed because src and dst are on
+// different volumes
+func crossDevice(err error) bool {
+	return errors.Is(err, errorNotSameDevice)
+}
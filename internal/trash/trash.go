@@ -0,0 +1,231 @@
+// Package trash keeps the files the app would otherwise delete or
+// overwrite, such as the untracked files of a workspace cleanup, under
+// ~/.ropcode/trash so they can be restored. Each item is a directory named
+// by its ID holding item.json and the file or directory itself as data.
+// Items older than the retention period are purged.
+package trash
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"ropcode/internal/fileops"
+)
+
+const (
+	infoFile = "item.json"
+	dataName = "data"
+)
+
+// Item is one trashed file or directory
+type Item struct {
+	ID string `json:"id"`
+	// Path is where the item was trashed from and is restored to
+	Path      string    `json:"path"`
+	Dir       bool      `json:"dir"`
+	Size      int64     `json:"size"`
+	Reason    string    `json:"reason"`
+	TrashedAt time.Time `json:"trashed_at"`
+}
+
+// Move moves path into the trash in dir
+func Move(dir, path, reason string) (*Item, error) {
+	return put(dir, path, reason, true)
+}
+
+// Copy puts a copy of path into the trash in dir, for a file about to be
+// overwritten in place
+func Copy(dir, path, reason string) (*Item, error) {
+	return put(dir, path, reason, false)
+}
+
+func put(dir, path, reason string, move bool) (*Item, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Lstat(path)
+	if err != nil {
+		return nil, err
+	}
+	id, err := newID()
+	if err != nil {
+		return nil, err
+	}
+	itemDir := filepath.Join(dir, id)
+	if err := os.MkdirAll(itemDir, 0o700); err != nil {
+		return nil, err
+	}
+	item := &Item{
+		ID:        id,
+		Path:      path,
+		Dir:       info.IsDir(),
+		Size:      diskUsage(path),
+		Reason:    reason,
+		TrashedAt: time.Now(),
+	}
+	data := filepath.Join(itemDir, dataName)
+	if move {
+		err = rename(path, data)
+	} else {
+		err = fileops.Copy(path, data)
+	}
+	if err == nil {
+		err = writeInfo(itemDir, item)
+	}
+	if err != nil {
+		os.RemoveAll(itemDir)
+		return nil, fmt.Errorf("failed to trash %s: %w", path, err)
+	}
+	return item, nil
+}
+
+// List returns the items in the trash in dir, newest first. A missing dir
+// has none.
+func List(dir string) ([]Item, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return []Item{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	items := []Item{}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		item, err := readInfo(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			continue
+		}
+		items = append(items, *item)
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].TrashedAt.After(items[j].TrashedAt) })
+	return items, nil
+}
+
+// Restore puts the item id back where it was trashed from and drops it
+// from the trash. A file replaces the one at its path; a directory is only
+// restored when nothing is in its place.
+func Restore(dir, id string) (*Item, error) {
+	if id == "" || filepath.Base(id) != id {
+		return nil, fmt.Errorf("invalid trash item: %q", id)
+	}
+	itemDir := filepath.Join(dir, id)
+	item, err := readInfo(itemDir)
+	if err != nil {
+		return nil, fmt.Errorf("trash item %s not found", id)
+	}
+	if current, err := os.Lstat(item.Path); err == nil && (item.Dir || current.IsDir()) {
+		return nil, fmt.Errorf("%s already exists", item.Path)
+	}
+	if err := os.MkdirAll(filepath.Dir(item.Path), 0o755); err != nil {
+		return nil, err
+	}
+	if err := rename(filepath.Join(itemDir, dataName), item.Path); err != nil {
+		return nil, fmt.Errorf("failed to restore %s: %w", item.Path, err)
+	}
+	os.RemoveAll(itemDir)
+	return item, nil
+}
+
+// Purge removes the items trashed more than maxAge before now and returns
+// how many it removed; a zero maxAge keeps everything
+func Purge(dir string, maxAge time.Duration, now time.Time) (int, error) {
+	if maxAge <= 0 {
+		return 0, nil
+	}
+	items, err := List(dir)
+	if err != nil {
+		return 0, err
+	}
+	removed := 0
+	for _, item := range items {
+		if now.Sub(item.TrashedAt) <= maxAge {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(dir, item.ID)); err != nil {
+			return removed, err
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// newID returns a unique ID that sorts by the time it was made
+func newID() (string, error) {
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", err
+	}
+	return strconv.FormatInt(time.Now().UnixNano(), 10) + "-" + hex.EncodeToString(suffix), nil
+}
+
+func readInfo(itemDir string) (*Item, error) {
+	data, err := os.ReadFile(filepath.Join(itemDir, infoFile))
+	if err != nil {
+		return nil, err
+	}
+	var item Item
+	if err := json.Unmarshal(data, &item); err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+func writeInfo(itemDir string, item *Item) error {
+	data, err := json.MarshalIndent(item, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(itemDir, infoFile), data, 0o600)
+}
+
+// rename moves src to dst, copying when they are on different file systems.
+// The copy is made next to dst and renamed over it, so a failed copy leaves
+// dst as it was.
+func rename(src, dst string) error {
+	err := os.Rename(src, dst)
+	if err == nil || !crossDevice(err) {
+		return err
+	}
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return err
+	}
+	tmp := dst + ".trash-" + hex.EncodeToString(suffix)
+	if err := fileops.Copy(src, tmp); err != nil {
+		os.RemoveAll(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, dst); err != nil {
+		os.RemoveAll(tmp)
+		return err
+	}
+	return os.RemoveAll(src)
+}
+
+// diskUsage sums the sizes of the regular files under path
+func diskUsage(path string) int64 {
+	var size int64
+	filepath.WalkDir(path, func(_ string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if info, err := entry.Info(); err == nil && info.Mode().IsRegular() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size
+}
@@ -0,0 +1,106 @@
+package trash
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestMoveCopyAndRestore(t *testing.T) {
+	trashDir := filepath.Join(t.TempDir(), "trash")
+	work := t.TempDir()
+	os.MkdirAll(filepath.Join(work, "build", "out"), 0755)
+	os.WriteFile(filepath.Join(work, "build", "out", "a.txt"), []byte("artifact"), 0644)
+	os.WriteFile(filepath.Join(work, "main.go"), []byte("edited"), 0644)
+
+	dirItem, err := Move(trashDir, filepath.Join(work, "build"), "cleanup")
+	if err != nil {
+		t.Fatalf("Move() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(work, "build")); !os.IsNotExist(err) {
+		t.Fatalf("build still exists after Move(): %v", err)
+	}
+	if !dirItem.Dir || dirItem.Size != int64(len("artifact")) {
+		t.Fatalf("dir item = %+v", dirItem)
+	}
+	fileItem, err := Copy(trashDir, filepath.Join(work, "main.go"), "revert")
+	if err != nil {
+		t.Fatalf("Copy() error = %v", err)
+	}
+	os.WriteFile(filepath.Join(work, "main.go"), []byte("original"), 0644)
+
+	items, err := List(trashDir)
+	if err != nil || len(items) != 2 || items[0].ID != fileItem.ID {
+		t.Fatalf("List() = %+v, %v, want newest first", items, err)
+	}
+
+	// A file replaces the one at its path
+	if _, err := Restore(trashDir, fileItem.ID); err != nil {
+		t.Fatalf("Restore(file) error = %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(work, "main.go")); string(data) != "edited" {
+		t.Fatalf("main.go = %q after restore", data)
+	}
+
+	// A directory doesn't replace anything
+	os.Mkdir(filepath.Join(work, "build"), 0755)
+	if _, err := Restore(trashDir, dirItem.ID); err == nil {
+		t.Fatal("expected restoring over an existing directory to fail")
+	}
+	os.Remove(filepath.Join(work, "build"))
+	if _, err := Restore(trashDir, dirItem.ID); err != nil {
+		t.Fatalf("Restore(dir) error = %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(work, "build", "out", "a.txt")); string(data) != "artifact" {
+		t.Fatalf("a.txt = %q after restore", data)
+	}
+	if items, _ := List(trashDir); len(items) != 0 {
+		t.Fatalf("trash = %+v after restoring everything", items)
+	}
+	if _, err := Restore(trashDir, "../trash"); err == nil {
+		t.Fatal("expected an ID outside the trash to be rejected")
+	}
+}
+
+func TestPurge(t *testing.T) {
+	trashDir := t.TempDir()
+	work := t.TempDir()
+	for _, name := range []string{"a.txt", "b.txt"} {
+		os.WriteFile(filepath.Join(work, name), []byte(name), 0644)
+		if _, err := Move(trashDir, filepath.Join(work, name), "cleanup"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if removed, _ := Purge(trashDir, 0, time.Now().Add(48*time.Hour)); removed != 0 {
+		t.Fatalf("Purge() with no retention removed %d", removed)
+	}
+	removed, err := Purge(trashDir, 24*time.Hour, time.Now().Add(48*time.Hour))
+	if err != nil || removed != 2 {
+		t.Fatalf("Purge() = %d, %v, want 2", removed, err)
+	}
+	if removed, _ := Purge(trashDir, 24*time.Hour, time.Now()); removed != 0 {
+		t.Fatalf("Purge() of an empty trash removed %d", removed)
+	}
+}
+
+func TestRenameOnlyCopiesAcrossFileSystems(t *testing.T) {
+	work := t.TempDir()
+	src := filepath.Join(work, "a.txt")
+	dst := filepath.Join(work, "dir")
+	os.WriteFile(src, []byte("a"), 0644)
+	os.MkdirAll(filepath.Join(dst, "keep"), 0755)
+
+	// Renaming a file over a directory fails on the same file system and
+	// must leave both alone
+	if err := rename(src, dst); err == nil {
+		t.Fatal("rename() over a directory should fail")
+	}
+	if _, err := os.Stat(filepath.Join(dst, "keep")); err != nil {
+		t.Errorf("directory damaged by a failed rename: %v", err)
+	}
+	if _, err := os.Stat(src); err != nil {
+		t.Errorf("source lost by a failed rename: %v", err)
+	}
+}
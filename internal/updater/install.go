@@ -0,0 +1,65 @@
+package updater
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+)
+
+// Install hands a staged installer to the platform. An AppImage replaces
+// the running one in place and takes effect on restart (replaced is true);
+// a DMG is opened in Finder and the Windows installer is started, both of
+// which need the user to finish the installation.
+func Install(path string) (replaced bool, err error) {
+	if _, err := os.Stat(path); err != nil {
+		return false, fmt.Errorf("staged update not found: %w", err)
+	}
+	switch runtime.GOOS {
+	case "darwin":
+		return false, exec.Command("open", path).Start()
+	case "windows":
+		return false, exec.Command(path).Start()
+	case "linux":
+		target := os.Getenv("APPIMAGE")
+		if target == "" {
+			return false, fmt.Errorf("ropcode is not running from an AppImage; install %s manually", path)
+		}
+		if err := replaceFile(path, target); err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+	return false, fmt.Errorf("self-update is not supported on %s", runtime.GOOS)
+}
+
+// replaceFile copies src over dst through a sibling temp file so dst is
+// never left half written
+func replaceFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	tmp, err := os.CreateTemp(filepath.Dir(dst), ".ropcode-update-*")
+	if err != nil {
+		return fmt.Errorf("failed to replace %s: %w", dst, err)
+	}
+	_, err = io.Copy(tmp, in)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), 0755)
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), dst)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to replace %s: %w", dst, err)
+	}
+	return nil
+}
@@ -0,0 +1,274 @@
+// Package updater finds new ropcode releases, downloads the installer for
+// the running platform and stages it once its SHA-256 checksum matches.
+//
+// The feed is the GitHub releases API: either a single release ("latest")
+// or a list, of which the newest published non-prerelease is used. Every
+// installer asset is published with a "<asset>.sha256" sibling holding its
+// checksum in sha256sum format; an asset without one is never staged.
+package updater
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DefaultFeedURL is the latest release of ropcode on GitHub
+const DefaultFeedURL = "https://api.github.com/repos/RubinCarter/ropcode/releases/latest"
+
+// checksumSuffix names the checksum asset published next to each installer
+const checksumSuffix = ".sha256"
+
+// Asset is a file attached to a release
+type Asset struct {
+	Name string `json:"name"`
+	URL  string `json:"browser_download_url"`
+	Size int64  `json:"size"`
+}
+
+// Release is a published ropcode release
+type Release struct {
+	Tag         string  `json:"tag_name"`
+	Name        string  `json:"name"`
+	Notes       string  `json:"body"`
+	URL         string  `json:"html_url"`
+	PublishedAt string  `json:"published_at"`
+	Draft       bool    `json:"draft"`
+	Prerelease  bool    `json:"prerelease"`
+	Assets      []Asset `json:"assets"`
+}
+
+// Version parses the release tag
+func (r *Release) Version() (Version, error) {
+	return ParseVersion(r.Tag)
+}
+
+// Client reads a release feed and downloads release assets
+type Client struct {
+	feedURL    string
+	httpClient *http.Client
+}
+
+// NewClient creates a client for the feed at feedURL; empty means
+// DefaultFeedURL
+func NewClient(feedURL string) *Client {
+	feedURL = strings.TrimSpace(feedURL)
+	if feedURL == "" {
+		feedURL = DefaultFeedURL
+	}
+	// Downloads are bounded by the caller's context, not a client timeout
+	return &Client{feedURL: feedURL, httpClient: &http.Client{}}
+}
+
+// Latest returns the newest published release of the feed
+func (c *Client) Latest(ctx context.Context) (*Release, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	body, err := c.get(ctx, c.feedURL, "application/vnd.github+json")
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read release feed: %w", err)
+	}
+
+	var releases []*Release
+	if trimmed := strings.TrimSpace(string(data)); strings.HasPrefix(trimmed, "[") {
+		err = json.Unmarshal(data, &releases)
+	} else {
+		var release Release
+		err = json.Unmarshal(data, &release)
+		releases = []*Release{&release}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse release feed: %w", err)
+	}
+
+	var latest *Release
+	var latestVersion Version
+	for _, release := range releases {
+		if release.Draft || release.Prerelease {
+			continue
+		}
+		version, err := release.Version()
+		if err != nil {
+			continue
+		}
+		if latest == nil || version.Compare(latestVersion) > 0 {
+			latest, latestVersion = release, version
+		}
+	}
+	if latest == nil {
+		return nil, fmt.Errorf("release feed has no published release")
+	}
+	return latest, nil
+}
+
+// PlatformAsset returns the installer of a release for goos/goarch
+func PlatformAsset(release *Release, goos, goarch string) (*Asset, error) {
+	var candidates []*Asset
+	for i := range release.Assets {
+		asset := &release.Assets[i]
+		name := strings.ToLower(asset.Name)
+		switch goos {
+		case "darwin":
+			if strings.HasSuffix(name, ".dmg") {
+				candidates = append(candidates, asset)
+			}
+		case "windows":
+			if strings.HasSuffix(name, ".exe") {
+				candidates = append(candidates, asset)
+			}
+		case "linux":
+			if strings.HasSuffix(name, ".appimage") {
+				candidates = append(candidates, asset)
+			}
+		}
+	}
+	// electron-builder marks arm64 artifacts and may leave x64 unmarked
+	arch := "x64"
+	if goarch == "arm64" {
+		arch = "arm64"
+	}
+	var unmarked *Asset
+	for _, asset := range candidates {
+		name := strings.ToLower(asset.Name)
+		switch {
+		case strings.Contains(name, arch) || arch == "x64" && (strings.Contains(name, "x86_64") || strings.Contains(name, "amd64")):
+			return asset, nil
+		case !strings.Contains(name, "arm64") && !strings.Contains(name, "x64") && !strings.Contains(name, "x86_64") && !strings.Contains(name, "amd64"):
+			unmarked = asset
+		}
+	}
+	if unmarked != nil && arch == "x64" {
+		return unmarked, nil
+	}
+	return nil, fmt.Errorf("release %s has no installer for %s/%s", release.Tag, goos, goarch)
+}
+
+// checksumAsset returns the checksum published for asset
+func checksumAsset(release *Release, asset *Asset) (*Asset, error) {
+	for i := range release.Assets {
+		if release.Assets[i].Name == asset.Name+checksumSuffix {
+			return &release.Assets[i], nil
+		}
+	}
+	return nil, fmt.Errorf("release %s has no checksum for %s", release.Tag, asset.Name)
+}
+
+// Download fetches asset into dir and verifies it against the release's
+// checksum, returning the path of the verified file. progress, when set, is
+// called as bytes arrive with the total from the response (0 if unknown).
+func (c *Client) Download(ctx context.Context, release *Release, asset *Asset, dir string, progress func(done, total int64)) (string, error) {
+	sumAsset, err := checksumAsset(release, asset)
+	if err != nil {
+		return "", err
+	}
+	want, err := c.fetchChecksum(ctx, sumAsset)
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create update directory: %w", err)
+	}
+	body, err := c.get(ctx, asset.URL, "application/octet-stream")
+	if err != nil {
+		return "", err
+	}
+	defer body.Close()
+
+	path := filepath.Join(dir, filepath.Base(asset.Name))
+	partial := path + ".part"
+	file, err := os.Create(partial)
+	if err != nil {
+		return "", fmt.Errorf("failed to create %s: %w", partial, err)
+	}
+	hash := sha256.New()
+	_, err = io.Copy(io.MultiWriter(file, hash), &progressReader{r: body, total: asset.Size, progress: progress})
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(partial)
+		return "", fmt.Errorf("failed to download %s: %w", asset.Name, err)
+	}
+	if got := hex.EncodeToString(hash.Sum(nil)); got != want {
+		os.Remove(partial)
+		return "", fmt.Errorf("checksum mismatch for %s: got %s, want %s", asset.Name, got, want)
+	}
+	if err := os.Rename(partial, path); err != nil {
+		os.Remove(partial)
+		return "", fmt.Errorf("failed to stage %s: %w", asset.Name, err)
+	}
+	return path, nil
+}
+
+// fetchChecksum reads a sha256sum-format checksum file
+func (c *Client) fetchChecksum(ctx context.Context, asset *Asset) (string, error) {
+	body, err := c.get(ctx, asset.URL, "application/octet-stream")
+	if err != nil {
+		return "", err
+	}
+	defer body.Close()
+	data, err := io.ReadAll(io.LimitReader(body, 4096))
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", asset.Name, err)
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) == 0 {
+		return "", fmt.Errorf("%s is empty", asset.Name)
+	}
+	sum := strings.ToLower(fields[0])
+	if decoded, err := hex.DecodeString(sum); err != nil || len(decoded) != sha256.Size {
+		return "", fmt.Errorf("%s does not hold a SHA-256 checksum", asset.Name)
+	}
+	return sum, nil
+}
+
+func (c *Client) get(ctx context.Context, url, accept string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", accept)
+	req.Header.Set("User-Agent", "ropcode-updater")
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", url, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("failed to fetch %s: %s", url, resp.Status)
+	}
+	return resp.Body, nil
+}
+
+type progressReader struct {
+	r        io.Reader
+	done     int64
+	total    int64
+	progress func(done, total int64)
+	reported time.Time
+}
+
+// Read reports progress at most every 250ms, and once at the end
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	p.done += int64(n)
+	if p.progress != nil && (err == io.EOF || time.Since(p.reported) >= 250*time.Millisecond) {
+		p.reported = time.Now()
+		p.progress(p.done, p.total)
+	}
+	return n, err
+}
@@ -0,0 +1,167 @@
+package updater
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestVersionCompare(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.2.3", "1.2.3", 0},
+		{"v1.2.3", "1.2.3", 0},
+		{"1.2.4", "1.2.3", 1},
+		{"1.10.0", "1.9.9", 1},
+		{"2.0", "1.99.99", 1},
+		{"1.2.3-beta.1", "1.2.3", -1},
+		{"1.2.3-beta.2", "1.2.3-beta.10", -1},
+		{"1.2.3-rc.1", "1.2.3-beta.9", 1},
+		{"1.2.3-beta", "1.2.3-beta.1", -1},
+		{"1.2.3+build.7", "1.2.3", 0},
+	}
+	for _, tt := range tests {
+		a, err := ParseVersion(tt.a)
+		if err != nil {
+			t.Fatalf("ParseVersion(%q): %v", tt.a, err)
+		}
+		b, err := ParseVersion(tt.b)
+		if err != nil {
+			t.Fatalf("ParseVersion(%q): %v", tt.b, err)
+		}
+		if got := a.Compare(b); got != tt.want {
+			t.Errorf("%s vs %s = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+
+	for _, invalid := range []string{"dev", "", "1.2.3.4", "1.x"} {
+		if _, err := ParseVersion(invalid); err == nil {
+			t.Errorf("ParseVersion(%q) succeeded", invalid)
+		}
+	}
+}
+
+func TestPlatformAsset(t *testing.T) {
+	release := &Release{Tag: "v0.3.0", Assets: []Asset{
+		{Name: "Ropcode-0.3.0-arm64.dmg"},
+		{Name: "Ropcode-0.3.0.dmg"},
+		{Name: "Ropcode-0.3.0.dmg.blockmap"},
+		{Name: "Ropcode Setup 0.3.0.exe"},
+		{Name: "Ropcode-0.3.0.AppImage"},
+		{Name: "ropcode_0.3.0_amd64.deb"},
+	}}
+	tests := []struct {
+		goos, goarch, want string
+	}{
+		{"darwin", "arm64", "Ropcode-0.3.0-arm64.dmg"},
+		{"darwin", "amd64", "Ropcode-0.3.0.dmg"},
+		{"windows", "amd64", "Ropcode Setup 0.3.0.exe"},
+		{"linux", "amd64", "Ropcode-0.3.0.AppImage"},
+	}
+	for _, tt := range tests {
+		asset, err := PlatformAsset(release, tt.goos, tt.goarch)
+		if err != nil || asset.Name != tt.want {
+			t.Errorf("PlatformAsset(%s/%s) = %v, %v; want %s", tt.goos, tt.goarch, asset, err, tt.want)
+		}
+	}
+	if _, err := PlatformAsset(release, "linux", "arm64"); err == nil {
+		t.Error("expected no installer for linux/arm64")
+	}
+}
+
+func TestLatestSkipsDraftsAndPrereleases(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[
+			{"tag_name": "v0.5.0", "draft": true},
+			{"tag_name": "v0.4.0-beta.1", "prerelease": true},
+			{"tag_name": "v0.3.1", "name": "0.3.1"},
+			{"tag_name": "v0.3.0"}
+		]`)
+	}))
+	defer server.Close()
+
+	release, err := NewClient(server.URL).Latest(context.Background())
+	if err != nil || release.Tag != "v0.3.1" {
+		t.Fatalf("Latest() = %+v, %v", release, err)
+	}
+}
+
+func TestDownloadVerifiesChecksum(t *testing.T) {
+	installer := []byte("installer bytes")
+	sum := sha256.Sum256(installer)
+	checksum := hex.EncodeToString(sum[:]) + "  Ropcode-0.3.0.AppImage\n"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/app":
+			w.Write(installer)
+		case "/app.sha256":
+			fmt.Fprint(w, checksum)
+		case "/bad.sha256":
+			fmt.Fprint(w, strings.Repeat("0", 64))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	asset := Asset{Name: "Ropcode-0.3.0.AppImage", URL: server.URL + "/app", Size: int64(len(installer))}
+	client := NewClient(server.URL)
+
+	t.Run("match", func(t *testing.T) {
+		release := &Release{Tag: "v0.3.0", Assets: []Asset{asset, {Name: asset.Name + ".sha256", URL: server.URL + "/app.sha256"}}}
+		var reported int64
+		path, err := client.Download(context.Background(), release, &asset, t.TempDir(), func(done, total int64) { reported = done })
+		if err != nil {
+			t.Fatalf("Download: %v", err)
+		}
+		if data, _ := os.ReadFile(path); string(data) != string(installer) {
+			t.Fatalf("staged %q", data)
+		}
+		if reported != int64(len(installer)) {
+			t.Errorf("progress reported %d bytes", reported)
+		}
+	})
+
+	t.Run("mismatch", func(t *testing.T) {
+		dir := t.TempDir()
+		release := &Release{Tag: "v0.3.0", Assets: []Asset{asset, {Name: asset.Name + ".sha256", URL: server.URL + "/bad.sha256"}}}
+		if _, err := client.Download(context.Background(), release, &asset, dir, nil); err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+			t.Fatalf("Download error = %v", err)
+		}
+		if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+			t.Errorf("left %d files behind", len(entries))
+		}
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		release := &Release{Tag: "v0.3.0", Assets: []Asset{asset}}
+		if _, err := client.Download(context.Background(), release, &asset, t.TempDir(), nil); err == nil {
+			t.Fatal("expected an error without a checksum asset")
+		}
+	})
+}
+
+func TestReplaceFile(t *testing.T) {
+	dir := t.TempDir()
+	src, dst := filepath.Join(dir, "new.AppImage"), filepath.Join(dir, "Ropcode.AppImage")
+	os.WriteFile(src, []byte("new"), 0644)
+	os.WriteFile(dst, []byte("old"), 0755)
+	if err := replaceFile(src, dst); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(dst); string(data) != "new" {
+		t.Fatalf("dst = %q", data)
+	}
+	if info, _ := os.Stat(dst); info.Mode().Perm()&0100 == 0 {
+		t.Errorf("dst is not executable: %v", info.Mode())
+	}
+}
@@ -0,0 +1,98 @@
+package updater
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Version is a semantic version
+type Version struct {
+	Major, Minor, Patch int
+	// Pre is the pre-release, e.g. "beta.2"; empty for a release
+	Pre string
+}
+
+// ParseVersion parses "1.2.3", "v1.2.3" or "1.2.3-beta.1"; build metadata
+// after "+" is ignored and missing minor or patch numbers are zero
+func ParseVersion(s string) (Version, error) {
+	var v Version
+	rest := strings.TrimPrefix(strings.TrimSpace(s), "v")
+	rest, _, _ = strings.Cut(rest, "+")
+	rest, v.Pre, _ = strings.Cut(rest, "-")
+	parts := strings.Split(rest, ".")
+	if len(parts) > 3 {
+		return Version{}, fmt.Errorf("invalid version %q", s)
+	}
+	numbers := []*int{&v.Major, &v.Minor, &v.Patch}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return Version{}, fmt.Errorf("invalid version %q", s)
+		}
+		*numbers[i] = n
+	}
+	return v, nil
+}
+
+func (v Version) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if v.Pre != "" {
+		s += "-" + v.Pre
+	}
+	return s
+}
+
+// Compare returns -1, 0 or 1 as v is older than, equal to or newer than w.
+// A pre-release is older than its release.
+func (v Version) Compare(w Version) int {
+	for _, d := range []int{v.Major - w.Major, v.Minor - w.Minor, v.Patch - w.Patch} {
+		if d != 0 {
+			return sign(d)
+		}
+	}
+	switch {
+	case v.Pre == w.Pre:
+		return 0
+	case v.Pre == "":
+		return 1
+	case w.Pre == "":
+		return -1
+	}
+	return comparePre(v.Pre, w.Pre)
+}
+
+// comparePre orders pre-releases by their dot-separated identifiers:
+// numeric ones numerically and below alphanumeric ones, shorter first
+func comparePre(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		an, aErr := strconv.Atoi(as[i])
+		bn, bErr := strconv.Atoi(bs[i])
+		switch {
+		case aErr == nil && bErr == nil:
+			if an != bn {
+				return sign(an - bn)
+			}
+		case aErr == nil:
+			return -1
+		case bErr == nil:
+			return 1
+		default:
+			if c := strings.Compare(as[i], bs[i]); c != 0 {
+				return c
+			}
+		}
+	}
+	return sign(len(as) - len(bs))
+}
+
+func sign(n int) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,96 @@
+// internal/usage/cache.go
+package usage
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"ropcode/internal/database"
+)
+
+// cacheVersion is bumped whenever parsing changes so entries cached by older
+// versions are parsed again. Cached entries are unpriced and carry no
+// workspace, so price changes and moved worktrees apply without a rescan.
+const cacheVersion = 3
+
+// Cache persists the entries parsed from each JSONL file so that only files
+// whose size or modification time changed are read again
+type Cache interface {
+	ListUsageFileCache() ([]*database.UsageFileCache, error)
+	UpdateUsageFileCache(save []*database.UsageFileCache, remove []string) error
+}
+
+// NewCachedCollector creates a collector that keeps parsed log files in cache
+func NewCachedCollector(claudeDir string, cache Cache) *Collector {
+	return &Collector{
+		claudeDir: claudeDir,
+		cache:     cache,
+	}
+}
+
+// scanAllJSONLFilesCached is scanAllJSONLFiles reading unchanged files from
+// the cache. A cache that cannot be read or written only costs a rescan.
+func (c *Collector) scanAllJSONLFilesCached() ([]*UsageEntry, error) {
+	projectsDir := filepath.Join(c.claudeDir, "projects")
+
+	cached := make(map[string]*database.UsageFileCache)
+	if files, err := c.cache.ListUsageFileCache(); err == nil {
+		for _, file := range files {
+			cached[file.Path] = file
+		}
+	}
+
+	allEntries := make([]*UsageEntry, 0)
+	var changed []*database.UsageFileCache
+	seen := make(map[string]bool)
+
+	err := filepath.Walk(projectsDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil // Skip errors
+		}
+		if info.IsDir() || !strings.HasSuffix(path, ".jsonl") {
+			return nil
+		}
+		seen[path] = true
+
+		size, modTime := info.Size(), info.ModTime().UnixNano()
+		if file, ok := cached[path]; ok && file.Size == size && file.ModTime == modTime && file.Version == cacheVersion {
+			var entries []*UsageEntry
+			if json.Unmarshal([]byte(file.Entries), &entries) == nil {
+				allEntries = append(allEntries, entries...)
+				return nil
+			}
+		}
+
+		entries, err := c.scanJSONLFile(path)
+		if err != nil {
+			return nil
+		}
+		allEntries = append(allEntries, entries...)
+		if data, err := json.Marshal(entries); err == nil {
+			changed = append(changed, &database.UsageFileCache{
+				Path:    path,
+				Size:    size,
+				ModTime: modTime,
+				Version: cacheVersion,
+				Entries: string(data),
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var removed []string
+	for path := range cached {
+		if !seen[path] {
+			removed = append(removed, path)
+		}
+	}
+	_ = c.cache.UpdateUsageFileCache(changed, removed)
+
+	return allEntries, nil
+}
@@ -0,0 +1,94 @@
+package usage
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"ropcode/internal/database"
+)
+
+const testUsageLine = `{"sessionId":"s1","cwd":"/work/app","timestamp":"2026-01-02T03:04:05Z","message":{"model":"claude-sonnet-4","usage":{"input_tokens":10,"output_tokens":5}}}`
+
+func TestCachedCollector_RescansOnlyChangedFiles(t *testing.T) {
+	claudeDir := t.TempDir()
+	projectDir := filepath.Join(claudeDir, "projects", "app")
+	if err := os.MkdirAll(projectDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	logPath := filepath.Join(projectDir, "s1.jsonl")
+	otherPath := filepath.Join(projectDir, "s2.jsonl")
+	for _, path := range []string{logPath, otherPath} {
+		if err := os.WriteFile(path, []byte(testUsageLine+"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	db, err := database.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer db.Close()
+	collector := NewCachedCollector(claudeDir, db)
+
+	stats, err := collector.CollectStats()
+	if err != nil || stats.TotalInputTokens != 20 {
+		t.Fatalf("CollectStats = %+v, %v", stats, err)
+	}
+	files, _ := db.ListUsageFileCache()
+	if len(files) != 2 {
+		t.Fatalf("Expected both files cached, got %d", len(files))
+	}
+
+	// A cached file is not read again while its size and mtime hold
+	if err := db.UpdateUsageFileCache([]*database.UsageFileCache{{
+		Path: otherPath, Size: int64(len(testUsageLine) + 1), ModTime: statModTime(t, otherPath), Version: cacheVersion, Entries: "[]",
+	}}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if stats, _ := collector.CollectStats(); stats.TotalInputTokens != 10 {
+		t.Errorf("Expected the cached entries of an unchanged file, got %d input tokens", stats.TotalInputTokens)
+	}
+
+	// Appending to a file rescans it
+	f, err := os.OpenFile(logPath, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(testUsageLine + "\n")
+	f.Close()
+	if stats, _ := collector.CollectStats(); stats.TotalInputTokens != 20 {
+		t.Errorf("Expected the appended entry counted, got %d input tokens", stats.TotalInputTokens)
+	}
+
+	// Deleted files leave the cache
+	if err := os.Remove(otherPath); err != nil {
+		t.Fatal(err)
+	}
+	collector.CollectStats()
+	if files, _ := db.ListUsageFileCache(); len(files) != 1 || files[0].Path != logPath {
+		t.Errorf("Expected only the remaining file cached, got %+v", files)
+	}
+}
+
+func statModTime(t *testing.T, path string) int64 {
+	t.Helper()
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return info.ModTime().UnixNano()
+}
+
+func TestCachedCollector_NoProjectsDir(t *testing.T) {
+	db, err := database.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer db.Close()
+	stats, err := NewCachedCollector(t.TempDir(), db).CollectStatsByDateRange(time.Time{}, time.Now())
+	if err != nil || stats.TotalTokens != 0 {
+		t.Errorf("Expected empty stats, got %+v, %v", stats, err)
+	}
+}
@@ -0,0 +1,102 @@
+// internal/usage/pricing.go
+package usage
+
+import (
+	"strings"
+	"time"
+
+	"ropcode/internal/database"
+)
+
+// BuiltinPricing returns the prices shipped with this version, in USD per
+// million tokens. Later releases of a family get a longer pattern.
+func BuiltinPricing() []*database.ModelPricing {
+	price := func(pattern string, input, output, cacheWrite, cacheRead float64) *database.ModelPricing {
+		return &database.ModelPricing{
+			ModelPattern:    pattern,
+			InputPrice:      input,
+			OutputPrice:     output,
+			CacheWritePrice: cacheWrite,
+			CacheReadPrice:  cacheRead,
+			Source:          database.PricingSourceBuiltin,
+		}
+	}
+	return []*database.ModelPricing{
+		price("opus", 15.0, 75.0, 18.75, 1.50),
+		price("opus-4-5", 5.0, 25.0, 6.25, 0.50),
+		price("sonnet", 3.0, 15.0, 3.75, 0.30),
+		price("haiku", 0.80, 4.0, 1.0, 0.08),
+		price("3-haiku", 0.25, 1.25, 0.30, 0.03),
+		price("haiku-4-5", 1.0, 5.0, 1.25, 0.10),
+	}
+}
+
+// Pricing looks up model prices
+type Pricing struct {
+	prices []*database.ModelPricing
+}
+
+// NewPricing creates a lookup over pricing rows
+func NewPricing(prices []*database.ModelPricing) *Pricing {
+	return &Pricing{prices: prices}
+}
+
+// DefaultPricing returns the builtin prices
+func DefaultPricing() *Pricing {
+	return NewPricing(BuiltinPricing())
+}
+
+var pricingSourceRank = map[string]int{
+	database.PricingSourceBuiltin: 0,
+	database.PricingSourceRemote:  1,
+	database.PricingSourceUser:    2,
+}
+
+// Lookup returns the price of a model at a time, or nil for an unknown
+// model. The longest matching pattern wins, then the latest effective date
+// not after at, then user over remote over builtin prices. A zero at
+// ignores effective dates.
+func (p *Pricing) Lookup(model string, at time.Time) *database.ModelPricing {
+	model = strings.ToLower(model)
+	day := ""
+	if !at.IsZero() {
+		day = at.Format("2006-01-02")
+	}
+
+	var best *database.ModelPricing
+	for _, price := range p.prices {
+		pattern := strings.ToLower(price.ModelPattern)
+		if pattern == "" || !strings.Contains(model, pattern) {
+			continue
+		}
+		if day != "" && price.EffectiveDate > day {
+			continue
+		}
+		if best == nil || betterPrice(price, best) {
+			best = price
+		}
+	}
+	return best
+}
+
+func betterPrice(a, b *database.ModelPricing) bool {
+	if len(a.ModelPattern) != len(b.ModelPattern) {
+		return len(a.ModelPattern) > len(b.ModelPattern)
+	}
+	if a.EffectiveDate != b.EffectiveDate {
+		return a.EffectiveDate > b.EffectiveDate
+	}
+	return pricingSourceRank[a.Source] > pricingSourceRank[b.Source]
+}
+
+// Cost returns the USD cost of token usage; unknown models cost nothing
+func (p *Pricing) Cost(model string, at time.Time, inputTokens, outputTokens, cacheCreation, cacheRead int64) float64 {
+	price := p.Lookup(model, at)
+	if price == nil {
+		return 0
+	}
+	return (float64(inputTokens)*price.InputPrice +
+		float64(outputTokens)*price.OutputPrice +
+		float64(cacheCreation)*price.CacheWritePrice +
+		float64(cacheRead)*price.CacheReadPrice) / 1_000_000.0
+}
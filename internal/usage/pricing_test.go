@@ -0,0 +1,53 @@
+package usage
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"ropcode/internal/database"
+)
+
+func TestPricing_Lookup(t *testing.T) {
+	prices := append(BuiltinPricing(),
+		&database.ModelPricing{ModelPattern: "sonnet", InputPrice: 4, EffectiveDate: "2026-03-01", Source: database.PricingSourceRemote},
+		&database.ModelPricing{ModelPattern: "sonnet", InputPrice: 5, EffectiveDate: "2026-03-01", Source: database.PricingSourceUser},
+	)
+	pricing := NewPricing(prices)
+	march := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+	february := time.Date(2026, 2, 2, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		model string
+		at    time.Time
+		want  float64 // input price
+	}{
+		{"claude-opus-4-20250514", march, 15},
+		{"claude-opus-4-5-20251101", march, 5},
+		{"claude-3-haiku-20240307", march, 0.25},
+		{"claude-3-5-haiku-20241022", march, 0.80},
+		{"claude-haiku-4-5", march, 1},
+		{"claude-sonnet-4-5", february, 3},
+		{"claude-sonnet-4-5", march, 5},
+		{"Claude-Sonnet-4", time.Time{}, 5},
+	}
+	for _, tt := range tests {
+		price := pricing.Lookup(tt.model, tt.at)
+		if price == nil || price.InputPrice != tt.want {
+			t.Errorf("Lookup(%q, %s) = %+v, want input price %v", tt.model, tt.at.Format("2006-01-02"), price, tt.want)
+		}
+	}
+	if price := pricing.Lookup("gpt-5", march); price != nil {
+		t.Errorf("Expected no price for an unknown model, got %+v", price)
+	}
+}
+
+func TestPricing_Cost(t *testing.T) {
+	cost := DefaultPricing().Cost("claude-sonnet-4", time.Now(), 1_000_000, 1_000_000, 1_000_000, 1_000_000)
+	if want := 3 + 15 + 3.75 + 0.30; math.Abs(cost-want) > 1e-9 {
+		t.Errorf("Cost = %v, want %v", cost, want)
+	}
+	if cost := DefaultPricing().Cost("gpt-5", time.Now(), 1000, 1000, 0, 0); cost != 0 {
+		t.Errorf("Expected unknown models to cost nothing, got %v", cost)
+	}
+}
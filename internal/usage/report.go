@@ -0,0 +1,281 @@
+// internal/usage/report.go
+package usage
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Report groupings
+const (
+	GroupByDay       = "day"
+	GroupByMonth     = "month"
+	GroupByModel     = "model"
+	GroupByProject   = "project"
+	GroupByWorkspace = "workspace"
+	// GroupByBranch keys rows "<workspace>@<branch>"; usage outside git is
+	// left out
+	GroupByBranch = "branch"
+)
+
+// ReportRow is the usage of one group in a report
+type ReportRow struct {
+	Key                 string  `json:"key"`
+	InputTokens         int64   `json:"input_tokens"`
+	OutputTokens        int64   `json:"output_tokens"`
+	CacheCreationTokens int64   `json:"cache_creation_tokens"`
+	CacheReadTokens     int64   `json:"cache_read_tokens"`
+	TotalTokens         int64   `json:"total_tokens"`
+	CostUSD             float64 `json:"cost_usd"`
+	Sessions            int     `json:"sessions"`
+	Requests            int     `json:"requests"`
+
+	sessions map[string]bool
+}
+
+func (r *ReportRow) add(entry *UsageEntry) {
+	r.InputTokens += entry.InputTokens
+	r.OutputTokens += entry.OutputTokens
+	r.CacheCreationTokens += entry.CacheCreation
+	r.CacheReadTokens += entry.CacheRead
+	r.TotalTokens += entry.InputTokens + entry.OutputTokens + entry.CacheCreation + entry.CacheRead
+	r.CostUSD += entry.CostUSD
+	r.Requests++
+	if entry.SessionID != "" {
+		if r.sessions == nil {
+			r.sessions = make(map[string]bool)
+		}
+		if !r.sessions[entry.SessionID] {
+			r.sessions[entry.SessionID] = true
+			r.Sessions++
+		}
+	}
+}
+
+// Report is usage in a date range broken down by one grouping
+type Report struct {
+	StartDate string       `json:"start_date"`
+	EndDate   string       `json:"end_date"`
+	GroupBy   string       `json:"group_by"`
+	Rows      []*ReportRow `json:"rows"`
+	Total     *ReportRow   `json:"total"`
+}
+
+// reportKey returns the group an entry falls in, or "" to leave it out
+func reportKey(entry *UsageEntry, groupBy string) string {
+	switch groupBy {
+	case GroupByDay:
+		if entry.Timestamp.IsZero() {
+			return ""
+		}
+		return entry.Timestamp.Format("2006-01-02")
+	case GroupByMonth:
+		if entry.Timestamp.IsZero() {
+			return ""
+		}
+		return entry.Timestamp.Format("2006-01")
+	case GroupByModel:
+		return entry.Model
+	case GroupByProject:
+		if entry.ProjectPath == "" {
+			return "(unknown)"
+		}
+		return entry.ProjectPath
+	case GroupByWorkspace:
+		if entry.Workspace == "" {
+			return "(unknown)"
+		}
+		return entry.Workspace
+	case GroupByBranch:
+		if entry.GitBranch == "" {
+			return ""
+		}
+		workspace := entry.Workspace
+		if workspace == "" {
+			workspace = entry.ProjectPath
+		}
+		return workspace + "@" + entry.GitBranch
+	}
+	return ""
+}
+
+// groupEntries aggregates entries into rows. Time groupings are sorted
+// chronologically, the others by cost.
+func groupEntries(entries []*UsageEntry, groupBy string) ([]*ReportRow, *ReportRow) {
+	total := &ReportRow{Key: "total"}
+	rowMap := make(map[string]*ReportRow)
+	for _, entry := range entries {
+		total.add(entry)
+		key := reportKey(entry, groupBy)
+		if key == "" {
+			continue
+		}
+		row, ok := rowMap[key]
+		if !ok {
+			row = &ReportRow{Key: key}
+			rowMap[key] = row
+		}
+		row.add(entry)
+	}
+
+	rows := make([]*ReportRow, 0, len(rowMap))
+	for _, row := range rowMap {
+		rows = append(rows, row)
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if groupBy == GroupByDay || groupBy == GroupByMonth || rows[i].CostUSD == rows[j].CostUSD {
+			return rows[i].Key < rows[j].Key
+		}
+		return rows[i].CostUSD > rows[j].CostUSD
+	})
+	return rows, total
+}
+
+// filterByDate keeps the entries recorded between start and end inclusive
+func filterByDate(entries []*UsageEntry, startDate, endDate time.Time) []*UsageEntry {
+	var filtered []*UsageEntry
+	for _, entry := range entries {
+		if !entry.Timestamp.IsZero() &&
+			!entry.Timestamp.Before(startDate) &&
+			!entry.Timestamp.After(endDate) {
+			filtered = append(filtered, entry)
+		}
+	}
+	return filtered
+}
+
+// CollectReport collects usage between startDate and endDate inclusive,
+// grouped by day, month, model, project, workspace or branch
+func (c *Collector) CollectReport(startDate, endDate time.Time, groupBy string) (*Report, error) {
+	switch groupBy {
+	case GroupByDay, GroupByMonth, GroupByModel, GroupByProject, GroupByWorkspace, GroupByBranch:
+	default:
+		return nil, fmt.Errorf("invalid grouping %q, want day, month, model, project, workspace or branch", groupBy)
+	}
+	entries, err := c.scanAllJSONLFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, total := groupEntries(filterByDate(entries, startDate, endDate), groupBy)
+	return &Report{
+		StartDate: startDate.Format("2006-01-02"),
+		EndDate:   endDate.Format("2006-01-02"),
+		GroupBy:   groupBy,
+		Rows:      rows,
+		Total:     total,
+	}, nil
+}
+
+var reportCSVHeader = []string{"input_tokens", "output_tokens", "cache_creation_tokens", "cache_read_tokens", "total_tokens", "cost_usd", "sessions", "requests"}
+
+func (r *ReportRow) csvRecord(prefix ...string) []string {
+	return append(prefix,
+		strconv.FormatInt(r.InputTokens, 10),
+		strconv.FormatInt(r.OutputTokens, 10),
+		strconv.FormatInt(r.CacheCreationTokens, 10),
+		strconv.FormatInt(r.CacheReadTokens, 10),
+		strconv.FormatInt(r.TotalTokens, 10),
+		strconv.FormatFloat(r.CostUSD, 'f', 4, 64),
+		strconv.Itoa(r.Sessions),
+		strconv.Itoa(r.Requests),
+	)
+}
+
+// WriteCSV writes one line per group and a closing total line
+func (r *Report) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	writer.Write(append([]string{r.GroupBy}, reportCSVHeader...))
+	for _, row := range r.Rows {
+		writer.Write(row.csvRecord(row.Key))
+	}
+	writer.Write(r.Total.csvRecord("total"))
+	writer.Flush()
+	return writer.Error()
+}
+
+// MonthlySummary is one calendar month of usage with its breakdowns
+type MonthlySummary struct {
+	Month     string       `json:"month"` // YYYY-MM
+	Total     *ReportRow   `json:"total"`
+	ByModel   []*ReportRow `json:"by_model"`
+	ByProject []*ReportRow `json:"by_project"`
+	ByDay     []*ReportRow `json:"by_day"`
+	// ByWorkspace folds worktrees into their project, ByBranch splits it
+	ByWorkspace []*ReportRow `json:"by_workspace"`
+	ByBranch    []*ReportRow `json:"by_branch"`
+	// PreviousMonthCostUSD is the cost of the month before, for comparison
+	PreviousMonthCostUSD float64 `json:"previous_month_cost_usd"`
+}
+
+// CollectMonthlySummary summarizes the calendar month containing month (UTC)
+func (c *Collector) CollectMonthlySummary(month time.Time) (*MonthlySummary, error) {
+	entries, err := c.scanAllJSONLFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 1, 0).Add(-time.Nanosecond)
+	inMonth := filterByDate(entries, start, end)
+	previous := filterByDate(entries, start.AddDate(0, -1, 0), start.Add(-time.Nanosecond))
+
+	summary := &MonthlySummary{Month: start.Format("2006-01")}
+	summary.ByModel, summary.Total = groupEntries(inMonth, GroupByModel)
+	summary.ByProject, _ = groupEntries(inMonth, GroupByProject)
+	summary.ByDay, _ = groupEntries(inMonth, GroupByDay)
+	summary.ByWorkspace, _ = groupEntries(inMonth, GroupByWorkspace)
+	summary.ByBranch, _ = groupEntries(inMonth, GroupByBranch)
+	_, previousTotal := groupEntries(previous, GroupByModel)
+	summary.PreviousMonthCostUSD = previousTotal.CostUSD
+	return summary, nil
+}
+
+// WriteCSV writes the breakdowns as one table whose first column names the
+// section (model, project, day, workspace, branch or total)
+func (s *MonthlySummary) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	writer.Write(append([]string{"section", "key"}, reportCSVHeader...))
+	for _, section := range []struct {
+		name string
+		rows []*ReportRow
+	}{
+		{GroupByModel, s.ByModel},
+		{GroupByProject, s.ByProject},
+		{GroupByDay, s.ByDay},
+		{GroupByWorkspace, s.ByWorkspace},
+		{GroupByBranch, s.ByBranch},
+	} {
+		for _, row := range section.rows {
+			writer.Write(row.csvRecord(section.name, row.Key))
+		}
+	}
+	writer.Write(s.Total.csvRecord("total", s.Month))
+	writer.Flush()
+	return writer.Error()
+}
+
+// CollectCosts returns the cost of usage from each start up to end, reading
+// the logs once
+func (c *Collector) CollectCosts(end time.Time, starts ...time.Time) ([]float64, error) {
+	entries, err := c.scanAllJSONLFiles()
+	if err != nil {
+		return nil, err
+	}
+	costs := make([]float64, len(starts))
+	for _, entry := range entries {
+		if entry.Timestamp.IsZero() || entry.Timestamp.After(end) {
+			continue
+		}
+		for i, start := range starts {
+			if !entry.Timestamp.Before(start) {
+				costs[i] += entry.CostUSD
+			}
+		}
+	}
+	return costs, nil
+}
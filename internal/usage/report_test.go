@@ -0,0 +1,170 @@
+package usage
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeUsageLog(t *testing.T, claudeDir string, lines ...string) {
+	t.Helper()
+	dir := filepath.Join(claudeDir, "projects", "app")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "log.jsonl"), []byte(strings.Join(lines, "\n")+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func usageLine(session, cwd, timestamp, model string, inputTokens int) string {
+	return fmt.Sprintf(`{"sessionId":%q,"cwd":%q,"timestamp":%q,"message":{"model":%q,"usage":{"input_tokens":%d,"output_tokens":0}}}`,
+		session, cwd, timestamp, model, inputTokens)
+}
+
+func TestCollectReport(t *testing.T) {
+	claudeDir := t.TempDir()
+	writeUsageLog(t, claudeDir,
+		usageLine("s1", "/work/a", "2026-03-01T10:00:00Z", "claude-sonnet-4", 1_000_000),
+		usageLine("s1", "/work/a", "2026-03-01T11:00:00Z", "claude-sonnet-4", 1_000_000),
+		usageLine("s2", "/work/b", "2026-03-02T10:00:00Z", "claude-opus-4", 1_000_000),
+		usageLine("s3", "/work/b", "2026-04-01T10:00:00Z", "claude-opus-4", 1_000_000),
+	)
+	collector := NewCollector(claudeDir)
+	start := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2026, 3, 31, 23, 59, 59, 0, time.UTC)
+
+	report, err := collector.CollectReport(start, end, GroupByModel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Rows) != 2 || report.Rows[0].Key != "claude-opus-4" || report.Rows[0].CostUSD != 15 {
+		t.Fatalf("Expected models ordered by cost, got %+v", report.Rows)
+	}
+	if report.Total.Requests != 3 || report.Total.Sessions != 2 || report.Total.CostUSD != 21 {
+		t.Errorf("Unexpected total %+v", report.Total)
+	}
+
+	report, err = collector.CollectReport(start, end, GroupByDay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := report.WriteCSV(&buf); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 4 || !strings.HasPrefix(lines[0], "day,input_tokens") ||
+		!strings.HasPrefix(lines[1], "2026-03-01,2000000,") || !strings.HasPrefix(lines[3], "total,3000000,") {
+		t.Errorf("Unexpected CSV:\n%s", buf.String())
+	}
+
+	if _, err := collector.CollectReport(start, end, "week"); err == nil {
+		t.Error("Expected an unknown grouping to be rejected")
+	}
+}
+
+func TestCollectMonthlySummary(t *testing.T) {
+	claudeDir := t.TempDir()
+	writeUsageLog(t, claudeDir,
+		usageLine("s1", "/work/a", "2026-02-28T23:00:00Z", "claude-sonnet-4", 1_000_000),
+		usageLine("s2", "/work/a", "2026-03-01T00:00:00Z", "claude-sonnet-4", 1_000_000),
+		usageLine("s3", "/work/b", "2026-03-31T23:59:59Z", "claude-opus-4", 1_000_000),
+		usageLine("s4", "/work/b", "2026-04-01T00:00:00Z", "claude-opus-4", 1_000_000),
+	)
+
+	summary, err := NewCollector(claudeDir).CollectMonthlySummary(time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Month != "2026-03" || summary.Total.Requests != 2 || summary.Total.CostUSD != 18 {
+		t.Errorf("Unexpected total %+v for %s", summary.Total, summary.Month)
+	}
+	if len(summary.ByProject) != 2 || summary.ByProject[0].Key != "/work/b" || len(summary.ByDay) != 2 {
+		t.Errorf("Unexpected breakdowns: %+v %+v", summary.ByProject, summary.ByDay)
+	}
+	if summary.PreviousMonthCostUSD != 3 {
+		t.Errorf("PreviousMonthCostUSD = %v, want 3", summary.PreviousMonthCostUSD)
+	}
+
+	var buf bytes.Buffer
+	if err := summary.WriteCSV(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "project,/work/b,") || !strings.Contains(buf.String(), "total,2026-03,") {
+		t.Errorf("Unexpected CSV:\n%s", buf.String())
+	}
+}
+
+func TestCollectReportByWorkspaceAndBranch(t *testing.T) {
+	claudeDir := t.TempDir()
+	branchLine := func(session, cwd, branch string) string {
+		return fmt.Sprintf(`{"sessionId":%q,"cwd":%q,"gitBranch":%q,"timestamp":"2026-03-01T10:00:00Z","message":{"model":"claude-sonnet-4","usage":{"input_tokens":1000000,"output_tokens":0}}}`,
+			session, cwd, branch)
+	}
+	writeUsageLog(t, claudeDir,
+		branchLine("s1", "/work/app", "main"),
+		branchLine("s2", "/work/app-feature", "feature"),
+		usageLine("s3", "/work/app-feature", "2026-03-01T11:00:00Z", "claude-sonnet-4", 1_000_000),
+		usageLine("s4", "/tmp/scratch", "2026-03-01T12:00:00Z", "claude-sonnet-4", 1_000_000),
+	)
+	resolved := 0
+	collector := NewCollector(claudeDir).WithWorkspaceResolver(func(path string) (string, string) {
+		resolved++
+		switch path {
+		case "/work/app":
+			return "/work/app", "main"
+		case "/work/app-feature":
+			return "/work/app", "feature"
+		}
+		return "", ""
+	})
+	start := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(24 * time.Hour)
+
+	report, err := collector.CollectReport(start, end, GroupByWorkspace)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Rows) != 2 || report.Rows[0].Key != "/work/app" || report.Rows[0].Requests != 3 || report.Rows[1].Key != "/tmp/scratch" {
+		t.Errorf("Expected the worktree folded into its project, got %+v", report.Rows)
+	}
+	if resolved != 3 {
+		t.Errorf("Expected each directory resolved once, got %d calls", resolved)
+	}
+
+	report, err = collector.CollectReport(start, end, GroupByBranch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Rows) != 2 || report.Rows[0].Key != "/work/app@feature" || report.Rows[0].Requests != 2 ||
+		report.Rows[1].Key != "/work/app@main" || report.Total.Requests != 4 {
+		t.Errorf("Unexpected branch rows %+v", report.Rows)
+	}
+}
+
+func TestCollectCosts(t *testing.T) {
+	claudeDir := t.TempDir()
+	writeUsageLog(t, claudeDir,
+		usageLine("s1", "/work/a", "2026-03-01T10:00:00Z", "claude-sonnet-4", 1_000_000),
+		usageLine("s2", "/work/a", "2026-03-09T10:00:00Z", "claude-sonnet-4", 1_000_000),
+		usageLine("s3", "/work/a", "2026-03-10T10:00:00Z", "claude-opus-4", 1_000_000),
+		usageLine("s4", "/work/a", "2026-03-11T10:00:00Z", "claude-opus-4", 1_000_000),
+	)
+	end := time.Date(2026, 3, 10, 23, 0, 0, 0, time.UTC)
+	costs, err := NewCollector(claudeDir).CollectCosts(end,
+		time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC),
+		time.Date(2026, 3, 9, 0, 0, 0, 0, time.UTC),
+		time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(costs) != 3 || costs[0] != 15 || costs[1] != 18 || costs[2] != 21 {
+		t.Errorf("CollectCosts = %v, want [15 18 21]", costs)
+	}
+}
@@ -11,61 +11,6 @@ import (
 	"time"
 )
 
-// Claude 4 pricing constants (per million tokens)
-const (
-	// Opus 4
-	Opus4InputPrice      = 15.0
-	Opus4OutputPrice     = 75.0
-	Opus4CacheWritePrice = 18.75
-	Opus4CacheReadPrice  = 1.50
-
-	// Sonnet 4
-	Sonnet4InputPrice      = 3.0
-	Sonnet4OutputPrice     = 15.0
-	Sonnet4CacheWritePrice = 3.75
-	Sonnet4CacheReadPrice  = 0.30
-
-	// Haiku 4
-	Haiku4InputPrice      = 0.80
-	Haiku4OutputPrice     = 4.0
-	Haiku4CacheWritePrice = 1.0
-	Haiku4CacheReadPrice  = 0.08
-)
-
-// calculateCost calculates cost based on model and token usage
-func calculateCost(model string, inputTokens, outputTokens, cacheCreation, cacheRead int64) float64 {
-	var inputPrice, outputPrice, cacheWritePrice, cacheReadPrice float64
-
-	switch {
-	case strings.Contains(model, "opus-4") || strings.Contains(model, "claude-opus-4"):
-		inputPrice = Opus4InputPrice
-		outputPrice = Opus4OutputPrice
-		cacheWritePrice = Opus4CacheWritePrice
-		cacheReadPrice = Opus4CacheReadPrice
-	case strings.Contains(model, "sonnet-4") || strings.Contains(model, "claude-sonnet-4"):
-		inputPrice = Sonnet4InputPrice
-		outputPrice = Sonnet4OutputPrice
-		cacheWritePrice = Sonnet4CacheWritePrice
-		cacheReadPrice = Sonnet4CacheReadPrice
-	case strings.Contains(model, "haiku") || strings.Contains(model, "claude-haiku"):
-		inputPrice = Haiku4InputPrice
-		outputPrice = Haiku4OutputPrice
-		cacheWritePrice = Haiku4CacheWritePrice
-		cacheReadPrice = Haiku4CacheReadPrice
-	default:
-		// Return 0 for unknown models
-		return 0.0
-	}
-
-	// Calculate cost (prices are per million tokens)
-	cost := (float64(inputTokens) * inputPrice / 1_000_000.0) +
-		(float64(outputTokens) * outputPrice / 1_000_000.0) +
-		(float64(cacheCreation) * cacheWritePrice / 1_000_000.0) +
-		(float64(cacheRead) * cacheReadPrice / 1_000_000.0)
-
-	return cost
-}
-
 // UsageEntry represents a single usage record from Claude JSONL logs
 type UsageEntry struct {
 	Model         string    `json:"model"`
@@ -77,17 +22,24 @@ type UsageEntry struct {
 	SessionID     string    `json:"session_id"`
 	ProjectPath   string    `json:"project_path"`
 	CostUSD       float64   `json:"cost_usd"`
+	// GitBranch is the branch the log recorded, or the one checked out in
+	// ProjectPath when it recorded none
+	GitBranch string `json:"git_branch,omitempty"`
+	// Workspace is the project ProjectPath belongs to, the main checkout
+	// for a worktree
+	Workspace string `json:"workspace,omitempty"`
 }
 
 // ModelStats represents aggregated statistics for a specific model
 type ModelStats struct {
-	Model              string `json:"model"`
-	TotalTokens        int64  `json:"total_tokens"`
-	TotalInputTokens   int64  `json:"total_input_tokens"`
-	TotalOutputTokens  int64  `json:"total_output_tokens"`
-	TotalCacheCreation int64  `json:"total_cache_creation_tokens"`
-	TotalCacheRead     int64  `json:"total_cache_read_tokens"`
-	SessionCount       int    `json:"session_count"`
+	Model              string  `json:"model"`
+	TotalTokens        int64   `json:"total_tokens"`
+	TotalInputTokens   int64   `json:"total_input_tokens"`
+	TotalOutputTokens  int64   `json:"total_output_tokens"`
+	TotalCacheCreation int64   `json:"total_cache_creation_tokens"`
+	TotalCacheRead     int64   `json:"total_cache_read_tokens"`
+	SessionCount       int     `json:"session_count"`
+	TotalCost          float64 `json:"total_cost"`
 }
 
 // DayStats represents aggregated statistics for a specific day
@@ -140,12 +92,75 @@ type SessionDetail struct {
 // Collector collects usage statistics from Claude session logs
 type Collector struct {
 	claudeDir string
+	cache     Cache // optional, see NewCachedCollector
+	pricing   *Pricing
+	resolve   WorkspaceResolver // optional
 }
 
+// WorkspaceResolver returns the project a working directory belongs to and
+// the branch checked out there ("" outside git)
+type WorkspaceResolver func(path string) (workspace, branch string)
+
 // NewCollector creates a new usage stats collector
 func NewCollector(claudeDir string) *Collector {
 	return &Collector{
 		claudeDir: claudeDir,
+		pricing:   DefaultPricing(),
+	}
+}
+
+// WithPricing makes the collector price usage with p instead of the builtin
+// prices
+func (c *Collector) WithPricing(p *Pricing) *Collector {
+	c.pricing = p
+	return c
+}
+
+// WithWorkspaceResolver makes the collector attribute usage to workspaces
+// and branches with resolve; without one every directory is its own
+// workspace and only branches recorded in the logs are known
+func (c *Collector) WithWorkspaceResolver(resolve WorkspaceResolver) *Collector {
+	c.resolve = resolve
+	return c
+}
+
+// applyWorkspaces fills in the workspace and missing branch of entries,
+// resolving each directory once
+func (c *Collector) applyWorkspaces(entries []*UsageEntry) {
+	type resolved struct{ workspace, branch string }
+	seen := make(map[string]resolved)
+	for _, entry := range entries {
+		if entry.ProjectPath == "" {
+			continue
+		}
+		r, ok := seen[entry.ProjectPath]
+		if !ok {
+			r = resolved{workspace: entry.ProjectPath}
+			if c.resolve != nil {
+				if workspace, branch := c.resolve(entry.ProjectPath); workspace != "" {
+					r = resolved{workspace: workspace, branch: branch}
+				}
+			}
+			seen[entry.ProjectPath] = r
+		}
+		entry.Workspace = r.workspace
+		if entry.GitBranch == "" {
+			entry.GitBranch = r.branch
+		}
+	}
+}
+
+// applyPricing prices the entries whose log carried no cost, at the price
+// in effect when they were recorded
+func (c *Collector) applyPricing(entries []*UsageEntry) {
+	pricing := c.pricing
+	if pricing == nil {
+		pricing = DefaultPricing()
+	}
+	for _, entry := range entries {
+		if entry.CostUSD == 0 {
+			entry.CostUSD = pricing.Cost(entry.Model, entry.Timestamp, entry.InputTokens, entry.OutputTokens, entry.CacheCreation, entry.CacheRead)
+		}
 	}
 }
 
@@ -198,12 +213,15 @@ func parseJSONLLine(line string) (*UsageEntry, error) {
 		entry.ProjectPath = cwd
 	}
 
-	// Extract cost from costUSD if available, otherwise calculate it
+	// Extract the branch checked out when the entry was logged
+	if branch, ok := raw["gitBranch"].(string); ok {
+		entry.GitBranch = branch
+	}
+
+	// Extract cost from costUSD if available; other entries are priced
+	// after scanning (see applyPricing)
 	if costUSD, ok := raw["costUSD"].(float64); ok {
 		entry.CostUSD = costUSD
-	} else if entry.Model != "" {
-		// Calculate cost based on model and token usage
-		entry.CostUSD = calculateCost(entry.Model, entry.InputTokens, entry.OutputTokens, entry.CacheCreation, entry.CacheRead)
 	}
 
 	return entry, nil
@@ -252,6 +270,23 @@ func (c *Collector) scanJSONLFile(path string) ([]*UsageEntry, error) {
 
 // scanAllJSONLFiles scans all JSONL files in the Claude projects directory
 func (c *Collector) scanAllJSONLFiles() ([]*UsageEntry, error) {
+	var entries []*UsageEntry
+	var err error
+	if c.cache != nil {
+		entries, err = c.scanAllJSONLFilesCached()
+	} else {
+		entries, err = c.walkJSONLFiles()
+	}
+	if err != nil {
+		return nil, err
+	}
+	c.applyPricing(entries)
+	c.applyWorkspaces(entries)
+	return entries, nil
+}
+
+// walkJSONLFiles parses every JSONL file under the projects directory
+func (c *Collector) walkJSONLFiles() ([]*UsageEntry, error) {
 	projectsDir := filepath.Join(c.claudeDir, "projects")
 
 	// Check if projects directory exists
@@ -303,17 +338,7 @@ func (c *Collector) CollectStatsByDateRange(startDate, endDate time.Time) (*Over
 		return nil, err
 	}
 
-	// Filter by date range
-	var filtered []*UsageEntry
-	for _, entry := range entries {
-		if !entry.Timestamp.IsZero() &&
-			!entry.Timestamp.Before(startDate) &&
-			!entry.Timestamp.After(endDate) {
-			filtered = append(filtered, entry)
-		}
-	}
-
-	return c.aggregateStats(filtered), nil
+	return c.aggregateStats(filterByDate(entries, startDate, endDate)), nil
 }
 
 // aggregateStats aggregates usage entries into overall statistics
@@ -363,6 +388,7 @@ func (c *Collector) aggregateStats(entries []*UsageEntry) *OverallStats {
 			ms.TotalOutputTokens += entry.OutputTokens
 			ms.TotalCacheCreation += entry.CacheCreation
 			ms.TotalCacheRead += entry.CacheRead
+			ms.TotalCost += entry.CostUSD
 
 			if entry.SessionID != "" {
 				sessionsByModel[entry.Model][entry.SessionID] = true
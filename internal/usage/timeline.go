@@ -0,0 +1,145 @@
+// internal/usage/timeline.go
+package usage
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Timeline buckets
+const (
+	BucketHour = "hour"
+	BucketDay  = "day"
+)
+
+// maxTimelinePoints bounds a timeline, e.g. 90 days of hours
+const maxTimelinePoints = 24 * 90
+
+// timelineTopSessions is how many sessions each point names
+const timelineTopSessions = 5
+
+// TimelineSession is one session's share of a timeline point
+type TimelineSession struct {
+	SessionID   string  `json:"session_id"`
+	ProjectPath string  `json:"project_path"`
+	TotalTokens int64   `json:"total_tokens"`
+	CostUSD     float64 `json:"cost_usd"`
+}
+
+// TimelinePoint is the usage of one bucket; Key is the bucket start (RFC3339)
+type TimelinePoint struct {
+	ReportRow
+	BurnRateUSDPerHour float64            `json:"burn_rate_usd_per_hour"`
+	TopSessions        []*TimelineSession `json:"top_sessions"`
+}
+
+// Timeline is usage over time in equal buckets, empty buckets included
+type Timeline struct {
+	Bucket string           `json:"bucket"`
+	Start  string           `json:"start"`
+	End    string           `json:"end"`
+	Points []*TimelinePoint `json:"points"`
+	// PeakIndex is the point with the highest cost, -1 without usage
+	PeakIndex int `json:"peak_index"`
+}
+
+func bucketStart(t time.Time, bucket string) time.Time {
+	t = t.UTC()
+	if bucket == BucketDay {
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	}
+	return t.Truncate(time.Hour)
+}
+
+func nextBucket(t time.Time, bucket string) time.Time {
+	if bucket == BucketDay {
+		return t.AddDate(0, 0, 1)
+	}
+	return t.Add(time.Hour)
+}
+
+// CollectTimeline collects usage between start and end in hour or day
+// buckets (UTC), naming the costliest sessions of each bucket
+func (c *Collector) CollectTimeline(start, end time.Time, bucket string) (*Timeline, error) {
+	if bucket != BucketHour && bucket != BucketDay {
+		return nil, fmt.Errorf("invalid bucket %q, want hour or day", bucket)
+	}
+	if end.Before(start) {
+		return nil, fmt.Errorf("end is before start")
+	}
+
+	first := bucketStart(start, bucket)
+	var points []*TimelinePoint
+	index := make(map[time.Time]int)
+	for t := first; !t.After(end); t = nextBucket(t, bucket) {
+		if len(points) == maxTimelinePoints {
+			return nil, fmt.Errorf("range too long for %s buckets", bucket)
+		}
+		index[t] = len(points)
+		points = append(points, &TimelinePoint{ReportRow: ReportRow{Key: t.Format(time.RFC3339)}})
+	}
+
+	entries, err := c.scanAllJSONLFiles()
+	if err != nil {
+		return nil, err
+	}
+	sessions := make([]map[string]*TimelineSession, len(points))
+	for _, entry := range filterByDate(entries, start, end) {
+		i, ok := index[bucketStart(entry.Timestamp, bucket)]
+		if !ok {
+			continue
+		}
+		points[i].add(entry)
+		if entry.SessionID == "" {
+			continue
+		}
+		if sessions[i] == nil {
+			sessions[i] = make(map[string]*TimelineSession)
+		}
+		session, ok := sessions[i][entry.SessionID]
+		if !ok {
+			session = &TimelineSession{SessionID: entry.SessionID, ProjectPath: entry.ProjectPath}
+			sessions[i][entry.SessionID] = session
+		}
+		session.TotalTokens += entry.InputTokens + entry.OutputTokens + entry.CacheCreation + entry.CacheRead
+		session.CostUSD += entry.CostUSD
+	}
+
+	hours := 1.0
+	if bucket == BucketDay {
+		hours = 24
+	}
+	timeline := &Timeline{
+		Bucket:    bucket,
+		Start:     start.UTC().Format(time.RFC3339),
+		End:       end.UTC().Format(time.RFC3339),
+		Points:    points,
+		PeakIndex: -1,
+	}
+	for i, point := range points {
+		point.BurnRateUSDPerHour = point.CostUSD / hours
+		point.TopSessions = topTimelineSessions(sessions[i])
+		if point.Requests > 0 && (timeline.PeakIndex < 0 || point.CostUSD > points[timeline.PeakIndex].CostUSD) {
+			timeline.PeakIndex = i
+		}
+	}
+	return timeline, nil
+}
+
+func topTimelineSessions(sessions map[string]*TimelineSession) []*TimelineSession {
+	top := make([]*TimelineSession, 0, len(sessions))
+	for _, session := range sessions {
+		top = append(top, session)
+	}
+	sort.Slice(top, func(i, j int) bool {
+		if top[i].CostUSD != top[j].CostUSD {
+			return top[i].CostUSD > top[j].CostUSD
+		}
+		return top[i].TotalTokens > top[j].TotalTokens
+	})
+	if len(top) > timelineTopSessions {
+		top = top[:timelineTopSessions]
+	}
+	return top
+}
@@ -0,0 +1,52 @@
+package usage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCollectTimeline(t *testing.T) {
+	claudeDir := t.TempDir()
+	writeUsageLog(t, claudeDir,
+		usageLine("s1", "/work/a", "2026-03-01T10:05:00Z", "claude-sonnet-4", 1_000_000),
+		usageLine("s2", "/work/b", "2026-03-01T10:40:00Z", "claude-opus-4", 1_000_000),
+		usageLine("s1", "/work/a", "2026-03-01T12:00:00Z", "claude-sonnet-4", 1_000_000),
+		usageLine("s3", "/work/a", "2026-03-02T10:00:00Z", "claude-sonnet-4", 1_000_000),
+	)
+	collector := NewCollector(claudeDir)
+	start := time.Date(2026, 3, 1, 9, 30, 0, 0, time.UTC)
+	end := time.Date(2026, 3, 1, 13, 0, 0, 0, time.UTC)
+
+	timeline, err := collector.CollectTimeline(start, end, BucketHour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(timeline.Points) != 5 || timeline.Points[0].Key != "2026-03-01T09:00:00Z" {
+		t.Fatalf("Expected 5 hourly points from 09:00, got %d", len(timeline.Points))
+	}
+	peak := timeline.Points[1]
+	if timeline.PeakIndex != 1 || peak.CostUSD != 18 || peak.BurnRateUSDPerHour != 18 || peak.Sessions != 2 {
+		t.Errorf("Unexpected peak %d: %+v", timeline.PeakIndex, peak.ReportRow)
+	}
+	if len(peak.TopSessions) != 2 || peak.TopSessions[0].SessionID != "s2" || peak.TopSessions[0].ProjectPath != "/work/b" {
+		t.Errorf("Expected the opus session first, got %+v", peak.TopSessions)
+	}
+	if timeline.Points[2].Requests != 0 || timeline.Points[2].TopSessions == nil {
+		t.Errorf("Expected an empty 11:00 point, got %+v", timeline.Points[2])
+	}
+
+	daily, err := collector.CollectTimeline(start, start.Add(48*time.Hour), BucketDay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(daily.Points) != 3 || daily.Points[0].CostUSD != 21 || daily.Points[0].BurnRateUSDPerHour != 21.0/24 {
+		t.Errorf("Unexpected daily points %+v", daily.Points[0].ReportRow)
+	}
+
+	if _, err := collector.CollectTimeline(start, end, "minute"); err == nil {
+		t.Error("Expected an unknown bucket to be rejected")
+	}
+	if _, err := collector.CollectTimeline(start, start.AddDate(1, 0, 0), BucketHour); err == nil {
+		t.Error("Expected a year of hourly buckets to be rejected")
+	}
+}
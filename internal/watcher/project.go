@@ -0,0 +1,350 @@
+package watcher
+
+import (
+	"fmt"
+	"io/fs"
+	"log"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"ropcode/internal/eventhub"
+)
+
+const (
+	// maxProjectDirs caps how many directories one project watch registers,
+	// so a huge tree cannot exhaust the inotify/kqueue limits
+	maxProjectDirs = 8192
+	// maxBatchChanges caps the changes carried by one event; a bigger batch
+	// is sent truncated and the listener refreshes everything
+	maxBatchChanges = 500
+	// maxBatchDelay bounds how long a steady stream of writes can hold
+	// back a batch
+	maxBatchDelay = 2 * time.Second
+)
+
+// ignoredDirs are never watched: version control metadata, dependencies,
+// caches and build output
+var ignoredDirs = map[string]bool{
+	".git":         true,
+	".hg":          true,
+	".svn":         true,
+	".ropcode":     true,
+	"node_modules": true,
+	"dist":         true,
+	"build":        true,
+	"target":       true,
+	"out":          true,
+	".next":        true,
+	".nuxt":        true,
+	".cache":       true,
+	".venv":        true,
+	"venv":         true,
+	"__pycache__":  true,
+	".gradle":      true,
+	".idea":        true,
+}
+
+// FsEmitter receives the batched changes of watched projects
+type FsEmitter interface {
+	EmitFsChanged(event eventhub.FsChangedEvent)
+}
+
+// ProjectWatchers watches the files of open projects recursively and emits
+// one debounced batch of changes per project
+type ProjectWatchers struct {
+	debounce time.Duration
+	emitter  FsEmitter
+	projects map[string]*projectWatch
+	mu       sync.Mutex
+}
+
+// NewProjectWatchers creates a ProjectWatchers that waits debounce after the
+// last change before emitting a batch
+func NewProjectWatchers(debounce time.Duration, emitter FsEmitter) *ProjectWatchers {
+	return &ProjectWatchers{
+		debounce: debounce,
+		emitter:  emitter,
+		projects: make(map[string]*projectWatch),
+	}
+}
+
+// Watch starts watching root, or adds a reference if it is already watched
+func (m *ProjectWatchers) Watch(root string) error {
+	root = filepath.Clean(root)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if p, ok := m.projects[root]; ok {
+		p.refs++
+		return nil
+	}
+
+	info, err := os.Stat(root)
+	if err != nil {
+		return fmt.Errorf("failed to watch project: %w", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("failed to watch project: %s is not a directory", root)
+	}
+
+	p, err := newProjectWatch(root, m.debounce, m.emitter)
+	if err != nil {
+		return err
+	}
+	m.projects[root] = p
+	return nil
+}
+
+// Unwatch drops a reference to root and stops watching once none are left
+func (m *ProjectWatchers) Unwatch(root string) {
+	root = filepath.Clean(root)
+
+	m.mu.Lock()
+	p, ok := m.projects[root]
+	if ok {
+		p.refs--
+		if p.refs > 0 {
+			ok = false
+		} else {
+			delete(m.projects, root)
+		}
+	}
+	m.mu.Unlock()
+
+	if ok {
+		p.close()
+	}
+}
+
+// Watching reports whether root is being watched
+func (m *ProjectWatchers) Watching(root string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, ok := m.projects[filepath.Clean(root)]
+	return ok
+}
+
+// Close stops watching all projects
+func (m *ProjectWatchers) Close() {
+	m.mu.Lock()
+	projects := m.projects
+	m.projects = make(map[string]*projectWatch)
+	m.mu.Unlock()
+
+	for _, p := range projects {
+		p.close()
+	}
+}
+
+// projectWatch is the watch on one project root
+type projectWatch struct {
+	root     string
+	debounce time.Duration
+	emitter  FsEmitter
+	watcher  *fsnotify.Watcher
+	done     chan struct{}
+	refs     int
+
+	mu         sync.Mutex
+	dirs       int
+	pending    map[string]EventType
+	batchStart time.Time
+	timer      *time.Timer
+	closed     bool
+}
+
+func newProjectWatch(root string, debounce time.Duration, emitter FsEmitter) (*projectWatch, error) {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create fsnotify watcher: %w", err)
+	}
+	p := &projectWatch{
+		root:     root,
+		debounce: debounce,
+		emitter:  emitter,
+		watcher:  w,
+		done:     make(chan struct{}),
+		refs:     1,
+		pending:  make(map[string]EventType),
+	}
+	if err := p.addTree(root); err != nil {
+		w.Close()
+		return nil, fmt.Errorf("failed to watch project %s: %w", root, err)
+	}
+	go p.watch()
+	return p, nil
+}
+
+// addTree watches dir and every directory below it that is not ignored
+func (p *projectWatch) addTree(dir string) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// The root must be readable; unreadable subdirectories are skipped
+			if path == dir {
+				return err
+			}
+			return filepath.SkipDir
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if path != p.root && ignoredDirs[d.Name()] {
+			return filepath.SkipDir
+		}
+
+		p.mu.Lock()
+		full := p.dirs >= maxProjectDirs
+		if !full {
+			p.dirs++
+		}
+		p.mu.Unlock()
+		if full {
+			log.Printf("[watcher] %s has more than %d directories, not watching the rest", p.root, maxProjectDirs)
+			return filepath.SkipAll
+		}
+
+		if err := p.watcher.Add(path); err != nil {
+			if path == dir {
+				return err
+			}
+			slog.Warn("failed to watch", "component", "watcher", "path", path, "error", err)
+		}
+		return nil
+	})
+}
+
+// watch is the event loop of the project
+func (p *projectWatch) watch() {
+	for {
+		select {
+		case event, ok := <-p.watcher.Events:
+			if !ok {
+				return
+			}
+			p.handleEvent(event)
+
+		case err, ok := <-p.watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Printf("[watcher] %s: %v", p.root, err)
+
+		case <-p.done:
+			return
+		}
+	}
+}
+
+// handleEvent records one fsnotify event in the pending batch
+func (p *projectWatch) handleEvent(event fsnotify.Event) {
+	if p.ignored(event.Name) {
+		return
+	}
+
+	var eventType EventType
+	switch {
+	case event.Op&fsnotify.Create == fsnotify.Create:
+		eventType = EventCreate
+		// New directories are not covered by the existing watches
+		if info, err := os.Lstat(event.Name); err == nil && info.IsDir() {
+			if err := p.addTree(event.Name); err != nil {
+				slog.Warn("failed to watch", "component", "watcher", "path", event.Name, "error", err)
+			}
+		}
+	case event.Op&fsnotify.Write == fsnotify.Write:
+		eventType = EventModify
+	case event.Op&fsnotify.Remove == fsnotify.Remove:
+		eventType = EventDelete
+	case event.Op&fsnotify.Rename == fsnotify.Rename:
+		eventType = EventRename
+	default:
+		// Chmod and the like don't change what the UI shows
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return
+	}
+	// A file created and then written within a batch is still new
+	if prev, ok := p.pending[event.Name]; !ok || !(prev == EventCreate && eventType == EventModify) {
+		p.pending[event.Name] = eventType
+	}
+
+	now := time.Now()
+	if p.timer == nil {
+		p.batchStart = now
+		p.timer = time.AfterFunc(p.debounce, p.flush)
+	} else if now.Sub(p.batchStart) < maxBatchDelay {
+		p.timer.Reset(p.debounce)
+	}
+}
+
+// ignored reports whether path lies in an ignored directory of the project
+func (p *projectWatch) ignored(path string) bool {
+	rel, err := filepath.Rel(p.root, path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return true
+	}
+	if rel == "." {
+		return false
+	}
+	for _, part := range strings.Split(rel, string(filepath.Separator)) {
+		if ignoredDirs[part] {
+			return true
+		}
+	}
+	return false
+}
+
+// flush emits the pending batch
+func (p *projectWatch) flush() {
+	p.mu.Lock()
+	pending := p.pending
+	p.pending = make(map[string]EventType)
+	p.timer = nil
+	closed := p.closed
+	p.mu.Unlock()
+
+	if closed || len(pending) == 0 || p.emitter == nil {
+		return
+	}
+
+	event := eventhub.FsChangedEvent{Root: p.root}
+	for path, eventType := range pending {
+		event.Changes = append(event.Changes, eventhub.FsChange{Path: path, Op: string(eventType)})
+	}
+	sort.Slice(event.Changes, func(i, j int) bool { return event.Changes[i].Path < event.Changes[j].Path })
+	if len(event.Changes) > maxBatchChanges {
+		event.Changes = event.Changes[:maxBatchChanges]
+		event.Truncated = true
+	}
+	p.emitter.EmitFsChanged(event)
+}
+
+func (p *projectWatch) close() {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return
+	}
+	p.closed = true
+	if p.timer != nil {
+		p.timer.Stop()
+		p.timer = nil
+	}
+	p.mu.Unlock()
+
+	close(p.done)
+	p.watcher.Close()
+}
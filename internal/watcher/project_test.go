@@ -0,0 +1,154 @@
+package watcher
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"ropcode/internal/eventhub"
+)
+
+type fsRecorder struct {
+	mu     sync.Mutex
+	events []eventhub.FsChangedEvent
+}
+
+func (r *fsRecorder) EmitFsChanged(event eventhub.FsChangedEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+}
+
+// changes returns the op of every path reported so far
+func (r *fsRecorder) changes() map[string]string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ops := map[string]string{}
+	for _, event := range r.events {
+		for _, change := range event.Changes {
+			ops[change.Path] = change.Op
+		}
+	}
+	return ops
+}
+
+func (r *fsRecorder) waitFor(t *testing.T, path string) string {
+	t.Helper()
+	deadline := time.Now().Add(3 * time.Second)
+	for time.Now().Before(deadline) {
+		if op, ok := r.changes()[path]; ok {
+			return op
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatalf("no change reported for %s, got %+v", path, r.changes())
+	return ""
+}
+
+func TestProjectWatchersRecursive(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "src", "pkg"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(root, "node_modules", "dep"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	recorder := &fsRecorder{}
+	m := NewProjectWatchers(50*time.Millisecond, recorder)
+	defer m.Close()
+	if err := m.Watch(root); err != nil {
+		t.Fatalf("Watch() error = %v", err)
+	}
+
+	// Existing subdirectories are watched
+	existing := filepath.Join(root, "src", "pkg", "main.go")
+	if err := os.WriteFile(existing, []byte("package pkg"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if op := recorder.waitFor(t, existing); op != string(EventCreate) {
+		t.Errorf("op = %q, want create", op)
+	}
+
+	// So are directories created after the watch started
+	created := filepath.Join(root, "docs", "guide")
+	if err := os.MkdirAll(created, 0755); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	nested := filepath.Join(created, "intro.md")
+	if err := os.WriteFile(nested, []byte("# Intro"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	recorder.waitFor(t, nested)
+
+	// Ignored directories are not
+	if err := os.WriteFile(filepath.Join(root, "node_modules", "dep", "index.js"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(200 * time.Millisecond)
+	for path := range recorder.changes() {
+		if rel, _ := filepath.Rel(root, path); strings.HasPrefix(rel, "node_modules") {
+			t.Errorf("change reported in ignored directory: %s", path)
+		}
+	}
+
+	recorder.mu.Lock()
+	for _, event := range recorder.events {
+		if event.Root != root {
+			t.Errorf("event root = %q, want %q", event.Root, root)
+		}
+	}
+	recorder.mu.Unlock()
+}
+
+func TestProjectWatchersBatchesChanges(t *testing.T) {
+	root := t.TempDir()
+	recorder := &fsRecorder{}
+	m := NewProjectWatchers(100*time.Millisecond, recorder)
+	defer m.Close()
+	if err := m.Watch(root); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"a.txt", "b.txt", "c.txt"} {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	recorder.waitFor(t, filepath.Join(root, "c.txt"))
+
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	if len(recorder.events) != 1 || len(recorder.events[0].Changes) != 3 {
+		t.Fatalf("events = %+v, want one batch of three changes", recorder.events)
+	}
+}
+
+func TestProjectWatchersReferenceCounting(t *testing.T) {
+	root := t.TempDir()
+	m := NewProjectWatchers(50*time.Millisecond, &fsRecorder{})
+	defer m.Close()
+
+	if err := m.Watch(root); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Watch(root + string(filepath.Separator)); err != nil {
+		t.Fatal(err)
+	}
+	m.Unwatch(root)
+	if !m.Watching(root) {
+		t.Fatal("Unwatch() stopped a watch that still has a reference")
+	}
+	m.Unwatch(root)
+	if m.Watching(root) {
+		t.Fatal("Unwatch() left the last reference watching")
+	}
+
+	if err := m.Watch(filepath.Join(root, "missing")); err == nil {
+		t.Fatal("Watch() should fail for a missing directory")
+	}
+}
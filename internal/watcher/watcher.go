@@ -2,6 +2,7 @@ package watcher
 
 import (
 	"fmt"
+	"log"
 	"sync"
 	"time"
 
@@ -134,7 +135,7 @@ func (w *Watcher) watch() {
 				return
 			}
 			// Log error but continue watching
-			fmt.Printf("watcher error: %v\n", err)
+			log.Printf("[watcher] %v", err)
 
 		case <-w.done:
 			return
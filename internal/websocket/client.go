@@ -23,15 +23,24 @@ const (
 	// pty-output during streaming).
 	responseBufferSize = 1024
 	eventBufferSize    = 4096
+
+	// eventsDroppedEvent tells a client how many events it missed while its
+	// queue was full, so it can reload state instead of trusting the stream.
+	eventsDroppedEvent = "ws:events-dropped"
 )
 
+// slowClientTimeout is how long a client's event queue may stay full before
+// the server disconnects it; reconnecting resyncs it.
+var slowClientTimeout = 30 * time.Second
+
 // Client 表示一个 WebSocket 客户端连接.
 //
 // RPC responses and push events are queued on separate channels so a flood of
 // streaming events cannot starve button RPC responses. WritePump drains the
 // response channel with priority over the event channel; the event buffer is
 // large enough to absorb a typical Claude streaming burst before any frame is
-// dropped.
+// dropped. Dropped events are counted and reported to the client once its
+// queue drains; a client that stays full for slowClientTimeout is cut off.
 type Client struct {
 	ID   string
 	Conn *websocket.Conn
@@ -42,8 +51,13 @@ type Client struct {
 	// etc). Larger buffer because the producer side is bursty.
 	Events chan []byte
 
-	mu     sync.Mutex
-	closed bool
+	mu       sync.Mutex
+	closed   bool
+	dropped  int       // events dropped since the queue last accepted one
+	lagSince time.Time // when the current run of drops started
+
+	subMu sync.RWMutex
+	subs  *subscriptionSet // nil until the client first subscribes
 }
 
 // NewClient 创建新的客户端
@@ -60,6 +74,11 @@ func NewClient(id string, conn *websocket.Conn) *Client {
 // Kept as the unified entry point for callers that already build WSMessage
 // values.
 func (c *Client) SendMessage(msg *WSMessage) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
@@ -67,21 +86,124 @@ func (c *Client) SendMessage(msg *WSMessage) error {
 		return ErrClientClosed
 	}
 
-	data, err := json.Marshal(msg)
-	if err != nil {
-		return err
+	if msg != nil && (msg.Kind == "rpc_response" || msg.Kind == "subscriptions") {
+		select {
+		case c.Responses <- data:
+			return nil
+		default:
+			return ErrClientBufferFull
+		}
+	}
+	return c.queueEventLocked(data)
+}
+
+// sendEventData queues an already marshaled event message
+func (c *Client) sendEventData(data []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return ErrClientClosed
 	}
+	return c.queueEventLocked(data)
+}
 
-	target := c.Events
-	if msg != nil && msg.Kind == "rpc_response" {
-		target = c.Responses
+// queueEventLocked queues an event, first telling the client how many events
+// it missed if earlier ones were dropped. Must hold c.mu.
+func (c *Client) queueEventLocked(data []byte) error {
+	if c.dropped > 0 {
+		notice, err := json.Marshal(&WSMessage{
+			Kind:  "event",
+			Event: &WSEvent{Type: eventsDroppedEvent, Payload: map[string]int{"count": c.dropped}},
+		})
+		if err != nil {
+			return err
+		}
+		select {
+		case c.Events <- notice:
+			c.dropped = 0
+		default:
+			return c.dropLocked()
+		}
 	}
 
 	select {
-	case target <- data:
+	case c.Events <- data:
 		return nil
 	default:
-		return ErrClientBufferFull
+		return c.dropLocked()
+	}
+}
+
+func (c *Client) dropLocked() error {
+	now := time.Now()
+	if c.dropped == 0 {
+		c.lagSince = now
+	}
+	c.dropped++
+	if now.Sub(c.lagSince) >= slowClientTimeout {
+		return ErrClientTooSlow
+	}
+	return ErrClientBufferFull
+}
+
+// Subscribe adds topics; the client then only receives events of its topics
+// and app-wide events
+func (c *Client) Subscribe(topics []string) error {
+	c.subMu.Lock()
+	defer c.subMu.Unlock()
+	subs := c.subs
+	if subs == nil {
+		subs = newSubscriptionSet()
+	}
+	if err := subs.update(topics, true); err != nil {
+		return err
+	}
+	c.subs = subs
+	return nil
+}
+
+// Unsubscribe removes topics. A client stays filtered after removing its
+// last topic; subscribe to "*" to receive everything again.
+func (c *Client) Unsubscribe(topics []string) error {
+	c.subMu.Lock()
+	defer c.subMu.Unlock()
+	if c.subs == nil {
+		c.subs = newSubscriptionSet()
+	}
+	return c.subs.update(topics, false)
+}
+
+// Topics returns the subscribed topics; nil means the client receives every
+// event
+func (c *Client) Topics() []string {
+	c.subMu.RLock()
+	defer c.subMu.RUnlock()
+	if c.subs == nil {
+		return nil
+	}
+	return c.subs.topics()
+}
+
+// filtered reports whether the client has subscribed to topics
+func (c *Client) filtered() bool {
+	c.subMu.RLock()
+	defer c.subMu.RUnlock()
+	return c.subs != nil
+}
+
+// wants reports whether a filtered client subscribed to an event
+func (c *Client) wants(eventType string, scope eventScope) bool {
+	c.subMu.RLock()
+	defer c.subMu.RUnlock()
+	return c.subs == nil || c.subs.matches(eventType, scope)
+}
+
+// Disconnect closes the connection; the read loop then unregisters the
+// client
+func (c *Client) Disconnect() {
+	if c.Conn != nil {
+		c.Conn.Close()
 	}
 }
 
@@ -234,6 +356,7 @@ func (c *Client) Close() {
 var (
 	ErrClientBufferFull = &ClientError{Message: "client send buffer full"}
 	ErrClientClosed     = &ClientError{Message: "client closed"}
+	ErrClientTooSlow    = &ClientError{Message: "client too slow"}
 )
 
 type ClientError struct {
@@ -0,0 +1,128 @@
+// internal/websocket/replay.go
+package websocket
+
+import (
+	"sort"
+	"sync"
+)
+
+// Every pushed event carries a sequence number. The server keeps the latest
+// events of each session and PTY so a client that reconnects can send
+//
+//	{"kind": "resume", "seq": <last seq seen>, "epoch": <epoch>}
+//
+// and receive what it missed before any newer event. The reply is
+//
+//	{"kind": "resumed", "seq": <current seq>, "epoch": <epoch>, "gap": bool}
+//
+// where gap means some missed events are gone (the log overflowed, or the
+// epoch changed because the server restarted) and the client should reload
+// its state. Events that belong to no session or PTY are state snapshots
+// (git status, queue changes, ...) and are not kept; reloading state on
+// reconnect covers them.
+const (
+	replayEventsPerKey = 1000
+	replayMaxKeys      = 256
+)
+
+type replayEntry struct {
+	seq       uint64
+	eventType string
+	scope     eventScope
+	data      []byte
+}
+
+// replayRing holds the latest events of one session or PTY
+type replayRing struct {
+	entries []replayEntry
+	evicted uint64 // seq of the newest event pushed out of the ring
+}
+
+// replayLog assigns sequence numbers and keeps bounded per-key logs
+type replayLog struct {
+	mu      sync.Mutex
+	seq     uint64
+	perKey  int
+	maxKeys int
+	rings   map[string]*replayRing
+	evicted uint64 // newest seq lost with a ring dropped to make room
+}
+
+func newReplayLog(perKey, maxKeys int) *replayLog {
+	return &replayLog{perKey: perKey, maxKeys: maxKeys, rings: make(map[string]*replayRing)}
+}
+
+// replayKey names the log an event is kept in; empty means it is not kept
+func replayKey(scope eventScope) string {
+	switch {
+	case scope.Session != "":
+		return TopicSession + scope.Session
+	case scope.Pty != "":
+		return TopicPty + scope.Pty
+	}
+	return ""
+}
+
+// next reserves the next sequence number. Callers hold l.mu.
+func (l *replayLog) next() uint64 {
+	l.seq++
+	return l.seq
+}
+
+// record keeps an event. Callers hold l.mu.
+func (l *replayLog) record(entry replayEntry) {
+	key := replayKey(entry.scope)
+	if key == "" {
+		return
+	}
+	ring, ok := l.rings[key]
+	if !ok {
+		if len(l.rings) >= l.maxKeys {
+			l.dropOldestRing()
+		}
+		ring = &replayRing{}
+		l.rings[key] = ring
+	}
+	if len(ring.entries) >= l.perKey {
+		ring.evicted = ring.entries[0].seq
+		ring.entries = append(ring.entries[:0], ring.entries[1:]...)
+	}
+	ring.entries = append(ring.entries, entry)
+}
+
+// dropOldestRing drops the ring whose latest event is the oldest
+func (l *replayLog) dropOldestRing() {
+	var oldestKey string
+	var oldestSeq uint64
+	for key, ring := range l.rings {
+		last := ring.evicted
+		if n := len(ring.entries); n > 0 {
+			last = ring.entries[n-1].seq
+		}
+		if oldestKey == "" || last < oldestSeq {
+			oldestKey, oldestSeq = key, last
+		}
+	}
+	if oldestKey != "" {
+		delete(l.rings, oldestKey)
+		if oldestSeq > l.evicted {
+			l.evicted = oldestSeq
+		}
+	}
+}
+
+// since returns the kept events after seq in order, and whether any event
+// after seq is no longer kept. Callers hold l.mu.
+func (l *replayLog) since(seq uint64) ([]replayEntry, bool) {
+	gap := l.evicted > seq
+	var entries []replayEntry
+	for _, ring := range l.rings {
+		if ring.evicted > seq {
+			gap = true
+		}
+		start := sort.Search(len(ring.entries), func(i int) bool { return ring.entries[i].seq > seq })
+		entries = append(entries, ring.entries[start:]...)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].seq < entries[j].seq })
+	return entries, gap
+}
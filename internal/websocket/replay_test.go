@@ -0,0 +1,120 @@
+package websocket
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// drainFrames returns the messages queued on a client's event queue
+func drainFrames(t *testing.T, client *Client) []WSMessage {
+	t.Helper()
+	var frames []WSMessage
+	for {
+		select {
+		case data := <-client.Events:
+			var msg WSMessage
+			if err := json.Unmarshal(data, &msg); err != nil {
+				t.Fatalf("Invalid frame %s: %v", data, err)
+			}
+			frames = append(frames, msg)
+		default:
+			return frames
+		}
+	}
+}
+
+func TestHandleResume_ReplaysMissedSessionEvents(t *testing.T) {
+	server := NewServer(&registryTestApp{})
+	server.instanceID = "epoch-1"
+	client := NewClient("c", nil)
+	server.clients = map[string]*Client{"c": client}
+
+	server.BroadcastEvent("claude-output", `{"session_id":"s1","n":1}`)
+	server.BroadcastEvent("git-status:changed", map[string]string{"state": "clean"})
+	server.BroadcastEvent("pty-output", map[string]string{"session_id": "p1", "data": "ls"})
+	frames := drainFrames(t, client)
+	if len(frames) != 3 || frames[0].Seq != 1 || frames[2].Seq != 3 {
+		t.Fatalf("Expected three numbered events, got %#v", frames)
+	}
+
+	// The client drops after seq 1 and misses two session events
+	delete(server.clients, "c")
+	server.BroadcastEvent("claude-output", `{"session_id":"s1","n":2}`)
+	server.BroadcastEvent("claude-output", `{"session_id":"s2","n":3}`)
+
+	reconnected := NewClient("c2", nil)
+	server.handleMessage(reconnected, []byte(`{"kind":"resume","seq":1,"epoch":"epoch-1"}`))
+	frames = drainFrames(t, reconnected)
+
+	var seqs []uint64
+	for _, frame := range frames[:len(frames)-1] {
+		seqs = append(seqs, frame.Seq)
+	}
+	// seq 2 is unscoped and not kept; seq 3 is the PTY event
+	if want := []uint64{3, 4, 5}; !reflect.DeepEqual(seqs, want) {
+		t.Errorf("Replayed seqs = %v, want %v", seqs, want)
+	}
+	reply := frames[len(frames)-1]
+	if reply.Kind != "resumed" || reply.Seq != 5 || reply.Epoch != "epoch-1" || reply.Gap {
+		t.Errorf("Unexpected resume reply: %#v", reply)
+	}
+}
+
+func TestHandleResume_FiltersBySubscription(t *testing.T) {
+	server := NewServer(&registryTestApp{})
+	server.instanceID = "epoch-1"
+	server.BroadcastEvent("claude-output", `{"session_id":"s1"}`)
+	server.BroadcastEvent("claude-output", `{"session_id":"s2"}`)
+
+	client := NewClient("c", nil)
+	if err := client.Subscribe([]string{"session:s2"}); err != nil {
+		t.Fatal(err)
+	}
+	server.handleMessage(client, []byte(`{"kind":"resume","seq":0,"epoch":"epoch-1"}`))
+	frames := drainFrames(t, client)
+	if len(frames) != 2 || frames[0].Seq != 2 || frames[1].Kind != "resumed" {
+		t.Errorf("Expected only the subscribed session replayed, got %#v", frames)
+	}
+}
+
+func TestHandleResume_ReportsGaps(t *testing.T) {
+	server := NewServer(&registryTestApp{})
+	server.instanceID = "epoch-2"
+	server.replay = newReplayLog(2, 1)
+	for i := 0; i < 3; i++ {
+		server.BroadcastEvent("claude-output", `{"session_id":"s1"}`)
+	}
+
+	tests := []struct {
+		name     string
+		resume   string
+		wantGap  bool
+		wantSeqs int
+	}{
+		{"ring overflowed", `{"kind":"resume","seq":0,"epoch":"epoch-2"}`, true, 2},
+		{"within the ring", `{"kind":"resume","seq":1,"epoch":"epoch-2"}`, false, 2},
+		{"server restarted", `{"kind":"resume","seq":7,"epoch":"epoch-1"}`, true, 0},
+		{"first connect", `{"kind":"resume"}`, false, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := NewClient("c", nil)
+			server.handleMessage(client, []byte(tt.resume))
+			frames := drainFrames(t, client)
+			reply := frames[len(frames)-1]
+			if reply.Gap != tt.wantGap || len(frames)-1 != tt.wantSeqs {
+				t.Errorf("gap = %v with %d events, want %v with %d", reply.Gap, len(frames)-1, tt.wantGap, tt.wantSeqs)
+			}
+		})
+	}
+
+	// Making room for a new session drops the oldest one
+	server.BroadcastEvent("claude-output", `{"session_id":"s2"}`)
+	if _, gap := server.replay.since(3); gap {
+		t.Error("Expected no gap after the dropped session's last event")
+	}
+	if _, gap := server.replay.since(2); !gap {
+		t.Error("Expected a gap for events of the dropped session")
+	}
+}
@@ -5,9 +5,11 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
+	"log/slog"
 	"net"
 	"net/http"
 	"net/http/httputil"
@@ -18,11 +20,11 @@ import (
 	"sync"
 	"sync/atomic"
 	"time"
-	"unicode"
 
 	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
 
+	"ropcode/internal/attachments"
 	"ropcode/internal/database"
 	appRuntime "ropcode/internal/runtime"
 )
@@ -55,12 +57,12 @@ type Server struct {
 
 	heartbeatMu sync.Mutex
 	stopped     atomic.Bool
-}
 
-const (
-	maxFilenameLength = 200
-	defaultFilename   = "unnamed_file"
-)
+	// handlers are extra routes mounted with Handle
+	handlers map[string]http.Handler
+	// replay numbers events and keeps recent ones for resuming clients
+	replay *replayLog
+}
 
 var heartbeatInterval = 30 * time.Second
 
@@ -91,6 +93,7 @@ func NewServer(app interface{}) *Server {
 		stopCh:       make(chan struct{}),
 		capabilities: []string{"rpc", "events"},
 		host:         "127.0.0.1",
+		replay:       newReplayLog(replayEventsPerKey, replayMaxKeys),
 	}
 
 	if provider, ok := app.(databaseProvider); ok {
@@ -125,18 +128,14 @@ func (s *Server) Start(ctx context.Context) (int, error) {
 		return 0, err
 	}
 
-	mux := http.NewServeMux()
-	mux.HandleFunc("/ws", s.handleWebSocket)
-	mux.HandleFunc("/health", s.handleHealth)
-	mux.HandleFunc("/api/upload-attachment", s.handleUploadAttachment)
-	mux.HandleFunc("/local-file/", s.handleLocalFile)
+	mux := s.newMux()
 	mux.Handle("/", s.frontendHandler())
 
 	s.httpServer = &http.Server{Handler: mux}
 
 	go func() {
 		if err := s.httpServer.Serve(listener); err != http.ErrServerClosed {
-			log.Printf("WebSocket server error: %v", err)
+			slog.Error("server error", "component", "WebSocket", "error", err)
 		}
 	}()
 
@@ -151,12 +150,52 @@ func (s *Server) Start(ctx context.Context) (int, error) {
 // ServeHTTP exposes the server mux for embedded shells that host the frontend
 // through their own asset server while still reusing the WebSocket/API routes.
 func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.newMux().ServeHTTP(w, r)
+}
+
+// Handle mounts an extra HTTP handler, guarded by the same auth key as /ws.
+// Call it before Start.
+func (s *Server) Handle(pattern string, handler http.Handler) {
+	if s.handlers == nil {
+		s.handlers = make(map[string]http.Handler)
+	}
+	s.handlers[pattern] = handler
+}
+
+// newMux 构建除前端以外的所有路由
+func (s *Server) newMux() *http.ServeMux {
 	mux := http.NewServeMux()
 	mux.HandleFunc("/ws", s.handleWebSocket)
 	mux.HandleFunc("/health", s.handleHealth)
 	mux.HandleFunc("/api/upload-attachment", s.handleUploadAttachment)
 	mux.HandleFunc("/local-file/", s.handleLocalFile)
-	mux.ServeHTTP(w, r)
+	for pattern, handler := range s.handlers {
+		mux.Handle(pattern, s.requireAuth(handler))
+	}
+	return mux
+}
+
+// authorized checks the auth key sent as X-Auth-Key, Authorization: Bearer
+// or the authKey query parameter
+func (s *Server) authorized(r *http.Request) bool {
+	if s.authKey == "" {
+		return true
+	}
+	if r.Header.Get("X-Auth-Key") == s.authKey || r.URL.Query().Get("authKey") == s.authKey {
+		return true
+	}
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && token == s.authKey
+}
+
+func (s *Server) requireAuth(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.authorized(r) {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
 }
 
 // Stop 停止服务器
@@ -194,14 +233,12 @@ func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
 func (s *Server) handleWebSocket(w http.ResponseWriter, r *http.Request) {
 	// 验证 authKey - 支持 Header 和 URL 参数两种方式
 	if s.authKey != "" {
-		authHeader := r.Header.Get("X-Auth-Key")
-		authQuery := r.URL.Query().Get("authKey")
-		authKey := authHeader
+		authKey := r.Header.Get("X-Auth-Key")
 		if authKey == "" {
-			authKey = authQuery
+			authKey = r.URL.Query().Get("authKey")
 		}
 		if authKey != s.authKey {
-			log.Printf("WS auth mismatch: expected=%q header=%q query=%q path=%s", s.authKey, authHeader, authQuery, r.URL.Path)
+			slog.Warn("auth mismatch", "component", "WebSocket", "path", r.URL.Path)
 			http.Error(w, "Unauthorized", http.StatusUnauthorized)
 			return
 		}
@@ -209,12 +246,20 @@ func (s *Server) handleWebSocket(w http.ResponseWriter, r *http.Request) {
 
 	conn, err := upgrader.Upgrade(w, r, nil)
 	if err != nil {
-		log.Printf("WebSocket upgrade error: %v", err)
+		slog.Warn("upgrade error", "component", "WebSocket", "error", err)
 		return
 	}
 
 	clientID := uuid.New().String()
 	client := NewClient(clientID, conn)
+	// Scripts can subscribe up front: /ws?topic=session:<id>&topic=...
+	if topics := r.URL.Query()["topic"]; len(topics) > 0 {
+		if err := client.Subscribe(topics); err != nil {
+			conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.ClosePolicyViolation, err.Error()), time.Now().Add(writeWait))
+			conn.Close()
+			return
+		}
+	}
 
 	s.clientsMu.Lock()
 	s.clients[clientID] = client
@@ -247,7 +292,7 @@ func (s *Server) readPump(client *Client) {
 		_, message, err := client.Conn.ReadMessage()
 		if err != nil {
 			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
-				log.Printf("WebSocket read error: %v", err)
+				slog.Warn("read error", "component", "WebSocket", "error", err)
 			}
 			break
 		}
@@ -264,8 +309,61 @@ func (s *Server) handleMessage(client *Client, message []byte) {
 		return
 	}
 
-	if msg.Kind == "rpc_request" && msg.Request != nil {
-		go s.handleRPCRequest(client, msg.Request)
+	switch msg.Kind {
+	case "rpc_request":
+		if msg.Request != nil {
+			go s.handleRPCRequest(client, msg.Request)
+		}
+	case "subscribe", "unsubscribe":
+		s.handleSubscription(client, &msg)
+	case "resume":
+		s.handleResume(client, &msg)
+	}
+}
+
+// handleResume 向重连的客户端补发其离线期间错过的事件 (see replay.go)
+func (s *Server) handleResume(client *Client, msg *WSMessage) {
+	s.replay.mu.Lock()
+	defer s.replay.mu.Unlock()
+
+	reply := &WSMessage{Kind: "resumed", Seq: s.replay.seq, Epoch: s.instanceID}
+	if msg.Epoch != s.instanceID {
+		// A different server produced the client's events
+		reply.Gap = msg.Seq > 0
+	} else {
+		entries, gap := s.replay.since(msg.Seq)
+		reply.Gap = gap
+		for _, entry := range entries {
+			if !client.wants(entry.eventType, entry.scope) {
+				continue
+			}
+			if err := client.sendEventData(entry.data); err != nil {
+				reply.Gap = true
+				break
+			}
+		}
+	}
+	// Queued behind the replayed events so it arrives after them
+	if err := client.SendMessage(reply); err != nil {
+		slog.Warn("Failed to send resume reply", "component", "WebSocket", "error", err)
+	}
+}
+
+// handleSubscription 更新客户端订阅并回复当前主题
+func (s *Server) handleSubscription(client *Client, msg *WSMessage) {
+	var err error
+	if msg.Kind == "subscribe" {
+		err = client.Subscribe(msg.Topics)
+	} else {
+		err = client.Unsubscribe(msg.Topics)
+	}
+
+	reply := &WSMessage{Kind: "subscriptions", Topics: client.Topics()}
+	if err != nil {
+		reply.Error = err.Error()
+	}
+	if err := client.SendMessage(reply); err != nil {
+		slog.Warn("Failed to send subscriptions", "component", "WebSocket", "error", err)
 	}
 }
 
@@ -279,17 +377,56 @@ func (s *Server) handleRPCRequest(client *Client, req *RPCRequest) {
 	}
 
 	if err := client.SendResponse(req.ID, result, errMsg); err != nil {
-		log.Printf("Failed to send response: %v", err)
+		slog.Warn("Failed to send response", "component", "WebSocket", "error", err)
 	}
 }
 
-// BroadcastEvent 向所有客户端广播事件
+// BroadcastEvent 向订阅了该事件的客户端推送事件.
+//
+// The event is numbered, kept in the replay log when it belongs to a
+// session or PTY, and marshaled once for all clients. A client whose queue
+// stays full past slowClientTimeout is disconnected.
 func (s *Server) BroadcastEvent(eventType string, payload interface{}) {
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		slog.Error("Failed to encode event", "component", "WebSocket", "event", eventType, "error", err)
+		return
+	}
+	var scope eventScope
+	if text, ok := payload.(string); ok {
+		scope = scopeOf(eventType, text)
+	} else {
+		scope = scopeOf(eventType, json.RawMessage(encoded))
+	}
+
+	// Numbering, logging and queueing happen under one lock so a resuming
+	// client gets its replay before any newer event
+	s.replay.mu.Lock()
+	defer s.replay.mu.Unlock()
+
+	seq := s.replay.next()
+	data, err := json.Marshal(&WSMessage{
+		Kind:  "event",
+		Event: &WSEvent{Type: eventType, Payload: json.RawMessage(encoded)},
+		Seq:   seq,
+	})
+	if err != nil {
+		slog.Error("Failed to encode event", "component", "WebSocket", "event", eventType, "error", err)
+		return
+	}
+	s.replay.record(replayEntry{seq: seq, eventType: eventType, scope: scope, data: data})
+
 	s.clientsMu.RLock()
 	defer s.clientsMu.RUnlock()
 
 	for _, client := range s.clients {
-		client.SendEvent(eventType, payload)
+		if !client.wants(eventType, scope) {
+			continue
+		}
+		if err := client.sendEventData(data); errors.Is(err, ErrClientTooSlow) {
+			log.Printf("Disconnecting slow WebSocket client %s", client.ID)
+			client.Disconnect()
+		}
 	}
 }
 
@@ -351,7 +488,7 @@ func (s *Server) heartbeatLoop() {
 		select {
 		case <-ticker.C:
 			if err := s.refreshHeartbeat(time.Now().UnixMilli()); err != nil {
-				log.Printf("Failed to refresh instance heartbeat: %v", err)
+				slog.Warn("Failed to refresh instance heartbeat", "component", "WebSocket", "error", err)
 			}
 		case <-s.stopCh:
 			return
@@ -363,13 +500,13 @@ func (s *Server) markInstanceStopped() {
 	if s.db != nil {
 		record, err := s.db.GetInstanceRecord(s.instanceID)
 		if err != nil {
-			log.Printf("Failed to load instance record for stop: %v", err)
+			slog.Error("Failed to load instance record for stop", "component", "WebSocket", "error", err)
 			return
 		}
 		record.Status = "stale"
 		record.HeartbeatAt = time.Now().UnixMilli()
 		if err := s.db.SaveInstanceRecord(record); err != nil {
-			log.Printf("Failed to persist stopped instance state: %v", err)
+			slog.Error("Failed to persist stopped instance state", "component", "WebSocket", "error", err)
 		}
 		return
 	}
@@ -379,7 +516,7 @@ func (s *Server) markInstanceStopped() {
 	}
 
 	if _, err := s.registry.MarkStaleInstances(time.Now().UnixMilli() + 1); err != nil {
-		log.Printf("Failed to mark instance stale: %v", err)
+		slog.Error("Failed to mark instance stale", "component", "WebSocket", "error", err)
 	}
 }
 
@@ -480,7 +617,7 @@ func (s *Server) proxyWebSocket(w http.ResponseWriter, r *http.Request, target *
 	// Dial backend as raw TCP
 	backendConn, err := net.Dial("tcp", backendAddr)
 	if err != nil {
-		log.Printf("WS proxy: failed to dial backend %s: %v", backendAddr, err)
+		slog.Error("failed to dial backend", "component", "WS proxy", "backend", backendAddr, "error", err)
 		http.Error(w, "WebSocket proxy failed", http.StatusBadGateway)
 		return
 	}
@@ -493,7 +630,7 @@ func (s *Server) proxyWebSocket(w http.ResponseWriter, r *http.Request, target *
 
 	// Write the original HTTP upgrade request to the backend
 	if err := r.Write(backendConn); err != nil {
-		log.Printf("WS proxy: failed to write request to backend: %v", err)
+		slog.Error("failed to write request to backend", "component", "WS proxy", "error", err)
 		http.Error(w, "WebSocket proxy failed", http.StatusBadGateway)
 		return
 	}
@@ -507,7 +644,7 @@ func (s *Server) proxyWebSocket(w http.ResponseWriter, r *http.Request, target *
 	}
 	clientConn, _, err := hijacker.Hijack()
 	if err != nil {
-		log.Printf("WS proxy: failed to hijack client connection: %v", err)
+		slog.Error("failed to hijack client connection", "component", "WS proxy", "error", err)
 		return
 	}
 	defer clientConn.Close()
@@ -588,7 +725,8 @@ func (s *Server) injectScriptIntoResponse(resp *http.Response) error {
 	return nil
 }
 
-// handleUploadAttachment handles file uploads via HTTP multipart/form-data
+// handleUploadAttachment handles file uploads via HTTP multipart/form-data.
+// Files are stored under ~/.ropcode/attachments/<sessionId>.
 func (s *Server) handleUploadAttachment(w http.ResponseWriter, r *http.Request) {
 	// 1. Validate request method
 	if r.Method != "POST" {
@@ -596,8 +734,9 @@ func (s *Server) handleUploadAttachment(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	// 2. Parse multipart form (max 50MB)
-	err := r.ParseMultipartForm(50 << 20) // 50MB
+	// 2. Parse multipart form
+	r.Body = http.MaxBytesReader(w, r.Body, attachments.MaxSize+1<<20)
+	err := r.ParseMultipartForm(attachments.MaxSize)
 	if err != nil {
 		http.Error(w, "Failed to parse form", http.StatusBadRequest)
 		return
@@ -611,94 +750,42 @@ func (s *Server) handleUploadAttachment(w http.ResponseWriter, r *http.Request)
 	}
 	defer file.Close()
 
-	// 4. Get optional projectPath
+	// 4. Get optional projectPath and sessionId
 	projectPath := r.FormValue("projectPath")
+	sessionID := r.FormValue("sessionId")
 
-	// 5. Generate safe filename: timestamp_originalname
-	timestamp := time.Now().Format("20060102-150405")
-	safeFilename := sanitizeFilename(header.Filename)
-	finalFilename := fmt.Sprintf("%s_%s", timestamp, safeFilename)
-
-	// 6. Ensure storage directory exists
+	// 5. Save into the session's attachments directory
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
 		http.Error(w, "Failed to get home directory", http.StatusInternalServerError)
 		return
 	}
-	attachmentsDir := filepath.Join(homeDir, ".claude", "attachments")
-	err = os.MkdirAll(attachmentsDir, 0755)
-	if err != nil {
-		http.Error(w, "Failed to create directory", http.StatusInternalServerError)
+	dir := attachments.SessionDir(filepath.Join(homeDir, ".ropcode", "attachments"), sessionID)
+	attachment, err := attachments.Save(dir, header.Filename, file)
+	switch {
+	case errors.Is(err, attachments.ErrUnsupported):
+		http.Error(w, err.Error(), http.StatusUnsupportedMediaType)
 		return
-	}
-
-	// 7. Create destination file
-	destPath := filepath.Join(attachmentsDir, finalFilename)
-	dest, err := os.Create(destPath)
-	if err != nil {
-		http.Error(w, "Failed to save file", http.StatusInternalServerError)
+	case errors.Is(err, attachments.ErrTooLarge):
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
 		return
-	}
-	defer dest.Close()
-
-	// 8. Write file content
-	written, err := io.Copy(dest, file)
-	if err != nil {
-		os.Remove(destPath) // Clean up incomplete file
-		http.Error(w, "Failed to write file", http.StatusInternalServerError)
+	case err != nil:
+		http.Error(w, "Failed to save file", http.StatusInternalServerError)
 		return
 	}
 
-	// 9. Return file path
+	// 6. Return file path
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(map[string]string{
-		"filePath": destPath,
-		"filename": finalFilename,
+		"filePath": attachment.Path,
+		"filename": attachment.Name,
+		"kind":     attachment.Kind,
+		"mimeType": attachment.MIMEType,
 	}); err != nil {
-		log.Printf("Failed to encode upload response: %v", err)
-	}
-
-	log.Printf("Uploaded attachment: %s (%d bytes, projectPath: %s)", finalFilename, written, projectPath)
-}
-
-// sanitizeFilename cleans a filename to prevent path traversal attacks
-// and ensure it's safe to use for storage.
-func sanitizeFilename(filename string) string {
-	// 1. Extract base filename (removes any path components)
-	filename = filepath.Base(filename)
-
-	// 2. Remove any remaining path separators and special characters
-	filename = strings.Map(func(r rune) rune {
-		// Allow alphanumeric, dots, hyphens, underscores
-		if unicode.IsLetter(r) || unicode.IsNumber(r) || r == '.' || r == '-' || r == '_' {
-			return r
-		}
-		// Replace other characters with underscore
-		return '_'
-	}, filename)
-
-	// 3. Limit filename length to maxFilenameLength characters
-	if len(filename) > maxFilenameLength {
-		originalExt := filepath.Ext(filename)
-		ext := originalExt
-		// Limit extension length to prevent panic
-		if len(ext) > maxFilenameLength/2 {
-			ext = ext[:maxFilenameLength/2]
-		}
-		nameWithoutExt := strings.TrimSuffix(filename, originalExt)
-		maxNameLength := maxFilenameLength - len(ext)
-		if len(nameWithoutExt) > maxNameLength {
-			nameWithoutExt = nameWithoutExt[:maxNameLength]
-		}
-		filename = nameWithoutExt + ext
-	}
-
-	// 4. Ensure filename is not empty
-	if filename == "" || filename == "." || filename == ".." {
-		filename = defaultFilename
+		slog.Warn("Failed to encode upload response", "component", "WebSocket", "error", err)
 	}
 
-	return filename
+	log.Printf("Uploaded attachment: %s (%d bytes, projectPath: %s)", attachment.Name, attachment.Size, projectPath)
 }
 
 // handleLocalFile serves local files by path for image preview.
@@ -724,6 +811,10 @@ func (s *Server) handleLocalFile(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Serve the file
+	// Serve the file, typed from the attachments table so PDFs and text
+	// open inline instead of by content sniffing
+	if _, mimeType, ok := attachments.Classify(filePath); ok {
+		w.Header().Set("Content-Type", mimeType)
+	}
 	http.ServeFile(w, r, filePath)
 }
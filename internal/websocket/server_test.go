@@ -2,6 +2,8 @@ package websocket
 
 import (
 	"context"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"reflect"
@@ -333,3 +335,32 @@ func TestSendResponse_AfterClientClose_DoesNotPanic(t *testing.T) {
 		t.Fatal("SendResponse did not return")
 	}
 }
+
+func TestServerHandle_RequiresAuthKey(t *testing.T) {
+	t.Setenv("ROPCODE_AUTH_KEY", "secret")
+	server := NewServer(&registryTestApp{})
+	server.Handle("/api/v1/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+
+	tests := []struct {
+		header, value string
+		want          int
+	}{
+		{"", "", http.StatusUnauthorized},
+		{"Authorization", "Bearer wrong", http.StatusUnauthorized},
+		{"Authorization", "Bearer secret", http.StatusOK},
+		{"X-Auth-Key", "secret", http.StatusOK},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/api/v1/projects", nil)
+		if tt.header != "" {
+			req.Header.Set(tt.header, tt.value)
+		}
+		recorder := httptest.NewRecorder()
+		server.ServeHTTP(recorder, req)
+		if recorder.Code != tt.want {
+			t.Errorf("%s %q: got status %d, want %d", tt.header, tt.value, recorder.Code, tt.want)
+		}
+	}
+}
@@ -0,0 +1,206 @@
+// internal/websocket/subscription.go
+package websocket
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Topics a client can subscribe to. A client that never subscribes receives
+// every event, which is what the bundled frontend relies on. After its first
+// subscribe it only receives events of its topics, plus app-wide events that
+// belong to no session, project or PTY (git status, queue changes, ...).
+const (
+	TopicAll     = "*"        // every event
+	TopicSession = "session:" // session:<session id>
+	TopicProject = "project:" // project:<path>, including paths below it
+	TopicPty     = "pty:"     // pty:<pty session id>
+	TopicEvent   = "event:"   // event:<event type>, e.g. event:claude-output
+)
+
+// subscriptionSet holds the topics of one client
+type subscriptionSet struct {
+	all      bool
+	sessions map[string]bool
+	projects map[string]bool
+	ptys     map[string]bool
+	events   map[string]bool
+}
+
+func newSubscriptionSet() *subscriptionSet {
+	return &subscriptionSet{
+		sessions: make(map[string]bool),
+		projects: make(map[string]bool),
+		ptys:     make(map[string]bool),
+		events:   make(map[string]bool),
+	}
+}
+
+// topicTarget returns the map a topic lives in and its value
+func (s *subscriptionSet) topicTarget(topic string) (map[string]bool, string, error) {
+	for prefix, target := range map[string]map[string]bool{
+		TopicSession: s.sessions,
+		TopicProject: s.projects,
+		TopicPty:     s.ptys,
+		TopicEvent:   s.events,
+	} {
+		if value, ok := strings.CutPrefix(topic, prefix); ok {
+			if value == "" {
+				return nil, "", fmt.Errorf("topic %q has no value", topic)
+			}
+			if prefix == TopicProject {
+				value = trimPathSeparators(value)
+			}
+			return target, value, nil
+		}
+	}
+	return nil, "", fmt.Errorf("unknown topic %q", topic)
+}
+
+// update adds or removes topics; nothing changes when a topic is invalid
+func (s *subscriptionSet) update(topics []string, subscribe bool) error {
+	type change struct {
+		target map[string]bool
+		value  string
+	}
+	changes := make([]change, 0, len(topics))
+	all := s.all
+	for _, topic := range topics {
+		topic = strings.TrimSpace(topic)
+		if topic == TopicAll {
+			all = subscribe
+			continue
+		}
+		target, value, err := s.topicTarget(topic)
+		if err != nil {
+			return err
+		}
+		changes = append(changes, change{target, value})
+	}
+
+	s.all = all
+	for _, c := range changes {
+		if subscribe {
+			c.target[c.value] = true
+		} else {
+			delete(c.target, c.value)
+		}
+	}
+	return nil
+}
+
+// topics lists the subscribed topics in a stable order
+func (s *subscriptionSet) topics() []string {
+	var topics []string
+	if s.all {
+		topics = append(topics, TopicAll)
+	}
+	for prefix, values := range map[string]map[string]bool{
+		TopicSession: s.sessions,
+		TopicProject: s.projects,
+		TopicPty:     s.ptys,
+		TopicEvent:   s.events,
+	} {
+		for value := range values {
+			topics = append(topics, prefix+value)
+		}
+	}
+	sort.Strings(topics)
+	return topics
+}
+
+// matches reports whether an event belongs to one of the topics
+func (s *subscriptionSet) matches(eventType string, scope eventScope) bool {
+	if s.all || s.events[eventType] {
+		return true
+	}
+	if scope == (eventScope{}) {
+		return true
+	}
+	if s.sessions[scope.Session] || s.ptys[scope.Pty] {
+		return true
+	}
+	if scope.Project == "" {
+		return false
+	}
+	for project := range s.projects {
+		if scope.Project == project || strings.HasPrefix(scope.Project, project+"/") || strings.HasPrefix(scope.Project, project+`\`) {
+			return true
+		}
+	}
+	return false
+}
+
+func trimPathSeparators(p string) string {
+	trimmed := strings.TrimRight(p, `/\`)
+	if trimmed == "" {
+		return p
+	}
+	return trimmed
+}
+
+// eventScope is the session, project and PTY an event belongs to. Zero
+// fields mean the event does not name one.
+type eventScope struct {
+	Session string
+	Project string
+	Pty     string
+}
+
+// scopeFields are the payload fields events use to name their scope
+type scopeFields struct {
+	SessionID   string   `json:"session_id"`
+	ID          string   `json:"id"`
+	Cwd         string   `json:"cwd"`
+	ProjectPath string   `json:"project_path"`
+	LocalPath   string   `json:"local_path"`
+	Path        string   `json:"path"`
+	Lines       []string `json:"lines"`
+}
+
+// scopeOf reads the scope of an event from its payload. Provider output is
+// a JSON string carrying session_id and cwd; batched output carries its
+// lines; other events are structs or maps with similar fields.
+func scopeOf(eventType string, payload interface{}) eventScope {
+	var data []byte
+	switch v := payload.(type) {
+	case nil:
+		return eventScope{}
+	case string:
+		if !strings.HasPrefix(strings.TrimSpace(v), "{") {
+			return eventScope{}
+		}
+		data = []byte(v)
+	case json.RawMessage:
+		data = v
+	default:
+		var err error
+		if data, err = json.Marshal(v); err != nil {
+			return eventScope{}
+		}
+	}
+
+	var fields scopeFields
+	// Type mismatches in unrelated fields still leave the others decoded
+	_ = json.Unmarshal(data, &fields)
+
+	scope := eventScope{Session: fields.SessionID}
+	if scope.Session == "" && strings.HasPrefix(eventType, "session:") {
+		scope.Session = fields.ID
+	}
+	for _, candidate := range []string{fields.ProjectPath, fields.Cwd, fields.LocalPath, fields.Path} {
+		if candidate != "" {
+			scope.Project = trimPathSeparators(candidate)
+			break
+		}
+	}
+	if scope.Project == "" && len(fields.Lines) > 0 {
+		scope.Project = scopeOf(eventType, fields.Lines[0]).Project
+	}
+	if strings.HasPrefix(eventType, "pty-") {
+		scope.Pty, scope.Session = scope.Session, ""
+	}
+	return scope
+}
@@ -0,0 +1,149 @@
+package websocket
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// drainEvents returns the event types queued for a client
+func drainEvents(t *testing.T, client *Client) []string {
+	t.Helper()
+	var types []string
+	for {
+		select {
+		case data := <-client.Events:
+			var msg WSMessage
+			if err := json.Unmarshal(data, &msg); err != nil || msg.Event == nil {
+				t.Fatalf("Invalid event frame %s: %v", data, err)
+			}
+			types = append(types, msg.Event.Type)
+		default:
+			return types
+		}
+	}
+}
+
+func TestBroadcastEvent_FiltersBySubscription(t *testing.T) {
+	server := NewServer(&registryTestApp{})
+	everything := NewClient("everything", nil)
+	session := NewClient("session", nil)
+	project := NewClient("project", nil)
+	pty := NewClient("pty", nil)
+	server.clients = map[string]*Client{"everything": everything, "session": session, "project": project, "pty": pty}
+
+	if err := session.Subscribe([]string{"session:s1"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := project.Subscribe([]string{"project:/work/app/", "event:agent-batch:changed"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := pty.Subscribe([]string{"pty:p1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	server.BroadcastEvent("claude-output", `{"type":"assistant","session_id":"s1","cwd":"/work/app/sub"}`)
+	server.BroadcastEvent("claude-output-batch", map[string]interface{}{"session_id": "s2", "lines": []string{`{"session_id":"s2","cwd":"/work/other"}`}})
+	server.BroadcastEvent("pty-output", map[string]interface{}{"session_id": "p1", "data": "ls"})
+	server.BroadcastEvent("session:changed", map[string]string{"id": "s1", "cwd": "/elsewhere"})
+	server.BroadcastEvent("agent-batch:changed", map[string]string{"project_path": "/work/other"})
+	server.BroadcastEvent("session-queue:changed", map[string]interface{}{"entries": []string{}})
+
+	tests := []struct {
+		client *Client
+		want   []string
+	}{
+		{everything, []string{"claude-output", "claude-output-batch", "pty-output", "session:changed", "agent-batch:changed", "session-queue:changed"}},
+		{session, []string{"claude-output", "session:changed", "session-queue:changed"}},
+		{project, []string{"claude-output", "agent-batch:changed", "session-queue:changed"}},
+		{pty, []string{"pty-output", "session-queue:changed"}},
+	}
+	for _, tt := range tests {
+		if got := drainEvents(t, tt.client); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("client %s got %v, want %v", tt.client.ID, got, tt.want)
+		}
+	}
+
+	if err := session.Unsubscribe([]string{"session:s1"}); err != nil {
+		t.Fatal(err)
+	}
+	server.BroadcastEvent("claude-output", `{"session_id":"s1"}`)
+	if got := drainEvents(t, session); len(got) != 0 {
+		t.Errorf("Expected no events after unsubscribing, got %v", got)
+	}
+	if err := session.Subscribe([]string{"*"}); err != nil {
+		t.Fatal(err)
+	}
+	server.BroadcastEvent("claude-output", `{"session_id":"s9"}`)
+	if got := drainEvents(t, session); len(got) != 1 {
+		t.Errorf("Expected every event after subscribing to *, got %v", got)
+	}
+}
+
+func TestHandleMessage_Subscribe(t *testing.T) {
+	server := NewServer(&registryTestApp{})
+	client := NewClient("c", nil)
+
+	server.handleMessage(client, []byte(`{"kind":"subscribe","topics":["session:s1","project:/a"]}`))
+	server.handleMessage(client, []byte(`{"kind":"subscribe","topics":["bogus"]}`))
+
+	var replies []WSMessage
+	for len(client.Responses) > 0 {
+		var msg WSMessage
+		json.Unmarshal(<-client.Responses, &msg)
+		replies = append(replies, msg)
+	}
+	if len(replies) != 2 {
+		t.Fatalf("Expected two subscription replies, got %#v", replies)
+	}
+	if replies[0].Kind != "subscriptions" || !reflect.DeepEqual(replies[0].Topics, []string{"project:/a", "session:s1"}) || replies[0].Error != "" {
+		t.Errorf("Unexpected reply: %#v", replies[0])
+	}
+	if replies[1].Error == "" || !reflect.DeepEqual(replies[1].Topics, replies[0].Topics) {
+		t.Errorf("Expected an invalid topic to be refused without changes, got %#v", replies[1])
+	}
+}
+
+func TestClient_BackpressureReportsDroppedEvents(t *testing.T) {
+	client := NewClient("slow", nil)
+	for i := 0; i < eventBufferSize; i++ {
+		if err := client.SendEvent("tick", i); err != nil {
+			t.Fatalf("SendEvent %d failed: %v", i, err)
+		}
+	}
+	for i := 0; i < 3; i++ {
+		if err := client.SendEvent("tick", i); !errors.Is(err, ErrClientBufferFull) {
+			t.Fatalf("Expected a full buffer, got %v", err)
+		}
+	}
+
+	// Free two slots: one for the drop notice, one for the next event
+	<-client.Events
+	<-client.Events
+	if err := client.SendEvent("tick", "next"); err != nil {
+		t.Fatalf("SendEvent after draining failed: %v", err)
+	}
+	var frames [][]byte
+	for len(client.Events) > 0 {
+		frames = append(frames, <-client.Events)
+	}
+	var notice WSMessage
+	json.Unmarshal(frames[len(frames)-2], &notice)
+	if notice.Event == nil || notice.Event.Type != eventsDroppedEvent || notice.Event.Payload.(map[string]interface{})["count"] != float64(3) {
+		t.Errorf("Expected a drop notice before the next event, got %s", frames[len(frames)-2])
+	}
+
+	original := slowClientTimeout
+	slowClientTimeout = 10 * time.Millisecond
+	defer func() { slowClientTimeout = original }()
+	for len(client.Events) < eventBufferSize {
+		client.SendEvent("tick", 0)
+	}
+	client.SendEvent("tick", 0)
+	time.Sleep(20 * time.Millisecond)
+	if err := client.SendEvent("tick", 0); !errors.Is(err, ErrClientTooSlow) {
+		t.Errorf("Expected a client full for too long to be too slow, got %v", err)
+	}
+}
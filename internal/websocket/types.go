@@ -23,7 +23,8 @@ type WSEvent struct {
 
 // WSMessage 是 WebSocket 消息的统一封装
 type WSMessage struct {
-	// 消息类型: "rpc_request", "rpc_response", "event"
+	// 消息类型: "rpc_request", "rpc_response", "event", "subscribe",
+	// "unsubscribe", "subscriptions", "resume", "resumed"
 	Kind string `json:"kind"`
 
 	// RPC 请求 (kind == "rpc_request")
@@ -34,4 +35,20 @@ type WSMessage struct {
 
 	// 事件 (kind == "event")
 	Event *WSEvent `json:"event,omitempty"`
+
+	// 订阅主题 (kind == "subscribe", "unsubscribe", "subscriptions")
+	Topics []string `json:"topics,omitempty"`
+
+	// 订阅失败时的错误信息 (kind == "subscriptions")
+	Error string `json:"error,omitempty"`
+
+	// 事件序号 (kind == "event"); 续传时为客户端最后收到的序号
+	// (kind == "resume") 或服务器当前序号 (kind == "resumed")
+	Seq uint64 `json:"seq,omitempty"`
+
+	// 序号所属的服务器实例 (kind == "resume", "resumed")
+	Epoch string `json:"epoch,omitempty"`
+
+	// 有错过的事件无法补发, 客户端应重新加载状态 (kind == "resumed")
+	Gap bool `json:"gap,omitempty"`
 }
@@ -0,0 +1,13 @@
+//go:build !windows
+
+package wsl
+
+import "fmt"
+
+// Supported reports whether WSL exists on this OS
+const Supported = false
+
+// Distros lists the installed WSL distros
+func Distros() ([]string, error) {
+	return nil, fmt.Errorf("WSL is only available on Windows")
+}
@@ -0,0 +1,20 @@
+//go:build windows
+
+package wsl
+
+import (
+	"fmt"
+	"os/exec"
+)
+
+// Supported reports whether WSL exists on this OS
+const Supported = true
+
+// Distros lists the installed WSL distros
+func Distros() ([]string, error) {
+	output, err := exec.Command(Program, "--list", "--quiet").Output()
+	if err != nil {
+		return nil, fmt.Errorf("WSL is not installed or has no distro: %w", err)
+	}
+	return parseDistros(output), nil
+}
@@ -0,0 +1,69 @@
+package wsl
+
+import (
+	"regexp"
+	"strings"
+)
+
+var drivePattern = regexp.MustCompile(`^([A-Za-z]):(/|$)`)
+
+// uncPrefixes lead the Windows paths of files inside a distro
+var uncPrefixes = []string{`//wsl.localhost/`, `//wsl$/`}
+
+// IsWindowsPath reports whether path is an absolute Windows path: a drive
+// path or a \\wsl.localhost\ or \\wsl$\ share
+func IsWindowsPath(path string) bool {
+	slashed := strings.ReplaceAll(path, `\`, "/")
+	if drivePattern.MatchString(slashed) {
+		return true
+	}
+	_, ok := uncRest(slashed)
+	return ok
+}
+
+// uncRest returns what follows \\wsl.localhost\<distro> in a slashed path
+func uncRest(slashed string) (string, bool) {
+	for _, prefix := range uncPrefixes {
+		if len(slashed) < len(prefix) || !strings.EqualFold(slashed[:len(prefix)], prefix) {
+			continue
+		}
+		_, rest, _ := strings.Cut(slashed[len(prefix):], "/")
+		return "/" + rest, true
+	}
+	return "", false
+}
+
+// ToLinux returns the path a Windows path has inside WSL: drive paths
+// under /mnt and paths on a distro's share as the distro's own. Other
+// paths only get forward slashes.
+func ToLinux(path string) string {
+	slashed := strings.ReplaceAll(path, `\`, "/")
+	if match := drivePattern.FindStringSubmatch(slashed); match != nil {
+		rest := strings.TrimPrefix(slashed[2:], "/")
+		linux := "/mnt/" + strings.ToLower(match[1])
+		if rest != "" {
+			linux += "/" + rest
+		}
+		return linux
+	}
+	if rest, ok := uncRest(slashed); ok {
+		return rest
+	}
+	return slashed
+}
+
+// ToWindows returns the Windows path of a path inside distro: /mnt/<drive>
+// paths on their drive, the rest on the distro's \\wsl.localhost\ share.
+// Relative paths only get backslashes.
+func ToWindows(distro, path string) string {
+	if !strings.HasPrefix(path, "/") {
+		return strings.ReplaceAll(path, "/", `\`)
+	}
+	if rest, ok := strings.CutPrefix(path, "/mnt/"); ok {
+		drive, tail, _ := strings.Cut(rest, "/")
+		if len(drive) == 1 {
+			return strings.ToUpper(drive) + `:\` + strings.ReplaceAll(tail, "/", `\`)
+		}
+	}
+	return `\\wsl.localhost\` + distro + strings.ReplaceAll(path, "/", `\`)
+}
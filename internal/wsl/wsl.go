@@ -0,0 +1,142 @@
+// Package wsl runs commands inside a Windows Subsystem for Linux distro.
+// Most Windows users of the provider CLIs work in WSL, with the CLIs, git
+// and their projects installed there, so provider sessions, terminals and
+// git can be pointed at a distro through wsl.exe. Paths cross between the
+// Windows form the UI uses and the Linux form the distro sees with ToLinux
+// and ToWindows.
+package wsl
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"unicode/utf16"
+)
+
+// Program launches commands in a distro
+const Program = "wsl.exe"
+
+// distroArgs selects distro, or the default one when it is empty
+func distroArgs(distro string) []string {
+	if distro == "" {
+		return []string{}
+	}
+	return []string{"-d", distro}
+}
+
+// Command returns a command that runs argv inside distro, in dir and with
+// env added to its environment. argv runs under the user's login shell to
+// get the same PATH as a terminal in the distro, where CLIs installed with
+// nvm or in ~/.local/bin live. Arguments that are absolute Windows paths,
+// like an MCP config file, are passed in their Linux form.
+func Command(ctx context.Context, distro, dir string, argv, env []string) (*exec.Cmd, error) {
+	if len(argv) == 0 {
+		return nil, fmt.Errorf("no command given")
+	}
+	// --exec hands the arguments over as they are instead of through the
+	// default shell, which would split the script again
+	args := append(distroArgs(distro), "--exec", "/bin/sh", "-c", Script(dir, argv, env))
+	return exec.CommandContext(ctx, Program, args...), nil
+}
+
+// Script builds the shell script of Command
+func Script(dir string, argv, env []string) string {
+	inner := make([]string, 0, len(argv)+len(env)+2)
+	inner = append(inner, "exec", "env")
+	for _, entry := range env {
+		inner = append(inner, quote(entry))
+	}
+	for _, arg := range argv {
+		if IsWindowsPath(arg) {
+			arg = ToLinux(arg)
+		}
+		inner = append(inner, quote(arg))
+	}
+	script := `shell=$(getent passwd "$(id -un)" | cut -d: -f7); `
+	if dir != "" {
+		script += "cd -- " + quote(ToLinux(dir)) + " && "
+	}
+	return script + `exec "${shell:-/bin/sh}" -lc ` + quote(strings.Join(inner, " "))
+}
+
+// Exec returns a command that runs name inside distro directly, without a
+// shell. Arguments that are absolute Windows paths are passed in their
+// Linux form; the working directory set on the command is translated by
+// wsl.exe.
+func Exec(distro, name string, args ...string) *exec.Cmd {
+	return ExecContext(context.Background(), distro, name, args...)
+}
+
+// ExecContext is Exec with a command killed when ctx is done
+func ExecContext(ctx context.Context, distro, name string, args ...string) *exec.Cmd {
+	wslArgs := append(distroArgs(distro), "--exec", name)
+	for _, arg := range args {
+		if IsWindowsPath(arg) {
+			arg = ToLinux(arg)
+		}
+		wslArgs = append(wslArgs, arg)
+	}
+	return exec.CommandContext(ctx, Program, wslArgs...)
+}
+
+// ShellArgs returns the wsl.exe arguments that open the user's login shell
+// in distro, in dir
+func ShellArgs(distro, dir string) []string {
+	args := distroArgs(distro)
+	if dir != "" {
+		args = append(args, "--cd", ToLinux(dir))
+	}
+	return args
+}
+
+// ShareEnv returns env with WSLENV naming the variables set in vars, so
+// wsl.exe carries them into the distro. Variables holding a Windows path
+// are marked for translation.
+func ShareEnv(env, vars []string) []string {
+	var names []string
+	if existing := os.Getenv("WSLENV"); existing != "" {
+		names = append(names, existing)
+	}
+	for _, entry := range vars {
+		name, value, ok := strings.Cut(entry, "=")
+		if !ok || name == "" || name == "WSLENV" {
+			continue
+		}
+		if IsWindowsPath(value) {
+			name += "/p"
+		}
+		names = append(names, name)
+	}
+	if len(names) == 0 {
+		return env
+	}
+	return append(env, "WSLENV="+strings.Join(names, ":"))
+}
+
+// parseDistros reads the names `wsl.exe --list --quiet` prints, which come
+// in UTF-16
+func parseDistros(output []byte) []string {
+	text := string(output)
+	if len(output) >= 2 && len(output)%2 == 0 && (output[1] == 0 || output[0] == 0xff) {
+		units := make([]uint16, 0, len(output)/2)
+		for i := 0; i+1 < len(output); i += 2 {
+			units = append(units, uint16(output[i])|uint16(output[i+1])<<8)
+		}
+		text = string(utf16.Decode(units))
+	}
+	var distros []string
+	for _, line := range strings.Split(text, "\n") {
+		name := strings.Trim(line, "\r\ufeff\x00 \t")
+		if name != "" {
+			distros = append(distros, name)
+		}
+	}
+	return distros
+}
+
+// quote quotes s for a POSIX shell
+func quote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,102 @@
+package wsl
+
+import (
+	"context"
+	"os/exec"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestPathTranslation(t *testing.T) {
+	toLinux := map[string]string{
+		`C:\Users\me\project`:                `/mnt/c/Users/me/project`,
+		`d:/work`:                            `/mnt/d/work`,
+		`C:\`:                                `/mnt/c`,
+		`\\wsl.localhost\Ubuntu\home\me\src`: `/home/me/src`,
+		`\\WSL$\Debian\etc`:                  `/etc`,
+		`/home/me`:                           `/home/me`,
+		`src\main.go`:                        `src/main.go`,
+	}
+	for windows, want := range toLinux {
+		if got := ToLinux(windows); got != want {
+			t.Errorf("ToLinux(%q) = %q, want %q", windows, got, want)
+		}
+	}
+
+	toWindows := map[string]string{
+		`/mnt/c/Users/me/project`: `C:\Users\me\project`,
+		`/mnt/d`:                  `D:\`,
+		`/home/me/src`:            `\\wsl.localhost\Ubuntu\home\me\src`,
+		`/mnt/wsl/shared`:         `\\wsl.localhost\Ubuntu\mnt\wsl\shared`,
+		`src/main.go`:             `src\main.go`,
+	}
+	for linux, want := range toWindows {
+		if got := ToWindows("Ubuntu", linux); got != want {
+			t.Errorf("ToWindows(%q) = %q, want %q", linux, got, want)
+		}
+	}
+
+	if IsWindowsPath("/home/me") || IsWindowsPath("HEAD") || !IsWindowsPath(`\\wsl.localhost\Ubuntu\home`) {
+		t.Error("IsWindowsPath() misclassifies paths")
+	}
+}
+
+func TestScriptRunsInTheLoginShell(t *testing.T) {
+	cmd, err := Command(context.Background(), "Ubuntu", `C:\src\it's`, []string{"claude", "-p", "say \"hi\""}, []string{"API_KEY=k"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{Program, "-d", "Ubuntu", "--exec", "/bin/sh", "-c"}; !reflect.DeepEqual(cmd.Args[:6], want) {
+		t.Fatalf("args = %q", cmd.Args)
+	}
+	if _, err := Command(context.Background(), "", "", nil, nil); err == nil {
+		t.Error("Command() without argv should fail")
+	}
+
+	if runtime.GOOS == "windows" {
+		t.Skip("needs a POSIX shell")
+	}
+	// Run the script with a stand-in login shell that ignores -l and the
+	// directory check
+	script := strings.Replace(Script("", []string{"sh", "-c", `printf '%s|%s|%s' "$API_KEY" "$1" "$2"`, "x", `it's "quoted"`, `C:\mcp.json`}, []string{"API_KEY=k"}),
+		`"${shell:-/bin/sh}" -lc`, `/bin/sh -c`, 1)
+	out, err := exec.Command("/bin/sh", "-c", script).Output()
+	if err != nil {
+		t.Fatalf("script failed: %v", err)
+	}
+	if got := string(out); got != `k|it's "quoted"|/mnt/c/mcp.json` {
+		t.Errorf("output = %q", got)
+	}
+}
+
+func TestExecTranslatesPathArguments(t *testing.T) {
+	cmd := Exec("Ubuntu", "git", "worktree", "add", `C:\src\feature`, "-m", "fix C: drive")
+	want := []string{Program, "-d", "Ubuntu", "--exec", "git", "worktree", "add", "/mnt/c/src/feature", "-m", "fix C: drive"}
+	if !reflect.DeepEqual(cmd.Args, want) {
+		t.Errorf("args = %q", cmd.Args)
+	}
+	if got := ShellArgs("", `C:\src`); !reflect.DeepEqual(got, []string{"--cd", "/mnt/c/src"}) {
+		t.Errorf("ShellArgs() = %q", got)
+	}
+}
+
+func TestShareEnv(t *testing.T) {
+	t.Setenv("WSLENV", "USERPROFILE/p")
+	env := ShareEnv([]string{"A=1"}, []string{"API_KEY=k", `GIT_INDEX_FILE=C:\tmp\index`})
+	if got := env[len(env)-1]; got != "WSLENV=USERPROFILE/p:API_KEY:GIT_INDEX_FILE/p" {
+		t.Errorf("WSLENV = %q", got)
+	}
+}
+
+func TestParseDistros(t *testing.T) {
+	// "Ubuntu\r\nDebian\r\n" in UTF-16LE, as wsl.exe prints it
+	var output []byte
+	for _, r := range "Ubuntu\r\nDebian\r\n" {
+		output = append(output, byte(r), 0)
+	}
+	if got := parseDistros(output); !reflect.DeepEqual(got, []string{"Ubuntu", "Debian"}) {
+		t.Errorf("parseDistros() = %q", got)
+	}
+}
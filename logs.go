@@ -0,0 +1,51 @@
+// logs.go
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"ropcode/internal/logging"
+)
+
+const logLevelSettingKey = "log_level"
+
+// GetRecentLogs returns up to limit of the newest log records at level or
+// above, oldest first; an empty level returns every level
+func (a *App) GetRecentLogs(level string, limit int) ([]logging.Entry, error) {
+	return logging.Recent(level, limit)
+}
+
+// GetLogLevel returns the lowest level that is logged
+func (a *App) GetLogLevel() string {
+	return logging.Level()
+}
+
+// SetLogLevel sets and saves the lowest level that is logged: debug, info,
+// warn or error. Without a database the level lasts until restart.
+func (a *App) SetLogLevel(level string) error {
+	if _, err := logging.ParseLevel(level); err != nil {
+		return err
+	}
+	if a.dbManager == nil {
+		return logging.SetLevel(level)
+	}
+	if _, err := a.UpdateSettings(map[string]interface{}{logLevelSettingKey: level}); err != nil {
+		return fmt.Errorf("failed to save log level: %w", err)
+	}
+	return nil
+}
+
+// applyLogLevel applies the saved log level
+func (a *App) applyLogLevel() {
+	if a.dbManager == nil {
+		return
+	}
+	level, err := a.dbManager.GetSetting(logLevelSettingKey)
+	if err != nil || level == "" {
+		return
+	}
+	if err := logging.SetLevel(level); err != nil {
+		log.Printf("[logging] ignoring saved level: %v", err)
+	}
+}
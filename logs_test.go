@@ -0,0 +1,38 @@
+package main
+
+import (
+	"testing"
+
+	"ropcode/internal/logging"
+)
+
+func TestSetLogLevelSavesAndApplies(t *testing.T) {
+	t.Cleanup(func() { logging.SetLevel("info") })
+	app := &App{dbManager: openAppConfigTestDB(t)}
+
+	if err := app.SetLogLevel("debug"); err != nil {
+		t.Fatal(err)
+	}
+	if app.GetLogLevel() != "debug" {
+		t.Errorf("GetLogLevel() = %q, want debug", app.GetLogLevel())
+	}
+	if saved, _ := app.GetSetting(logLevelSettingKey); saved != "debug" {
+		t.Errorf("Expected the level saved, got %q", saved)
+	}
+	if err := app.SetLogLevel("chatty"); err == nil {
+		t.Error("Expected an unknown level to be rejected")
+	}
+
+	logging.SetLevel("info")
+	app.applyLogLevel()
+	if app.GetLogLevel() != "debug" {
+		t.Errorf("Expected the saved level applied at startup, got %q", app.GetLogLevel())
+	}
+
+	if err := app.SaveSetting(logLevelSettingKey, "error"); err != nil || app.GetLogLevel() != "error" {
+		t.Errorf("Expected a raw setting change to apply, level %q, err %v", app.GetLogLevel(), err)
+	}
+	if _, err := app.GetRecentLogs("chatty", 10); err == nil {
+		t.Error("Expected GetRecentLogs to reject an unknown level")
+	}
+}
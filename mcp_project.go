@@ -0,0 +1,198 @@
+// mcp_project.go
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"log/slog"
+	"regexp"
+	"sort"
+
+	"ropcode/internal/claude"
+	"ropcode/internal/codex"
+	"ropcode/internal/mcp"
+)
+
+// ProjectMcpServer is an MCP server as seen by the sessions of a project
+type ProjectMcpServer struct {
+	Name      string `json:"name"`
+	Scope     string `json:"scope"` // "global" or "project"
+	Transport string `json:"transport"`
+	Command   string `json:"command,omitempty"`
+	URL       string `json:"url,omitempty"`
+	Enabled   bool   `json:"enabled"`
+}
+
+// ListProjectMcpServers merges the global servers with the project's own
+// servers and reports whether each is enabled for the project
+func (a *App) ListProjectMcpServers(projectPath string) ([]ProjectMcpServer, error) {
+	project, err := a.McpReadProjectConfig(projectPath)
+	if err != nil {
+		return nil, err
+	}
+
+	byName := make(map[string]ProjectMcpServer)
+	if a.mcpManager != nil {
+		servers, err := a.mcpManager.ListMcpServers()
+		if err != nil {
+			return nil, err
+		}
+		for _, server := range servers {
+			byName[server.Name] = ProjectMcpServer{
+				Name:      server.Name,
+				Scope:     "global",
+				Transport: server.Transport,
+				Command:   server.Command,
+				URL:       server.URL,
+			}
+		}
+	}
+	for name, config := range project.Servers {
+		transport, err := config.Transport()
+		if err != nil {
+			transport = config.Type
+		}
+		byName[name] = ProjectMcpServer{
+			Name:      name,
+			Scope:     "project",
+			Transport: transport,
+			Command:   config.Command,
+			URL:       config.URL,
+		}
+	}
+
+	result := make([]ProjectMcpServer, 0, len(byName))
+	for name, server := range byName {
+		server.Enabled = mcpServerEnabled(project, name)
+		result = append(result, server)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result, nil
+}
+
+// SetProjectMcpServerEnabled enables or disables a server for the sessions of
+// a project
+func (a *App) SetProjectMcpServerEnabled(projectPath, name string, enabled bool) error {
+	if name == "" {
+		return fmt.Errorf("server name is required")
+	}
+	project, err := a.McpReadProjectConfig(projectPath)
+	if err != nil {
+		return err
+	}
+	if project.Enabled == nil {
+		project.Enabled = make(map[string]bool)
+	}
+	if enabled {
+		delete(project.Enabled, name)
+	} else {
+		project.Enabled[name] = false
+	}
+	_, err = a.McpSaveProjectConfig(projectPath, project)
+	return err
+}
+
+func mcpServerEnabled(project *MCPProjectConfig, name string) bool {
+	enabled, ok := project.Enabled[name]
+	return !ok || enabled
+}
+
+// projectMcpSelection is the MCP server selection of a project
+type projectMcpSelection struct {
+	servers  map[string]mcp.MCPServerConfig // enabled project servers
+	disabled []string                       // disabled servers of any scope
+}
+
+// loadProjectMcpSelection reads a project's MCP selection. Projects without
+// an mcp.json select nothing, leaving the CLIs' defaults untouched.
+func (a *App) loadProjectMcpSelection(projectPath string) projectMcpSelection {
+	selection := projectMcpSelection{servers: make(map[string]mcp.MCPServerConfig)}
+	if projectPath == "" {
+		return selection
+	}
+	project, err := a.McpReadProjectConfig(projectPath)
+	if err != nil {
+		slog.Warn("Failed to read project MCP config", "component", "MCP", "project", projectPath, "error", err)
+		return selection
+	}
+	for name, config := range project.Servers {
+		if !mcpServerEnabled(project, name) {
+			continue
+		}
+		transport, err := config.Transport()
+		if err != nil {
+			log.Printf("[MCP] Skipping project server %s: %v", name, err)
+			continue
+		}
+		if transport != mcp.TransportStdio {
+			config.Type = transport
+		}
+		selection.servers[name] = config
+	}
+	for name, enabled := range project.Enabled {
+		if !enabled {
+			selection.disabled = append(selection.disabled, name)
+		}
+	}
+	sort.Strings(selection.disabled)
+	return selection
+}
+
+var claudeMcpNameChars = regexp.MustCompile(`[^A-Za-z0-9_-]`)
+
+// applyProjectMcpToClaude adds the project's MCP selection to a Claude session
+func (a *App) applyProjectMcpToClaude(config *claude.SessionConfig) {
+	selection := a.loadProjectMcpSelection(config.ProjectPath)
+	if len(selection.servers) > 0 {
+		data, err := json.Marshal(map[string]interface{}{"mcpServers": selection.servers})
+		if err == nil {
+			config.McpConfig = string(data)
+		}
+	}
+	for _, name := range selection.disabled {
+		// Claude names MCP tools mcp__<server>__<tool>; the server prefix
+		// alone matches all of them.
+		config.DisallowedTools = append(config.DisallowedTools, "mcp__"+claudeMcpNameChars.ReplaceAllString(name, "_"))
+	}
+}
+
+// applyProjectMcpToCodex adds the project's MCP selection to a Codex session.
+// Codex can only disable servers from its own config.toml and can't reach SSE
+// servers.
+func (a *App) applyProjectMcpToCodex(config *codex.SessionConfig) {
+	selection := a.loadProjectMcpSelection(config.ProjectPath)
+	for name, server := range selection.servers {
+		if server.Type == mcp.TransportSSE {
+			log.Printf("[MCP] Codex does not support SSE server %s, skipping", name)
+			continue
+		}
+		if config.McpServers == nil {
+			config.McpServers = make(map[string]codex.McpServer)
+		}
+		config.McpServers[name] = codex.McpServer{
+			Command: server.Command,
+			Args:    server.Args,
+			Env:     server.Env,
+			URL:     server.URL,
+			Headers: server.Headers,
+		}
+	}
+	if len(selection.disabled) == 0 {
+		return
+	}
+	configured, err := codex.ConfiguredMcpServers()
+	if err != nil {
+		slog.Warn("Failed to read codex MCP servers", "component", "MCP", "error", err)
+		return
+	}
+	known := make(map[string]bool, len(configured))
+	for _, name := range configured {
+		known[name] = true
+	}
+	for _, name := range selection.disabled {
+		if known[name] {
+			config.DisabledMcpServers = append(config.DisabledMcpServers, name)
+		}
+	}
+}
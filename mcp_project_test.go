@@ -0,0 +1,121 @@
+package main
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"ropcode/internal/claude"
+	"ropcode/internal/codex"
+	"ropcode/internal/mcp"
+)
+
+func newMcpTestApp(t *testing.T) *App {
+	t.Helper()
+	manager := mcp.NewManager(t.TempDir())
+	manager.SetClaudeBinary("/definitely/missing/claude")
+	if err := manager.SaveMcpServer("github", &mcp.MCPServerConfig{Command: "npx", Args: []string{"github-mcp"}}); err != nil {
+		t.Fatalf("SaveMcpServer failed: %v", err)
+	}
+	return &App{mcpManager: manager}
+}
+
+func TestProjectMcpSelection(t *testing.T) {
+	app := newMcpTestApp(t)
+	project := t.TempDir()
+	t.Setenv("CODEX_HOME", t.TempDir())
+
+	if _, err := app.McpSaveProjectConfig(project, &MCPProjectConfig{Servers: map[string]mcp.MCPServerConfig{
+		"docs":  {Type: "http", URL: "https://docs.example/mcp"},
+		"local": {Command: "node", Args: []string{"server.js"}},
+	}}); err != nil {
+		t.Fatalf("McpSaveProjectConfig failed: %v", err)
+	}
+	if err := app.SetProjectMcpServerEnabled(project, "github", false); err != nil {
+		t.Fatalf("SetProjectMcpServerEnabled failed: %v", err)
+	}
+	if err := app.SetProjectMcpServerEnabled(project, "local", false); err != nil {
+		t.Fatalf("SetProjectMcpServerEnabled failed: %v", err)
+	}
+
+	servers, err := app.ListProjectMcpServers(project)
+	if err != nil {
+		t.Fatalf("ListProjectMcpServers failed: %v", err)
+	}
+	enabled := map[string]bool{}
+	for _, server := range servers {
+		enabled[server.Name+"/"+server.Scope] = server.Enabled
+	}
+	if len(servers) != 3 || !enabled["docs/project"] || enabled["local/project"] || enabled["github/global"] {
+		t.Fatalf("Unexpected project servers: %#v", servers)
+	}
+
+	claudeConfig := claude.SessionConfig{ProjectPath: project}
+	app.applyProjectMcpToClaude(&claudeConfig)
+	var mcpConfig struct {
+		McpServers map[string]mcp.MCPServerConfig `json:"mcpServers"`
+	}
+	if err := json.Unmarshal([]byte(claudeConfig.McpConfig), &mcpConfig); err != nil {
+		t.Fatalf("Invalid --mcp-config %q: %v", claudeConfig.McpConfig, err)
+	}
+	if len(mcpConfig.McpServers) != 1 || mcpConfig.McpServers["docs"].Type != "http" {
+		t.Errorf("Expected only the enabled project server, got %#v", mcpConfig.McpServers)
+	}
+	if strings.Join(claudeConfig.DisallowedTools, ",") != "mcp__github,mcp__local" {
+		t.Errorf("Unexpected disallowed tools: %v", claudeConfig.DisallowedTools)
+	}
+
+	codexConfig := codex.SessionConfig{ProjectPath: project}
+	app.applyProjectMcpToCodex(&codexConfig)
+	if _, ok := codexConfig.McpServers["docs"]; !ok || len(codexConfig.McpServers) != 1 {
+		t.Errorf("Unexpected codex servers: %#v", codexConfig.McpServers)
+	}
+	if len(codexConfig.DisabledMcpServers) != 0 {
+		t.Errorf("Expected no codex servers to disable without a codex config, got %v", codexConfig.DisabledMcpServers)
+	}
+
+	if err := app.SetProjectMcpServerEnabled(project, "github", true); err != nil {
+		t.Fatalf("SetProjectMcpServerEnabled failed: %v", err)
+	}
+	claudeConfig = claude.SessionConfig{ProjectPath: project}
+	app.applyProjectMcpToClaude(&claudeConfig)
+	if strings.Join(claudeConfig.DisallowedTools, ",") != "mcp__local" {
+		t.Errorf("Expected github to be re-enabled, got %v", claudeConfig.DisallowedTools)
+	}
+}
+
+func TestProjectMcpSelection_NoProjectConfig(t *testing.T) {
+	app := newMcpTestApp(t)
+	config := claude.SessionConfig{ProjectPath: t.TempDir()}
+	app.applyProjectMcpToClaude(&config)
+	if config.McpConfig != "" || len(config.DisallowedTools) != 0 {
+		t.Errorf("Expected CLI defaults for a project without mcp.json, got %#v", config)
+	}
+}
+
+func TestMcpExportImportServers(t *testing.T) {
+	app := newMcpTestApp(t)
+	path := filepath.Join(t.TempDir(), "mcp-servers.json")
+
+	count, err := app.McpExportServers(path)
+	if err != nil || count != 1 {
+		t.Fatalf("McpExportServers = %d, %v", count, err)
+	}
+
+	target := newMcpTestApp(t)
+	if err := target.mcpManager.DeleteMcpServer("github"); err != nil {
+		t.Fatalf("DeleteMcpServer failed: %v", err)
+	}
+	result, err := target.McpImportServers(path)
+	if err != nil {
+		t.Fatalf("McpImportServers failed: %v", err)
+	}
+	if !result.Success || result.ImportedCount != 1 {
+		t.Fatalf("Unexpected import result: %#v", result)
+	}
+	server, err := target.mcpManager.GetMcpServer("github")
+	if err != nil || server.Command != "npx" || len(server.Args) != 1 {
+		t.Errorf("Imported server = %#v, %v", server, err)
+	}
+}
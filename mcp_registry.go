@@ -0,0 +1,94 @@
+// mcp_registry.go
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"ropcode/internal/mcp"
+)
+
+// The registry URL defaults to the official registry and may also point at a
+// static JSON index in the registry's {"servers": [...]} format
+const (
+	mcpRegistrySettingKey = "mcp_registry_url"
+	mcpRegistryPageSize   = 30
+	mcpRegistryTimeout    = 60 * time.Second
+)
+
+// GetMcpRegistryURL returns the configured MCP registry URL
+func (a *App) GetMcpRegistryURL() string {
+	if a.dbManager != nil {
+		if value, err := a.dbManager.GetSetting(mcpRegistrySettingKey); err == nil && strings.TrimSpace(value) != "" {
+			return strings.TrimSpace(value)
+		}
+	}
+	return mcp.DefaultRegistryURL
+}
+
+// SetMcpRegistryURL sets the MCP registry URL; empty restores the official
+// registry
+func (a *App) SetMcpRegistryURL(registryURL string) error {
+	if a.dbManager == nil {
+		return a.unavailable(subsystemDatabase)
+	}
+	registryURL = strings.TrimSpace(registryURL)
+	if registryURL != "" {
+		parsed, err := url.Parse(registryURL)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return fmt.Errorf("registry URL must be an http(s) URL")
+		}
+	}
+	return a.dbManager.SaveSetting(mcpRegistrySettingKey, registryURL)
+}
+
+func (a *App) mcpRegistryContext() (context.Context, context.CancelFunc) {
+	parent := a.ctx
+	if parent == nil {
+		parent = context.Background()
+	}
+	return context.WithTimeout(parent, mcpRegistryTimeout)
+}
+
+// SearchMcpRegistry searches the MCP registry; pass the returned next_cursor
+// to fetch the following page
+func (a *App) SearchMcpRegistry(query, cursor string) (*mcp.RegistrySearchResult, error) {
+	ctx, cancel := a.mcpRegistryContext()
+	defer cancel()
+	return mcp.NewRegistryClient(a.GetMcpRegistryURL()).Search(ctx, query, cursor, mcpRegistryPageSize)
+}
+
+// InstallMcpServerFromRegistry configures a registry server under
+// installName (derived from the registry name when empty). values supplies
+// environment variables, arguments and headers the server asks for.
+func (a *App) InstallMcpServerFromRegistry(serverName, installName string, values map[string]string) (*mcp.MCPServer, error) {
+	if a.mcpManager == nil {
+		return nil, a.unavailable(subsystemMCP)
+	}
+
+	ctx, cancel := a.mcpRegistryContext()
+	defer cancel()
+	server, err := mcp.NewRegistryClient(a.GetMcpRegistryURL()).Find(ctx, serverName)
+	if err != nil {
+		return nil, err
+	}
+	config, err := server.InstallConfig(values)
+	if err != nil {
+		return nil, err
+	}
+
+	installName = strings.TrimSpace(installName)
+	if installName == "" {
+		installName = mcp.DefaultInstallName(server.Name)
+	}
+	if _, err := a.mcpManager.GetMcpServer(installName); err == nil {
+		return nil, fmt.Errorf("an MCP server named %s already exists", installName)
+	}
+	if err := a.mcpManager.SaveMcpServer(installName, config); err != nil {
+		return nil, err
+	}
+	return a.mcpManager.GetMcpServer(installName)
+}
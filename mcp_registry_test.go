@@ -0,0 +1,54 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const testMcpRegistryIndex = `{"servers": [{"server": {"name": "io.github.acme/weather",
+  "packages": [{"registryType": "npm", "identifier": "@acme/weather", "version": "1.0.0",
+    "environmentVariables": [{"name": "WEATHER_KEY", "isRequired": true}]}]}}]}`
+
+func TestInstallMcpServerFromRegistry(t *testing.T) {
+	registry := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(testMcpRegistryIndex))
+	}))
+	defer registry.Close()
+
+	app := newMcpTestApp(t)
+	app.dbManager = openAppConfigTestDB(t)
+	if err := app.SetMcpRegistryURL("ftp://nope"); err == nil {
+		t.Fatal("Expected invalid registry URL to be rejected")
+	}
+	if err := app.SetMcpRegistryURL(registry.URL + "/index.json"); err != nil {
+		t.Fatalf("SetMcpRegistryURL failed: %v", err)
+	}
+
+	result, err := app.SearchMcpRegistry("weather", "")
+	if err != nil || len(result.Servers) != 1 {
+		t.Fatalf("SearchMcpRegistry = %#v, %v", result, err)
+	}
+
+	if _, err := app.InstallMcpServerFromRegistry("io.github.acme/weather", "", nil); err == nil || !strings.Contains(err.Error(), "WEATHER_KEY") {
+		t.Fatalf("Expected missing value error, got %v", err)
+	}
+	server, err := app.InstallMcpServerFromRegistry("io.github.acme/weather", "", map[string]string{"WEATHER_KEY": "k"})
+	if err != nil {
+		t.Fatalf("InstallMcpServerFromRegistry failed: %v", err)
+	}
+	if server.Name != "weather" || server.Command != "npx" || server.Env["WEATHER_KEY"] != "k" {
+		t.Errorf("Unexpected installed server: %#v", server)
+	}
+	if _, err := app.InstallMcpServerFromRegistry("io.github.acme/weather", "", map[string]string{"WEATHER_KEY": "k"}); err == nil {
+		t.Error("Expected error installing over an existing server")
+	}
+
+	if err := app.SetMcpRegistryURL(""); err != nil {
+		t.Fatalf("SetMcpRegistryURL failed: %v", err)
+	}
+	if got := app.GetMcpRegistryURL(); got != "https://registry.modelcontextprotocol.io" {
+		t.Errorf("Expected the default registry, got %q", got)
+	}
+}
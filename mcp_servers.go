@@ -0,0 +1,234 @@
+// mcp_servers.go
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"ropcode/internal/mcp"
+)
+
+// mcpToolTimeout bounds a tool browser request, including server startup.
+const mcpToolTimeout = 60 * time.Second
+
+// emitMcpServerChanged pushes a supervised server's status to the front-end.
+func (a *App) emitMcpServerChanged(status mcp.RuntimeStatus) {
+	if a == nil || a.eventHub == nil {
+		return
+	}
+	a.eventHub.Emit("mcp-server:changed", status)
+}
+
+// findMcpServer returns the configuration of a server by name, including
+// servers that are only known to the claude CLI.
+func (a *App) findMcpServer(name string) (*mcp.MCPServer, error) {
+	if a.mcpManager == nil {
+		return nil, a.unavailable(subsystemMCP)
+	}
+	if server, err := a.mcpManager.GetMcpServer(name); err == nil {
+		return server, nil
+	}
+	servers, err := a.mcpManager.ListMcpServers()
+	if err != nil {
+		return nil, err
+	}
+	for _, server := range servers {
+		if server.Name == name {
+			return server, nil
+		}
+	}
+	return nil, fmt.Errorf("server %s not found", name)
+}
+
+// StartMcpServer launches and supervises a stdio MCP server, restarting it
+// according to restartPolicy ("never", "on-failure" or "always")
+func (a *App) StartMcpServer(name, restartPolicy string) (*mcp.RuntimeStatus, error) {
+	if a.mcpSupervisor == nil {
+		return nil, a.unavailable(subsystemMCP)
+	}
+	policy, err := mcp.ParseRestartPolicy(restartPolicy)
+	if err != nil {
+		return nil, err
+	}
+	server, err := a.findMcpServer(name)
+	if err != nil {
+		return nil, err
+	}
+	return a.mcpSupervisor.Start(server, policy)
+}
+
+// StopMcpServer stops a supervised MCP server
+func (a *App) StopMcpServer(name string) error {
+	if a.mcpSupervisor == nil {
+		return a.unavailable(subsystemMCP)
+	}
+	return a.mcpSupervisor.Stop(name)
+}
+
+// RestartMcpServer restarts an MCP server with the current configuration,
+// starting it if it was not running
+func (a *App) RestartMcpServer(name, restartPolicy string) (*mcp.RuntimeStatus, error) {
+	if a.mcpSupervisor == nil {
+		return nil, a.unavailable(subsystemMCP)
+	}
+	policy, err := mcp.ParseRestartPolicy(restartPolicy)
+	if err != nil {
+		return nil, err
+	}
+	server, err := a.findMcpServer(name)
+	if err != nil {
+		return nil, err
+	}
+	return a.mcpSupervisor.Restart(server, policy)
+}
+
+// ListRunningMcpServers returns the status of every supervised MCP server
+func (a *App) ListRunningMcpServers() []mcp.RuntimeStatus {
+	if a.mcpSupervisor == nil {
+		return []mcp.RuntimeStatus{}
+	}
+	return a.mcpSupervisor.List()
+}
+
+// withMcpClient runs fn with an initialized connection to the server.
+func (a *App) withMcpClient(name string, fn func(ctx context.Context, client *mcp.Client) error) error {
+	parent := a.ctx
+	if parent == nil {
+		parent = context.Background()
+	}
+	ctx, cancel := context.WithTimeout(parent, mcpToolTimeout)
+	defer cancel()
+
+	if a.mcpSupervisor != nil {
+		if client, ok := a.mcpSupervisor.Client(name); ok {
+			return fn(ctx, client)
+		}
+	}
+
+	server, err := a.findMcpServer(name)
+	if err != nil {
+		return err
+	}
+	client, _, err := mcp.Connect(ctx, server)
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s: %w", name, err)
+	}
+	defer client.Close()
+	return fn(ctx, client)
+}
+
+// McpListTools lists the tools advertised by an MCP server
+func (a *App) McpListTools(name string) ([]mcp.Tool, error) {
+	var tools []mcp.Tool
+	err := a.withMcpClient(name, func(ctx context.Context, client *mcp.Client) error {
+		var err error
+		tools, err = client.ListTools(ctx)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tools, nil
+}
+
+// McpCallTool invokes a tool of an MCP server. argsJSON is the tool's
+// arguments as a JSON object; empty means no arguments.
+func (a *App) McpCallTool(name, tool, argsJSON string) (*mcp.CallToolResult, error) {
+	if strings.TrimSpace(tool) == "" {
+		return nil, fmt.Errorf("tool name is required")
+	}
+	var arguments json.RawMessage
+	if argsJSON = strings.TrimSpace(argsJSON); argsJSON != "" {
+		var object map[string]interface{}
+		if err := json.Unmarshal([]byte(argsJSON), &object); err != nil || object == nil {
+			return nil, fmt.Errorf("arguments must be a JSON object")
+		}
+		arguments = json.RawMessage(argsJSON)
+	}
+
+	var result *mcp.CallToolResult
+	err := a.withMcpClient(name, func(ctx context.Context, client *mcp.Client) error {
+		var err error
+		result, err = client.CallTool(ctx, tool, arguments)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// McpExportServers writes the global MCP server configurations to path and
+// returns how many were exported
+func (a *App) McpExportServers(path string) (int, error) {
+	if a.mcpManager == nil {
+		return 0, a.unavailable(subsystemMCP)
+	}
+	configs, err := a.mcpManager.ServerConfigs()
+	if err != nil {
+		return 0, err
+	}
+	data, err := json.MarshalIndent(map[string]interface{}{"mcpServers": configs}, "", "  ")
+	if err != nil {
+		return 0, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return 0, err
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return 0, err
+	}
+	return len(configs), nil
+}
+
+// McpImportServers imports the MCP servers of an exported file, Claude
+// Desktop config or .mcp.json, replacing servers with the same name
+func (a *App) McpImportServers(path string) (*MCPImportResult, error) {
+	if a.mcpManager == nil {
+		return nil, a.unavailable(subsystemMCP)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	result := &MCPImportResult{
+		Success:  true,
+		Messages: []string{},
+	}
+	a.importMcpServers(data, result)
+	return result, nil
+}
+
+// importMcpServers saves every server of an {"mcpServers": {...}} document.
+func (a *App) importMcpServers(data []byte, result *MCPImportResult) {
+	var document struct {
+		McpServers map[string]mcp.MCPServerConfig `json:"mcpServers"`
+	}
+	if err := json.Unmarshal(data, &document); err != nil {
+		result.Success = false
+		result.Messages = append(result.Messages, "Failed to parse config: "+err.Error())
+		return
+	}
+
+	names := make([]string, 0, len(document.McpServers))
+	for name := range document.McpServers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		config := document.McpServers[name]
+		if err := a.mcpManager.SaveMcpServer(name, &config); err != nil {
+			result.FailedCount++
+			result.Messages = append(result.Messages, fmt.Sprintf("Failed to import '%s': %s", name, err.Error()))
+		} else {
+			result.ImportedCount++
+			result.Messages = append(result.Messages, fmt.Sprintf("Imported '%s'", name))
+		}
+	}
+}
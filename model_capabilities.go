@@ -0,0 +1,126 @@
+// model_capabilities.go
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"ropcode/internal/database"
+	"ropcode/internal/models"
+)
+
+// modelCapabilitiesURLSettingKey stores the URL RefreshModelCapabilities
+// fetched last
+const modelCapabilitiesURLSettingKey = "model_capabilities_url"
+
+// ListModelCapabilities returns the builtin capability rows followed by the
+// fetched ones
+func (a *App) ListModelCapabilities() ([]*database.ModelCapabilities, error) {
+	if a.modelRegistry == nil {
+		return models.BuiltinCapabilities(), nil
+	}
+	return a.modelRegistry.Capabilities(), nil
+}
+
+// GetModelCapabilitiesURL returns the URL capabilities were last fetched from
+func (a *App) GetModelCapabilitiesURL() (string, error) {
+	if a.dbManager == nil {
+		return "", a.unavailable(subsystemDatabase)
+	}
+	return a.dbManager.GetSetting(modelCapabilitiesURLSettingKey)
+}
+
+// RefreshModelCapabilities replaces the fetched capabilities with the JSON
+// list of capability rows served at catalogURL, or at the last URL when
+// empty, and returns how many rows were loaded
+func (a *App) RefreshModelCapabilities(catalogURL string) (int, error) {
+	if a.dbManager == nil {
+		return 0, a.unavailable(subsystemDatabase)
+	}
+	catalogURL = strings.TrimSpace(catalogURL)
+	if catalogURL == "" {
+		saved, err := a.dbManager.GetSetting(modelCapabilitiesURLSettingKey)
+		if err != nil {
+			return 0, err
+		}
+		if saved == "" {
+			return 0, fmt.Errorf("no capabilities URL configured")
+		}
+		catalogURL = saved
+	}
+
+	capabilities, err := fetchModelCapabilities(a.ctx, catalogURL)
+	if err != nil {
+		return 0, err
+	}
+	if err := a.dbManager.ReplaceModelCapabilities(capabilities); err != nil {
+		return 0, fmt.Errorf("failed to save fetched capabilities: %w", err)
+	}
+	if err := a.dbManager.SaveSetting(modelCapabilitiesURLSettingKey, catalogURL); err != nil {
+		return 0, err
+	}
+	return len(capabilities), nil
+}
+
+func fetchModelCapabilities(ctx context.Context, catalogURL string) ([]*database.ModelCapabilities, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if !strings.HasPrefix(catalogURL, "https://") && !strings.HasPrefix(catalogURL, "http://") {
+		return nil, fmt.Errorf("invalid capabilities URL %q", catalogURL)
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", catalogURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch capabilities: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch capabilities: %s", resp.Status)
+	}
+
+	// The catalog is about as large as a pricing list
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxPricingResponseSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read capabilities: %w", err)
+	}
+	var capabilities []*database.ModelCapabilities
+	if err := json.Unmarshal(body, &capabilities); err != nil {
+		return nil, fmt.Errorf("invalid capabilities list: %w", err)
+	}
+	for i, c := range capabilities {
+		if err := validateModelCapabilities(c); err != nil {
+			return nil, fmt.Errorf("capabilities entry %d: %w", i, err)
+		}
+		c.Source = database.PricingSourceRemote
+	}
+	return capabilities, nil
+}
+
+func validateModelCapabilities(c *database.ModelCapabilities) error {
+	if c == nil {
+		return fmt.Errorf("capabilities are required")
+	}
+	c.ModelPattern = strings.TrimSpace(c.ModelPattern)
+	if c.ModelPattern == "" {
+		return fmt.Errorf("model pattern is required")
+	}
+	if c.ContextWindow < 0 || c.MaxOutputTokens < 0 {
+		return fmt.Errorf("token limits cannot be negative")
+	}
+	if c.LastVerified != "" {
+		if _, err := time.Parse("2006-01-02", c.LastVerified); err != nil {
+			return fmt.Errorf("invalid last verified date %q, want YYYY-MM-DD", c.LastVerified)
+		}
+	}
+	return nil
+}
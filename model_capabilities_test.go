@@ -0,0 +1,44 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"ropcode/internal/database"
+	"ropcode/internal/models"
+)
+
+func TestRefreshModelCapabilities(t *testing.T) {
+	body := `[{"model_pattern":"deepseek","context_window":128000,"max_output_tokens":8000,"supports_thinking":true,"last_verified":"2026-09-30"}]`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(body))
+	}))
+	defer server.Close()
+	db := openAppConfigTestDB(t)
+	app := &App{dbManager: db, modelRegistry: models.NewRegistry(db)}
+
+	if _, err := app.RefreshModelCapabilities(""); err == nil {
+		t.Error("Expected an error without a capabilities URL")
+	}
+	if n, err := app.RefreshModelCapabilities(server.URL); err != nil || n != 1 {
+		t.Fatalf("RefreshModelCapabilities = %d, %v", n, err)
+	}
+	capabilities, _ := app.ListModelCapabilities()
+	fetched := capabilities[len(capabilities)-1]
+	if len(capabilities) != len(models.BuiltinCapabilities())+1 || fetched.ModelPattern != "deepseek" || fetched.Source != database.PricingSourceRemote {
+		t.Fatalf("capabilities = %+v", capabilities)
+	}
+	if url, _ := app.GetModelCapabilitiesURL(); url != server.URL {
+		t.Errorf("Expected the capabilities URL saved, got %q", url)
+	}
+
+	// A bad entry leaves the fetched rows alone
+	body = `[{"model_pattern":"deepseek","last_verified":"last week"}]`
+	if _, err := app.RefreshModelCapabilities(""); err == nil {
+		t.Error("Expected an invalid last verified date to be rejected")
+	}
+	if capabilities, _ := app.ListModelCapabilities(); capabilities[len(capabilities)-1].LastVerified != "2026-09-30" {
+		t.Errorf("capabilities = %+v", capabilities)
+	}
+}
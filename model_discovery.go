@@ -0,0 +1,73 @@
+// model_discovery.go
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"ropcode/internal/database"
+	"ropcode/internal/models"
+)
+
+// ModelDiscovery lists the models a provider API config's endpoint serves
+type ModelDiscovery struct {
+	ProviderApiID string                    `json:"provider_api_id"`
+	ProviderID    string                    `json:"provider_id"`
+	BaseURL       string                    `json:"base_url"`
+	Models        []*models.DiscoveredModel `json:"models"`
+}
+
+// DiscoverModels queries the endpoint of the provider API config
+// providerApiID with its auth token
+func (a *App) DiscoverModels(providerApiID string) (*ModelDiscovery, error) {
+	if a.modelRegistry == nil || a.dbManager == nil {
+		return nil, a.unavailable(subsystemModels)
+	}
+	apiConfig, err := a.discoveryAPIConfig(providerApiID)
+	if err != nil {
+		return nil, err
+	}
+	modelIDs, err := fetchProviderModelIDs(apiConfig.ProviderID, apiConfig)
+	if err != nil {
+		return nil, err
+	}
+	discovered, err := a.modelRegistry.Discover(apiConfig.ProviderID, modelIDs)
+	if err != nil {
+		return nil, err
+	}
+	return &ModelDiscovery{
+		ProviderApiID: apiConfig.ID,
+		ProviderID:    apiConfig.ProviderID,
+		BaseURL:       apiConfig.BaseURL,
+		Models:        discovered,
+	}, nil
+}
+
+// ImportDiscoveredModels adds the models modelIDs, as discovered through
+// the provider API config providerApiID, to the registry and returns those
+// added
+func (a *App) ImportDiscoveredModels(providerApiID string, modelIDs []string) ([]*database.ModelConfig, error) {
+	if a.modelRegistry == nil || a.dbManager == nil {
+		return nil, a.unavailable(subsystemModels)
+	}
+	apiConfig, err := a.discoveryAPIConfig(providerApiID)
+	if err != nil {
+		return nil, err
+	}
+	return a.modelRegistry.SyncProviderModels(apiConfig.ProviderID, modelIDs)
+}
+
+func (a *App) discoveryAPIConfig(providerApiID string) (*database.ProviderApiConfig, error) {
+	providerApiID = strings.TrimSpace(providerApiID)
+	if providerApiID == "" {
+		return nil, fmt.Errorf("provider API config is required")
+	}
+	apiConfig, err := a.dbManager.GetProviderApiConfig(providerApiID)
+	if err != nil || apiConfig == nil {
+		return nil, fmt.Errorf("provider API config %q not found", providerApiID)
+	}
+	if strings.TrimSpace(apiConfig.ProviderID) == "" {
+		return nil, fmt.Errorf("provider API config %q has no provider", providerApiID)
+	}
+	return apiConfig, nil
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"ropcode/internal/models"
+)
+
+func TestDiscoverModelsOffersModelsAndImportsThePickedOnes(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"data": []map[string]string{{"id": "relay-chat"}, {"id": "relay-coder"}, {"id": "whisper-1"}},
+		})
+	}))
+	defer server.Close()
+	app := newModelSyncTestApp(t, "codex", server.URL)
+
+	if _, err := app.DiscoverModels("missing"); err == nil {
+		t.Fatal("expected an unknown provider API config to be rejected")
+	}
+	discovery, err := app.DiscoverModels("codex-api")
+	if err != nil {
+		t.Fatalf("DiscoverModels failed: %v", err)
+	}
+	if discovery.ProviderID != "codex" || discovery.BaseURL != server.URL || len(discovery.Models) != 3 {
+		t.Fatalf("discovery = %+v", discovery)
+	}
+	// codex only syncs gpt-* and o* IDs
+	for _, m := range discovery.Models {
+		if m.Status != models.DiscoveredUnsupported {
+			t.Fatalf("%s status = %s", m.ModelID, m.Status)
+		}
+	}
+	if all, _ := app.modelRegistry.GetModelsByProvider("codex"); len(all) != len(models.GetBuiltinModelsByProvider("codex")) {
+		t.Fatal("DiscoverModels changed the registry")
+	}
+
+	claudeApp := newModelSyncTestApp(t, "claude", server.URL)
+	if discovery, err = claudeApp.DiscoverModels("claude-api"); err != nil {
+		t.Fatalf("DiscoverModels failed: %v", err)
+	}
+	if discovery.Models[0].ModelID != "relay-chat" || discovery.Models[0].Status != models.DiscoveredNew {
+		t.Fatalf("models = %+v", discovery.Models)
+	}
+	imported, err := claudeApp.ImportDiscoveredModels("claude-api", []string{"relay-coder"})
+	if err != nil || len(imported) != 1 || imported[0].ModelID != "relay-coder" {
+		t.Fatalf("ImportDiscoveredModels = %+v, %v", imported, err)
+	}
+	if discovery, _ = claudeApp.DiscoverModels("claude-api"); discovery.Models[1].Status != models.DiscoveredExists || discovery.Models[0].Status != models.DiscoveredNew {
+		t.Fatalf("models after import = %+v", discovery.Models)
+	}
+}
@@ -0,0 +1,199 @@
+// model_pricing.go
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"ropcode/internal/database"
+	"ropcode/internal/usage"
+)
+
+// modelPricingURLSettingKey stores the URL RefreshModelPricing fetched last
+const modelPricingURLSettingKey = "model_pricing_url"
+
+// maxPricingResponseSize bounds a fetched pricing list
+const maxPricingResponseSize = 1 << 20
+
+// seedModelPricing installs the builtin prices of this release, replacing
+// the previous builtin rows. Users override a builtin row by adding their own
+// row with the same pattern.
+func (a *App) seedModelPricing() {
+	if a.dbManager == nil {
+		return
+	}
+	if err := a.dbManager.ReplaceModelPricing(database.PricingSourceBuiltin, usage.BuiltinPricing()); err != nil {
+		slog.Error("Failed to seed model pricing", "component", "ModelPricing", "error", err)
+	}
+}
+
+// modelPricing returns the prices in effect, falling back to the builtin
+// prices without a database
+func (a *App) modelPricing() *usage.Pricing {
+	if a.dbManager == nil {
+		return usage.DefaultPricing()
+	}
+	prices, err := a.dbManager.ListModelPricing()
+	if err != nil || len(prices) == 0 {
+		return usage.DefaultPricing()
+	}
+	return usage.NewPricing(prices)
+}
+
+// ListModelPricing returns every pricing row
+func (a *App) ListModelPricing() ([]*database.ModelPricing, error) {
+	if a.dbManager == nil {
+		return nil, a.unavailable(subsystemDatabase)
+	}
+	prices, err := a.dbManager.ListModelPricing()
+	if err != nil {
+		return nil, err
+	}
+	if prices == nil {
+		prices = []*database.ModelPricing{}
+	}
+	return prices, nil
+}
+
+// SaveModelPricing creates (ID 0) or updates a user pricing row
+func (a *App) SaveModelPricing(price *database.ModelPricing) (*database.ModelPricing, error) {
+	if a.dbManager == nil {
+		return nil, a.unavailable(subsystemDatabase)
+	}
+	if err := validateModelPricing(price); err != nil {
+		return nil, err
+	}
+	if price.ID != 0 {
+		existing, err := a.dbManager.GetModelPricing(price.ID)
+		if err != nil {
+			return nil, fmt.Errorf("pricing %d not found", price.ID)
+		}
+		if existing.Source == database.PricingSourceBuiltin {
+			return nil, fmt.Errorf("builtin pricing cannot be modified")
+		}
+	}
+	price.Source = database.PricingSourceUser
+	if err := a.dbManager.SaveModelPricing(price); err != nil {
+		return nil, fmt.Errorf("failed to save pricing: %w", err)
+	}
+	return price, nil
+}
+
+// DeleteModelPricing deletes a user or fetched pricing row
+func (a *App) DeleteModelPricing(id int64) error {
+	if a.dbManager == nil {
+		return a.unavailable(subsystemDatabase)
+	}
+	existing, err := a.dbManager.GetModelPricing(id)
+	if err != nil {
+		return fmt.Errorf("pricing %d not found", id)
+	}
+	if existing.Source == database.PricingSourceBuiltin {
+		return fmt.Errorf("builtin pricing cannot be deleted")
+	}
+	return a.dbManager.DeleteModelPricing(id)
+}
+
+// GetModelPricingURL returns the URL prices were last fetched from
+func (a *App) GetModelPricingURL() (string, error) {
+	if a.dbManager == nil {
+		return "", a.unavailable(subsystemDatabase)
+	}
+	return a.dbManager.GetSetting(modelPricingURLSettingKey)
+}
+
+// RefreshModelPricing replaces the fetched prices with the JSON list of
+// pricing rows served at pricingURL, or at the last URL when empty, and
+// returns how many rows were loaded
+func (a *App) RefreshModelPricing(pricingURL string) (int, error) {
+	if a.dbManager == nil {
+		return 0, a.unavailable(subsystemDatabase)
+	}
+	pricingURL = strings.TrimSpace(pricingURL)
+	if pricingURL == "" {
+		saved, err := a.dbManager.GetSetting(modelPricingURLSettingKey)
+		if err != nil {
+			return 0, err
+		}
+		if saved == "" {
+			return 0, fmt.Errorf("no pricing URL configured")
+		}
+		pricingURL = saved
+	}
+
+	prices, err := fetchModelPricing(a.ctx, pricingURL)
+	if err != nil {
+		return 0, err
+	}
+	if err := a.dbManager.ReplaceModelPricing(database.PricingSourceRemote, prices); err != nil {
+		return 0, fmt.Errorf("failed to save fetched pricing: %w", err)
+	}
+	if err := a.dbManager.SaveSetting(modelPricingURLSettingKey, pricingURL); err != nil {
+		return 0, err
+	}
+	return len(prices), nil
+}
+
+func fetchModelPricing(ctx context.Context, pricingURL string) ([]*database.ModelPricing, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if !strings.HasPrefix(pricingURL, "https://") && !strings.HasPrefix(pricingURL, "http://") {
+		return nil, fmt.Errorf("invalid pricing URL %q", pricingURL)
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", pricingURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch pricing: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch pricing: %s", resp.Status)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxPricingResponseSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pricing: %w", err)
+	}
+	var prices []*database.ModelPricing
+	if err := json.Unmarshal(body, &prices); err != nil {
+		return nil, fmt.Errorf("invalid pricing list: %w", err)
+	}
+	for i, price := range prices {
+		if err := validateModelPricing(price); err != nil {
+			return nil, fmt.Errorf("pricing entry %d: %w", i, err)
+		}
+	}
+	return prices, nil
+}
+
+func validateModelPricing(price *database.ModelPricing) error {
+	if price == nil {
+		return fmt.Errorf("pricing is required")
+	}
+	price.ModelPattern = strings.TrimSpace(price.ModelPattern)
+	if price.ModelPattern == "" {
+		return fmt.Errorf("model pattern is required")
+	}
+	for _, p := range []float64{price.InputPrice, price.OutputPrice, price.CacheWritePrice, price.CacheReadPrice} {
+		if p < 0 {
+			return fmt.Errorf("prices cannot be negative")
+		}
+	}
+	if price.EffectiveDate != "" {
+		if _, err := time.Parse("2006-01-02", price.EffectiveDate); err != nil {
+			return fmt.Errorf("invalid effective date %q, want YYYY-MM-DD", price.EffectiveDate)
+		}
+	}
+	return nil
+}
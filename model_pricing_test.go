@@ -0,0 +1,76 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"ropcode/internal/database"
+)
+
+func TestModelPricingBindings(t *testing.T) {
+	app := &App{dbManager: openAppConfigTestDB(t)}
+	app.seedModelPricing()
+
+	prices, err := app.ListModelPricing()
+	if err != nil || len(prices) == 0 {
+		t.Fatalf("Expected builtin prices, got %v, %v", prices, err)
+	}
+	builtin := prices[0]
+	if err := app.DeleteModelPricing(builtin.ID); err == nil {
+		t.Error("Expected builtin pricing to be undeletable")
+	}
+	builtin.InputPrice = 1
+	if _, err := app.SaveModelPricing(builtin); err == nil {
+		t.Error("Expected builtin pricing to be read-only")
+	}
+	if _, err := app.SaveModelPricing(&database.ModelPricing{ModelPattern: "sonnet", InputPrice: -1}); err == nil {
+		t.Error("Expected negative prices to be rejected")
+	}
+	if _, err := app.SaveModelPricing(&database.ModelPricing{ModelPattern: "sonnet", EffectiveDate: "March"}); err == nil {
+		t.Error("Expected an invalid effective date to be rejected")
+	}
+
+	saved, err := app.SaveModelPricing(&database.ModelPricing{ModelPattern: " sonnet ", InputPrice: 9, Source: database.PricingSourceBuiltin})
+	if err != nil || saved.ID == 0 || saved.Source != database.PricingSourceUser || saved.ModelPattern != "sonnet" {
+		t.Fatalf("SaveModelPricing = %+v, %v", saved, err)
+	}
+	if price := app.modelPricing().Lookup("claude-sonnet-4", time.Now()); price == nil || price.InputPrice != 9 {
+		t.Errorf("Expected the user price to win, got %+v", price)
+	}
+
+	// Reseeding keeps user rows
+	app.seedModelPricing()
+	if err := app.DeleteModelPricing(saved.ID); err != nil {
+		t.Errorf("DeleteModelPricing failed: %v", err)
+	}
+}
+
+func TestRefreshModelPricing(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"model_pattern":"gpt-5","input_price":1.25,"output_price":10,"effective_date":"2025-08-07"}]`))
+	}))
+	defer server.Close()
+	app := &App{dbManager: openAppConfigTestDB(t)}
+
+	if _, err := app.RefreshModelPricing(""); err == nil {
+		t.Error("Expected an error without a pricing URL")
+	}
+	for i := 0; i < 2; i++ {
+		n, err := app.RefreshModelPricing(server.URL)
+		if err != nil || n != 1 {
+			t.Fatalf("RefreshModelPricing = %d, %v", n, err)
+		}
+	}
+	prices, _ := app.ListModelPricing()
+	if len(prices) != 1 || prices[0].Source != database.PricingSourceRemote {
+		t.Errorf("Expected one fetched price after refreshing twice, got %+v", prices)
+	}
+	if url, _ := app.GetModelPricingURL(); url != server.URL {
+		t.Errorf("Expected the pricing URL saved, got %q", url)
+	}
+	if _, err := app.RefreshModelPricing(""); err != nil {
+		t.Errorf("Expected a refresh from the saved URL, got %v", err)
+	}
+}
@@ -0,0 +1,197 @@
+// notifications.go
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"ropcode/internal/database"
+	"ropcode/internal/eventhub"
+	"ropcode/internal/notify"
+)
+
+const notificationRulesSettingKey = "notification_rules"
+
+// defaultLongCommandSeconds is how long a command runs before its end is
+// worth a notification
+const defaultLongCommandSeconds = 60
+
+// sendDesktopNotification shows a notification; tests replace it
+var sendDesktopNotification = notify.Send
+
+// NotificationRules selects the events that show desktop notifications
+type NotificationRules struct {
+	Enabled         bool `json:"enabled"`
+	SessionComplete bool `json:"session_complete"`
+	AgentRunFailed  bool `json:"agent_run_failed"`
+	CommandFinished bool `json:"command_finished"`
+	// LongCommandSeconds is how long a command must run to be reported
+	LongCommandSeconds int `json:"long_command_seconds"`
+}
+
+func defaultNotificationRules() *NotificationRules {
+	return &NotificationRules{
+		Enabled:            true,
+		SessionComplete:    true,
+		AgentRunFailed:     true,
+		CommandFinished:    true,
+		LongCommandSeconds: defaultLongCommandSeconds,
+	}
+}
+
+// GetNotificationRules returns the notification rules, all on when none are
+// saved
+func (a *App) GetNotificationRules() (*NotificationRules, error) {
+	rules := defaultNotificationRules()
+	if a.dbManager == nil {
+		return rules, nil
+	}
+	raw, err := a.dbManager.GetSetting(notificationRulesSettingKey)
+	if err != nil {
+		return nil, err
+	}
+	if raw != "" {
+		if err := json.Unmarshal([]byte(raw), rules); err != nil {
+			return nil, fmt.Errorf("invalid %s setting: %w", notificationRulesSettingKey, err)
+		}
+	}
+	return rules, nil
+}
+
+// SetNotificationRules saves the notification rules
+func (a *App) SetNotificationRules(rules *NotificationRules) error {
+	if a.dbManager == nil {
+		return a.unavailable(subsystemDatabase)
+	}
+	if rules == nil {
+		return fmt.Errorf("rules are required")
+	}
+	if rules.LongCommandSeconds < 0 {
+		return fmt.Errorf("long command duration cannot be negative")
+	}
+	data, err := json.Marshal(rules)
+	if err != nil {
+		return fmt.Errorf("failed to encode notification rules: %w", err)
+	}
+	if err := a.dbManager.SaveSetting(notificationRulesSettingKey, string(data)); err != nil {
+		return fmt.Errorf("failed to save notification rules: %w", err)
+	}
+	return nil
+}
+
+// notificationRules returns the rules in effect, the defaults when they
+// cannot be read
+func (a *App) notificationRules() *NotificationRules {
+	rules, err := a.GetNotificationRules()
+	if err != nil {
+		log.Printf("[notify] %v", err)
+		return defaultNotificationRules()
+	}
+	return rules
+}
+
+// desktopNotify shows a notification, logging failures other than a
+// system without notifications
+func (a *App) desktopNotify(title, body string) {
+	if err := sendDesktopNotification(title, body); err != nil && !errors.Is(err, notify.ErrUnavailable) {
+		log.Printf("[notify] %v", err)
+	}
+}
+
+// NotifySessionComplete shows a notification for a finished provider
+// session when the rules ask for one. It returns notify.ErrUnavailable
+// when the host cannot show notifications so the frontend can fall back
+// to its own.
+func (a *App) NotifySessionComplete(provider, sessionID, projectPath string, success bool) error {
+	a.playEventSound(attentionSessionComplete)
+	rules := a.notificationRules()
+	if !rules.Enabled || !rules.SessionComplete {
+		return nil
+	}
+	title := "Session finished"
+	if !success {
+		title = "Session failed"
+	}
+	body := filepath.Base(projectPath)
+	if a.sessionTitles != nil {
+		if name := a.sessionTitles.Get(provider, sessionID); name != "" {
+			body = name + " · " + body
+		}
+	}
+	return sendDesktopNotification(title, body)
+}
+
+// notifyAgentRunFailed reports a failed agent run when the rules ask for it
+func (a *App) notifyAgentRunFailed(run *database.AgentRun) {
+	a.playEventSound(attentionAgentRunFailed)
+	rules := a.notificationRules()
+	if !rules.Enabled || !rules.AgentRunFailed {
+		return
+	}
+	name := run.AgentName
+	if name == "" {
+		name = "Agent"
+	}
+	a.desktopNotify(name+" run failed", filepath.Base(run.ProjectPath))
+}
+
+// commandNotifier adapts EventHub to process.EventEmitter and reports
+// managed commands that finish after running for a while
+type commandNotifier struct {
+	app      *App
+	eventHub *eventhub.EventHub
+
+	mu      sync.Mutex
+	started map[int]time.Time // by PID
+}
+
+func newCommandNotifier(app *App, eventHub *eventhub.EventHub) *commandNotifier {
+	return &commandNotifier{
+		app:      app,
+		eventHub: eventHub,
+		started:  make(map[int]time.Time),
+	}
+}
+
+func (n *commandNotifier) EmitProcessChanged(event eventhub.ProcessChangedEvent) {
+	if n.eventHub != nil {
+		n.eventHub.EmitProcessChanged(event)
+	}
+
+	n.mu.Lock()
+	if event.State == "running" {
+		n.started[event.PID] = time.Now()
+		n.mu.Unlock()
+		return
+	}
+	startedAt, ok := n.started[event.PID]
+	delete(n.started, event.PID)
+	n.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	rules := n.app.notificationRules()
+	elapsed := time.Since(startedAt)
+	if elapsed < time.Duration(rules.LongCommandSeconds)*time.Second {
+		return
+	}
+	n.app.playEventSound(attentionCommandFinished)
+	if !rules.Enabled || !rules.CommandFinished {
+		return
+	}
+	title := "Command finished"
+	if event.ExitCode != nil && *event.ExitCode != 0 {
+		title = fmt.Sprintf("Command failed (exit %d)", *event.ExitCode)
+	}
+	body := event.Command
+	if body == "" {
+		body = filepath.Base(event.Cwd)
+	}
+	n.app.desktopNotify(title, fmt.Sprintf("%s · %s", body, elapsed.Round(time.Second)))
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"testing"
+
+	"ropcode/internal/database"
+	"ropcode/internal/eventhub"
+)
+
+func stubDesktopNotifications(t *testing.T) *[]string {
+	t.Helper()
+	var sent []string
+	previous := sendDesktopNotification
+	sendDesktopNotification = func(title, body string) error {
+		sent = append(sent, title+": "+body)
+		return nil
+	}
+	previousSound := playAttentionSound
+	playAttentionSound = func(sound string) error { return nil }
+	t.Cleanup(func() {
+		sendDesktopNotification = previous
+		playAttentionSound = previousSound
+	})
+	return &sent
+}
+
+func TestNotificationRules(t *testing.T) {
+	sent := stubDesktopNotifications(t)
+	app := &App{dbManager: openAppConfigTestDB(t), sessionTitles: newSessionTitleStore()}
+
+	rules, err := app.GetNotificationRules()
+	if err != nil || !rules.Enabled || !rules.SessionComplete || rules.LongCommandSeconds != defaultLongCommandSeconds {
+		t.Fatalf("GetNotificationRules() = %+v, %v, want defaults", rules, err)
+	}
+
+	app.sessionTitles.Set("claude", "s1", "Fix login")
+	if err := app.NotifySessionComplete("claude", "s1", "/work/app", true); err != nil {
+		t.Fatal(err)
+	}
+	app.notifyAgentRunFailed(&database.AgentRun{AgentName: "Reviewer", ProjectPath: "/work/app"})
+	if len(*sent) != 2 || (*sent)[0] != "Session finished: Fix login · app" || (*sent)[1] != "Reviewer run failed: app" {
+		t.Fatalf("Unexpected notifications %q", *sent)
+	}
+
+	rules.SessionComplete = false
+	if err := app.SetNotificationRules(rules); err != nil {
+		t.Fatal(err)
+	}
+	if err := app.NotifySessionComplete("claude", "s1", "/work/app", false); err != nil || len(*sent) != 2 {
+		t.Errorf("Expected a disabled rule to stay quiet, got %q, %v", *sent, err)
+	}
+
+	if err := app.SetNotificationRules(&NotificationRules{LongCommandSeconds: -1}); err == nil {
+		t.Error("Expected a negative duration to be rejected")
+	}
+}
+
+func TestCommandNotifierReportsLongCommands(t *testing.T) {
+	sent := stubDesktopNotifications(t)
+	app := &App{dbManager: openAppConfigTestDB(t)}
+	notifier := newCommandNotifier(app, nil)
+	exitCode := 2
+
+	// A minute-long command finishing right away stays quiet
+	notifier.EmitProcessChanged(eventhub.ProcessChangedEvent{PID: 1, State: "running", Command: "make test"})
+	notifier.EmitProcessChanged(eventhub.ProcessChangedEvent{PID: 1, State: "stopped", ExitCode: &exitCode, Command: "make test"})
+	if len(*sent) != 0 {
+		t.Fatalf("Expected a short command to stay quiet, got %q", *sent)
+	}
+
+	rules := defaultNotificationRules()
+	rules.LongCommandSeconds = 0
+	if err := app.SetNotificationRules(rules); err != nil {
+		t.Fatal(err)
+	}
+	notifier.EmitProcessChanged(eventhub.ProcessChangedEvent{PID: 2, State: "running", Command: "make test"})
+	notifier.EmitProcessChanged(eventhub.ProcessChangedEvent{PID: 2, State: "stopped", ExitCode: &exitCode, Command: "make test"})
+	if len(*sent) != 1 || (*sent)[0] != "Command failed (exit 2): make test · 0s" {
+		t.Errorf("Unexpected notifications %q", *sent)
+	}
+}
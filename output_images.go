@@ -0,0 +1,30 @@
+// output_images.go
+package main
+
+import (
+	"ropcode/internal/claude"
+	"ropcode/internal/outputimages"
+)
+
+// outputImages returns the store for output images, nil when there is no
+// attachments directory
+func (a *App) outputImages() *outputimages.Store {
+	root, err := a.attachmentsDir()
+	if err != nil {
+		return nil
+	}
+	return &outputimages.Store{Root: root}
+}
+
+// rewriteHistoryImages points the images of loaded history at stored files
+func (a *App) rewriteHistoryImages(sessionID string, messages []claude.Message) {
+	store := a.outputImages()
+	if store == nil {
+		return
+	}
+	for _, message := range messages {
+		if message.Message != nil {
+			store.RewriteMessage(sessionID, message.Message)
+		}
+	}
+}
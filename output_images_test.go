@@ -0,0 +1,43 @@
+package main
+
+import (
+	"encoding/base64"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"ropcode/internal/claude"
+	"ropcode/internal/config"
+)
+
+func TestRewriteHistoryImagesStoresImagesWithTheSession(t *testing.T) {
+	root := filepath.Join(t.TempDir(), ".ropcode")
+	app := &App{config: &config.Config{RopcodeDir: root}}
+	messages := []claude.Message{
+		{Type: "assistant", Message: map[string]interface{}{
+			"role":    "assistant",
+			"content": []interface{}{map[string]interface{}{"type": "text", "text": "Here is the chart"}},
+		}},
+		{Type: "user", Message: map[string]interface{}{
+			"role": "user",
+			"content": []interface{}{map[string]interface{}{
+				"type": "image",
+				"source": map[string]interface{}{
+					"type":       "base64",
+					"media_type": "image/gif",
+					"data":       base64.StdEncoding.EncodeToString([]byte("GIF89a")),
+				},
+			}},
+		}},
+	}
+
+	app.rewriteHistoryImages("sess-1", messages)
+
+	block := messages[1].Message["content"].([]interface{})[0].(map[string]interface{})
+	source := block["source"].(map[string]interface{})
+	path, _ := source["path"].(string)
+	if source["type"] != "url" || !strings.HasPrefix(path, filepath.Join(root, "attachments", "sess-1")+string(filepath.Separator)) ||
+		filepath.Ext(path) != ".gif" {
+		t.Fatalf("source = %v", source)
+	}
+}
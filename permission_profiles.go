@@ -0,0 +1,268 @@
+// permission_profiles.go
+package main
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"ropcode/internal/claude"
+	"ropcode/internal/codex"
+	"ropcode/internal/database"
+)
+
+// Built-in permission profile IDs; these profiles can't be changed
+const (
+	permissionProfileFull      = "full"
+	permissionProfileNoNetwork = "no-network"
+	permissionProfileReadOnly  = "read-only"
+)
+
+// claudeProfilePermissionModes are the Claude permission modes a profile may
+// use. "default" is left out: nobody could answer its prompts.
+var claudeProfilePermissionModes = []string{"", "bypassPermissions", "acceptEdits", "dontAsk", "plan"}
+
+var codexProfileSandboxes = []string{"", codex.SandboxReadOnly, codex.SandboxWorkspaceWrite, codex.SandboxDangerFullAccess}
+
+// builtinPermissionProfiles returns the profiles built into ropcode
+func builtinPermissionProfiles() []*database.PermissionProfile {
+	return []*database.PermissionProfile{
+		{
+			ID:                    permissionProfileFull,
+			Name:                  "Full access",
+			Description:           "No permission checks, full disk and network access",
+			ClaudePermissionMode:  "bypassPermissions",
+			ClaudeAllowedTools:    []string{},
+			ClaudeDisallowedTools: []string{},
+			CodexSandbox:          codex.SandboxDangerFullAccess,
+			CodexNetworkAccess:    true,
+			Builtin:               true,
+		},
+		{
+			ID:                    permissionProfileNoNetwork,
+			Name:                  "No network",
+			Description:           "Edits the workspace without web access; Claude loses its web tools and Codex commands run offline",
+			ClaudePermissionMode:  "bypassPermissions",
+			ClaudeAllowedTools:    []string{},
+			ClaudeDisallowedTools: []string{"WebFetch", "WebSearch"},
+			CodexSandbox:          codex.SandboxWorkspaceWrite,
+			Builtin:               true,
+		},
+		{
+			ID:                    permissionProfileReadOnly,
+			Name:                  "Read-only",
+			Description:           "Reads and searches the project without changing it",
+			ClaudePermissionMode:  "dontAsk",
+			ClaudeAllowedTools:    []string{"Read", "Glob", "Grep", "LS", "TodoWrite"},
+			ClaudeDisallowedTools: []string{},
+			CodexSandbox:          codex.SandboxReadOnly,
+			Builtin:               true,
+		},
+	}
+}
+
+// ListPermissionProfiles returns the built-in profiles followed by the
+// saved ones
+func (a *App) ListPermissionProfiles() ([]*database.PermissionProfile, error) {
+	profiles := builtinPermissionProfiles()
+	if a.dbManager == nil {
+		return profiles, nil
+	}
+	saved, err := a.dbManager.ListPermissionProfiles()
+	if err != nil {
+		return nil, err
+	}
+	return append(profiles, saved...), nil
+}
+
+// SavePermissionProfile creates the profile, or updates it when it has an ID
+func (a *App) SavePermissionProfile(profile database.PermissionProfile) (*database.PermissionProfile, error) {
+	if a.dbManager == nil {
+		return nil, a.unavailable(subsystemDatabase)
+	}
+	if builtinPermissionProfile(profile.ID) != nil {
+		return nil, fmt.Errorf("built-in profile %q cannot be changed", profile.ID)
+	}
+	profile.Name = strings.TrimSpace(profile.Name)
+	if profile.Name == "" {
+		return nil, fmt.Errorf("profile name is required")
+	}
+	if !slices.Contains(claudeProfilePermissionModes, profile.ClaudePermissionMode) {
+		return nil, fmt.Errorf("unsupported Claude permission mode: %q", profile.ClaudePermissionMode)
+	}
+	if !slices.Contains(codexProfileSandboxes, profile.CodexSandbox) {
+		return nil, fmt.Errorf("unsupported Codex sandbox: %q", profile.CodexSandbox)
+	}
+	profile.ClaudeAllowedTools = cleanToolList(profile.ClaudeAllowedTools)
+	profile.ClaudeDisallowedTools = cleanToolList(profile.ClaudeDisallowedTools)
+	profile.Builtin = false
+
+	if profile.ID == "" {
+		profile.ID = uuid.NewString()
+	} else if existing, err := a.dbManager.GetPermissionProfile(profile.ID); err == nil {
+		profile.CreatedAt = existing.CreatedAt
+	}
+	if err := a.dbManager.SavePermissionProfile(&profile); err != nil {
+		return nil, err
+	}
+	return a.dbManager.GetPermissionProfile(profile.ID)
+}
+
+// DeletePermissionProfile deletes a saved profile; projects that selected
+// it go back to full access
+func (a *App) DeletePermissionProfile(id string) error {
+	if a.dbManager == nil {
+		return a.unavailable(subsystemDatabase)
+	}
+	if builtinPermissionProfile(id) != nil {
+		return fmt.Errorf("built-in profile %q cannot be deleted", id)
+	}
+	a.mu.Lock()
+	for project, profileID := range a.sessionProfiles {
+		if profileID == id {
+			delete(a.sessionProfiles, project)
+		}
+	}
+	a.mu.Unlock()
+	return a.dbManager.DeletePermissionProfile(id)
+}
+
+// GetProjectPermissionProfile returns the ID of the profile selected for
+// the sessions of a project, empty when there is none
+func (a *App) GetProjectPermissionProfile(projectPath string) (string, error) {
+	if a.dbManager == nil {
+		return "", a.unavailable(subsystemDatabase)
+	}
+	return a.dbManager.GetProjectPermissionProfile(cleanProjectPath(projectPath))
+}
+
+// SetProjectPermissionProfile selects the profile of a project's sessions;
+// an empty ID clears the selection
+func (a *App) SetProjectPermissionProfile(projectPath, profileID string) error {
+	if a.dbManager == nil {
+		return a.unavailable(subsystemDatabase)
+	}
+	if profileID != "" && a.permissionProfile(profileID) == nil {
+		return fmt.Errorf("permission profile not found: %s", profileID)
+	}
+	return a.dbManager.SetProjectPermissionProfile(cleanProjectPath(projectPath), profileID)
+}
+
+// GetSessionPermissionProfile returns the profile the next sessions of a
+// project start with: the one picked for its sessions, else the project's
+func (a *App) GetSessionPermissionProfile(projectPath string) string {
+	projectPath = cleanProjectPath(projectPath)
+	a.mu.RLock()
+	id, ok := a.sessionProfiles[projectPath]
+	a.mu.RUnlock()
+	if ok {
+		return id
+	}
+	if a.dbManager == nil {
+		return ""
+	}
+	id, err := a.dbManager.GetProjectPermissionProfile(projectPath)
+	if err != nil {
+		log.Printf("[Permissions] %v", err)
+		return ""
+	}
+	return id
+}
+
+// SetSessionPermissionProfile picks the profile of the sessions a project
+// starts from now on, until the app restarts; an empty ID goes back to the
+// project's profile
+func (a *App) SetSessionPermissionProfile(projectPath, profileID string) error {
+	if profileID != "" && a.permissionProfile(profileID) == nil {
+		return fmt.Errorf("permission profile not found: %s", profileID)
+	}
+	projectPath = cleanProjectPath(projectPath)
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if profileID == "" {
+		delete(a.sessionProfiles, projectPath)
+		return nil
+	}
+	if a.sessionProfiles == nil {
+		a.sessionProfiles = make(map[string]string)
+	}
+	a.sessionProfiles[projectPath] = profileID
+	return nil
+}
+
+func builtinPermissionProfile(id string) *database.PermissionProfile {
+	for _, profile := range builtinPermissionProfiles() {
+		if profile.ID == id {
+			return profile
+		}
+	}
+	return nil
+}
+
+// permissionProfile returns the built-in or saved profile with id, nil when
+// there is none
+func (a *App) permissionProfile(id string) *database.PermissionProfile {
+	if profile := builtinPermissionProfile(id); profile != nil {
+		return profile
+	}
+	if a.dbManager == nil {
+		return nil
+	}
+	profile, err := a.dbManager.GetPermissionProfile(id)
+	if err != nil {
+		return nil
+	}
+	return profile
+}
+
+// sessionPermissionProfile returns the profile of a session starting in
+// projectPath, nil for full access. Without a profile Codex sessions get the
+// sandbox defaults of codex_sandbox.go.
+func (a *App) sessionPermissionProfile(projectPath string) *database.PermissionProfile {
+	id := a.GetSessionPermissionProfile(projectPath)
+	if id == "" {
+		return nil
+	}
+	profile := a.permissionProfile(id)
+	if profile == nil {
+		log.Printf("[Permissions] profile %s of %s not found, using full access", id, projectPath)
+	}
+	return profile
+}
+
+// applyPermissionProfileToClaude restricts a Claude session to its profile
+func (a *App) applyPermissionProfileToClaude(config *claude.SessionConfig) {
+	profile := a.sessionPermissionProfile(config.ProjectPath)
+	if profile == nil {
+		return
+	}
+	config.PermissionMode = profile.ClaudePermissionMode
+	config.AllowedTools = append(config.AllowedTools, profile.ClaudeAllowedTools...)
+	config.DisallowedTools = append(config.DisallowedTools, profile.ClaudeDisallowedTools...)
+}
+
+// applyPermissionProfileToCodex restricts a Codex session to its profile
+func (a *App) applyPermissionProfileToCodex(config *codex.SessionConfig) {
+	profile := a.sessionPermissionProfile(config.ProjectPath)
+	if profile == nil || profile.CodexSandbox == "" {
+		// The Codex sandbox settings apply
+		return
+	}
+	config.SandboxMode = profile.CodexSandbox
+	config.DisableNetwork = !profile.CodexNetworkAccess
+}
+
+// cleanToolList trims tool names and drops empty and duplicate ones
+func cleanToolList(tools []string) []string {
+	cleaned := make([]string, 0, len(tools))
+	for _, tool := range tools {
+		tool = strings.TrimSpace(tool)
+		if tool != "" && !slices.Contains(cleaned, tool) {
+			cleaned = append(cleaned, tool)
+		}
+	}
+	return cleaned
+}
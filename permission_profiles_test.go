@@ -0,0 +1,76 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"ropcode/internal/claude"
+	"ropcode/internal/codex"
+	"ropcode/internal/database"
+)
+
+func TestPermissionProfilesApplyToSessions(t *testing.T) {
+	app := &App{dbManager: openAppConfigTestDB(t)}
+
+	// Without a profile sessions keep full access
+	claudeConfig := claude.SessionConfig{ProjectPath: "/p"}
+	app.applyPermissionProfileToClaude(&claudeConfig)
+	if claudeConfig.PermissionMode != "" || len(claudeConfig.DisallowedTools) != 0 {
+		t.Fatalf("unexpected restrictions without a profile: %+v", claudeConfig)
+	}
+
+	if _, err := app.SavePermissionProfile(database.PermissionProfile{ID: permissionProfileFull, Name: "Mine"}); err == nil {
+		t.Fatal("expected a built-in profile to be read-only")
+	}
+	if _, err := app.SavePermissionProfile(database.PermissionProfile{Name: "Bad", ClaudePermissionMode: "default"}); err == nil {
+		t.Fatal("expected the interactive default permission mode to be rejected")
+	}
+	custom, err := app.SavePermissionProfile(database.PermissionProfile{
+		Name:                 "Edits only",
+		ClaudePermissionMode: "acceptEdits",
+		ClaudeAllowedTools:   []string{" Bash(git:*) ", "", "Bash(git:*)"},
+		CodexSandbox:         codex.SandboxWorkspaceWrite,
+		CodexNetworkAccess:   true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if custom.ID == "" || !reflect.DeepEqual(custom.ClaudeAllowedTools, []string{"Bash(git:*)"}) {
+		t.Fatalf("saved profile = %+v", custom)
+	}
+
+	if err := app.SetProjectPermissionProfile("/p/", permissionProfileReadOnly); err != nil {
+		t.Fatal(err)
+	}
+	claudeConfig = claude.SessionConfig{ProjectPath: "/p", DisallowedTools: []string{"mcp__github"}}
+	app.applyPermissionProfileToClaude(&claudeConfig)
+	if claudeConfig.PermissionMode != "dontAsk" || len(claudeConfig.AllowedTools) == 0 ||
+		!reflect.DeepEqual(claudeConfig.DisallowedTools, []string{"mcp__github"}) {
+		t.Fatalf("read-only Claude config = %+v", claudeConfig)
+	}
+	codexConfig := codex.SessionConfig{ProjectPath: "/p"}
+	app.applyPermissionProfileToCodex(&codexConfig)
+	if codexConfig.SandboxMode != codex.SandboxReadOnly || !codexConfig.DisableNetwork {
+		t.Fatalf("read-only Codex config = %+v", codexConfig)
+	}
+
+	// A profile picked for the sessions overrides the project's
+	if err := app.SetSessionPermissionProfile("/p", custom.ID); err != nil {
+		t.Fatal(err)
+	}
+	codexConfig = codex.SessionConfig{ProjectPath: "/p"}
+	app.applyPermissionProfileToCodex(&codexConfig)
+	if codexConfig.SandboxMode != codex.SandboxWorkspaceWrite || codexConfig.DisableNetwork {
+		t.Fatalf("session profile Codex config = %+v", codexConfig)
+	}
+
+	if err := app.DeletePermissionProfile(custom.ID); err != nil {
+		t.Fatal(err)
+	}
+	if id := app.GetSessionPermissionProfile("/p"); id != permissionProfileReadOnly {
+		t.Fatalf("profile after deleting the session's = %q, want the project's", id)
+	}
+	if err := app.SetSessionPermissionProfile("/p", "missing"); err == nil {
+		t.Fatal("expected an unknown profile to be rejected")
+	}
+}
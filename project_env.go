@@ -0,0 +1,149 @@
+// project_env.go
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"ropcode/internal/database"
+	"ropcode/internal/netproxy"
+)
+
+// maskedEnvValue stands in for the value of a secret variable. Saving a
+// variable that was secret with it keeps the stored value.
+const maskedEnvValue = "********"
+
+var envVarName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ListProjectEnvVars returns the environment variables of a project with
+// secret values masked
+func (a *App) ListProjectEnvVars(projectPath string) ([]*database.ProjectEnvVar, error) {
+	if a.dbManager == nil {
+		return nil, a.unavailable(subsystemDatabase)
+	}
+	vars, err := a.dbManager.ListProjectEnvVars(cleanProjectPath(projectPath))
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range vars {
+		maskProjectEnvVar(v)
+	}
+	return vars, nil
+}
+
+// SaveProjectEnvVar adds a project environment variable, or updates it when
+// it has an ID, and returns it as listed
+func (a *App) SaveProjectEnvVar(v database.ProjectEnvVar) (*database.ProjectEnvVar, error) {
+	if a.dbManager == nil {
+		return nil, a.unavailable(subsystemDatabase)
+	}
+	if v.ProjectPath == "" {
+		return nil, fmt.Errorf("project path is required")
+	}
+	if !envVarName.MatchString(v.Name) {
+		return nil, fmt.Errorf("invalid environment variable name: %q", v.Name)
+	}
+	v.ProjectPath = cleanProjectPath(v.ProjectPath)
+	if v.ID != 0 && v.Value == maskedEnvValue {
+		stored, err := a.dbManager.GetProjectEnvVar(v.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load environment variable %s: %w", v.Name, err)
+		}
+		if stored.Secret {
+			v.Value = stored.Value
+		}
+	}
+	if err := a.dbManager.SaveProjectEnvVar(&v); err != nil {
+		return nil, fmt.Errorf("failed to save environment variable %s: %w", v.Name, err)
+	}
+	maskProjectEnvVar(&v)
+	return &v, nil
+}
+
+// DeleteProjectEnvVar deletes a project environment variable
+func (a *App) DeleteProjectEnvVar(id int64) error {
+	if a.dbManager == nil {
+		return a.unavailable(subsystemDatabase)
+	}
+	return a.dbManager.DeleteProjectEnvVar(id)
+}
+
+func maskProjectEnvVar(v *database.ProjectEnvVar) {
+	if v.Secret {
+		v.Value = maskedEnvValue
+	}
+}
+
+func cleanProjectPath(projectPath string) string {
+	if projectPath == "" {
+		return ""
+	}
+	return filepath.Clean(projectPath)
+}
+
+// projectEnv returns the environment variables of the project at
+// projectPath, nil when it has none
+func (a *App) projectEnv(projectPath string) map[string]string {
+	if a.dbManager == nil || projectPath == "" {
+		return nil
+	}
+	vars, err := a.dbManager.ListProjectEnvVars(cleanProjectPath(projectPath))
+	if err != nil {
+		slog.Warn("Failed to load environment", "component", "ProjectEnv", "project", projectPath, "error", err)
+		return nil
+	}
+	if len(vars) == 0 {
+		return nil
+	}
+	env := make(map[string]string, len(vars))
+	for _, v := range vars {
+		env[v.Name] = v.Value
+	}
+	return env
+}
+
+// sessionEnv returns the environment added to provider sessions of the
+// project at projectPath: the proxy settings, then the project's variables
+func (a *App) sessionEnv(projectPath string) map[string]string {
+	proxyEnv := netproxy.Env()
+	env := a.projectEnv(projectPath)
+	if len(proxyEnv) == 0 {
+		return env
+	}
+	merged := make(map[string]string, len(proxyEnv)+len(env))
+	for _, entry := range proxyEnv {
+		name, value, _ := strings.Cut(entry, "=")
+		merged[name] = value
+	}
+	for name, value := range env {
+		merged[name] = value
+	}
+	return merged
+}
+
+// projectEnvList returns the project's environment variables as NAME=value
+// entries sorted by name
+func (a *App) projectEnvList(projectPath string) []string {
+	env := a.projectEnv(projectPath)
+	list := make([]string, 0, len(env))
+	for name, value := range env {
+		list = append(list, name+"="+value)
+	}
+	sort.Strings(list)
+	return list
+}
+
+// projectCommandEnv returns the full environment of a command run in the
+// project at projectPath, nil to inherit it unchanged
+func (a *App) projectCommandEnv(projectPath string) []string {
+	list := a.projectEnvList(projectPath)
+	if len(list) == 0 {
+		return nil
+	}
+	return append(os.Environ(), list...)
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"ropcode/internal/database"
+)
+
+func TestProjectEnvVarsMaskSecrets(t *testing.T) {
+	app := &App{dbManager: openAppConfigTestDB(t)}
+
+	if _, err := app.SaveProjectEnvVar(database.ProjectEnvVar{ProjectPath: "/p", Name: "BAD-NAME"}); err == nil {
+		t.Fatal("expected an invalid name to be rejected")
+	}
+	if _, err := app.SaveProjectEnvVar(database.ProjectEnvVar{ProjectPath: "/p/", Name: "HTTPS_PROXY", Value: "http://proxy:8080"}); err != nil {
+		t.Fatal(err)
+	}
+	token, err := app.SaveProjectEnvVar(database.ProjectEnvVar{ProjectPath: "/p", Name: "API_TOKEN", Value: "s3cret", Secret: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.Value != maskedEnvValue {
+		t.Fatalf("saved secret value = %q, want it masked", token.Value)
+	}
+
+	// Saving the masked value back, e.g. after renaming or unmarking it as
+	// secret, keeps the stored value
+	token.Name = "SERVICE_TOKEN"
+	if _, err := app.SaveProjectEnvVar(*token); err != nil {
+		t.Fatal(err)
+	}
+
+	listed, err := app.ListProjectEnvVars("/p")
+	if err != nil || len(listed) != 2 || listed[1].Name != "SERVICE_TOKEN" || listed[1].Value != maskedEnvValue {
+		t.Fatalf("ListProjectEnvVars() = %+v, %v", listed, err)
+	}
+	want := []string{"HTTPS_PROXY=http://proxy:8080", "SERVICE_TOKEN=s3cret"}
+	if got := app.projectEnvList("/p"); !reflect.DeepEqual(got, want) {
+		t.Fatalf("projectEnvList() = %q, want %q", got, want)
+	}
+
+	if err := app.DeleteProjectEnvVar(token.ID); err != nil {
+		t.Fatal(err)
+	}
+	if env := app.projectEnv("/p"); len(env) != 1 || env["HTTPS_PROXY"] == "" {
+		t.Fatalf("projectEnv() after delete = %v", env)
+	}
+	if env := app.projectCommandEnv("/elsewhere"); env != nil {
+		t.Fatalf("projectCommandEnv() without variables = %v, want nil", env)
+	}
+}
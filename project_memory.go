@@ -0,0 +1,70 @@
+// project_memory.go
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"ropcode/internal/projectmemory"
+)
+
+// projectMemoryTimeout bounds drafting a CLAUDE.md
+const projectMemoryTimeout = 5 * time.Minute
+
+// ProjectMemoryDraft is a CLAUDE.md drafted for a project, not saved yet
+type ProjectMemoryDraft struct {
+	// Path is where the draft is meant to be saved
+	Path string `json:"path"`
+	// Exists is set when saving the draft replaces a CLAUDE.md
+	Exists  bool                  `json:"exists"`
+	Content string                `json:"content"`
+	Survey  *projectmemory.Survey `json:"survey"`
+}
+
+// GenerateProjectMemory drafts a CLAUDE.md for the project at projectPath
+// with provider, which defaults to claude. Nothing is written; the UI saves
+// the draft once the user confirms it.
+func (a *App) GenerateProjectMemory(projectPath, provider string) (*ProjectMemoryDraft, error) {
+	provider, err := validateAgentProvider(provider)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.requireLocalProject(projectPath, provider); err != nil {
+		return nil, err
+	}
+	survey, err := projectmemory.Inspect(projectPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect project: %w", err)
+	}
+
+	parent := a.ctx
+	if parent == nil {
+		parent = context.Background()
+	}
+	ctx, cancel := context.WithTimeout(parent, projectMemoryTimeout)
+	defer cancel()
+
+	var content string
+	switch provider {
+	case "codex":
+		content, err = a.runCodexCLIForTitle(ctx, projectPath, "", survey.Prompt())
+	case "gemini":
+		content, err = a.runGeminiCLIForTitle(ctx, projectPath, "", survey.Prompt())
+	default:
+		content, err = a.runClaudeCLIForTitle(ctx, projectPath, "", survey.Prompt())
+	}
+	if err != nil {
+		return nil, err
+	}
+	content = projectmemory.CleanDraft(content)
+	if content == "" {
+		return nil, fmt.Errorf("%s returned an empty draft", provider)
+	}
+
+	path := filepath.Join(projectPath, "CLAUDE.md")
+	_, statErr := os.Stat(path)
+	return &ProjectMemoryDraft{Path: path, Exists: statErr == nil, Content: content + "\n", Survey: survey}, nil
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"ropcode/internal/gemini"
+)
+
+func TestGenerateProjectMemoryDraftsWithoutSaving(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script as the provider CLI")
+	}
+	project := t.TempDir()
+	if err := os.WriteFile(filepath.Join(project, "go.mod"), []byte("module example.com/shop\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// The fake CLI answers with its prompt, fenced like models tend to do
+	binPath := filepath.Join(t.TempDir(), "fake-gemini.sh")
+	script := "#!/bin/sh\nfor last; do :; done\nprintf '```markdown\\n%s\\n```\\n' \"$last\"\n"
+	if err := os.WriteFile(binPath, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	manager := gemini.NewSessionManager(context.Background(), nil)
+	manager.SetBinaryPath(binPath)
+	app := &App{geminiManager: manager}
+
+	draft, err := app.GenerateProjectMemory(project, "gemini")
+	if err != nil {
+		t.Fatalf("GenerateProjectMemory: %v", err)
+	}
+	if draft.Path != filepath.Join(project, "CLAUDE.md") || draft.Exists {
+		t.Fatalf("draft = %+v", draft)
+	}
+	if strings.HasPrefix(draft.Content, "```") || !strings.Contains(draft.Content, "module example.com/shop") {
+		t.Fatalf("content = %q", draft.Content)
+	}
+	if _, err := os.Stat(draft.Path); !os.IsNotExist(err) {
+		t.Fatal("draft saved before confirmation")
+	}
+
+	if _, err := app.GenerateProjectMemory(project, "cursor"); err == nil {
+		t.Fatal("drafted with an unsupported provider")
+	}
+}
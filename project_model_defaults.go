@@ -0,0 +1,150 @@
+// project_model_defaults.go
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"ropcode/internal/database"
+)
+
+// ProjectModelDefaults are the model and thinking level new sessions of a
+// provider start with in a project
+type ProjectModelDefaults struct {
+	Model         string `json:"model"`
+	ThinkingLevel string `json:"thinking_level"`
+}
+
+// GetProjectModelDefaults returns the defaults of providerName in the
+// project or workspace at projectPath; empty fields have no default. A
+// workspace inherits its project's defaults unless it sets its own.
+func (a *App) GetProjectModelDefaults(projectPath, providerName string) (*ProjectModelDefaults, error) {
+	if a.dbManager == nil {
+		return nil, a.unavailable(subsystemDatabase)
+	}
+	project, workspace, err := a.findProjectIndex(projectPath)
+	if err != nil {
+		return nil, err
+	}
+	defaults := &ProjectModelDefaults{}
+	if project == nil {
+		return defaults, nil
+	}
+	if workspace != nil {
+		mergeModelDefaults(defaults, workspace.Providers, providerName)
+	}
+	mergeModelDefaults(defaults, project.Providers, providerName)
+	return defaults, nil
+}
+
+// SetProjectModelDefaults sets the defaults of providerName in the project
+// or workspace at projectPath; empty values clear them
+func (a *App) SetProjectModelDefaults(projectPath, providerName, model, thinkingLevel string) error {
+	if a.dbManager == nil {
+		return a.unavailable(subsystemDatabase)
+	}
+	providerName = strings.TrimSpace(providerName)
+	if providerName == "" {
+		return fmt.Errorf("provider is required")
+	}
+	project, workspace, err := a.findProjectIndex(projectPath)
+	if err != nil {
+		return err
+	}
+	if project == nil {
+		return fmt.Errorf("project not found: %s", projectPath)
+	}
+
+	setDefaults := func(providers *[]database.ProviderInfo, name string) {
+		info := providerInfoFor(providers, providerName)
+		if info == nil {
+			*providers = append(*providers, database.ProviderInfo{
+				ID:         name,
+				ProviderID: providerName,
+				Path:       projectPath,
+			})
+			info = &(*providers)[len(*providers)-1]
+		}
+		info.DefaultModel = strings.TrimSpace(model)
+		info.DefaultThinkingLevel = strings.TrimSpace(thinkingLevel)
+	}
+	if workspace != nil {
+		_, err = a.dbManager.PatchWorkspaceIndex(workspace.Name, func(_ *database.ProjectIndex, workspace *database.WorkspaceIndex) error {
+			setDefaults(&workspace.Providers, workspace.Name)
+			return nil
+		})
+		return err
+	}
+	_, err = a.dbManager.PatchProjectIndex(project.Name, func(project *database.ProjectIndex) error {
+		setDefaults(&project.Providers, project.Name)
+		return nil
+	})
+	return err
+}
+
+// applyProjectModelDefaults fills in an empty model, and then an empty
+// reasoning effort for that model, from the project's defaults
+func (a *App) applyProjectModelDefaults(provider, projectPath, model, reasoningEffort string) (string, string) {
+	if a.dbManager == nil || model != "" && reasoningEffort != "" {
+		return model, reasoningEffort
+	}
+	defaults, err := a.GetProjectModelDefaults(projectPath, normalizeSessionProvider(provider))
+	if err != nil {
+		return model, reasoningEffort
+	}
+	if model == "" {
+		model = defaults.Model
+	}
+	// A thinking level is only meaningful for the model it was picked with
+	if reasoningEffort == "" && (defaults.Model == "" || model == defaults.Model) {
+		reasoningEffort = defaults.ThinkingLevel
+	}
+	return model, reasoningEffort
+}
+
+// findProjectIndex returns the project index at projectPath, or the
+// project owning the workspace at projectPath together with the workspace.
+// Both are nil when neither is indexed.
+func (a *App) findProjectIndex(projectPath string) (*database.ProjectIndex, *database.WorkspaceIndex, error) {
+	name := filepath.Base(projectPath)
+	if project, err := a.dbManager.GetProjectIndex(name); err == nil {
+		return project, nil, nil
+	}
+	projects, err := a.dbManager.GetAllProjectIndexes()
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, project := range projects {
+		for i := range project.Workspaces {
+			if project.Workspaces[i].Name == name {
+				return project, &project.Workspaces[i], nil
+			}
+		}
+	}
+	return nil, nil, nil
+}
+
+func providerInfoFor(providers *[]database.ProviderInfo, providerName string) *database.ProviderInfo {
+	for i := range *providers {
+		if (*providers)[i].ProviderID == providerName {
+			return &(*providers)[i]
+		}
+	}
+	return nil
+}
+
+// mergeModelDefaults fills the empty fields of defaults from the provider
+// info of providerName
+func mergeModelDefaults(defaults *ProjectModelDefaults, providers []database.ProviderInfo, providerName string) {
+	info := providerInfoFor(&providers, providerName)
+	if info == nil {
+		return
+	}
+	if defaults.Model == "" {
+		defaults.Model = info.DefaultModel
+		if defaults.ThinkingLevel == "" {
+			defaults.ThinkingLevel = info.DefaultThinkingLevel
+		}
+	}
+}
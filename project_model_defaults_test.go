@@ -0,0 +1,75 @@
+package main
+
+import (
+	"testing"
+
+	"ropcode/internal/database"
+)
+
+func TestProjectModelDefaultsInheritToWorkspaces(t *testing.T) {
+	db := openAppConfigTestDB(t)
+	if err := db.SaveProjectIndex(&database.ProjectIndex{
+		Name:       "demo",
+		Providers:  []database.ProviderInfo{{ID: "demo", ProviderID: "codex", Path: "/src/demo", ProviderApiID: "relay"}},
+		Workspaces: []database.WorkspaceIndex{{Name: "feature"}},
+	}); err != nil {
+		t.Fatalf("SaveProjectIndex() error = %v", err)
+	}
+	app := &App{dbManager: db}
+	workspacePath := "/src/demo/.ropcode/feature"
+
+	if err := app.SetProjectModelDefaults("/src/demo", "codex", "gpt-5", "high"); err != nil {
+		t.Fatalf("SetProjectModelDefaults() error = %v", err)
+	}
+	project, _ := db.GetProjectIndex("demo")
+	if info := project.Providers[0]; info.ProviderApiID != "relay" || info.DefaultModel != "gpt-5" {
+		t.Fatalf("provider info = %+v", info)
+	}
+	defaults, err := app.GetProjectModelDefaults(workspacePath, "codex")
+	if err != nil || *defaults != (ProjectModelDefaults{Model: "gpt-5", ThinkingLevel: "high"}) {
+		t.Fatalf("workspace defaults = %+v, %v", defaults, err)
+	}
+
+	if err := app.SetProjectModelDefaults(workspacePath, "codex", "gpt-5-codex", ""); err != nil {
+		t.Fatalf("SetProjectModelDefaults(workspace) error = %v", err)
+	}
+	// The project's thinking level belongs to its own model
+	defaults, _ = app.GetProjectModelDefaults(workspacePath, "codex")
+	if *defaults != (ProjectModelDefaults{Model: "gpt-5-codex"}) {
+		t.Fatalf("workspace defaults = %+v", defaults)
+	}
+	if defaults, _ := app.GetProjectModelDefaults("/src/demo", "claude"); *defaults != (ProjectModelDefaults{}) {
+		t.Fatalf("claude defaults = %+v", defaults)
+	}
+	if defaults, err := app.GetProjectModelDefaults("/src/unknown", "codex"); err != nil || *defaults != (ProjectModelDefaults{}) {
+		t.Fatalf("unknown project defaults = %+v, %v", defaults, err)
+	}
+}
+
+func TestApplyProjectModelDefaultsOnlyFillsEmptyValues(t *testing.T) {
+	db := openAppConfigTestDB(t)
+	if err := db.SaveProjectIndex(&database.ProjectIndex{Name: "demo"}); err != nil {
+		t.Fatalf("SaveProjectIndex() error = %v", err)
+	}
+	app := &App{dbManager: db}
+	if err := app.SetProjectModelDefaults("/src/demo", "codex", "gpt-5", "high"); err != nil {
+		t.Fatalf("SetProjectModelDefaults() error = %v", err)
+	}
+
+	tests := []struct {
+		model, effort         string
+		wantModel, wantEffort string
+	}{
+		{"", "", "gpt-5", "high"},
+		{"", "low", "gpt-5", "low"},
+		{"o3", "", "o3", ""},
+		{"gpt-5", "", "gpt-5", "high"},
+	}
+	for _, tt := range tests {
+		model, effort := app.applyProjectModelDefaults("codex", "/src/demo", tt.model, tt.effort)
+		if model != tt.wantModel || effort != tt.wantEffort {
+			t.Errorf("applyProjectModelDefaults(%q, %q) = %q, %q, want %q, %q",
+				tt.model, tt.effort, model, effort, tt.wantModel, tt.wantEffort)
+		}
+	}
+}
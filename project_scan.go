@@ -0,0 +1,109 @@
+// project_scan.go
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"ropcode/internal/projectscan"
+)
+
+// ProjectAddFailure is a project AddProjectsToIndex could not add
+type ProjectAddFailure struct {
+	Path  string `json:"path"`
+	Error string `json:"error"`
+}
+
+// ProjectsAddResult reports what AddProjectsToIndex did
+type ProjectsAddResult struct {
+	Added  []string            `json:"added"`
+	Failed []ProjectAddFailure `json:"failed"`
+}
+
+// ScanForProjects returns the git repositories under rootDirs that are not
+// in the project index. A leading ~ in a root is the home directory. The
+// index is keyed by directory name, so candidates sharing a name with an
+// indexed project are flagged.
+func (a *App) ScanForProjects(rootDirs []string) ([]projectscan.Candidate, error) {
+	if a.dbManager == nil {
+		return []projectscan.Candidate{}, a.unavailable(subsystemDatabase)
+	}
+	roots := make([]string, 0, len(rootDirs))
+	for _, root := range rootDirs {
+		if root = expandHome(strings.TrimSpace(root)); root != "" {
+			roots = append(roots, root)
+		}
+	}
+	candidates, err := projectscan.Scan(roots, projectscan.DefaultMaxDepth)
+	if err != nil {
+		return []projectscan.Candidate{}, err
+	}
+
+	projects, err := a.dbManager.GetAllProjectIndexes()
+	if err != nil {
+		return []projectscan.Candidate{}, err
+	}
+	indexedPaths := make(map[string]bool)
+	indexedNames := make(map[string]bool)
+	for _, project := range projects {
+		indexedNames[project.Name] = true
+		for _, provider := range project.Providers {
+			indexedPaths[filepath.Clean(provider.Path)] = true
+		}
+		for _, workspace := range project.Workspaces {
+			for _, provider := range workspace.Providers {
+				indexedPaths[filepath.Clean(provider.Path)] = true
+			}
+		}
+	}
+
+	unindexed := []projectscan.Candidate{}
+	for _, candidate := range candidates {
+		if indexedPaths[candidate.Path] {
+			continue
+		}
+		candidate.NameInUse = indexedNames[candidate.Name]
+		unindexed = append(unindexed, candidate)
+	}
+	return unindexed, nil
+}
+
+// AddProjectsToIndex adds each of paths to the project index like
+// AddProjectToIndex, carrying on past the ones that fail
+func (a *App) AddProjectsToIndex(paths []string) (*ProjectsAddResult, error) {
+	if a.dbManager == nil {
+		return nil, a.unavailable(subsystemDatabase)
+	}
+	result := &ProjectsAddResult{Added: []string{}, Failed: []ProjectAddFailure{}}
+	for _, path := range paths {
+		path = filepath.Clean(expandHome(path))
+		var err error
+		if info, statErr := os.Stat(path); statErr != nil {
+			err = statErr
+		} else if !info.IsDir() {
+			err = fmt.Errorf("not a directory: %s", path)
+		} else {
+			err = a.AddProjectToIndex(path)
+		}
+		if err != nil {
+			result.Failed = append(result.Failed, ProjectAddFailure{Path: path, Error: err.Error()})
+			continue
+		}
+		result.Added = append(result.Added, path)
+	}
+	return result, nil
+}
+
+// expandHome replaces a leading ~ with the home directory
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, strings.TrimPrefix(path, "~"))
+}
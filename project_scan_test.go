@@ -0,0 +1,50 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"ropcode/internal/database"
+)
+
+func TestScanForProjectsSkipsIndexedRepositories(t *testing.T) {
+	root := t.TempDir()
+	for _, repo := range []string{"indexed", "fresh", filepath.Join("other", "indexed")} {
+		if err := os.MkdirAll(filepath.Join(root, repo, ".git"), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	db := openAppConfigTestDB(t)
+	if err := db.SaveProjectIndex(&database.ProjectIndex{
+		Name:      "indexed",
+		Providers: []database.ProviderInfo{{ID: "indexed", ProviderID: "claude", Path: filepath.Join(root, "indexed")}},
+	}); err != nil {
+		t.Fatalf("SaveProjectIndex() error = %v", err)
+	}
+	app := &App{dbManager: db}
+
+	candidates, err := app.ScanForProjects([]string{root})
+	if err != nil {
+		t.Fatalf("ScanForProjects() error = %v", err)
+	}
+	if len(candidates) != 2 {
+		t.Fatalf("candidates = %+v, want fresh and other/indexed", candidates)
+	}
+	for _, candidate := range candidates {
+		if want := candidate.Name == "indexed"; candidate.NameInUse != want {
+			t.Errorf("%s name in use = %v, want %v", candidate.Path, candidate.NameInUse, want)
+		}
+	}
+
+	// Missing paths and files are reported, not added
+	notes := filepath.Join(root, "notes.txt")
+	os.WriteFile(notes, []byte("x"), 0644)
+	result, err := app.AddProjectsToIndex([]string{filepath.Join(root, "missing"), notes})
+	if err != nil {
+		t.Fatalf("AddProjectsToIndex() error = %v", err)
+	}
+	if len(result.Added) != 0 || len(result.Failed) != 2 {
+		t.Fatalf("result = %+v, want both paths failed", result)
+	}
+}
@@ -0,0 +1,37 @@
+// provider_capabilities.go
+package main
+
+import (
+	"ropcode/internal/clicompat"
+	"ropcode/internal/cliinstall"
+)
+
+// probeProviderCLIs starts reading the version of every provider CLI, so
+// sessions needing a newer CLI fail up front with the version to update to
+func (a *App) probeProviderCLIs() {
+	for _, provider := range cliinstall.Providers {
+		locator, err := a.binaryLocator(provider)
+		if err != nil {
+			continue
+		}
+		if path, err := locator.ResolveBinaryPath(); err == nil {
+			clicompat.Probe(path)
+		}
+	}
+}
+
+// GetProviderCapabilities reports which features the CLI provider's
+// sessions use supports, and the version each needs
+func (a *App) GetProviderCapabilities(provider string) (*clicompat.Capabilities, error) {
+	locator, err := a.binaryLocator(provider)
+	if err != nil {
+		return nil, err
+	}
+	path, err := locator.ResolveBinaryPath()
+	if err != nil {
+		c := clicompat.Report(provider, "")
+		c.Error = err.Error()
+		return c, nil
+	}
+	return clicompat.Check(provider, path), nil
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"ropcode/internal/clicompat"
+	"ropcode/internal/codex"
+)
+
+func TestOutdatedCLIIsGatedBeforeTheSessionStarts(t *testing.T) {
+	app := newInstallTestApp(t)
+	binary := fakeCLI(t, "codex-cli 0.30.0")
+	if err := app.SwitchProviderInstallation("codex", binary); err != nil {
+		t.Fatal(err)
+	}
+
+	capabilities, err := app.GetProviderCapabilities("codex")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if capabilities.Path != binary || !capabilities.Known || capabilities.Supports("json") {
+		t.Errorf("GetProviderCapabilities() = %+v", capabilities)
+	}
+
+	_, err = app.codexManager.StartSession(codex.SessionConfig{ProjectPath: t.TempDir(), Prompt: "hi"})
+	var unsupported *clicompat.UnsupportedError
+	if !errors.As(err, &unsupported) || unsupported.Feature.ID != "json" {
+		t.Fatalf("StartSession() error = %v, want the json feature gated", err)
+	}
+
+	if _, err := app.GetProviderCapabilities("cursor"); err == nil {
+		t.Error("GetProviderCapabilities() for an unknown provider should fail")
+	}
+}
@@ -0,0 +1,327 @@
+// provider_comparisons.go
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"ropcode/internal/database"
+	"ropcode/internal/git"
+)
+
+// normalizeComparisonProviders validates and de-duplicates providers, keeping order.
+func normalizeComparisonProviders(providers []string) ([]string, error) {
+	seen := make(map[string]bool, len(providers))
+	result := make([]string, 0, len(providers))
+	for _, provider := range providers {
+		provider = strings.TrimSpace(provider)
+		if provider == "" || seen[provider] {
+			continue
+		}
+		if _, err := validateAgentProvider(provider); err != nil {
+			return nil, err
+		}
+		seen[provider] = true
+		result = append(result, provider)
+	}
+	if len(result) < 2 {
+		return nil, fmt.Errorf("select at least two providers to compare")
+	}
+	return result, nil
+}
+
+// providerComparisonRunner drives one comparison. mu guards comparison, which
+// is shared by the per-provider goroutines.
+type providerComparisonRunner struct {
+	app        *App
+	mu         sync.Mutex
+	comparison *database.ProviderComparison
+}
+
+// update applies fn to the comparison, then persists and broadcasts the result.
+func (r *providerComparisonRunner) update(fn func(comparison *database.ProviderComparison)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	fn(r.comparison)
+	if err := r.app.dbManager.UpdateProviderComparison(r.comparison); err != nil {
+		slog.Error("failed to save comparison", "component", "provider-comparison", "comparison_id", r.comparison.ID, "error", err)
+	}
+	if r.app.eventHub != nil {
+		snapshot := *r.comparison
+		snapshot.Items = append([]database.ProviderComparisonItem(nil), r.comparison.Items...)
+		r.app.eventHub.Emit("provider-comparison:changed", &snapshot)
+	}
+}
+
+func (r *providerComparisonRunner) run(ctx context.Context) {
+	r.update(func(comparison *database.ProviderComparison) { comparison.Status = "running" })
+
+	// Worktrees are added one at a time: each one rewrites the project index
+	r.mu.Lock()
+	projectPath := r.comparison.ProjectPath
+	providers := make([]string, 0, len(r.comparison.Items))
+	for _, item := range r.comparison.Items {
+		providers = append(providers, item.Provider)
+	}
+	r.mu.Unlock()
+	ready := make([]bool, len(providers))
+	for i, provider := range providers {
+		if ctx.Err() != nil {
+			break
+		}
+		workspacePath, branch, base, err := r.app.addComparisonWorktree(projectPath, r.comparison.ID, provider)
+		r.update(func(comparison *database.ProviderComparison) {
+			item := &comparison.Items[i]
+			if err != nil {
+				item.Status = "failed"
+				item.Error = err.Error()
+				return
+			}
+			item.WorkspacePath, item.Branch, item.BaseCommit = workspacePath, branch, base
+		})
+		ready[i] = err == nil
+	}
+
+	var wg sync.WaitGroup
+	for i := range providers {
+		if !ready[i] || ctx.Err() != nil {
+			continue
+		}
+		wg.Add(1)
+		go func(index int) {
+			defer wg.Done()
+			r.runItem(ctx, index)
+		}(i)
+	}
+	wg.Wait()
+
+	r.update(func(comparison *database.ProviderComparison) {
+		failed := false
+		for i := range comparison.Items {
+			switch comparison.Items[i].Status {
+			case "pending":
+				comparison.Items[i].Status = "cancelled"
+			case "failed", "cancelled":
+				failed = true
+			}
+		}
+		switch {
+		case ctx.Err() != nil:
+			comparison.Status = "cancelled"
+		case failed:
+			comparison.Status = "failed"
+		default:
+			comparison.Status = "completed"
+		}
+		completedAt := time.Now()
+		comparison.CompletedAt = &completedAt
+	})
+}
+
+func (r *providerComparisonRunner) runItem(ctx context.Context, index int) {
+	r.mu.Lock()
+	item := r.comparison.Items[index]
+	prompt := r.comparison.Prompt
+	r.mu.Unlock()
+
+	startedAt := time.Now()
+	sessionID, err := r.app.StartProviderSession(item.Provider, item.WorkspacePath, prompt, "", "", "")
+	if err != nil {
+		r.update(func(comparison *database.ProviderComparison) {
+			comparison.Items[index].Status = "failed"
+			comparison.Items[index].Error = err.Error()
+		})
+		return
+	}
+	r.update(func(comparison *database.ProviderComparison) {
+		comparison.Items[index].Status = "running"
+		comparison.Items[index].SessionID = sessionID
+		comparison.Items[index].StartedAt = &startedAt
+	})
+
+	manager := r.app.sessionManagerFor(item.Provider)
+	status, err := manager.WaitForSession(ctx, sessionID)
+	if err != nil && ctx.Err() != nil {
+		if termErr := manager.TerminateSession(sessionID); termErr != nil {
+			slog.Warn("failed to stop session", "component", "provider-comparison", "session_id", sessionID, "error", termErr)
+		}
+		status, err = "cancelled", nil
+	} else if err != nil {
+		status = "failed"
+	}
+	completedAt := time.Now()
+
+	var metrics database.AgentRunMetrics
+	if output, outputErr := manager.GetSessionOutput(sessionID); outputErr == nil {
+		metrics = parseRunMetrics(output)
+	}
+	metrics.DurationMS = completedAt.Sub(startedAt).Milliseconds()
+	diff, diffErr := git.WorkTreeDiff(item.WorkspacePath, item.BaseCommit)
+	if diffErr != nil {
+		slog.Warn("failed to diff", "component", "provider-comparison", "workspace", item.WorkspacePath, "error", diffErr)
+	}
+
+	r.update(func(comparison *database.ProviderComparison) {
+		item := &comparison.Items[index]
+		item.Status = status
+		if err != nil {
+			item.Error = err.Error()
+		}
+		item.CompletedAt = &completedAt
+		item.Metrics = metrics
+		item.Diff = diff
+	})
+}
+
+// addComparisonWorktree adds the worktree a provider of a comparison works in
+// and returns its path, branch and starting commit.
+func (a *App) addComparisonWorktree(projectPath string, comparisonID int64, provider string) (string, string, string, error) {
+	name := fmt.Sprintf("compare-%d-%s", comparisonID, provider)
+	branch := "ropcode/" + name
+	if err := a.CreateWorkspace(projectPath, branch, name); err != nil {
+		return "", "", "", err
+	}
+	workspacePath := filepath.Join(projectPath, ".ropcode", name)
+	if err := a.setWorkspaceProvider(projectPath, name, provider); err != nil {
+		slog.Warn("failed to set provider of workspace", "component", "provider-comparison", "workspace", name, "error", err)
+	}
+
+	cmd := git.Command("rev-parse", "HEAD")
+	cmd.Dir = workspacePath
+	out, err := cmd.Output()
+	if err != nil {
+		return "", "", "", fmt.Errorf("failed to resolve HEAD of %s: %w", workspacePath, err)
+	}
+	return workspacePath, branch, strings.TrimSpace(string(out)), nil
+}
+
+// setWorkspaceProvider makes provider the one that opens workspace name of
+// the project at projectPath.
+func (a *App) setWorkspaceProvider(projectPath, name, provider string) error {
+	_, err := a.dbManager.PatchProjectIndex(filepath.Base(projectPath), func(project *database.ProjectIndex) error {
+		for i := range project.Workspaces {
+			workspace := &project.Workspaces[i]
+			if workspace.Name != name {
+				continue
+			}
+			for j := range workspace.Providers {
+				workspace.Providers[j].ProviderID = provider
+			}
+			workspace.LastProvider = provider
+			return nil
+		}
+		return fmt.Errorf("workspace not found: %s", name)
+	})
+	return err
+}
+
+// CompareProviders runs prompt with every provider in providers, each in a
+// worktree of projectPath of its own, and returns the comparison record
+// immediately. Progress is reported through "provider-comparison:changed"
+// events. Worktrees are kept afterwards so the winning change can be merged
+// from its branch.
+func (a *App) CompareProviders(projectPath, prompt string, providers []string) (*database.ProviderComparison, error) {
+	if a.dbManager == nil {
+		return nil, a.unavailable(subsystemDatabase)
+	}
+
+	providers, err := normalizeComparisonProviders(providers)
+	if err != nil {
+		return nil, err
+	}
+	for _, provider := range providers {
+		if a.sessionManagerFor(provider) == nil {
+			return nil, fmt.Errorf("%s manager not initialized", provider)
+		}
+	}
+	if strings.TrimSpace(prompt) == "" {
+		return nil, fmt.Errorf("prompt is empty")
+	}
+	if err := a.requireLocalProject(projectPath, "comparison"); err != nil {
+		return nil, err
+	}
+	cmd := git.Command("rev-parse", "--verify", "HEAD")
+	cmd.Dir = projectPath
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("%s is not a git repository with commits", projectPath)
+	}
+	if _, err := a.dbManager.GetProjectIndex(filepath.Base(projectPath)); err != nil {
+		return nil, err
+	}
+
+	comparison := &database.ProviderComparison{
+		ProjectPath: projectPath,
+		Prompt:      prompt,
+		Status:      "pending",
+		Items:       make([]database.ProviderComparisonItem, 0, len(providers)),
+	}
+	for _, provider := range providers {
+		comparison.Items = append(comparison.Items, database.ProviderComparisonItem{Provider: provider, Status: "pending"})
+	}
+	if _, err := a.dbManager.CreateProviderComparison(comparison); err != nil {
+		return nil, err
+	}
+
+	parent := a.ctx
+	if parent == nil {
+		parent = context.Background()
+	}
+	ctx, cancel := context.WithCancel(parent)
+	a.providerComparisons.add(comparison.ID, cancel)
+
+	snapshot := *comparison
+	snapshot.Items = append([]database.ProviderComparisonItem(nil), comparison.Items...)
+	runner := &providerComparisonRunner{app: a, comparison: comparison}
+	go func() {
+		defer cancel()
+		defer a.providerComparisons.remove(comparison.ID)
+		runner.run(ctx)
+	}()
+	return &snapshot, nil
+}
+
+// GetProviderComparison returns a provider comparison by ID
+func (a *App) GetProviderComparison(comparisonID int64) (*database.ProviderComparison, error) {
+	if a.dbManager == nil {
+		return nil, a.unavailable(subsystemDatabase)
+	}
+	return a.dbManager.GetProviderComparison(comparisonID)
+}
+
+// ListProviderComparisons returns provider comparisons, optionally filtered
+// by project path
+func (a *App) ListProviderComparisons(projectPath string, limit int) ([]*database.ProviderComparison, error) {
+	if a.dbManager == nil {
+		return nil, a.unavailable(subsystemDatabase)
+	}
+	if limit <= 0 {
+		limit = 50 // Default limit
+	}
+	return a.dbManager.ListProviderComparisons(projectPath, limit)
+}
+
+// CancelProviderComparison stops a running comparison: running sessions are
+// terminated and providers that have not started yet are skipped.
+func (a *App) CancelProviderComparison(comparisonID int64) error {
+	if !a.providerComparisons.cancel(comparisonID) {
+		return fmt.Errorf("provider comparison is not active: %d", comparisonID)
+	}
+	return nil
+}
+
+// DeleteProviderComparison deletes a finished comparison record; its
+// worktrees and sessions are kept
+func (a *App) DeleteProviderComparison(comparisonID int64) error {
+	if a.dbManager == nil {
+		return a.unavailable(subsystemDatabase)
+	}
+	if a.providerComparisons.active(comparisonID) {
+		return fmt.Errorf("provider comparison is still running: %d", comparisonID)
+	}
+	return a.dbManager.DeleteProviderComparison(comparisonID)
+}
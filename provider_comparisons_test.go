@@ -0,0 +1,72 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"ropcode/internal/config"
+	"ropcode/internal/database"
+	"ropcode/internal/git"
+)
+
+func TestNormalizeComparisonProviders(t *testing.T) {
+	got, err := normalizeComparisonProviders([]string{" codex", "claude", "codex", ""})
+	if err != nil || !reflect.DeepEqual(got, []string{"codex", "claude"}) {
+		t.Fatalf("normalizeComparisonProviders = %v, %v", got, err)
+	}
+	if _, err := normalizeComparisonProviders([]string{"claude", "claude"}); err == nil {
+		t.Fatal("compared a single provider")
+	}
+	if _, err := normalizeComparisonProviders([]string{"claude", "cursor"}); err == nil {
+		t.Fatal("accepted an unsupported provider")
+	}
+}
+
+func TestComparisonWorktreeIsAWorkspaceOfItsProvider(t *testing.T) {
+	project := filepath.Join(t.TempDir(), "shop")
+	if err := os.MkdirAll(project, 0755); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, project, "init")
+	runGit(t, project, "-c", "user.name=t", "-c", "user.email=t@example.com", "commit", "--allow-empty", "-m", "initial")
+
+	db := openAppConfigTestDB(t)
+	if err := db.SaveProjectIndex(&database.ProjectIndex{Name: "shop", Available: true, Providers: []database.ProviderInfo{}, Workspaces: []database.WorkspaceIndex{}}); err != nil {
+		t.Fatal(err)
+	}
+	app := &App{dbManager: db, config: &config.Config{RopcodeDir: filepath.Join(t.TempDir(), ".ropcode")}}
+
+	workspacePath, branch, base, err := app.addComparisonWorktree(project, 7, "codex")
+	if err != nil {
+		t.Fatalf("addComparisonWorktree: %v", err)
+	}
+	if workspacePath != filepath.Join(project, ".ropcode", "compare-7-codex") || branch != "ropcode/compare-7-codex" || base == "" {
+		t.Fatalf("worktree = %s, %s, %s", workspacePath, branch, base)
+	}
+	index, err := db.GetProjectIndex("shop")
+	if err != nil || len(index.Workspaces) != 1 {
+		t.Fatalf("project index = %+v, %v", index, err)
+	}
+	if workspace := index.Workspaces[0]; workspace.LastProvider != "codex" || workspace.Providers[0].ProviderID != "codex" {
+		t.Fatalf("workspace = %+v", workspace)
+	}
+
+	// What the provider changes in its worktree makes up its diff
+	if err := os.WriteFile(filepath.Join(workspacePath, "health.go"), []byte("package shop\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	diff, err := git.WorkTreeDiff(workspacePath, base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diff.Files) != 1 || diff.Files[0].Path != "health.go" || !strings.Contains(diff.Patch, "+package shop") {
+		t.Fatalf("diff = %+v", diff)
+	}
+
+	if _, err := app.CompareProviders(project, "add a health check", []string{"claude"}); err == nil {
+		t.Fatal("compared a single provider")
+	}
+}
@@ -0,0 +1,295 @@
+// provider_failover.go
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"ropcode/internal/database"
+)
+
+// ProviderFailover is the payload of "provider-session:failover"
+type ProviderFailover struct {
+	Provider          string `json:"provider"`
+	ProjectPath       string `json:"project_path"`
+	FailedSessionID   string `json:"failed_session_id,omitempty"`
+	SessionID         string `json:"session_id"`
+	FromProviderApiID string `json:"from_provider_api_id"`
+	ProviderApiID     string `json:"provider_api_id"`
+	Reason            string `json:"reason"`
+}
+
+// failoverErrorMarkers identify authentication and rate-limit errors in
+// start errors and session output, matched case-insensitively
+var failoverErrorMarkers = []string{
+	"401", "403", "429",
+	"unauthorized", "forbidden", "authentication", "invalid api key", "invalid_api_key",
+	"rate limit", "rate_limit", "too many requests", "quota",
+}
+
+// maxFailoverReasonLength bounds the error text kept as a failover reason
+const maxFailoverReasonLength = 300
+
+// GetProviderFailoverChain returns the IDs of the API configs a provider's
+// sessions fail over through, in order
+func (a *App) GetProviderFailoverChain(providerID string) ([]string, error) {
+	if a.dbManager == nil {
+		return []string{}, a.unavailable(subsystemDatabase)
+	}
+	return a.dbManager.GetProviderFailoverChain(providerID)
+}
+
+// SetProviderFailoverChain orders API configs of a provider into its
+// failover chain. Fewer than two configs turn failover off.
+func (a *App) SetProviderFailoverChain(providerID string, providerApiIDs []string) error {
+	if a.dbManager == nil {
+		return a.unavailable(subsystemDatabase)
+	}
+	providerID = strings.TrimSpace(providerID)
+	if providerID == "" {
+		return fmt.Errorf("provider is required")
+	}
+	chain := make([]string, 0, len(providerApiIDs))
+	seen := make(map[string]bool)
+	for _, id := range providerApiIDs {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		apiConfig, err := a.dbManager.GetProviderApiConfig(id)
+		if err != nil || apiConfig == nil {
+			return fmt.Errorf("provider API config %q not found", id)
+		}
+		if apiConfig.ProviderID != providerID {
+			return fmt.Errorf("provider API config %q belongs to %s, not %s", apiConfig.Name, apiConfig.ProviderID, providerID)
+		}
+		chain = append(chain, id)
+	}
+	if len(chain) < 2 {
+		chain = nil
+	}
+	return a.dbManager.SetProviderFailoverChain(providerID, chain)
+}
+
+// GetSessionProviderApi returns the API config record of a session, nil
+// when the session was not started on a saved config
+func (a *App) GetSessionProviderApi(sessionID string) (*database.SessionProviderApi, error) {
+	if a.dbManager == nil {
+		return nil, a.unavailable(subsystemDatabase)
+	}
+	return a.dbManager.GetSessionProviderApi(sessionID)
+}
+
+// nextFailoverConfig returns the config after providerApiID in the
+// failover chain of its provider, empty when there is none
+func (a *App) nextFailoverConfig(providerApiID string) string {
+	if a.dbManager == nil || providerApiID == "" {
+		return ""
+	}
+	apiConfig, err := a.dbManager.GetProviderApiConfig(providerApiID)
+	if err != nil || apiConfig == nil {
+		return ""
+	}
+	chain, err := a.dbManager.GetProviderFailoverChain(apiConfig.ProviderID)
+	if err != nil {
+		slog.Error("failed to load the failover chain", "component", "failover", "provider", apiConfig.ProviderID, "error", err)
+		return ""
+	}
+	for i, id := range chain {
+		if id == providerApiID && i+1 < len(chain) {
+			return chain[i+1]
+		}
+	}
+	return ""
+}
+
+// startProviderSessionWithFailover starts a session like
+// startProviderSession, moving down the failover chain while the start
+// fails with an authentication or rate-limit error. It returns the config
+// that served the session and the last error failed over from.
+func (a *App) startProviderSessionWithFailover(provider, projectPath, prompt, model, providerApiID, reasoningEffort string) (string, string, string, error) {
+	reason := ""
+	for {
+		sessionID, err := a.startProviderSession(provider, projectPath, prompt, model, providerApiID, reasoningEffort)
+		if err == nil || !isFailoverError(err.Error()) {
+			return sessionID, providerApiID, reason, err
+		}
+		next := a.nextFailoverConfig(providerApiID)
+		if next == "" {
+			return "", providerApiID, reason, err
+		}
+		slog.Warn("session start failed, trying the next config", "component", "failover", "provider", provider, "config", providerApiID, "next", next, "error", err)
+		reason = failoverReason(err.Error())
+		providerApiID = next
+	}
+}
+
+// trackProviderSession records the config a session was started with and,
+// when the config has a successor in the failover chain, watches the
+// session for an early authentication or rate-limit failure
+func (a *App) trackProviderSession(record database.SessionProviderApi, prompt, model, reasoningEffort string) {
+	if a.dbManager == nil || record.ProviderApiID == "" {
+		return
+	}
+	if err := a.dbManager.RecordSessionProviderApi(&record); err != nil {
+		slog.Error("failed to record the config of session", "component", "failover", "session_id", record.SessionID, "error", err)
+	}
+	if a.nextFailoverConfig(record.ProviderApiID) == "" {
+		return
+	}
+	go a.watchProviderFailover(record, prompt, model, reasoningEffort)
+}
+
+// watchProviderFailover waits for a session to exit and starts it again on
+// the next config of the chain when it failed over an authentication or
+// rate-limit error without finishing a turn. Resumed sessions never fail
+// over; their history is tied to the config they ran on.
+func (a *App) watchProviderFailover(record database.SessionProviderApi, prompt, model, reasoningEffort string) {
+	manager := a.sessionManagerFor(record.Provider)
+	if manager == nil {
+		return
+	}
+	ctx := a.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	status, err := manager.WaitForSession(ctx, record.SessionID)
+	if err != nil || status == "cancelled" {
+		return
+	}
+	output, err := manager.GetSessionOutput(record.SessionID)
+	if err != nil {
+		return
+	}
+	reason := sessionFailoverReason(output)
+	if reason == "" {
+		return
+	}
+	next := a.nextFailoverConfig(record.ProviderApiID)
+	if next == "" {
+		return
+	}
+	slog.Warn("session failed, restarting on the next config", "component", "failover",
+		"provider", record.Provider, "session_id", record.SessionID, "config", record.ProviderApiID, "next", next, "reason", reason)
+
+	release, err := a.acquireSessionSlot(record.Provider, record.ProjectPath)
+	if err != nil {
+		slog.Error("no session slot to restart", "component", "failover", "session_id", record.SessionID, "error", err)
+		return
+	}
+	defer release()
+	sessionID, servedBy, startReason, err := a.startProviderSessionWithFailover(
+		record.Provider, record.ProjectPath, prompt, model, next, reasoningEffort)
+	if err != nil {
+		slog.Error("failed to restart session", "component", "failover", "provider", record.Provider, "session_id", record.SessionID, "error", err)
+		return
+	}
+	if startReason != "" {
+		reason = startReason
+	}
+	a.trackProviderSession(database.SessionProviderApi{
+		SessionID:      sessionID,
+		Provider:       record.Provider,
+		ProjectPath:    record.ProjectPath,
+		ProviderApiID:  servedBy,
+		FailedOverFrom: record.SessionID,
+		Reason:         reason,
+	}, prompt, model, reasoningEffort)
+	if a.eventHub != nil {
+		a.eventHub.Emit("provider-session:failover", ProviderFailover{
+			Provider:          record.Provider,
+			ProjectPath:       record.ProjectPath,
+			FailedSessionID:   record.SessionID,
+			SessionID:         sessionID,
+			FromProviderApiID: record.ProviderApiID,
+			ProviderApiID:     servedBy,
+			Reason:            reason,
+		})
+	}
+}
+
+// isFailoverError reports whether an error text is an authentication or
+// rate-limit error
+func isFailoverError(text string) bool {
+	text = strings.ToLower(text)
+	for _, marker := range failoverErrorMarkers {
+		if strings.Contains(text, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// sessionFailoverReason returns the authentication or rate-limit error a
+// session's raw output ended on, empty when the session finished a turn or
+// failed for another reason
+func sessionFailoverReason(output string) string {
+	var errorTexts []string
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		var event map[string]interface{}
+		if !strings.HasPrefix(line, "{") || json.Unmarshal([]byte(line), &event) != nil {
+			// stderr and other raw lines
+			errorTexts = append(errorTexts, line)
+			continue
+		}
+
+		eventType, _ := event["type"].(string)
+		subtype, _ := event["subtype"].(string)
+		switch {
+		case eventType == "result":
+			isError, _ := event["is_error"].(bool)
+			status, _ := event["status"].(string)
+			if !isError && subtype != "error" && status != "error" {
+				return ""
+			}
+			errorTexts = append(errorTexts, eventText(event, "result", "error"))
+		case eventType == "turn.completed":
+			return ""
+		case eventType == "error" || eventType == "turn.failed":
+			errorTexts = append(errorTexts, eventText(event, "message", "error"))
+		case eventType == "system" && subtype == "api_retry":
+			if status, _ := event["error_status"].(float64); status > 0 {
+				errorTexts = append(errorTexts, fmt.Sprintf("%v (status %d)", event["error"], int(status)))
+			}
+		}
+	}
+	for i := len(errorTexts) - 1; i >= 0; i-- {
+		if isFailoverError(errorTexts[i]) {
+			return failoverReason(errorTexts[i])
+		}
+	}
+	return ""
+}
+
+// eventText returns the first of keys an event carries as text; an error
+// object gives its message
+func eventText(event map[string]interface{}, keys ...string) string {
+	for _, key := range keys {
+		switch value := event[key].(type) {
+		case string:
+			if value != "" {
+				return value
+			}
+		case map[string]interface{}:
+			if message, _ := value["message"].(string); message != "" {
+				return message
+			}
+		}
+	}
+	return ""
+}
+
+func failoverReason(text string) string {
+	text = strings.TrimSpace(text)
+	if len(text) > maxFailoverReasonLength {
+		text = text[:maxFailoverReasonLength] + "…"
+	}
+	return text
+}
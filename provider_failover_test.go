@@ -0,0 +1,128 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"ropcode/internal/database"
+)
+
+func TestProviderFailoverChainOrdersConfigsOfOneProvider(t *testing.T) {
+	db := openAppConfigTestDB(t)
+	for _, c := range []*database.ProviderApiConfig{
+		{ID: "primary", Name: "Primary", ProviderID: "claude"},
+		{ID: "backup", Name: "Backup", ProviderID: "claude"},
+		{ID: "last", Name: "Last", ProviderID: "claude"},
+		{ID: "gemini-key", Name: "Gemini", ProviderID: "gemini"},
+	} {
+		if err := db.SaveProviderApiConfig(c); err != nil {
+			t.Fatalf("SaveProviderApiConfig() error = %v", err)
+		}
+	}
+	app := &App{dbManager: db}
+
+	if err := app.SetProviderFailoverChain("claude", []string{"primary", "gemini-key"}); err == nil {
+		t.Fatal("expected a config of another provider to be rejected")
+	}
+	if err := app.SetProviderFailoverChain("claude", []string{"primary", "backup", "primary", "last"}); err != nil {
+		t.Fatalf("SetProviderFailoverChain() error = %v", err)
+	}
+	chain, err := app.GetProviderFailoverChain("claude")
+	if err != nil || strings.Join(chain, ",") != "primary,backup,last" {
+		t.Fatalf("chain = %v, %v", chain, err)
+	}
+	if next := app.nextFailoverConfig("primary"); next != "backup" {
+		t.Fatalf("next after primary = %q", next)
+	}
+	if next := app.nextFailoverConfig("last"); next != "" {
+		t.Fatalf("next after the end of the chain = %q", next)
+	}
+	if next := app.nextFailoverConfig(""); next != "" {
+		t.Fatalf("a session without a config failed over to %q", next)
+	}
+
+	if err := db.DeleteProviderApiConfig("backup"); err != nil {
+		t.Fatalf("DeleteProviderApiConfig() error = %v", err)
+	}
+	if next := app.nextFailoverConfig("primary"); next != "last" {
+		t.Fatalf("next after deleting backup = %q", next)
+	}
+
+	// A single config is no chain
+	if err := app.SetProviderFailoverChain("claude", []string{"primary"}); err != nil {
+		t.Fatalf("SetProviderFailoverChain() error = %v", err)
+	}
+	if chain, _ := app.GetProviderFailoverChain("claude"); len(chain) != 0 {
+		t.Fatalf("chain = %v", chain)
+	}
+}
+
+func TestTrackProviderSessionRecordsTheServingConfig(t *testing.T) {
+	db := openAppConfigTestDB(t)
+	app := &App{dbManager: db}
+
+	app.trackProviderSession(database.SessionProviderApi{
+		SessionID: "s2", Provider: "claude", ProjectPath: "/tmp/p", ProviderApiID: "backup",
+		FailedOverFrom: "s1", Reason: "Invalid API key",
+	}, "hi", "", "")
+	record, err := app.GetSessionProviderApi("s2")
+	if err != nil || record == nil {
+		t.Fatalf("GetSessionProviderApi() = %v, %v", record, err)
+	}
+	if record.ProviderApiID != "backup" || record.FailedOverFrom != "s1" || record.Reason != "Invalid API key" {
+		t.Fatalf("record = %+v", record)
+	}
+
+	// Sessions on the CLI's own login are not recorded
+	app.trackProviderSession(database.SessionProviderApi{SessionID: "s3", Provider: "claude"}, "hi", "", "")
+	if record, err := app.GetSessionProviderApi("s3"); err != nil || record != nil {
+		t.Fatalf("GetSessionProviderApi(s3) = %v, %v", record, err)
+	}
+}
+
+func TestSessionFailoverReason(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   string
+	}{
+		{
+			name:   "claude invalid key",
+			output: `{"type":"system","subtype":"init"}` + "\n" + `{"type":"result","subtype":"success","is_error":true,"result":"Invalid API key · Please run /login"}`,
+			want:   "Invalid API key · Please run /login",
+		},
+		{
+			name: "claude rate limited retries",
+			output: `{"type":"system","subtype":"api_retry","error":"rate_limit","error_status":429}` + "\n" +
+				`{"type":"result","subtype":"error_during_execution","is_error":true,"result":"API Error"}`,
+			want: "rate_limit (status 429)",
+		},
+		{
+			name:   "codex turn failed",
+			output: `{"type":"thread.started"}` + "\n" + `{"type":"turn.failed","error":{"message":"unexpected status 401 Unauthorized"}}`,
+			want:   "unexpected status 401 Unauthorized",
+		},
+		{
+			name:   "stderr only",
+			output: "Error: 429 Too Many Requests",
+			want:   "Error: 429 Too Many Requests",
+		},
+		{
+			name:   "finished a turn",
+			output: `{"type":"result","subtype":"success","is_error":false,"result":"done"}` + "\n" + "Error: 429 Too Many Requests",
+			want:   "",
+		},
+		{
+			name:   "other failure",
+			output: `{"type":"result","subtype":"error_max_turns","is_error":true,"result":"Reached max turns"}`,
+			want:   "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sessionFailoverReason(tt.output); got != tt.want {
+				t.Fatalf("sessionFailoverReason() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,288 @@
+// provider_install.go
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sync"
+
+	"ropcode/internal/clicompat"
+	"ropcode/internal/cliinstall"
+	"ropcode/internal/command"
+	"ropcode/internal/jobs"
+)
+
+// ProviderInstallFinishedEvent is emitted when an install job ends
+type ProviderInstallFinishedEvent struct {
+	Provider string `json:"provider"`
+	JobKey   string `json:"job_key"`
+	Status   string `json:"status"`
+	// Path and Version describe the installation sessions use now, when
+	// there is one
+	Path    string `json:"path,omitempty"`
+	Version string `json:"version,omitempty"`
+}
+
+// binaryLocator is the part of a provider's session manager that knows
+// its CLI
+type binaryLocator interface {
+	GetBinaryPath() string
+	SetBinaryPath(path string)
+	ResolveBinaryPath() (string, error)
+}
+
+// binaryLocator returns provider's session manager
+func (a *App) binaryLocator(provider string) (binaryLocator, error) {
+	switch provider {
+	case "claude":
+		if a.claudeManager == nil {
+			return nil, a.unavailable(subsystemClaude)
+		}
+		return a.claudeManager, nil
+	case "codex":
+		if a.codexManager == nil {
+			return nil, a.unavailable(subsystemCodex)
+		}
+		return a.codexManager, nil
+	case "gemini":
+		if a.geminiManager == nil {
+			return nil, a.unavailable(subsystemGemini)
+		}
+		return a.geminiManager, nil
+	}
+	return nil, fmt.Errorf("unknown provider %q", provider)
+}
+
+func providerBinarySettingKey(provider string) string {
+	return provider + "_binary_path"
+}
+
+// applyProviderBinaryPath points provider's sessions at path, or at the
+// installation discovery finds when path is empty
+func (a *App) applyProviderBinaryPath(provider, path string) {
+	locator, err := a.binaryLocator(provider)
+	if err != nil {
+		return
+	}
+	locator.SetBinaryPath(path)
+	if path == "" {
+		path, _ = locator.ResolveBinaryPath()
+	}
+	if provider == "claude" && a.mcpManager != nil {
+		a.mcpManager.SetClaudeBinary(path)
+	}
+	if path != "" {
+		clicompat.Probe(path)
+	}
+}
+
+// applyProviderBinaryPaths applies the installations chosen earlier
+func (a *App) applyProviderBinaryPaths() {
+	if a.dbManager == nil {
+		return
+	}
+	for _, provider := range cliinstall.Providers {
+		if path, err := a.dbManager.GetSetting(providerBinarySettingKey(provider)); err == nil && path != "" {
+			a.applyProviderBinaryPath(provider, path)
+		}
+	}
+}
+
+// providerBinaryPathsChanged applies the installations changed in settings
+func (a *App) providerBinaryPathsChanged(changed map[string]interface{}) {
+	for _, provider := range cliinstall.Providers {
+		if path, ok := changed[providerBinarySettingKey(provider)].(string); ok {
+			a.applyProviderBinaryPath(provider, path)
+		}
+	}
+}
+
+// ListInstallMethods returns the ways provider's CLI installs on this OS,
+// the recommended one first
+func (a *App) ListInstallMethods(provider string) ([]cliinstall.Method, error) {
+	if _, err := a.binaryLocator(provider); err != nil {
+		return nil, err
+	}
+	return cliinstall.Methods(provider), nil
+}
+
+// InstallProviderBinary installs provider's CLI with method (native, npm
+// or brew, see ListInstallMethods) and returns the key of the install job
+func (a *App) InstallProviderBinary(provider, method string) (string, error) {
+	if a.jobRunner == nil {
+		return "", a.unavailable(subsystemProcesses)
+	}
+	if _, err := a.binaryLocator(provider); err != nil {
+		return "", err
+	}
+	install, err := cliinstall.Find(provider, method)
+	if err != nil {
+		return "", err
+	}
+	shell := a.shellPreference()
+	if install.Shell != "" {
+		shell = command.Preference{Path: install.Shell}
+	}
+	job, err := a.jobRunner.Start(jobs.Spec{
+		Command: install.Command,
+		Shell:   shell,
+		Prefix:  "install-" + provider,
+		Done:    func(job jobs.Job) { a.providerInstallFinished(provider, job) },
+	})
+	if err != nil {
+		return "", err
+	}
+	log.Printf("[install] installing %s with %s", provider, install.Command)
+	return job.Key, nil
+}
+
+// InstallClaudeBinary installs the Claude CLI with method
+func (a *App) InstallClaudeBinary(method string) (string, error) {
+	return a.InstallProviderBinary("claude", method)
+}
+
+// providerInstallFinished looks the CLI up again after an install, unless
+// the user chose an installation of their own, and reports what is used
+func (a *App) providerInstallFinished(provider string, job jobs.Job) {
+	event := &ProviderInstallFinishedEvent{Provider: provider, JobKey: job.Key, Status: job.Status}
+	if locator, err := a.binaryLocator(provider); err == nil {
+		chosen := ""
+		if a.dbManager != nil {
+			chosen, _ = a.dbManager.GetSetting(providerBinarySettingKey(provider))
+		}
+		if job.Status == jobs.StatusSucceeded && chosen == "" {
+			a.applyProviderBinaryPath(provider, "")
+		}
+		if path, err := locator.ResolveBinaryPath(); err == nil {
+			event.Path = path
+			event.Version, _ = doctorVersion(path, "--version")
+		}
+	}
+	if a.eventHub != nil {
+		a.eventHub.Emit("provider-install:finished", event)
+	}
+}
+
+// SwitchProviderInstallation makes provider's sessions use the CLI at path
+// from now on, or the one discovery finds when path is empty
+func (a *App) SwitchProviderInstallation(provider, path string) error {
+	if _, err := a.binaryLocator(provider); err != nil {
+		return err
+	}
+	if a.dbManager == nil {
+		return a.unavailable(subsystemDatabase)
+	}
+	if path != "" {
+		if _, err := doctorVersion(path, "--version"); err != nil {
+			return fmt.Errorf("%s does not run: %w", path, err)
+		}
+	}
+	key := providerBinarySettingKey(provider)
+	if current, _ := a.dbManager.GetSetting(key); current == path {
+		// Unchanged settings are not applied again
+		a.applyProviderBinaryPath(provider, path)
+		return nil
+	}
+	_, err := a.UpdateSettings(map[string]interface{}{key: path})
+	return err
+}
+
+// SwitchClaudeInstallation makes Claude sessions use the CLI at path
+func (a *App) SwitchClaudeInstallation(path string) error {
+	return a.SwitchProviderInstallation("claude", path)
+}
+
+// ProviderVersionInfo describes the CLI a provider's sessions run
+type ProviderVersionInfo struct {
+	Provider    string `json:"provider"`
+	IsInstalled bool   `json:"is_installed"`
+	Path        string `json:"path,omitempty"`
+	Version     string `json:"version,omitempty"`
+	// Output is the version line, or why the CLI was not found or did not
+	// run
+	Output string `json:"output"`
+}
+
+// CheckProviderVersion runs the CLI provider's sessions use with --version
+func (a *App) CheckProviderVersion(provider string) (*ProviderVersionInfo, error) {
+	locator, err := a.binaryLocator(provider)
+	if err != nil {
+		return nil, err
+	}
+	info := &ProviderVersionInfo{Provider: provider}
+	path, err := locator.ResolveBinaryPath()
+	if err != nil {
+		info.Output = err.Error()
+		return info, nil
+	}
+	info.Path = path
+	version, err := doctorVersion(path, "--version")
+	if err != nil {
+		info.Output = fmt.Sprintf("%s does not run: %v", path, err)
+		return info, nil
+	}
+	info.IsInstalled, info.Version, info.Output = true, version, version
+	return info, nil
+}
+
+// ProviderInstallation is an installation of a provider CLI and whether
+// it works
+type ProviderInstallation struct {
+	cliinstall.Installation
+	Version string `json:"version,omitempty"`
+	// Error says why the binary did not run
+	Error string `json:"error,omitempty"`
+	// Active marks the installation sessions use
+	Active bool `json:"active"`
+}
+
+// ListProviderInstallations lists the installations of provider's CLI on
+// PATH and in the npm, Homebrew, nvm, cargo and installer locations, each
+// with the version it reports
+func (a *App) ListProviderInstallations(provider string) ([]ProviderInstallation, error) {
+	locator, err := a.binaryLocator(provider)
+	if err != nil {
+		return nil, err
+	}
+	found := cliinstall.Discover(provider)
+	active, _ := locator.ResolveBinaryPath()
+	if active != "" {
+		// A chosen binary outside the usual places is listed too
+		listed := false
+		for _, installation := range found {
+			listed = listed || sameFile(installation.Path, active)
+		}
+		if !listed {
+			found = append(found, cliinstall.Installation{Path: active, Source: "custom", InstallationType: "Custom"})
+		}
+	}
+
+	installations := make([]ProviderInstallation, len(found))
+	var wg sync.WaitGroup
+	for i, installation := range found {
+		installations[i] = ProviderInstallation{Installation: installation, Active: active != "" && sameFile(installation.Path, active)}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			version, err := doctorVersion(installations[i].Path, "--version")
+			if err != nil {
+				installations[i].Error = err.Error()
+				return
+			}
+			installations[i].Version = version
+		}(i)
+	}
+	wg.Wait()
+	return installations, nil
+}
+
+// sameFile reports whether two paths lead to the same file
+func sameFile(a, b string) bool {
+	if a == b {
+		return true
+	}
+	infoA, errA := os.Stat(a)
+	infoB, errB := os.Stat(b)
+	return errA == nil && errB == nil && os.SameFile(infoA, infoB)
+}
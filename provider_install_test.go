@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"testing"
+
+	"ropcode/internal/codex"
+	"ropcode/internal/database"
+	"ropcode/internal/eventhub"
+	"ropcode/internal/jobs"
+)
+
+type installRecorder struct {
+	mu       sync.Mutex
+	finished *ProviderInstallFinishedEvent
+}
+
+func (r *installRecorder) BroadcastEvent(eventType string, payload interface{}) {
+	if eventType == "provider-install:finished" {
+		r.mu.Lock()
+		r.finished = payload.(*ProviderInstallFinishedEvent)
+		r.mu.Unlock()
+	}
+}
+
+// fakeCLI writes a script that prints version
+func fakeCLI(t *testing.T, version string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "codex")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\necho '"+version+"'\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func newInstallTestApp(t *testing.T) *App {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake CLI is a shell script")
+	}
+	db, err := database.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return &App{dbManager: db, codexManager: codex.NewSessionManager(context.Background(), nil)}
+}
+
+func TestSwitchProviderInstallationPersistsAndApplies(t *testing.T) {
+	app := newInstallTestApp(t)
+	binary := fakeCLI(t, "codex-cli 0.50.0")
+
+	if err := app.SwitchProviderInstallation("codex", binary); err != nil {
+		t.Fatalf("SwitchProviderInstallation() error = %v", err)
+	}
+	if got := app.codexManager.GetBinaryPath(); got != binary {
+		t.Errorf("codex binary = %q, want %q", got, binary)
+	}
+	if stored, _ := app.dbManager.GetSetting("codex_binary_path"); stored != binary {
+		t.Errorf("stored path = %q", stored)
+	}
+
+	// A restart applies the stored choice over discovery
+	restarted := &App{dbManager: app.dbManager, codexManager: codex.NewSessionManager(context.Background(), nil)}
+	restarted.applyProviderBinaryPaths()
+	if got := restarted.codexManager.GetBinaryPath(); got != binary {
+		t.Errorf("codex binary after restart = %q", got)
+	}
+
+	if err := app.SwitchProviderInstallation("codex", filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("switching to a binary that does not run should fail")
+	}
+	if err := app.SwitchProviderInstallation("gemini", binary); err == nil {
+		t.Error("switching a provider without a session manager should fail")
+	}
+	if _, err := app.InstallProviderBinary("codex", "npm"); err == nil {
+		t.Error("installing without a job runner should fail")
+	}
+}
+
+func TestProviderInstallFinishedReportsInstallation(t *testing.T) {
+	app := newInstallTestApp(t)
+	binary := fakeCLI(t, "codex-cli 0.51.0")
+	if err := app.SwitchProviderInstallation("codex", binary); err != nil {
+		t.Fatal(err)
+	}
+	hub := eventhub.New(nil)
+	recorder := &installRecorder{}
+	hub.SetBroadcaster(recorder)
+	app.eventHub = hub
+
+	app.providerInstallFinished("codex", jobs.Job{Key: "install-codex-1", Status: jobs.StatusSucceeded})
+
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	event := recorder.finished
+	if event == nil || event.JobKey != "install-codex-1" || event.Path != binary || event.Version != "codex-cli 0.51.0" {
+		t.Fatalf("provider-install:finished = %+v", event)
+	}
+}
+
+func TestListProviderInstallationsReportsVersionsAndActive(t *testing.T) {
+	app := newInstallTestApp(t)
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("PATH", "/usr/bin:/bin")
+	cargo := filepath.Join(home, ".cargo", "bin", "codex")
+	os.MkdirAll(filepath.Dir(cargo), 0755)
+	if err := os.WriteFile(cargo, []byte("#!/bin/sh\necho 'codex-cli 0.52.0'\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	chosen := fakeCLI(t, "codex-cli 0.40.0")
+	if err := app.SwitchProviderInstallation("codex", chosen); err != nil {
+		t.Fatal(err)
+	}
+
+	installations, err := app.ListProviderInstallations("codex")
+	if err != nil {
+		t.Fatal(err)
+	}
+	byPath := map[string]ProviderInstallation{}
+	for _, installation := range installations {
+		byPath[installation.Path] = installation
+	}
+	if got := byPath[cargo]; got.Source != "cargo" || got.Version != "codex-cli 0.52.0" || got.Active {
+		t.Errorf("cargo installation = %+v", got)
+	}
+	if got := byPath[chosen]; got.Source != "custom" || !got.Active || got.Version != "codex-cli 0.40.0" {
+		t.Errorf("chosen installation = %+v", got)
+	}
+
+	info, err := app.CheckProviderVersion("codex")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.IsInstalled || info.Path != chosen || info.Version != "codex-cli 0.40.0" {
+		t.Errorf("CheckProviderVersion() = %+v", info)
+	}
+	if _, err := app.CheckProviderVersion("cursor"); err == nil {
+		t.Error("CheckProviderVersion() for an unknown provider should fail")
+	}
+}
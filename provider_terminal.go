@@ -0,0 +1,268 @@
+// provider_terminal.go
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"ropcode/internal/claude"
+	"ropcode/internal/codex"
+	"ropcode/internal/pty"
+
+	"github.com/google/uuid"
+)
+
+// providerTerminalPollInterval is how often the transcript is checked for
+// new messages
+const providerTerminalPollInterval = 500 * time.Millisecond
+
+// ProviderTerminal is a provider CLI running interactively in a PTY session
+type ProviderTerminal struct {
+	// TerminalID is the PTY session the CLI runs in
+	TerminalID  string `json:"terminal_id"`
+	Provider    string `json:"provider"`
+	ProjectPath string `json:"project_path"`
+	// SessionID and TranscriptPath are set once the CLI has written its
+	// transcript
+	SessionID      string `json:"session_id,omitempty"`
+	TranscriptPath string `json:"transcript_path,omitempty"`
+}
+
+// ProviderTerminalExitedEvent is emitted when the CLI of a terminal exits
+type ProviderTerminalExitedEvent struct {
+	TerminalID string `json:"terminal_id"`
+	SessionID  string `json:"session_id,omitempty"`
+}
+
+// providerTranscript says where a provider CLI writes its transcript and
+// how to read it
+type providerTranscript struct {
+	// candidates lists the files the CLI may write its transcript to
+	candidates func() []string
+	// sessionID returns the session a transcript belongs to
+	sessionID func(path string) string
+	// messages converts a transcript line
+	messages func(line []byte) []claude.Message
+}
+
+// find returns the candidate that appeared or grew since before and the
+// offset its new lines start at, or "" while there is none yet
+func (t *providerTranscript) find(before map[string]int64) (string, int64) {
+	paths := t.candidates()
+	sort.Strings(paths)
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		size, existed := before[path]
+		if !existed || info.Size() > size {
+			return path, size
+		}
+	}
+	return "", 0
+}
+
+// transcriptSizes returns the size of each existing file of paths
+func transcriptSizes(paths []string) map[string]int64 {
+	sizes := make(map[string]int64, len(paths))
+	for _, path := range paths {
+		if info, err := os.Stat(path); err == nil {
+			sizes[path] = info.Size()
+		}
+	}
+	return sizes
+}
+
+// StartProviderTerminal runs provider's CLI interactively in a new PTY
+// session terminalID, resuming resumeSessionID when set, and captures the
+// transcript it writes, replaying it as claude-output lines so the session
+// view stays up to date. "provider-terminal:session" is emitted once the
+// transcript is found and "provider-terminal:exited" when the CLI exits.
+func (a *App) StartProviderTerminal(terminalID, provider, projectPath, resumeSessionID string, rows, cols int) (*ProviderTerminal, error) {
+	if a.ptyManager == nil {
+		return nil, a.unavailable(subsystemTerminals)
+	}
+	var binary string
+	var args []string
+	var transcript *providerTranscript
+	var err error
+	switch provider {
+	case "claude":
+		if a.claudeManager == nil || a.config == nil {
+			return nil, a.unavailable(subsystemClaude)
+		}
+		if binary, err = a.claudeManager.ResolveBinaryPath(); err != nil {
+			return nil, err
+		}
+		projectDir := filepath.Join(a.config.ClaudeDir, "projects", claude.GetProjectHash(projectPath))
+		transcript = &providerTranscript{
+			sessionID: func(path string) string { return strings.TrimSuffix(filepath.Base(path), ".jsonl") },
+			messages: func(line []byte) []claude.Message {
+				var msg claude.Message
+				if err := json.Unmarshal(line, &msg); err != nil {
+					return nil
+				}
+				return []claude.Message{msg}
+			},
+		}
+		if resumeSessionID == "" {
+			// A new session's transcript is named after the ID it is given
+			sessionID := uuid.NewString()
+			args = []string{"--session-id", sessionID}
+			path := filepath.Join(projectDir, sessionID+".jsonl")
+			transcript.candidates = func() []string { return []string{path} }
+		} else {
+			// A resumed session may continue in a new transcript
+			args = []string{"--resume", resumeSessionID}
+			transcript.candidates = func() []string {
+				paths, _ := filepath.Glob(filepath.Join(projectDir, "*.jsonl"))
+				return paths
+			}
+		}
+
+	case "codex":
+		if a.codexManager == nil {
+			return nil, a.unavailable(subsystemCodex)
+		}
+		if binary, err = a.codexManager.ResolveBinaryPath(); err != nil {
+			return nil, err
+		}
+		codexDir, err := codex.CodexDir()
+		if err != nil {
+			return nil, err
+		}
+		if resumeSessionID != "" {
+			args = []string{"resume", resumeSessionID}
+		}
+		transcript = &providerTranscript{
+			candidates: func() []string { return a.codexRolloutFiles(codexDir, projectPath) },
+			sessionID: func(path string) string {
+				// rollout-<timestamp>-<session id>.jsonl
+				name := strings.TrimSuffix(filepath.Base(path), ".jsonl")
+				if len(name) > 36 {
+					return name[len(name)-36:]
+				}
+				return name
+			},
+			messages: func(line []byte) []claude.Message { return codex.HistoryMessages(line, projectPath) },
+		}
+
+	default:
+		return nil, fmt.Errorf("provider %s has no terminal mode", provider)
+	}
+
+	before := transcriptSizes(transcript.candidates())
+	session, err := a.ptyManager.CreateCommandSession(terminalID, projectPath, rows, cols, binary, args, a.projectEnvList(projectPath))
+	if err != nil {
+		return nil, err
+	}
+	terminal := ProviderTerminal{TerminalID: terminalID, Provider: provider, ProjectPath: projectPath}
+	go a.captureProviderTranscript(session, terminal, transcript, before)
+	return &terminal, nil
+}
+
+// codexRolloutFiles lists the rollout files of projectPath's sessions, or
+// every rollout file without a catalog to tell projects apart
+func (a *App) codexRolloutFiles(codexDir, projectPath string) []string {
+	catalog := a.codexCatalog(codexDir)
+	if catalog == nil {
+		paths, _ := filepath.Glob(filepath.Join(codexDir, "sessions", "*", "*", "*", "rollout-*.jsonl"))
+		return paths
+	}
+	var paths []string
+	for path, project := range catalog.ProjectPaths() {
+		if filepath.Clean(project) == filepath.Clean(projectPath) {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+// captureProviderTranscript waits for the transcript of a terminal's CLI
+// and replays the messages appended to it until the CLI exits
+func (a *App) captureProviderTranscript(session *pty.Session, terminal ProviderTerminal, transcript *providerTranscript, before map[string]int64) {
+	output := &coalescedEmitter{
+		coalescer: a.aiOutputCoalescer,
+		images:    a.outputImages(),
+		onLine:    a.observeOutputLine,
+	}
+	var offset int64
+	poll := func() {
+		if terminal.TranscriptPath == "" {
+			path, start := transcript.find(before)
+			if path == "" {
+				return
+			}
+			terminal.TranscriptPath, terminal.SessionID, offset = path, transcript.sessionID(path), start
+			found := terminal
+			a.emitProviderTerminal("provider-terminal:session", &found)
+		}
+		lines, next, err := claude.ReadLinesFrom(terminal.TranscriptPath, offset)
+		if err != nil {
+			return
+		}
+		offset = next
+		for _, line := range lines {
+			for _, msg := range transcript.messages(line) {
+				if out, ok := providerOutputLine(msg, terminal.SessionID); ok {
+					output.Emit("claude-output", out)
+				}
+			}
+		}
+	}
+
+	ticker := time.NewTicker(providerTerminalPollInterval)
+	defer ticker.Stop()
+	for done := false; !done; {
+		select {
+		case <-ticker.C:
+		case <-session.Exited():
+			done = true
+		case <-session.Done():
+			done = true
+		}
+		poll()
+	}
+	a.emitProviderTerminal("provider-terminal:exited", &ProviderTerminalExitedEvent{
+		TerminalID: terminal.TerminalID,
+		SessionID:  terminal.SessionID,
+	})
+}
+
+// providerOutputLine turns a transcript message into a claude-output line
+// of session sessionID; only the conversation itself is replayed
+func providerOutputLine(msg claude.Message, sessionID string) (string, bool) {
+	if msg.Type != "user" && msg.Type != "assistant" {
+		return "", false
+	}
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return "", false
+	}
+	var line map[string]interface{}
+	if err := json.Unmarshal(data, &line); err != nil {
+		return "", false
+	}
+	line["session_id"] = sessionID
+	data, err = json.Marshal(line)
+	if err != nil {
+		return "", false
+	}
+	return string(data), true
+}
+
+// emitProviderTerminal emits through the output coalescer, when there is
+// one, so the replayed lines go out first
+func (a *App) emitProviderTerminal(name string, payload interface{}) {
+	if a.aiOutputCoalescer != nil {
+		a.aiOutputCoalescer.Emit(name, payload)
+	} else if a.eventHub != nil {
+		a.eventHub.Emit(name, payload)
+	}
+}
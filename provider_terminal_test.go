@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"ropcode/internal/claude"
+	"ropcode/internal/config"
+	"ropcode/internal/eventhub"
+	"ropcode/internal/pty"
+)
+
+type providerTerminalRecorder struct {
+	mu      sync.Mutex
+	session *ProviderTerminal
+	exited  *ProviderTerminalExitedEvent
+	output  []string
+}
+
+func (r *providerTerminalRecorder) BroadcastEvent(eventType string, payload interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	switch eventType {
+	case "provider-terminal:session":
+		r.session = payload.(*ProviderTerminal)
+	case "provider-terminal:exited":
+		r.exited = payload.(*ProviderTerminalExitedEvent)
+	case "claude-output", "claude-output-batch":
+		r.output = append(r.output, fmt.Sprint(payload))
+	}
+}
+
+func TestStartProviderTerminalCapturesTranscript(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake CLI is a shell script")
+	}
+	root := t.TempDir()
+	claudeDir := filepath.Join(root, ".claude")
+	projectPath := filepath.Join(root, "work")
+	os.MkdirAll(projectPath, 0755)
+	t.Setenv("TRANSCRIPT_DIR", filepath.Join(claudeDir, "projects", claude.GetProjectHash(projectPath)))
+
+	// Writes a transcript named after --session-id, as the claude CLI does
+	binary := filepath.Join(root, "claude")
+	script := `#!/bin/sh
+mkdir -p "$TRANSCRIPT_DIR"
+echo '{"type":"user","uuid":"u1","message":{"role":"user","content":"hello from the cli"}}' >> "$TRANSCRIPT_DIR/$2.jsonl"
+echo '{"type":"file-history-snapshot","uuid":"f1"}' >> "$TRANSCRIPT_DIR/$2.jsonl"
+`
+	if err := os.WriteFile(binary, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	hub := eventhub.New(nil)
+	recorder := &providerTerminalRecorder{}
+	hub.SetBroadcaster(recorder)
+	app := &App{
+		config:            &config.Config{ClaudeDir: claudeDir},
+		eventHub:          hub,
+		aiOutputCoalescer: eventhub.NewClaudeOutputCoalescer(hub.Emit),
+		ptyManager:        pty.NewManager(ctx, nil),
+		claudeManager:     claude.NewSessionManager(ctx, nil),
+	}
+	defer app.ptyManager.CloseAll()
+	app.claudeManager.SetBinaryPath(binary)
+
+	terminal, err := app.StartProviderTerminal("term-1", "claude", projectPath, "", 24, 80)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if terminal.TerminalID != "term-1" || terminal.Provider != "claude" {
+		t.Fatalf("StartProviderTerminal() = %+v", terminal)
+	}
+
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		recorder.mu.Lock()
+		exited, session, output := recorder.exited, recorder.session, strings.Join(recorder.output, "\n")
+		recorder.mu.Unlock()
+		if exited != nil {
+			if session == nil || session.SessionID == "" || exited.SessionID != session.SessionID {
+				t.Fatalf("session = %+v, exited = %+v", session, exited)
+			}
+			if !strings.Contains(output, "hello from the cli") || !strings.Contains(output, session.SessionID) {
+				t.Fatalf("claude-output = %q", output)
+			}
+			if strings.Contains(output, "file-history-snapshot") {
+				t.Fatalf("bookkeeping lines should not be replayed: %q", output)
+			}
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the terminal never exited")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestStartProviderTerminalRejectsUnknownProvider(t *testing.T) {
+	app := &App{ptyManager: pty.NewManager(context.Background(), nil)}
+	if _, err := app.StartProviderTerminal("term-1", "gemini", t.TempDir(), "", 24, 80); err == nil {
+		t.Fatal("StartProviderTerminal() for gemini should fail")
+	}
+}
@@ -0,0 +1,62 @@
+// proxy.go
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+
+	"ropcode/internal/netproxy"
+)
+
+// proxySettingsKey routes the app's own HTTP requests through netproxy; the
+// provider CLIs get it as HTTP(S)_PROXY, ALL_PROXY and NO_PROXY
+const proxySettingsKey = "proxy_settings"
+
+// applyProxySettings installs the proxy and applies the saved settings
+func (a *App) applyProxySettings() {
+	netproxy.Install()
+	if a.dbManager == nil {
+		return
+	}
+	value, err := a.dbManager.GetSetting(proxySettingsKey)
+	if err != nil {
+		return
+	}
+	a.setProxySettings(value)
+}
+
+// proxySettingsChanged applies proxy settings decoded by the settings schema
+func (a *App) proxySettingsChanged(value interface{}) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		log.Printf("[Proxy] ignoring settings: %v", err)
+		return
+	}
+	a.setProxySettings(string(data))
+}
+
+func (a *App) setProxySettings(value string) {
+	s, err := netproxy.Parse(value)
+	if err == nil {
+		err = s.Validate()
+	}
+	if err != nil {
+		log.Printf("[Proxy] ignoring saved settings: %v", err)
+		return
+	}
+	netproxy.Set(s)
+	if s.Enabled {
+		log.Printf("[Proxy] using proxy settings")
+	}
+}
+
+// TestProxy requests target, or a provider API when empty, through the
+// given settings whether or not they are enabled
+func (a *App) TestProxy(settings netproxy.Settings, target string) netproxy.TestResult {
+	ctx := a.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return netproxy.Test(ctx, settings, target)
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"testing"
+
+	"ropcode/internal/database"
+	"ropcode/internal/netproxy"
+)
+
+func TestSavedProxySettingsReachSessions(t *testing.T) {
+	t.Cleanup(func() { netproxy.Set(netproxy.Settings{}) })
+	app := &App{dbManager: openAppConfigTestDB(t)}
+
+	if err := app.SaveSetting(proxySettingsKey, `{"enabled":true,"https_proxy":"http://proxy:8080","no_proxy":"localhost"}`); err != nil {
+		t.Fatal(err)
+	}
+	if got := netproxy.Current(); !got.Enabled || got.HTTPSProxy != "http://proxy:8080" {
+		t.Fatalf("current settings = %+v", got)
+	}
+
+	if _, err := app.SaveProjectEnvVar(database.ProjectEnvVar{ProjectPath: "/p", Name: "NO_PROXY", Value: "localhost,.corp"}); err != nil {
+		t.Fatal(err)
+	}
+	env := app.sessionEnv("/p")
+	if env["HTTPS_PROXY"] != "http://proxy:8080" || env["https_proxy"] != "http://proxy:8080" || env["NO_PROXY"] != "localhost,.corp" {
+		t.Fatalf("sessionEnv() = %v", env)
+	}
+
+	// Invalid settings leave the current ones in place
+	if err := app.SaveSetting(proxySettingsKey, `{"enabled":true,"https_proxy":"proxy"}`); err != nil {
+		t.Fatal(err)
+	}
+	if got := netproxy.Current(); got.HTTPSProxy != "http://proxy:8080" {
+		t.Fatalf("current settings after invalid save = %+v", got)
+	}
+
+	if err := app.SaveSetting(proxySettingsKey, `{"enabled":false}`); err != nil {
+		t.Fatal(err)
+	}
+	if env := app.sessionEnv("/elsewhere"); env != nil {
+		t.Fatalf("sessionEnv() with the proxy disabled = %v", env)
+	}
+}
@@ -0,0 +1,195 @@
+// remote_project.go
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"ropcode/internal/claude"
+	"ropcode/internal/codex"
+	"ropcode/internal/sessionproc"
+)
+
+// remoteProjectsSettingKey stores a JSON object of RemoteProject keyed by
+// local project path
+const remoteProjectsSettingKey = "remote_projects"
+
+// RemoteProject says where a project's provider sessions run
+type RemoteProject struct {
+	Connection string `json:"connection"`
+	RemotePath string `json:"remote_path"`
+	// ClaudeBinary and CodexBinary override the CLI names looked up on the
+	// host's login PATH
+	ClaudeBinary string `json:"claude_binary,omitempty"`
+	CodexBinary  string `json:"codex_binary,omitempty"`
+}
+
+func (a *App) loadRemoteProjects() (map[string]RemoteProject, error) {
+	projects := make(map[string]RemoteProject)
+	if a.dbManager == nil {
+		return projects, nil
+	}
+	raw, err := a.dbManager.GetSetting(remoteProjectsSettingKey)
+	if err != nil || raw == "" {
+		return projects, nil
+	}
+	if err := json.Unmarshal([]byte(raw), &projects); err != nil {
+		return nil, fmt.Errorf("invalid %s setting: %w", remoteProjectsSettingKey, err)
+	}
+	return projects, nil
+}
+
+func (a *App) saveRemoteProjects(projects map[string]RemoteProject) error {
+	if a.dbManager == nil {
+		return a.unavailable(subsystemDatabase)
+	}
+	data, err := json.Marshal(projects)
+	if err != nil {
+		return fmt.Errorf("failed to encode remote projects: %w", err)
+	}
+	if err := a.dbManager.SaveSetting(remoteProjectsSettingKey, string(data)); err != nil {
+		return fmt.Errorf("failed to save remote projects: %w", err)
+	}
+	return nil
+}
+
+// ListRemoteProjects returns every remote project keyed by local project path
+func (a *App) ListRemoteProjects() (map[string]RemoteProject, error) {
+	return a.loadRemoteProjects()
+}
+
+// GetRemoteProject returns where a project's sessions run, or nil for a
+// local project
+func (a *App) GetRemoteProject(projectPath string) (*RemoteProject, error) {
+	projects, err := a.loadRemoteProjects()
+	if err != nil {
+		return nil, err
+	}
+	remote, ok := projects[projectPath]
+	if !ok {
+		return nil, nil
+	}
+	return &remote, nil
+}
+
+// SetRemoteProject marks a project as remote so its Claude and Codex
+// sessions run on the SSH host
+func (a *App) SetRemoteProject(projectPath string, remote RemoteProject) error {
+	if projectPath == "" {
+		return fmt.Errorf("project path is required")
+	}
+	remote.RemotePath = strings.TrimSpace(remote.RemotePath)
+	if remote.Connection == "" || remote.RemotePath == "" {
+		return fmt.Errorf("connection and remote path are required")
+	}
+	if err := a.requireSshConnection(remote.Connection); err != nil {
+		return err
+	}
+	if server, err := a.GetRemoteServer(projectPath); err != nil {
+		return err
+	} else if server != nil {
+		return fmt.Errorf("project %s already runs on ropcode server %s", projectPath, server.URL)
+	}
+
+	projects, err := a.loadRemoteProjects()
+	if err != nil {
+		return err
+	}
+	projects[projectPath] = remote
+	return a.saveRemoteProjects(projects)
+}
+
+// ClearRemoteProject makes a project's sessions run locally again
+func (a *App) ClearRemoteProject(projectPath string) error {
+	projects, err := a.loadRemoteProjects()
+	if err != nil {
+		return err
+	}
+	if _, ok := projects[projectPath]; !ok {
+		return nil
+	}
+	delete(projects, projectPath)
+	return a.saveRemoteProjects(projects)
+}
+
+func (a *App) requireSshConnection(name string) error {
+	if a.sshManager == nil {
+		return a.unavailable(subsystemSSH)
+	}
+	connections, err := a.sshManager.ListGlobalConnections()
+	if err != nil {
+		return err
+	}
+	for _, conn := range connections {
+		if conn.Name == name {
+			return nil
+		}
+	}
+	return fmt.Errorf("SSH connection '%s' not found", name)
+}
+
+// sessionRemote returns how to run a provider CLI for a remote project, or
+// nil for a local one
+func (a *App) sessionRemote(projectPath string, binaryOf func(RemoteProject) string) (*sessionproc.Remote, error) {
+	if projectPath == "" {
+		return nil, nil
+	}
+	remote, err := a.GetRemoteProject(projectPath)
+	if err != nil || remote == nil {
+		return nil, err
+	}
+	if err := a.requireSshConnection(remote.Connection); err != nil {
+		return nil, err
+	}
+	connection := remote.Connection
+	return &sessionproc.Remote{
+		Dir:    remote.RemotePath,
+		Binary: binaryOf(*remote),
+		Command: func(ctx context.Context, dir string, argv, env []string) (*exec.Cmd, error) {
+			return a.sshManager.RemoteCommand(ctx, connection, dir, argv, env)
+		},
+	}, nil
+}
+
+// applyRemoteProjectToClaude makes a Claude session of a remote project run
+// on its SSH host, and one of a local project in the WSL distro in WSL mode
+func (a *App) applyRemoteProjectToClaude(config *claude.SessionConfig) error {
+	remote, err := a.sessionRemote(config.ProjectPath, func(r RemoteProject) string { return r.ClaudeBinary })
+	if err != nil {
+		return err
+	}
+	if remote == nil {
+		remote = a.wslRemote(config.ProjectPath)
+	}
+	config.Remote = remote
+	return nil
+}
+
+// applyRemoteProjectToCodex makes a Codex session of a remote project run
+// on its SSH host, and one of a local project in the WSL distro in WSL mode
+func (a *App) applyRemoteProjectToCodex(config *codex.SessionConfig) error {
+	remote, err := a.sessionRemote(config.ProjectPath, func(r RemoteProject) string { return r.CodexBinary })
+	if err != nil {
+		return err
+	}
+	if remote == nil {
+		remote = a.wslRemote(config.ProjectPath)
+	}
+	config.Remote = remote
+	return nil
+}
+
+// requireLocalProject rejects providers that cannot run in a remote project
+func (a *App) requireLocalProject(projectPath, provider string) error {
+	remote, err := a.GetRemoteProject(projectPath)
+	if err != nil {
+		return err
+	}
+	if remote != nil {
+		return fmt.Errorf("%s sessions cannot run in remote project %s", provider, projectPath)
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"testing"
+
+	"ropcode/internal/claude"
+	"ropcode/internal/codex"
+	"ropcode/internal/ssh"
+)
+
+func newRemoteProjectTestApp(t *testing.T) *App {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	manager := ssh.NewManager()
+	if err := manager.AddGlobalConnection(ssh.SshConnection{Name: "build", Host: "build.example", User: "dev", Port: 22}); err != nil {
+		t.Fatalf("AddGlobalConnection failed: %v", err)
+	}
+	return &App{dbManager: openAppConfigTestDB(t), sshManager: manager}
+}
+
+func TestRemoteProjectSettings(t *testing.T) {
+	app := newRemoteProjectTestApp(t)
+
+	if remote, err := app.GetRemoteProject("/work/app"); err != nil || remote != nil {
+		t.Fatalf("Expected a local project, got %#v, %v", remote, err)
+	}
+	if err := app.SetRemoteProject("/work/app", RemoteProject{Connection: "missing", RemotePath: "/srv/app"}); err == nil {
+		t.Error("Expected an unknown connection to be rejected")
+	}
+	if err := app.SetRemoteProject("/work/app", RemoteProject{Connection: "build", RemotePath: " "}); err == nil {
+		t.Error("Expected an empty remote path to be rejected")
+	}
+	if err := app.SetRemoteProject("/work/app", RemoteProject{Connection: "build", RemotePath: "/srv/app ", CodexBinary: "/opt/codex"}); err != nil {
+		t.Fatalf("SetRemoteProject failed: %v", err)
+	}
+
+	remote, err := app.GetRemoteProject("/work/app")
+	if err != nil || remote == nil || remote.Connection != "build" || remote.RemotePath != "/srv/app" || remote.CodexBinary != "/opt/codex" {
+		t.Fatalf("GetRemoteProject = %#v, %v", remote, err)
+	}
+	if projects, err := app.ListRemoteProjects(); err != nil || len(projects) != 1 {
+		t.Fatalf("ListRemoteProjects = %#v, %v", projects, err)
+	}
+
+	if err := app.ClearRemoteProject("/work/app"); err != nil {
+		t.Fatalf("ClearRemoteProject failed: %v", err)
+	}
+	if remote, _ := app.GetRemoteProject("/work/app"); remote != nil {
+		t.Errorf("Expected the project to be local after clearing, got %#v", remote)
+	}
+}
+
+func TestApplyRemoteProject(t *testing.T) {
+	app := newRemoteProjectTestApp(t)
+	if err := app.SetRemoteProject("/work/app", RemoteProject{Connection: "build", RemotePath: "/srv/app", CodexBinary: "/opt/codex"}); err != nil {
+		t.Fatalf("SetRemoteProject failed: %v", err)
+	}
+
+	local := claude.SessionConfig{ProjectPath: "/work/other"}
+	if err := app.applyRemoteProjectToClaude(&local); err != nil || local.Remote != nil {
+		t.Errorf("Expected a local project to stay local, got %#v, %v", local.Remote, err)
+	}
+
+	claudeConfig := claude.SessionConfig{ProjectPath: "/work/app"}
+	if err := app.applyRemoteProjectToClaude(&claudeConfig); err != nil {
+		t.Fatalf("applyRemoteProjectToClaude failed: %v", err)
+	}
+	if claudeConfig.Remote == nil || claudeConfig.Remote.Dir != "/srv/app" || claudeConfig.Remote.BinaryOr("claude") != "claude" || claudeConfig.Remote.Command == nil {
+		t.Errorf("Unexpected claude remote: %#v", claudeConfig.Remote)
+	}
+
+	codexConfig := codex.SessionConfig{ProjectPath: "/work/app"}
+	if err := app.applyRemoteProjectToCodex(&codexConfig); err != nil {
+		t.Fatalf("applyRemoteProjectToCodex failed: %v", err)
+	}
+	if codexConfig.Remote == nil || codexConfig.Remote.BinaryOr("codex") != "/opt/codex" {
+		t.Errorf("Unexpected codex remote: %#v", codexConfig.Remote)
+	}
+
+	if err := app.requireLocalProject("/work/app", "gemini"); err == nil {
+		t.Error("Expected gemini to be refused in a remote project")
+	}
+	if err := app.requireLocalProject("/work/other", "gemini"); err != nil {
+		t.Errorf("Expected gemini to run in a local project, got %v", err)
+	}
+}
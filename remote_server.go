@@ -0,0 +1,645 @@
+// remote_server.go
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"log/slog"
+	"net/url"
+	"strings"
+	"sync"
+
+	"ropcode/internal/keychain"
+	"ropcode/internal/rpc"
+	"ropcode/internal/websocket"
+)
+
+// remoteServersSettingKey stores a JSON object of RemoteServer keyed by local
+// project path
+const remoteServersSettingKey = "remote_servers"
+
+// RemoteServer says which ropcode server a project runs on. A project runs
+// either on a remote server or on an SSH host, not both.
+type RemoteServer struct {
+	// URL is the server's WebSocket endpoint, e.g. ws://workstation:8080/ws
+	URL string `json:"url"`
+	// AuthKey is only passed to SetRemoteServer; it is stored in the
+	// keychain and never returned
+	AuthKey    string `json:"auth_key,omitempty"`
+	HasAuthKey bool   `json:"has_auth_key,omitempty"`
+	// RemotePath is the project's directory on the server
+	RemotePath string `json:"remote_path,omitempty"`
+}
+
+// remoteServerClient is the part of rpc.Client the proxy uses
+type remoteServerClient interface {
+	Call(method string, params []any, out any) error
+	Forward(handler rpc.ForwardHandler)
+	Subscribe(topics []string) error
+	Unsubscribe(topics []string) error
+	Done() <-chan struct{}
+	Close() error
+}
+
+// remoteServerPool keeps one connection per server and remembers which
+// server each proxied session runs on
+type remoteServerPool struct {
+	mu       sync.Mutex
+	clients  map[string]remoteServerClient
+	authKeys map[string]string // server URL -> auth key the client connected with
+	sessions map[string]string // session:<id> or pty:<id> topic -> server URL
+	dial     func(wsURL, authKey string) (remoteServerClient, error)
+	secrets  keychain.Store
+}
+
+func newRemoteServerPool() *remoteServerPool {
+	return &remoteServerPool{
+		clients:  make(map[string]remoteServerClient),
+		authKeys: make(map[string]string),
+		sessions: make(map[string]string),
+		dial: func(wsURL, authKey string) (remoteServerClient, error) {
+			return rpc.Dial(wsURL, authKey)
+		},
+		secrets: keychain.System,
+	}
+}
+
+// authKeyAccount is the keychain account holding a server's auth key
+func authKeyAccount(wsURL string) string {
+	return "remote-server:" + wsURL
+}
+
+// authKey returns the auth key stored for a server, or "" when there is none
+func (p *remoteServerPool) authKey(wsURL string) (string, error) {
+	key, err := p.secrets.Get(authKeyAccount(wsURL))
+	if errors.Is(err, keychain.ErrNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read auth key of %s from keychain: %w", wsURL, err)
+	}
+	return key, nil
+}
+
+// forgetAuthKey deletes a server's auth key once no project uses the server
+func (p *remoteServerPool) forgetAuthKey(wsURL string, servers map[string]RemoteServer) {
+	if p == nil {
+		return
+	}
+	for _, server := range servers {
+		if server.URL == wsURL {
+			return
+		}
+	}
+	if err := p.secrets.Delete(authKeyAccount(wsURL)); err != nil && !errors.Is(err, keychain.ErrNotFound) {
+		slog.Warn("Failed to delete auth key", "component", "RemoteServer", "url", wsURL, "error", err)
+	}
+}
+
+// remoteTarget is a remote server resolved for one call
+type remoteTarget struct {
+	client remoteServerClient
+	url    string
+	server RemoteServer
+	local  string // local project path
+}
+
+// remotePath translates a local path in the project to the server's path
+func (t *remoteTarget) remotePath(path string) string {
+	rest := strings.TrimPrefix(path, t.local)
+	return t.server.RemotePath + strings.ReplaceAll(rest, `\`, "/")
+}
+
+// localPath translates a server path in the project back to the local path
+func (t *remoteTarget) localPath(path string) string {
+	if rest, ok := strings.CutPrefix(path, t.server.RemotePath); ok && (rest == "" || rest[0] == '/') {
+		return t.local + rest
+	}
+	return path
+}
+
+func (a *App) loadRemoteServers() (map[string]RemoteServer, error) {
+	servers := make(map[string]RemoteServer)
+	if a.dbManager == nil {
+		return servers, nil
+	}
+	raw, err := a.dbManager.GetSetting(remoteServersSettingKey)
+	if err != nil || raw == "" {
+		return servers, nil
+	}
+	if err := json.Unmarshal([]byte(raw), &servers); err != nil {
+		return nil, fmt.Errorf("invalid %s setting: %w", remoteServersSettingKey, err)
+	}
+	a.migrateRemoteServerKeys(servers)
+	return servers, nil
+}
+
+// migrateRemoteServerKeys moves auth keys saved in settings by earlier
+// versions into the keychain
+func (a *App) migrateRemoteServerKeys(servers map[string]RemoteServer) {
+	if a.remoteServers == nil {
+		return
+	}
+	migrated := false
+	for project, server := range servers {
+		if server.AuthKey == "" {
+			continue
+		}
+		if err := a.remoteServers.secrets.Set(authKeyAccount(server.URL), server.AuthKey); err != nil {
+			slog.Error("Failed to move auth key to keychain", "component", "RemoteServer", "url", server.URL, "error", err)
+			return
+		}
+		server.AuthKey = ""
+		server.HasAuthKey = true
+		servers[project] = server
+		migrated = true
+	}
+	if migrated {
+		if err := a.saveRemoteServers(servers); err != nil {
+			log.Printf("[RemoteServer] %v", err)
+		}
+	}
+}
+
+func (a *App) saveRemoteServers(servers map[string]RemoteServer) error {
+	if a.dbManager == nil {
+		return a.unavailable(subsystemDatabase)
+	}
+	stored := make(map[string]RemoteServer, len(servers))
+	for project, server := range servers {
+		server.AuthKey = ""
+		stored[project] = server
+	}
+	data, err := json.Marshal(stored)
+	if err != nil {
+		return fmt.Errorf("failed to encode remote servers: %w", err)
+	}
+	if err := a.dbManager.SaveSetting(remoteServersSettingKey, string(data)); err != nil {
+		return fmt.Errorf("failed to save remote servers: %w", err)
+	}
+	return nil
+}
+
+// ListRemoteServers returns every project's remote server keyed by local
+// project path
+func (a *App) ListRemoteServers() (map[string]RemoteServer, error) {
+	return a.loadRemoteServers()
+}
+
+// GetRemoteServer returns the server a project runs on, or nil for a local
+// project
+func (a *App) GetRemoteServer(projectPath string) (*RemoteServer, error) {
+	servers, err := a.loadRemoteServers()
+	if err != nil {
+		return nil, err
+	}
+	server, ok := servers[projectPath]
+	if !ok {
+		return nil, nil
+	}
+	return &server, nil
+}
+
+// SetRemoteServer makes a project's sessions, terminals and git run on a
+// ropcode server. The server must be reachable with the auth key; an empty
+// key reuses the one stored for the server, if any.
+func (a *App) SetRemoteServer(projectPath string, server RemoteServer) error {
+	projectPath = trimTrailingSeparators(projectPath)
+	if projectPath == "" {
+		return fmt.Errorf("project path is required")
+	}
+	server.URL = strings.TrimSpace(server.URL)
+	parsed, err := url.Parse(server.URL)
+	if err != nil || (parsed.Scheme != "ws" && parsed.Scheme != "wss") || parsed.Host == "" {
+		return fmt.Errorf("invalid server URL %q, want ws://host:port/ws", server.URL)
+	}
+	server.RemotePath = trimTrailingSeparators(strings.TrimSpace(server.RemotePath))
+	if server.RemotePath == "" {
+		server.RemotePath = projectPath
+	}
+	if remote, err := a.GetRemoteProject(projectPath); err != nil {
+		return err
+	} else if remote != nil {
+		return fmt.Errorf("project %s already runs on SSH connection '%s'", projectPath, remote.Connection)
+	}
+
+	if a.remoteServers == nil {
+		return fmt.Errorf("remote servers not initialized")
+	}
+
+	authKey := server.AuthKey
+	if authKey == "" {
+		if authKey, err = a.remoteServers.authKey(server.URL); err != nil {
+			return err
+		}
+	}
+	client, err := a.remoteServers.connect(a, server.URL, authKey)
+	if err != nil {
+		return err
+	}
+	if server.AuthKey != "" {
+		if err := a.remoteServers.secrets.Set(authKeyAccount(server.URL), server.AuthKey); err != nil {
+			return fmt.Errorf("failed to store auth key in keychain: %w", err)
+		}
+	}
+	server.AuthKey = ""
+	server.HasAuthKey = authKey != ""
+
+	servers, err := a.loadRemoteServers()
+	if err != nil {
+		return err
+	}
+	previous, hadPrevious := servers[projectPath]
+	servers[projectPath] = server
+	if err := a.saveRemoteServers(servers); err != nil {
+		return err
+	}
+	if err := client.Subscribe([]string{websocket.TopicProject + server.RemotePath}); err != nil {
+		slog.Warn("Failed to subscribe", "component", "RemoteServer", "url", server.URL, "error", err)
+	}
+	if hadPrevious && previous != server {
+		a.remoteServers.release(previous, servers)
+		if previous.URL != server.URL {
+			a.remoteServers.forgetAuthKey(previous.URL, servers)
+		}
+	}
+	return nil
+}
+
+// ClearRemoteServer makes a project run locally again. Sessions already
+// running on the server keep running there.
+func (a *App) ClearRemoteServer(projectPath string) error {
+	servers, err := a.loadRemoteServers()
+	if err != nil {
+		return err
+	}
+	server, ok := servers[projectPath]
+	if !ok {
+		return nil
+	}
+	delete(servers, projectPath)
+	if err := a.saveRemoteServers(servers); err != nil {
+		return err
+	}
+	a.remoteServers.release(server, servers)
+	a.remoteServers.forgetAuthKey(server.URL, servers)
+	return nil
+}
+
+func trimTrailingSeparators(path string) string {
+	trimmed := strings.TrimRight(path, `/\`)
+	if trimmed == "" {
+		return path
+	}
+	return trimmed
+}
+
+// remoteServerFor returns the server a path's project runs on, or nil when
+// the path is local
+func (a *App) remoteServerFor(path string) (*remoteTarget, error) {
+	if path == "" || a.remoteServers == nil {
+		return nil, nil
+	}
+	servers, err := a.loadRemoteServers()
+	if err != nil || len(servers) == 0 {
+		return nil, err
+	}
+	var project string
+	for candidate := range servers {
+		if pathWithin(path, candidate) && len(candidate) > len(project) {
+			project = candidate
+		}
+	}
+	if project == "" {
+		return nil, nil
+	}
+	server := servers[project]
+	authKey, err := a.remoteServers.authKey(server.URL)
+	if err != nil {
+		return nil, err
+	}
+	if authKey == "" && server.HasAuthKey {
+		return nil, fmt.Errorf("auth key of ropcode server %s is missing from the keychain; set the project's server again", server.URL)
+	}
+	client, err := a.remoteServers.connect(a, server.URL, authKey)
+	if err != nil {
+		return nil, err
+	}
+	return &remoteTarget{client: client, url: server.URL, server: server, local: project}, nil
+}
+
+// pathWithin reports whether path is dir or below it
+func pathWithin(path, dir string) bool {
+	if path == dir {
+		return true
+	}
+	rest, ok := strings.CutPrefix(path, dir)
+	return ok && (rest[0] == '/' || rest[0] == '\\')
+}
+
+// connect returns the connection to a server, dialing it when there is
+// none, the last one dropped or the auth key changed
+func (p *remoteServerPool) connect(a *App, wsURL, authKey string) (remoteServerClient, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if client, ok := p.clients[wsURL]; ok {
+		select {
+		case <-client.Done():
+		default:
+			if p.authKeys[wsURL] == authKey {
+				return client, nil
+			}
+			go client.Close()
+		}
+		delete(p.clients, wsURL)
+	}
+
+	client, err := p.dial(wsURL, authKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to ropcode server %s: %w", wsURL, err)
+	}
+	client.Forward(a.forwardRemoteEvent)
+	p.clients[wsURL] = client
+	p.authKeys[wsURL] = authKey
+
+	servers, err := a.loadRemoteServers()
+	if err != nil {
+		log.Printf("[RemoteServer] %v", err)
+	}
+	if err := client.Subscribe(p.topicsLocked(wsURL, servers)); err != nil {
+		slog.Warn("Failed to subscribe", "component", "RemoteServer", "url", wsURL, "error", err)
+	}
+	return client, nil
+}
+
+// topicsLocked lists the events wanted from a server: its projects and the
+// sessions started through it. Must hold p.mu.
+func (p *remoteServerPool) topicsLocked(wsURL string, servers map[string]RemoteServer) []string {
+	var topics []string
+	for _, server := range servers {
+		if server.URL == wsURL {
+			topics = append(topics, websocket.TopicProject+server.RemotePath)
+		}
+	}
+	for topic, sessionURL := range p.sessions {
+		if sessionURL == wsURL {
+			topics = append(topics, topic)
+		}
+	}
+	return topics
+}
+
+// release stops the events of a project no longer run on a server, and
+// closes the connection when nothing else uses it
+func (p *remoteServerPool) release(server RemoteServer, servers map[string]RemoteServer) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	client, ok := p.clients[server.URL]
+	if !ok {
+		p.mu.Unlock()
+		return
+	}
+	topics := p.topicsLocked(server.URL, servers)
+	if len(topics) == 0 {
+		delete(p.clients, server.URL)
+		p.mu.Unlock()
+		client.Close()
+		return
+	}
+	p.mu.Unlock()
+
+	project := websocket.TopicProject + server.RemotePath
+	for _, topic := range topics {
+		if topic == project {
+			// Another local project maps to the same directory
+			return
+		}
+	}
+	if err := client.Unsubscribe([]string{project}); err != nil {
+		slog.Warn("Failed to unsubscribe", "component", "RemoteServer", "url", server.URL, "error", err)
+	}
+}
+
+// track remembers that a session runs on a server and subscribes to its
+// events. topic is websocket.TopicSession or websocket.TopicPty.
+func (p *remoteServerPool) track(topic, sessionID string, target *remoteTarget) {
+	p.mu.Lock()
+	p.sessions[topic+sessionID] = target.url
+	p.mu.Unlock()
+	if err := target.client.Subscribe([]string{topic + sessionID}); err != nil {
+		slog.Warn("Failed to subscribe", "component", "RemoteServer", "topic", topic+sessionID, "error", err)
+	}
+}
+
+func (p *remoteServerPool) untrack(topic, sessionID string) {
+	p.mu.Lock()
+	delete(p.sessions, topic+sessionID)
+	p.mu.Unlock()
+}
+
+// sessionClient returns the connection of a proxied session, or nil for a
+// local session
+func (p *remoteServerPool) sessionClient(topic, sessionID string) remoteServerClient {
+	if p == nil {
+		return nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	wsURL, ok := p.sessions[topic+sessionID]
+	if !ok {
+		return nil
+	}
+	return p.clients[wsURL]
+}
+
+// connected returns the live connections
+func (p *remoteServerPool) connected() map[string]remoteServerClient {
+	clients := make(map[string]remoteServerClient)
+	if p == nil {
+		return clients
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for wsURL, client := range p.clients {
+		clients[wsURL] = client
+	}
+	return clients
+}
+
+func (p *remoteServerPool) closeAll() {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	clients := p.clients
+	p.clients = make(map[string]remoteServerClient)
+	p.mu.Unlock()
+	for _, client := range clients {
+		client.Close()
+	}
+}
+
+// forwardRemoteEvent republishes a server's event locally. Provider output
+// arrives as JSON strings and stays a string so local consumers see the
+// same payload as for local sessions.
+func (a *App) forwardRemoteEvent(eventType string, payload json.RawMessage) {
+	if a.eventHub == nil {
+		return
+	}
+	var text string
+	if len(payload) > 0 && payload[0] == '"' && json.Unmarshal(payload, &text) == nil {
+		a.eventHub.Emit(eventType, text)
+		return
+	}
+	a.eventHub.Emit(eventType, payload)
+}
+
+// ===== Proxied bindings =====
+//
+// Each proxy returns ok == false when the project is local, in which case
+// the binding runs as usual.
+
+func (a *App) proxyStartProviderSession(provider, projectPath, prompt, model, providerApiID, reasoningEffort string) (string, bool, error) {
+	target, err := a.remoteServerFor(projectPath)
+	if err != nil || target == nil {
+		return "", err != nil, err
+	}
+	var sessionID string
+	err = target.client.Call("StartProviderSession", []any{provider, target.remotePath(projectPath), prompt, model, providerApiID, reasoningEffort}, &sessionID)
+	if err == nil {
+		a.remoteServers.track(websocket.TopicSession, sessionID, target)
+	}
+	return sessionID, true, err
+}
+
+func (a *App) proxyResumeProviderSession(provider, projectPath, prompt, model, sessionID, providerApiID, reasoningEffort string) (string, bool, error) {
+	target, err := a.remoteServerFor(projectPath)
+	if err != nil || target == nil {
+		return "", err != nil, err
+	}
+	var resumedID string
+	err = target.client.Call("ResumeProviderSession", []any{provider, target.remotePath(projectPath), prompt, model, sessionID, providerApiID, reasoningEffort}, &resumedID)
+	if err == nil {
+		a.remoteServers.track(websocket.TopicSession, resumedID, target)
+	}
+	return resumedID, true, err
+}
+
+func (a *App) proxySendProviderSessionMessage(provider, projectPath, sessionID, prompt string) (string, bool, error) {
+	target, err := a.remoteServerFor(projectPath)
+	if err != nil || target == nil {
+		return "", err != nil, err
+	}
+	var resultID string
+	err = target.client.Call("SendProviderSessionMessage", []any{provider, target.remotePath(projectPath), sessionID, prompt}, &resultID)
+	if err == nil {
+		a.remoteServers.track(websocket.TopicSession, resultID, target)
+	}
+	return resultID, true, err
+}
+
+func (a *App) proxyStopProviderSession(sessionID string) (bool, error) {
+	client := a.remoteServers.sessionClient(websocket.TopicSession, sessionID)
+	if client == nil {
+		return false, nil
+	}
+	err := client.Call("StopProviderSession", []any{sessionID}, nil)
+	if err == nil {
+		a.remoteServers.untrack(websocket.TopicSession, sessionID)
+	}
+	return true, err
+}
+
+func (a *App) proxyGetProviderSessionOutput(sessionID string) (string, bool, error) {
+	client := a.remoteServers.sessionClient(websocket.TopicSession, sessionID)
+	if client == nil {
+		return "", false, nil
+	}
+	var output string
+	err := client.Call("GetProviderSessionOutput", []any{sessionID}, &output)
+	return output, true, err
+}
+
+// remoteRunningProviderSessions lists the running sessions of the connected
+// servers that belong to projects run there, with local project paths
+func (a *App) remoteRunningProviderSessions() []LiveProviderSession {
+	clients := a.remoteServers.connected()
+	if len(clients) == 0 {
+		return nil
+	}
+	servers, err := a.loadRemoteServers()
+	if err != nil {
+		return nil
+	}
+	var result []LiveProviderSession
+	for wsURL, client := range clients {
+		var sessions []LiveProviderSession
+		if err := client.Call("ListRunningProviderSessions", nil, &sessions); err != nil {
+			slog.Warn("Failed to list sessions", "component", "RemoteServer", "url", wsURL, "error", err)
+			continue
+		}
+		for _, session := range sessions {
+			for local, server := range servers {
+				target := &remoteTarget{url: wsURL, server: server, local: local}
+				if server.URL != wsURL || !pathWithin(session.ProjectPath, server.RemotePath) {
+					continue
+				}
+				session.ProjectPath = target.localPath(session.ProjectPath)
+				result = append(result, session)
+				break
+			}
+		}
+	}
+	return result
+}
+
+func (a *App) proxyCreatePtySession(sessionID, cwd string, rows, cols int, shell string) (*PtySessionInfo, bool, error) {
+	target, err := a.remoteServerFor(cwd)
+	if err != nil || target == nil {
+		return nil, err != nil, err
+	}
+	var info PtySessionInfo
+	if err := target.client.Call("CreatePtySession", []any{sessionID, target.remotePath(cwd), rows, cols, shell}, &info); err != nil {
+		return nil, true, err
+	}
+	a.remoteServers.track(websocket.TopicPty, info.SessionID, target)
+	info.Cwd = target.localPath(info.Cwd)
+	return &info, true, nil
+}
+
+// proxyPtyCall forwards a call on an existing PTY session
+func (a *App) proxyPtyCall(method, sessionID string, params ...any) (bool, error) {
+	client := a.remoteServers.sessionClient(websocket.TopicPty, sessionID)
+	if client == nil {
+		return false, nil
+	}
+	err := client.Call(method, append([]any{sessionID}, params...), nil)
+	if err == nil && method == "ClosePtySession" {
+		a.remoteServers.untrack(websocket.TopicPty, sessionID)
+	}
+	return true, err
+}
+
+func (a *App) proxyIsPtySessionAlive(sessionID string) (bool, bool, error) {
+	client := a.remoteServers.sessionClient(websocket.TopicPty, sessionID)
+	if client == nil {
+		return false, false, nil
+	}
+	var alive bool
+	err := client.Call("IsPtySessionAlive", []any{sessionID}, &alive)
+	return alive, true, err
+}
+
+// proxyGitCall forwards a git binding whose first parameter is a path
+func (a *App) proxyGitCall(method, path string, out any, params ...any) (bool, error) {
+	target, err := a.remoteServerFor(path)
+	if err != nil || target == nil {
+		return err != nil, err
+	}
+	return true, target.client.Call(method, append([]any{target.remotePath(path)}, params...), out)
+}
@@ -0,0 +1,278 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"ropcode/internal/eventhub"
+	"ropcode/internal/keychain"
+	"ropcode/internal/rpc"
+)
+
+type remoteCall struct {
+	method string
+	params []any
+}
+
+type memorySecrets map[string]string
+
+func (s memorySecrets) Set(account, secret string) error { s[account] = secret; return nil }
+
+func (s memorySecrets) Get(account string) (string, error) {
+	secret, ok := s[account]
+	if !ok {
+		return "", keychain.ErrNotFound
+	}
+	return secret, nil
+}
+
+func (s memorySecrets) Delete(account string) error {
+	if _, ok := s[account]; !ok {
+		return keychain.ErrNotFound
+	}
+	delete(s, account)
+	return nil
+}
+
+// fakeRemoteServer records calls and answers them from results
+type fakeRemoteServer struct {
+	mu           sync.Mutex
+	calls        []remoteCall
+	results      map[string]any
+	subscribed   []string
+	unsubscribed []string
+	forward      rpc.ForwardHandler
+	done         chan struct{}
+	closed       bool
+}
+
+func (f *fakeRemoteServer) Call(method string, params []any, out any) error {
+	f.mu.Lock()
+	f.calls = append(f.calls, remoteCall{method, params})
+	result, ok := f.results[method]
+	f.mu.Unlock()
+	if err, isErr := result.(error); isErr {
+		return err
+	}
+	if !ok || out == nil {
+		return nil
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, out)
+}
+
+func (f *fakeRemoteServer) Forward(handler rpc.ForwardHandler) { f.forward = handler }
+
+func (f *fakeRemoteServer) Subscribe(topics []string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.subscribed = append(f.subscribed, topics...)
+	return nil
+}
+
+func (f *fakeRemoteServer) Unsubscribe(topics []string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.unsubscribed = append(f.unsubscribed, topics...)
+	return nil
+}
+
+func (f *fakeRemoteServer) Done() <-chan struct{} { return f.done }
+
+func (f *fakeRemoteServer) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !f.closed {
+		f.closed = true
+		close(f.done)
+	}
+	return nil
+}
+
+func (f *fakeRemoteServer) lastCall() remoteCall {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.calls) == 0 {
+		return remoteCall{}
+	}
+	return f.calls[len(f.calls)-1]
+}
+
+type recordingBroadcaster struct {
+	events []string
+	last   interface{}
+}
+
+func (b *recordingBroadcaster) BroadcastEvent(eventType string, payload interface{}) {
+	b.events = append(b.events, eventType)
+	b.last = payload
+}
+
+func newRemoteServerTestApp(t *testing.T) (*App, *fakeRemoteServer, *int) {
+	t.Helper()
+	server := &fakeRemoteServer{results: make(map[string]any), done: make(chan struct{})}
+	dials := 0
+	pool := newRemoteServerPool()
+	pool.dial = func(wsURL, authKey string) (remoteServerClient, error) {
+		if authKey != "secret" {
+			return nil, errors.New("unauthorized")
+		}
+		dials++
+		return server, nil
+	}
+	pool.secrets = memorySecrets{}
+	app := &App{dbManager: openAppConfigTestDB(t), remoteServers: pool, eventHub: eventhub.New(nil)}
+	return app, server, &dials
+}
+
+func TestSetRemoteServer(t *testing.T) {
+	app, server, _ := newRemoteServerTestApp(t)
+
+	if err := app.SetRemoteServer("/work/app", RemoteServer{URL: "http://box:8080/ws", AuthKey: "secret"}); err == nil {
+		t.Error("Expected a non-WebSocket URL to be rejected")
+	}
+	if err := app.SetRemoteServer("/work/app", RemoteServer{URL: "ws://box:8080/ws", AuthKey: "wrong"}); err == nil {
+		t.Error("Expected an unreachable server to be rejected")
+	}
+	if err := app.SetRemoteServer("/work/app/", RemoteServer{URL: " ws://box:8080/ws", AuthKey: "secret", RemotePath: "/srv/app/"}); err != nil {
+		t.Fatalf("SetRemoteServer failed: %v", err)
+	}
+	got, err := app.GetRemoteServer("/work/app")
+	want := &RemoteServer{URL: "ws://box:8080/ws", HasAuthKey: true, RemotePath: "/srv/app"}
+	if err != nil || !reflect.DeepEqual(got, want) {
+		t.Fatalf("GetRemoteServer = %#v, %v; want %#v", got, err, want)
+	}
+	secrets := app.remoteServers.secrets.(memorySecrets)
+	if raw, _ := app.dbManager.GetSetting(remoteServersSettingKey); strings.Contains(raw, "secret") || secrets[authKeyAccount("ws://box:8080/ws")] != "secret" {
+		t.Errorf("Expected the auth key in the keychain only, settings hold %s", raw)
+	}
+	if !reflect.DeepEqual(server.subscribed, []string{"project:/srv/app"}) {
+		t.Errorf("Expected the project topic subscribed, got %v", server.subscribed)
+	}
+
+	// The stored key is reused for another project on the same server
+	if err := app.SetRemoteServer("/work/other", RemoteServer{URL: "ws://box:8080/ws"}); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := app.GetRemoteServer("/work/other"); got == nil || got.RemotePath != "/work/other" || !got.HasAuthKey {
+		t.Errorf("Expected the remote path to default to the local path, got %#v", got)
+	}
+
+	if err := app.ClearRemoteServer("/work/app"); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(server.unsubscribed, []string{"project:/srv/app"}) || server.closed {
+		t.Errorf("Expected only the cleared project unsubscribed, got %v (closed %v)", server.unsubscribed, server.closed)
+	}
+	if err := app.ClearRemoteServer("/work/other"); err != nil {
+		t.Fatal(err)
+	}
+	if !server.closed {
+		t.Error("Expected the connection closed once no project uses it")
+	}
+	if len(secrets) != 0 {
+		t.Errorf("Expected the auth key deleted once no project uses the server, got %v", secrets)
+	}
+}
+
+func TestRemoteServerKeysMoveOutOfSettings(t *testing.T) {
+	app, _, _ := newRemoteServerTestApp(t)
+	legacy := `{"/work/app":{"url":"ws://box:8080/ws","auth_key":"secret","remote_path":"/srv/app"}}`
+	if err := app.dbManager.SaveSetting(remoteServersSettingKey, legacy); err != nil {
+		t.Fatal(err)
+	}
+	servers, err := app.ListRemoteServers()
+	if err != nil || servers["/work/app"].AuthKey != "" || !servers["/work/app"].HasAuthKey {
+		t.Fatalf("ListRemoteServers = %#v, %v", servers, err)
+	}
+	if raw, _ := app.dbManager.GetSetting(remoteServersSettingKey); strings.Contains(raw, "secret") {
+		t.Errorf("Expected the plaintext key removed from settings, got %s", raw)
+	}
+	if target, err := app.remoteServerFor("/work/app/src"); err != nil || target == nil {
+		t.Errorf("Expected the migrated key to connect, got %v", err)
+	}
+
+	delete(app.remoteServers.secrets.(memorySecrets), authKeyAccount("ws://box:8080/ws"))
+	app.remoteServers.closeAll()
+	if _, err := app.remoteServerFor("/work/app"); err == nil {
+		t.Error("Expected a missing keychain entry to be reported")
+	}
+}
+
+func TestRemoteServerProxiesSessionsPtyAndGit(t *testing.T) {
+	app, server, dials := newRemoteServerTestApp(t)
+	if err := app.SetRemoteServer("/work/app", RemoteServer{URL: "ws://box:8080/ws", AuthKey: "secret", RemotePath: "/srv/app"}); err != nil {
+		t.Fatal(err)
+	}
+
+	server.results["StartProviderSession"] = "remote-1"
+	sessionID, err := app.StartProviderSession("claude", "/work/app", "hi", "sonnet", "", "")
+	if err != nil || sessionID != "remote-1" {
+		t.Fatalf("StartProviderSession = %q, %v", sessionID, err)
+	}
+	if call := server.lastCall(); call.method != "StartProviderSession" || call.params[1] != "/srv/app" {
+		t.Errorf("Expected the remote project path, got %#v", call)
+	}
+	if err := app.StopProviderSession("remote-1"); err != nil || server.lastCall().method != "StopProviderSession" {
+		t.Errorf("Expected the stop proxied, got %#v, %v", server.lastCall(), err)
+	}
+
+	server.results["CreatePtySession"] = PtySessionInfo{SessionID: "pty-1", Cwd: "/srv/app/sub"}
+	info, err := app.CreatePtySession("pty-1", "/work/app/sub", 24, 80, "")
+	if err != nil || info.Cwd != "/work/app/sub" {
+		t.Fatalf("CreatePtySession = %#v, %v", info, err)
+	}
+	if call := server.lastCall(); call.params[1] != "/srv/app/sub" {
+		t.Errorf("Expected the remote cwd, got %#v", call)
+	}
+	if err := app.WriteToPty("pty-1", "ls\n"); err != nil || server.lastCall().method != "WriteToPty" {
+		t.Errorf("Expected the write proxied, got %#v, %v", server.lastCall(), err)
+	}
+
+	server.results["GetCurrentBranch"] = "main"
+	if branch, err := app.GetCurrentBranch("/work/app"); err != nil || branch != "main" {
+		t.Errorf("GetCurrentBranch = %q, %v", branch, err)
+	}
+
+	server.results["ListRunningProviderSessions"] = []LiveProviderSession{
+		{SessionID: "remote-2", ProjectPath: "/srv/app", Provider: "codex"},
+		{SessionID: "theirs", ProjectPath: "/srv/unrelated", Provider: "claude"},
+	}
+	running := app.ListRunningProviderSessions()
+	if len(running) != 1 || running[0].SessionID != "remote-2" || running[0].ProjectPath != "/work/app" {
+		t.Errorf("Expected only the project's remote session with its local path, got %#v", running)
+	}
+
+	// Paths outside the project stay local
+	calls := len(server.calls)
+	if app.IsGitRepository(t.TempDir()) || len(server.calls) != calls {
+		t.Error("Expected a local path not to be proxied")
+	}
+	if *dials != 1 {
+		t.Errorf("Expected one connection, dialed %d times", *dials)
+	}
+
+	broadcaster := &recordingBroadcaster{}
+	app.eventHub.SetBroadcaster(broadcaster)
+	server.forward("claude-output", json.RawMessage(`"{\"session_id\":\"remote-1\"}"`))
+	if broadcaster.last != `{"session_id":"remote-1"}` {
+		t.Errorf("Expected string payloads forwarded as strings, got %#v", broadcaster.last)
+	}
+}
+
+func TestSetRemoteServerRejectsSshProject(t *testing.T) {
+	app, _, _ := newRemoteServerTestApp(t)
+	if err := app.saveRemoteProjects(map[string]RemoteProject{"/work/app": {Connection: "build", RemotePath: "/srv/app"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := app.SetRemoteServer("/work/app", RemoteServer{URL: "ws://box:8080/ws", AuthKey: "secret"}); err == nil {
+		t.Error("Expected a project on an SSH host to be rejected")
+	}
+}
@@ -0,0 +1,84 @@
+// resource_usage.go
+package main
+
+import (
+	"sort"
+
+	"ropcode/internal/eventhub"
+	"ropcode/internal/resource"
+)
+
+// resourceTargets lists the root processes the resource monitor should sample.
+func (a *App) resourceTargets() []resource.Target {
+	targets := make([]resource.Target, 0)
+	for _, session := range a.ListRunningProviderSessions() {
+		if session.PID <= 0 {
+			continue
+		}
+		targets = append(targets, resource.Target{
+			Kind:     resource.KindSession,
+			ID:       session.SessionID,
+			Provider: session.Provider,
+			Cwd:      session.ProjectPath,
+			PID:      session.PID,
+		})
+	}
+
+	if a.processManager != nil {
+		keys := a.processManager.List()
+		sort.Strings(keys)
+		for _, key := range keys {
+			proc, ok := a.processManager.Get(key)
+			if !ok || !proc.IsRunning() {
+				continue
+			}
+			cwd := ""
+			if proc.Cmd != nil {
+				cwd = proc.Cmd.Dir
+			}
+			targets = append(targets, resource.Target{
+				Kind: resource.KindProcess,
+				ID:   key,
+				Cwd:  cwd,
+				PID:  proc.Pid(),
+			})
+		}
+	}
+	return targets
+}
+
+// GetSessionResourceUsage samples CPU and memory for all running provider
+// sessions and managed processes, including their child process trees.
+func (a *App) GetSessionResourceUsage() []resource.Usage {
+	if a.resourceMonitor == nil {
+		return []resource.Usage{}
+	}
+	return a.resourceMonitor.Sample()
+}
+
+// resourceUsageEmitter adapts EventHub to resource.UsageEmitter
+type resourceUsageEmitter struct {
+	eventHub *eventhub.EventHub
+}
+
+func (e *resourceUsageEmitter) EmitResourceUsage(samples []resource.Usage) {
+	event := eventhub.ResourceUsageEvent{
+		Samples: make([]eventhub.ResourceUsage, 0, len(samples)),
+	}
+	for _, sample := range samples {
+		event.Samples = append(event.Samples, eventhub.ResourceUsage{
+			Kind:         sample.Kind,
+			ID:           sample.ID,
+			Provider:     sample.Provider,
+			Cwd:          sample.Cwd,
+			PID:          sample.PID,
+			CPUPercent:   sample.CPUPercent,
+			RSSBytes:     sample.RSSBytes,
+			ProcessCount: sample.ProcessCount,
+		})
+		if event.SampledAt == 0 {
+			event.SampledAt = sample.SampledAt.UnixMilli()
+		}
+	}
+	e.eventHub.EmitResourceUsage(event)
+}
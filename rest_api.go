@@ -0,0 +1,148 @@
+// rest_api.go
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// restAPIPrefix is where the server build mounts restAPIHandler, behind the
+// same auth key as /ws
+const restAPIPrefix = "/api/v1/"
+
+// restBadRequest marks an error as caused by the request
+type restBadRequest struct{ err error }
+
+func (e restBadRequest) Error() string { return e.err.Error() }
+
+func badRequest(format string, args ...interface{}) error {
+	return restBadRequest{fmt.Errorf(format, args...)}
+}
+
+// restHandler adapts a binding call to JSON in and out. Failures are
+// {"error": "..."} with 400 for bad input and 500 otherwise.
+func restHandler(call func(r *http.Request) (interface{}, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		result, err := call(r)
+		w.Header().Set("Content-Type", "application/json")
+		if err != nil {
+			status := http.StatusInternalServerError
+			if errors.As(err, new(restBadRequest)) {
+				status = http.StatusBadRequest
+			}
+			w.WriteHeader(status)
+			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+		if err := json.NewEncoder(w).Encode(result); err != nil {
+			slog.Error("Failed to encode response", "component", "REST", "path", r.URL.Path, "error", err)
+		}
+	}
+}
+
+// restAPIHandler routes the REST API to the bindings
+func (a *App) restAPIHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+restAPIPrefix+"projects", restHandler(func(r *http.Request) (interface{}, error) {
+		return nonNil(a.ListProjects())
+	}))
+	mux.HandleFunc("GET "+restAPIPrefix+"sessions", restHandler(func(r *http.Request) (interface{}, error) {
+		return a.ListRunningProviderSessions(), nil
+	}))
+	mux.HandleFunc("POST "+restAPIPrefix+"sessions", restHandler(a.restStartSession))
+	mux.HandleFunc("DELETE "+restAPIPrefix+"sessions/{id}", restHandler(func(r *http.Request) (interface{}, error) {
+		if err := a.StopProviderSession(r.PathValue("id")); err != nil {
+			return nil, err
+		}
+		return map[string]bool{"stopped": true}, nil
+	}))
+	mux.HandleFunc("GET "+restAPIPrefix+"usage", restHandler(func(r *http.Request) (interface{}, error) {
+		start, end := r.URL.Query().Get("start"), r.URL.Query().Get("end")
+		if start == "" && end == "" {
+			return a.GetUsageStats()
+		}
+		if start == "" || end == "" {
+			return nil, badRequest("start and end must be given together")
+		}
+		for _, date := range []string{start, end} {
+			if _, err := time.Parse("2006-01-02", date); err != nil {
+				return nil, badRequest("invalid date %q, want YYYY-MM-DD", date)
+			}
+		}
+		return a.GetUsageByDateRange(start, end)
+	}))
+	mux.HandleFunc("GET "+restAPIPrefix+"agent-runs", restHandler(func(r *http.Request) (interface{}, error) {
+		agentID, err := queryInt(r, "agent_id")
+		if err != nil {
+			return nil, err
+		}
+		limit, err := queryInt(r, "limit")
+		if err != nil {
+			return nil, err
+		}
+		return nonNil(a.ListAgentRuns(int64(agentID), limit))
+	}))
+	return mux
+}
+
+// restStartSessionRequest is the body of POST /api/v1/sessions
+type restStartSessionRequest struct {
+	Provider        string `json:"provider"`
+	ProjectPath     string `json:"project_path"`
+	Prompt          string `json:"prompt"`
+	Model           string `json:"model"`
+	ProviderApiID   string `json:"provider_api_id"`
+	ReasoningEffort string `json:"reasoning_effort"`
+}
+
+func (a *App) restStartSession(r *http.Request) (interface{}, error) {
+	var req restStartSessionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, badRequest("invalid request body: %v", err)
+	}
+	if req.ProjectPath == "" || req.Prompt == "" {
+		return nil, badRequest("project_path and prompt are required")
+	}
+	if req.Provider == "" {
+		req.Provider = "claude"
+	}
+	switch req.Provider {
+	case "claude", "codex", "gemini":
+	default:
+		return nil, badRequest("unknown provider %q", req.Provider)
+	}
+
+	sessionID, err := a.StartProviderSession(req.Provider, req.ProjectPath, req.Prompt, req.Model, req.ProviderApiID, req.ReasoningEffort)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{"session_id": sessionID}, nil
+}
+
+func queryInt(r *http.Request, name string) (int, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return 0, badRequest("%s must be a non-negative integer", name)
+	}
+	return n, nil
+}
+
+// nonNil encodes missing lists as [] rather than null
+func nonNil[T any](items []T, err error) (interface{}, error) {
+	if err != nil {
+		return nil, err
+	}
+	if items == nil {
+		items = []T{}
+	}
+	return items, nil
+}
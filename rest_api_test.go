@@ -0,0 +1,55 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRestAPI(t *testing.T) {
+	app := &App{dbManager: openAppConfigTestDB(t)}
+	server := httptest.NewServer(app.restAPIHandler())
+	defer server.Close()
+
+	request := func(method, path, body string) (int, string) {
+		t.Helper()
+		req, err := http.NewRequest(method, server.URL+path, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("%s %s failed: %v", method, path, err)
+		}
+		defer resp.Body.Close()
+		var raw json.RawMessage
+		json.NewDecoder(resp.Body).Decode(&raw)
+		return resp.StatusCode, strings.TrimSpace(string(raw))
+	}
+
+	tests := []struct {
+		method, path, body string
+		status             int
+		want               string
+	}{
+		{"GET", "/api/v1/projects", "", http.StatusOK, "[]"},
+		{"GET", "/api/v1/sessions", "", http.StatusOK, "[]"},
+		{"GET", "/api/v1/agent-runs?limit=5", "", http.StatusOK, "[]"},
+		{"GET", "/api/v1/agent-runs?limit=x", "", http.StatusBadRequest, `{"error":"limit must be a non-negative integer"}`},
+		{"GET", "/api/v1/usage?start=2026-01-01", "", http.StatusBadRequest, `{"error":"start and end must be given together"}`},
+		{"GET", "/api/v1/usage?start=2026-01-01&end=soon", "", http.StatusBadRequest, `{"error":"invalid date \"soon\", want YYYY-MM-DD"}`},
+		{"POST", "/api/v1/sessions", `{"project_path":"/p"}`, http.StatusBadRequest, `{"error":"project_path and prompt are required"}`},
+		{"POST", "/api/v1/sessions", `{"provider":"other","project_path":"/p","prompt":"hi"}`, http.StatusBadRequest, `{"error":"unknown provider \"other\""}`},
+		{"POST", "/api/v1/sessions", `not json`, http.StatusBadRequest, ""},
+		{"DELETE", "/api/v1/sessions/missing", "", http.StatusInternalServerError, `{"error":"session not found: missing"}`},
+		{"PUT", "/api/v1/projects", "", http.StatusMethodNotAllowed, ""},
+	}
+	for _, tt := range tests {
+		status, body := request(tt.method, tt.path, tt.body)
+		if status != tt.status || (tt.want != "" && body != tt.want) {
+			t.Errorf("%s %s = %d %s, want %d %s", tt.method, tt.path, status, body, tt.status, tt.want)
+		}
+	}
+}
@@ -26,17 +26,19 @@ echo "Detected OS: $CURRENT_OS ($OSTYPE)"
 # 1. 构建 Go 服务器
  echo "Building Go server..."
 mkdir -p bin/darwin/arm64 bin/darwin/x64 bin/linux/x64 bin/win32/x64
+VERSION="$(node -p "require('./package.json').version")"
+LDFLAGS="-X main.appVersion=$VERSION"
 
 if [[ "$CURRENT_OS" == "darwin" ]]; then
-  GOOS=darwin GOARCH=arm64 go build -tags server -o bin/darwin/arm64/ropcode-server .
-  GOOS=darwin GOARCH=amd64 go build -tags server -o bin/darwin/x64/ropcode-server .
+  GOOS=darwin GOARCH=arm64 go build -tags server -ldflags "$LDFLAGS" -o bin/darwin/arm64/ropcode-server .
+  GOOS=darwin GOARCH=amd64 go build -tags server -ldflags "$LDFLAGS" -o bin/darwin/x64/ropcode-server .
   GOOS=darwin GOARCH=arm64 go build -o bin/darwin/arm64/ropcode ./cmd/ropcode
   GOOS=darwin GOARCH=amd64 go build -o bin/darwin/x64/ropcode ./cmd/ropcode
 elif [[ "$CURRENT_OS" == "linux" ]]; then
-  GOOS=linux GOARCH=amd64 go build -tags server -o bin/linux/x64/ropcode-server .
+  GOOS=linux GOARCH=amd64 go build -tags server -ldflags "$LDFLAGS" -o bin/linux/x64/ropcode-server .
   GOOS=linux GOARCH=amd64 go build -o bin/linux/x64/ropcode ./cmd/ropcode
 elif [[ "$CURRENT_OS" == "win32" ]]; then
-  GOOS=windows GOARCH=amd64 go build -tags server -o bin/win32/x64/ropcode-server.exe .
+  GOOS=windows GOARCH=amd64 go build -tags server -ldflags "$LDFLAGS" -o bin/win32/x64/ropcode-server.exe .
   GOOS=windows GOARCH=amd64 go build -o bin/win32/x64/ropcode.exe ./cmd/ropcode
 fi
 
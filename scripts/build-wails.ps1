@@ -105,7 +105,8 @@ Stop-WailsBuildProcesses
 Remove-Item -Recurse -Force -LiteralPath "build-wails" -ErrorAction SilentlyContinue
 
 Write-Step "Building Wails shell"
-& $wails build -clean -tags "wails" -ldflags "-s -w" -trimpath -skipbindings
+$version = (Get-Content -Raw "package.json" | ConvertFrom-Json).version
+& $wails build -clean -tags "wails" -ldflags "-s -w -X main.appVersion=$version" -trimpath -skipbindings
 
 Write-Step "Size summary"
 $paths = @(
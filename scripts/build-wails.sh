@@ -74,7 +74,8 @@ step "Cleaning Wails output folder"
 rm -rf build-wails
 
 step "Building Wails shell"
-"$WAILS" build -clean -tags "wails" -ldflags "-s -w" -trimpath -skipbindings
+VERSION="$(node -p "require('./package.json').version")"
+"$WAILS" build -clean -tags "wails" -ldflags "-s -w -X main.appVersion=$VERSION" -trimpath -skipbindings
 
 step "Size summary"
 for path in build-wails "build-wails/bin/RopcodeWails.app" frontend/dist; do
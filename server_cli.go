@@ -0,0 +1,324 @@
+// server_cli.go
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"ropcode/internal/eventhub"
+)
+
+// headlessApp is the part of App the headless commands use
+type headlessApp interface {
+	SetBroadcaster(b eventhub.Broadcaster)
+	StartProviderSession(provider, projectPath, prompt, model, providerApiID, reasoningEffort string) (string, error)
+	ListProviderSessions(projectPath, provider string) ([]ProviderSession, error)
+	GetUsageStats() (*UsageStats, error)
+	GetUsageByDateRange(start, end string) (*UsageStats, error)
+}
+
+// isHeadlessCommand reports whether the arguments name a headless
+// subcommand rather than asking to serve
+func isHeadlessCommand(args []string) bool {
+	if len(args) == 0 {
+		return false
+	}
+	switch args[0] {
+	case "run", "sessions", "usage", "help", "-h", "--help":
+		return true
+	}
+	return false
+}
+
+// isBootstrapCommand reports whether a headless subcommand needs the
+// runtime; help does not
+func isBootstrapCommand(args []string) bool {
+	switch args[0] {
+	case "help", "-h", "--help":
+		return false
+	}
+	return true
+}
+
+const headlessUsage = `Usage:
+  ropcode run --project DIR --prompt TEXT [--provider claude|codex|gemini] [--model M]
+              [--provider-api ID] [--reasoning-effort E]
+  ropcode sessions list [--project DIR] [--provider P] [--json]
+  ropcode usage [--since 7d|24h|YYYY-MM-DD] [--json]
+  ropcode                 start the server
+`
+
+// runHeadlessCommand runs a headless subcommand and returns its exit code
+func runHeadlessCommand(ctx context.Context, app headlessApp, args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	var err error
+	switch args[0] {
+	case "run":
+		err = headlessRun(ctx, app, args[1:], stdin, stdout, stderr)
+	case "sessions":
+		if len(args) < 2 || args[1] != "list" {
+			err = fmt.Errorf("usage: ropcode sessions list [--project DIR] [--provider P] [--json]")
+			break
+		}
+		err = headlessListSessions(app, args[2:], stdout, stderr)
+	case "usage":
+		err = headlessUsageStats(app, args[1:], stdout, stderr, time.Now())
+	default:
+		fmt.Fprint(stdout, headlessUsage)
+		return 0
+	}
+	if err == flag.ErrHelp {
+		return 0
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "ropcode: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+func newHeadlessFlags(name string, stderr io.Writer) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	return flags
+}
+
+// headlessRun prints the session's output as JSON lines and fails when the
+// session does; --prompt - reads the prompt from stdin
+func headlessRun(ctx context.Context, app headlessApp, args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	flags := newHeadlessFlags("run", stderr)
+	project := flags.String("project", "", "project directory (default: current directory)")
+	prompt := flags.String("prompt", "", "prompt to send; - reads it from stdin")
+	provider := flags.String("provider", "claude", "claude, codex or gemini")
+	model := flags.String("model", "", "model to use")
+	providerAPI := flags.String("provider-api", "", "saved provider API configuration ID")
+	effort := flags.String("reasoning-effort", "", "reasoning effort (codex)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	projectPath, err := headlessProject(*project)
+	if err != nil {
+		return err
+	}
+	text := *prompt
+	if text == "-" {
+		data, err := io.ReadAll(stdin)
+		if err != nil {
+			return fmt.Errorf("failed to read prompt: %w", err)
+		}
+		text = string(data)
+	}
+	if strings.TrimSpace(text) == "" {
+		return fmt.Errorf("--prompt is required")
+	}
+	switch *provider {
+	case "claude", "codex", "gemini":
+	default:
+		return fmt.Errorf("unknown provider %q", *provider)
+	}
+
+	stream := newHeadlessStream(stdout, stderr)
+	app.SetBroadcaster(stream)
+	if _, err := app.StartProviderSession(*provider, projectPath, text, *model, *providerAPI, *effort); err != nil {
+		return err
+	}
+	return stream.wait(ctx)
+}
+
+func headlessListSessions(app headlessApp, args []string, stdout, stderr io.Writer) error {
+	flags := newHeadlessFlags("sessions list", stderr)
+	project := flags.String("project", "", "project directory (default: current directory)")
+	provider := flags.String("provider", "claude", "claude or codex")
+	asJSON := flags.Bool("json", false, "print JSON")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	projectPath, err := headlessProject(*project)
+	if err != nil {
+		return err
+	}
+
+	sessions, err := app.ListProviderSessions(projectPath, *provider)
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		if sessions == nil {
+			sessions = []ProviderSession{}
+		}
+		return writeJSON(stdout, sessions)
+	}
+
+	w := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tCREATED\tLAST MESSAGE")
+	for _, session := range sessions {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", session.ID, formatUnix(session.CreatedAt), session.MessageTimestamp)
+	}
+	return w.Flush()
+}
+
+func headlessUsageStats(app headlessApp, args []string, stdout, stderr io.Writer, now time.Time) error {
+	flags := newHeadlessFlags("usage", stderr)
+	since := flags.String("since", "", "period to report: 7d, 24h or a YYYY-MM-DD start date (default: all time)")
+	asJSON := flags.Bool("json", false, "print JSON")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	var stats *UsageStats
+	if *since == "" {
+		var err error
+		if stats, err = app.GetUsageStats(); err != nil {
+			return err
+		}
+	} else {
+		start, err := parseSince(*since, now)
+		if err != nil {
+			return err
+		}
+		if stats, err = app.GetUsageByDateRange(start.Format("2006-01-02"), now.Format("2006-01-02")); err != nil {
+			return err
+		}
+	}
+	if *asJSON {
+		return writeJSON(stdout, stats)
+	}
+
+	w := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Sessions\t%d\n", stats.TotalSessions)
+	fmt.Fprintf(w, "Input tokens\t%d\n", stats.TotalInputTokens)
+	fmt.Fprintf(w, "Output tokens\t%d\n", stats.TotalOutputTokens)
+	fmt.Fprintf(w, "Cache write tokens\t%d\n", stats.TotalCacheCreationTokens)
+	fmt.Fprintf(w, "Cache read tokens\t%d\n", stats.TotalCacheReadTokens)
+	fmt.Fprintf(w, "Cost\t$%.2f\n", stats.TotalCost)
+	if len(stats.ByModel) > 0 {
+		fmt.Fprintln(w, "\nMODEL\tSESSIONS\tTOKENS\tCOST")
+		for _, model := range stats.ByModel {
+			fmt.Fprintf(w, "%s\t%d\t%d\t$%.2f\n", model.Model, model.SessionCount, model.TotalTokens, model.TotalCost)
+		}
+	}
+	return w.Flush()
+}
+
+// parseSince turns 7d, 24h or a date into the start of the period
+func parseSince(value string, now time.Time) (time.Time, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			return now.AddDate(0, 0, -n), nil
+		}
+	}
+	if d, err := time.ParseDuration(value); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, now.Location()); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid --since %q, want e.g. 7d, 24h or 2006-01-02", value)
+}
+
+func headlessProject(project string) (string, error) {
+	if project == "" {
+		return os.Getwd()
+	}
+	return project, nil
+}
+
+// formatUnix formats seconds (or milliseconds) since the epoch
+func formatUnix(ts int64) string {
+	if ts == 0 {
+		return "-"
+	}
+	if ts > 1e12 {
+		return time.UnixMilli(ts).Format("2006-01-02 15:04")
+	}
+	return time.Unix(ts, 0).Format("2006-01-02 15:04")
+}
+
+func writeJSON(w io.Writer, value interface{}) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
+
+// headlessStream receives the events of a headless run in place of the
+// WebSocket server. Only one session runs, so every output line is its.
+type headlessStream struct {
+	mu     sync.Mutex
+	stdout io.Writer
+	stderr io.Writer
+	failed string
+	done   chan struct{}
+	closed bool
+}
+
+func newHeadlessStream(stdout, stderr io.Writer) *headlessStream {
+	return &headlessStream{stdout: stdout, stderr: stderr, done: make(chan struct{})}
+}
+
+// BroadcastEvent implements eventhub.Broadcaster
+func (s *headlessStream) BroadcastEvent(eventType string, payload interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+
+	switch eventType {
+	case "claude-output":
+		if line, ok := payload.(string); ok {
+			fmt.Fprintln(s.stdout, line)
+		}
+	case "claude-output-batch":
+		if batch, ok := payload.(map[string]interface{}); ok {
+			lines, _ := batch["lines"].([]string)
+			for _, line := range lines {
+				fmt.Fprintln(s.stdout, line)
+			}
+		}
+	case "claude-error":
+		var msg struct {
+			Error string `json:"error"`
+		}
+		if text, ok := payload.(string); ok && json.Unmarshal([]byte(text), &msg) == nil && msg.Error != "" {
+			s.failed = msg.Error
+			fmt.Fprintf(s.stderr, "ropcode: %s\n", msg.Error)
+		}
+	case "claude-complete":
+		var msg struct {
+			Success bool   `json:"success"`
+			Status  string `json:"status"`
+		}
+		if text, ok := payload.(string); ok && json.Unmarshal([]byte(text), &msg) == nil && !msg.Success && s.failed == "" {
+			s.failed = "session failed"
+			if msg.Status != "" {
+				s.failed = "session ended with status " + msg.Status
+			}
+		}
+		s.closed = true
+		close(s.done)
+	}
+}
+
+// wait blocks until the session completes and reports whether it failed
+func (s *headlessStream) wait(ctx context.Context) error {
+	select {
+	case <-s.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.failed != "" {
+		return fmt.Errorf("%s", s.failed)
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"ropcode/internal/eventhub"
+)
+
+type fakeHeadlessApp struct {
+	broadcaster eventhub.Broadcaster
+	started     []string
+	events      func(b eventhub.Broadcaster)
+	usageRange  [2]string
+}
+
+func (f *fakeHeadlessApp) SetBroadcaster(b eventhub.Broadcaster) { f.broadcaster = b }
+
+func (f *fakeHeadlessApp) StartProviderSession(provider, projectPath, prompt, model, providerApiID, reasoningEffort string) (string, error) {
+	f.started = append(f.started, provider, projectPath, prompt, model)
+	go f.events(f.broadcaster)
+	return "s1", nil
+}
+
+func (f *fakeHeadlessApp) ListProviderSessions(projectPath, provider string) ([]ProviderSession, error) {
+	return []ProviderSession{{ID: "abc", ProjectPath: projectPath, CreatedAt: 1700000000}}, nil
+}
+
+func (f *fakeHeadlessApp) GetUsageStats() (*UsageStats, error) {
+	return &UsageStats{TotalSessions: 3}, nil
+}
+
+func (f *fakeHeadlessApp) GetUsageByDateRange(start, end string) (*UsageStats, error) {
+	f.usageRange = [2]string{start, end}
+	return &UsageStats{TotalSessions: 1, ByModel: []ModelStat{{Model: "sonnet", SessionCount: 1, TotalTokens: 42}}}, nil
+}
+
+func runHeadlessTest(app headlessApp, stdin string, args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	code := runHeadlessCommand(context.Background(), app, args, strings.NewReader(stdin), &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestHeadlessRun(t *testing.T) {
+	app := &fakeHeadlessApp{events: func(b eventhub.Broadcaster) {
+		b.BroadcastEvent("claude-output", `{"type":"system","session_id":"s1"}`)
+		b.BroadcastEvent("claude-output-batch", map[string]interface{}{"session_id": "s1", "lines": []string{`{"type":"assistant"}`, `{"type":"result"}`}})
+		b.BroadcastEvent("claude-complete", `{"success":true,"status":"completed"}`)
+	}}
+	code, stdout, stderr := runHeadlessTest(app, "fix the build\n", "run", "--project", "/work/app", "--provider", "codex", "--prompt", "-")
+	if code != 0 {
+		t.Fatalf("run exited %d: %s", code, stderr)
+	}
+	if want := "{\"type\":\"system\",\"session_id\":\"s1\"}\n{\"type\":\"assistant\"}\n{\"type\":\"result\"}\n"; stdout != want {
+		t.Errorf("run printed %q, want %q", stdout, want)
+	}
+	if strings.Join(app.started, "|") != "codex|/work/app|fix the build\n|" {
+		t.Errorf("Unexpected session start: %q", app.started)
+	}
+
+	app.events = func(b eventhub.Broadcaster) {
+		b.BroadcastEvent("claude-error", `{"type":"error","error":"model not found"}`)
+		b.BroadcastEvent("claude-complete", `{"success":false,"status":"failed"}`)
+	}
+	code, _, stderr = runHeadlessTest(app, "", "run", "--project", "/work/app", "--prompt", "hi")
+	if code != 1 || !strings.Contains(stderr, "model not found") {
+		t.Errorf("Expected a failed session to exit 1 with its error, got %d %q", code, stderr)
+	}
+
+	if code, _, stderr := runHeadlessTest(app, "", "run", "--project", "/work/app"); code != 1 || !strings.Contains(stderr, "--prompt is required") {
+		t.Errorf("Expected a missing prompt to be refused, got %d %q", code, stderr)
+	}
+	if code, _, _ := runHeadlessTest(app, "", "run", "--prompt", "hi", "--provider", "other"); code != 1 {
+		t.Errorf("Expected an unknown provider to be refused, got %d", code)
+	}
+}
+
+func TestHeadlessSessionsAndUsage(t *testing.T) {
+	app := &fakeHeadlessApp{}
+
+	code, stdout, _ := runHeadlessTest(app, "", "sessions", "list", "--project", "/work/app", "--json")
+	if code != 0 || !strings.Contains(stdout, `"id": "abc"`) || !strings.Contains(stdout, `"project_path": "/work/app"`) {
+		t.Errorf("sessions list --json = %d %s", code, stdout)
+	}
+	if code, _, _ := runHeadlessTest(app, "", "sessions"); code != 1 {
+		t.Errorf("Expected sessions without list to fail, got %d", code)
+	}
+
+	code, stdout, _ = runHeadlessTest(app, "", "usage")
+	if code != 0 || !strings.Contains(stdout, "Sessions") || !strings.Contains(stdout, "3") {
+		t.Errorf("usage = %d %s", code, stdout)
+	}
+	code, stdout, _ = runHeadlessTest(app, "", "usage", "--since", "2026-01-01")
+	if code != 0 || app.usageRange[0] != "2026-01-01" || !strings.Contains(stdout, "sonnet") {
+		t.Errorf("usage --since = %d %v %s", code, app.usageRange, stdout)
+	}
+	if code, _, stderr := runHeadlessTest(app, "", "usage", "--since", "soon"); code != 1 || !strings.Contains(stderr, "invalid --since") {
+		t.Errorf("Expected an invalid --since to fail, got %d %q", code, stderr)
+	}
+}
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	tests := map[string]time.Time{
+		"7d":         time.Date(2026, 3, 3, 12, 0, 0, 0, time.UTC),
+		"24h":        time.Date(2026, 3, 9, 12, 0, 0, 0, time.UTC),
+		"2026-02-01": time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC),
+	}
+	for value, want := range tests {
+		if got, err := parseSince(value, now); err != nil || !got.Equal(want) {
+			t.Errorf("parseSince(%q) = %v, %v; want %v", value, got, err, want)
+		}
+	}
+	if !isHeadlessCommand([]string{"run"}) || isHeadlessCommand(nil) || isHeadlessCommand([]string{"--port"}) {
+		t.Error("Unexpected headless command detection")
+	}
+}
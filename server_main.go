@@ -11,10 +11,18 @@ import (
 	"syscall"
 
 	"ropcode/internal/logging"
+	"ropcode/internal/ssh"
 	"ropcode/internal/websocket"
 )
 
 func main() {
+	// ssh runs ropcode as SSH_ASKPASS to fetch saved passwords
+	ssh.RunAskpassIfRequested()
+
+	if args := os.Args[1:]; isHeadlessCommand(args) {
+		os.Exit(runHeadless(args))
+	}
+
 	logPath, cleanupLogging, err := logging.ConfigureServerLogging()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Failed to configure logging: %v\n", err)
@@ -36,6 +44,7 @@ func main() {
 	// 创建并启动 WebSocket 服务器
 	wsServer := websocket.NewServer(app)
 	app.SetBroadcaster(wsServer)
+	wsServer.Handle(restAPIPrefix, app.restAPIHandler())
 
 	// 启动服务器
 	port, err := wsServer.Start(ctx)
@@ -54,3 +63,25 @@ func main() {
 	fmt.Println("Shutting down...")
 	_ = wsServer.Stop(ctx)
 }
+
+// runHeadless bootstraps the runtime without a WebSocket server and runs a
+// headless subcommand (see server_cli.go)
+func runHeadless(args []string) int {
+	if _, cleanupLogging, err := logging.ConfigureHeadlessLogging(); err == nil {
+		defer cleanupLogging()
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	if !isBootstrapCommand(args) {
+		return runHeadlessCommand(ctx, nil, args, os.Stdin, os.Stdout, os.Stderr)
+	}
+	app, shutdownApp, err := BootstrapRuntime(ctx)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ropcode: failed to bootstrap runtime: %v\n", err)
+		return 1
+	}
+	defer shutdownApp(context.Background())
+	return runHeadlessCommand(ctx, app, args, os.Stdin, os.Stdout, os.Stderr)
+}
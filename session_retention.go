@@ -0,0 +1,140 @@
+// session_retention.go
+package main
+
+import (
+	"context"
+	"log"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"ropcode/internal/codex"
+	"ropcode/internal/gemini"
+	"ropcode/internal/retention"
+	"ropcode/internal/settings"
+)
+
+const (
+	sessionRetentionDaysSettingKey  = "session_retention_days"
+	sessionRetentionMaxMBSettingKey = "session_retention_max_mb"
+	sessionArchivalDelay            = 10 * time.Minute
+	sessionArchivalInterval         = 24 * time.Hour
+)
+
+// sessionArchivalMu keeps a background and a requested archival from
+// overlapping
+var sessionArchivalMu sync.Mutex
+
+// sessionArchiveDir is where transcripts are archived,
+// ~/.ropcode/session-archive
+func (a *App) sessionArchiveDir() (string, error) {
+	if a.config != nil {
+		return filepath.Join(a.config.RopcodeDir, "session-archive"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".ropcode", "session-archive"), nil
+}
+
+// sessionRetention returns the retention manager of the providers' session
+// directories
+func (a *App) sessionRetention() (*retention.Manager, error) {
+	archiveDir, err := a.sessionArchiveDir()
+	if err != nil {
+		return nil, err
+	}
+	manager := &retention.Manager{ArchiveDir: archiveDir}
+	if a.config != nil {
+		manager.Sources = append(manager.Sources, retention.Source{
+			Provider: "claude", Root: filepath.Join(a.config.ClaudeDir, "projects"), Exts: []string{".jsonl"},
+		})
+	} else if home, err := os.UserHomeDir(); err == nil {
+		manager.Sources = append(manager.Sources, retention.Source{
+			Provider: "claude", Root: filepath.Join(home, ".claude", "projects"), Exts: []string{".jsonl"},
+		})
+	}
+	if dir, err := codex.CodexDir(); err == nil {
+		manager.Sources = append(manager.Sources, retention.Source{
+			Provider: "codex", Root: filepath.Join(dir, "sessions"), Exts: []string{".jsonl"},
+		})
+	}
+	if dir, err := gemini.GeminiDir(); err == nil {
+		manager.Sources = append(manager.Sources, retention.Source{
+			Provider: "gemini", Root: filepath.Join(dir, "tmp"), Exts: []string{".json"},
+		})
+	}
+	return manager, nil
+}
+
+// sessionRetentionPolicy reads the retention settings, defaults filled in
+func (a *App) sessionRetentionPolicy() retention.Policy {
+	number := func(key string) float64 {
+		raw := ""
+		if a.dbManager != nil {
+			raw, _ = a.dbManager.GetSetting(key)
+		}
+		value, _ := settings.Lookup(key).Decode(raw).(float64)
+		return max(value, 0)
+	}
+	return retention.Policy{
+		MaxAge:   time.Duration(number(sessionRetentionDaysSettingKey) * float64(24*time.Hour)),
+		MaxBytes: int64(number(sessionRetentionMaxMBSettingKey) * (1 << 20)),
+	}
+}
+
+// GetSessionRetentionStats reports how much space each provider's session
+// transcripts take and how much the retention settings would reclaim
+func (a *App) GetSessionRetentionStats() (*retention.Stats, error) {
+	manager, err := a.sessionRetention()
+	if err != nil {
+		return nil, err
+	}
+	return manager.Stats(a.sessionRetentionPolicy(), time.Now())
+}
+
+// ArchiveOldSessions archives the session transcripts the retention
+// settings don't keep and reports what is left
+func (a *App) ArchiveOldSessions() (*retention.ArchiveResult, error) {
+	sessionArchivalMu.Lock()
+	defer sessionArchivalMu.Unlock()
+
+	manager, err := a.sessionRetention()
+	if err != nil {
+		return nil, err
+	}
+	result, err := manager.Archive(a.sessionRetentionPolicy(), time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if result.Archived > 0 {
+		log.Printf("[session-retention] archived %d transcripts, freed %d bytes", result.Archived, result.FreedBytes)
+	}
+	for _, failure := range result.Failed {
+		slog.Warn("not archived", "component", "session-retention", "reason", failure)
+	}
+	return result, nil
+}
+
+// runSessionArchival archives old session transcripts a while after
+// startup and then once a day, while a retention setting is set
+func (a *App) runSessionArchival(ctx context.Context) {
+	timer := time.NewTimer(sessionArchivalDelay)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+		if a.sessionRetentionPolicy() != (retention.Policy{}) {
+			if _, err := a.ArchiveOldSessions(); err != nil {
+				slog.Error("archival failed", "component", "session-retention", "error", err)
+			}
+		}
+		timer.Reset(sessionArchivalInterval)
+	}
+}
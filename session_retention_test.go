@@ -0,0 +1,52 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"ropcode/internal/config"
+)
+
+func TestArchiveOldSessionsFollowsRetentionSettings(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("CODEX_HOME", filepath.Join(home, ".codex"))
+	claudeDir := filepath.Join(home, ".claude")
+	old := time.Now().Add(-60 * 24 * time.Hour)
+	transcripts := []string{
+		filepath.Join(claudeDir, "projects", "-work-app", "s1.jsonl"),
+		filepath.Join(home, ".codex", "sessions", "2026", "01", "02", "rollout-c1.jsonl"),
+	}
+	for _, path := range transcripts {
+		os.MkdirAll(filepath.Dir(path), 0755)
+		os.WriteFile(path, []byte(`{"type":"user"}`+"\n"), 0644)
+		os.Chtimes(path, old, old)
+	}
+
+	db := openAppConfigTestDB(t)
+	app := &App{dbManager: db, config: &config.Config{ClaudeDir: claudeDir, RopcodeDir: filepath.Join(home, ".ropcode")}}
+
+	// Nothing is archived until a retention setting is set
+	result, err := app.ArchiveOldSessions()
+	if err != nil || result.Archived != 0 || result.Stats.Files != 2 {
+		t.Fatalf("ArchiveOldSessions() without a policy = %+v, %v", result, err)
+	}
+
+	if err := db.SaveSetting(sessionRetentionDaysSettingKey, "30"); err != nil {
+		t.Fatal(err)
+	}
+	stats, err := app.GetSessionRetentionStats()
+	if err != nil || stats.ReclaimableFiles != 2 || len(stats.Providers) != 3 {
+		t.Fatalf("GetSessionRetentionStats() = %+v, %v", stats, err)
+	}
+	result, err = app.ArchiveOldSessions()
+	if err != nil || result.Archived != 2 || result.Stats.ArchivedFiles != 2 {
+		t.Fatalf("ArchiveOldSessions() = %+v, %v", result, err)
+	}
+	archived := filepath.Join(home, ".ropcode", "session-archive", "codex", "2026", "01", "02", "rollout-c1.jsonl.zst")
+	if _, err := os.Stat(archived); err != nil {
+		t.Fatalf("codex archive: %v", err)
+	}
+}
@@ -0,0 +1,140 @@
+// session_scheduler.go
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"ropcode/internal/eventhub"
+	"ropcode/internal/scheduler"
+)
+
+// sessionConcurrencyLimitsSettingKey stores the session limits; zero means
+// unlimited
+const sessionConcurrencyLimitsSettingKey = "session_concurrency_limits"
+
+// normalizeSessionProvider maps a provider name onto the manager that will
+// actually run it; unknown providers fall back to Claude.
+func normalizeSessionProvider(provider string) string {
+	switch provider {
+	case "gemini", "codex":
+		return provider
+	default:
+		return "claude"
+	}
+}
+
+// runningSessionCounts reports running sessions per provider for the scheduler.
+func (a *App) runningSessionCounts() map[string]int {
+	counts := make(map[string]int)
+	for _, session := range a.ListRunningProviderSessions() {
+		counts[session.Provider]++
+	}
+	return counts
+}
+
+// acquireSessionSlot waits for a free session slot. The returned release func
+// must be called once the provider manager has registered the new session.
+func (a *App) acquireSessionSlot(provider, projectPath string) (func(), error) {
+	if a.sessionScheduler == nil {
+		return func() {}, nil
+	}
+	ctx := a.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	release, err := a.sessionScheduler.Acquire(ctx, normalizeSessionProvider(provider), projectPath)
+	if err != nil {
+		return nil, fmt.Errorf("session not started: %w", err)
+	}
+	return release, nil
+}
+
+func (a *App) loadSessionConcurrencyLimits() {
+	if a.dbManager == nil || a.sessionScheduler == nil {
+		return
+	}
+	raw, err := a.dbManager.GetSetting(sessionConcurrencyLimitsSettingKey)
+	if err != nil || raw == "" {
+		return
+	}
+	var limits scheduler.Limits
+	if err := json.Unmarshal([]byte(raw), &limits); err != nil {
+		log.Printf("[scheduler] ignoring invalid %s setting: %v", sessionConcurrencyLimitsSettingKey, err)
+		return
+	}
+	if err := a.sessionScheduler.SetLimits(limits); err != nil {
+		log.Printf("[scheduler] ignoring invalid %s setting: %v", sessionConcurrencyLimitsSettingKey, err)
+	}
+}
+
+// GetSessionConcurrencyLimits returns the configured session concurrency limits
+func (a *App) GetSessionConcurrencyLimits() (scheduler.Limits, error) {
+	if a.sessionScheduler == nil {
+		return scheduler.Limits{}, a.unavailable(subsystemScheduler)
+	}
+	return a.sessionScheduler.Limits(), nil
+}
+
+// SetSessionConcurrencyLimits updates and persists the session concurrency limits
+func (a *App) SetSessionConcurrencyLimits(limits scheduler.Limits) error {
+	if a.sessionScheduler == nil {
+		return a.unavailable(subsystemScheduler)
+	}
+	if err := a.sessionScheduler.SetLimits(limits); err != nil {
+		return err
+	}
+	if a.dbManager == nil {
+		return nil
+	}
+	data, err := json.Marshal(limits)
+	if err != nil {
+		return fmt.Errorf("failed to encode session concurrency limits: %w", err)
+	}
+	if err := a.dbManager.SaveSetting(sessionConcurrencyLimitsSettingKey, string(data)); err != nil {
+		return fmt.Errorf("failed to save session concurrency limits: %w", err)
+	}
+	return nil
+}
+
+// ListQueuedProviderSessions returns session start requests waiting for a slot
+func (a *App) ListQueuedProviderSessions() []scheduler.QueueEntry {
+	if a.sessionScheduler == nil {
+		return []scheduler.QueueEntry{}
+	}
+	return a.sessionScheduler.Queue()
+}
+
+// CancelQueuedProviderSession removes a waiting session start request
+func (a *App) CancelQueuedProviderSession(queueID string) error {
+	if a.sessionScheduler == nil {
+		return a.unavailable(subsystemScheduler)
+	}
+	if !a.sessionScheduler.Cancel(queueID) {
+		return fmt.Errorf("queued session not found: %s", queueID)
+	}
+	return nil
+}
+
+// sessionQueueEmitter adapts EventHub to scheduler.QueueEmitter
+type sessionQueueEmitter struct {
+	eventHub *eventhub.EventHub
+}
+
+func (e *sessionQueueEmitter) EmitSessionQueueChanged(entries []scheduler.QueueEntry) {
+	event := eventhub.SessionQueueChangedEvent{
+		Entries: make([]eventhub.SessionQueueEntry, 0, len(entries)),
+	}
+	for _, entry := range entries {
+		event.Entries = append(event.Entries, eventhub.SessionQueueEntry{
+			ID:          entry.ID,
+			Provider:    entry.Provider,
+			ProjectPath: entry.ProjectPath,
+			Position:    entry.Position,
+			QueuedAt:    entry.QueuedAt.UnixMilli(),
+		})
+	}
+	e.eventHub.EmitSessionQueueChanged(event)
+}
@@ -0,0 +1,72 @@
+// session_share.go
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"time"
+
+	"ropcode/internal/claude"
+	"ropcode/internal/sessionshare"
+)
+
+// SessionShare is a rendered session share file
+type SessionShare struct {
+	// FileName is a suggested name to save HTML under
+	FileName string `json:"file_name"`
+	HTML     string `json:"html"`
+	Messages int    `json:"messages"`
+	Files    int    `json:"files"`
+}
+
+// ShareSession renders the session sessionID of provider in projectPath as
+// an HTML page. Diffs need a session checkpoint; without one the changed
+// files are only listed. With redactPaths the page doesn't name the project
+// path or the home directory.
+func (a *App) ShareSession(sessionID, projectPath, provider string, redactPaths bool) (*SessionShare, error) {
+	provider = normalizeSessionProvider(provider)
+	projectID := projectPath
+	if provider == "claude" {
+		projectID = claude.GetProjectHash(projectPath)
+	}
+	messages, err := a.loadProviderHistory(sessionID, projectID, provider)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load session history: %w", err)
+	}
+
+	doc := &sessionshare.Document{
+		SessionID:   sessionID,
+		Provider:    provider,
+		ProjectPath: projectPath,
+		GeneratedAt: time.Now(),
+		Messages:    messages,
+		Usage:       sessionshare.CollectUsage(messages, a.modelPricing().Cost),
+	}
+	if a.dbManager != nil {
+		files, err := a.GetSessionChangedFiles(sessionID)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			shared := sessionshare.File{Path: file.Path, Kind: file.Kind}
+			// Sessions without a checkpoint have no diffs to show
+			if hunks, err := a.GetSessionEditHunks(sessionID, file.Path); err == nil {
+				shared.Hunks = hunks
+			}
+			doc.Files = append(doc.Files, shared)
+		}
+	}
+
+	home, _ := os.UserHomeDir()
+	var page bytes.Buffer
+	if err := sessionshare.Write(&page, doc, sessionshare.Options{RedactPaths: redactPaths, Home: home}); err != nil {
+		return nil, fmt.Errorf("failed to render session: %w", err)
+	}
+	return &SessionShare{
+		FileName: fmt.Sprintf("%s-session-%s.html", provider, sessionID),
+		HTML:     page.String(),
+		Messages: len(messages),
+		Files:    len(doc.Files),
+	}, nil
+}
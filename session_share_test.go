@@ -0,0 +1,63 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"ropcode/internal/claude"
+	"ropcode/internal/config"
+	"ropcode/internal/database"
+	"ropcode/internal/session"
+)
+
+func TestShareSessionWritesTheTranscriptAndChangedFiles(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	project := filepath.Join(home, "shop")
+	claudeDir := filepath.Join(home, ".claude")
+	history := claude.GetSessionFilePath(claudeDir, claude.GetProjectHash(project), "s1")
+	if err := os.MkdirAll(filepath.Dir(history), 0755); err != nil {
+		t.Fatal(err)
+	}
+	lines := `{"type":"user","uuid":"u1","timestamp":"2026-10-18T10:00:00Z","message":{"role":"user","content":"Add a health check to ` + project + `/server.go"}}
+{"type":"assistant","uuid":"a1","timestamp":"2026-10-18T10:00:05Z","message":{"id":"m1","role":"assistant","model":"claude-sonnet-4-5","usage":{"input_tokens":10,"output_tokens":5},"content":[{"type":"text","text":"Done."}]}}
+`
+	if err := os.WriteFile(history, []byte(lines), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	db := openAppConfigTestDB(t)
+	if err := db.AddSessionFileChange(&database.SessionFileChange{
+		SessionID: "s1", ToolUseID: "t1", Path: filepath.Join(project, "server.go"), Kind: "modified",
+		Ranges: []database.LineRange{{Start: 3, End: 4}}, CreatedAt: time.Now(),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	app := &App{
+		dbManager:      db,
+		config:         &config.Config{RopcodeDir: filepath.Join(home, ".ropcode")},
+		sessionManager: session.NewHistoryManager(claudeDir),
+	}
+
+	share, err := app.ShareSession("s1", project, "claude", true)
+	if err != nil {
+		t.Fatalf("ShareSession: %v", err)
+	}
+	if share.Messages != 2 || share.Files != 1 || share.FileName != "claude-session-s1.html" {
+		t.Fatalf("share = %+v", share)
+	}
+	page := share.HTML
+	if !strings.Contains(page, "Done.") || !strings.Contains(page, "&lt;project&gt;/server.go") {
+		t.Fatalf("page = %s", page)
+	}
+	if strings.Contains(page, home) {
+		t.Fatal("redacted page names the home directory")
+	}
+
+	if _, err := app.ShareSession("missing", project, "claude", false); err == nil {
+		t.Fatal("shared a session without history")
+	}
+}
@@ -0,0 +1,114 @@
+// session_tail.go
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"ropcode/internal/claude"
+)
+
+// sessionFollowInterval is how often a followed session file is checked
+const sessionFollowInterval = 500 * time.Millisecond
+
+// sessionFollowSeq numbers session follows
+var sessionFollowSeq atomic.Int64
+
+// SessionHistoryTail is the end of a session's history
+type SessionHistoryTail struct {
+	Messages []claude.Message `json:"messages"`
+	// Offset is where the messages end in the session file; follow from it
+	Offset int64 `json:"offset"`
+}
+
+// SessionHistoryMessagesEvent is the payload of "session-history:messages"
+type SessionHistoryMessagesEvent struct {
+	FollowID  int64            `json:"follow_id"`
+	SessionID string           `json:"session_id"`
+	Messages  []claude.Message `json:"messages"`
+	Offset    int64            `json:"offset"`
+}
+
+// SessionHistoryStoppedEvent is the payload of "session-history:stopped"
+type SessionHistoryStoppedEvent struct {
+	FollowID  int64  `json:"follow_id"`
+	SessionID string `json:"session_id"`
+	Error     string `json:"error,omitempty"`
+}
+
+// TailSessionHistory returns the last n messages of a session, read
+// backwards from the end of its JSONL file
+func (a *App) TailSessionHistory(projectID, sessionID string, n int) (*SessionHistoryTail, error) {
+	if a.sessionManager == nil {
+		return nil, a.unavailable(subsystemSessionHistory)
+	}
+	if n < 0 {
+		return nil, fmt.Errorf("invalid message count: %d", n)
+	}
+	messages, offset, err := a.sessionManager.TailSessionHistory(projectID, sessionID, n)
+	if err != nil {
+		return nil, err
+	}
+	a.rewriteHistoryImages(sessionID, messages)
+	return &SessionHistoryTail{Messages: messages, Offset: offset}, nil
+}
+
+// FollowSessionHistory emits the messages appended to a session after
+// offset, as returned by TailSessionHistory, until
+// StopFollowingSessionHistory is called. It returns the ID carried by the
+// follow's events.
+func (a *App) FollowSessionHistory(projectID, sessionID string, offset int64) (int64, error) {
+	if a.sessionManager == nil {
+		return 0, a.unavailable(subsystemSessionHistory)
+	}
+	filePath, err := a.sessionManager.GetSessionFilePath(projectID, sessionID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to find session file: %w", err)
+	}
+
+	parent := a.ctx
+	if parent == nil {
+		parent = context.Background()
+	}
+	ctx, cancel := context.WithCancel(parent)
+	followID := sessionFollowSeq.Add(1)
+	a.sessionFollows.add(followID, cancel)
+
+	go func() {
+		err := claude.FollowMessages(ctx, filePath, offset, sessionFollowInterval, func(messages []claude.Message, offset int64) {
+			a.rewriteHistoryImages(sessionID, messages)
+			a.emitSessionHistory("session-history:messages", &SessionHistoryMessagesEvent{
+				FollowID:  followID,
+				SessionID: sessionID,
+				Messages:  messages,
+				Offset:    offset,
+			})
+		})
+		cancel()
+		a.sessionFollows.remove(followID)
+
+		stopped := &SessionHistoryStoppedEvent{FollowID: followID, SessionID: sessionID}
+		if err != nil && !errors.Is(err, context.Canceled) {
+			stopped.Error = err.Error()
+		}
+		a.emitSessionHistory("session-history:stopped", stopped)
+	}()
+	return followID, nil
+}
+
+// StopFollowingSessionHistory stops a follow started by FollowSessionHistory
+func (a *App) StopFollowingSessionHistory(followID int64) error {
+	if !a.sessionFollows.cancel(followID) {
+		return fmt.Errorf("session follow %d is not running", followID)
+	}
+	return nil
+}
+
+func (a *App) emitSessionHistory(name string, payload interface{}) {
+	if a.eventHub != nil {
+		a.eventHub.Emit(name, payload)
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"ropcode/internal/config"
+	"ropcode/internal/eventhub"
+	"ropcode/internal/session"
+)
+
+type sessionHistoryRecorder struct {
+	mu       sync.Mutex
+	messages []*SessionHistoryMessagesEvent
+	stopped  *SessionHistoryStoppedEvent
+}
+
+func (r *sessionHistoryRecorder) BroadcastEvent(eventType string, payload interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	switch eventType {
+	case "session-history:messages":
+		r.messages = append(r.messages, payload.(*SessionHistoryMessagesEvent))
+	case "session-history:stopped":
+		r.stopped = payload.(*SessionHistoryStoppedEvent)
+	}
+}
+
+func (r *sessionHistoryRecorder) wait(t *testing.T, ready func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		r.mu.Lock()
+		ok := ready()
+		r.mu.Unlock()
+		if ok {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("timed out waiting for session history events")
+}
+
+func TestTailAndFollowSessionHistory(t *testing.T) {
+	claudeDir := filepath.Join(t.TempDir(), ".claude")
+	projectDir := filepath.Join(claudeDir, "projects", "demo")
+	os.MkdirAll(projectDir, 0755)
+	path := filepath.Join(projectDir, "s1.jsonl")
+	os.WriteFile(path, []byte(`{"type":"user","uuid":"u1"}
+{"type":"assistant","uuid":"a1"}
+{"type":"user","uuid":"u2"}
+`), 0644)
+
+	hub := eventhub.New(nil)
+	recorder := &sessionHistoryRecorder{}
+	hub.SetBroadcaster(recorder)
+	app := &App{
+		config:         &config.Config{RopcodeDir: t.TempDir()},
+		eventHub:       hub,
+		sessionManager: session.NewHistoryManager(claudeDir),
+		sessionFollows: newRunCancelStore(),
+	}
+
+	tail, err := app.TailSessionHistory("demo", "s1", 2)
+	if err != nil || len(tail.Messages) != 2 || tail.Messages[0].UUID != "a1" {
+		t.Fatalf("TailSessionHistory() = %+v, %v", tail, err)
+	}
+	if _, err := app.FollowSessionHistory("demo", "missing", 0); err == nil {
+		t.Fatal("FollowSessionHistory() accepted an unknown session")
+	}
+
+	followID, err := app.FollowSessionHistory("demo", "s1", tail.Offset)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	file.WriteString(`{"type":"assistant","uuid":"a2"}` + "\n")
+	file.Close()
+	recorder.wait(t, func() bool { return len(recorder.messages) > 0 })
+	event := recorder.messages[0]
+	if event.FollowID != followID || event.SessionID != "s1" || len(event.Messages) != 1 || event.Messages[0].UUID != "a2" {
+		t.Fatalf("messages event = %+v", event)
+	}
+
+	if err := app.StopFollowingSessionHistory(followID); err != nil {
+		t.Fatal(err)
+	}
+	recorder.wait(t, func() bool { return recorder.stopped != nil })
+	if recorder.stopped.FollowID != followID || recorder.stopped.Error != "" {
+		t.Fatalf("stopped event = %+v", recorder.stopped)
+	}
+	if err := app.StopFollowingSessionHistory(followID); err == nil {
+		t.Error("StopFollowingSessionHistory() succeeded for a stopped follow")
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/exec"
@@ -20,6 +21,7 @@ import (
 	"ropcode/internal/claude"
 	"ropcode/internal/database"
 	"ropcode/internal/git"
+	"ropcode/internal/netproxy"
 )
 
 const (
@@ -161,7 +163,7 @@ func (a *App) loadGeneratedSessionTitles() {
 	}
 	raw, err := a.dbManager.GetSetting(generatedSessionTitlesSettingKey)
 	if err != nil {
-		log.Printf("[SessionTitle] failed to load generated session titles: %v", err)
+		slog.Error("failed to load generated session titles", "component", "SessionTitle", "error", err)
 		return
 	}
 	if strings.TrimSpace(raw) == "" {
@@ -169,7 +171,7 @@ func (a *App) loadGeneratedSessionTitles() {
 	}
 	var titles map[string]string
 	if err := json.Unmarshal([]byte(raw), &titles); err != nil {
-		log.Printf("[SessionTitle] failed to parse generated session titles: %v", err)
+		slog.Error("failed to parse generated session titles", "component", "SessionTitle", "error", err)
 		return
 	}
 	if a.sessionTitles == nil {
@@ -397,7 +399,7 @@ func (a *App) titleClaudeHomeDir() (string, error) {
 		}
 		dst := filepath.Join(titleClaudeDir, name)
 		if writeErr := os.WriteFile(dst, data, 0o600); writeErr != nil {
-			log.Printf("[SessionTitle] copy %s into title home failed: %v", name, writeErr)
+			slog.Warn("copy into title home failed", "component", "SessionTitle", "file", name, "error", writeErr)
 		}
 	}
 
@@ -456,6 +458,15 @@ func (a *App) runCLIForTitle(ctx context.Context, provider, projectPath, model,
 	}
 }
 
+// cliRunContext bounds a one-shot CLI run by ctx's deadline when it has one,
+// by sessionTitleCLITimeout otherwise
+func cliRunContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, sessionTitleCLITimeout)
+}
+
 func resolveCLIWorkingDir(projectPath string) string {
 	projectPath = strings.TrimSpace(projectPath)
 	if projectPath == "" {
@@ -472,7 +483,7 @@ func resolveCLIWorkingDir(projectPath string) string {
 // or a synthetic env-detected ID (e.g. "env:claude").
 func (a *App) loadTitleAPIConfig() (apiURL, apiKey, model, apiFormat string, err error) {
 	if a.dbManager == nil {
-		return "", "", "", "", fmt.Errorf("database not initialized")
+		return "", "", "", "", a.unavailable(subsystemDatabase)
 	}
 
 	model, _ = a.dbManager.GetSetting("session_title_model")
@@ -616,7 +627,7 @@ func readClaudeSettingsEnv() (baseURL, authToken string) {
 // and returns the list of available model IDs for the title generation dropdown.
 func (a *App) GetSessionTitleAvailableModels() ([]string, error) {
 	if a.dbManager == nil {
-		return nil, fmt.Errorf("database not initialized")
+		return nil, a.unavailable(subsystemDatabase)
 	}
 
 	providerApiID, _ := a.dbManager.GetSetting("session_title_provider_api_id")
@@ -807,7 +818,7 @@ func (a *App) callAnthropicAPI(ctx context.Context, apiURL, apiKey, model, syste
 
 func (a *App) runClaudeCLIForTitle(ctx context.Context, projectPath, model, prompt string) (string, error) {
 	if a.claudeManager == nil {
-		return "", fmt.Errorf("claude manager not initialized")
+		return "", a.unavailable(subsystemClaude)
 	}
 	binary := a.claudeManager.GetBinaryPath()
 	if strings.TrimSpace(binary) == "" {
@@ -827,11 +838,11 @@ func (a *App) runClaudeCLIForTitle(ctx context.Context, projectPath, model, prom
 		args = append(args, "--model", model)
 	}
 
-	runCtx, cancel := context.WithTimeout(ctx, sessionTitleCLITimeout)
+	runCtx, cancel := cliRunContext(ctx)
 	defer cancel()
 
 	cmd := exec.CommandContext(runCtx, binary, args...)
-	cmd.Env = os.Environ()
+	cmd.Env = append(os.Environ(), netproxy.Env()...)
 	cmd.Env = append(cmd.Env, "CLAUDE_CODE_DISABLE_NONESSENTIAL_TRAFFIC=true")
 
 	// Run from a bare directory with NO .claude/ or .git/ so the CLI doesn't
@@ -852,7 +863,7 @@ func (a *App) runClaudeCLIForTitle(ctx context.Context, projectPath, model, prom
 
 func (a *App) runCodexCLIForTitle(ctx context.Context, projectPath, model, prompt string) (string, error) {
 	if a.codexManager == nil {
-		return "", fmt.Errorf("codex manager not initialized")
+		return "", a.unavailable(subsystemCodex)
 	}
 	binary := a.codexManager.GetBinaryPath()
 	if strings.TrimSpace(binary) == "" {
@@ -876,12 +887,12 @@ func (a *App) runCodexCLIForTitle(ctx context.Context, projectPath, model, promp
 	}
 	args = append(args, "--", prompt)
 
-	runCtx, cancel := context.WithTimeout(ctx, sessionTitleCLITimeout)
+	runCtx, cancel := cliRunContext(ctx)
 	defer cancel()
 
 	cmd := exec.CommandContext(runCtx, binary, args...)
 	cmd.Dir = resolveCLIWorkingDir(projectPath)
-	cmd.Env = os.Environ()
+	cmd.Env = append(os.Environ(), netproxy.Env()...)
 
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
@@ -898,7 +909,7 @@ func (a *App) runCodexCLIForTitle(ctx context.Context, projectPath, model, promp
 
 func (a *App) runGeminiCLIForTitle(ctx context.Context, projectPath, model, prompt string) (string, error) {
 	if a.geminiManager == nil {
-		return "", fmt.Errorf("gemini manager not initialized")
+		return "", a.unavailable(subsystemGemini)
 	}
 	binary := a.geminiManager.GetBinaryPath()
 	if strings.TrimSpace(binary) == "" {
@@ -911,12 +922,12 @@ func (a *App) runGeminiCLIForTitle(ctx context.Context, projectPath, model, prom
 	}
 	args = append(args, "-p", prompt)
 
-	runCtx, cancel := context.WithTimeout(ctx, sessionTitleCLITimeout)
+	runCtx, cancel := cliRunContext(ctx)
 	defer cancel()
 
 	cmd := exec.CommandContext(runCtx, binary, args...)
 	cmd.Dir = resolveCLIWorkingDir(projectPath)
-	cmd.Env = os.Environ()
+	cmd.Env = append(os.Environ(), netproxy.Env()...)
 
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
@@ -978,7 +989,7 @@ func (a *App) GenerateSessionTitle(prompt string) (string, error) {
 
 	title, err := a.runDirectAPIForTitle(a.ctx, model, sessionTitleSystemPrompt, prompt)
 	if err != nil {
-		log.Printf("[SessionTitle] generation failed: %v", err)
+		slog.Warn("generation failed", "component", "SessionTitle", "error", err)
 		return fallbackSessionTitleFromPrompt(prompt), nil
 	}
 	title = cleanGeneratedSessionTitle(title)
@@ -1026,7 +1037,7 @@ func (a *App) GenerateSessionTitleForSession(provider, sessionID, projectID stri
 		),
 	)
 	if err != nil {
-		log.Printf("[SessionTitle] regen failed for %s/%s: %v", provider, sessionID, err)
+		slog.Warn("regen failed", "component", "SessionTitle", "provider", provider, "session_id", sessionID, "error", err)
 		return "", err
 	}
 	title = cleanGeneratedSessionTitle(title)
@@ -1034,7 +1045,7 @@ func (a *App) GenerateSessionTitleForSession(provider, sessionID, projectID stri
 		return "", fmt.Errorf("model returned a generic/empty title %q — try again when the session has more content", title)
 	}
 	if saveErr := a.SaveGeneratedSessionTitle(provider, sessionID, title); saveErr != nil {
-		log.Printf("[SessionTitle] persist failed for %s/%s: %v", provider, sessionID, saveErr)
+		slog.Error("persist failed", "component", "SessionTitle", "provider", provider, "session_id", sessionID, "error", saveErr)
 	}
 	return title, nil
 }
@@ -1082,7 +1093,7 @@ func (a *App) GenerateBranchName(projectPath string) (string, error) {
 	for _, s := range result.Sessions {
 		messages, err := a.LoadProviderSessionHistory(s.ID, s.ProjectID, s.Provider)
 		if err != nil {
-			log.Printf("[BranchName] load history %s/%s failed: %v", s.Provider, s.ID, err)
+			slog.Warn("load history failed", "component", "BranchName", "provider", s.Provider, "session_id", s.ID, "error", err)
 			continue
 		}
 		t := buildRecentTranscript(messages)
@@ -1147,7 +1158,7 @@ func (a *App) RenameGitBranch(projectPath, newBranch string) (string, error) {
 	}
 
 	if err := a.NotifyBranchRenamed(projectPath, newBranch); err != nil {
-		log.Printf("[BranchRename] notify rename failed for %s: %v", projectPath, err)
+		slog.Warn("notify rename failed", "component", "BranchRename", "project", projectPath, "error", err)
 	}
 	return newBranch, nil
 }
@@ -0,0 +1,490 @@
+// settings_sync.go
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"ropcode/internal/database"
+	"ropcode/internal/git"
+)
+
+const settingsSyncSettingKey = "settings_sync"
+
+const (
+	settingsSyncAgentsDir     = "agents"
+	settingsSyncCommandsDir   = "commands"
+	settingsSyncActionsFile   = "actions.json"
+	settingsSyncModelsFile    = "model_configs.json"
+	settingsSyncDefaultBranch = "main"
+)
+
+var settingsSyncFileNameUnsafe = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// SettingsSyncConfig configures the settings sync repository
+type SettingsSyncConfig struct {
+	RepoURL      string `json:"repo_url"`
+	Branch       string `json:"branch"`
+	LastSyncedAt int64  `json:"last_synced_at,omitempty"`
+	LastCommit   string `json:"last_commit,omitempty"`
+}
+
+// SettingsSyncResult reports the outcome of a sync
+type SettingsSyncResult struct {
+	Committed bool     `json:"committed"` // local changes were committed
+	Pushed    bool     `json:"pushed"`
+	Conflicts []string `json:"conflicts,omitempty"` // files that could not be merged; nothing was imported
+	Agents    int      `json:"agents"`
+	Actions   int      `json:"actions"`
+	Commands  int      `json:"commands"`
+	Models    int      `json:"models"`
+	Commit    string   `json:"commit,omitempty"`
+	SyncedAt  int64    `json:"synced_at"`
+}
+
+// settingsSyncAgent is the on-disk form of an agent in the sync repository
+type settingsSyncAgent struct {
+	Name          string `json:"name"`
+	Icon          string `json:"icon"`
+	SystemPrompt  string `json:"system_prompt"`
+	DefaultTask   string `json:"default_task,omitempty"`
+	Model         string `json:"model"`
+	Provider      string `json:"provider"`
+	ProviderApiID string `json:"provider_api_id,omitempty"`
+	Hooks         string `json:"hooks,omitempty"`
+}
+
+// settingsSyncModelConfig is the on-disk form of a model config. IDs and
+// timestamps differ per machine and are left out.
+type settingsSyncModelConfig struct {
+	ModelID        string                   `json:"model_id"`
+	ProviderID     string                   `json:"provider_id"`
+	DisplayName    string                   `json:"display_name"`
+	Description    string                   `json:"description"`
+	IsBuiltin      bool                     `json:"is_builtin"`
+	IsEnabled      bool                     `json:"is_enabled"`
+	IsDefault      bool                     `json:"is_default"`
+	ThinkingLevels []database.ThinkingLevel `json:"thinking_levels"`
+}
+
+// settingsSyncAgentFileName returns the file name used for an agent.
+func settingsSyncAgentFileName(name string) string {
+	slug := strings.Trim(settingsSyncFileNameUnsafe.ReplaceAllString(strings.TrimSpace(name), "-"), "-.")
+	if slug == "" {
+		slug = "agent"
+	}
+	return slug + ".json"
+}
+
+// loadSettingsSyncConfig returns the stored sync configuration, or nil when
+// settings sync is not configured.
+func (a *App) loadSettingsSyncConfig() (*SettingsSyncConfig, error) {
+	raw, err := a.dbManager.GetSetting(settingsSyncSettingKey)
+	if err != nil || raw == "" {
+		return nil, err
+	}
+	var config SettingsSyncConfig
+	if err := json.Unmarshal([]byte(raw), &config); err != nil {
+		return nil, fmt.Errorf("invalid %s setting: %w", settingsSyncSettingKey, err)
+	}
+	return &config, nil
+}
+
+func (a *App) saveSettingsSyncConfig(config *SettingsSyncConfig) error {
+	data, err := json.Marshal(config)
+	if err != nil {
+		return fmt.Errorf("failed to encode settings sync config: %w", err)
+	}
+	if err := a.dbManager.SaveSetting(settingsSyncSettingKey, string(data)); err != nil {
+		return fmt.Errorf("failed to save settings sync config: %w", err)
+	}
+	return nil
+}
+
+// settingsSyncDir returns where the sync repository is cloned
+func settingsSyncDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, ".ropcode", "settings-sync"), nil
+}
+
+func writeSettingsSyncJSON(path string, value interface{}) error {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// userCommandsDir returns the user-level slash commands directory
+func userCommandsDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, ".claude", "commands"), nil
+}
+
+// copyMarkdownTree copies every .md file below src to the same relative path
+// below dst and returns how many files were copied.
+func copyMarkdownTree(src, dst string) (int, error) {
+	count := 0
+	err := filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == src {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if d.IsDir() || !strings.HasSuffix(d.Name(), ".md") {
+			return nil
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(target, data, 0644); err != nil {
+			return err
+		}
+		count++
+		return nil
+	})
+	return count, err
+}
+
+// exportSettingsSync writes the local configuration into the sync clone.
+func (a *App) exportSettingsSync(dir string) error {
+	agents, err := a.dbManager.ListAgents()
+	if err != nil {
+		return fmt.Errorf("failed to read agents: %w", err)
+	}
+	for _, agent := range agents {
+		file := filepath.Join(dir, settingsSyncAgentsDir, settingsSyncAgentFileName(agent.Name))
+		if err := writeSettingsSyncJSON(file, settingsSyncAgent{
+			Name:          agent.Name,
+			Icon:          agent.Icon,
+			SystemPrompt:  agent.SystemPrompt,
+			DefaultTask:   agent.DefaultTask,
+			Model:         agent.Model,
+			Provider:      agent.Provider,
+			ProviderApiID: agent.ProviderApiID,
+			Hooks:         agent.Hooks,
+		}); err != nil {
+			return fmt.Errorf("failed to export agent %s: %w", agent.Name, err)
+		}
+	}
+
+	actions, err := a.GetGlobalActions()
+	if err != nil {
+		return err
+	}
+	if len(actions) > 0 {
+		for i := range actions {
+			actions[i].Scope = ""
+		}
+		if err := writeSettingsSyncJSON(filepath.Join(dir, settingsSyncActionsFile), actions); err != nil {
+			return fmt.Errorf("failed to export actions: %w", err)
+		}
+	}
+
+	commandsDir, err := userCommandsDir()
+	if err != nil {
+		return err
+	}
+	if _, err := copyMarkdownTree(commandsDir, filepath.Join(dir, settingsSyncCommandsDir)); err != nil {
+		return fmt.Errorf("failed to export slash commands: %w", err)
+	}
+
+	configs, err := a.dbManager.GetAllModelConfigs()
+	if err != nil {
+		return fmt.Errorf("failed to read model configs: %w", err)
+	}
+	models := make([]settingsSyncModelConfig, 0, len(configs))
+	for _, config := range configs {
+		models = append(models, settingsSyncModelConfig{
+			ModelID:        config.ModelID,
+			ProviderID:     config.ProviderID,
+			DisplayName:    config.DisplayName,
+			Description:    config.Description,
+			IsBuiltin:      config.IsBuiltin,
+			IsEnabled:      config.IsEnabled,
+			IsDefault:      config.IsDefault,
+			ThinkingLevels: config.ThinkingLevels,
+		})
+	}
+	sort.Slice(models, func(i, j int) bool {
+		if models[i].ProviderID != models[j].ProviderID {
+			return models[i].ProviderID < models[j].ProviderID
+		}
+		return models[i].ModelID < models[j].ModelID
+	})
+	if err := writeSettingsSyncJSON(filepath.Join(dir, settingsSyncModelsFile), models); err != nil {
+		return fmt.Errorf("failed to export model configs: %w", err)
+	}
+	return nil
+}
+
+// importSettingsSync applies the files in the sync clone to the local
+// configuration and records the counts in result. Sync is additive: nothing
+// deleted on one machine is removed from the repository or other machines.
+func (a *App) importSettingsSync(dir string, result *SettingsSyncResult) error {
+	existing, err := a.dbManager.ListAgents()
+	if err != nil {
+		return err
+	}
+	agentsByName := make(map[string]*database.Agent, len(existing))
+	for _, agent := range existing {
+		agentsByName[agent.Name] = agent
+	}
+
+	agentFiles, _ := filepath.Glob(filepath.Join(dir, settingsSyncAgentsDir, "*.json"))
+	sort.Strings(agentFiles)
+	for _, file := range agentFiles {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		var synced settingsSyncAgent
+		if err := json.Unmarshal(data, &synced); err != nil {
+			return fmt.Errorf("invalid agent file %s: %w", filepath.Base(file), err)
+		}
+		if synced.Name == "" {
+			continue
+		}
+		agent := agentsByName[synced.Name]
+		if agent == nil {
+			agent = &database.Agent{}
+		}
+		agent.Name = synced.Name
+		agent.Icon = synced.Icon
+		agent.SystemPrompt = synced.SystemPrompt
+		agent.DefaultTask = synced.DefaultTask
+		agent.Model = synced.Model
+		agent.Provider = synced.Provider
+		agent.ProviderApiID = synced.ProviderApiID
+		agent.Hooks = synced.Hooks
+		if agent.ID != 0 {
+			err = a.dbManager.UpdateAgent(agent)
+		} else {
+			_, err = a.dbManager.CreateAgent(agent)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to import agent %s: %w", synced.Name, err)
+		}
+		result.Agents++
+	}
+
+	if data, err := os.ReadFile(filepath.Join(dir, settingsSyncActionsFile)); err == nil {
+		var actions []Action
+		if err := json.Unmarshal(data, &actions); err != nil {
+			return fmt.Errorf("invalid %s: %w", settingsSyncActionsFile, err)
+		}
+		if err := a.UpdateGlobalActions(actions); err != nil {
+			return fmt.Errorf("failed to import actions: %w", err)
+		}
+		result.Actions = len(actions)
+	}
+
+	commandsDir, err := userCommandsDir()
+	if err != nil {
+		return err
+	}
+	if result.Commands, err = copyMarkdownTree(filepath.Join(dir, settingsSyncCommandsDir), commandsDir); err != nil {
+		return fmt.Errorf("failed to import slash commands: %w", err)
+	}
+
+	if data, err := os.ReadFile(filepath.Join(dir, settingsSyncModelsFile)); err == nil {
+		var models []settingsSyncModelConfig
+		if err := json.Unmarshal(data, &models); err != nil {
+			return fmt.Errorf("invalid %s: %w", settingsSyncModelsFile, err)
+		}
+		configs, err := a.dbManager.GetAllModelConfigs()
+		if err != nil {
+			return err
+		}
+		byModel := make(map[string]*database.ModelConfig, len(configs))
+		for _, config := range configs {
+			byModel[config.ProviderID+"/"+config.ModelID] = config
+		}
+		for _, synced := range models {
+			if synced.ModelID == "" {
+				continue
+			}
+			config := byModel[synced.ProviderID+"/"+synced.ModelID]
+			if config == nil {
+				config = &database.ModelConfig{ID: uuid.New().String()}
+			}
+			config.ModelID = synced.ModelID
+			config.ProviderID = synced.ProviderID
+			config.DisplayName = synced.DisplayName
+			config.Description = synced.Description
+			config.IsBuiltin = synced.IsBuiltin
+			config.IsEnabled = synced.IsEnabled
+			config.IsDefault = synced.IsDefault
+			config.ThinkingLevels = synced.ThinkingLevels
+			if config.IsDefault {
+				if err := a.dbManager.ClearDefaultModelConfig(config.ProviderID); err != nil {
+					return err
+				}
+			}
+			if err := a.dbManager.SaveModelConfig(config); err != nil {
+				return fmt.Errorf("failed to import model config %s: %w", synced.ModelID, err)
+			}
+			result.Models++
+		}
+	}
+	return nil
+}
+
+// runSettingsSync commits the local configuration, merges the remote branch,
+// imports the merged files and optionally pushes.
+func (a *App) runSettingsSync(push bool, strategy string) (*SettingsSyncResult, error) {
+	if a.dbManager == nil {
+		return nil, a.unavailable(subsystemDatabase)
+	}
+	config, err := a.loadSettingsSyncConfig()
+	if err != nil {
+		return nil, err
+	}
+	if config == nil || config.RepoURL == "" {
+		return nil, fmt.Errorf("settings sync is not configured")
+	}
+	dir, err := settingsSyncDir()
+	if err != nil {
+		return nil, err
+	}
+
+	a.settingsSyncMu.Lock()
+	defer a.settingsSyncMu.Unlock()
+
+	repo := git.NewSyncRepo(dir, config.RepoURL, config.Branch)
+	cloned, err := repo.Ensure()
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare sync repository: %w", err)
+	}
+
+	result := &SettingsSyncResult{}
+	if cloned {
+		// Take over the shared settings first so the first export from this
+		// machine adds to them instead of conflicting with them.
+		if err := a.importSettingsSync(dir, &SettingsSyncResult{}); err != nil {
+			return nil, err
+		}
+	}
+	if err := a.exportSettingsSync(dir); err != nil {
+		return nil, err
+	}
+	hostname, _ := os.Hostname()
+	if result.Committed, err = repo.CommitAll(fmt.Sprintf("Update settings from %s", hostname)); err != nil {
+		return nil, fmt.Errorf("failed to commit settings: %w", err)
+	}
+
+	conflicts, err := repo.Merge(git.MergeStrategy(strategy))
+	if err != nil {
+		return nil, fmt.Errorf("failed to merge remote settings: %w", err)
+	}
+	if len(conflicts) > 0 {
+		result.Conflicts = conflicts
+		return result, nil
+	}
+
+	if err := a.importSettingsSync(dir, result); err != nil {
+		return nil, err
+	}
+	if push && repo.Head() != "" {
+		if err := repo.Push(); err != nil {
+			return nil, fmt.Errorf("failed to push settings: %w", err)
+		}
+		result.Pushed = true
+	}
+
+	result.Commit = repo.Head()
+	result.SyncedAt = time.Now().Unix()
+	config.LastSyncedAt = result.SyncedAt
+	config.LastCommit = result.Commit
+	if err := a.saveSettingsSyncConfig(config); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// GetSettingsSyncConfig returns the settings sync configuration, or nil when
+// settings sync is not configured
+func (a *App) GetSettingsSyncConfig() (*SettingsSyncConfig, error) {
+	if a.dbManager == nil {
+		return nil, a.unavailable(subsystemDatabase)
+	}
+	return a.loadSettingsSyncConfig()
+}
+
+// SetSettingsSyncConfig sets the repository used for settings sync. An empty
+// repo URL disables settings sync.
+func (a *App) SetSettingsSyncConfig(repoURL, branch string) (*SettingsSyncConfig, error) {
+	if a.dbManager == nil {
+		return nil, a.unavailable(subsystemDatabase)
+	}
+	repoURL = strings.TrimSpace(repoURL)
+	branch = strings.TrimSpace(branch)
+	if branch == "" {
+		branch = settingsSyncDefaultBranch
+	}
+	if strings.ContainsAny(branch, " ~^:?*[\\") {
+		return nil, fmt.Errorf("invalid branch name: %s", branch)
+	}
+
+	previous, err := a.loadSettingsSyncConfig()
+	if err != nil {
+		previous = nil
+	}
+	config := &SettingsSyncConfig{RepoURL: repoURL, Branch: branch}
+	if previous != nil && previous.RepoURL == repoURL && previous.Branch == branch {
+		config.LastSyncedAt = previous.LastSyncedAt
+		config.LastCommit = previous.LastCommit
+	} else if dir, err := settingsSyncDir(); err == nil {
+		// A different repository starts from a fresh clone.
+		a.settingsSyncMu.Lock()
+		err = os.RemoveAll(dir)
+		a.settingsSyncMu.Unlock()
+		if err != nil {
+			return nil, fmt.Errorf("failed to reset sync repository: %w", err)
+		}
+	}
+	if err := a.saveSettingsSyncConfig(config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// PullSettingsSync merges the settings from the sync repository into the
+// local configuration. strategy is "" to report conflicts, or "local" /
+// "remote" to resolve them in favour of one side.
+func (a *App) PullSettingsSync(strategy string) (*SettingsSyncResult, error) {
+	return a.runSettingsSync(false, strategy)
+}
+
+// PushSettingsSync merges the settings from the sync repository and pushes
+// the local configuration to it. strategy works as in PullSettingsSync.
+func (a *App) PushSettingsSync(strategy string) (*SettingsSyncResult, error) {
+	return a.runSettingsSync(true, strategy)
+}
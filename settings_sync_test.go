@@ -0,0 +1,90 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"ropcode/internal/database"
+)
+
+func TestSettingsSyncAgentFileName(t *testing.T) {
+	cases := map[string]string{
+		"Code Reviewer": "Code-Reviewer.json",
+		"  a/b\\c  ":    "a-b-c.json",
+		"...":           "agent.json",
+	}
+	for input, want := range cases {
+		if got := settingsSyncAgentFileName(input); got != want {
+			t.Errorf("settingsSyncAgentFileName(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestSettingsSyncPushThenPullOnAnotherMachine(t *testing.T) {
+	remote := filepath.Join(t.TempDir(), "settings.git")
+	if out, err := exec.Command("git", "init", "--bare", remote).CombinedOutput(); err != nil {
+		t.Fatalf("git init --bare: %v: %s", err, out)
+	}
+
+	// First machine: one agent and one user slash command.
+	firstHome := t.TempDir()
+	t.Setenv("HOME", firstHome)
+	firstDB := openAppConfigTestDB(t)
+	if _, err := firstDB.CreateAgent(&database.Agent{Name: "Reviewer", SystemPrompt: "Review the diff", Model: "sonnet"}); err != nil {
+		t.Fatalf("CreateAgent() error = %v", err)
+	}
+	commandPath := filepath.Join(firstHome, ".claude", "commands", "team", "deploy.md")
+	if err := os.MkdirAll(filepath.Dir(commandPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(commandPath, []byte("Deploy $ARGUMENTS\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	first := &App{dbManager: firstDB}
+	if _, err := first.SetSettingsSyncConfig(remote, ""); err != nil {
+		t.Fatalf("SetSettingsSyncConfig() error = %v", err)
+	}
+	pushed, err := first.PushSettingsSync("")
+	if err != nil {
+		t.Fatalf("PushSettingsSync() error = %v", err)
+	}
+	if !pushed.Committed || !pushed.Pushed || pushed.Commit == "" {
+		t.Fatalf("push result = %#v, want committed and pushed", pushed)
+	}
+
+	// Second machine pulls the shared configuration.
+	secondHome := t.TempDir()
+	t.Setenv("HOME", secondHome)
+	secondDB := openAppConfigTestDB(t)
+	second := &App{dbManager: secondDB}
+	if _, err := second.SetSettingsSyncConfig(remote, "main"); err != nil {
+		t.Fatalf("SetSettingsSyncConfig() error = %v", err)
+	}
+	pulled, err := second.PullSettingsSync("")
+	if err != nil {
+		t.Fatalf("PullSettingsSync() error = %v", err)
+	}
+	if len(pulled.Conflicts) != 0 || pulled.Agents != 1 || pulled.Commands != 1 {
+		t.Fatalf("pull result = %#v, want one agent and one command", pulled)
+	}
+
+	agents, err := secondDB.ListAgents()
+	if err != nil {
+		t.Fatalf("ListAgents() error = %v", err)
+	}
+	if len(agents) != 1 || agents[0].Name != "Reviewer" || agents[0].SystemPrompt != "Review the diff" {
+		t.Fatalf("agents = %#v, want synced agent", agents)
+	}
+	data, err := os.ReadFile(filepath.Join(secondHome, ".claude", "commands", "team", "deploy.md"))
+	if err != nil || string(data) != "Deploy $ARGUMENTS\n" {
+		t.Fatalf("synced command = %q, %v", data, err)
+	}
+
+	config, err := second.GetSettingsSyncConfig()
+	if err != nil || config.LastCommit != pulled.Commit || config.LastSyncedAt == 0 {
+		t.Fatalf("sync config = %#v, %v; want last sync recorded", config, err)
+	}
+}
@@ -0,0 +1,85 @@
+// shell_preference.go
+package main
+
+import (
+	"runtime"
+
+	"ropcode/internal/command"
+	"ropcode/internal/loginenv"
+	"ropcode/internal/pty"
+	"ropcode/internal/settings"
+	"ropcode/internal/wsl"
+)
+
+// shellPreferenceSettingKey holds a shell path and login flag per OS, so a
+// config bundle shared between a Mac and a Windows machine suits both
+const shellPreferenceSettingKey = "shell_preference"
+
+// ShellInfo describes the shells of this machine and the one in use
+type ShellInfo struct {
+	OS string `json:"os"`
+	// Detected is the shell used while the preference has no path
+	Detected  string   `json:"detected"`
+	Available []string `json:"available"`
+	// Shell and Login are what commands and terminals run in
+	Shell string `json:"shell"`
+	Login bool   `json:"login"`
+}
+
+// shellPreference returns the shell preferred on this OS, with the
+// detected shell filled in for an empty path
+func (a *App) shellPreference() command.Preference {
+	var preference command.Preference
+	if a.dbManager != nil {
+		if raw, err := a.dbManager.GetSetting(shellPreferenceSettingKey); err == nil {
+			preferences, _ := settings.Lookup(shellPreferenceSettingKey).Decode(raw).(map[string]interface{})
+			entry, _ := preferences[runtime.GOOS].(map[string]interface{})
+			preference.Path, _ = entry["path"].(string)
+			preference.Login, _ = entry["login"].(bool)
+		}
+	}
+	if preference.Path == "" {
+		preference.Path = pty.DefaultShell()
+	}
+	return preference
+}
+
+// GetShellInfo returns the detected and installed shells and the one
+// commands and terminals run in
+func (a *App) GetShellInfo() *ShellInfo {
+	preference := a.shellPreference()
+	return &ShellInfo{
+		OS:        runtime.GOOS,
+		Detected:  pty.DefaultShell(),
+		Available: pty.AvailableShells(),
+		Shell:     preference.Path,
+		Login:     preference.Login,
+	}
+}
+
+// createPreferredPtySession creates a PTY session in shell, or the
+// preferred shell when shell is empty, started as a login shell when the
+// preference asks for one
+func (a *App) createPreferredPtySession(sessionID, cwd string, rows, cols int, shell string) (*pty.Session, error) {
+	preference := a.shellPreference()
+	if shell == "" {
+		shell = preference.Path
+	}
+	env := a.projectEnvList(cwd)
+	if distro := a.wslDistro(); distro != "" && shell == preference.Path {
+		// WSL mode opens the distro's login shell unless another shell
+		// was asked for
+		return a.ptyManager.CreateCommandSession(sessionID, cwd, rows, cols, wsl.Program, wsl.ShellArgs(distro, cwd), wsl.ShareEnv(env, env))
+	}
+	if preference.Login {
+		return a.ptyManager.CreateCommandSession(sessionID, cwd, rows, cols, shell, pty.LoginShellArgs(shell), env)
+	}
+	return a.ptyManager.CreateSessionWithEnv(sessionID, cwd, rows, cols, shell, env)
+}
+
+// ReloadEnvironment captures the preferred shell's login environment again,
+// for after the user changed their profile; sessions, terminals and
+// commands started afterwards get it
+func (a *App) ReloadEnvironment() *loginenv.Snapshot {
+	return loginenv.Reload(a.shellPreference().Path)
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"ropcode/internal/database"
+	"ropcode/internal/pty"
+)
+
+func TestShellPreferenceFollowsSettingForThisOS(t *testing.T) {
+	db, err := database.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	app := &App{dbManager: db}
+
+	if preference := app.shellPreference(); preference.Path != pty.DefaultShell() || preference.Login {
+		t.Fatalf("default preference = %+v, want the detected shell", preference)
+	}
+
+	if _, err := app.UpdateSettings(map[string]interface{}{
+		shellPreferenceSettingKey: map[string]interface{}{
+			runtime.GOOS: map[string]interface{}{"path": "/opt/shells/fish", "login": true},
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	info := app.GetShellInfo()
+	if info.Shell != "/opt/shells/fish" || !info.Login || info.OS != runtime.GOOS || len(info.Available) == 0 {
+		t.Fatalf("GetShellInfo() = %+v", info)
+	}
+}
+
+func TestCreatePtySessionUsesPreferredLoginShell(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	db, err := database.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	app := &App{dbManager: db, ptyManager: pty.NewManager(context.Background(), nil)}
+	defer app.ptyManager.CloseAll()
+
+	if _, err := app.UpdateSettings(map[string]interface{}{
+		shellPreferenceSettingKey: map[string]interface{}{
+			runtime.GOOS: map[string]interface{}{"path": "/bin/sh", "login": true},
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	info, err := app.CreatePtySession("pty-1", t.TempDir(), 24, 80, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Shell != "/bin/sh" {
+		t.Fatalf("CreatePtySession() shell = %q", info.Shell)
+	}
+	session, ok := app.ptyManager.GetSession("pty-1")
+	if !ok || len(session.Args) == 0 || session.Args[0] != "-l" {
+		t.Fatalf("session = %+v, want a login shell", session)
+	}
+}
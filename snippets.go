@@ -0,0 +1,155 @@
+// snippets.go
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"ropcode/internal/database"
+	"ropcode/internal/fuzzy"
+)
+
+// defaultSnippetSearchLimit is used when SearchSnippets is given no limit
+const defaultSnippetSearchLimit = 20
+
+// ListSnippets returns all prompt snippets, most used first
+func (a *App) ListSnippets() ([]*database.Snippet, error) {
+	if a.dbManager == nil {
+		return nil, a.unavailable(subsystemDatabase)
+	}
+	return a.dbManager.ListSnippets()
+}
+
+// SaveSnippet creates the snippet, or updates it when it has an ID
+func (a *App) SaveSnippet(snippet database.Snippet) (*database.Snippet, error) {
+	if a.dbManager == nil {
+		return nil, a.unavailable(subsystemDatabase)
+	}
+	snippet.Title = strings.TrimSpace(snippet.Title)
+	if snippet.Title == "" {
+		return nil, fmt.Errorf("snippet title is required")
+	}
+	if strings.TrimSpace(snippet.Body) == "" {
+		return nil, fmt.Errorf("snippet body is required")
+	}
+	snippet.Tags = cleanSnippetTags(snippet.Tags)
+
+	if snippet.ID == 0 {
+		if _, err := a.dbManager.CreateSnippet(&snippet); err != nil {
+			return nil, err
+		}
+	} else if err := a.dbManager.UpdateSnippet(&snippet); err != nil {
+		return nil, err
+	}
+	return a.dbManager.GetSnippet(snippet.ID)
+}
+
+// DeleteSnippet deletes a prompt snippet
+func (a *App) DeleteSnippet(id int64) error {
+	if a.dbManager == nil {
+		return a.unavailable(subsystemDatabase)
+	}
+	return a.dbManager.DeleteSnippet(id)
+}
+
+// SearchSnippets returns up to limit snippets fuzzy-matching query on title,
+// tags and body, best first, favouring the title and the most used snippets.
+// An empty query returns the most used snippets.
+func (a *App) SearchSnippets(query string, limit int) ([]*database.Snippet, error) {
+	if a.dbManager == nil {
+		return nil, a.unavailable(subsystemDatabase)
+	}
+	snippets, err := a.dbManager.ListSnippets()
+	if err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = defaultSnippetSearchLimit
+	}
+	results := rankSnippets(snippets, strings.TrimSpace(query))
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+// UseSnippet counts an insertion of the snippet and returns it
+func (a *App) UseSnippet(id int64) (*database.Snippet, error) {
+	if a.dbManager == nil {
+		return nil, a.unavailable(subsystemDatabase)
+	}
+	if err := a.dbManager.RecordSnippetUse(id); err != nil {
+		return nil, err
+	}
+	return a.dbManager.GetSnippet(id)
+}
+
+// rankSnippets orders the snippets matching query by score, then usage.
+// snippets must already be ordered by usage.
+func rankSnippets(snippets []*database.Snippet, query string) []*database.Snippet {
+	if query == "" {
+		return snippets
+	}
+	type ranked struct {
+		snippet *database.Snippet
+		score   int
+	}
+	var matches []ranked
+	for _, snippet := range snippets {
+		if score, ok := snippetScore(snippet, query); ok {
+			matches = append(matches, ranked{snippet, score})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].score > matches[j].score
+	})
+	results := make([]*database.Snippet, len(matches))
+	for i, m := range matches {
+		results[i] = m.snippet
+	}
+	return results
+}
+
+// snippetScore is the best score of query against the snippet's title,
+// which counts double, its tags and its body. Every word of the query has
+// to match somewhere.
+func snippetScore(snippet *database.Snippet, query string) (int, bool) {
+	total := 0
+	for _, word := range strings.Fields(query) {
+		best, found := 0, false
+		if score, ok := fuzzy.Score(word, snippet.Title); ok {
+			best, found = score*2, true
+		}
+		for _, tag := range snippet.Tags {
+			if score, ok := fuzzy.Score(word, tag); ok && (!found || score > best) {
+				best, found = score, true
+			}
+		}
+		if !found {
+			// The body is only searched for the word as typed, since a
+			// scattered match in a long body means little
+			if !strings.Contains(strings.ToLower(snippet.Body), strings.ToLower(word)) {
+				return 0, false
+			}
+		}
+		total += best
+	}
+	return total, true
+}
+
+// cleanSnippetTags trims tags and drops empty and duplicate ones
+func cleanSnippetTags(tags []string) []string {
+	cleaned := make([]string, 0, len(tags))
+	seen := make(map[string]bool)
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		key := strings.ToLower(tag)
+		if tag == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		cleaned = append(cleaned, tag)
+	}
+	return cleaned
+}
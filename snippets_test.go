@@ -0,0 +1,75 @@
+package main
+
+import (
+	"testing"
+
+	"ropcode/internal/database"
+)
+
+func TestSnippetsSearch(t *testing.T) {
+	app := &App{dbManager: openAppConfigTestDB(t)}
+
+	if _, err := app.SaveSnippet(database.Snippet{Title: " ", Body: "text"}); err == nil {
+		t.Fatal("expected a snippet without a title to be rejected")
+	}
+	review, err := app.SaveSnippet(database.Snippet{
+		Title: "Code review checklist",
+		Body:  "Check error handling, tests and naming.",
+		Tags:  []string{"review", " Review ", ""},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(review.Tags) != 1 || review.Tags[0] != "review" {
+		t.Fatalf("saved tags = %q, want [review]", review.Tags)
+	}
+	bug, err := app.SaveSnippet(database.Snippet{
+		Title: "Bug report",
+		Body:  "Steps to reproduce, expected and actual behaviour.",
+		Tags:  []string{"template"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := app.UseSnippet(bug.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		query string
+		want  []int64
+	}{
+		{"", []int64{bug.ID, review.ID}},
+		{"cr", []int64{review.ID}},
+		{"re", []int64{bug.ID, review.ID}}, // equal scores, bug is used more
+		{"bug rep", []int64{bug.ID}},
+		{"templ", []int64{bug.ID}},
+		{"reproduce", []int64{bug.ID}},
+		{"naming review", []int64{review.ID}},
+		{"xyz", nil},
+	}
+	for _, tt := range tests {
+		got, err := app.SearchSnippets(tt.query, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var ids []int64
+		for _, s := range got {
+			ids = append(ids, s.ID)
+		}
+		if len(ids) != len(tt.want) {
+			t.Errorf("SearchSnippets(%q) = %v, want %v", tt.query, ids, tt.want)
+			continue
+		}
+		for i := range ids {
+			if ids[i] != tt.want[i] {
+				t.Errorf("SearchSnippets(%q) = %v, want %v", tt.query, ids, tt.want)
+				break
+			}
+		}
+	}
+
+	if got, _ := app.SearchSnippets("", 1); len(got) != 1 || got[0].UsageCount != 1 {
+		t.Fatalf("SearchSnippets with limit 1 = %+v", got)
+	}
+}
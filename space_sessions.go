@@ -2,7 +2,7 @@ package main
 
 import (
 	"fmt"
-	"log"
+	"log/slog"
 	"sort"
 	"strings"
 	"time"
@@ -67,7 +67,7 @@ func listSpaceSessionsFromScanners(projectPath string, limit int, scanners []spa
 		providerResult, err := scanner.scan(projectPath, limit)
 		if err != nil {
 			failures++
-			log.Printf("[ListSpaceSessions] Failed to list %s sessions for %s: %v", scanner.provider, projectPath, err)
+			slog.Warn("Failed to list sessions", "component", "ListSpaceSessions", "provider", scanner.provider, "project", projectPath, "error", err)
 			continue
 		}
 		sessions = append(sessions, providerResult.sessions...)
@@ -0,0 +1,36 @@
+// ssh_files.go
+package main
+
+import (
+	"ropcode/internal/ssh"
+)
+
+// ListRemoteDirectory lists a directory on the host of a saved SSH
+// connection, directories first. Relative paths and ~/ resolve against the
+// remote home directory.
+func (a *App) ListRemoteDirectory(connectionName, path string) ([]ssh.RemoteEntry, error) {
+	if a.sshManager == nil {
+		return nil, a.unavailable(subsystemSSH)
+	}
+	return a.sshManager.ListRemoteDirectory(connectionName, path)
+}
+
+// ReadRemoteFile reads a text file on the host of a saved SSH connection
+func (a *App) ReadRemoteFile(connectionName, path string) (string, error) {
+	if a.sshManager == nil {
+		return "", a.unavailable(subsystemSSH)
+	}
+	data, err := a.sshManager.ReadRemoteFile(connectionName, path)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// WriteRemoteFile saves a file on the host of a saved SSH connection
+func (a *App) WriteRemoteFile(connectionName, path, content string) error {
+	if a.sshManager == nil {
+		return a.unavailable(subsystemSSH)
+	}
+	return a.sshManager.WriteRemoteFile(connectionName, path, []byte(content))
+}
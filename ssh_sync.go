@@ -0,0 +1,99 @@
+// ssh_sync.go
+package main
+
+import (
+	"ropcode/internal/eventhub"
+	"ropcode/internal/ssh"
+)
+
+// GetSyncProgress returns the progress of the latest SSH sync of a path
+func (a *App) GetSyncProgress(localPath string) *ssh.SyncProgress {
+	if a.sshManager == nil {
+		return &ssh.SyncProgress{LocalPath: localPath, Stage: ssh.SyncStageIdle}
+	}
+	return a.sshManager.GetSyncProgress(localPath)
+}
+
+// ListSshSyncRules returns the include/exclude rules saved for every sync pair
+func (a *App) ListSshSyncRules() ([]ssh.SyncRules, error) {
+	if a.sshManager == nil {
+		return []ssh.SyncRules{}, a.unavailable(subsystemSSH)
+	}
+	return a.sshManager.ListSyncRules()
+}
+
+// GetSshSyncRules returns the include/exclude rules of a sync pair. Pairs
+// without saved rules skip .git/ and node_modules/ and respect .gitignore.
+func (a *App) GetSshSyncRules(localPath, remotePath, connectionName string) (*ssh.SyncRules, error) {
+	if a.sshManager == nil {
+		return nil, a.unavailable(subsystemSSH)
+	}
+	return a.sshManager.GetSyncRules(localPath, remotePath, connectionName)
+}
+
+// SetSshSyncRules saves the include/exclude rules of a sync pair
+func (a *App) SetSshSyncRules(rules ssh.SyncRules) error {
+	if a.sshManager == nil {
+		return a.unavailable(subsystemSSH)
+	}
+	return a.sshManager.SetSyncRules(rules)
+}
+
+// ResetSshSyncRules drops the saved rules of a sync pair, restoring the defaults
+func (a *App) ResetSshSyncRules(localPath, remotePath, connectionName string) error {
+	if a.sshManager == nil {
+		return a.unavailable(subsystemSSH)
+	}
+	return a.sshManager.DeleteSyncRules(localPath, remotePath, connectionName)
+}
+
+// StartTwoWaySync starts automatic two-way sync for a path
+func (a *App) StartTwoWaySync(localPath, remotePath, connectionName string) error {
+	if a.sshManager == nil {
+		return a.unavailable(subsystemSSH)
+	}
+	return a.sshManager.StartTwoWaySync(localPath, remotePath, connectionName)
+}
+
+// SyncTwoWay runs a single two-way sync of a path. A file changed on both
+// sides since the last sync is left alone and reported as a conflict.
+func (a *App) SyncTwoWay(localPath, remotePath, connectionName string) error {
+	if a.sshManager == nil {
+		return a.unavailable(subsystemSSH)
+	}
+	return a.sshManager.SyncTwoWay(localPath, remotePath, connectionName)
+}
+
+// ListSshSyncConflicts returns the unresolved two-way sync conflicts of a path
+func (a *App) ListSshSyncConflicts(localPath string) []ssh.SyncConflict {
+	if a.sshManager == nil {
+		return []ssh.SyncConflict{}
+	}
+	return a.sshManager.ListSyncConflicts(localPath)
+}
+
+// ResolveSshSyncConflict settles a two-way sync conflict; resolution is
+// "keep_local", "keep_remote" or "keep_both"
+func (a *App) ResolveSshSyncConflict(localPath, filePath, resolution string) error {
+	if a.sshManager == nil {
+		return a.unavailable(subsystemSSH)
+	}
+	return a.sshManager.ResolveSyncConflict(localPath, filePath, resolution)
+}
+
+// sshSyncEmitter adapts EventHub to ssh.SyncEmitter
+type sshSyncEmitter struct {
+	eventHub *eventhub.EventHub
+}
+
+func (e *sshSyncEmitter) EmitSyncProgress(progress ssh.SyncProgress) {
+	e.eventHub.Emit("ssh-sync:progress", progress)
+}
+
+func (e *sshSyncEmitter) EmitSyncFile(file ssh.SyncFile) {
+	e.eventHub.Emit("ssh-sync:file", file)
+}
+
+func (e *sshSyncEmitter) EmitSyncConflicts(event ssh.SyncConflictsEvent) {
+	e.eventHub.Emit("ssh-sync:conflicts", event)
+}
@@ -0,0 +1,246 @@
+// storage_report.go
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"ropcode/internal/claude"
+	"ropcode/internal/codex"
+	"ropcode/internal/gemini"
+	"ropcode/internal/retention"
+)
+
+// Cleanup actions a storage category can offer
+const (
+	storageCleanupArchiveSessions = "archive_sessions"
+	storageCleanupTempImages      = "temp_images"
+	storageCleanupTrash           = "trash"
+)
+
+// StorageCategory is the disk used by one kind of data
+type StorageCategory struct {
+	ID    string `json:"id"`
+	Path  string `json:"path"`
+	Bytes int64  `json:"bytes"`
+	Files int    `json:"files"`
+	// Cleanup is the CleanupStorage action that frees space here, if any
+	Cleanup string `json:"cleanup,omitempty"`
+}
+
+// ProjectStorage is the disk used by the session transcripts of a project
+type ProjectStorage struct {
+	// Path is the project's path; empty when the transcripts could not be
+	// matched to one
+	Path string `json:"path"`
+	// Name is the indexed project's name, or a label for an unmatched
+	// session directory
+	Name        string           `json:"name"`
+	Indexed     bool             `json:"indexed"`
+	Bytes       int64            `json:"bytes"`
+	Transcripts int              `json:"transcripts"`
+	Providers   map[string]int64 `json:"providers"`
+	// ReclaimableBytes is what the session retention settings would archive
+	ReclaimableBytes int64 `json:"reclaimable_bytes"`
+}
+
+// StorageReport summarizes the disk used by sessions and ropcode's data
+type StorageReport struct {
+	Categories []StorageCategory `json:"categories"`
+	// Projects are sorted by size, largest first
+	Projects    []ProjectStorage `json:"projects"`
+	TotalBytes  int64            `json:"total_bytes"`
+	GeneratedAt time.Time        `json:"generated_at"`
+}
+
+// GetStorageReport reports the disk used per category and per project
+func (a *App) GetStorageReport() (*StorageReport, error) {
+	manager, err := a.sessionRetention()
+	if err != nil {
+		return nil, err
+	}
+	report := &StorageReport{Categories: []StorageCategory{}, GeneratedAt: time.Now()}
+	add := func(id, path, cleanup string) {
+		if path == "" {
+			return
+		}
+		bytes, files := diskUsage(path)
+		report.Categories = append(report.Categories, StorageCategory{ID: id, Path: path, Bytes: bytes, Files: files, Cleanup: cleanup})
+		report.TotalBytes += bytes
+	}
+
+	for _, source := range manager.Sources {
+		add(source.Provider+"_sessions", source.Root, storageCleanupArchiveSessions)
+	}
+	add("session_archive", manager.ArchiveDir, "")
+	if dir, err := a.tempImagesDir(); err == nil {
+		add("temp_images", dir, storageCleanupTempImages)
+	}
+	if dir, err := a.trashDir(); err == nil {
+		add("trash", dir, storageCleanupTrash)
+	}
+	add("logs", a.diagnosticsLogDir(), "")
+	if a.config != nil && a.config.DatabasePath != "" {
+		bytes, files := int64(0), 0
+		// SQLite keeps uncheckpointed writes beside the database
+		for _, suffix := range []string{"", "-wal", "-shm"} {
+			if info, err := os.Stat(a.config.DatabasePath + suffix); err == nil {
+				bytes += info.Size()
+				files++
+			}
+		}
+		report.Categories = append(report.Categories, StorageCategory{ID: "database", Path: a.config.DatabasePath, Bytes: bytes, Files: files})
+		report.TotalBytes += bytes
+	}
+
+	files, err := manager.List()
+	if err != nil {
+		return nil, err
+	}
+	report.Projects = a.projectStorage(manager.Sources, files, retention.Select(files, a.sessionRetentionPolicy(), time.Now()))
+	return report, nil
+}
+
+// projectStorage groups transcripts by the project their sessions ran in.
+// Claude and Gemini name a project's session directory after its path, so
+// those of indexed projects are recognized; Codex records the path in each
+// rollout file, which the catalog keeps.
+func (a *App) projectStorage(sources []retention.Source, files, reclaimable []retention.File) []ProjectStorage {
+	names := make(map[string]string) // project path → indexed name
+	if a.dbManager != nil {
+		if indexes, err := a.dbManager.GetAllProjectIndexes(); err == nil {
+			for _, index := range indexes {
+				for _, provider := range index.Providers {
+					names[provider.Path] = index.Name
+				}
+				for _, workspace := range index.Workspaces {
+					for _, provider := range workspace.Providers {
+						names[provider.Path] = index.Name + "/" + workspace.Name
+					}
+				}
+			}
+		}
+	}
+	byDir := map[string]map[string]string{"claude": {}, "gemini": {}}
+	for path := range names {
+		if path == "" {
+			continue
+		}
+		byDir["claude"][claude.GetProjectHash(path)] = path
+		byDir["gemini"][gemini.GetProjectHash(path)] = path
+	}
+	codexPaths := map[string]string{}
+	if dir, err := codex.CodexDir(); err == nil {
+		if catalog := a.codexCatalog(dir); catalog != nil {
+			codexPaths = catalog.ProjectPaths()
+		}
+	}
+
+	roots := make(map[string]string)
+	for _, source := range sources {
+		roots[source.Provider] = source.Root
+	}
+
+	// projectOf returns the key grouping file, its project path and a
+	// label for when the path is unknown
+	projectOf := func(file retention.File) (key, path, label string) {
+		if file.Provider == "codex" {
+			if path := codexPaths[file.Path]; path != "" {
+				return path, path, path
+			}
+			return "codex:", "", "Codex (unknown project)"
+		}
+		dir := ""
+		if rel, err := filepath.Rel(roots[file.Provider], file.Path); err == nil {
+			dir, _, _ = strings.Cut(filepath.ToSlash(rel), "/")
+		}
+		if path := byDir[file.Provider][dir]; path != "" {
+			return path, path, path
+		}
+		return file.Provider + ":" + dir, "", dir
+	}
+
+	projects := make(map[string]*ProjectStorage)
+	get := func(file retention.File) *ProjectStorage {
+		key, path, label := projectOf(file)
+		project := projects[key]
+		if project == nil {
+			project = &ProjectStorage{Path: path, Name: label, Providers: map[string]int64{}}
+			if name, ok := names[path]; ok && path != "" {
+				project.Name, project.Indexed = name, true
+			}
+			projects[key] = project
+		}
+		return project
+	}
+	for _, file := range files {
+		project := get(file)
+		project.Bytes += file.Size
+		project.Transcripts++
+		project.Providers[file.Provider] += file.Size
+	}
+	for _, file := range reclaimable {
+		get(file).ReclaimableBytes += file.Size
+	}
+
+	result := make([]ProjectStorage, 0, len(projects))
+	for _, project := range projects {
+		result = append(result, *project)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Bytes != result[j].Bytes {
+			return result[i].Bytes > result[j].Bytes
+		}
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+// CleanupStorage runs a category's cleanup action and reports again:
+// archive_sessions archives the transcripts the session retention settings
+// don't keep, temp_images removes pasted images past their retention and
+// trash purges expired trash items
+func (a *App) CleanupStorage(action string) (*StorageReport, error) {
+	switch action {
+	case storageCleanupArchiveSessions:
+		if _, err := a.ArchiveOldSessions(); err != nil {
+			return nil, err
+		}
+	case storageCleanupTempImages:
+		if _, err := a.CleanupTempImages(); err != nil {
+			return nil, err
+		}
+	case storageCleanupTrash:
+		dir, err := a.trashDir()
+		if err != nil {
+			return nil, err
+		}
+		a.purgeTrash(dir)
+	default:
+		return nil, fmt.Errorf("unknown storage cleanup action: %s", action)
+	}
+	return a.GetStorageReport()
+}
+
+// diskUsage returns the size and number of the regular files under path,
+// or of path itself. A missing path uses nothing.
+func diskUsage(path string) (int64, int) {
+	var bytes int64
+	var files int
+	filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return nil
+		}
+		if info, err := d.Info(); err == nil {
+			bytes += info.Size()
+			files++
+		}
+		return nil
+	})
+	return bytes, files
+}
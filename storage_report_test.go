@@ -0,0 +1,88 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"ropcode/internal/config"
+	"ropcode/internal/database"
+	"ropcode/internal/gemini"
+)
+
+func TestGetStorageReportGroupsTranscriptsByProject(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("CODEX_HOME", filepath.Join(home, ".codex"))
+	claudeDir := filepath.Join(home, ".claude")
+	ropcodeDir := filepath.Join(home, ".ropcode")
+	old := time.Now().Add(-60 * 24 * time.Hour)
+	write := func(path, content string, modTime time.Time) {
+		t.Helper()
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		os.Chtimes(path, modTime, modTime)
+	}
+	write(filepath.Join(claudeDir, "projects", "-work-app", "s1.jsonl"), `{"type":"user"}`+"\n", old)
+	write(filepath.Join(claudeDir, "projects", "-work-app", "s2.jsonl"), `{"type":"user"}`+"\n", time.Now())
+	write(filepath.Join(claudeDir, "projects", "-elsewhere", "s3.jsonl"), `{"type":"user"}`+"\n", time.Now())
+	write(filepath.Join(home, ".codex", "sessions", "2026", "01", "02", "rollout-c1.jsonl"),
+		`{"timestamp":"2026-01-02T13:21:50.490Z","type":"session_meta","payload":{"id":"c1","timestamp":"2026-01-02T13:21:40.249Z","cwd":"/work/app"}}`+"\n", old)
+	write(filepath.Join(home, ".gemini", "tmp", gemini.GetProjectHash("/work/app"), "chats", "session-g1.json"), `{}`, time.Now())
+	write(filepath.Join(ropcodeDir, "temp-images", "paste.png"), "png", time.Now())
+
+	db := openAppConfigTestDB(t)
+	if err := db.SaveProjectIndex(&database.ProjectIndex{
+		Name:      "app",
+		Providers: []database.ProviderInfo{{ID: "claude", ProviderID: "claude", Path: "/work/app"}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.SaveSetting(sessionRetentionDaysSettingKey, "30"); err != nil {
+		t.Fatal(err)
+	}
+	app := &App{dbManager: db, config: &config.Config{ClaudeDir: claudeDir, RopcodeDir: ropcodeDir, LogDir: filepath.Join(ropcodeDir, "logs")}}
+
+	report, err := app.GetStorageReport()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Projects) != 2 {
+		t.Fatalf("projects = %+v", report.Projects)
+	}
+	project := report.Projects[0]
+	if project.Name != "app" || !project.Indexed || project.Transcripts != 4 || len(project.Providers) != 3 {
+		t.Fatalf("indexed project = %+v", project)
+	}
+	if project.ReclaimableBytes == 0 || project.ReclaimableBytes >= project.Bytes {
+		t.Fatalf("reclaimable = %d of %d bytes", project.ReclaimableBytes, project.Bytes)
+	}
+	if other := report.Projects[1]; other.Name != "-elsewhere" || other.Indexed || other.Path != "" {
+		t.Fatalf("unmatched project = %+v", other)
+	}
+
+	categories := make(map[string]StorageCategory)
+	for _, category := range report.Categories {
+		categories[category.ID] = category
+	}
+	if images := categories["temp_images"]; images.Files != 1 || images.Cleanup != storageCleanupTempImages {
+		t.Fatalf("temp_images = %+v", images)
+	}
+	if sessions := categories["claude_sessions"]; sessions.Files != 3 || sessions.Cleanup != storageCleanupArchiveSessions {
+		t.Fatalf("claude_sessions = %+v", sessions)
+	}
+
+	report, err = app.CleanupStorage(storageCleanupArchiveSessions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if project := report.Projects[0]; project.Transcripts != 2 || project.ReclaimableBytes != 0 {
+		t.Fatalf("project after archiving = %+v", project)
+	}
+	if _, err := app.CleanupStorage("everything"); err == nil {
+		t.Fatal("CleanupStorage() with an unknown action should fail")
+	}
+}
@@ -0,0 +1,51 @@
+// system_prompt.go
+package main
+
+import (
+	"log"
+	"time"
+
+	"ropcode/internal/sysprompt"
+)
+
+// GetSessionSystemPrompt returns the system prompt template of scope
+// ("global" or "project")
+func (a *App) GetSessionSystemPrompt(scope, projectPath string) (string, error) {
+	if a.config == nil {
+		return "", a.unavailable(subsystemConfig)
+	}
+	return sysprompt.Read(a.config.ClaudeDir, scope, projectPath)
+}
+
+// SaveSessionSystemPrompt saves the system prompt template of scope; an
+// empty template removes it
+func (a *App) SaveSessionSystemPrompt(scope, projectPath, content string) error {
+	if a.config == nil {
+		return a.unavailable(subsystemConfig)
+	}
+	return sysprompt.Save(a.config.ClaudeDir, scope, projectPath, content)
+}
+
+// PreviewSessionSystemPrompt resolves the system prompt a session started
+// now in projectPath would get
+func (a *App) PreviewSessionSystemPrompt(projectPath string) (*sysprompt.Resolved, error) {
+	if a.config == nil {
+		return nil, a.unavailable(subsystemConfig)
+	}
+	return sysprompt.Resolve(a.config.ClaudeDir, projectPath, time.Now())
+}
+
+// sessionSystemPrompt returns the resolved system prompt of a session
+// starting in projectPath, empty when there is none. A project's template
+// replaces the global one.
+func (a *App) sessionSystemPrompt(projectPath string) string {
+	if a.config == nil {
+		return ""
+	}
+	resolved, err := sysprompt.Resolve(a.config.ClaudeDir, projectPath, time.Now())
+	if err != nil {
+		log.Printf("[SystemPrompt] %v", err)
+		return ""
+	}
+	return resolved.Prompt
+}
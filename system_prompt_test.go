@@ -0,0 +1,32 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	"ropcode/internal/config"
+	"ropcode/internal/sysprompt"
+)
+
+func TestSessionSystemPromptProjectOverride(t *testing.T) {
+	app := &App{config: &config.Config{ClaudeDir: t.TempDir()}}
+	project := filepath.Join(t.TempDir(), "shop")
+
+	if err := app.SaveSessionSystemPrompt(sysprompt.ScopeGlobal, "", "Global rules"); err != nil {
+		t.Fatal(err)
+	}
+	if err := app.SaveSessionSystemPrompt(sysprompt.ScopeProject, project, "Rules for {{projectName}}"); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := app.GetSessionSystemPrompt(sysprompt.ScopeProject, project); err != nil || got != "Rules for {{projectName}}" {
+		t.Fatalf("GetSessionSystemPrompt() = %q, %v", got, err)
+	}
+
+	preview, err := app.PreviewSessionSystemPrompt(project)
+	if err != nil || preview.Scope != sysprompt.ScopeProject || preview.Prompt != "Rules for shop" {
+		t.Fatalf("PreviewSessionSystemPrompt() = %+v, %v", preview, err)
+	}
+	if got := app.sessionSystemPrompt(t.TempDir()); got != "Global rules" {
+		t.Fatalf("sessionSystemPrompt() elsewhere = %q", got)
+	}
+}
@@ -0,0 +1,235 @@
+// telemetry.go
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"runtime"
+	"time"
+
+	"ropcode/internal/database"
+)
+
+const (
+	telemetryEnabledSettingKey   = "telemetry_enabled"
+	telemetryEndpointSettingKey  = "telemetry_endpoint"
+	telemetryInstallIDSettingKey = "telemetry_install_id"
+	// telemetrySessionPollInterval is how often running sessions are looked
+	// at; session durations are accurate to about this much
+	telemetrySessionPollInterval = 15 * time.Second
+	telemetryFlushTimeout        = 30 * time.Second
+)
+
+// Telemetry metric kinds
+const (
+	telemetryKindFeature = "feature"
+	telemetryKindSession = "session"
+)
+
+// telemetryNamePattern keeps metric names to short identifiers, so free
+// text can never end up in a report
+var telemetryNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_.-]{0,63}$`)
+
+// TelemetryReport is what a flush sends
+type TelemetryReport struct {
+	InstallID  string                      `json:"install_id"`
+	AppVersion string                      `json:"app_version"`
+	OS         string                      `json:"os"`
+	Arch       string                      `json:"arch"`
+	Metrics    []*database.TelemetryMetric `json:"metrics"`
+}
+
+// TelemetryFlushResult is the result of FlushTelemetry
+type TelemetryFlushResult struct {
+	// Sent is the number of metrics delivered and removed from the buffer
+	Sent int `json:"sent"`
+}
+
+// RecordTelemetryFeature counts one use of a frontend feature. It does
+// nothing while telemetry is off.
+func (a *App) RecordTelemetryFeature(name string) error {
+	if !telemetryNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid telemetry feature name %q", name)
+	}
+	a.recordTelemetry(telemetryKindFeature, name, 0)
+	return nil
+}
+
+// ViewPendingTelemetry returns the report the next flush would send
+func (a *App) ViewPendingTelemetry() (*TelemetryReport, error) {
+	if a.dbManager == nil {
+		return nil, a.unavailable(subsystemDatabase)
+	}
+	metrics, err := a.dbManager.ListTelemetryMetrics()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read telemetry: %w", err)
+	}
+	if metrics == nil {
+		metrics = []*database.TelemetryMetric{}
+	}
+	installID, err := a.dbManager.GetSetting(telemetryInstallIDSettingKey)
+	if err != nil {
+		return nil, err
+	}
+	return &TelemetryReport{
+		InstallID:  installID,
+		AppVersion: currentVersion().Version,
+		OS:         runtime.GOOS,
+		Arch:       runtime.GOARCH,
+		Metrics:    metrics,
+	}, nil
+}
+
+// FlushTelemetry sends the pending report to telemetry_endpoint and removes
+// what was sent from the buffer. Nothing is sent unless this is called.
+func (a *App) FlushTelemetry() (*TelemetryFlushResult, error) {
+	if a.dbManager == nil {
+		return nil, a.unavailable(subsystemDatabase)
+	}
+	if !a.telemetryEnabled() {
+		return nil, fmt.Errorf("telemetry is turned off")
+	}
+	endpoint, err := a.dbManager.GetSetting(telemetryEndpointSettingKey)
+	if err != nil {
+		return nil, err
+	}
+	if endpoint == "" {
+		return nil, fmt.Errorf("no telemetry endpoint is configured")
+	}
+	report, err := a.ViewPendingTelemetry()
+	if err != nil {
+		return nil, err
+	}
+	if len(report.Metrics) == 0 {
+		return &TelemetryFlushResult{}, nil
+	}
+
+	data, err := json.Marshal(report)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode telemetry: %w", err)
+	}
+	parent := a.ctx
+	if parent == nil {
+		parent = context.Background()
+	}
+	ctx, cancel := context.WithTimeout(parent, telemetryFlushTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("invalid telemetry endpoint: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send telemetry: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("failed to send telemetry: %s", resp.Status)
+	}
+
+	if err := a.dbManager.RemoveTelemetryMetrics(report.Metrics); err != nil {
+		return nil, fmt.Errorf("telemetry was sent but could not be removed from the buffer: %w", err)
+	}
+	return &TelemetryFlushResult{Sent: len(report.Metrics)}, nil
+}
+
+// ClearTelemetry discards the pending telemetry without sending it
+func (a *App) ClearTelemetry() error {
+	if a.dbManager == nil {
+		return a.unavailable(subsystemDatabase)
+	}
+	if err := a.dbManager.ClearTelemetryMetrics(); err != nil {
+		return fmt.Errorf("failed to clear telemetry: %w", err)
+	}
+	return nil
+}
+
+// telemetryEnabled reads telemetry_enabled, which defaults to off
+func (a *App) telemetryEnabled() bool {
+	if a.dbManager == nil {
+		return false
+	}
+	value, err := a.dbManager.GetSetting(telemetryEnabledSettingKey)
+	return err == nil && value == "true"
+}
+
+// telemetryToggled starts a fresh install ID when telemetry is turned on
+// and discards the ID and the buffer when it is turned off
+func (a *App) telemetryToggled(enabled bool) {
+	if a.dbManager == nil {
+		return
+	}
+	installID := ""
+	if enabled {
+		id := make([]byte, 16)
+		if _, err := rand.Read(id); err != nil {
+			slog.Error("failed to create install ID", "component", "telemetry", "error", err)
+			return
+		}
+		installID = hex.EncodeToString(id)
+	} else if err := a.dbManager.ClearTelemetryMetrics(); err != nil {
+		slog.Error("failed to clear telemetry", "component", "telemetry", "error", err)
+	}
+	if err := a.dbManager.SaveSetting(telemetryInstallIDSettingKey, installID); err != nil {
+		slog.Error("failed to save install ID", "component", "telemetry", "error", err)
+	}
+}
+
+// recordTelemetry adds one occurrence of a metric to today's total while
+// telemetry is on
+func (a *App) recordTelemetry(kind, name string, duration time.Duration) {
+	if !telemetryNamePattern.MatchString(name) || !a.telemetryEnabled() {
+		return
+	}
+	if err := a.dbManager.AddTelemetryMetric(&database.TelemetryMetric{
+		Day:        time.Now().UTC().Format("2006-01-02"),
+		Kind:       kind,
+		Name:       name,
+		Count:      1,
+		DurationMS: duration.Milliseconds(),
+	}); err != nil {
+		slog.Warn("failed to record", "component", "telemetry", "kind", kind, "name", name, "error", err)
+	}
+}
+
+// runTelemetrySessionTracker records the duration of each provider session
+// once it stops running
+func (a *App) runTelemetrySessionTracker(ctx context.Context) {
+	ticker := time.NewTicker(telemetrySessionPollInterval)
+	defer ticker.Stop()
+	running := map[string]LiveProviderSession{}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		running = a.trackTelemetrySessions(running, time.Now())
+	}
+}
+
+// trackTelemetrySessions records the sessions of running that have stopped
+// and returns the sessions running now
+func (a *App) trackTelemetrySessions(running map[string]LiveProviderSession, now time.Time) map[string]LiveProviderSession {
+	if !a.telemetryEnabled() {
+		return map[string]LiveProviderSession{}
+	}
+	current := map[string]LiveProviderSession{}
+	for _, session := range a.ListRunningProviderSessions() {
+		current[session.Provider+"/"+session.SessionID] = session
+	}
+	for key, session := range running {
+		if _, ok := current[key]; !ok {
+			a.recordTelemetry(telemetryKindSession, session.Provider, now.Sub(session.StartedAt))
+		}
+	}
+	return current
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTelemetryIsOffByDefault(t *testing.T) {
+	app := &App{dbManager: openAppConfigTestDB(t)}
+	if err := app.RecordTelemetryFeature("settings.open"); err != nil {
+		t.Fatal(err)
+	}
+	report, err := app.ViewPendingTelemetry()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Metrics) != 0 || report.InstallID != "" {
+		t.Fatalf("recorded while off: %+v", report)
+	}
+	if _, err := app.FlushTelemetry(); err == nil {
+		t.Fatal("FlushTelemetry succeeded while telemetry is off")
+	}
+}
+
+func TestTelemetryRecordViewAndFlush(t *testing.T) {
+	var received TelemetryReport
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Errorf("decode report: %v", err)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	app := &App{dbManager: openAppConfigTestDB(t)}
+	if _, err := app.UpdateSettings(map[string]interface{}{
+		telemetryEnabledSettingKey:  true,
+		telemetryEndpointSettingKey: server.URL,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	app.RecordTelemetryFeature("settings.open")
+	app.RecordTelemetryFeature("settings.open")
+	app.recordTelemetry(telemetryKindSession, "claude", 90*time.Second)
+	if err := app.RecordTelemetryFeature("Fix the login bug in /home/me/app"); err == nil {
+		t.Error("free text was accepted as a feature name")
+	}
+
+	report, err := app.ViewPendingTelemetry()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.InstallID) != 32 || len(report.Metrics) != 2 {
+		t.Fatalf("report = %+v", report)
+	}
+	for _, m := range report.Metrics {
+		switch m.Name {
+		case "settings.open":
+			if m.Kind != telemetryKindFeature || m.Count != 2 {
+				t.Errorf("feature metric = %+v", m)
+			}
+		case "claude":
+			if m.Kind != telemetryKindSession || m.Count != 1 || m.DurationMS != 90000 {
+				t.Errorf("session metric = %+v", m)
+			}
+		default:
+			t.Errorf("unexpected metric %+v", m)
+		}
+	}
+
+	result, err := app.FlushTelemetry()
+	if err != nil || result.Sent != 2 {
+		t.Fatalf("FlushTelemetry = %+v, %v", result, err)
+	}
+	if received.InstallID != report.InstallID || len(received.Metrics) != 2 {
+		t.Fatalf("server received %+v", received)
+	}
+	if report, _ = app.ViewPendingTelemetry(); len(report.Metrics) != 0 {
+		t.Fatalf("buffer not emptied: %+v", report.Metrics)
+	}
+}
+
+func TestTelemetryTurnedOffDiscardsBuffer(t *testing.T) {
+	app := &App{dbManager: openAppConfigTestDB(t)}
+	if err := app.SaveSetting(telemetryEnabledSettingKey, "true"); err != nil {
+		t.Fatal(err)
+	}
+	app.RecordTelemetryFeature("agent.run.claude")
+	first, _ := app.ViewPendingTelemetry()
+	if len(first.Metrics) != 1 {
+		t.Fatalf("metrics = %+v", first.Metrics)
+	}
+
+	if err := app.SaveSetting(telemetryEnabledSettingKey, "false"); err != nil {
+		t.Fatal(err)
+	}
+	report, _ := app.ViewPendingTelemetry()
+	if len(report.Metrics) != 0 || report.InstallID != "" {
+		t.Fatalf("turning telemetry off kept %+v", report)
+	}
+
+	// Turning it back on starts over with a new install ID
+	app.SaveSetting(telemetryEnabledSettingKey, "true")
+	if report, _ = app.ViewPendingTelemetry(); report.InstallID == "" || report.InstallID == first.InstallID {
+		t.Fatalf("install ID = %q, first was %q", report.InstallID, first.InstallID)
+	}
+}
+
+func TestTrackTelemetrySessionsRecordsStoppedSessions(t *testing.T) {
+	app := &App{dbManager: openAppConfigTestDB(t)}
+	app.SaveSetting(telemetryEnabledSettingKey, "true")
+
+	now := time.Now()
+	running := map[string]LiveProviderSession{
+		"codex/s1": {Provider: "codex", SessionID: "s1", StartedAt: now.Add(-2 * time.Minute)},
+	}
+	if current := app.trackTelemetrySessions(running, now); len(current) != 0 {
+		t.Fatalf("current = %v", current)
+	}
+	report, _ := app.ViewPendingTelemetry()
+	if len(report.Metrics) != 1 || report.Metrics[0].Name != "codex" || report.Metrics[0].DurationMS != 120000 {
+		t.Fatalf("metrics = %+v", report.Metrics)
+	}
+}
@@ -0,0 +1,160 @@
+// temp_images.go
+package main
+
+import (
+	"context"
+	"log"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"ropcode/internal/codex"
+	"ropcode/internal/gemini"
+	"ropcode/internal/imageconv"
+	"ropcode/internal/settings"
+	"ropcode/internal/tempimages"
+)
+
+const (
+	tempImagesRetentionSettingKey = "temp_images_retention_days"
+	tempImagesMaxMBSettingKey     = "temp_images_max_mb"
+	pasteImageFormatSettingKey    = "paste_image_format"
+	pasteImageMaxWidthSettingKey  = "paste_image_max_width"
+	pasteImageMaxHeightSettingKey = "paste_image_max_height"
+	pasteImageQualitySettingKey   = "paste_image_quality"
+	tempImagesCleanupDelay        = 5 * time.Minute
+	tempImagesCleanupInterval     = 24 * time.Hour
+)
+
+// tempImagesMu keeps a background and a requested cleanup from overlapping
+var tempImagesMu sync.Mutex
+
+// tempImagesDir is where pasted images are saved, ~/.ropcode/temp-images
+func (a *App) tempImagesDir() (string, error) {
+	if a.config != nil {
+		return filepath.Join(a.config.RopcodeDir, "temp-images"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".ropcode", "temp-images"), nil
+}
+
+// sessionHistoryRoots are the directories where the providers keep session
+// transcripts, which is where prompts refer to pasted images
+func (a *App) sessionHistoryRoots() []string {
+	var roots []string
+	if a.config != nil {
+		roots = append(roots, filepath.Join(a.config.ClaudeDir, "projects"))
+	} else if home, err := os.UserHomeDir(); err == nil {
+		roots = append(roots, filepath.Join(home, ".claude", "projects"))
+	}
+	if dir, err := codex.CodexDir(); err == nil {
+		roots = append(roots, filepath.Join(dir, "sessions"))
+	}
+	if dir, err := gemini.GeminiDir(); err == nil {
+		roots = append(roots, filepath.Join(dir, "tmp"))
+	}
+	return roots
+}
+
+// tempImagesPolicy reads the retention settings, defaults filled in
+func (a *App) tempImagesPolicy() tempimages.Policy {
+	number := func(key string) float64 {
+		raw := ""
+		if a.dbManager != nil {
+			raw, _ = a.dbManager.GetSetting(key)
+		}
+		value, _ := settings.Lookup(key).Decode(raw).(float64)
+		return max(value, 0)
+	}
+	return tempimages.Policy{
+		MaxAge:   time.Duration(number(tempImagesRetentionSettingKey) * float64(24*time.Hour)),
+		MaxBytes: int64(number(tempImagesMaxMBSettingKey) * (1 << 20)),
+	}
+}
+
+// listTempImages lists the saved images with their references marked
+func (a *App) listTempImages() (string, []tempimages.Image, error) {
+	dir, err := a.tempImagesDir()
+	if err != nil {
+		return "", nil, err
+	}
+	images, err := tempimages.List(dir)
+	if err != nil {
+		return "", nil, err
+	}
+	tempimages.MarkReferenced(images, a.sessionHistoryRoots())
+	return dir, images, nil
+}
+
+// pastedImageOptions reads how pasted images are converted, defaults
+// filled in
+func (a *App) pastedImageOptions() imageconv.Options {
+	setting := func(key string) interface{} {
+		raw := ""
+		if a.dbManager != nil {
+			raw, _ = a.dbManager.GetSetting(key)
+		}
+		return settings.Lookup(key).Decode(raw)
+	}
+	number := func(key string) int {
+		value, _ := setting(key).(float64)
+		return int(max(value, 0))
+	}
+	format, _ := setting(pasteImageFormatSettingKey).(string)
+	return imageconv.Options{
+		MaxWidth:  number(pasteImageMaxWidthSettingKey),
+		MaxHeight: number(pasteImageMaxHeightSettingKey),
+		Format:    format,
+		Quality:   number(pasteImageQualitySettingKey),
+	}
+}
+
+// GetTempImagesStats reports how many pasted images are saved, how much
+// space they take and how many session history still refers to
+func (a *App) GetTempImagesStats() (*tempimages.Stats, error) {
+	dir, images, err := a.listTempImages()
+	if err != nil {
+		return nil, err
+	}
+	return tempimages.Summarize(dir, images), nil
+}
+
+// CleanupTempImages removes the pasted images the retention settings don't
+// keep and reports what is left
+func (a *App) CleanupTempImages() (*tempimages.CleanupResult, error) {
+	tempImagesMu.Lock()
+	defer tempImagesMu.Unlock()
+
+	dir, images, err := a.listTempImages()
+	if err != nil {
+		return nil, err
+	}
+	result := tempimages.Cleanup(dir, images, a.tempImagesPolicy(), time.Now())
+	if result.Removed > 0 {
+		log.Printf("[temp-images] removed %d images, %d bytes", result.Removed, result.FreedBytes)
+	}
+	return result, nil
+}
+
+// runTempImagesCleanup cleans up pasted images shortly after startup and
+// then once a day
+func (a *App) runTempImagesCleanup(ctx context.Context) {
+	timer := time.NewTimer(tempImagesCleanupDelay)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+		if _, err := a.CleanupTempImages(); err != nil {
+			slog.Warn("cleanup failed", "component", "temp-images", "error", err)
+		}
+		timer.Reset(tempImagesCleanupInterval)
+	}
+}
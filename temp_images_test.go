@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"ropcode/internal/config"
+)
+
+func TestTempImagesPolicyAndCleanup(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("CODEX_HOME", filepath.Join(home, ".codex"))
+	db := openAppConfigTestDB(t)
+	app := &App{
+		dbManager: db,
+		config:    &config.Config{RopcodeDir: filepath.Join(home, ".ropcode"), ClaudeDir: filepath.Join(home, ".claude")},
+	}
+
+	if policy := app.tempImagesPolicy(); policy.MaxAge != 30*24*time.Hour || policy.MaxBytes != 1024<<20 {
+		t.Fatalf("default policy = %+v", policy)
+	}
+	db.SaveSetting(tempImagesRetentionSettingKey, "0")
+	db.SaveSetting(tempImagesMaxMBSettingKey, "0.5")
+	if policy := app.tempImagesPolicy(); policy.MaxAge != 0 || policy.MaxBytes != 512<<10 {
+		t.Fatalf("configured policy = %+v", policy)
+	}
+
+	saved, err := app.SavePastedImage("data:image/png;base64,aGVsbG8=", "../escape.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := saved.Path
+	if filepath.Dir(path) != filepath.Join(home, ".ropcode", "temp-images") {
+		t.Fatalf("SavePastedImage() wrote %s", path)
+	}
+	old := time.Now().Add(-48 * time.Hour)
+	os.Chtimes(path, old, old)
+
+	stats, err := app.GetTempImagesStats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Count != 1 || stats.Bytes != 5 || stats.Referenced != 0 {
+		t.Fatalf("stats = %+v", stats)
+	}
+	result, err := app.CleanupTempImages()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Removed != 1 || result.Stats.Count != 0 {
+		t.Fatalf("cleanup = %+v", result)
+	}
+}
+
+func TestSavePastedImageConverts(t *testing.T) {
+	home := t.TempDir()
+	db := openAppConfigTestDB(t)
+	app := &App{dbManager: db, config: &config.Config{RopcodeDir: filepath.Join(home, ".ropcode")}}
+
+	img := image.NewRGBA(image.Rect(0, 0, 2400, 1200))
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	data := base64.StdEncoding.EncodeToString(buf.Bytes())
+
+	// By default the image is kept as pasted
+	saved, err := app.SavePastedImage(data, "shot.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.Width != 2400 || saved.Height != 1200 || saved.Size != buf.Len() {
+		t.Fatalf("default SavePastedImage() = %+v", saved)
+	}
+
+	db.SaveSetting(pasteImageMaxWidthSettingKey, "1200")
+	db.SaveSetting(pasteImageFormatSettingKey, "jpeg")
+	saved, err = app.SavePastedImage(data, "shot.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Base(saved.Path) != "shot.jpg" || saved.Width != 1200 || saved.Height != 600 {
+		t.Fatalf("converted SavePastedImage() = %+v", saved)
+	}
+	if info, err := os.Stat(saved.Path); err != nil || info.Size() != int64(saved.Size) {
+		t.Errorf("saved file = %v, %v", info, err)
+	}
+}
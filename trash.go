@@ -0,0 +1,97 @@
+// trash.go
+package main
+
+import (
+	"log"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"ropcode/internal/settings"
+	"ropcode/internal/trash"
+)
+
+// Trashed items are purged after trash_retention_days, checked whenever
+// something is trashed
+const (
+	trashEnabledSettingKey   = "trash_discarded_files"
+	trashRetentionSettingKey = "trash_retention_days"
+)
+
+// trashDir is where discarded files are kept, ~/.ropcode/trash
+func (a *App) trashDir() (string, error) {
+	if a.config != nil {
+		return filepath.Join(a.config.RopcodeDir, "trash"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".ropcode", "trash"), nil
+}
+
+// trashEnabled reports whether discarded files go to the trash
+func (a *App) trashEnabled() bool {
+	if a.dbManager == nil {
+		return false
+	}
+	raw, _ := a.dbManager.GetSetting(trashEnabledSettingKey)
+	enabled, _ := settings.Lookup(trashEnabledSettingKey).Decode(raw).(bool)
+	return enabled
+}
+
+// trashPaths moves paths into the trash, or with keep set puts copies of
+// them there, and returns how many it trashed. Expired items are purged
+// first.
+func (a *App) trashPaths(paths []string, keep bool, reason string) (int, error) {
+	dir, err := a.trashDir()
+	if err != nil {
+		return 0, err
+	}
+	a.purgeTrash(dir)
+	put := trash.Move
+	if keep {
+		put = trash.Copy
+	}
+	for i, path := range paths {
+		if _, err := put(dir, path, reason); err != nil {
+			return i, err
+		}
+	}
+	return len(paths), nil
+}
+
+// purgeTrash removes the items older than the retention setting
+func (a *App) purgeTrash(dir string) {
+	raw := ""
+	if a.dbManager != nil {
+		raw, _ = a.dbManager.GetSetting(trashRetentionSettingKey)
+	}
+	days, _ := settings.Lookup(trashRetentionSettingKey).Decode(raw).(float64)
+	removed, err := trash.Purge(dir, time.Duration(max(days, 0)*float64(24*time.Hour)), time.Now())
+	if err != nil {
+		slog.Error("purge failed", "component", "trash", "error", err)
+	} else if removed > 0 {
+		log.Printf("[trash] purged %d expired items", removed)
+	}
+}
+
+// ListTrash returns the trashed files and directories, newest first
+func (a *App) ListTrash() ([]trash.Item, error) {
+	dir, err := a.trashDir()
+	if err != nil {
+		return nil, err
+	}
+	return trash.List(dir)
+}
+
+// RestoreFromTrash puts a trashed item back where it came from. A file
+// replaces what is at its path now; a directory needs its path free.
+func (a *App) RestoreFromTrash(id string) (*trash.Item, error) {
+	dir, err := a.trashDir()
+	if err != nil {
+		return nil, err
+	}
+	return trash.Restore(dir, id)
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"ropcode/internal/config"
+)
+
+func TestCleanupWorkspaceMovesDiscardedFilesToTrash(t *testing.T) {
+	project := t.TempDir()
+	if err := os.WriteFile(filepath.Join(project, "main.go"), []byte("v1"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, project, "init")
+	runGit(t, project, "add", "-A")
+	runGit(t, project, "-c", "user.name=t", "-c", "user.email=t@example.com", "commit", "-m", "initial")
+	os.WriteFile(filepath.Join(project, "main.go"), []byte("v2"), 0644)
+	os.WriteFile(filepath.Join(project, "notes.txt"), []byte("mine"), 0644)
+
+	db := openAppConfigTestDB(t)
+	app := &App{dbManager: db, config: &config.Config{RopcodeDir: filepath.Join(t.TempDir(), ".ropcode")}}
+	if err := db.SaveSetting(trashEnabledSettingKey, "true"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := app.CleanupWorkspace(project, false); err != nil {
+		t.Fatalf("CleanupWorkspace: %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(project, "main.go")); string(data) != "v1" {
+		t.Fatalf("main.go = %q after cleanup", data)
+	}
+	if _, err := os.Stat(filepath.Join(project, "notes.txt")); !os.IsNotExist(err) {
+		t.Fatalf("notes.txt survived cleanup: %v", err)
+	}
+
+	items, err := app.ListTrash()
+	if err != nil || len(items) != 2 {
+		t.Fatalf("ListTrash = %+v, %v", items, err)
+	}
+	for _, item := range items {
+		if _, err := app.RestoreFromTrash(item.ID); err != nil {
+			t.Fatalf("RestoreFromTrash(%s): %v", item.Path, err)
+		}
+	}
+	if data, _ := os.ReadFile(filepath.Join(project, "main.go")); string(data) != "v2" {
+		t.Fatalf("main.go = %q after restore", data)
+	}
+	if data, _ := os.ReadFile(filepath.Join(project, "notes.txt")); string(data) != "mine" {
+		t.Fatalf("notes.txt = %q after restore", data)
+	}
+}
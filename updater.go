@@ -0,0 +1,301 @@
+// updater.go
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"ropcode/internal/updater"
+)
+
+const (
+	updateAutoCheckSettingKey = "update_auto_check"
+	updateFeedSettingKey      = "update_feed_url"
+	updateCheckDelay          = time.Minute
+	updateCheckInterval       = 12 * time.Hour
+	updateDownloadTimeout     = 30 * time.Minute
+)
+
+// Update states
+const (
+	updateStateIdle        = "idle"
+	updateStateUpToDate    = "up_to_date"
+	updateStateAvailable   = "available"
+	updateStateDownloading = "downloading"
+	updateStateStaged      = "staged"
+	updateStateInstalling  = "installing"
+	updateStateError       = "error"
+)
+
+// Swapped in tests
+var (
+	updatePlatform = func() (goos, goarch string) { return runtime.GOOS, runtime.GOARCH }
+	installUpdate  = updater.Install
+)
+
+// UpdateStatus is the state of the updater
+type UpdateStatus struct {
+	State          string `json:"state"` // idle, up_to_date, available, downloading, staged, installing or error
+	CurrentVersion string `json:"current_version"`
+	LatestVersion  string `json:"latest_version,omitempty"`
+	ReleaseName    string `json:"release_name,omitempty"`
+	ReleaseNotes   string `json:"release_notes,omitempty"`
+	ReleaseURL     string `json:"release_url,omitempty"`
+	PublishedAt    string `json:"published_at,omitempty"`
+	Asset          string `json:"asset,omitempty"`       // installer for this platform
+	StagedPath     string `json:"staged_path,omitempty"` // verified installer, once staged
+	// RestartRequired is set once an update replaced the app in place
+	RestartRequired bool   `json:"restart_required,omitempty"`
+	Error           string `json:"error,omitempty"`
+	CheckedAt       string `json:"checked_at,omitempty"` // RFC3339
+}
+
+// UpdateProgress is the payload of update:progress
+type UpdateProgress struct {
+	Version    string `json:"version"`
+	Downloaded int64  `json:"downloaded"`
+	Total      int64  `json:"total"` // 0 when unknown
+}
+
+// GetUpdateStatus returns the state of the updater
+func (a *App) GetUpdateStatus() *UpdateStatus {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if a.updateStatus == nil {
+		return &UpdateStatus{State: updateStateIdle, CurrentVersion: currentVersion().Version}
+	}
+	status := *a.updateStatus
+	return &status
+}
+
+// CheckForUpdates looks for a release newer than the running build.
+// Development builds have no comparable version and never update.
+func (a *App) CheckForUpdates() (*UpdateStatus, error) {
+	a.updateMu.Lock()
+	defer a.updateMu.Unlock()
+	return a.checkForUpdates()
+}
+
+func (a *App) checkForUpdates() (*UpdateStatus, error) {
+	current := currentVersion().Version
+	currentSemver, err := updater.ParseVersion(current)
+	if err != nil {
+		return nil, fmt.Errorf("development build %q cannot be compared with releases", current)
+	}
+
+	ctx, cancel := a.updateContext(time.Minute)
+	defer cancel()
+	release, err := a.updateClient().Latest(ctx)
+	if err != nil {
+		a.setUpdateError(err)
+		return nil, err
+	}
+	latest, _ := release.Version()
+
+	status := &UpdateStatus{
+		State:          updateStateUpToDate,
+		CurrentVersion: current,
+		LatestVersion:  latest.String(),
+		ReleaseName:    release.Name,
+		ReleaseNotes:   release.Notes,
+		ReleaseURL:     release.URL,
+		PublishedAt:    release.PublishedAt,
+		CheckedAt:      time.Now().Format(time.RFC3339),
+	}
+	if latest.Compare(currentSemver) > 0 {
+		status.State = updateStateAvailable
+		goos, goarch := updatePlatform()
+		if asset, err := updater.PlatformAsset(release, goos, goarch); err == nil {
+			status.Asset = asset.Name
+		} else {
+			status.Error = err.Error()
+		}
+		// Keep what is already staged for this release
+		if previous := a.GetUpdateStatus(); previous.State == updateStateStaged && previous.LatestVersion == status.LatestVersion {
+			status.State, status.StagedPath = updateStateStaged, previous.StagedPath
+		}
+	}
+
+	a.mu.Lock()
+	a.updateStatus, a.updateRelease = status, release
+	a.mu.Unlock()
+	if status.State == updateStateAvailable {
+		a.emitUpdateEvent("update:available", status)
+	}
+	return a.GetUpdateStatus(), nil
+}
+
+// DownloadUpdate downloads, verifies and stages the newest release,
+// checking for it first when needed
+func (a *App) DownloadUpdate() (*UpdateStatus, error) {
+	a.updateMu.Lock()
+	defer a.updateMu.Unlock()
+
+	status := a.GetUpdateStatus()
+	if status.State != updateStateAvailable && status.State != updateStateStaged {
+		var err error
+		if status, err = a.checkForUpdates(); err != nil {
+			return nil, err
+		}
+	}
+	switch status.State {
+	case updateStateStaged:
+		return status, nil
+	case updateStateAvailable:
+	default:
+		return nil, fmt.Errorf("no update available; %s is the latest version", status.CurrentVersion)
+	}
+
+	a.mu.RLock()
+	release := a.updateRelease
+	a.mu.RUnlock()
+	goos, goarch := updatePlatform()
+	asset, err := updater.PlatformAsset(release, goos, goarch)
+	if err != nil {
+		a.setUpdateError(err)
+		return nil, err
+	}
+	dir, err := a.updateDir()
+	if err != nil {
+		return nil, err
+	}
+	removeStaleUpdates(dir, status.LatestVersion)
+
+	a.setUpdateState(updateStateDownloading, "")
+	ctx, cancel := a.updateContext(updateDownloadTimeout)
+	defer cancel()
+	path, err := a.updateClient().Download(ctx, release, asset, filepath.Join(dir, status.LatestVersion), func(done, total int64) {
+		a.emitUpdateEvent("update:progress", UpdateProgress{Version: status.LatestVersion, Downloaded: done, Total: total})
+	})
+	if err != nil {
+		a.setUpdateError(err)
+		return nil, err
+	}
+
+	a.mu.Lock()
+	a.updateStatus.State, a.updateStatus.StagedPath, a.updateStatus.Error = updateStateStaged, path, ""
+	a.mu.Unlock()
+	status = a.GetUpdateStatus()
+	a.emitUpdateEvent("update:staged", status)
+	log.Printf("[updater] staged %s at %s", status.LatestVersion, path)
+	return status, nil
+}
+
+// InstallUpdate installs the staged update: an AppImage is replaced and
+// restart_required is set; elsewhere the platform installer is opened
+func (a *App) InstallUpdate() (*UpdateStatus, error) {
+	a.updateMu.Lock()
+	defer a.updateMu.Unlock()
+
+	status := a.GetUpdateStatus()
+	if status.State != updateStateStaged {
+		return nil, fmt.Errorf("no update is staged")
+	}
+	replaced, err := installUpdate(status.StagedPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to install update: %w", err)
+	}
+	a.mu.Lock()
+	a.updateStatus.State = updateStateInstalling
+	a.updateStatus.RestartRequired = replaced
+	a.mu.Unlock()
+	log.Printf("[updater] installing %s (replaced=%t)", status.LatestVersion, replaced)
+	return a.GetUpdateStatus(), nil
+}
+
+// runUpdateChecker checks for updates while update_auto_check is on
+func (a *App) runUpdateChecker(ctx context.Context) {
+	timer := time.NewTimer(updateCheckDelay)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+		if a.updateAutoCheckEnabled() {
+			if _, err := updater.ParseVersion(currentVersion().Version); err == nil {
+				if _, err := a.CheckForUpdates(); err != nil {
+					slog.Warn("check failed", "component", "updater", "error", err)
+				}
+			}
+		}
+		timer.Reset(updateCheckInterval)
+	}
+}
+
+// updateAutoCheckEnabled reads update_auto_check, which defaults to on
+func (a *App) updateAutoCheckEnabled() bool {
+	if a.dbManager == nil {
+		return true
+	}
+	value, err := a.dbManager.GetSetting(updateAutoCheckSettingKey)
+	return err != nil || value != "false"
+}
+
+func (a *App) updateClient() *updater.Client {
+	feedURL := ""
+	if a.dbManager != nil {
+		feedURL, _ = a.dbManager.GetSetting(updateFeedSettingKey)
+	}
+	return updater.NewClient(feedURL)
+}
+
+func (a *App) updateContext(timeout time.Duration) (context.Context, context.CancelFunc) {
+	parent := a.ctx
+	if parent == nil {
+		parent = context.Background()
+	}
+	return context.WithTimeout(parent, timeout)
+}
+
+// updateDir is where updates are staged, ~/.ropcode/updates
+func (a *App) updateDir() (string, error) {
+	if a.config != nil {
+		return filepath.Join(a.config.RopcodeDir, "updates"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve home directory: %w", err)
+	}
+	return filepath.Join(home, ".ropcode", "updates"), nil
+}
+
+// removeStaleUpdates deletes updates staged for other versions
+func removeStaleUpdates(dir, keep string) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		if entry.Name() != keep {
+			os.RemoveAll(filepath.Join(dir, entry.Name()))
+		}
+	}
+}
+
+func (a *App) setUpdateState(state, errMessage string) {
+	a.mu.Lock()
+	if a.updateStatus == nil {
+		a.updateStatus = &UpdateStatus{CurrentVersion: currentVersion().Version}
+	}
+	a.updateStatus.State, a.updateStatus.Error = state, errMessage
+	a.mu.Unlock()
+}
+
+func (a *App) setUpdateError(err error) {
+	a.setUpdateState(updateStateError, err.Error())
+	a.emitUpdateEvent("update:error", map[string]string{"error": err.Error()})
+}
+
+func (a *App) emitUpdateEvent(name string, payload interface{}) {
+	if a.eventHub != nil {
+		a.eventHub.Emit(name, payload)
+	}
+}
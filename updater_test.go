@@ -0,0 +1,155 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"ropcode/internal/eventhub"
+)
+
+type updateEventRecorder struct {
+	events []string
+}
+
+func (r *updateEventRecorder) BroadcastEvent(eventType string, payload interface{}) {
+	r.events = append(r.events, eventType)
+}
+
+func (r *updateEventRecorder) has(event string) bool {
+	for _, e := range r.events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+func newUpdateFeed(t *testing.T, tag string) *httptest.Server {
+	t.Helper()
+	installer := []byte("ropcode " + tag)
+	sum := sha256.Sum256(installer)
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/latest":
+			fmt.Fprintf(w, `{"tag_name": %q, "name": "Ropcode %s", "html_url": "https://example.com/release", "assets": [
+				{"name": "Ropcode.AppImage", "browser_download_url": "%s/Ropcode.AppImage", "size": %d},
+				{"name": "Ropcode.AppImage.sha256", "browser_download_url": "%s/Ropcode.AppImage.sha256"}
+			]}`, tag, tag, server.URL, len(installer), server.URL)
+		case "/Ropcode.AppImage":
+			w.Write(installer)
+		case "/Ropcode.AppImage.sha256":
+			fmt.Fprintf(w, "%s  Ropcode.AppImage\n", hex.EncodeToString(sum[:]))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func setUpdateTestVersion(t *testing.T, version string) {
+	t.Helper()
+	previousVersion, previousPlatform, previousInstall := appVersion, updatePlatform, installUpdate
+	appVersion = version
+	updatePlatform = func() (string, string) { return "linux", "amd64" }
+	t.Cleanup(func() { appVersion, updatePlatform, installUpdate = previousVersion, previousPlatform, previousInstall })
+}
+
+func TestCheckDownloadAndInstallUpdate(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	setUpdateTestVersion(t, "0.2.3")
+	feed := newUpdateFeed(t, "v0.3.0")
+
+	db := openAppConfigTestDB(t)
+	if err := db.SaveSetting(updateFeedSettingKey, feed.URL+"/latest"); err != nil {
+		t.Fatal(err)
+	}
+	hub := eventhub.New(nil)
+	recorder := &updateEventRecorder{}
+	hub.SetBroadcaster(recorder)
+	app := &App{dbManager: db, eventHub: hub}
+
+	status, err := app.CheckForUpdates()
+	if err != nil {
+		t.Fatalf("CheckForUpdates: %v", err)
+	}
+	if status.State != updateStateAvailable || status.LatestVersion != "0.3.0" || status.Asset != "Ropcode.AppImage" {
+		t.Fatalf("status = %+v", status)
+	}
+	if !recorder.has("update:available") {
+		t.Errorf("events = %v", recorder.events)
+	}
+
+	status, err = app.DownloadUpdate()
+	if err != nil {
+		t.Fatalf("DownloadUpdate: %v", err)
+	}
+	home, _ := os.UserHomeDir()
+	if status.State != updateStateStaged || status.StagedPath != filepath.Join(home, ".ropcode", "updates", "0.3.0", "Ropcode.AppImage") {
+		t.Fatalf("status = %+v", status)
+	}
+	if !recorder.has("update:progress") || !recorder.has("update:staged") {
+		t.Errorf("events = %v", recorder.events)
+	}
+
+	// A later check keeps the staged update
+	if status, err = app.CheckForUpdates(); err != nil || status.State != updateStateStaged {
+		t.Fatalf("CheckForUpdates after staging = %+v, %v", status, err)
+	}
+
+	var installed string
+	installUpdate = func(path string) (bool, error) {
+		installed = path
+		return true, nil
+	}
+	status, err = app.InstallUpdate()
+	if err != nil || installed != status.StagedPath || !status.RestartRequired {
+		t.Fatalf("InstallUpdate = %+v, %v (installed %q)", status, err, installed)
+	}
+}
+
+func TestCheckForUpdatesUpToDate(t *testing.T) {
+	setUpdateTestVersion(t, "v0.3.0")
+	feed := newUpdateFeed(t, "v0.3.0")
+	db := openAppConfigTestDB(t)
+	db.SaveSetting(updateFeedSettingKey, feed.URL+"/latest")
+	app := &App{dbManager: db}
+
+	status, err := app.CheckForUpdates()
+	if err != nil || status.State != updateStateUpToDate {
+		t.Fatalf("CheckForUpdates = %+v, %v", status, err)
+	}
+	if _, err := app.DownloadUpdate(); err == nil {
+		t.Fatal("DownloadUpdate succeeded without an update")
+	}
+	if _, err := app.InstallUpdate(); err == nil {
+		t.Fatal("InstallUpdate succeeded without a staged update")
+	}
+}
+
+func TestCheckForUpdatesRejectsDevelopmentBuilds(t *testing.T) {
+	setUpdateTestVersion(t, "dev")
+	if _, err := (&App{}).CheckForUpdates(); err == nil {
+		t.Fatal("expected development builds to be rejected")
+	}
+}
+
+func TestUpdateAutoCheckDefaultsOn(t *testing.T) {
+	app := &App{dbManager: openAppConfigTestDB(t)}
+	if !app.updateAutoCheckEnabled() {
+		t.Fatal("auto check should default to on")
+	}
+	if _, err := app.UpdateSettings(map[string]interface{}{updateAutoCheckSettingKey: false}); err != nil {
+		t.Fatal(err)
+	}
+	if app.updateAutoCheckEnabled() {
+		t.Fatal("auto check should be off")
+	}
+}
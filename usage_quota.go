@@ -0,0 +1,241 @@
+// usage_quota.go
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"log/slog"
+	"time"
+)
+
+const (
+	usageQuotaSettingKey       = "usage_quota"
+	usageQuotaAlertsSettingKey = "usage_quota_alerts"
+)
+
+// usageQuotaCheckInterval is how often the checker rereads usage
+const usageQuotaCheckInterval = 5 * time.Minute
+
+// usageQuotaThresholds are the alert levels in percent of a limit, highest
+// first
+var usageQuotaThresholds = []int{100, 80}
+
+// Usage quota periods
+const (
+	usageQuotaDaily   = "daily"
+	usageQuotaWeekly  = "weekly"
+	usageQuotaMonthly = "monthly"
+)
+
+// UsageQuota holds the spend limits in USD; 0 disables a limit
+type UsageQuota struct {
+	DailyUSD   float64 `json:"daily_usd"`
+	WeeklyUSD  float64 `json:"weekly_usd"`
+	MonthlyUSD float64 `json:"monthly_usd"`
+	// Notify also shows alerts as desktop notifications
+	Notify bool `json:"notify"`
+}
+
+// UsageQuotaPeriod is the spend of the current period against its limit
+type UsageQuotaPeriod struct {
+	Period   string  `json:"period"` // daily, weekly or monthly
+	Start    string  `json:"start"`  // RFC3339
+	LimitUSD float64 `json:"limit_usd"`
+	SpentUSD float64 `json:"spent_usd"`
+	Percent  float64 `json:"percent"`
+}
+
+// UsageQuotaAlert is emitted when a period crosses a threshold
+type UsageQuotaAlert struct {
+	UsageQuotaPeriod
+	Threshold int `json:"threshold"` // 80 or 100
+}
+
+// usageQuotaAlertState is the highest threshold alerted in a period
+type usageQuotaAlertState struct {
+	Start     string `json:"start"`
+	Threshold int    `json:"threshold"`
+}
+
+// GetUsageQuota returns the spend limits
+func (a *App) GetUsageQuota() (*UsageQuota, error) {
+	if a.dbManager == nil {
+		return nil, a.unavailable(subsystemDatabase)
+	}
+	quota := &UsageQuota{}
+	raw, err := a.dbManager.GetSetting(usageQuotaSettingKey)
+	if err != nil {
+		return nil, err
+	}
+	if raw != "" {
+		if err := json.Unmarshal([]byte(raw), quota); err != nil {
+			return nil, fmt.Errorf("invalid %s setting: %w", usageQuotaSettingKey, err)
+		}
+	}
+	return quota, nil
+}
+
+// SetUsageQuota saves the spend limits and checks them right away
+func (a *App) SetUsageQuota(quota *UsageQuota) error {
+	if a.dbManager == nil {
+		return a.unavailable(subsystemDatabase)
+	}
+	if quota == nil {
+		return fmt.Errorf("quota is required")
+	}
+	if quota.DailyUSD < 0 || quota.WeeklyUSD < 0 || quota.MonthlyUSD < 0 {
+		return fmt.Errorf("limits cannot be negative")
+	}
+	data, err := json.Marshal(quota)
+	if err != nil {
+		return fmt.Errorf("failed to encode usage quota: %w", err)
+	}
+	if err := a.dbManager.SaveSetting(usageQuotaSettingKey, string(data)); err != nil {
+		return fmt.Errorf("failed to save usage quota: %w", err)
+	}
+	go func() {
+		if _, err := a.checkUsageQuota(time.Now()); err != nil {
+			slog.Warn("check failed", "component", "usage-quota", "error", err)
+		}
+	}()
+	return nil
+}
+
+// GetUsageQuotaStatus returns the spend of every limited period
+func (a *App) GetUsageQuotaStatus() ([]*UsageQuotaPeriod, error) {
+	quota, err := a.GetUsageQuota()
+	if err != nil {
+		return nil, err
+	}
+	return a.usageQuotaPeriods(quota, time.Now())
+}
+
+// usageQuotaPeriods measures the current periods that have a limit
+func (a *App) usageQuotaPeriods(quota *UsageQuota, now time.Time) ([]*UsageQuotaPeriod, error) {
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	week := day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+
+	periods := []*UsageQuotaPeriod{}
+	var starts []time.Time
+	for _, p := range []struct {
+		name  string
+		start time.Time
+		limit float64
+	}{
+		{usageQuotaDaily, day, quota.DailyUSD},
+		{usageQuotaWeekly, week, quota.WeeklyUSD},
+		{usageQuotaMonthly, month, quota.MonthlyUSD},
+	} {
+		if p.limit <= 0 {
+			continue
+		}
+		periods = append(periods, &UsageQuotaPeriod{Period: p.name, Start: p.start.Format(time.RFC3339), LimitUSD: p.limit})
+		starts = append(starts, p.start)
+	}
+	if len(periods) == 0 {
+		return periods, nil
+	}
+
+	collector, err := a.claudeUsageCollector()
+	if err != nil {
+		return nil, err
+	}
+	costs, err := collector.CollectCosts(now, starts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect usage: %w", err)
+	}
+	for i, period := range periods {
+		period.SpentUSD = costs[i]
+		period.Percent = costs[i] / period.LimitUSD * 100
+	}
+	return periods, nil
+}
+
+// runUsageQuotaChecker checks the limits until ctx is done
+func (a *App) runUsageQuotaChecker(ctx context.Context) {
+	ticker := time.NewTicker(usageQuotaCheckInterval)
+	defer ticker.Stop()
+	for {
+		if _, err := a.checkUsageQuota(time.Now()); err != nil {
+			slog.Warn("check failed", "component", "usage-quota", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// checkUsageQuota raises and returns the alerts for thresholds crossed
+// since the last check. Raised alerts are remembered per period so a restart
+// doesn't repeat them; weeks start on Monday.
+func (a *App) checkUsageQuota(now time.Time) ([]*UsageQuotaAlert, error) {
+	a.usageQuotaMu.Lock()
+	defer a.usageQuotaMu.Unlock()
+
+	quota, err := a.GetUsageQuota()
+	if err != nil {
+		return nil, err
+	}
+	periods, err := a.usageQuotaPeriods(quota, now)
+	if err != nil || len(periods) == 0 {
+		return nil, err
+	}
+
+	state := make(map[string]usageQuotaAlertState)
+	if raw, err := a.dbManager.GetSetting(usageQuotaAlertsSettingKey); err == nil && raw != "" {
+		if err := json.Unmarshal([]byte(raw), &state); err != nil {
+			log.Printf("[usage-quota] ignoring invalid %s setting: %v", usageQuotaAlertsSettingKey, err)
+		}
+	}
+
+	var alerts []*UsageQuotaAlert
+	for _, period := range periods {
+		previous := state[period.Period]
+		if previous.Start != period.Start {
+			previous = usageQuotaAlertState{Start: period.Start}
+		}
+		for _, threshold := range usageQuotaThresholds {
+			if period.Percent < float64(threshold) {
+				continue
+			}
+			if threshold > previous.Threshold {
+				alerts = append(alerts, &UsageQuotaAlert{UsageQuotaPeriod: *period, Threshold: threshold})
+				previous.Threshold = threshold
+			}
+			break
+		}
+		state[period.Period] = previous
+	}
+	if len(alerts) == 0 {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.dbManager.SaveSetting(usageQuotaAlertsSettingKey, string(data)); err != nil {
+		return nil, fmt.Errorf("failed to save usage quota alerts: %w", err)
+	}
+	for _, alert := range alerts {
+		a.raiseUsageQuotaAlert(alert, quota.Notify)
+	}
+	return alerts, nil
+}
+
+func (a *App) raiseUsageQuotaAlert(alert *UsageQuotaAlert, desktop bool) {
+	if a.eventHub != nil {
+		a.eventHub.Emit("usage-quota:alert", alert)
+	}
+	a.playEventSound(attentionUsageQuota)
+	if !desktop || !a.notificationRules().Enabled {
+		return
+	}
+	title := fmt.Sprintf("Claude %s spend at %d%% of limit", alert.Period, alert.Threshold)
+	a.desktopNotify(title, fmt.Sprintf("$%.2f of $%.2f spent", alert.SpentUSD, alert.LimitUSD))
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheckUsageQuota(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	logDir := filepath.Join(home, ".claude", "projects", "app")
+	if err := os.MkdirAll(logDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	logPath := filepath.Join(logDir, "s1.jsonl")
+	writeLog := func(timestamps ...string) {
+		t.Helper()
+		var lines []string
+		for _, ts := range timestamps {
+			lines = append(lines, `{"sessionId":"s1","cwd":"/work/app","timestamp":"`+ts+`","message":{"model":"claude-sonnet-4","usage":{"input_tokens":1000000,"output_tokens":0}}}`)
+		}
+		if err := os.WriteFile(logPath, []byte(strings.Join(lines, "\n")+"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	app := &App{dbManager: openAppConfigTestDB(t)}
+
+	if err := app.SetUsageQuota(&UsageQuota{DailyUSD: -1}); err == nil {
+		t.Error("Expected a negative limit to be rejected")
+	}
+	if err := app.dbManager.SaveSetting(usageQuotaSettingKey, `{"daily_usd":5,"monthly_usd":10}`); err != nil {
+		t.Fatal(err)
+	}
+
+	// Wednesday; $3 of the $5 daily limit, $6 of the $10 monthly one
+	now := time.Date(2026, 3, 11, 12, 0, 0, 0, time.UTC)
+	writeLog("2026-03-02T10:00:00Z", "2026-03-11T10:00:00Z")
+	alerts, err := app.checkUsageQuota(now)
+	if err != nil || len(alerts) != 0 {
+		t.Fatalf("checkUsageQuota() = %+v, %v, want no alerts", alerts, err)
+	}
+
+	// $6 today crosses the daily limit outright, $9 this month crosses 80%
+	writeLog("2026-03-02T10:00:00Z", "2026-03-11T10:00:00Z", "2026-03-11T11:00:00Z")
+	alerts, err = app.checkUsageQuota(now)
+	if err != nil || len(alerts) != 2 {
+		t.Fatalf("checkUsageQuota() = %+v, %v, want 2 alerts", alerts, err)
+	}
+	if alerts[0].Period != "daily" || alerts[0].Threshold != 100 || alerts[0].SpentUSD != 6 || alerts[0].Start != "2026-03-11T00:00:00Z" {
+		t.Errorf("Unexpected daily alert %+v", alerts[0])
+	}
+	if alerts[1].Period != "monthly" || alerts[1].Threshold != 80 {
+		t.Errorf("Unexpected monthly alert %+v", alerts[1])
+	}
+
+	if alerts, err := app.checkUsageQuota(now); err != nil || len(alerts) != 0 {
+		t.Errorf("Expected alerts to be raised once, got %+v, %v", alerts, err)
+	}
+
+	// A new day starts a new daily period
+	if alerts, err := app.checkUsageQuota(now.AddDate(0, 0, 1)); err != nil || len(alerts) != 0 {
+		t.Errorf("Expected no alerts on a quiet day, got %+v, %v", alerts, err)
+	}
+	status, err := app.usageQuotaPeriods(&UsageQuota{WeeklyUSD: 12}, now)
+	if err != nil || len(status) != 1 || status[0].Start != "2026-03-09T00:00:00Z" || status[0].Percent != 50 {
+		t.Errorf("usageQuotaPeriods() = %+v, %v", status, err)
+	}
+}
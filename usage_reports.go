@@ -0,0 +1,134 @@
+// usage_reports.go
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"ropcode/internal/git"
+	"ropcode/internal/usage"
+)
+
+func (a *App) claudeUsageCollector() (*usage.Collector, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return a.usageCollector(filepath.Join(homeDir, ".claude")), nil
+}
+
+// resolveUsageWorkspace attributes a session's working directory to its
+// project, the main checkout when it is a worktree, and its current branch,
+// so worktrees count towards the project they were created from
+func (a *App) resolveUsageWorkspace(path string) (string, string) {
+	workspace := path
+	if info, err := a.DetectWorktree(path); err == nil {
+		workspace = info.RootPath
+	}
+	branch := ""
+	if repo, err := git.Open(path); err == nil {
+		branch, _ = repo.CurrentBranch()
+	}
+	return workspace, branch
+}
+
+// ExportUsageReport returns usage between startDate and endDate (YYYY-MM-DD,
+// inclusive) grouped by day, month, model, project, workspace or branch, as
+// "csv" or "json"
+func (a *App) ExportUsageReport(format, startDate, endDate, groupBy string) (string, error) {
+	start, err := time.Parse("2006-01-02", startDate)
+	if err != nil {
+		return "", fmt.Errorf("invalid start date format: %w", err)
+	}
+	end, err := time.Parse("2006-01-02", endDate)
+	if err != nil {
+		return "", fmt.Errorf("invalid end date format: %w", err)
+	}
+	if end.Before(start) {
+		return "", fmt.Errorf("end date is before start date")
+	}
+	if groupBy == "" {
+		groupBy = usage.GroupByDay
+	}
+
+	collector, err := a.claudeUsageCollector()
+	if err != nil {
+		return "", err
+	}
+	report, err := collector.CollectReport(start, end.Add(24*time.Hour-time.Nanosecond), groupBy)
+	if err != nil {
+		return "", err
+	}
+	return encodeUsageReport(format, report, report.WriteCSV)
+}
+
+// GetMonthlyUsageSummary summarizes a calendar month (YYYY-MM)
+func (a *App) GetMonthlyUsageSummary(month string) (*usage.MonthlySummary, error) {
+	start, err := time.Parse("2006-01", month)
+	if err != nil {
+		return nil, fmt.Errorf("invalid month %q, want YYYY-MM", month)
+	}
+	collector, err := a.claudeUsageCollector()
+	if err != nil {
+		return nil, err
+	}
+	return collector.CollectMonthlySummary(start)
+}
+
+// ExportMonthlyUsageReport returns the monthly summary as "csv" or "json"
+func (a *App) ExportMonthlyUsageReport(month, format string) (string, error) {
+	summary, err := a.GetMonthlyUsageSummary(month)
+	if err != nil {
+		return "", err
+	}
+	return encodeUsageReport(format, summary, summary.WriteCSV)
+}
+
+// GetUsageTimeline returns usage in "hour" or "day" buckets over the last
+// timeRange (e.g. 24h, 7d, or a YYYY-MM-DD start date). It defaults to
+// hourly buckets, over the last day for hours and 30 days for days.
+func (a *App) GetUsageTimeline(bucket, timeRange string) (*usage.Timeline, error) {
+	if bucket == "" {
+		bucket = usage.BucketHour
+	}
+	if timeRange == "" {
+		timeRange = "24h"
+		if bucket == usage.BucketDay {
+			timeRange = "30d"
+		}
+	}
+	now := time.Now()
+	start, err := parseSince(timeRange, now)
+	if err != nil {
+		return nil, fmt.Errorf("invalid range %q, want e.g. 24h, 7d or 2006-01-02", timeRange)
+	}
+
+	collector, err := a.claudeUsageCollector()
+	if err != nil {
+		return nil, err
+	}
+	return collector.CollectTimeline(start, now, bucket)
+}
+
+func encodeUsageReport(format string, report interface{}, writeCSV func(w io.Writer) error) (string, error) {
+	switch format {
+	case "csv":
+		var buf bytes.Buffer
+		if err := writeCSV(&buf); err != nil {
+			return "", fmt.Errorf("failed to write report: %w", err)
+		}
+		return buf.String(), nil
+	case "json", "":
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return "", fmt.Errorf("failed to encode report: %w", err)
+		}
+		return string(data), nil
+	}
+	return "", fmt.Errorf("unsupported report format %q, want csv or json", format)
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExportUsageReport(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	logDir := filepath.Join(home, ".claude", "projects", "app")
+	if err := os.MkdirAll(logDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	line := `{"sessionId":"s1","cwd":"/work/app","timestamp":"2026-03-05T10:00:00Z","message":{"model":"claude-sonnet-4","usage":{"input_tokens":1000,"output_tokens":10}}}`
+	if err := os.WriteFile(filepath.Join(logDir, "s1.jsonl"), []byte(line+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	app := &App{}
+
+	csvReport, err := app.ExportUsageReport("csv", "2026-03-01", "2026-03-05", "project")
+	if err != nil || !strings.Contains(csvReport, "/work/app,1000,10,") {
+		t.Errorf("ExportUsageReport csv = %q, %v", csvReport, err)
+	}
+
+	jsonReport, err := app.ExportUsageReport("json", "2026-03-06", "2026-03-31", "")
+	var decoded struct {
+		GroupBy string        `json:"group_by"`
+		Rows    []interface{} `json:"rows"`
+	}
+	if err != nil || json.Unmarshal([]byte(jsonReport), &decoded) != nil || decoded.GroupBy != "day" || len(decoded.Rows) != 0 {
+		t.Errorf("ExportUsageReport json = %q, %v", jsonReport, err)
+	}
+
+	if _, err := app.ExportUsageReport("xlsx", "2026-03-01", "2026-03-05", "day"); err == nil {
+		t.Error("Expected an unsupported format to be rejected")
+	}
+	if _, err := app.ExportUsageReport("csv", "2026-03-05", "2026-03-01", "day"); err == nil {
+		t.Error("Expected a reversed range to be rejected")
+	}
+
+	if _, err := app.ExportMonthlyUsageReport("March", "csv"); err == nil {
+		t.Error("Expected an invalid month to be rejected")
+	}
+	monthly, err := app.ExportMonthlyUsageReport("2026-03", "csv")
+	if err != nil || !strings.Contains(monthly, "model,claude-sonnet-4,1000,") {
+		t.Errorf("ExportMonthlyUsageReport = %q, %v", monthly, err)
+	}
+}
+
+func TestGetUsageTimeline(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	app := &App{}
+
+	timeline, err := app.GetUsageTimeline("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if timeline.Bucket != "hour" || len(timeline.Points) < 24 || timeline.PeakIndex != -1 {
+		t.Errorf("Unexpected default timeline: bucket %s, %d points, peak %d", timeline.Bucket, len(timeline.Points), timeline.PeakIndex)
+	}
+
+	if _, err := app.GetUsageTimeline("day", "yesterday"); err == nil {
+		t.Error("Expected an invalid range to be rejected")
+	}
+}
+
+func TestResolveUsageWorkspace(t *testing.T) {
+	root := t.TempDir()
+	project := filepath.Join(root, "app")
+	worktree := filepath.Join(root, "app-feature")
+	git := func(dir string, args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, out)
+		}
+	}
+	if err := os.MkdirAll(project, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	git(project, "init", "-b", "main")
+	git(project, "-c", "user.name=Test", "-c", "user.email=test@example.com", "commit", "--allow-empty", "-m", "init")
+	git(project, "worktree", "add", "-b", "feature", worktree)
+
+	app := &App{}
+	if workspace, branch := app.resolveUsageWorkspace(worktree); workspace != project || branch != "feature" {
+		t.Errorf("resolveUsageWorkspace(worktree) = %q, %q", workspace, branch)
+	}
+	if workspace, branch := app.resolveUsageWorkspace(project); workspace != project || branch != "main" {
+		t.Errorf("resolveUsageWorkspace(project) = %q, %q", workspace, branch)
+	}
+	scratch := filepath.Join(root, "scratch")
+	if workspace, branch := app.resolveUsageWorkspace(scratch); workspace != scratch || branch != "" {
+		t.Errorf("resolveUsageWorkspace(scratch) = %q, %q", workspace, branch)
+	}
+}
@@ -0,0 +1,50 @@
+// version.go
+package main
+
+import (
+	"runtime"
+	"runtime/debug"
+	"strings"
+)
+
+// appVersion is set at link time by release builds with
+// -ldflags "-X main.appVersion=<version>"
+var appVersion = "dev"
+
+// AppVersionInfo describes the running build
+type AppVersionInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit,omitempty"`
+	GoVersion string `json:"go_version"`
+	OS        string `json:"os"`
+	Arch      string `json:"arch"`
+}
+
+// GetAppVersion returns the version of the running build
+func (a *App) GetAppVersion() *AppVersionInfo {
+	return currentVersion()
+}
+
+// currentVersion falls back to the module version Go embeds when appVersion
+// isn't set
+func currentVersion() *AppVersionInfo {
+	info := &AppVersionInfo{
+		Version:   strings.TrimPrefix(appVersion, "v"),
+		GoVersion: runtime.Version(),
+		OS:        runtime.GOOS,
+		Arch:      runtime.GOARCH,
+	}
+	build, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+	if info.Version == "dev" && build.Main.Version != "" && build.Main.Version != "(devel)" {
+		info.Version = strings.TrimPrefix(build.Main.Version, "v")
+	}
+	for _, setting := range build.Settings {
+		if setting.Key == "vcs.revision" {
+			info.Commit = setting.Value
+		}
+	}
+	return info
+}
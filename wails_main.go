@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"log/slog"
 	"net/http"
 	"os"
 
@@ -20,6 +21,7 @@ import (
 	wailsRuntime "github.com/wailsapp/wails/v2/pkg/runtime"
 
 	"ropcode/internal/logging"
+	"ropcode/internal/ssh"
 	"ropcode/internal/websocket"
 )
 
@@ -35,6 +37,9 @@ type wailsShell struct {
 }
 
 func main() {
+	// ssh runs ropcode as SSH_ASKPASS to fetch saved passwords
+	ssh.RunAskpassIfRequested()
+
 	attachHiddenConsole()
 
 	shell := &wailsShell{}
@@ -73,21 +78,21 @@ func (s *wailsShell) startup(ctx context.Context) {
 
 	logPath, cleanupLogging, err := logging.ConfigureServerLogging()
 	if err != nil {
-		log.Printf("Failed to configure logging: %v", err)
+		slog.Error("Failed to configure logging", "component", "Wails", "error", err)
 	} else {
 		log.Printf("[wails] logging to %s", logPath)
 		_ = cleanupLogging
 	}
 
 	if err := os.Setenv("ROPCODE_AUTH_KEY", ""); err != nil {
-		log.Printf("Failed to clear auth key: %v", err)
+		slog.Warn("Failed to clear auth key", "component", "Wails", "error", err)
 	}
 	_ = os.Unsetenv("ROPCODE_AUTH_KEY")
 	_ = os.Setenv("ROPCODE_MODE", "websocket")
 
 	app, shutdownApp, err := BootstrapRuntime(s.ctx)
 	if err != nil {
-		log.Printf("Failed to bootstrap runtime: %v", err)
+		slog.Error("Failed to bootstrap runtime", "component", "Wails", "error", err)
 		wailsRuntime.Quit(ctx)
 		return
 	}
@@ -100,7 +105,7 @@ func (s *wailsShell) startup(ctx context.Context) {
 
 	port, err := s.wsServer.Start(s.ctx)
 	if err != nil {
-		log.Printf("Failed to start WebSocket server: %v", err)
+		slog.Error("Failed to start WebSocket server", "component", "Wails", "error", err)
 		wailsRuntime.Quit(ctx)
 		return
 	}
@@ -120,7 +125,7 @@ func (s *wailsShell) shutdown(ctx context.Context) {
 	}
 	if s.wsServer != nil {
 		if err := s.wsServer.Stop(ctx); err != nil {
-			log.Printf("Failed to stop WebSocket server: %v", err)
+			slog.Warn("Failed to stop WebSocket server", "component", "Wails", "error", err)
 		}
 		s.wsServer = nil
 	}
@@ -214,6 +219,7 @@ func (s *wailsShell) runtimeScript() string {
     setMaxSize: (width, height) => call('SetMaxSize', width, height),
     setAlwaysOnTop: (flag) => call('SetAlwaysOnTop', flag),
     quit: () => call('Quit'),
+    setBadgeCount: () => Promise.resolve(), // Wails v2 has no badge API
     openDirectory: () => call('OpenDirectory'),
     openFile: (options) => call('OpenFile', options || {}),
     getWebviewPreload: () => Promise.resolve(''),
@@ -0,0 +1,55 @@
+// workspace_protection.go
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"ropcode/internal/settings"
+)
+
+const workspaceProtectionSettingKey = "workspace_protection_enabled"
+
+// ErrWorkspaceProtected is wrapped by the errors of bindings refused while
+// workspace protection is on. Its text starts those errors so the frontend
+// can ask before retrying with override set.
+var ErrWorkspaceProtected = errors.New("protection_enabled")
+
+// GetWorkspaceProtectionEnabled reports whether destructive workspace
+// operations need an explicit override
+func (a *App) GetWorkspaceProtectionEnabled() (bool, error) {
+	if a.dbManager == nil {
+		return false, a.unavailable(subsystemDatabase)
+	}
+	raw, err := a.dbManager.GetSetting(workspaceProtectionSettingKey)
+	if err != nil {
+		return false, err
+	}
+	enabled, _ := settings.Lookup(workspaceProtectionSettingKey).Decode(raw).(bool)
+	return enabled, nil
+}
+
+// SetWorkspaceProtectionEnabled turns workspace protection on or off
+func (a *App) SetWorkspaceProtectionEnabled(enabled bool) error {
+	if a.dbManager == nil {
+		return a.unavailable(subsystemDatabase)
+	}
+	return a.dbManager.SaveSetting(workspaceProtectionSettingKey, fmt.Sprint(enabled))
+}
+
+// checkWorkspaceProtection returns an ErrWorkspaceProtected error for
+// operation unless protection is off or override is set. Without a
+// database nothing is protected.
+func (a *App) checkWorkspaceProtection(operation string, override bool) error {
+	if override || a.dbManager == nil {
+		return nil
+	}
+	enabled, err := a.GetWorkspaceProtectionEnabled()
+	if err != nil {
+		return err
+	}
+	if enabled {
+		return fmt.Errorf("%w: workspace protection is on, confirm to %s", ErrWorkspaceProtected, operation)
+	}
+	return nil
+}
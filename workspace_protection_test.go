@@ -0,0 +1,48 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"ropcode/internal/database"
+)
+
+func TestRemoveWorkspaceNeedsOverrideWhenProtected(t *testing.T) {
+	db := openAppConfigTestDB(t)
+	if err := db.SaveProjectIndex(&database.ProjectIndex{
+		Name:       "demo",
+		Workspaces: []database.WorkspaceIndex{{Name: "feature"}, {Name: "bugfix"}},
+	}); err != nil {
+		t.Fatalf("SaveProjectIndex() error = %v", err)
+	}
+	app := &App{dbManager: db}
+
+	if enabled, err := app.GetWorkspaceProtectionEnabled(); err != nil || enabled {
+		t.Fatalf("GetWorkspaceProtectionEnabled() = %v, %v, want off by default", enabled, err)
+	}
+	if err := app.RemoveWorkspace("bugfix", false); err != nil {
+		t.Fatalf("RemoveWorkspace() without protection error = %v", err)
+	}
+
+	if err := app.SetWorkspaceProtectionEnabled(true); err != nil {
+		t.Fatalf("SetWorkspaceProtectionEnabled() error = %v", err)
+	}
+	if err := app.RemoveWorkspace("feature", false); !errors.Is(err, ErrWorkspaceProtected) {
+		t.Fatalf("RemoveWorkspace() error = %v, want ErrWorkspaceProtected", err)
+	}
+	if _, err := app.CleanupWorkspace(t.TempDir(), false); !errors.Is(err, ErrWorkspaceProtected) {
+		t.Fatalf("CleanupWorkspace() error = %v, want ErrWorkspaceProtected", err)
+	}
+	project, _ := db.GetProjectIndex("demo")
+	if len(project.Workspaces) != 1 {
+		t.Fatalf("workspaces = %+v, want feature kept", project.Workspaces)
+	}
+
+	if err := app.RemoveWorkspace("feature", true); err != nil {
+		t.Fatalf("RemoveWorkspace() with override error = %v", err)
+	}
+	project, _ = db.GetProjectIndex("demo")
+	if len(project.Workspaces) != 0 {
+		t.Fatalf("workspaces = %+v, want none", project.Workspaces)
+	}
+}
@@ -0,0 +1,77 @@
+// wsl_mode.go
+package main
+
+import (
+	"context"
+	"os/exec"
+
+	"ropcode/internal/git"
+	"ropcode/internal/sessionproc"
+	"ropcode/internal/wsl"
+)
+
+const wslDistroSettingKey = "wsl_distro"
+
+// WSLInfo describes the WSL distros of this machine and the one in use
+type WSLInfo struct {
+	Supported bool     `json:"supported"`
+	Distros   []string `json:"distros"`
+	// Distro is where sessions, terminals and git run; empty for Windows
+	Distro string `json:"distro"`
+	// Error says why the distros could not be listed
+	Error string `json:"error,omitempty"`
+}
+
+// wslDistro returns the distro sessions, terminals and git run in, or ""
+// when they run on the host. Remote projects keep running on their SSH
+// host.
+func (a *App) wslDistro() string {
+	if !wsl.Supported || a.dbManager == nil {
+		return ""
+	}
+	distro, err := a.dbManager.GetSetting(wslDistroSettingKey)
+	if err != nil {
+		return ""
+	}
+	return distro
+}
+
+// applyWSLDistro points git at the chosen distro
+func (a *App) applyWSLDistro() {
+	git.UseWSL(a.wslDistro())
+}
+
+// GetWSLInfo lists the installed distros and the one in use
+func (a *App) GetWSLInfo() *WSLInfo {
+	info := &WSLInfo{Supported: wsl.Supported, Distros: []string{}, Distro: a.wslDistro()}
+	if !wsl.Supported {
+		return info
+	}
+	distros, err := wsl.Distros()
+	if err != nil {
+		info.Error = err.Error()
+		return info
+	}
+	info.Distros = distros
+	return info
+}
+
+// wslRemote returns how to run a provider CLI of the project at
+// projectPath inside the distro, or nil outside WSL mode. The CLI is looked
+// up on the distro user's login PATH.
+func (a *App) wslRemote(projectPath string) *sessionproc.Remote {
+	distro := a.wslDistro()
+	if distro == "" {
+		return nil
+	}
+	dir := ""
+	if projectPath != "" {
+		dir = wsl.ToLinux(projectPath)
+	}
+	return &sessionproc.Remote{
+		Dir: dir,
+		Command: func(ctx context.Context, dir string, argv, env []string) (*exec.Cmd, error) {
+			return wsl.Command(ctx, distro, dir, argv, env)
+		},
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"testing"
+
+	"ropcode/internal/codex"
+	"ropcode/internal/git"
+	"ropcode/internal/wsl"
+)
+
+func TestWSLModeMovesLocalSessionsIntoTheDistro(t *testing.T) {
+	app := newRemoteProjectTestApp(t)
+	if err := app.SetRemoteProject(`C:\remote`, RemoteProject{Connection: "build", RemotePath: "/srv/app"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := app.dbManager.SaveSetting(wslDistroSettingKey, "Ubuntu"); err != nil {
+		t.Fatal(err)
+	}
+	app.applyWSLDistro()
+	t.Cleanup(func() { git.UseWSL("") })
+
+	local := codex.SessionConfig{ProjectPath: `C:\src\app`}
+	if err := app.applyRemoteProjectToCodex(&local); err != nil {
+		t.Fatal(err)
+	}
+	remote := codex.SessionConfig{ProjectPath: `C:\remote`}
+	if err := app.applyRemoteProjectToCodex(&remote); err != nil {
+		t.Fatal(err)
+	}
+	if remote.Remote == nil || remote.Remote.Dir != "/srv/app" {
+		t.Errorf("a remote project should keep running over ssh, got %#v", remote.Remote)
+	}
+
+	info := app.GetWSLInfo()
+	if !wsl.Supported {
+		// The setting only means something on Windows
+		if local.Remote != nil || info.Supported || info.Distro != "" || git.WSLDistro() != "" {
+			t.Errorf("WSL mode applied off Windows: remote = %#v, info = %+v", local.Remote, info)
+		}
+		return
+	}
+	if local.Remote == nil || local.Remote.Dir != "/mnt/c/src/app" || git.WSLDistro() != "Ubuntu" || info.Distro != "Ubuntu" {
+		t.Errorf("WSL mode not applied: remote = %#v, info = %+v", local.Remote, info)
+	}
+}